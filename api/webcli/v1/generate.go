@@ -0,0 +1,4 @@
+// Package webcliv1 is the generated Go client and server code of the web-cli gRPC API
+package webcliv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative webcli.proto
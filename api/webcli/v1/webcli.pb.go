@@ -0,0 +1,1766 @@
+// gRPC API of web-cli, for automation written in Go and other languages with gRPC support.
+// Every RPC is served by the same handler as the matching REST endpoint, so
+// authentication, group permissions, command policies, approvals, audit logging
+// and history work exactly as they do over HTTP.
+//
+// Credentials are sent as metadata: "authorization: Bearer <api token>" or
+// "authorization: Basic <base64 user:password>".
+//
+// Regenerate the Go code with: go generate ./api/...
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: webcli.proto
+
+package webcliv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ListFilter filters, sorts and pages a list, like the list query parameters of the REST API
+type ListFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`                     // Only items in this group
+	Query         string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`                     // Case-insensitive text in the name or description
+	Tag           string                 `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`                         // Only items with this tag (scripts)
+	Sort          string                 `protobuf:"bytes,4,opt,name=sort,proto3" json:"sort,omitempty"`                       // Field to sort by
+	Desc          bool                   `protobuf:"varint,5,opt,name=desc,proto3" json:"desc,omitempty"`                      // Sort in descending order
+	Page          int32                  `protobuf:"varint,6,opt,name=page,proto3" json:"page,omitempty"`                      // Page number, from 1; without page or per_page every item is returned
+	PerPage       int32                  `protobuf:"varint,7,opt,name=per_page,json=perPage,proto3" json:"per_page,omitempty"` // Items per page, 1 to 500 (default 50)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFilter) Reset() {
+	*x = ListFilter{}
+	mi := &file_webcli_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilter) ProtoMessage() {}
+
+func (x *ListFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFilter.ProtoReflect.Descriptor instead.
+func (*ListFilter) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListFilter) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *ListFilter) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *ListFilter) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListFilter) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListFilter) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+func (x *ListFilter) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListFilter) GetPerPage() int32 {
+	if x != nil {
+		return x.PerPage
+	}
+	return 0
+}
+
+type Server struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"` // 0 for servers stored in Vault
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	IpAddress        string                 `protobuf:"bytes,3,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	Port             int32                  `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+	Username         string                 `protobuf:"bytes,5,opt,name=username,proto3" json:"username,omitempty"`
+	Group            string                 `protobuf:"bytes,6,opt,name=group,proto3" json:"group,omitempty"`
+	RequiresApproval bool                   `protobuf:"varint,7,opt,name=requires_approval,json=requiresApproval,proto3" json:"requires_approval,omitempty"`
+	Source           string                 `protobuf:"bytes,8,opt,name=source,proto3" json:"source,omitempty"` // "sqlite" or "vault"
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Server) Reset() {
+	*x = Server{}
+	mi := &file_webcli_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server) ProtoMessage() {}
+
+func (x *Server) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server.ProtoReflect.Descriptor instead.
+func (*Server) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Server) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Server) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Server) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *Server) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *Server) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Server) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Server) GetRequiresApproval() bool {
+	if x != nil {
+		return x.RequiresApproval
+	}
+	return false
+}
+
+func (x *Server) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Server) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Server) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListServersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *ListFilter            `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServersRequest) Reset() {
+	*x = ListServersRequest{}
+	mi := &file_webcli_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServersRequest) ProtoMessage() {}
+
+func (x *ListServersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServersRequest.ProtoReflect.Descriptor instead.
+func (*ListServersRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListServersRequest) GetFilter() *ListFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type ListServersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Servers       []*Server              `protobuf:"bytes,1,rep,name=servers,proto3" json:"servers,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"` // Number of matching servers, across all pages
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServersResponse) Reset() {
+	*x = ListServersResponse{}
+	mi := &file_webcli_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServersResponse) ProtoMessage() {}
+
+func (x *ListServersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServersResponse.ProtoReflect.Descriptor instead.
+func (*ListServersResponse) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListServersResponse) GetServers() []*Server {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+func (x *ListServersResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetServerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServerRequest) Reset() {
+	*x = GetServerRequest{}
+	mi := &file_webcli_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerRequest) ProtoMessage() {}
+
+func (x *GetServerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerRequest.ProtoReflect.Descriptor instead.
+func (*GetServerRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetServerRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type Script struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"` // 0 for scripts stored in Vault
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description      string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Content          string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	Filename         string                 `protobuf:"bytes,5,opt,name=filename,proto3" json:"filename,omitempty"`
+	Group            string                 `protobuf:"bytes,6,opt,name=group,proto3" json:"group,omitempty"`
+	Interpreter      string                 `protobuf:"bytes,7,opt,name=interpreter,proto3" json:"interpreter,omitempty"`
+	Tags             []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	RequiresApproval bool                   `protobuf:"varint,9,opt,name=requires_approval,json=requiresApproval,proto3" json:"requires_approval,omitempty"`
+	ReadOnly         bool                   `protobuf:"varint,10,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	Source           string                 `protobuf:"bytes,11,opt,name=source,proto3" json:"source,omitempty"` // "sqlite" or "vault"
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Script) Reset() {
+	*x = Script{}
+	mi := &file_webcli_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Script) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Script) ProtoMessage() {}
+
+func (x *Script) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Script.ProtoReflect.Descriptor instead.
+func (*Script) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Script) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Script) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Script) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Script) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Script) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Script) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Script) GetInterpreter() string {
+	if x != nil {
+		return x.Interpreter
+	}
+	return ""
+}
+
+func (x *Script) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Script) GetRequiresApproval() bool {
+	if x != nil {
+		return x.RequiresApproval
+	}
+	return false
+}
+
+func (x *Script) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *Script) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Script) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Script) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListScriptsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *ListFilter            `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScriptsRequest) Reset() {
+	*x = ListScriptsRequest{}
+	mi := &file_webcli_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScriptsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScriptsRequest) ProtoMessage() {}
+
+func (x *ListScriptsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScriptsRequest.ProtoReflect.Descriptor instead.
+func (*ListScriptsRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListScriptsRequest) GetFilter() *ListFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type ListScriptsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scripts       []*Script              `protobuf:"bytes,1,rep,name=scripts,proto3" json:"scripts,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"` // Number of matching scripts, across all pages
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScriptsResponse) Reset() {
+	*x = ListScriptsResponse{}
+	mi := &file_webcli_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScriptsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScriptsResponse) ProtoMessage() {}
+
+func (x *ListScriptsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScriptsResponse.ProtoReflect.Descriptor instead.
+func (*ListScriptsResponse) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListScriptsResponse) GetScripts() []*Script {
+	if x != nil {
+		return x.Scripts
+	}
+	return nil
+}
+
+func (x *ListScriptsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetScriptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetScriptRequest) Reset() {
+	*x = GetScriptRequest{}
+	mi := &file_webcli_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetScriptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetScriptRequest) ProtoMessage() {}
+
+func (x *GetScriptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetScriptRequest.ProtoReflect.Descriptor instead.
+func (*GetScriptRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetScriptRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// Target selects where a command or script runs; without a server it runs locally
+type Target struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerId      int64                  `protobuf:"varint,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`         // Server stored in SQLite
+	ServerRef     string                 `protobuf:"bytes,2,opt,name=server_ref,json=serverRef,proto3" json:"server_ref,omitempty"`       // Server stored in Vault, as "vault:group/name"
+	SshKeyId      int64                  `protobuf:"varint,3,opt,name=ssh_key_id,json=sshKeyId,proto3" json:"ssh_key_id,omitempty"`       // SSH key stored in SQLite
+	SshKeyRef     string                 `protobuf:"bytes,4,opt,name=ssh_key_ref,json=sshKeyRef,proto3" json:"ssh_key_ref,omitempty"`     // SSH key stored in Vault, as "vault:group/name"
+	SshPassword   string                 `protobuf:"bytes,5,opt,name=ssh_password,json=sshPassword,proto3" json:"ssh_password,omitempty"` // SSH password, if key authentication fails
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Target) Reset() {
+	*x = Target{}
+	mi := &file_webcli_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Target) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Target) ProtoMessage() {}
+
+func (x *Target) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Target.ProtoReflect.Descriptor instead.
+func (*Target) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Target) GetServerId() int64 {
+	if x != nil {
+		return x.ServerId
+	}
+	return 0
+}
+
+func (x *Target) GetServerRef() string {
+	if x != nil {
+		return x.ServerRef
+	}
+	return ""
+}
+
+func (x *Target) GetSshKeyId() int64 {
+	if x != nil {
+		return x.SshKeyId
+	}
+	return 0
+}
+
+func (x *Target) GetSshKeyRef() string {
+	if x != nil {
+		return x.SshKeyRef
+	}
+	return ""
+}
+
+func (x *Target) GetSshPassword() string {
+	if x != nil {
+		return x.SshPassword
+	}
+	return ""
+}
+
+type ExecuteCommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Target        *Target                `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	User          string                 `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"` // User to run as (default: root)
+	SudoPassword  string                 `protobuf:"bytes,4,opt,name=sudo_password,json=sudoPassword,proto3" json:"sudo_password,omitempty"`
+	Stdin         []byte                 `protobuf:"bytes,5,opt,name=stdin,proto3" json:"stdin,omitempty"`                              // Piped into the command's stdin
+	Workdir       string                 `protobuf:"bytes,6,opt,name=workdir,proto3" json:"workdir,omitempty"`                          // Absolute directory the command runs in
+	ApprovalId    int64                  `protobuf:"varint,7,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"` // Approved request to run, for servers that require approval
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteCommandRequest) Reset() {
+	*x = ExecuteCommandRequest{}
+	mi := &file_webcli_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteCommandRequest) ProtoMessage() {}
+
+func (x *ExecuteCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteCommandRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteCommandRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ExecuteCommandRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *ExecuteCommandRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetSudoPassword() string {
+	if x != nil {
+		return x.SudoPassword
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *ExecuteCommandRequest) GetWorkdir() string {
+	if x != nil {
+		return x.Workdir
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetApprovalId() int64 {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return 0
+}
+
+type CommandResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Command         string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Output          string                 `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	ExitCode        int32                  `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	User            string                 `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`
+	ExecutionTimeMs int64                  `protobuf:"varint,5,opt,name=execution_time_ms,json=executionTimeMs,proto3" json:"execution_time_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CommandResult) Reset() {
+	*x = CommandResult{}
+	mi := &file_webcli_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResult) ProtoMessage() {}
+
+func (x *CommandResult) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResult.ProtoReflect.Descriptor instead.
+func (*CommandResult) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CommandResult) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *CommandResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *CommandResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *CommandResult) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *CommandResult) GetExecutionTimeMs() int64 {
+	if x != nil {
+		return x.ExecutionTimeMs
+	}
+	return 0
+}
+
+type ExecuteScriptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScriptId      int64                  `protobuf:"varint,1,opt,name=script_id,json=scriptId,proto3" json:"script_id,omitempty"`         // Script stored in SQLite
+	ScriptName    string                 `protobuf:"bytes,2,opt,name=script_name,json=scriptName,proto3" json:"script_name,omitempty"`    // Script stored in Vault (instead of script_id)
+	ScriptGroup   string                 `protobuf:"bytes,3,opt,name=script_group,json=scriptGroup,proto3" json:"script_group,omitempty"` // Group of the Vault script
+	Target        *Target                `protobuf:"bytes,4,opt,name=target,proto3" json:"target,omitempty"`
+	User          string                 `protobuf:"bytes,5,opt,name=user,proto3" json:"user,omitempty"` // User to run as (default: root)
+	SudoPassword  string                 `protobuf:"bytes,6,opt,name=sudo_password,json=sudoPassword,proto3" json:"sudo_password,omitempty"`
+	EnvVarIds     []int64                `protobuf:"varint,7,rep,packed,name=env_var_ids,json=envVarIds,proto3" json:"env_var_ids,omitempty"`  // Environment variables stored in SQLite
+	EnvVarNames   []string               `protobuf:"bytes,8,rep,name=env_var_names,json=envVarNames,proto3" json:"env_var_names,omitempty"`    // Environment variables stored in Vault
+	EnvVarGroups  []string               `protobuf:"bytes,9,rep,name=env_var_groups,json=envVarGroups,proto3" json:"env_var_groups,omitempty"` // Groups of env_var_names; without names, every variable in these groups
+	Stdin         []byte                 `protobuf:"bytes,10,opt,name=stdin,proto3" json:"stdin,omitempty"`                                    // Piped into the script's stdin
+	Workdir       string                 `protobuf:"bytes,11,opt,name=workdir,proto3" json:"workdir,omitempty"`                                // Absolute directory the script runs in
+	ApprovalId    int64                  `protobuf:"varint,12,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`       // Approved request to run, for scripts or servers that require approval
+	DryRun        bool                   `protobuf:"varint,13,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                   // Only check the syntax (not supported by StreamScript)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteScriptRequest) Reset() {
+	*x = ExecuteScriptRequest{}
+	mi := &file_webcli_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteScriptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteScriptRequest) ProtoMessage() {}
+
+func (x *ExecuteScriptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteScriptRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteScriptRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ExecuteScriptRequest) GetScriptId() int64 {
+	if x != nil {
+		return x.ScriptId
+	}
+	return 0
+}
+
+func (x *ExecuteScriptRequest) GetScriptName() string {
+	if x != nil {
+		return x.ScriptName
+	}
+	return ""
+}
+
+func (x *ExecuteScriptRequest) GetScriptGroup() string {
+	if x != nil {
+		return x.ScriptGroup
+	}
+	return ""
+}
+
+func (x *ExecuteScriptRequest) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *ExecuteScriptRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ExecuteScriptRequest) GetSudoPassword() string {
+	if x != nil {
+		return x.SudoPassword
+	}
+	return ""
+}
+
+func (x *ExecuteScriptRequest) GetEnvVarIds() []int64 {
+	if x != nil {
+		return x.EnvVarIds
+	}
+	return nil
+}
+
+func (x *ExecuteScriptRequest) GetEnvVarNames() []string {
+	if x != nil {
+		return x.EnvVarNames
+	}
+	return nil
+}
+
+func (x *ExecuteScriptRequest) GetEnvVarGroups() []string {
+	if x != nil {
+		return x.EnvVarGroups
+	}
+	return nil
+}
+
+func (x *ExecuteScriptRequest) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *ExecuteScriptRequest) GetWorkdir() string {
+	if x != nil {
+		return x.Workdir
+	}
+	return ""
+}
+
+func (x *ExecuteScriptRequest) GetApprovalId() int64 {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return 0
+}
+
+func (x *ExecuteScriptRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ScriptResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ScriptId        int64                  `protobuf:"varint,1,opt,name=script_id,json=scriptId,proto3" json:"script_id,omitempty"`
+	ScriptName      string                 `protobuf:"bytes,2,opt,name=script_name,json=scriptName,proto3" json:"script_name,omitempty"`
+	Output          string                 `protobuf:"bytes,3,opt,name=output,proto3" json:"output,omitempty"`
+	ExitCode        int32                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	User            string                 `protobuf:"bytes,5,opt,name=user,proto3" json:"user,omitempty"`
+	Server          string                 `protobuf:"bytes,6,opt,name=server,proto3" json:"server,omitempty"` // "local" or the server's name
+	ExecutionTimeMs int64                  `protobuf:"varint,7,opt,name=execution_time_ms,json=executionTimeMs,proto3" json:"execution_time_ms,omitempty"`
+	EnvVars         []string               `protobuf:"bytes,8,rep,name=env_vars,json=envVars,proto3" json:"env_vars,omitempty"` // Names of the environment variables injected
+	DryRun          bool                   `protobuf:"varint,9,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ScriptResult) Reset() {
+	*x = ScriptResult{}
+	mi := &file_webcli_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScriptResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScriptResult) ProtoMessage() {}
+
+func (x *ScriptResult) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScriptResult.ProtoReflect.Descriptor instead.
+func (*ScriptResult) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ScriptResult) GetScriptId() int64 {
+	if x != nil {
+		return x.ScriptId
+	}
+	return 0
+}
+
+func (x *ScriptResult) GetScriptName() string {
+	if x != nil {
+		return x.ScriptName
+	}
+	return ""
+}
+
+func (x *ScriptResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *ScriptResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *ScriptResult) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ScriptResult) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *ScriptResult) GetExecutionTimeMs() int64 {
+	if x != nil {
+		return x.ExecutionTimeMs
+	}
+	return 0
+}
+
+func (x *ScriptResult) GetEnvVars() []string {
+	if x != nil {
+		return x.EnvVars
+	}
+	return nil
+}
+
+func (x *ScriptResult) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// ExecutionEvent is one message of a streamed execution
+type ExecutionEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ExecutionEvent_Status
+	//	*ExecutionEvent_Output
+	//	*ExecutionEvent_Error
+	//	*ExecutionEvent_Result
+	Event         isExecutionEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutionEvent) Reset() {
+	*x = ExecutionEvent{}
+	mi := &file_webcli_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionEvent) ProtoMessage() {}
+
+func (x *ExecutionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionEvent.ProtoReflect.Descriptor instead.
+func (*ExecutionEvent) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ExecutionEvent) GetEvent() isExecutionEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ExecutionEvent) GetStatus() string {
+	if x != nil {
+		if x, ok := x.Event.(*ExecutionEvent_Status); ok {
+			return x.Status
+		}
+	}
+	return ""
+}
+
+func (x *ExecutionEvent) GetOutput() string {
+	if x != nil {
+		if x, ok := x.Event.(*ExecutionEvent_Output); ok {
+			return x.Output
+		}
+	}
+	return ""
+}
+
+func (x *ExecutionEvent) GetError() string {
+	if x != nil {
+		if x, ok := x.Event.(*ExecutionEvent_Error); ok {
+			return x.Error
+		}
+	}
+	return ""
+}
+
+func (x *ExecutionEvent) GetResult() *ScriptResult {
+	if x != nil {
+		if x, ok := x.Event.(*ExecutionEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isExecutionEvent_Event interface {
+	isExecutionEvent_Event()
+}
+
+type ExecutionEvent_Status struct {
+	Status string `protobuf:"bytes,1,opt,name=status,proto3,oneof"` // Progress, such as connecting to the server
+}
+
+type ExecutionEvent_Output struct {
+	Output string `protobuf:"bytes,2,opt,name=output,proto3,oneof"` // Output produced by the script, with secret values masked
+}
+
+type ExecutionEvent_Error struct {
+	Error string `protobuf:"bytes,3,opt,name=error,proto3,oneof"` // The execution failed; no result follows
+}
+
+type ExecutionEvent_Result struct {
+	Result *ScriptResult `protobuf:"bytes,4,opt,name=result,proto3,oneof"` // The execution finished; always the last event
+}
+
+func (*ExecutionEvent_Status) isExecutionEvent_Event() {}
+
+func (*ExecutionEvent_Output) isExecutionEvent_Event() {}
+
+func (*ExecutionEvent_Error) isExecutionEvent_Event() {}
+
+func (*ExecutionEvent_Result) isExecutionEvent_Event() {}
+
+type HistoryEntry struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Command         string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	Output          string                 `protobuf:"bytes,3,opt,name=output,proto3" json:"output,omitempty"`
+	ExitCode        *int32                 `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
+	Server          string                 `protobuf:"bytes,5,opt,name=server,proto3" json:"server,omitempty"` // "local" or the server's name
+	User            string                 `protobuf:"bytes,6,opt,name=user,proto3" json:"user,omitempty"`
+	ExecutionTimeMs int64                  `protobuf:"varint,7,opt,name=execution_time_ms,json=executionTimeMs,proto3" json:"execution_time_ms,omitempty"`
+	ExecutedAt      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=executed_at,json=executedAt,proto3" json:"executed_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HistoryEntry) Reset() {
+	*x = HistoryEntry{}
+	mi := &file_webcli_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryEntry) ProtoMessage() {}
+
+func (x *HistoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryEntry.ProtoReflect.Descriptor instead.
+func (*HistoryEntry) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HistoryEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *HistoryEntry) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetExitCode() int32 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+func (x *HistoryEntry) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetExecutionTimeMs() int64 {
+	if x != nil {
+		return x.ExecutionTimeMs
+	}
+	return 0
+}
+
+func (x *HistoryEntry) GetExecutedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExecutedAt
+	}
+	return nil
+}
+
+type ListHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"` // Only executions on this server ("local" for local ones)
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`  // Maximum number of entries (default 100)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHistoryRequest) Reset() {
+	*x = ListHistoryRequest{}
+	mi := &file_webcli_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHistoryRequest) ProtoMessage() {}
+
+func (x *ListHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ListHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListHistoryRequest) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *ListHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*HistoryEntry        `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHistoryResponse) Reset() {
+	*x = ListHistoryResponse{}
+	mi := &file_webcli_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHistoryResponse) ProtoMessage() {}
+
+func (x *ListHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ListHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListHistoryResponse) GetEntries() []*HistoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type GetHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHistoryRequest) Reset() {
+	*x = GetHistoryRequest{}
+	mi := &file_webcli_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHistoryRequest) ProtoMessage() {}
+
+func (x *GetHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_webcli_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_webcli_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetHistoryRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+var File_webcli_proto protoreflect.FileDescriptor
+
+const file_webcli_proto_rawDesc = "" +
+	"\n" +
+	"\fwebcli.proto\x12\twebcli.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa1\x01\n" +
+	"\n" +
+	"ListFilter\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x12\n" +
+	"\x04sort\x18\x04 \x01(\tR\x04sort\x12\x12\n" +
+	"\x04desc\x18\x05 \x01(\bR\x04desc\x12\x12\n" +
+	"\x04page\x18\x06 \x01(\x05R\x04page\x12\x19\n" +
+	"\bper_page\x18\a \x01(\x05R\aperPage\"\xcc\x02\n" +
+	"\x06Server\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\x03 \x01(\tR\tipAddress\x12\x12\n" +
+	"\x04port\x18\x04 \x01(\x05R\x04port\x12\x1a\n" +
+	"\busername\x18\x05 \x01(\tR\busername\x12\x14\n" +
+	"\x05group\x18\x06 \x01(\tR\x05group\x12+\n" +
+	"\x11requires_approval\x18\a \x01(\bR\x10requiresApproval\x12\x16\n" +
+	"\x06source\x18\b \x01(\tR\x06source\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"C\n" +
+	"\x12ListServersRequest\x12-\n" +
+	"\x06filter\x18\x01 \x01(\v2\x15.webcli.v1.ListFilterR\x06filter\"X\n" +
+	"\x13ListServersResponse\x12+\n" +
+	"\aservers\x18\x01 \x03(\v2\x11.webcli.v1.ServerR\aservers\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"\"\n" +
+	"\x10GetServerRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xa8\x03\n" +
+	"\x06Script\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12\x1a\n" +
+	"\bfilename\x18\x05 \x01(\tR\bfilename\x12\x14\n" +
+	"\x05group\x18\x06 \x01(\tR\x05group\x12 \n" +
+	"\vinterpreter\x18\a \x01(\tR\vinterpreter\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12+\n" +
+	"\x11requires_approval\x18\t \x01(\bR\x10requiresApproval\x12\x1b\n" +
+	"\tread_only\x18\n" +
+	" \x01(\bR\breadOnly\x12\x16\n" +
+	"\x06source\x18\v \x01(\tR\x06source\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"C\n" +
+	"\x12ListScriptsRequest\x12-\n" +
+	"\x06filter\x18\x01 \x01(\v2\x15.webcli.v1.ListFilterR\x06filter\"X\n" +
+	"\x13ListScriptsResponse\x12+\n" +
+	"\ascripts\x18\x01 \x03(\v2\x11.webcli.v1.ScriptR\ascripts\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"\"\n" +
+	"\x10GetScriptRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xa5\x01\n" +
+	"\x06Target\x12\x1b\n" +
+	"\tserver_id\x18\x01 \x01(\x03R\bserverId\x12\x1d\n" +
+	"\n" +
+	"server_ref\x18\x02 \x01(\tR\tserverRef\x12\x1c\n" +
+	"\n" +
+	"ssh_key_id\x18\x03 \x01(\x03R\bsshKeyId\x12\x1e\n" +
+	"\vssh_key_ref\x18\x04 \x01(\tR\tsshKeyRef\x12!\n" +
+	"\fssh_password\x18\x05 \x01(\tR\vsshPassword\"\xe6\x01\n" +
+	"\x15ExecuteCommandRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12)\n" +
+	"\x06target\x18\x02 \x01(\v2\x11.webcli.v1.TargetR\x06target\x12\x12\n" +
+	"\x04user\x18\x03 \x01(\tR\x04user\x12#\n" +
+	"\rsudo_password\x18\x04 \x01(\tR\fsudoPassword\x12\x14\n" +
+	"\x05stdin\x18\x05 \x01(\fR\x05stdin\x12\x18\n" +
+	"\aworkdir\x18\x06 \x01(\tR\aworkdir\x12\x1f\n" +
+	"\vapproval_id\x18\a \x01(\x03R\n" +
+	"approvalId\"\x9e\x01\n" +
+	"\rCommandResult\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\tR\x06output\x12\x1b\n" +
+	"\texit_code\x18\x03 \x01(\x05R\bexitCode\x12\x12\n" +
+	"\x04user\x18\x04 \x01(\tR\x04user\x12*\n" +
+	"\x11execution_time_ms\x18\x05 \x01(\x03R\x0fexecutionTimeMs\"\xaf\x03\n" +
+	"\x14ExecuteScriptRequest\x12\x1b\n" +
+	"\tscript_id\x18\x01 \x01(\x03R\bscriptId\x12\x1f\n" +
+	"\vscript_name\x18\x02 \x01(\tR\n" +
+	"scriptName\x12!\n" +
+	"\fscript_group\x18\x03 \x01(\tR\vscriptGroup\x12)\n" +
+	"\x06target\x18\x04 \x01(\v2\x11.webcli.v1.TargetR\x06target\x12\x12\n" +
+	"\x04user\x18\x05 \x01(\tR\x04user\x12#\n" +
+	"\rsudo_password\x18\x06 \x01(\tR\fsudoPassword\x12\x1e\n" +
+	"\venv_var_ids\x18\a \x03(\x03R\tenvVarIds\x12\"\n" +
+	"\renv_var_names\x18\b \x03(\tR\venvVarNames\x12$\n" +
+	"\x0eenv_var_groups\x18\t \x03(\tR\fenvVarGroups\x12\x14\n" +
+	"\x05stdin\x18\n" +
+	" \x01(\fR\x05stdin\x12\x18\n" +
+	"\aworkdir\x18\v \x01(\tR\aworkdir\x12\x1f\n" +
+	"\vapproval_id\x18\f \x01(\x03R\n" +
+	"approvalId\x12\x17\n" +
+	"\adry_run\x18\r \x01(\bR\x06dryRun\"\x8d\x02\n" +
+	"\fScriptResult\x12\x1b\n" +
+	"\tscript_id\x18\x01 \x01(\x03R\bscriptId\x12\x1f\n" +
+	"\vscript_name\x18\x02 \x01(\tR\n" +
+	"scriptName\x12\x16\n" +
+	"\x06output\x18\x03 \x01(\tR\x06output\x12\x1b\n" +
+	"\texit_code\x18\x04 \x01(\x05R\bexitCode\x12\x12\n" +
+	"\x04user\x18\x05 \x01(\tR\x04user\x12\x16\n" +
+	"\x06server\x18\x06 \x01(\tR\x06server\x12*\n" +
+	"\x11execution_time_ms\x18\a \x01(\x03R\x0fexecutionTimeMs\x12\x19\n" +
+	"\benv_vars\x18\b \x03(\tR\aenvVars\x12\x17\n" +
+	"\adry_run\x18\t \x01(\bR\x06dryRun\"\x98\x01\n" +
+	"\x0eExecutionEvent\x12\x18\n" +
+	"\x06status\x18\x01 \x01(\tH\x00R\x06status\x12\x18\n" +
+	"\x06output\x18\x02 \x01(\tH\x00R\x06output\x12\x16\n" +
+	"\x05error\x18\x03 \x01(\tH\x00R\x05error\x121\n" +
+	"\x06result\x18\x04 \x01(\v2\x17.webcli.v1.ScriptResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\x95\x02\n" +
+	"\fHistoryEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\x12\x16\n" +
+	"\x06output\x18\x03 \x01(\tR\x06output\x12 \n" +
+	"\texit_code\x18\x04 \x01(\x05H\x00R\bexitCode\x88\x01\x01\x12\x16\n" +
+	"\x06server\x18\x05 \x01(\tR\x06server\x12\x12\n" +
+	"\x04user\x18\x06 \x01(\tR\x04user\x12*\n" +
+	"\x11execution_time_ms\x18\a \x01(\x03R\x0fexecutionTimeMs\x12;\n" +
+	"\vexecuted_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"executedAtB\f\n" +
+	"\n" +
+	"_exit_code\"B\n" +
+	"\x12ListHistoryRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"H\n" +
+	"\x13ListHistoryResponse\x121\n" +
+	"\aentries\x18\x01 \x03(\v2\x17.webcli.v1.HistoryEntryR\aentries\"#\n" +
+	"\x11GetHistoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id2\x9a\x01\n" +
+	"\rServerService\x12L\n" +
+	"\vListServers\x12\x1d.webcli.v1.ListServersRequest\x1a\x1e.webcli.v1.ListServersResponse\x12;\n" +
+	"\tGetServer\x12\x1b.webcli.v1.GetServerRequest\x1a\x11.webcli.v1.Server2\x9a\x01\n" +
+	"\rScriptService\x12L\n" +
+	"\vListScripts\x12\x1d.webcli.v1.ListScriptsRequest\x1a\x1e.webcli.v1.ListScriptsResponse\x12;\n" +
+	"\tGetScript\x12\x1b.webcli.v1.GetScriptRequest\x1a\x11.webcli.v1.Script2\xf9\x01\n" +
+	"\x10ExecutionService\x12L\n" +
+	"\x0eExecuteCommand\x12 .webcli.v1.ExecuteCommandRequest\x1a\x18.webcli.v1.CommandResult\x12I\n" +
+	"\rExecuteScript\x12\x1f.webcli.v1.ExecuteScriptRequest\x1a\x17.webcli.v1.ScriptResult\x12L\n" +
+	"\fStreamScript\x12\x1f.webcli.v1.ExecuteScriptRequest\x1a\x19.webcli.v1.ExecutionEvent0\x012\xa3\x01\n" +
+	"\x0eHistoryService\x12L\n" +
+	"\vListHistory\x12\x1d.webcli.v1.ListHistoryRequest\x1a\x1e.webcli.v1.ListHistoryResponse\x12C\n" +
+	"\n" +
+	"GetHistory\x12\x1c.webcli.v1.GetHistoryRequest\x1a\x17.webcli.v1.HistoryEntryB1Z/github.com/pozgo/web-cli/api/webcli/v1;webcliv1b\x06proto3"
+
+var (
+	file_webcli_proto_rawDescOnce sync.Once
+	file_webcli_proto_rawDescData []byte
+)
+
+func file_webcli_proto_rawDescGZIP() []byte {
+	file_webcli_proto_rawDescOnce.Do(func() {
+		file_webcli_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_webcli_proto_rawDesc), len(file_webcli_proto_rawDesc)))
+	})
+	return file_webcli_proto_rawDescData
+}
+
+var file_webcli_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_webcli_proto_goTypes = []any{
+	(*ListFilter)(nil),            // 0: webcli.v1.ListFilter
+	(*Server)(nil),                // 1: webcli.v1.Server
+	(*ListServersRequest)(nil),    // 2: webcli.v1.ListServersRequest
+	(*ListServersResponse)(nil),   // 3: webcli.v1.ListServersResponse
+	(*GetServerRequest)(nil),      // 4: webcli.v1.GetServerRequest
+	(*Script)(nil),                // 5: webcli.v1.Script
+	(*ListScriptsRequest)(nil),    // 6: webcli.v1.ListScriptsRequest
+	(*ListScriptsResponse)(nil),   // 7: webcli.v1.ListScriptsResponse
+	(*GetScriptRequest)(nil),      // 8: webcli.v1.GetScriptRequest
+	(*Target)(nil),                // 9: webcli.v1.Target
+	(*ExecuteCommandRequest)(nil), // 10: webcli.v1.ExecuteCommandRequest
+	(*CommandResult)(nil),         // 11: webcli.v1.CommandResult
+	(*ExecuteScriptRequest)(nil),  // 12: webcli.v1.ExecuteScriptRequest
+	(*ScriptResult)(nil),          // 13: webcli.v1.ScriptResult
+	(*ExecutionEvent)(nil),        // 14: webcli.v1.ExecutionEvent
+	(*HistoryEntry)(nil),          // 15: webcli.v1.HistoryEntry
+	(*ListHistoryRequest)(nil),    // 16: webcli.v1.ListHistoryRequest
+	(*ListHistoryResponse)(nil),   // 17: webcli.v1.ListHistoryResponse
+	(*GetHistoryRequest)(nil),     // 18: webcli.v1.GetHistoryRequest
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
+}
+var file_webcli_proto_depIdxs = []int32{
+	19, // 0: webcli.v1.Server.created_at:type_name -> google.protobuf.Timestamp
+	19, // 1: webcli.v1.Server.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: webcli.v1.ListServersRequest.filter:type_name -> webcli.v1.ListFilter
+	1,  // 3: webcli.v1.ListServersResponse.servers:type_name -> webcli.v1.Server
+	19, // 4: webcli.v1.Script.created_at:type_name -> google.protobuf.Timestamp
+	19, // 5: webcli.v1.Script.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 6: webcli.v1.ListScriptsRequest.filter:type_name -> webcli.v1.ListFilter
+	5,  // 7: webcli.v1.ListScriptsResponse.scripts:type_name -> webcli.v1.Script
+	9,  // 8: webcli.v1.ExecuteCommandRequest.target:type_name -> webcli.v1.Target
+	9,  // 9: webcli.v1.ExecuteScriptRequest.target:type_name -> webcli.v1.Target
+	13, // 10: webcli.v1.ExecutionEvent.result:type_name -> webcli.v1.ScriptResult
+	19, // 11: webcli.v1.HistoryEntry.executed_at:type_name -> google.protobuf.Timestamp
+	15, // 12: webcli.v1.ListHistoryResponse.entries:type_name -> webcli.v1.HistoryEntry
+	2,  // 13: webcli.v1.ServerService.ListServers:input_type -> webcli.v1.ListServersRequest
+	4,  // 14: webcli.v1.ServerService.GetServer:input_type -> webcli.v1.GetServerRequest
+	6,  // 15: webcli.v1.ScriptService.ListScripts:input_type -> webcli.v1.ListScriptsRequest
+	8,  // 16: webcli.v1.ScriptService.GetScript:input_type -> webcli.v1.GetScriptRequest
+	10, // 17: webcli.v1.ExecutionService.ExecuteCommand:input_type -> webcli.v1.ExecuteCommandRequest
+	12, // 18: webcli.v1.ExecutionService.ExecuteScript:input_type -> webcli.v1.ExecuteScriptRequest
+	12, // 19: webcli.v1.ExecutionService.StreamScript:input_type -> webcli.v1.ExecuteScriptRequest
+	16, // 20: webcli.v1.HistoryService.ListHistory:input_type -> webcli.v1.ListHistoryRequest
+	18, // 21: webcli.v1.HistoryService.GetHistory:input_type -> webcli.v1.GetHistoryRequest
+	3,  // 22: webcli.v1.ServerService.ListServers:output_type -> webcli.v1.ListServersResponse
+	1,  // 23: webcli.v1.ServerService.GetServer:output_type -> webcli.v1.Server
+	7,  // 24: webcli.v1.ScriptService.ListScripts:output_type -> webcli.v1.ListScriptsResponse
+	5,  // 25: webcli.v1.ScriptService.GetScript:output_type -> webcli.v1.Script
+	11, // 26: webcli.v1.ExecutionService.ExecuteCommand:output_type -> webcli.v1.CommandResult
+	13, // 27: webcli.v1.ExecutionService.ExecuteScript:output_type -> webcli.v1.ScriptResult
+	14, // 28: webcli.v1.ExecutionService.StreamScript:output_type -> webcli.v1.ExecutionEvent
+	17, // 29: webcli.v1.HistoryService.ListHistory:output_type -> webcli.v1.ListHistoryResponse
+	15, // 30: webcli.v1.HistoryService.GetHistory:output_type -> webcli.v1.HistoryEntry
+	22, // [22:31] is the sub-list for method output_type
+	13, // [13:22] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_webcli_proto_init() }
+func file_webcli_proto_init() {
+	if File_webcli_proto != nil {
+		return
+	}
+	file_webcli_proto_msgTypes[14].OneofWrappers = []any{
+		(*ExecutionEvent_Status)(nil),
+		(*ExecutionEvent_Output)(nil),
+		(*ExecutionEvent_Error)(nil),
+		(*ExecutionEvent_Result)(nil),
+	}
+	file_webcli_proto_msgTypes[15].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_webcli_proto_rawDesc), len(file_webcli_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_webcli_proto_goTypes,
+		DependencyIndexes: file_webcli_proto_depIdxs,
+		MessageInfos:      file_webcli_proto_msgTypes,
+	}.Build()
+	File_webcli_proto = out.File
+	file_webcli_proto_goTypes = nil
+	file_webcli_proto_depIdxs = nil
+}
@@ -62,6 +62,10 @@ cp -r frontend/dist/* assets/frontend/
 
 echo -e "${GREEN}Frontend build completed!${NC}"
 
+# Regenerate the OpenAPI spec served at /api/openapi.json
+echo -e "${YELLOW}Generating OpenAPI spec...${NC}"
+go generate ./cmd/web-cli
+
 # Function to build for a specific platform
 build_platform() {
     local GOOS=$1
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pozgo/web-cli/assets"
+	"github.com/pozgo/web-cli/internal/agent"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/kms"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/server"
+	"github.com/pozgo/web-cli/internal/validation"
+	"github.com/pozgo/web-cli/internal/vault"
+)
+
+// command is a CLI subcommand
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+// commands lists the available subcommands in the order shown by help
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "serve", usage: "serve [flags]", summary: "Start the web server (default)", run: runServe},
+		{name: "version", usage: "version", summary: "Print the build version", run: runVersion},
+		{name: "migrate", usage: "migrate [-to <version>] [-force <version>] [-status] [flags]", summary: "Apply pending database migrations or roll back to a schema version", run: runMigrate},
+		{name: "gen-encryption-key", usage: "gen-encryption-key [-write] [-force] [-from <file>] [flags]", summary: "Generate a new encryption key", run: runGenEncryptionKey},
+		{name: "user", usage: "user add <name> [flags]", summary: "Manage local users available for command execution", run: runUser},
+		{name: "backup", usage: "backup [-passphrase-file <file>] [flags] <file>", summary: "Write a consistent copy of the database to a file", run: runBackup},
+		{name: "restore", usage: "restore [-force] [-passphrase-file <file>] [flags] <file>", summary: "Replace the database with a backup (server must be stopped)", run: runRestore},
+		{name: "agent", usage: "agent -url <web-cli URL> [-name <server>] [-token-file <file>] [-ca-cert <file>]", summary: "Run commands for a server without inbound SSH, connecting out to web-cli", run: runAgent},
+		{name: "help", usage: "help", summary: "Show this help", run: runHelp},
+	}
+}
+
+// findCommand returns the subcommand with the given name, or nil
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// printUsage writes the list of subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: web-cli <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-20s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'web-cli <command> -h' for command flags.\n")
+}
+
+// newFlagSet creates a flag set for a subcommand with usage output
+func newFlagSet(cmd string) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	fs.Usage = func() {
+		if c := findCommand(cmd); c != nil {
+			fmt.Fprintf(fs.Output(), "Usage: web-cli %s\n\n%s\n\nFlags:\n", c.usage, c.summary)
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// loadConfigWithArg loads configuration for a subcommand taking one positional argument
+// The argument may appear before or after the flags
+func loadConfigWithArg(fs *flag.FlagSet, args []string, usage string) (*config.Config, string, error) {
+	var arg string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		arg, args = args[0], args[1:]
+	}
+
+	cfg, err := config.LoadFlagSet(fs, args)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if arg == "" && fs.NArg() == 1 {
+		arg = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		arg = ""
+	}
+
+	if arg == "" {
+		return nil, "", fmt.Errorf("usage: web-cli %s", usage)
+	}
+
+	return cfg, arg, nil
+}
+
+// openDatabase initializes encryption and opens (and migrates) the database
+func openDatabase(cfg *config.Config) (*database.DB, error) {
+	if err := initEncryption(cfg); err != nil {
+		return nil, fmt.Errorf("failed to initialize encryption: %w", err)
+	}
+
+	db, err := database.New(cfg.DatabasePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return db, nil
+}
+
+// initEncryption sets up encryption of stored secrets with the configured provider
+func initEncryption(cfg *config.Config) error {
+	switch cfg.EncryptionProvider {
+	case "", "local":
+		return database.InitializeEncryption(cfg.EncryptionKeyPath)
+	case kms.ProviderAWS, kms.ProviderGCP, kms.ProviderAge, kms.ProviderSops:
+		wrapper, err := keyWrapper(cfg)
+		if err != nil {
+			return err
+		}
+		if err := database.InitializeWrappedEncryption(cfg.EncryptionKeyPath, wrapper); err != nil {
+			return err
+		}
+		log.Printf("Encryption key unwrapped with %s", cfg.EncryptionProvider)
+		return nil
+	case "vault-transit":
+		transit, err := vault.NewTransit(&vault.TransitConfig{
+			Address:   cfg.TransitAddress,
+			Token:     cfg.TransitToken,
+			Namespace: cfg.TransitNamespace,
+			Mount:     cfg.TransitMount,
+			Key:       cfg.TransitKey,
+			CacheSize: cfg.TransitCacheSize,
+			CacheTTL:  cfg.GetTransitCacheTTL(),
+			Timeout:   cfg.GetVaultTimeout(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := transit.Check(); err != nil {
+			transit.Close()
+			return fmt.Errorf("vault transit key is not usable: %w", err)
+		}
+		database.SetEncryptor(transit)
+
+		// No key is generated; an existing one only decrypts values stored before the switch
+		if database.LoadEncryptionKey(cfg.EncryptionKeyPath) {
+			log.Printf("Encrypting secrets with Vault transit key %q; the local key is kept to read older values", cfg.TransitKey)
+		} else {
+			log.Printf("Encrypting secrets with Vault transit key %q", cfg.TransitKey)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown encryption provider %q (use local, vault-transit, aws-kms, gcp-kms, age or sops)", cfg.EncryptionProvider)
+	}
+}
+
+// keyWrapper returns the wrapper of the key file for a KMS encryption provider
+func keyWrapper(cfg *config.Config) (*kms.Wrapper, error) {
+	return kms.New(&kms.Config{
+		Provider:     cfg.EncryptionProvider,
+		Key:          cfg.EncryptionKMSKey,
+		AgeIdentity:  cfg.EncryptionAgeIdentity,
+		AgeRecipient: cfg.EncryptionAgeRecipient,
+	})
+}
+
+// runServe starts the HTTP server
+func runServe(args []string) error {
+	cfg, err := config.LoadFlagSet(newFlagSet("serve"), args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize encryption and database
+	log.Println("Initializing encryption...")
+	log.Printf("Initializing database at %s...", cfg.DatabasePath)
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Get database version
+	version, err := db.GetVersion()
+	if err != nil {
+		log.Printf("Warning: failed to get database version: %v", err)
+	} else {
+		log.Printf("Database schema version: %d", version)
+	}
+
+	// Initialize audit logging
+	if auditLogger := initAuditLogging(cfg); auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	// Set embedded frontend and build version
+	server.EmbeddedFrontend = assets.FrontendFS
+	server.Version = Version
+
+	// Create and start server
+	srv, err := server.New(cfg, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize server: %w", err)
+	}
+
+	return srv.Start()
+}
+
+// initAuditLogging sets up the audit log file and the configured syslog, journald and webhook sinks
+// Returns nil when audit logging is disabled
+func initAuditLogging(cfg *config.Config) *audit.Logger {
+	var sinks []audit.Sink
+	var targets []string
+
+	if cfg.AuditSyslog != "" {
+		sink, err := audit.NewSyslogSink(cfg.AuditSyslog, cfg.AuditSyslogFacility)
+		if err != nil {
+			log.Printf("Warning: Failed to initialize syslog audit sink: %v", err)
+		} else {
+			sinks = append(sinks, sink)
+			targets = append(targets, "syslog "+cfg.AuditSyslog)
+		}
+	}
+	if cfg.AuditJournald {
+		sink, err := audit.NewJournaldSink()
+		if err != nil {
+			log.Printf("Warning: Failed to initialize journald audit sink: %v", err)
+		} else {
+			sinks = append(sinks, sink)
+			targets = append(targets, "journald")
+		}
+	}
+
+	if cfg.AuditWebhookURL != "" {
+		sink, err := audit.NewWebhookSink(audit.WebhookConfig{
+			URL:           cfg.AuditWebhookURL,
+			Secret:        cfg.AuditWebhookSecret,
+			BatchSize:     cfg.AuditWebhookBatchSize,
+			FlushInterval: cfg.GetAuditWebhookFlushInterval(),
+			QueueSize:     cfg.AuditWebhookQueueSize,
+			MaxRetries:    cfg.AuditWebhookMaxRetries,
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to initialize audit webhook: %v", err)
+		} else {
+			sinks = append(sinks, sink)
+			target := cfg.AuditWebhookURL
+			if u, err := url.Parse(target); err == nil {
+				target = u.Redacted() // Hide credentials embedded in the URL
+			}
+			targets = append(targets, "webhook "+target)
+		}
+	}
+
+	if cfg.AuditLogPath == "" && len(sinks) == 0 {
+		log.Println("Audit logging is disabled (set AUDIT_LOG_PATH, AUDIT_SYSLOG, AUDIT_JOURNALD or AUDIT_WEBHOOK_URL to enable)")
+		return nil
+	}
+
+	auditLogger, err := audit.Initialize(cfg.AuditLogPath, sinks...)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize audit logging: %v", err)
+	} else if cfg.AuditLogPath != "" {
+		targets = append([]string{cfg.AuditLogPath}, targets...)
+	}
+	if len(targets) > 0 {
+		log.Printf("Audit logging enabled: %s", strings.Join(targets, ", "))
+	}
+	return auditLogger
+}
+
+// runVersion prints the build version
+func runVersion(args []string) error {
+	fmt.Printf("web-cli %s\n", Version)
+	return nil
+}
+
+// runMigrate applies pending migrations, or moves the schema to a given version, and reports the schema version
+func runMigrate(args []string) error {
+	fs := newFlagSet("migrate")
+	to := fs.Int("to", 0, "Migrate up or roll back to this schema version (default: the latest)")
+	force := fs.Int("force", 0, "Record this version as applied without running migrations, to recover a dirty database")
+	status := fs.Bool("status", false, "List migrations and whether each is applied, without changing the schema")
+	cfg, err := config.LoadFlagSet(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// Migrations don't touch encrypted values, so no key is needed
+	db, err := database.Open(cfg.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch {
+	case *status:
+		statuses, err := db.Migrations()
+		if err != nil {
+			return err
+		}
+		for _, m := range statuses {
+			state := "pending"
+			if m.Dirty {
+				state = "dirty"
+			} else if m.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%4d  %-8s %s\n", m.Version, state, m.Description)
+		}
+	case *force > 0:
+		if err := db.Force(*force); err != nil {
+			return err
+		}
+	default:
+		target := database.LatestVersion()
+		if *to > 0 {
+			target = *to
+		}
+		if err := db.MigrateTo(target); err != nil {
+			return err
+		}
+	}
+
+	version, err := db.GetVersion()
+	if err != nil {
+		return fmt.Errorf("failed to get database version: %w", err)
+	}
+
+	fmt.Printf("Database schema version: %d\n", version)
+	return nil
+}
+
+// runGenEncryptionKey prints a new key, or writes it to the configured key path
+func runGenEncryptionKey(args []string) error {
+	fs := newFlagSet("gen-encryption-key")
+	write := fs.Bool("write", false, "Write the key to the encryption key path instead of printing it, wrapped when a KMS provider is configured")
+	force := fs.Bool("force", false, "Overwrite an existing key file (existing data will become unreadable)")
+	from := fs.String("from", "", "Use the plaintext key in this file instead of generating one, e.g. to wrap an existing key")
+	cfg, err := config.LoadFlagSet(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var key string
+	if *from != "" {
+		data, err := os.ReadFile(*from)
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+		raw, err := database.ParseEncryptionKey(data)
+		if err != nil {
+			return err
+		}
+		key = base64.StdEncoding.EncodeToString(raw)
+	} else {
+		key, err = database.GenerateEncryptionKey()
+		if err != nil {
+			return err
+		}
+	}
+
+	if !*write {
+		fmt.Println(key)
+		return nil
+	}
+
+	if _, err := os.Stat(cfg.EncryptionKeyPath); err == nil && !*force {
+		return fmt.Errorf("encryption key already exists at %s (use -force to overwrite)", cfg.EncryptionKeyPath)
+	}
+
+	if kms.IsProvider(cfg.EncryptionProvider) {
+		wrapper, err := keyWrapper(cfg)
+		if err != nil {
+			return err
+		}
+		raw, _ := base64.StdEncoding.DecodeString(key)
+		if err := wrapper.WrapKey(raw, cfg.EncryptionKeyPath); err != nil {
+			return fmt.Errorf("failed to wrap encryption key: %w", err)
+		}
+		fmt.Printf("Encryption key wrapped with %s and written to %s\n", cfg.EncryptionProvider, cfg.EncryptionKeyPath)
+		return nil
+	}
+
+	if err := os.WriteFile(cfg.EncryptionKeyPath, []byte(key), 0600); err != nil {
+		return fmt.Errorf("failed to save encryption key: %w", err)
+	}
+
+	fmt.Printf("Encryption key written to %s\n", cfg.EncryptionKeyPath)
+	return nil
+}
+
+// runUser dispatches user management subcommands
+func runUser(args []string) error {
+	if len(args) == 0 || args[0] != "add" {
+		return fmt.Errorf("usage: web-cli user add <name> [flags]")
+	}
+
+	cfg, name, err := loadConfigWithArg(newFlagSet("user"), args[1:], "user add <name> [flags]")
+	if err != nil {
+		return err
+	}
+
+	if err := validation.ValidateUsername(name); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	repo := repository.NewLocalUserRepository(db)
+	user, err := repo.Create(&models.LocalUserCreate{Name: name})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added local user %s (ID %d)\n", user.Name, user.ID)
+	return nil
+}
+
+// readPassphraseFile reads a passphrase from a file, without its trailing newline
+// Returns "" when no file is given
+func readPassphraseFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// runBackup writes a snapshot of the database to a file
+func runBackup(args []string) error {
+	fs := newFlagSet("backup")
+	passphraseFile := fs.String("passphrase-file", "", "Encrypt the backup with the passphrase in this file")
+	cfg, dest, err := loadConfigWithArg(fs, args, "backup [-passphrase-file <file>] [flags] <file>")
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphraseFile(*passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if passphrase == "" {
+		if err := db.Backup(dest); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+		err = db.WriteBackup(f, passphrase)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(dest)
+			return err
+		}
+	}
+
+	fmt.Printf("Database backed up to %s\n", dest)
+	if cfg.EncryptionProvider == "vault-transit" {
+		fmt.Printf("Note: secrets in the backup are encrypted with the Vault transit key %q; it is needed to restore them\n", cfg.TransitKey)
+	} else if kms.IsProvider(cfg.EncryptionProvider) {
+		fmt.Printf("Note: secrets in the backup are encrypted; keep a copy of the wrapped key %s and access to %s to restore them\n", cfg.EncryptionKeyPath, cfg.EncryptionProvider)
+	} else {
+		fmt.Printf("Note: secrets in the backup are encrypted; keep a copy of %s to restore them\n", cfg.EncryptionKeyPath)
+	}
+	return nil
+}
+
+// runRestore replaces the database with a backup file
+func runRestore(args []string) error {
+	fs := newFlagSet("restore")
+	force := fs.Bool("force", false, "Overwrite the existing database")
+	passphraseFile := fs.String("passphrase-file", "", "Decrypt an encrypted backup with the passphrase in this file")
+	cfg, src, err := loadConfigWithArg(fs, args, "restore [-force] [-passphrase-file <file>] [flags] <file>")
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphraseFile(*passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(cfg.DatabasePath); err == nil && !*force {
+		return fmt.Errorf("database already exists at %s (use -force to overwrite)", cfg.DatabasePath)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	staged, err := database.StageBackup(f, filepath.Dir(cfg.DatabasePath), passphrase)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(staged)
+
+	if err := database.Restore(staged, cfg.DatabasePath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Database restored from %s to %s\n", src, cfg.DatabasePath)
+	return nil
+}
+
+// runAgent connects to web-cli as the agent of a server and runs its commands until interrupted
+func runAgent(args []string) error {
+	fs := newFlagSet("agent")
+	serverURL := fs.String("url", os.Getenv("AGENT_URL"), "web-cli base URL, e.g. https://web-cli.example.com (env AGENT_URL)")
+	hostname, _ := os.Hostname()
+	name := fs.String("name", hostname, "Name of the server in web-cli, which must use the agent transport")
+	tokenFile := fs.String("token-file", "", "Read the API token (agent scope) from this file instead of AGENT_TOKEN")
+	caCert := fs.String("ca-cert", "", "PEM file of the CA that signed web-cli's certificate, if not a system-trusted one")
+	shell := fs.String("shell", "/bin/sh", "Shell commands run with")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *serverURL == "" || *name == "" {
+		return fmt.Errorf("usage: web-cli %s", findCommand("agent").usage)
+	}
+
+	token := os.Getenv("AGENT_TOKEN")
+	if *tokenFile != "" {
+		data, err := os.ReadFile(*tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	var tlsConfig *tls.Config
+	if *caCert != "" {
+		pem, err := os.ReadFile(*caCert)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", *caCert)
+		}
+		tlsConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Starting web-cli agent %s for server %s", Version, *name)
+	client := &agent.Client{
+		URL:       *serverURL,
+		Name:      *name,
+		Token:     token,
+		Version:   Version,
+		TLSConfig: tlsConfig,
+		Shell:     *shell,
+	}
+	return client.Run(ctx)
+}
+
+// runHelp prints the list of subcommands
+func runHelp(args []string) error {
+	printUsage(os.Stdout)
+	return nil
+}
@@ -1,17 +1,19 @@
 package main
 
 import (
-	"log"
-
-	"github.com/pozgo/web-cli/assets"
-	"github.com/pozgo/web-cli/internal/audit"
-	"github.com/pozgo/web-cli/internal/config"
-	"github.com/pozgo/web-cli/internal/database"
-	"github.com/pozgo/web-cli/internal/server"
+	"fmt"
+	"os"
+	"strings"
 
 	_ "github.com/pozgo/web-cli/docs" // Swagger docs
 )
 
+// Regenerate the OpenAPI spec served at /api/openapi.json from the handler annotations
+//go:generate go run github.com/swaggo/swag/cmd/swag init -d ../.. -g cmd/web-cli/main.go -o ../../docs
+
+// Version is the build version, set at build time via -ldflags "-X main.Version=..."
+var Version = "dev"
+
 // @title Web CLI API
 // @version 1.1.0
 // @description Web-based CLI tool for executing shell commands locally and remotely with SSH key management, script storage, and interactive terminal sessions.
@@ -69,52 +71,22 @@ import (
 // @tag.description System information endpoints
 
 func main() {
-	// Load configuration
-	cfg := config.Load()
-
-	// Initialize encryption
-	log.Println("Initializing encryption...")
-	if err := database.InitializeEncryption(cfg.EncryptionKeyPath); err != nil {
-		log.Fatalf("Failed to initialize encryption: %v", err)
+	// Default to serve so existing invocations (e.g. "web-cli -port 8080") keep working
+	name := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	// Initialize database
-	log.Printf("Initializing database at %s...", cfg.DatabasePath)
-	db, err := database.New(cfg.DatabasePath)
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
-	}
-	defer db.Close()
-
-	// Get database version
-	version, err := db.GetVersion()
-	if err != nil {
-		log.Printf("Warning: failed to get database version: %v", err)
-	} else {
-		log.Printf("Database schema version: %d", version)
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
 	}
 
-	// Initialize audit logging
-	if cfg.AuditLogPath != "" {
-		auditLogger, err := audit.Initialize(cfg.AuditLogPath)
-		if err != nil {
-			log.Printf("Warning: Failed to initialize audit logging: %v", err)
-		} else {
-			log.Printf("Audit logging enabled: %s", cfg.AuditLogPath)
-			defer auditLogger.Close()
-		}
-	} else {
-		log.Println("Audit logging is disabled (set AUDIT_LOG_PATH to enable)")
+	if err := cmd.run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-
-	// Set embedded frontend
-	server.EmbeddedFrontend = assets.FrontendFS
-
-	// Create and start server
-	srv, err := server.New(cfg, db)
-	if err != nil {
-		log.Fatalf("Failed to initialize server: %v", err)
-	}
-
-	log.Fatal(srv.Start())
 }
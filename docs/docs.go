@@ -24,29 +24,107 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/bash-scripts": {
+        "/agents": {
             "get": {
+                "description": "List the agents connected now, by the name of the server they run commands for, with their hostname, version, address and how many commands they are running. Servers using the agent transport without a connected agent cannot run commands. Requires admin access.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "List connected agents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ConnectedAgent"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
+                ]
+            }
+        },
+        "/agents/connect": {
+            "get": {
+                "description": "WebSocket endpoint that \"web-cli agent\" dials out to, so servers without inbound SSH access can run commands. The agent names the server it runs commands for, which must use the agent transport and be the server its API token is bound to. A server has one agent at a time: others get 409 Conflict until it disconnects. Commands run as the user the agent runs as, through sudo for other users. Requires an API token with the agent scope, or admin access.",
+                "tags": [
+                    "Servers"
                 ],
-                "description": "Get a list of all bash scripts (without content by default)",
-                "consumes": [
-                    "application/json"
+                "summary": "Connect an agent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the server the agent runs commands for",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/approvals": {
+            "get": {
+                "description": "List execution approval requests, newest first. Filter with ?status=pending|approved|rejected|executed|expired",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
+                    "Approvals"
                 ],
-                "summary": "List all bash scripts",
+                "summary": "List approvals",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter by group name",
-                        "name": "group",
+                        "description": "Status filter",
+                        "name": "status",
                         "in": "query"
                     }
                 ],
@@ -56,281 +134,315 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.BashScriptResponse"
+                                "$ref": "#/definitions/models.Approval"
                             }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Create a new bash script (stored encrypted)",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/approvals/{id}": {
+            "get": {
+                "description": "Get an execution approval request by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
+                    "Approvals"
                 ],
-                "summary": "Create a bash script",
+                "summary": "Get an approval",
                 "parameters": [
                     {
-                        "description": "Bash script to create",
-                        "name": "script",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.BashScriptCreate"
-                        }
+                        "type": "integer",
+                        "description": "Approval ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.BashScriptResponse"
+                            "$ref": "#/definitions/models.Approval"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/bash-scripts/execute": {
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Execute a stored bash script locally or remotely",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/approvals/{id}/approve": {
+            "post": {
+                "description": "Approve a pending execution request. The approver must be an admin or have the approver role, and cannot be the requester.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
+                    "Approvals"
                 ],
-                "summary": "Execute a bash script",
+                "summary": "Approve an execution",
                 "parameters": [
                     {
-                        "description": "Script execution request",
-                        "name": "execution",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptExecution"
-                        }
+                        "type": "integer",
+                        "description": "Approval ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptResult"
+                            "$ref": "#/definitions/models.Approval"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/bash-scripts/execute/stream": {
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Execute a stored bash script locally or remotely with real-time output streaming via SSE",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/approvals/{id}/reject": {
+            "post": {
+                "description": "Reject a pending execution request. The approver must be an admin or have the approver role, and cannot be the requester.",
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
+                    "Approvals"
                 ],
-                "summary": "Execute a bash script with streaming output",
+                "summary": "Reject an execution",
                 "parameters": [
                     {
-                        "description": "Script execution request",
-                        "name": "execution",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptExecution"
-                        }
+                        "type": "integer",
+                        "description": "Approval ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.StreamMessage"
+                            "$ref": "#/definitions/models.Approval"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/bash-scripts/groups": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all distinct group names for bash scripts",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/audit/keystrokes": {
+            "get": {
+                "description": "List the keystroke logs of terminal sessions, newest first, without their keystrokes. Logs are only kept when TERMINAL_KEYSTROKE_LOG is enabled. Requires admin access.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
+                    "Audit"
                 ],
-                "summary": "List all bash script groups",
+                "summary": "List terminal keystroke logs",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/models.KeystrokeLog"
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/bash-scripts/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a single bash script by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/audit/keystrokes/{id}": {
+            "get": {
+                "description": "Get a terminal session's keystroke log with its decrypted keystrokes. Input typed at password prompts is stored as redacted events. Requires admin access.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
+                    "Audit"
                 ],
-                "summary": "Get a bash script by ID",
+                "summary": "Get a terminal keystroke log",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Bash Script ID",
+                        "description": "Keystroke log ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "default": true,
-                        "description": "Include script content in response",
-                        "name": "include_content",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.BashScriptResponse"
+                            "$ref": "#/definitions/models.KeystrokeLog"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
+                ]
+            }
+        },
+        "/auth/lockouts": {
+            "get": {
+                "description": "List client IPs and usernames currently locked out after repeated failed login attempts",
+                "produces": [
+                    "application/json"
                 ],
-                "description": "Update an existing bash script by its ID",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "List login lockouts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/middleware.Lockout"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Exchange the configured username and password for a signed session token. The token is also set as an HTTP-only cookie for browser clients and may be sent as a Bearer token. This endpoint does not require authentication.",
                 "consumes": [
                     "application/json"
                 ],
@@ -338,24 +450,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
+                    "Auth"
                 ],
-                "summary": "Update a bash script",
+                "summary": "Log in",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Bash Script ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Bash script update data",
-                        "name": "script",
+                        "description": "Login credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.BashScriptUpdate"
+                            "$ref": "#/definitions/server.LoginRequest"
                         }
                     }
                 ],
@@ -363,76 +468,143 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.BashScriptResponse"
+                            "$ref": "#/definitions/server.SessionResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "End the current session and clear the session cookie",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                },
+                "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Delete a bash script by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Replace the current session token with a new one, extending the session",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bash Scripts"
-                ],
-                "summary": "Delete a bash script",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Bash Script ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Auth"
                 ],
+                "summary": "Refresh session",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SessionResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/auth/session": {
+            "get": {
+                "description": "Return the session that authenticated the request, or 404 if another authentication method was used",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get current session",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SessionResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
             }
         },
-        "/bash-scripts/{id}/presets": {
+        "/auth/sudo-password": {
             "get": {
+                "description": "Report whether a sudo password is cached for the current login session. The password itself is never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get the session's sudo password status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SudoPasswordResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get all presets for a specific bash script",
+                ]
+            },
+            "post": {
+                "description": "Keep a sudo password in memory, encrypted, for the current login session, so local executions without a sudo_password use it instead of needing it on every call. It expires after SUDO_PASSWORD_TTL, with the session or on logout.",
                 "consumes": [
                     "application/json"
                 ],
@@ -440,57 +612,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Script Presets"
+                    "Auth"
                 ],
-                "summary": "Get presets for a script",
+                "summary": "Cache a sudo password for the session",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Bash Script ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Sudo password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SudoPasswordRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptPresetResponse"
-                            }
+                            "$ref": "#/definitions/server.SudoPasswordResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Remove the sudo password cached for the current login session",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Forget the session's sudo password",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/commands/execute": {
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Execute a shell command locally or remotely via SSH",
+                ]
+            }
+        },
+        "/auth/unlock": {
+            "post": {
+                "description": "Clear failed login attempts for a client IP and/or username, lifting any lockout",
                 "consumes": [
                     "application/json"
                 ],
@@ -498,17 +680,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Commands"
+                    "Auth"
                 ],
-                "summary": "Execute a command",
+                "summary": "Unlock a client IP or username",
                 "parameters": [
                     {
-                        "description": "Command execution request",
-                        "name": "command",
+                        "description": "IP and/or username to unlock",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.CommandExecution"
+                            "$ref": "#/definitions/server.UnlockRequest"
                         }
                     }
                 ],
@@ -516,38 +698,26 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.CommandResult"
+                            "$ref": "#/definitions/server.UnlockResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/env-variables": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all environment variables (values masked by default)",
+                ]
+            }
+        },
+        "/bash-scripts": {
+            "get": {
+                "description": "Get a list of all bash scripts (without content by default), optionally filtered, sorted and paged",
                 "consumes": [
                     "application/json"
                 ],
@@ -555,20 +725,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Environment Variables"
+                    "Bash Scripts"
                 ],
-                "summary": "List all environment variables",
+                "summary": "List all bash scripts",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "description": "Show actual values instead of masked values",
-                        "name": "show_values",
+                        "type": "string",
+                        "description": "Filter by group name",
+                        "name": "group",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Filter by group name",
-                        "name": "group",
+                        "description": "Case-insensitive text in the name, description, filename, content or tags",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only scripts with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by: name, group, filename, created_at, updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1; without page or per_page every item is returned",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page, 1 to 500 (default 50)",
+                        "name": "per_page",
                         "in": "query"
                     }
                 ],
@@ -578,25 +778,31 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.EnvVariableResponse"
+                                "$ref": "#/definitions/models.BashScriptResponse"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Create a new environment variable (stored encrypted)",
+                ]
+            },
+            "post": {
+                "description": "Create a new bash script (stored encrypted)",
                 "consumes": [
                     "application/json"
                 ],
@@ -604,17 +810,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Environment Variables"
+                    "Bash Scripts"
                 ],
-                "summary": "Create an environment variable",
+                "summary": "Create a bash script",
                 "parameters": [
                     {
-                        "description": "Environment variable to create",
-                        "name": "envvar",
+                        "description": "Bash script to create",
+                        "name": "script",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.EnvVariableCreate"
+                            "$ref": "#/definitions/models.BashScriptCreate"
                         }
                     }
                 ],
@@ -622,32 +828,32 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.EnvVariableResponse"
+                            "$ref": "#/definitions/models.BashScriptResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/env-variables/groups": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all distinct group names for environment variables",
+                ]
+            }
+        },
+        "/bash-scripts/execute": {
+            "post": {
+                "description": "Execute a stored bash script locally or remotely. With dry_run the script's syntax is only checked with its interpreter (bash -n, python3 -m py_compile, node --check), on the target server for remote runs, and the result lists the env vars that would be injected; nothing is executed, approved or recorded in history.",
                 "consumes": [
                     "application/json"
                 ],
@@ -655,36 +861,113 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Environment Variables"
+                    "Bash Scripts"
+                ],
+                "summary": "Execute a bash script",
+                "parameters": [
+                    {
+                        "description": "Script execution request",
+                        "name": "execution",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptExecution"
+                        }
+                    }
                 ],
-                "summary": "List all environment variable groups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.ScriptResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/env-variables/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
+                ]
+            }
+        },
+        "/bash-scripts/execute/stream": {
+            "post": {
+                "description": "Execute a stored bash script locally or remotely with real-time output streaming via SSE",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Get a single environment variable by its ID",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Bash Scripts"
+                ],
+                "summary": "Execute a bash script with streaming output",
+                "parameters": [
+                    {
+                        "description": "Script execution request",
+                        "name": "execution",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptExecution"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.StreamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/bash-scripts/groups": {
+            "get": {
+                "description": "Get a list of all distinct group names for bash scripts",
                 "consumes": [
                     "application/json"
                 ],
@@ -692,21 +975,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Environment Variables"
+                    "Bash Scripts"
                 ],
-                "summary": "Get an environment variable by ID",
+                "summary": "List all bash script groups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/bash-scripts/{id}": {
+            "get": {
+                "description": "Get a single bash script by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bash Scripts"
+                ],
+                "summary": "Get a bash script by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Environment Variable ID",
+                        "description": "Bash Script ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "boolean",
-                        "description": "Show actual value instead of masked value",
-                        "name": "show_value",
+                        "default": true,
+                        "description": "Include script content in response",
+                        "name": "include_content",
                         "in": "query"
                     }
                 ],
@@ -714,30 +1035,30 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.EnvVariableResponse"
+                            "$ref": "#/definitions/models.BashScriptResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Update an existing environment variable by its ID",
+                ]
+            },
+            "put": {
+                "description": "Update an existing bash script by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -745,24 +1066,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Environment Variables"
+                    "Bash Scripts"
                 ],
-                "summary": "Update an environment variable",
+                "summary": "Update a bash script",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Environment Variable ID",
+                        "description": "Bash Script ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Environment variable update data",
-                        "name": "envvar",
+                        "description": "Bash script update data",
+                        "name": "script",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.EnvVariableUpdate"
+                            "$ref": "#/definitions/models.BashScriptUpdate"
                         }
                     }
                 ],
@@ -770,30 +1091,36 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.EnvVariableResponse"
+                            "$ref": "#/definitions/models.BashScriptResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Delete an environment variable by its ID",
+                ]
+            },
+            "delete": {
+                "description": "Delete a bash script by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -801,13 +1128,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Environment Variables"
+                    "Bash Scripts"
                 ],
-                "summary": "Delete an environment variable",
+                "summary": "Delete a bash script",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Environment Variable ID",
+                        "description": "Bash Script ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -820,46 +1147,32 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "Check if the server is running and responsive. This endpoint does not require authentication.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "System"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.HealthResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/history": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get command execution history with optional filtering",
+                ]
+            }
+        },
+        "/bash-scripts/{id}/diff": {
+            "get": {
+                "description": "GET: unified diff from an earlier version to the current content.\nPOST: unified diff from the current content to proposed changes, to review an edit before saving it. Fields left empty are unchanged, as with PUT /bash-scripts/{id}",
                 "consumes": [
                     "application/json"
                 ],
@@ -867,51 +1180,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Command History"
+                    "Bash Scripts"
                 ],
-                "summary": "List command history",
+                "summary": "Diff a bash script",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Filter by server name",
-                        "name": "server",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Bash Script ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "default": 100,
-                        "description": "Maximum number of records to return",
-                        "name": "limit",
+                        "description": "Version to diff from (GET, required)",
+                        "name": "version",
                         "in": "query"
+                    },
+                    {
+                        "description": "Proposed changes (POST)",
+                        "name": "script",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.BashScriptUpdate"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.CommandHistory"
-                            }
+                            "$ref": "#/definitions/models.BashScriptDiff"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/history/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a single command history entry by its ID",
+                ]
+            },
+            "post": {
+                "description": "GET: unified diff from an earlier version to the current content.\nPOST: unified diff from the current content to proposed changes, to review an edit before saving it. Fields left empty are unchanged, as with PUT /bash-scripts/{id}",
                 "consumes": [
                     "application/json"
                 ],
@@ -919,91 +1241,131 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Command History"
+                    "Bash Scripts"
                 ],
-                "summary": "Get a command history entry by ID",
+                "summary": "Diff a bash script",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Command History ID",
+                        "description": "Bash Script ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to diff from (GET, required)",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Proposed changes (POST)",
+                        "name": "script",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.BashScriptUpdate"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.CommandHistory"
+                            "$ref": "#/definitions/models.BashScriptDiff"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/keys": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all SSH keys stored in the system",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/bash-scripts/{id}/migrate-to-vault": {
+            "post": {
+                "description": "Copy a bash script to Vault under the same group and name and delete it, with its version history, from the database",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "SSH Keys"
+                    "Bash Scripts"
                 ],
-                "summary": "List all SSH keys",
+                "summary": "Move bash script to Vault",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Filter by group name",
-                        "name": "group",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Bash Script ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SSHKey"
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Store a new SSH private key in the system",
+                ]
+            }
+        },
+        "/bash-scripts/{id}/presets": {
+            "get": {
+                "description": "Get all presets for a specific bash script",
                 "consumes": [
                     "application/json"
                 ],
@@ -1011,271 +1373,283 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "SSH Keys"
+                    "Script Presets"
                 ],
-                "summary": "Create a new SSH key",
+                "summary": "Get presets for a script",
                 "parameters": [
                     {
-                        "description": "SSH Key to create",
-                        "name": "key",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SSHKeyCreate"
-                        }
+                        "type": "integer",
+                        "description": "Bash Script ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SSHKey"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ScriptPresetResponse"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/keys/groups": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all distinct group names for SSH keys",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/bash-scripts/{id}/versions": {
+            "get": {
+                "description": "List the version history of a bash script, newest first. Content is not included",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "SSH Keys"
+                    "Bash Scripts"
+                ],
+                "summary": "List bash script versions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Bash Script ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List all SSH key groups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/models.BashScriptVersion"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/keys/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a single SSH key by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/bash-scripts/{id}/versions/{version}": {
+            "get": {
+                "description": "Get one version of a bash script, including its content",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "SSH Keys"
+                    "Bash Scripts"
                 ],
-                "summary": "Get an SSH key by ID",
+                "summary": "Get a bash script version",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "SSH Key ID",
+                        "description": "Bash Script ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version number",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SSHKey"
+                            "$ref": "#/definitions/models.BashScriptVersion"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Update an existing SSH key by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/bash-scripts/{id}/versions/{version}/diff": {
+            "get": {
+                "description": "Unified diff of the content from one version of a bash script to another (the latest by default)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "SSH Keys"
+                    "Bash Scripts"
                 ],
-                "summary": "Update an SSH key",
+                "summary": "Diff bash script versions",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "SSH Key ID",
+                        "description": "Bash Script ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "SSH Key update data",
-                        "name": "key",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SSHKeyUpdate"
-                        }
+                        "type": "integer",
+                        "description": "Version to diff from",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to diff to (default: latest)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SSHKey"
+                            "$ref": "#/definitions/models.BashScriptDiff"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Delete an SSH key by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/bash-scripts/{id}/versions/{version}/rollback": {
+            "post": {
+                "description": "Restore a bash script to an earlier version. The restored state is recorded as a new version",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "SSH Keys"
+                    "Bash Scripts"
                 ],
-                "summary": "Delete an SSH key",
+                "summary": "Roll back a bash script",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "SSH Key ID",
+                        "description": "Bash Script ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to restore",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BashScriptResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/local-users": {
-            "get": {
-                "security": [
-                    {
-                        "BasicAuth": []
-                    }
-                ],
-                "description": "Get a list of all local system users configured for command execution",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Local Users"
-                ],
-                "summary": "List all local users",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.LocalUser"
-                            }
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Add a new local system user for command execution",
+                ]
+            }
+        },
+        "/commands/execute": {
+            "post": {
+                "description": "Execute a shell command locally or remotely via SSH",
                 "consumes": [
                     "application/json"
                 ],
@@ -1283,50 +1657,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Local Users"
+                    "Commands"
                 ],
-                "summary": "Create a local user",
+                "summary": "Execute a command",
                 "parameters": [
                     {
-                        "description": "Local user to create",
-                        "name": "user",
+                        "description": "Command execution request",
+                        "name": "command",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.LocalUserCreate"
+                            "$ref": "#/definitions/models.CommandExecution"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.LocalUser"
+                            "$ref": "#/definitions/models.CommandResult"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/local-users/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a single local user by its ID",
+                ]
+            }
+        },
+        "/env-variables": {
+            "get": {
+                "description": "Get a list of all environment variables (values masked by default), optionally filtered, sorted and paged",
                 "consumes": [
                     "application/json"
                 ],
@@ -1334,46 +1714,84 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Local Users"
+                    "Environment Variables"
                 ],
-                "summary": "Get a local user by ID",
+                "summary": "List all environment variables",
                 "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Show actual values instead of masked values",
+                        "name": "show_values",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text in the name or description",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by: name, group, created_at, updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Local User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Page number, from 1; without page or per_page every item is returned",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page, 1 to 500 (default 50)",
+                        "name": "per_page",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.LocalUser"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EnvVariableResponse"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Update an existing local user by its ID",
+                ]
+            },
+            "post": {
+                "description": "Create a new environment variable (stored encrypted)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1381,55 +1799,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Local Users"
+                    "Environment Variables"
                 ],
-                "summary": "Update a local user",
+                "summary": "Create an environment variable",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Local User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Local user update data",
-                        "name": "user",
+                        "description": "Environment variable to create",
+                        "name": "envvar",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.LocalUserUpdate"
+                            "$ref": "#/definitions/models.EnvVariableCreate"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.LocalUser"
+                            "$ref": "#/definitions/models.EnvVariableResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Delete a local user by its ID",
+                ]
+            }
+        },
+        "/env-variables/batch": {
+            "post": {
+                "description": "Apply several environment variable operations in one transaction. Every operation is validated first; if any is invalid or fails, none is applied. The response reports each operation's outcome, with values masked.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1437,45 +1850,107 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Local Users"
+                    "Environment Variables"
                 ],
-                "summary": "Delete a local user",
+                "summary": "Create, update and delete environment variables in one batch",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Local User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Operations to apply",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EnvVariableBatchRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
             }
         },
-        "/saved-commands": {
+        "/env-variables/export": {
             "get": {
+                "description": "Render environment variables, including ones stored in Vault, in dotenv format with decrypted values, sorted by group and name. Requires admin access; every export is audited. With masked=true values are replaced by a mask so only the names are shared.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Environment Variables"
+                ],
+                "summary": "Export environment variables as a .env file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only export variables in this group",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace values with a mask",
+                        "name": "masked",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": ".env file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all saved command templates",
+                ]
+            }
+        },
+        "/env-variables/groups": {
+            "get": {
+                "description": "Get a list of all distinct group names for environment variables",
                 "consumes": [
                     "application/json"
                 ],
@@ -1483,34 +1958,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Saved Commands"
+                    "Environment Variables"
                 ],
-                "summary": "List all saved commands",
+                "summary": "List all environment variable groups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SavedCommand"
+                                "type": "string"
                             }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Create a new saved command template",
+                ]
+            }
+        },
+        "/env-variables/import": {
+            "post": {
+                "description": "Parse dotenv-format text and create its variables in one group. Variables whose name already exists are skipped. Every line is validated before anything is written; with dry_run the result only previews what would be created and skipped.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1518,50 +1995,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Saved Commands"
+                    "Environment Variables"
                 ],
-                "summary": "Create a saved command",
+                "summary": "Import environment variables from a .env file",
                 "parameters": [
                     {
-                        "description": "Saved command to create",
-                        "name": "command",
+                        "description": ".env file contents and target group",
+                        "name": "import",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SavedCommandCreate"
+                            "$ref": "#/definitions/models.EnvVariableImport"
                         }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Dry run, or nothing to create",
+                        "schema": {
+                            "$ref": "#/definitions/models.EnvVariableImportResult"
+                        }
+                    },
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SavedCommand"
+                            "$ref": "#/definitions/models.EnvVariableImportResult"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/saved-commands/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a single saved command template by its ID",
+                ]
+            }
+        },
+        "/env-variables/{id}": {
+            "get": {
+                "description": "Get a single environment variable by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1569,46 +2058,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Saved Commands"
+                    "Environment Variables"
                 ],
-                "summary": "Get a saved command by ID",
+                "summary": "Get an environment variable by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Saved Command ID",
+                        "description": "Environment Variable ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Show actual value instead of masked value",
+                        "name": "show_value",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SavedCommand"
+                            "$ref": "#/definitions/models.EnvVariableResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Update an existing saved command template by its ID",
+                ]
+            },
+            "put": {
+                "description": "Update an existing environment variable by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1616,24 +2111,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Saved Commands"
+                    "Environment Variables"
                 ],
-                "summary": "Update a saved command",
+                "summary": "Update an environment variable",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Saved Command ID",
+                        "description": "Environment Variable ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Saved command update data",
-                        "name": "command",
+                        "description": "Environment variable update data",
+                        "name": "envvar",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SavedCommandUpdate"
+                            "$ref": "#/definitions/models.EnvVariableUpdate"
                         }
                     }
                 ],
@@ -1641,30 +2136,30 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.SavedCommand"
+                            "$ref": "#/definitions/models.EnvVariableResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Delete a saved command template by its ID",
+                ]
+            },
+            "delete": {
+                "description": "Delete an environment variable by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1672,13 +2167,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Saved Commands"
+                    "Environment Variables"
                 ],
-                "summary": "Delete a saved command",
+                "summary": "Delete an environment variable",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Saved Command ID",
+                        "description": "Environment Variable ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1691,229 +2186,233 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/script-presets": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all script execution presets",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/env-variables/{id}/migrate-to-vault": {
+            "post": {
+                "description": "Copy an environment variable to Vault under the same group and name and delete it from the database",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Script Presets"
+                    "Environment Variables"
                 ],
-                "summary": "List all script presets",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptPresetResponse"
+                "summary": "Move environment variable to Vault",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Environment Variable ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Create a new script execution preset",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Stream server-sent events as executions start and finish, terminal sessions open and close and configuration changes, so dashboards update without polling. Each event is a data line holding an ActivityEvent; idle streams get a comment every 30 seconds. A client that falls behind by more than 64 events misses the excess. Requires admin access.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "Script Presets"
-                ],
-                "summary": "Create a script preset",
-                "parameters": [
-                    {
-                        "description": "Script preset to create",
-                        "name": "preset",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptPresetCreate"
-                        }
-                    }
+                    "System"
                 ],
+                "summary": "Stream live activity",
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptPresetResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/models.ActivityEvent"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/script-presets/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a single script preset by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/executions/locks": {
+            "get": {
+                "description": "List the exclusive scripts running now, with who started them, where and since when",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Script Presets"
-                ],
-                "summary": "Get a script preset by ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Script Preset ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "System"
                 ],
+                "summary": "List held script locks",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptPresetResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/executor.LockInfo"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Update an existing script preset by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/executions/queue": {
+            "get": {
+                "description": "Report the commands and scripts running and waiting for a slot, overall and per server, with the configured limits",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Script Presets"
+                    "System"
                 ],
-                "summary": "Update a script preset",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Script Preset ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                "summary": "Get the execution queue",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/executor.QueueStats"
+                        }
                     },
-                    {
-                        "description": "Script preset update data",
-                        "name": "preset",
-                        "in": "body",
-                        "required": true,
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptPresetUpdate"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/executions/running": {
+            "get": {
+                "description": "List the commands and scripts (including streaming and scheduled runs) running now, oldest first, with their target, user, who started them and for how long they have run. Executions waiting for a slot are not listed; see /executions/queue. Requires admin access.",
+                "produces": [
+                    "application/json"
                 ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "List running executions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptPresetResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RunningExecution"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Delete a script preset by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/executions/running/{id}": {
+            "delete": {
+                "description": "Cancel a running command or script, killing its process or closing its SSH session. The execution ends as failed and is recorded in history with what it printed so far. Requires admin access.",
                 "tags": [
-                    "Script Presets"
+                    "System"
                 ],
-                "summary": "Delete a script preset",
+                "summary": "Cancel a running execution",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Script Preset ID",
+                        "description": "Running execution ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1926,171 +2425,160 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/servers": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all remote servers configured in the system",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/export": {
+            "get": {
+                "description": "Export all servers, SSH keys, environment variables, bash scripts, script presets and saved commands as one file encrypted with the passphrase in the X-Export-Passphrase header (at least 12 characters). Secrets are included, decrypted from this instance and encrypted with the passphrase.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Servers"
+                    "System"
                 ],
-                "summary": "List all servers",
+                "summary": "Export configuration",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter by group name",
-                        "name": "group",
-                        "in": "query"
+                        "description": "Passphrase to encrypt the export with",
+                        "name": "X-Export-Passphrase",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Encrypted export file",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.Server"
-                            }
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Add a new remote server configuration",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/favorites": {
+            "get": {
+                "description": "List the scripts, saved commands and servers the caller marked as favorites, newest first. Items that were deleted or are in groups the caller may no longer view are left out.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Servers"
+                    "Favorites"
                 ],
-                "summary": "Create a new server",
+                "summary": "List the caller's favorites",
                 "parameters": [
                     {
-                        "description": "Server to create",
-                        "name": "server",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ServerCreate"
-                        }
+                        "enum": [
+                            "bash_scripts",
+                            "saved_commands",
+                            "servers"
+                        ],
+                        "type": "string",
+                        "description": "Only favorites of this type",
+                        "name": "type",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.Server"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Favorite"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/servers/groups": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of all distinct group names for servers",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Servers"
-                ],
-                "summary": "List all server groups",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
-                        }
-                    }
-                }
+                ]
             }
         },
-        "/servers/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BasicAuth": []
-                    }
-                ],
-                "description": "Get a single server configuration by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+        "/favorites/{type}/{id}": {
+            "put": {
+                "description": "Mark a script, saved command or server as a favorite of the caller. Adding an existing favorite returns it unchanged.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Servers"
+                    "Favorites"
                 ],
-                "summary": "Get a server by ID",
+                "summary": "Add a favorite",
                 "parameters": [
+                    {
+                        "enum": [
+                            "bash_scripts",
+                            "saved_commands",
+                            "servers"
+                        ],
+                        "type": "string",
+                        "description": "Item type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
-                        "description": "Server ID",
+                        "description": "Item ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -2100,100 +2588,56 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.Server"
+                            "$ref": "#/definitions/models.Favorite"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
-                        }
-                    }
-                }
-            },
-            "put": {
-                "security": [
-                    {
-                        "BasicAuth": []
-                    }
-                ],
-                "description": "Update an existing server configuration by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Servers"
-                ],
-                "summary": "Update a server",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Server ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Server update data",
-                        "name": "server",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ServerUpdate"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.Server"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Delete a server configuration by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                ]
+            },
+            "delete": {
+                "description": "Remove a script, saved command or server from the caller's favorites",
                 "tags": [
-                    "Servers"
+                    "Favorites"
                 ],
-                "summary": "Delete a server",
+                "summary": "Remove a favorite",
                 "parameters": [
+                    {
+                        "enum": [
+                            "bash_scripts",
+                            "saved_commands",
+                            "servers"
+                        ],
+                        "type": "string",
+                        "description": "Item type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
-                        "description": "Server ID",
+                        "description": "Item ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -2206,129 +2650,136 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/system/current-user": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get information about the current system user running the server",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/git-sync": {
+            "get": {
+                "description": "Report the Git sync configuration and the outcome of the last sync of bash scripts",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Bash Scripts"
                 ],
-                "summary": "Get current system user",
+                "summary": "Get Git sync status",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.CurrentUserResponse"
+                            "$ref": "#/definitions/server.GitSyncStatus"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/system/shells": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Get a list of shells that are installed and available on the system",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            },
+            "post": {
+                "description": "Pull the Git repository and update the synced bash scripts without waiting for the next interval",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Bash Scripts"
                 ],
-                "summary": "List available shells",
+                "summary": "Sync scripts from Git now",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/internal_server.ShellInfo"
-                            }
+                            "$ref": "#/definitions/server.GitSyncStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/server.GitSyncStatus"
                         }
                     }
-                }
-            }
-        },
-        "/vault/bash-scripts": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Retrieve all bash scripts stored in Vault",
+                ]
+            }
+        },
+        "/group-permissions": {
+            "get": {
+                "description": "List the users, tokens and roles granted access to restricted resource groups",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "Group Permissions"
                 ],
-                "summary": "List bash scripts from Vault",
+                "summary": "List group permissions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_vault.BashScript"
+                                "$ref": "#/definitions/models.GroupPermission"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Create a new bash script in HashiCorp Vault storage",
+                ]
+            },
+            "post": {
+                "description": "Grant a user (\"user:\u003cname\u003e\"), API token (\"token:\u003cname\u003e\") or role (\"role:\u003crole\u003e\") view or execute access to a resource group. Once a group has a permission, it is hidden from everyone else except admins.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2336,435 +2787,647 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "Group Permissions"
                 ],
-                "summary": "Store bash script in Vault",
+                "summary": "Grant a group permission",
                 "parameters": [
                     {
-                        "description": "Bash script data",
-                        "name": "script",
+                        "description": "Permission to grant",
+                        "name": "permission",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/models.GroupPermissionCreate"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created script info with source field",
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.GroupPermission"
                         }
                     },
                     "400": {
-                        "description": "Invalid request or Vault not configured",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Failed to store script",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/vault/config": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Retrieve the current Vault configuration (token is never returned)",
-                "produces": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/group-permissions/{id}": {
+            "delete": {
+                "description": "Delete a group permission. A group left without permissions is open to everyone again.",
                 "tags": [
-                    "Vault"
+                    "Group Permissions"
+                ],
+                "summary": "Revoke a group permission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Group Permission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get Vault configuration",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.VaultConfigResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Configure or update the Vault connection settings",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Report the health of the server and its components (database, encryption key, Vault, disk space, last database maintenance). Returns 503 when a required component is failing. This endpoint does not require authentication.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
-                ],
-                "summary": "Create or update Vault configuration",
-                "parameters": [
-                    {
-                        "description": "Vault configuration",
-                        "name": "config",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.VaultConfigCreate"
-                        }
-                    }
+                    "System"
                 ],
+                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.VaultConfigResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.HealthResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.HealthResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BasicAuth": []
-                    }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Report that the server process is running. Served at /healthz outside the /api prefix and never requires authentication or HTTPS.",
+                "produces": [
+                    "application/json"
                 ],
-                "description": "Remove the Vault configuration",
                 "tags": [
-                    "Vault"
+                    "System"
                 ],
-                "summary": "Delete Vault configuration",
+                "summary": "Liveness probe",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ComponentHealth"
                         }
                     }
                 }
             }
         },
-        "/vault/env-variables": {
+        "/history": {
             "get": {
-                "security": [
-                    {
-                        "BasicAuth": []
-                    }
+                "description": "Get command execution history with optional filtering, newest first. Filters combine: only entries matching all of them are returned.",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Retrieve all environment variables stored in Vault",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "Command History"
+                ],
+                "summary": "List command history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by server name",
+                        "name": "server",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only runs of this script",
+                        "name": "script_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only runs started from this script preset",
+                        "name": "preset_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only runs of this saved command",
+                        "name": "saved_command_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only runs that exited with this code",
+                        "name": "exit_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only runs as this user",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only runs at or after this time (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only runs before this time (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of records to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove terminal color and cursor codes from the outputs",
+                        "name": "strip_ansi",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List environment variables from Vault",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_vault.EnvVariable"
+                                "$ref": "#/definitions/models.CommandHistory"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Store a new environment variable in Vault",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/history/suggest": {
+            "get": {
+                "description": "Complete a command from the caller's previous commands: the distinct commands starting with prefix among the caller's latest 1000, most run first. Script executions are left out.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "Command History"
                 ],
-                "summary": "Create environment variable in Vault",
+                "summary": "Suggest commands from history",
                 "parameters": [
                     {
-                        "description": "Environment Variable",
-                        "name": "envVar",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "description": {
-                                    "type": "string"
-                                },
-                                "group": {
-                                    "type": "string"
-                                },
-                                "name": {
-                                    "type": "string"
-                                },
-                                "value": {
-                                    "type": "string"
-                                }
-                            }
-                        }
+                        "type": "string",
+                        "description": "Start of the command being typed; empty for the most run commands",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of suggestions, up to 50",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "description": {
-                                    "type": "string"
-                                },
-                                "group": {
-                                    "type": "string"
-                                },
-                                "name": {
-                                    "type": "string"
-                                },
-                                "source": {
-                                    "type": "string"
-                                }
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CommandSuggestion"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/vault/servers": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
+                ]
+            }
+        },
+        "/history/{id}": {
+            "get": {
+                "description": "Get a single command history entry by its ID",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Retrieve all server configurations stored in Vault",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "Command History"
+                ],
+                "summary": "Get a command history entry by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command History ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove terminal color and cursor codes from the output",
+                        "name": "strip_ansi",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List servers from Vault",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_vault.Server"
-                            }
+                            "$ref": "#/definitions/models.CommandHistory"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Store a new server configuration in Vault",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/history/{id}/log": {
+            "get": {
+                "description": "Download the full output of an execution as a gzip-compressed text file, including outputs cut to OUTPUT_MAX_SIZE in history and API responses",
                 "produces": [
-                    "application/json"
+                    "application/gzip"
                 ],
                 "tags": [
-                    "Vault"
+                    "Command History"
                 ],
-                "summary": "Create server in Vault",
+                "summary": "Download the full output of a command history entry",
                 "parameters": [
                     {
-                        "description": "Server",
-                        "name": "server",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Command History ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove terminal color and cursor codes from the output",
+                        "name": "strip_ansi",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "group": {
-                                    "type": "string"
-                                },
-                                "ip_address": {
-                                    "type": "string"
-                                },
-                                "name": {
-                                    "type": "string"
-                                },
-                                "port": {
-                                    "type": "integer"
-                                },
-                                "username": {
-                                    "type": "string"
-                                }
-                            }
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/import": {
+            "post": {
+                "description": "Import an export file, decrypted with the passphrase in the X-Export-Passphrase header. Items are matched by name (servers without a name by IP address); the strategy decides what happens when one already exists: skip keeps it, overwrite replaces its fields, rename imports the item under a new name. Scripts managed by Git sync are never overwritten.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Import configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passphrase the export was encrypted with",
+                        "name": "X-Export-Passphrase",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Conflict strategy: skip (default), overwrite or rename",
+                        "name": "strategy",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Export file",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "group": {
-                                    "type": "string"
-                                },
-                                "ip_address": {
-                                    "type": "string"
-                                },
-                                "name": {
-                                    "type": "string"
-                                },
-                                "port": {
-                                    "type": "integer"
-                                },
-                                "source": {
-                                    "type": "string"
-                                },
-                                "username": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/bundle.Result"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
             }
         },
-        "/vault/ssh-keys": {
+        "/inventory": {
             "get": {
+                "description": "Report the configured cloud providers and the outcome of the last inventory sync of servers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Get cloud inventory status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.InventoryStatus"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
+                ]
+            },
+            "post": {
+                "description": "Discover the instances of the configured cloud providers and update their servers without waiting for the next interval",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Sync servers from cloud providers now",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.InventoryStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/server.InventoryStatus"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/keys": {
+            "get": {
+                "description": "Get a list of all SSH keys stored in the system, optionally filtered, sorted and paged",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Retrieve all SSH keys stored in Vault",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "SSH Keys"
+                ],
+                "summary": "List all SSH keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text in the name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by: name, group, last_used_at, use_count, created_at, updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1; without page or per_page every item is returned",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page, 1 to 500 (default 50)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List SSH keys from Vault",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_pozgo_web-cli_internal_vault.SSHKey"
+                                "$ref": "#/definitions/models.SSHKey"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the next and previous pages, when paged"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching items"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Store a new SSH key in Vault",
+                ]
+            },
+            "post": {
+                "description": "Store a new SSH private key in the system",
                 "consumes": [
                     "application/json"
                 ],
@@ -2772,28 +3435,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "SSH Keys"
                 ],
-                "summary": "Create SSH key in Vault",
+                "summary": "Create a new SSH key",
                 "parameters": [
                     {
-                        "description": "SSH Key",
+                        "description": "SSH Key to create",
                         "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "group": {
-                                    "type": "string"
-                                },
-                                "name": {
-                                    "type": "string"
-                                },
-                                "private_key": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/models.SSHKeyCreate"
                         }
                     }
                 ],
@@ -2801,181 +3453,9557 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "created_at": {
-                                    "type": "string"
-                                },
-                                "group": {
-                                    "type": "string"
-                                },
-                                "name": {
-                                    "type": "string"
-                                },
-                                "source": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/models.SSHKey"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/vault/status": {
-            "get": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
+                ]
+            }
+        },
+        "/keys/groups": {
+            "get": {
+                "description": "Get a list of all distinct group names for SSH keys",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Check the current Vault connection status",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "SSH Keys"
                 ],
-                "summary": "Get Vault connection status",
+                "summary": "List all SSH key groups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.VaultStatus"
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/vault/test": {
-            "post": {
+                },
                 "security": [
                     {
                         "BasicAuth": []
                     }
-                ],
-                "description": "Test the connection to Vault and initialize the secrets structure",
-                "produces": [
-                    "application/json"
+                ]
+            }
+        },
+        "/keys/{id}": {
+            "get": {
+                "description": "Get a single SSH key by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
                 ],
                 "tags": [
-                    "Vault"
+                    "SSH Keys"
+                ],
+                "summary": "Get an SSH key by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "SSH Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SSHKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update an existing SSH key by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SSH Keys"
+                ],
+                "summary": "Update an SSH key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "SSH Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SSH Key update data",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SSHKeyUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SSHKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete an SSH key by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SSH Keys"
+                ],
+                "summary": "Delete an SSH key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "SSH Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/keys/{id}/migrate-to-vault": {
+            "post": {
+                "description": "Copy an SSH key to Vault under the same group and name and delete it from the database",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SSH Keys"
+                ],
+                "summary": "Move SSH key to Vault",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "SSH Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Test Vault connection",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.VaultStatus"
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/internal_server.ErrorResponse"
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/kubernetes/namespaces": {
+            "get": {
+                "description": "List the namespaces of the configured cluster whose pods the caller may view (pods group permissions use the namespace as group)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Kubernetes"
+                ],
+                "summary": "List Kubernetes namespaces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/kubernetes/pods": {
+            "get": {
+                "description": "List the pods of a namespace that commands and scripts can run in, sorted by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Kubernetes"
+                ],
+                "summary": "List Kubernetes pods",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace (default: the namespace of the configured context)",
+                        "name": "namespace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label selector, such as app=web",
+                        "name": "selector",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.KubernetesPod"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/local-users": {
+            "get": {
+                "description": "Get a list of all local system users configured for command execution",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Local Users"
+                ],
+                "summary": "List all local users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LocalUser"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Add a new local system user for command execution",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Local Users"
+                ],
+                "summary": "Create a local user",
+                "parameters": [
+                    {
+                        "description": "Local user to create",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LocalUserCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.LocalUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/local-users/import": {
+            "post": {
+                "description": "Add login users discovered from /etc/passwd (see GET /system/users) as local users. Names that are not discovered login users are rejected; users already listed are skipped. An empty list imports every discovered user. Requires admin access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Local Users"
+                ],
+                "summary": "Import login users as local users",
+                "parameters": [
+                    {
+                        "description": "Login users to import",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.LocalUserImportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LocalUserImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/local-users/{id}": {
+            "get": {
+                "description": "Get a single local user by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Local Users"
+                ],
+                "summary": "Get a local user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Local User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LocalUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update an existing local user by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Local Users"
+                ],
+                "summary": "Update a local user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Local User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Local user update data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LocalUserUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LocalUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a local user by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Local Users"
+                ],
+                "summary": "Delete a local user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Local User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/local-users/{id}/test-sudo": {
+            "post": {
+                "description": "Check that local commands can run as a local user through sudo before real commands fail: runs true with sudo -n (passwordless), and with sudo -S when a password is given. Only true is run. Requires admin access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Local Users"
+                ],
+                "summary": "Test sudo for a local user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Local User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Sudo password to check",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/server.SudoTestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.SudoTestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/maintenance": {
+            "get": {
+                "description": "Report whether the server is in read-only (maintenance) mode",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Get read-only mode",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.MaintenanceStatus"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Turn read-only (maintenance) mode on or off. While on, command, script and terminal execution and all changes are rejected with 503; reads keep working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Set read-only mode",
+                "parameters": [
+                    {
+                        "description": "Read-only mode",
+                        "name": "mode",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.MaintenanceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.MaintenanceStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/maintenance-windows": {
+            "get": {
+                "description": "List the maintenance windows blocking executions on the servers of a group, with whether each is in effect now",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance Windows"
+                ],
+                "summary": "List maintenance windows",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MaintenanceWindow"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a window during which commands and scripts on the servers of a group are rejected with 423 and scheduled presets are skipped. A recurring window starts whenever its cron schedule fires and lasts duration_minutes; a one-off window lasts from starts_at until ends_at. A server group of * covers every server and the local machine.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance Windows"
+                ],
+                "summary": "Create a maintenance window",
+                "parameters": [
+                    {
+                        "description": "Window to create",
+                        "name": "window",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MaintenanceWindowCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.MaintenanceWindow"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/maintenance-windows/{id}": {
+            "get": {
+                "description": "Get a maintenance window by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance Windows"
+                ],
+                "summary": "Get a maintenance window",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maintenance Window ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MaintenanceWindow"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update a maintenance window's timing, server group or enabled state. Setting a schedule turns a one-off window into a recurring one, and setting starts_at or ends_at does the opposite.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance Windows"
+                ],
+                "summary": "Update a maintenance window",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maintenance Window ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "window",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MaintenanceWindowUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MaintenanceWindow"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a maintenance window, ending it at once if it is in effect",
+                "tags": [
+                    "Maintenance Windows"
+                ],
+                "summary": "Delete a maintenance window",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maintenance Window ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/notification-rules": {
+            "get": {
+                "description": "List the rules that send matching command and script executions to webhooks, Slack or email",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification Rules"
+                ],
+                "summary": "List notification rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.NotificationRule"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a rule evaluated after every execution. Every condition that is set must match; channels are webhook:\u003cname\u003e, slack, slack:\u003cchannel\u003e, email or email:\u003caddress\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification Rules"
+                ],
+                "summary": "Create a notification rule",
+                "parameters": [
+                    {
+                        "description": "Rule to create",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationRuleCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/notification-rules/{id}": {
+            "get": {
+                "description": "Get a notification rule by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification Rules"
+                ],
+                "summary": "Get a notification rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update a notification rule's conditions, channels or enabled state",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification Rules"
+                ],
+                "summary": "Update a notification rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationRuleUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a notification rule",
+                "tags": [
+                    "Notification Rules"
+                ],
+                "summary": "Delete a notification rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/openapi.json": {
+            "get": {
+                "description": "Get the Swagger 2.0 specification of this API, generated from the handler annotations at build time. The host and base path are those of the running instance, so the spec can be loaded into API clients as is.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Get the OpenAPI specification",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/policies": {
+            "get": {
+                "description": "List the allow and deny rules evaluated before commands and scripts are executed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Command Policies"
+                ],
+                "summary": "List command policies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CommandPolicy"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create an allow or deny rule. Deny rules reject matching commands; allow rules restrict the callers they apply to to matching commands only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Command Policies"
+                ],
+                "summary": "Create a command policy",
+                "parameters": [
+                    {
+                        "description": "Policy to create",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandPolicyCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/policies/test": {
+            "post": {
+                "description": "Evaluate the enabled command policies against a command or stored script without executing it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Command Policies"
+                ],
+                "summary": "Test command policies",
+                "parameters": [
+                    {
+                        "description": "Command or script to evaluate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PolicyTestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PolicyDecision"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/policies/{id}": {
+            "get": {
+                "description": "Get a command policy by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Command Policies"
+                ],
+                "summary": "Get a command policy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update a command policy's rule, principal or enabled state",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Command Policies"
+                ],
+                "summary": "Update a command policy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandPolicyUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a command policy",
+                "tags": [
+                    "Command Policies"
+                ],
+                "summary": "Delete a command policy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/quotas": {
+            "get": {
+                "description": "List the quotas limiting the executions of users, tokens and roles",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Quotas"
+                ],
+                "summary": "List execution quotas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExecutionQuota"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a quota for user:\u003cname\u003e, token:\u003cname\u003e, role:\u003crole\u003e or * (everyone without a more specific quota). Limits of 0 are unlimited.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Quotas"
+                ],
+                "summary": "Create an execution quota",
+                "parameters": [
+                    {
+                        "description": "Quota to create",
+                        "name": "quota",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExecutionQuotaCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExecutionQuota"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/quotas/me": {
+            "get": {
+                "description": "Get the quota applying to the caller and its executions running now and started in the last hour",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Quotas"
+                ],
+                "summary": "Get the caller's execution quota",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.QuotaStatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/quotas/{id}": {
+            "put": {
+                "description": "Update the limits of an execution quota",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Quotas"
+                ],
+                "summary": "Update an execution quota",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quota ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Limits to update",
+                        "name": "quota",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExecutionQuotaUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExecutionQuota"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete an execution quota",
+                "tags": [
+                    "Quotas"
+                ],
+                "summary": "Delete an execution quota",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quota ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Report whether the server can serve requests (database reachable and encryption key loaded). Served at /readyz outside the /api prefix and never requires authentication or HTTPS.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/server.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recent": {
+            "get": {
+                "description": "List the scripts, saved commands and servers the caller ran most recently, derived from command history, with the number of executions and the outcome of the last one. A saved command run on a server lists both. Items that were deleted or are in groups the caller may no longer view are left out, as are executions recorded before recent items were tracked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "List the caller's recent items",
+                "parameters": [
+                    {
+                        "enum": [
+                            "bash_scripts",
+                            "saved_commands",
+                            "servers"
+                        ],
+                        "type": "string",
+                        "description": "Only items of this type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of items to return",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RecentItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/saved-commands": {
+            "get": {
+                "description": "Get a list of all saved command templates, optionally filtered by text and tag, sorted and paged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Commands"
+                ],
+                "summary": "List all saved commands",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text in the name, command, description or tags",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only commands with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by: name, created_at, updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1; without page or per_page every item is returned",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page, 1 to 500 (default 50)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SavedCommand"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a new saved command template",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Commands"
+                ],
+                "summary": "Create a saved command",
+                "parameters": [
+                    {
+                        "description": "Saved command to create",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedCommandCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedCommand"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/saved-commands/{id}": {
+            "get": {
+                "description": "Get a single saved command template by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Commands"
+                ],
+                "summary": "Get a saved command by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Saved Command ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedCommand"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update an existing saved command template by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Commands"
+                ],
+                "summary": "Update a saved command",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Saved Command ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Saved command update data",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedCommandUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedCommand"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a saved command template by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Commands"
+                ],
+                "summary": "Delete a saved command",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Saved Command ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/saved-commands/{id}/execute": {
+            "post": {
+                "description": "Run a saved command with its placeholders (names in double braces) filled in from values, else their declared defaults. Each value is single-quoted, so it reaches the command as one shell word. The command, user and target are the saved command's; other fields are execution options as for POST /commands/execute (sudo_password, ssh_password, stdin, workdir, retries, ...). The execution goes through the same checks, queue and history as POST /commands/execute, and history records the resolved command.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Commands"
+                ],
+                "summary": "Execute a saved command",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Saved Command ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Placeholder values and execution options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedCommandExecution"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/script-presets": {
+            "get": {
+                "description": "Get a list of all script execution presets, optionally filtered, sorted and paged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Script Presets"
+                ],
+                "summary": "List all script presets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text in the name or description",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by: name, created_at, updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1; without page or per_page every item is returned",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page, 1 to 500 (default 50)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ScriptPresetResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a new script execution preset. A preset with a schedule (a cron expression in server time) and schedule_enabled also runs on that schedule; setting a schedule requires admin access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Script Presets"
+                ],
+                "summary": "Create a script preset",
+                "parameters": [
+                    {
+                        "description": "Script preset to create",
+                        "name": "preset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptPresetCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptPresetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/script-presets/{id}": {
+            "get": {
+                "description": "Get a single script preset by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Script Presets"
+                ],
+                "summary": "Get a script preset by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Script Preset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptPresetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update an existing script preset by its ID. Changing schedule or schedule_enabled requires admin access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Script Presets"
+                ],
+                "summary": "Update a script preset",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Script Preset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Script preset update data",
+                        "name": "preset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptPresetUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptPresetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a script preset by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Script Presets"
+                ],
+                "summary": "Delete a script preset",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Script Preset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/server-groups": {
+            "get": {
+                "description": "List the server groups the caller can view, with their descriptions, default credentials and number of servers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "List server groups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ServerGroup"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a server group. Servers added to it without a username or port take its default_username and default_port, and executions on its servers that select no SSH key use its default_ssh_key_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Create a server group",
+                "parameters": [
+                    {
+                        "description": "Server group to create",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerGroupCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/server-groups/{id}": {
+            "get": {
+                "description": "Get a server group by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Get a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update a server group's name, description or default credentials. Renaming a group moves its servers and server group permissions along; the default group cannot be renamed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Update a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerGroupUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete an empty server group along with its server group permissions. The default group cannot be deleted.",
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Delete a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/server-groups/{id}/bash-scripts/execute": {
+            "post": {
+                "description": "Run a bash script on every server of the group at once, as POST /bash-scripts/execute would on each. server_id, server_name, server_group and server_ref are ignored. Each server counts as one execution against the caller's quota and reports the status and result or error it would have returned on its own.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Execute a script on a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Script execution request",
+                        "name": "script",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScriptExecution"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ServerGroupExecutionResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/server-groups/{id}/commands/execute": {
+            "post": {
+                "description": "Run a command on every server of the group at once, as POST /commands/execute would on each. server_id, server_name, server_group and server_ref are ignored. Each server counts as one execution against the caller's quota and reports the status and result or error it would have returned on its own.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Execute a command on a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Command execution request",
+                        "name": "command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CommandExecution"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ServerGroupExecutionResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/server-groups/{id}/servers": {
+            "get": {
+                "description": "List the servers in a server group",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "List the servers of a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Server"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Move servers into a server group. Either all of them are moved or none.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Add servers to a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Servers to add",
+                        "name": "members",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerGroupMembers"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Server"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/server-groups/{id}/servers/{serverId}": {
+            "delete": {
+                "description": "Move a server of the group back to the default group",
+                "tags": [
+                    "Server Groups"
+                ],
+                "summary": "Remove a server from a server group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "serverId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/servers": {
+            "get": {
+                "description": "Get a list of all remote servers configured in the system, optionally filtered, sorted and paged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "List all servers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text in the name, IP address or username",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by: name, ip_address, port, username, group, created_at, updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1; without page or per_page every item is returned",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page, 1 to 500 (default 50)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Server"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the next and previous pages, when paged"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching items"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Add a new remote server configuration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Create a new server",
+                "parameters": [
+                    {
+                        "description": "Server to create",
+                        "name": "server",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/servers/batch": {
+            "post": {
+                "description": "Apply several server operations in one transaction. Every operation is validated first; if any is invalid or fails, none is applied. The response reports each operation's outcome.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Create, update and delete servers in one batch",
+                "parameters": [
+                    {
+                        "description": "Operations to apply",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/servers/groups": {
+            "get": {
+                "description": "Get a list of all distinct group names for servers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "List all server groups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/servers/{id}": {
+            "get": {
+                "description": "Get a single server configuration by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Get a server by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update an existing server configuration by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Update a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Server update data",
+                        "name": "server",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a server configuration by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Delete a server",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/servers/{id}/migrate-to-vault": {
+            "post": {
+                "description": "Copy a server to Vault under the same group and name (or IP address) and delete it from the database",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Move server to Vault",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/servers/{id}/wake": {
+            "post": {
+                "description": "Send a Wake-on-LAN magic packet to the mac_address of a server. By default web-cli broadcasts it on its own network; with relay_server_id another server on the sleeping server's network sends it instead, running python3, wakeonlan or wol as a command execution with the same checks, history, rate limit and quota as POST /commands/execute. The body is optional. A sent packet does not mean the server woke up. Requires execute permission on the server's group.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Servers"
+                ],
+                "summary": "Wake a server with Wake-on-LAN",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Where to send the packet from and to",
+                        "name": "wake",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerWakeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ServerWakeResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/system/backup": {
+            "post": {
+                "description": "Download a consistent snapshot of the SQLite database, taken while the server keeps running. With the X-Backup-Passphrase header (at least 12 characters) the snapshot is encrypted with it. Secrets inside stay encrypted with this instance's key either way; keep the key with the backup.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Back up the database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passphrase to encrypt the backup with",
+                        "name": "X-Backup-Passphrase",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Database backup",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/system/current-user": {
+            "get": {
+                "description": "Get information about the current system user running the server",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Get current system user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CurrentUserResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/system/restore": {
+            "post": {
+                "description": "Replace the database with a backup taken by POST /system/backup or the backup command, while the server keeps running. Encrypted backups need the passphrase in the X-Backup-Passphrase header. Migrations the backup lacks are applied; if the backup cannot be opened the current database is kept. The backup must have been taken with the same encryption key.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Restore the database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passphrase the backup was encrypted with",
+                        "name": "X-Backup-Passphrase",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Database backup",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.RestoreResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/system/shells": {
+            "get": {
+                "description": "Get the shells from the configured catalog that are installed on the system, marking the caller's default shell",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "List available shells",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.ShellInfo"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/system/users": {
+            "get": {
+                "description": "Discover the login users of the machine from /etc/passwd, within the configured UID range (LOCAL_USER_MIN_UID to LOCAL_USER_MAX_UID) and without nologin or false shells, marking the ones already listed under local users. Requires admin access.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "List login users of the machine",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.SystemUserResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/terminal/files/{id}": {
+            "get": {
+                "description": "List the files in a terminal session's file exchange directory ($WEBCLI_FILES in the shell). Requires ownership of the session or admin access.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "List terminal session files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TerminalFile"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Upload a file (multipart field \"file\") into a terminal session's file exchange directory. An existing file with the same name is replaced. Requires ownership of the session or admin access.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "Upload a file into a terminal session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TerminalFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/terminal/files/{id}/{path}": {
+            "get": {
+                "description": "Download a file from a terminal session's file exchange directory. Paths are relative to the directory and cannot leave it. Requires ownership of the session or admin access.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "Download a file from a terminal session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File path relative to the directory",
+                        "name": "path",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/terminal/recordings": {
+            "get": {
+                "description": "List recorded terminal sessions, newest first. Empty when recording is disabled. Requires admin access.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "List terminal recordings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TerminalRecording"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/terminal/recordings/{id}": {
+            "get": {
+                "description": "Stream a recorded terminal session as an asciicast v2 file for playback. Recordings of running sessions can be fetched while they grow. Requires admin access.",
+                "produces": [
+                    "application/x-asciicast"
+                ],
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "Download a terminal recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recording ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "asciicast v2 recording",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/terminal/sessions": {
+            "get": {
+                "description": "List the interactive terminal sessions currently open, oldest first, with their owner, shell and target server. Requires admin access.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "List open terminal sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TerminalSession"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/terminal/sessions/{id}": {
+            "delete": {
+                "description": "Force-close an open terminal session, ending its shell and disconnecting the client. Requires admin access.",
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "Close a terminal session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/terminal/sessions/{id}/watch": {
+            "get": {
+                "description": "Attach a read-only WebSocket to an open terminal session. The observer receives the same output as the session owner; anything it sends is ignored. Requires admin access or ownership of the session.",
+                "tags": [
+                    "Terminal"
+                ],
+                "summary": "Watch a terminal session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/tokens": {
+            "get": {
+                "description": "List all API tokens (token secrets are never returned)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Tokens"
+                ],
+                "summary": "List API tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIToken"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a scoped bearer token for API clients. The token is only returned in this response; store it securely. Tokens with the agent scope need the server_id of the agent-transport server they connect as.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Tokens"
+                ],
+                "summary": "Create an API token",
+                "parameters": [
+                    {
+                        "description": "API token to create",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.APITokenCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APITokenCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/tokens/{id}": {
+            "get": {
+                "description": "Get a single API token by its ID (the token secret is never returned)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Tokens"
+                ],
+                "summary": "Get an API token by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Rename an API token, change its scopes or, for agent tokens, its server",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Tokens"
+                ],
+                "summary": "Update an API token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "API token update data",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.APITokenUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete an API token so it can no longer be used",
+                "tags": [
+                    "API Tokens"
+                ],
+                "summary": "Revoke an API token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/bash-scripts": {
+            "get": {
+                "description": "Retrieve all bash scripts stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "List bash scripts from Vault",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/vault.BashScript"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a new bash script in HashiCorp Vault storage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Store bash script in Vault",
+                "parameters": [
+                    {
+                        "description": "Bash script data",
+                        "name": "script",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created script info with source field",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or Vault not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "No view permission on the group",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to store script",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/bash-scripts/{group}/{name}": {
+            "get": {
+                "description": "Retrieve a single bash script stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get bash script from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vault.BashScript"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update a bash script stored in Vault. Only provided fields are changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Update bash script in Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bash script data",
+                        "name": "script",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "content": {
+                                    "type": "string"
+                                },
+                                "description": {
+                                    "type": "string"
+                                },
+                                "filename": {
+                                    "type": "string"
+                                },
+                                "interpreter": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated script info with source field",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Permanently delete a bash script and all its versions from Vault",
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Delete bash script from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/bash-scripts/{group}/{name}/migrate-to-sqlite": {
+            "post": {
+                "description": "Copy a bash script from Vault to the database and delete it from Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Move bash script from Vault to the database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BashScriptResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/config": {
+            "get": {
+                "description": "Retrieve the current Vault configuration (token is never returned)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get Vault configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VaultConfigResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Configure or update the Vault connection settings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Create or update Vault configuration",
+                "parameters": [
+                    {
+                        "description": "Vault configuration",
+                        "name": "config",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.VaultConfigCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VaultConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Remove the Vault configuration",
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Delete Vault configuration",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/env-variables": {
+            "get": {
+                "description": "Retrieve all environment variables stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "List environment variables from Vault",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/vault.EnvVariable"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Store a new environment variable in Vault",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Create environment variable in Vault",
+                "parameters": [
+                    {
+                        "description": "Environment Variable",
+                        "name": "envVar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "description": {
+                                    "type": "string"
+                                },
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "value": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "description": {
+                                    "type": "string"
+                                },
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/env-variables/{group}/{name}": {
+            "get": {
+                "description": "Retrieve a single environment variable stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get environment variable from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Environment variable name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vault.EnvVariable"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update an environment variable stored in Vault. Only provided fields are changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Update environment variable in Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Environment variable name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Environment Variable",
+                        "name": "envVar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "description": {
+                                    "type": "string"
+                                },
+                                "value": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "description": {
+                                    "type": "string"
+                                },
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Permanently delete an environment variable and all its versions from Vault",
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Delete environment variable from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Environment variable name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/env-variables/{group}/{name}/migrate-to-sqlite": {
+            "post": {
+                "description": "Copy an environment variable from Vault to the database and delete it from Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Move environment variable from Vault to the database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variable name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EnvVariableResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/servers": {
+            "get": {
+                "description": "Retrieve all server configurations stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "List servers from Vault",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/vault.Server"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Store a new server configuration in Vault",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Create server in Vault",
+                "parameters": [
+                    {
+                        "description": "Server",
+                        "name": "server",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "ip_address": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "port": {
+                                    "type": "integer"
+                                },
+                                "username": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "ip_address": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "port": {
+                                    "type": "integer"
+                                },
+                                "source": {
+                                    "type": "string"
+                                },
+                                "username": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/servers/{group}/{name}": {
+            "get": {
+                "description": "Retrieve a single server configuration stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get server from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Server name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vault.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update a server configuration stored in Vault. Only provided fields are changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Update server in Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Server name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Server",
+                        "name": "server",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "ip_address": {
+                                    "type": "string"
+                                },
+                                "port": {
+                                    "type": "integer"
+                                },
+                                "username": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "ip_address": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "port": {
+                                    "type": "integer"
+                                },
+                                "source": {
+                                    "type": "string"
+                                },
+                                "username": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Permanently delete a server configuration and all its versions from Vault",
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Delete server from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Server name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/servers/{group}/{name}/migrate-to-sqlite": {
+            "post": {
+                "description": "Copy a server from Vault to the database and delete it from Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Move server from Vault to the database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Server name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Server"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/ssh-keys": {
+            "get": {
+                "description": "Retrieve all SSH keys stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "List SSH keys from Vault",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/vault.SSHKey"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Store a new SSH key in Vault",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Create SSH key in Vault",
+                "parameters": [
+                    {
+                        "description": "SSH Key",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "private_key": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "created_at": {
+                                    "type": "string"
+                                },
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/ssh-keys/{group}/{name}": {
+            "get": {
+                "description": "Retrieve a single SSH key stored in Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get SSH key from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "SSH key name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vault.SSHKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Replace the private key of an SSH key stored in Vault",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Update SSH key in Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "SSH key name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SSH Key",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "private_key": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "created_at": {
+                                    "type": "string"
+                                },
+                                "group": {
+                                    "type": "string"
+                                },
+                                "name": {
+                                    "type": "string"
+                                },
+                                "source": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Permanently delete an SSH key and all its versions from Vault",
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Delete SSH key from Vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "SSH key name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/ssh-keys/{group}/{name}/migrate-to-sqlite": {
+            "post": {
+                "description": "Copy an SSH key from Vault to the database and delete it from Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Move SSH key from Vault to the database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "SSH key name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SSHKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/status": {
+            "get": {
+                "description": "Check the current Vault connection status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get Vault connection status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VaultStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/sync": {
+            "get": {
+                "description": "Report the sync direction of each resource type, the conflict rule and the outcome of the last sync between SQLite and Vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Get Vault sync status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.VaultSyncStatus"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Sync SSH keys, servers, environment variables and scripts between SQLite and Vault by the configured policy without waiting for the next interval",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Sync with Vault now",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.VaultSyncStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/server.VaultSyncStatus"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/vault/test": {
+            "post": {
+                "description": "Test the connection to Vault and initialize the secrets structure",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vault"
+                ],
+                "summary": "Test Vault connection",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VaultStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "List the endpoints notified when commands and scripts finish. Secrets are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create an endpoint notified when commands and scripts finish. Events filters the event types delivered (all when empty); a secret signs each delivery.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Create a webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook to create",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Get a webhook by ID, with the outcome of its latest delivery",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update a webhook's name, URL, secret, events or enabled state. An empty secret removes it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Update a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a webhook",
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        },
+        "/webhooks/{id}/test": {
+            "post": {
+                "description": "Send a ping event to a webhook once, without retries, and report whether it was accepted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Test a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookTestResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ]
+            }
+        }
+    },
+    "definitions": {
+        "apierror.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "ip_address"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "invalid IP address or hostname"
+                }
+            }
+        },
+        "bundle.Counts": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "renamed": {
+                    "description": "Created under a new name",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "bundle.Result": {
+            "type": "object",
+            "properties": {
+                "bash_scripts": {
+                    "$ref": "#/definitions/bundle.Counts"
+                },
+                "env_variables": {
+                    "$ref": "#/definitions/bundle.Counts"
+                },
+                "saved_commands": {
+                    "$ref": "#/definitions/bundle.Counts"
+                },
+                "script_presets": {
+                    "$ref": "#/definitions/bundle.Counts"
+                },
+                "servers": {
+                    "$ref": "#/definitions/bundle.Counts"
+                },
+                "ssh_keys": {
+                    "$ref": "#/definitions/bundle.Counts"
+                },
+                "strategy": {
+                    "type": "string"
+                }
+            }
+        },
+        "executor.Attempt": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 10003
+                },
+                "error": {
+                    "description": "Empty for the attempt that connected",
+                    "type": "string",
+                    "example": "failed to connect to 10.0.0.5:22: i/o timeout"
+                }
+            }
+        },
+        "executor.LockInfo": {
+            "type": "object",
+            "properties": {
+                "held_by": {
+                    "type": "string",
+                    "example": "user:alice"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "script:3"
+                },
+                "script": {
+                    "type": "string",
+                    "example": "db-backup"
+                },
+                "script_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "server": {
+                    "description": "\"local\" or the server's name",
+                    "type": "string",
+                    "example": "db1"
+                },
+                "since": {
+                    "type": "string"
+                }
+            }
+        },
+        "executor.QueueStats": {
+            "type": "object",
+            "properties": {
+                "max_queued": {
+                    "description": "0 when unlimited",
+                    "type": "integer",
+                    "example": 100
+                },
+                "max_running": {
+                    "description": "0 when unlimited",
+                    "type": "integer",
+                    "example": 20
+                },
+                "queued": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "running": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "servers": {
+                    "description": "Servers with running or waiting executions",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/executor.ServerQueueStats"
+                    }
+                }
+            }
+        },
+        "executor.ServerQueueStats": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "0 when unlimited",
+                    "type": "integer",
+                    "example": 4
+                },
+                "queued": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "running": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "server": {
+                    "type": "string",
+                    "example": "web1"
+                }
+            }
+        },
+        "middleware.Lockout": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "key": {
+                    "type": "string",
+                    "example": "ip:192.0.2.10"
+                },
+                "locked_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "middleware.MaintenanceStatus": {
+            "type": "object",
+            "properties": {
+                "changed_by": {
+                    "description": "Who last changed the mode (\"config\" at startup)",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "since": {
+                    "description": "When read-only mode was last turned on",
+                    "type": "string"
+                }
+            }
+        },
+        "middleware.QuotaUsage": {
+            "type": "object",
+            "properties": {
+                "last_hour": {
+                    "description": "Executions started in the last hour",
+                    "type": "integer",
+                    "example": 42
+                },
+                "running": {
+                    "description": "Executions running now",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.APIToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "Nil for tokens that never expire",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "description": "Last successful authentication",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Human-readable label (e.g., \"ci-deploy\")",
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "First characters of the token, for identification",
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "Roles used for group permissions (e.g., \"deployers\")",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "description": "Granted scopes (read, write, execute, agent, admin)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "server_id": {
+                    "description": "Server an agent token may connect as the agent of",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APITokenCreate": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "0 for no expiry",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "server_id": {
+                    "description": "Required with the agent scope: the server the agent runs commands for",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.APITokenCreated": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "Nil for tokens that never expire",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "description": "Last successful authentication",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Human-readable label (e.g., \"ci-deploy\")",
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "First characters of the token, for identification",
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "Roles used for group permissions (e.g., \"deployers\")",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "description": "Granted scopes (read, write, execute, agent, admin)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "server_id": {
+                    "description": "Server an agent token may connect as the agent of",
+                    "type": "integer"
+                },
+                "token": {
+                    "description": "Bearer token (only shown once)",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APITokenUpdate": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "server_id": {
+                    "description": "Server of an agent token",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ActivityEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "actor": {
+                    "description": "Who caused it",
+                    "type": "string",
+                    "example": "user:alice"
+                },
+                "command": {
+                    "description": "Command that runs (commands only)",
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "Executions",
+                    "type": "string",
+                    "example": "script"
+                },
+                "outcome": {
+                    "description": "SUCCESS, FAILURE or DENIED",
+                    "type": "string",
+                    "example": "SUCCESS"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "resource": {
+                    "description": "Configuration changes",
+                    "type": "string",
+                    "example": "server"
+                },
+                "script": {
+                    "type": "string",
+                    "example": "backup"
+                },
+                "server": {
+                    "description": "\"local\" or the server's name; also for terminals",
+                    "type": "string",
+                    "example": "web1"
+                },
+                "session_id": {
+                    "description": "Terminal sessions",
+                    "type": "string"
+                },
+                "severity": {
+                    "description": "ok, warning or critical",
+                    "type": "string",
+                    "example": "ok"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "execution.finished"
+                },
+                "user": {
+                    "description": "User it runs as; also for terminals",
+                    "type": "string",
+                    "example": "deploy"
+                }
+            }
+        },
+        "models.Approval": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "decided_at": {
+                    "type": "string"
+                },
+                "decided_by": {
+                    "type": "string"
+                },
+                "executed_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "command or script",
+                    "type": "string",
+                    "example": "script"
+                },
+                "reason": {
+                    "description": "Why approval is required",
+                    "type": "string",
+                    "example": "script requires approval"
+                },
+                "requested_by": {
+                    "type": "string",
+                    "example": "token:ci"
+                },
+                "run_as": {
+                    "description": "User the execution runs as",
+                    "type": "string",
+                    "example": "root"
+                },
+                "server": {
+                    "description": "Target server, or \"local\"",
+                    "type": "string",
+                    "example": "db1"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "summary": {
+                    "description": "Command text or script name",
+                    "type": "string",
+                    "example": "rotate-certs"
+                }
+            }
+        },
+        "models.BashScriptCreate": {
+            "type": "object",
+            "required": [
+                "content",
+                "name"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "exclusive": {
+                    "description": "Optional, executions never overlap",
+                    "type": "boolean"
+                },
+                "exit_codes": {
+                    "description": "Optional, severity of exit codes; undeclared non-zero codes are critical",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ExitCodes"
+                        }
+                    ]
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "Optional, defaults to \"default\"",
+                    "type": "string"
+                },
+                "interpreter": {
+                    "description": "Optional, defaults to \"bash\"",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "requires_approval": {
+                    "description": "Optional, executions need approval",
+                    "type": "boolean"
+                },
+                "tags": {
+                    "description": "Optional labels",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.BashScriptDiff": {
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "description": "Empty when the content is identical",
+                    "type": "string"
+                },
+                "from_version": {
+                    "type": "integer"
+                },
+                "script_id": {
+                    "type": "integer"
+                },
+                "to_version": {
+                    "description": "0 when diffing against unsaved changes",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BashScriptResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Only included when specifically requested",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "exclusive": {
+                    "type": "boolean"
+                },
+                "exit_codes": {
+                    "$ref": "#/definitions/models.ExitCodes"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "Group/category for organization",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interpreter": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "read_only": {
+                    "type": "boolean"
+                },
+                "requires_approval": {
+                    "type": "boolean"
+                },
+                "source": {
+                    "description": "\"sqlite\" or \"vault\"",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BashScriptUpdate": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "exclusive": {
+                    "type": "boolean"
+                },
+                "exit_codes": {
+                    "description": "Replaces the exit codes when present; {} removes them",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ExitCodes"
+                        }
+                    ]
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "interpreter": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "requires_approval": {
+                    "type": "boolean"
+                },
+                "tags": {
+                    "description": "Replaces the tags when present; [] removes them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.BashScriptVersion": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Only included when a single version is requested",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interpreter": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "script_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchResult"
+                    }
+                }
+            }
+        },
+        "models.BatchResult": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "description": "Position of the operation in the request",
+                    "type": "integer"
+                },
+                "item": {
+                    "description": "The created or updated resource"
+                },
+                "status": {
+                    "description": "ok, failed or not_applied",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandExecution": {
+            "type": "object",
+            "required": [
+                "command"
+            ],
+            "properties": {
+                "approval_id": {
+                    "description": "Approved request to run, for servers that require approval",
+                    "type": "integer"
+                },
+                "command": {
+                    "description": "Command to execute",
+                    "type": "string"
+                },
+                "is_remote": {
+                    "description": "True if remote execution",
+                    "type": "boolean"
+                },
+                "kubernetes": {
+                    "description": "Run in a Kubernetes pod instead of locally or over SSH",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.KubernetesTarget"
+                        }
+                    ]
+                },
+                "maintenance_override": {
+                    "description": "Run even though a maintenance window covers the server; the override is audited",
+                    "type": "boolean"
+                },
+                "retries": {
+                    "description": "Retries of transient connection failures on a remote server (0-5)",
+                    "type": "integer"
+                },
+                "retry_backoff_ms": {
+                    "description": "Wait before the first retry in milliseconds, doubled for each one (default: 1000)",
+                    "type": "integer"
+                },
+                "run_as_remote_user": {
+                    "description": "User the command runs as through sudo on a remote server, after connecting as user",
+                    "type": "string"
+                },
+                "save_as": {
+                    "description": "Optional: save as template with this name",
+                    "type": "string"
+                },
+                "server_group": {
+                    "description": "Server group for remote execution (Vault)",
+                    "type": "string"
+                },
+                "server_id": {
+                    "description": "Server ID for remote execution (SQLite)",
+                    "type": "integer"
+                },
+                "server_name": {
+                    "description": "Server name for remote execution (Vault)",
+                    "type": "string"
+                },
+                "server_ref": {
+                    "description": "Server as \"vault:group/name\" (instead of server_name and server_group)",
+                    "type": "string"
+                },
+                "ssh_key_group": {
+                    "description": "SSH key group for remote execution (Vault)",
+                    "type": "string"
+                },
+                "ssh_key_id": {
+                    "description": "SSH key ID for remote execution (SQLite)",
+                    "type": "integer"
+                },
+                "ssh_key_name": {
+                    "description": "SSH key name for remote execution (Vault)",
+                    "type": "string"
+                },
+                "ssh_key_ref": {
+                    "description": "SSH key as \"vault:group/name\" (instead of ssh_key_name and ssh_key_group)",
+                    "type": "string"
+                },
+                "ssh_password": {
+                    "description": "SSH password (for remote, if key auth fails)",
+                    "type": "string"
+                },
+                "stdin": {
+                    "description": "Text piped into the command's stdin",
+                    "type": "string"
+                },
+                "stdin_base64": {
+                    "description": "Base64 payload piped into stdin, for binary input (instead of stdin)",
+                    "type": "string"
+                },
+                "strip_ansi": {
+                    "description": "Remove terminal color and cursor codes from the returned output; history keeps them",
+                    "type": "boolean"
+                },
+                "sudo_password": {
+                    "description": "Sudo password (required when user != current for local, or for run_as_remote_user without passwordless sudo)",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "User to run as (default: root)",
+                    "type": "string"
+                },
+                "workdir": {
+                    "description": "Absolute directory the command runs in (default: the user's usual directory)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandHistory": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "Decrypted value",
+                    "type": "string"
+                },
+                "executed_at": {
+                    "type": "string"
+                },
+                "execution_time_ms": {
+                    "type": "integer"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "output": {
+                    "description": "Decrypted value",
+                    "type": "string"
+                },
+                "output_size": {
+                    "description": "Bytes of the full output",
+                    "type": "integer"
+                },
+                "output_truncated": {
+                    "description": "Output holds the end of the full output, downloadable from /history/{id}/log",
+                    "type": "boolean"
+                },
+                "preset_id": {
+                    "description": "Script preset the run was started from",
+                    "type": "integer"
+                },
+                "saved_command_id": {
+                    "description": "Saved command that ran",
+                    "type": "integer"
+                },
+                "script_id": {
+                    "description": "Script that ran",
+                    "type": "integer"
+                },
+                "server": {
+                    "description": "\"local\" for local commands, or server name/IP",
+                    "type": "string"
+                },
+                "server_id": {
+                    "description": "Server it ran on",
+                    "type": "integer"
+                },
+                "severity": {
+                    "description": "\"ok\", \"warning\" or \"critical\", from the exit code",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "User who executed the command (for local commands)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandPlaceholder": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Value used when none is given (empty for a required value)",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Placeholder as written in the command, without braces",
+                    "type": "string"
+                },
+                "prompt": {
+                    "description": "Question asking for the value",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandPolicy": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "allow or deny",
+                    "type": "string",
+                    "example": "deny"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "match_type": {
+                    "description": "glob or regex",
+                    "type": "string",
+                    "example": "regex"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "no-root-wipe"
+                },
+                "pattern": {
+                    "description": "Pattern matched against the target",
+                    "type": "string",
+                    "example": "rm\\s+-rf\\s+/"
+                },
+                "principal": {
+                    "description": "\"user:\u003cname\u003e\", \"token:\u003cname\u003e\" or \"role:\u003crole\u003e\"; empty applies to everyone",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "command or script",
+                    "type": "string",
+                    "example": "command"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandPolicyCreate": {
+            "type": "object",
+            "required": [
+                "action",
+                "name",
+                "pattern"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "Optional, defaults to true",
+                    "type": "boolean"
+                },
+                "match_type": {
+                    "description": "Optional, defaults to \"glob\"",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "principal": {
+                    "description": "Optional, empty applies to everyone",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "Optional, defaults to \"command\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandPolicyUpdate": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "match_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "principal": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandResult": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Connection attempts, when retries were allowed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/executor.Attempt"
+                    }
+                },
+                "command": {
+                    "type": "string"
+                },
+                "executed_at": {
+                    "type": "string"
+                },
+                "execution_time_ms": {
+                    "description": "Execution time in milliseconds",
+                    "type": "integer"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "history_id": {
+                    "description": "Command history entry of the execution",
+                    "type": "integer"
+                },
+                "output": {
+                    "type": "string"
+                },
+                "output_truncated": {
+                    "description": "Output holds the end of the full output, downloadable from /history/{history_id}/log",
+                    "type": "boolean"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommandSuggestion": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string",
+                    "example": "systemctl status nginx"
+                },
+                "count": {
+                    "description": "Times the caller ran the command, among their latest commands",
+                    "type": "integer",
+                    "example": 14
+                },
+                "last_used_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConnectedAgent": {
+            "type": "object",
+            "properties": {
+                "connected_at": {
+                    "type": "string"
+                },
+                "connected_by": {
+                    "description": "Principal the agent authenticated as",
+                    "type": "string",
+                    "example": "token:web1-agent"
+                },
+                "hostname": {
+                    "description": "Hostname reported by the agent",
+                    "type": "string",
+                    "example": "web1.local"
+                },
+                "name": {
+                    "description": "Name of the server it runs commands for",
+                    "type": "string",
+                    "example": "web1"
+                },
+                "remote_addr": {
+                    "type": "string",
+                    "example": "203.0.113.7:51234"
+                },
+                "running": {
+                    "description": "Commands running on it now",
+                    "type": "integer"
+                },
+                "user": {
+                    "description": "User the agent runs commands as; others are switched to with sudo",
+                    "type": "string",
+                    "example": "webcli"
+                },
+                "version": {
+                    "description": "web-cli version of the agent",
+                    "type": "string",
+                    "example": "1.4.0"
+                }
+            }
+        },
+        "models.EnvVariableBatchOperation": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "create, update or delete",
+                    "type": "string",
+                    "example": "create"
+                },
+                "env_variable": {
+                    "description": "Fields to create the variable with, or to change",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EnvVariableUpdate"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Variable to update or delete",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EnvVariableBatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EnvVariableBatchOperation"
+                    }
+                }
+            }
+        },
+        "models.EnvVariableCreate": {
+            "type": "object",
+            "required": [
+                "name",
+                "value"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "Optional, defaults to \"default\"",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnvVariableImport": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "description": ".env file contents",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "description": "Only report what would be created, without writing",
+                    "type": "boolean"
+                },
+                "group": {
+                    "description": "Group the variables are created in (default: \"default\")",
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnvVariableImportItem": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "\"create\", or \"skip\" when a variable with the name exists",
+                    "type": "string"
+                },
+                "existing_group": {
+                    "description": "Group of the existing variable, for skipped ones",
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnvVariableImportResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "Created, or that would be created on a dry run",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EnvVariableImportItem"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EnvVariableResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "Group/category for organization",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "\"sqlite\" or \"vault\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Will be masked unless explicitly requested",
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnvVariableUpdate": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExecutionQuota": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "executions_per_hour": {
+                    "description": "Executions started in any rolling hour; 0 for no limit",
+                    "type": "integer",
+                    "example": 60
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_concurrent": {
+                    "description": "Executions running at once; 0 for no limit",
+                    "type": "integer",
+                    "example": 2
+                },
+                "max_timeout_seconds": {
+                    "description": "Longest a command or script may run; 0 for the default",
+                    "type": "integer",
+                    "example": 120
+                },
+                "principal": {
+                    "description": "\"user:\u003cname\u003e\", \"token:\u003cname\u003e\", \"role:\u003crole\u003e\" or \"*\"",
+                    "type": "string",
+                    "example": "token:ci"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExecutionQuotaCreate": {
+            "type": "object",
+            "required": [
+                "principal"
+            ],
+            "properties": {
+                "executions_per_hour": {
+                    "type": "integer"
+                },
+                "max_concurrent": {
+                    "type": "integer"
+                },
+                "max_timeout_seconds": {
+                    "type": "integer"
+                },
+                "principal": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExecutionQuotaUpdate": {
+            "type": "object",
+            "properties": {
+                "executions_per_hour": {
+                    "type": "integer"
+                },
+                "max_concurrent": {
+                    "type": "integer"
+                },
+                "max_timeout_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ExitCodes": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "models.Favorite": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name of the script, saved command or server",
+                    "type": "string",
+                    "example": "deploy"
+                },
+                "resource_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "resource_type": {
+                    "description": "bash_scripts, saved_commands or servers",
+                    "type": "string",
+                    "example": "bash_scripts"
+                }
+            }
+        },
+        "models.GroupPermission": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "Group name the permission applies to",
+                    "type": "string",
+                    "example": "production"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "permission": {
+                    "description": "view or execute",
+                    "type": "string",
+                    "example": "execute"
+                },
+                "principal": {
+                    "description": "\"user:\u003cname\u003e\", \"token:\u003cname\u003e\" or \"role:\u003crole\u003e\"",
+                    "type": "string",
+                    "example": "role:senior-ops"
+                },
+                "resource_type": {
+                    "description": "servers, ssh_keys, env_variables, bash_scripts or pods",
+                    "type": "string",
+                    "example": "servers"
+                }
+            }
+        },
+        "models.GroupPermissionCreate": {
+            "type": "object",
+            "required": [
+                "permission",
+                "principal",
+                "resource_type"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "description": "Server group ID, instead of group; implies the servers resource type",
+                    "type": "integer"
+                },
+                "permission": {
+                    "type": "string"
+                },
+                "principal": {
+                    "type": "string"
+                },
+                "resource_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.KeystrokeEvent": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "string",
+                    "example": "ls -la"
+                },
+                "redacted": {
+                    "type": "boolean"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.KeystrokeLog": {
+            "type": "object",
+            "properties": {
+                "ended_at": {
+                    "description": "Unset while the session is open",
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Only included when a single log is requested",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.KeystrokeEvent"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "description": "Principal that opened the session",
+                    "type": "string",
+                    "example": "user:admin"
+                },
+                "server": {
+                    "description": "Target server (\"local\" for a shell on this host)",
+                    "type": "string",
+                    "example": "local"
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "9c41d7e2a0b35f18"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.KubernetesPod": {
+            "type": "object",
+            "properties": {
+                "containers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "app",
+                        "sidecar"
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "web-7d4b9c6f5-x2k8p"
+                },
+                "namespace": {
+                    "type": "string",
+                    "example": "production"
+                },
+                "node": {
+                    "type": "string",
+                    "example": "node-1"
+                },
+                "phase": {
+                    "description": "Pending, Running, Succeeded, Failed or Unknown",
+                    "type": "string",
+                    "example": "Running"
+                }
+            }
+        },
+        "models.KubernetesTarget": {
+            "type": "object",
+            "properties": {
+                "container": {
+                    "description": "Default: the pod's only container",
+                    "type": "string",
+                    "example": "app"
+                },
+                "namespace": {
+                    "description": "Default: the namespace of the configured context",
+                    "type": "string",
+                    "example": "production"
+                },
+                "pod": {
+                    "description": "Pod to run in",
+                    "type": "string",
+                    "example": "web-7d4b9c6f5-x2k8p"
+                },
+                "selector": {
+                    "description": "Label selector; the first running pod by name is used (instead of pod)",
+                    "type": "string",
+                    "example": "app=web"
+                }
+            }
+        },
+        "models.LocalUser": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Unix username (must be valid system username)",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LocalUserCreate": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Unix username",
+                    "type": "string"
+                }
+            }
+        },
+        "models.LocalUserUpdate": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Unix username",
+                    "type": "string"
+                }
+            }
+        },
+        "models.MaintenanceWindow": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "In effect now",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "duration_minutes": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "ends_at": {
+                    "description": "End of a one-off window",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "weekly-patching"
+                },
+                "schedule": {
+                    "description": "Cron expression starting a recurring window, in the server's time zone",
+                    "type": "string",
+                    "example": "0 2 * * 6"
+                },
+                "server_group": {
+                    "description": "Group of the servers covered; \"*\" covers every server and the local machine",
+                    "type": "string",
+                    "example": "production"
+                },
+                "starts_at": {
+                    "description": "Start of a one-off window",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MaintenanceWindowCreate": {
+            "type": "object",
+            "required": [
+                "name",
+                "server_group"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "duration_minutes": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "description": "Optional, defaults to true",
+                    "type": "boolean"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "server_group": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MaintenanceWindowUpdate": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "duration_minutes": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "server_group": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationRule": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Where matching executions are sent",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "webhook:ci",
+                        "slack:#ops",
+                        "email"
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "exit_codes": {
+                    "description": "Exit codes that match; empty matches any",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "command or script; empty matches both",
+                    "type": "string",
+                    "example": "script"
+                },
+                "min_duration_ms": {
+                    "description": "Executions lasting at least this long match; 0 matches any",
+                    "type": "integer",
+                    "example": 600000
+                },
+                "name": {
+                    "type": "string",
+                    "example": "slow-backups"
+                },
+                "outcome": {
+                    "description": "any, failure, warning or success",
+                    "type": "string",
+                    "example": "failure"
+                },
+                "script": {
+                    "description": "Glob matched against the script name; only scripts match when set",
+                    "type": "string",
+                    "example": "backup-*"
+                },
+                "server_group": {
+                    "description": "Group of the server; \"local\" matches local executions",
+                    "type": "string",
+                    "example": "production"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationRuleCreate": {
+            "type": "object",
+            "required": [
+                "channels",
+                "name"
+            ],
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "Optional, defaults to true",
+                    "type": "boolean"
+                },
+                "exit_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "min_duration_ms": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "outcome": {
+                    "description": "Optional, defaults to \"failure\"",
+                    "type": "string"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "server_group": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationRuleUpdate": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "exit_codes": {
+                    "description": "Replaces the exit codes when present; [] matches any",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "min_duration_ms": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "outcome": {
+                    "type": "string"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "server_group": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PolicyDecision": {
+            "type": "object",
+            "properties": {
+                "allowed": {
+                    "type": "boolean"
+                },
+                "policy": {
+                    "description": "Policy that denied the execution",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CommandPolicy"
+                        }
+                    ]
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PolicyTestRequest": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "Command to evaluate",
+                    "type": "string"
+                },
+                "principal": {
+                    "description": "Optional \"user:\u003cname\u003e\" or \"token:\u003cname\u003e\" to evaluate as",
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "Optional roles of the principal",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "script_id": {
+                    "description": "Script to evaluate instead of a command",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RecentItem": {
+            "type": "object",
+            "properties": {
+                "executions": {
+                    "description": "Executions of the item in history",
+                    "type": "integer",
+                    "example": 12
+                },
+                "favorite": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "last_executed_at": {
+                    "type": "string"
+                },
+                "last_exit_code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "last_history_id": {
+                    "type": "integer",
+                    "example": 240
+                },
+                "last_severity": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "web-01"
+                },
+                "resource_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "resource_type": {
+                    "description": "bash_scripts, saved_commands or servers",
+                    "type": "string",
+                    "example": "servers"
+                }
+            }
+        },
+        "models.RunningExecution": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "Command that runs (commands only)",
+                    "type": "string"
+                },
+                "elapsed_ms": {
+                    "type": "integer",
+                    "example": 12500
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "kind": {
+                    "description": "command or script",
+                    "type": "string",
+                    "example": "script"
+                },
+                "request_id": {
+                    "description": "ID of the API request that started it",
+                    "type": "string"
+                },
+                "script": {
+                    "type": "string",
+                    "example": "backup"
+                },
+                "server": {
+                    "description": "\"local\" or the server's name",
+                    "type": "string",
+                    "example": "web1"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "started_by": {
+                    "description": "Principal that started it",
+                    "type": "string",
+                    "example": "user:alice"
+                },
+                "user": {
+                    "description": "User it runs as",
+                    "type": "string",
+                    "example": "deploy"
+                }
+            }
+        },
+        "models.SSHKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "Group/category for organization",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "description": "Last execution or terminal session that used the key",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "private_key": {
+                    "description": "Decrypted value",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "\"sqlite\" or \"vault\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "use_count": {
+                    "description": "Number of executions and terminal sessions that used the key",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SSHKeyCreate": {
+            "type": "object",
+            "required": [
+                "name",
+                "private_key"
+            ],
+            "properties": {
+                "group": {
+                    "description": "Optional, defaults to \"default\"",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "private_key": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SSHKeyUpdate": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "private_key": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SavedCommand": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "The actual command to execute",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Optional description",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_remote": {
+                    "description": "True if this is a remote command",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "Friendly name for the command",
+                    "type": "string"
+                },
+                "placeholders": {
+                    "description": "Prompts and defaults of the placeholders in the command (names in double braces), filled in when it is executed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommandPlaceholder"
+                    }
+                },
+                "server_id": {
+                    "description": "Foreign key to servers table (for remote commands)",
+                    "type": "integer"
+                },
+                "ssh_key_id": {
+                    "description": "Foreign key to ssh_keys table (for remote commands)",
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "Labels for finding the command",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "description": "User to run as (default: root)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SavedCommandCreate": {
+            "type": "object",
+            "required": [
+                "command",
+                "name"
+            ],
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "is_remote": {
+                    "description": "True if this is a remote command",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "placeholders": {
+                    "description": "Prompts and defaults of the command's placeholders",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommandPlaceholder"
+                    }
+                },
+                "server_id": {
+                    "description": "For remote commands",
+                    "type": "integer"
+                },
+                "ssh_key_id": {
+                    "description": "For remote commands",
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "Optional labels",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user": {
+                    "description": "Optional, defaults to \"root\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SavedCommandExecution": {
+            "type": "object",
+            "properties": {
+                "values": {
+                    "description": "Placeholder values by name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.SavedCommandUpdate": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "is_remote": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "placeholders": {
+                    "description": "Replaces the declarations when present; [] removes them",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommandPlaceholder"
+                    }
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "ssh_key_id": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "Replaces the tags when present; [] removes them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScriptExecution": {
+            "type": "object",
+            "properties": {
+                "approval_id": {
+                    "description": "Approved request to run, for scripts or servers that require approval",
+                    "type": "integer"
+                },
+                "args": {
+                    "description": "Arguments passed to an uploaded script",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dry_run": {
+                    "description": "Only check the script's syntax and report what would run, without executing it",
+                    "type": "boolean"
+                },
+                "env_var_groups": {
+                    "description": "Groups of the EnvVarNames (Vault); without names, every env var in these groups (SQLite)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "env_var_ids": {
+                    "description": "Specific env var IDs to include (SQLite)",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "env_var_names": {
+                    "description": "Names of env vars to include (Vault)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "include_env_vars": {
+                    "description": "Deprecated: use EnvVarIDs instead",
+                    "type": "boolean"
+                },
+                "is_remote": {
+                    "description": "True if remote execution",
+                    "type": "boolean"
+                },
+                "kubernetes": {
+                    "description": "Run in a Kubernetes pod instead of locally or over SSH",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.KubernetesTarget"
+                        }
+                    ]
+                },
+                "maintenance_override": {
+                    "description": "Run even though a maintenance window covers the server; the override is audited",
+                    "type": "boolean"
+                },
+                "preset_id": {
+                    "description": "Script preset the execution was started from, whose notification settings apply",
+                    "type": "integer"
+                },
+                "retries": {
+                    "description": "Retries of transient connection failures on a remote server (0-5)",
+                    "type": "integer"
+                },
+                "retry_backoff_ms": {
+                    "description": "Wait before the first retry in milliseconds, doubled for each one (default: 1000)",
+                    "type": "integer"
+                },
+                "run_as_remote_user": {
+                    "description": "User the script runs as through sudo on a remote server, after connecting as user",
+                    "type": "string"
+                },
+                "script_group": {
+                    "description": "Script group for execution (Vault)",
+                    "type": "string"
+                },
+                "script_id": {
+                    "description": "ID of the script to execute (SQLite)",
+                    "type": "integer"
+                },
+                "script_name": {
+                    "description": "Name of the script to execute (Vault)",
+                    "type": "string"
+                },
+                "server_group": {
+                    "description": "Server group for remote execution (Vault)",
+                    "type": "string"
+                },
+                "server_id": {
+                    "description": "Server ID for remote execution (SQLite)",
+                    "type": "integer"
+                },
+                "server_name": {
+                    "description": "Server name for remote execution (Vault)",
+                    "type": "string"
+                },
+                "server_ref": {
+                    "description": "Server as \"vault:group/name\" (instead of server_name and server_group)",
+                    "type": "string"
+                },
+                "ssh_key_group": {
+                    "description": "SSH key group for remote execution (Vault)",
+                    "type": "string"
+                },
+                "ssh_key_id": {
+                    "description": "SSH key ID for remote execution (SQLite)",
+                    "type": "integer"
+                },
+                "ssh_key_name": {
+                    "description": "SSH key name for remote execution (Vault)",
+                    "type": "string"
+                },
+                "ssh_key_ref": {
+                    "description": "SSH key as \"vault:group/name\" (instead of ssh_key_name and ssh_key_group)",
+                    "type": "string"
+                },
+                "ssh_password": {
+                    "description": "SSH password (for remote, if key auth fails)",
+                    "type": "string"
+                },
+                "stdin": {
+                    "description": "Text piped into the script's stdin",
+                    "type": "string"
+                },
+                "stdin_base64": {
+                    "description": "Base64 payload piped into stdin, for binary input (instead of stdin)",
+                    "type": "string"
+                },
+                "strip_ansi": {
+                    "description": "Remove terminal color and cursor codes from the returned output; history keeps them",
+                    "type": "boolean"
+                },
+                "sudo_password": {
+                    "description": "Sudo password (required when user != current for local, or for run_as_remote_user without passwordless sudo)",
+                    "type": "string"
+                },
+                "upload": {
+                    "description": "Copy the script to the remote server over SFTP and run it from a temporary file (SSH only)",
+                    "type": "boolean"
+                },
+                "user": {
+                    "description": "User to run as (default: root)",
+                    "type": "string"
+                },
+                "wait_for_lock": {
+                    "description": "Wait for a running exclusive script to finish instead of failing with 409 Conflict",
+                    "type": "boolean"
+                },
+                "workdir": {
+                    "description": "Absolute directory the script runs in (default: the user's usual directory)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScriptPresetCreate": {
+            "type": "object",
+            "required": [
+                "name",
+                "script_id"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "env_var_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "exclusive": {
+                    "type": "boolean"
+                },
+                "is_remote": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notify_channel": {
+                    "description": "webhook:\u003cname\u003e, slack, slack:\u003cchannel\u003e, email or email:\u003caddress\u003e",
+                    "type": "string"
+                },
+                "retries": {
+                    "type": "integer"
+                },
+                "retry_backoff_ms": {
+                    "type": "integer"
+                },
+                "schedule": {
+                    "description": "Cron expression, such as \"0 2 * * *\"",
+                    "type": "string"
+                },
+                "schedule_enabled": {
+                    "type": "boolean"
+                },
+                "script_id": {
+                    "type": "integer"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "slack_notify": {
+                    "description": "always, failures or empty for never",
+                    "type": "string"
+                },
+                "ssh_key_id": {
+                    "type": "integer"
+                },
+                "timeout_seconds": {
+                    "type": "integer"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScriptPresetResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "env_var_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "exclusive": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_remote": {
+                    "type": "boolean"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "last_run_error": {
+                    "type": "string"
+                },
+                "last_run_history_id": {
+                    "type": "integer"
+                },
+                "last_run_status": {
+                    "description": "ok, warning, critical or error",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "description": "Next scheduled run, when the schedule is enabled",
+                    "type": "string"
+                },
+                "notify_channel": {
+                    "type": "string"
+                },
+                "retries": {
+                    "type": "integer"
+                },
+                "retry_backoff_ms": {
+                    "type": "integer"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "schedule_enabled": {
+                    "type": "boolean"
+                },
+                "script_id": {
+                    "type": "integer"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "slack_notify": {
+                    "type": "string"
+                },
+                "ssh_key_id": {
+                    "type": "integer"
+                },
+                "timeout_seconds": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScriptPresetUpdate": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "env_var_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "exclusive": {
+                    "type": "boolean"
+                },
+                "is_remote": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notify_channel": {
+                    "description": "Channel, or \"\" for the Slack webhook",
+                    "type": "string"
+                },
+                "retries": {
+                    "type": "integer"
+                },
+                "retry_backoff_ms": {
+                    "type": "integer"
+                },
+                "schedule": {
+                    "description": "Cron expression, or \"\" to remove the schedule",
+                    "type": "string"
+                },
+                "schedule_enabled": {
+                    "type": "boolean"
+                },
+                "script_id": {
+                    "type": "integer"
+                },
+                "server_id": {
+                    "type": "integer"
+                },
+                "slack_notify": {
+                    "description": "always, failures or \"\" for never",
+                    "type": "string"
+                },
+                "ssh_key_id": {
+                    "type": "integer"
+                },
+                "timeout_seconds": {
+                    "type": "integer"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScriptResult": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Connection attempts, when retries were allowed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/executor.Attempt"
+                    }
+                },
+                "dry_run": {
+                    "description": "Output and exit code are from the syntax check; nothing was executed",
+                    "type": "boolean"
+                },
+                "env_vars": {
+                    "description": "Names of the env vars injected (or that would be, for dry runs)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "env_vars_injected": {
+                    "description": "Number of env vars injected",
+                    "type": "integer"
+                },
+                "execution_time_ms": {
+                    "description": "Execution time in milliseconds",
+                    "type": "integer"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "history_id": {
+                    "description": "Command history entry of the execution",
+                    "type": "integer"
+                },
+                "output": {
+                    "type": "string"
+                },
+                "output_truncated": {
+                    "description": "Output holds the end of the full output, downloadable from /history/{history_id}/log",
+                    "type": "boolean"
+                },
+                "script_id": {
+                    "type": "integer"
+                },
+                "script_name": {
+                    "type": "string"
+                },
+                "server": {
+                    "description": "\"local\" or server name",
+                    "type": "string"
+                },
+                "severity": {
+                    "description": "\"ok\", \"warning\" or \"critical\", from the exit code meanings of the script",
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Server": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "Group/category for organization",
+                    "type": "string"
+                },
+                "has_ssm_secret_access_key": {
+                    "description": "Credentials are stored",
+                    "type": "boolean"
+                },
+                "host_key_fingerprint": {
+                    "description": "Pinned SHA256 fingerprint, checked instead of known_hosts",
+                    "type": "string",
+                    "example": "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"
+                },
+                "host_key_policy": {
+                    "description": "SSH host key verification",
+                    "type": "string",
+                    "example": "tofu"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inventory_id": {
+                    "description": "Cloud instance the server is discovered from, kept up to date by the inventory sync",
+                    "type": "string",
+                    "example": "aws:i-0abc123"
+                },
+                "ip_address": {
+                    "description": "IP address",
+                    "type": "string"
+                },
+                "mac_address": {
+                    "description": "Wake-on-LAN",
+                    "type": "string",
+                    "example": "00:11:22:33:44:55"
+                },
+                "max_parallel": {
+                    "description": "Executions running at once on this server (0 for the SERVER_MAX_PARALLEL default)",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Hostname (must follow hostname conventions)",
+                    "type": "string"
+                },
+                "port": {
+                    "description": "SSH port (default: 22)",
+                    "type": "integer"
+                },
+                "requires_approval": {
+                    "description": "Executions on this server need a second person's approval",
+                    "type": "boolean"
+                },
+                "source": {
+                    "description": "\"sqlite\" or \"vault\"",
+                    "type": "string"
+                },
+                "ssm_access_key_id": {
+                    "description": "Stored credentials (default: the environment's or the instance role of web-cli)",
+                    "type": "string"
+                },
+                "ssm_instance_id": {
+                    "description": "Instance commands are sent to (default: the instance of an aws inventory_id)",
+                    "type": "string",
+                    "example": "i-0abc123"
+                },
+                "ssm_region": {
+                    "description": "Region of the instance",
+                    "type": "string",
+                    "example": "eu-west-1"
+                },
+                "ssm_role_arn": {
+                    "description": "IAM role assumed to send commands",
+                    "type": "string"
+                },
+                "transport": {
+                    "description": "AWS Systems Manager settings, used by the ssm transport",
+                    "type": "string",
+                    "example": "ssh"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "description": "SSH username for remote connections",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ServerBatchOperation": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "create, update or delete",
+                    "type": "string",
+                    "example": "create"
+                },
+                "id": {
+                    "description": "Server to update or delete",
+                    "type": "integer"
+                },
+                "server": {
+                    "description": "Fields to create the server with, or to change",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ServerUpdate"
                         }
+                    ]
+                }
+            }
+        },
+        "models.ServerBatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ServerBatchOperation"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_pozgo_web-cli_internal_models.BashScriptCreate": {
+        },
+        "models.ServerCreate": {
             "type": "object",
-            "required": [
-                "content",
-                "name"
-            ],
             "properties": {
-                "content": {
+                "group": {
+                    "description": "Optional, defaults to \"default\"",
                     "type": "string"
                 },
-                "description": {
+                "host_key_fingerprint": {
+                    "description": "Optional pinned SHA256 fingerprint of the host key",
                     "type": "string"
                 },
-                "filename": {
+                "host_key_policy": {
+                    "description": "Optional, strict, tofu (default) or insecure",
                     "type": "string"
                 },
-                "group": {
-                    "description": "Optional, defaults to \"default\"",
+                "ip_address": {
+                    "type": "string"
+                },
+                "mac_address": {
+                    "description": "Optional, for Wake-on-LAN",
                     "type": "string"
                 },
+                "max_parallel": {
+                    "description": "Optional, 0 uses the server-wide default",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
+                },
+                "port": {
+                    "description": "Optional, defaults to 22 if not provided",
+                    "type": "integer"
+                },
+                "requires_approval": {
+                    "description": "Optional, executions need approval",
+                    "type": "boolean"
+                },
+                "ssm_access_key_id": {
+                    "description": "Optional stored credentials, with ssm_secret_access_key",
+                    "type": "string"
+                },
+                "ssm_instance_id": {
+                    "description": "Required for ssm unless discovered from EC2",
+                    "type": "string"
+                },
+                "ssm_region": {
+                    "description": "Required for ssm",
+                    "type": "string"
+                },
+                "ssm_role_arn": {
+                    "description": "Optional IAM role to assume",
+                    "type": "string"
+                },
+                "ssm_secret_access_key": {
+                    "description": "Encrypted at rest",
+                    "type": "string"
+                },
+                "transport": {
+                    "description": "Optional, ssh (default), ssm or agent",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "SSH username for remote connections",
+                    "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.BashScriptResponse": {
+        "models.ServerGroup": {
             "type": "object",
             "properties": {
-                "content": {
-                    "description": "Only included when specifically requested",
-                    "type": "string"
-                },
                 "created_at": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string"
+                "default_port": {
+                    "description": "SSH port of servers added without one (0 for 22)",
+                    "type": "integer",
+                    "example": 2222
                 },
-                "filename": {
-                    "type": "string"
+                "default_ssh_key_id": {
+                    "description": "SSH key used on members when an execution selects none",
+                    "type": "integer",
+                    "example": 3
                 },
-                "group": {
-                    "description": "Group/category for organization",
-                    "type": "string"
+                "default_username": {
+                    "description": "Username of servers added without one (empty for root)",
+                    "type": "string",
+                    "example": "deploy"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Customer-facing web and database servers"
                 },
                 "id": {
                     "type": "integer"
                 },
                 "name": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "production"
                 },
-                "source": {
-                    "description": "\"sqlite\" or \"vault\"",
-                    "type": "string"
+                "server_count": {
+                    "description": "Servers in the group",
+                    "type": "integer",
+                    "example": 12
                 },
                 "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.BashScriptUpdate": {
+        "models.ServerGroupCreate": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "content": {
+                "default_port": {
+                    "type": "integer"
+                },
+                "default_ssh_key_id": {
+                    "type": "integer"
+                },
+                "default_username": {
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
-                "filename": {
+                "name": {
                     "type": "string"
+                }
+            }
+        },
+        "models.ServerGroupExecutionResult": {
+            "type": "object",
+            "properties": {
+                "result": {
+                    "description": "The execution's result, or its error response",
+                    "type": "object"
                 },
-                "group": {
+                "server": {
+                    "type": "string",
+                    "example": "web1"
+                },
+                "server_id": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "status": {
+                    "description": "HTTP status the execution would have returned on its own",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "models.ServerGroupMembers": {
+            "type": "object",
+            "required": [
+                "server_ids"
+            ],
+            "properties": {
+                "server_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.ServerGroupUpdate": {
+            "type": "object",
+            "properties": {
+                "default_port": {
+                    "type": "integer"
+                },
+                "default_ssh_key_id": {
+                    "description": "0 removes the default key",
+                    "type": "integer"
+                },
+                "default_username": {
+                    "type": "string"
+                },
+                "description": {
                     "type": "string"
                 },
                 "name": {
@@ -2983,201 +13011,302 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.CommandExecution": {
+        "models.ServerUpdate": {
             "type": "object",
-            "required": [
-                "command"
-            ],
             "properties": {
-                "command": {
-                    "description": "Command to execute",
+                "group": {
                     "type": "string"
                 },
-                "is_remote": {
-                    "description": "True if remote execution",
-                    "type": "boolean"
+                "host_key_fingerprint": {
+                    "description": "\"\" removes the pinned fingerprint",
+                    "type": "string"
                 },
-                "save_as": {
-                    "description": "Optional: save as template with this name",
+                "host_key_policy": {
                     "type": "string"
                 },
-                "server_group": {
-                    "description": "Server group for remote execution (Vault)",
+                "ip_address": {
                     "type": "string"
                 },
-                "server_id": {
-                    "description": "Server ID for remote execution (SQLite)",
+                "mac_address": {
+                    "description": "\"\" removes it",
+                    "type": "string"
+                },
+                "max_parallel": {
+                    "description": "0 restores the server-wide default",
                     "type": "integer"
                 },
-                "server_name": {
-                    "description": "Server name for remote execution (Vault)",
+                "name": {
                     "type": "string"
                 },
-                "ssh_key_group": {
-                    "description": "SSH key group for remote execution (Vault)",
+                "port": {
+                    "type": "integer"
+                },
+                "requires_approval": {
+                    "type": "boolean"
+                },
+                "ssm_access_key_id": {
+                    "description": "Replaces the stored credentials with ssm_secret_access_key; \"\" removes them",
                     "type": "string"
                 },
-                "ssh_key_id": {
-                    "description": "SSH key ID for remote execution (SQLite)",
-                    "type": "integer"
+                "ssm_instance_id": {
+                    "description": "\"\" restores the instance of an aws inventory_id",
+                    "type": "string"
                 },
-                "ssh_key_name": {
-                    "description": "SSH key name for remote execution (Vault)",
+                "ssm_region": {
                     "type": "string"
                 },
-                "ssh_password": {
-                    "description": "SSH password (for remote, if key auth fails)",
+                "ssm_role_arn": {
+                    "description": "\"\" stops assuming a role",
+                    "type": "string"
+                },
+                "ssm_secret_access_key": {
+                    "type": "string"
+                },
+                "transport": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ServerWakeRequest": {
+            "type": "object",
+            "properties": {
+                "broadcast": {
+                    "description": "IPv4 address the packet is sent to (default: 255.255.255.255)",
+                    "type": "string",
+                    "example": "192.168.1.255"
+                },
+                "port": {
+                    "description": "UDP port the packet is sent to (default: 9)",
+                    "type": "integer",
+                    "example": 9
+                },
+                "relay_server_id": {
+                    "description": "Server on the sleeping server's network that sends the packet, with python3, wakeonlan or wol",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.ServerWakeResult": {
+            "type": "object",
+            "properties": {
+                "broadcast": {
+                    "type": "string",
+                    "example": "255.255.255.255"
+                },
+                "mac_address": {
+                    "type": "string",
+                    "example": "00:11:22:33:44:55"
+                },
+                "port": {
+                    "type": "integer",
+                    "example": 9
+                },
+                "relay": {
+                    "description": "Server that sent the packet, when relayed",
+                    "type": "string",
+                    "example": "pi"
+                },
+                "server": {
+                    "type": "string",
+                    "example": "nas"
+                }
+            }
+        },
+        "models.TerminalFile": {
+            "type": "object",
+            "properties": {
+                "modified_at": {
                     "type": "string"
                 },
-                "sudo_password": {
-                    "description": "Sudo password (required when user != current for local)",
+                "path": {
+                    "description": "Slash-separated path relative to the directory",
+                    "type": "string",
+                    "example": "reports/disk-usage.csv"
+                },
+                "size": {
+                    "description": "Size in bytes",
+                    "type": "integer",
+                    "example": 2048
+                }
+            }
+        },
+        "models.TerminalRecording": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "description": "Terminal rows when the session started",
+                    "type": "integer",
+                    "example": 24
+                },
+                "id": {
+                    "description": "File name without the .cast extension",
+                    "type": "string",
+                    "example": "20251112T091500Z-3f2a9c1b"
+                },
+                "size": {
+                    "description": "File size in bytes",
+                    "type": "integer",
+                    "example": 48213
+                },
+                "started_at": {
                     "type": "string"
                 },
-                "user": {
-                    "description": "User to run as (default: root)",
+                "updated_at": {
+                    "description": "Last write; the session end for finished sessions",
                     "type": "string"
+                },
+                "width": {
+                    "description": "Terminal columns when the session started",
+                    "type": "integer",
+                    "example": 80
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.CommandHistory": {
+        "models.TerminalSession": {
             "type": "object",
             "properties": {
-                "command": {
-                    "description": "Decrypted value",
-                    "type": "string"
-                },
-                "executed_at": {
-                    "type": "string"
+                "connected": {
+                    "description": "False while detached and waiting to be resumed",
+                    "type": "boolean",
+                    "example": true
                 },
-                "execution_time_ms": {
-                    "type": "integer"
+                "id": {
+                    "type": "string",
+                    "example": "9c41d7e2a0b35f18"
                 },
-                "exit_code": {
-                    "type": "integer"
+                "observers": {
+                    "description": "Read-only observers watching the session",
+                    "type": "integer",
+                    "example": 0
                 },
-                "id": {
-                    "type": "integer"
+                "owner": {
+                    "description": "Principal that opened the session",
+                    "type": "string",
+                    "example": "user:admin"
                 },
-                "output": {
-                    "description": "Decrypted value",
-                    "type": "string"
+                "recording_id": {
+                    "description": "Recording of the session, if recorded",
+                    "type": "string",
+                    "example": "20251112T091500Z-3f2a9c1b"
                 },
                 "server": {
-                    "description": "\"local\" for local commands, or server name/IP",
-                    "type": "string"
+                    "description": "Target server (\"local\" for a shell on this host)",
+                    "type": "string",
+                    "example": "local"
                 },
-                "user": {
-                    "description": "User who executed the command (for local commands)",
+                "shell": {
+                    "description": "Shell running in the session",
+                    "type": "string",
+                    "example": "/bin/bash"
+                },
+                "started_at": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.CommandResult": {
+        "models.VaultConfigCreate": {
             "type": "object",
             "properties": {
-                "command": {
+                "address": {
                     "type": "string"
                 },
-                "executed_at": {
+                "auth_method": {
+                    "description": "\"token\" (default), \"approle\" or \"kubernetes\"",
                     "type": "string"
                 },
-                "execution_time_ms": {
-                    "description": "Execution time in milliseconds",
-                    "type": "integer"
+                "auth_mount": {
+                    "type": "string"
                 },
-                "exit_code": {
-                    "type": "integer"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "output": {
+                "jwt_path": {
                     "type": "string"
                 },
-                "user": {
+                "kubernetes_role": {
                     "type": "string"
-                }
-            }
-        },
-        "github_com_pozgo_web-cli_internal_models.EnvVariableCreate": {
-            "type": "object",
-            "required": [
-                "name",
-                "value"
-            ],
-            "properties": {
-                "description": {
+                },
+                "mount_path": {
                     "type": "string"
                 },
-                "group": {
-                    "description": "Optional, defaults to \"default\"",
+                "namespace": {
                     "type": "string"
                 },
-                "name": {
+                "role_id": {
                     "type": "string"
                 },
-                "value": {
+                "secret_id": {
+                    "type": "string"
+                },
+                "ssh_ca_mount": {
+                    "type": "string"
+                },
+                "ssh_ca_role": {
+                    "type": "string"
+                },
+                "ssh_ca_ttl": {
+                    "type": "string"
+                },
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.EnvVariableResponse": {
+        "models.VaultConfigResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "address": {
                     "type": "string"
                 },
-                "description": {
+                "auth_method": {
                     "type": "string"
                 },
-                "group": {
-                    "description": "Group/category for organization",
+                "auth_mount": {
+                    "type": "string"
+                },
+                "created_at": {
                     "type": "string"
                 },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "has_secret_id": {
+                    "description": "Indicates if an AppRole secret ID is configured",
+                    "type": "boolean"
+                },
+                "has_token": {
+                    "description": "Indicates if a token is configured",
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
+                "jwt_path": {
                     "type": "string"
                 },
-                "source": {
-                    "description": "\"sqlite\" or \"vault\"",
+                "kubernetes_role": {
                     "type": "string"
                 },
-                "updated_at": {
+                "mount_path": {
                     "type": "string"
                 },
-                "value": {
-                    "description": "Will be masked unless explicitly requested",
-                    "type": "string"
-                }
-            }
-        },
-        "github_com_pozgo_web-cli_internal_models.EnvVariableUpdate": {
-            "type": "object",
-            "properties": {
-                "description": {
+                "namespace": {
                     "type": "string"
                 },
-                "group": {
+                "role_id": {
                     "type": "string"
                 },
-                "name": {
+                "ssh_ca_mount": {
                     "type": "string"
                 },
-                "value": {
-                    "type": "string"
-                }
-            }
-        },
-        "github_com_pozgo_web-cli_internal_models.LocalUser": {
-            "type": "object",
-            "properties": {
-                "created_at": {
+                "ssh_ca_role": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "description": "Unix username (must be valid system username)",
+                "ssh_ca_ttl": {
                     "type": "string"
                 },
                 "updated_at": {
@@ -3185,572 +13314,685 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.LocalUserCreate": {
+        "models.VaultStatus": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
-                    "description": "Unix username",
+                "address": {
                     "type": "string"
-                }
-            }
-        },
-        "github_com_pozgo_web-cli_internal_models.LocalUserUpdate": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "description": "Unix username",
+                },
+                "configured": {
+                    "description": "Whether Vault is configured",
+                    "type": "boolean"
+                },
+                "connected": {
+                    "description": "Whether connection test passed",
+                    "type": "boolean"
+                },
+                "enabled": {
+                    "description": "Whether Vault is enabled",
+                    "type": "boolean"
+                },
+                "error": {
                     "type": "string"
+                },
+                "vault_sealed": {
+                    "type": "boolean"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.SSHKey": {
+        "models.Webhook": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "group": {
-                    "description": "Group/category for organization",
-                    "type": "string"
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "description": "Event types or patterns; empty for every event",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "script.failed",
+                        "command.*"
+                    ]
+                },
+                "has_secret": {
+                    "description": "Deliveries are signed",
+                    "type": "boolean"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
+                "last_delivered_at": {
+                    "description": "Time of the last delivery attempt",
                     "type": "string"
                 },
-                "private_key": {
-                    "description": "Decrypted value",
+                "last_error": {
                     "type": "string"
                 },
-                "source": {
-                    "description": "\"sqlite\" or \"vault\"",
-                    "type": "string"
+                "last_status": {
+                    "description": "Outcome of the last delivery: delivered or failed",
+                    "type": "string",
+                    "example": "delivered"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "ci"
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://ci.example.com/hooks/web-cli"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.SSHKeyCreate": {
+        "models.WebhookCreate": {
             "type": "object",
             "required": [
                 "name",
-                "private_key"
+                "url"
             ],
             "properties": {
-                "group": {
-                    "description": "Optional, defaults to \"default\"",
-                    "type": "string"
+                "enabled": {
+                    "description": "Optional, defaults to true",
+                    "type": "boolean"
+                },
+                "events": {
+                    "description": "Optional, defaults to every event",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "name": {
                     "type": "string"
                 },
-                "private_key": {
+                "secret": {
+                    "description": "Optional, signs deliveries with HMAC-SHA256",
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.SSHKeyUpdate": {
+        "models.WebhookTestResult": {
             "type": "object",
             "properties": {
-                "group": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
+                "delivered": {
+                    "type": "boolean"
                 },
-                "private_key": {
+                "error": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.SavedCommand": {
+        "models.WebhookUpdate": {
             "type": "object",
             "properties": {
-                "command": {
-                    "description": "The actual command to execute",
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "description": {
-                    "description": "Optional description",
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "is_remote": {
-                    "description": "True if this is a remote command",
+                "enabled": {
                     "type": "boolean"
                 },
+                "events": {
+                    "description": "Replaces the filters when present; [] subscribes to every event",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "name": {
-                    "description": "Friendly name for the command",
                     "type": "string"
                 },
-                "server_id": {
-                    "description": "Foreign key to servers table (for remote commands)",
-                    "type": "integer"
-                },
-                "ssh_key_id": {
-                    "description": "Foreign key to ssh_keys table (for remote commands)",
-                    "type": "integer"
-                },
-                "updated_at": {
+                "secret": {
+                    "description": "\"\" removes the secret",
                     "type": "string"
                 },
-                "user": {
-                    "description": "User to run as (default: root)",
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.SavedCommandCreate": {
+        "server.ComponentHealth": {
+            "description": "Health status of a single server component",
             "type": "object",
-            "required": [
-                "command",
-                "name"
-            ],
             "properties": {
-                "command": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "is_remote": {
-                    "description": "True if this is a remote command",
-                    "type": "boolean"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "server_id": {
-                    "description": "For remote commands",
-                    "type": "integer"
-                },
-                "ssh_key_id": {
-                    "description": "For remote commands",
-                    "type": "integer"
+                "message": {
+                    "type": "string",
+                    "example": "connected"
                 },
-                "user": {
-                    "description": "Optional, defaults to \"root\"",
-                    "type": "string"
+                "status": {
+                    "type": "string",
+                    "example": "ok"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.SavedCommandUpdate": {
+        "server.CurrentUserResponse": {
+            "description": "Current system user information",
             "type": "object",
             "properties": {
-                "command": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
+                "gid": {
+                    "type": "string",
+                    "example": "0"
                 },
-                "is_remote": {
-                    "type": "boolean"
+                "home_dir": {
+                    "type": "string",
+                    "example": "/root"
                 },
                 "name": {
-                    "type": "string"
-                },
-                "server_id": {
-                    "type": "integer"
+                    "type": "string",
+                    "example": "root"
                 },
-                "ssh_key_id": {
-                    "type": "integer"
+                "uid": {
+                    "type": "string",
+                    "example": "0"
                 },
-                "user": {
-                    "type": "string"
+                "username": {
+                    "type": "string",
+                    "example": "root"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.ScriptExecution": {
+        "server.ErrorResponse": {
+            "description": "Error response returned by the API",
             "type": "object",
             "properties": {
-                "env_var_groups": {
-                    "description": "Groups of env vars to include (Vault, paired with EnvVarNames)",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "env_var_ids": {
-                    "description": "Specific env var IDs to include (SQLite)",
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
                 },
-                "env_var_names": {
-                    "description": "Names of env vars to include (Vault)",
+                "fields": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/apierror.FieldError"
                     }
                 },
-                "include_env_vars": {
-                    "description": "Deprecated: use EnvVarIDs instead",
-                    "type": "boolean"
+                "message": {
+                    "type": "string",
+                    "example": "Server not found"
                 },
-                "is_remote": {
-                    "description": "True if remote execution",
-                    "type": "boolean"
+                "request_id": {
+                    "type": "string",
+                    "example": "5f2b8c1e9a7d4e3f"
+                }
+            }
+        },
+        "server.GitSyncStatus": {
+            "type": "object",
+            "properties": {
+                "branch": {
+                    "type": "string"
                 },
-                "script_group": {
-                    "description": "Script group for execution (Vault)",
+                "commit": {
+                    "description": "Commit the scripts were synced from",
                     "type": "string"
                 },
-                "script_id": {
-                    "description": "ID of the script to execute (SQLite)",
+                "created": {
+                    "description": "Scripts added by the last sync",
                     "type": "integer"
                 },
-                "script_name": {
-                    "description": "Name of the script to execute (Vault)",
+                "deleted": {
+                    "description": "Scripts removed because their file was deleted",
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "Why the last sync failed",
                     "type": "string"
                 },
-                "server_group": {
-                    "description": "Server group for remote execution (Vault)",
+                "group": {
                     "type": "string"
                 },
-                "server_id": {
-                    "description": "Server ID for remote execution (SQLite)",
+                "interval_seconds": {
                     "type": "integer"
                 },
-                "server_name": {
-                    "description": "Server name for remote execution (Vault)",
+                "last_sync": {
                     "type": "string"
                 },
-                "ssh_key_group": {
-                    "description": "SSH key group for remote execution (Vault)",
+                "path": {
                     "type": "string"
                 },
-                "ssh_key_id": {
-                    "description": "SSH key ID for remote execution (SQLite)",
+                "skipped": {
+                    "description": "Files that could not be synced, with the reason",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated": {
+                    "description": "Scripts changed by the last sync",
                     "type": "integer"
                 },
-                "ssh_key_name": {
-                    "description": "SSH key name for remote execution (Vault)",
+                "url": {
                     "type": "string"
+                }
+            }
+        },
+        "server.HealthResponse": {
+            "description": "Health check response with per-component status",
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/server.ComponentHealth"
+                    }
                 },
-                "ssh_password": {
-                    "description": "SSH password (for remote, if key auth fails)",
-                    "type": "string"
+                "status": {
+                    "type": "string",
+                    "example": "ok"
                 },
-                "sudo_password": {
-                    "description": "Sudo password (required when user != current for local)",
+                "timestamp": {
                     "type": "string"
                 },
-                "user": {
-                    "description": "User to run as (default: root)",
-                    "type": "string"
+                "version": {
+                    "type": "string",
+                    "example": "1.1.0"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.ScriptPresetCreate": {
+        "server.InventoryStatus": {
             "type": "object",
-            "required": [
-                "name",
-                "script_id"
-            ],
             "properties": {
-                "description": {
-                    "type": "string"
+                "created": {
+                    "description": "Servers added by the last sync",
+                    "type": "integer"
                 },
-                "env_var_ids": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "deleted": {
+                    "description": "Servers removed because their instance is gone",
+                    "type": "integer"
                 },
-                "is_remote": {
+                "discovered": {
+                    "description": "Hosts reported by the providers",
+                    "type": "integer"
+                },
+                "enabled": {
                     "type": "boolean"
                 },
-                "name": {
+                "error": {
+                    "description": "Why the last sync failed for some providers",
                     "type": "string"
                 },
-                "script_id": {
+                "interval_seconds": {
                     "type": "integer"
                 },
-                "server_id": {
-                    "type": "integer"
+                "last_sync": {
+                    "type": "string"
                 },
-                "ssh_key_id": {
-                    "type": "integer"
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "user": {
-                    "type": "string"
+                "skipped": {
+                    "description": "Hosts that could not be synced, with the reason",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated": {
+                    "description": "Servers whose name, address or group changed",
+                    "type": "integer"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.ScriptPresetResponse": {
+        "server.LocalUserImportRequest": {
+            "description": "Login users to import as local users",
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "env_var_ids": {
+                "names": {
+                    "description": "Empty to import every discovered user",
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "server.LocalUserImportResponse": {
+            "description": "Local users created by an import, and the names already listed",
+            "type": "object",
+            "properties": {
+                "imported": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LocalUser"
                     }
                 },
-                "id": {
-                    "type": "integer"
+                "skipped": {
+                    "description": "Already listed under local users",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "server.LoginRequest": {
+            "description": "Username and password for session login",
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "changeme"
                 },
-                "is_remote": {
+                "username": {
+                    "type": "string",
+                    "example": "admin"
+                }
+            }
+        },
+        "server.MaintenanceRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
                     "type": "boolean"
                 },
-                "name": {
+                "reason": {
+                    "type": "string",
+                    "example": "Incident 42: credentials rotation"
+                }
+            }
+        },
+        "server.QuotaStatusResponse": {
+            "type": "object",
+            "properties": {
+                "quota": {
+                    "description": "null when no quota applies",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ExecutionQuota"
+                        }
+                    ]
+                },
+                "usage": {
+                    "$ref": "#/definitions/middleware.QuotaUsage"
+                }
+            }
+        },
+        "server.RestoreResult": {
+            "type": "object",
+            "properties": {
+                "schema_version": {
+                    "description": "Schema version after applying migrations the backup lacked",
+                    "type": "integer",
+                    "example": 29
+                }
+            }
+        },
+        "server.SessionResponse": {
+            "description": "Session token and expiry returned by login and refresh",
+            "type": "object",
+            "properties": {
+                "expires_at": {
                     "type": "string"
                 },
-                "script_id": {
-                    "type": "integer"
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
                 },
-                "server_id": {
-                    "type": "integer"
+                "username": {
+                    "type": "string",
+                    "example": "admin"
+                }
+            }
+        },
+        "server.ShellInfo": {
+            "description": "Information about an available shell",
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Shell used when the caller does not pick one",
+                    "type": "boolean"
                 },
-                "ssh_key_id": {
-                    "type": "integer"
+                "name": {
+                    "type": "string",
+                    "example": "bash"
                 },
-                "updated_at": {
+                "path": {
+                    "type": "string",
+                    "example": "/bin/bash"
+                }
+            }
+        },
+        "server.StreamMessage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "output chunk or error message",
                     "type": "string"
                 },
-                "user": {
+                "result": {
+                    "description": "final result",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ScriptResult"
+                        }
+                    ]
+                },
+                "type": {
+                    "description": "\"output\", \"result\", \"error\"",
+                    "type": "string"
+                }
+            }
+        },
+        "server.SudoPasswordRequest": {
+            "description": "Sudo password reused by the session's local executions",
+            "type": "object",
+            "properties": {
+                "sudo_password": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.ScriptPresetUpdate": {
+        "server.SudoPasswordResponse": {
+            "description": "Whether a sudo password is cached for the session, and until when",
             "type": "object",
             "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "env_var_ids": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "cached": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "is_remote": {
-                    "type": "boolean"
-                },
-                "name": {
+                "expires_at": {
                     "type": "string"
-                },
-                "script_id": {
-                    "type": "integer"
-                },
-                "server_id": {
-                    "type": "integer"
-                },
-                "ssh_key_id": {
-                    "type": "integer"
-                },
-                "user": {
+                }
+            }
+        },
+        "server.SudoTestRequest": {
+            "description": "Sudo password to check, empty to only check passwordless sudo",
+            "type": "object",
+            "properties": {
+                "sudo_password": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.ScriptResult": {
+        "server.SudoTestResponse": {
+            "description": "Outcome of a sudo test for a local user",
             "type": "object",
             "properties": {
-                "env_vars_injected": {
-                    "description": "Number of env vars injected",
-                    "type": "integer"
-                },
-                "execution_time_ms": {
-                    "description": "Execution time in milliseconds",
-                    "type": "integer"
-                },
-                "exit_code": {
-                    "type": "integer"
+                "message": {
+                    "type": "string",
+                    "example": "sudo works for deploy with the password"
                 },
                 "output": {
-                    "type": "string"
+                    "description": "What sudo printed when a check failed",
+                    "type": "string",
+                    "example": "sudo: a password is required"
                 },
-                "script_id": {
-                    "type": "integer"
+                "password_tested": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "script_name": {
-                    "type": "string"
+                "password_works": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "server": {
-                    "description": "\"local\" or server name",
-                    "type": "string"
+                "passwordless": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "sudo_required": {
+                    "description": "False for the server's own user",
+                    "type": "boolean",
+                    "example": true
                 },
                 "user": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "deploy"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.Server": {
+        "server.SystemUserResponse": {
+            "description": "Login user discovered from /etc/passwd",
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "group": {
-                    "description": "Group/category for organization",
-                    "type": "string"
+                "gid": {
+                    "type": "integer",
+                    "example": 1001
                 },
-                "id": {
-                    "type": "integer"
+                "home_dir": {
+                    "type": "string",
+                    "example": "/home/deploy"
                 },
-                "ip_address": {
-                    "description": "IP address",
-                    "type": "string"
+                "imported": {
+                    "description": "Already listed under local users",
+                    "type": "boolean",
+                    "example": false
                 },
                 "name": {
-                    "description": "Hostname (must follow hostname conventions)",
-                    "type": "string"
-                },
-                "port": {
-                    "description": "SSH port (default: 22)",
-                    "type": "integer"
+                    "type": "string",
+                    "example": "Deploy User"
                 },
-                "source": {
-                    "description": "\"sqlite\" or \"vault\"",
-                    "type": "string"
+                "shell": {
+                    "type": "string",
+                    "example": "/bin/bash"
                 },
-                "updated_at": {
-                    "type": "string"
+                "uid": {
+                    "type": "integer",
+                    "example": 1001
                 },
                 "username": {
-                    "description": "SSH username for remote connections",
-                    "type": "string"
+                    "type": "string",
+                    "example": "deploy"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.ServerCreate": {
+        "server.UnlockRequest": {
+            "description": "Client IP and/or username to clear failed login attempts for",
             "type": "object",
             "properties": {
-                "group": {
-                    "description": "Optional, defaults to \"default\"",
-                    "type": "string"
-                },
-                "ip_address": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "port": {
-                    "description": "Optional, defaults to 22 if not provided",
-                    "type": "integer"
+                "ip": {
+                    "type": "string",
+                    "example": "192.0.2.10"
                 },
                 "username": {
-                    "description": "SSH username for remote connections",
-                    "type": "string"
+                    "type": "string",
+                    "example": "admin"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.ServerUpdate": {
+        "server.UnlockResponse": {
+            "description": "Result of an unlock request",
             "type": "object",
             "properties": {
-                "group": {
-                    "type": "string"
-                },
-                "ip_address": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "port": {
-                    "type": "integer"
-                },
-                "username": {
-                    "type": "string"
+                "unlocked": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.VaultConfigCreate": {
+        "server.VaultSyncPolicy": {
             "type": "object",
             "properties": {
-                "address": {
+                "conflict": {
                     "type": "string"
                 },
-                "enabled": {
-                    "type": "boolean"
-                },
-                "mount_path": {
-                    "type": "string"
-                },
-                "namespace": {
-                    "type": "string"
+                "directions": {
+                    "description": "By resource type",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "token": {
-                    "type": "string"
+                "interval_seconds": {
+                    "description": "0 when only manual syncs run",
+                    "type": "integer"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.VaultConfigResponse": {
+        "server.VaultSyncResult": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
+                "conflicts": {
+                    "description": "Differing items left alone by the skip rule, as group/name",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "created_at": {
+                "direction": {
                     "type": "string"
                 },
-                "enabled": {
-                    "type": "boolean"
+                "error": {
+                    "description": "Why the type could not be synced",
+                    "type": "string"
                 },
-                "has_token": {
-                    "description": "Indicates if a token is configured",
-                    "type": "boolean"
+                "skipped": {
+                    "description": "Items that could not be written, with the reason",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "id": {
+                "to_sqlite": {
+                    "description": "Items written to SQLite",
                     "type": "integer"
                 },
-                "mount_path": {
-                    "type": "string"
+                "to_vault": {
+                    "description": "Items written to Vault",
+                    "type": "integer"
                 },
-                "namespace": {
+                "type": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "unchanged": {
+                    "description": "Items already equal in both stores",
+                    "type": "integer"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_models.VaultStatus": {
+        "server.VaultSyncStatus": {
             "type": "object",
             "properties": {
-                "address": {
+                "error": {
+                    "description": "Why the last sync could not run",
                     "type": "string"
                 },
-                "configured": {
-                    "description": "Whether Vault is configured",
-                    "type": "boolean"
-                },
-                "connected": {
-                    "description": "Whether connection test passed",
-                    "type": "boolean"
-                },
-                "enabled": {
-                    "description": "Whether Vault is enabled",
-                    "type": "boolean"
-                },
-                "error": {
+                "last_sync": {
                     "type": "string"
                 },
-                "vault_sealed": {
-                    "type": "boolean"
+                "policy": {
+                    "$ref": "#/definitions/server.VaultSyncPolicy"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.VaultSyncResult"
+                    }
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_vault.BashScript": {
+        "vault.BashScript": {
             "type": "object",
             "properties": {
                 "content": {
@@ -3765,12 +14007,15 @@ const docTemplate = `{
                 "group": {
                     "type": "string"
                 },
+                "interpreter": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_vault.EnvVariable": {
+        "vault.EnvVariable": {
             "type": "object",
             "properties": {
                 "description": {
@@ -3787,7 +14032,7 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_vault.SSHKey": {
+        "vault.SSHKey": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -3804,7 +14049,7 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_pozgo_web-cli_internal_vault.Server": {
+        "vault.Server": {
             "type": "object",
             "properties": {
                 "group": {
@@ -3823,87 +14068,6 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
-        },
-        "internal_server.CurrentUserResponse": {
-            "description": "Current system user information",
-            "type": "object",
-            "properties": {
-                "gid": {
-                    "type": "string",
-                    "example": "0"
-                },
-                "home_dir": {
-                    "type": "string",
-                    "example": "/root"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "root"
-                },
-                "uid": {
-                    "type": "string",
-                    "example": "0"
-                },
-                "username": {
-                    "type": "string",
-                    "example": "root"
-                }
-            }
-        },
-        "internal_server.ErrorResponse": {
-            "description": "Error response returned by the API",
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Invalid request body"
-                }
-            }
-        },
-        "internal_server.HealthResponse": {
-            "description": "Health check response",
-            "type": "object",
-            "properties": {
-                "status": {
-                    "type": "string",
-                    "example": "ok"
-                }
-            }
-        },
-        "internal_server.ShellInfo": {
-            "description": "Information about an available shell",
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string",
-                    "example": "bash"
-                },
-                "path": {
-                    "type": "string",
-                    "example": "/bin/bash"
-                }
-            }
-        },
-        "internal_server.StreamMessage": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "description": "output chunk or error message",
-                    "type": "string"
-                },
-                "result": {
-                    "description": "final result",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/github_com_pozgo_web-cli_internal_models.ScriptResult"
-                        }
-                    ]
-                },
-                "type": {
-                    "description": "\"output\", \"result\", \"error\"",
-                    "type": "string"
-                }
-            }
         }
     },
     "securityDefinitions": {
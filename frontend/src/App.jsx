@@ -2,6 +2,7 @@ import React, { useState, useMemo, useEffect } from 'react';
 import { BrowserRouter, Routes, Route } from 'react-router-dom';
 import { ThemeProvider, CssBaseline } from '@mui/material';
 import { getTheme } from './theme/theme';
+import { BASE_PATH } from './basePath';
 import Header from './components/Header';
 import Dashboard from './components/Dashboard';
 import AdminPanel from './components/AdminPanel';
@@ -41,7 +42,7 @@ function App() {
   const theme = useMemo(() => getTheme(mode), [mode]);
 
   return (
-    <BrowserRouter>
+    <BrowserRouter basename={BASE_PATH || undefined}>
       <ThemeProvider theme={theme}>
         <CssBaseline />
         <Header mode={mode} toggleTheme={toggleTheme} />
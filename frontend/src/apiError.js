@@ -0,0 +1,17 @@
+/**
+ * Read the message of an API error response.
+ * The API answers errors with {code, message, fields, request_id}; the request ID
+ * is appended so a reported failure can be found in the server logs.
+ */
+export const errorMessage = async (response, fallback = 'Request failed') => {
+  const text = await response.text();
+  try {
+    const data = JSON.parse(text);
+    if (data && data.message) {
+      return data.request_id ? `${data.message} (request ${data.request_id})` : data.message;
+    }
+  } catch {
+    // Not an API error envelope, such as a proxy error page
+  }
+  return text || fallback;
+};
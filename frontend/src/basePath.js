@@ -0,0 +1,28 @@
+/**
+ * Base path support for serving Web CLI under a URL prefix (e.g. behind a reverse proxy).
+ * The server injects window.__WEBCLI_BASE_PATH__ into index.html when BASE_PATH is configured.
+ */
+export const BASE_PATH = (window.__WEBCLI_BASE_PATH__ || '').replace(/\/+$/, '');
+
+/**
+ * Prefix an absolute application path (e.g. "/api/keys") with the base path
+ */
+export const withBasePath = (path) => `${BASE_PATH}${path}`;
+
+/**
+ * Wrap window.fetch so absolute /api requests resolve under the base path.
+ * Components can keep using fetch('/api/...') unchanged.
+ */
+export const installBasePathFetch = () => {
+  if (!BASE_PATH) {
+    return;
+  }
+
+  const originalFetch = window.fetch.bind(window);
+  window.fetch = (input, init) => {
+    if (typeof input === 'string' && input.startsWith('/api/')) {
+      return originalFetch(withBasePath(input), init);
+    }
+    return originalFetch(input, init);
+  };
+};
@@ -11,6 +11,7 @@ import {
 } from '@mui/material';
 import StorageSelector from './shared/StorageSelector';
 import GroupInput from './shared/GroupInput';
+import { errorMessage } from '../apiError';
 
 /**
  * AddKeyDialog component - dialog for adding new SSH keys
@@ -62,8 +63,7 @@ const AddKeyDialog = ({ open, onClose, onKeyAdded }) => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to create SSH key');
+        throw new Error(await errorMessage(response, 'Failed to create SSH key'));
       }
 
       // Success - reset form and notify parent
@@ -9,6 +9,7 @@ import {
   Alert,
   Box,
 } from '@mui/material';
+import { errorMessage } from '../apiError';
 
 /**
  * AddLocalUserDialog component - dialog for adding new local users
@@ -55,8 +56,7 @@ const AddLocalUserDialog = ({ open, onClose, onUserAdded }) => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to create local user');
+        throw new Error(await errorMessage(response, 'Failed to create local user'));
       }
 
       // Success - reset form and notify parent
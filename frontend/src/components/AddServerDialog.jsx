@@ -11,6 +11,7 @@ import {
 } from '@mui/material';
 import StorageSelector from './shared/StorageSelector';
 import GroupInput from './shared/GroupInput';
+import { errorMessage } from '../apiError';
 
 /**
  * AddServerDialog component - dialog for adding new servers
@@ -89,8 +90,7 @@ const AddServerDialog = ({ open, onClose, onServerAdded }) => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to create server');
+        throw new Error(await errorMessage(response, 'Failed to create server'));
       }
 
       // Success - reset form and notify parent
@@ -78,14 +78,29 @@ const CommandHistory = () => {
     return new Date(dateString).toLocaleString();
   };
 
-  const getStatusColor = (exitCode) => {
-    if (exitCode === null || exitCode === undefined) return 'default';
-    return exitCode === 0 ? 'success' : 'error';
+  // Scripts can declare exit codes as ok or warnings; older entries have no severity
+  const getSeverity = (entry) => {
+    if (entry.severity) return entry.severity;
+    if (entry.exit_code === null || entry.exit_code === undefined) return null;
+    return entry.exit_code === 0 ? 'ok' : 'critical';
   };
 
-  const getStatusLabel = (exitCode) => {
-    if (exitCode === null || exitCode === undefined) return 'Unknown';
-    return exitCode === 0 ? 'Success' : `Failed (${exitCode})`;
+  const getStatusColor = (entry) => {
+    switch (getSeverity(entry)) {
+      case 'ok': return 'success';
+      case 'warning': return 'warning';
+      case 'critical': return 'error';
+      default: return 'default';
+    }
+  };
+
+  const getStatusLabel = (entry) => {
+    switch (getSeverity(entry)) {
+      case 'ok': return entry.exit_code === 0 ? 'Success' : `Success (${entry.exit_code})`;
+      case 'warning': return `Warning (${entry.exit_code})`;
+      case 'critical': return `Failed (${entry.exit_code})`;
+      default: return 'Unknown';
+    }
   };
 
   return (
@@ -172,8 +187,8 @@ const CommandHistory = () => {
                     <TableCell>{entry.user || '-'}</TableCell>
                     <TableCell>
                       <Chip
-                        label={getStatusLabel(entry.exit_code)}
-                        color={getStatusColor(entry.exit_code)}
+                        label={getStatusLabel(entry)}
+                        color={getStatusColor(entry)}
                         size="small"
                       />
                     </TableCell>
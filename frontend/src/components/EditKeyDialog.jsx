@@ -9,6 +9,7 @@ import {
   Alert,
   Box,
 } from '@mui/material';
+import { errorMessage } from '../apiError';
 
 /**
  * EditKeyDialog component - dialog for editing existing SSH keys
@@ -66,8 +67,7 @@ const EditKeyDialog = ({ open, onClose, onKeyUpdated, keyData }) => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to update SSH key');
+        throw new Error(await errorMessage(response, 'Failed to update SSH key'));
       }
 
       // Success - reset form and notify parent
@@ -9,6 +9,7 @@ import {
   Alert,
   Box,
 } from '@mui/material';
+import { errorMessage } from '../apiError';
 
 /**
  * EditLocalUserDialog component - dialog for editing existing local users
@@ -62,8 +63,7 @@ const EditLocalUserDialog = ({ open, onClose, onUserUpdated, userData }) => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to update local user');
+        throw new Error(await errorMessage(response, 'Failed to update local user'));
       }
 
       // Success - reset form and notify parent
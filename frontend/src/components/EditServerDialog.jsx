@@ -9,6 +9,7 @@ import {
   Alert,
   Box,
 } from '@mui/material';
+import { errorMessage } from '../apiError';
 
 /**
  * EditServerDialog component - dialog for editing existing servers
@@ -95,8 +96,7 @@ const EditServerDialog = ({ open, onClose, onServerUpdated, serverData }) => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to update server');
+        throw new Error(await errorMessage(response, 'Failed to update server'));
       }
 
       // Success - reset form and notify parent
@@ -26,6 +26,7 @@ import SourceChip from './shared/SourceChip';
 import GroupSelector from './shared/GroupSelector';
 import StorageSelector from './shared/StorageSelector';
 import GroupInput from './shared/GroupInput';
+import { errorMessage } from '../apiError';
 
 /**
  * EnvVariableList component - displays and manages encrypted environment variables
@@ -200,8 +201,7 @@ const EnvVariableList = () => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to save environment variable');
+        throw new Error(await errorMessage(response, 'Failed to save environment variable'));
       }
 
       setOpenDialog(false);
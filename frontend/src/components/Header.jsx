@@ -1,10 +1,11 @@
-import React from 'react';
+import React, { useState, useEffect } from 'react';
 import { AppBar, Toolbar, Typography, Box, IconButton, Tooltip } from '@mui/material';
-import { Api } from '@mui/icons-material';
+import { Api, Logout } from '@mui/icons-material';
 import GitHubIcon from '@mui/icons-material/GitHub';
 import { useNavigate } from 'react-router-dom';
 import ThemeToggle from './ThemeToggle';
 import VaultIcon from './shared/VaultIcon';
+import { withBasePath } from '../basePath';
 import logo from "../../assets/favicon.ico"
 
 /**
@@ -15,6 +16,20 @@ import logo from "../../assets/favicon.ico"
  */
 const Header = ({ mode, toggleTheme }) => {
   const navigate = useNavigate();
+  const [session, setSession] = useState(null);
+
+  // Show the logout button only when signed in with a login session
+  useEffect(() => {
+    fetch('/api/auth/session')
+      .then((response) => (response.ok ? response.json() : null))
+      .then(setSession)
+      .catch(() => setSession(null));
+  }, []);
+
+  const handleLogout = async () => {
+    await fetch('/api/auth/logout', { method: 'POST' });
+    window.location.reload();
+  };
 
   return (
     <AppBar position="static" elevation={0}>
@@ -41,7 +56,7 @@ const Header = ({ mode, toggleTheme }) => {
           <IconButton
             color="inherit"
             component="a"
-            href="/swagger/"
+            href={withBasePath('/swagger/')}
             target="_blank"
             rel="noopener noreferrer"
             sx={{ mr: 1 }}
@@ -62,6 +77,13 @@ const Header = ({ mode, toggleTheme }) => {
           </IconButton>
         </Tooltip>
         <ThemeToggle mode={mode} toggleTheme={toggleTheme} />
+        {session && (
+          <Tooltip title={`Log out ${session.username}`}>
+            <IconButton color="inherit" onClick={handleLogout} sx={{ ml: 1 }}>
+              <Logout />
+            </IconButton>
+          </Tooltip>
+        )}
       </Toolbar>
     </AppBar>
   );
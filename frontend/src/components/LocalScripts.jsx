@@ -26,6 +26,7 @@ import {
 } from '@mui/material';
 import { PlayArrow, ArrowBack, ExpandMore, Code, Save, BookmarkBorder, Storage, Lock } from '@mui/icons-material';
 import { useNavigate } from 'react-router-dom';
+import { errorMessage } from '../apiError';
 
 /**
  * LocalScripts component - execute stored bash scripts locally
@@ -54,6 +55,7 @@ const LocalScripts = () => {
   const [saveDialogOpen, setSaveDialogOpen] = useState(false);
   const [presetName, setPresetName] = useState('');
   const [presetDescription, setPresetDescription] = useState('');
+  const [presetSlackNotify, setPresetSlackNotify] = useState('');
   const [savingPreset, setSavingPreset] = useState(false);
 
   // Fetch scripts, users, env vars, and presets on mount
@@ -170,6 +172,7 @@ const LocalScripts = () => {
   const handleSavePresetOpen = () => {
     setPresetName('');
     setPresetDescription('');
+    setPresetSlackNotify('');
     setSaveDialogOpen(true);
   };
 
@@ -177,6 +180,7 @@ const LocalScripts = () => {
     setSaveDialogOpen(false);
     setPresetName('');
     setPresetDescription('');
+    setPresetSlackNotify('');
   };
 
   const handleSavePresetSubmit = async () => {
@@ -194,6 +198,7 @@ const LocalScripts = () => {
       const payload = {
         name: presetName.trim(),
         description: presetDescription.trim(),
+        slack_notify: presetSlackNotify,
         script_id: parseInt(selectedScriptId, 10),
         env_var_ids: selectedEnvVarIds,
         is_remote: false,
@@ -209,8 +214,7 @@ const LocalScripts = () => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to save preset');
+        throw new Error(await errorMessage(response, 'Failed to save preset'));
       }
 
       const savedPreset = await response.json();
@@ -318,6 +322,11 @@ const LocalScripts = () => {
       payload.sudo_password = password;
     }
 
+    // Executions started from a preset follow its notification settings
+    if (selectedPresetId) {
+      payload.preset_id = parseInt(selectedPresetId, 10);
+    }
+
     try {
       const response = await fetch('/api/bash-scripts/execute/stream', {
         method: 'POST',
@@ -328,8 +337,7 @@ const LocalScripts = () => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to execute script');
+        throw new Error(await errorMessage(response, 'Failed to execute script'));
       }
 
       // Read the SSE stream
@@ -755,6 +763,18 @@ const LocalScripts = () => {
             rows={2}
             placeholder="Brief description of this preset..."
           />
+          <FormControl fullWidth sx={{ mt: 2 }}>
+            <InputLabel>Slack Notifications</InputLabel>
+            <Select
+              value={presetSlackNotify}
+              onChange={(e) => setPresetSlackNotify(e.target.value)}
+              label="Slack Notifications"
+            >
+              <MenuItem value="">Never</MenuItem>
+              <MenuItem value="always">Every run</MenuItem>
+              <MenuItem value="failures">Failed runs only</MenuItem>
+            </Select>
+          </FormControl>
           <Box sx={{ mt: 2, p: 2, bgcolor: 'action.hover', borderRadius: 1 }}>
             <Typography variant="subtitle2" gutterBottom>
               This preset will save:
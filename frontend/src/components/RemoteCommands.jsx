@@ -25,6 +25,7 @@ import {
 import { PlayArrow, ArrowBack, Save, Storage, Lock } from '@mui/icons-material';
 import { useNavigate, useLocation } from 'react-router-dom';
 import GroupSelector from './shared/GroupSelector';
+import { errorMessage } from '../apiError';
 
 /**
  * RemoteCommands component - execute commands on remote servers via SSH
@@ -273,8 +274,7 @@ const RemoteCommands = () => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to execute command');
+        throw new Error(await errorMessage(response, 'Failed to execute command'));
       }
 
       const result = await response.json();
@@ -27,6 +27,7 @@ import {
 import { PlayArrow, ArrowBack, ExpandMore, Code, Cloud, Save, Storage, Lock } from '@mui/icons-material';
 import { useNavigate } from 'react-router-dom';
 import GroupSelector from './shared/GroupSelector';
+import { errorMessage } from '../apiError';
 
 /**
  * RemoteScripts component - execute stored bash scripts on remote servers
@@ -59,6 +60,7 @@ const RemoteScripts = () => {
   const [saveDialogOpen, setSaveDialogOpen] = useState(false);
   const [presetName, setPresetName] = useState('');
   const [presetDescription, setPresetDescription] = useState('');
+  const [presetSlackNotify, setPresetSlackNotify] = useState('');
   const [savingPreset, setSavingPreset] = useState(false);
   const [scriptGroupFilter, setScriptGroupFilter] = useState('all');
   const [serverGroupFilter, setServerGroupFilter] = useState('all');
@@ -223,6 +225,7 @@ const RemoteScripts = () => {
   const handleSavePresetOpen = () => {
     setPresetName('');
     setPresetDescription('');
+    setPresetSlackNotify('');
     setSaveDialogOpen(true);
   };
 
@@ -230,6 +233,7 @@ const RemoteScripts = () => {
     setSaveDialogOpen(false);
     setPresetName('');
     setPresetDescription('');
+    setPresetSlackNotify('');
   };
 
   const handleSavePresetSubmit = async () => {
@@ -247,6 +251,7 @@ const RemoteScripts = () => {
       const payload = {
         name: presetName.trim(),
         description: presetDescription.trim(),
+        slack_notify: presetSlackNotify,
         script_id: parseInt(selectedScriptId, 10),
         env_var_ids: selectedEnvVarIds,
         is_remote: true,
@@ -264,8 +269,7 @@ const RemoteScripts = () => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to save preset');
+        throw new Error(await errorMessage(response, 'Failed to save preset'));
       }
 
       const savedPreset = await response.json();
@@ -405,6 +409,11 @@ const RemoteScripts = () => {
       payload.ssh_password = password;
     }
 
+    // Executions started from a preset follow its notification settings
+    if (selectedPresetId) {
+      payload.preset_id = parseInt(selectedPresetId, 10);
+    }
+
     try {
       const response = await fetch('/api/bash-scripts/execute/stream', {
         method: 'POST',
@@ -415,8 +424,7 @@ const RemoteScripts = () => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to execute script');
+        throw new Error(await errorMessage(response, 'Failed to execute script'));
       }
 
       // Read the SSE stream
@@ -1000,6 +1008,18 @@ const RemoteScripts = () => {
             rows={2}
             placeholder="Brief description of this preset..."
           />
+          <FormControl fullWidth sx={{ mt: 2 }}>
+            <InputLabel>Slack Notifications</InputLabel>
+            <Select
+              value={presetSlackNotify}
+              onChange={(e) => setPresetSlackNotify(e.target.value)}
+              label="Slack Notifications"
+            >
+              <MenuItem value="">Never</MenuItem>
+              <MenuItem value="always">Every run</MenuItem>
+              <MenuItem value="failures">Failed runs only</MenuItem>
+            </Select>
+          </FormControl>
           <Box sx={{ mt: 2, p: 2, bgcolor: 'action.hover', borderRadius: 1 }}>
             <Typography variant="subtitle2" gutterBottom>
               This preset will save:
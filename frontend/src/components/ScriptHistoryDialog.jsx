@@ -0,0 +1,143 @@
+import React, { useCallback, useEffect, useState } from 'react';
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  DialogActions,
+  Button,
+  Alert,
+  Box,
+  List,
+  ListItemButton,
+  ListItemText,
+  Typography,
+} from '@mui/material';
+import DiffView from './shared/DiffView';
+import { errorMessage } from '../apiError';
+
+/**
+ * ScriptHistoryDialog component - browse a script's versions, see how each
+ * differs from the latest one and roll back to it
+ * @param {Object} props - Component props
+ * @param {boolean} props.open - Whether the dialog is open
+ * @param {Function} props.onClose - Function to close the dialog
+ * @param {Object} props.script - Script whose history is shown
+ * @param {Function} props.onRestored - Called after the script was rolled back
+ */
+const ScriptHistoryDialog = ({ open, onClose, script, onRestored }) => {
+  const [versions, setVersions] = useState([]);
+  const [selected, setSelected] = useState(null);
+  const [diff, setDiff] = useState('');
+  const [error, setError] = useState(null);
+
+  // Load the version list, newest first
+  const fetchVersions = useCallback(async () => {
+    if (!script) return;
+    try {
+      setError(null);
+      const response = await fetch(`/api/bash-scripts/${script.id}/versions`);
+      if (!response.ok) {
+        throw new Error(await errorMessage(response));
+      }
+      setVersions((await response.json()) || []);
+    } catch (err) {
+      setError(err.message || 'Failed to load versions');
+    }
+  }, [script]);
+
+  useEffect(() => {
+    if (open) {
+      setSelected(null);
+      setDiff('');
+      fetchVersions();
+    }
+  }, [open, fetchVersions]);
+
+  // Show how the selected version differs from the latest one
+  const handleSelect = async (version) => {
+    setSelected(version);
+    try {
+      setError(null);
+      const response = await fetch(`/api/bash-scripts/${script.id}/versions/${version.version}/diff`);
+      if (!response.ok) {
+        throw new Error(await errorMessage(response));
+      }
+      const data = await response.json();
+      setDiff(data.diff);
+    } catch (err) {
+      setError(err.message || 'Failed to load diff');
+    }
+  };
+
+  // Roll the script back to the selected version
+  const handleRollback = async () => {
+    if (!window.confirm(`Restore version ${selected.version} of this script?`)) {
+      return;
+    }
+    try {
+      setError(null);
+      const response = await fetch(`/api/bash-scripts/${script.id}/versions/${selected.version}/rollback`, {
+        method: 'POST',
+      });
+      if (!response.ok) {
+        throw new Error(await errorMessage(response));
+      }
+      onRestored(selected.version);
+      onClose();
+    } catch (err) {
+      setError(err.message || 'Failed to roll back');
+    }
+  };
+
+  const latest = versions.length > 0 ? versions[0].version : 0;
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="md" fullWidth>
+      <DialogTitle>History: {script?.name}</DialogTitle>
+      <DialogContent>
+        {error && (
+          <Alert severity="error" sx={{ mb: 2 }} onClose={() => setError(null)}>
+            {error}
+          </Alert>
+        )}
+        <Box sx={{ display: 'flex', gap: 2 }}>
+          <List dense sx={{ width: 220, flexShrink: 0 }}>
+            {versions.map((version) => (
+              <ListItemButton
+                key={version.version}
+                selected={selected?.version === version.version}
+                onClick={() => handleSelect(version)}
+              >
+                <ListItemText
+                  primary={`Version ${version.version}${version.version === latest ? ' (current)' : ''}`}
+                  secondary={new Date(version.created_at).toLocaleString()}
+                />
+              </ListItemButton>
+            ))}
+          </List>
+          <Box sx={{ flex: 1, minWidth: 0 }}>
+            {!selected && (
+              <Typography color="text.secondary">Select a version to compare it with the current one</Typography>
+            )}
+            {selected && !diff && (
+              <Typography color="text.secondary">Content is the same as the current version</Typography>
+            )}
+            {selected && diff && <DiffView diff={diff} />}
+          </Box>
+        </Box>
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose}>Close</Button>
+        <Button
+          variant="contained"
+          onClick={handleRollback}
+          disabled={!selected || selected.version === latest || script?.read_only}
+        >
+          Restore Version
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
+
+export default ScriptHistoryDialog;
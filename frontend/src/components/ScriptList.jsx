@@ -20,12 +20,19 @@ import {
   TextField,
   Tooltip,
   Chip,
+  MenuItem,
 } from '@mui/material';
-import { Add, Delete, Edit, Code, Upload, ContentCopy } from '@mui/icons-material';
+import { Add, Delete, Edit, Code, Upload, ContentCopy, History } from '@mui/icons-material';
 import SourceChip from './shared/SourceChip';
 import GroupSelector from './shared/GroupSelector';
 import StorageSelector from './shared/StorageSelector';
 import GroupInput from './shared/GroupInput';
+import ScriptHistoryDialog from './ScriptHistoryDialog';
+import DiffView from './shared/DiffView';
+import { errorMessage } from '../apiError';
+
+// Interpreters a script can be run with (see models.ScriptInterpreters)
+const INTERPRETERS = ['bash', 'sh', 'python3', 'node', 'pwsh'];
 
 /**
  * ScriptList component - displays and manages bash scripts stored in the database
@@ -43,19 +50,25 @@ const ScriptList = () => {
     content: '',
     filename: '',
     group: 'default',
+    interpreter: 'bash',
+    tags: '',
     storage: 'local',
   });
   const [selectedGroup, setSelectedGroup] = useState('all');
+  const [search, setSearch] = useState('');
+  const [historyScript, setHistoryScript] = useState(null);
+  const [changes, setChanges] = useState(null); // Diff of unsaved edits, shown before saving
 
   // Fetch scripts from API
   const fetchScripts = async () => {
     try {
       setLoading(true);
       setError(null);
-      const url = selectedGroup === 'all'
-        ? '/api/bash-scripts'
-        : `/api/bash-scripts?group=${encodeURIComponent(selectedGroup)}`;
-      const response = await fetch(url);
+      const params = new URLSearchParams();
+      if (selectedGroup !== 'all') params.set('group', selectedGroup);
+      if (search.trim()) params.set('q', search.trim());
+      const query = params.toString();
+      const response = await fetch(query ? `/api/bash-scripts?${query}` : '/api/bash-scripts');
 
       if (!response.ok) {
         throw new Error('Failed to fetch scripts');
@@ -85,10 +98,12 @@ const ScriptList = () => {
     }
   };
 
-  // Load scripts on component mount or when group changes
+  // Load scripts on component mount or when group or search changes
+  // Searching waits for a pause in typing so every keystroke doesn't hit the API
   useEffect(() => {
-    fetchScripts();
-  }, [selectedGroup]);
+    const timer = setTimeout(fetchScripts, search ? 300 : 0);
+    return () => clearTimeout(timer);
+  }, [selectedGroup, search]);
 
   // Handle file upload
   const handleFileUpload = (event) => {
@@ -151,7 +166,10 @@ const ScriptList = () => {
         content: fullScript.content || '',
         filename: fullScript.filename || '',
         group: fullScript.group || 'default',
+        interpreter: fullScript.interpreter || 'bash',
+        tags: (fullScript.tags || []).join(', '),
       });
+      setChanges(null);
       setOpenDialog(true);
     }
   };
@@ -165,11 +183,35 @@ const ScriptList = () => {
       content: '',
       filename: '',
       group: 'default',
+      interpreter: 'bash',
+      tags: '',
       storage: 'local',
     });
     setOpenDialog(true);
   };
 
+  // Show how the edited content differs from the saved script
+  const handleReviewChanges = async () => {
+    try {
+      const response = await fetch(`/api/bash-scripts/${editingScript.id}/diff`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ content: formData.content }),
+      });
+
+      if (!response.ok) {
+        throw new Error(await errorMessage(response, 'Failed to compare changes'));
+      }
+
+      const data = await response.json();
+      setChanges(data.diff);
+    } catch (err) {
+      setError(err.message);
+    }
+  };
+
   // Handle form save
   const handleSave = async () => {
     if (!formData.name || !formData.content) {
@@ -188,8 +230,9 @@ const ScriptList = () => {
         : baseEndpoint;
       const method = editingScript ? 'PUT' : 'POST';
 
-      // Don't send storage field to the API
-      const { storage, ...dataToSend } = formData;
+      // Don't send storage field to the API; tags are entered comma-separated
+      const { storage, tags, ...fields } = formData;
+      const dataToSend = { ...fields, tags: tags.split(',').map((t) => t.trim()).filter(Boolean) };
 
       const response = await fetch(url, {
         method,
@@ -200,8 +243,7 @@ const ScriptList = () => {
       });
 
       if (!response.ok) {
-        const errorText = await response.text();
-        throw new Error(errorText || 'Failed to save script');
+        throw new Error(await errorMessage(response, 'Failed to save script'));
       }
 
       setOpenDialog(false);
@@ -229,6 +271,13 @@ const ScriptList = () => {
           Bash Scripts
         </Typography>
         <Box sx={{ display: 'flex', gap: 2, alignItems: 'center' }}>
+          <TextField
+            size="small"
+            label="Search"
+            value={search}
+            onChange={(e) => setSearch(e.target.value)}
+            placeholder="Name, content or tag"
+          />
           <GroupSelector
             resourceType="bash-scripts"
             selectedGroup={selectedGroup}
@@ -267,7 +316,7 @@ const ScriptList = () => {
       ) : scripts.length === 0 ? (
         <Paper sx={{ p: 4, textAlign: 'center' }}>
           <Typography variant="body1" color="text.secondary">
-            No scripts found. Click "Add Script" to create one.
+            {search ? 'No scripts match your search.' : 'No scripts found. Click "Add Script" to create one.'}
           </Typography>
         </Paper>
       ) : (
@@ -277,6 +326,7 @@ const ScriptList = () => {
               <TableRow>
                 <TableCell>Name</TableCell>
                 <TableCell>Filename</TableCell>
+                <TableCell>Interpreter</TableCell>
                 <TableCell>Description</TableCell>
                 <TableCell>Group</TableCell>
                 <TableCell>Source</TableCell>
@@ -291,6 +341,9 @@ const ScriptList = () => {
                     <Box sx={{ display: 'flex', alignItems: 'center', gap: 1 }}>
                       <Code fontSize="small" color="primary" />
                       <Typography fontWeight="bold">{script.name}</Typography>
+                      {(script.tags || []).map((tag) => (
+                        <Chip key={tag} label={tag} size="small" onClick={() => setSearch(tag)} />
+                      ))}
                     </Box>
                   </TableCell>
                   <TableCell>
@@ -305,6 +358,7 @@ const ScriptList = () => {
                       <Typography color="text.secondary">-</Typography>
                     )}
                   </TableCell>
+                  <TableCell>{script.interpreter || 'bash'}</TableCell>
                   <TableCell>{script.description || '-'}</TableCell>
                   <TableCell>{script.group || 'default'}</TableCell>
                   <TableCell>
@@ -323,13 +377,26 @@ const ScriptList = () => {
                         <ContentCopy fontSize="small" />
                       </IconButton>
                     </Tooltip>
+                    {script.source !== 'vault' && (
+                      <Tooltip title="Version history">
+                        <IconButton
+                          size="small"
+                          onClick={() => setHistoryScript(script)}
+                          sx={{ mr: 1 }}
+                        >
+                          <History fontSize="small" />
+                        </IconButton>
+                      </Tooltip>
+                    )}
                     <IconButton
                       color="primary"
                       onClick={() => handleEdit(script)}
                       aria-label="edit"
                       sx={{ mr: 1 }}
-                      disabled={script.source === 'vault'}
-                      title={script.source === 'vault' ? 'Vault items cannot be edited here' : 'Edit'}
+                      disabled={script.source === 'vault' || script.read_only}
+                      title={script.source === 'vault'
+                        ? 'Vault items cannot be edited here'
+                        : script.read_only ? 'Managed by Git sync: edit it in the repository' : 'Edit'}
                     >
                       <Edit />
                     </IconButton>
@@ -337,8 +404,10 @@ const ScriptList = () => {
                       color="error"
                       onClick={() => handleDelete(script.id)}
                       aria-label="delete"
-                      disabled={script.source === 'vault'}
-                      title={script.source === 'vault' ? 'Vault items cannot be deleted here' : 'Delete'}
+                      disabled={script.source === 'vault' || script.read_only}
+                      title={script.source === 'vault'
+                        ? 'Vault items cannot be deleted here'
+                        : script.read_only ? 'Managed by Git sync: delete it in the repository' : 'Delete'}
                     >
                       <Delete />
                     </IconButton>
@@ -384,6 +453,31 @@ const ScriptList = () => {
             resourceType="bash-scripts"
             helperText="Select an existing group or type a new one"
           />
+          <TextField
+            select
+            margin="dense"
+            label="Interpreter"
+            fullWidth
+            variant="outlined"
+            value={formData.interpreter}
+            onChange={(e) => setFormData({ ...formData, interpreter: e.target.value })}
+            helperText="Program the script is run with, locally or on the remote server"
+          >
+            {INTERPRETERS.map((interpreter) => (
+              <MenuItem key={interpreter} value={interpreter}>{interpreter}</MenuItem>
+            ))}
+          </TextField>
+          <TextField
+            margin="dense"
+            label="Tags (optional)"
+            fullWidth
+            variant="outlined"
+            value={formData.tags}
+            onChange={(e) => setFormData({ ...formData, tags: e.target.value })}
+            placeholder="deploy, production"
+            helperText="Comma-separated; used to search and filter scripts"
+            disabled={!editingScript && formData.storage === 'vault'}
+          />
           {!editingScript && (
             <Box sx={{ mb: 2 }}>
               <StorageSelector
@@ -409,7 +503,7 @@ const ScriptList = () => {
             <Box>
               <input
                 type="file"
-                accept=".sh,.bash,text/*"
+                accept=".sh,.bash,.py,.js,.ps1,text/*"
                 onChange={handleFileUpload}
                 style={{ display: 'none' }}
                 id="script-file-upload"
@@ -433,21 +527,46 @@ const ScriptList = () => {
             multiline
             rows={15}
             value={formData.content}
-            onChange={(e) => setFormData({ ...formData, content: e.target.value })}
+            onChange={(e) => {
+              setFormData({ ...formData, content: e.target.value });
+              setChanges(null);
+            }}
             placeholder="#!/bin/bash&#10;&#10;# Your script here..."
             helperText={`Script content (${formatSize(formData.content)})`}
             inputProps={{
               style: { fontFamily: 'monospace', fontSize: '0.9rem' },
             }}
           />
+          {changes !== null && (
+            <Box sx={{ mt: 2 }}>
+              <Typography variant="subtitle2" gutterBottom>Changes to the saved content</Typography>
+              {changes ? <DiffView diff={changes} /> : (
+                <Typography color="text.secondary">No changes to the content</Typography>
+              )}
+            </Box>
+          )}
         </DialogContent>
         <DialogActions>
           <Button onClick={() => setOpenDialog(false)}>Cancel</Button>
+          {editingScript && (
+            <Button onClick={handleReviewChanges}>Review Changes</Button>
+          )}
           <Button onClick={handleSave} variant="contained">
             {editingScript ? 'Update' : 'Create'}
           </Button>
         </DialogActions>
       </Dialog>
+
+      <ScriptHistoryDialog
+        open={Boolean(historyScript)}
+        onClose={() => setHistoryScript(null)}
+        script={historyScript}
+        onRestored={(version) => {
+          setSuccess(`Restored version ${version}`);
+          setTimeout(() => setSuccess(null), 2000);
+          fetchScripts();
+        }}
+      />
     </Box>
   );
 };
@@ -22,11 +22,14 @@ import {
   VpnKey,
   Storage,
   Lock,
+  Dns,
+  FolderOpen,
 } from '@mui/icons-material';
 import { useNavigate } from 'react-router-dom';
 import { TerminalProvider, useTerminal } from '../context/TerminalContext';
 import TabBar from './TabBar';
 import TerminalPane from './TerminalPane';
+import TerminalFilesDialog from './TerminalFilesDialog';
 
 /**
  * TerminalContent component - Main terminal UI with multi-tab support
@@ -38,6 +41,11 @@ const TerminalContent = () => {
   const [isFullscreen, setIsFullscreen] = useState(false);
   const [availableShells, setAvailableShells] = useState([]);
   const [sshKeys, setSshKeys] = useState([]);
+  const [servers, setServers] = useState([]);
+  const [envGroups, setEnvGroups] = useState([]);
+  // Server-side session ID per tab, for the files dialog
+  const [sessionIds, setSessionIds] = useState({});
+  const [filesOpen, setFilesOpen] = useState(false);
 
   const {
     tabs,
@@ -87,8 +95,35 @@ const TerminalContent = () => {
       }
     };
 
+    const fetchServers = async () => {
+      try {
+        const response = await fetch('/api/servers');
+        if (response.ok) {
+          const data = await response.json();
+          // Only servers stored locally have an ID the terminal can connect to
+          setServers((data || []).filter((server) => server.source !== 'vault' && server.id));
+        }
+      } catch (err) {
+        console.error('Failed to fetch servers:', err);
+      }
+    };
+
+    const fetchEnvGroups = async () => {
+      try {
+        const response = await fetch('/api/env-variables/groups');
+        if (response.ok) {
+          const data = await response.json();
+          setEnvGroups(data || []);
+        }
+      } catch (err) {
+        console.error('Failed to fetch env variable groups:', err);
+      }
+    };
+
     fetchShells();
     fetchSshKeys();
+    fetchServers();
+    fetchEnvGroups();
   }, []);
 
   // Handle connection status updates
@@ -99,6 +134,10 @@ const TerminalContent = () => {
     [setTabConnected]
   );
 
+  const handleSession = useCallback((tabId, sessionId) => {
+    setSessionIds((prev) => ({ ...prev, [tabId]: sessionId }));
+  }, []);
+
   const handleDisconnected = useCallback(
     (tabId) => {
       setTabConnected(tabId, false);
@@ -106,6 +145,10 @@ const TerminalContent = () => {
     [setTabConnected]
   );
 
+  // Shell used by tabs that have not picked one
+  const defaultShell =
+    availableShells.find((s) => s.default)?.name || availableShells[0]?.name || '';
+
   // Handle shell change for active tab
   const handleShellChange = (e) => {
     if (activeTab) {
@@ -129,6 +172,36 @@ const TerminalContent = () => {
     }
   };
 
+  // Handle SSH target change for active tab
+  const handleServerChange = (e) => {
+    if (activeTab) {
+      updateTabConfig(activeTab.id, { serverId: String(e.target.value) });
+      // Trigger reconnect
+      window.dispatchEvent(
+        new CustomEvent('terminal-reconnect', { detail: { tabId: activeTab.id } })
+      );
+    }
+  };
+
+  // Handle env variable groups change for active tab
+  const handleEnvGroupsChange = (e) => {
+    if (activeTab) {
+      const value = e.target.value;
+      const groups = typeof value === 'string' ? value.split(',') : value;
+      updateTabConfig(activeTab.id, { envGroups: groups.filter(Boolean).join(',') });
+      // Trigger reconnect
+      window.dispatchEvent(
+        new CustomEvent('terminal-reconnect', { detail: { tabId: activeTab.id } })
+      );
+    }
+  };
+
+  // Helper to get the selected server object from its ID
+  const getSelectedServer = (serverId) => {
+    if (!serverId) return null;
+    return servers.find((server) => String(server.id) === String(serverId));
+  };
+
   // Helper to get the selected key object from composite ID
   const getSelectedKey = (compositeId) => {
     if (!compositeId) return null;
@@ -180,7 +253,7 @@ const TerminalContent = () => {
         <FormControl size="small" sx={{ minWidth: 120 }}>
           <InputLabel>Shell</InputLabel>
           <Select
-            value={activeTab?.shell || 'bash'}
+            value={activeTab?.shell || defaultShell}
             label="Shell"
             onChange={handleShellChange}
             disabled={availableShells.length === 0}
@@ -193,6 +266,47 @@ const TerminalContent = () => {
           </Select>
         </FormControl>
 
+        {/* Target selector for active tab: local shell or SSH to a server */}
+        <FormControl size="small" sx={{ minWidth: 180 }}>
+          <InputLabel>Target</InputLabel>
+          <Select
+            value={activeTab?.serverId || ''}
+            label="Target"
+            onChange={handleServerChange}
+          >
+            <MenuItem value="">
+              <em>Local shell</em>
+            </MenuItem>
+            {servers.map((server) => (
+              <MenuItem key={server.id} value={String(server.id)}>
+                <ListItemIcon sx={{ minWidth: 36 }}>
+                  <Dns fontSize="small" />
+                </ListItemIcon>
+                <ListItemText primary={server.name || server.ip_address} />
+              </MenuItem>
+            ))}
+          </Select>
+        </FormControl>
+
+        {/* Env variable groups exported into the active tab's shell */}
+        <FormControl size="small" sx={{ minWidth: 160 }}>
+          <InputLabel>Env Vars</InputLabel>
+          <Select
+            multiple
+            value={activeTab?.envGroups ? activeTab.envGroups.split(',') : []}
+            label="Env Vars"
+            onChange={handleEnvGroupsChange}
+            renderValue={(selected) => selected.join(', ')}
+            disabled={envGroups.length === 0}
+          >
+            {envGroups.map((group) => (
+              <MenuItem key={group} value={group}>
+                <ListItemText primary={group} />
+              </MenuItem>
+            ))}
+          </Select>
+        </FormControl>
+
         {/* SSH Key selector for active tab */}
         <FormControl size="small" sx={{ minWidth: 220 }}>
           <InputLabel>SSH Key</InputLabel>
@@ -239,6 +353,17 @@ const TerminalContent = () => {
           </Select>
         </FormControl>
 
+        <Tooltip title="Session Files">
+          <span>
+            <IconButton
+              onClick={() => setFilesOpen(true)}
+              color="primary"
+              disabled={!activeTab?.connected || !sessionIds[activeTab?.id]}
+            >
+              <FolderOpen />
+            </IconButton>
+          </span>
+        </Tooltip>
         <Tooltip title="Reconnect">
           <IconButton onClick={handleReconnect} color="primary">
             <Refresh />
@@ -272,9 +397,12 @@ const TerminalContent = () => {
             tabId={tab.id}
             shell={tab.shell}
             sshKeyId={tab.sshKeyId}
+            serverId={tab.serverId}
+            envGroups={tab.envGroups}
             isActive={tab.id === activeTabId}
             onConnected={handleConnected}
             onDisconnected={handleDisconnected}
+            onSession={handleSession}
           />
         ))}
       </Paper>
@@ -300,7 +428,16 @@ const TerminalContent = () => {
           <Box sx={{ display: 'flex', alignItems: 'center', gap: 1 }}>
             <span>Tabs: {tabs.length}/{maxTabs}</span>
             <span>|</span>
-            <span>Shell: {activeTab?.shell || 'bash'}</span>
+            <span>Shell: {activeTab?.shell || defaultShell}</span>
+            {activeTab?.serverId && (() => {
+              const selectedServer = getSelectedServer(activeTab.serverId);
+              return selectedServer ? (
+                <>
+                  <span>|</span>
+                  <span>SSH: {selectedServer.name || selectedServer.ip_address}</span>
+                </>
+              ) : null;
+            })()}
             {activeTab?.sshKeyId && (() => {
               const selectedKey = getSelectedKey(activeTab.sshKeyId);
               return selectedKey ? (
@@ -334,6 +471,12 @@ const TerminalContent = () => {
           </Box>
         </Typography>
       </Box>
+
+      <TerminalFilesDialog
+        open={filesOpen}
+        onClose={() => setFilesOpen(false)}
+        sessionId={activeTab ? sessionIds[activeTab.id] : null}
+      />
     </Container>
   );
 };
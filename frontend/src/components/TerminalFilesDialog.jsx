@@ -0,0 +1,150 @@
+import React, { useCallback, useEffect, useRef, useState } from 'react';
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  DialogActions,
+  Button,
+  Alert,
+  List,
+  ListItem,
+  ListItemText,
+  IconButton,
+  Typography,
+  Tooltip,
+} from '@mui/material';
+import { Download, Upload, Refresh } from '@mui/icons-material';
+import { errorMessage } from '../apiError';
+
+// Format a byte count for display
+const formatSize = (bytes) => {
+  if (bytes < 1024) return `${bytes} B`;
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+};
+
+/**
+ * TerminalFilesDialog component - upload files into and download files from
+ * a terminal session's file directory ($WEBCLI_FILES in the shell)
+ * @param {Object} props - Component props
+ * @param {boolean} props.open - Whether the dialog is open
+ * @param {Function} props.onClose - Function to close the dialog
+ * @param {string} props.sessionId - Terminal session ID
+ */
+const TerminalFilesDialog = ({ open, onClose, sessionId }) => {
+  const [files, setFiles] = useState([]);
+  const [error, setError] = useState(null);
+  const [loading, setLoading] = useState(false);
+  const inputRef = useRef(null);
+
+  // Load the files in the session directory
+  const fetchFiles = useCallback(async () => {
+    if (!sessionId) return;
+    try {
+      setError(null);
+      const response = await fetch(`/api/terminal/files/${encodeURIComponent(sessionId)}`);
+      if (!response.ok) {
+        throw new Error(await errorMessage(response));
+      }
+      setFiles((await response.json()) || []);
+    } catch (err) {
+      setError(err.message || 'Failed to load files');
+    }
+  }, [sessionId]);
+
+  useEffect(() => {
+    if (open) {
+      fetchFiles();
+    }
+  }, [open, fetchFiles]);
+
+  // Upload the selected file
+  const handleUpload = async (e) => {
+    const file = e.target.files?.[0];
+    e.target.value = '';
+    if (!file) return;
+
+    const body = new FormData();
+    body.append('file', file);
+
+    try {
+      setLoading(true);
+      setError(null);
+      const response = await fetch(`/api/terminal/files/${encodeURIComponent(sessionId)}`, {
+        method: 'POST',
+        body,
+      });
+      if (!response.ok) {
+        throw new Error(await errorMessage(response));
+      }
+      await fetchFiles();
+    } catch (err) {
+      setError(err.message || 'Failed to upload file');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // Build the download URL for a file, keeping path separators
+  const downloadUrl = (path) =>
+    `/api/terminal/files/${encodeURIComponent(sessionId)}/${path
+      .split('/')
+      .map(encodeURIComponent)
+      .join('/')}`;
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+      <DialogTitle>Session Files</DialogTitle>
+      <DialogContent>
+        {error && (
+          <Alert severity="error" sx={{ mb: 2 }}>
+            {error}
+          </Alert>
+        )}
+        <Typography variant="body2" color="text.secondary" sx={{ mb: 1 }}>
+          Files are stored in <code>$WEBCLI_FILES</code> in the terminal and are
+          deleted when the session ends.
+        </Typography>
+        {files.length === 0 ? (
+          <Typography variant="body2" sx={{ py: 2 }}>
+            No files yet.
+          </Typography>
+        ) : (
+          <List dense>
+            {files.map((file) => (
+              <ListItem
+                key={file.path}
+                secondaryAction={
+                  <Tooltip title="Download">
+                    <IconButton edge="end" component="a" href={downloadUrl(file.path)}>
+                      <Download />
+                    </IconButton>
+                  </Tooltip>
+                }
+              >
+                <ListItemText primary={file.path} secondary={formatSize(file.size)} />
+              </ListItem>
+            ))}
+          </List>
+        )}
+        <input ref={inputRef} type="file" hidden onChange={handleUpload} />
+      </DialogContent>
+      <DialogActions>
+        <Button startIcon={<Refresh />} onClick={fetchFiles} disabled={loading}>
+          Refresh
+        </Button>
+        <Button
+          startIcon={<Upload />}
+          variant="contained"
+          onClick={() => inputRef.current?.click()}
+          disabled={loading || !sessionId}
+        >
+          {loading ? 'Uploading...' : 'Upload'}
+        </Button>
+        <Button onClick={onClose}>Close</Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
+
+export default TerminalFilesDialog;
@@ -3,6 +3,7 @@ import { Box } from '@mui/material';
 import { Terminal as XTerm } from '@xterm/xterm';
 import { FitAddon } from '@xterm/addon-fit';
 import '@xterm/xterm/css/xterm.css';
+import { withBasePath } from '../basePath';
 
 /**
  * TerminalPane component - Individual terminal instance with xterm.js
@@ -12,15 +13,22 @@ const TerminalPane = ({
   tabId,
   shell,
   sshKeyId,
+  serverId,
+  envGroups,
   isActive,
   onConnected,
   onDisconnected,
+  onSession,
 }) => {
   const terminalRef = useRef(null);
   const xtermRef = useRef(null);
   const fitAddonRef = useRef(null);
   const wsRef = useRef(null);
   const dataHandlerRef = useRef(null);
+  // Server-side session ID, used to resume the shell after a disconnect
+  const sessionIdRef = useRef(null);
+  // Shell, key, server and env groups the session was started with
+  const sessionConfigRef = useRef(null);
   const [isInitialized, setIsInitialized] = useState(false);
 
   // Send resize message to server
@@ -40,15 +48,27 @@ const TerminalPane = ({
   }, []);
 
   // Connect to WebSocket - stable function that reads current values from refs
-  const connectWebSocket = useCallback((currentShell, currentSshKeyId) => {
+  const connectWebSocket = useCallback((currentShell, currentSshKeyId, currentServerId, currentEnvGroups) => {
     if (!xtermRef.current) return;
 
     const xterm = xtermRef.current;
     const fitAddon = fitAddonRef.current;
 
-    // Close existing connection if any
+    // A changed shell, key, server or env groups needs a new session
+    const config = `${currentShell}|${currentSshKeyId || ''}|${currentServerId || ''}|${currentEnvGroups || ''}`;
+    if (sessionConfigRef.current !== config) {
+      sessionIdRef.current = null;
+    }
+    const resumeId = sessionIdRef.current;
+
+    // Close existing connection if any; a normal close ends the server-side
+    // session, so use another code when the shell will be resumed
     if (wsRef.current) {
-      wsRef.current.close();
+      if (resumeId) {
+        wsRef.current.close(4000, 'reconnect');
+      } else {
+        wsRef.current.close();
+      }
       wsRef.current = null;
     }
     if (dataHandlerRef.current) {
@@ -58,7 +78,18 @@ const TerminalPane = ({
 
     // Build WebSocket URL
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-    let wsUrl = `${protocol}//${window.location.host}/api/terminal/ws?shell=${encodeURIComponent(currentShell)}`;
+    // An empty shell lets the server pick the user's default
+    let wsUrl = `${protocol}//${window.location.host}${withBasePath('/api/terminal/ws')}?shell=${encodeURIComponent(currentShell || '')}`;
+    if (resumeId) {
+      wsUrl += `&session=${encodeURIComponent(resumeId)}`;
+    }
+    if (currentServerId) {
+      wsUrl += `&serverId=${encodeURIComponent(currentServerId)}`;
+    }
+    // Stored env vars of these groups are exported into the shell
+    if (currentEnvGroups) {
+      wsUrl += `&envGroups=${encodeURIComponent(currentEnvGroups)}`;
+    }
 
     // Handle composite SSH key ID (format: "source:id" e.g., "local:123" or "vault:keyname")
     if (currentSshKeyId) {
@@ -76,12 +107,16 @@ const TerminalPane = ({
       }
     }
 
-    xterm.write('\r\n\x1b[33mConnecting to terminal...\x1b[0m\r\n');
+    xterm.write(resumeId
+      ? '\r\n\x1b[33mResuming terminal session...\x1b[0m\r\n'
+      : '\r\n\x1b[33mConnecting to terminal...\x1b[0m\r\n');
 
     const ws = new WebSocket(wsUrl);
     ws.binaryType = 'arraybuffer';
+    let opened = false;
 
     ws.onopen = () => {
+      opened = true;
       onConnected(tabId);
       xterm.write('\x1b[32mConnected!\x1b[0m\r\n\r\n');
 
@@ -99,6 +134,17 @@ const TerminalPane = ({
         const text = new TextDecoder().decode(event.data);
         xterm.write(text);
       } else {
+        // The server announces the session ID in a JSON control message
+        if (event.data.startsWith('{"type":"session"')) {
+          try {
+            sessionIdRef.current = JSON.parse(event.data).id;
+            sessionConfigRef.current = config;
+            onSession?.(tabId, sessionIdRef.current);
+            return;
+          } catch {
+            // Not a control message, show it
+          }
+        }
         xterm.write(event.data);
       }
     };
@@ -109,6 +155,12 @@ const TerminalPane = ({
     };
 
     ws.onclose = () => {
+      // The session to resume has ended: start a new one instead
+      if (resumeId && !opened && wsRef.current === ws) {
+        sessionIdRef.current = null;
+        connectWebSocket(currentShell, currentSshKeyId, currentServerId);
+        return;
+      }
       onDisconnected(tabId);
       xterm.write('\r\n\x1b[31mDisconnected from terminal.\x1b[0m\r\n');
     };
@@ -121,7 +173,7 @@ const TerminalPane = ({
         ws.send(data);
       }
     });
-  }, [tabId, onConnected, onDisconnected, sendResize]);
+  }, [tabId, onConnected, onDisconnected, onSession, sendResize]);
 
   // Initialize terminal - only once when tab first becomes active
   useEffect(() => {
@@ -181,9 +233,9 @@ const TerminalPane = ({
     // Mark as initialized
     setIsInitialized(true);
 
-    // Connect to WebSocket with current shell/sshKeyId
-    connectWebSocket(shell, sshKeyId);
-  }, [isActive, isInitialized, shell, sshKeyId, connectWebSocket]);
+    // Connect to WebSocket with current shell/sshKeyId/serverId/envGroups
+    connectWebSocket(shell, sshKeyId, serverId, envGroups);
+  }, [isActive, isInitialized, shell, sshKeyId, serverId, envGroups, connectWebSocket]);
 
   // Cleanup on unmount only
   useEffect(() => {
@@ -233,12 +285,12 @@ const TerminalPane = ({
     const handleReconnect = (e) => {
       if (e.detail.tabId === tabId && xtermRef.current) {
         xtermRef.current.clear();
-        connectWebSocket(shell, sshKeyId);
+        connectWebSocket(shell, sshKeyId, serverId, envGroups);
       }
     };
     window.addEventListener('terminal-reconnect', handleReconnect);
     return () => window.removeEventListener('terminal-reconnect', handleReconnect);
-  }, [tabId, shell, sshKeyId, connectWebSocket]);
+  }, [tabId, shell, sshKeyId, serverId, envGroups, connectWebSocket]);
 
   return (
     <Box
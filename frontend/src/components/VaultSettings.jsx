@@ -18,6 +18,7 @@ import {
   IconButton,
   Tooltip,
   Collapse,
+  MenuItem,
 } from '@mui/material';
 import {
   Save,
@@ -34,6 +35,7 @@ import {
   Code,
   Settings,
 } from '@mui/icons-material';
+import { errorMessage } from '../apiError';
 
 /**
  * VaultSettings component - HashiCorp Vault integration configuration
@@ -41,7 +43,16 @@ import {
 const VaultSettings = () => {
   const [config, setConfig] = useState({
     address: '',
+    auth_method: 'token',
     token: '',
+    role_id: '',
+    secret_id: '',
+    auth_mount: '',
+    kubernetes_role: '',
+    jwt_path: '',
+    ssh_ca_mount: '',
+    ssh_ca_role: '',
+    ssh_ca_ttl: '',
     namespace: '',
     mount_path: 'secret',
     enabled: false,
@@ -54,6 +65,7 @@ const VaultSettings = () => {
   const [success, setSuccess] = useState(null);
   const [showToken, setShowToken] = useState(false);
   const [hasExistingToken, setHasExistingToken] = useState(false);
+  const [hasExistingSecretId, setHasExistingSecretId] = useState(false);
   const [showAdvanced, setShowAdvanced] = useState(false);
   const [vaultData, setVaultData] = useState({
     sshKeys: [],
@@ -89,12 +101,22 @@ const VaultSettings = () => {
       if (data && data.id) {
         setConfig({
           address: data.address || '',
+          auth_method: data.auth_method || 'token',
           token: '', // Token is never returned
+          role_id: data.role_id || '',
+          secret_id: '', // Secret ID is never returned
+          auth_mount: data.auth_mount || '',
+          kubernetes_role: data.kubernetes_role || '',
+          jwt_path: data.jwt_path || '',
+          ssh_ca_mount: data.ssh_ca_mount || '',
+          ssh_ca_role: data.ssh_ca_role || '',
+          ssh_ca_ttl: data.ssh_ca_ttl || '',
           namespace: data.namespace || '',
           mount_path: data.mount_path || 'secret',
           enabled: data.enabled || false,
         });
         setHasExistingToken(data.has_token || false);
+        setHasExistingSecretId(data.has_secret_id || false);
       }
     } catch (err) {
       console.error('VaultSettings fetchConfig error:', err);
@@ -188,7 +210,18 @@ const VaultSettings = () => {
         throw new Error('Vault address is required');
       }
 
-      if (!hasExistingToken && !config.token) {
+      if (config.auth_method === 'approle') {
+        if (!config.role_id) {
+          throw new Error('AppRole role ID is required');
+        }
+        if (!hasExistingSecretId && !config.secret_id) {
+          throw new Error('AppRole secret ID is required');
+        }
+      } else if (config.auth_method === 'kubernetes') {
+        if (!config.kubernetes_role) {
+          throw new Error('Kubernetes role is required');
+        }
+      } else if (!hasExistingToken && !config.token) {
         throw new Error('Vault token is required');
       }
 
@@ -199,13 +232,13 @@ const VaultSettings = () => {
       });
 
       if (!response.ok) {
-        const text = await response.text();
-        throw new Error(text || 'Failed to save configuration');
+        throw new Error(await errorMessage(response, 'Failed to save configuration'));
       }
 
       setSuccess('Vault configuration saved successfully');
-      setHasExistingToken(true);
-      setConfig((prev) => ({ ...prev, token: '' })); // Clear token from form
+      setHasExistingToken(config.auth_method === 'token');
+      setHasExistingSecretId(config.auth_method === 'approle');
+      setConfig((prev) => ({ ...prev, token: '', secret_id: '' })); // Clear secrets from form
       fetchStatus();
     } catch (err) {
       setError(err.message);
@@ -265,12 +298,22 @@ const VaultSettings = () => {
 
       setConfig({
         address: '',
+        auth_method: 'token',
         token: '',
+        role_id: '',
+        secret_id: '',
+        auth_mount: '',
+        kubernetes_role: '',
+        jwt_path: '',
+        ssh_ca_mount: '',
+        ssh_ca_role: '',
+        ssh_ca_ttl: '',
         namespace: '',
         mount_path: 'secret',
         enabled: false,
       });
       setHasExistingToken(false);
+      setHasExistingSecretId(false);
       setStatus(null);
       setVaultData({ sshKeys: [], servers: [], envVars: [], scripts: [] });
       setSuccess('Vault configuration removed');
@@ -364,27 +407,83 @@ const VaultSettings = () => {
           />
 
           <TextField
-            label="Vault Token"
-            type={showToken ? 'text' : 'password'}
-            value={config.token}
-            onChange={handleChange('token')}
-            placeholder={hasExistingToken ? '(token configured - enter new token to change)' : 'hvs.xxxxx'}
+            select
+            label="Authentication Method"
+            value={config.auth_method}
+            onChange={handleChange('auth_method')}
             fullWidth
-            required={!hasExistingToken}
-            helperText={hasExistingToken ? 'Leave blank to keep existing token' : 'Your Vault authentication token'}
-            InputProps={{
-              endAdornment: (
-                <InputAdornment position="end">
-                  <IconButton
-                    onClick={() => setShowToken(!showToken)}
-                    edge="end"
-                  >
-                    {showToken ? <VisibilityOff /> : <Visibility />}
-                  </IconButton>
-                </InputAdornment>
-              ),
-            }}
-          />
+            helperText="How Web CLI authenticates to Vault"
+          >
+            <MenuItem value="token">Token</MenuItem>
+            <MenuItem value="approle">AppRole</MenuItem>
+            <MenuItem value="kubernetes">Kubernetes</MenuItem>
+          </TextField>
+
+          {config.auth_method === 'approle' ? (
+            <>
+              <TextField
+                label="Role ID"
+                value={config.role_id}
+                onChange={handleChange('role_id')}
+                fullWidth
+                required
+              />
+              <TextField
+                label="Secret ID"
+                type={showToken ? 'text' : 'password'}
+                value={config.secret_id}
+                onChange={handleChange('secret_id')}
+                placeholder={hasExistingSecretId ? '(secret ID configured - enter new secret ID to change)' : ''}
+                fullWidth
+                required={!hasExistingSecretId}
+                helperText={hasExistingSecretId ? 'Leave blank to keep existing secret ID' : 'Web CLI logs in with the role ID and secret ID, and again before its token expires'}
+                InputProps={{
+                  endAdornment: (
+                    <InputAdornment position="end">
+                      <IconButton
+                        onClick={() => setShowToken(!showToken)}
+                        edge="end"
+                      >
+                        {showToken ? <VisibilityOff /> : <Visibility />}
+                      </IconButton>
+                    </InputAdornment>
+                  ),
+                }}
+              />
+            </>
+          ) : config.auth_method === 'kubernetes' ? (
+            <TextField
+              label="Kubernetes Role"
+              value={config.kubernetes_role}
+              onChange={handleChange('kubernetes_role')}
+              fullWidth
+              required
+              helperText="Vault role bound to this pod's service account; Web CLI logs in with the service account token"
+            />
+          ) : (
+            <TextField
+              label="Vault Token"
+              type={showToken ? 'text' : 'password'}
+              value={config.token}
+              onChange={handleChange('token')}
+              placeholder={hasExistingToken ? '(token configured - enter new token to change)' : 'hvs.xxxxx'}
+              fullWidth
+              required={!hasExistingToken}
+              helperText={hasExistingToken ? 'Leave blank to keep existing token' : 'Your Vault authentication token'}
+              InputProps={{
+                endAdornment: (
+                  <InputAdornment position="end">
+                    <IconButton
+                      onClick={() => setShowToken(!showToken)}
+                      edge="end"
+                    >
+                      {showToken ? <VisibilityOff /> : <Visibility />}
+                    </IconButton>
+                  </InputAdornment>
+                ),
+              }}
+            />
+          )}
 
           <Button
             variant="text"
@@ -415,6 +514,55 @@ const VaultSettings = () => {
                 fullWidth
                 helperText="KV v2 secrets engine mount path (default: secret)"
               />
+              {config.auth_method !== 'token' && (
+                <TextField
+                  label="Auth Mount Path"
+                  value={config.auth_mount}
+                  onChange={handleChange('auth_mount')}
+                  placeholder={config.auth_method}
+                  fullWidth
+                  helperText={`Path the auth method is enabled at (default: ${config.auth_method})`}
+                />
+              )}
+              {config.auth_method === 'kubernetes' && (
+                <TextField
+                  label="Service Account Token Path"
+                  value={config.jwt_path}
+                  onChange={handleChange('jwt_path')}
+                  placeholder="/var/run/secrets/kubernetes.io/serviceaccount/token"
+                  fullWidth
+                  helperText="File the pod's service account token is mounted at"
+                />
+              )}
+
+              <TextField
+                label="SSH CA Role (optional)"
+                value={config.ssh_ca_role}
+                onChange={handleChange('ssh_ca_role')}
+                placeholder="web-cli"
+                fullWidth
+                helperText="SSH secrets engine role that signs a short-lived key when no SSH key is selected"
+              />
+              {config.ssh_ca_role && (
+                <>
+                  <TextField
+                    label="SSH CA Mount Path"
+                    value={config.ssh_ca_mount}
+                    onChange={handleChange('ssh_ca_mount')}
+                    placeholder="ssh-client-signer"
+                    fullWidth
+                    helperText="Path the SSH secrets engine is enabled at (default: ssh-client-signer)"
+                  />
+                  <TextField
+                    label="Certificate Lifetime"
+                    value={config.ssh_ca_ttl}
+                    onChange={handleChange('ssh_ca_ttl')}
+                    placeholder="30m"
+                    fullWidth
+                    helperText="Requested certificate TTL (default: the role's TTL)"
+                  />
+                </>
+              )}
             </Box>
           </Collapse>
 
@@ -0,0 +1,30 @@
+import React from 'react';
+import { Box } from '@mui/material';
+
+// Colour unified diff lines by whether they were removed or added
+const lineColor = (line) => {
+  if (line.startsWith('+') && !line.startsWith('+++')) return 'success.main';
+  if (line.startsWith('-') && !line.startsWith('---')) return 'error.main';
+  if (line.startsWith('@@')) return 'info.main';
+  return 'text.primary';
+};
+
+/**
+ * DiffView component - displays a unified diff with added and removed lines highlighted
+ * @param {Object} props
+ * @param {string} props.diff - Unified diff text
+ */
+const DiffView = ({ diff }) => (
+  <Box
+    component="pre"
+    sx={{ m: 0, p: 1, overflow: 'auto', maxHeight: 400, fontSize: '0.85rem', bgcolor: 'action.hover' }}
+  >
+    {diff.split('\n').map((line, i) => (
+      <Box component="span" key={i} sx={{ display: 'block', color: lineColor(line) }}>
+        {line}
+      </Box>
+    ))}
+  </Box>
+);
+
+export default DiffView;
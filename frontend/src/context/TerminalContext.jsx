@@ -22,8 +22,12 @@ const actions = {
 const createDefaultTab = (index = 1) => ({
   id: generateId(),
   title: `Terminal ${index}`,
-  shell: 'bash',
+  // Empty uses the user's default shell from the server
+  shell: '',
   sshKeyId: '',
+  serverId: '',
+  // Comma-separated env var groups exported into the shell
+  envGroups: '',
   connected: false,
 });
 
@@ -44,8 +48,11 @@ const getInitialState = () => {
             title: typeof tab.title === 'string' && tab.title ? tab.title : 'Terminal',
             // Ensure sshKeyId is always a string (older versions stored numeric IDs)
             sshKeyId: tab.sshKeyId != null ? String(tab.sshKeyId) : '',
-            // Ensure shell is a string with default fallback
-            shell: typeof tab.shell === 'string' && tab.shell ? tab.shell : 'bash',
+            // Server to SSH into directly (empty for a local shell)
+            serverId: tab.serverId != null ? String(tab.serverId) : '',
+            envGroups: typeof tab.envGroups === 'string' ? tab.envGroups : '',
+            // Ensure shell is a string (empty uses the user's default shell)
+            shell: typeof tab.shell === 'string' ? tab.shell : '',
             connected: false,
           }));
         
@@ -187,11 +194,13 @@ export function TerminalProvider({ children }) {
   useEffect(() => {
     try {
       // Save only serializable tab data (not connected state)
-      const tabsToSave = state.tabs.map(({ id, title, shell, sshKeyId }) => ({
+      const tabsToSave = state.tabs.map(({ id, title, shell, sshKeyId, serverId, envGroups }) => ({
         id,
         title,
         shell,
         sshKeyId,
+        serverId,
+        envGroups,
       }));
       localStorage.setItem(STORAGE_KEY, JSON.stringify(tabsToSave));
     } catch (e) {
@@ -1,8 +1,12 @@
 import React from 'react'
 import ReactDOM from 'react-dom/client'
 import App from './App.jsx'
+import { installBasePathFetch } from './basePath'
 import './styles/index.css'
 
+// Route API calls through the configured base path (no-op when served from root)
+installBasePathFetch()
+
 ReactDOM.createRoot(document.getElementById('root')).render(
   <React.StrictMode>
     <App />
@@ -4,6 +4,8 @@ import react from '@vitejs/plugin-react'
 // https://vite.dev/config/
 export default defineConfig({
   plugins: [react()],
+  // Relative asset URLs so the app can be served under a path prefix (BASE_PATH)
+  base: './',
   server: {
     port: 3000,
     proxy: {
@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ConnectPath is the API endpoint agents connect to, below the web-cli base URL
+const ConnectPath = "/api/agents/connect"
+
+// Reconnection backoff after a connection fails or ends
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// killWait is how long a killed script's processes get to close its output
+const killWait = 5 * time.Second
+
+// Client is the agent side: it keeps a connection to web-cli open and runs the scripts it
+// is sent as the user the agent runs as
+type Client struct {
+	URL       string      // web-cli base URL, e.g. https://web-cli.example.com
+	Name      string      // Name of the server the agent runs commands for
+	Token     string      // API token with the agent scope
+	Version   string      // Reported to web-cli
+	TLSConfig *tls.Config // Optional, e.g. to trust a private CA
+	Shell     string      // Shell scripts run with (default: /bin/sh)
+}
+
+// Run connects to web-cli and runs scripts until ctx ends, reconnecting with backoff when the
+// connection drops
+// It returns an error when web-cli rejects the agent, as retrying would not help.
+func (c *Client) Run(ctx context.Context) error {
+	connectURL, err := c.connectURL()
+	if err != nil {
+		return err
+	}
+
+	backoff := minBackoff
+	for {
+		connected, err := c.session(ctx, connectURL)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var rejected *rejectedError
+		if errors.As(err, &rejected) {
+			return err
+		}
+		if connected {
+			backoff = minBackoff
+		}
+		log.Printf("Agent connection to %s ended: %v; reconnecting in %s", c.URL, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// rejectedError is returned when web-cli refuses the agent's connection
+type rejectedError struct {
+	status string
+}
+
+func (e *rejectedError) Error() string {
+	return "web-cli rejected the agent: " + e.status
+}
+
+// connectURL returns the WebSocket URL of the connect endpoint
+func (c *Client) connectURL() (string, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid web-cli URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https", "wss":
+		u.Scheme = "wss"
+	case "http", "ws":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("invalid web-cli URL: scheme must be https or http")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + ConnectPath
+	u.RawQuery = url.Values{"name": {c.Name}}.Encode()
+	return u.String(), nil
+}
+
+// session runs one connection until it ends
+// It reports whether the connection was established.
+func (c *Client) session(ctx context.Context, connectURL string) (bool, error) {
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 30 * time.Second,
+		TLSClientConfig:  c.TLSConfig,
+	}
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+	ws, resp, err := dialer.DialContext(ctx, connectURL, header)
+	if err != nil {
+		if resp != nil {
+			switch resp.StatusCode {
+			case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+				return false, &rejectedError{status: resp.Status}
+			}
+			// 409 Conflict clears once a stale connection of the server times out
+			return false, fmt.Errorf("failed to connect: %s", resp.Status)
+		}
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	// Scripts still running when the connection ends are killed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		ws.Close()
+	}()
+
+	var writeMu sync.Mutex
+	write := func(msg Message) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		ws.SetWriteDeadline(time.Now().Add(writeWait))
+		return ws.WriteJSON(msg)
+	}
+
+	hostname, _ := os.Hostname()
+	var username string
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+	if err := write(Message{Type: MessageHello, Hostname: hostname, User: username, Version: c.Version}); err != nil {
+		return false, fmt.Errorf("failed to send hello: %w", err)
+	}
+	log.Printf("Agent %s connected to %s", c.Name, c.URL)
+
+	ws.SetReadLimit(maxMessageSize)
+	ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPingHandler(func(data string) error {
+		ws.SetReadDeadline(time.Now().Add(pongWait))
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+	})
+
+	var mu sync.Mutex
+	running := make(map[int64]context.CancelFunc)
+	for {
+		var msg Message
+		if err := ws.ReadJSON(&msg); err != nil {
+			return true, err
+		}
+		ws.SetReadDeadline(time.Now().Add(pongWait))
+
+		switch msg.Type {
+		case MessageRun:
+			scriptCtx, cancelScript := context.WithCancel(ctx)
+			mu.Lock()
+			running[msg.ID] = cancelScript
+			mu.Unlock()
+			go func() {
+				result := c.runScript(scriptCtx, msg)
+				mu.Lock()
+				delete(running, msg.ID)
+				mu.Unlock()
+				cancelScript()
+				if err := write(result); err != nil {
+					log.Printf("Failed to send result of script %d: %v", msg.ID, err)
+				}
+			}()
+		case MessageCancel:
+			mu.Lock()
+			if cancelScript, ok := running[msg.ID]; ok {
+				cancelScript()
+			}
+			mu.Unlock()
+		}
+	}
+}
+
+// runScript runs a script with the shell and returns its result
+func (c *Client) runScript(ctx context.Context, msg Message) Message {
+	if msg.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(msg.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	shell := c.Shell
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, shell, "-c", msg.Script)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	cmd.WaitDelay = killWait
+	err := cmd.Run()
+
+	result := Message{Type: MessageResult, ID: msg.ID, Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.ExitCode, result.Error = -1, fmt.Sprintf("command timed out after %s", time.Duration(msg.TimeoutMs)*time.Millisecond)
+	case ctx.Err() != nil:
+		result.ExitCode, result.Error = -1, "command cancelled"
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.ExitCode, result.Error = -1, err.Error()
+	}
+	return result
+}
+
+// limitedBuffer keeps the first maxOutput bytes written to it
+type limitedBuffer struct {
+	data      []byte
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - len(b.data); room < len(p) {
+		b.data = append(b.data, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.data = append(b.data, p...)
+	}
+	return len(p), nil
+}
+
+// String returns the output kept, marked when some was dropped
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return string(b.data) + "\n[output truncated]"
+	}
+	return string(b.data)
+}
@@ -0,0 +1,243 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// Hub keeps the connected agents and sends them scripts to run
+// The zero value is a hub without agents.
+type Hub struct {
+	mu     sync.Mutex
+	agents map[string]*connection
+}
+
+// connection is a connected agent
+type connection struct {
+	ws     *websocket.Conn
+	info   models.ConnectedAgent
+	closed chan struct{}
+	once   sync.Once
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan Message
+}
+
+// Serve registers the agent connected over ws as the agent of the server name and handles
+// its messages until it disconnects
+// The agent must say hello first. While the server has an agent, others are turned away with
+// ErrAlreadyConnected; a stale connection is dropped once its pings go unanswered.
+func (h *Hub) Serve(ws *websocket.Conn, name, connectedBy string) error {
+	ws.SetReadLimit(maxMessageSize)
+	ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	var hello Message
+	if err := ws.ReadJSON(&hello); err != nil {
+		ws.Close()
+		return fmt.Errorf("failed to read hello: %w", err)
+	}
+	if hello.Type != MessageHello {
+		ws.Close()
+		return fmt.Errorf("expected hello, got %q", hello.Type)
+	}
+
+	c := &connection{
+		ws: ws,
+		info: models.ConnectedAgent{
+			Name:        name,
+			Hostname:    hello.Hostname,
+			User:        hello.User,
+			Version:     hello.Version,
+			RemoteAddr:  ws.RemoteAddr().String(),
+			ConnectedBy: connectedBy,
+			ConnectedAt: time.Now().UTC(),
+		},
+		closed:  make(chan struct{}),
+		pending: make(map[int64]chan Message),
+	}
+
+	h.mu.Lock()
+	if h.agents == nil {
+		h.agents = make(map[string]*connection)
+	}
+	if _, ok := h.agents[name]; ok {
+		h.mu.Unlock()
+		ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, ErrAlreadyConnected.Error()), time.Now().Add(writeWait))
+		ws.Close()
+		return ErrAlreadyConnected
+	}
+	h.agents[name] = c
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		if h.agents[name] == c {
+			delete(h.agents, name)
+		}
+		h.mu.Unlock()
+		c.close()
+	}()
+
+	go c.ping()
+	for {
+		var msg Message
+		if err := ws.ReadJSON(&msg); err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				return nil
+			}
+			select {
+			case <-c.closed:
+				return nil // Closed after a failed ping
+			default:
+			}
+			return err
+		}
+		ws.SetReadDeadline(time.Now().Add(pongWait))
+		if msg.Type == MessageResult {
+			c.deliver(msg)
+		}
+	}
+}
+
+// Run sends a script to the agent of the server name and waits for its result
+// When ctx ends first the agent is told to kill the script.
+func (h *Hub) Run(ctx context.Context, name, script string, timeout time.Duration) (*Result, error) {
+	h.mu.Lock()
+	c := h.agents[name]
+	h.mu.Unlock()
+	if c == nil {
+		return nil, ErrNotConnected
+	}
+
+	id, results := c.register()
+	defer c.unregister(id)
+
+	if err := c.write(Message{Type: MessageRun, ID: id, Script: script, TimeoutMs: timeout.Milliseconds()}); err != nil {
+		return nil, fmt.Errorf("failed to send script to agent: %w", err)
+	}
+
+	select {
+	case msg := <-results:
+		result := &Result{Stdout: msg.Stdout, Stderr: msg.Stderr, ExitCode: msg.ExitCode}
+		if msg.Error != "" {
+			return result, errors.New(msg.Error)
+		}
+		return result, nil
+	case <-ctx.Done():
+		c.write(Message{Type: MessageCancel, ID: id})
+		return nil, ctx.Err()
+	case <-c.closed:
+		return nil, ErrDisconnected
+	}
+}
+
+// Get returns the agent connected for the server name, if any
+func (h *Hub) Get(name string) (models.ConnectedAgent, bool) {
+	h.mu.Lock()
+	c := h.agents[name]
+	h.mu.Unlock()
+	if c == nil {
+		return models.ConnectedAgent{}, false
+	}
+	return c.info, true
+}
+
+// List returns the connected agents by name
+func (h *Hub) List() []models.ConnectedAgent {
+	h.mu.Lock()
+	connections := make([]*connection, 0, len(h.agents))
+	for _, c := range h.agents {
+		connections = append(connections, c)
+	}
+	h.mu.Unlock()
+
+	agents := make([]models.ConnectedAgent, 0, len(connections))
+	for _, c := range connections {
+		c.mu.Lock()
+		info := c.info
+		info.Running = len(c.pending)
+		c.mu.Unlock()
+		agents = append(agents, info)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].Name < agents[j].Name
+	})
+	return agents
+}
+
+// register allocates the ID of a script and the channel its result is delivered on
+func (c *connection) register() (int64, chan Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	results := make(chan Message, 1)
+	c.pending[c.nextID] = results
+	return c.nextID, results
+}
+
+// unregister forgets a script that finished or was abandoned
+func (c *connection) unregister(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// deliver passes a result on to the Run waiting for it
+// Results of abandoned scripts are dropped.
+func (c *connection) deliver(msg Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if results, ok := c.pending[msg.ID]; ok {
+		select {
+		case results <- msg:
+		default:
+		}
+	}
+}
+
+// write sends a message to the agent
+func (c *connection) write(msg Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.ws.WriteJSON(msg)
+}
+
+// ping keeps the connection alive until it closes
+func (c *connection) ping() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// close ends the connection, failing the scripts waiting for a result
+func (c *connection) close() {
+	c.once.Do(func() {
+		close(c.closed)
+		c.ws.Close()
+	})
+}
@@ -0,0 +1,69 @@
+// Package agent runs commands on servers that web-cli cannot reach, through an agent on the
+// server that dials out to web-cli over a WebSocket and waits for commands
+package agent
+
+import (
+	"errors"
+	"time"
+)
+
+// Message types exchanged over an agent connection
+const (
+	MessageHello  = "hello"  // Agent to web-cli, first message: hostname, user and version
+	MessageRun    = "run"    // web-cli to agent: run a script
+	MessageCancel = "cancel" // web-cli to agent: kill a running script
+	MessageResult = "result" // Agent to web-cli: outcome of a script
+)
+
+// pingInterval is how often each side pings the other; a connection silent for pongWait is
+// considered dead, and a write not done within writeWait fails
+const (
+	pingInterval = 25 * time.Second
+	pongWait     = 60 * time.Second
+	writeWait    = 10 * time.Second
+)
+
+// maxOutput is how much of each of stdout and stderr an agent sends back
+const maxOutput = 1 << 20
+
+// maxMessageSize bounds the messages read from a connection
+const maxMessageSize = 4 * maxOutput
+
+// ErrNotConnected is returned when no agent is connected for a server
+var ErrNotConnected = errors.New("agent is not connected")
+
+// ErrAlreadyConnected is returned when an agent connects for a server that already has one
+var ErrAlreadyConnected = errors.New("an agent is already connected for the server")
+
+// ErrDisconnected is returned when the agent disconnects while a script runs
+var ErrDisconnected = errors.New("agent disconnected")
+
+// Message is a message exchanged over an agent connection, as JSON
+// Fields that do not apply to the message type are left out.
+type Message struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id,omitempty"` // Script the run, cancel or result is about
+
+	// hello
+	Hostname string `json:"hostname,omitempty"`
+	User     string `json:"user,omitempty"` // User the agent runs scripts as
+	Version  string `json:"version,omitempty"`
+
+	// run
+	Script    string `json:"script,omitempty"`     // Shell script, run with sh -c
+	TimeoutMs int64  `json:"timeout_ms,omitempty"` // Kill the script after this long
+
+	// result
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"` // The script could not run or was killed
+}
+
+// Result is the outcome of a script run by an agent
+// At most 1 MiB of each of stdout and stderr is kept.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
@@ -0,0 +1,113 @@
+// Package apierror writes API errors as a JSON envelope with a stable code,
+// so clients can handle errors without matching on their messages
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RequestIDHeader carries the ID of a request, set by the request ID middleware
+const RequestIDHeader = "X-Request-ID"
+
+// Error codes
+// Codes are part of the API: new ones may be added, existing ones never change meaning
+const (
+	CodeInvalidRequest   = "invalid_request"   // Malformed request or invalid parameters
+	CodeValidationFailed = "validation_failed" // One or more fields are invalid; see fields
+	CodeUnauthorized     = "unauthorized"      // Missing or wrong credentials
+	CodeForbidden        = "forbidden"         // Authenticated, but not allowed
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"          // Clashes with the current state, such as a duplicate name
+	CodeTooLarge         = "payload_too_large" // Request body over the limit
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "unavailable"
+
+	CodeGroupPermissionDenied = "group_permission_denied" // No permission on the resource group
+	CodeInsufficientScope     = "insufficient_scope"      // API token lacks the required scope
+	CodeLoginLocked           = "login_locked"            // Too many failed logins from the client or for the user
+	CodeSessionExpired        = "session_expired"
+	CodeCertificateRequired   = "client_certificate_required"
+	CodeHTTPSRequired         = "https_required"
+	CodeIPDenied              = "ip_denied" // Client IP rejected by the IP access lists
+	CodeReadOnly              = "read_only" // Writes are blocked by read-only mode
+	CodeInvalidSort           = "invalid_sort"
+	CodeQueueFull             = "queue_full"         // No execution slot became free in time
+	CodeQuotaExceeded         = "quota_exceeded"     // The caller's execution quota is used up
+	CodeMaintenanceWindow     = "maintenance_window" // A maintenance window covers the target server
+)
+
+// FieldError reports one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field" example:"ip_address"`
+	Message string `json:"message" example:"invalid IP address or hostname"`
+}
+
+// Response is the JSON body of every API error
+// @Description Error response returned by the API
+type Response struct {
+	Code      string       `json:"code" example:"not_found"`
+	Message   string       `json:"message" example:"Server not found"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty" example:"5f2b8c1e9a7d4e3f"`
+}
+
+// Error writes an error with the code matching its status
+// It is a drop-in replacement for http.Error
+func Error(w http.ResponseWriter, message string, status int) {
+	Write(w, status, Response{Code: CodeForStatus(status), Message: message})
+}
+
+// WithCode writes an error with a specific code
+func WithCode(w http.ResponseWriter, code, message string, status int) {
+	Write(w, status, Response{Code: code, Message: message})
+}
+
+// InvalidField writes a 400 validation error for one field
+func InvalidField(w http.ResponseWriter, field, message string) {
+	Write(w, http.StatusBadRequest, Response{
+		Code:    CodeValidationFailed,
+		Message: message,
+		Fields:  []FieldError{{Field: field, Message: message}},
+	})
+}
+
+// Write encodes an error response with the status, adding the request's ID
+func Write(w http.ResponseWriter, status int, resp Response) {
+	if resp.RequestID == "" {
+		resp.RequestID = w.Header().Get(RequestIDHeader)
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CodeForStatus returns the generic code of an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
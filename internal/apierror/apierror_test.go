@@ -0,0 +1,65 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		write    func(w http.ResponseWriter)
+		status   int
+		expected Response
+	}{
+		{
+			name:     "code from status",
+			write:    func(w http.ResponseWriter) { Error(w, "Failed to fetch servers", http.StatusInternalServerError) },
+			status:   http.StatusInternalServerError,
+			expected: Response{Code: CodeInternal, Message: "Failed to fetch servers"},
+		},
+		{
+			name: "specific code",
+			write: func(w http.ResponseWriter) {
+				WithCode(w, CodeReadOnly, "Server is in read-only mode", http.StatusServiceUnavailable)
+			},
+			status:   http.StatusServiceUnavailable,
+			expected: Response{Code: CodeReadOnly, Message: "Server is in read-only mode"},
+		},
+		{
+			name:   "invalid field",
+			write:  func(w http.ResponseWriter) { InvalidField(w, "port", "Invalid port: out of range") },
+			status: http.StatusBadRequest,
+			expected: Response{Code: CodeValidationFailed, Message: "Invalid port: out of range",
+				Fields: []FieldError{{Field: "port", Message: "Invalid port: out of range"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set(RequestIDHeader, "abc123")
+			tt.write(rec)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", ct)
+			}
+			var resp Response
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Code != tt.expected.Code || resp.Message != tt.expected.Message || resp.RequestID != "abc123" || len(resp.Fields) != len(tt.expected.Fields) {
+				t.Errorf("Expected %+v with request ID abc123, got %+v", tt.expected, resp)
+			}
+			for i := range resp.Fields {
+				if resp.Fields[i] != tt.expected.Fields[i] {
+					t.Errorf("Expected field error %+v, got %+v", tt.expected.Fields[i], resp.Fields[i])
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,11 @@
 package audit
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -13,12 +14,16 @@ import (
 type EventType string
 
 const (
-	EventTypeCommandExecution EventType = "COMMAND_EXECUTION"
-	EventTypeScriptExecution  EventType = "SCRIPT_EXECUTION"
-	EventTypeSSHConnection    EventType = "SSH_CONNECTION"
-	EventTypeTerminalSession  EventType = "TERMINAL_SESSION"
-	EventTypeConfigChange     EventType = "CONFIG_CHANGE"
-	EventTypeAuthAttempt      EventType = "AUTH_ATTEMPT"
+	EventTypeCommandExecution    EventType = "COMMAND_EXECUTION"
+	EventTypeScriptExecution     EventType = "SCRIPT_EXECUTION"
+	EventTypeSSHConnection       EventType = "SSH_CONNECTION"
+	EventTypeTerminalSession     EventType = "TERMINAL_SESSION"
+	EventTypeConfigChange        EventType = "CONFIG_CHANGE"
+	EventTypeAuthAttempt         EventType = "AUTH_ATTEMPT"
+	EventTypeAccessDenied        EventType = "ACCESS_DENIED"
+	EventTypeApproval            EventType = "APPROVAL"
+	EventTypeMaintenanceOverride EventType = "MAINTENANCE_OVERRIDE"
+	EventTypeServerWake          EventType = "SERVER_WAKE"
 )
 
 // EventOutcome represents the result of an audited event
@@ -51,7 +56,7 @@ type AuditEvent struct {
 type Logger struct {
 	mu       sync.Mutex
 	enabled  bool
-	file     *os.File
+	sinks    []Sink
 	filePath string
 }
 
@@ -61,25 +66,29 @@ var (
 )
 
 // Initialize creates or returns the singleton audit logger
-func Initialize(filePath string) (*Logger, error) {
+// Events are appended to filePath (empty for no file) and written to every extra sink,
+// such as syslog or journald. A file that cannot be opened is reported in the
+// returned error; logging continues to the other sinks
+func Initialize(filePath string, sinks ...Sink) (*Logger, error) {
 	var initErr error
 	once.Do(func() {
 		logger := &Logger{
-			enabled:  filePath != "",
 			filePath: filePath,
 		}
 
 		if filePath != "" {
-			file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+			file, err := newFileSink(filePath)
 			if err != nil {
 				initErr = err
 				log.Printf("Warning: Failed to open audit log file %s: %v", filePath, err)
-				logger.enabled = false
 			} else {
-				logger.file = file
+				logger.sinks = append(logger.sinks, file)
 			}
 		}
 
+		logger.sinks = append(logger.sinks, sinks...)
+		logger.enabled = len(logger.sinks) > 0
+
 		defaultLogger = logger
 	})
 
@@ -95,28 +104,61 @@ func GetLogger() *Logger {
 	return defaultLogger
 }
 
-// Close closes the audit log file
+// Close closes the audit log file and all sinks
 func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if l.file != nil {
-		return l.file.Close()
+	var firstErr error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return nil
+	return firstErr
 }
 
-// Log writes an audit event
-func (l *Logger) Log(event *AuditEvent) {
-	if !l.enabled || l.file == nil {
-		return
+// Observer receives every audited event, whether or not audit logging is enabled
+// Observers are called synchronously and must not block.
+type Observer func(event *AuditEvent)
+
+var (
+	observersMu sync.RWMutex
+	observers   = map[int]Observer{}
+	nextID      int
+)
+
+// Observe registers an observer of audited events and returns the function removing it
+func Observe(observer Observer) (remove func()) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	id := nextID
+	nextID++
+	observers[id] = observer
+	return func() {
+		observersMu.Lock()
+		defer observersMu.Unlock()
+		delete(observers, id)
 	}
+}
 
+// Log writes an audit event to every sink
+func (l *Logger) Log(event *AuditEvent) {
 	// Ensure timestamp is set
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now().UTC()
 	}
 
+	observersMu.RLock()
+	for _, observer := range observers {
+		observer(event)
+	}
+	observersMu.RUnlock()
+
+	if !l.enabled || len(l.sinks) == 0 {
+		return
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -126,11 +168,10 @@ func (l *Logger) Log(event *AuditEvent) {
 		return
 	}
 
-	// Append newline for JSONL format
-	data = append(data, '\n')
-
-	if _, err := l.file.Write(data); err != nil {
-		log.Printf("Warning: Failed to write audit event: %v", err)
+	for _, sink := range l.sinks {
+		if err := sink.Write(event, data); err != nil {
+			log.Printf("Warning: Failed to write audit event: %v", err)
+		}
 	}
 }
 
@@ -185,6 +226,28 @@ func (l *Logger) LogScriptExecution(r *http.Request, scriptName, user, server st
 	l.Log(event)
 }
 
+// LogExecutionCancel logs the cancellation of a running command or script
+// kind is "command" or "script"; target is the command or the script's name
+func (l *Logger) LogExecutionCancel(r *http.Request, kind, target, user, server string, metadata map[string]string) {
+	event := &AuditEvent{
+		EventType: EventTypeCommandExecution,
+		Outcome:   OutcomeSuccess,
+		Actor:     getActorFromRequest(r),
+		SourceIP:  getClientIP(r),
+		Target:    server,
+		Command:   sanitizeCommand(target),
+		User:      user,
+		Server:    server,
+		Metadata:  metadata,
+	}
+	if kind == "script" {
+		event.EventType = EventTypeScriptExecution
+		event.Target, event.Command = target, ""
+	}
+
+	l.Log(event)
+}
+
 // LogTerminalSession logs a terminal session start/end
 func (l *Logger) LogTerminalSession(r *http.Request, server, user string, outcome EventOutcome, metadata map[string]string) {
 	event := &AuditEvent{
@@ -201,6 +264,27 @@ func (l *Logger) LogTerminalSession(r *http.Request, server, user string, outcom
 	l.Log(event)
 }
 
+// LogSSHKeyUsage logs an SSH connection made with a stored SSH key
+// usedBy is "command", "script" or "terminal"
+func (l *Logger) LogSSHKeyUsage(r *http.Request, keyName, keySource, server, user, usedBy string) {
+	event := &AuditEvent{
+		EventType: EventTypeSSHConnection,
+		Outcome:   OutcomeSuccess,
+		Actor:     getActorFromRequest(r),
+		SourceIP:  getClientIP(r),
+		Target:    server,
+		User:      user,
+		Server:    server,
+		Metadata: map[string]string{
+			"ssh_key":    keyName,
+			"key_source": keySource,
+			"used_by":    usedBy,
+		},
+	}
+
+	l.Log(event)
+}
+
 // LogAuthAttempt logs an authentication attempt
 func (l *Logger) LogAuthAttempt(r *http.Request, outcome EventOutcome, method string) {
 	event := &AuditEvent{
@@ -217,6 +301,95 @@ func (l *Logger) LogAuthAttempt(r *http.Request, outcome EventOutcome, method st
 	l.Log(event)
 }
 
+// LogAccessDenied logs an authenticated request refused by an access policy
+func (l *Logger) LogAccessDenied(r *http.Request, target string, metadata map[string]string) {
+	event := &AuditEvent{
+		EventType: EventTypeAccessDenied,
+		Outcome:   OutcomeDenied,
+		Actor:     getActorFromRequest(r),
+		SourceIP:  getClientIP(r),
+		Target:    target,
+		Metadata:  metadata,
+	}
+
+	l.Log(event)
+}
+
+// LogPolicyDenied logs a command or script rejected by a command policy
+func (l *Logger) LogPolicyDenied(r *http.Request, target, command, policyName, reason string) {
+	event := &AuditEvent{
+		EventType: EventTypeAccessDenied,
+		Outcome:   OutcomeDenied,
+		Actor:     getActorFromRequest(r),
+		SourceIP:  getClientIP(r),
+		Target:    target,
+		Command:   sanitizeCommand(command),
+		Metadata: map[string]string{
+			"reason":      "command_policy",
+			"policy":      policyName,
+			"explanation": reason,
+		},
+	}
+
+	l.Log(event)
+}
+
+// LogApproval logs a step of the two-person approval workflow
+// Actions are "requested", "approved", "rejected" and "executed"
+func (l *Logger) LogApproval(r *http.Request, approvalID int64, action, target, command string, outcome EventOutcome) {
+	event := &AuditEvent{
+		EventType: EventTypeApproval,
+		Outcome:   outcome,
+		Actor:     getActorFromRequest(r),
+		SourceIP:  getClientIP(r),
+		Target:    target,
+		Command:   sanitizeCommand(command),
+		Metadata: map[string]string{
+			"action":      action,
+			"approval_id": strconv.FormatInt(approvalID, 10),
+		},
+	}
+
+	l.Log(event)
+}
+
+// LogMaintenanceOverride logs an execution run despite a maintenance window covering its server
+func (l *Logger) LogMaintenanceOverride(r *http.Request, server, user, window string) {
+	event := &AuditEvent{
+		EventType: EventTypeMaintenanceOverride,
+		Outcome:   OutcomeSuccess,
+		Actor:     getActorFromRequest(r),
+		SourceIP:  getClientIP(r),
+		Target:    server,
+		User:      user,
+		Server:    server,
+		Metadata: map[string]string{
+			"window": window,
+		},
+	}
+
+	l.Log(event)
+}
+
+// LogServerWake logs a Wake-on-LAN magic packet sent to a server, from web-cli or a relay server
+func (l *Logger) LogServerWake(r *http.Request, server string, metadata map[string]string, err error) {
+	event := &AuditEvent{
+		EventType: EventTypeServerWake,
+		Outcome:   OutcomeSuccess,
+		Actor:     getActorFromRequest(r),
+		SourceIP:  getClientIP(r),
+		Target:    server,
+		Server:    server,
+		Metadata:  metadata,
+	}
+	if err != nil {
+		event.Outcome = OutcomeFailure
+		event.ErrorMsg = err.Error()
+	}
+
+	l.Log(event)
+}
+
 // LogConfigChange logs a configuration change
 func (l *Logger) LogConfigChange(r *http.Request, configType, action string, outcome EventOutcome) {
 	event := &AuditEvent{
@@ -233,12 +406,33 @@ func (l *Logger) LogConfigChange(r *http.Request, configType, action string, out
 	l.Log(event)
 }
 
+// actorKey is the context key for the authenticated actor
+type actorKey struct{}
+
+// WithActor returns a context carrying the authenticated actor
+// Used by authentication methods whose identity is not visible in the request headers
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
 // getActorFromRequest extracts the actor (username) from the request
 func getActorFromRequest(r *http.Request) string {
 	if r == nil {
 		return "system"
 	}
 
+	// Check for an actor set by the authentication middleware
+	if actor, ok := r.Context().Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	// Check for a verified TLS client certificate (mutual TLS)
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			return cn
+		}
+	}
+
 	// Check for basic auth username
 	if username, _, ok := r.BasicAuth(); ok && username != "" {
 		return username
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// journaldSocket is the systemd journal's native protocol socket
+const journaldSocket = "/run/systemd/journal/socket"
+
+// JournaldSink sends audit events to the systemd journal using its native protocol
+// MESSAGE holds the JSON encoded event; the main fields are also sent as
+// WEBCLI_* journal fields so they can be filtered with journalctl
+type JournaldSink struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewJournaldSink connects to the local systemd journal
+func NewJournaldSink() (*JournaldSink, error) {
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &JournaldSink{conn: conn}, nil
+}
+
+// Write sends the event as a journal entry
+func (s *JournaldSink) Write(event *AuditEvent, data []byte) error {
+	priority := severityNotice
+	if event.Outcome != OutcomeSuccess {
+		priority = severityWarning
+	}
+
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", string(data))
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(priority))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", syslogAppName)
+	writeJournalField(&buf, "WEBCLI_EVENT_TYPE", string(event.EventType))
+	writeJournalField(&buf, "WEBCLI_OUTCOME", string(event.Outcome))
+	writeJournalField(&buf, "WEBCLI_ACTOR", event.Actor)
+	writeJournalField(&buf, "WEBCLI_SOURCE_IP", event.SourceIP)
+	writeJournalField(&buf, "WEBCLI_TARGET", event.Target)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+// writeJournalField encodes one field of the journal native protocol
+// Values containing newlines use the length-prefixed binary form
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if value == "" {
+		return
+	}
+
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// Close closes the journal connection
+func (s *JournaldSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
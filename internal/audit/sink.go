@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"os"
+)
+
+// Sink receives every audit event written by the Logger
+// data is the event encoded as a single line of JSON, without a trailing newline
+type Sink interface {
+	Write(event *AuditEvent, data []byte) error
+	Close() error
+}
+
+// fileSink appends events to a JSON Lines file
+type fileSink struct {
+	file *os.File
+}
+
+// newFileSink opens (or creates) the audit log file for appending
+func newFileSink(filePath string) (*fileSink, error) {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file}, nil
+}
+
+// Write appends the event followed by a newline
+func (s *fileSink) Write(_ *AuditEvent, data []byte) error {
+	line := make([]byte, 0, len(data)+1)
+	line = append(line, data...)
+	line = append(line, '\n')
+	_, err := s.file.Write(line)
+	return err
+}
+
+// Close closes the audit log file
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog severities used for audit events
+const (
+	severityWarning = 4 // Failed and denied events
+	severityNotice  = 5 // Successful events
+)
+
+// syslogFacilities maps facility names to RFC 5424 facility codes
+var syslogFacilities = map[string]int{
+	"user":     1,
+	"daemon":   3,
+	"auth":     4,
+	"authpriv": 10,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// localSyslogPaths are the sockets tried for the "local" syslog destination
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogAppName is the APP-NAME field of syslog messages
+const syslogAppName = "web-cli"
+
+// SyslogSink sends audit events to a syslog daemon as RFC 5424 messages
+// The message body is the JSON encoded event and MSGID is the event type
+type SyslogSink struct {
+	mu       sync.Mutex
+	network  string
+	address  string
+	facility int
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogSink connects to a syslog destination
+// destination is "local" for the local syslog socket, or an address such as
+// udp://logs.example.com:514, tcp://logs.example.com:601 or unix:///dev/log.
+// facility is a facility name such as "auth" or "local0" (default: "auth")
+func NewSyslogSink(destination, facility string) (*SyslogSink, error) {
+	if facility == "" {
+		facility = "auth"
+	}
+	code, ok := syslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", facility)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	s := &SyslogSink{facility: code, hostname: hostname}
+
+	switch {
+	case destination == "local":
+		s.network = "unixgram"
+	case strings.HasPrefix(destination, "udp://"):
+		s.network, s.address = "udp", strings.TrimPrefix(destination, "udp://")
+	case strings.HasPrefix(destination, "tcp://"):
+		s.network, s.address = "tcp", strings.TrimPrefix(destination, "tcp://")
+	case strings.HasPrefix(destination, "unix://"):
+		s.network, s.address = "unixgram", strings.TrimPrefix(destination, "unix://")
+	default:
+		return nil, fmt.Errorf("invalid syslog destination %q (use local, udp://, tcp:// or unix://)", destination)
+	}
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect dials the syslog destination
+func (s *SyslogSink) connect() error {
+	if s.network == "unixgram" && s.address == "" {
+		for _, path := range localSyslogPaths {
+			conn, err := net.Dial("unixgram", path)
+			if err == nil {
+				s.conn = conn
+				return nil
+			}
+		}
+		return fmt.Errorf("no local syslog socket found (tried %s)", strings.Join(localSyslogPaths, ", "))
+	}
+
+	conn, err := net.DialTimeout(s.network, s.address, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// Write sends the event, reconnecting once if the connection was lost
+func (s *SyslogSink) Write(event *AuditEvent, data []byte) error {
+	msg := s.format(event, data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if _, err := s.conn.Write(msg); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// format builds an RFC 5424 message, framed with an octet count over TCP (RFC 6587)
+func (s *SyslogSink) format(event *AuditEvent, data []byte) []byte {
+	severity := severityNotice
+	if event.Outcome != OutcomeSuccess {
+		severity = severityWarning
+	}
+
+	msgID := string(event.EventType)
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		s.facility*8+severity,
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		syslogAppName,
+		os.Getpid(),
+		msgID,
+		data,
+	)
+
+	if s.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	return []byte(msg)
+}
+
+// Close closes the syslog connection
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,230 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 signature of the request body
+const WebhookSignatureHeader = "X-WebCLI-Signature"
+
+// WebhookConfig holds audit webhook settings
+type WebhookConfig struct {
+	URL           string        // Endpoint receiving batches of events
+	Secret        string        // Key for the HMAC-SHA256 body signature (empty to send unsigned)
+	BatchSize     int           // Maximum events per request (default: 100)
+	FlushInterval time.Duration // Maximum time an event waits for a batch to fill (default: 5s)
+	QueueSize     int           // Events buffered while the endpoint is slow or down (default: 10000)
+	MaxRetries    int           // Retries for a failed batch before it is dropped
+	Timeout       time.Duration // Timeout for each request (default: 10s)
+}
+
+// WebhookSink forwards audit events to an HTTP endpoint such as a SIEM collector
+// Events are queued and POSTed in batches as a JSON array. Failed batches are
+// retried with exponential backoff. When the queue is full, new events are
+// dropped and counted rather than slowing down requests.
+type WebhookSink struct {
+	config  WebhookConfig
+	client  *http.Client
+	queue   chan []byte
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	mu           sync.Mutex
+	dropped      int   // Dropped since last reported
+	totalDropped int64 // Dropped since the sink was created
+}
+
+// NewWebhookSink creates the sink and starts delivering events in the background
+func NewWebhookSink(config WebhookConfig) (*WebhookSink, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("audit webhook URL is required")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	s := &WebhookSink{
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		queue:   make(chan []byte, config.QueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write queues the event for delivery without blocking
+func (s *WebhookSink) Write(_ *AuditEvent, data []byte) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("audit webhook is closed")
+	default:
+	}
+
+	select {
+	case s.queue <- data:
+		return nil
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.totalDropped++
+		s.mu.Unlock()
+		return nil
+	}
+}
+
+// Close delivers queued events and stops the sink
+func (s *WebhookSink) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+// run collects queued events into batches and sends them
+func (s *WebhookSink) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.config.BatchSize)
+	flush := func() {
+		s.reportDropped()
+		if len(batch) == 0 {
+			return
+		}
+		s.send(batch)
+		batch = make([][]byte, 0, s.config.BatchSize)
+	}
+
+	for {
+		select {
+		case data := <-s.queue:
+			batch = append(batch, data)
+			if len(batch) >= s.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.done:
+			// Deliver what is already queued, then stop
+			for {
+				select {
+				case data := <-s.queue:
+					batch = append(batch, data)
+					if len(batch) >= s.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// reportDropped logs events dropped because the queue was full
+func (s *WebhookSink) reportDropped() {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Warning: audit webhook queue full, dropped %d events", dropped)
+	}
+}
+
+// send POSTs a batch, retrying with exponential backoff
+func (s *WebhookSink) send(batch [][]byte) {
+	body := make([]byte, 0, 2+len(batch)*256)
+	body = append(body, '[')
+	body = append(body, bytes.Join(batch, []byte{','})...)
+	body = append(body, ']')
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= s.config.MaxRetries {
+			log.Printf("Warning: failed to deliver %d audit events to webhook: %v", len(batch), err)
+			return
+		}
+
+		// Do not hold up shutdown with retries
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			log.Printf("Warning: failed to deliver %d audit events to webhook before shutdown: %v", len(batch), err)
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// post sends one request and reports whether a failure is worth retrying
+func (s *WebhookSink) post(body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "web-cli-audit")
+	if s.config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookBody(s.config.Secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+}
+
+// SignWebhookBody returns the signature header value for a webhook body:
+// "sha256=" followed by the hex HMAC-SHA256 of the body keyed with secret
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
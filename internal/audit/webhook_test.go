@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookSinkBatchesAndSigns(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]AuditEvent
+	var calls int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+
+		// The first request fails and must be retried
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if got := r.Header.Get(WebhookSignatureHeader); got != SignWebhookBody("s3cret", body) {
+			t.Errorf("Signature mismatch: got %q", got)
+		}
+
+		var events []AuditEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			t.Errorf("Body is not a JSON array of events: %v", err)
+		}
+		batches = append(batches, events)
+	}))
+	defer ts.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: ts.URL, Secret: "s3cret", BatchSize: 2, MaxRetries: 3, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create webhook sink: %v", err)
+	}
+	logger := &Logger{enabled: true, sinks: []Sink{sink}}
+
+	for _, actor := range []string{"alice", "bob", "carol"} {
+		logger.Log(&AuditEvent{EventType: EventTypeAuthAttempt, Outcome: OutcomeSuccess, Actor: actor})
+	}
+
+	// Wait for the full batch to be delivered after its retry
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		delivered := len(batches)
+		mu.Unlock()
+		if delivered == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Closing delivers the partial batch
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close logger: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("Expected batches of 2 and 1 events, got %v", batches)
+	}
+	if batches[0][0].Actor != "alice" || batches[1][0].Actor != "carol" {
+		t.Errorf("Events delivered out of order: %v", batches)
+	}
+}
+
+func TestWebhookSinkDropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer ts.Close()
+	defer close(block)
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: ts.URL, BatchSize: 1, QueueSize: 1, MaxRetries: 0, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create webhook sink: %v", err)
+	}
+
+	// Writes never block, even while the endpoint hangs
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 50; i++ {
+			sink.Write(&AuditEvent{}, []byte(`{}`))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Write blocked with a full queue")
+	}
+
+	sink.mu.Lock()
+	dropped := sink.totalDropped
+	sink.mu.Unlock()
+	if dropped == 0 {
+		t.Error("Expected events to be dropped when the queue is full")
+	}
+}
@@ -0,0 +1,110 @@
+package awsauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// Example request from the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	Sign(req, nil, Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}, "us-east-1", "iam",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Unexpected signature\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+// resetInstanceCredentials empties the instance role's credential cache
+func resetInstanceCredentials() {
+	instanceCredentials.mu.Lock()
+	defer instanceCredentials.mu.Unlock()
+	instanceCredentials.creds, instanceCredentials.expires = Credentials{}, time.Time{}
+}
+
+func TestDefault(t *testing.T) {
+	resetInstanceCredentials()
+	t.Cleanup(resetInstanceCredentials)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	creds, err := Default(context.Background())
+	if err != nil || creds.AccessKeyID != "AKIDENV" || creds.SessionToken != "session" {
+		t.Errorf("Expected the environment's credentials, got %+v (%v)", creds, err)
+	}
+
+	// Without them, the instance role's credentials are read with an IMDSv2 session token
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	var requests int
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			fmt.Fprint(w, "imds-token")
+		case r.Header.Get("X-aws-ec2-metadata-token") != "imds-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			fmt.Fprint(w, "web-cli")
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/web-cli":
+			fmt.Fprintf(w, `{"AccessKeyId":"ASIAROLE","SecretAccessKey":"role-secret","Token":"role-token","Expiration":%q}`,
+				time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer metadata.Close()
+	defer func(endpoint string) { metadataEndpoint = endpoint }(metadataEndpoint)
+	metadataEndpoint = metadata.URL
+
+	for range 2 {
+		creds, err = Default(context.Background())
+		if err != nil || creds.AccessKeyID != "ASIAROLE" || creds.SessionToken != "role-token" {
+			t.Fatalf("Expected the instance role's credentials, got %+v (%v)", creds, err)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("Expected the role's credentials to be cached, got %d requests", requests)
+	}
+}
+
+func TestAssumeRole(t *testing.T) {
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKID/") || !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/sts/aws4_request") {
+			t.Errorf("Unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("RoleArn") == "arn:aws:iam::123456789012:role/denied" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>AccessDenied</Code><Message>not authorized to perform sts:AssumeRole</Message></Error></ErrorResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+			<AccessKeyId>ASIAASSUMED</AccessKeyId><SecretAccessKey>assumed-secret</SecretAccessKey><SessionToken>assumed-token</SessionToken>
+		</Credentials></AssumeRoleResult></AssumeRoleResponse>`)
+	}))
+	defer sts.Close()
+
+	base := Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	creds, err := AssumeRole(context.Background(), base, "eu-west-1", sts.URL, "arn:aws:iam::123456789012:role/ssm", "web-cli")
+	if err != nil || creds.AccessKeyID != "ASIAASSUMED" || creds.SessionToken != "assumed-token" {
+		t.Errorf("Expected the role's credentials, got %+v (%v)", creds, err)
+	}
+
+	_, err = AssumeRole(context.Background(), base, "eu-west-1", sts.URL, "arn:aws:iam::123456789012:role/denied", "web-cli")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Expected the STS error, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package awsauth
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestTimeout bounds requests for credentials
+const requestTimeout = 10 * time.Second
+
+// httpClient is used for all credential requests
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// metadataEndpoint is the EC2 instance metadata service (IMDSv2)
+var metadataEndpoint = "http://169.254.169.254"
+
+// instanceCredentials caches the credentials of the instance role until shortly before they expire
+var instanceCredentials struct {
+	mu      sync.Mutex
+	creds   Credentials
+	expires time.Time
+}
+
+// Default returns the credentials of the environment (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+// and AWS_SESSION_TOKEN), or else those of the IAM role of the EC2 instance web-cli runs on
+func Default(ctx context.Context) (Credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return Credentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	instanceCredentials.mu.Lock()
+	defer instanceCredentials.mu.Unlock()
+	if instanceCredentials.creds.AccessKeyID != "" && time.Now().Before(instanceCredentials.expires) {
+		return instanceCredentials.creds, nil
+	}
+	creds, expires, err := fromInstanceMetadata(ctx)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("no AWS credentials: AWS_ACCESS_KEY_ID is not set and the instance role is unavailable: %w", err)
+	}
+	instanceCredentials.creds, instanceCredentials.expires = creds, expires.Add(-5*time.Minute)
+	return creds, nil
+}
+
+// fromInstanceMetadata reads the credentials of the instance role from the metadata service
+func fromInstanceMetadata(ctx context.Context) (Credentials, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	token, err := metadataRequest(ctx, http.MethodPut, "/latest/api/token", "")
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	roles, err := metadataRequest(ctx, http.MethodGet, "/latest/meta-data/iam/security-credentials/", token)
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(roles), "\n")
+	if role == "" {
+		return Credentials{}, time.Time{}, fmt.Errorf("the instance has no IAM role")
+	}
+	data, err := metadataRequest(ctx, http.MethodGet, "/latest/meta-data/iam/security-credentials/"+url.PathEscape(role), token)
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+
+	var response struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal([]byte(data), &response); err != nil || response.AccessKeyID == "" {
+		return Credentials{}, time.Time{}, fmt.Errorf("invalid instance role credentials")
+	}
+	return Credentials{AccessKeyID: response.AccessKeyID, SecretAccessKey: response.SecretAccessKey, SessionToken: response.Token}, response.Expiration, nil
+}
+
+// metadataRequest makes a request to the metadata service with a session token
+// Without a token, a new one is requested.
+func metadataRequest(ctx context.Context, method, path, token string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, metadataEndpoint+path, nil)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	} else {
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("instance metadata request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("instance metadata request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("instance metadata request failed: %s", resp.Status)
+	}
+	return string(body), nil
+}
+
+// assumeRoleResponse is the body of an AssumeRole response
+type assumeRoleResponse struct {
+	Credentials struct {
+		AccessKeyID     string `xml:"AccessKeyId"`
+		SecretAccessKey string `xml:"SecretAccessKey"`
+		SessionToken    string `xml:"SessionToken"`
+	} `xml:"AssumeRoleResult>Credentials"`
+}
+
+// stsErrorResponse is the body of a failed STS request
+type stsErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// AssumeRole returns temporary credentials of an IAM role, assumed with creds for 15 minutes
+// endpoint is the STS endpoint (empty for the regional one of AWS).
+func AssumeRole(ctx context.Context, creds Credentials, region, endpoint, roleARN, sessionName string) (Credentials, error) {
+	if endpoint == "" {
+		endpoint = "https://sts." + region + ".amazonaws.com"
+	}
+	query := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {roleARN},
+		"RoleSessionName": {sessionName},
+		"DurationSeconds": {"900"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/", nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.URL.RawQuery = CanonicalQuery(query)
+	Sign(req, nil, creds, region, "sts", time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to assume role %s: %w", roleARN, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResponse stsErrorResponse
+		if xml.NewDecoder(resp.Body).Decode(&errResponse) != nil || errResponse.Code == "" {
+			return Credentials{}, fmt.Errorf("failed to assume role %s: %s", roleARN, resp.Status)
+		}
+		return Credentials{}, fmt.Errorf("failed to assume role %s: %s: %s", roleARN, errResponse.Code, errResponse.Message)
+	}
+
+	var response assumeRoleResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&response); err != nil || response.Credentials.AccessKeyID == "" {
+		return Credentials{}, fmt.Errorf("failed to assume role %s: invalid STS response", roleARN)
+	}
+	return Credentials{
+		AccessKeyID:     response.Credentials.AccessKeyID,
+		SecretAccessKey: response.Credentials.SecretAccessKey,
+		SessionToken:    response.Credentials.SessionToken,
+	}, nil
+}
@@ -0,0 +1,166 @@
+// Package bundle exports the stored configuration to a single passphrase-encrypted
+// file and imports it again, for migrating between instances and disaster recovery
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+const (
+	// Format identifies export files
+	Format = "web-cli-export"
+	// FormatVersion is the version of the bundle layout written by Export
+	FormatVersion = 1
+	// MinPassphraseLength is the shortest passphrase accepted for encrypting a bundle
+	MinPassphraseLength = 12
+
+	kdfIterations = 600000 // PBKDF2-SHA256 rounds (OWASP recommendation)
+	saltSize      = 16
+)
+
+// Bundle is the configuration stored in an export file
+// IDs are those of the exporting instance and are only used to relink
+// references (presets to scripts, commands to servers and keys) on import
+type Bundle struct {
+	Version       int                    `json:"version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	SSHKeys       []*models.SSHKey       `json:"ssh_keys"`
+	Servers       []*models.Server       `json:"servers"`
+	EnvVariables  []*models.EnvVariable  `json:"env_variables"`
+	BashScripts   []*models.BashScript   `json:"bash_scripts"`
+	ScriptPresets []*models.ScriptPreset `json:"script_presets"`
+	SavedCommands []*models.SavedCommand `json:"saved_commands"`
+}
+
+// envelope is the JSON written to an export file, holding the encrypted bundle
+type envelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Data       []byte `json:"data"` // Nonce followed by the AES-256-GCM sealed bundle
+}
+
+// Export reads the stored servers, SSH keys, environment variables, bash scripts,
+// script presets and saved commands. Script version history is not included
+func Export(db *database.DB) (*Bundle, error) {
+	b := &Bundle{Version: FormatVersion, ExportedAt: time.Now().UTC()}
+
+	var err error
+	if b.SSHKeys, err = repository.NewSSHKeyRepository(db).GetAll(); err != nil {
+		return nil, err
+	}
+	if b.Servers, err = repository.NewServerRepository(db).GetAll(); err != nil {
+		return nil, err
+	}
+	if b.EnvVariables, err = repository.NewEnvVariableRepository(db).GetAll(); err != nil {
+		return nil, err
+	}
+	if b.BashScripts, err = repository.NewBashScriptRepository(db).GetAll(); err != nil {
+		return nil, err
+	}
+	if b.ScriptPresets, err = repository.NewScriptPresetRepository(db).GetAll(); err != nil {
+		return nil, err
+	}
+	if b.SavedCommands, err = repository.NewSavedCommandRepository(db).GetAll(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Seal encrypts a bundle with a key derived from the passphrase
+func Seal(b *Bundle, passphrase string) ([]byte, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+	}
+
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := newGCM(passphrase, salt, kdfIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return json.MarshalIndent(envelope{
+		Format:     Format,
+		Version:    FormatVersion,
+		KDF:        "pbkdf2-sha256",
+		Iterations: kdfIterations,
+		Salt:       salt,
+		Data:       gcm.Seal(nonce, nonce, plaintext, []byte(Format)),
+	}, "", "  ")
+}
+
+// Open decrypts an export file written by Seal
+func Open(data []byte, passphrase string) (*Bundle, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Format != Format {
+		return nil, fmt.Errorf("not a web-cli export file")
+	}
+	if env.Version > FormatVersion {
+		return nil, fmt.Errorf("export file version %d is newer than supported (%d)", env.Version, FormatVersion)
+	}
+	if env.KDF != "pbkdf2-sha256" || env.Iterations <= 0 {
+		return nil, fmt.Errorf("unsupported key derivation %q", env.KDF)
+	}
+
+	gcm, err := newGCM(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("export file is truncated")
+	}
+	nonce, sealed := env.Data[:gcm.NonceSize()], env.Data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, []byte(Format))
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted export file")
+	}
+
+	var b Bundle
+	if err := json.Unmarshal(plaintext, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle: %w", err)
+	}
+	return &b, nil
+}
+
+// newGCM derives an AES-256 key from the passphrase and returns its GCM cipher
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,191 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"golang.org/x/crypto/ssh"
+)
+
+func setupTestDB(t *testing.T) *database.DB {
+	tmpDir := t.TempDir()
+	if err := database.InitializeEncryption(filepath.Join(tmpDir, ".encryption_key")); err != nil {
+		t.Fatalf("Failed to initialize encryption: %v", err)
+	}
+	db, err := database.New(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// seed stores one item of every exported type, linked to each other
+func seed(t *testing.T, db *database.DB) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := repository.NewSSHKeyRepository(db).Create(&models.SSHKeyCreate{Name: "deploy-key", PrivateKey: string(pem.EncodeToMemory(block))})
+	if err != nil {
+		t.Fatalf("Failed to create SSH key: %v", err)
+	}
+	server, err := repository.NewServerRepository(db).Create(&models.ServerCreate{Name: "web1", IPAddress: "10.0.0.1", Username: "deploy"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	envVar, err := repository.NewEnvVariableRepository(db).Create(&models.EnvVariableCreate{Name: "API_TOKEN", Value: "s3cret"})
+	if err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+	script, err := repository.NewBashScriptRepository(db).Create(&models.BashScriptCreate{Name: "deploy", Content: "echo deploy\n", Interpreter: "sh"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	if _, err := repository.NewScriptPresetRepository(db).Create(&models.ScriptPresetCreate{
+		Name: "deploy web1", ScriptID: script.ID, EnvVarIDs: []int64{envVar.ID}, IsRemote: true, ServerID: &server.ID, SSHKeyID: &key.ID,
+	}); err != nil {
+		t.Fatalf("Failed to create preset: %v", err)
+	}
+	if _, err := repository.NewSavedCommandRepository(db).Create(&models.SavedCommandCreate{
+		Name: "uptime", Command: "uptime", IsRemote: true, ServerID: &server.ID, SSHKeyID: &key.ID,
+	}); err != nil {
+		t.Fatalf("Failed to create saved command: %v", err)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	b := &Bundle{Version: FormatVersion, EnvVariables: []*models.EnvVariable{{Name: "API_TOKEN", Value: "s3cret"}}}
+
+	if _, err := Seal(b, "short"); err == nil {
+		t.Error("Expected short passphrase to be rejected")
+	}
+
+	data, err := Seal(b, "correct horse battery")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if strings.Contains(string(data), "s3cret") || strings.Contains(string(data), "API_TOKEN") {
+		t.Error("Export file contains plaintext")
+	}
+
+	if _, err := Open(data, "wrong horse battery"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Expected wrong passphrase error, got %v", err)
+	}
+	if _, err := Open([]byte(`{"hello":"world"}`), "correct horse battery"); err == nil {
+		t.Error("Expected non-export file to be rejected")
+	}
+
+	opened, err := Open(data, "correct horse battery")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(opened.EnvVariables) != 1 || opened.EnvVariables[0].Value != "s3cret" {
+		t.Errorf("Unexpected bundle after round trip: %+v", opened)
+	}
+}
+
+func TestImport(t *testing.T) {
+	source := setupTestDB(t)
+	seed(t, source)
+
+	b, err := Export(source)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	target := setupTestDB(t)
+	if _, err := Import(target, b, "merge"); err == nil {
+		t.Error("Expected invalid strategy to be rejected")
+	}
+
+	result, err := Import(target, b, StrategySkip)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	for name, counts := range map[string]Counts{
+		"ssh_keys": result.SSHKeys, "servers": result.Servers, "env_variables": result.EnvVariables,
+		"bash_scripts": result.BashScripts, "script_presets": result.ScriptPresets, "saved_commands": result.SavedCommands,
+	} {
+		if counts.Created != 1 {
+			t.Errorf("%s: expected 1 created, got %+v", name, counts)
+		}
+	}
+
+	// References are relinked to the imported items
+	presets, _ := repository.NewScriptPresetRepository(target).GetAll()
+	scripts, _ := repository.NewBashScriptRepository(target).GetAll()
+	servers, _ := repository.NewServerRepository(target).GetAll()
+	envVars, _ := repository.NewEnvVariableRepository(target).GetAll()
+	if len(presets) != 1 || presets[0].ScriptID != scripts[0].ID || *presets[0].ServerID != servers[0].ID || presets[0].EnvVarIDs[0] != envVars[0].ID {
+		t.Errorf("Preset not relinked: %+v", presets)
+	}
+	if scripts[0].Interpreter != "sh" || envVars[0].Value != "s3cret" {
+		t.Errorf("Unexpected imported data: %+v %+v", scripts[0], envVars[0])
+	}
+
+	// Skipping again changes nothing
+	result, err = Import(target, b, StrategySkip)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.BashScripts.Skipped != 1 || result.BashScripts.Created != 0 {
+		t.Errorf("Expected script to be skipped, got %+v", result.BashScripts)
+	}
+
+	// Overwrite replaces the existing items' fields
+	b.BashScripts[0].Content = "echo deploy v2\n"
+	b.EnvVariables[0].Value = "rotated"
+	if _, err := Import(target, b, StrategyOverwrite); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	script, _ := repository.NewBashScriptRepository(target).GetByID(scripts[0].ID)
+	envVar, _ := repository.NewEnvVariableRepository(target).GetByID(envVars[0].ID)
+	if script.Content != "echo deploy v2\n" || envVar.Value != "rotated" {
+		t.Errorf("Expected overwritten content, got %q %q", script.Content, envVar.Value)
+	}
+
+	// Rename imports a second copy under new names
+	result, err = Import(target, b, StrategyRename)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.BashScripts.Renamed != 1 || result.EnvVariables.Renamed != 1 {
+		t.Errorf("Expected renamed items, got %+v", result)
+	}
+	if _, err := repository.NewBashScriptRepository(target).GetByName("deploy-imported"); err != nil {
+		t.Errorf("Renamed script not found: %v", err)
+	}
+	if _, err := repository.NewEnvVariableRepository(target).GetByName("API_TOKEN_IMPORTED"); err != nil {
+		t.Errorf("Renamed env variable not found: %v", err)
+	}
+	if _, err := Import(target, b, StrategyRename); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if _, err := repository.NewBashScriptRepository(target).GetByName("deploy-imported-2"); err != nil {
+		t.Errorf("Second renamed script not found: %v", err)
+	}
+
+	// Invalid bundles are rejected before anything is written
+	before, _ := repository.NewSavedCommandRepository(target).GetAll()
+	b.BashScripts[0].Content = ""
+	if _, err := Import(target, b, StrategyRename); err == nil {
+		t.Error("Expected invalid bundle to be rejected")
+	}
+	after, _ := repository.NewSavedCommandRepository(target).GetAll()
+	if len(after) != len(before) {
+		t.Errorf("Invalid bundle imported saved commands: %d -> %d", len(before), len(after))
+	}
+}
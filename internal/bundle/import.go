@@ -0,0 +1,558 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// Conflict strategies for items whose name already exists
+const (
+	StrategySkip      = "skip"      // Keep the existing item
+	StrategyOverwrite = "overwrite" // Replace the existing item's fields with the imported ones
+	StrategyRename    = "rename"    // Import the item under a new name
+)
+
+// Strategies lists the supported conflict strategies
+var Strategies = []string{StrategySkip, StrategyOverwrite, StrategyRename}
+
+// Counts reports what happened to the items of one type
+type Counts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Renamed int `json:"renamed"` // Created under a new name
+	Skipped int `json:"skipped"`
+}
+
+// Result reports the outcome of an import
+type Result struct {
+	Strategy      string `json:"strategy"`
+	SSHKeys       Counts `json:"ssh_keys"`
+	Servers       Counts `json:"servers"`
+	EnvVariables  Counts `json:"env_variables"`
+	BashScripts   Counts `json:"bash_scripts"`
+	ScriptPresets Counts `json:"script_presets"`
+	SavedCommands Counts `json:"saved_commands"`
+}
+
+// importer holds the state of one import
+type importer struct {
+	db       *database.DB
+	strategy string
+	result   Result
+
+	// Bundle IDs mapped to the IDs of the imported (or kept) items
+	keyIDs    map[int64]int64
+	serverIDs map[int64]int64
+	envIDs    map[int64]int64
+	scriptIDs map[int64]int64
+}
+
+// Import stores a bundle's items, matching existing ones by name (servers without
+// a name by IP address) and resolving conflicts with the strategy. The bundle is
+// validated before anything is written; references between items are relinked
+// to the imported IDs and dropped when their target is not in the bundle
+func Import(db *database.DB, b *Bundle, strategy string) (*Result, error) {
+	switch strategy {
+	case StrategySkip, StrategyOverwrite, StrategyRename:
+	default:
+		return nil, fmt.Errorf("invalid strategy %q (must be one of %s)", strategy, strings.Join(Strategies, ", "))
+	}
+	if err := validate(b); err != nil {
+		return nil, err
+	}
+
+	im := &importer{
+		db:        db,
+		strategy:  strategy,
+		result:    Result{Strategy: strategy},
+		keyIDs:    map[int64]int64{},
+		serverIDs: map[int64]int64{},
+		envIDs:    map[int64]int64{},
+		scriptIDs: map[int64]int64{},
+	}
+
+	// Items are imported before the items that reference them
+	for _, step := range []func(*Bundle) error{
+		im.importSSHKeys, im.importServers, im.importEnvVariables,
+		im.importBashScripts, im.importSavedCommands, im.importScriptPresets,
+	} {
+		if err := step(b); err != nil {
+			return &im.result, err
+		}
+	}
+
+	return &im.result, nil
+}
+
+// validate checks every item of the bundle so an invalid bundle is rejected as a whole
+func validate(b *Bundle) error {
+	for _, key := range b.SSHKeys {
+		if key.Name == "" {
+			return fmt.Errorf("SSH key without a name")
+		}
+		if err := validation.ValidateSSHPrivateKey(key.PrivateKey); err != nil {
+			return fmt.Errorf("SSH key %s: %w", key.Name, err)
+		}
+	}
+	for _, server := range b.Servers {
+		if server.Name == "" && server.IPAddress == "" {
+			return fmt.Errorf("server without a name or IP address")
+		}
+	}
+	for _, envVar := range b.EnvVariables {
+		if err := validation.ValidateEnvVarName(envVar.Name); err != nil {
+			return err
+		}
+		if err := validation.ValidateEnvVarValue(envVar.Value); err != nil {
+			return fmt.Errorf("environment variable %s: %w", envVar.Name, err)
+		}
+	}
+	for _, script := range b.BashScripts {
+		if err := validation.ValidateBashScriptName(script.Name); err != nil {
+			return err
+		}
+		if err := validation.ValidateBashScriptContent(script.Content); err != nil {
+			return fmt.Errorf("bash script %s: %w", script.Name, err)
+		}
+		if script.Filename != "" {
+			if err := validation.ValidateBashScriptFilename(script.Filename); err != nil {
+				return fmt.Errorf("bash script %s: %w", script.Name, err)
+			}
+		}
+		if err := validation.ValidateScriptInterpreter(script.Interpreter); err != nil {
+			return fmt.Errorf("bash script %s: %w", script.Name, err)
+		}
+		if err := validation.ValidateTags(script.Tags); err != nil {
+			return fmt.Errorf("bash script %s: %w", script.Name, err)
+		}
+		if err := validation.ValidateExitCodes(script.ExitCodes); err != nil {
+			return fmt.Errorf("bash script %s: %w", script.Name, err)
+		}
+	}
+	for _, preset := range b.ScriptPresets {
+		if preset.Name == "" {
+			return fmt.Errorf("script preset without a name")
+		}
+		if err := validation.ValidateSchedule(preset.Schedule, preset.ScheduleEnabled); err != nil {
+			return fmt.Errorf("script preset %s: %w", preset.Name, err)
+		}
+	}
+	for _, cmd := range b.SavedCommands {
+		if err := validation.ValidateCommandName(cmd.Name); err != nil {
+			return err
+		}
+		if err := validation.ValidateCommand(cmd.Command); err != nil {
+			return fmt.Errorf("saved command %s: %w", cmd.Name, err)
+		}
+		if err := validation.ValidateTags(cmd.Tags); err != nil {
+			return fmt.Errorf("saved command %s: %w", cmd.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolve decides what to do with an imported item whose name may already exist
+// It returns the existing item's ID to overwrite or keep (0 to create), and the name to create it under
+func (im *importer) resolve(counts *Counts, existing map[string]int64, name, separator string) (id int64, newName string, skip bool) {
+	id, ok := existing[name]
+	if !ok {
+		return 0, name, false
+	}
+
+	switch im.strategy {
+	case StrategyOverwrite:
+		return id, name, false
+	case StrategyRename:
+		return 0, renamed(existing, name, separator), false
+	default:
+		counts.Skipped++
+		return id, name, true
+	}
+}
+
+// created records a newly created item under its name
+func created(counts *Counts, existing map[string]int64, name, originalName string, id int64) {
+	existing[name] = id
+	if name == originalName {
+		counts.Created++
+	} else {
+		counts.Renamed++
+	}
+}
+
+// renamed returns the first free name of the form name-imported, name-imported-2, ...
+func renamed(existing map[string]int64, name, separator string) string {
+	base := name + separator + "imported"
+	if separator == "_" {
+		base = name + "_IMPORTED"
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		if _, ok := existing[candidate]; !ok {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%s%d", base, separator, n)
+	}
+}
+
+// mapID relinks a reference from the bundle to the imported item, nil when it was not imported
+func mapID(ids map[int64]int64, id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	if mapped, ok := ids[*id]; ok {
+		return &mapped
+	}
+	return nil
+}
+
+func (im *importer) importSSHKeys(b *Bundle) error {
+	repo := repository.NewSSHKeyRepository(im.db)
+	current, err := repo.GetAll()
+	if err != nil {
+		return err
+	}
+	existing := map[string]int64{}
+	for _, key := range current {
+		existing[key.Name] = key.ID
+	}
+
+	counts := &im.result.SSHKeys
+	for _, key := range b.SSHKeys {
+		id, name, skip := im.resolve(counts, existing, key.Name, "-")
+		if skip {
+			im.keyIDs[key.ID] = id
+			continue
+		}
+		if id != 0 {
+			if _, err := repo.Update(id, &models.SSHKeyUpdate{Name: name, PrivateKey: key.PrivateKey, Group: key.Group}); err != nil {
+				return err
+			}
+			counts.Updated++
+			im.keyIDs[key.ID] = id
+			continue
+		}
+		createdKey, err := repo.Create(&models.SSHKeyCreate{Name: name, PrivateKey: key.PrivateKey, Group: key.Group})
+		if err != nil {
+			return err
+		}
+		created(counts, existing, name, key.Name, createdKey.ID)
+		im.keyIDs[key.ID] = createdKey.ID
+	}
+	return nil
+}
+
+// serverName is the name servers are matched by: their hostname, or IP address when unnamed
+func serverName(server *models.Server) string {
+	if server.Name != "" {
+		return server.Name
+	}
+	return server.IPAddress
+}
+
+func (im *importer) importServers(b *Bundle) error {
+	repo := repository.NewServerRepository(im.db)
+	current, err := repo.GetAll()
+	if err != nil {
+		return err
+	}
+	existing := map[string]int64{}
+	for _, server := range current {
+		existing[serverName(server)] = server.ID
+	}
+
+	counts := &im.result.Servers
+	for _, server := range b.Servers {
+		id, name, skip := im.resolve(counts, existing, serverName(server), "-")
+		if skip {
+			im.serverIDs[server.ID] = id
+			continue
+		}
+		if id != 0 {
+			requiresApproval, maxParallel := server.RequiresApproval, server.MaxParallel
+			if _, err := repo.Update(id, &models.ServerUpdate{
+				IPAddress:        server.IPAddress,
+				Port:             server.Port,
+				Username:         server.Username,
+				Group:            server.Group,
+				RequiresApproval: &requiresApproval,
+				MaxParallel:      &maxParallel,
+			}); err != nil {
+				return err
+			}
+			counts.Updated++
+			im.serverIDs[server.ID] = id
+			continue
+		}
+
+		create := &models.ServerCreate{
+			Name:             server.Name,
+			IPAddress:        server.IPAddress,
+			Port:             server.Port,
+			Username:         server.Username,
+			Group:            server.Group,
+			RequiresApproval: server.RequiresApproval,
+			MaxParallel:      server.MaxParallel,
+		}
+		if name != serverName(server) {
+			create.Name = name
+		}
+		createdServer, err := repo.Create(create)
+		if err != nil {
+			return err
+		}
+		created(counts, existing, name, serverName(server), createdServer.ID)
+		im.serverIDs[server.ID] = createdServer.ID
+	}
+	return nil
+}
+
+func (im *importer) importEnvVariables(b *Bundle) error {
+	repo := repository.NewEnvVariableRepository(im.db)
+	current, err := repo.GetAll()
+	if err != nil {
+		return err
+	}
+	existing := map[string]int64{}
+	for _, envVar := range current {
+		existing[envVar.Name] = envVar.ID
+	}
+
+	counts := &im.result.EnvVariables
+	for _, envVar := range b.EnvVariables {
+		id, name, skip := im.resolve(counts, existing, envVar.Name, "_")
+		if skip {
+			im.envIDs[envVar.ID] = id
+			continue
+		}
+		if id != 0 {
+			if _, err := repo.Update(id, &models.EnvVariableUpdate{Value: envVar.Value, Description: envVar.Description, Group: envVar.Group}); err != nil {
+				return err
+			}
+			counts.Updated++
+			im.envIDs[envVar.ID] = id
+			continue
+		}
+		createdVar, err := repo.Create(&models.EnvVariableCreate{Name: name, Value: envVar.Value, Description: envVar.Description, Group: envVar.Group})
+		if err != nil {
+			return err
+		}
+		created(counts, existing, name, envVar.Name, createdVar.ID)
+		im.envIDs[envVar.ID] = createdVar.ID
+	}
+	return nil
+}
+
+func (im *importer) importBashScripts(b *Bundle) error {
+	repo := repository.NewBashScriptRepository(im.db)
+	current, err := repo.GetAll()
+	if err != nil {
+		return err
+	}
+	existing := map[string]int64{}
+	readOnly := map[int64]bool{}
+	for _, script := range current {
+		existing[script.Name] = script.ID
+		readOnly[script.ID] = script.ReadOnly
+	}
+
+	counts := &im.result.BashScripts
+	for _, script := range b.BashScripts {
+		id, name, skip := im.resolve(counts, existing, script.Name, "-")
+		if skip {
+			im.scriptIDs[script.ID] = id
+			continue
+		}
+		if id != 0 {
+			// Scripts managed by Git sync are only changed through the repository
+			if readOnly[id] {
+				counts.Skipped++
+				im.scriptIDs[script.ID] = id
+				continue
+			}
+			requiresApproval := script.RequiresApproval
+			exclusive := script.Exclusive
+			if _, err := repo.Update(id, &models.BashScriptUpdate{
+				Description:      script.Description,
+				Content:          script.Content,
+				Filename:         script.Filename,
+				Group:            script.Group,
+				Interpreter:      script.Interpreter,
+				RequiresApproval: &requiresApproval,
+				Exclusive:        &exclusive,
+				ExitCodes:        script.ExitCodes,
+				Tags:             script.Tags,
+			}); err != nil {
+				return err
+			}
+			counts.Updated++
+			im.scriptIDs[script.ID] = id
+			continue
+		}
+		createdScript, err := repo.Create(&models.BashScriptCreate{
+			Name:             name,
+			Description:      script.Description,
+			Content:          script.Content,
+			Filename:         script.Filename,
+			Group:            script.Group,
+			Interpreter:      script.Interpreter,
+			RequiresApproval: script.RequiresApproval,
+			Exclusive:        script.Exclusive,
+			ExitCodes:        script.ExitCodes,
+			Tags:             script.Tags,
+		})
+		if err != nil {
+			return err
+		}
+		created(counts, existing, name, script.Name, createdScript.ID)
+		im.scriptIDs[script.ID] = createdScript.ID
+	}
+	return nil
+}
+
+func (im *importer) importSavedCommands(b *Bundle) error {
+	repo := repository.NewSavedCommandRepository(im.db)
+	current, err := repo.GetAll()
+	if err != nil {
+		return err
+	}
+	existing := map[string]int64{}
+	for _, cmd := range current {
+		existing[cmd.Name] = cmd.ID
+	}
+
+	counts := &im.result.SavedCommands
+	for _, cmd := range b.SavedCommands {
+		id, name, skip := im.resolve(counts, existing, cmd.Name, "-")
+		if skip {
+			continue
+		}
+		serverID, keyID := mapID(im.serverIDs, cmd.ServerID), mapID(im.keyIDs, cmd.SSHKeyID)
+		if id != 0 {
+			isRemote := cmd.IsRemote
+			if _, err := repo.Update(id, &models.SavedCommandUpdate{
+				Command:     cmd.Command,
+				Description: cmd.Description,
+				User:        cmd.User,
+				IsRemote:    &isRemote,
+				ServerID:    serverID,
+				SSHKeyID:    keyID,
+				Tags:        cmd.Tags,
+
+				Placeholders: cmd.Placeholders,
+			}); err != nil {
+				return err
+			}
+			counts.Updated++
+			continue
+		}
+		createdCmd, err := repo.Create(&models.SavedCommandCreate{
+			Name:        name,
+			Command:     cmd.Command,
+			Description: cmd.Description,
+			User:        cmd.User,
+			IsRemote:    cmd.IsRemote,
+			ServerID:    serverID,
+			SSHKeyID:    keyID,
+			Tags:        cmd.Tags,
+
+			Placeholders: cmd.Placeholders,
+		})
+		if err != nil {
+			return err
+		}
+		created(counts, existing, name, cmd.Name, createdCmd.ID)
+	}
+	return nil
+}
+
+func (im *importer) importScriptPresets(b *Bundle) error {
+	repo := repository.NewScriptPresetRepository(im.db)
+	current, err := repo.GetAll()
+	if err != nil {
+		return err
+	}
+	existing := map[string]int64{}
+	for _, preset := range current {
+		existing[preset.Name] = preset.ID
+	}
+
+	counts := &im.result.ScriptPresets
+	for _, preset := range b.ScriptPresets {
+		// A preset is useless without its script
+		scriptID, ok := im.scriptIDs[preset.ScriptID]
+		if !ok {
+			counts.Skipped++
+			continue
+		}
+
+		id, name, skip := im.resolve(counts, existing, preset.Name, "-")
+		if skip {
+			continue
+		}
+		envVarIDs := []int64{}
+		for _, envID := range preset.EnvVarIDs {
+			if mapped, ok := im.envIDs[envID]; ok {
+				envVarIDs = append(envVarIDs, mapped)
+			}
+		}
+		serverID, keyID := mapID(im.serverIDs, preset.ServerID), mapID(im.keyIDs, preset.SSHKeyID)
+
+		if id != 0 {
+			isRemote := preset.IsRemote
+			if _, err := repo.Update(id, &models.ScriptPresetUpdate{
+				Description: preset.Description,
+				ScriptID:    &scriptID,
+				EnvVarIDs:   envVarIDs,
+				IsRemote:    &isRemote,
+				ServerID:    serverID,
+				SSHKeyID:    keyID,
+				User:        preset.User,
+				SlackNotify: &preset.SlackNotify,
+				Exclusive:   &preset.Exclusive,
+
+				Schedule:        &preset.Schedule,
+				ScheduleEnabled: &preset.ScheduleEnabled,
+
+				TimeoutSeconds: &preset.TimeoutSeconds,
+				Retries:        &preset.Retries,
+				RetryBackoff:   &preset.RetryBackoff,
+				NotifyChannel:  &preset.NotifyChannel,
+			}); err != nil {
+				return err
+			}
+			counts.Updated++
+			continue
+		}
+		createdPreset, err := repo.Create(&models.ScriptPresetCreate{
+			Name:        name,
+			Description: preset.Description,
+			ScriptID:    scriptID,
+			EnvVarIDs:   envVarIDs,
+			IsRemote:    preset.IsRemote,
+			ServerID:    serverID,
+			SSHKeyID:    keyID,
+			User:        preset.User,
+			SlackNotify: preset.SlackNotify,
+			Exclusive:   preset.Exclusive,
+
+			Schedule:        preset.Schedule,
+			ScheduleEnabled: preset.ScheduleEnabled,
+
+			TimeoutSeconds: preset.TimeoutSeconds,
+			Retries:        preset.Retries,
+			RetryBackoff:   preset.RetryBackoff,
+			NotifyChannel:  preset.NotifyChannel,
+		})
+		if err != nil {
+			return err
+		}
+		created(counts, existing, name, preset.Name, createdPreset.ID)
+	}
+	return nil
+}
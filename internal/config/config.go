@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,11 +26,21 @@ type Config struct {
 	Port              int    // Server port (default: 7777)
 	Host              string // Server host (default: 0.0.0.0)
 	FrontendPath      string // Path to frontend build files
+	BasePath          string // URL path prefix to serve the app under (e.g. /webcli), empty for root
+	SwaggerUI         bool   // Serve the Swagger UI at /swagger/ (default: true)
 	DatabasePath      string // Path to SQLite database file
+	DBMaintenanceTime string // Local time of day (HH:MM) to check and compact the database, empty to disable
 	EncryptionKeyPath string // Path to encryption key file
 	TLSCertPath       string // Path to TLS certificate file (enables HTTPS)
 	TLSKeyPath        string // Path to TLS private key file
 	RequireHTTPS      bool   // Require HTTPS when auth is enabled (reject HTTP requests)
+	TLSClientCAPath   string // Path to CA bundle for verifying client certificates (enables mutual TLS)
+	TLSClientAuth     string // Client certificate mode: "optional" (default) or "require"
+	HealthPort        int    // Optional plain-HTTP port serving only health probes (0 to disable)
+	GRPCPort          int    // Optional port serving the gRPC API (0 to disable)
+	ListenSocket      string // Optional unix domain socket path to serve on
+	ListenSocketMode  string // Octal permissions for the unix socket (default: 0660)
+	SocketOnly        bool   // Serve only on the unix socket (disable the TCP listener)
 
 	// Timeout configurations (all in seconds)
 	ReadTimeout       int // HTTP server read timeout (default: 30)
@@ -38,7 +51,187 @@ type Config struct {
 	SSHConnectTimeout int // SSH connection timeout (default: 30)
 
 	// Audit logging
-	AuditLogPath string // Path to audit log file (empty to disable)
+	AuditLogPath        string // Path to audit log file (empty to disable)
+	AuditSyslog         string // Syslog destination: "local", udp://host:port, tcp://host:port or unix:///path (empty to disable)
+	AuditSyslogFacility string // Syslog facility for audit events (default: "auth")
+	AuditJournald       bool   // Also write audit events to the systemd journal
+
+	// Audit webhook (SIEM forwarding)
+	AuditWebhookURL           string // Endpoint receiving batches of audit events (empty to disable)
+	AuditWebhookSecret        string // Key for the HMAC-SHA256 request signature
+	AuditWebhookBatchSize     int    // Maximum events per request (default: 100)
+	AuditWebhookFlushInterval int    // Seconds an event waits for a batch to fill (default: 5)
+	AuditWebhookQueueSize     int    // Events buffered while the endpoint is unavailable (default: 10000)
+	AuditWebhookMaxRetries    int    // Retries for a failed batch before it is dropped (default: 5)
+
+	// Slack / Mattermost notifications
+	SlackWebhookURL string // Incoming webhook receiving the results of script presets that opt in (empty to disable)
+	SlackChannel    string // Channel posted to instead of the webhook's default (optional)
+	SlackUsername   string // Name messages are posted as (default: web-cli)
+
+	// Email notifications
+	SMTPHost          string   // SMTP server emails are sent through (empty to disable)
+	SMTPPort          int      // SMTP port (default: 465 with implicit TLS, 587 otherwise)
+	SMTPUsername      string   // SMTP username (empty to send without authentication)
+	SMTPPassword      string   // SMTP password
+	SMTPTLS           string   // starttls, tls (implicit) or none (default: starttls)
+	EmailFrom         string   // Sender address
+	EmailTo           []string // Recipient addresses
+	EmailEvents       []string // Event types or patterns emails are sent for (default: *.failed)
+	EmailSubject      string   // Subject template (empty for the built-in one)
+	EmailBodyTemplate string   // File holding the body template (empty for the built-in one)
+
+	// Local execution
+	LocalShell      string // Shell for local commands, a name or path (empty to detect: bash or sh, PowerShell or cmd.exe on Windows)
+	LocalElevation  string // Tool for running local commands as another user: auto, sudo, doas, su or none (default: auto)
+	LocalUsersOnly  bool   // Local executions may only run as users listed under local users, or "current" (default: false)
+	LocalUserMinUID int    // Lowest UID of the login users discovered from /etc/passwd (default: 1000)
+	LocalUserMaxUID int    // Highest UID of the login users discovered from /etc/passwd (default: 60000)
+
+	// Git sync of bash scripts
+	GitSyncURL        string // Repository to sync scripts from (empty to disable)
+	GitSyncBranch     string // Branch to sync (default: main)
+	GitSyncPath       string // Directory in the repository holding the scripts (default: repository root)
+	GitSyncGroup      string // Script group the synced scripts are kept in (default: git)
+	GitSyncInterval   int    // Seconds between syncs (default: 300)
+	GitSyncUsername   string // Username for HTTPS repositories (default: git)
+	GitSyncToken      string // Password or access token for HTTPS repositories
+	GitSyncSSHKeyPath string // Private key for SSH repositories
+	GitSyncDir        string // Working copy of the repository (default: git-sync next to the database)
+
+	// Cloud inventory of servers
+	InventoryInterval           int      // Seconds between inventory syncs (default: 600)
+	InventoryGroupTag           string   // Tag or label naming the server group of an instance (default: group)
+	InventoryPrivateIP          bool     // Connect to private instead of public addresses (default: false)
+	InventoryAWSRegions         []string // EC2 regions to discover instances in (empty to disable AWS)
+	InventoryAWSAccessKeyID     string   // AWS access key ID (falls back to AWS_ACCESS_KEY_ID)
+	InventoryAWSSecretAccessKey string   // AWS secret access key (falls back to AWS_SECRET_ACCESS_KEY)
+	InventoryAWSSessionToken    string   // AWS session token for temporary credentials (falls back to AWS_SESSION_TOKEN)
+	InventoryDigitalOceanToken  string   // DigitalOcean API token (empty to disable DigitalOcean)
+	InventoryHetznerToken       string   // Hetzner Cloud API token (empty to disable Hetzner)
+
+	// Kubernetes pod exec
+	KubernetesKubeconfig string // Path to a kubeconfig file (empty to disable unless in-cluster)
+	KubernetesContext    string // Context of the kubeconfig to use (default: its current context)
+	KubernetesInCluster  bool   // Use the service account of the pod web-cli runs in (default: false)
+
+	// Rate limiting for execution endpoints (per client)
+	ExecutionRateLimit int // Executions allowed per client per minute (default: 30, 0 to disable)
+	ExecutionRateBurst int // Executions a client may make in a burst (default: 10)
+
+	// Execution queue
+	MaxConcurrentExecutions int // Commands and scripts running at once (default: 20, 0 for no limit)
+	MaxQueuedExecutions     int // Executions waiting for a slot before new ones get 503 (default: 100, 0 for no limit)
+	ServerMaxParallel       int // Executions running at once on one server, unless it sets max_parallel (default: 4, 0 for no limit)
+	ExecutionQueueTimeout   int // Longest wait for a slot in seconds (default: 300, 0 to wait indefinitely)
+
+	// Execution output
+	OutputMaxSize   int    // Output kept in history and API responses, in KB (default: 1024, 0 for no limit)
+	ExecutionLogDir string // Full logs of truncated outputs (default: execution-logs next to the database)
+
+	// Login lockout (brute-force protection)
+	AuthLockoutThreshold   int // Failed attempts before an IP or username is locked (default: 5, 0 to disable)
+	AuthLockoutDuration    int // Initial lock duration in seconds, doubled on each further failure (default: 30)
+	AuthLockoutMaxDuration int // Maximum lock duration in seconds (default: 3600)
+
+	// Session login
+	SessionSecret   string // Secret for signing session tokens (random per start if empty)
+	SessionTTL      int    // Session lifetime in seconds (default: 28800)
+	SudoPasswordTTL int    // Seconds a sudo password posted for a session is kept for local executions (default: 900, 0 to disable)
+
+	// Two-person approval
+	ApprovalTTL  int    // Seconds a pending or approved execution stays valid (default: 3600)
+	ApproverRole string // Role allowed to approve executions, besides admins (default: "approver")
+
+	// Read-only (maintenance) mode, can be toggled at runtime via the API
+	ReadOnly       bool   // Start with execution, terminals and mutations blocked (default: false)
+	ReadOnlyReason string // Reason shown to clients while read-only
+
+	// Terminal session recording (asciicast v2)
+	TerminalRecordingDir       string // Directory for terminal recordings (empty to disable)
+	TerminalRecordInput        bool   // Also record keyboard input, which may include typed passwords (default: false)
+	TerminalRecordingRetention int    // Days to keep recordings (default: 90, 0 keeps them forever)
+
+	// Terminal session limits (0 for no limit)
+	TerminalMaxSessions        int // Open terminal sessions across all users (default: 50)
+	TerminalMaxSessionsPerUser int // Open terminal sessions per user (default: 5)
+
+	// Resumable terminal sessions
+	TerminalDetachGrace int // Seconds a disconnected session's shell is kept for the client to reattach (default: 60, 0 ends it at once)
+	TerminalScrollback  int // Bytes of recent output replayed on reattach and to new observers (default: 65536)
+
+	// Terminal file exchange
+	TerminalFileMaxSize int // Largest file that can be uploaded into a terminal session, in MB (default: 100)
+
+	// Terminal shells
+	TerminalShells       []string // Shell catalog entries "name=/path [args]" (empty for bash, sh, zsh, fish and ash where installed)
+	TerminalDefaultShell string   // Shell used when a client does not pick one (default: bash)
+	TerminalUserShells   []string // Per-user default shells as "principal=shell", e.g. "user:alice=zsh"
+	TerminalShellRC      string   // Startup file sourced by local shells after the user's own (optional)
+
+	// Keystroke logging
+	TerminalKeystrokeLog bool // Store what users type in terminals, encrypted, with password prompts redacted (default: false)
+
+	// Encryption of stored secrets
+	EncryptionProvider     string // "local" (default) uses the encryption key file; "vault-transit" uses Vault's transit engine; "aws-kms", "gcp-kms", "age" or "sops" keep the key file wrapped
+	EncryptionKMSKey       string // AWS KMS key ID, ARN or alias, or GCP KMS key resource name
+	EncryptionAgeIdentity  string // age identity file that unwraps the key file
+	EncryptionAgeRecipient string // age recipient a new key file is wrapped for
+	TransitAddress         string // Vault server holding the transit key
+	TransitToken           string // Vault token allowed to encrypt and decrypt with the key
+	TransitNamespace       string // Vault Enterprise namespace (optional)
+	TransitMount           string // Path the transit engine is enabled at (default: transit)
+	TransitKey             string // Name of the transit key (default: web-cli)
+	TransitCacheSize       int    // Decrypted values kept in memory (default: 1000, 0 to disable)
+	TransitCacheTTL        int    // Seconds a decrypted value is kept (default: 300)
+
+	// Sync between SQLite and Vault, per resource type: none (default), to-vault, from-vault or two-way
+	VaultSyncSSHKeys      string
+	VaultSyncServers      string
+	VaultSyncEnvVariables string
+	VaultSyncScripts      string
+	VaultSyncConflict     string // Store that wins when a two-way synced item differs: sqlite (default), vault or skip
+	VaultSyncInterval     int    // Seconds between background syncs (default: 300, 0 for manual syncs only)
+
+	// IP access control (comma-separated CIDR ranges or addresses)
+	IPAllowList          []string // Clients allowed to reach the server (empty allows all)
+	IPDenyList           []string // Clients always rejected
+	ExecutionIPAllowList []string // Stricter allowlist for command, script and terminal execution
+
+	// CORS policy (lists are comma-separated in env vars and flags)
+	CORSAllowedOrigins   []string // Allowed origins (default: localhost on the server port)
+	CORSAllowedMethods   []string // Allowed HTTP methods
+	CORSAllowedHeaders   []string // Allowed request headers
+	CORSExposedHeaders   []string // Response headers exposed to cross-origin clients
+	CORSAllowCredentials bool     // Allow credentials on cross-origin requests (default: true)
+	CORSMaxAge           int      // Preflight cache duration in seconds (default: 300)
+}
+
+// basePathPattern restricts base path segments to URL-safe characters
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// GetBasePath returns the normalized base path: a leading slash, no trailing slash,
+// and empty when serving from the root. Invalid values fall back to the root
+func (c *Config) GetBasePath() string {
+	p := strings.Trim(strings.TrimSpace(c.BasePath), "/")
+	if p == "" {
+		return ""
+	}
+	p = "/" + p
+	if !basePathPattern.MatchString(p) || path.Clean(p) != p || strings.Contains(p, "/.") {
+		log.Printf("Warning: ignoring invalid base path %q", c.BasePath)
+		return ""
+	}
+	return p
+}
+
+// GetListenSocketMode returns the unix socket permissions as an os.FileMode
+func (c *Config) GetListenSocketMode() os.FileMode {
+	mode, err := strconv.ParseUint(c.ListenSocketMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0660
+	}
+	return os.FileMode(mode)
 }
 
 // GetReadTimeout returns the read timeout as a time.Duration
@@ -73,6 +266,23 @@ func (c *Config) GetVaultTimeout() time.Duration {
 	return time.Duration(c.VaultTimeout) * time.Second
 }
 
+// GetTransitCacheTTL returns how long decrypted transit values are cached as a time.Duration
+func (c *Config) GetTransitCacheTTL() time.Duration {
+	if c.TransitCacheTTL <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(c.TransitCacheTTL) * time.Second
+}
+
+// GetVaultSyncInterval returns the time between background syncs with Vault
+// Zero means syncs only run when requested through the API
+func (c *Config) GetVaultSyncInterval() time.Duration {
+	if c.VaultSyncInterval <= 0 {
+		return 0
+	}
+	return time.Duration(c.VaultSyncInterval) * time.Second
+}
+
 // GetCommandTimeout returns the command execution timeout as a time.Duration
 func (c *Config) GetCommandTimeout() time.Duration {
 	if c.CommandTimeout <= 0 {
@@ -81,6 +291,31 @@ func (c *Config) GetCommandTimeout() time.Duration {
 	return time.Duration(c.CommandTimeout) * time.Second
 }
 
+// GetExecutionQueueTimeout returns the longest wait for an execution slot as a duration
+func (c *Config) GetExecutionQueueTimeout() time.Duration {
+	if c.ExecutionQueueTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.ExecutionQueueTimeout) * time.Second
+}
+
+// GetOutputMaxSize returns the output kept in history and API responses in bytes
+// Zero means outputs are never truncated
+func (c *Config) GetOutputMaxSize() int {
+	if c.OutputMaxSize <= 0 {
+		return 0
+	}
+	return c.OutputMaxSize << 10
+}
+
+// GetExecutionLogDir returns the directory holding the full logs of truncated outputs
+func (c *Config) GetExecutionLogDir() string {
+	if c.ExecutionLogDir != "" {
+		return c.ExecutionLogDir
+	}
+	return filepath.Join(filepath.Dir(c.DatabasePath), "execution-logs")
+}
+
 // GetSSHConnectTimeout returns the SSH connection timeout as a time.Duration
 func (c *Config) GetSSHConnectTimeout() time.Duration {
 	if c.SSHConnectTimeout <= 0 {
@@ -89,19 +324,173 @@ func (c *Config) GetSSHConnectTimeout() time.Duration {
 	return time.Duration(c.SSHConnectTimeout) * time.Second
 }
 
+// GetAuthLockoutDuration returns the initial login lock duration as a time.Duration
+func (c *Config) GetAuthLockoutDuration() time.Duration {
+	if c.AuthLockoutDuration <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.AuthLockoutDuration) * time.Second
+}
+
+// GetAuthLockoutMaxDuration returns the maximum login lock duration as a time.Duration
+func (c *Config) GetAuthLockoutMaxDuration() time.Duration {
+	if c.AuthLockoutMaxDuration <= 0 {
+		return time.Hour
+	}
+	return time.Duration(c.AuthLockoutMaxDuration) * time.Second
+}
+
+// GetSessionTTL returns the session lifetime as a time.Duration
+func (c *Config) GetSessionTTL() time.Duration {
+	if c.SessionTTL <= 0 {
+		return 8 * time.Hour
+	}
+	return time.Duration(c.SessionTTL) * time.Second
+}
+
+// GetSudoPasswordTTL returns how long a session's sudo password is kept as a time.Duration
+// Zero disables sudo password caching
+func (c *Config) GetSudoPasswordTTL() time.Duration {
+	if c.SudoPasswordTTL <= 0 {
+		return 0
+	}
+	return time.Duration(c.SudoPasswordTTL) * time.Second
+}
+
+// GetApprovalTTL returns how long an approval request stays valid as a time.Duration
+func (c *Config) GetApprovalTTL() time.Duration {
+	if c.ApprovalTTL <= 0 {
+		return time.Hour
+	}
+	return time.Duration(c.ApprovalTTL) * time.Second
+}
+
+// GetAuditWebhookFlushInterval returns the audit webhook flush interval as a time.Duration
+func (c *Config) GetAuditWebhookFlushInterval() time.Duration {
+	if c.AuditWebhookFlushInterval <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(c.AuditWebhookFlushInterval) * time.Second
+}
+
+// GetGitSyncInterval returns the time between Git syncs of bash scripts
+func (c *Config) GetGitSyncInterval() time.Duration {
+	if c.GitSyncInterval <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(c.GitSyncInterval) * time.Second
+}
+
+// GetGitSyncDir returns the directory holding the working copy of the Git sync repository
+func (c *Config) GetGitSyncDir() string {
+	if c.GitSyncDir != "" {
+		return c.GitSyncDir
+	}
+	return filepath.Join(filepath.Dir(c.DatabasePath), "git-sync")
+}
+
+// GetInventoryInterval returns the time between cloud inventory syncs of servers
+func (c *Config) GetInventoryInterval() time.Duration {
+	if c.InventoryInterval <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.InventoryInterval) * time.Second
+}
+
+// GetTerminalRecordingRetention returns how long terminal recordings are kept
+// Zero means recordings are never deleted
+func (c *Config) GetTerminalRecordingRetention() time.Duration {
+	if c.TerminalRecordingRetention <= 0 {
+		return 0
+	}
+	return time.Duration(c.TerminalRecordingRetention) * 24 * time.Hour
+}
+
+// GetTerminalDetachGrace returns how long a disconnected terminal session waits to be resumed
+func (c *Config) GetTerminalDetachGrace() time.Duration {
+	if c.TerminalDetachGrace <= 0 {
+		return 0
+	}
+	return time.Duration(c.TerminalDetachGrace) * time.Second
+}
+
+// GetTerminalUserShell returns the name of the default shell for a principal
+func (c *Config) GetTerminalUserShell(principal string) string {
+	for _, entry := range c.TerminalUserShells {
+		if name, shell, ok := strings.Cut(entry, "="); ok && strings.TrimSpace(name) == principal {
+			return strings.TrimSpace(shell)
+		}
+	}
+	return c.TerminalDefaultShell
+}
+
+// GetTerminalFileMaxSize returns the largest terminal file upload in bytes
+func (c *Config) GetTerminalFileMaxSize() int64 {
+	if c.TerminalFileMaxSize <= 0 {
+		return 100 << 20
+	}
+	return int64(c.TerminalFileMaxSize) << 20
+}
+
 // Load parses command-line flags and environment variables to load configuration
 func Load() *Config {
+	v := newViper()
+
+	// Command-line flags (highest priority) - only define once
+	flagsMu.Lock()
+	if !flagsInitialized {
+		registerFlags(flag.CommandLine, v)
+		flagsInitialized = true
+	}
+	flagsMu.Unlock()
+
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	applyFlags(flag.CommandLine, v)
+
+	return fromViper(v)
+}
+
+// LoadFlagSet loads configuration using a dedicated flag set, for use by CLI subcommands
+// The configuration flags are added to fs before args are parsed, so callers may
+// define additional subcommand flags on fs beforehand
+func LoadFlagSet(fs *flag.FlagSet, args []string) (*Config, error) {
+	v := newViper()
+	registerFlags(fs, v)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	applyFlags(fs, v)
+
+	return fromViper(v), nil
+}
+
+// newViper creates a viper instance with defaults, environment bindings and the optional config file
+func newViper() *viper.Viper {
 	v := viper.New()
 
 	// Set default values
 	v.SetDefault("port", 7777)
 	v.SetDefault("host", "0.0.0.0")
 	v.SetDefault("frontend_path", "./frontend/build")
+	v.SetDefault("base_path", "")
+	v.SetDefault("swagger_ui", true)
 	v.SetDefault("database_path", "./data/web-cli.db")
 	v.SetDefault("encryption_key_path", "./.encryption_key")
 	v.SetDefault("tls_cert_path", "")
 	v.SetDefault("tls_key_path", "")
 	v.SetDefault("require_https", false)
+	v.SetDefault("tls_client_ca_path", "")
+	v.SetDefault("tls_client_auth", "optional")
+	v.SetDefault("health_port", 0) // 0 disables the health probe listener
+	v.SetDefault("grpc_port", 0)   // 0 disables the gRPC API
+	v.SetDefault("listen_socket", "")
+	v.SetDefault("listen_socket_mode", "0660")
+	v.SetDefault("socket_only", false)
 
 	// Timeout defaults (in seconds)
 	v.SetDefault("read_timeout", 30)
@@ -111,6 +500,148 @@ func Load() *Config {
 	v.SetDefault("command_timeout", 300) // 5 minutes
 	v.SetDefault("ssh_connect_timeout", 30)
 	v.SetDefault("audit_log_path", "") // Empty to disable audit logging
+	v.SetDefault("audit_syslog", "")   // Empty to disable the syslog sink
+	v.SetDefault("audit_syslog_facility", "auth")
+	v.SetDefault("audit_journald", false)
+	v.SetDefault("audit_webhook_url", "") // Empty to disable the webhook sink
+	v.SetDefault("audit_webhook_secret", "")
+	v.SetDefault("audit_webhook_batch_size", 100)
+	v.SetDefault("audit_webhook_flush_interval", 5)
+	v.SetDefault("audit_webhook_queue_size", 10000)
+	v.SetDefault("audit_webhook_max_retries", 5)
+	v.SetDefault("slack_webhook_url", "") // Empty to disable Slack notifications
+	v.SetDefault("slack_channel", "")
+	v.SetDefault("slack_username", "web-cli")
+	v.SetDefault("smtp_host", "") // Empty to disable email notifications
+	v.SetDefault("smtp_port", 0)
+	v.SetDefault("smtp_username", "")
+	v.SetDefault("smtp_password", "")
+	v.SetDefault("smtp_tls", "starttls")
+	v.SetDefault("email_from", "")
+	v.SetDefault("email_to", "")
+	v.SetDefault("email_events", "*.failed")
+	v.SetDefault("email_subject", "")
+	v.SetDefault("email_body_template", "")
+
+	// Local execution defaults
+	v.SetDefault("local_shell", "")
+	v.SetDefault("local_elevation", "auto")
+	v.SetDefault("local_users_only", false)
+	v.SetDefault("local_user_min_uid", 1000)
+	v.SetDefault("local_user_max_uid", 60000)
+
+	// Git sync defaults
+	v.SetDefault("git_sync_url", "")
+	v.SetDefault("git_sync_branch", "main")
+	v.SetDefault("git_sync_path", "")
+	v.SetDefault("git_sync_group", "git")
+	v.SetDefault("git_sync_interval", 300)
+	v.SetDefault("git_sync_username", "git")
+	v.SetDefault("git_sync_token", "")
+	v.SetDefault("git_sync_ssh_key_path", "")
+	v.SetDefault("git_sync_dir", "")
+	v.SetDefault("inventory_interval", 600)
+	v.SetDefault("inventory_group_tag", "group")
+	v.SetDefault("inventory_private_ip", false)
+	v.SetDefault("inventory_aws_regions", "")
+	v.SetDefault("inventory_aws_access_key_id", "")
+	v.SetDefault("inventory_aws_secret_access_key", "")
+	v.SetDefault("inventory_aws_session_token", "")
+	v.SetDefault("inventory_digitalocean_token", "")
+	v.SetDefault("inventory_hetzner_token", "")
+
+	// Kubernetes defaults
+	v.SetDefault("kubernetes_kubeconfig", "")
+	v.SetDefault("kubernetes_context", "")
+	v.SetDefault("kubernetes_in_cluster", false)
+
+	// Rate limiting defaults
+	v.SetDefault("execution_rate_limit", 30) // per client per minute
+	v.SetDefault("execution_rate_burst", 10)
+
+	// Execution queue defaults
+	v.SetDefault("max_concurrent_executions", 20)
+	v.SetDefault("max_queued_executions", 100)
+	v.SetDefault("server_max_parallel", 4)
+	v.SetDefault("execution_queue_timeout", 300) // 5 minutes
+
+	// Execution output defaults
+	v.SetDefault("output_max_size", 1024) // 1 MB
+	v.SetDefault("execution_log_dir", "")
+
+	// Login lockout defaults
+	v.SetDefault("auth_lockout_threshold", 5)
+	v.SetDefault("auth_lockout_duration", 30)
+	v.SetDefault("auth_lockout_max_duration", 3600)
+
+	// Session defaults
+	v.SetDefault("session_secret", "")
+	v.SetDefault("session_ttl", 28800)     // 8 hours
+	v.SetDefault("sudo_password_ttl", 900) // 15 minutes
+
+	// Approval defaults
+	v.SetDefault("approval_ttl", 3600) // 1 hour
+	v.SetDefault("approver_role", "approver")
+
+	// Read-only mode defaults
+	v.SetDefault("read_only", false)
+	v.SetDefault("read_only_reason", "")
+
+	// Terminal recording defaults
+	v.SetDefault("terminal_recording_dir", "") // Empty to disable recording
+	v.SetDefault("terminal_record_input", false)
+	v.SetDefault("terminal_recording_retention", 90)
+
+	// Terminal session limit defaults
+	v.SetDefault("terminal_max_sessions", 50)
+	v.SetDefault("terminal_max_sessions_per_user", 5)
+
+	// Resumable terminal session defaults
+	v.SetDefault("terminal_detach_grace", 60)
+	v.SetDefault("terminal_scrollback", 65536)
+
+	// Terminal file exchange defaults
+	v.SetDefault("terminal_file_max_size", 100)
+
+	// Terminal shell defaults
+	v.SetDefault("terminal_shells", "")
+	v.SetDefault("terminal_default_shell", "bash")
+	v.SetDefault("terminal_user_shells", "")
+	v.SetDefault("terminal_shell_rc", "")
+
+	// Keystroke logging defaults
+	v.SetDefault("terminal_keystroke_log", false)
+
+	// Encryption defaults
+	v.SetDefault("encryption_provider", "local")
+	v.SetDefault("transit_address", "")
+	v.SetDefault("transit_token", "")
+	v.SetDefault("transit_namespace", "")
+	v.SetDefault("transit_mount", "transit")
+	v.SetDefault("transit_key", "web-cli")
+	v.SetDefault("transit_cache_size", 1000)
+	v.SetDefault("transit_cache_ttl", 300)
+
+	// Vault sync defaults (nothing is synced)
+	v.SetDefault("vault_sync_ssh_keys", "none")
+	v.SetDefault("vault_sync_servers", "none")
+	v.SetDefault("vault_sync_env_variables", "none")
+	v.SetDefault("vault_sync_scripts", "none")
+	v.SetDefault("vault_sync_conflict", "sqlite")
+	v.SetDefault("vault_sync_interval", 300)
+
+	// IP access control defaults (no filtering)
+	v.SetDefault("ip_allowlist", "")
+	v.SetDefault("ip_denylist", "")
+	v.SetDefault("execution_ip_allowlist", "")
+
+	// CORS defaults (empty origins means localhost on the server port)
+	v.SetDefault("cors_allowed_origins", "")
+	v.SetDefault("cors_allowed_methods", "GET,POST,PUT,DELETE,OPTIONS")
+	v.SetDefault("cors_allowed_headers", "Accept,Authorization,Content-Type,X-CSRF-Token")
+	v.SetDefault("cors_exposed_headers", "")
+	v.SetDefault("cors_allow_credentials", true)
+	v.SetDefault("cors_max_age", 300)
 
 	// Enable environment variable support
 	v.SetEnvPrefix("WEBCLI") // Environment variables will be WEBCLI_PORT, WEBCLI_HOST, etc.
@@ -120,11 +651,21 @@ func Load() *Config {
 	v.BindEnv("port", "PORT", "WEBCLI_PORT")
 	v.BindEnv("host", "HOST", "WEBCLI_HOST")
 	v.BindEnv("frontend_path", "FRONTEND_PATH", "WEBCLI_FRONTEND_PATH")
+	v.BindEnv("base_path", "BASE_PATH", "WEBCLI_BASE_PATH")
+	v.BindEnv("swagger_ui", "SWAGGER_UI", "WEBCLI_SWAGGER_UI")
 	v.BindEnv("database_path", "DATABASE_PATH", "WEBCLI_DATABASE_PATH")
+	v.BindEnv("db_maintenance_time", "DB_MAINTENANCE_TIME", "WEBCLI_DB_MAINTENANCE_TIME")
 	v.BindEnv("encryption_key_path", "ENCRYPTION_KEY_PATH", "WEBCLI_ENCRYPTION_KEY_PATH")
 	v.BindEnv("tls_cert_path", "TLS_CERT_PATH", "WEBCLI_TLS_CERT_PATH")
 	v.BindEnv("tls_key_path", "TLS_KEY_PATH", "WEBCLI_TLS_KEY_PATH")
 	v.BindEnv("require_https", "REQUIRE_HTTPS", "WEBCLI_REQUIRE_HTTPS")
+	v.BindEnv("tls_client_ca_path", "TLS_CLIENT_CA_PATH", "WEBCLI_TLS_CLIENT_CA_PATH")
+	v.BindEnv("tls_client_auth", "TLS_CLIENT_AUTH", "WEBCLI_TLS_CLIENT_AUTH")
+	v.BindEnv("health_port", "HEALTH_PORT", "WEBCLI_HEALTH_PORT")
+	v.BindEnv("grpc_port", "GRPC_PORT", "WEBCLI_GRPC_PORT")
+	v.BindEnv("listen_socket", "LISTEN_SOCKET", "WEBCLI_LISTEN_SOCKET")
+	v.BindEnv("listen_socket_mode", "LISTEN_SOCKET_MODE", "WEBCLI_LISTEN_SOCKET_MODE")
+	v.BindEnv("socket_only", "SOCKET_ONLY", "WEBCLI_SOCKET_ONLY")
 
 	// Timeout environment variables
 	v.BindEnv("read_timeout", "READ_TIMEOUT", "WEBCLI_READ_TIMEOUT")
@@ -136,6 +677,155 @@ func Load() *Config {
 
 	// Audit logging
 	v.BindEnv("audit_log_path", "AUDIT_LOG_PATH", "WEBCLI_AUDIT_LOG_PATH")
+	v.BindEnv("audit_syslog", "AUDIT_SYSLOG", "WEBCLI_AUDIT_SYSLOG")
+	v.BindEnv("audit_syslog_facility", "AUDIT_SYSLOG_FACILITY", "WEBCLI_AUDIT_SYSLOG_FACILITY")
+	v.BindEnv("audit_journald", "AUDIT_JOURNALD", "WEBCLI_AUDIT_JOURNALD")
+	v.BindEnv("audit_webhook_url", "AUDIT_WEBHOOK_URL", "WEBCLI_AUDIT_WEBHOOK_URL")
+	v.BindEnv("audit_webhook_secret", "AUDIT_WEBHOOK_SECRET", "WEBCLI_AUDIT_WEBHOOK_SECRET")
+	v.BindEnv("audit_webhook_batch_size", "AUDIT_WEBHOOK_BATCH_SIZE", "WEBCLI_AUDIT_WEBHOOK_BATCH_SIZE")
+	v.BindEnv("audit_webhook_flush_interval", "AUDIT_WEBHOOK_FLUSH_INTERVAL", "WEBCLI_AUDIT_WEBHOOK_FLUSH_INTERVAL")
+	v.BindEnv("audit_webhook_queue_size", "AUDIT_WEBHOOK_QUEUE_SIZE", "WEBCLI_AUDIT_WEBHOOK_QUEUE_SIZE")
+	v.BindEnv("audit_webhook_max_retries", "AUDIT_WEBHOOK_MAX_RETRIES", "WEBCLI_AUDIT_WEBHOOK_MAX_RETRIES")
+
+	// Slack / Mattermost notifications
+	v.BindEnv("slack_webhook_url", "SLACK_WEBHOOK_URL", "WEBCLI_SLACK_WEBHOOK_URL")
+	v.BindEnv("slack_channel", "SLACK_CHANNEL", "WEBCLI_SLACK_CHANNEL")
+	v.BindEnv("slack_username", "SLACK_USERNAME", "WEBCLI_SLACK_USERNAME")
+
+	// Email notifications
+	v.BindEnv("smtp_host", "SMTP_HOST", "WEBCLI_SMTP_HOST")
+	v.BindEnv("smtp_port", "SMTP_PORT", "WEBCLI_SMTP_PORT")
+	v.BindEnv("smtp_username", "SMTP_USERNAME", "WEBCLI_SMTP_USERNAME")
+	v.BindEnv("smtp_password", "SMTP_PASSWORD", "WEBCLI_SMTP_PASSWORD")
+	v.BindEnv("smtp_tls", "SMTP_TLS", "WEBCLI_SMTP_TLS")
+	v.BindEnv("email_from", "EMAIL_FROM", "WEBCLI_EMAIL_FROM")
+	v.BindEnv("email_to", "EMAIL_TO", "WEBCLI_EMAIL_TO")
+	v.BindEnv("email_events", "EMAIL_EVENTS", "WEBCLI_EMAIL_EVENTS")
+	v.BindEnv("email_subject", "EMAIL_SUBJECT", "WEBCLI_EMAIL_SUBJECT")
+	v.BindEnv("email_body_template", "EMAIL_BODY_TEMPLATE", "WEBCLI_EMAIL_BODY_TEMPLATE")
+
+	// Local execution
+	v.BindEnv("local_shell", "LOCAL_SHELL", "WEBCLI_LOCAL_SHELL")
+	v.BindEnv("local_elevation", "LOCAL_ELEVATION", "WEBCLI_LOCAL_ELEVATION")
+	v.BindEnv("local_users_only", "LOCAL_USERS_ONLY", "WEBCLI_LOCAL_USERS_ONLY")
+	v.BindEnv("local_user_min_uid", "LOCAL_USER_MIN_UID", "WEBCLI_LOCAL_USER_MIN_UID")
+	v.BindEnv("local_user_max_uid", "LOCAL_USER_MAX_UID", "WEBCLI_LOCAL_USER_MAX_UID")
+
+	// Git sync
+	v.BindEnv("git_sync_url", "GIT_SYNC_URL", "WEBCLI_GIT_SYNC_URL")
+	v.BindEnv("git_sync_branch", "GIT_SYNC_BRANCH", "WEBCLI_GIT_SYNC_BRANCH")
+	v.BindEnv("git_sync_path", "GIT_SYNC_PATH", "WEBCLI_GIT_SYNC_PATH")
+	v.BindEnv("git_sync_group", "GIT_SYNC_GROUP", "WEBCLI_GIT_SYNC_GROUP")
+	v.BindEnv("git_sync_interval", "GIT_SYNC_INTERVAL", "WEBCLI_GIT_SYNC_INTERVAL")
+	v.BindEnv("git_sync_username", "GIT_SYNC_USERNAME", "WEBCLI_GIT_SYNC_USERNAME")
+	v.BindEnv("git_sync_token", "GIT_SYNC_TOKEN", "WEBCLI_GIT_SYNC_TOKEN")
+	v.BindEnv("git_sync_ssh_key_path", "GIT_SYNC_SSH_KEY_PATH", "WEBCLI_GIT_SYNC_SSH_KEY_PATH")
+	v.BindEnv("git_sync_dir", "GIT_SYNC_DIR", "WEBCLI_GIT_SYNC_DIR")
+
+	// Cloud inventory
+	v.BindEnv("inventory_interval", "INVENTORY_INTERVAL", "WEBCLI_INVENTORY_INTERVAL")
+	v.BindEnv("inventory_group_tag", "INVENTORY_GROUP_TAG", "WEBCLI_INVENTORY_GROUP_TAG")
+	v.BindEnv("inventory_private_ip", "INVENTORY_PRIVATE_IP", "WEBCLI_INVENTORY_PRIVATE_IP")
+	v.BindEnv("inventory_aws_regions", "INVENTORY_AWS_REGIONS", "WEBCLI_INVENTORY_AWS_REGIONS")
+	v.BindEnv("inventory_aws_access_key_id", "INVENTORY_AWS_ACCESS_KEY_ID", "WEBCLI_INVENTORY_AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
+	v.BindEnv("inventory_aws_secret_access_key", "INVENTORY_AWS_SECRET_ACCESS_KEY", "WEBCLI_INVENTORY_AWS_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
+	v.BindEnv("inventory_aws_session_token", "INVENTORY_AWS_SESSION_TOKEN", "WEBCLI_INVENTORY_AWS_SESSION_TOKEN", "AWS_SESSION_TOKEN")
+	v.BindEnv("inventory_digitalocean_token", "INVENTORY_DIGITALOCEAN_TOKEN", "WEBCLI_INVENTORY_DIGITALOCEAN_TOKEN")
+	v.BindEnv("inventory_hetzner_token", "INVENTORY_HETZNER_TOKEN", "WEBCLI_INVENTORY_HETZNER_TOKEN")
+
+	// Kubernetes
+	v.BindEnv("kubernetes_kubeconfig", "KUBERNETES_KUBECONFIG", "WEBCLI_KUBERNETES_KUBECONFIG")
+	v.BindEnv("kubernetes_context", "KUBERNETES_CONTEXT", "WEBCLI_KUBERNETES_CONTEXT")
+	v.BindEnv("kubernetes_in_cluster", "KUBERNETES_IN_CLUSTER", "WEBCLI_KUBERNETES_IN_CLUSTER")
+
+	// Rate limiting
+	v.BindEnv("execution_rate_limit", "EXECUTION_RATE_LIMIT", "WEBCLI_EXECUTION_RATE_LIMIT")
+	v.BindEnv("execution_rate_burst", "EXECUTION_RATE_BURST", "WEBCLI_EXECUTION_RATE_BURST")
+
+	// Execution queue
+	v.BindEnv("max_concurrent_executions", "MAX_CONCURRENT_EXECUTIONS", "WEBCLI_MAX_CONCURRENT_EXECUTIONS")
+	v.BindEnv("max_queued_executions", "MAX_QUEUED_EXECUTIONS", "WEBCLI_MAX_QUEUED_EXECUTIONS")
+	v.BindEnv("server_max_parallel", "SERVER_MAX_PARALLEL", "WEBCLI_SERVER_MAX_PARALLEL")
+	v.BindEnv("execution_queue_timeout", "EXECUTION_QUEUE_TIMEOUT", "WEBCLI_EXECUTION_QUEUE_TIMEOUT")
+
+	// Execution output
+	v.BindEnv("output_max_size", "OUTPUT_MAX_SIZE", "WEBCLI_OUTPUT_MAX_SIZE")
+	v.BindEnv("execution_log_dir", "EXECUTION_LOG_DIR", "WEBCLI_EXECUTION_LOG_DIR")
+
+	// Login lockout
+	v.BindEnv("auth_lockout_threshold", "AUTH_LOCKOUT_THRESHOLD", "WEBCLI_AUTH_LOCKOUT_THRESHOLD")
+	v.BindEnv("auth_lockout_duration", "AUTH_LOCKOUT_DURATION", "WEBCLI_AUTH_LOCKOUT_DURATION")
+	v.BindEnv("auth_lockout_max_duration", "AUTH_LOCKOUT_MAX_DURATION", "WEBCLI_AUTH_LOCKOUT_MAX_DURATION")
+
+	// Session login
+	v.BindEnv("session_secret", "SESSION_SECRET", "WEBCLI_SESSION_SECRET")
+	v.BindEnv("session_ttl", "SESSION_TTL", "WEBCLI_SESSION_TTL")
+	v.BindEnv("sudo_password_ttl", "SUDO_PASSWORD_TTL", "WEBCLI_SUDO_PASSWORD_TTL")
+
+	// Two-person approval
+	v.BindEnv("approval_ttl", "APPROVAL_TTL", "WEBCLI_APPROVAL_TTL")
+	v.BindEnv("approver_role", "APPROVER_ROLE", "WEBCLI_APPROVER_ROLE")
+
+	// Read-only mode
+	v.BindEnv("read_only", "READ_ONLY", "WEBCLI_READ_ONLY")
+	v.BindEnv("read_only_reason", "READ_ONLY_REASON", "WEBCLI_READ_ONLY_REASON")
+
+	// Terminal recording
+	v.BindEnv("terminal_recording_dir", "TERMINAL_RECORDING_DIR", "WEBCLI_TERMINAL_RECORDING_DIR")
+	v.BindEnv("terminal_record_input", "TERMINAL_RECORD_INPUT", "WEBCLI_TERMINAL_RECORD_INPUT")
+	v.BindEnv("terminal_recording_retention", "TERMINAL_RECORDING_RETENTION", "WEBCLI_TERMINAL_RECORDING_RETENTION")
+
+	// Terminal session limits
+	v.BindEnv("terminal_max_sessions", "TERMINAL_MAX_SESSIONS", "WEBCLI_TERMINAL_MAX_SESSIONS")
+	v.BindEnv("terminal_max_sessions_per_user", "TERMINAL_MAX_SESSIONS_PER_USER", "WEBCLI_TERMINAL_MAX_SESSIONS_PER_USER")
+
+	// Resumable terminal sessions
+	v.BindEnv("terminal_detach_grace", "TERMINAL_DETACH_GRACE", "WEBCLI_TERMINAL_DETACH_GRACE")
+	v.BindEnv("terminal_scrollback", "TERMINAL_SCROLLBACK", "WEBCLI_TERMINAL_SCROLLBACK")
+
+	// Terminal file exchange
+	v.BindEnv("terminal_file_max_size", "TERMINAL_FILE_MAX_SIZE", "WEBCLI_TERMINAL_FILE_MAX_SIZE")
+
+	// Terminal shells
+	v.BindEnv("terminal_shells", "TERMINAL_SHELLS", "WEBCLI_TERMINAL_SHELLS")
+	v.BindEnv("terminal_default_shell", "TERMINAL_DEFAULT_SHELL", "WEBCLI_TERMINAL_DEFAULT_SHELL")
+	v.BindEnv("terminal_user_shells", "TERMINAL_USER_SHELLS", "WEBCLI_TERMINAL_USER_SHELLS")
+	v.BindEnv("terminal_shell_rc", "TERMINAL_SHELL_RC", "WEBCLI_TERMINAL_SHELL_RC")
+
+	// Keystroke logging
+	v.BindEnv("terminal_keystroke_log", "TERMINAL_KEYSTROKE_LOG", "WEBCLI_TERMINAL_KEYSTROKE_LOG")
+	v.BindEnv("encryption_provider", "ENCRYPTION_PROVIDER", "WEBCLI_ENCRYPTION_PROVIDER")
+	v.BindEnv("encryption_kms_key", "ENCRYPTION_KMS_KEY", "WEBCLI_ENCRYPTION_KMS_KEY")
+	v.BindEnv("encryption_age_identity", "ENCRYPTION_AGE_IDENTITY", "WEBCLI_ENCRYPTION_AGE_IDENTITY")
+	v.BindEnv("encryption_age_recipient", "ENCRYPTION_AGE_RECIPIENT", "WEBCLI_ENCRYPTION_AGE_RECIPIENT")
+	v.BindEnv("transit_address", "TRANSIT_ADDRESS", "WEBCLI_TRANSIT_ADDRESS")
+	v.BindEnv("transit_token", "TRANSIT_TOKEN", "WEBCLI_TRANSIT_TOKEN")
+	v.BindEnv("transit_namespace", "TRANSIT_NAMESPACE", "WEBCLI_TRANSIT_NAMESPACE")
+	v.BindEnv("transit_mount", "TRANSIT_MOUNT", "WEBCLI_TRANSIT_MOUNT")
+	v.BindEnv("transit_key", "TRANSIT_KEY", "WEBCLI_TRANSIT_KEY")
+	v.BindEnv("transit_cache_size", "TRANSIT_CACHE_SIZE", "WEBCLI_TRANSIT_CACHE_SIZE")
+	v.BindEnv("transit_cache_ttl", "TRANSIT_CACHE_TTL", "WEBCLI_TRANSIT_CACHE_TTL")
+
+	// Vault sync
+	v.BindEnv("vault_sync_ssh_keys", "VAULT_SYNC_SSH_KEYS", "WEBCLI_VAULT_SYNC_SSH_KEYS")
+	v.BindEnv("vault_sync_servers", "VAULT_SYNC_SERVERS", "WEBCLI_VAULT_SYNC_SERVERS")
+	v.BindEnv("vault_sync_env_variables", "VAULT_SYNC_ENV_VARIABLES", "WEBCLI_VAULT_SYNC_ENV_VARIABLES")
+	v.BindEnv("vault_sync_scripts", "VAULT_SYNC_SCRIPTS", "WEBCLI_VAULT_SYNC_SCRIPTS")
+	v.BindEnv("vault_sync_conflict", "VAULT_SYNC_CONFLICT", "WEBCLI_VAULT_SYNC_CONFLICT")
+	v.BindEnv("vault_sync_interval", "VAULT_SYNC_INTERVAL", "WEBCLI_VAULT_SYNC_INTERVAL")
+
+	// IP access control
+	v.BindEnv("ip_allowlist", "IP_ALLOWLIST", "WEBCLI_IP_ALLOWLIST")
+	v.BindEnv("ip_denylist", "IP_DENYLIST", "WEBCLI_IP_DENYLIST")
+	v.BindEnv("execution_ip_allowlist", "EXECUTION_IP_ALLOWLIST", "WEBCLI_EXECUTION_IP_ALLOWLIST")
+
+	// CORS policy
+	v.BindEnv("cors_allowed_origins", "CORS_ALLOWED_ORIGINS", "WEBCLI_CORS_ALLOWED_ORIGINS")
+	v.BindEnv("cors_allowed_methods", "CORS_ALLOWED_METHODS", "WEBCLI_CORS_ALLOWED_METHODS")
+	v.BindEnv("cors_allowed_headers", "CORS_ALLOWED_HEADERS", "WEBCLI_CORS_ALLOWED_HEADERS")
+	v.BindEnv("cors_exposed_headers", "CORS_EXPOSED_HEADERS", "WEBCLI_CORS_EXPOSED_HEADERS")
+	v.BindEnv("cors_allow_credentials", "CORS_ALLOW_CREDENTIALS", "WEBCLI_CORS_ALLOW_CREDENTIALS")
+	v.BindEnv("cors_max_age", "CORS_MAX_AGE", "WEBCLI_CORS_MAX_AGE")
 
 	// Config file support (optional)
 	v.SetConfigName("config")       // config.yaml, config.json, config.toml
@@ -156,27 +846,33 @@ func Load() *Config {
 		log.Printf("Using config file: %s", v.ConfigFileUsed())
 	}
 
-	// Command-line flags (highest priority) - only define once
-	flagsMu.Lock()
-	if !flagsInitialized {
-		flag.Int("port", v.GetInt("port"), "Port to listen on")
-		flag.String("host", v.GetString("host"), "Host to bind to")
-		flag.String("frontend", v.GetString("frontend_path"), "Path to frontend build files")
-		flag.String("db", v.GetString("database_path"), "Path to SQLite database file")
-		flag.String("encryption-key", v.GetString("encryption_key_path"), "Path to encryption key file")
-		flag.String("tls-cert", v.GetString("tls_cert_path"), "Path to TLS certificate file (enables HTTPS)")
-		flag.String("tls-key", v.GetString("tls_key_path"), "Path to TLS private key file")
-		flag.Bool("require-https", v.GetBool("require_https"), "Require HTTPS when auth is enabled")
-		flagsInitialized = true
-	}
-	flagsMu.Unlock()
+	return v
+}
 
-	if !flag.Parsed() {
-		flag.Parse()
-	}
+// registerFlags defines the configuration flags on fs, using current values as defaults
+func registerFlags(fs *flag.FlagSet, v *viper.Viper) {
+	fs.Int("port", v.GetInt("port"), "Port to listen on")
+	fs.String("host", v.GetString("host"), "Host to bind to")
+	fs.String("frontend", v.GetString("frontend_path"), "Path to frontend build files")
+	fs.String("base-path", v.GetString("base_path"), "URL path prefix to serve the app under (e.g. /webcli)")
+	fs.String("db", v.GetString("database_path"), "Path to SQLite database file")
+	fs.String("encryption-key", v.GetString("encryption_key_path"), "Path to encryption key file")
+	fs.String("tls-cert", v.GetString("tls_cert_path"), "Path to TLS certificate file (enables HTTPS)")
+	fs.String("tls-key", v.GetString("tls_key_path"), "Path to TLS private key file")
+	fs.Bool("require-https", v.GetBool("require_https"), "Require HTTPS when auth is enabled")
+	fs.String("tls-client-ca", v.GetString("tls_client_ca_path"), "Path to CA bundle for verifying client certificates (enables mutual TLS)")
+	fs.String("tls-client-auth", v.GetString("tls_client_auth"), "Client certificate mode: optional or require")
+	fs.Int("health-port", v.GetInt("health_port"), "Plain-HTTP port for health probes (0 to disable)")
+	fs.Int("grpc-port", v.GetInt("grpc_port"), "Port for the gRPC API (0 to disable)")
+	fs.String("cors-origins", v.GetString("cors_allowed_origins"), "Comma-separated origins allowed for cross-origin requests")
+	fs.String("listen-socket", v.GetString("listen_socket"), "Unix domain socket path to serve on")
+	fs.String("listen-socket-mode", v.GetString("listen_socket_mode"), "Octal permissions for the unix socket")
+	fs.Bool("socket-only", v.GetBool("socket_only"), "Serve only on the unix socket (disable TCP)")
+}
 
-	// Bind flags to viper (so flag values override config/env)
-	flag.Visit(func(f *flag.Flag) {
+// applyFlags copies explicitly set flags into viper (so flag values override config/env)
+func applyFlags(fs *flag.FlagSet, v *viper.Viper) {
+	fs.Visit(func(f *flag.Flag) {
 		switch f.Name {
 		case "port":
 			if val, err := strconv.Atoi(f.Value.String()); err == nil {
@@ -186,6 +882,8 @@ func Load() *Config {
 			v.Set("host", f.Value.String())
 		case "frontend":
 			v.Set("frontend_path", f.Value.String())
+		case "base-path":
+			v.Set("base_path", f.Value.String())
 		case "db":
 			v.Set("database_path", f.Value.String())
 		case "encryption-key":
@@ -196,18 +894,51 @@ func Load() *Config {
 			v.Set("tls_key_path", f.Value.String())
 		case "require-https":
 			v.Set("require_https", f.Value.String() == "true")
+		case "tls-client-ca":
+			v.Set("tls_client_ca_path", f.Value.String())
+		case "tls-client-auth":
+			v.Set("tls_client_auth", f.Value.String())
+		case "health-port":
+			if val, err := strconv.Atoi(f.Value.String()); err == nil {
+				v.Set("health_port", val)
+			}
+		case "grpc-port":
+			if val, err := strconv.Atoi(f.Value.String()); err == nil {
+				v.Set("grpc_port", val)
+			}
+		case "cors-origins":
+			v.Set("cors_allowed_origins", f.Value.String())
+		case "listen-socket":
+			v.Set("listen_socket", f.Value.String())
+		case "listen-socket-mode":
+			v.Set("listen_socket_mode", f.Value.String())
+		case "socket-only":
+			v.Set("socket_only", f.Value.String() == "true")
 		}
 	})
+}
 
+// fromViper builds a Config from the resolved viper values
+func fromViper(v *viper.Viper) *Config {
 	return &Config{
 		Port:              v.GetInt("port"),
 		Host:              v.GetString("host"),
 		FrontendPath:      v.GetString("frontend_path"),
+		BasePath:          v.GetString("base_path"),
+		SwaggerUI:         v.GetBool("swagger_ui"),
 		DatabasePath:      v.GetString("database_path"),
+		DBMaintenanceTime: v.GetString("db_maintenance_time"),
 		EncryptionKeyPath: v.GetString("encryption_key_path"),
 		TLSCertPath:       v.GetString("tls_cert_path"),
 		TLSKeyPath:        v.GetString("tls_key_path"),
 		RequireHTTPS:      v.GetBool("require_https"),
+		TLSClientCAPath:   v.GetString("tls_client_ca_path"),
+		TLSClientAuth:     v.GetString("tls_client_auth"),
+		HealthPort:        v.GetInt("health_port"),
+		GRPCPort:          v.GetInt("grpc_port"),
+		ListenSocket:      v.GetString("listen_socket"),
+		ListenSocketMode:  v.GetString("listen_socket_mode"),
+		SocketOnly:        v.GetBool("socket_only"),
 
 		// Timeout values
 		ReadTimeout:       v.GetInt("read_timeout"),
@@ -218,7 +949,182 @@ func Load() *Config {
 		SSHConnectTimeout: v.GetInt("ssh_connect_timeout"),
 
 		// Audit logging
-		AuditLogPath: v.GetString("audit_log_path"),
+		AuditLogPath:        v.GetString("audit_log_path"),
+		AuditSyslog:         v.GetString("audit_syslog"),
+		AuditSyslogFacility: v.GetString("audit_syslog_facility"),
+		AuditJournald:       v.GetBool("audit_journald"),
+
+		// Audit webhook
+		AuditWebhookURL:           v.GetString("audit_webhook_url"),
+		AuditWebhookSecret:        v.GetString("audit_webhook_secret"),
+		AuditWebhookBatchSize:     v.GetInt("audit_webhook_batch_size"),
+		AuditWebhookFlushInterval: v.GetInt("audit_webhook_flush_interval"),
+		AuditWebhookQueueSize:     v.GetInt("audit_webhook_queue_size"),
+		AuditWebhookMaxRetries:    v.GetInt("audit_webhook_max_retries"),
+
+		// Slack / Mattermost notifications
+		SlackWebhookURL: v.GetString("slack_webhook_url"),
+		SlackChannel:    v.GetString("slack_channel"),
+		SlackUsername:   v.GetString("slack_username"),
+
+		// Email notifications
+		SMTPHost:          v.GetString("smtp_host"),
+		SMTPPort:          v.GetInt("smtp_port"),
+		SMTPUsername:      v.GetString("smtp_username"),
+		SMTPPassword:      v.GetString("smtp_password"),
+		SMTPTLS:           v.GetString("smtp_tls"),
+		EmailFrom:         v.GetString("email_from"),
+		EmailTo:           splitList(v.GetString("email_to")),
+		EmailEvents:       splitList(v.GetString("email_events")),
+		EmailSubject:      v.GetString("email_subject"),
+		EmailBodyTemplate: v.GetString("email_body_template"),
+
+		// Local execution
+		LocalShell:      v.GetString("local_shell"),
+		LocalElevation:  v.GetString("local_elevation"),
+		LocalUsersOnly:  v.GetBool("local_users_only"),
+		LocalUserMinUID: v.GetInt("local_user_min_uid"),
+		LocalUserMaxUID: v.GetInt("local_user_max_uid"),
+
+		// Git sync
+		GitSyncURL:        v.GetString("git_sync_url"),
+		GitSyncBranch:     v.GetString("git_sync_branch"),
+		GitSyncPath:       v.GetString("git_sync_path"),
+		GitSyncGroup:      v.GetString("git_sync_group"),
+		GitSyncInterval:   v.GetInt("git_sync_interval"),
+		GitSyncUsername:   v.GetString("git_sync_username"),
+		GitSyncToken:      v.GetString("git_sync_token"),
+		GitSyncSSHKeyPath: v.GetString("git_sync_ssh_key_path"),
+		GitSyncDir:        v.GetString("git_sync_dir"),
+
+		// Cloud inventory
+		InventoryInterval:           v.GetInt("inventory_interval"),
+		InventoryGroupTag:           v.GetString("inventory_group_tag"),
+		InventoryPrivateIP:          v.GetBool("inventory_private_ip"),
+		InventoryAWSRegions:         splitList(v.GetString("inventory_aws_regions")),
+		InventoryAWSAccessKeyID:     v.GetString("inventory_aws_access_key_id"),
+		InventoryAWSSecretAccessKey: v.GetString("inventory_aws_secret_access_key"),
+		InventoryAWSSessionToken:    v.GetString("inventory_aws_session_token"),
+		InventoryDigitalOceanToken:  v.GetString("inventory_digitalocean_token"),
+		InventoryHetznerToken:       v.GetString("inventory_hetzner_token"),
+
+		// Kubernetes
+		KubernetesKubeconfig: v.GetString("kubernetes_kubeconfig"),
+		KubernetesContext:    v.GetString("kubernetes_context"),
+		KubernetesInCluster:  v.GetBool("kubernetes_in_cluster"),
+
+		// Rate limiting
+		ExecutionRateLimit: v.GetInt("execution_rate_limit"),
+		ExecutionRateBurst: v.GetInt("execution_rate_burst"),
+
+		// Execution queue
+		MaxConcurrentExecutions: v.GetInt("max_concurrent_executions"),
+		MaxQueuedExecutions:     v.GetInt("max_queued_executions"),
+		ServerMaxParallel:       v.GetInt("server_max_parallel"),
+		ExecutionQueueTimeout:   v.GetInt("execution_queue_timeout"),
+
+		// Execution output
+		OutputMaxSize:   v.GetInt("output_max_size"),
+		ExecutionLogDir: v.GetString("execution_log_dir"),
+
+		// Login lockout
+		AuthLockoutThreshold:   v.GetInt("auth_lockout_threshold"),
+		AuthLockoutDuration:    v.GetInt("auth_lockout_duration"),
+		AuthLockoutMaxDuration: v.GetInt("auth_lockout_max_duration"),
+
+		// Session login
+		SessionSecret:   v.GetString("session_secret"),
+		SessionTTL:      v.GetInt("session_ttl"),
+		SudoPasswordTTL: v.GetInt("sudo_password_ttl"),
+
+		// Two-person approval
+		ApprovalTTL:  v.GetInt("approval_ttl"),
+		ApproverRole: v.GetString("approver_role"),
+
+		// Read-only mode
+		ReadOnly:       v.GetBool("read_only"),
+		ReadOnlyReason: v.GetString("read_only_reason"),
+
+		// Terminal recording
+		TerminalRecordingDir:       v.GetString("terminal_recording_dir"),
+		TerminalRecordInput:        v.GetBool("terminal_record_input"),
+		TerminalRecordingRetention: v.GetInt("terminal_recording_retention"),
+
+		// Terminal session limits
+		TerminalMaxSessions:        v.GetInt("terminal_max_sessions"),
+		TerminalMaxSessionsPerUser: v.GetInt("terminal_max_sessions_per_user"),
+
+		// Resumable terminal sessions
+		TerminalDetachGrace: v.GetInt("terminal_detach_grace"),
+		TerminalScrollback:  v.GetInt("terminal_scrollback"),
+
+		// Terminal file exchange
+		TerminalFileMaxSize: v.GetInt("terminal_file_max_size"),
+
+		// Terminal shells
+		TerminalShells:       splitList(v.GetString("terminal_shells")),
+		TerminalDefaultShell: v.GetString("terminal_default_shell"),
+		TerminalUserShells:   splitList(v.GetString("terminal_user_shells")),
+		TerminalShellRC:      v.GetString("terminal_shell_rc"),
+
+		// Keystroke logging
+		TerminalKeystrokeLog: v.GetBool("terminal_keystroke_log"),
+
+		// Encryption
+		EncryptionProvider:     v.GetString("encryption_provider"),
+		EncryptionKMSKey:       v.GetString("encryption_kms_key"),
+		EncryptionAgeIdentity:  v.GetString("encryption_age_identity"),
+		EncryptionAgeRecipient: v.GetString("encryption_age_recipient"),
+		TransitAddress:         v.GetString("transit_address"),
+		TransitToken:           v.GetString("transit_token"),
+		TransitNamespace:       v.GetString("transit_namespace"),
+		TransitMount:           v.GetString("transit_mount"),
+		TransitKey:             v.GetString("transit_key"),
+		TransitCacheSize:       v.GetInt("transit_cache_size"),
+		TransitCacheTTL:        v.GetInt("transit_cache_ttl"),
+
+		// Vault sync
+		VaultSyncSSHKeys:      v.GetString("vault_sync_ssh_keys"),
+		VaultSyncServers:      v.GetString("vault_sync_servers"),
+		VaultSyncEnvVariables: v.GetString("vault_sync_env_variables"),
+		VaultSyncScripts:      v.GetString("vault_sync_scripts"),
+		VaultSyncConflict:     v.GetString("vault_sync_conflict"),
+		VaultSyncInterval:     v.GetInt("vault_sync_interval"),
+
+		// IP access control
+		IPAllowList:          splitList(v.GetString("ip_allowlist")),
+		IPDenyList:           splitList(v.GetString("ip_denylist")),
+		ExecutionIPAllowList: splitList(v.GetString("execution_ip_allowlist")),
+
+		// CORS policy
+		CORSAllowedOrigins:   splitList(v.GetString("cors_allowed_origins")),
+		CORSAllowedMethods:   splitList(v.GetString("cors_allowed_methods")),
+		CORSAllowedHeaders:   splitList(v.GetString("cors_allowed_headers")),
+		CORSExposedHeaders:   splitList(v.GetString("cors_exposed_headers")),
+		CORSAllowCredentials: v.GetBool("cors_allow_credentials"),
+		CORSMaxAge:           v.GetInt("cors_max_age"),
+	}
+}
+
+// splitList splits a comma-separated value into trimmed, non-empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetCORSAllowedOrigins returns the allowed CORS origins, defaulting to localhost on the server port
+func (c *Config) GetCORSAllowedOrigins() []string {
+	if len(c.CORSAllowedOrigins) > 0 {
+		return c.CORSAllowedOrigins
+	}
+	return []string{
+		fmt.Sprintf("http://localhost:%d", c.Port),
+		fmt.Sprintf("http://127.0.0.1:%d", c.Port),
 	}
 }
 
@@ -227,6 +1133,21 @@ func (c *Config) GetAddress() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// GetHealthAddress returns the health probe listener address (host:port)
+func (c *Config) GetHealthAddress() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.HealthPort)
+}
+
+// GetGRPCAddress returns the gRPC listener address (host:port)
+func (c *Config) GetGRPCAddress() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.GRPCPort)
+}
+
+// ClientCertAuthEnabled returns true if client certificates are verified (mutual TLS)
+func (c *Config) ClientCertAuthEnabled() bool {
+	return c.TLSEnabled() && c.TLSClientCAPath != ""
+}
+
 // TLSEnabled returns true if TLS certificate and key paths are configured
 func (c *Config) TLSEnabled() bool {
 	return c.TLSCertPath != "" && c.TLSKeyPath != ""
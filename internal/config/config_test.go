@@ -1,16 +1,19 @@
 package config
 
 import (
+	"flag"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestConfigDefaults(t *testing.T) {
 	// Clear environment variables that might affect the test
 	envVars := []string{"PORT", "HOST", "FRONTEND_PATH", "DATABASE_PATH",
 		"ENCRYPTION_KEY_PATH", "TLS_CERT_PATH", "TLS_KEY_PATH", "REQUIRE_HTTPS",
-		"WEBCLI_PORT", "WEBCLI_HOST"}
+		"WEBCLI_PORT", "WEBCLI_HOST", "HEALTH_PORT", "WEBCLI_HEALTH_PORT",
+		"GRPC_PORT", "WEBCLI_GRPC_PORT"}
 	for _, v := range envVars {
 		os.Unsetenv(v)
 	}
@@ -49,6 +52,14 @@ func TestConfigDefaults(t *testing.T) {
 	if cfg.RequireHTTPS != false {
 		t.Errorf("Expected RequireHTTPS false by default, got %v", cfg.RequireHTTPS)
 	}
+
+	if cfg.HealthPort != 0 {
+		t.Errorf("Expected health port disabled by default, got %d", cfg.HealthPort)
+	}
+
+	if cfg.GRPCPort != 0 {
+		t.Errorf("Expected gRPC API disabled by default, got %d", cfg.GRPCPort)
+	}
 }
 
 func TestConfigFromEnvironment(t *testing.T) {
@@ -197,3 +208,586 @@ func TestConfigPrefixedEnvVars(t *testing.T) {
 		t.Errorf("Expected host 192.168.1.1 from WEBCLI_HOST env, got %s", cfg.Host)
 	}
 }
+
+func TestLoadFlagSet(t *testing.T) {
+	os.Setenv("WEBCLI_PORT", "4444")
+	defer os.Unsetenv("WEBCLI_PORT")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	force := fs.Bool("force", false, "subcommand flag")
+
+	cfg, err := LoadFlagSet(fs, []string{"-db", "/tmp/custom.db", "-force", "extra"})
+	if err != nil {
+		t.Fatalf("LoadFlagSet failed: %v", err)
+	}
+
+	if cfg.DatabasePath != "/tmp/custom.db" {
+		t.Errorf("Expected database path from flag, got %s", cfg.DatabasePath)
+	}
+
+	if cfg.Port != 4444 {
+		t.Errorf("Expected port 4444 from WEBCLI_PORT env, got %d", cfg.Port)
+	}
+
+	if !*force {
+		t.Error("Expected subcommand flag to be parsed")
+	}
+
+	if fs.NArg() != 1 || fs.Arg(0) != "extra" {
+		t.Errorf("Expected positional argument 'extra', got %v", fs.Args())
+	}
+}
+
+func TestConfigGetListenSocketMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected os.FileMode
+	}{
+		{"0660", 0660},
+		{"600", 0600},
+		{"", 0660},
+		{"invalid", 0660},
+		{"7777", 0660},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{ListenSocketMode: tt.mode}
+		if got := cfg.GetListenSocketMode(); got != tt.expected {
+			t.Errorf("GetListenSocketMode(%q) = %04o, want %04o", tt.mode, got, tt.expected)
+		}
+	}
+}
+
+func TestConfigGetBasePath(t *testing.T) {
+	tests := []struct {
+		basePath string
+		expected string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"webcli", "/webcli"},
+		{"/webcli/", "/webcli"},
+		{"/tools/web-cli", "/tools/web-cli"},
+		{"/web cli", ""},
+		{"/webcli/../admin", ""},
+		{"/webcli/<script>", ""},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{BasePath: tt.basePath}
+		if got := cfg.GetBasePath(); got != tt.expected {
+			t.Errorf("GetBasePath(%q) = %q, want %q", tt.basePath, got, tt.expected)
+		}
+	}
+}
+
+func TestConfigCORS(t *testing.T) {
+	os.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com,")
+	os.Setenv("WEBCLI_CORS_ALLOWED_METHODS", "GET,POST")
+	defer func() {
+		os.Unsetenv("CORS_ALLOWED_ORIGINS")
+		os.Unsetenv("WEBCLI_CORS_ALLOWED_METHODS")
+	}()
+
+	cfg := Load()
+
+	origins := cfg.GetCORSAllowedOrigins()
+	if len(origins) != 2 || origins[0] != "https://a.example.com" || origins[1] != "https://b.example.com" {
+		t.Errorf("Unexpected CORS origins: %v", origins)
+	}
+
+	if len(cfg.CORSAllowedMethods) != 2 || cfg.CORSAllowedMethods[1] != "POST" {
+		t.Errorf("Unexpected CORS methods: %v", cfg.CORSAllowedMethods)
+	}
+
+	if !cfg.CORSAllowCredentials {
+		t.Error("Expected CORS credentials allowed by default")
+	}
+
+	if cfg.CORSMaxAge != 300 {
+		t.Errorf("Expected default CORS max age 300, got %d", cfg.CORSMaxAge)
+	}
+}
+
+func TestConfigCORSDefaultOrigins(t *testing.T) {
+	cfg := &Config{Port: 8080}
+
+	origins := cfg.GetCORSAllowedOrigins()
+	expected := []string{"http://localhost:8080", "http://127.0.0.1:8080"}
+	if len(origins) != len(expected) || origins[0] != expected[0] || origins[1] != expected[1] {
+		t.Errorf("Expected default origins %v, got %v", expected, origins)
+	}
+}
+
+func TestConfigExecutionRateLimit(t *testing.T) {
+	cfg := Load()
+	if cfg.ExecutionRateLimit != 30 {
+		t.Errorf("Expected default execution rate limit 30, got %d", cfg.ExecutionRateLimit)
+	}
+	if cfg.ExecutionRateBurst != 10 {
+		t.Errorf("Expected default execution rate burst 10, got %d", cfg.ExecutionRateBurst)
+	}
+
+	os.Setenv("WEBCLI_EXECUTION_RATE_LIMIT", "0")
+	defer os.Unsetenv("WEBCLI_EXECUTION_RATE_LIMIT")
+
+	cfg = Load()
+	if cfg.ExecutionRateLimit != 0 {
+		t.Errorf("Expected execution rate limit 0 from env, got %d", cfg.ExecutionRateLimit)
+	}
+}
+
+func TestConfigExecutionQueue(t *testing.T) {
+	cfg := Load()
+	if cfg.MaxConcurrentExecutions != 20 || cfg.MaxQueuedExecutions != 100 || cfg.ServerMaxParallel != 4 {
+		t.Errorf("Unexpected default queue limits %d, %d, %d", cfg.MaxConcurrentExecutions, cfg.MaxQueuedExecutions, cfg.ServerMaxParallel)
+	}
+	if cfg.GetExecutionQueueTimeout() != 5*time.Minute {
+		t.Errorf("Expected default queue timeout 5m, got %v", cfg.GetExecutionQueueTimeout())
+	}
+
+	os.Setenv("SERVER_MAX_PARALLEL", "1")
+	os.Setenv("EXECUTION_QUEUE_TIMEOUT", "0")
+	defer os.Unsetenv("SERVER_MAX_PARALLEL")
+	defer os.Unsetenv("EXECUTION_QUEUE_TIMEOUT")
+
+	cfg = Load()
+	if cfg.ServerMaxParallel != 1 || cfg.GetExecutionQueueTimeout() != 0 {
+		t.Errorf("Expected limits from env, got %d and %v", cfg.ServerMaxParallel, cfg.GetExecutionQueueTimeout())
+	}
+}
+
+func TestConfigExecutionOutput(t *testing.T) {
+	cfg := Load()
+	if cfg.GetOutputMaxSize() != 1<<20 {
+		t.Errorf("Expected default output limit 1 MB, got %d", cfg.GetOutputMaxSize())
+	}
+	if want := filepath.Join(filepath.Dir(cfg.DatabasePath), "execution-logs"); cfg.GetExecutionLogDir() != want {
+		t.Errorf("Expected log dir %s, got %s", want, cfg.GetExecutionLogDir())
+	}
+
+	os.Setenv("OUTPUT_MAX_SIZE", "0")
+	os.Setenv("EXECUTION_LOG_DIR", "/var/log/web-cli")
+	defer os.Unsetenv("OUTPUT_MAX_SIZE")
+	defer os.Unsetenv("EXECUTION_LOG_DIR")
+
+	cfg = Load()
+	if cfg.GetOutputMaxSize() != 0 || cfg.GetExecutionLogDir() != "/var/log/web-cli" {
+		t.Errorf("Expected output settings from env, got %d and %s", cfg.GetOutputMaxSize(), cfg.GetExecutionLogDir())
+	}
+}
+
+func TestConfigAuthLockout(t *testing.T) {
+	cfg := Load()
+	if cfg.AuthLockoutThreshold != 5 {
+		t.Errorf("Expected default lockout threshold 5, got %d", cfg.AuthLockoutThreshold)
+	}
+	if cfg.GetAuthLockoutDuration() != 30*time.Second {
+		t.Errorf("Expected default lockout duration 30s, got %v", cfg.GetAuthLockoutDuration())
+	}
+	if cfg.GetAuthLockoutMaxDuration() != time.Hour {
+		t.Errorf("Expected default max lockout duration 1h, got %v", cfg.GetAuthLockoutMaxDuration())
+	}
+}
+
+func TestConfigIPAccessControl(t *testing.T) {
+	os.Setenv("IP_ALLOWLIST", "10.0.0.0/8, 192.0.2.10")
+	os.Setenv("WEBCLI_EXECUTION_IP_ALLOWLIST", "10.1.0.0/16")
+	defer func() {
+		os.Unsetenv("IP_ALLOWLIST")
+		os.Unsetenv("WEBCLI_EXECUTION_IP_ALLOWLIST")
+	}()
+
+	cfg := Load()
+
+	if len(cfg.IPAllowList) != 2 || cfg.IPAllowList[1] != "192.0.2.10" {
+		t.Errorf("Unexpected IP allowlist: %v", cfg.IPAllowList)
+	}
+	if len(cfg.IPDenyList) != 0 {
+		t.Errorf("Expected empty IP denylist by default, got %v", cfg.IPDenyList)
+	}
+	if len(cfg.ExecutionIPAllowList) != 1 || cfg.ExecutionIPAllowList[0] != "10.1.0.0/16" {
+		t.Errorf("Unexpected execution IP allowlist: %v", cfg.ExecutionIPAllowList)
+	}
+}
+
+func TestConfigApproval(t *testing.T) {
+	cfg := Load()
+	if cfg.GetApprovalTTL() != time.Hour {
+		t.Errorf("Expected default approval TTL 1h, got %v", cfg.GetApprovalTTL())
+	}
+	if cfg.ApproverRole != "approver" {
+		t.Errorf("Expected default approver role 'approver', got %q", cfg.ApproverRole)
+	}
+
+	os.Setenv("APPROVAL_TTL", "600")
+	defer os.Unsetenv("APPROVAL_TTL")
+
+	cfg = Load()
+	if cfg.GetApprovalTTL() != 10*time.Minute {
+		t.Errorf("Expected approval TTL 10m, got %v", cfg.GetApprovalTTL())
+	}
+}
+
+func TestConfigSudoPasswordTTL(t *testing.T) {
+	cfg := Load()
+	if cfg.GetSudoPasswordTTL() != 15*time.Minute {
+		t.Errorf("Expected default sudo password TTL 15m, got %v", cfg.GetSudoPasswordTTL())
+	}
+
+	os.Setenv("WEBCLI_SUDO_PASSWORD_TTL", "0")
+	defer os.Unsetenv("WEBCLI_SUDO_PASSWORD_TTL")
+
+	cfg = Load()
+	if cfg.GetSudoPasswordTTL() != 0 {
+		t.Errorf("Expected sudo password caching to be disabled, got %v", cfg.GetSudoPasswordTTL())
+	}
+}
+
+func TestConfigReadOnly(t *testing.T) {
+	cfg := Load()
+	if cfg.ReadOnly {
+		t.Error("Expected read-only mode to be off by default")
+	}
+
+	os.Setenv("WEBCLI_READ_ONLY", "true")
+	os.Setenv("READ_ONLY_REASON", "audit in progress")
+	defer func() {
+		os.Unsetenv("WEBCLI_READ_ONLY")
+		os.Unsetenv("READ_ONLY_REASON")
+	}()
+
+	cfg = Load()
+	if !cfg.ReadOnly || cfg.ReadOnlyReason != "audit in progress" {
+		t.Errorf("Expected read-only mode from environment, got %v %q", cfg.ReadOnly, cfg.ReadOnlyReason)
+	}
+}
+
+func TestConfigAuditSinks(t *testing.T) {
+	cfg := Load()
+	if cfg.AuditSyslog != "" || cfg.AuditJournald {
+		t.Error("Expected syslog and journald audit sinks to be off by default")
+	}
+	if cfg.AuditSyslogFacility != "auth" {
+		t.Errorf("Expected default syslog facility auth, got %q", cfg.AuditSyslogFacility)
+	}
+
+	os.Setenv("WEBCLI_AUDIT_SYSLOG", "udp://logs.example.com:514")
+	os.Setenv("AUDIT_SYSLOG_FACILITY", "local4")
+	os.Setenv("AUDIT_JOURNALD", "true")
+	defer func() {
+		os.Unsetenv("WEBCLI_AUDIT_SYSLOG")
+		os.Unsetenv("AUDIT_SYSLOG_FACILITY")
+		os.Unsetenv("AUDIT_JOURNALD")
+	}()
+
+	cfg = Load()
+	if cfg.AuditSyslog != "udp://logs.example.com:514" || cfg.AuditSyslogFacility != "local4" || !cfg.AuditJournald {
+		t.Errorf("Expected audit sinks from environment, got %q %q %v", cfg.AuditSyslog, cfg.AuditSyslogFacility, cfg.AuditJournald)
+	}
+}
+
+func TestConfigAuditWebhook(t *testing.T) {
+	cfg := Load()
+	if cfg.AuditWebhookURL != "" {
+		t.Error("Expected audit webhook to be off by default")
+	}
+	if cfg.AuditWebhookBatchSize != 100 || cfg.AuditWebhookMaxRetries != 5 || cfg.GetAuditWebhookFlushInterval() != 5*time.Second {
+		t.Errorf("Unexpected audit webhook defaults: %+v", cfg)
+	}
+
+	os.Setenv("WEBCLI_AUDIT_WEBHOOK_URL", "https://siem.example.com/ingest")
+	os.Setenv("AUDIT_WEBHOOK_SECRET", "hmac-key")
+	os.Setenv("AUDIT_WEBHOOK_FLUSH_INTERVAL", "30")
+	defer func() {
+		os.Unsetenv("WEBCLI_AUDIT_WEBHOOK_URL")
+		os.Unsetenv("AUDIT_WEBHOOK_SECRET")
+		os.Unsetenv("AUDIT_WEBHOOK_FLUSH_INTERVAL")
+	}()
+
+	cfg = Load()
+	if cfg.AuditWebhookURL != "https://siem.example.com/ingest" || cfg.AuditWebhookSecret != "hmac-key" {
+		t.Errorf("Expected audit webhook from environment, got %q %q", cfg.AuditWebhookURL, cfg.AuditWebhookSecret)
+	}
+	if cfg.GetAuditWebhookFlushInterval() != 30*time.Second {
+		t.Errorf("Expected 30s flush interval, got %v", cfg.GetAuditWebhookFlushInterval())
+	}
+}
+
+func TestConfigTerminalRecording(t *testing.T) {
+	cfg := Load()
+	if cfg.TerminalRecordingDir != "" || cfg.TerminalRecordInput {
+		t.Error("Expected terminal recording to be off by default")
+	}
+	if cfg.GetTerminalRecordingRetention() != 90*24*time.Hour {
+		t.Errorf("Expected 90 day retention by default, got %v", cfg.GetTerminalRecordingRetention())
+	}
+
+	os.Setenv("WEBCLI_TERMINAL_RECORDING_DIR", "/var/lib/web-cli/recordings")
+	os.Setenv("TERMINAL_RECORDING_RETENTION", "0")
+	defer func() {
+		os.Unsetenv("WEBCLI_TERMINAL_RECORDING_DIR")
+		os.Unsetenv("TERMINAL_RECORDING_RETENTION")
+	}()
+
+	cfg = Load()
+	if cfg.TerminalRecordingDir != "/var/lib/web-cli/recordings" {
+		t.Errorf("Expected recording directory from environment, got %q", cfg.TerminalRecordingDir)
+	}
+	if cfg.GetTerminalRecordingRetention() != 0 {
+		t.Errorf("Expected recordings kept forever, got %v", cfg.GetTerminalRecordingRetention())
+	}
+}
+
+func TestConfigTerminalSessionLimits(t *testing.T) {
+	cfg := Load()
+	if cfg.TerminalMaxSessions != 50 || cfg.TerminalMaxSessionsPerUser != 5 {
+		t.Errorf("Expected default limits 50/5, got %d/%d", cfg.TerminalMaxSessions, cfg.TerminalMaxSessionsPerUser)
+	}
+
+	os.Setenv("WEBCLI_TERMINAL_MAX_SESSIONS", "0")
+	os.Setenv("TERMINAL_MAX_SESSIONS_PER_USER", "2")
+	defer func() {
+		os.Unsetenv("WEBCLI_TERMINAL_MAX_SESSIONS")
+		os.Unsetenv("TERMINAL_MAX_SESSIONS_PER_USER")
+	}()
+
+	cfg = Load()
+	if cfg.TerminalMaxSessions != 0 {
+		t.Errorf("Expected no global limit, got %d", cfg.TerminalMaxSessions)
+	}
+	if cfg.TerminalMaxSessionsPerUser != 2 {
+		t.Errorf("Expected per-user limit 2, got %d", cfg.TerminalMaxSessionsPerUser)
+	}
+}
+
+func TestConfigTerminalDetach(t *testing.T) {
+	cfg := Load()
+	if cfg.GetTerminalDetachGrace() != time.Minute {
+		t.Errorf("Expected 1m detach grace by default, got %v", cfg.GetTerminalDetachGrace())
+	}
+	if cfg.TerminalScrollback != 65536 {
+		t.Errorf("Expected 64 KiB scrollback by default, got %d", cfg.TerminalScrollback)
+	}
+
+	os.Setenv("WEBCLI_TERMINAL_DETACH_GRACE", "0")
+	defer os.Unsetenv("WEBCLI_TERMINAL_DETACH_GRACE")
+
+	cfg = Load()
+	if cfg.GetTerminalDetachGrace() != 0 {
+		t.Errorf("Expected sessions to end on disconnect, got %v", cfg.GetTerminalDetachGrace())
+	}
+}
+
+func TestConfigTerminalFileMaxSize(t *testing.T) {
+	cfg := Load()
+	if cfg.GetTerminalFileMaxSize() != 100<<20 {
+		t.Errorf("Expected 100 MB upload limit by default, got %d", cfg.GetTerminalFileMaxSize())
+	}
+
+	os.Setenv("WEBCLI_TERMINAL_FILE_MAX_SIZE", "5")
+	defer os.Unsetenv("WEBCLI_TERMINAL_FILE_MAX_SIZE")
+
+	cfg = Load()
+	if cfg.GetTerminalFileMaxSize() != 5<<20 {
+		t.Errorf("Expected 5 MB upload limit, got %d", cfg.GetTerminalFileMaxSize())
+	}
+}
+
+func TestConfigTerminalKeystrokeLog(t *testing.T) {
+	cfg := Load()
+	if cfg.TerminalKeystrokeLog {
+		t.Error("Expected keystroke logging to be disabled by default")
+	}
+
+	os.Setenv("TERMINAL_KEYSTROKE_LOG", "true")
+	defer os.Unsetenv("TERMINAL_KEYSTROKE_LOG")
+
+	cfg = Load()
+	if !cfg.TerminalKeystrokeLog {
+		t.Error("Expected keystroke logging to be enabled")
+	}
+}
+
+func TestConfigTerminalShells(t *testing.T) {
+	cfg := Load()
+	if len(cfg.TerminalShells) != 0 || cfg.TerminalShellRC != "" {
+		t.Errorf("Expected built-in shell catalog by default, got %v", cfg.TerminalShells)
+	}
+	if cfg.GetTerminalUserShell("user:alice") != "bash" {
+		t.Errorf("Expected bash as default shell, got %s", cfg.GetTerminalUserShell("user:alice"))
+	}
+
+	os.Setenv("TERMINAL_SHELLS", "bash=/bin/bash, ash=/bin/busybox ash")
+	os.Setenv("TERMINAL_DEFAULT_SHELL", "ash")
+	os.Setenv("TERMINAL_USER_SHELLS", "user:alice=bash")
+	defer os.Unsetenv("TERMINAL_SHELLS")
+	defer os.Unsetenv("TERMINAL_DEFAULT_SHELL")
+	defer os.Unsetenv("TERMINAL_USER_SHELLS")
+
+	cfg = Load()
+	if len(cfg.TerminalShells) != 2 || cfg.TerminalShells[1] != "ash=/bin/busybox ash" {
+		t.Errorf("Unexpected shell catalog %v", cfg.TerminalShells)
+	}
+	if cfg.GetTerminalUserShell("user:alice") != "bash" {
+		t.Errorf("Expected bash for alice, got %s", cfg.GetTerminalUserShell("user:alice"))
+	}
+	if cfg.GetTerminalUserShell("user:bob") != "ash" {
+		t.Errorf("Expected ash for bob, got %s", cfg.GetTerminalUserShell("user:bob"))
+	}
+}
+
+func TestConfigLocalExecution(t *testing.T) {
+	cfg := Load()
+	if cfg.LocalShell != "" || cfg.LocalElevation != "auto" || cfg.LocalUsersOnly {
+		t.Errorf("Expected detected shell and elevation by default, got %q %q %v", cfg.LocalShell, cfg.LocalElevation, cfg.LocalUsersOnly)
+	}
+	if cfg.LocalUserMinUID != 1000 || cfg.LocalUserMaxUID != 60000 {
+		t.Errorf("Expected UIDs 1000-60000 by default, got %d-%d", cfg.LocalUserMinUID, cfg.LocalUserMaxUID)
+	}
+
+	os.Setenv("WEBCLI_LOCAL_SHELL", "sh")
+	os.Setenv("LOCAL_ELEVATION", "doas")
+	os.Setenv("LOCAL_USERS_ONLY", "true")
+	defer os.Unsetenv("WEBCLI_LOCAL_SHELL")
+	defer os.Unsetenv("LOCAL_ELEVATION")
+	defer os.Unsetenv("LOCAL_USERS_ONLY")
+	os.Setenv("LOCAL_USER_MIN_UID", "500")
+	os.Setenv("WEBCLI_LOCAL_USER_MAX_UID", "999")
+	defer os.Unsetenv("LOCAL_USER_MIN_UID")
+	defer os.Unsetenv("WEBCLI_LOCAL_USER_MAX_UID")
+
+	cfg = Load()
+	if cfg.LocalShell != "sh" || cfg.LocalElevation != "doas" || !cfg.LocalUsersOnly {
+		t.Errorf("Unexpected local execution config %q %q %v", cfg.LocalShell, cfg.LocalElevation, cfg.LocalUsersOnly)
+	}
+	if cfg.LocalUserMinUID != 500 || cfg.LocalUserMaxUID != 999 {
+		t.Errorf("Expected UIDs 500-999, got %d-%d", cfg.LocalUserMinUID, cfg.LocalUserMaxUID)
+	}
+}
+
+func TestConfigGitSync(t *testing.T) {
+	cfg := Load()
+	if cfg.GitSyncURL != "" || cfg.GitSyncBranch != "main" || cfg.GitSyncGroup != "git" {
+		t.Errorf("Unexpected git sync defaults %q %q %q", cfg.GitSyncURL, cfg.GitSyncBranch, cfg.GitSyncGroup)
+	}
+	if cfg.GetGitSyncInterval() != 5*time.Minute {
+		t.Errorf("Expected 5m sync interval, got %v", cfg.GetGitSyncInterval())
+	}
+	if cfg.GetGitSyncDir() != filepath.Join(filepath.Dir(cfg.DatabasePath), "git-sync") {
+		t.Errorf("Expected working copy next to the database, got %s", cfg.GetGitSyncDir())
+	}
+
+	os.Setenv("GIT_SYNC_URL", "https://git.example.com/ops/scripts.git")
+	os.Setenv("WEBCLI_GIT_SYNC_BRANCH", "release")
+	os.Setenv("GIT_SYNC_INTERVAL", "60")
+	os.Setenv("GIT_SYNC_DIR", "/var/lib/web-cli/scripts")
+	defer os.Unsetenv("GIT_SYNC_URL")
+	defer os.Unsetenv("WEBCLI_GIT_SYNC_BRANCH")
+	defer os.Unsetenv("GIT_SYNC_INTERVAL")
+	defer os.Unsetenv("GIT_SYNC_DIR")
+
+	cfg = Load()
+	if cfg.GitSyncURL != "https://git.example.com/ops/scripts.git" || cfg.GitSyncBranch != "release" {
+		t.Errorf("Unexpected git sync config %q %q", cfg.GitSyncURL, cfg.GitSyncBranch)
+	}
+	if cfg.GetGitSyncInterval() != time.Minute {
+		t.Errorf("Expected 1m sync interval, got %v", cfg.GetGitSyncInterval())
+	}
+	if cfg.GetGitSyncDir() != "/var/lib/web-cli/scripts" {
+		t.Errorf("Unexpected working copy %s", cfg.GetGitSyncDir())
+	}
+}
+
+func TestConfigInventory(t *testing.T) {
+	cfg := Load()
+	if len(cfg.InventoryAWSRegions) != 0 || cfg.InventoryDigitalOceanToken != "" || cfg.InventoryHetznerToken != "" {
+		t.Errorf("Expected no inventory providers by default, got %v %q %q", cfg.InventoryAWSRegions, cfg.InventoryDigitalOceanToken, cfg.InventoryHetznerToken)
+	}
+	if cfg.InventoryGroupTag != "group" || cfg.InventoryPrivateIP {
+		t.Errorf("Unexpected inventory defaults %q %v", cfg.InventoryGroupTag, cfg.InventoryPrivateIP)
+	}
+	if cfg.GetInventoryInterval() != 10*time.Minute {
+		t.Errorf("Expected 10m inventory interval, got %v", cfg.GetInventoryInterval())
+	}
+
+	os.Setenv("INVENTORY_AWS_REGIONS", "eu-west-1, us-east-1")
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	os.Setenv("WEBCLI_INVENTORY_HETZNER_TOKEN", "hcloud-token")
+	os.Setenv("INVENTORY_INTERVAL", "120")
+	defer os.Unsetenv("INVENTORY_AWS_REGIONS")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("WEBCLI_INVENTORY_HETZNER_TOKEN")
+	defer os.Unsetenv("INVENTORY_INTERVAL")
+
+	cfg = Load()
+	if len(cfg.InventoryAWSRegions) != 2 || cfg.InventoryAWSRegions[1] != "us-east-1" {
+		t.Errorf("Unexpected AWS regions %v", cfg.InventoryAWSRegions)
+	}
+	if cfg.InventoryAWSAccessKeyID != "AKIDEXAMPLE" || cfg.InventoryHetznerToken != "hcloud-token" {
+		t.Errorf("Unexpected inventory credentials %q %q", cfg.InventoryAWSAccessKeyID, cfg.InventoryHetznerToken)
+	}
+	if cfg.GetInventoryInterval() != 2*time.Minute {
+		t.Errorf("Expected 2m inventory interval, got %v", cfg.GetInventoryInterval())
+	}
+}
+
+func TestConfigKubernetes(t *testing.T) {
+	cfg := Load()
+	if cfg.KubernetesKubeconfig != "" || cfg.KubernetesContext != "" || cfg.KubernetesInCluster {
+		t.Errorf("Expected Kubernetes disabled by default, got %q %q %v", cfg.KubernetesKubeconfig, cfg.KubernetesContext, cfg.KubernetesInCluster)
+	}
+
+	os.Setenv("KUBERNETES_KUBECONFIG", "/etc/web-cli/kubeconfig")
+	os.Setenv("WEBCLI_KUBERNETES_CONTEXT", "production")
+	os.Setenv("KUBERNETES_IN_CLUSTER", "true")
+	defer os.Unsetenv("KUBERNETES_KUBECONFIG")
+	defer os.Unsetenv("WEBCLI_KUBERNETES_CONTEXT")
+	defer os.Unsetenv("KUBERNETES_IN_CLUSTER")
+
+	cfg = Load()
+	if cfg.KubernetesKubeconfig != "/etc/web-cli/kubeconfig" || cfg.KubernetesContext != "production" || !cfg.KubernetesInCluster {
+		t.Errorf("Unexpected Kubernetes configuration %q %q %v", cfg.KubernetesKubeconfig, cfg.KubernetesContext, cfg.KubernetesInCluster)
+	}
+}
+
+func TestConfigSlack(t *testing.T) {
+	cfg := Load()
+	if cfg.SlackWebhookURL != "" || cfg.SlackChannel != "" || cfg.SlackUsername != "web-cli" {
+		t.Errorf("Unexpected Slack defaults %q %q %q", cfg.SlackWebhookURL, cfg.SlackChannel, cfg.SlackUsername)
+	}
+
+	os.Setenv("SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/T0/B0/x")
+	os.Setenv("WEBCLI_SLACK_CHANNEL", "#ops")
+	defer os.Unsetenv("SLACK_WEBHOOK_URL")
+	defer os.Unsetenv("WEBCLI_SLACK_CHANNEL")
+
+	cfg = Load()
+	if cfg.SlackWebhookURL != "https://hooks.slack.com/services/T0/B0/x" || cfg.SlackChannel != "#ops" {
+		t.Errorf("Unexpected Slack config %q %q", cfg.SlackWebhookURL, cfg.SlackChannel)
+	}
+}
+
+func TestConfigEmail(t *testing.T) {
+	cfg := Load()
+	if cfg.SMTPHost != "" || cfg.SMTPTLS != "starttls" || len(cfg.EmailEvents) != 1 || cfg.EmailEvents[0] != "*.failed" {
+		t.Errorf("Unexpected email defaults %q %q %v", cfg.SMTPHost, cfg.SMTPTLS, cfg.EmailEvents)
+	}
+
+	os.Setenv("SMTP_HOST", "smtp.example.com")
+	os.Setenv("WEBCLI_SMTP_PORT", "465")
+	os.Setenv("EMAIL_TO", "ops@example.com, oncall@example.com")
+	os.Setenv("EMAIL_EVENTS", "script.*")
+	defer os.Unsetenv("SMTP_HOST")
+	defer os.Unsetenv("WEBCLI_SMTP_PORT")
+	defer os.Unsetenv("EMAIL_TO")
+	defer os.Unsetenv("EMAIL_EVENTS")
+
+	cfg = Load()
+	if cfg.SMTPHost != "smtp.example.com" || cfg.SMTPPort != 465 {
+		t.Errorf("Unexpected SMTP server %q:%d", cfg.SMTPHost, cfg.SMTPPort)
+	}
+	if len(cfg.EmailTo) != 2 || cfg.EmailTo[1] != "oncall@example.com" || cfg.EmailEvents[0] != "script.*" {
+		t.Errorf("Unexpected recipients %v or events %v", cfg.EmailTo, cfg.EmailEvents)
+	}
+}
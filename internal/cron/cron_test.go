@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@every 5m",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected an error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2025, time.March, 14, 10, 7, 30, 0, time.UTC) // A Friday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.March, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.March, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 9-17 * * *", time.Date(2025, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, time.March, 15, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon-fri", time.Date(2025, time.March, 17, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2025, time.March, 16, 8, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13,20 * 1", time.Date(2025, time.March, 17, 12, 0, 0, 0, time.UTC)}, // Either day field matches
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	s, err := Parse("5,35 */6 * * *")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !s.Matches(time.Date(2025, time.March, 14, 12, 35, 59, 0, time.UTC)) {
+		t.Error("Expected 12:35 to match")
+	}
+	if s.Matches(time.Date(2025, time.March, 14, 13, 35, 0, 0, time.UTC)) {
+		t.Error("Expected 13:35 not to match")
+	}
+}
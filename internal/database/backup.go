@@ -0,0 +1,340 @@
+package database
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// MinBackupPassphraseLength is the shortest passphrase accepted for encrypting a backup
+	MinBackupPassphraseLength = 12
+
+	// backupMagic starts encrypted backups; plain backups start with "SQLite format 3"
+	backupMagic         = "web-cli-backup1\n"
+	backupKDFIterations = 600000 // PBKDF2-SHA256 rounds (OWASP recommendation)
+	backupSaltSize      = 16
+)
+
+// Backup writes a consistent snapshot of the database to destPath
+// Uses VACUUM INTO so the copy is safe while the database is in use
+func (db *DB) Backup(destPath string) error {
+	if fileExists(destPath) {
+		return fmt.Errorf("backup destination already exists: %s", destPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	if _, err := db.GetConnection().Exec("VACUUM INTO ?", destPath); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	if err := os.Chmod(destPath, 0600); err != nil {
+		return fmt.Errorf("failed to set backup permissions: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyBackup checks that backupPath is an intact web-cli database
+func VerifyBackup(backupPath string) error {
+	if !fileExists(backupPath) {
+		return fmt.Errorf("backup file not found: %s", backupPath)
+	}
+
+	conn, err := sql.Open("sqlite", "file:"+backupPath+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check backup integrity: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup integrity check failed: %s", result)
+	}
+
+	var version int
+	if err := conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return fmt.Errorf("backup is not a web-cli database: %w", err)
+	}
+	if version > LatestVersion() {
+		return fmt.Errorf("backup schema version %d is newer than this build supports (%d)", version, LatestVersion())
+	}
+
+	return nil
+}
+
+// Restore replaces the database at dbPath with the backup at backupPath
+// The server must not be running against dbPath while restoring
+func Restore(backupPath, dbPath string) error {
+	if err := VerifyBackup(backupPath); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer src.Close()
+
+	// Copy to a temporary file next to the target, then rename for an atomic swap
+	tmp, err := os.CreateTemp(filepath.Dir(dbPath), ".restore-*.db")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync restored database: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close restored database: %w", err)
+	}
+
+	// Remove stale journal files belonging to the old database
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(dbPath + suffix)
+	}
+
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+
+	return nil
+}
+
+// WriteBackup writes a consistent snapshot of the database to w, encrypted with
+// passphrase unless it is empty
+func (db *DB) WriteBackup(w io.Writer, passphrase string) error {
+	if passphrase != "" && len(passphrase) < MinBackupPassphraseLength {
+		return fmt.Errorf("passphrase must be at least %d characters", MinBackupPassphraseLength)
+	}
+
+	// VACUUM INTO needs a file; it is kept next to the database, which already holds the same data
+	dir, err := os.MkdirTemp(filepath.Dir(db.path), ".backup-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	snapshot := filepath.Join(dir, "web-cli.db")
+	if err := db.Backup(snapshot); err != nil {
+		return err
+	}
+
+	if passphrase == "" {
+		f, err := os.Open(snapshot)
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	}
+
+	data, err := os.ReadFile(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	sealed, err := EncryptBackup(data, passphrase)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(sealed)
+	return err
+}
+
+// EncryptBackup encrypts a backup with AES-256-GCM and a key derived from the passphrase
+func EncryptBackup(data []byte, passphrase string) ([]byte, error) {
+	if len(passphrase) < MinBackupPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinBackupPassphraseLength)
+	}
+
+	salt := make([]byte, backupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := backupGCM(passphrase, salt, backupKDFIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// Magic, iteration count, salt and nonce, then the sealed backup
+	out := make([]byte, 0, len(backupMagic)+4+len(salt)+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, backupMagic...)
+	out = binary.BigEndian.AppendUint32(out, backupKDFIterations)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, []byte(backupMagic)), nil
+}
+
+// IsEncryptedBackup reports whether data is a backup written by EncryptBackup
+func IsEncryptedBackup(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(backupMagic))
+}
+
+// DecryptBackup decrypts a backup written by EncryptBackup
+func DecryptBackup(data []byte, passphrase string) ([]byte, error) {
+	if !IsEncryptedBackup(data) {
+		return nil, fmt.Errorf("not an encrypted web-cli backup")
+	}
+	data = data[len(backupMagic):]
+	if len(data) < 4+backupSaltSize {
+		return nil, fmt.Errorf("encrypted backup is truncated")
+	}
+	iterations := binary.BigEndian.Uint32(data)
+	if iterations == 0 {
+		return nil, fmt.Errorf("encrypted backup has an invalid header")
+	}
+	salt, data := data[4:4+backupSaltSize], data[4+backupSaltSize:]
+
+	gcm, err := backupGCM(passphrase, salt, int(iterations))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted backup is truncated")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, []byte(backupMagic))
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted backup")
+	}
+	return plaintext, nil
+}
+
+// backupGCM derives an AES-256 key from the passphrase and returns its GCM cipher
+func backupGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// StageBackup writes the backup read from r to a temporary file in dir,
+// decrypting it with passphrase if it is encrypted, and verifies it
+// The caller removes the returned file
+func StageBackup(r io.Reader, dir, passphrase string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read backup: %w", err)
+	}
+	if IsEncryptedBackup(data) {
+		if passphrase == "" {
+			return "", fmt.Errorf("backup is encrypted: a passphrase is required")
+		}
+		if data, err = DecryptBackup(data, passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, ".staged-*.db")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	if err := VerifyBackup(path); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// RestoreLive replaces the open database with the backup at backupPath (see
+// StageBackup) and applies migrations the backup lacks
+// Queries after it returns see the restored data; if the backup cannot be
+// opened, the database is put back as it was
+func (db *DB) RestoreLive(backupPath string) error {
+	if err := VerifyBackup(backupPath); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Kept until the restored database is open, to put back on failure
+	previous := filepath.Join(filepath.Dir(db.path), fmt.Sprintf(".pre-restore-%d.db", os.Getpid()))
+	os.Remove(previous)
+	if _, err := db.conn.Exec("VACUUM INTO ?", previous); err != nil {
+		return fmt.Errorf("failed to save the current database: %w", err)
+	}
+	defer os.Remove(previous)
+
+	if err := db.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+
+	restoreErr := db.swapIn(backupPath)
+	if restoreErr == nil {
+		return nil
+	}
+
+	log.Printf("Restore failed, putting the previous database back: %v", restoreErr)
+	if err := db.swapIn(previous); err != nil {
+		return fmt.Errorf("%w; the previous database could not be put back either: %v", restoreErr, err)
+	}
+	return restoreErr
+}
+
+// swapIn replaces the database file with the one at path and reopens it, with mu held
+func (db *DB) swapIn(path string) error {
+	if err := Restore(path, db.path); err != nil {
+		return err
+	}
+	conn, err := openConn(db.path)
+	if err != nil {
+		return err
+	}
+	db.conn = conn
+	if err := db.runMigrations(); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
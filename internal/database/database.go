@@ -1,17 +1,20 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	_ "modernc.org/sqlite"
 )
 
 // DB wraps the database connection
 type DB struct {
+	mu   sync.RWMutex // Guards conn, which RestoreLive replaces
 	conn *sql.DB
 	path string
 }
@@ -19,6 +22,23 @@ type DB struct {
 // New creates a new database connection and initializes the database
 // If the database file doesn't exist, it will be created
 func New(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations
+	if err := db.runMigrations(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open creates a database connection without applying migrations, for
+// inspecting or changing the schema version with MigrateTo and Force
+func Open(dbPath string) (*DB, error) {
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -28,22 +48,9 @@ func New(dbPath string) (*DB, error) {
 	// Check if database is being created for the first time
 	isNewDB := !fileExists(dbPath)
 
-	// Open database connection
-	conn, err := sql.Open("sqlite", dbPath)
+	conn, err := openConn(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	// Test connection
-	if err := conn.Ping(); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	// Enable foreign keys
-	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+		return nil, err
 	}
 
 	db := &DB{
@@ -57,25 +64,52 @@ func New(dbPath string) (*DB, error) {
 		log.Printf("Using existing database at %s", dbPath)
 	}
 
-	// Run migrations
-	if err := db.runMigrations(); err != nil {
+	return db, nil
+}
+
+// openConn opens and checks a connection to the SQLite file at dbPath
+func openConn(dbPath string) (*sql.DB, error) {
+	// Writers wait for each other instead of failing with SQLITE_BUSY, as background
+	// tasks such as webhook deliveries write while requests are served
+	conn, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Test connection
+	if err := conn.Ping(); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return db, nil
+	// Enable foreign keys
+	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+
+	return conn, nil
 }
 
 // Close closes the database connection
 func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 	return db.conn.Close()
 }
 
 // GetConnection returns the underlying database connection
 func (db *DB) GetConnection() *sql.DB {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	return db.conn
 }
 
+// Ping verifies the database connection is alive
+func (db *DB) Ping(ctx context.Context) error {
+	return db.GetConnection().PingContext(ctx)
+}
+
 // fileExists checks if a file exists
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
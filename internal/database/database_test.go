@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -70,8 +72,8 @@ func TestDatabaseCreation(t *testing.T) {
 		t.Fatalf("Failed to get version: %v", err)
 	}
 
-	if version != 16 {
-		t.Errorf("Expected schema version 16, got %d", version)
+	if version != 49 {
+		t.Errorf("Expected schema version 49, got %d", version)
 	}
 
 	// Verify all tables exist
@@ -85,6 +87,10 @@ func TestDatabaseCreation(t *testing.T) {
 		"env_variables",
 		"bash_scripts",
 		"vault_config",
+		"api_tokens",
+		"group_permissions",
+		"command_policies",
+		"approvals",
 	}
 
 	for _, table := range tables {
@@ -198,3 +204,184 @@ func TestDatabasePersistence(t *testing.T) {
 		t.Errorf("Version mismatch: first=%d, second=%d", version1, version2)
 	}
 }
+
+func TestMigrateToAndRollback(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	tableExists := func(table string) bool {
+		var name string
+		return db.conn.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&name) == nil
+	}
+
+	// Every migration can be rolled back and applied again
+	if err := db.MigrateTo(1); err != nil {
+		t.Fatalf("MigrateTo(1) failed: %v", err)
+	}
+	if version, _ := db.GetVersion(); version != 1 {
+		t.Errorf("Expected version 1 after rolling back, got %d", version)
+	}
+	if tableExists("ssh_keys") {
+		t.Error("Expected ssh_keys to be dropped")
+	}
+	if err := db.MigrateTo(LatestVersion()); err != nil {
+		t.Fatalf("MigrateTo(latest) failed: %v", err)
+	}
+
+	if err := db.MigrateTo(23); err != nil {
+		t.Fatalf("MigrateTo(23) failed: %v", err)
+	}
+	if version, _ := db.GetVersion(); version != 23 {
+		t.Errorf("Expected version 23, got %d", version)
+	}
+	if tableExists("bash_script_versions") || !tableExists("terminal_keystroke_logs") {
+		t.Error("Expected only migrations after 23 to be rolled back")
+	}
+
+	statuses, err := db.Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() failed: %v", err)
+	}
+	if len(statuses) != LatestVersion() || !statuses[22].Applied || statuses[23].Applied || statuses[23].AppliedAt != nil {
+		t.Errorf("Unexpected migration status: %+v", statuses[22:24])
+	}
+
+	if err := db.MigrateTo(LatestVersion() + 1); err == nil {
+		t.Error("Expected error for an unknown version")
+	}
+	db.Close()
+
+	// A migration that did not finish blocks startup until forced
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := db.conn.Exec("INSERT INTO schema_migrations (version, description, applied_at, dirty) VALUES (24, 'test', datetime('now'), 1)"); err != nil {
+		t.Fatalf("Failed to mark migration dirty: %v", err)
+	}
+	db.Close()
+
+	var dirty *DirtyError
+	if _, err := New(dbPath); !errors.As(err, &dirty) || dirty.Version != 24 {
+		t.Fatalf("Expected a dirty error for migration 24, got %v", err)
+	}
+
+	db, _ = Open(dbPath)
+	if err := db.Force(23); err != nil {
+		t.Fatalf("Force(23) failed: %v", err)
+	}
+	db.Close()
+
+	db, err = New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database after forcing: %v", err)
+	}
+	defer db.Close()
+	if version, _ := db.GetVersion(); version != LatestVersion() {
+		t.Errorf("Expected version %d, got %d", LatestVersion(), version)
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+	backupPath := filepath.Join(tmpDir, "backups", "backup.db")
+	restorePath := filepath.Join(tmpDir, "restored", "web-cli.db")
+
+	if err := InitializeEncryption(filepath.Join(tmpDir, ".encryption_key")); err != nil {
+		t.Fatalf("Failed to initialize encryption: %v", err)
+	}
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	if _, err := db.GetConnection().Exec("INSERT INTO local_users (name, created_at, updated_at) VALUES ('alice', datetime('now'), datetime('now'))"); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	if err := db.Backup(backupPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	db.Close()
+
+	// Refuse to overwrite an existing backup
+	db, _ = New(dbPath)
+	if err := db.Backup(backupPath); err == nil {
+		t.Error("Expected error when backup destination exists")
+	}
+	db.Close()
+
+	if err := Restore(backupPath, restorePath); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	restored, err := New(restorePath)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer restored.Close()
+
+	var name string
+	if err := restored.GetConnection().QueryRow("SELECT name FROM local_users").Scan(&name); err != nil {
+		t.Fatalf("Failed to read restored data: %v", err)
+	}
+	if name != "alice" {
+		t.Errorf("Expected restored user 'alice', got %q", name)
+	}
+}
+
+func TestRestoreRejectsInvalidBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	bogus := filepath.Join(tmpDir, "bogus.db")
+	if err := os.WriteFile(bogus, []byte("not a database"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := Restore(bogus, filepath.Join(tmpDir, "web-cli.db")); err == nil {
+		t.Error("Expected error restoring an invalid backup")
+	}
+
+	if err := Restore(filepath.Join(tmpDir, "missing.db"), filepath.Join(tmpDir, "web-cli.db")); err == nil {
+		t.Error("Expected error restoring a missing backup")
+	}
+}
+
+func TestMaintain(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "web-cli.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// Leave free pages behind for VACUUM to reclaim
+	conn := db.GetConnection()
+	if _, err := conn.Exec("CREATE TABLE filler (data BLOB)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if _, err := conn.Exec("INSERT INTO filler VALUES (zeroblob(16384))"); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+	if _, err := conn.Exec("DELETE FROM filler"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	result, err := db.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("Maintain() error = %v", err)
+	}
+	if result.Integrity != "ok" || !result.Vacuumed {
+		t.Errorf("Maintain() = %+v, want a sound, vacuumed database", result)
+	}
+	if result.SizeAfter >= result.SizeBefore {
+		t.Errorf("Size after VACUUM = %d, want less than %d", result.SizeAfter, result.SizeBefore)
+	}
+}
@@ -18,6 +18,24 @@ import (
 
 var encryptionKey []byte
 
+// Encryptor encrypts stored secrets with keys held outside web-cli, such as Vault's transit engine
+// Batch methods let callers encrypt or decrypt many values in one round trip
+type Encryptor interface {
+	EncryptBatch(plaintexts []string) ([][]byte, error)
+	DecryptBatch(ciphertexts [][]byte) ([]string, error)
+	// Handles reports whether ciphertext was produced by this encryptor
+	Handles(ciphertext []byte) bool
+}
+
+// encryptor replaces the local key for new values when set; values encrypted
+// with the local key stay readable while the key is available
+var encryptor Encryptor
+
+// SetEncryptor routes encryption through e instead of the local key
+func SetEncryptor(e Encryptor) {
+	encryptor = e
+}
+
 // checkEntropyAvailable verifies sufficient system entropy before key generation
 // On Linux, checks /proc/sys/kernel/random/entropy_avail
 // Returns error if entropy is critically low (< 128 bits)
@@ -49,52 +67,204 @@ func checkEntropyAvailable() error {
 	return nil
 }
 
-// InitializeEncryption initializes the encryption key
-// If ENCRYPTION_KEY environment variable is set, it uses that
-// Otherwise, it generates a random key and stores it in .encryption_key file
-func InitializeEncryption(keyPath string) error {
-	// Try to get key from environment
+// LoadEncryptionKey loads an existing key from ENCRYPTION_KEY or keyPath without generating one
+// It is used with an Encryptor to keep values encrypted with the local key readable
+func LoadEncryptionKey(keyPath string) bool {
 	if envKey := os.Getenv("ENCRYPTION_KEY"); envKey != "" {
 		decoded, err := base64.StdEncoding.DecodeString(envKey)
 		if err == nil && len(decoded) == 32 {
 			encryptionKey = decoded
-			return nil
+			return true
 		}
 	}
-
-	// Try to load from file
 	if data, err := os.ReadFile(keyPath); err == nil {
 		decoded, err := base64.StdEncoding.DecodeString(string(data))
 		if err == nil && len(decoded) == 32 {
 			encryptionKey = decoded
-			return nil
+			return true
 		}
 	}
+	return false
+}
 
-	// Check system entropy before generating new key
-	if err := checkEntropyAvailable(); err != nil {
-		return fmt.Errorf("entropy check failed: %w", err)
+// InitializeEncryption initializes the encryption key
+// If ENCRYPTION_KEY environment variable is set, it uses that
+// Otherwise, it generates a random key and stores it in .encryption_key file
+func InitializeEncryption(keyPath string) error {
+	// Try to get key from environment, then from file
+	if LoadEncryptionKey(keyPath) {
+		return nil
 	}
 
 	// Generate new key
 	log.Println("Generating new encryption key...")
-	key := make([]byte, 32) // AES-256
-	if _, err := rand.Read(key); err != nil {
-		return fmt.Errorf("failed to generate encryption key: %w", err)
+	encoded, err := GenerateEncryptionKey()
+	if err != nil {
+		return err
 	}
 
 	// Save key to file
-	encoded := base64.StdEncoding.EncodeToString(key)
 	if err := os.WriteFile(keyPath, []byte(encoded), 0600); err != nil {
 		return fmt.Errorf("failed to save encryption key: %w", err)
 	}
 
+	key, _ := base64.StdEncoding.DecodeString(encoded)
 	encryptionKey = key
 	return nil
 }
 
-// Encrypt encrypts data using AES-256-GCM
+// KeyWrapper keeps the local key encrypted at rest by a key held elsewhere, such as a cloud KMS,
+// so the key file alone cannot decrypt anything
+type KeyWrapper interface {
+	// WrapKey encrypts key and writes the result to path
+	WrapKey(key []byte, path string) error
+	// UnwrapKey decrypts the key stored at path
+	UnwrapKey(path string) ([]byte, error)
+}
+
+// InitializeWrappedEncryption loads the local key by unwrapping the file at keyPath with w
+// If the file does not exist, a new key is generated and stored wrapped
+func InitializeWrappedEncryption(keyPath string, w KeyWrapper) error {
+	if _, err := os.Stat(keyPath); err == nil {
+		data, err := w.UnwrapKey(keyPath)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap encryption key: %w", err)
+		}
+		key, err := ParseEncryptionKey(data)
+		if err != nil {
+			return err
+		}
+		encryptionKey = key
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read encryption key: %w", err)
+	}
+
+	log.Println("Generating new wrapped encryption key...")
+	encoded, err := GenerateEncryptionKey()
+	if err != nil {
+		return err
+	}
+	key, _ := base64.StdEncoding.DecodeString(encoded)
+	if err := w.WrapKey(key, keyPath); err != nil {
+		return fmt.Errorf("failed to wrap encryption key: %w", err)
+	}
+	encryptionKey = key
+	return nil
+}
+
+// ParseEncryptionKey accepts a 32-byte key, raw or base64 encoded
+func ParseEncryptionKey(data []byte) ([]byte, error) {
+	if len(data) == 32 {
+		return data, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(decoded) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, raw or base64 encoded")
+	}
+	return decoded, nil
+}
+
+// GenerateEncryptionKey creates a new random AES-256 key and returns it base64 encoded
+func GenerateEncryptionKey() (string, error) {
+	// Check system entropy before generating new key
+	if err := checkEntropyAvailable(); err != nil {
+		return "", fmt.Errorf("entropy check failed: %w", err)
+	}
+
+	key := make([]byte, 32) // AES-256
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptionReady reports whether the encryption key or an Encryptor has been initialized
+func EncryptionReady() bool {
+	return encryptor != nil || len(encryptionKey) == 32
+}
+
+// Encrypt encrypts data using the Encryptor if one is set, otherwise AES-256-GCM with the local key
 func Encrypt(plaintext string) ([]byte, error) {
+	if encryptor != nil {
+		ciphertexts, err := encryptor.EncryptBatch([]string{plaintext})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt: %w", err)
+		}
+		return ciphertexts[0], nil
+	}
+	return encryptLocal(plaintext)
+}
+
+// EncryptMany encrypts several values, in one batch when an Encryptor is set
+func EncryptMany(plaintexts []string) ([][]byte, error) {
+	if encryptor != nil {
+		if len(plaintexts) == 0 {
+			return nil, nil
+		}
+		ciphertexts, err := encryptor.EncryptBatch(plaintexts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt: %w", err)
+		}
+		return ciphertexts, nil
+	}
+
+	ciphertexts := make([][]byte, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		ciphertext, err := encryptLocal(plaintext)
+		if err != nil {
+			return nil, err
+		}
+		ciphertexts[i] = ciphertext
+	}
+	return ciphertexts, nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt, with the Encryptor that produced it or the local key
+func Decrypt(ciphertext []byte) (string, error) {
+	if encryptor != nil && encryptor.Handles(ciphertext) {
+		plaintexts, err := encryptor.DecryptBatch([][]byte{ciphertext})
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt: %w", err)
+		}
+		return plaintexts[0], nil
+	}
+	return decryptLocal(ciphertext)
+}
+
+// DecryptMany decrypts several values, sending the Encryptor's values in one batch
+func DecryptMany(ciphertexts [][]byte) ([]string, error) {
+	plaintexts := make([]string, len(ciphertexts))
+	var batch [][]byte
+	var batchIndexes []int
+	for i, ciphertext := range ciphertexts {
+		if encryptor != nil && encryptor.Handles(ciphertext) {
+			batch = append(batch, ciphertext)
+			batchIndexes = append(batchIndexes, i)
+			continue
+		}
+		plaintext, err := decryptLocal(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		plaintexts[i] = plaintext
+	}
+
+	if len(batch) > 0 {
+		decrypted, err := encryptor.DecryptBatch(batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt: %w", err)
+		}
+		for j, i := range batchIndexes {
+			plaintexts[i] = decrypted[j]
+		}
+	}
+	return plaintexts, nil
+}
+
+// encryptLocal encrypts data using AES-256-GCM with the local key
+func encryptLocal(plaintext string) ([]byte, error) {
 	if encryptionKey == nil {
 		return nil, fmt.Errorf("encryption key not initialized")
 	}
@@ -118,9 +288,9 @@ func Encrypt(plaintext string) ([]byte, error) {
 	return ciphertext, nil
 }
 
-// Decrypt decrypts data using AES-256-GCM
+// decryptLocal decrypts data using AES-256-GCM with the local key
 // Includes detailed logging for audit purposes (without exposing sensitive data)
-func Decrypt(ciphertext []byte) (string, error) {
+func decryptLocal(ciphertext []byte) (string, error) {
 	if encryptionKey == nil {
 		log.Println("Decryption failed: encryption key not initialized")
 		return "", fmt.Errorf("encryption key not initialized")
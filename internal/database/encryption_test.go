@@ -1,7 +1,13 @@
 package database
 
 import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"reflect"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -146,3 +152,122 @@ func TestHashPasswordUniqueness(t *testing.T) {
 		t.Errorf("VerifyPassword() failed for hash2: %v", err)
 	}
 }
+
+// prefixEncryptor is a reversible Encryptor that counts its batches
+type prefixEncryptor struct {
+	batches int
+}
+
+func (e *prefixEncryptor) EncryptBatch(plaintexts []string) ([][]byte, error) {
+	e.batches++
+	ciphertexts := make([][]byte, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		ciphertexts[i] = []byte("ext:" + plaintext)
+	}
+	return ciphertexts, nil
+}
+
+func (e *prefixEncryptor) DecryptBatch(ciphertexts [][]byte) ([]string, error) {
+	e.batches++
+	plaintexts := make([]string, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		plaintexts[i] = strings.TrimPrefix(string(ciphertext), "ext:")
+	}
+	return plaintexts, nil
+}
+
+func (e *prefixEncryptor) Handles(ciphertext []byte) bool {
+	return bytes.HasPrefix(ciphertext, []byte("ext:"))
+}
+
+func TestEncryptorRouting(t *testing.T) {
+	if err := InitializeEncryption(t.TempDir() + "/.encryption_key"); err != nil {
+		t.Fatalf("Failed to initialize encryption: %v", err)
+	}
+	legacy, err := Encrypt("old")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	ext := &prefixEncryptor{}
+	SetEncryptor(ext)
+	defer SetEncryptor(nil)
+
+	ciphertext, err := Encrypt("new")
+	if err != nil || string(ciphertext) != "ext:new" {
+		t.Fatalf("Encrypt() = %q, %v; want the encryptor's ciphertext", ciphertext, err)
+	}
+
+	// Values encrypted with the local key stay readable
+	ext.batches = 0
+	plaintexts, err := DecryptMany([][]byte{legacy, ciphertext, []byte("ext:other")})
+	if err != nil {
+		t.Fatalf("DecryptMany() error = %v", err)
+	}
+	if want := []string{"old", "new", "other"}; !reflect.DeepEqual(plaintexts, want) {
+		t.Errorf("DecryptMany() = %v, want %v", plaintexts, want)
+	}
+	if ext.batches != 1 {
+		t.Errorf("Expected the encryptor's values in 1 batch, got %d", ext.batches)
+	}
+}
+
+// reverseWrapper is a KeyWrapper that stores the key reversed
+type reverseWrapper struct {
+	wraps int
+}
+
+func (w *reverseWrapper) WrapKey(key []byte, path string) error {
+	w.wraps++
+	wrapped := slices.Clone(key)
+	slices.Reverse(wrapped)
+	return os.WriteFile(path, wrapped, 0600)
+}
+
+func (w *reverseWrapper) UnwrapKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	slices.Reverse(key)
+	return key, err
+}
+
+func TestInitializeWrappedEncryption(t *testing.T) {
+	keyPath := t.TempDir() + "/.encryption_key"
+	w := &reverseWrapper{}
+
+	// A new key is generated and stored wrapped
+	if err := InitializeWrappedEncryption(keyPath, w); err != nil {
+		t.Fatalf("InitializeWrappedEncryption() error = %v", err)
+	}
+	if w.wraps != 1 {
+		t.Fatalf("Key wrapped %d times, want 1", w.wraps)
+	}
+	key := slices.Clone(encryptionKey)
+	stored, _ := os.ReadFile(keyPath)
+	if bytes.Equal(stored, key) {
+		t.Error("Key file holds the plaintext key")
+	}
+	ciphertext, err := Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// The existing key is unwrapped on the next start
+	encryptionKey = nil
+	if err := InitializeWrappedEncryption(keyPath, w); err != nil {
+		t.Fatalf("InitializeWrappedEncryption() error = %v", err)
+	}
+	if w.wraps != 1 || !bytes.Equal(encryptionKey, key) {
+		t.Fatal("Expected the stored key to be unwrapped, not replaced")
+	}
+	if plaintext, err := Decrypt(ciphertext); err != nil || plaintext != "secret" {
+		t.Errorf("Decrypt() = %q, %v", plaintext, err)
+	}
+
+	// Base64 plaintexts, as printed by aws kms decrypt, are accepted
+	if parsed, err := ParseEncryptionKey([]byte(base64.StdEncoding.EncodeToString(key) + "\n")); err != nil || !bytes.Equal(parsed, key) {
+		t.Errorf("ParseEncryptionKey() = %v, %v", parsed, err)
+	}
+	if _, err := ParseEncryptionKey([]byte("short")); err == nil {
+		t.Error("Expected error for a short key")
+	}
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceResult reports what one maintenance run found and did
+type MaintenanceResult struct {
+	Started    time.Time `json:"started"`
+	DurationMs int64     `json:"duration_ms"`
+	Integrity  string    `json:"integrity"`   // "ok", or the problems integrity_check reported
+	Vacuumed   bool      `json:"vacuumed"`    // False when VACUUM was skipped because the integrity check failed
+	SizeBefore int64     `json:"size_before"` // Bytes used by the database before the run
+	SizeAfter  int64     `json:"size_after"`
+}
+
+// maxIntegrityErrors bounds the problems integrity_check reports
+const maxIntegrityErrors = 10
+
+// Maintain checks the integrity of the database, refreshes the query planner's
+// statistics with ANALYZE and reclaims free pages with VACUUM
+// VACUUM is skipped if the integrity check fails, so a damaged file is left as found
+func (db *DB) Maintain(ctx context.Context) (*MaintenanceResult, error) {
+	// Hold the connection so a restore cannot replace it mid-run
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	conn := db.conn
+
+	result := &MaintenanceResult{Started: time.Now().UTC()}
+	defer func() { result.DurationMs = time.Since(result.Started).Milliseconds() }()
+
+	var err error
+	if result.SizeBefore, err = databaseSize(ctx, conn); err != nil {
+		return result, err
+	}
+
+	problems, err := integrityCheck(ctx, conn)
+	if err != nil {
+		return result, fmt.Errorf("integrity check failed: %w", err)
+	}
+	result.Integrity = "ok"
+	if len(problems) > 0 {
+		result.Integrity = strings.Join(problems, "; ")
+	}
+
+	if _, err := conn.ExecContext(ctx, "ANALYZE"); err != nil {
+		return result, fmt.Errorf("analyze failed: %w", err)
+	}
+
+	if len(problems) == 0 {
+		if _, err := conn.ExecContext(ctx, "VACUUM"); err != nil {
+			return result, fmt.Errorf("vacuum failed: %w", err)
+		}
+		result.Vacuumed = true
+	}
+
+	if result.SizeAfter, err = databaseSize(ctx, conn); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// integrityCheck returns the problems PRAGMA integrity_check finds, none when the database is sound
+func integrityCheck(ctx context.Context, conn *sql.DB) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("PRAGMA integrity_check(%d)", maxIntegrityErrors))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// databaseSize returns the bytes used by the database's pages
+func databaseSize(ctx context.Context, conn *sql.DB) (int64, error) {
+	var pages, pageSize int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := conn.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pages * pageSize, nil
+}
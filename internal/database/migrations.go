@@ -1,7 +1,6 @@
 package database
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"time"
@@ -12,6 +11,7 @@ type Migration struct {
 	Version     int
 	Description string
 	SQL         string
+	Down        string // Reverts SQL; empty if the migration cannot be rolled back
 }
 
 // migrations contains all database migrations in order
@@ -24,7 +24,8 @@ var migrations = []Migration{
 				id INTEGER PRIMARY KEY AUTOINCREMENT,
 				version INTEGER NOT NULL UNIQUE,
 				description TEXT NOT NULL,
-				applied_at DATETIME NOT NULL
+				applied_at DATETIME NOT NULL,
+				dirty INTEGER NOT NULL DEFAULT 0
 			);
 		`,
 	},
@@ -41,6 +42,9 @@ var migrations = []Migration{
 			);
 			CREATE INDEX IF NOT EXISTS idx_ssh_keys_name ON ssh_keys(name);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS ssh_keys;
+		`,
 	},
 	{
 		Version:     3,
@@ -58,6 +62,9 @@ var migrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_command_history_executed_at ON command_history(executed_at DESC);
 			CREATE INDEX IF NOT EXISTS idx_command_history_server ON command_history(server);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS command_history;
+		`,
 	},
 	{
 		Version:     4,
@@ -65,6 +72,9 @@ var migrations = []Migration{
 		SQL: `
 			ALTER TABLE ssh_keys RENAME COLUMN public_key_encrypted TO private_key_encrypted;
 		`,
+		Down: `
+			ALTER TABLE ssh_keys RENAME COLUMN private_key_encrypted TO public_key_encrypted;
+		`,
 	},
 	{
 		Version:     5,
@@ -81,6 +91,9 @@ var migrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_servers_name ON servers(name);
 			CREATE INDEX IF NOT EXISTS idx_servers_ip_address ON servers(ip_address);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS servers;
+		`,
 	},
 	{
 		Version:     6,
@@ -88,6 +101,9 @@ var migrations = []Migration{
 		SQL: `
 			ALTER TABLE servers ADD COLUMN port INTEGER NOT NULL DEFAULT 22;
 		`,
+		Down: `
+			ALTER TABLE servers DROP COLUMN port;
+		`,
 	},
 	{
 		Version:     7,
@@ -95,6 +111,9 @@ var migrations = []Migration{
 		SQL: `
 			ALTER TABLE command_history ADD COLUMN user TEXT;
 		`,
+		Down: `
+			ALTER TABLE command_history DROP COLUMN user;
+		`,
 	},
 	{
 		Version:     8,
@@ -111,6 +130,9 @@ var migrations = []Migration{
 			);
 			CREATE INDEX IF NOT EXISTS idx_saved_commands_name ON saved_commands(name);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS saved_commands;
+		`,
 	},
 	{
 		Version:     9,
@@ -118,6 +140,9 @@ var migrations = []Migration{
 		SQL: `
 			ALTER TABLE servers ADD COLUMN username TEXT NOT NULL DEFAULT 'root';
 		`,
+		Down: `
+			ALTER TABLE servers DROP COLUMN username;
+		`,
 	},
 	{
 		Version:     10,
@@ -131,6 +156,9 @@ var migrations = []Migration{
 			);
 			CREATE INDEX IF NOT EXISTS idx_local_users_name ON local_users(name);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS local_users;
+		`,
 	},
 	{
 		Version:     11,
@@ -143,6 +171,14 @@ var migrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_saved_commands_server ON saved_commands(server_id);
 			CREATE INDEX IF NOT EXISTS idx_saved_commands_ssh_key ON saved_commands(ssh_key_id);
 		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_saved_commands_server;
+			DROP INDEX IF EXISTS idx_saved_commands_ssh_key;
+
+			ALTER TABLE saved_commands DROP COLUMN is_remote;
+			ALTER TABLE saved_commands DROP COLUMN server_id;
+			ALTER TABLE saved_commands DROP COLUMN ssh_key_id;
+		`,
 	},
 	{
 		Version:     12,
@@ -158,6 +194,9 @@ var migrations = []Migration{
 			);
 			CREATE INDEX IF NOT EXISTS idx_env_variables_name ON env_variables(name);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS env_variables;
+		`,
 	},
 	{
 		Version:     13,
@@ -174,6 +213,9 @@ var migrations = []Migration{
 			);
 			CREATE INDEX IF NOT EXISTS idx_bash_scripts_name ON bash_scripts(name);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS bash_scripts;
+		`,
 	},
 	{
 		Version:     14,
@@ -198,6 +240,9 @@ var migrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_script_presets_name ON script_presets(name);
 			CREATE INDEX IF NOT EXISTS idx_script_presets_script_id ON script_presets(script_id);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS script_presets;
+		`,
 	},
 	{
 		Version:     15,
@@ -214,6 +259,9 @@ var migrations = []Migration{
 				updated_at DATETIME NOT NULL
 			);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS vault_config;
+		`,
 	},
 	{
 		Version:     16,
@@ -229,78 +277,863 @@ var migrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_env_variables_group ON env_variables(group_name);
 			CREATE INDEX IF NOT EXISTS idx_bash_scripts_group ON bash_scripts(group_name);
 		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_servers_group;
+			DROP INDEX IF EXISTS idx_ssh_keys_group;
+			DROP INDEX IF EXISTS idx_env_variables_group;
+			DROP INDEX IF EXISTS idx_bash_scripts_group;
+
+			ALTER TABLE servers DROP COLUMN group_name;
+			ALTER TABLE ssh_keys DROP COLUMN group_name;
+			ALTER TABLE env_variables DROP COLUMN group_name;
+			ALTER TABLE bash_scripts DROP COLUMN group_name;
+		`,
+	},
+	{
+		Version:     17,
+		Description: "Create api_tokens table for scoped personal access tokens",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS api_tokens (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				token_hash TEXT NOT NULL UNIQUE,
+				prefix TEXT NOT NULL,
+				scopes TEXT NOT NULL,
+				expires_at DATETIME,
+				last_used_at DATETIME,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_api_tokens_name ON api_tokens(name);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS api_tokens;
+		`,
+	},
+	{
+		Version:     18,
+		Description: "Create group_permissions table and add roles to api_tokens",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS group_permissions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				resource_type TEXT NOT NULL,
+				group_name TEXT NOT NULL,
+				principal TEXT NOT NULL,
+				permission TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				UNIQUE(resource_type, group_name, principal)
+			);
+			CREATE INDEX IF NOT EXISTS idx_group_permissions_group ON group_permissions(resource_type, group_name);
+
+			ALTER TABLE api_tokens ADD COLUMN roles TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			DROP TABLE IF EXISTS group_permissions;
+
+			ALTER TABLE api_tokens DROP COLUMN roles;
+		`,
+	},
+	{
+		Version:     19,
+		Description: "Create command_policies table",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS command_policies (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				description TEXT NOT NULL DEFAULT '',
+				action TEXT NOT NULL,
+				match_type TEXT NOT NULL,
+				pattern TEXT NOT NULL,
+				target TEXT NOT NULL,
+				principal TEXT NOT NULL DEFAULT '',
+				enabled INTEGER NOT NULL DEFAULT 1,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS command_policies;
+		`,
+	},
+	{
+		Version:     20,
+		Description: "Create approvals table and add requires_approval to servers and bash_scripts",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS approvals (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				kind TEXT NOT NULL,
+				summary TEXT NOT NULL,
+				server TEXT NOT NULL,
+				run_as TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				fingerprint TEXT NOT NULL,
+				status TEXT NOT NULL,
+				requested_by TEXT NOT NULL,
+				decided_by TEXT NOT NULL DEFAULT '',
+				expires_at DATETIME NOT NULL,
+				created_at DATETIME NOT NULL,
+				decided_at DATETIME,
+				executed_at DATETIME
+			);
+			CREATE INDEX IF NOT EXISTS idx_approvals_status ON approvals(status);
+
+			ALTER TABLE servers ADD COLUMN requires_approval INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE bash_scripts ADD COLUMN requires_approval INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			DROP TABLE IF EXISTS approvals;
+
+			ALTER TABLE servers DROP COLUMN requires_approval;
+			ALTER TABLE bash_scripts DROP COLUMN requires_approval;
+		`,
+	},
+	{
+		Version:     21,
+		Description: "Add usage tracking columns to ssh_keys",
+		SQL: `
+			ALTER TABLE ssh_keys ADD COLUMN last_used_at DATETIME;
+			ALTER TABLE ssh_keys ADD COLUMN use_count INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			ALTER TABLE ssh_keys DROP COLUMN last_used_at;
+			ALTER TABLE ssh_keys DROP COLUMN use_count;
+		`,
+	},
+	{
+		Version:     22,
+		Description: "Create terminal keystroke log tables",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS terminal_keystroke_logs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				session_id TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				server TEXT NOT NULL,
+				started_at DATETIME NOT NULL,
+				ended_at DATETIME
+			);
+			CREATE INDEX IF NOT EXISTS idx_terminal_keystroke_logs_session_id ON terminal_keystroke_logs(session_id);
+
+			CREATE TABLE IF NOT EXISTS terminal_keystroke_chunks (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				log_id INTEGER NOT NULL REFERENCES terminal_keystroke_logs(id) ON DELETE CASCADE,
+				events BLOB NOT NULL,
+				created_at DATETIME NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_terminal_keystroke_chunks_log_id ON terminal_keystroke_chunks(log_id);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS terminal_keystroke_chunks;
+			DROP TABLE IF EXISTS terminal_keystroke_logs;
+		`,
+	},
+	{
+		Version:     23,
+		Description: "Add interpreter to bash_scripts",
+		SQL: `
+			ALTER TABLE bash_scripts ADD COLUMN interpreter TEXT NOT NULL DEFAULT 'bash';
+		`,
+		Down: `
+			ALTER TABLE bash_scripts DROP COLUMN interpreter;
+		`,
+	},
+	{
+		Version:     24,
+		Description: "Create bash_script_versions table and record existing scripts as version 1",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS bash_script_versions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				script_id INTEGER NOT NULL REFERENCES bash_scripts(id) ON DELETE CASCADE,
+				version INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				description TEXT,
+				content_encrypted BLOB NOT NULL,
+				filename TEXT,
+				group_name TEXT NOT NULL,
+				interpreter TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				UNIQUE(script_id, version)
+			);
+
+			INSERT INTO bash_script_versions (script_id, version, name, description, content_encrypted, filename, group_name, interpreter, created_at)
+			SELECT id, 1, name, description, content_encrypted, filename, group_name, interpreter, updated_at FROM bash_scripts;
+		`,
+		Down: `
+			DROP TABLE IF EXISTS bash_script_versions;
+		`,
+	},
+	{
+		Version:     25,
+		Description: "Add read_only to bash_scripts for scripts managed by Git sync",
+		SQL: `
+			ALTER TABLE bash_scripts ADD COLUMN read_only INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			ALTER TABLE bash_scripts DROP COLUMN read_only;
+		`,
+	},
+	{
+		Version:     26,
+		Description: "Add tags to bash_scripts and saved_commands",
+		SQL: `
+			ALTER TABLE bash_scripts ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';
+			ALTER TABLE saved_commands ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';
+		`,
+		Down: `
+			ALTER TABLE bash_scripts DROP COLUMN tags;
+			ALTER TABLE saved_commands DROP COLUMN tags;
+		`,
+	},
+	{
+		Version:     27,
+		Description: "Add AppRole authentication to vault_config",
+		SQL: `
+			ALTER TABLE vault_config ADD COLUMN auth_method TEXT NOT NULL DEFAULT 'token';
+			ALTER TABLE vault_config ADD COLUMN role_id TEXT;
+			ALTER TABLE vault_config ADD COLUMN secret_id_encrypted BLOB;
+			ALTER TABLE vault_config ADD COLUMN auth_mount TEXT;
+		`,
+		Down: `
+			ALTER TABLE vault_config DROP COLUMN auth_method;
+			ALTER TABLE vault_config DROP COLUMN role_id;
+			ALTER TABLE vault_config DROP COLUMN secret_id_encrypted;
+			ALTER TABLE vault_config DROP COLUMN auth_mount;
+		`,
+	},
+	{
+		Version:     28,
+		Description: "Add Kubernetes authentication to vault_config",
+		SQL: `
+			ALTER TABLE vault_config ADD COLUMN kubernetes_role TEXT;
+			ALTER TABLE vault_config ADD COLUMN jwt_path TEXT;
+		`,
+		Down: `
+			ALTER TABLE vault_config DROP COLUMN kubernetes_role;
+			ALTER TABLE vault_config DROP COLUMN jwt_path;
+		`,
+	},
+	{
+		Version:     29,
+		Description: "Add SSH certificate signing to vault_config",
+		SQL: `
+			ALTER TABLE vault_config ADD COLUMN ssh_ca_mount TEXT;
+			ALTER TABLE vault_config ADD COLUMN ssh_ca_role TEXT;
+			ALTER TABLE vault_config ADD COLUMN ssh_ca_ttl TEXT;
+		`,
+		Down: `
+			ALTER TABLE vault_config DROP COLUMN ssh_ca_mount;
+			ALTER TABLE vault_config DROP COLUMN ssh_ca_role;
+			ALTER TABLE vault_config DROP COLUMN ssh_ca_ttl;
+		`,
+	},
+	{
+		Version:     30,
+		Description: "Create webhooks table",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS webhooks (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				url TEXT NOT NULL,
+				secret_encrypted BLOB,
+				events TEXT NOT NULL DEFAULT '[]',
+				enabled INTEGER NOT NULL DEFAULT 1,
+				last_status TEXT,
+				last_error TEXT,
+				last_delivered_at DATETIME,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS webhooks;
+		`,
+	},
+	{
+		Version:     31,
+		Description: "Add Slack notification mode to script_presets",
+		SQL: `
+			ALTER TABLE script_presets ADD COLUMN slack_notify TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE script_presets DROP COLUMN slack_notify;
+		`,
+	},
+	{
+		Version:     32,
+		Description: "Create notification_rules table",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS notification_rules (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				description TEXT NOT NULL DEFAULT '',
+				kind TEXT NOT NULL DEFAULT '',
+				script TEXT NOT NULL DEFAULT '',
+				server_group TEXT NOT NULL DEFAULT '',
+				outcome TEXT NOT NULL DEFAULT 'failure',
+				exit_codes TEXT NOT NULL DEFAULT '[]',
+				min_duration_ms INTEGER NOT NULL DEFAULT 0,
+				channels TEXT NOT NULL DEFAULT '[]',
+				enabled INTEGER NOT NULL DEFAULT 1,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS notification_rules;
+		`,
+	},
+	{
+		Version:     33,
+		Description: "Add max_parallel to servers",
+		SQL: `
+			ALTER TABLE servers ADD COLUMN max_parallel INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			ALTER TABLE servers DROP COLUMN max_parallel;
+		`,
+	},
+	{
+		Version:     34,
+		Description: "Add exclusive to bash_scripts and script_presets",
+		SQL: `
+			ALTER TABLE bash_scripts ADD COLUMN exclusive INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE script_presets ADD COLUMN exclusive INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			ALTER TABLE script_presets DROP COLUMN exclusive;
+			ALTER TABLE bash_scripts DROP COLUMN exclusive;
+		`,
+	},
+	{
+		Version:     35,
+		Description: "Add output size and truncation to command_history",
+		SQL: `
+			ALTER TABLE command_history ADD COLUMN output_size INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE command_history ADD COLUMN output_truncated INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			ALTER TABLE command_history DROP COLUMN output_truncated;
+			ALTER TABLE command_history DROP COLUMN output_size;
+		`,
+	},
+	{
+		Version:     36,
+		Description: "Add exit code meanings to bash_scripts and severity to command_history",
+		SQL: `
+			ALTER TABLE bash_scripts ADD COLUMN exit_codes TEXT NOT NULL DEFAULT '{}';
+			ALTER TABLE command_history ADD COLUMN severity TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE command_history DROP COLUMN severity;
+			ALTER TABLE bash_scripts DROP COLUMN exit_codes;
+		`,
+	},
+	{
+		Version:     37,
+		Description: "Create execution_quotas table",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS execution_quotas (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				principal TEXT NOT NULL UNIQUE,
+				executions_per_hour INTEGER NOT NULL DEFAULT 0,
+				max_concurrent INTEGER NOT NULL DEFAULT 0,
+				max_timeout_seconds INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS execution_quotas;
+		`,
+	},
+	{
+		Version:     38,
+		Description: "Create server_groups table from the groups of existing servers",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS server_groups (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				description TEXT NOT NULL DEFAULT '',
+				default_username TEXT NOT NULL DEFAULT '',
+				default_port INTEGER NOT NULL DEFAULT 0,
+				default_ssh_key_id INTEGER REFERENCES ssh_keys(id) ON DELETE SET NULL,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+			INSERT OR IGNORE INTO server_groups (name, created_at, updated_at)
+			VALUES ('default', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+			INSERT OR IGNORE INTO server_groups (name, created_at, updated_at)
+			SELECT DISTINCT group_name, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM servers;
+		`,
+		Down: `
+			DROP TABLE IF EXISTS server_groups;
+		`,
+	},
+	{
+		Version:     39,
+		Description: "Add inventory_id to servers",
+		SQL: `
+			ALTER TABLE servers ADD COLUMN inventory_id TEXT NOT NULL DEFAULT '';
+			CREATE INDEX IF NOT EXISTS idx_servers_inventory_id ON servers(inventory_id);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_servers_inventory_id;
+			ALTER TABLE servers DROP COLUMN inventory_id;
+		`,
+	},
+	{
+		Version:     40,
+		Description: "Add the SSM transport to servers",
+		SQL: `
+			ALTER TABLE servers ADD COLUMN transport TEXT NOT NULL DEFAULT 'ssh';
+			ALTER TABLE servers ADD COLUMN ssm_instance_id TEXT NOT NULL DEFAULT '';
+			ALTER TABLE servers ADD COLUMN ssm_region TEXT NOT NULL DEFAULT '';
+			ALTER TABLE servers ADD COLUMN ssm_role_arn TEXT NOT NULL DEFAULT '';
+			ALTER TABLE servers ADD COLUMN ssm_access_key_id TEXT NOT NULL DEFAULT '';
+			ALTER TABLE servers ADD COLUMN ssm_secret_access_key_encrypted BLOB;
+		`,
+		Down: `
+			ALTER TABLE servers DROP COLUMN ssm_secret_access_key_encrypted;
+			ALTER TABLE servers DROP COLUMN ssm_access_key_id;
+			ALTER TABLE servers DROP COLUMN ssm_role_arn;
+			ALTER TABLE servers DROP COLUMN ssm_region;
+			ALTER TABLE servers DROP COLUMN ssm_instance_id;
+			ALTER TABLE servers DROP COLUMN transport;
+		`,
+	},
+	{
+		Version:     41,
+		Description: "Add host key verification settings to servers",
+		SQL: `
+			ALTER TABLE servers ADD COLUMN host_key_policy TEXT NOT NULL DEFAULT 'tofu';
+			ALTER TABLE servers ADD COLUMN host_key_fingerprint TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE servers DROP COLUMN host_key_fingerprint;
+			ALTER TABLE servers DROP COLUMN host_key_policy;
+		`,
+	},
+	{
+		Version:     42,
+		Description: "Add placeholder declarations to saved commands",
+		SQL: `
+			ALTER TABLE saved_commands ADD COLUMN placeholders TEXT NOT NULL DEFAULT '[]';
+		`,
+		Down: `
+			ALTER TABLE saved_commands DROP COLUMN placeholders;
+		`,
+	},
+	{
+		Version:     43,
+		Description: "Create favorites table and record who ran what in command_history",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS favorites (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				principal TEXT NOT NULL,
+				resource_type TEXT NOT NULL,
+				resource_id INTEGER NOT NULL,
+				created_at DATETIME NOT NULL,
+				UNIQUE(principal, resource_type, resource_id)
+			);
+			ALTER TABLE command_history ADD COLUMN principal TEXT NOT NULL DEFAULT '';
+			ALTER TABLE command_history ADD COLUMN script_id INTEGER;
+			ALTER TABLE command_history ADD COLUMN saved_command_id INTEGER;
+			ALTER TABLE command_history ADD COLUMN server_id INTEGER;
+			CREATE INDEX IF NOT EXISTS idx_command_history_principal ON command_history(principal, executed_at DESC);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_command_history_principal;
+			ALTER TABLE command_history DROP COLUMN server_id;
+			ALTER TABLE command_history DROP COLUMN saved_command_id;
+			ALTER TABLE command_history DROP COLUMN script_id;
+			ALTER TABLE command_history DROP COLUMN principal;
+			DROP TABLE IF EXISTS favorites;
+		`,
+	},
+	{
+		Version:     44,
+		Description: "Add schedules and last run status to script_presets",
+		SQL: `
+			ALTER TABLE script_presets ADD COLUMN schedule TEXT NOT NULL DEFAULT '';
+			ALTER TABLE script_presets ADD COLUMN schedule_enabled INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE script_presets ADD COLUMN last_run_at DATETIME;
+			ALTER TABLE script_presets ADD COLUMN last_run_status TEXT NOT NULL DEFAULT '';
+			ALTER TABLE script_presets ADD COLUMN last_run_error TEXT NOT NULL DEFAULT '';
+			ALTER TABLE script_presets ADD COLUMN last_run_history_id INTEGER;
+		`,
+		Down: `
+			ALTER TABLE script_presets DROP COLUMN last_run_history_id;
+			ALTER TABLE script_presets DROP COLUMN last_run_error;
+			ALTER TABLE script_presets DROP COLUMN last_run_status;
+			ALTER TABLE script_presets DROP COLUMN last_run_at;
+			ALTER TABLE script_presets DROP COLUMN schedule_enabled;
+			ALTER TABLE script_presets DROP COLUMN schedule;
+		`,
+	},
+	{
+		Version:     45,
+		Description: "Add timeout, retry and notification channel overrides to script_presets",
+		SQL: `
+			ALTER TABLE script_presets ADD COLUMN timeout_seconds INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE script_presets ADD COLUMN retries INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE script_presets ADD COLUMN retry_backoff_ms INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE script_presets ADD COLUMN notify_channel TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE script_presets DROP COLUMN notify_channel;
+			ALTER TABLE script_presets DROP COLUMN retry_backoff_ms;
+			ALTER TABLE script_presets DROP COLUMN retries;
+			ALTER TABLE script_presets DROP COLUMN timeout_seconds;
+		`,
+	},
+	{
+		Version:     46,
+		Description: "Link command_history to script presets and index its links",
+		SQL: `
+			ALTER TABLE command_history ADD COLUMN preset_id INTEGER;
+			CREATE INDEX IF NOT EXISTS idx_command_history_script_id ON command_history(script_id, executed_at DESC);
+			CREATE INDEX IF NOT EXISTS idx_command_history_preset_id ON command_history(preset_id, executed_at DESC);
+			CREATE INDEX IF NOT EXISTS idx_command_history_saved_command_id ON command_history(saved_command_id, executed_at DESC);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_command_history_saved_command_id;
+			DROP INDEX IF EXISTS idx_command_history_preset_id;
+			DROP INDEX IF EXISTS idx_command_history_script_id;
+			ALTER TABLE command_history DROP COLUMN preset_id;
+		`,
+	},
+	{
+		Version:     47,
+		Description: "Create maintenance_windows table",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS maintenance_windows (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				description TEXT NOT NULL DEFAULT '',
+				server_group TEXT NOT NULL,
+				schedule TEXT NOT NULL DEFAULT '',
+				duration_minutes INTEGER NOT NULL DEFAULT 0,
+				starts_at DATETIME,
+				ends_at DATETIME,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS maintenance_windows;
+		`,
+	},
+	{
+		Version:     48,
+		Description: "Add Wake-on-LAN MAC address to servers",
+		SQL: `
+			ALTER TABLE servers ADD COLUMN mac_address TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE servers DROP COLUMN mac_address;
+		`,
+	},
+	{
+		Version:     49,
+		Description: "Bind agent API tokens to a server",
+		SQL: `
+			ALTER TABLE api_tokens ADD COLUMN server_id INTEGER;
+		`,
+		Down: `
+			ALTER TABLE api_tokens DROP COLUMN server_id;
+		`,
 	},
 }
 
+// DirtyError reports a migration that started but did not finish, leaving the
+// schema in an unknown state
+type DirtyError struct {
+	Version int
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("database is dirty: migration %d did not finish; check the schema, then run 'web-cli migrate -force %d' if the migration took effect or 'web-cli migrate -force %d' if it did not",
+		e.Version, e.Version, e.Version-1)
+}
+
+// MigrationStatus reports whether a migration is applied to the database
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	Dirty       bool       // Started but did not finish
+	AppliedAt   *time.Time // Nil when not applied, and for the schema_migrations table itself
+	Reversible  bool       // Has a down migration
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	appliedAt time.Time
+	dirty     bool
+}
+
+// LatestVersion returns the schema version this build migrates to
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
 // runMigrations executes all pending migrations
 func (db *DB) runMigrations() error {
-	// First, ensure the schema_migrations table exists
+	return db.MigrateTo(LatestVersion())
+}
+
+// MigrateTo applies pending migrations up to version and rolls back applied
+// ones after it, so exactly the migrations up to version are applied
+// Rolling back drops the tables and columns those migrations added, with their data
+func (db *DB) MigrateTo(version int) error {
+	if version < 1 || version > LatestVersion() {
+		return fmt.Errorf("invalid schema version %d (must be between 1 and %d)", version, LatestVersion())
+	}
+
+	if err := db.ensureMigrationsTable(); err != nil {
+		return err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	if err := checkApplied(applied); err != nil {
+		return err
+	}
+
+	// Roll back newest first, so each down migration sees the schema its up migration left
+	for i := len(migrations) - 1; i > 0; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := db.revertMigration(migration); err != nil {
+			return err
+		}
+	}
+
+	for _, migration := range migrations[1:] { // The first migration is applied by ensureMigrationsTable
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := db.applyMigration(migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureMigrationsTable creates the schema_migrations table (migration 1),
+// adding the dirty flag to tables created before it existed
+func (db *DB) ensureMigrationsTable() error {
 	if _, err := db.conn.Exec(migrations[0].SQL); err != nil {
 		return fmt.Errorf("failed to create schema_migrations table: %w", err)
 	}
 
-	// Get current version
-	currentVersion := db.getCurrentVersion()
+	var hasDirty int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM pragma_table_info('schema_migrations') WHERE name = 'dirty'").Scan(&hasDirty); err != nil {
+		return fmt.Errorf("failed to inspect schema_migrations table: %w", err)
+	}
+	if hasDirty == 0 {
+		if _, err := db.conn.Exec("ALTER TABLE schema_migrations ADD COLUMN dirty INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return fmt.Errorf("failed to add dirty flag to schema_migrations table: %w", err)
+		}
+	}
 
-	// Apply pending migrations
-	for _, migration := range migrations[1:] { // Skip first migration as it's already applied
-		if migration.Version <= currentVersion {
-			continue
+	return nil
+}
+
+// appliedMigrations returns the recorded migrations by version
+func (db *DB) appliedMigrations() (map[int]appliedMigration, error) {
+	rows, err := db.conn.Query("SELECT version, applied_at, dirty FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var migration appliedMigration
+		if err := rows.Scan(&version, &migration.appliedAt, &migration.dirty); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
 		}
+		applied[version] = migration
+	}
+	return applied, rows.Err()
+}
 
-		log.Printf("Applying migration %d: %s", migration.Version, migration.Description)
+// checkApplied refuses to migrate a dirty database or one migrated by a newer build
+func checkApplied(applied map[int]appliedMigration) error {
+	newest := 0
+	for version, migration := range applied {
+		if migration.dirty {
+			return &DirtyError{Version: version}
+		}
+		newest = max(newest, version)
+	}
+	if newest > LatestVersion() {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d); run a newer web-cli, or roll back with 'web-cli migrate -to %d' using the build that applied it",
+			newest, LatestVersion(), LatestVersion())
+	}
+	return nil
+}
 
-		// Start transaction
-		tx, err := db.conn.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to begin transaction: %w", err)
+// applyMigration runs a migration's up SQL and records it
+func (db *DB) applyMigration(migration Migration) error {
+	log.Printf("Applying migration %d: %s", migration.Version, migration.Description)
+
+	// Marked dirty until the transaction commits, so a process stopped in
+	// between is detected on the next start
+	if _, err := db.conn.Exec(
+		"INSERT INTO schema_migrations (version, description, applied_at, dirty) VALUES (?, ?, ?, 1)",
+		migration.Version,
+		migration.Description,
+		time.Now().UTC(),
+	); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	err := db.inTransaction(migration.SQL, "UPDATE schema_migrations SET dirty = 0 WHERE version = ?", migration.Version)
+	if err != nil {
+		// The transaction was rolled back, so the migration is not applied
+		if _, clearErr := db.conn.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version); clearErr != nil {
+			log.Printf("Warning: failed to clear dirty flag of migration %d: %v", migration.Version, clearErr)
 		}
+		return fmt.Errorf("failed to execute migration %d: %w", migration.Version, err)
+	}
 
-		// Execute migration
-		if _, err := tx.Exec(migration.SQL); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to execute migration %d: %w", migration.Version, err)
+	log.Printf("Successfully applied migration %d", migration.Version)
+	return nil
+}
+
+// revertMigration runs a migration's down SQL and removes its record
+func (db *DB) revertMigration(migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d (%s) cannot be rolled back", migration.Version, migration.Description)
+	}
+
+	log.Printf("Rolling back migration %d: %s", migration.Version, migration.Description)
+
+	if _, err := db.conn.Exec("UPDATE schema_migrations SET dirty = 1 WHERE version = ?", migration.Version); err != nil {
+		return fmt.Errorf("failed to record rollback of migration %d: %w", migration.Version, err)
+	}
+
+	err := db.inTransaction(migration.Down, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+	if err != nil {
+		// The transaction was rolled back, so the migration is still applied
+		if _, clearErr := db.conn.Exec("UPDATE schema_migrations SET dirty = 0 WHERE version = ?", migration.Version); clearErr != nil {
+			log.Printf("Warning: failed to clear dirty flag of migration %d: %v", migration.Version, clearErr)
 		}
+		return fmt.Errorf("failed to roll back migration %d: %w", migration.Version, err)
+	}
+
+	log.Printf("Successfully rolled back migration %d", migration.Version)
+	return nil
+}
+
+// inTransaction executes schema SQL and the statement recording it in one transaction
+func (db *DB) inTransaction(schemaSQL, record string, version int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
 
-		// Record migration
+	if _, err := tx.Exec(schemaSQL); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(record, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Force records the migrations up to version as applied and later ones as not
+// applied, and clears dirty flags, without running any migration
+// For recovering a dirty database once its schema was checked or fixed by hand
+func (db *DB) Force(version int) error {
+	if version < 1 || version > LatestVersion() {
+		return fmt.Errorf("invalid schema version %d (must be between 1 and %d)", version, LatestVersion())
+	}
+
+	if err := db.ensureMigrationsTable(); err != nil {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version > ? OR dirty = 1", version); err != nil {
+		return fmt.Errorf("failed to reset migrations: %w", err)
+	}
+	now := time.Now().UTC()
+	for _, migration := range migrations[1:] {
+		if migration.Version > version {
+			break
+		}
 		if _, err := tx.Exec(
-			"INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)",
+			"INSERT OR IGNORE INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)",
 			migration.Version,
 			migration.Description,
-			time.Now().UTC(),
+			now,
 		); err != nil {
-			tx.Rollback()
 			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
 		}
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
-		}
-
-		log.Printf("Successfully applied migration %d", migration.Version)
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// getCurrentVersion returns the current schema version
-func (db *DB) getCurrentVersion() int {
-	var version int
-	err := db.conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Warning: failed to get current version: %v", err)
-		return 0
+// Migrations reports each migration of this build and whether it is applied
+func (db *DB) Migrations() ([]MigrationStatus, error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for i, migration := range migrations {
+		status := MigrationStatus{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Applied:     i == 0, // The table holding the records exists
+			Reversible:  migration.Down != "",
+		}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = !record.dirty
+			status.Dirty = record.dirty
+			appliedAt := record.appliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
 	}
-	return version
+	return statuses, nil
 }
 
-// GetVersion returns the current database schema version
+// GetVersion returns the current database schema version: the last migration
+// that is applied along with every migration before it
 func (db *DB) GetVersion() (int, error) {
-	var version int
-	err := db.conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	applied, err := db.appliedMigrations()
 	if err != nil {
 		return 0, err
 	}
+
+	version := migrations[0].Version // The table holding the records exists
+	for _, migration := range migrations[1:] {
+		if record, ok := applied[migration.Version]; !ok || record.dirty {
+			break
+		}
+		version = migration.Version
+	}
 	return version, nil
 }
@@ -0,0 +1,177 @@
+// Package diff produces unified diffs between two texts, line by line
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is one line of the edit script turning a into b
+type edit struct {
+	kind opKind
+	line string
+	a, b int // 1-based line numbers in a and b before this edit is applied
+}
+
+// Unified returns a unified diff turning a into b, labelled with fromName and toName
+// It returns an empty string when the texts have the same lines; a missing newline at
+// the end of the text is not reported as a change
+func Unified(fromName, toName, a, b string) string {
+	edits := compute(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for _, h := range hunks(edits) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		out.WriteString(h)
+	}
+	return out.String()
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// compute returns the shortest edit script from a to b (Myers' algorithm)
+func compute(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds v[-d-1..d+1] as it was before step d, for backtracking
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// Walk back from the end, collecting edits in reverse
+	var reversed []edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, edit{kind: opEqual, line: a[x-1], a: x, b: y})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, edit{kind: opInsert, line: b[y-1], a: x + 1, b: y})
+			y--
+		} else {
+			reversed = append(reversed, edit{kind: opDelete, line: a[x-1], a: x, b: y + 1})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, edit{kind: opEqual, line: a[x-1], a: x, b: y})
+		x--
+		y--
+	}
+
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+// hunks formats the edit script as unified diff hunks
+func hunks(edits []edit) []string {
+	var result []string
+	for i := 0; i < len(edits); {
+		if edits[i].kind == opEqual {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is close enough to share context
+		start := max(0, i-contextLines)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].kind != opEqual {
+				end = j + 1
+			} else if j-end >= 2*contextLines {
+				break
+			}
+		}
+		end = min(len(edits), end+contextLines)
+
+		var body strings.Builder
+		aStart, bStart := edits[start].a, edits[start].b
+		aCount, bCount := 0, 0
+		for _, e := range edits[start:end] {
+			switch e.kind {
+			case opEqual:
+				body.WriteString(" " + e.line + "\n")
+				aCount++
+				bCount++
+			case opDelete:
+				body.WriteString("-" + e.line + "\n")
+				aCount++
+			case opInsert:
+				body.WriteString("+" + e.line + "\n")
+				bCount++
+			}
+		}
+		result = append(result, fmt.Sprintf("@@ -%s +%s @@\n%s", hunkRange(aStart, aCount), hunkRange(bStart, bCount), body.String()))
+		i = end
+	}
+	return result
+}
+
+// hunkRange formats a hunk's line range; empty ranges point at the line before them
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
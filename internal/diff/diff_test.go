@@ -0,0 +1,56 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "echo one\necho two\n",
+			b:    "echo one\necho two",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "#!/bin/bash\nset -e\necho one\n",
+			b:    "#!/bin/bash\nset -eu\necho one\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n #!/bin/bash\n-set -e\n+set -eu\n echo one\n",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "echo one\necho two\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+echo one\n+echo two\n",
+		},
+		{
+			name: "to empty",
+			a:    "echo one\n",
+			b:    "",
+			want: "--- a\n+++ b\n@@ -1 +0,0 @@\n-echo one\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+		{
+			name: "nearby changes share a hunk",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:    "1\nX\n3\n4\n5\n6\nY\n8\n",
+			want: "--- a\n+++ b\n@@ -1,8 +1,8 @@\n 1\n-2\n+X\n 3\n 4\n 5\n 6\n-7\n+Y\n 8\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a", "b", tt.a, tt.b); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package dotenv reads environment variables in the .env file format
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entry is one variable of a .env file
+type Entry struct {
+	Name  string
+	Value string
+	Line  int // Line the variable starts on
+}
+
+// Parse reads NAME=value lines as written by Docker Compose and most dotenv libraries
+// Blank lines and # comments are skipped and an "export " prefix is allowed. Unquoted
+// values end at a " #" comment; single-quoted values are literal; double-quoted values
+// expand \n, \r, \t, \" and \\ escapes. Quoted values may span several lines.
+// Names and values are not validated.
+func Parse(content string) ([]Entry, error) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	var entries []Entry
+
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNum)
+		}
+		name = strings.TrimSpace(name)
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			// Quoted values continue on the following lines until the closing quote
+			quote := rest[0]
+			text := rest[1:]
+			for {
+				end := closingQuote(text, quote)
+				if end >= 0 {
+					if trailing := strings.TrimSpace(text[end+1:]); trailing != "" && !strings.HasPrefix(trailing, "#") {
+						return nil, fmt.Errorf("line %d: unexpected text after closing quote", i+1)
+					}
+					text = text[:end]
+					break
+				}
+				if i+1 >= len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated quoted value", lineNum)
+				}
+				i++
+				text += "\n" + lines[i]
+			}
+			value = text
+			if quote == '"' {
+				value = unescape(value)
+			}
+		} else {
+			// An unquoted value ends at a comment preceded by whitespace
+			if idx := strings.Index(rest, " #"); idx >= 0 {
+				rest = rest[:idx]
+			}
+			if idx := strings.Index(rest, "\t#"); idx >= 0 {
+				rest = rest[:idx]
+			}
+			value = strings.TrimSpace(rest)
+		}
+
+		entries = append(entries, Entry{Name: name, Value: value, Line: lineNum})
+	}
+
+	return entries, nil
+}
+
+// closingQuote returns the index of the quote ending a value, or -1 if text has none
+// Backslashes escape double quotes only
+func closingQuote(text string, quote byte) int {
+	for i := 0; i < len(text); i++ {
+		if quote == '"' && text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape expands the escapes of a double-quoted value
+func unescape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(value[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// Format writes entries as NAME=value lines that Parse reads back unchanged
+// Values are left bare when they only hold safe characters, single-quoted so shells and
+// Docker Compose do not expand them, and double-quoted with escapes when they contain
+// a single quote or line breaks.
+func Format(entries []Entry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.Name)
+		b.WriteByte('=')
+		b.WriteString(quote(entry.Value))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// quote returns value as it is written in a .env file
+func quote(value string) string {
+	if value == "" {
+		return ""
+	}
+	if isBare(value) {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// isBare reports whether value can be written without quotes
+func isBare(value string) bool {
+	for _, c := range value {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("_-.,:/@+=%", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package dotenv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := strings.Join([]string{
+		"# Database",
+		"DB_HOST=db.internal",
+		"export DB_PORT = 5432",
+		"",
+		"DB_PASSWORD='p@ss#word $HOME'",
+		`GREETING="hello\n\"world\""`,
+		"URL=https://example.com/#anchor # comment",
+		"CERT=\"-----BEGIN-----",
+		"abc",
+		"-----END-----\"",
+		"EMPTY=",
+	}, "\r\n")
+
+	entries, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Entry{
+		{Name: "DB_HOST", Value: "db.internal", Line: 2},
+		{Name: "DB_PORT", Value: "5432", Line: 3},
+		{Name: "DB_PASSWORD", Value: "p@ss#word $HOME", Line: 5},
+		{Name: "GREETING", Value: "hello\n\"world\"", Line: 6},
+		{Name: "URL", Value: "https://example.com/#anchor", Line: 7},
+		{Name: "CERT", Value: "-----BEGIN-----\nabc\n-----END-----", Line: 8},
+		{Name: "EMPTY", Value: "", Line: 11},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"missing equals", "A=1\nJUST_A_NAME", "line 2: expected NAME=value"},
+		{"unterminated quote", "A=\"open\nB=2", "line 1: unterminated quoted value"},
+		{"text after quote", "A='x' y", "line 1: unexpected text after closing quote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	entries := []Entry{
+		{Name: "PLAIN", Value: "db.internal:5432"},
+		{Name: "EMPTY", Value: ""},
+		{Name: "SPACES", Value: "hello world $HOME #x"},
+		{Name: "QUOTE", Value: "it's \"quoted\" \\ here"},
+		{Name: "MULTI", Value: "line1\nline2\ttab"},
+	}
+
+	content := Format(entries)
+	want := strings.Join([]string{
+		"PLAIN=db.internal:5432",
+		"EMPTY=",
+		"SPACES='hello world $HOME #x'",
+		`QUOTE="it's \"quoted\" \\ here"`,
+		`MULTI="line1\nline2\ttab"`,
+	}, "\n") + "\n"
+	if content != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", content, want)
+	}
+
+	// Parse reads the formatted values back unchanged
+	parsed, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	for i := range parsed {
+		if parsed[i].Name != entries[i].Name || parsed[i].Value != entries[i].Value {
+			t.Errorf("Round trip %d = %+v, want %+v", i, parsed[i], entries[i])
+		}
+	}
+}
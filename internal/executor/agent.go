@@ -0,0 +1,13 @@
+package executor
+
+// NewAgentExecutor creates an executor running commands through the web-cli agent of a server,
+// which runs scripts as agentUser
+// Commands for any other user, root included, switch to it with sudo. Agents that do not report
+// their user (empty agentUser) switch for every user.
+func NewAgentExecutor(run SSMRun, agentUser string) *SSMExecutor {
+	e := NewSSMExecutor(run)
+	e.script = func(command, user string, opts RunOptions) string {
+		return scriptAs(command, user, agentUser, opts)
+	}
+	return e
+}
@@ -0,0 +1,12 @@
+package executor
+
+import "regexp"
+
+// ansiPattern matches terminal escape sequences: colors and cursor movement (CSI),
+// window titles and links (OSC), character set switches and two-character escapes
+var ansiPattern = regexp.MustCompile(`\x1b(\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[()*+][0-9A-Za-z]|[0-Z\\-_])`)
+
+// StripANSI removes terminal escape sequences from output, leaving its plain text
+func StripANSI(output string) string {
+	return ansiPattern.ReplaceAllString(output, "")
+}
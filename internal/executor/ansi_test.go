@@ -0,0 +1,21 @@
+package executor
+
+import "testing"
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text\n", "plain text\n"},
+		{"\x1b[1;32mPASS\x1b[0m ok\n", "PASS ok\n"},
+		{"\x1b[2K\x1b[1Gprogress 50%\r", "progress 50%\r"},
+		{"\x1b]0;build\x07done", "done"},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"\x1b(Bascii\x1b=", "ascii"},
+	}
+	for _, tt := range tests {
+		if got := StripANSI(tt.in); got != tt.want {
+			t.Errorf("StripANSI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package executor
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// EnvVar is an environment variable set for an execution
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// exportEnv returns the shell statements exporting env vars, with their values quoted
+func exportEnv(env []EnvVar) string {
+	var b strings.Builder
+	for _, v := range env {
+		b.WriteString("export " + v.Name + "=" + shellQuote(v.Value) + "\n")
+	}
+	return b.String()
+}
+
+// setEnv sends env vars with env requests, keeping their values out of the command line
+// (and the remote process list), and returns the command to run
+// sshd only accepts the names listed in its AcceptEnv: from the first one it refuses, the
+// remaining variables are exported by the command instead.
+func setEnv(session *ssh.Session, command string, env []EnvVar) string {
+	for i, v := range env {
+		if err := session.Setenv(v.Name, v.Value); err != nil {
+			return exportEnv(env[i:]) + command
+		}
+	}
+	return command
+}
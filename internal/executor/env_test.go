@@ -0,0 +1,31 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRemoteEnv(t *testing.T) {
+	port := startSSHServer(t)
+	config := &SSHConfig{Host: "127.0.0.1", Port: port, Username: "deploy", Password: "secret"}
+
+	// LC_TOKEN is accepted by the server; from TOKEN on, the rest are exported by the command
+	env := []EnvVar{{Name: "LC_TOKEN", Value: "sent by request"}, {Name: "TOKEN", Value: "it's secret"}, {Name: "LC_OTHER", Value: "exported"}}
+	command := `printf '%s|%s|%s\n' "$LC_TOKEN" "$TOKEN" "$LC_OTHER"; ps -o args= -p $$`
+	result := NewRemoteExecutor().ExecuteWithOptions(context.Background(), command, config, RunOptions{Env: env})
+	lines := strings.SplitN(result.Output, "\n", 2)
+	if result.ExitCode != 0 || len(lines) != 2 {
+		t.Fatalf("Command failed: %q (%v)", result.Output, result.Error)
+	}
+	if lines[0] != "sent by request|it's secret|exported" {
+		t.Errorf("Unexpected env: %q", lines[0])
+	}
+	if strings.Contains(lines[1], "sent by request") || !strings.Contains(lines[1], "export TOKEN=") {
+		t.Errorf("Expected only the refused variables on the command line: %q", lines[1])
+	}
+
+	if got := exportEnv(env[1:2]); got != "export TOKEN='it'\\''s secret'\n" {
+		t.Errorf("Unexpected exports %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"net"
 	"os"
@@ -12,6 +13,13 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// Host key policies: how the SSH host key of a server is verified
+const (
+	HostKeyPolicyStrict   = "strict"   // Only keys already in known_hosts are accepted
+	HostKeyPolicyTOFU     = "tofu"     // Keys of new hosts are trusted on first use and saved to known_hosts
+	HostKeyPolicyInsecure = "insecure" // Any key is accepted
+)
+
 // HostKeyVerifier manages SSH host key verification
 type HostKeyVerifier struct {
 	knownHostsPath  string
@@ -39,13 +47,18 @@ func NewHostKeyVerifier(knownHostsPath string, trustOnFirstUse bool) (*HostKeyVe
 
 // VerifyHostKey verifies the host key against known_hosts
 func (v *HostKeyVerifier) VerifyHostKey(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	return v.verify(hostname, key, v.trustOnFirstUse)
+}
+
+// verify checks a host key against known_hosts, trusting new hosts when trustOnFirstUse is set
+func (v *HostKeyVerifier) verify(hostname string, key ssh.PublicKey, trustOnFirstUse bool) error {
 	v.mu.RLock()
 	knownKey, exists := v.knownHosts[hostname]
 	v.mu.RUnlock()
 
 	if !exists {
 		// Host key not in known_hosts
-		if v.trustOnFirstUse {
+		if trustOnFirstUse {
 			// Trust on first use - add to known_hosts
 			if err := v.addHostKey(hostname, key); err != nil {
 				return fmt.Errorf("failed to add host key: %w", err)
@@ -70,6 +83,17 @@ func (v *HostKeyVerifier) GetHostKeyCallback() ssh.HostKeyCallback {
 	}
 }
 
+// pinnedHostKey returns a callback accepting only the host key with a SHA256 fingerprint
+// (as printed by ssh-keygen -lf), whatever known_hosts holds
+func pinnedHostKey(fingerprint string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if actual := ssh.FingerprintSHA256(key); actual != fingerprint {
+			return fmt.Errorf("host key mismatch for %s: fingerprint %s is not the pinned %s - possible man-in-the-middle attack!", hostname, actual, fingerprint)
+		}
+		return nil
+	}
+}
+
 // loadKnownHosts loads known_hosts file into memory
 func (v *HostKeyVerifier) loadKnownHosts() error {
 	// Create known_hosts file if it doesn't exist
@@ -117,6 +141,9 @@ func (v *HostKeyVerifier) loadKnownHosts() error {
 		hostname := parts[0]
 		keyType := parts[1]
 		keyData := parts[2]
+		if keyData == keyType && len(parts) > 3 {
+			keyData = parts[3] // Lines saved by earlier versions repeat the key type
+		}
 
 		// Parse the public key
 		key, err := parsePublicKeyFromKnownHosts(keyType, keyData)
@@ -146,7 +173,7 @@ func (v *HostKeyVerifier) addHostKey(hostname string, key ssh.PublicKey) error {
 	defer file.Close()
 
 	// Format: hostname key-type base64-key
-	line := fmt.Sprintf("%s %s %s\n", hostname, key.Type(), ssh.MarshalAuthorizedKey(key))
+	line := fmt.Sprintf("%s %s\n", hostname, bytes.TrimSpace(ssh.MarshalAuthorizedKey(key)))
 	if _, err := file.WriteString(line); err != nil {
 		return err
 	}
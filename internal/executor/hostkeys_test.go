@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestHostKeyPolicies(t *testing.T) {
+	port := startSSHServer(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	run := func(e *RemoteExecutor, policy, fingerprint string) *ExecuteResult {
+		config := &SSHConfig{Host: "127.0.0.1", Port: port, Username: "deploy", Password: "secret", HostKeyPolicy: policy, HostKeyFingerprint: fingerprint}
+		return e.ExecuteWithOptions(context.Background(), "true", config, RunOptions{})
+	}
+
+	// The executor's default is kept when the server sets no policy
+	strictByDefault := NewRemoteExecutorWithHostKeys(knownHosts, false)
+	if result := run(strictByDefault, "", ""); result.Error == nil || !strings.Contains(result.Error.Error(), "not found in known_hosts") {
+		t.Fatalf("Expected an unknown host to be rejected, got %v", result.Error)
+	}
+	if result := run(strictByDefault, HostKeyPolicyInsecure, ""); result.Error != nil {
+		t.Fatalf("Expected the insecure policy to accept any key, got %v", result.Error)
+	}
+
+	// Trusting the host on first use saves its key, which strict servers then accept
+	if result := run(strictByDefault, HostKeyPolicyTOFU, ""); result.Error != nil {
+		t.Fatalf("Expected trust on first use, got %v", result.Error)
+	}
+	if result := run(NewRemoteExecutorWithHostKeys(knownHosts, false), HostKeyPolicyStrict, ""); result.Error != nil {
+		t.Fatalf("Expected the saved key to be accepted, got %v", result.Error)
+	}
+
+	// A pinned fingerprint is checked instead of known_hosts
+	var fingerprint string
+	config := &ssh.ClientConfig{
+		User: "deploy",
+		Auth: []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			fingerprint = ssh.FingerprintSHA256(key)
+			return nil
+		},
+	}
+	client, err := ssh.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	client.Close()
+
+	if result := run(NewRemoteExecutor(), HostKeyPolicyStrict, fingerprint); result.Error != nil {
+		t.Errorf("Expected the pinned key to be accepted, got %v", result.Error)
+	}
+	wrong := "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"
+	if result := run(NewRemoteExecutorWithHostKeys(knownHosts, true), "", wrong); result.Error == nil || !strings.Contains(result.Error.Error(), "host key mismatch") {
+		t.Errorf("Expected another pinned key to be rejected, got %v", result.Error)
+	}
+}
+
+func TestKnownHostsFormat(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	signer, _ := ssh.NewSignerFromKey(private)
+	key := signer.PublicKey()
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+
+	// Lines saved by earlier versions repeat the key type and are still read
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte("old:22 "+key.Type()+" "+authorized+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewHostKeyVerifier(path, true)
+	if err != nil {
+		t.Fatalf("Failed to load known hosts: %v", err)
+	}
+	if err := verifier.verify("old:22", key, false); err != nil {
+		t.Errorf("Expected the legacy line to be read, got %v", err)
+	}
+
+	// New keys are saved in the known_hosts format
+	if err := verifier.verify("new:22", key, true); err != nil {
+		t.Fatalf("Failed to trust a new host: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || lines[1] != "new:22 "+authorized {
+		t.Errorf("Unexpected known_hosts %q", data)
+	}
+}
@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// scriptInterpreter describes how stored scripts are run for an interpreter
+type scriptInterpreter struct {
+	binary    string
+	args      []string // Arguments placed before the script file
+	checkArgs []string // Arguments that check the script's syntax without running it (nil if unsupported)
+	extension string   // Some interpreters (pwsh) refuse files without the right extension
+}
+
+// scriptInterpreters maps interpreter names to how their scripts are run
+var scriptInterpreters = map[string]scriptInterpreter{
+	"bash":    {binary: "bash", checkArgs: []string{"-n"}, extension: ".sh"},
+	"sh":      {binary: "sh", checkArgs: []string{"-n"}, extension: ".sh"},
+	"python3": {binary: "python3", checkArgs: []string{"-m", "py_compile"}, extension: ".py"},
+	"node":    {binary: "node", checkArgs: []string{"--check"}, extension: ".js"},
+	"pwsh":    {binary: "pwsh", args: []string{"-NoProfile", "-NonInteractive", "-File"}, extension: ".ps1"},
+}
+
+// scriptDelimiter is the heredoc delimiter used to write script files
+const scriptDelimiter = "WEBCLI_SCRIPT_EOF"
+
+// CheckInterpreter reports whether an interpreter is installed on this machine
+func CheckInterpreter(name string) error {
+	interp, ok := scriptInterpreters[name]
+	if !ok {
+		return fmt.Errorf("unsupported interpreter %q", name)
+	}
+	if _, err := exec.LookPath(interp.binary); err != nil {
+		return fmt.Errorf("interpreter %s is not installed", name)
+	}
+	return nil
+}
+
+// ScriptCommand builds the shell command that runs a stored script with its interpreter
+// Bash scripts run directly in the shell; other interpreters get the script written to a
+// temporary file, and the command exits with 127 when the interpreter is missing
+func ScriptCommand(interpreter, content string) (string, error) {
+	if interpreter == "" || interpreter == "bash" {
+		return content, nil
+	}
+
+	interp, ok := scriptInterpreters[interpreter]
+	if !ok {
+		return "", fmt.Errorf("unsupported interpreter %q", interpreter)
+	}
+	return fileCommand(interp, interp.args, content), nil
+}
+
+// UploadScript prepares a stored script to be copied to a remote server and run from a
+// file with args, rather than sent on the command line, which avoids quoting issues and
+// command line length limits
+// It returns the command that runs the uploaded file, and the upload.
+func UploadScript(interpreter, content string, args []string) (string, *ScriptUpload, error) {
+	if interpreter == "" {
+		interpreter = "bash"
+	}
+	interp, ok := scriptInterpreters[interpreter]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported interpreter %q", interpreter)
+	}
+
+	run := append([]string{interp.binary}, interp.args...)
+	run = append(run, `"$webcli_script"`)
+	for _, arg := range args {
+		run = append(run, shellQuote(arg))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "command -v %s >/dev/null 2>&1 || { echo '%s: interpreter not found' >&2; exit 127; }\n", interp.binary, interp.binary)
+	b.WriteString(strings.Join(run, " ") + "\n")
+	return b.String(), &ScriptUpload{Content: []byte(content), Extension: interp.extension}, nil
+}
+
+// SyntaxCheckCommand builds the shell command that checks a stored script's syntax
+// with its interpreter without running it (bash -n, python3 -m py_compile, node --check)
+func SyntaxCheckCommand(interpreter, content string) (string, error) {
+	if interpreter == "" {
+		interpreter = "bash"
+	}
+	interp, ok := scriptInterpreters[interpreter]
+	if !ok {
+		return "", fmt.Errorf("unsupported interpreter %q", interpreter)
+	}
+	if interp.checkArgs == nil {
+		return "", fmt.Errorf("syntax check is not available for %s scripts", interpreter)
+	}
+	return fileCommand(interp, interp.checkArgs, content), nil
+}
+
+// fileCommand builds a shell command that writes content to a temporary file and runs
+// the interpreter with args on it, exiting with 127 when the interpreter is missing
+func fileCommand(interp scriptInterpreter, args []string, content string) string {
+	// The delimiter must not appear as a line of the script, or the heredoc would end early
+	delimiter := scriptDelimiter
+	for i := 1; containsLine(content, delimiter); i++ {
+		delimiter = fmt.Sprintf("%s_%d", scriptDelimiter, i)
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	run := append([]string{interp.binary}, args...)
+	file := `"$webcli_dir/script` + interp.extension + `"`
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "command -v %s >/dev/null 2>&1 || { echo '%s: interpreter not found' >&2; exit 127; }\n", interp.binary, interp.binary)
+	b.WriteString("webcli_dir=$(mktemp -d) || exit 1\n")
+	fmt.Fprintf(&b, "cat > %s <<'%s'\n%s%s\n", file, delimiter, content, delimiter)
+	fmt.Fprintf(&b, "%s %s\n", strings.Join(run, " "), file)
+	b.WriteString("webcli_rc=$?\nrm -rf \"$webcli_dir\"\nexit $webcli_rc\n")
+	return b.String()
+}
+
+// containsLine reports whether any line of s equals line
+func containsLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSuffix(l, "\r") == line {
+			return true
+		}
+	}
+	return false
+}
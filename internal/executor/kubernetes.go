@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// PodExec runs a program in a pod's container, copying its output to stdout and
+// stderr as it arrives, and returns its exit code (see kubernetes.Client.Exec)
+type PodExec func(ctx context.Context, command []string, stdin []byte, stdout, stderr io.Writer) (int, error)
+
+// KubernetesExecutor runs commands in a pod's container through the Kubernetes exec API
+// Commands run as the container's user, through a shell in the container.
+type KubernetesExecutor struct {
+	exec           PodExec
+	shell          string
+	defaultTimeout time.Duration
+}
+
+// NewKubernetesExecutor creates an executor running commands with shell (default: sh) through exec
+func NewKubernetesExecutor(exec PodExec, shell string) *KubernetesExecutor {
+	if shell == "" {
+		shell = "sh"
+	}
+	return &KubernetesExecutor{
+		exec:           exec,
+		shell:          shell,
+		defaultTimeout: 5 * time.Minute,
+	}
+}
+
+// ExecuteWithOptions runs a command in the container with stdin piped through the
+// exec stream and the working directory changed before the command runs
+func (e *KubernetesExecutor) ExecuteWithOptions(ctx context.Context, command string, opts RunOptions) *ExecuteResult {
+	startTime := time.Now()
+
+	cmdCtx, cancel := context.WithTimeout(ctx, opts.timeout(e.defaultTimeout))
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := e.exec(cmdCtx, []string{e.shell, "-c", inDir(opts.Dir, exportEnv(opts.Env)+command)}, opts.Stdin, &stdout, &stderr)
+
+	// Combine stdout and stderr
+	output := stdout.String()
+	if stderr.Len() > 0 {
+		if len(output) > 0 {
+			output += "\n"
+		}
+		output += stderr.String()
+	}
+	if err != nil {
+		exitCode = -1
+		if len(output) > 0 {
+			output += "\n"
+		}
+		output += fmt.Sprintf("Error: %v", err)
+	}
+
+	return &ExecuteResult{
+		Output:        output,
+		ExitCode:      exitCode,
+		ExecutionTime: time.Since(startTime).Milliseconds(),
+		Error:         err,
+	}
+}
+
+// ExecuteWithStreamingOptions streams a command's output from the container with stdin
+// piped through the exec stream and the working directory changed before the command runs
+func (e *KubernetesExecutor) ExecuteWithStreamingOptions(ctx context.Context, command string, opts RunOptions) (<-chan string, <-chan *ExecuteResult) {
+	outputChan := make(chan string, 10)
+	resultChan := make(chan *ExecuteResult, 1)
+
+	go func() {
+		defer close(outputChan)
+		defer close(resultChan)
+
+		startTime := time.Now()
+
+		// Streamed commands run until they finish unless a timeout is requested
+		cmdCtx := ctx
+		if opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			cmdCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+		}
+
+		// Stdout and stderr are written from the one goroutine reading the exec stream
+		stream := &streamWriter{output: outputChan}
+		exitCode, err := e.exec(cmdCtx, []string{e.shell, "-c", inDir(opts.Dir, exportEnv(opts.Env)+command)}, opts.Stdin, stream, stream)
+		if err != nil {
+			exitCode = -1
+		}
+
+		resultChan <- &ExecuteResult{
+			Output:        stream.full.String(),
+			ExitCode:      exitCode,
+			ExecutionTime: time.Since(startTime).Milliseconds(),
+			Error:         err,
+		}
+	}()
+
+	return outputChan, resultChan
+}
+
+// streamWriter sends each write to a channel and keeps the full output
+type streamWriter struct {
+	output chan<- string
+	full   bytes.Buffer
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	chunk := string(p)
+	w.output <- chunk
+	w.full.WriteString(chunk)
+	return len(p), nil
+}
@@ -16,13 +16,53 @@ import (
 type LocalExecutor struct {
 	// defaultTimeout for command execution (can be overridden per command)
 	defaultTimeout time.Duration
+	shell          *LocalShell
+	shellErr       error  // Set when no usable shell was found; executions fail with it
+	elevation      string // Tool used to run commands as another user
 }
 
 // NewLocalExecutor creates a new local command executor
+// The shell and elevation tool are detected for the platform
 func NewLocalExecutor() *LocalExecutor {
-	return &LocalExecutor{
+	return NewLocalExecutorWith("", ElevationAuto)
+}
+
+// NewLocalExecutorWith creates a local command executor with a configured shell
+// (name or path, empty to detect) and elevation tool (auto, sudo, doas, su or none)
+func NewLocalExecutorWith(shell string, elevation string) *LocalExecutor {
+	e := &LocalExecutor{
 		defaultTimeout: 5 * time.Minute, // Default 5 minute timeout
 	}
+	e.shell, e.shellErr = DetectLocalShell(shell)
+	if elevation, err := DetectElevation(elevation); err == nil {
+		e.elevation = elevation
+	} else {
+		e.elevation = ElevationNone
+	}
+	return e
+}
+
+// RunOptions holds optional settings for running a command
+type RunOptions struct {
+	Stdin   []byte        // Piped into the command's stdin (nil for no input)
+	Dir     string        // Working directory (empty for the default)
+	Retry   RetryPolicy   // Retries of transient connection failures (remote executions only)
+	Timeout time.Duration // Longest the command may run, when shorter than the executor's timeout (0 for the executor's)
+	Upload  *ScriptUpload // Script copied to the server over SFTP before the command runs (remote executions only)
+	Env     []EnvVar      // Environment variables the command runs with
+
+	// Remote user the command runs as through sudo, after connecting as the SSH user, and
+	// the password sudo asks for (fed through a PTY; empty for passwordless sudo)
+	RunAs        string
+	SudoPassword string
+}
+
+// timeout returns how long a command may run: the executor's timeout, or opts.Timeout when shorter
+func (o RunOptions) timeout(executorTimeout time.Duration) time.Duration {
+	if o.Timeout > 0 && o.Timeout < executorTimeout {
+		return o.Timeout
+	}
+	return executorTimeout
 }
 
 // ExecuteResult contains the result of a command execution
@@ -31,97 +71,45 @@ type ExecuteResult struct {
 	ExitCode      int
 	ExecutionTime int64 // in milliseconds
 	Error         error
+	Attempts      []Attempt // Connection attempts of a remote execution allowed to retry
 }
 
 // Execute runs a command locally as the specified user
-// If user is empty or "root", it runs with current process privileges
-// sudoPassword is required when running as a different user (empty string for passwordless sudo)
+// An empty user means root (the server's own user on Windows); other users than the
+// server's own are switched to with the elevation tool
+// sudoPassword is required when running as a different user with sudo (empty string for passwordless sudo)
 func (e *LocalExecutor) Execute(ctx context.Context, command string, asUser string, sudoPassword string) *ExecuteResult {
-	startTime := time.Now()
+	return e.ExecuteWithOptions(ctx, command, asUser, sudoPassword, RunOptions{})
+}
 
-	// Default to root if not specified
-	if asUser == "" {
-		asUser = "root"
-	}
+// ExecuteWithOptions runs a command locally as the specified user with stdin and
+// working directory options
+func (e *LocalExecutor) ExecuteWithOptions(ctx context.Context, command string, asUser string, sudoPassword string, opts RunOptions) *ExecuteResult {
+	startTime := time.Now()
 
 	// Create context with timeout
-	cmdCtx, cancel := context.WithTimeout(ctx, e.defaultTimeout)
+	cmdCtx, cancel := context.WithTimeout(ctx, opts.timeout(e.defaultTimeout))
 	defer cancel()
 
-	var cmd *exec.Cmd
 	var stdout, stderr bytes.Buffer
 
-	// Check if we need to run as a different user
-	currentUser, err := user.Current()
+	cmd, sendPassword, err := e.command(cmdCtx, exportEnv(opts.Env)+command, asUser)
 	if err != nil {
 		return &ExecuteResult{
 			Output:        "",
 			ExitCode:      -1,
 			ExecutionTime: time.Since(startTime).Milliseconds(),
-			Error:         fmt.Errorf("failed to get current user: %w", err),
+			Error:         err,
 		}
 	}
 
-	// If requested user is different from current user, use sudo
-	if asUser != currentUser.Username && asUser != "current" {
-		// Use sudo -S to read password from stdin
-		// Note: This requires sudo privileges and proper sudoers configuration
-		cmd = exec.CommandContext(cmdCtx, "sudo", "-S", "-u", asUser, "bash", "-c", command)
-
-		// If password provided, set up stdin pipe
-		if sudoPassword != "" {
-			stdin, err := cmd.StdinPipe()
-			if err != nil {
-				return &ExecuteResult{
-					Output:        "",
-					ExitCode:      -1,
-					ExecutionTime: time.Since(startTime).Milliseconds(),
-					Error:         fmt.Errorf("failed to create stdin pipe: %w", err),
-				}
-			}
-
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
-
-			// Start the command
-			if err := cmd.Start(); err != nil {
-				return &ExecuteResult{
-					Output:        "",
-					ExitCode:      -1,
-					ExecutionTime: time.Since(startTime).Milliseconds(),
-					Error:         fmt.Errorf("failed to start command: %w", err),
-				}
-			}
-
-			// Write password to stdin immediately
-			_, err = stdin.Write([]byte(sudoPassword + "\n"))
-			stdin.Close()
-			if err != nil {
-				return &ExecuteResult{
-					Output:        "",
-					ExitCode:      -1,
-					ExecutionTime: time.Since(startTime).Milliseconds(),
-					Error:         fmt.Errorf("failed to write password: %w", err),
-				}
-			}
-
-			// Wait for command to complete
-			err = cmd.Wait()
-		} else {
-			// No password provided, let sudo handle it (will likely fail)
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
-			err = cmd.Run()
-		}
-	} else {
-		// Run as current user
-		cmd = exec.CommandContext(cmdCtx, "bash", "-c", command)
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
+	cmd.Stdin = commandInput(sendPassword, sudoPassword, opts.Stdin)
+	cmd.Dir = opts.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 
-		// Execute the command
-		err = cmd.Run()
-	}
+	// Execute the command
+	err = cmd.Run()
 
 	// Combine stdout and stderr
 	output := stdout.String()
@@ -172,6 +160,12 @@ func (e *LocalExecutor) ExecuteWithTimeout(ctx context.Context, command string,
 // ExecuteWithStreaming runs a command and streams output in real-time
 // Returns a channel that will receive output chunks as they arrive
 func (e *LocalExecutor) ExecuteWithStreaming(ctx context.Context, command string, asUser string, sudoPassword string) (<-chan string, <-chan *ExecuteResult) {
+	return e.ExecuteWithStreamingOptions(ctx, command, asUser, sudoPassword, RunOptions{})
+}
+
+// ExecuteWithStreamingOptions streams a local command's output with stdin and working
+// directory options
+func (e *LocalExecutor) ExecuteWithStreamingOptions(ctx context.Context, command string, asUser string, sudoPassword string, opts RunOptions) (<-chan string, <-chan *ExecuteResult) {
 	outputChan := make(chan string, 10)
 	resultChan := make(chan *ExecuteResult, 1)
 
@@ -181,36 +175,22 @@ func (e *LocalExecutor) ExecuteWithStreaming(ctx context.Context, command string
 
 		startTime := time.Now()
 
-		// Default to root if not specified
-		if asUser == "" {
-			asUser = "root"
-		}
-
 		// Create context with timeout
-		cmdCtx, cancel := context.WithTimeout(ctx, e.defaultTimeout)
+		cmdCtx, cancel := context.WithTimeout(ctx, opts.timeout(e.defaultTimeout))
 		defer cancel()
 
-		var cmd *exec.Cmd
-
-		// Check if we need to run as a different user
-		currentUser, err := user.Current()
+		// Prepare the command
+		cmd, sendPassword, err := e.command(cmdCtx, exportEnv(opts.Env)+command, asUser)
 		if err != nil {
 			resultChan <- &ExecuteResult{
 				Output:        "",
 				ExitCode:      -1,
 				ExecutionTime: time.Since(startTime).Milliseconds(),
-				Error:         fmt.Errorf("failed to get current user: %w", err),
+				Error:         err,
 			}
 			return
 		}
 
-		// Prepare the command
-		if asUser != currentUser.Username && asUser != "current" {
-			cmd = exec.CommandContext(cmdCtx, "sudo", "-S", "-u", asUser, "bash", "-c", command)
-		} else {
-			cmd = exec.CommandContext(cmdCtx, "bash", "-c", command)
-		}
-
 		// Set up pipes for streaming output
 		stdoutPipe, err := cmd.StdoutPipe()
 		if err != nil {
@@ -234,20 +214,8 @@ func (e *LocalExecutor) ExecuteWithStreaming(ctx context.Context, command string
 			return
 		}
 
-		// Handle sudo password if needed
-		var stdinPipe io.WriteCloser
-		if asUser != currentUser.Username && asUser != "current" && sudoPassword != "" {
-			stdinPipe, err = cmd.StdinPipe()
-			if err != nil {
-				resultChan <- &ExecuteResult{
-					Output:        "",
-					ExitCode:      -1,
-					ExecutionTime: time.Since(startTime).Milliseconds(),
-					Error:         fmt.Errorf("failed to create stdin pipe: %w", err),
-				}
-				return
-			}
-		}
+		cmd.Stdin = commandInput(sendPassword, sudoPassword, opts.Stdin)
+		cmd.Dir = opts.Dir
 
 		// Start the command
 		if err := cmd.Start(); err != nil {
@@ -260,12 +228,6 @@ func (e *LocalExecutor) ExecuteWithStreaming(ctx context.Context, command string
 			return
 		}
 
-		// Write sudo password if needed
-		if stdinPipe != nil {
-			_, _ = stdinPipe.Write([]byte(sudoPassword + "\n"))
-			stdinPipe.Close()
-		}
-
 		// Collect full output for the result
 		var fullOutput strings.Builder
 
@@ -343,6 +305,22 @@ func (e *LocalExecutor) ExecuteWithStreaming(ctx context.Context, command string
 	return outputChan, resultChan
 }
 
+// commandInput returns what is piped into a command's stdin, or nil for no input
+// sudo -S reads the password line first and passes the rest on to the command
+func commandInput(sendPassword bool, sudoPassword string, stdin []byte) io.Reader {
+	var readers []io.Reader
+	if sendPassword && sudoPassword != "" {
+		readers = append(readers, strings.NewReader(sudoPassword+"\n"))
+	}
+	if stdin != nil {
+		readers = append(readers, bytes.NewReader(stdin))
+	}
+	if len(readers) == 0 {
+		return nil
+	}
+	return io.MultiReader(readers...)
+}
+
 // ValidateUser checks if a user exists on the system
 func ValidateUser(username string) error {
 	if username == "" || username == "root" || username == "current" {
@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Elevation tools for running local commands as another user
+const (
+	ElevationAuto = "auto" // First of sudo, doas and su that is installed
+	ElevationSudo = "sudo"
+	ElevationDoas = "doas"
+	ElevationSu   = "su"
+	ElevationNone = "none" // Commands only run as the server's own user
+)
+
+// LocalShell is the interpreter local commands are passed to
+type LocalShell struct {
+	Name string   // bash, sh, powershell, pwsh or cmd
+	Path string   // Resolved executable path
+	Args []string // Arguments placed before the command string
+}
+
+// knownShellArgs are the arguments each supported shell takes before a command string
+var knownShellArgs = map[string][]string{
+	"bash":       {"-c"},
+	"sh":         {"-c"},
+	"dash":       {"-c"},
+	"ash":        {"-c"},
+	"zsh":        {"-c"},
+	"ksh":        {"-c"},
+	"powershell": {"-NoProfile", "-NonInteractive", "-Command"},
+	"pwsh":       {"-NoProfile", "-NonInteractive", "-Command"},
+	"cmd":        {"/C"},
+}
+
+// DetectLocalShell returns the shell for local commands
+// preferred (a shell name or path) is used when set; otherwise the platform's
+// candidates are tried in order (bash then sh, or PowerShell then cmd.exe on Windows)
+func DetectLocalShell(preferred string) (*LocalShell, error) {
+	candidates := defaultLocalShells
+	if preferred != "" {
+		candidates = []string{preferred}
+	}
+
+	for _, candidate := range candidates {
+		path, err := exec.LookPath(candidate)
+		if err != nil {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		args, ok := knownShellArgs[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported local shell %q", candidate)
+		}
+		return &LocalShell{Name: name, Path: path, Args: args}, nil
+	}
+
+	if preferred != "" {
+		return nil, fmt.Errorf("local shell %q not found", preferred)
+	}
+	return nil, fmt.Errorf("no local shell found (tried %s)", strings.Join(candidates, ", "))
+}
+
+// DetectElevation returns the tool used to run commands as another user
+// "auto" picks the first of sudo, doas and su that is installed, or "none"
+func DetectElevation(preferred string) (string, error) {
+	switch preferred {
+	case "", ElevationAuto:
+		if !elevationSupported {
+			return ElevationNone, nil
+		}
+		for _, tool := range []string{ElevationSudo, ElevationDoas, ElevationSu} {
+			if _, err := exec.LookPath(tool); err == nil {
+				return tool, nil
+			}
+		}
+		return ElevationNone, nil
+	case ElevationNone:
+		return ElevationNone, nil
+	case ElevationSudo, ElevationDoas, ElevationSu:
+		if !elevationSupported {
+			return "", fmt.Errorf("%s is not supported on this platform", preferred)
+		}
+		if _, err := exec.LookPath(preferred); err != nil {
+			return "", fmt.Errorf("%s not found: %w", preferred, err)
+		}
+		return preferred, nil
+	}
+	return "", fmt.Errorf("unknown elevation tool %q (use auto, sudo, doas, su or none)", preferred)
+}
+
+// command builds the process running command as asUser
+// Returns whether the sudo password should be written to its stdin
+func (e *LocalExecutor) command(ctx context.Context, command string, asUser string) (*exec.Cmd, bool, error) {
+	if e.shellErr != nil {
+		return nil, false, e.shellErr
+	}
+	shellArgv := append(append([]string{e.shell.Path}, e.shell.Args...), command)
+
+	if asUser == "" {
+		asUser = defaultRunAsUser
+	}
+	currentUser, err := user.Current()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get current user: %w", err)
+	}
+	if asUser == "current" || isCurrentUser(asUser, currentUser.Username) {
+		return exec.CommandContext(ctx, shellArgv[0], shellArgv[1:]...), false, nil
+	}
+
+	switch e.elevation {
+	case ElevationSudo:
+		// sudo -S reads the password from stdin
+		args := append([]string{"-S", "-u", asUser}, shellArgv...)
+		return exec.CommandContext(ctx, "sudo", args...), true, nil
+	case ElevationDoas:
+		// doas only reads passwords from a terminal, so the rule must not need one
+		args := append([]string{"-n", "-u", asUser}, shellArgv...)
+		return exec.CommandContext(ctx, "doas", args...), false, nil
+	case ElevationSu:
+		// su only works without a password when the server runs as root
+		return exec.CommandContext(ctx, "su", "-s", e.shell.Path, "-c", command, asUser), false, nil
+	}
+	return nil, false, fmt.Errorf("cannot run as user '%s': no elevation tool (sudo, doas or su) is available", asUser)
+}
+
+// isCurrentUser reports whether name refers to the user the server runs as
+// Windows usernames are matched with or without their DOMAIN\ prefix
+func isCurrentUser(name, current string) bool {
+	if name == current {
+		return true
+	}
+	if i := strings.LastIndex(current, `\`); i >= 0 {
+		return strings.EqualFold(name, current) || strings.EqualFold(name, current[i+1:])
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package executor
+
+// defaultLocalShells are tried in order when no local shell is configured
+var defaultLocalShells = []string{"bash", "sh"}
+
+// defaultRunAsUser is used when an execution does not name a user
+const defaultRunAsUser = "root"
+
+// elevationSupported reports whether commands can run as another user
+const elevationSupported = true
@@ -0,0 +1,14 @@
+//go:build windows
+
+package executor
+
+// defaultLocalShells are tried in order when no local shell is configured
+var defaultLocalShells = []string{"pwsh", "powershell", "cmd"}
+
+// defaultRunAsUser is used when an execution does not name a user
+// Windows has no root, so commands run as the server's own user
+const defaultRunAsUser = "current"
+
+// elevationSupported reports whether commands can run as another user
+// sudo, doas and su are not available on Windows
+const elevationSupported = false
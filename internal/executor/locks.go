@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrLocked is returned when an exclusive script is already running
+var ErrLocked = errors.New("script is already running")
+
+// LockInfo describes the execution holding a script lock
+type LockInfo struct {
+	Key      string    `json:"key" example:"script:3"`
+	ScriptID int64     `json:"script_id,omitempty" example:"3"`
+	Script   string    `json:"script" example:"db-backup"`
+	Server   string    `json:"server" example:"db1"` // "local" or the server's name
+	HeldBy   string    `json:"held_by,omitempty" example:"user:alice"`
+	Since    time.Time `json:"since"`
+}
+
+// lock is one held lock and the channel closed when it is released
+type lock struct {
+	info     LockInfo
+	released chan struct{}
+}
+
+// Locks keeps executions of exclusive scripts from overlapping
+// Each key is held by at most one execution; a nil Locks never blocks.
+type Locks struct {
+	mu    sync.Mutex
+	locks map[string]*lock
+}
+
+// NewLocks creates an empty lock table
+func NewLocks() *Locks {
+	return &Locks{locks: make(map[string]*lock)}
+}
+
+// Acquire takes the lock named info.Key for an execution described by info
+// When the lock is held and wait is false, it returns ErrLocked with the holder right away;
+// otherwise it waits until the lock is released or ctx is done. The returned unlock must be
+// called when the execution finishes.
+func (l *Locks) Acquire(ctx context.Context, info LockInfo, wait bool) (unlock func(), holder *LockInfo, err error) {
+	if l == nil {
+		return func() {}, nil, nil
+	}
+
+	for {
+		l.mu.Lock()
+		held, ok := l.locks[info.Key]
+		if !ok {
+			info.Since = time.Now().UTC()
+			held = &lock{info: info, released: make(chan struct{})}
+			l.locks[info.Key] = held
+			l.mu.Unlock()
+			return l.unlocker(held), nil, nil
+		}
+		current := held.info
+		l.mu.Unlock()
+
+		if !wait {
+			return nil, &current, ErrLocked
+		}
+		select {
+		case <-held.released:
+		case <-ctx.Done():
+			return nil, &current, ctx.Err()
+		}
+	}
+}
+
+// Held returns the locks currently held, ordered by key
+func (l *Locks) Held() []LockInfo {
+	if l == nil {
+		return []LockInfo{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	held := make([]LockInfo, 0, len(l.locks))
+	for _, lock := range l.locks {
+		held = append(held, lock.info)
+	}
+	sort.Slice(held, func(i, j int) bool { return held[i].Key < held[j].Key })
+	return held
+}
+
+// unlocker returns the function releasing a lock, which does nothing after its first call
+func (l *Locks) unlocker(held *lock) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.locks[held.info.Key] == held {
+				delete(l.locks, held.info.Key)
+			}
+			close(held.released)
+		})
+	}
+}
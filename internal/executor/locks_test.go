@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLocks(t *testing.T) {
+	l := NewLocks()
+	ctx := context.Background()
+
+	unlock, _, err := l.Acquire(ctx, LockInfo{Key: "script:1", Script: "backup", HeldBy: "user:alice"}, false)
+	if err != nil {
+		t.Fatalf("Expected the lock, got %v", err)
+	}
+
+	// A second execution fails fast with the holder
+	_, holder, err := l.Acquire(ctx, LockInfo{Key: "script:1", Script: "backup", HeldBy: "user:bob"}, false)
+	if !errors.Is(err, ErrLocked) || holder == nil || holder.HeldBy != "user:alice" {
+		t.Errorf("Expected ErrLocked held by alice, got %v %+v", err, holder)
+	}
+
+	// Other scripts are not affected
+	unlockOther, _, err := l.Acquire(ctx, LockInfo{Key: "script:2"}, false)
+	if err != nil {
+		t.Fatalf("Expected another script's lock, got %v", err)
+	}
+	if held := l.Held(); len(held) != 2 || held[0].Key != "script:1" || held[0].Since.IsZero() {
+		t.Errorf("Unexpected held locks %+v", held)
+	}
+	unlockOther()
+
+	// Waiting is bounded by the caller's context
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := l.Acquire(timeout, LockInfo{Key: "script:1"}, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline, got %v", err)
+	}
+
+	// A waiting execution gets the lock once it is released
+	acquired := make(chan func())
+	go func() {
+		unlock, _, err := l.Acquire(ctx, LockInfo{Key: "script:1", HeldBy: "user:bob"}, true)
+		if err != nil {
+			t.Errorf("Expected the waiting execution to get the lock, got %v", err)
+		}
+		acquired <- unlock
+	}()
+	unlock()
+	unlock() // Unlocking twice releases once
+	select {
+	case unlock = <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Waiting execution did not get the released lock")
+	}
+	if held := l.Held(); len(held) != 1 || held[0].HeldBy != "user:bob" {
+		t.Errorf("Expected bob to hold the lock, got %+v", held)
+	}
+	unlock()
+	if held := l.Held(); len(held) != 0 {
+		t.Errorf("Expected no locks, got %+v", held)
+	}
+
+	// Nil locks never block
+	var none *Locks
+	if unlock, _, err := none.Acquire(ctx, LockInfo{Key: "script:1"}, false); err != nil || unlock == nil {
+		t.Errorf("Expected nil locks to allow everything, got %v", err)
+	}
+}
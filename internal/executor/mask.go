@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SecretMask is the replacement written in place of secret values
+const SecretMask = "*****"
+
+// minSecretLength is the shortest value that is masked
+// Shorter values (flags such as "1" or "yes") would mask unrelated output
+const minSecretLength = 4
+
+// SecretMasker replaces secret values in execution output with SecretMask
+// It can mask complete output with Mask, or a stream of chunks with Write and
+// Flush, holding back enough output to catch secrets split across chunks
+type SecretMasker struct {
+	secrets []string // Longest first, so a secret containing another is masked whole
+	maxLen  int
+	pending string
+}
+
+// NewSecretMasker creates a masker for the given secret values
+// Empty and very short values are ignored
+func NewSecretMasker(secrets []string) *SecretMasker {
+	m := &SecretMasker{}
+	seen := make(map[string]bool)
+	for _, secret := range secrets {
+		if len(secret) < minSecretLength || seen[secret] {
+			continue
+		}
+		seen[secret] = true
+		m.secrets = append(m.secrets, secret)
+		if len(secret) > m.maxLen {
+			m.maxLen = len(secret)
+		}
+	}
+	sort.Slice(m.secrets, func(i, j int) bool {
+		return len(m.secrets[i]) > len(m.secrets[j])
+	})
+	return m
+}
+
+// Mask replaces every secret value in s
+func (m *SecretMasker) Mask(s string) string {
+	for _, secret := range m.secrets {
+		s = strings.ReplaceAll(s, secret, SecretMask)
+	}
+	return s
+}
+
+// Write masks the next chunk of streamed output and returns the part that is safe to send
+// The end of the chunk is held back while it could be the start of a secret
+func (m *SecretMasker) Write(chunk string) string {
+	if len(m.secrets) == 0 {
+		return chunk
+	}
+
+	text := m.pending + chunk
+	// A secret starting before cut ends within text, so it can be matched now
+	cut := len(text) - (m.maxLen - 1)
+	// Never hold back from the middle of a UTF-8 character
+	for cut > 0 && cut < len(text) && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	var out strings.Builder
+	i := 0
+	for i < cut {
+		matched := false
+		for _, secret := range m.secrets {
+			if strings.HasPrefix(text[i:], secret) {
+				out.WriteString(SecretMask)
+				i += len(secret)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out.WriteByte(text[i])
+			i++
+		}
+	}
+
+	if i < len(text) {
+		m.pending = text[i:]
+	} else {
+		m.pending = ""
+	}
+	return out.String()
+}
+
+// Flush masks and returns output held back by Write
+func (m *SecretMasker) Flush() string {
+	rest := m.Mask(m.pending)
+	m.pending = ""
+	return rest
+}
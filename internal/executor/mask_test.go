@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSecretMaskerStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		chunks  []string
+		want    string
+	}{
+		{
+			name:    "secret in one chunk",
+			secrets: []string{"hunter22"},
+			chunks:  []string{"pass=hunter22\n"},
+			want:    "pass=*****\n",
+		},
+		{
+			name:    "secret split across two writes",
+			secrets: []string{"hunter22"},
+			chunks:  []string{"pass=hun", "ter22\n"},
+			want:    "pass=*****\n",
+		},
+		{
+			name:    "secret split across three writes",
+			secrets: []string{"hunter22"},
+			chunks:  []string{"pass=hu", "nte", "r22 done"},
+			want:    "pass=***** done",
+		},
+		{
+			name:    "overlapping secrets",
+			secrets: []string{"abcdef", "cdefgh"},
+			chunks:  []string{"xabc", "defghx"},
+			want:    "x*****ghx",
+		},
+		{
+			name:    "secrets sharing a prefix",
+			secrets: []string{"token", "token-long"},
+			chunks:  []string{"a tok", "en-long b tok", "en c"},
+			want:    "a ***** b ***** c",
+		},
+		{
+			name:    "multi-byte secret split inside a rune",
+			secrets: []string{"ключ-1234"},
+			chunks:  []string{"ответ: кл\xd1", "\x8eч-1234 ок"},
+			want:    "ответ: ***** ок",
+		},
+		{
+			name:    "multi-byte rune at the cut",
+			secrets: []string{"abcd"},
+			chunks:  []string{"x€y", "z abcd"},
+			want:    "x€yz *****",
+		},
+		{
+			name:    "short secrets are ignored",
+			secrets: []string{"yes", ""},
+			chunks:  []string{"yes", " or no"},
+			want:    "yes or no",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewSecretMasker(tt.secrets)
+			var out strings.Builder
+			for _, chunk := range tt.chunks {
+				written := m.Write(chunk)
+				if !utf8.ValidString(written) {
+					t.Errorf("Write(%q) returned a partial character: %q", chunk, written)
+				}
+				out.WriteString(written)
+			}
+			out.WriteString(m.Flush())
+			if got := out.String(); got != tt.want {
+				t.Errorf("Streamed output = %q, want %q", got, tt.want)
+			}
+			if got := m.Mask(strings.Join(tt.chunks, "")); got != tt.want {
+				t.Errorf("Mask() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretMaskerFlush(t *testing.T) {
+	m := NewSecretMasker([]string{"hunter22"})
+
+	// The end of the output could be the start of the secret, so it is held back
+	if got := m.Write("ok hunt"); got != "" {
+		t.Errorf("Expected the possible secret to be held back, got %q", got)
+	}
+	// It turns out not to be the secret, and is emitted as is
+	if got := m.Flush(); got != "ok hunt" {
+		t.Errorf("Flush() = %q, want %q", got, "ok hunt")
+	}
+	if got := m.Flush(); got != "" {
+		t.Errorf("Expected nothing left after a flush, got %q", got)
+	}
+
+	// Held-back output that the next chunk completes into the secret is masked
+	if got := m.Write("pw: hunter2") + m.Write("2") + m.Flush(); got != "pw: *****" {
+		t.Errorf("Expected the completed secret masked, got %q", got)
+	}
+
+	// Without secrets, chunks pass straight through
+	if got := NewSecretMasker(nil).Write("hunt"); got != "hunt" {
+		t.Errorf("Expected the chunk unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package executor
+
+import "regexp"
+
+// Placeholder is a reference to a secret in script content, resolved when the script runs
+// {{env.NAME}} refers to a stored env variable, {{vault:group/NAME}} to one in Vault
+type Placeholder struct {
+	Key   string // Normalized form without braces or spaces, e.g. "env.DB_PASSWORD"
+	Vault bool   // Whether the variable is read from Vault
+	Group string // Vault group
+	Name  string
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(?:env\.([A-Za-z_][A-Za-z0-9_]*)|vault:([A-Za-z0-9_.-]+)/([A-Za-z_][A-Za-z0-9_]*))\s*\}\}`)
+
+// FindPlaceholders returns the secret placeholders in content, each once, in order of appearance
+func FindPlaceholders(content string) []Placeholder {
+	var placeholders []Placeholder
+	seen := make(map[string]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		p := parsePlaceholder(match)
+		if !seen[p.Key] {
+			seen[p.Key] = true
+			placeholders = append(placeholders, p)
+		}
+	}
+	return placeholders
+}
+
+// RenderPlaceholders replaces each placeholder in content with its value, keyed by Placeholder.Key
+// Values are inserted as is; scripts quote them as their language requires
+func RenderPlaceholders(content string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(content, func(text string) string {
+		p := parsePlaceholder(placeholderPattern.FindStringSubmatch(text))
+		if value, ok := values[p.Key]; ok {
+			return value
+		}
+		return text
+	})
+}
+
+// parsePlaceholder builds a Placeholder from a placeholderPattern match
+func parsePlaceholder(match []string) Placeholder {
+	if match[1] != "" {
+		return Placeholder{Key: "env." + match[1], Name: match[1]}
+	}
+	return Placeholder{Key: "vault:" + match[2] + "/" + match[3], Vault: true, Group: match[2], Name: match[3]}
+}
+
+// String returns the placeholder as written in a script
+func (p Placeholder) String() string {
+	return "{{" + p.Key + "}}"
+}
+
+// commandPlaceholderPattern matches a {{name}} value placeholder of a saved command
+var commandPlaceholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// FindCommandPlaceholders returns the names of the value placeholders in a saved command,
+// each once, in order of appearance
+func FindCommandPlaceholders(command string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range commandPlaceholderPattern.FindAllStringSubmatch(command, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// QuotedCommandPlaceholders returns the names of the value placeholders of a saved command
+// that sit inside single or double quotes, each once, in order of appearance
+// Rendered values are only safe outside quotes: within double quotes command substitutions in
+// a value would run, and within single quotes a value could close the surrounding quote.
+func QuotedCommandPlaceholders(command string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	var quote byte
+	end := 0
+	for _, match := range commandPlaceholderPattern.FindAllStringSubmatchIndex(command, -1) {
+		quote = openQuote(command[end:match[0]], quote)
+		end = match[1]
+		if name := command[match[2]:match[3]]; quote != 0 && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// RenderCommandPlaceholders replaces each value placeholder in a saved command with its
+// value, single-quoted so it stays one shell word whatever it contains
+// Placeholders without a value are left as written.
+func RenderCommandPlaceholders(command string, values map[string]string) string {
+	return commandPlaceholderPattern.ReplaceAllStringFunc(command, func(text string) string {
+		name := commandPlaceholderPattern.FindStringSubmatch(text)[1]
+		if value, ok := values[name]; ok {
+			return shellQuote(value)
+		}
+		return text
+	})
+}
@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandPlaceholders(t *testing.T) {
+	command := "grep {{pattern}} {{ file }} | head -n {{lines}} && echo {{pattern}} {{env.HOME}}"
+	if names := FindCommandPlaceholders(command); !reflect.DeepEqual(names, []string{"pattern", "file", "lines"}) {
+		t.Errorf("Unexpected placeholders %v", names)
+	}
+
+	got := RenderCommandPlaceholders(command, map[string]string{
+		"pattern": "it's; rm -rf /",
+		"file":    "/var/log/$(whoami).log",
+	})
+	want := `grep 'it'\''s; rm -rf /' '/var/log/$(whoami).log' | head -n {{lines}} && echo 'it'\''s; rm -rf /' {{env.HOME}}`
+	if got != want {
+		t.Errorf("RenderCommandPlaceholders() = %s, want %s", got, want)
+	}
+}
+
+func TestQuotedCommandPlaceholders(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{`grep {{pattern}} {{file}}`, nil},
+		{`echo "{{msg}}"`, []string{"msg"}},
+		{`grep '{{p}}' file`, []string{"p"}},
+		{`echo "a $(date) {{when}}" {{who}}`, []string{"when"}},
+		{`echo 'it''s' {{a}} "x" {{b}}`, nil},
+		{`echo \" {{a}} \'`, nil},
+		{`echo "\"{{a}}"`, []string{"a"}},
+		{`echo "'" {{a}} '"' {{b}} "{{a}}"`, []string{"a"}},
+	}
+	for _, tt := range tests {
+		if got := QuotedCommandPlaceholders(tt.command); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("QuotedCommandPlaceholders(%s) = %v, want %v", tt.command, got, tt.expected)
+		}
+	}
+}
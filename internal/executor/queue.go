@@ -0,0 +1,207 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Queue errors
+var (
+	ErrQueueFull    = errors.New("too many executions are waiting")
+	ErrQueueTimeout = errors.New("timed out waiting for a free execution slot")
+)
+
+// QueueConfig holds execution concurrency limits
+type QueueConfig struct {
+	MaxRunning   int           // Executions running at once (0 for no limit)
+	MaxQueued    int           // Executions waiting for a slot before new ones are rejected (0 for no limit)
+	MaxPerServer int           // Executions running at once on one server, unless the server sets its own limit (0 for no limit)
+	Timeout      time.Duration // Longest wait for a slot (0 to wait until the caller gives up)
+}
+
+// QueueStats is a snapshot of the executions running and waiting
+type QueueStats struct {
+	Running    int                `json:"running" example:"3"`
+	Queued     int                `json:"queued" example:"1"`
+	MaxRunning int                `json:"max_running" example:"20"` // 0 when unlimited
+	MaxQueued  int                `json:"max_queued" example:"100"` // 0 when unlimited
+	Servers    []ServerQueueStats `json:"servers"`                  // Servers with running or waiting executions
+}
+
+// ServerQueueStats counts the executions running and waiting on one server
+type ServerQueueStats struct {
+	Server  string `json:"server" example:"web1"`
+	Running int    `json:"running" example:"2"`
+	Queued  int    `json:"queued" example:"1"`
+	Limit   int    `json:"limit" example:"4"` // 0 when unlimited
+}
+
+// serverSlots tracks the executions of one server
+type serverSlots struct {
+	running int
+	queued  int
+	limit   int
+}
+
+// Queue bounds the number of executions running at once, overall and per server,
+// so bursts of API calls wait their turn instead of opening hundreds of SSH sessions.
+// Waiting executions are woken whenever a slot frees up; they are not strictly first in, first out.
+// A nil Queue runs every execution immediately.
+type Queue struct {
+	config  QueueConfig
+	mu      sync.Mutex
+	running int
+	queued  int
+	servers map[string]*serverSlots
+	freed   chan struct{} // Closed and replaced whenever a slot frees up
+}
+
+// NewQueue creates an execution queue
+func NewQueue(config QueueConfig) *Queue {
+	return &Queue{
+		config:  config,
+		servers: make(map[string]*serverSlots),
+		freed:   make(chan struct{}),
+	}
+}
+
+// Acquire waits for a slot to run an execution on server, an empty server meaning the local machine
+// limit overrides the per-server limit when positive. queued, if not nil, is called once if the
+// execution has to wait. The returned release must be called when the execution finishes.
+func (q *Queue) Acquire(ctx context.Context, server string, limit int, queued func()) (release func(), err error) {
+	if q == nil {
+		return func() {}, nil
+	}
+	if limit <= 0 {
+		limit = q.config.MaxPerServer
+	}
+	wait := ctx
+	if q.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		wait, cancel = context.WithTimeout(ctx, q.config.Timeout)
+		defer cancel()
+	}
+
+	waiting := false
+	for {
+		q.mu.Lock()
+		slots := q.slots(server)
+		slots.limit = limit
+		if q.free(server, slots) {
+			if waiting {
+				q.queued--
+				slots.queued--
+			}
+			q.running++
+			slots.running++
+			q.mu.Unlock()
+			return q.releaser(server), nil
+		}
+
+		firstWait := !waiting
+		if firstWait {
+			if q.config.MaxQueued > 0 && q.queued >= q.config.MaxQueued {
+				q.forget(server, slots)
+				q.mu.Unlock()
+				return nil, ErrQueueFull
+			}
+			waiting = true
+			q.queued++
+			slots.queued++
+		}
+		freed := q.freed
+		q.mu.Unlock()
+
+		if firstWait && queued != nil {
+			queued()
+		}
+
+		select {
+		case <-freed:
+		case <-wait.Done():
+			q.mu.Lock()
+			q.queued--
+			slots.queued--
+			q.forget(server, slots)
+			q.mu.Unlock()
+			if ctx.Err() != nil {
+				return nil, ctx.Err() // The caller gave up
+			}
+			return nil, ErrQueueTimeout
+		}
+	}
+}
+
+// Stats returns the executions running and waiting, overall and per server
+func (q *Queue) Stats() QueueStats {
+	if q == nil {
+		return QueueStats{Servers: []ServerQueueStats{}}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := QueueStats{
+		Running:    q.running,
+		Queued:     q.queued,
+		MaxRunning: q.config.MaxRunning,
+		MaxQueued:  q.config.MaxQueued,
+		Servers:    make([]ServerQueueStats, 0, len(q.servers)),
+	}
+	for server, slots := range q.servers {
+		if server == "" {
+			continue
+		}
+		stats.Servers = append(stats.Servers, ServerQueueStats{Server: server, Running: slots.running, Queued: slots.queued, Limit: slots.limit})
+	}
+	sort.Slice(stats.Servers, func(i, j int) bool { return stats.Servers[i].Server < stats.Servers[j].Server })
+	return stats
+}
+
+// free reports whether an execution on server can start now; the caller holds q.mu
+// The local machine is only bound by the overall limit
+func (q *Queue) free(server string, slots *serverSlots) bool {
+	if q.config.MaxRunning > 0 && q.running >= q.config.MaxRunning {
+		return false
+	}
+	return server == "" || slots.limit <= 0 || slots.running < slots.limit
+}
+
+// releaser returns the function giving back a slot on server, which does nothing after its first call
+func (q *Queue) releaser(server string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			slots := q.slots(server)
+			q.running--
+			slots.running--
+			q.forget(server, slots)
+
+			// Wake every waiting execution to check for its slot
+			close(q.freed)
+			q.freed = make(chan struct{})
+		})
+	}
+}
+
+// slots returns the tracking of server, creating it if needed; the caller holds q.mu
+func (q *Queue) slots(server string) *serverSlots {
+	slots, ok := q.servers[server]
+	if !ok {
+		slots = &serverSlots{}
+		q.servers[server] = slots
+	}
+	return slots
+}
+
+// forget stops tracking a server without executions; the caller holds q.mu
+func (q *Queue) forget(server string, slots *serverSlots) {
+	if slots.running == 0 && slots.queued == 0 {
+		delete(q.servers, server)
+	}
+}
@@ -0,0 +1,91 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueueLimits(t *testing.T) {
+	q := NewQueue(QueueConfig{MaxRunning: 3, MaxQueued: 1, MaxPerServer: 2})
+	ctx := context.Background()
+
+	// Two executions fill web1; the local machine is only bound by the overall limit
+	release1, _ := q.Acquire(ctx, "web1", 0, nil)
+	release2, _ := q.Acquire(ctx, "web1", 0, nil)
+	releaseLocal, err := q.Acquire(ctx, "", 0, nil)
+	if err != nil {
+		t.Fatalf("Expected a local slot, got %v", err)
+	}
+
+	// A third web1 execution waits for a slot
+	queued := make(chan struct{})
+	acquired := make(chan func())
+	go func() {
+		release, err := q.Acquire(ctx, "web1", 0, func() { close(queued) })
+		if err != nil {
+			t.Errorf("Expected the waiting execution to run, got %v", err)
+		}
+		acquired <- release
+	}()
+	<-queued
+
+	stats := q.Stats()
+	if stats.Running != 3 || stats.Queued != 1 || len(stats.Servers) != 1 || stats.Servers[0].Running != 2 || stats.Servers[0].Queued != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// The queue holds one waiting execution
+	if _, err := q.Acquire(ctx, "web2", 0, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	// Freeing the local slot does not help web1; freeing a web1 slot does
+	releaseLocal()
+	select {
+	case <-acquired:
+		t.Fatal("Execution exceeded the per-server limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release1()
+	release1() // Releasing twice gives back one slot
+	var release3 func()
+	select {
+	case release3 = <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Waiting execution did not get the freed slot")
+	}
+
+	// A server's own limit overrides the default
+	releaseWeb2, err := q.Acquire(ctx, "web2", 1, nil)
+	if err != nil {
+		t.Fatalf("Expected a web2 slot, got %v", err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.Acquire(timeout, "web2", 1, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline, got %v", err)
+	}
+
+	release2()
+	release3()
+	releaseWeb2()
+	if stats := q.Stats(); stats.Running != 0 || stats.Queued != 0 || len(stats.Servers) != 0 {
+		t.Errorf("Expected an empty queue, got %+v", stats)
+	}
+
+	// Waits are bounded by the queue's timeout
+	q = NewQueue(QueueConfig{MaxRunning: 1, Timeout: 20 * time.Millisecond})
+	release, _ := q.Acquire(ctx, "", 0, nil)
+	defer release()
+	if _, err := q.Acquire(ctx, "", 0, nil); !errors.Is(err, ErrQueueTimeout) {
+		t.Errorf("Expected ErrQueueTimeout, got %v", err)
+	}
+
+	// A nil queue never waits
+	var unlimited *Queue
+	if release, err := unlimited.Acquire(ctx, "web1", 0, nil); err != nil || release == nil {
+		t.Errorf("Expected a nil queue to run everything, got %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -64,29 +65,78 @@ type SSHConfig struct {
 	Username   string // SSH username
 	PrivateKey string // PEM-encoded private key (optional)
 	Password   string // SSH password (optional, used if key auth fails)
+
+	// Certificate for PrivateKey in authorized_keys format, e.g. signed by Vault's SSH CA (optional)
+	Certificate string
+
+	// How the server's host key is verified: a HostKeyPolicy (empty for the executor's
+	// default) and a pinned SHA256 fingerprint, checked instead of known_hosts (optional)
+	HostKeyPolicy      string
+	HostKeyFingerprint string
+}
+
+// hostKeyCallback returns the callback verifying the host key of config's server
+func (e *RemoteExecutor) hostKeyCallback(config *SSHConfig) ssh.HostKeyCallback {
+	if config.HostKeyFingerprint != "" {
+		return pinnedHostKey(config.HostKeyFingerprint)
+	}
+	if config.HostKeyPolicy == HostKeyPolicyInsecure {
+		return ssh.InsecureIgnoreHostKey()
+	}
+	if e.hostKeyVerifier == nil {
+		if config.HostKeyPolicy == HostKeyPolicyStrict {
+			return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				return fmt.Errorf("host key for %s cannot be verified: known_hosts is not available", hostname)
+			}
+		}
+		// Fallback to insecure mode if no verifier configured
+		return ssh.InsecureIgnoreHostKey()
+	}
+
+	trustOnFirstUse := e.hostKeyVerifier.trustOnFirstUse
+	if config.HostKeyPolicy != "" {
+		trustOnFirstUse = config.HostKeyPolicy == HostKeyPolicyTOFU
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return e.hostKeyVerifier.verify(hostname, key, trustOnFirstUse)
+	}
+}
+
+// withCertificate presents signer's key with the configured certificate, if any
+func (config *SSHConfig) withCertificate(signer ssh.Signer) (ssh.Signer, error) {
+	if config.Certificate == "" {
+		return signer, nil
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH certificate: %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("SSH certificate is a plain public key")
+	}
+	return ssh.NewCertSigner(cert, signer)
 }
 
 // Execute runs a command on a remote server via SSH
 // It tries key-based authentication first, then falls back to password if provided
 func (e *RemoteExecutor) Execute(ctx context.Context, command string, config *SSHConfig) *ExecuteResult {
+	return e.ExecuteWithOptions(ctx, command, config, RunOptions{})
+}
+
+// ExecuteWithOptions runs a command on a remote server via SSH with stdin piped through
+// the session and the working directory changed before the command runs
+func (e *RemoteExecutor) ExecuteWithOptions(ctx context.Context, command string, config *SSHConfig, opts RunOptions) *ExecuteResult {
 	startTime := time.Now()
 
 	// Create context with timeout
-	cmdCtx, cancel := context.WithTimeout(ctx, e.defaultTimeout)
+	cmdCtx, cancel := context.WithTimeout(ctx, opts.timeout(e.defaultTimeout))
 	defer cancel()
 
 	// Prepare SSH client configuration
-	var hostKeyCallback ssh.HostKeyCallback
-	if e.hostKeyVerifier != nil {
-		hostKeyCallback = e.hostKeyVerifier.GetHostKeyCallback()
-	} else {
-		// Fallback to insecure mode if no verifier configured
-		hostKeyCallback = ssh.InsecureIgnoreHostKey()
-	}
-
 	sshConfig := &ssh.ClientConfig{
 		User:            config.Username,
-		HostKeyCallback: hostKeyCallback,
+		HostKeyCallback: e.hostKeyCallback(config),
 		Timeout:         10 * time.Second,
 		Auth:            []ssh.AuthMethod{},
 	}
@@ -111,6 +161,15 @@ func (e *RemoteExecutor) Execute(ctx context.Context, command string, config *SS
 		}
 
 		if signer != nil {
+			signer, err = config.withCertificate(signer)
+			if err != nil {
+				return &ExecuteResult{
+					Output:        "",
+					ExitCode:      -1,
+					ExecutionTime: time.Since(startTime).Milliseconds(),
+					Error:         err,
+				}
+			}
 			sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeys(signer))
 		}
 	}
@@ -147,48 +206,89 @@ func (e *RemoteExecutor) Execute(ctx context.Context, command string, config *SS
 		Timeout: 10 * time.Second,
 	}
 
-	conn, err := dialer.DialContext(cmdCtx, "tcp", address)
+	client, attempts, err := connectWithRetry(cmdCtx, opts.Retry, func() (*ssh.Client, error) {
+		conn, err := dialer.DialContext(cmdCtx, "tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+		}
+
+		// Upgrade connection to SSH
+		sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, sshConfig)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("SSH authentication failed: %w", err)
+		}
+		return ssh.NewClient(sshConn, chans, reqs), nil
+	})
 	if err != nil {
 		return &ExecuteResult{
 			Output:        "",
 			ExitCode:      -1,
 			ExecutionTime: time.Since(startTime).Milliseconds(),
-			Error:         fmt.Errorf("failed to connect to %s: %w", address, err),
+			Error:         err,
+			Attempts:      attempts,
 		}
 	}
-	defer conn.Close()
+	defer client.Close()
 
-	// Upgrade connection to SSH
-	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, sshConfig)
+	// Uploaded scripts run from a temporary file, deleted once the command ends
+	if opts.Upload != nil {
+		var remove func()
+		command, remove, err = uploadScript(client, opts.Upload, command)
+		if err != nil {
+			return &ExecuteResult{
+				Output:        "",
+				ExitCode:      -1,
+				ExecutionTime: time.Since(startTime).Milliseconds(),
+				Error:         err,
+				Attempts:      attempts,
+			}
+		}
+		defer remove()
+	}
+
+	// Create a session
+	session, err := client.NewSession()
 	if err != nil {
 		return &ExecuteResult{
 			Output:        "",
 			ExitCode:      -1,
 			ExecutionTime: time.Since(startTime).Milliseconds(),
-			Error:         fmt.Errorf("SSH authentication failed: %w", err),
+			Error:         fmt.Errorf("failed to create SSH session: %w", err),
+			Attempts:      attempts,
 		}
 	}
-	defer sshConn.Close()
+	defer session.Close()
 
-	client := ssh.NewClient(sshConn, chans, reqs)
-	defer client.Close()
+	// Env vars are sent with env requests, except through sudo, which resets the environment
+	if opts.RunAs != "" {
+		command = exportEnv(opts.Env) + command
+	} else {
+		command = setEnv(session, command, opts.Env)
+	}
 
-	// Create a session
-	session, err := client.NewSession()
+	// Commands run as another user go through sudo
+	command, sudo, err := runAs(session, inDir(opts.Dir, command), opts)
 	if err != nil {
 		return &ExecuteResult{
 			Output:        "",
 			ExitCode:      -1,
 			ExecutionTime: time.Since(startTime).Milliseconds(),
-			Error:         fmt.Errorf("failed to create SSH session: %w", err),
+			Error:         fmt.Errorf("failed to request a PTY for sudo: %w", err),
+			Attempts:      attempts,
 		}
 	}
-	defer session.Close()
 
 	// Capture stdout and stderr
 	var stdout, stderr bytes.Buffer
 	session.Stdout = &stdout
+	if sudo != nil {
+		session.Stdout = sudo.writer(&stdout)
+	}
 	session.Stderr = &stderr
+	if opts.Stdin != nil && sudo == nil {
+		session.Stdin = bytes.NewReader(opts.Stdin)
+	}
 
 	// Execute command with context monitoring
 	errChan := make(chan error, 1)
@@ -206,6 +306,9 @@ func (e *RemoteExecutor) Execute(ctx context.Context, command string, config *SS
 		cmdErr = fmt.Errorf("command execution timeout or cancelled")
 	case cmdErr = <-errChan:
 		// Command completed
+		if sudo != nil {
+			stdout.Write(sudo.flush())
+		}
 	}
 
 	// Combine stdout and stderr
@@ -239,6 +342,7 @@ func (e *RemoteExecutor) Execute(ctx context.Context, command string, config *SS
 		ExitCode:      exitCode,
 		ExecutionTime: executionTime,
 		Error:         cmdErr,
+		Attempts:      attempts,
 	}
 }
 
@@ -254,6 +358,12 @@ func (e *RemoteExecutor) ExecuteWithTimeout(ctx context.Context, command string,
 // ExecuteWithStreaming runs a command and streams output in real-time
 // Returns a channel that will receive output chunks as they arrive
 func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command string, config *SSHConfig) (<-chan string, <-chan *ExecuteResult) {
+	return e.ExecuteWithStreamingOptions(ctx, command, config, RunOptions{})
+}
+
+// ExecuteWithStreamingOptions streams a remote command's output with stdin piped through
+// the session and the working directory changed before the command runs
+func (e *RemoteExecutor) ExecuteWithStreamingOptions(ctx context.Context, command string, config *SSHConfig, opts RunOptions) (<-chan string, <-chan *ExecuteResult) {
 	outputChan := make(chan string, 10)
 	resultChan := make(chan *ExecuteResult, 1)
 
@@ -264,16 +374,9 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 		startTime := time.Now()
 
 		// Prepare SSH client configuration (same as Execute)
-		var hostKeyCallback ssh.HostKeyCallback
-		if e.hostKeyVerifier != nil {
-			hostKeyCallback = e.hostKeyVerifier.GetHostKeyCallback()
-		} else {
-			hostKeyCallback = ssh.InsecureIgnoreHostKey()
-		}
-
 		sshConfig := &ssh.ClientConfig{
 			User:            config.Username,
-			HostKeyCallback: hostKeyCallback,
+			HostKeyCallback: e.hostKeyCallback(config),
 			Timeout:         10 * time.Second,
 			Auth:            []ssh.AuthMethod{},
 		}
@@ -281,6 +384,16 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 		if config.PrivateKey != "" {
 			signer, err := ssh.ParsePrivateKey([]byte(config.PrivateKey))
 			if err == nil {
+				signer, err = config.withCertificate(signer)
+				if err != nil {
+					resultChan <- &ExecuteResult{
+						Output:        "",
+						ExitCode:      -1,
+						ExecutionTime: time.Since(startTime).Milliseconds(),
+						Error:         err,
+					}
+					return
+				}
 				sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeys(signer))
 			}
 		}
@@ -301,18 +414,41 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 
 		// Connect to remote server
 		address := fmt.Sprintf("%s:%d", config.Host, config.Port)
-		client, err := ssh.Dial("tcp", address, sshConfig)
+		client, attempts, err := connectWithRetry(ctx, opts.Retry, func() (*ssh.Client, error) {
+			client, err := ssh.Dial("tcp", address, sshConfig)
+			if err != nil {
+				return nil, fmt.Errorf("SSH connection failed: %w", err)
+			}
+			return client, nil
+		})
 		if err != nil {
 			resultChan <- &ExecuteResult{
 				Output:        "",
 				ExitCode:      -1,
 				ExecutionTime: time.Since(startTime).Milliseconds(),
-				Error:         fmt.Errorf("SSH connection failed: %w", err),
+				Error:         err,
+				Attempts:      attempts,
 			}
 			return
 		}
 		defer client.Close()
 
+		if opts.Upload != nil {
+			var remove func()
+			command, remove, err = uploadScript(client, opts.Upload, command)
+			if err != nil {
+				resultChan <- &ExecuteResult{
+					Output:        "",
+					ExitCode:      -1,
+					ExecutionTime: time.Since(startTime).Milliseconds(),
+					Error:         err,
+					Attempts:      attempts,
+				}
+				return
+			}
+			defer remove()
+		}
+
 		session, err := client.NewSession()
 		if err != nil {
 			resultChan <- &ExecuteResult{
@@ -320,11 +456,32 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 				ExitCode:      -1,
 				ExecutionTime: time.Since(startTime).Milliseconds(),
 				Error:         fmt.Errorf("failed to create session: %w", err),
+				Attempts:      attempts,
 			}
 			return
 		}
 		defer session.Close()
 
+		// Env vars are sent with env requests, except through sudo, which resets the environment
+		if opts.RunAs != "" {
+			command = exportEnv(opts.Env) + command
+		} else {
+			command = setEnv(session, command, opts.Env)
+		}
+
+		// Commands run as another user go through sudo
+		command, sudo, err := runAs(session, inDir(opts.Dir, command), opts)
+		if err != nil {
+			resultChan <- &ExecuteResult{
+				Output:        "",
+				ExitCode:      -1,
+				ExecutionTime: time.Since(startTime).Milliseconds(),
+				Error:         fmt.Errorf("failed to request a PTY for sudo: %w", err),
+				Attempts:      attempts,
+			}
+			return
+		}
+
 		// Set up pipes for streaming output
 		stdoutPipe, err := session.StdoutPipe()
 		if err != nil {
@@ -333,6 +490,7 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 				ExitCode:      -1,
 				ExecutionTime: time.Since(startTime).Milliseconds(),
 				Error:         fmt.Errorf("failed to create stdout pipe: %w", err),
+				Attempts:      attempts,
 			}
 			return
 		}
@@ -344,9 +502,13 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 				ExitCode:      -1,
 				ExecutionTime: time.Since(startTime).Milliseconds(),
 				Error:         fmt.Errorf("failed to create stderr pipe: %w", err),
+				Attempts:      attempts,
 			}
 			return
 		}
+		if opts.Stdin != nil && sudo == nil {
+			session.Stdin = bytes.NewReader(opts.Stdin)
+		}
 
 		// Start the command
 		if err := session.Start(command); err != nil {
@@ -355,28 +517,49 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 				ExitCode:      -1,
 				ExecutionTime: time.Since(startTime).Milliseconds(),
 				Error:         fmt.Errorf("failed to start command: %w", err),
+				Attempts:      attempts,
 			}
 			return
 		}
 
+		// Streamed commands run until they finish unless a timeout is requested
+		if opts.Timeout > 0 {
+			timer := time.AfterFunc(opts.Timeout, func() {
+				session.Signal(ssh.SIGKILL)
+				session.Close()
+			})
+			defer timer.Stop()
+		}
+
 		// Read and stream output
 		var fullOutput bytes.Buffer
 		outputDone := make(chan bool)
 
-		// Stream stdout
+		// Stream stdout, without sudo's password prompt
 		go func() {
 			buf := make([]byte, 1024)
 			for {
 				n, err := stdoutPipe.Read(buf)
 				if n > 0 {
 					chunk := string(buf[:n])
-					outputChan <- chunk
-					fullOutput.WriteString(chunk)
+					if sudo != nil {
+						chunk = string(sudo.filter(buf[:n]))
+					}
+					if chunk != "" {
+						outputChan <- chunk
+						fullOutput.WriteString(chunk)
+					}
 				}
 				if err == io.EOF || err != nil {
 					break
 				}
 			}
+			if sudo != nil {
+				if chunk := string(sudo.flush()); chunk != "" {
+					outputChan <- chunk
+					fullOutput.WriteString(chunk)
+				}
+			}
 			outputDone <- true
 		}()
 
@@ -420,8 +603,18 @@ func (e *RemoteExecutor) ExecuteWithStreaming(ctx context.Context, command strin
 			ExitCode:      exitCode,
 			ExecutionTime: executionTime,
 			Error:         cmdErr,
+			Attempts:      attempts,
 		}
 	}()
 
 	return outputChan, resultChan
 }
+
+// inDir prefixes a remote command with a change to dir, exiting when it fails
+// A separate line rather than "cd dir &&" keeps multi-line scripts from running elsewhere
+func inDir(dir, command string) string {
+	if dir == "" {
+		return command
+	}
+	return "cd '" + strings.ReplaceAll(dir, "'", `'\''`) + "' || exit 1\n" + command
+}
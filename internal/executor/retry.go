@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Retry limits
+const (
+	MaxRetries     = 5
+	MaxRetryDelay  = time.Minute
+	DefaultBackoff = time.Second
+)
+
+// RetryPolicy retries connections to a remote server that fail for transient reasons,
+// such as a dial timeout or a reset SSH handshake. Commands that ran are never retried,
+// whatever their exit code.
+type RetryPolicy struct {
+	Retries int           // Retries after the first attempt (0 for none)
+	Backoff time.Duration // Wait before the first retry, doubled for each one (default: 1s)
+}
+
+// Attempt records one connection attempt of a remote execution
+type Attempt struct {
+	Error      string `json:"error,omitempty" example:"failed to connect to 10.0.0.5:22: i/o timeout"` // Empty for the attempt that connected
+	DurationMs int64  `json:"duration_ms" example:"10003"`
+}
+
+// connectWithRetry connects to a remote server, retrying transient failures as the policy allows
+// Attempts are only recorded when the policy allows retries.
+func connectWithRetry(ctx context.Context, policy RetryPolicy, connect func() (*ssh.Client, error)) (*ssh.Client, []Attempt, error) {
+	backoff := policy.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	var attempts []Attempt
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		client, err := connect()
+		if policy.Retries > 0 {
+			record := Attempt{DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				record.Error = err.Error()
+			}
+			attempts = append(attempts, record)
+		}
+		if err == nil || attempt >= policy.Retries || !transient(err) {
+			return client, attempts, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, attempts, err
+		}
+		backoff = min(backoff*2, MaxRetryDelay)
+	}
+}
+
+// transient reports whether a connection failure may succeed when tried again
+// Rejected credentials and unknown host keys fail the same way every time.
+func transient(err error) bool {
+	if strings.Contains(err.Error(), "unable to authenticate") || strings.Contains(err.Error(), "host key") {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, syscall.ENETUNREACH)
+}
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestRetryConnectionFailures(t *testing.T) {
+	// A port nobody listens on refuses connections, which is worth retrying
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	config := &SSHConfig{Host: "127.0.0.1", Port: port, Username: "root", Password: "secret"}
+	result := NewRemoteExecutor().ExecuteWithOptions(context.Background(), "true", config, RunOptions{Retry: RetryPolicy{Retries: 2, Backoff: time.Millisecond}})
+	if result.Error == nil || len(result.Attempts) != 3 || result.Attempts[2].Error == "" {
+		t.Errorf("Expected three failed attempts, got %v %+v", result.Error, result.Attempts)
+	}
+
+	// Without retries nothing is recorded
+	result = NewRemoteExecutor().ExecuteWithOptions(context.Background(), "true", config, RunOptions{})
+	if result.Error == nil || result.Attempts != nil {
+		t.Errorf("Expected one unrecorded attempt, got %v %+v", result.Error, result.Attempts)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("failed to connect to web1:22: %w", &net.OpError{Op: "dial", Err: timeoutError{}}), true},
+		{fmt.Errorf("SSH authentication failed: ssh: handshake failed: %w", io.EOF), true}, // Reset mid-handshake
+		{fmt.Errorf("SSH authentication failed: ssh: handshake failed: %w", context.DeadlineExceeded), true},
+		{errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey]"), false},
+		{errors.New("ssh: handshake failed: host key mismatch for web1"), false},
+	}
+	for _, tt := range tests {
+		if got := transient(tt.err); got != tt.want {
+			t.Errorf("transient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+
+	// Permanent failures are not retried
+	calls := 0
+	_, attempts, err := connectWithRetry(context.Background(), RetryPolicy{Retries: 3, Backoff: time.Millisecond}, func() (*ssh.Client, error) {
+		calls++
+		return nil, errors.New("ssh: unable to authenticate")
+	})
+	if err == nil || calls != 1 || len(attempts) != 1 {
+		t.Errorf("Expected one attempt, got %d calls and %+v", calls, attempts)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,237 @@
+package executor
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP (version 3) packet types and flags used to upload scripts
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpWrite   = 6
+	sftpRemove  = 13
+	sftpStatus  = 101
+	sftpHandle  = 102
+
+	sftpFlagWrite  = 0x02
+	sftpFlagCreate = 0x08
+	sftpFlagExcl   = 0x20
+
+	sftpAttrPermissions = 0x04
+
+	sftpStatusOK = 0
+
+	// sftpChunkSize is the most data sent in one write, which every server accepts
+	sftpChunkSize = 32 * 1024
+)
+
+// sftpClient makes requests to an SFTP server one at a time
+// It implements only what uploading a script needs: creating, writing and removing files.
+type sftpClient struct {
+	w      io.Writer
+	r      io.Reader
+	nextID uint32
+}
+
+// newSFTPClient starts an SFTP session over a subsystem's stdin (w) and stdout (r)
+func newSFTPClient(w io.Writer, r io.Reader) (*sftpClient, error) {
+	c := &sftpClient{w: w, r: r}
+	if err := c.send(sftpInit, binary.BigEndian.AppendUint32(nil, 3)); err != nil {
+		return nil, err
+	}
+	typ, _, err := c.receive()
+	if err != nil {
+		return nil, err
+	}
+	if typ != sftpVersion {
+		return nil, fmt.Errorf("unexpected SFTP packet %d", typ)
+	}
+	return c, nil
+}
+
+// create creates a new file with mode and content, failing if it already exists
+func (c *sftpClient) create(path string, mode os.FileMode, content []byte) error {
+	payload := appendSFTPString(nil, path)
+	payload = binary.BigEndian.AppendUint32(payload, sftpFlagWrite|sftpFlagCreate|sftpFlagExcl)
+	payload = binary.BigEndian.AppendUint32(payload, sftpAttrPermissions)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(mode.Perm()))
+	typ, data, err := c.request(sftpOpen, payload)
+	if err != nil {
+		return err
+	}
+	if typ != sftpHandle {
+		return sftpError("open", typ, data)
+	}
+	handle, _, ok := cutSFTPString(data)
+	if !ok {
+		return fmt.Errorf("invalid SFTP handle")
+	}
+
+	for offset := 0; offset < len(content); offset += sftpChunkSize {
+		chunk := content[offset:min(offset+sftpChunkSize, len(content))]
+		payload := appendSFTPString(nil, handle)
+		payload = binary.BigEndian.AppendUint64(payload, uint64(offset))
+		payload = appendSFTPString(payload, string(chunk))
+		if err := c.status("write", sftpWrite, payload); err != nil {
+			c.status("close", sftpClose, appendSFTPString(nil, handle))
+			return err
+		}
+	}
+	return c.status("close", sftpClose, appendSFTPString(nil, handle))
+}
+
+// remove deletes a file
+func (c *sftpClient) remove(path string) error {
+	return c.status("remove", sftpRemove, appendSFTPString(nil, path))
+}
+
+// status makes a request answered with a status, returning an error unless it is OK
+func (c *sftpClient) status(op string, typ byte, payload []byte) error {
+	respType, data, err := c.request(typ, payload)
+	if err != nil {
+		return err
+	}
+	return sftpError(op, respType, data)
+}
+
+// request sends a request with the next ID and returns the type and payload of its response
+func (c *sftpClient) request(typ byte, payload []byte) (byte, []byte, error) {
+	c.nextID++
+	id := c.nextID
+	if err := c.send(typ, append(binary.BigEndian.AppendUint32(nil, id), payload...)); err != nil {
+		return 0, nil, err
+	}
+	respType, data, err := c.receive()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 4 || binary.BigEndian.Uint32(data) != id {
+		return 0, nil, fmt.Errorf("unexpected SFTP response")
+	}
+	return respType, data[4:], nil
+}
+
+// send writes a packet
+func (c *sftpClient) send(typ byte, payload []byte) error {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+1))
+	packet = append(packet, typ)
+	if _, err := c.w.Write(append(packet, payload...)); err != nil {
+		return fmt.Errorf("SFTP request failed: %w", err)
+	}
+	return nil
+}
+
+// receive reads a packet
+func (c *sftpClient) receive() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("SFTP response failed: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > 256*1024 {
+		return 0, nil, fmt.Errorf("invalid SFTP packet length %d", length)
+	}
+	data := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return 0, nil, fmt.Errorf("SFTP response failed: %w", err)
+	}
+	return header[4], data, nil
+}
+
+// sftpError returns the error of a status response, or nil when it is OK
+func sftpError(op string, typ byte, data []byte) error {
+	if typ != sftpStatus || len(data) < 4 {
+		return fmt.Errorf("SFTP %s failed: unexpected response %d", op, typ)
+	}
+	if code := binary.BigEndian.Uint32(data); code != sftpStatusOK {
+		message, _, _ := cutSFTPString(data[4:])
+		if message == "" {
+			message = fmt.Sprintf("status %d", code)
+		}
+		return fmt.Errorf("SFTP %s failed: %s", op, message)
+	}
+	return nil
+}
+
+// appendSFTPString appends a length-prefixed string
+func appendSFTPString(b []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint32(b, uint32(len(s))), s...)
+}
+
+// cutSFTPString reads a length-prefixed string and returns it with the rest of data
+func cutSFTPString(data []byte) (string, []byte, bool) {
+	if len(data) < 4 {
+		return "", nil, false
+	}
+	length := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < length {
+		return "", nil, false
+	}
+	return string(data[4 : 4+length]), data[4+length:], true
+}
+
+// ScriptUpload is a script copied to the remote server over SFTP before it runs
+// The command of the execution finds the file's path in $webcli_script.
+type ScriptUpload struct {
+	Content   []byte
+	Extension string // File name extension some interpreters need, such as ".ps1"
+}
+
+// uploadScript copies a script to a new temporary file only its owner can access, and
+// returns the command with $webcli_script set to the file and a function deleting it
+func uploadScript(client *ssh.Client, upload *ScriptUpload, command string) (string, func(), error) {
+	suffix := make([]byte, 12)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", nil, err
+	}
+	path := "/tmp/webcli-" + hex.EncodeToString(suffix) + upload.Extension
+
+	if err := withSFTP(client, func(c *sftpClient) error {
+		return c.create(path, 0700, upload.Content)
+	}); err != nil {
+		return "", nil, fmt.Errorf("failed to upload script: %w", err)
+	}
+
+	remove := func() {
+		if err := withSFTP(client, func(c *sftpClient) error { return c.remove(path) }); err != nil {
+			fmt.Printf("Warning: failed to delete uploaded script %s: %v\n", path, err)
+		}
+	}
+	return "webcli_script=" + path + "\n" + command, remove, nil
+}
+
+// withSFTP runs fn with an SFTP session of client
+func withSFTP(client *ssh.Client, fn func(*sftpClient) error) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return fmt.Errorf("SFTP is not available: %w", err)
+	}
+
+	c, err := newSFTPClient(w, r)
+	if err != nil {
+		return err
+	}
+	return fn(c)
+}
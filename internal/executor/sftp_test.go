@@ -0,0 +1,203 @@
+package executor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startSSHServer starts an SSH server accepting any password that runs commands with the
+// local sh and serves SFTP from the local file system
+// Like a default sshd, it only accepts env requests for LANG and LC_* variables.
+func startSSHServer(t *testing.T) int {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("Failed to create host key signer: %v", err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) { return nil, nil },
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go serveSession(channel, requests)
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// serveSession runs an exec request or the sftp subsystem on a session channel
+func serveSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	var env []string
+	for req := range requests {
+		var payload struct{ Value string }
+		ssh.Unmarshal(req.Payload, &payload)
+		switch {
+		case req.Type == "env":
+			var variable struct{ Name, Value string }
+			ssh.Unmarshal(req.Payload, &variable)
+			accepted := variable.Name == "LANG" || strings.HasPrefix(variable.Name, "LC_")
+			if accepted {
+				env = append(env, variable.Name+"="+variable.Value)
+			}
+			req.Reply(accepted, nil)
+		case req.Type == "exec":
+			req.Reply(true, nil)
+			cmd := exec.Command("sh", "-c", payload.Value)
+			cmd.Env = append(os.Environ(), env...)
+			cmd.Stdout, cmd.Stderr = channel, channel.Stderr()
+			status := 0
+			if err := cmd.Run(); err != nil {
+				status = 1
+				if exitErr, ok := err.(*exec.ExitError); ok {
+					status = exitErr.ExitCode()
+				}
+			}
+			channel.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, uint32(status)))
+			return
+		case req.Type == "subsystem" && payload.Value == "sftp":
+			req.Reply(true, nil)
+			serveSFTP(channel)
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// serveSFTP answers the SFTP requests sftpClient makes with the local file system
+func serveSFTP(rw io.ReadWriter) {
+	c := &sftpClient{w: rw, r: rw}
+	files := map[string]*os.File{}
+	typ, _, err := c.receive()
+	if err != nil || typ != sftpInit {
+		return
+	}
+	c.send(sftpVersion, binary.BigEndian.AppendUint32(nil, 3))
+
+	status := func(id []byte, err error) {
+		code := uint32(sftpStatusOK)
+		message := ""
+		if err != nil {
+			code, message = 4, err.Error()
+		}
+		payload := binary.BigEndian.AppendUint32(id, code)
+		c.send(sftpStatus, appendSFTPString(appendSFTPString(payload, message), ""))
+	}
+	for {
+		typ, data, err := c.receive()
+		if err != nil {
+			return
+		}
+		id, data := data[:4], data[4:]
+		switch typ {
+		case sftpOpen:
+			path, rest, _ := cutSFTPString(data)
+			mode := os.FileMode(binary.BigEndian.Uint32(rest[8:]))
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+			if err != nil {
+				status(id, err)
+				continue
+			}
+			files[path] = f
+			c.send(sftpHandle, appendSFTPString(id, path))
+		case sftpWrite:
+			handle, rest, _ := cutSFTPString(data)
+			offset := binary.BigEndian.Uint64(rest)
+			chunk, _, _ := cutSFTPString(rest[8:])
+			_, err := files[handle].WriteAt([]byte(chunk), int64(offset))
+			status(id, err)
+		case sftpClose:
+			handle, _, _ := cutSFTPString(data)
+			status(id, files[handle].Close())
+		case sftpRemove:
+			path, _, _ := cutSFTPString(data)
+			status(id, os.Remove(path))
+		}
+	}
+}
+
+func TestUploadScript(t *testing.T) {
+	port := startSSHServer(t)
+	config := &SSHConfig{Host: "127.0.0.1", Port: port, Username: "deploy", Password: "secret"}
+
+	// Arguments keep their quotes and spaces, and the script is larger than one SFTP write
+	content := "stat -c %a \"$0\"\necho \"$0\"\nprintf '%s|' \"$@\"\necho\necho \"$GREETING\"\n" + strings.Repeat("# padding\n", 10000) + "exit 3\n"
+	command, upload, err := UploadScript("bash", content, []string{"it's", "a b", "$HOME"})
+	if err != nil {
+		t.Fatalf("UploadScript failed: %v", err)
+	}
+
+	env := []EnvVar{{Name: "GREETING", Value: "hello world"}}
+	result := NewRemoteExecutor().ExecuteWithOptions(context.Background(), command, config, RunOptions{Upload: upload, Env: env})
+	lines := strings.Split(strings.TrimSpace(result.Output), "\n")
+	if result.ExitCode != 3 || len(lines) != 4 {
+		t.Fatalf("Expected four lines and exit code 3, got %d: %q (%v)", result.ExitCode, result.Output, result.Error)
+	}
+	if lines[0] != "700" {
+		t.Errorf("Expected the script to be private to its owner, got mode %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "/tmp/webcli-") || !strings.HasSuffix(lines[1], ".sh") {
+		t.Errorf("Expected a temporary script file, got %s", lines[1])
+	}
+	if _, err := os.Stat(lines[1]); !os.IsNotExist(err) {
+		t.Errorf("Expected the script to be deleted, got %v", err)
+	}
+	if lines[2] != "it's|a b|$HOME|" || lines[3] != "hello world" {
+		t.Errorf("Unexpected arguments or environment: %q", lines[2:])
+	}
+
+	// Streaming runs the uploaded file the same way
+	command, upload, _ = UploadScript("sh", "echo \"streamed $1\"\n", []string{"ok"})
+	outputChan, resultChan := NewRemoteExecutor().ExecuteWithStreamingOptions(context.Background(), command, config, RunOptions{Upload: upload})
+	var output strings.Builder
+	for chunk := range outputChan {
+		output.WriteString(chunk)
+	}
+	if result := <-resultChan; result.ExitCode != 0 || output.String() != "streamed ok\n" {
+		t.Errorf("Expected the streamed script output, got %d: %q", result.ExitCode, output.String())
+	}
+
+	if _, _, err := UploadScript("ruby", "puts 1", nil); err == nil {
+		t.Error("Expected an unsupported interpreter to fail")
+	}
+}
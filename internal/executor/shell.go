@@ -0,0 +1,32 @@
+package executor
+
+import "strings"
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// openQuote returns the quote left open after text, starting with quote open (0 for none)
+// Backslashes escape the next character outside single quotes, as in a POSIX shell.
+func openQuote(text string, quote byte) byte {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case c == '"':
+			if quote == '"' {
+				quote = 0
+			} else {
+				quote = '"'
+			}
+		case c == '\'' && quote == 0:
+			quote = '\''
+		}
+	}
+	return quote
+}
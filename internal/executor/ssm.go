@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// SSMRun runs a shell script as root on an instance through AWS Systems Manager and
+// returns its output once it finishes (see ssm.Client.Run)
+type SSMRun func(ctx context.Context, script string, timeout time.Duration) (stdout, stderr string, exitCode int, err error)
+
+// SSMExecutor runs commands on EC2 instances through AWS Systems Manager Run Command
+// instead of SSH. Output is only available once a command finishes.
+type SSMExecutor struct {
+	run            SSMRun
+	script         func(command, user string, opts RunOptions) string // Builds the script run for a command
+	defaultTimeout time.Duration
+}
+
+// NewSSMExecutor creates an executor running commands through run
+func NewSSMExecutor(run SSMRun) *SSMExecutor {
+	return &SSMExecutor{
+		run:            run,
+		script:         ssmScript,
+		defaultTimeout: 5 * time.Minute,
+	}
+}
+
+// ExecuteWithOptions runs a command as user (root without sudo) with stdin piped into it
+// and the working directory changed before it runs
+func (e *SSMExecutor) ExecuteWithOptions(ctx context.Context, command, user string, opts RunOptions) *ExecuteResult {
+	startTime := time.Now()
+
+	timeout := opts.timeout(e.defaultTimeout)
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, stderr, exitCode, err := e.run(cmdCtx, e.script(command, user, opts), timeout)
+
+	// Combine stdout and stderr
+	output := stdout
+	if stderr != "" {
+		if len(output) > 0 {
+			output += "\n"
+		}
+		output += stderr
+	}
+	if err != nil {
+		exitCode = -1
+		if len(output) > 0 {
+			output += "\n"
+		}
+		output += fmt.Sprintf("Error: %v", err)
+	}
+
+	return &ExecuteResult{
+		Output:        output,
+		ExitCode:      exitCode,
+		ExecutionTime: time.Since(startTime).Milliseconds(),
+		Error:         err,
+	}
+}
+
+// ExecuteWithStreamingOptions runs a command like ExecuteWithOptions and sends its whole
+// output as one chunk when it finishes, as Run Command does not stream output
+func (e *SSMExecutor) ExecuteWithStreamingOptions(ctx context.Context, command, user string, opts RunOptions) (<-chan string, <-chan *ExecuteResult) {
+	outputChan := make(chan string, 1)
+	resultChan := make(chan *ExecuteResult, 1)
+
+	go func() {
+		defer close(outputChan)
+		defer close(resultChan)
+
+		result := e.ExecuteWithOptions(ctx, command, user, opts)
+		if result.Output != "" {
+			outputChan <- result.Output
+		}
+		resultChan <- result
+	}()
+
+	return outputChan, resultChan
+}
+
+// ssmScript builds the script run as root for a command: it changes to the working
+// directory, switches to user with sudo and pipes stdin in, decoded from base64
+func ssmScript(command, user string, opts RunOptions) string {
+	return scriptAs(command, user, "root", opts)
+}
+
+// scriptAs builds the script run as runner for a command: it changes to the working directory,
+// switches to user with sudo unless user is runner (or empty) and pipes stdin in, decoded from base64
+func scriptAs(command, user, runner string, opts RunOptions) string {
+	script := inDir(opts.Dir, exportEnv(opts.Env)+command)
+	if user != "" && user != runner {
+		script = "sudo -H -u " + shellQuote(user) + " -- sh -c " + shellQuote(script)
+	} else if opts.Stdin != nil {
+		script = "{\n" + script + "\n}"
+	}
+	if opts.Stdin != nil {
+		script = "printf '%s' '" + base64.StdEncoding.EncodeToString(opts.Stdin) + "' | base64 -d | " + script
+	}
+	return script
+}
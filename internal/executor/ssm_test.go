@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSMScript(t *testing.T) {
+	dir := t.TempDir()
+
+	// The script runs in the instance's shell, so check it with the local one
+	script := ssmScript("pwd; cat", "root", RunOptions{Dir: dir, Stdin: []byte("it's\ninput")})
+	output, err := exec.Command("sh", "-c", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Script failed: %v: %s", err, output)
+	}
+	if string(output) != dir+"\nit's\ninput" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	script = ssmScript("id -un", "deploy", RunOptions{})
+	if script != "sudo -H -u 'deploy' -- sh -c 'id -un'" {
+		t.Errorf("Expected the command to run through sudo, got %q", script)
+	}
+}
+
+func TestSSMExecutor(t *testing.T) {
+	var sent string
+	var sentTimeout time.Duration
+	run := func(ctx context.Context, script string, timeout time.Duration) (string, string, int, error) {
+		sent, sentTimeout = script, timeout
+		return "out", "err", 2, nil
+	}
+
+	result := NewSSMExecutor(run).ExecuteWithOptions(context.Background(), "false", "root", RunOptions{Timeout: time.Minute})
+	if result.ExitCode != 2 || result.Output != "out\nerr" || sent != "false" || sentTimeout != time.Minute {
+		t.Errorf("Unexpected result %+v for %q with %v", result, sent, sentTimeout)
+	}
+
+	outputChan, resultChan := NewSSMExecutor(run).ExecuteWithStreamingOptions(context.Background(), "true", "", RunOptions{})
+	var chunks []string
+	for chunk := range outputChan {
+		chunks = append(chunks, chunk)
+	}
+	if result := <-resultChan; strings.Join(chunks, "") != "out\nerr" || result.ExitCode != 2 || sentTimeout != 5*time.Minute {
+		t.Errorf("Expected the output in one chunk, got %q and %+v", chunks, result)
+	}
+}
+
+func TestAgentExecutor(t *testing.T) {
+	var sent string
+	run := func(ctx context.Context, script string, timeout time.Duration) (string, string, int, error) {
+		sent = script
+		return "", "", 0, nil
+	}
+
+	// Agents running unprivileged need sudo for root too
+	NewAgentExecutor(run, "webcli").ExecuteWithOptions(context.Background(), "id -un", "root", RunOptions{})
+	if sent != "sudo -H -u 'root' -- sh -c 'id -un'" {
+		t.Errorf("Expected the command to run through sudo, got %q", sent)
+	}
+	NewAgentExecutor(run, "webcli").ExecuteWithOptions(context.Background(), "id -un", "webcli", RunOptions{})
+	if sent != "id -un" {
+		t.Errorf("Expected the agent's own user to run without sudo, got %q", sent)
+	}
+	NewAgentExecutor(run, "").ExecuteWithOptions(context.Background(), "id -un", "root", RunOptions{})
+	if sent != "sudo -H -u 'root' -- sh -c 'id -un'" {
+		t.Errorf("Expected agents without a reported user to use sudo, got %q", sent)
+	}
+}
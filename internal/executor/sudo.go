@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sudoPrompt is the password prompt sudo is given, recognized in the output to answer it
+const sudoPrompt = "[webcli] sudo password: "
+
+// sudoCommand wraps a remote command to run as user through sudo
+// Without a password sudo must not ask for one (-n); with one it prompts with sudoPrompt.
+func sudoCommand(user string, withPassword bool, command string) string {
+	flags := "-n"
+	if withPassword {
+		flags = "-p " + shellQuote(sudoPrompt)
+	}
+	return "sudo " + flags + " -H -u " + shellQuote(user) + " -- sh -c " + shellQuote(command)
+}
+
+// runAs prepares a session to run a command as opts.RunAs through sudo and returns the
+// wrapped command; with a sudo password, a PTY is requested (sudo may require one) and
+// the returned responder answers the prompt and must filter the session's output
+func runAs(session *ssh.Session, command string, opts RunOptions) (string, *sudoResponder, error) {
+	if opts.RunAs == "" {
+		return command, nil, nil
+	}
+	if opts.SudoPassword == "" {
+		return sudoCommand(opts.RunAs, false, command), nil, nil
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return "", nil, err
+	}
+	modes := ssh.TerminalModes{ssh.ECHO: 0, ssh.TTY_OP_ISPEED: 14400, ssh.TTY_OP_OSPEED: 14400}
+	if err := session.RequestPty("xterm", 40, 200, modes); err != nil {
+		return "", nil, err
+	}
+	return sudoCommand(opts.RunAs, true, command), &sudoResponder{password: opts.SudoPassword, stdin: stdin}, nil
+}
+
+// sudoResponder answers sudo's password prompt in the output of a PTY, removing the
+// prompt and turning the PTY's CRLF line endings back into LF
+// A second prompt means the password was wrong: stdin is closed so sudo gives up.
+type sudoResponder struct {
+	password    string
+	stdin       io.WriteCloser
+	pending     []byte // Output held back as it may be the start of a prompt or a CRLF
+	answered    bool
+	skipNewline bool // The newline sudo prints after reading the password is dropped
+}
+
+// filter returns the output of a chunk of PTY output, answering any prompt in it
+func (r *sudoResponder) filter(chunk []byte) []byte {
+	data := append(r.pending, chunk...)
+	r.pending = nil
+
+	var out bytes.Buffer
+	for {
+		i := bytes.Index(data, []byte(sudoPrompt))
+		if i < 0 {
+			break
+		}
+		r.emit(&out, data[:i])
+		data = data[i+len(sudoPrompt):]
+		if r.answered {
+			r.stdin.Close()
+			continue
+		}
+		r.stdin.Write([]byte(r.password + "\n"))
+		r.answered, r.skipNewline = true, true
+	}
+
+	// Hold back what may continue in the next chunk
+	hold := 0
+	for n := min(len(data), len(sudoPrompt)-1); n > 0; n-- {
+		if bytes.HasPrefix([]byte(sudoPrompt), data[len(data)-n:]) {
+			hold = n
+			break
+		}
+	}
+	if hold == 0 && bytes.HasSuffix(data, []byte("\r")) {
+		hold = 1
+	}
+	r.pending = append(r.pending, data[len(data)-hold:]...)
+	r.emit(&out, data[:len(data)-hold])
+	return out.Bytes()
+}
+
+// emit writes output with CRLF line endings turned into LF
+func (r *sudoResponder) emit(out *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if r.skipNewline {
+		data = bytes.TrimPrefix(data, []byte("\n"))
+		r.skipNewline = false
+	}
+	out.Write(data)
+}
+
+// writer returns a writer passing output to w through the responder
+func (r *sudoResponder) writer(w io.Writer) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		w.Write(r.filter(p))
+		return len(p), nil
+	})
+}
+
+// writerFunc adapts a function to io.Writer
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// flush returns the output held back at the end of a session
+func (r *sudoResponder) flush() []byte {
+	var out bytes.Buffer
+	r.emit(&out, r.pending)
+	r.pending = nil
+	return out.Bytes()
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"bytes"
+	"testing"
+)
+
+// recordingStdin records what a responder writes to sudo and whether it closed stdin
+type recordingStdin struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (s *recordingStdin) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestSudoCommand(t *testing.T) {
+	if got := sudoCommand("deploy", false, "echo 'hi'"); got != `sudo -n -H -u 'deploy' -- sh -c 'echo '\''hi'\'''` {
+		t.Errorf("Unexpected passwordless command %q", got)
+	}
+	if got := sudoCommand("deploy", true, "id"); got != "sudo -p '[webcli] sudo password: ' -H -u 'deploy' -- sh -c 'id'" {
+		t.Errorf("Unexpected command with password %q", got)
+	}
+}
+
+func TestSudoResponder(t *testing.T) {
+	stdin := &recordingStdin{}
+	r := &sudoResponder{password: "s3cret", stdin: stdin}
+
+	// The prompt is split across chunks and followed by the newline sudo prints
+	var out bytes.Buffer
+	for _, chunk := range []string{"[webcli] sudo", " password: ", "\r", "\nline one\r\nline", " two\r"} {
+		out.Write(r.filter([]byte(chunk)))
+	}
+	out.Write(r.flush())
+	if out.String() != "line one\nline two\r" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+	if stdin.String() != "s3cret\n" || stdin.closed {
+		t.Errorf("Expected the password to be written once, got %q (closed %v)", stdin.String(), stdin.closed)
+	}
+
+	// A second prompt means the password was rejected
+	r.filter([]byte("Sorry, try again.\r\n[webcli] sudo password: "))
+	if stdin.String() != "s3cret\n" || !stdin.closed {
+		t.Errorf("Expected stdin to be closed without a second answer, got %q (closed %v)", stdin.String(), stdin.closed)
+	}
+
+	// Output that only looks like the start of a prompt is passed on at the end
+	r = &sudoResponder{password: "s3cret", stdin: &recordingStdin{}}
+	if got := string(r.filter([]byte("done [web"))); got != "done " {
+		t.Errorf("Expected a possible prompt to be held back, got %q", got)
+	}
+	if got := string(r.flush()); got != "[web" {
+		t.Errorf("Expected the held back output to be flushed, got %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// sudoCheckTimeout bounds each sudo check, so a sudo waiting on a terminal cannot hang it
+const sudoCheckTimeout = 10 * time.Second
+
+// SudoCheck is the outcome of checking that local commands can run as a user through sudo
+type SudoCheck struct {
+	Required       bool   // The user is not the server's own, so commands go through sudo
+	Passwordless   bool   // sudo runs commands as the user without a password
+	PasswordTested bool   // A password was given and checked
+	PasswordWorks  bool   // sudo runs commands as the user with the given password
+	Output         string // What sudo printed when a check failed
+}
+
+// Elevation returns the tool used to run commands as another user (sudo, doas, su or none)
+func (e *LocalExecutor) Elevation() string {
+	return e.elevation
+}
+
+// CheckSudo checks that commands can run as asUser through sudo by running true, first
+// without a password (sudo -n) and then, when password is given, with it (sudo -S)
+// Cached sudo credentials are ignored (-k), so a wrong password is caught.
+func (e *LocalExecutor) CheckSudo(ctx context.Context, asUser, password string) (*SudoCheck, error) {
+	if asUser == "" {
+		asUser = defaultRunAsUser
+	}
+	currentUser, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+	if asUser == "current" || isCurrentUser(asUser, currentUser.Username) {
+		return &SudoCheck{}, nil
+	}
+	if e.elevation != ElevationSudo {
+		return nil, fmt.Errorf("local commands run as other users with %s, not sudo", e.elevation)
+	}
+
+	check := &SudoCheck{Required: true}
+	output, ok := runSudo(ctx, nil, "-n", "-u", asUser, "--", "true")
+	check.Passwordless = ok
+	if !ok {
+		check.Output = output
+	}
+	if password != "" {
+		check.PasswordTested = true
+		output, ok = runSudo(ctx, []byte(password+"\n"), "-S", "-k", "-p", "", "-u", asUser, "--", "true")
+		check.PasswordWorks = ok
+		if !ok {
+			check.Output = output
+		}
+	}
+	return check, nil
+}
+
+// runSudo runs sudo with args and stdin, returning its output and whether it exited with 0
+func runSudo(ctx context.Context, stdin []byte, args ...string) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, sudoCheckTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sudo", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil && len(output) == 0 {
+		output = []byte(err.Error())
+	}
+	return strings.TrimSpace(string(output)), err == nil
+}
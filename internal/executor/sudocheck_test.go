@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSudo needs a password, and accepts "secret"
+const fakeSudo = `#!/bin/sh
+case "$1" in
+-n) echo "sudo: a password is required" >&2; exit 1 ;;
+-S) read -r password; [ "$password" = secret ] && exit 0; echo "sudo: 1 incorrect password attempt" >&2; exit 1 ;;
+esac
+exit 1
+`
+
+func TestCheckSudo(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "sudo"), []byte(fakeSudo), 0755); err != nil {
+		t.Fatalf("Failed to write fake sudo: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	e := NewLocalExecutorWith("", ElevationSudo)
+	if e.Elevation() != ElevationSudo {
+		t.Fatalf("Expected sudo elevation, got %s", e.Elevation())
+	}
+
+	// The server's own user needs no sudo
+	check, err := e.CheckSudo(context.Background(), "current", "")
+	if err != nil || check.Required {
+		t.Errorf("Expected no sudo for the current user, got %+v %v", check, err)
+	}
+
+	check, err = e.CheckSudo(context.Background(), "webcli-test-user", "")
+	if err != nil {
+		t.Fatalf("CheckSudo: %v", err)
+	}
+	if !check.Required || check.Passwordless || check.PasswordTested || check.Output != "sudo: a password is required" {
+		t.Errorf("Expected sudo to need a password, got %+v", check)
+	}
+
+	check, err = e.CheckSudo(context.Background(), "webcli-test-user", "secret")
+	if err != nil || !check.PasswordTested || !check.PasswordWorks {
+		t.Errorf("Expected the password to work, got %+v %v", check, err)
+	}
+
+	check, err = e.CheckSudo(context.Background(), "webcli-test-user", "wrong")
+	if err != nil || !check.PasswordTested || check.PasswordWorks || check.Output != "sudo: 1 incorrect password attempt" {
+		t.Errorf("Expected the wrong password to fail, got %+v %v", check, err)
+	}
+
+	// Other elevation tools are not checked
+	if _, err := NewLocalExecutorWith("", ElevationNone).CheckSudo(context.Background(), "webcli-test-user", ""); err == nil {
+		t.Error("Expected an error without sudo elevation")
+	}
+}
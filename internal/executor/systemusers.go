@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// PasswdFile is the file login users are discovered from
+const PasswdFile = "/etc/passwd"
+
+// noLoginShells are the shells of accounts that cannot log in
+var noLoginShells = map[string]bool{
+	"nologin":  true,
+	"false":    true,
+	"sync":     true,
+	"halt":     true,
+	"shutdown": true,
+}
+
+// SystemUser is a login user of the machine, as listed in the passwd file
+type SystemUser struct {
+	Name     string
+	UID      int
+	GID      int
+	FullName string // First field of the GECOS comment
+	Home     string
+	Shell    string
+}
+
+// ListSystemUsers returns the login users of a passwd file with a UID from minUID to maxUID
+// Accounts that cannot log in (a nologin, false or similar shell) are left out.
+func ListSystemUsers(file string, minUID, maxUID int) ([]SystemUser, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parsePasswd(f, minUID, maxUID)
+}
+
+// parsePasswd reads passwd entries (name:password:uid:gid:gecos:home:shell), skipping
+// comments, NIS entries and malformed lines
+func parsePasswd(r io.Reader, minUID, maxUID int) ([]SystemUser, error) {
+	var users []SystemUser
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) != 7 || fields[0] == "" {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil || uid < minUID || uid > maxUID {
+			continue
+		}
+		gid, err := strconv.Atoi(fields[3])
+		if err != nil {
+			continue
+		}
+		if noLoginShells[path.Base(fields[6])] || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		users = append(users, SystemUser{
+			Name:     fields[0],
+			UID:      uid,
+			GID:      gid,
+			FullName: strings.Split(fields[4], ",")[0],
+			Home:     fields[5],
+			Shell:    fields[6],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read passwd entries: %w", err)
+	}
+	return users, nil
+}
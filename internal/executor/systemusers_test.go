@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePasswd(t *testing.T) {
+	passwd := `# local accounts
+root:x:0:0:root:/root:/bin/bash
+daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin
+alice:x:1000:1000:Alice Smith,,,:/home/alice:/bin/bash
+bob:x:1001:1001::/home/bob:/bin/zsh
+svc:x:1002:1002::/srv/svc:/bin/false
+nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin
+broken:x:abc:1003::/home/broken:/bin/sh
++nisuser::::::
+`
+	users, err := parsePasswd(strings.NewReader(passwd), 1000, 60000)
+	if err != nil {
+		t.Fatalf("parsePasswd: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Expected alice and bob, got %+v", users)
+	}
+	alice := users[0]
+	if alice.Name != "alice" || alice.UID != 1000 || alice.FullName != "Alice Smith" || alice.Home != "/home/alice" || alice.Shell != "/bin/bash" {
+		t.Errorf("Unexpected user %+v", alice)
+	}
+	if users[1].Name != "bob" || users[1].Shell != "/bin/zsh" {
+		t.Errorf("Unexpected user %+v", users[1])
+	}
+
+	// The UID range is inclusive and may include root
+	users, err = parsePasswd(strings.NewReader(passwd), 0, 1000)
+	if err != nil {
+		t.Fatalf("parsePasswd: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "root" || users[1].Name != "alice" {
+		t.Errorf("Expected root and alice, got %+v", users)
+	}
+}
@@ -0,0 +1,155 @@
+package inventory
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/awsauth"
+)
+
+// ec2APIVersion is the EC2 query API version requests are made with
+const ec2APIVersion = "2016-11-15"
+
+// AWS discovers EC2 instances in one or more regions
+// An instance's name comes from its Name tag and its group from its GroupTag tag.
+// Terminated instances are left out, so their servers are deleted.
+type AWS struct {
+	Regions         []string // Regions to list instances in
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Optional, for temporary credentials
+	GroupTag        string // Tag naming the server group, matched case-insensitively (default: group)
+	PrivateIP       bool   // Connect to the private address instead of the public one
+	Endpoint        string // API endpoint for every region (default: https://ec2.<region>.amazonaws.com)
+}
+
+// Name returns "aws"
+func (a *AWS) Name() string {
+	return "aws"
+}
+
+// ec2Instance is an instance in a DescribeInstances response
+type ec2Instance struct {
+	InstanceID string `xml:"instanceId"`
+	PrivateIP  string `xml:"privateIpAddress"`
+	PublicIP   string `xml:"ipAddress"`
+	Tags       []struct {
+		Key   string `xml:"key"`
+		Value string `xml:"value"`
+	} `xml:"tagSet>item"`
+}
+
+// describeInstancesResponse is a page of a DescribeInstances response
+type describeInstancesResponse struct {
+	Reservations []struct {
+		Instances []ec2Instance `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+	NextToken string `xml:"nextToken"`
+}
+
+// ec2ErrorResponse is the body of a failed EC2 request
+type ec2ErrorResponse struct {
+	Errors []struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Errors>Error"`
+}
+
+// Discover lists the instances of every region that are not terminated
+func (a *AWS) Discover(ctx context.Context) ([]Host, error) {
+	groupTag := groupKey(a.GroupTag)
+
+	var hosts []Host
+	for _, region := range a.Regions {
+		instances, err := a.describeInstances(ctx, region)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", region, err)
+		}
+
+		for _, instance := range instances {
+			host := Host{
+				ID:        instance.InstanceID,
+				Name:      instance.InstanceID,
+				IPAddress: pickAddress(instance.PublicIP, instance.PrivateIP, a.PrivateIP),
+			}
+			for _, tag := range instance.Tags {
+				switch {
+				case tag.Key == "Name" && tag.Value != "":
+					host.Name = tag.Value
+				case strings.EqualFold(tag.Key, groupTag):
+					host.Group = tag.Value
+				}
+			}
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// describeInstances lists the instances of a region, following pagination
+func (a *AWS) describeInstances(ctx context.Context, region string) ([]ec2Instance, error) {
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://ec2." + region + ".amazonaws.com"
+	}
+
+	var instances []ec2Instance
+	nextToken := ""
+	for {
+		query := url.Values{
+			"Action":           {"DescribeInstances"},
+			"Version":          {ec2APIVersion},
+			"MaxResults":       {"1000"},
+			"Filter.1.Name":    {"instance-state-name"},
+			"Filter.1.Value.1": {"pending"},
+			"Filter.1.Value.2": {"running"},
+			"Filter.1.Value.3": {"stopping"},
+			"Filter.1.Value.4": {"stopped"},
+		}
+		if nextToken != "" {
+			query.Set("NextToken", nextToken)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.URL.RawQuery = awsauth.CanonicalQuery(query)
+		awsauth.Sign(req, nil, awsauth.Credentials{AccessKeyID: a.AccessKeyID, SecretAccessKey: a.SecretAccessKey, SessionToken: a.SessionToken}, region, "ec2", time.Now())
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("API request failed: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			var errResponse ec2ErrorResponse
+			decodeErr := xml.NewDecoder(resp.Body).Decode(&errResponse)
+			resp.Body.Close()
+			if decodeErr != nil || len(errResponse.Errors) == 0 {
+				return nil, fmt.Errorf("API request failed: %s", resp.Status)
+			}
+			return nil, fmt.Errorf("API request failed: %s: %s", errResponse.Errors[0].Code, errResponse.Errors[0].Message)
+		}
+
+		var response describeInstancesResponse
+		err = xml.NewDecoder(resp.Body).Decode(&response)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid API response: %w", err)
+		}
+
+		for _, reservation := range response.Reservations {
+			instances = append(instances, reservation.Instances...)
+		}
+		if response.NextToken == "" {
+			return instances, nil
+		}
+		nextToken = response.NextToken
+	}
+}
@@ -0,0 +1,131 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DigitalOcean discovers droplets
+// A droplet's group comes from its "<GroupTag>:<group>" tag, e.g. "group:production".
+type DigitalOcean struct {
+	Token     string // API token with read access to droplets
+	GroupTag  string // Tag prefix naming the server group (default: group)
+	PrivateIP bool   // Connect to the private address instead of the public one
+	BaseURL   string // API endpoint (default: https://api.digitalocean.com)
+}
+
+// Name returns "digitalocean"
+func (d *DigitalOcean) Name() string {
+	return "digitalocean"
+}
+
+// doDropletsResponse is a page of the droplets API
+type doDropletsResponse struct {
+	Droplets []struct {
+		ID       int64    `json:"id"`
+		Name     string   `json:"name"`
+		Tags     []string `json:"tags"`
+		Networks struct {
+			V4 []struct {
+				IPAddress string `json:"ip_address"`
+				Type      string `json:"type"`
+			} `json:"v4"`
+		} `json:"networks"`
+	} `json:"droplets"`
+	Links struct {
+		Pages struct {
+			Next string `json:"next"`
+		} `json:"pages"`
+	} `json:"links"`
+}
+
+// Discover lists all droplets of the account
+func (d *DigitalOcean) Discover(ctx context.Context) ([]Host, error) {
+	baseURL := d.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.digitalocean.com"
+	}
+	prefix := groupKey(d.GroupTag) + ":"
+
+	var hosts []Host
+	for page := 1; ; page++ {
+		query := url.Values{"page": {strconv.Itoa(page)}, "per_page": {"200"}}
+		var response doDropletsResponse
+		if err := getJSON(ctx, strings.TrimSuffix(baseURL, "/")+"/v2/droplets?"+query.Encode(), d.Token, &response); err != nil {
+			return nil, err
+		}
+
+		for _, droplet := range response.Droplets {
+			host := Host{ID: strconv.FormatInt(droplet.ID, 10), Name: droplet.Name}
+			for _, tag := range droplet.Tags {
+				if len(tag) > len(prefix) && strings.EqualFold(tag[:len(prefix)], prefix) {
+					host.Group = tag[len(prefix):]
+					break
+				}
+			}
+			var public, private string
+			for _, network := range droplet.Networks.V4 {
+				switch network.Type {
+				case "public":
+					public = network.IPAddress
+				case "private":
+					private = network.IPAddress
+				}
+			}
+			host.IPAddress = pickAddress(public, private, d.PrivateIP)
+			hosts = append(hosts, host)
+		}
+
+		if response.Links.Pages.Next == "" {
+			return hosts, nil
+		}
+	}
+}
+
+// getJSON fetches url with a bearer token and decodes the JSON response into v
+func getJSON(ctx context.Context, url, token string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid API response: %w", err)
+	}
+	return nil
+}
+
+// groupKey returns the tag or label naming the server group
+func groupKey(key string) string {
+	if key == "" {
+		return "group"
+	}
+	return key
+}
+
+// pickAddress returns the address to connect to, falling back to the other one
+func pickAddress(public, private string, preferPrivate bool) string {
+	if preferPrivate {
+		public, private = private, public
+	}
+	if public != "" {
+		return public
+	}
+	return private
+}
@@ -0,0 +1,82 @@
+package inventory
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Hetzner discovers Hetzner Cloud servers
+// A server's group comes from its GroupTag label, e.g. group=production.
+type Hetzner struct {
+	Token     string // API token of the project, read access is enough
+	GroupTag  string // Label naming the server group (default: group)
+	PrivateIP bool   // Connect to the first private network address instead of the public one
+	BaseURL   string // API endpoint (default: https://api.hetzner.cloud)
+}
+
+// Name returns "hetzner"
+func (h *Hetzner) Name() string {
+	return "hetzner"
+}
+
+// hetznerServersResponse is a page of the servers API
+type hetznerServersResponse struct {
+	Servers []struct {
+		ID        int64             `json:"id"`
+		Name      string            `json:"name"`
+		Labels    map[string]string `json:"labels"`
+		PublicNet struct {
+			IPv4 *struct {
+				IP string `json:"ip"`
+			} `json:"ipv4"`
+		} `json:"public_net"`
+		PrivateNet []struct {
+			IP string `json:"ip"`
+		} `json:"private_net"`
+	} `json:"servers"`
+	Meta struct {
+		Pagination struct {
+			NextPage *int `json:"next_page"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+// Discover lists all servers of the project
+func (h *Hetzner) Discover(ctx context.Context) ([]Host, error) {
+	baseURL := h.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.hetzner.cloud"
+	}
+	label := groupKey(h.GroupTag)
+
+	var hosts []Host
+	for page := 1; ; page++ {
+		query := url.Values{"page": {strconv.Itoa(page)}, "per_page": {"50"}}
+		var response hetznerServersResponse
+		if err := getJSON(ctx, strings.TrimSuffix(baseURL, "/")+"/v1/servers?"+query.Encode(), h.Token, &response); err != nil {
+			return nil, err
+		}
+
+		for _, server := range response.Servers {
+			var public, private string
+			if server.PublicNet.IPv4 != nil {
+				public = server.PublicNet.IPv4.IP
+			}
+			if len(server.PrivateNet) > 0 {
+				private = server.PrivateNet[0].IP
+			}
+			hosts = append(hosts, Host{
+				ID:        strconv.FormatInt(server.ID, 10),
+				Name:      server.Name,
+				IPAddress: pickAddress(public, private, h.PrivateIP),
+				Group:     server.Labels[label],
+			})
+		}
+
+		if response.Meta.Pagination.NextPage == nil {
+			return hosts, nil
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package inventory keeps servers in sync with the instances of cloud providers
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// requestTimeout bounds each provider API request so a hung endpoint cannot stall syncing
+const requestTimeout = 30 * time.Second
+
+// httpClient is used for all provider API requests
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// Host is an instance discovered from a provider
+type Host struct {
+	ID        string // Instance ID, unique within the provider
+	Name      string // Instance name; hosts with a name that is not a valid hostname are named <provider>-<id>
+	IPAddress string // Address to connect to; empty when the instance has none right now
+	Group     string // Server group from the instance's group tag or label; empty for the provider's name
+}
+
+// Provider discovers the hosts of a cloud account
+type Provider interface {
+	// Name identifies the provider, e.g. "aws"; servers discovered from it have an inventory ID of <name>:<host ID>
+	Name() string
+	// Discover lists the provider's hosts
+	Discover(ctx context.Context) ([]Host, error)
+}
+
+// Config holds inventory sync settings
+type Config struct {
+	Providers []Provider    // Providers to discover servers from
+	Interval  time.Duration // Time between syncs (default: 10m)
+}
+
+// Status reports the outcome of the most recent sync
+type Status struct {
+	Providers  []string   `json:"providers"`
+	Interval   int        `json:"interval_seconds"`
+	LastSync   *time.Time `json:"last_sync,omitempty"`
+	Discovered int        `json:"discovered"`        // Hosts reported by the providers
+	Created    int        `json:"created"`           // Servers added by the last sync
+	Updated    int        `json:"updated"`           // Servers whose name, address or group changed
+	Deleted    int        `json:"deleted"`           // Servers removed because their instance is gone
+	Skipped    []string   `json:"skipped,omitempty"` // Hosts that could not be synced, with the reason
+	Error      string     `json:"error,omitempty"`   // Why the last sync failed for some providers
+}
+
+// Syncer periodically discovers the hosts of each provider and upserts them as
+// servers, matched by inventory ID. The name, IP address and group of discovered
+// servers follow the provider; their other settings are left alone. Servers whose
+// instance is gone are deleted, unless their provider could not be reached.
+type Syncer struct {
+	config  Config
+	servers *repository.ServerRepository
+
+	syncMu sync.Mutex // Serializes syncs
+
+	mu     sync.Mutex
+	status Status
+
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// New creates a syncer; call Start to sync in the background
+func New(config Config, servers *repository.ServerRepository) (*Syncer, error) {
+	if len(config.Providers) == 0 {
+		return nil, fmt.Errorf("at least one inventory provider is required")
+	}
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Minute
+	}
+
+	names := make([]string, 0, len(config.Providers))
+	for _, provider := range config.Providers {
+		if slices.Contains(names, provider.Name()) {
+			return nil, fmt.Errorf("inventory provider %s is configured twice", provider.Name())
+		}
+		names = append(names, provider.Name())
+	}
+
+	return &Syncer{
+		config:  config,
+		servers: servers,
+		status: Status{
+			Providers: names,
+			Interval:  int(config.Interval / time.Second),
+		},
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}, nil
+}
+
+// Start syncs now and then at every interval until Close is called
+func (s *Syncer) Start() {
+	go func() {
+		defer close(s.stopped)
+
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Sync(context.Background()); err != nil {
+				log.Printf("Warning: inventory sync of servers failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops background syncing
+func (s *Syncer) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// Status returns the outcome of the most recent sync
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Providers = slices.Clone(s.status.Providers)
+	status.Skipped = slices.Clone(s.status.Skipped)
+	return status
+}
+
+// Sync discovers the hosts of every provider and updates the servers now
+// Providers are synced independently: one failing leaves its servers as they are
+// and does not stop the others.
+func (s *Syncer) Sync(ctx context.Context) (Status, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	result := &Status{}
+	var errs []error
+	for _, provider := range s.config.Providers {
+		if err := s.syncProvider(ctx, provider, result); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		}
+	}
+	err := errors.Join(errs...)
+
+	if result.Created+result.Updated+result.Deleted > 0 {
+		log.Printf("Inventory sync: %d servers created, %d updated, %d deleted", result.Created, result.Updated, result.Deleted)
+	}
+
+	s.mu.Lock()
+	now := time.Now().UTC()
+	s.status.LastSync = &now
+	s.status.Discovered = result.Discovered
+	s.status.Created = result.Created
+	s.status.Updated = result.Updated
+	s.status.Deleted = result.Deleted
+	s.status.Skipped = result.Skipped
+	s.status.Error = ""
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+	s.mu.Unlock()
+
+	return s.Status(), err
+}
+
+// syncProvider upserts the hosts of one provider, adding its counts to result
+func (s *Syncer) syncProvider(ctx context.Context, provider Provider, result *Status) error {
+	hosts, err := provider.Discover(ctx)
+	if err != nil {
+		return err
+	}
+	result.Discovered += len(hosts)
+
+	existing, err := s.servers.GetByInventoryProvider(provider.Name())
+	if err != nil {
+		return err
+	}
+	byInventoryID := make(map[string]*models.Server, len(existing))
+	for _, server := range existing {
+		byInventoryID[server.InventoryID] = server
+	}
+
+	for _, host := range hosts {
+		inventoryID := provider.Name() + ":" + host.ID
+		server, ok := byInventoryID[inventoryID]
+		delete(byInventoryID, inventoryID)
+
+		name := host.Name
+		if validation.ValidateHostname(name) != nil {
+			name = provider.Name() + "-" + host.ID
+		}
+		group := strings.TrimSpace(host.Group)
+		if group == "" {
+			group = provider.Name()
+		}
+
+		if !ok {
+			if host.IPAddress == "" {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%s): no IP address", name, inventoryID))
+				continue
+			}
+			if _, err := s.servers.Create(&models.ServerCreate{
+				Name:        name,
+				IPAddress:   host.IPAddress,
+				Group:       group,
+				InventoryID: inventoryID,
+			}); err != nil {
+				return fmt.Errorf("failed to create server %s: %w", name, err)
+			}
+			result.Created++
+			continue
+		}
+
+		// An instance without an address right now (e.g. stopped) keeps its last one
+		if server.Name == name && server.Group == group && (host.IPAddress == "" || server.IPAddress == host.IPAddress) {
+			continue
+		}
+		if _, err := s.servers.Update(server.ID, &models.ServerUpdate{
+			Name:      name,
+			IPAddress: host.IPAddress,
+			Group:     group,
+		}); err != nil {
+			return fmt.Errorf("failed to update server %s: %w", name, err)
+		}
+		result.Updated++
+	}
+
+	for _, server := range byInventoryID {
+		if err := s.servers.Delete(server.ID); err != nil {
+			return fmt.Errorf("failed to delete server %s: %w", server.Name, err)
+		}
+		result.Deleted++
+	}
+
+	return nil
+}
+
+// responseError describes a failed provider API response
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return fmt.Errorf("API request failed: %s", resp.Status)
+	}
+	return fmt.Errorf("API request failed: %s: %s", resp.Status, message)
+}
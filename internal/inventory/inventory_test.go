@@ -0,0 +1,151 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// fakeProvider reports a fixed list of hosts, or an error
+type fakeProvider struct {
+	name  string
+	hosts []Host
+	err   error
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) Discover(ctx context.Context) ([]Host, error) {
+	return p.hosts, p.err
+}
+
+func setupServers(t *testing.T) *repository.ServerRepository {
+	t.Helper()
+	tmpDir := t.TempDir()
+	if err := database.InitializeEncryption(filepath.Join(tmpDir, ".encryption_key")); err != nil {
+		t.Fatalf("Failed to initialize encryption: %v", err)
+	}
+	db, err := database.New(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return repository.NewServerRepository(db)
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{}, nil); err == nil {
+		t.Error("Expected an error without providers")
+	}
+	if _, err := New(Config{Providers: []Provider{&fakeProvider{name: "aws"}, &fakeProvider{name: "aws"}}}, nil); err == nil {
+		t.Error("Expected an error for a provider configured twice")
+	}
+
+	syncer, err := New(Config{Providers: []Provider{&fakeProvider{name: "aws"}, &fakeProvider{name: "hetzner"}}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create syncer: %v", err)
+	}
+	status := syncer.Status()
+	if status.Interval != 600 || len(status.Providers) != 2 || status.LastSync != nil {
+		t.Errorf("Unexpected initial status %+v", status)
+	}
+}
+
+func TestSync(t *testing.T) {
+	servers := setupServers(t)
+
+	// A server added by hand is never touched
+	manual, err := servers.Create(&models.ServerCreate{Name: "manual", IPAddress: "192.0.2.1", Group: "aws"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	aws := &fakeProvider{name: "aws", hosts: []Host{
+		{ID: "i-1", Name: "web1", IPAddress: "203.0.113.1", Group: "production"},
+		{ID: "i-2", Name: "not a hostname", IPAddress: "203.0.113.2"},
+		{ID: "i-3", Name: "pending", IPAddress: ""},
+	}}
+	hetzner := &fakeProvider{name: "hetzner", hosts: []Host{
+		{ID: "42", Name: "db1", IPAddress: "198.51.100.1", Group: "production"},
+	}}
+	syncer, err := New(Config{Providers: []Provider{aws, hetzner}}, servers)
+	if err != nil {
+		t.Fatalf("Failed to create syncer: %v", err)
+	}
+
+	status, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if status.Discovered != 4 || status.Created != 3 || status.Updated != 0 || status.Deleted != 0 || len(status.Skipped) != 1 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if status.LastSync == nil || status.Error != "" {
+		t.Errorf("Expected a successful sync, got %+v", status)
+	}
+
+	discovered, err := servers.GetByInventoryProvider("aws")
+	if err != nil {
+		t.Fatalf("Failed to get servers: %v", err)
+	}
+	byID := map[string]*models.Server{}
+	for _, server := range discovered {
+		byID[server.InventoryID] = server
+	}
+	if len(byID) != 2 {
+		t.Fatalf("Expected 2 AWS servers, got %d", len(byID))
+	}
+	if web := byID["aws:i-1"]; web == nil || web.Name != "web1" || web.IPAddress != "203.0.113.1" || web.Group != "production" || web.Port != 22 {
+		t.Errorf("Unexpected server %+v", web)
+	}
+	if unnamed := byID["aws:i-2"]; unnamed == nil || unnamed.Name != "aws-i-2" || unnamed.Group != "aws" {
+		t.Errorf("Expected an invalid name to fall back to the instance ID and the group to the provider, got %+v", unnamed)
+	}
+
+	// Changed addresses and groups are followed; a host without an address keeps its last one
+	aws.hosts = []Host{
+		{ID: "i-1", Name: "web1", IPAddress: "203.0.113.10", Group: "staging"},
+		{ID: "i-2", Name: "not a hostname", IPAddress: ""},
+	}
+	status, err = syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+	if status.Created != 0 || status.Updated != 1 || status.Deleted != 0 {
+		t.Errorf("Unexpected status after update %+v", status)
+	}
+	web, err := servers.GetByID(byID["aws:i-1"].ID)
+	if err != nil {
+		t.Fatalf("Failed to get server: %v", err)
+	}
+	if web.IPAddress != "203.0.113.10" || web.Group != "staging" {
+		t.Errorf("Expected the new address and group, got %+v", web)
+	}
+	unnamed, err := servers.GetByID(byID["aws:i-2"].ID)
+	if err != nil || unnamed.IPAddress != "203.0.113.2" {
+		t.Errorf("Expected the last address to be kept, got %+v (%v)", unnamed, err)
+	}
+
+	// A failing provider keeps its servers while the others sync
+	hetzner.err = fmt.Errorf("unauthorized")
+	aws.hosts = aws.hosts[:1]
+	status, err = syncer.Sync(context.Background())
+	if err == nil || status.Error == "" {
+		t.Fatal("Expected the Hetzner failure to be reported")
+	}
+	if status.Deleted != 1 {
+		t.Errorf("Expected the vanished AWS instance to be deleted, got %+v", status)
+	}
+	if remaining, _ := servers.GetByInventoryProvider("hetzner"); len(remaining) != 1 {
+		t.Errorf("Expected the Hetzner server to be kept, got %d", len(remaining))
+	}
+
+	if _, err := servers.GetByID(manual.ID); err != nil {
+		t.Errorf("Expected the manual server to be kept: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSDiscover(t *testing.T) {
+	var pages int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/ec2/aws4_request") {
+			t.Errorf("Unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Error("Expected the session token to be sent")
+		}
+		if r.URL.Query().Get("Action") != "DescribeInstances" || r.URL.Query().Get("Filter.1.Name") != "instance-state-name" {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		pages++
+
+		w.Header().Set("Content-Type", "text/xml")
+		if r.URL.Query().Get("NextToken") == "" {
+			fmt.Fprint(w, `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <reservationSet><item><instancesSet>
+    <item>
+      <instanceId>i-1</instanceId>
+      <privateIpAddress>10.0.0.1</privateIpAddress>
+      <ipAddress>203.0.113.1</ipAddress>
+      <tagSet>
+        <item><key>Name</key><value>web1</value></item>
+        <item><key>Group</key><value>production</value></item>
+      </tagSet>
+    </item>
+  </instancesSet></item></reservationSet>
+  <nextToken>page2</nextToken>
+</DescribeInstancesResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <reservationSet><item><instancesSet>
+    <item><instanceId>i-2</instanceId><privateIpAddress>10.0.0.2</privateIpAddress></item>
+  </instancesSet></item></reservationSet>
+</DescribeInstancesResponse>`)
+	}))
+	defer server.Close()
+
+	provider := &AWS{
+		Regions:         []string{"eu-west-1"},
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+		Endpoint:        server.URL,
+	}
+	hosts, err := provider.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if pages != 2 {
+		t.Errorf("Expected 2 pages, got %d", pages)
+	}
+	expected := []Host{
+		{ID: "i-1", Name: "web1", IPAddress: "203.0.113.1", Group: "production"},
+		{ID: "i-2", Name: "i-2", IPAddress: "10.0.0.2"},
+	}
+	if fmt.Sprint(hosts) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected hosts %+v", hosts)
+	}
+
+	provider.PrivateIP = true
+	hosts, err = provider.Discover(context.Background())
+	if err != nil || hosts[0].IPAddress != "10.0.0.1" {
+		t.Errorf("Expected the private address, got %+v (%v)", hosts, err)
+	}
+}
+
+func TestAWSDiscoverError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `<Response><Errors><Error><Code>AuthFailure</Code><Message>AWS was not able to validate the provided access credentials</Message></Error></Errors></Response>`)
+	}))
+	defer server.Close()
+
+	provider := &AWS{Regions: []string{"us-east-1"}, AccessKeyID: "AKID", SecretAccessKey: "wrong", Endpoint: server.URL}
+	_, err := provider.Discover(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "AuthFailure") || !strings.Contains(err.Error(), "us-east-1") {
+		t.Errorf("Expected the AWS error with the region, got %v", err)
+	}
+}
+
+func TestDigitalOceanDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer do-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"id":"unauthorized","message":"Unable to authenticate you."}`)
+			return
+		}
+		if r.URL.Path != "/v2/droplets" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			fmt.Fprintf(w, `{"droplets":[{"id":1,"name":"web1","tags":["env:prod","Group:production"],
+				"networks":{"v4":[{"ip_address":"10.0.0.1","type":"private"},{"ip_address":"203.0.113.1","type":"public"}]}}],
+				"links":{"pages":{"next":"%s/v2/droplets?page=2"}}}`, "https://api.digitalocean.com")
+			return
+		}
+		fmt.Fprint(w, `{"droplets":[{"id":2,"name":"db1","tags":[],"networks":{"v4":[{"ip_address":"10.0.0.2","type":"private"}]}}],"links":{}}`)
+	}))
+	defer server.Close()
+
+	provider := &DigitalOcean{Token: "do-token", BaseURL: server.URL}
+	hosts, err := provider.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	expected := []Host{
+		{ID: "1", Name: "web1", IPAddress: "203.0.113.1", Group: "production"},
+		{ID: "2", Name: "db1", IPAddress: "10.0.0.2"},
+	}
+	if fmt.Sprint(hosts) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected hosts %+v", hosts)
+	}
+
+	provider.Token = "wrong"
+	if _, err := provider.Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "Unable to authenticate") {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
+
+func TestHetznerDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer hcloud-token" {
+			t.Errorf("Unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/v1/servers" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			fmt.Fprint(w, `{"servers":[{"id":42,"name":"web1","labels":{"role":"web","tier":"production"},
+				"public_net":{"ipv4":{"ip":"198.51.100.1"}},"private_net":[{"ip":"10.1.0.2"}]}],
+				"meta":{"pagination":{"page":1,"next_page":2}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"servers":[{"id":43,"name":"db1","labels":{},"public_net":{"ipv4":null},"private_net":[{"ip":"10.1.0.3"}]}],
+			"meta":{"pagination":{"page":2,"next_page":null}}}`)
+	}))
+	defer server.Close()
+
+	provider := &Hetzner{Token: "hcloud-token", GroupTag: "tier", BaseURL: server.URL}
+	hosts, err := provider.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	expected := []Host{
+		{ID: "42", Name: "web1", IPAddress: "198.51.100.1", Group: "production"},
+		{ID: "43", Name: "db1", IPAddress: "10.1.0.3"},
+	}
+	if fmt.Sprint(hosts) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected hosts %+v", hosts)
+	}
+}
@@ -0,0 +1,153 @@
+// Package kms keeps the local encryption key wrapped by an external key service
+// (AWS KMS, Google Cloud KMS, age or SOPS) instead of in plaintext on disk.
+// Each provider runs its own command line tool, so credentials are resolved
+// the way operators already configure them (instance roles, workload identity,
+// profiles, identity files)
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Providers that can wrap the encryption key
+const (
+	ProviderAWS  = "aws-kms"
+	ProviderGCP  = "gcp-kms"
+	ProviderAge  = "age"
+	ProviderSops = "sops"
+)
+
+// IsProvider reports whether name is a key wrapping provider
+func IsProvider(name string) bool {
+	switch name {
+	case ProviderAWS, ProviderGCP, ProviderAge, ProviderSops:
+		return true
+	}
+	return false
+}
+
+// Config holds the settings for wrapping the encryption key
+type Config struct {
+	Provider     string
+	Key          string        // AWS key ID, ARN or alias, or GCP key resource name
+	AgeIdentity  string        // age identity file used to unwrap
+	AgeRecipient string        // age recipient a new key is wrapped for
+	Timeout      time.Duration // Timeout of each command (default: 30s)
+}
+
+// Wrapper wraps and unwraps the encryption key with the provider's command line tool
+// It implements database.KeyWrapper
+type Wrapper struct {
+	cfg Config
+}
+
+// New validates cfg and creates a wrapper
+func New(cfg *Config) (*Wrapper, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("kms config is nil")
+	}
+	switch cfg.Provider {
+	case ProviderAWS:
+		// The key ID is optional to unwrap a symmetric key but required to wrap one
+	case ProviderGCP:
+		if cfg.Key == "" {
+			return nil, fmt.Errorf("ENCRYPTION_KMS_KEY is required for gcp-kms (projects/.../cryptoKeys/...)")
+		}
+	case ProviderAge:
+		if cfg.AgeIdentity == "" {
+			return nil, fmt.Errorf("ENCRYPTION_AGE_IDENTITY is required for age")
+		}
+	case ProviderSops:
+	default:
+		return nil, fmt.Errorf("unknown key wrapping provider %q", cfg.Provider)
+	}
+
+	w := &Wrapper{cfg: *cfg}
+	if w.cfg.Timeout <= 0 {
+		w.cfg.Timeout = 30 * time.Second
+	}
+	return w, nil
+}
+
+// UnwrapKey decrypts the wrapped key stored at path
+func (w *Wrapper) UnwrapKey(path string) ([]byte, error) {
+	switch w.cfg.Provider {
+	case ProviderAWS:
+		args := []string{"kms", "decrypt", "--ciphertext-blob", "fileb://" + path, "--output", "text", "--query", "Plaintext"}
+		if w.cfg.Key != "" {
+			args = append(args, "--key-id", w.cfg.Key)
+		}
+		// The plaintext is printed base64 encoded
+		return w.run(nil, "aws", args...)
+	case ProviderGCP:
+		return w.run(nil, "gcloud", "kms", "decrypt", "--key", w.cfg.Key, "--ciphertext-file", path, "--plaintext-file", "-")
+	case ProviderAge:
+		return w.run(nil, "age", "--decrypt", "--identity", w.cfg.AgeIdentity, path)
+	default:
+		return w.run(nil, "sops", "--decrypt", "--input-type", "binary", "--output-type", "binary", path)
+	}
+}
+
+// WrapKey encrypts key and writes it to path, readable only by the owner
+// The plaintext key is passed on standard input and never written to disk
+func (w *Wrapper) WrapKey(key []byte, path string) error {
+	switch w.cfg.Provider {
+	case ProviderAWS:
+		if w.cfg.Key == "" {
+			return fmt.Errorf("ENCRYPTION_KMS_KEY is required to wrap a new key with aws-kms")
+		}
+		out, err := w.run(key, "aws", "kms", "encrypt", "--key-id", w.cfg.Key, "--plaintext", "fileb:///dev/stdin",
+			"--output", "text", "--query", "CiphertextBlob")
+		if err != nil {
+			return err
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+		if err != nil {
+			return fmt.Errorf("unexpected output from aws kms encrypt: %w", err)
+		}
+		return os.WriteFile(path, wrapped, 0600)
+	case ProviderGCP:
+		if _, err := w.run(key, "gcloud", "kms", "encrypt", "--key", w.cfg.Key, "--plaintext-file", "-", "--ciphertext-file", path); err != nil {
+			return err
+		}
+	case ProviderAge:
+		if w.cfg.AgeRecipient == "" {
+			return fmt.Errorf("ENCRYPTION_AGE_RECIPIENT is required to wrap a new key with age")
+		}
+		if _, err := w.run(key, "age", "--encrypt", "--recipient", w.cfg.AgeRecipient, "--output", path); err != nil {
+			return err
+		}
+	default:
+		// SOPS picks keys from its creation rules, which web-cli does not manage
+		return fmt.Errorf("sops cannot create the key file; encrypt one with sops and set ENCRYPTION_KEY_PATH to it")
+	}
+	return os.Chmod(path, 0600)
+}
+
+// run executes a provider command with stdin and returns its standard output
+func (w *Wrapper) run(stdin []byte, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
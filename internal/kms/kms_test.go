@@ -0,0 +1,76 @@
+package kms
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeAge stands in for the age tool: it "encrypts" by prefixing the input and
+// records its arguments
+const fakeAge = `#!/bin/sh
+echo "$@" >> "$(dirname "$0")/args"
+case "$1" in
+--encrypt) { printf 'wrapped:'; cat; } > "$5" ;;
+--decrypt) tail -c +9 "$4" ;;
+esac
+`
+
+func TestWrapperAge(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "age"), []byte(fakeAge), 0755); err != nil {
+		t.Fatalf("Failed to write fake age: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, err := New(&Config{Provider: ProviderAge}); err == nil {
+		t.Error("Expected error without an identity file")
+	}
+	if _, err := New(&Config{Provider: "rot13"}); err == nil {
+		t.Error("Expected error for an unknown provider")
+	}
+
+	w, err := New(&Config{Provider: ProviderAge, AgeIdentity: "/etc/web-cli/age.key", AgeRecipient: "age1example"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".encryption_key")
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if err := w.WrapKey(key, path); err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+	stored, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(stored, []byte("wrapped:")) {
+		t.Errorf("Key file holds %q, want the wrapped key", stored)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	unwrapped, err := w.UnwrapKey(path)
+	if err != nil {
+		t.Fatalf("UnwrapKey() error = %v", err)
+	}
+	if !bytes.Equal(unwrapped, key) {
+		t.Errorf("UnwrapKey() = %q, want %q", unwrapped, key)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(bin, "args"))
+	want := "--encrypt --recipient age1example --output " + path + "\n--decrypt --identity /etc/web-cli/age.key " + path + "\n"
+	if string(args) != want {
+		t.Errorf("age was run with:\n%s\nwant:\n%s", args, want)
+	}
+
+	// A failing tool reports its error output
+	os.WriteFile(filepath.Join(bin, "age"), []byte("#!/bin/sh\necho 'no identity matched' >&2\nexit 1\n"), 0755)
+	if _, err := w.UnwrapKey(path); err == nil || !bytes.Contains([]byte(err.Error()), []byte("no identity matched")) {
+		t.Errorf("UnwrapKey() error = %v, want the tool's message", err)
+	}
+
+	sops, _ := New(&Config{Provider: ProviderSops})
+	if err := sops.WrapKey(key, filepath.Join(t.TempDir(), "key")); err == nil {
+		t.Error("Expected sops to refuse creating a key file")
+	}
+}
@@ -0,0 +1,301 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// requestTimeout bounds API requests other than exec
+const requestTimeout = 30 * time.Second
+
+// Client talks to the API server of one cluster
+type Client struct {
+	server    *url.URL
+	namespace string
+	tlsConfig *tls.Config
+	http      *http.Client
+	creds     *credentials
+}
+
+// newClient creates a client for the API server
+func newClient(server *url.URL, namespace string, tlsConfig *tls.Config, creds *credentials) (*Client, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{
+		server:    server,
+		namespace: namespace,
+		tlsConfig: tlsConfig,
+		http:      &http.Client{Transport: transport, Timeout: requestTimeout},
+		creds:     creds,
+	}, nil
+}
+
+// Server returns the URL of the API server
+func (c *Client) Server() string {
+	return c.server.String()
+}
+
+// Namespace returns the namespace used when an execution names none
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// ListNamespaces returns the names of the cluster's namespaces
+func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := c.get(ctx, "/api/v1/namespaces", nil, &list); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		names = append(names, item.Metadata.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// podList is the subset of a pod list that is used
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string            `json:"name"`
+			Namespace         string            `json:"namespace"`
+			Labels            map[string]string `json:"labels"`
+			CreationTimestamp time.Time         `json:"creationTimestamp"`
+		} `json:"metadata"`
+		Spec struct {
+			NodeName   string `json:"nodeName"`
+			Containers []struct {
+				Name string `json:"name"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			Phase string `json:"phase"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// ListPods returns the pods of a namespace, sorted by name
+// selector is a label selector such as "app=web", or empty for every pod.
+func (c *Client) ListPods(ctx context.Context, namespace, selector string) ([]models.KubernetesPod, error) {
+	query := url.Values{}
+	if selector != "" {
+		query.Set("labelSelector", selector)
+	}
+	var list podList
+	if err := c.get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/pods", query, &list); err != nil {
+		return nil, err
+	}
+
+	pods := make([]models.KubernetesPod, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := models.KubernetesPod{
+			Name:      item.Metadata.Name,
+			Namespace: item.Metadata.Namespace,
+			Phase:     item.Status.Phase,
+			Node:      item.Spec.NodeName,
+			Labels:    item.Metadata.Labels,
+			CreatedAt: item.Metadata.CreationTimestamp,
+		}
+		for _, container := range item.Spec.Containers {
+			pod.Containers = append(pod.Containers, container.Name)
+		}
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+// get fetches an API path and decodes the JSON response into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	u := *c.server
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if err := c.creds.authorize(ctx, req.Header); err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("Kubernetes API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid Kubernetes API response: %w", err)
+	}
+	return nil
+}
+
+// status is a Kubernetes Status object, returned for failed requests and finished execs
+type status struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Details struct {
+		Causes []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"causes"`
+	} `json:"details"`
+}
+
+// statusError describes a failed API response, using its Status message when there is one
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var s status
+	if json.Unmarshal(body, &s) == nil && s.Message != "" {
+		return fmt.Errorf("Kubernetes API request failed: %s: %s", resp.Status, s.Message)
+	}
+	if message := strings.TrimSpace(string(body)); message != "" {
+		return fmt.Errorf("Kubernetes API request failed: %s: %s", resp.Status, message)
+	}
+	return fmt.Errorf("Kubernetes API request failed: %s", resp.Status)
+}
+
+// credentials authenticate requests to the API server
+// Client certificates are part of the TLS configuration instead.
+type credentials struct {
+	token     string
+	tokenFile string // Read for every request, as it may be rotated
+	username  string
+	password  string
+	exec      *execPlugin
+}
+
+// authorize sets the Authorization header of a request, if any credentials apply
+func (c *credentials) authorize(ctx context.Context, header http.Header) error {
+	token := c.token
+	switch {
+	case c.tokenFile != "":
+		data, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read Kubernetes token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	case c.exec != nil:
+		var err error
+		if token, err = c.exec.getToken(ctx); err != nil {
+			return err
+		}
+	}
+
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else if c.username != "" {
+		req := http.Request{Header: header}
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return nil
+}
+
+// execPluginTTL is how long a token of an exec plugin is used when it has no expiry
+const execPluginTTL = 10 * time.Minute
+
+// execPlugin runs a credential plugin, such as "aws eks get-token", for tokens
+type execPlugin struct {
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	APIVersion string   `yaml:"apiVersion"`
+	Env        []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+
+	dir string // Directory of the kubeconfig, for relative commands
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// getToken returns the plugin's cached token, or runs the plugin for a new one
+func (p *execPlugin) getToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+
+	apiVersion := p.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1beta1"
+	}
+	command := p.Command
+	if strings.Contains(command, "/") {
+		command = resolvePath(command, p.dir)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, p.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range p.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	cmd.Env = append(cmd.Env, `KUBERNETES_EXEC_INFO={"apiVersion":"`+apiVersion+`","kind":"ExecCredential","spec":{"interactive":false}}`)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("Kubernetes credential plugin %s failed: %s", p.Command, message)
+	}
+
+	var credential struct {
+		Status struct {
+			Token               string     `json:"token"`
+			ExpirationTimestamp *time.Time `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(output, &credential); err != nil {
+		return "", fmt.Errorf("invalid output of Kubernetes credential plugin %s: %w", p.Command, err)
+	}
+	if credential.Status.Token == "" {
+		return "", fmt.Errorf("Kubernetes credential plugin %s returned no token", p.Command)
+	}
+
+	p.token = credential.Status.Token
+	p.expires = time.Now().Add(execPluginTTL)
+	if expiry := credential.Status.ExpirationTimestamp; expiry != nil {
+		p.expires = expiry.Add(-30 * time.Second)
+	}
+	return p.token, nil
+}
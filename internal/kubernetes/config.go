@@ -0,0 +1,245 @@
+// Package kubernetes lists pods and runs commands in them through the Kubernetes API
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// In-cluster service account files and environment, as mounted into every pod
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceHostEnv    = "KUBERNETES_SERVICE_HOST"
+	servicePortEnv    = "KUBERNETES_SERVICE_PORT"
+)
+
+// Config selects how to reach the cluster
+type Config struct {
+	Kubeconfig string // Path to a kubeconfig file
+	Context    string // Context of the kubeconfig to use (default: its current context)
+	InCluster  bool   // Use the service account of the pod web-cli runs in instead of a kubeconfig
+}
+
+// kubeconfig is the subset of a kubeconfig file that is supported
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+			TLSServerName            string `yaml:"tls-server-name"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string      `yaml:"token"`
+			TokenFile             string      `yaml:"tokenFile"`
+			ClientCertificate     string      `yaml:"client-certificate"`
+			ClientCertificateData string      `yaml:"client-certificate-data"`
+			ClientKey             string      `yaml:"client-key"`
+			ClientKeyData         string      `yaml:"client-key-data"`
+			Username              string      `yaml:"username"`
+			Password              string      `yaml:"password"`
+			Exec                  *execPlugin `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// New creates a client from a kubeconfig file or the in-cluster service account
+func New(config Config) (*Client, error) {
+	if config.InCluster {
+		if config.Kubeconfig != "" {
+			return nil, fmt.Errorf("a kubeconfig and in-cluster configuration cannot be used together")
+		}
+		return inCluster(serviceAccountDir)
+	}
+	if config.Kubeconfig == "" {
+		return nil, fmt.Errorf("a kubeconfig path or in-cluster configuration is required")
+	}
+	return fromKubeconfig(config.Kubeconfig, config.Context)
+}
+
+// inCluster creates a client from the service account mounted in dir
+// The token is read for every request, as the kubelet rotates it.
+func inCluster(dir string) (*Client, error) {
+	host, port := os.Getenv(serviceHostEnv), os.Getenv(servicePortEnv)
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster: %s and %s are not set", serviceHostEnv, servicePortEnv)
+	}
+
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	tlsConfig, err := newTLSConfig(ca, false, "")
+	if err != nil {
+		return nil, err
+	}
+	namespace, _ := os.ReadFile(filepath.Join(dir, "namespace"))
+
+	tokenFile := filepath.Join(dir, "token")
+	if _, err := os.Stat(tokenFile); err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	return newClient(&url.URL{Scheme: "https", Host: net.JoinHostPort(host, port)}, strings.TrimSpace(string(namespace)), tlsConfig,
+		&credentials{tokenFile: tokenFile})
+}
+
+// fromKubeconfig creates a client from a context of a kubeconfig file
+func fromKubeconfig(path, contextName string) (*Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	dir := filepath.Dir(path)
+
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	if contextName == "" {
+		return nil, fmt.Errorf("kubeconfig has no current context: set one or choose a context")
+	}
+	contextIndex := -1
+	for i, c := range kc.Contexts {
+		if c.Name == contextName {
+			contextIndex = i
+			break
+		}
+	}
+	if contextIndex < 0 {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+	context := kc.Contexts[contextIndex].Context
+
+	clusterIndex := -1
+	for i, c := range kc.Clusters {
+		if c.Name == context.Cluster {
+			clusterIndex = i
+			break
+		}
+	}
+	if clusterIndex < 0 {
+		return nil, fmt.Errorf("cluster %q of context %q not found in kubeconfig", context.Cluster, contextName)
+	}
+	cluster := kc.Clusters[clusterIndex].Cluster
+
+	server, err := url.Parse(cluster.Server)
+	if err != nil || (server.Scheme != "https" && server.Scheme != "http") || server.Host == "" {
+		return nil, fmt.Errorf("invalid server %q of cluster %q", cluster.Server, context.Cluster)
+	}
+
+	ca, err := readData(cluster.CertificateAuthorityData, cluster.CertificateAuthority, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate authority of cluster %q: %w", context.Cluster, err)
+	}
+	tlsConfig, err := newTLSConfig(ca, cluster.InsecureSkipTLSVerify, cluster.TLSServerName)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &credentials{}
+	if context.User != "" {
+		found := false
+		for _, u := range kc.Users {
+			if u.Name != context.User {
+				continue
+			}
+			found = true
+			user := u.User
+
+			creds.token = user.Token
+			if user.TokenFile != "" {
+				creds.tokenFile = resolvePath(user.TokenFile, dir)
+			}
+			creds.username, creds.password = user.Username, user.Password
+			if user.Exec != nil {
+				user.Exec.dir = dir
+				creds.exec = user.Exec
+			}
+
+			cert, err := readData(user.ClientCertificateData, user.ClientCertificate, dir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read client certificate of user %q: %w", context.User, err)
+			}
+			key, err := readData(user.ClientKeyData, user.ClientKey, dir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read client key of user %q: %w", context.User, err)
+			}
+			if cert != nil || key != nil {
+				pair, err := tls.X509KeyPair(cert, key)
+				if err != nil {
+					return nil, fmt.Errorf("invalid client certificate of user %q: %w", context.User, err)
+				}
+				tlsConfig.Certificates = []tls.Certificate{pair}
+			}
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("user %q of context %q not found in kubeconfig", context.User, contextName)
+		}
+	}
+
+	namespace := context.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return newClient(server, namespace, tlsConfig, creds)
+}
+
+// newTLSConfig returns the TLS settings for the API server
+func newTLSConfig(ca []byte, insecure bool, serverName string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName, InsecureSkipVerify: insecure}
+	if ca != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid certificate authority: no PEM certificates found")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// readData returns base64 inline data, or the contents of a file relative to dir
+func readData(data, file, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(resolvePath(file, dir))
+	}
+	return nil, nil
+}
+
+// resolvePath makes a path of a kubeconfig relative to the kubeconfig's directory
+func resolvePath(path, dir string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,162 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Exec stream protocols, preferred first
+// v5 adds closing stdin, which commands reading their input need to finish.
+const (
+	protocolV5 = "v5.channel.k8s.io"
+	protocolV4 = "v4.channel.k8s.io"
+)
+
+// Exec stream channels: the first byte of every message
+const (
+	channelStdin  = 0
+	channelStdout = 1
+	channelStderr = 2
+	channelError  = 3
+	channelClose  = 255
+)
+
+// stdinChunkSize is the largest stdin message sent at once
+const stdinChunkSize = 32 * 1024
+
+// ExecOptions selects the container and command of an exec
+type ExecOptions struct {
+	Namespace string
+	Pod       string
+	Container string   // Empty for the pod's only container
+	Command   []string // Program and arguments; not run through a shell
+	Stdin     []byte   // Piped into the command (nil for no input)
+}
+
+// Exec runs a command in a container, copying its output to stdout and stderr as
+// it arrives, and returns its exit code
+// Cancelling ctx closes the connection; the API server then stops streaming, but
+// a process that ignores its closed streams may keep running in the container.
+func (c *Client) Exec(ctx context.Context, opts ExecOptions, stdout, stderr io.Writer) (int, error) {
+	query := url.Values{"stdout": {"true"}, "stderr": {"true"}}
+	for _, arg := range opts.Command {
+		query.Add("command", arg)
+	}
+	if opts.Container != "" {
+		query.Set("container", opts.Container)
+	}
+	if opts.Stdin != nil {
+		query.Set("stdin", "true")
+	}
+
+	u := *c.server
+	u.Scheme = map[string]string{"https": "wss", "http": "ws"}[u.Scheme]
+	u.Path = u.Path + "/api/v1/namespaces/" + url.PathEscape(opts.Namespace) + "/pods/" + url.PathEscape(opts.Pod) + "/exec"
+	u.RawQuery = query.Encode()
+
+	header := http.Header{}
+	if err := c.creds.authorize(ctx, header); err != nil {
+		return -1, err
+	}
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  c.tlsConfig,
+		HandshakeTimeout: requestTimeout,
+		Subprotocols:     []string{protocolV5, protocolV4},
+	}
+	conn, resp, err := dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			defer resp.Body.Close()
+			return -1, statusError(resp)
+		}
+		return -1, fmt.Errorf("Kubernetes exec failed: %w", err)
+	}
+	defer conn.Close()
+
+	if opts.Stdin != nil && conn.Subprotocol() != protocolV5 {
+		return -1, fmt.Errorf("stdin for Kubernetes exec requires an API server supporting %s (Kubernetes 1.30 or newer)", protocolV5)
+	}
+
+	// Closing the connection ends the read loop when the context ends first
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if opts.Stdin != nil {
+		go writeStdin(conn, opts.Stdin)
+	}
+
+	var result *status
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return -1, fmt.Errorf("command execution timeout or cancelled")
+			}
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || result != nil {
+				break
+			}
+			return -1, fmt.Errorf("Kubernetes exec stream failed: %w", err)
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case channelStdout:
+			stdout.Write(data[1:])
+		case channelStderr:
+			stderr.Write(data[1:])
+		case channelError:
+			result = &status{}
+			if err := json.Unmarshal(data[1:], result); err != nil {
+				return -1, fmt.Errorf("invalid Kubernetes exec status: %w", err)
+			}
+		}
+	}
+
+	return exitCode(result)
+}
+
+// writeStdin sends stdin in chunks, then closes it so the command sees its end
+func writeStdin(conn *websocket.Conn, stdin []byte) {
+	deadline := time.Now().Add(requestTimeout)
+	for len(stdin) > 0 {
+		n := min(len(stdin), stdinChunkSize)
+		message := append([]byte{channelStdin}, stdin[:n]...)
+		conn.SetWriteDeadline(deadline)
+		if err := conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+			return
+		}
+		stdin = stdin[n:]
+	}
+	conn.WriteMessage(websocket.BinaryMessage, []byte{channelClose, channelStdin})
+}
+
+// exitCode reads the exit code from the status sent when an exec finishes
+func exitCode(result *status) (int, error) {
+	if result == nil {
+		return -1, fmt.Errorf("Kubernetes exec ended without a status")
+	}
+	if result.Status == "Success" {
+		return 0, nil
+	}
+	if result.Reason == "NonZeroExitCode" {
+		for _, cause := range result.Details.Causes {
+			if cause.Reason == "ExitCode" {
+				if code, err := strconv.Atoi(cause.Message); err == nil {
+					return code, nil
+				}
+			}
+		}
+	}
+	return -1, fmt.Errorf("Kubernetes exec failed: %s", result.Message)
+}
@@ -0,0 +1,292 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeCluster serves the pod, namespace and exec APIs of a cluster
+type fakeCluster struct {
+	*httptest.Server
+	protocols []string // Exec subprotocols the API server supports
+
+	mu    sync.Mutex
+	auths []string // Authorization headers received
+}
+
+func newFakeCluster(t *testing.T) *fakeCluster {
+	c := &fakeCluster{protocols: []string{protocolV5, protocolV4}}
+	c.Server = httptest.NewTLSServer(http.HandlerFunc(c.serve))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *fakeCluster) serve(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	c.auths = append(c.auths, r.Header.Get("Authorization"))
+	c.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/api/v1/namespaces":
+		fmt.Fprint(w, `{"items":[{"metadata":{"name":"kube-system"}},{"metadata":{"name":"default"}}]}`)
+	case r.URL.Path == "/api/v1/namespaces/default/pods":
+		if r.URL.Query().Get("labelSelector") == "app=none" {
+			fmt.Fprint(w, `{"items":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"items":[
+			{"metadata":{"name":"web-2","namespace":"default","labels":{"app":"web"}},"spec":{"nodeName":"node-1","containers":[{"name":"app"}]},"status":{"phase":"Running"}},
+			{"metadata":{"name":"web-1","namespace":"default","labels":{"app":"web"},"creationTimestamp":"2026-01-02T03:04:05Z"},"spec":{"containers":[{"name":"app"},{"name":"sidecar"}]},"status":{"phase":"Pending"}}
+		]}`)
+	case r.URL.Path == "/api/v1/namespaces/default/pods/web-1/exec":
+		c.exec(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"kind":"Status","status":"Failure","message":"pods \"missing\" not found","reason":"NotFound"}`)
+	}
+}
+
+// exec runs a fake command: it echoes its command line and stdin, and exits with the
+// code following "exit " in it
+func (c *fakeCluster) exec(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{Subprotocols: c.protocols}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	command := strings.Join(r.URL.Query()["command"], " ")
+	conn.WriteMessage(websocket.BinaryMessage, append([]byte{channelStdout}, "ran: "+command+"\n"...))
+	conn.WriteMessage(websocket.BinaryMessage, append([]byte{channelStderr}, "container: "+r.URL.Query().Get("container")...))
+
+	if r.URL.Query().Get("stdin") == "true" {
+		var stdin []byte
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil || data[0] == channelClose {
+				break
+			}
+			stdin = append(stdin, data[1:]...)
+		}
+		conn.WriteMessage(websocket.BinaryMessage, append([]byte{channelStdout}, "stdin: "+string(stdin)...))
+	}
+
+	result := `{"status":"Success"}`
+	if _, code, ok := strings.Cut(command, "exit "); ok {
+		result = `{"status":"Failure","message":"command terminated with non-zero exit code","reason":"NonZeroExitCode","details":{"causes":[{"reason":"ExitCode","message":"` + code + `"}]}}`
+	}
+	conn.WriteMessage(websocket.BinaryMessage, append([]byte{channelError}, result...))
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// kubeconfig writes a kubeconfig for the cluster with the given user fields
+func (c *fakeCluster) kubeconfig(t *testing.T, user string) string {
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Certificate().Raw})
+	config := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+users:
+- name: test
+  user:
+%s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+- name: other
+  context:
+    cluster: missing
+`, c.URL, base64.StdEncoding.EncodeToString(ca), user)
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+	return path
+}
+
+func TestListPods(t *testing.T) {
+	cluster := newFakeCluster(t)
+	client, err := New(Config{Kubeconfig: cluster.kubeconfig(t, "    token: secret-token")})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.Namespace() != "default" || client.Server() != cluster.URL {
+		t.Errorf("Unexpected client %s %s", client.Server(), client.Namespace())
+	}
+
+	pods, err := client.ListPods(context.Background(), "default", "app=web")
+	if err != nil {
+		t.Fatalf("Failed to list pods: %v", err)
+	}
+	if len(pods) != 2 || pods[0].Name != "web-1" || pods[1].Name != "web-2" {
+		t.Fatalf("Expected pods sorted by name, got %+v", pods)
+	}
+	if pods[0].Phase != "Pending" || len(pods[0].Containers) != 2 || pods[0].CreatedAt.Year() != 2026 {
+		t.Errorf("Unexpected pod %+v", pods[0])
+	}
+	if pods[1].Node != "node-1" || pods[1].Labels["app"] != "web" {
+		t.Errorf("Unexpected pod %+v", pods[1])
+	}
+
+	namespaces, err := client.ListNamespaces(context.Background())
+	if err != nil || strings.Join(namespaces, ",") != "default,kube-system" {
+		t.Errorf("Expected sorted namespaces, got %v (%v)", namespaces, err)
+	}
+
+	if _, err := client.ListPods(context.Background(), "missing", ""); err == nil || !strings.Contains(err.Error(), `pods "missing" not found`) {
+		t.Errorf("Expected the API status message, got %v", err)
+	}
+	if cluster.auths[0] != "Bearer secret-token" {
+		t.Errorf("Expected the kubeconfig token, got %q", cluster.auths[0])
+	}
+}
+
+func TestExec(t *testing.T) {
+	cluster := newFakeCluster(t)
+	client, err := New(Config{Kubeconfig: cluster.kubeconfig(t, "    username: admin\n    password: secret")})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code, err := client.Exec(context.Background(), ExecOptions{
+		Namespace: "default",
+		Pod:       "web-1",
+		Container: "app",
+		Command:   []string{"sh", "-c", "cat; exit 3"},
+		Stdin:     []byte("hello"),
+	}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("Expected exit code 3, got %d", code)
+	}
+	if stdout.String() != "ran: sh -c cat; exit 3\nstdin: hello" || stderr.String() != "container: app" {
+		t.Errorf("Unexpected output %q %q", stdout.String(), stderr.String())
+	}
+	if !strings.HasPrefix(cluster.auths[0], "Basic ") {
+		t.Errorf("Expected basic auth, got %q", cluster.auths[0])
+	}
+
+	stdout.Reset()
+	code, err = client.Exec(context.Background(), ExecOptions{Namespace: "default", Pod: "web-1", Command: []string{"true"}}, &stdout, io.Discard)
+	if err != nil || code != 0 || stdout.String() != "ran: true\n" {
+		t.Errorf("Expected a successful exec, got %d %q (%v)", code, stdout.String(), err)
+	}
+
+	if _, err := client.Exec(context.Background(), ExecOptions{Namespace: "default", Pod: "missing", Command: []string{"true"}}, io.Discard, io.Discard); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an error for a missing pod, got %v", err)
+	}
+
+	// Older API servers cannot close stdin, so input is refused rather than hanging
+	cluster.protocols = []string{protocolV4}
+	if _, err := client.Exec(context.Background(), ExecOptions{Namespace: "default", Pod: "web-1", Command: []string{"cat"}, Stdin: []byte("x")}, io.Discard, io.Discard); err == nil || !strings.Contains(err.Error(), protocolV5) {
+		t.Errorf("Expected stdin to require %s, got %v", protocolV5, err)
+	}
+	code, err = client.Exec(context.Background(), ExecOptions{Namespace: "default", Pod: "web-1", Command: []string{"true"}}, io.Discard, io.Discard)
+	if err != nil || code != 0 {
+		t.Errorf("Expected exec without stdin to work over %s, got %d (%v)", protocolV4, code, err)
+	}
+}
+
+func TestKubeconfigErrors(t *testing.T) {
+	cluster := newFakeCluster(t)
+	path := cluster.kubeconfig(t, "    token: x")
+
+	if _, err := New(Config{}); err == nil {
+		t.Error("Expected an error without a kubeconfig or in-cluster configuration")
+	}
+	if _, err := New(Config{Kubeconfig: path, InCluster: true}); err == nil {
+		t.Error("Expected an error for a kubeconfig with in-cluster configuration")
+	}
+	if _, err := New(Config{Kubeconfig: path, Context: "staging"}); err == nil || !strings.Contains(err.Error(), `context "staging" not found`) {
+		t.Errorf("Expected a missing context error, got %v", err)
+	}
+	if _, err := New(Config{Kubeconfig: path, Context: "other"}); err == nil || !strings.Contains(err.Error(), `cluster "missing"`) {
+		t.Errorf("Expected a missing cluster error, got %v", err)
+	}
+	if _, err := New(Config{Kubeconfig: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("Expected an error for a missing kubeconfig")
+	}
+}
+
+func TestInCluster(t *testing.T) {
+	cluster := newFakeCluster(t)
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cluster.Certificate().Raw})
+	os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0600)
+	os.WriteFile(filepath.Join(dir, "token"), []byte("sa-token\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "namespace"), []byte("apps"), 0600)
+
+	t.Setenv(serviceHostEnv, "")
+	if _, err := inCluster(dir); err == nil {
+		t.Error("Expected an error outside a cluster")
+	}
+
+	host, port, _ := strings.Cut(strings.TrimPrefix(cluster.URL, "https://"), ":")
+	t.Setenv(serviceHostEnv, host)
+	t.Setenv(servicePortEnv, port)
+	client, err := inCluster(dir)
+	if err != nil {
+		t.Fatalf("Failed to create in-cluster client: %v", err)
+	}
+	if client.Namespace() != "apps" {
+		t.Errorf("Expected the service account namespace, got %q", client.Namespace())
+	}
+
+	// The token is read for every request, so a rotated token is used
+	os.WriteFile(filepath.Join(dir, "token"), []byte("rotated-token"), 0600)
+	if _, err := client.ListNamespaces(context.Background()); err != nil {
+		t.Fatalf("Failed to list namespaces: %v", err)
+	}
+	if cluster.auths[0] != "Bearer rotated-token" {
+		t.Errorf("Expected the rotated token, got %q", cluster.auths[0])
+	}
+}
+
+func TestExecPlugin(t *testing.T) {
+	cluster := newFakeCluster(t)
+	dir := t.TempDir()
+	credential, _ := json.Marshal(map[string]any{"status": map[string]string{"token": "plugin-token"}})
+	plugin := filepath.Join(dir, "credential")
+	os.WriteFile(plugin, []byte("#!/bin/sh\necho '"+string(credential)+"'\necho run >> "+filepath.Join(dir, "runs")+"\n"), 0700)
+
+	client, err := New(Config{Kubeconfig: cluster.kubeconfig(t, "    exec:\n      command: "+plugin)})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	for range 2 {
+		if _, err := client.ListNamespaces(context.Background()); err != nil {
+			t.Fatalf("Failed to list namespaces: %v", err)
+		}
+	}
+	if cluster.auths[0] != "Bearer plugin-token" || cluster.auths[1] != "Bearer plugin-token" {
+		t.Errorf("Expected the plugin's token, got %v", cluster.auths)
+	}
+	if runs, _ := os.ReadFile(filepath.Join(dir, "runs")); string(runs) != "run\n" {
+		t.Errorf("Expected the token to be cached after one run, got %q", runs)
+	}
+}
@@ -3,21 +3,109 @@ package middleware
 import (
 	"crypto/subtle"
 	"errors"
+	"math"
 	"net/http"
 	"os"
+	"path"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
 )
 
+// TokenAuthenticator resolves a bearer token issued through the API
+// Returns nil if the token is unknown or expired
+type TokenAuthenticator func(token string) *models.APIToken
+
+// executionPaths are the API endpoints that run commands and require the execute scope
+// Entries are path.Match patterns, so routes with IDs are covered with "*".
+var executionPaths = []string{
+	"/api/commands/execute",
+	"/api/bash-scripts/execute",
+	"/api/bash-scripts/execute/stream",
+	"/api/saved-commands/*/execute",
+	"/api/server-groups/*/commands/execute",
+	"/api/server-groups/*/bash-scripts/execute",
+	"/api/servers/*/wake",
+	"/api/terminal/ws",
+}
+
+// executionApprovalPaths are the API endpoints that let executions run without running anything
+// themselves, and also require the execute scope
+var executionApprovalPaths = []string{
+	"/api/approvals/*/approve",
+}
+
+// agentConnectPath is the API endpoint web-cli agents connect to, requiring the agent scope
+const agentConnectPath = "/api/agents/connect"
+
+// adminPathPrefixes are the API endpoints that require admin access: the admin scope for
+// API tokens, and an "admin" OU for client certificates
+var adminPathPrefixes = []string{
+	"/api/tokens",
+	"/api/group-permissions",
+	"/api/policies",
+	"/api/webhooks",
+	"/api/notification-rules",
+	"/api/auth/",
+	"/api/vault/config",
+	"/api/vault/sync",
+	"/api/terminal/recordings",
+	"/api/audit",
+	"/api/git-sync",
+	"/api/export",
+	"/api/import",
+	"/api/inventory",
+	"/api/events",
+	"/api/executions/queue",
+	"/api/executions/locks",
+	"/api/executions/running",
+	"/api/env-variables/export",
+	"/api/local-users/import",
+	"/api/system/users",
+	"/api/system/backup",
+	"/api/system/restore",
+}
+
+// adminEndpoints are the admin-only endpoints of resources that non-admins may otherwise use
+// Entries are "METHOD pattern" with path.Match patterns.
+var adminEndpoints = []string{
+	"GET /api/agents",
+	"PUT /api/maintenance",
+	"POST /api/maintenance-windows",
+	"PUT /api/maintenance-windows/*",
+	"DELETE /api/maintenance-windows/*",
+	"GET /api/quotas",
+	"POST /api/quotas",
+	"PUT /api/quotas/*",
+	"DELETE /api/quotas/*",
+	"POST /api/server-groups",
+	"PUT /api/server-groups/*",
+	"DELETE /api/server-groups/*",
+	"POST /api/local-users/*/test-sudo",
+	"GET /api/terminal/sessions",
+	"DELETE /api/terminal/sessions/*",
+}
+
 // ErrAuthMisconfigured is returned when authentication is enabled but credentials are missing
-var ErrAuthMisconfigured = errors.New("authentication is enabled but no credentials are configured: set AUTH_USERNAME and AUTH_PASSWORD, or AUTH_API_TOKEN")
+var ErrAuthMisconfigured = errors.New("authentication is enabled but no credentials are configured: set AUTH_USERNAME and AUTH_PASSWORD, AUTH_API_TOKEN, or TLS_CLIENT_CA_PATH")
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	Enabled      bool
-	Username     string
-	Password     string
-	APIToken     string
-	ExcludePaths []string // Paths exempt from authentication (e.g., /api/health)
+	Enabled        bool
+	Username       string
+	Password       string
+	APIToken       string
+	ClientCertAuth bool               // Accept verified TLS client certificates as authentication
+	ExcludePaths   []string           // Paths exempt from authentication (e.g., /api/health)
+	Lockout        *LoginLockout      // Tracks failed attempts and locks out brute-force clients (nil to disable)
+	TokenAuth      TokenAuthenticator // Resolves scoped API tokens (nil to disable)
+	BasePath       string             // Path prefix the application is served under, for scope checks
+	Sessions       *SessionManager    // Verifies login session tokens (nil to disable session login)
+	LoginPage      http.Handler       // Served to unauthenticated browsers instead of the Basic Auth prompt
 }
 
 // LoadAuthConfig loads authentication configuration from environment
@@ -45,7 +133,7 @@ func (c *AuthConfig) Validate() error {
 	hasBasicAuth := c.Username != "" && c.Password != ""
 	hasAPIToken := c.APIToken != ""
 
-	if !hasBasicAuth && !hasAPIToken {
+	if !hasBasicAuth && !hasAPIToken && !c.ClientCertAuth {
 		return ErrAuthMisconfigured
 	}
 
@@ -70,45 +158,242 @@ func BasicAuth(config *AuthConfig) func(http.Handler) http.Handler {
 				}
 			}
 
+			// Accept a client certificate verified against the configured CA
+			if config.ClientCertAuth && HasVerifiedClientCert(r) {
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), clientCertPrincipal(r))))
+				return
+			}
+
+			// Accept a login session (cookie, Bearer JWT, or access_token on WebSocket upgrades)
+			if config.Sessions != nil {
+				if token := sessionToken(r); token != "" {
+					if session, err := config.Sessions.Validate(token); err == nil {
+						ctx := WithSession(r.Context(), session)
+						ctx = audit.WithActor(ctx, session.Username)
+						ctx = WithPrincipal(ctx, &Principal{Name: "user:" + session.Username, Admin: true})
+						next.ServeHTTP(w, r.WithContext(ctx))
+						return
+					}
+				}
+			}
+
+			// Reject clients locked out after repeated failed attempts
+			ip := RemoteIP(r)
+			attemptedUser, _, _ := r.BasicAuth()
+			if retryAfter := config.Lockout.RetryAfter(ip, attemptedUser); retryAfter > 0 {
+				audit.GetLogger().LogAuthAttempt(r, audit.OutcomeDenied, "lockout")
+				SetRetryAfter(w, retryAfter)
+				apierror.WithCode(w, apierror.CodeLoginLocked, "Too many failed login attempts. Try again later.", http.StatusTooManyRequests)
+				return
+			}
+
 			// Check for API token first (Bearer token in Authorization header)
 			authHeader := r.Header.Get("Authorization")
 			if strings.HasPrefix(authHeader, "Bearer ") {
 				token := strings.TrimPrefix(authHeader, "Bearer ")
 				if config.APIToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(config.APIToken)) == 1 {
-					next.ServeHTTP(w, r)
+					recordAuthSuccess(config, r, ip, "", "token")
+					next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), &Principal{Name: "token", Admin: true})))
 					return
 				}
+
+				// Scoped API tokens may only call endpoints covered by their scopes
+				if config.TokenAuth != nil {
+					if apiToken := config.TokenAuth(token); apiToken != nil {
+						recordAuthSuccess(config, r, ip, "", "api_token")
+						ctx := audit.WithActor(r.Context(), "token:"+apiToken.Name)
+						ctx = WithPrincipal(ctx, &Principal{
+							Name:  "token:" + apiToken.Name,
+							Roles: apiToken.Roles,
+							Admin: apiToken.HasScope(models.APITokenScopeAdmin),
+
+							AgentServerID: apiToken.ServerID,
+						})
+						r = r.WithContext(ctx)
+						scope := RequiredScope(r, config.BasePath)
+						if !apiToken.HasScope(scope) {
+							audit.GetLogger().LogAuthAttempt(r, audit.OutcomeDenied, "api_token")
+							apierror.WithCode(w, apierror.CodeInsufficientScope, "API token does not have the required scope: "+scope, http.StatusForbidden)
+							return
+						}
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+				recordAuthFailure(config, r, ip, "", "token")
 			}
 
 			// Fall back to Basic Auth
 			if config.Username != "" && config.Password != "" {
 				username, password, ok := r.BasicAuth()
 				if !ok {
-					requireAuth(w)
+					challenge(config, w, r)
 					return
 				}
 
-				// Use constant time comparison to prevent timing attacks
-				usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(config.Username)) == 1
-				passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(config.Password)) == 1
-
-				if !usernameMatch || !passwordMatch {
+				if !config.CheckPassword(username, password) {
+					recordAuthFailure(config, r, ip, username, "basic")
 					requireAuth(w)
 					return
 				}
 
-				next.ServeHTTP(w, r)
+				recordAuthSuccess(config, r, ip, username, "basic")
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), &Principal{Name: "user:" + username, Admin: true})))
+				return
+			}
+
+			// Client certificate is the only configured method but none was presented
+			if config.ClientCertAuth {
+				apierror.WithCode(w, apierror.CodeCertificateRequired, "Client certificate required", http.StatusUnauthorized)
 				return
 			}
 
 			// If auth is enabled but no credentials configured, deny access
-			http.Error(w, "Authentication required but not configured", http.StatusInternalServerError)
+			apierror.Error(w, "Authentication required but not configured", http.StatusInternalServerError)
 		})
 	}
 }
 
+// RequiredScope returns the API token scope needed for a request
+func RequiredScope(r *http.Request, basePath string) string {
+	path := strings.TrimPrefix(r.URL.Path, basePath)
+
+	if isExecutionPath(path) || matchesPath(executionApprovalPaths, path) {
+		return models.APITokenScopeExecute
+	}
+
+	if path == agentConnectPath {
+		return models.APITokenScopeAgent
+	}
+
+	if isAdminPath(r.Method, path) {
+		return models.APITokenScopeAdmin
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return models.APITokenScopeRead
+	}
+
+	return models.APITokenScopeWrite
+}
+
+// isAdminPath reports whether the API path (without base path) requires admin access for the method
+func isAdminPath(method, apiPath string) bool {
+	for _, prefix := range adminPathPrefixes {
+		if strings.HasPrefix(apiPath, prefix) {
+			return true
+		}
+	}
+	for _, endpoint := range adminEndpoints {
+		endpointMethod, pattern, _ := strings.Cut(endpoint, " ")
+		if ok, _ := path.Match(pattern, apiPath); ok && endpointMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAdmin rejects callers without admin access on admin-only endpoints
+// API tokens are already held to them by the admin scope in BasicAuth; this covers the
+// other principals, such as client certificates without an "admin" OU.
+func RequireAdmin(basePath string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isAdminPath(r.Method, strings.TrimPrefix(r.URL.Path, basePath)) || PrincipalFromContext(r.Context()).IsAdmin() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			apierror.Error(w, "This endpoint requires admin access", http.StatusForbidden)
+		})
+	}
+}
+
+// isExecutionPath reports whether the API path (without base path) runs commands
+func isExecutionPath(apiPath string) bool {
+	return matchesPath(executionPaths, apiPath)
+}
+
+// matchesPath reports whether the API path (without base path) matches one of the path.Match patterns
+func matchesPath(patterns []string, apiPath string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, apiPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// HasVerifiedClientCert reports whether the request presented a TLS client
+// certificate that was verified against the configured client CA
+func HasVerifiedClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0
+}
+
+// CheckPassword reports whether the username and password match the configured credentials
+func (c *AuthConfig) CheckPassword(username, password string) bool {
+	if c.Username == "" || c.Password == "" {
+		return false
+	}
+
+	// Use constant time comparison to prevent timing attacks
+	usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(c.Username)) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(c.Password)) == 1
+
+	return usernameMatch && passwordMatch
+}
+
+// recordAuthFailure counts a failed attempt towards lockout and audits it
+func recordAuthFailure(config *AuthConfig, r *http.Request, ip, username, method string) {
+	config.Lockout.RecordFailure(ip, username)
+	audit.GetLogger().LogAuthAttempt(r, audit.OutcomeFailure, method)
+}
+
+// recordAuthSuccess clears failed attempts for the client
+// Successful requests are only audited when they follow failures, since every
+// request carries credentials and logging them all would flood the audit log
+func recordAuthSuccess(config *AuthConfig, r *http.Request, ip, username, method string) {
+	if config.Lockout.RecordSuccess(ip, username) {
+		audit.GetLogger().LogAuthAttempt(r, audit.OutcomeSuccess, method)
+	}
+}
+
+// challenge responds to a request that presented no credentials
+// Browsers navigating to the application get the login page when session login is
+// enabled; clients with a stale session cookie get a plain 401 so the browser does
+// not fall back to its Basic Auth prompt
+func challenge(config *AuthConfig, w http.ResponseWriter, r *http.Request) {
+	if config.Sessions != nil {
+		if config.LoginPage != nil && wantsLoginPage(r, config.BasePath) {
+			config.LoginPage.ServeHTTP(w, r)
+			return
+		}
+		if _, err := r.Cookie(SessionCookieName); err == nil {
+			apierror.WithCode(w, apierror.CodeSessionExpired, "Session expired", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	requireAuth(w)
+}
+
+// wantsLoginPage reports whether the request is a browser page load outside the API
+func wantsLoginPage(r *http.Request, basePath string) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	if strings.HasPrefix(strings.TrimPrefix(r.URL.Path, basePath), "/api/") {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// SetRetryAfter sets the Retry-After header, rounding up to whole seconds
+func SetRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
 // requireAuth sends a 401 response requesting authentication
 func requireAuth(w http.ResponseWriter) {
 	w.Header().Set("WWW-Authenticate", `Basic realm="Web CLI"`)
-	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
 }
@@ -1,10 +1,15 @@
 package middleware
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+
+	"github.com/pozgo/web-cli/internal/models"
 )
 
 func TestBasicAuth_Disabled(t *testing.T) {
@@ -336,3 +341,250 @@ func TestBasicAuth_ExcludedPathsMultiple(t *testing.T) {
 		})
 	}
 }
+
+func TestBasicAuth_ClientCertificate(t *testing.T) {
+	config := &AuthConfig{
+		Enabled:        true,
+		Username:       "admin",
+		Password:       "secret",
+		ClientCertAuth: true,
+	}
+
+	handler := BasicAuth(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ops-bot"}}
+
+	// Verified client certificate is accepted without credentials
+	req := httptest.NewRequest("GET", "/api/keys", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d with verified client cert, got %d", http.StatusOK, w.Code)
+	}
+
+	// Unverified peer certificate is not enough
+	req = httptest.NewRequest("GET", "/api/keys", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d with unverified cert, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Basic auth still works alongside client certificates
+	req = httptest.NewRequest("GET", "/api/keys", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d with basic auth, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestBasicAuth_ClientCertificateOnly(t *testing.T) {
+	config := &AuthConfig{
+		Enabled:        true,
+		ClientCertAuth: true,
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Client certificate auth alone should be a valid configuration: %v", err)
+	}
+
+	handler := BasicAuth(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/api/keys", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without client cert, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestBasicAuth_ScopedAPIToken(t *testing.T) {
+	config := &AuthConfig{
+		Enabled:  true,
+		Username: "admin",
+		Password: "secret",
+		BasePath: "/webcli",
+		TokenAuth: func(token string) *models.APIToken {
+			if token == "wcli_ci" {
+				return &models.APIToken{Name: "ci", Scopes: []string{models.APITokenScopeExecute}}
+			}
+			return nil
+		},
+	}
+
+	handler := BasicAuth(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method   string
+		path     string
+		token    string
+		expected int
+	}{
+		{"POST", "/webcli/api/commands/execute", "wcli_ci", http.StatusOK},
+		{"POST", "/webcli/api/bash-scripts/execute/stream", "wcli_ci", http.StatusOK},
+		{"GET", "/webcli/api/keys", "wcli_ci", http.StatusForbidden},
+		{"POST", "/webcli/api/tokens", "wcli_ci", http.StatusForbidden},
+		{"POST", "/webcli/api/commands/execute", "wcli_unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s %s with %s: expected %d, got %d", tt.method, tt.path, tt.token, tt.expected, w.Code)
+		}
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/api/keys", models.APITokenScopeRead},
+		{"GET", "/", models.APITokenScopeRead},
+		{"POST", "/api/keys", models.APITokenScopeWrite},
+		{"DELETE", "/api/servers/1", models.APITokenScopeWrite},
+		{"POST", "/api/commands/execute", models.APITokenScopeExecute},
+		{"GET", "/api/terminal/ws", models.APITokenScopeExecute},
+		{"POST", "/api/saved-commands/7/execute", models.APITokenScopeExecute},
+		{"GET", "/api/saved-commands/7", models.APITokenScopeRead},
+		{"POST", "/api/server-groups/2/commands/execute", models.APITokenScopeExecute},
+		{"POST", "/api/server-groups/2/bash-scripts/execute", models.APITokenScopeExecute},
+		{"POST", "/api/approvals/3/approve", models.APITokenScopeExecute},
+		{"POST", "/api/servers/1/wake", models.APITokenScopeExecute},
+		{"POST", "/api/approvals/3/reject", models.APITokenScopeWrite},
+		{"GET", "/api/tokens", models.APITokenScopeAdmin},
+		{"POST", "/api/auth/unlock", models.APITokenScopeAdmin},
+		{"GET", "/api/vault/config", models.APITokenScopeAdmin},
+		{"GET", "/api/terminal/recordings/20251112T091500Z-3f2a9c1b", models.APITokenScopeAdmin},
+		{"DELETE", "/api/terminal/sessions/9c41d7e2a0b35f18", models.APITokenScopeAdmin},
+		{"GET", "/api/audit/keystrokes/1", models.APITokenScopeAdmin},
+		{"POST", "/api/git-sync", models.APITokenScopeAdmin},
+		{"GET", "/api/export", models.APITokenScopeAdmin},
+		{"POST", "/api/import", models.APITokenScopeAdmin},
+		{"GET", "/api/webhooks/4", models.APITokenScopeAdmin},
+		{"POST", "/api/inventory", models.APITokenScopeAdmin},
+		{"GET", "/api/events", models.APITokenScopeAdmin},
+		{"DELETE", "/api/executions/running/5", models.APITokenScopeAdmin},
+		{"GET", "/api/agents", models.APITokenScopeAdmin},
+		{"GET", "/api/quotas", models.APITokenScopeAdmin},
+		{"PUT", "/api/quotas/2", models.APITokenScopeAdmin},
+		{"GET", "/api/quotas/me", models.APITokenScopeRead},
+		{"PUT", "/api/maintenance", models.APITokenScopeAdmin},
+		{"GET", "/api/maintenance", models.APITokenScopeRead},
+		{"POST", "/api/maintenance-windows", models.APITokenScopeAdmin},
+		{"GET", "/api/maintenance-windows/1", models.APITokenScopeRead},
+		{"DELETE", "/api/server-groups/2", models.APITokenScopeAdmin},
+		{"POST", "/api/server-groups/2/servers", models.APITokenScopeWrite},
+		{"POST", "/api/local-users/3/test-sudo", models.APITokenScopeAdmin},
+		{"PUT", "/api/local-users/3", models.APITokenScopeWrite},
+		{"GET", "/api/terminal/sessions", models.APITokenScopeAdmin},
+		{"GET", "/api/terminal/sessions/9c41d7e2a0b35f18/watch", models.APITokenScopeRead},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := RequiredScope(req, ""); got != tt.expected {
+			t.Errorf("RequiredScope(%s %s) = %q, want %q", tt.method, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	handler := RequireAdmin("/webcli")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		principal *Principal
+		expected  int
+	}{
+		{"non-admin on an admin endpoint", "GET", "/webcli/api/tokens", &Principal{Name: "user:bob"}, http.StatusForbidden},
+		{"non-admin on an admin method", "POST", "/webcli/api/quotas", &Principal{Name: "user:bob"}, http.StatusForbidden},
+		{"non-admin elsewhere", "GET", "/webcli/api/quotas/me", &Principal{Name: "user:bob"}, http.StatusOK},
+		{"admin", "GET", "/webcli/api/tokens", &Principal{Name: "user:alice", Admin: true}, http.StatusOK},
+		{"authentication disabled", "GET", "/webcli/api/tokens", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, rr.Code)
+			}
+		})
+	}
+}
+
+func TestBasicAuth_Principal(t *testing.T) {
+	config := &AuthConfig{
+		Enabled:        true,
+		Username:       "admin",
+		Password:       "secret",
+		ClientCertAuth: true,
+		TokenAuth: func(token string) *models.APIToken {
+			if token == "wcli_ci" {
+				return &models.APIToken{Name: "ci", Scopes: []string{models.APITokenScopeRead}, Roles: []string{"deployers"}}
+			}
+			return nil
+		},
+	}
+
+	var principal *Principal
+	handler := BasicAuth(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Basic auth user is an admin
+	req := httptest.NewRequest("GET", "/api/keys", nil)
+	req.SetBasicAuth("admin", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if principal == nil || principal.Name != "user:admin" || !principal.IsAdmin() {
+		t.Errorf("Unexpected principal for basic auth: %+v", principal)
+	}
+
+	// API token carries its roles and is not an admin without the admin scope
+	req = httptest.NewRequest("GET", "/api/keys", nil)
+	req.Header.Set("Authorization", "Bearer wcli_ci")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if principal == nil || principal.IsAdmin() {
+		t.Fatalf("Unexpected principal for API token: %+v", principal)
+	}
+	identities := principal.Identities()
+	if len(identities) != 2 || identities[0] != "token:ci" || identities[1] != "role:deployers" {
+		t.Errorf("Unexpected identities for API token: %v", identities)
+	}
+
+	// Client certificate OUs become roles
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ops-bot", OrganizationalUnit: []string{"senior-ops"}}}
+	req = httptest.NewRequest("GET", "/api/keys", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if principal == nil || principal.IsAdmin() || principal.Name != "user:ops-bot" || len(principal.Roles) != 1 || principal.Roles[0] != "senior-ops" {
+		t.Errorf("Unexpected principal for client certificate: %+v", principal)
+	}
+}
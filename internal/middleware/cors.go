@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/rs/cors"
+)
+
+// CORSConfig holds Cross-Origin Resource Sharing configuration
+type CORSConfig struct {
+	AllowedOrigins   []string // Origins allowed to call the API ("*" allows any origin)
+	AllowedMethods   []string // HTTP methods allowed in cross-origin requests
+	AllowedHeaders   []string // Request headers allowed in cross-origin requests
+	ExposedHeaders   []string // Response headers readable by cross-origin clients
+	AllowCredentials bool     // Allow cookies and Authorization headers on cross-origin requests
+	MaxAge           int      // Seconds browsers may cache preflight responses
+}
+
+// CORS applies the configured cross-origin policy to all requests
+func CORS(config *CORSConfig) func(http.Handler) http.Handler {
+	allowCredentials := config.AllowCredentials
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" && allowCredentials {
+			// Reflecting any origin with credentials would let any site act as the user
+			log.Println("WARNING: CORS allows any origin; disabling credentials for cross-origin requests")
+			allowCredentials = false
+			break
+		}
+	}
+
+	c := cors.New(cors.Options{
+		AllowedOrigins:   config.AllowedOrigins,
+		AllowedMethods:   config.AllowedMethods,
+		AllowedHeaders:   config.AllowedHeaders,
+		ExposedHeaders:   config.ExposedHeaders,
+		AllowCredentials: allowCredentials,
+		MaxAge:           config.MaxAge,
+	})
+
+	return c.Handler
+}
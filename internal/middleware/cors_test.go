@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCORSTestHandler(config *CORSConfig) http.Handler {
+	return CORS(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestCORS_AllowedOrigin(t *testing.T) {
+	handler := newCORSTestHandler(&CORSConfig{
+		AllowedOrigins:   []string{"https://tools.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+
+	req := httptest.NewRequest("OPTIONS", "/api/keys", nil)
+	req.Header.Set("Origin", "https://tools.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://tools.example.com" {
+		t.Errorf("Expected allowed origin header, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Expected credentials allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Expected max age 600, got %q", got)
+	}
+}
+
+func TestCORS_DisallowedOriginAndMethod(t *testing.T) {
+	handler := newCORSTestHandler(&CORSConfig{
+		AllowedOrigins: []string{"https://tools.example.com"},
+		AllowedMethods: []string{"GET"},
+	})
+
+	req := httptest.NewRequest("GET", "/api/keys", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no allowed origin header for disallowed origin, got %q", got)
+	}
+
+	req = httptest.NewRequest("OPTIONS", "/api/keys", nil)
+	req.Header.Set("Origin", "https://tools.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected preflight for disallowed method to be rejected, got %q", got)
+	}
+}
+
+func TestCORS_WildcardDisablesCredentials(t *testing.T) {
+	handler := newCORSTestHandler(&CORSConfig{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET"},
+		AllowCredentials: true,
+	})
+
+	req := httptest.NewRequest("GET", "/api/keys", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected wildcard origin, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected credentials to be disabled with wildcard origin, got %q", got)
+	}
+}
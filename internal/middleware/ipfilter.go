@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+)
+
+// IPFilterConfig holds CIDR-based client access control configuration
+// Entries are CIDR ranges (10.0.0.0/8) or single addresses (192.0.2.10)
+type IPFilterConfig struct {
+	Allow          []string // Clients allowed to reach the server (empty allows all)
+	Deny           []string // Clients always rejected, even if allowed
+	ExecutionAllow []string // Stricter allowlist for command, script and terminal execution (empty applies no extra limit)
+	ExcludePaths   []string // Paths exempt from filtering (e.g., health checks)
+	BasePath       string   // Path prefix the application is served under, for matching execution endpoints
+}
+
+// IPFilter rejects clients by address before authentication
+type IPFilter struct {
+	config         *IPFilterConfig
+	allow          []netip.Prefix
+	deny           []netip.Prefix
+	executionAllow []netip.Prefix
+}
+
+// NewIPFilter parses the configured address lists
+func NewIPFilter(config *IPFilterConfig) (*IPFilter, error) {
+	allow, err := parsePrefixes(config.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP allowlist: %w", err)
+	}
+	deny, err := parsePrefixes(config.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP denylist: %w", err)
+	}
+	executionAllow, err := parsePrefixes(config.ExecutionAllow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid execution IP allowlist: %w", err)
+	}
+
+	return &IPFilter{
+		config:         config,
+		allow:          allow,
+		deny:           deny,
+		executionAllow: executionAllow,
+	}, nil
+}
+
+// Enabled reports whether any address list is configured
+func (f *IPFilter) Enabled() bool {
+	return len(f.allow) > 0 || len(f.deny) > 0 || len(f.executionAllow) > 0
+}
+
+// Filter rejects requests from clients outside the configured lists with 403 Forbidden
+// Denied requests are written to the audit log
+func (f *IPFilter) Filter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !f.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		for _, path := range f.config.ExcludePaths {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if rule := f.deniedBy(r); rule != "" {
+			audit.GetLogger().LogAccessDenied(r, r.URL.Path, map[string]string{
+				"reason": "ip_filter",
+				"rule":   rule,
+			})
+			apierror.WithCode(w, apierror.CodeIPDenied, "Access denied", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// deniedBy returns the list that rejects the request, or "" if the client is allowed
+// Clients without an IP address (unix socket) are rejected whenever an allowlist applies
+func (f *IPFilter) deniedBy(r *http.Request) string {
+	addr, err := netip.ParseAddr(RemoteIP(r))
+	valid := err == nil
+	addr = addr.Unmap()
+
+	if valid && containsAddr(f.deny, addr) {
+		return "denylist"
+	}
+	if len(f.allow) > 0 && (!valid || !containsAddr(f.allow, addr)) {
+		return "allowlist"
+	}
+	if len(f.executionAllow) > 0 && isExecutionPath(strings.TrimPrefix(r.URL.Path, f.config.BasePath)) &&
+		(!valid || !containsAddr(f.executionAllow, addr)) {
+		return "execution_allowlist"
+	}
+	return ""
+}
+
+// parsePrefixes parses CIDR ranges and single addresses
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// containsAddr reports whether any prefix contains the address
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
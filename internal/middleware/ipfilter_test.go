@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newIPFilterTestHandler(t *testing.T, config *IPFilterConfig) http.Handler {
+	filter, err := NewIPFilter(config)
+	if err != nil {
+		t.Fatalf("Failed to create IP filter: %v", err)
+	}
+	return filter.Filter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestIPFilter(t *testing.T) {
+	handler := newIPFilterTestHandler(t, &IPFilterConfig{
+		Allow:          []string{"10.0.0.0/8", "2001:db8::/32"},
+		Deny:           []string{"10.0.0.66"},
+		ExecutionAllow: []string{"10.1.0.0/16"},
+		ExcludePaths:   []string{"/webcli/api/health"},
+		BasePath:       "/webcli",
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		path       string
+		expected   int
+	}{
+		{"allowed client", "10.2.3.4:5000", "/webcli/api/servers", http.StatusOK},
+		{"allowed IPv6 client", "[2001:db8::1]:5000", "/webcli/api/servers", http.StatusOK},
+		{"IPv4-mapped IPv6 client", "[::ffff:10.2.3.4]:5000", "/webcli/api/servers", http.StatusOK},
+		{"outside allowlist", "192.0.2.1:5000", "/webcli/api/servers", http.StatusForbidden},
+		{"denylist wins over allowlist", "10.0.0.66:5000", "/webcli/api/servers", http.StatusForbidden},
+		{"execution from execution allowlist", "10.1.2.3:5000", "/webcli/api/commands/execute", http.StatusOK},
+		{"execution outside execution allowlist", "10.2.3.4:5000", "/webcli/api/commands/execute", http.StatusForbidden},
+		{"terminal outside execution allowlist", "10.2.3.4:5000", "/webcli/api/terminal/ws", http.StatusForbidden},
+		{"saved command outside execution allowlist", "10.2.3.4:5000", "/webcli/api/saved-commands/7/execute", http.StatusForbidden},
+		{"saved command from execution allowlist", "10.1.2.3:5000", "/webcli/api/saved-commands/7/execute", http.StatusOK},
+		{"group execution outside execution allowlist", "10.2.3.4:5000", "/webcli/api/server-groups/2/commands/execute", http.StatusForbidden},
+		{"saved command read outside execution allowlist", "10.2.3.4:5000", "/webcli/api/saved-commands/7", http.StatusOK},
+		{"excluded path", "192.0.2.1:5000", "/webcli/api/health", http.StatusOK},
+		{"no client IP", "@", "/webcli/api/servers", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
+func TestIPFilter_DenyOnly(t *testing.T) {
+	handler := newIPFilterTestHandler(t, &IPFilterConfig{Deny: []string{"192.0.2.0/24"}})
+
+	send := func(remoteAddr string) int {
+		req := httptest.NewRequest("GET", "/api/servers", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send("192.0.2.200:5000"); code != http.StatusForbidden {
+		t.Errorf("Expected denied client to get 403, got %d", code)
+	}
+	if code := send("198.51.100.1:5000"); code != http.StatusOK {
+		t.Errorf("Expected other clients to be allowed, got %d", code)
+	}
+	if code := send("@"); code != http.StatusOK {
+		t.Errorf("Expected clients without an IP to be allowed when only a denylist is set, got %d", code)
+	}
+}
+
+func TestNewIPFilter_Invalid(t *testing.T) {
+	if _, err := NewIPFilter(&IPFilterConfig{Allow: []string{"10.0.0.0/33"}}); err == nil {
+		t.Error("Expected invalid CIDR to be rejected")
+	}
+	if _, err := NewIPFilter(&IPFilterConfig{Deny: []string{"not-an-ip"}}); err == nil {
+		t.Error("Expected invalid address to be rejected")
+	}
+
+	filter, err := NewIPFilter(&IPFilterConfig{})
+	if err != nil {
+		t.Fatalf("Unexpected error for empty config: %v", err)
+	}
+	if filter.Enabled() {
+		t.Error("Expected filter without lists to be disabled")
+	}
+}
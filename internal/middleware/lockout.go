@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockoutConfig holds login lockout configuration
+type LockoutConfig struct {
+	Threshold    int           // Failed attempts allowed before locking (0 disables lockout)
+	BaseDuration time.Duration // Lock duration once the threshold is reached; doubles with each further failure
+	MaxDuration  time.Duration // Upper bound for the lock duration
+}
+
+// Lockout describes the failed login state of a client IP or username
+type Lockout struct {
+	Key         string    `json:"key" example:"ip:192.0.2.10"`
+	Failures    int       `json:"failures" example:"7"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// LoginLockout tracks failed login attempts per client IP and per username
+type LoginLockout struct {
+	config  *LockoutConfig
+	mu      sync.Mutex
+	entries map[string]*lockoutEntry
+}
+
+// lockoutEntry is the failure count for a single IP or username
+type lockoutEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// NewLoginLockout creates a login lockout tracker
+func NewLoginLockout(config *LockoutConfig) *LoginLockout {
+	return &LoginLockout{
+		config:  config,
+		entries: make(map[string]*lockoutEntry),
+	}
+}
+
+// Enabled reports whether failed attempts are tracked
+func (l *LoginLockout) Enabled() bool {
+	return l != nil && l.config.Threshold > 0
+}
+
+// RetryAfter returns how long the IP or username remains locked (zero if not locked)
+func (l *LoginLockout) RetryAfter(ip, username string) time.Duration {
+	if !l.Enabled() {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var remaining time.Duration
+	for _, key := range lockoutKeys(ip, username) {
+		if entry, ok := l.entries[key]; ok {
+			if d := entry.lockedUntil.Sub(now); d > remaining {
+				remaining = d
+			}
+		}
+	}
+	return remaining
+}
+
+// RecordFailure counts a failed attempt against the IP and username
+// Once the threshold is reached each further failure doubles the lock duration
+func (l *LoginLockout) RecordFailure(ip, username string) {
+	if !l.Enabled() {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	for _, key := range lockoutKeys(ip, username) {
+		entry, ok := l.entries[key]
+		if !ok {
+			entry = &lockoutEntry{}
+			l.entries[key] = entry
+		}
+		entry.failures++
+		entry.lastFailure = now
+		if entry.failures >= l.config.Threshold {
+			entry.lockedUntil = now.Add(l.lockDuration(entry.failures))
+		}
+	}
+}
+
+// RecordSuccess clears the failure counts for the IP and username
+// Returns true if there were failed attempts to clear
+func (l *LoginLockout) RecordSuccess(ip, username string) bool {
+	if !l.Enabled() {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cleared := false
+	for _, key := range lockoutKeys(ip, username) {
+		if _, ok := l.entries[key]; ok {
+			delete(l.entries, key)
+			cleared = true
+		}
+	}
+	return cleared
+}
+
+// List returns the IPs and usernames that are currently locked
+func (l *LoginLockout) List() []Lockout {
+	lockouts := []Lockout{}
+	if !l.Enabled() {
+		return lockouts
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range l.entries {
+		if entry.lockedUntil.After(now) {
+			lockouts = append(lockouts, Lockout{Key: key, Failures: entry.failures, LockedUntil: entry.lockedUntil})
+		}
+	}
+
+	sort.Slice(lockouts, func(i, j int) bool { return lockouts[i].Key < lockouts[j].Key })
+	return lockouts
+}
+
+// Unlock clears the failure counts for the IP and/or username
+// Returns the number of entries removed
+func (l *LoginLockout) Unlock(ip, username string) int {
+	if !l.Enabled() {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for _, key := range lockoutKeys(ip, username) {
+		if _, ok := l.entries[key]; ok {
+			delete(l.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// lockDuration returns the lock duration for the given number of failures
+func (l *LoginLockout) lockDuration(failures int) time.Duration {
+	d := l.config.BaseDuration
+	for i := l.config.Threshold; i < failures; i++ {
+		d *= 2
+		if l.config.MaxDuration > 0 && d >= l.config.MaxDuration {
+			return l.config.MaxDuration
+		}
+	}
+	return d
+}
+
+// sweep forgets entries that are no longer locked and have not failed recently
+// Caller must hold l.mu
+func (l *LoginLockout) sweep(now time.Time) {
+	window := l.config.MaxDuration
+	if window <= 0 {
+		window = l.config.BaseDuration
+	}
+	for key, entry := range l.entries {
+		if now.After(entry.lockedUntil) && now.Sub(entry.lastFailure) > window {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// lockoutKeys returns the tracking keys for an IP and username
+func lockoutKeys(ip, username string) []string {
+	var keys []string
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	if username != "" {
+		keys = append(keys, "user:"+username)
+	}
+	return keys
+}
+
+// RemoteIP returns the IP of the directly connected client
+// Forwarding headers are ignored since they are set by the client
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return strings.TrimSpace(host)
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoginLockout_Backoff(t *testing.T) {
+	lockout := NewLoginLockout(&LockoutConfig{
+		Threshold:    3,
+		BaseDuration: 10 * time.Second,
+		MaxDuration:  30 * time.Second,
+	})
+
+	for i := 0; i < 2; i++ {
+		lockout.RecordFailure("192.0.2.1", "admin")
+	}
+	if d := lockout.RetryAfter("192.0.2.1", ""); d != 0 {
+		t.Fatalf("Expected no lock below threshold, got %v", d)
+	}
+
+	lockout.RecordFailure("192.0.2.1", "admin")
+	if d := lockout.RetryAfter("192.0.2.1", ""); d <= 0 || d > 10*time.Second {
+		t.Errorf("Expected lock of up to 10s at threshold, got %v", d)
+	}
+
+	lockout.RecordFailure("192.0.2.1", "admin")
+	if d := lockout.RetryAfter("", "admin"); d <= 10*time.Second || d > 20*time.Second {
+		t.Errorf("Expected lock to double to 20s, got %v", d)
+	}
+
+	lockout.RecordFailure("192.0.2.1", "admin")
+	lockout.RecordFailure("192.0.2.1", "admin")
+	if d := lockout.RetryAfter("", "admin"); d <= 20*time.Second || d > 30*time.Second {
+		t.Errorf("Expected lock capped at 30s, got %v", d)
+	}
+
+	if d := lockout.RetryAfter("192.0.2.2", "other"); d != 0 {
+		t.Errorf("Expected other clients to be unaffected, got %v", d)
+	}
+
+	if got := len(lockout.List()); got != 2 {
+		t.Errorf("Expected 2 locked entries, got %d", got)
+	}
+
+	if removed := lockout.Unlock("192.0.2.1", "admin"); removed != 2 {
+		t.Errorf("Expected 2 entries unlocked, got %d", removed)
+	}
+	if d := lockout.RetryAfter("192.0.2.1", "admin"); d != 0 {
+		t.Errorf("Expected no lock after unlock, got %v", d)
+	}
+}
+
+func TestLoginLockout_SuccessResets(t *testing.T) {
+	lockout := NewLoginLockout(&LockoutConfig{Threshold: 2, BaseDuration: time.Minute})
+
+	lockout.RecordFailure("192.0.2.1", "admin")
+	if !lockout.RecordSuccess("192.0.2.1", "admin") {
+		t.Error("Expected success to clear previous failures")
+	}
+	if lockout.RecordSuccess("192.0.2.1", "admin") {
+		t.Error("Expected nothing to clear on second success")
+	}
+
+	lockout.RecordFailure("192.0.2.1", "admin")
+	if d := lockout.RetryAfter("192.0.2.1", "admin"); d != 0 {
+		t.Errorf("Expected failure count reset by success, got lock %v", d)
+	}
+}
+
+func TestLoginLockout_Disabled(t *testing.T) {
+	var nilLockout *LoginLockout
+	nilLockout.RecordFailure("192.0.2.1", "admin")
+	if d := nilLockout.RetryAfter("192.0.2.1", "admin"); d != 0 {
+		t.Errorf("Expected nil lockout to never lock, got %v", d)
+	}
+
+	lockout := NewLoginLockout(&LockoutConfig{Threshold: 0, BaseDuration: time.Minute})
+	for i := 0; i < 10; i++ {
+		lockout.RecordFailure("192.0.2.1", "admin")
+	}
+	if d := lockout.RetryAfter("192.0.2.1", "admin"); d != 0 {
+		t.Errorf("Expected disabled lockout to never lock, got %v", d)
+	}
+}
+
+func TestBasicAuth_Lockout(t *testing.T) {
+	config := &AuthConfig{
+		Enabled:  true,
+		Username: "admin",
+		Password: "secret",
+		Lockout:  NewLoginLockout(&LockoutConfig{Threshold: 2, BaseDuration: time.Minute, MaxDuration: time.Hour}),
+	}
+
+	handler := BasicAuth(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/keys", nil)
+		req.RemoteAddr = "192.0.2.1:5555"
+		req.SetBasicAuth("admin", password)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := send("wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected 401, got %d", i+1, w.Code)
+		}
+	}
+
+	// Locked out even with the correct password
+	w := send("secret")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 while locked out, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", got)
+	}
+
+	config.Lockout.Unlock("192.0.2.1", "admin")
+	if w := send("secret"); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 after unlock, got %d", w.Code)
+	}
+}
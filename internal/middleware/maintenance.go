@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+)
+
+// MaintenanceConfig holds read-only mode configuration
+type MaintenanceConfig struct {
+	Enabled    bool     // Start in read-only mode
+	Reason     string   // Reason shown to clients while read-only
+	BasePath   string   // Path prefix the application is served under
+	AllowPaths []string // API paths (without base path) that may still change state, e.g. login and the mode toggle itself
+}
+
+// MaintenanceStatus describes the current read-only mode
+type MaintenanceStatus struct {
+	Enabled   bool       `json:"enabled"`
+	Reason    string     `json:"reason,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`      // When read-only mode was last turned on
+	ChangedBy string     `json:"changed_by,omitempty"` // Who last changed the mode ("config" at startup)
+}
+
+// MaintenanceMode is a runtime switch that freezes the system in read-only mode
+// While enabled, command, script and terminal execution and all mutating
+// requests are rejected; reads keep working
+type MaintenanceMode struct {
+	config *MaintenanceConfig
+	mu     sync.RWMutex
+	status MaintenanceStatus
+}
+
+// NewMaintenanceMode creates the read-only mode switch in its configured initial state
+func NewMaintenanceMode(config *MaintenanceConfig) *MaintenanceMode {
+	m := &MaintenanceMode{config: config}
+	if config.Enabled {
+		m.Set(true, config.Reason, "config")
+	}
+	return m
+}
+
+// Status returns the current read-only mode
+func (m *MaintenanceMode) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Set turns read-only mode on or off
+func (m *MaintenanceMode) Set(enabled bool, reason, changedBy string) MaintenanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := MaintenanceStatus{Enabled: enabled, ChangedBy: changedBy}
+	if enabled {
+		now := time.Now().UTC()
+		status.Reason = reason
+		status.Since = &now
+	}
+	m.status = status
+	return status
+}
+
+// Block rejects execution and mutating requests with 503 Service Unavailable while read-only
+// Rejected requests are written to the audit log
+func (m *MaintenanceMode) Block(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := m.Status()
+		if !status.Enabled || !m.blocks(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		audit.GetLogger().LogAccessDenied(r, r.URL.Path, map[string]string{
+			"reason": "read_only",
+		})
+
+		message := "Server is in read-only mode"
+		if status.Reason != "" {
+			message += ": " + status.Reason
+		}
+		apierror.WithCode(w, apierror.CodeReadOnly, message, http.StatusServiceUnavailable)
+	})
+}
+
+// blocks reports whether read-only mode rejects the request
+// Execution endpoints are blocked for every method, since the terminal is opened with GET
+func (m *MaintenanceMode) blocks(r *http.Request) bool {
+	path := strings.TrimPrefix(r.URL.Path, m.config.BasePath)
+	if isExecutionPath(path) {
+		return true
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	for _, allowed := range m.config.AllowPaths {
+		if path == allowed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	mode := NewMaintenanceMode(&MaintenanceConfig{
+		Enabled:    true,
+		Reason:     "incident 42",
+		BasePath:   "/webcli",
+		AllowPaths: []string{"/api/maintenance"},
+	})
+	handler := mode.Block(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	if status := mode.Status(); !status.Enabled || status.ChangedBy != "config" || status.Since == nil {
+		t.Errorf("Expected read-only mode from config, got %+v", status)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{"read", "GET", "/webcli/api/servers", http.StatusOK},
+		{"create", "POST", "/webcli/api/servers", http.StatusServiceUnavailable},
+		{"update", "PUT", "/webcli/api/servers/1", http.StatusServiceUnavailable},
+		{"delete", "DELETE", "/webcli/api/servers/1", http.StatusServiceUnavailable},
+		{"command execution", "POST", "/webcli/api/commands/execute", http.StatusServiceUnavailable},
+		{"terminal", "GET", "/webcli/api/terminal/ws", http.StatusServiceUnavailable},
+		{"allowed path", "PUT", "/webcli/api/maintenance", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, rec.Code)
+			}
+			if rec.Code == http.StatusServiceUnavailable && !strings.Contains(rec.Body.String(), "incident 42") {
+				t.Errorf("Expected reason in response, got %q", rec.Body.String())
+			}
+		})
+	}
+
+	// Turning read-only mode off lets everything through
+	if status := mode.Set(false, "ignored", "user:admin"); status.Enabled || status.Reason != "" {
+		t.Errorf("Expected read-only mode off, got %+v", status)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/webcli/api/commands/execute", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 after disabling read-only mode, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// Principal identifies the authenticated caller for group permission checks
+type Principal struct {
+	Name  string   // "user:<name>" or "token:<name>"
+	Roles []string // Roles granted to the caller (API token roles or client certificate OUs)
+	Admin bool     // Admins bypass group permissions
+
+	AgentServerID *int64 // Server an agent token may connect as the agent of
+}
+
+// Identities returns the principal names matched against group permissions
+func (p *Principal) Identities() []string {
+	identities := []string{p.Name}
+	for _, role := range p.Roles {
+		identities = append(identities, "role:"+role)
+	}
+	return identities
+}
+
+// IsAdmin reports whether the caller bypasses group permissions
+// Requests without a principal (authentication disabled) are treated as admin
+func (p *Principal) IsAdmin() bool {
+	return p == nil || p.Admin
+}
+
+// principalKey is the context key for the authenticated principal
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal that authenticated the request, or nil
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// clientCertPrincipal returns the principal for a verified client certificate
+// The common name is the user and organizational units are roles; an "admin" OU grants admin
+func clientCertPrincipal(r *http.Request) *Principal {
+	subject := r.TLS.VerifiedChains[0][0].Subject
+	principal := &Principal{Name: "user:" + subject.CommonName}
+	for _, ou := range subject.OrganizationalUnit {
+		principal.Roles = append(principal.Roles, ou)
+		if ou == "admin" {
+			principal.Admin = true
+		}
+	}
+	return principal
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+)
+
+// quotaWindow is the period executions per hour are counted over
+const quotaWindow = time.Hour
+
+// Quota limits the executions of one principal
+type Quota struct {
+	ExecutionsPerHour int           // Executions started in any rolling hour (0 for no limit)
+	MaxConcurrent     int           // Executions running at once (0 for no limit)
+	MaxTimeout        time.Duration // Longest an execution may run (0 for the default)
+}
+
+// QuotaConfig holds execution quota configuration
+type QuotaConfig struct {
+	// Lookup returns the quota of the principal, or nil when it has none
+	Lookup func(principal *Principal) *Quota
+}
+
+// QuotaUsage is a snapshot of a principal's executions
+type QuotaUsage struct {
+	Running  int `json:"running" example:"1"`    // Executions running now
+	LastHour int `json:"last_hour" example:"42"` // Executions started in the last hour
+}
+
+// Quotas enforces per-principal execution quotas, so a single automation account
+// cannot starve interactive operators. Usage is kept in memory, so a restart resets it.
+// A nil Quotas enforces nothing.
+type Quotas struct {
+	config *QuotaConfig
+	mu     sync.Mutex
+	usage  map[string]*principalUsage
+}
+
+// principalUsage tracks the executions of one principal
+type principalUsage struct {
+	running int
+	started []time.Time // Start times within the last hour, oldest first
+}
+
+// NewQuotas creates a quota enforcer
+func NewQuotas(config *QuotaConfig) *Quotas {
+	return &Quotas{config: config, usage: make(map[string]*principalUsage)}
+}
+
+// Enforce rejects executions over the caller's quota with 429 Too Many Requests
+// Admitted executions carry the quota's timeout in their context (see ExecutionTimeoutFromContext)
+// and count as running until the handler returns. Requests without a principal have no quota.
+func (q *Quotas) Enforce(next http.Handler) http.Handler {
+	if q == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, release, ok := q.Admit(w, r)
+		if !ok {
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Admit counts one execution of the caller against its quota, for handlers that run several
+// executions per request
+// It returns the request carrying the quota's timeout and the release ending the execution, or
+// answers w with 429 Too Many Requests and returns false when the quota is used up.
+func (q *Quotas) Admit(w http.ResponseWriter, r *http.Request) (*http.Request, func(), bool) {
+	if q == nil {
+		return r, func() {}, true
+	}
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil || q.config.Lookup == nil {
+		return r, func() {}, true
+	}
+	quota := q.config.Lookup(principal)
+	if quota == nil {
+		return r, func() {}, true
+	}
+
+	release, retryAfter, denied := q.acquire(principal.Name, quota, time.Now())
+	if release == nil {
+		if retryAfter > 0 {
+			SetRetryAfter(w, retryAfter)
+		}
+		apierror.WithCode(w, apierror.CodeQuotaExceeded, "Execution quota exceeded: "+denied, http.StatusTooManyRequests)
+		return r, nil, false
+	}
+
+	if quota.MaxTimeout > 0 {
+		r = r.WithContext(WithExecutionTimeout(r.Context(), quota.MaxTimeout))
+	}
+	return r, release, true
+}
+
+// Usage returns the executions of the principal named name
+func (q *Quotas) Usage(name string) QuotaUsage {
+	if q == nil {
+		return QuotaUsage{}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, ok := q.usage[name]
+	if !ok {
+		return QuotaUsage{}
+	}
+	usage.expire(time.Now())
+	return QuotaUsage{Running: usage.running, LastHour: len(usage.started)}
+}
+
+// acquire counts an execution of the principal against its quota
+// When the quota is used up it returns no release, the limit reached and, for the hourly limit,
+// when the next execution is allowed.
+func (q *Quotas) acquire(name string, quota *Quota, now time.Time) (release func(), retryAfter time.Duration, denied string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, ok := q.usage[name]
+	if !ok {
+		usage = &principalUsage{}
+		q.usage[name] = usage
+	}
+	usage.expire(now)
+
+	if quota.MaxConcurrent > 0 && usage.running >= quota.MaxConcurrent {
+		return nil, 0, fmt.Sprintf("%s may run %d executions at once", name, quota.MaxConcurrent)
+	}
+	if quota.ExecutionsPerHour > 0 && len(usage.started) >= quota.ExecutionsPerHour {
+		retryAfter = usage.started[len(usage.started)-quota.ExecutionsPerHour].Add(quotaWindow).Sub(now)
+		return nil, retryAfter, fmt.Sprintf("%s may start %d executions per hour", name, quota.ExecutionsPerHour)
+	}
+
+	usage.running++
+	usage.started = append(usage.started, now)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			usage.running--
+		})
+	}, 0, ""
+}
+
+// expire forgets executions started more than an hour before now
+func (u *principalUsage) expire(now time.Time) {
+	cutoff := now.Add(-quotaWindow)
+	i := 0
+	for i < len(u.started) && !u.started[i].After(cutoff) {
+		i++
+	}
+	u.started = u.started[i:]
+}
+
+// executionTimeoutKey is the context key for the longest an execution may run
+type executionTimeoutKey struct{}
+
+// WithExecutionTimeout returns a context limiting executions to timeout
+func WithExecutionTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, executionTimeoutKey{}, timeout)
+}
+
+// ExecutionTimeoutFromContext returns the longest the request's execution may run, or 0 for no limit
+func ExecutionTimeoutFromContext(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(executionTimeoutKey{}).(time.Duration)
+	return timeout
+}
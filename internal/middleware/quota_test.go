@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuotas_Enforce(t *testing.T) {
+	quotas := NewQuotas(&QuotaConfig{
+		Lookup: func(principal *Principal) *Quota {
+			if principal.Name != "token:ci" {
+				return nil
+			}
+			return &Quota{ExecutionsPerHour: 3, MaxConcurrent: 1, MaxTimeout: time.Minute}
+		},
+	})
+
+	release := make(chan struct{})
+	var timeout time.Duration
+	handler := quotas.Enforce(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout = ExecutionTimeoutFromContext(r.Context())
+		if r.URL.Query().Get("block") != "" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(name, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, nil)
+		if name != "" {
+			req = req.WithContext(WithPrincipal(req.Context(), &Principal{Name: name}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("token:ci", "/api/commands/execute"); rec.Code != http.StatusOK || timeout != time.Minute {
+		t.Fatalf("Expected the first execution to pass with the quota's timeout, got %d and %v", rec.Code, timeout)
+	}
+
+	// A running execution blocks a second one
+	done := make(chan struct{})
+	go func() {
+		send("token:ci", "/api/commands/execute?block=1")
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for quotas.Usage("token:ci").Running != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Blocking execution never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if rec := send("token:ci", "/api/commands/execute"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "" {
+		t.Errorf("Expected 429 without Retry-After while an execution runs, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	close(release)
+	<-done
+
+	// Rejected executions do not count, so one of the three per hour is left
+	if rec := send("token:ci", "/api/commands/execute"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the third execution to pass, got %d", rec.Code)
+	}
+	rec := send("token:ci", "/api/commands/execute")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After past the hourly limit, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if usage := quotas.Usage("token:ci"); usage.Running != 0 || usage.LastHour != 3 {
+		t.Errorf("Expected 3 executions in the last hour and none running, got %+v", usage)
+	}
+
+	// Callers without a quota are not limited
+	for i := 0; i < 5; i++ {
+		if rec := send("user:alice", "/api/commands/execute"); rec.Code != http.StatusOK || timeout != 0 {
+			t.Fatalf("Expected callers without a quota to pass, got %d and %v", rec.Code, timeout)
+		}
+		if rec := send("", "/api/commands/execute"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected anonymous requests to pass, got %d", rec.Code)
+		}
+	}
+}
+
+func TestQuotas_HourlyWindow(t *testing.T) {
+	quotas := NewQuotas(&QuotaConfig{})
+	quota := &Quota{ExecutionsPerHour: 2}
+	start := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if release, _, _ := quotas.acquire("user:bob", quota, start.Add(time.Duration(i)*time.Minute)); release == nil {
+			t.Fatalf("Expected execution %d to be allowed", i+1)
+		} else {
+			release()
+		}
+	}
+	release, retryAfter, _ := quotas.acquire("user:bob", quota, start.Add(10*time.Minute))
+	if release != nil || retryAfter != 50*time.Minute {
+		t.Errorf("Expected a retry once the oldest execution leaves the window, got %v", retryAfter)
+	}
+	if release, _, _ := quotas.acquire("user:bob", quota, start.Add(time.Hour+time.Second)); release == nil {
+		t.Error("Expected an execution to be allowed an hour later")
+	}
+
+	// Nil quotas enforce nothing
+	var none *Quotas
+	if usage := none.Usage("user:bob"); usage.Running != 0 {
+		t.Errorf("Expected no usage, got %+v", usage)
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+)
+
+// rateLimiterIdleTTL is how long an unused client limiter is kept before eviction
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// RateLimitConfig holds rate limiting configuration
+type RateLimitConfig struct {
+	RequestsPerMinute int // Sustained requests allowed per client per minute (0 disables)
+	Burst             int // Requests a client may make in a burst before being limited
+}
+
+// RateLimiter tracks a token bucket per client
+type RateLimiter struct {
+	config    *RateLimitConfig
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+// clientLimiter is the token bucket for a single client
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter creates a rate limiter with per-client buckets
+func NewRateLimiter(config *RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:    config,
+		clients:   make(map[string]*clientLimiter),
+		lastSweep: time.Now(),
+	}
+}
+
+// Limit rejects requests over the client's rate with 429 Too Many Requests and Retry-After
+func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl.config.RequestsPerMinute <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reservation := rl.reserve(rateLimitKey(r))
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			SetRetryAfter(w, delay)
+			apierror.Error(w, "Rate limit exceeded. Try again later.", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reserve takes a token from the client's bucket, creating the bucket on first use
+func (rl *RateLimiter) reserve(key string) *rate.Reservation {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rl.lastSweep) > rateLimiterIdleTTL {
+		for k, c := range rl.clients {
+			if now.Sub(c.lastSeen) > rateLimiterIdleTTL {
+				delete(rl.clients, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	client, ok := rl.clients[key]
+	if !ok {
+		burst := rl.config.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		limit := rate.Limit(float64(rl.config.RequestsPerMinute) / 60)
+		client = &clientLimiter{limiter: rate.NewLimiter(limit, burst)}
+		rl.clients[key] = client
+	}
+	client.lastSeen = now
+
+	return client.limiter.ReserveN(now, 1)
+}
+
+// rateLimitKey identifies the client: by the authenticated principal, otherwise by IP
+// Keying on the principal rather than the presented credential keeps every token, session
+// and password of one caller in a single bucket.
+func rateLimitKey(r *http.Request) string {
+	if principal := PrincipalFromContext(r.Context()); principal != nil {
+		return "principal:" + principal.Name
+	}
+
+	return "ip:" + RemoteIP(r)
+}
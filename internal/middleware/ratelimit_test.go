@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newRateLimitTestHandler(config *RateLimitConfig) http.Handler {
+	return NewRateLimiter(config).Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestRateLimit_BurstThenLimited(t *testing.T) {
+	handler := newRateLimitTestHandler(&RateLimitConfig{RequestsPerMinute: 6, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/api/commands/execute", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/api/commands/execute", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after burst, got %d", rec.Code)
+	}
+	// 6 per minute refills one token every 10 seconds
+	if got := rec.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Expected Retry-After 10, got %q", got)
+	}
+}
+
+func TestRateLimit_SeparateClients(t *testing.T) {
+	handler := newRateLimitTestHandler(&RateLimitConfig{RequestsPerMinute: 1, Burst: 1})
+
+	send := func(remoteAddr, auth string, principal *Principal) int {
+		req := httptest.NewRequest("POST", "/api/bash-scripts/execute", nil)
+		req.RemoteAddr = remoteAddr
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		if principal != nil {
+			req = req.WithContext(WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send("10.0.0.1:1000", "", nil); code != http.StatusOK {
+		t.Fatalf("Expected first request from IP to pass, got %d", code)
+	}
+	if code := send("10.0.0.1:2000", "", nil); code != http.StatusTooManyRequests {
+		t.Errorf("Expected second request from same IP to be limited, got %d", code)
+	}
+	if code := send("10.0.0.2:1000", "", nil); code != http.StatusOK {
+		t.Errorf("Expected request from another IP to pass, got %d", code)
+	}
+
+	// Without authentication, made-up credentials do not get buckets of their own
+	if code := send("10.0.0.2:2000", "Bearer made-up", nil); code != http.StatusTooManyRequests {
+		t.Errorf("Expected an unauthenticated credential to share the IP's bucket, got %d", code)
+	}
+
+	// Authenticated clients are limited per principal, not per IP
+	alice := &Principal{Name: "user:alice"}
+	if code := send("10.0.0.1:3000", "Bearer session-1", alice); code != http.StatusOK {
+		t.Errorf("Expected first request of a principal to pass, got %d", code)
+	}
+	if code := send("10.0.0.3:1000", "Bearer session-1", alice); code != http.StatusTooManyRequests {
+		t.Errorf("Expected same principal from another IP to be limited, got %d", code)
+	}
+	if code := send("10.0.0.1:4000", "Bearer token", &Principal{Name: "user:bob"}); code != http.StatusOK {
+		t.Errorf("Expected request of another principal to pass, got %d", code)
+	}
+}
+
+func TestRateLimit_SharedAcrossCredentials(t *testing.T) {
+	handler := newRateLimitTestHandler(&RateLimitConfig{RequestsPerMinute: 1, Burst: 1})
+
+	// A new session token, such as one from a login or refresh, shares the user's bucket
+	alice := &Principal{Name: "user:alice"}
+	for i, token := range []string{"Bearer first-session", "Bearer refreshed-session"} {
+		req := httptest.NewRequest("POST", "/api/commands/execute", nil)
+		req.Header.Set("Authorization", token)
+		req = req.WithContext(WithPrincipal(req.Context(), alice))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		expected := http.StatusOK
+		if i > 0 {
+			expected = http.StatusTooManyRequests
+		}
+		if rec.Code != expected {
+			t.Errorf("Request with %s: expected %d, got %d", token, expected, rec.Code)
+		}
+	}
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	handler := newRateLimitTestHandler(&RateLimitConfig{RequestsPerMinute: 0, Burst: 1})
+
+	for i := 0; i < 20; i++ {
+		req := httptest.NewRequest("POST", "/api/commands/execute", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200 with rate limiting disabled, got %d", i+1, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+		apierror.Error(w, "Server not found", http.StatusNotFound)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/servers/9", nil))
+
+	id := rec.Header().Get(apierror.RequestIDHeader)
+	if len(id) != 16 || id != seen {
+		t.Errorf("Expected a generated ID in the header and context, got %q and %q", id, seen)
+	}
+	var resp apierror.Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if resp.Code != apierror.CodeNotFound || resp.Message != "Server not found" || resp.RequestID != id {
+		t.Errorf("Unexpected error response: %+v", resp)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		kept   bool
+	}{
+		{"proxy ID", "req-42.abc_DEF", true},
+		{"unsafe characters", "id\nInjected: yes", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/servers", nil)
+			req.Header.Set(apierror.RequestIDHeader, tt.header)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if kept := rec.Header().Get(apierror.RequestIDHeader) == tt.header; kept != tt.kept {
+				t.Errorf("Expected kept=%v, got ID %q", tt.kept, rec.Header().Get(apierror.RequestIDHeader))
+			}
+		})
+	}
+}
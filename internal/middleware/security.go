@@ -2,12 +2,15 @@ package middleware
 
 import (
 	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
 )
 
 // SecurityConfig holds security middleware configuration
 type SecurityConfig struct {
-	RequireHTTPS bool // If true, reject non-HTTPS requests when auth is enabled
-	AuthEnabled  bool // Whether authentication is enabled
+	RequireHTTPS bool     // If true, reject non-HTTPS requests when auth is enabled
+	AuthEnabled  bool     // Whether authentication is enabled
+	ExcludePaths []string // Paths exempt from HTTPS enforcement (e.g., /healthz)
 }
 
 // RequireHTTPS middleware rejects non-HTTPS requests when configured
@@ -21,12 +24,18 @@ func RequireHTTPS(config *SecurityConfig) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Skip check for excluded paths (e.g., health probes from plain HTTP clients)
+			for _, path := range config.ExcludePaths {
+				if r.URL.Path == path {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
 			// Check if request is over HTTPS
 			// Check TLS directly, or X-Forwarded-Proto header (for reverse proxy setups)
-			isHTTPS := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
-
-			if !isHTTPS {
-				http.Error(w, "HTTPS required. This endpoint requires a secure connection.", http.StatusForbidden)
+			if !isHTTPS(r) {
+				apierror.WithCode(w, apierror.CodeHTTPSRequired, "HTTPS required. This endpoint requires a secure connection.", http.StatusForbidden)
 				return
 			}
 
@@ -87,6 +87,38 @@ func TestRequireHTTPS_AllowsXForwardedProto(t *testing.T) {
 	}
 }
 
+func TestRequireHTTPS_ExcludedPaths(t *testing.T) {
+	config := &SecurityConfig{
+		RequireHTTPS: true,
+		AuthEnabled:  true,
+		ExcludePaths: []string{"/healthz"},
+	}
+
+	handler := RequireHTTPS(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{"/healthz", http.StatusOK},
+		{"/api/test", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d for %s, got %d", tt.expectedStatus, tt.path, rec.Code)
+			}
+		})
+	}
+}
+
 func TestSecureHeaders(t *testing.T) {
 	handler := SecureHeaders()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionCookieName is the cookie holding the session token for browser clients
+const SessionCookieName = "webcli_session"
+
+// jwtHeader is the fixed header of issued session tokens (HMAC-SHA256 JWT)
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// ErrInvalidSession is returned for malformed, forged, expired or revoked session tokens
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// Session is an authenticated login session
+type Session struct {
+	ID        string    `json:"jti"`
+	Username  string    `json:"sub"`
+	IssuedAt  time.Time `json:"-"`
+	ExpiresAt time.Time `json:"-"`
+}
+
+// sessionClaims are the JWT claims of a session token
+type sessionClaims struct {
+	ID        string `json:"jti"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SessionManager issues and verifies signed session tokens
+// Tokens are stateless; logged-out tokens are remembered until they expire
+type SessionManager struct {
+	secret  []byte
+	ttl     time.Duration
+	mu      sync.Mutex
+	revoked map[string]time.Time // Session ID -> expiry
+}
+
+// NewSessionManager creates a session manager
+// A random signing secret is generated if secret is empty, so sessions end on restart
+func NewSessionManager(secret string, ttl time.Duration) (*SessionManager, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate session secret: %w", err)
+		}
+	}
+
+	return &SessionManager{
+		secret:  key,
+		ttl:     ttl,
+		revoked: make(map[string]time.Time),
+	}, nil
+}
+
+// TTL returns the lifetime of issued sessions
+func (m *SessionManager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Issue creates a signed session token for the user
+func (m *SessionManager) Issue(username string) (string, *Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	session := &Session{
+		ID:        hex.EncodeToString(id),
+		Username:  username,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(m.ttl),
+	}
+
+	payload, err := json.Marshal(sessionClaims{
+		ID:        session.ID,
+		Subject:   session.Username,
+		IssuedAt:  session.IssuedAt.Unix(),
+		ExpiresAt: session.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + m.sign(unsigned), session, nil
+}
+
+// Validate verifies a session token and returns its session
+func (m *SessionManager) Validate(token string) (*Session, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidSession
+	}
+
+	expected := m.sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return nil, ErrInvalidSession
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	var claims sessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	session := &Session{
+		ID:        claims.ID,
+		Username:  claims.Subject,
+		IssuedAt:  time.Unix(claims.IssuedAt, 0).UTC(),
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrInvalidSession
+	}
+
+	m.mu.Lock()
+	_, revoked := m.revoked[session.ID]
+	m.mu.Unlock()
+	if revoked {
+		return nil, ErrInvalidSession
+	}
+
+	return session, nil
+}
+
+// Revoke invalidates a session before it expires (logout)
+func (m *SessionManager) Revoke(session *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, expiresAt := range m.revoked {
+		if now.After(expiresAt) {
+			delete(m.revoked, id)
+		}
+	}
+	m.revoked[session.ID] = session.ExpiresAt
+}
+
+// sign returns the HMAC-SHA256 signature of the unsigned token
+func (m *SessionManager) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SetSessionCookie stores the session token in a secure, HTTP-only cookie
+func SetSessionCookie(w http.ResponseWriter, r *http.Request, basePath, token string, session *Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     basePath + "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// ClearSessionCookie removes the session cookie
+func ClearSessionCookie(w http.ResponseWriter, r *http.Request, basePath string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     basePath + "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// sessionToken returns the session token presented with the request, if any
+// Checked in order: Bearer header (JWT form), session cookie, and the
+// access_token query parameter on WebSocket upgrades (browsers cannot set headers there)
+func sessionToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		if token := strings.TrimPrefix(auth, "Bearer "); strings.Count(token, ".") == 2 {
+			return token
+		}
+	}
+
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return r.URL.Query().Get("access_token")
+	}
+
+	return ""
+}
+
+// sessionKey is the context key for the authenticated session
+type sessionKey struct{}
+
+// WithSession returns a context carrying the session that authenticated the request
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns the session that authenticated the request, or nil
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+// isHTTPS reports whether the request arrived over HTTPS (directly or via a TLS-terminating proxy)
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager_IssueAndValidate(t *testing.T) {
+	sessions, err := NewSessionManager("test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session manager: %v", err)
+	}
+
+	token, issued, err := sessions.Issue("admin")
+	if err != nil {
+		t.Fatalf("Failed to issue session: %v", err)
+	}
+
+	session, err := sessions.Validate(token)
+	if err != nil {
+		t.Fatalf("Expected valid session, got %v", err)
+	}
+	if session.Username != "admin" || session.ID != issued.ID {
+		t.Errorf("Unexpected session: %+v", session)
+	}
+
+	// A token signed with another secret is rejected
+	other, _ := NewSessionManager("other-secret", time.Hour)
+	if _, err := other.Validate(token); err == nil {
+		t.Error("Expected token signed with another secret to be rejected")
+	}
+
+	// A tampered payload is rejected
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := sessions.Validate(forged); err == nil {
+		t.Error("Expected tampered token to be rejected")
+	}
+
+	// A revoked session is rejected
+	sessions.Revoke(session)
+	if _, err := sessions.Validate(token); err == nil {
+		t.Error("Expected revoked session to be rejected")
+	}
+}
+
+func TestSessionManager_Expired(t *testing.T) {
+	sessions, _ := NewSessionManager("", -time.Minute)
+
+	token, _, err := sessions.Issue("admin")
+	if err != nil {
+		t.Fatalf("Failed to issue session: %v", err)
+	}
+
+	if _, err := sessions.Validate(token); err == nil {
+		t.Error("Expected expired session to be rejected")
+	}
+}
+
+func TestBasicAuth_Session(t *testing.T) {
+	sessions, _ := NewSessionManager("test-secret", time.Hour)
+	config := &AuthConfig{
+		Enabled:  true,
+		Username: "admin",
+		Password: "secret",
+		Sessions: sessions,
+		LoginPage: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("login page"))
+		}),
+	}
+
+	var actor string
+	handler := BasicAuth(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if session := SessionFromContext(r.Context()); session != nil {
+			actor = session.Username
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	token, _, _ := sessions.Issue("admin")
+
+	// Session cookie
+	req := httptest.NewRequest("GET", "/api/keys", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: token})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || actor != "admin" {
+		t.Errorf("Expected session cookie to authenticate, got %d (actor %q)", w.Code, actor)
+	}
+
+	// Bearer session token
+	req = httptest.NewRequest("GET", "/api/keys", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected Bearer session token to authenticate, got %d", w.Code)
+	}
+
+	// access_token query parameter is only accepted on WebSocket upgrades
+	req = httptest.NewRequest("GET", "/api/terminal/ws?access_token="+token, nil)
+	req.Header.Set("Upgrade", "websocket")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected WebSocket access_token to authenticate, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/keys?access_token="+token, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected access_token outside WebSocket to be ignored, got %d", w.Code)
+	}
+
+	// Browsers without credentials get the login page instead of a Basic Auth prompt
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Body.String() != "login page" || w.Header().Get("WWW-Authenticate") != "" {
+		t.Errorf("Expected login page without Basic Auth challenge, got %q", w.Body.String())
+	}
+
+	// API clients without credentials still get the Basic Auth challenge
+	req = httptest.NewRequest("GET", "/api/keys", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("Expected Basic Auth challenge for API clients")
+	}
+
+	// Basic Auth remains available as a fallback
+	req = httptest.NewRequest("GET", "/api/keys", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected Basic Auth fallback to succeed, got %d", w.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SudoPasswordCache keeps the sudo password of login sessions in memory, so it is sent once
+// per session rather than with every local execution
+// Passwords are encrypted with a key generated at startup and never written to disk; an
+// entry ends after the TTL, when its session expires or on logout, whichever comes first.
+type SudoPasswordCache struct {
+	aead    cipher.AEAD
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]sudoPasswordEntry // Session ID -> encrypted password
+}
+
+// sudoPasswordEntry is an encrypted sudo password and when it expires
+type sudoPasswordEntry struct {
+	nonce     []byte
+	sealed    []byte
+	expiresAt time.Time
+}
+
+// NewSudoPasswordCache creates a sudo password cache keeping passwords for ttl
+func NewSudoPasswordCache(ttl time.Duration) (*SudoPasswordCache, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate sudo password key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SudoPasswordCache{
+		aead:    aead,
+		ttl:     ttl,
+		entries: make(map[string]sudoPasswordEntry),
+	}, nil
+}
+
+// Set caches the sudo password of a session and returns when it expires
+func (c *SudoPasswordCache) Set(session *Session, password string) (time.Time, error) {
+	return c.set(session, password, time.Now().Add(c.ttl))
+}
+
+// Move hands the sudo password of a session over to the session replacing it (refresh),
+// keeping its expiry
+func (c *SudoPasswordCache) Move(from, to *Session) {
+	if password, expiresAt, ok := c.Get(from); ok {
+		c.set(to, password, expiresAt)
+	}
+	c.Delete(from)
+}
+
+// set caches the sudo password of a session until expiresAt, or the end of the session
+func (c *SudoPasswordCache) set(session *Session, password string, expiresAt time.Time) (time.Time, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return time.Time{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	if session.ExpiresAt.Before(expiresAt) {
+		expiresAt = session.ExpiresAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune()
+	c.entries[session.ID] = sudoPasswordEntry{
+		nonce:     nonce,
+		sealed:    c.aead.Seal(nil, nonce, []byte(password), []byte(session.ID)),
+		expiresAt: expiresAt,
+	}
+	return expiresAt, nil
+}
+
+// Get returns the cached sudo password of a session and when it expires
+func (c *SudoPasswordCache) Get(session *Session) (string, time.Time, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[session.ID]
+	c.mu.Unlock()
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return "", time.Time{}, false
+	}
+
+	password, err := c.aead.Open(nil, entry.nonce, entry.sealed, []byte(session.ID))
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return string(password), entry.expiresAt, true
+}
+
+// Delete forgets the sudo password of a session
+func (c *SudoPasswordCache) Delete(session *Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, session.ID)
+}
+
+// prune drops expired entries; the caller holds the lock
+func (c *SudoPasswordCache) prune() {
+	now := time.Now()
+	for id, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+}
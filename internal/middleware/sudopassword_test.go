@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSudoPasswordCache(t *testing.T) {
+	cache, err := NewSudoPasswordCache(time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create sudo password cache: %v", err)
+	}
+	session := &Session{ID: "s1", Username: "admin", ExpiresAt: time.Now().Add(time.Hour)}
+	other := &Session{ID: "s2", Username: "admin", ExpiresAt: time.Now().Add(time.Hour)}
+
+	expiresAt, err := cache.Set(session, "secret")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if expiresAt.After(time.Now().Add(time.Minute)) {
+		t.Errorf("Expected the entry to expire within the TTL, got %v", expiresAt)
+	}
+	if password, _, ok := cache.Get(session); !ok || password != "secret" {
+		t.Errorf("Expected the cached password, got %q %v", password, ok)
+	}
+	if _, _, ok := cache.Get(other); ok {
+		t.Error("Expected no password for another session")
+	}
+
+	// Passwords are held encrypted
+	if bytes.Contains(cache.entries["s1"].sealed, []byte("secret")) {
+		t.Error("Expected the password to be encrypted in memory")
+	}
+
+	// A refreshed session takes the password over, with the same expiry
+	cache.Move(session, other)
+	if _, _, ok := cache.Get(session); ok {
+		t.Error("Expected no password for the replaced session")
+	}
+	if password, moved, ok := cache.Get(other); !ok || password != "secret" || !moved.Equal(expiresAt) {
+		t.Errorf("Expected the password to move with its expiry, got %q %v %v", password, moved, ok)
+	}
+
+	cache.Delete(other)
+	if _, _, ok := cache.Get(other); ok {
+		t.Error("Expected no password after delete")
+	}
+
+	// An entry ends with its session
+	ending := &Session{ID: "s3", ExpiresAt: time.Now().Add(-time.Second)}
+	if _, err := cache.Set(ending, "secret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, _, ok := cache.Get(ending); ok {
+		t.Error("Expected no password for an expired session")
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Activity event types sent by GET /events
+const (
+	ActivityExecutionStarted  = "execution.started"
+	ActivityExecutionFinished = "execution.finished"
+	ActivityTerminalOpened    = "terminal.opened"
+	ActivityTerminalClosed    = "terminal.closed"
+	ActivityConfigChanged     = "config.changed"
+)
+
+// ActivityEvent is something happening on the server, sent live to dashboards
+// Fields that do not apply to the event type are left out.
+type ActivityEvent struct {
+	Type  string    `json:"type" example:"execution.finished"`
+	Time  time.Time `json:"time"`
+	Actor string    `json:"actor,omitempty" example:"user:alice"` // Who caused it
+
+	// Executions
+	Kind       string `json:"kind,omitempty" example:"script"` // command or script
+	Command    string `json:"command,omitempty"`               // Command that runs (commands only)
+	Script     string `json:"script,omitempty" example:"backup"`
+	Server     string `json:"server,omitempty" example:"web1"` // "local" or the server's name; also for terminals
+	User       string `json:"user,omitempty" example:"deploy"` // User it runs as; also for terminals
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	Severity   string `json:"severity,omitempty" example:"ok"` // ok, warning or critical
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+
+	// Terminal sessions
+	SessionID string `json:"session_id,omitempty"`
+
+	// Configuration changes
+	Resource string `json:"resource,omitempty" example:"server"` // What changed
+	Action   string `json:"action,omitempty" example:"update"`
+	Outcome  string `json:"outcome,omitempty" example:"SUCCESS"` // SUCCESS, FAILURE or DENIED
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ConnectedAgent is a web-cli agent connected to the server, running commands for the server
+// of the same name
+type ConnectedAgent struct {
+	Name        string    `json:"name" example:"web1"`                     // Name of the server it runs commands for
+	Hostname    string    `json:"hostname,omitempty" example:"web1.local"` // Hostname reported by the agent
+	User        string    `json:"user,omitempty" example:"webcli"`         // User the agent runs commands as; others are switched to with sudo
+	Version     string    `json:"version,omitempty" example:"1.4.0"`       // web-cli version of the agent
+	RemoteAddr  string    `json:"remote_addr" example:"203.0.113.7:51234"`
+	ConnectedBy string    `json:"connected_by,omitempty" example:"token:web1-agent"` // Principal the agent authenticated as
+	ConnectedAt time.Time `json:"connected_at"`
+	Running     int       `json:"running"` // Commands running on it now
+}
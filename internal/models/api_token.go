@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// API token scopes
+// A token may only call endpoints covered by its scopes; "admin" grants all access
+const (
+	APITokenScopeRead    = "read"    // GET requests
+	APITokenScopeWrite   = "write"   // Create, update and delete resources
+	APITokenScopeExecute = "execute" // Command, script and terminal execution
+	APITokenScopeAgent   = "agent"   // Connecting as the web-cli agent of a server, and nothing else
+	APITokenScopeAdmin   = "admin"   // Everything, including token and Vault configuration management
+)
+
+// APITokenScopes lists the valid API token scopes
+var APITokenScopes = []string{APITokenScopeRead, APITokenScopeWrite, APITokenScopeExecute, APITokenScopeAgent, APITokenScopeAdmin}
+
+// APIToken represents a personal access token for API clients such as CI jobs
+// Only a hash of the token is stored; the token itself is returned once on creation
+type APIToken struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`                   // Human-readable label (e.g., "ci-deploy")
+	Prefix     string     `json:"prefix"`                 // First characters of the token, for identification
+	Scopes     []string   `json:"scopes"`                 // Granted scopes (read, write, execute, agent, admin)
+	Roles      []string   `json:"roles"`                  // Roles used for group permissions (e.g., "deployers")
+	ServerID   *int64     `json:"server_id,omitempty"`    // Server an agent token may connect as the agent of
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`   // Nil for tokens that never expire
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // Last successful authentication
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// APITokenCreate represents the data needed to create a new API token
+type APITokenCreate struct {
+	Name          string   `json:"name" validate:"required"`
+	Scopes        []string `json:"scopes" validate:"required"`
+	Roles         []string `json:"roles,omitempty"`
+	ServerID      *int64   `json:"server_id,omitempty"`       // Required with the agent scope: the server the agent runs commands for
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // 0 for no expiry
+}
+
+// APITokenUpdate represents the data that can be updated for an API token
+type APITokenUpdate struct {
+	Name     string   `json:"name,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	ServerID *int64   `json:"server_id,omitempty"` // Server of an agent token
+}
+
+// APITokenCreated is returned once when a token is created and includes the secret token
+type APITokenCreated struct {
+	APIToken
+	Token string `json:"token"` // Bearer token (only shown once)
+}
+
+// Expired reports whether the token has passed its expiry time
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HasScope reports whether the token grants the scope (admin grants every scope)
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == APITokenScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
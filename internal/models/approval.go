@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Approval statuses
+const (
+	ApprovalStatusPending  = "pending"  // Waiting for an approver
+	ApprovalStatusApproved = "approved" // Approved, the requester may now run it once
+	ApprovalStatusRejected = "rejected" // Rejected by an approver
+	ApprovalStatusExecuted = "executed" // Approved and run
+	ApprovalStatusExpired  = "expired"  // Not approved or run before expires_at
+)
+
+// Approval kinds
+const (
+	ApprovalKindCommand = "command"
+	ApprovalKindScript  = "script"
+)
+
+// Approval is a request to run a command or script that needs a second person's approval
+type Approval struct {
+	ID          int64      `json:"id"`
+	Kind        string     `json:"kind" example:"script"`                     // command or script
+	Summary     string     `json:"summary" example:"rotate-certs"`            // Command text or script name
+	Server      string     `json:"server" example:"db1"`                      // Target server, or "local"
+	RunAs       string     `json:"run_as" example:"root"`                     // User the execution runs as
+	Reason      string     `json:"reason" example:"script requires approval"` // Why approval is required
+	Fingerprint string     `json:"-"`                                         // Hash of the execution request the approval is bound to
+	Status      string     `json:"status" example:"pending"`
+	RequestedBy string     `json:"requested_by" example:"token:ci"`
+	DecidedBy   string     `json:"decided_by,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	ExecutedAt  *time.Time `json:"executed_at,omitempty"`
+}
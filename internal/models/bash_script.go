@@ -2,49 +2,91 @@ package models
 
 import "time"
 
+// Script interpreters
+const (
+	ScriptInterpreterBash    = "bash"
+	ScriptInterpreterSh      = "sh"
+	ScriptInterpreterPython3 = "python3"
+	ScriptInterpreterNode    = "node"
+	ScriptInterpreterPwsh    = "pwsh"
+)
+
+// ScriptInterpreters lists the interpreters a script can be run with
+var ScriptInterpreters = []string{
+	ScriptInterpreterBash,
+	ScriptInterpreterSh,
+	ScriptInterpreterPython3,
+	ScriptInterpreterNode,
+	ScriptInterpreterPwsh,
+}
+
 // BashScript represents a bash script stored in the database
 // Script content is encrypted at rest using AES-256-GCM
 type BashScript struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`             // Display name for the script
-	Description string    `json:"description"`      // Optional description
-	Content     string    `json:"content"`          // Script content (encrypted in DB)
-	Filename    string    `json:"filename"`         // Original filename if uploaded
-	Group       string    `json:"group"`            // Group/category for organization
-	Source      string    `json:"source,omitempty"` // "sqlite" or "vault"
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`              // Display name for the script
+	Description      string    `json:"description"`       // Optional description
+	Content          string    `json:"content"`           // Script content (encrypted in DB)
+	Filename         string    `json:"filename"`          // Original filename if uploaded
+	Group            string    `json:"group"`             // Group/category for organization
+	Interpreter      string    `json:"interpreter"`       // Interpreter the script runs with (bash, sh, python3, node, pwsh)
+	Tags             []string  `json:"tags"`              // Labels for finding the script
+	RequiresApproval bool      `json:"requires_approval"` // Executions need a second person's approval
+	Exclusive        bool      `json:"exclusive"`         // Only one execution of the script runs at a time
+	ExitCodes        ExitCodes `json:"exit_codes"`        // Severity of exit codes, such as {"1": "warning"}
+	ReadOnly         bool      `json:"read_only"`         // Managed by Git sync; cannot be changed through the API
+	Source           string    `json:"source,omitempty"`  // "sqlite" or "vault"
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 // BashScriptCreate represents the data needed to create a new bash script
 type BashScriptCreate struct {
-	Name        string `json:"name" validate:"required"`
-	Description string `json:"description,omitempty"`
-	Content     string `json:"content" validate:"required"`
-	Filename    string `json:"filename,omitempty"`
-	Group       string `json:"group"` // Optional, defaults to "default"
+	Name             string    `json:"name" validate:"required"`
+	Description      string    `json:"description,omitempty"`
+	Content          string    `json:"content" validate:"required"`
+	Filename         string    `json:"filename,omitempty"`
+	Group            string    `json:"group"`             // Optional, defaults to "default"
+	Interpreter      string    `json:"interpreter"`       // Optional, defaults to "bash"
+	Tags             []string  `json:"tags,omitempty"`    // Optional labels
+	RequiresApproval bool      `json:"requires_approval"` // Optional, executions need approval
+	Exclusive        bool      `json:"exclusive"`         // Optional, executions never overlap
+	ExitCodes        ExitCodes `json:"exit_codes"`        // Optional, severity of exit codes; undeclared non-zero codes are critical
+	ReadOnly         bool      `json:"-"`                 // Set by Git sync only
 }
 
 // BashScriptUpdate represents the data that can be updated for a bash script
 type BashScriptUpdate struct {
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
-	Content     string `json:"content,omitempty"`
-	Filename    string `json:"filename,omitempty"`
-	Group       string `json:"group,omitempty"`
+	Name             string    `json:"name,omitempty"`
+	Description      string    `json:"description,omitempty"`
+	Content          string    `json:"content,omitempty"`
+	Filename         string    `json:"filename,omitempty"`
+	Group            string    `json:"group,omitempty"`
+	Interpreter      string    `json:"interpreter,omitempty"`
+	Tags             []string  `json:"tags,omitempty"` // Replaces the tags when present; [] removes them
+	RequiresApproval *bool     `json:"requires_approval,omitempty"`
+	Exclusive        *bool     `json:"exclusive,omitempty"`
+	ExitCodes        ExitCodes `json:"exit_codes,omitempty"` // Replaces the exit codes when present; {} removes them
+	ReadOnly         *bool     `json:"-"`                    // Set by Git sync only
 }
 
 // BashScriptResponse is the API response format
 type BashScriptResponse struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Content     string    `json:"content,omitempty"` // Only included when specifically requested
-	Filename    string    `json:"filename"`
-	Group       string    `json:"group"`            // Group/category for organization
-	Source      string    `json:"source,omitempty"` // "sqlite" or "vault"
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	Content          string    `json:"content,omitempty"` // Only included when specifically requested
+	Filename         string    `json:"filename"`
+	Group            string    `json:"group"` // Group/category for organization
+	Interpreter      string    `json:"interpreter"`
+	Tags             []string  `json:"tags"`
+	RequiresApproval bool      `json:"requires_approval"`
+	Exclusive        bool      `json:"exclusive"`
+	ExitCodes        ExitCodes `json:"exit_codes"`
+	ReadOnly         bool      `json:"read_only"`
+	Source           string    `json:"source,omitempty"` // "sqlite" or "vault"
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 // ToResponse converts a BashScript to a response
@@ -55,15 +97,21 @@ func (s *BashScript) ToResponse(includeContent bool) *BashScriptResponse {
 		content = s.Content
 	}
 	return &BashScriptResponse{
-		ID:          s.ID,
-		Name:        s.Name,
-		Description: s.Description,
-		Content:     content,
-		Filename:    s.Filename,
-		Group:       s.Group,
-		Source:      s.Source,
-		CreatedAt:   s.CreatedAt,
-		UpdatedAt:   s.UpdatedAt,
+		ID:               s.ID,
+		Name:             s.Name,
+		Description:      s.Description,
+		Content:          content,
+		Filename:         s.Filename,
+		Group:            s.Group,
+		Interpreter:      s.Interpreter,
+		Tags:             s.Tags,
+		RequiresApproval: s.RequiresApproval,
+		Exclusive:        s.Exclusive,
+		ExitCodes:        s.ExitCodes,
+		ReadOnly:         s.ReadOnly,
+		Source:           s.Source,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
 	}
 }
 
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// BashScriptVersion is an immutable snapshot of a bash script, recorded each time it changes
+// Version 1 is the script as created; rolling back records a new version
+type BashScriptVersion struct {
+	ID          int64     `json:"id"`
+	ScriptID    int64     `json:"script_id"`
+	Version     int       `json:"version"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Content     string    `json:"content,omitempty"` // Only included when a single version is requested
+	Filename    string    `json:"filename"`
+	Group       string    `json:"group"`
+	Interpreter string    `json:"interpreter"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BashScriptDiff is a unified diff of bash script content between versions or against unsaved changes
+type BashScriptDiff struct {
+	ScriptID    int64  `json:"script_id"`
+	FromVersion int    `json:"from_version"`
+	ToVersion   int    `json:"to_version"` // 0 when diffing against unsaved changes
+	Diff        string `json:"diff"`       // Empty when the content is identical
+}
@@ -0,0 +1,55 @@
+package models
+
+// Actions of a batch operation
+const (
+	BatchActionCreate = "create"
+	BatchActionUpdate = "update"
+	BatchActionDelete = "delete"
+)
+
+// Outcomes of a batch operation
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusNotApplied = "not_applied" // Valid, but rolled back because another operation failed
+)
+
+// ServerBatchOperation is one create, update or delete of a server batch
+type ServerBatchOperation struct {
+	Action string       `json:"action" example:"create"` // create, update or delete
+	ID     int64        `json:"id,omitempty"`            // Server to update or delete
+	Server ServerUpdate `json:"server"`                  // Fields to create the server with, or to change
+}
+
+// ServerBatchRequest applies server operations in one transaction
+type ServerBatchRequest struct {
+	Operations []ServerBatchOperation `json:"operations"`
+}
+
+// EnvVariableBatchOperation is one create, update or delete of an environment variable batch
+type EnvVariableBatchOperation struct {
+	Action      string            `json:"action" example:"create"` // create, update or delete
+	ID          int64             `json:"id,omitempty"`            // Variable to update or delete
+	EnvVariable EnvVariableUpdate `json:"env_variable"`            // Fields to create the variable with, or to change
+}
+
+// EnvVariableBatchRequest applies environment variable operations in one transaction
+type EnvVariableBatchRequest struct {
+	Operations []EnvVariableBatchOperation `json:"operations"`
+}
+
+// BatchResult is the outcome of one operation of a batch
+type BatchResult struct {
+	Index  int    `json:"index"` // Position of the operation in the request
+	Action string `json:"action"`
+	ID     int64  `json:"id,omitempty"`
+	Status string `json:"status"` // ok, failed or not_applied
+	Error  string `json:"error,omitempty"`
+	Item   any    `json:"item,omitempty"` // The created or updated resource
+}
+
+// BatchResponse reports a batch; either every operation was applied or none was
+type BatchResponse struct {
+	Applied bool          `json:"applied"`
+	Results []BatchResult `json:"results"`
+}
@@ -11,9 +11,36 @@ type CommandHistory struct {
 	Server          string    `json:"server"`         // "local" for local commands, or server name/IP
 	User            string    `json:"user,omitempty"` // User who executed the command (for local commands)
 	ExecutionTimeMs int64     `json:"execution_time_ms,omitempty"`
+	OutputSize      int64     `json:"output_size,omitempty"`      // Bytes of the full output
+	OutputTruncated bool      `json:"output_truncated,omitempty"` // Output holds the end of the full output, downloadable from /history/{id}/log
+	Severity        string    `json:"severity,omitempty"`         // "ok", "warning" or "critical", from the exit code
+	ScriptID        *int64    `json:"script_id,omitempty"`        // Script that ran
+	PresetID        *int64    `json:"preset_id,omitempty"`        // Script preset the run was started from
+	SavedCommandID  *int64    `json:"saved_command_id,omitempty"` // Saved command that ran
+	ServerID        *int64    `json:"server_id,omitempty"`        // Server it ran on
 	ExecutedAt      time.Time `json:"executed_at"`
 }
 
+// CommandHistoryFilter selects command history records; unset fields match every record
+type CommandHistoryFilter struct {
+	Server         string
+	ScriptID       *int64
+	PresetID       *int64
+	SavedCommandID *int64
+	ExitCode       *int
+	User           string     // User the command ran as
+	Since          *time.Time // Executed at or after
+	Until          *time.Time // Executed before
+	Limit          int        // 0 for no limit
+}
+
+// CommandSuggestion is a previous command offered to complete what a user is typing
+type CommandSuggestion struct {
+	Command    string    `json:"command" example:"systemctl status nginx"`
+	Count      int       `json:"count" example:"14"` // Times the caller ran the command, among their latest commands
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
 // CommandHistoryCreate represents the data needed to create a command history record
 type CommandHistoryCreate struct {
 	Command         string `json:"command" validate:"required"`
@@ -22,4 +49,14 @@ type CommandHistoryCreate struct {
 	Server          string `json:"server" validate:"required"` // "local" for local commands
 	User            string `json:"user,omitempty"`             // User who executed the command
 	ExecutionTimeMs int64  `json:"execution_time_ms,omitempty"`
+	OutputSize      int64  `json:"output_size,omitempty"`
+	OutputTruncated bool   `json:"output_truncated,omitempty"`
+	Severity        string `json:"severity,omitempty"`
+
+	// What was run, by whom, for the recent items of each caller and history filters
+	Principal      string `json:"-"` // "user:<name>", "token:<name>" or "anonymous"
+	ScriptID       *int64 `json:"-"`
+	PresetID       *int64 `json:"-"`
+	SavedCommandID *int64 `json:"-"`
+	ServerID       *int64 `json:"-"`
 }
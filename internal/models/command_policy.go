@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Command policy actions
+const (
+	PolicyActionAllow = "allow" // Callers the policy applies to may only run matching commands
+	PolicyActionDeny  = "deny"  // Matching commands are rejected
+)
+
+// Command policy match types
+const (
+	PolicyMatchGlob  = "glob"  // Shell-style pattern matched against the whole command ("*" and "?")
+	PolicyMatchRegex = "regex" // Regular expression matched anywhere in the command
+)
+
+// Command policy targets
+const (
+	PolicyTargetCommand = "command" // Ad-hoc commands and each line of executed scripts
+	PolicyTargetScript  = "script"  // Names of executed scripts
+)
+
+// CommandPolicy is an allow or deny rule evaluated before commands and scripts run
+type CommandPolicy struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name" example:"no-root-wipe"`
+	Description string    `json:"description,omitempty"`
+	Action      string    `json:"action" example:"deny"`            // allow or deny
+	MatchType   string    `json:"match_type" example:"regex"`       // glob or regex
+	Pattern     string    `json:"pattern" example:"rm\\s+-rf\\s+/"` // Pattern matched against the target
+	Target      string    `json:"target" example:"command"`         // command or script
+	Principal   string    `json:"principal,omitempty"`              // "user:<name>", "token:<name>" or "role:<role>"; empty applies to everyone
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CommandPolicyCreate represents the data needed to create a command policy
+type CommandPolicyCreate struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	Action      string `json:"action" validate:"required"`
+	MatchType   string `json:"match_type"` // Optional, defaults to "glob"
+	Pattern     string `json:"pattern" validate:"required"`
+	Target      string `json:"target"`    // Optional, defaults to "command"
+	Principal   string `json:"principal"` // Optional, empty applies to everyone
+	Enabled     *bool  `json:"enabled"`   // Optional, defaults to true
+}
+
+// CommandPolicyUpdate represents the data that can be updated for a command policy
+type CommandPolicyUpdate struct {
+	Name        string  `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Action      string  `json:"action,omitempty"`
+	MatchType   string  `json:"match_type,omitempty"`
+	Pattern     string  `json:"pattern,omitempty"`
+	Target      string  `json:"target,omitempty"`
+	Principal   *string `json:"principal,omitempty"`
+	Enabled     *bool   `json:"enabled,omitempty"`
+}
+
+// PolicyDecision is the result of evaluating command policies
+type PolicyDecision struct {
+	Allowed bool           `json:"allowed"`
+	Reason  string         `json:"reason,omitempty"`
+	Policy  *CommandPolicy `json:"policy,omitempty"` // Policy that denied the execution
+}
+
+// PolicyTestRequest represents a dry run of the command policies
+type PolicyTestRequest struct {
+	Command   string   `json:"command"`   // Command to evaluate
+	ScriptID  int64    `json:"script_id"` // Script to evaluate instead of a command
+	Principal string   `json:"principal"` // Optional "user:<name>" or "token:<name>" to evaluate as
+	Roles     []string `json:"roles"`     // Optional roles of the principal
+}
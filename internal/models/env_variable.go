@@ -31,6 +31,30 @@ type EnvVariableUpdate struct {
 	Group       string `json:"group,omitempty"`
 }
 
+// EnvVariableImport is a request to import environment variables from a .env file
+type EnvVariableImport struct {
+	Content string `json:"content" validate:"required"` // .env file contents
+	Group   string `json:"group"`                       // Group the variables are created in (default: "default")
+	DryRun  bool   `json:"dry_run"`                     // Only report what would be created, without writing
+}
+
+// EnvVariableImportItem is the outcome for one variable of an imported .env file
+type EnvVariableImportItem struct {
+	Name          string `json:"name"`
+	Line          int    `json:"line"`
+	Action        string `json:"action"`                   // "create", or "skip" when a variable with the name exists
+	ExistingGroup string `json:"existing_group,omitempty"` // Group of the existing variable, for skipped ones
+}
+
+// EnvVariableImportResult is the outcome of a .env import or its dry run
+type EnvVariableImportResult struct {
+	DryRun  bool                     `json:"dry_run"`
+	Group   string                   `json:"group"`
+	Created int                      `json:"created"` // Created, or that would be created on a dry run
+	Skipped int                      `json:"skipped"`
+	Items   []*EnvVariableImportItem `json:"items"`
+}
+
 // EnvVariableResponse is the API response format (value masked by default)
 type EnvVariableResponse struct {
 	ID          int64     `json:"id"`
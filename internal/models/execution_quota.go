@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ExecutionQuotaDefault is the principal of the quota applying to callers without their own
+const ExecutionQuotaDefault = "*"
+
+// ExecutionQuota limits the command, script and terminal executions of a user, API token or role
+// A caller gets its own quota, else the quota of its first role that has one, else the "*" quota
+type ExecutionQuota struct {
+	ID                int64     `json:"id"`
+	Principal         string    `json:"principal" example:"token:ci"`      // "user:<name>", "token:<name>", "role:<role>" or "*"
+	ExecutionsPerHour int       `json:"executions_per_hour" example:"60"`  // Executions started in any rolling hour; 0 for no limit
+	MaxConcurrent     int       `json:"max_concurrent" example:"2"`        // Executions running at once; 0 for no limit
+	MaxTimeoutSeconds int       `json:"max_timeout_seconds" example:"120"` // Longest a command or script may run; 0 for the default
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ExecutionQuotaCreate represents the data needed to create an execution quota
+type ExecutionQuotaCreate struct {
+	Principal         string `json:"principal" validate:"required"`
+	ExecutionsPerHour int    `json:"executions_per_hour"`
+	MaxConcurrent     int    `json:"max_concurrent"`
+	MaxTimeoutSeconds int    `json:"max_timeout_seconds"`
+}
+
+// ExecutionQuotaUpdate represents the data that can be updated for an execution quota
+type ExecutionQuotaUpdate struct {
+	ExecutionsPerHour *int `json:"executions_per_hour,omitempty"`
+	MaxConcurrent     *int `json:"max_concurrent,omitempty"`
+	MaxTimeoutSeconds *int `json:"max_timeout_seconds,omitempty"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Execution severities
+const (
+	SeverityOK       = "ok"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the severities an exit code can be declared with
+var Severities = []string{SeverityOK, SeverityWarning, SeverityCritical}
+
+// maxExitCode is the highest exit status a process can report
+const maxExitCode = 255
+
+// ExitCodes declares what a script's exit codes mean, keyed by a code ("1"), a range ("2-9")
+// or a code and every one above it ("10+"), such as {"1": "warning", "2+": "critical"}
+type ExitCodes map[string]string
+
+// Severity classifies an exit code by the narrowest declaration containing it, the more severe on a tie
+// Undeclared codes are ok when 0 and critical otherwise, as are executions that could not complete (-1).
+func (c ExitCodes) Severity(exitCode int) string {
+	severity, width := CommandSeverity(exitCode), maxExitCode+1
+	for key, declared := range c {
+		low, high, err := ParseExitCodeRange(key)
+		if err == nil && exitCode >= low && exitCode <= high && (high-low < width || high-low == width && slices.Index(Severities, declared) > slices.Index(Severities, severity)) {
+			severity, width = declared, high-low
+		}
+	}
+	return severity
+}
+
+// CommandSeverity classifies the exit code of a command, which declares no meanings
+func CommandSeverity(exitCode int) string {
+	if exitCode == 0 {
+		return SeverityOK
+	}
+	return SeverityCritical
+}
+
+// exitCodeRangeRegex matches an ExitCodes key
+var exitCodeRangeRegex = regexp.MustCompile(`^([0-9]{1,3})(?:-([0-9]{1,3})|(\+))?$`)
+
+// ParseExitCodeRange parses an ExitCodes key, "N", "N-M" or "N+", into the codes it covers
+func ParseExitCodeRange(key string) (low, high int, err error) {
+	match := exitCodeRangeRegex.FindStringSubmatch(key)
+	if match != nil {
+		low, _ = strconv.Atoi(match[1])
+		high = low
+		if match[2] != "" {
+			high, _ = strconv.Atoi(match[2])
+		} else if match[3] != "" {
+			high = maxExitCode
+		}
+	}
+	if match == nil || high > maxExitCode || low > high {
+		return 0, 0, fmt.Errorf("invalid exit code %q (use a code or a range such as 2-9 or 10+, from 0 to %d)", key, maxExitCode)
+	}
+	return low, high, nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ResourceTypeSavedCommands is the resource type of saved commands, which are favorites and
+// recent items along with servers and bash scripts
+const ResourceTypeSavedCommands = "saved_commands"
+
+// FavoriteResourceTypes lists the resource types that can be marked as favorites
+var FavoriteResourceTypes = []string{ResourceTypeBashScripts, ResourceTypeSavedCommands, ResourceTypeServers}
+
+// Favorite marks a script, saved command or server as a favorite of a user or API token
+type Favorite struct {
+	ID           int64     `json:"id"`
+	ResourceType string    `json:"resource_type" example:"bash_scripts"` // bash_scripts, saved_commands or servers
+	ResourceID   int64     `json:"resource_id" example:"3"`
+	Name         string    `json:"name" example:"deploy"` // Name of the script, saved command or server
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RecentItem is a script, saved command or server recently run by a user or API token,
+// derived from command history
+type RecentItem struct {
+	ResourceType   string    `json:"resource_type" example:"servers"` // bash_scripts, saved_commands or servers
+	ResourceID     int64     `json:"resource_id" example:"1"`
+	Name           string    `json:"name" example:"web-01"`
+	Favorite       bool      `json:"favorite" example:"false"`
+	Executions     int       `json:"executions" example:"12"` // Executions of the item in history
+	LastHistoryID  int64     `json:"last_history_id" example:"240"`
+	LastExitCode   *int      `json:"last_exit_code,omitempty" example:"0"`
+	LastSeverity   string    `json:"last_severity,omitempty" example:"ok"`
+	LastExecutedAt time.Time `json:"last_executed_at"`
+}
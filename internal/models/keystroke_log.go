@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// KeystrokeLog is the encrypted record of what was typed in a terminal session
+type KeystrokeLog struct {
+	ID        int64            `json:"id"`
+	SessionID string           `json:"session_id" example:"9c41d7e2a0b35f18"`
+	Owner     string           `json:"owner" example:"user:admin"` // Principal that opened the session
+	Server    string           `json:"server" example:"local"`     // Target server ("local" for a shell on this host)
+	StartedAt time.Time        `json:"started_at"`
+	EndedAt   *time.Time       `json:"ended_at,omitempty"` // Unset while the session is open
+	Events    []KeystrokeEvent `json:"events,omitempty"`   // Only included when a single log is requested
+}
+
+// KeystrokeEvent is a piece of input typed in a terminal session
+// Input typed at a password prompt is not stored: the event is marked redacted instead
+type KeystrokeEvent struct {
+	Time     time.Time `json:"time"`
+	Data     string    `json:"data,omitempty" example:"ls -la"`
+	Redacted bool      `json:"redacted,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// KubernetesTarget selects the container a command or script runs in through the Kubernetes exec API
+// Either Pod or Selector must be provided
+type KubernetesTarget struct {
+	Namespace string `json:"namespace,omitempty" example:"production"`    // Default: the namespace of the configured context
+	Pod       string `json:"pod,omitempty" example:"web-7d4b9c6f5-x2k8p"` // Pod to run in
+	Selector  string `json:"selector,omitempty" example:"app=web"`        // Label selector; the first running pod by name is used (instead of pod)
+	Container string `json:"container,omitempty" example:"app"`           // Default: the pod's only container
+}
+
+// KubernetesPod is a pod commands and scripts can run in
+type KubernetesPod struct {
+	Name       string            `json:"name" example:"web-7d4b9c6f5-x2k8p"`
+	Namespace  string            `json:"namespace" example:"production"`
+	Phase      string            `json:"phase" example:"Running"` // Pending, Running, Succeeded, Failed or Unknown
+	Node       string            `json:"node,omitempty" example:"node-1"`
+	Containers []string          `json:"containers" example:"app,sidecar"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// ListFilter narrows, sorts and pages a list
+type ListFilter struct {
+	Group  string   // Only items in this group (lists with groups only)
+	Groups []string // Only items in these groups, unless nil: the groups the caller may view
+	Query  string   // Case-insensitive text the item's fields must contain
+	Tag    string   // Tag the item must have (lists with tags only)
+	Sort   string   // Field to sort by, empty for the list's default order
+	Desc   bool     // Sort in descending order
+	Limit  int      // Items per page, 0 for all
+	Offset int      // Items skipped before the page
+}
+
+// Unpaged returns the filter without its page, to load every matching item
+func (f ListFilter) Unpaged() ListFilter {
+	f.Limit, f.Offset = 0, 0
+	return f
+}
+
+// Page returns the bounds of the filter's page in a list of n items
+func (f ListFilter) Page(n int) (start, end int) {
+	if f.Limit <= 0 {
+		return 0, n
+	}
+	start = min(f.Offset, n)
+	return start, min(start+f.Limit, n)
+}
+
+// MatchesText reports whether any of the fields contains the query, ignoring case
+// An empty query matches everything
+func (f ListFilter) MatchesText(fields ...string) bool {
+	query := strings.ToLower(strings.TrimSpace(f.Query))
+	if query == "" {
+		return true
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTag trims and lowercases a tag so tags match regardless of case
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeTags normalizes, sorts and deduplicates tags, dropping empty ones
+// It never returns nil, so items without tags list them as []
+func NormalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pozgo/web-cli/internal/cron"
+)
+
+// MaintenanceWindowAllServers is the server group of windows covering every server and the local machine
+const MaintenanceWindowAllServers = "*"
+
+// MaintenanceWindow blocks executions on the servers of a group while it is in effect
+// A recurring window starts whenever its cron schedule fires and lasts duration_minutes; a
+// one-off window lasts from starts_at until ends_at.
+type MaintenanceWindow struct {
+	ID              int64      `json:"id"`
+	Name            string     `json:"name" example:"weekly-patching"`
+	Description     string     `json:"description,omitempty"`
+	ServerGroup     string     `json:"server_group" example:"production"`      // Group of the servers covered; "*" covers every server and the local machine
+	Schedule        string     `json:"schedule,omitempty" example:"0 2 * * 6"` // Cron expression starting a recurring window, in the server's time zone
+	DurationMinutes int        `json:"duration_minutes,omitempty" example:"120"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"` // Start of a one-off window
+	EndsAt          *time.Time `json:"ends_at,omitempty"`   // End of a one-off window
+	Enabled         bool       `json:"enabled"`
+	Active          bool       `json:"active"` // In effect now
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// MaintenanceWindowCreate represents the data needed to create a maintenance window
+// Set either schedule and duration_minutes, or starts_at and ends_at
+type MaintenanceWindowCreate struct {
+	Name            string     `json:"name" validate:"required"`
+	Description     string     `json:"description"`
+	ServerGroup     string     `json:"server_group" validate:"required"`
+	Schedule        string     `json:"schedule"`
+	DurationMinutes int        `json:"duration_minutes"`
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+	Enabled         *bool      `json:"enabled"` // Optional, defaults to true
+}
+
+// MaintenanceWindowUpdate represents the data that can be updated for a maintenance window
+// Setting a schedule clears starts_at and ends_at, and setting them clears the schedule
+type MaintenanceWindowUpdate struct {
+	Name            string     `json:"name,omitempty"`
+	Description     *string    `json:"description,omitempty"`
+	ServerGroup     string     `json:"server_group,omitempty"`
+	Schedule        *string    `json:"schedule,omitempty"`
+	DurationMinutes *int       `json:"duration_minutes,omitempty"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+	Enabled         *bool      `json:"enabled,omitempty"`
+}
+
+// Covers reports whether the window applies to the servers of group, "" meaning the local machine
+func (w *MaintenanceWindow) Covers(group string) bool {
+	return w.ServerGroup == MaintenanceWindowAllServers || (group != "" && w.ServerGroup == group)
+}
+
+// ActiveUntil reports whether the window is in effect at t, and when that occurrence ends
+func (w *MaintenanceWindow) ActiveUntil(t time.Time) (time.Time, bool) {
+	if !w.Enabled {
+		return time.Time{}, false
+	}
+	if w.Schedule == "" {
+		if w.StartsAt == nil || w.EndsAt == nil || t.Before(*w.StartsAt) || !t.Before(*w.EndsAt) {
+			return time.Time{}, false
+		}
+		return *w.EndsAt, true
+	}
+
+	schedule, err := cron.Parse(w.Schedule)
+	if err != nil {
+		return time.Time{}, false
+	}
+	// The window is in effect when the schedule fired within the last duration
+	duration := time.Duration(w.DurationMinutes) * time.Minute
+	start := schedule.Next(t.Add(-duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	return start.Add(duration), true
+}
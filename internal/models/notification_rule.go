@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Notification rule outcomes
+const (
+	RuleOutcomeAny     = "any"     // Every execution matches
+	RuleOutcomeFailure = "failure" // Failed executions match, those with a critical exit code
+	RuleOutcomeWarning = "warning" // Executions whose exit code the script declares a warning match
+	RuleOutcomeSuccess = "success" // Successful executions match
+)
+
+// NotificationRule sends matching executions to notification channels
+// Every condition that is set must match; unset conditions match anything
+type NotificationRule struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name" example:"slow-backups"`
+	Description   string    `json:"description,omitempty"`
+	Kind          string    `json:"kind,omitempty" example:"script"`                // command or script; empty matches both
+	Script        string    `json:"script,omitempty" example:"backup-*"`            // Glob matched against the script name; only scripts match when set
+	ServerGroup   string    `json:"server_group,omitempty" example:"production"`    // Group of the server; "local" matches local executions
+	Outcome       string    `json:"outcome" example:"failure"`                      // any, failure, warning or success
+	ExitCodes     []int     `json:"exit_codes" example:"1,2"`                       // Exit codes that match; empty matches any
+	MinDurationMs int64     `json:"min_duration_ms,omitempty" example:"600000"`     // Executions lasting at least this long match; 0 matches any
+	Channels      []string  `json:"channels" example:"webhook:ci,slack:#ops,email"` // Where matching executions are sent
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NotificationRuleCreate represents the data needed to create a notification rule
+type NotificationRuleCreate struct {
+	Name          string   `json:"name" validate:"required"`
+	Description   string   `json:"description"`
+	Kind          string   `json:"kind"`
+	Script        string   `json:"script"`
+	ServerGroup   string   `json:"server_group"`
+	Outcome       string   `json:"outcome"` // Optional, defaults to "failure"
+	ExitCodes     []int    `json:"exit_codes"`
+	MinDurationMs int64    `json:"min_duration_ms"`
+	Channels      []string `json:"channels" validate:"required"`
+	Enabled       *bool    `json:"enabled"` // Optional, defaults to true
+}
+
+// NotificationRuleUpdate represents the data that can be updated for a notification rule
+type NotificationRuleUpdate struct {
+	Name          string   `json:"name,omitempty"`
+	Description   *string  `json:"description,omitempty"`
+	Kind          *string  `json:"kind,omitempty"`
+	Script        *string  `json:"script,omitempty"`
+	ServerGroup   *string  `json:"server_group,omitempty"`
+	Outcome       string   `json:"outcome,omitempty"`
+	ExitCodes     []int    `json:"exit_codes,omitempty"` // Replaces the exit codes when present; [] matches any
+	MinDurationMs *int64   `json:"min_duration_ms,omitempty"`
+	Channels      []string `json:"channels,omitempty"`
+	Enabled       *bool    `json:"enabled,omitempty"`
+}
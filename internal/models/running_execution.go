@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RunningExecution describes a command or script execution in progress
+type RunningExecution struct {
+	ID        int64     `json:"id" example:"42"`
+	Kind      string    `json:"kind" example:"script"` // command or script
+	Command   string    `json:"command,omitempty"`     // Command that runs (commands only)
+	Script    string    `json:"script,omitempty" example:"backup"`
+	Server    string    `json:"server" example:"web1"`           // "local" or the server's name
+	User      string    `json:"user" example:"deploy"`           // User it runs as
+	StartedBy string    `json:"started_by" example:"user:alice"` // Principal that started it
+	StartedAt time.Time `json:"started_at"`
+	ElapsedMs int64     `json:"elapsed_ms" example:"12500"`
+	RequestID string    `json:"request_id,omitempty"` // ID of the API request that started it
+}
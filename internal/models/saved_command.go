@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/pozgo/web-cli/internal/executor"
+)
 
 // SavedCommand represents a command template that can be reused
 // Users can save commands with descriptions for easy execution later
@@ -13,87 +17,154 @@ type SavedCommand struct {
 	IsRemote    bool      `json:"is_remote"`   // True if this is a remote command
 	ServerID    *int64    `json:"server_id"`   // Foreign key to servers table (for remote commands)
 	SSHKeyID    *int64    `json:"ssh_key_id"`  // Foreign key to ssh_keys table (for remote commands)
+	Tags        []string  `json:"tags"`        // Labels for finding the command
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Prompts and defaults of the placeholders in the command (names in double braces), filled in when it is executed
+	Placeholders []CommandPlaceholder `json:"placeholders"`
+}
+
+// CommandPlaceholder declares a {{name}} placeholder of a saved command
+// Placeholders used in the command without a declaration need a value on every execution.
+type CommandPlaceholder struct {
+	Name    string `json:"name"`              // Placeholder as written in the command, without braces
+	Prompt  string `json:"prompt,omitempty"`  // Question asking for the value
+	Default string `json:"default,omitempty"` // Value used when none is given (empty for a required value)
+}
+
+// SavedCommandExecution holds the placeholder values of a saved command run
+// Other fields are the options of a command execution (sudo_password, stdin, workdir, ...);
+// the command, user and target come from the saved command.
+type SavedCommandExecution struct {
+	Values map[string]string `json:"values,omitempty"` // Placeholder values by name
 }
 
 // SavedCommandCreate represents the data needed to create a new saved command
 type SavedCommandCreate struct {
-	Name        string `json:"name" validate:"required"`
-	Command     string `json:"command" validate:"required"`
-	Description string `json:"description,omitempty"`
-	User        string `json:"user"`       // Optional, defaults to "root"
-	IsRemote    bool   `json:"is_remote"`  // True if this is a remote command
-	ServerID    *int64 `json:"server_id"`  // For remote commands
-	SSHKeyID    *int64 `json:"ssh_key_id"` // For remote commands
+	Name        string   `json:"name" validate:"required"`
+	Command     string   `json:"command" validate:"required"`
+	Description string   `json:"description,omitempty"`
+	User        string   `json:"user"`           // Optional, defaults to "root"
+	IsRemote    bool     `json:"is_remote"`      // True if this is a remote command
+	ServerID    *int64   `json:"server_id"`      // For remote commands
+	SSHKeyID    *int64   `json:"ssh_key_id"`     // For remote commands
+	Tags        []string `json:"tags,omitempty"` // Optional labels
+
+	Placeholders []CommandPlaceholder `json:"placeholders,omitempty"` // Prompts and defaults of the command's placeholders
 }
 
 // SavedCommandUpdate represents the data that can be updated for a saved command
 type SavedCommandUpdate struct {
-	Name        string `json:"name,omitempty"`
-	Command     string `json:"command,omitempty"`
-	Description string `json:"description,omitempty"`
-	User        string `json:"user,omitempty"`
-	IsRemote    *bool  `json:"is_remote,omitempty"`
-	ServerID    *int64 `json:"server_id,omitempty"`
-	SSHKeyID    *int64 `json:"ssh_key_id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Command     string   `json:"command,omitempty"`
+	Description string   `json:"description,omitempty"`
+	User        string   `json:"user,omitempty"`
+	IsRemote    *bool    `json:"is_remote,omitempty"`
+	ServerID    *int64   `json:"server_id,omitempty"`
+	SSHKeyID    *int64   `json:"ssh_key_id,omitempty"`
+	Tags        []string `json:"tags,omitempty"` // Replaces the tags when present; [] removes them
+
+	Placeholders []CommandPlaceholder `json:"placeholders,omitempty"` // Replaces the declarations when present; [] removes them
 }
 
 // CommandExecution represents a request to execute a command
 type CommandExecution struct {
-	Command      string `json:"command" validate:"required"` // Command to execute
-	User         string `json:"user"`                        // User to run as (default: root)
-	SudoPassword string `json:"sudo_password,omitempty"`     // Sudo password (required when user != current for local)
-	SSHPassword  string `json:"ssh_password,omitempty"`      // SSH password (for remote, if key auth fails)
-	SaveAs       string `json:"save_as,omitempty"`           // Optional: save as template with this name
-	IsRemote     bool   `json:"is_remote"`                   // True if remote execution
-	ServerID     *int64 `json:"server_id,omitempty"`         // Server ID for remote execution (SQLite)
-	ServerName   string `json:"server_name,omitempty"`       // Server name for remote execution (Vault)
-	ServerGroup  string `json:"server_group,omitempty"`      // Server group for remote execution (Vault)
-	SSHKeyID     *int64 `json:"ssh_key_id,omitempty"`        // SSH key ID for remote execution (SQLite)
-	SSHKeyName   string `json:"ssh_key_name,omitempty"`      // SSH key name for remote execution (Vault)
-	SSHKeyGroup  string `json:"ssh_key_group,omitempty"`     // SSH key group for remote execution (Vault)
+	Command      string            `json:"command" validate:"required"`  // Command to execute
+	User         string            `json:"user"`                         // User to run as (default: root)
+	SudoPassword string            `json:"sudo_password,omitempty"`      // Sudo password (required when user != current for local, or for run_as_remote_user without passwordless sudo)
+	SSHPassword  string            `json:"ssh_password,omitempty"`       // SSH password (for remote, if key auth fails)
+	SaveAs       string            `json:"save_as,omitempty"`            // Optional: save as template with this name
+	IsRemote     bool              `json:"is_remote"`                    // True if remote execution
+	ServerID     *int64            `json:"server_id,omitempty"`          // Server ID for remote execution (SQLite)
+	ServerName   string            `json:"server_name,omitempty"`        // Server name for remote execution (Vault)
+	ServerGroup  string            `json:"server_group,omitempty"`       // Server group for remote execution (Vault)
+	SSHKeyID     *int64            `json:"ssh_key_id,omitempty"`         // SSH key ID for remote execution (SQLite)
+	SSHKeyName   string            `json:"ssh_key_name,omitempty"`       // SSH key name for remote execution (Vault)
+	SSHKeyGroup  string            `json:"ssh_key_group,omitempty"`      // SSH key group for remote execution (Vault)
+	ServerRef    string            `json:"server_ref,omitempty"`         // Server as "vault:group/name" (instead of server_name and server_group)
+	SSHKeyRef    string            `json:"ssh_key_ref,omitempty"`        // SSH key as "vault:group/name" (instead of ssh_key_name and ssh_key_group)
+	ApprovalID   *int64            `json:"approval_id,omitempty"`        // Approved request to run, for servers that require approval
+	Stdin        string            `json:"stdin,omitempty"`              // Text piped into the command's stdin
+	StdinBase64  string            `json:"stdin_base64,omitempty"`       // Base64 payload piped into stdin, for binary input (instead of stdin)
+	Workdir      string            `json:"workdir,omitempty"`            // Absolute directory the command runs in (default: the user's usual directory)
+	Retries      int               `json:"retries,omitempty"`            // Retries of transient connection failures on a remote server (0-5)
+	RetryBackoff int               `json:"retry_backoff_ms,omitempty"`   // Wait before the first retry in milliseconds, doubled for each one (default: 1000)
+	StripANSI    bool              `json:"strip_ansi,omitempty"`         // Remove terminal color and cursor codes from the returned output; history keeps them
+	Kubernetes   *KubernetesTarget `json:"kubernetes,omitempty"`         // Run in a Kubernetes pod instead of locally or over SSH
+	RunAsRemote  string            `json:"run_as_remote_user,omitempty"` // User the command runs as through sudo on a remote server, after connecting as user
+
+	// Run even though a maintenance window covers the server; the override is audited
+	MaintenanceOverride bool `json:"maintenance_override,omitempty"`
 }
 
 // CommandResult represents the result of a command execution
 type CommandResult struct {
-	Command       string `json:"command"`
-	Output        string `json:"output"`
-	ExitCode      int    `json:"exit_code"`
-	User          string `json:"user"`
-	ExecutionTime int64  `json:"execution_time_ms"` // Execution time in milliseconds
-	ExecutedAt    string `json:"executed_at"`
+	Command       string             `json:"command"`
+	Output        string             `json:"output"`
+	ExitCode      int                `json:"exit_code"`
+	User          string             `json:"user"`
+	ExecutionTime int64              `json:"execution_time_ms"` // Execution time in milliseconds
+	ExecutedAt    string             `json:"executed_at"`
+	Attempts      []executor.Attempt `json:"attempts,omitempty"`         // Connection attempts, when retries were allowed
+	Truncated     bool               `json:"output_truncated,omitempty"` // Output holds the end of the full output, downloadable from /history/{history_id}/log
+	HistoryID     int64              `json:"history_id,omitempty"`       // Command history entry of the execution
 }
 
 // ScriptExecution represents a request to execute a stored bash script
 type ScriptExecution struct {
-	ScriptID       int64    `json:"script_id,omitempty"`      // ID of the script to execute (SQLite)
-	ScriptName     string   `json:"script_name,omitempty"`    // Name of the script to execute (Vault)
-	ScriptGroup    string   `json:"script_group,omitempty"`   // Script group for execution (Vault)
-	User           string   `json:"user"`                     // User to run as (default: root)
-	SudoPassword   string   `json:"sudo_password,omitempty"`  // Sudo password (required when user != current for local)
-	SSHPassword    string   `json:"ssh_password,omitempty"`   // SSH password (for remote, if key auth fails)
-	IsRemote       bool     `json:"is_remote"`                // True if remote execution
-	ServerID       *int64   `json:"server_id,omitempty"`      // Server ID for remote execution (SQLite)
-	ServerName     string   `json:"server_name,omitempty"`    // Server name for remote execution (Vault)
-	ServerGroup    string   `json:"server_group,omitempty"`   // Server group for remote execution (Vault)
-	SSHKeyID       *int64   `json:"ssh_key_id,omitempty"`     // SSH key ID for remote execution (SQLite)
-	SSHKeyName     string   `json:"ssh_key_name,omitempty"`   // SSH key name for remote execution (Vault)
-	SSHKeyGroup    string   `json:"ssh_key_group,omitempty"`  // SSH key group for remote execution (Vault)
-	IncludeEnvVars bool     `json:"include_env_vars"`         // Deprecated: use EnvVarIDs instead
-	EnvVarIDs      []int64  `json:"env_var_ids,omitempty"`    // Specific env var IDs to include (SQLite)
-	EnvVarNames    []string `json:"env_var_names,omitempty"`  // Names of env vars to include (Vault)
-	EnvVarGroups   []string `json:"env_var_groups,omitempty"` // Groups of env vars to include (Vault, paired with EnvVarNames)
+	ScriptID       int64             `json:"script_id,omitempty"`          // ID of the script to execute (SQLite)
+	ScriptName     string            `json:"script_name,omitempty"`        // Name of the script to execute (Vault)
+	ScriptGroup    string            `json:"script_group,omitempty"`       // Script group for execution (Vault)
+	User           string            `json:"user"`                         // User to run as (default: root)
+	SudoPassword   string            `json:"sudo_password,omitempty"`      // Sudo password (required when user != current for local, or for run_as_remote_user without passwordless sudo)
+	SSHPassword    string            `json:"ssh_password,omitempty"`       // SSH password (for remote, if key auth fails)
+	IsRemote       bool              `json:"is_remote"`                    // True if remote execution
+	ServerID       *int64            `json:"server_id,omitempty"`          // Server ID for remote execution (SQLite)
+	ServerName     string            `json:"server_name,omitempty"`        // Server name for remote execution (Vault)
+	ServerGroup    string            `json:"server_group,omitempty"`       // Server group for remote execution (Vault)
+	SSHKeyID       *int64            `json:"ssh_key_id,omitempty"`         // SSH key ID for remote execution (SQLite)
+	SSHKeyName     string            `json:"ssh_key_name,omitempty"`       // SSH key name for remote execution (Vault)
+	SSHKeyGroup    string            `json:"ssh_key_group,omitempty"`      // SSH key group for remote execution (Vault)
+	ServerRef      string            `json:"server_ref,omitempty"`         // Server as "vault:group/name" (instead of server_name and server_group)
+	SSHKeyRef      string            `json:"ssh_key_ref,omitempty"`        // SSH key as "vault:group/name" (instead of ssh_key_name and ssh_key_group)
+	IncludeEnvVars bool              `json:"include_env_vars"`             // Deprecated: use EnvVarIDs instead
+	EnvVarIDs      []int64           `json:"env_var_ids,omitempty"`        // Specific env var IDs to include (SQLite)
+	EnvVarNames    []string          `json:"env_var_names,omitempty"`      // Names of env vars to include (Vault)
+	EnvVarGroups   []string          `json:"env_var_groups,omitempty"`     // Groups of the EnvVarNames (Vault); without names, every env var in these groups (SQLite)
+	ApprovalID     *int64            `json:"approval_id,omitempty"`        // Approved request to run, for scripts or servers that require approval
+	DryRun         bool              `json:"dry_run,omitempty"`            // Only check the script's syntax and report what would run, without executing it
+	Stdin          string            `json:"stdin,omitempty"`              // Text piped into the script's stdin
+	StdinBase64    string            `json:"stdin_base64,omitempty"`       // Base64 payload piped into stdin, for binary input (instead of stdin)
+	Workdir        string            `json:"workdir,omitempty"`            // Absolute directory the script runs in (default: the user's usual directory)
+	PresetID       *int64            `json:"preset_id,omitempty"`          // Script preset the execution was started from, whose notification settings apply
+	WaitForLock    bool              `json:"wait_for_lock,omitempty"`      // Wait for a running exclusive script to finish instead of failing with 409 Conflict
+	Retries        int               `json:"retries,omitempty"`            // Retries of transient connection failures on a remote server (0-5)
+	RetryBackoff   int               `json:"retry_backoff_ms,omitempty"`   // Wait before the first retry in milliseconds, doubled for each one (default: 1000)
+	StripANSI      bool              `json:"strip_ansi,omitempty"`         // Remove terminal color and cursor codes from the returned output; history keeps them
+	Kubernetes     *KubernetesTarget `json:"kubernetes,omitempty"`         // Run in a Kubernetes pod instead of locally or over SSH
+	Upload         bool              `json:"upload,omitempty"`             // Copy the script to the remote server over SFTP and run it from a temporary file (SSH only)
+	Args           []string          `json:"args,omitempty"`               // Arguments passed to an uploaded script
+	RunAsRemote    string            `json:"run_as_remote_user,omitempty"` // User the script runs as through sudo on a remote server, after connecting as user
+
+	// Run even though a maintenance window covers the server; the override is audited
+	MaintenanceOverride bool `json:"maintenance_override,omitempty"`
 }
 
 // ScriptResult represents the result of a script execution
 type ScriptResult struct {
-	ScriptID      int64  `json:"script_id"`
-	ScriptName    string `json:"script_name"`
-	Output        string `json:"output"`
-	ExitCode      int    `json:"exit_code"`
-	User          string `json:"user"`
-	Server        string `json:"server"`            // "local" or server name
-	ExecutionTime int64  `json:"execution_time_ms"` // Execution time in milliseconds
-	EnvVarsCount  int    `json:"env_vars_injected"` // Number of env vars injected
+	ScriptID      int64              `json:"script_id"`
+	ScriptName    string             `json:"script_name"`
+	Output        string             `json:"output"`
+	ExitCode      int                `json:"exit_code"`
+	User          string             `json:"user"`
+	Server        string             `json:"server"`                     // "local" or server name
+	ExecutionTime int64              `json:"execution_time_ms"`          // Execution time in milliseconds
+	EnvVarsCount  int                `json:"env_vars_injected"`          // Number of env vars injected
+	DryRun        bool               `json:"dry_run,omitempty"`          // Output and exit code are from the syntax check; nothing was executed
+	EnvVars       []string           `json:"env_vars,omitempty"`         // Names of the env vars injected (or that would be, for dry runs)
+	Attempts      []executor.Attempt `json:"attempts,omitempty"`         // Connection attempts, when retries were allowed
+	Truncated     bool               `json:"output_truncated,omitempty"` // Output holds the end of the full output, downloadable from /history/{history_id}/log
+	HistoryID     int64              `json:"history_id,omitempty"`       // Command history entry of the execution
+	Severity      string             `json:"severity,omitempty"`         // "ok", "warning" or "critical", from the exit code meanings of the script
 }
@@ -1,21 +1,55 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/pozgo/web-cli/internal/cron"
+)
+
+// Slack notification modes of script presets
+const (
+	SlackNotifyNever    = ""         // Results are not posted
+	SlackNotifyAlways   = "always"   // Every result is posted
+	SlackNotifyFailures = "failures" // Only failed runs are posted
+)
+
+// PresetRunError is the last run status of a scheduled run that could not start; runs that
+// started take the severity of their exit code (ok, warning or critical)
+const PresetRunError = "error"
+
+// ValidSlackNotify reports whether mode is a Slack notification mode
+func ValidSlackNotify(mode string) bool {
+	return mode == SlackNotifyNever || mode == SlackNotifyAlways || mode == SlackNotifyFailures
+}
 
 // ScriptPreset represents a saved script execution configuration
 // It stores which environment variables to use and optionally remote execution settings
 type ScriptPreset struct {
 	ID          int64     `json:"id"`
-	Name        string    `json:"name"`        // Display name for the preset
-	Description string    `json:"description"` // Optional description
-	ScriptID    int64     `json:"script_id"`   // Reference to bash_scripts table
-	EnvVarIDs   []int64   `json:"env_var_ids"` // Selected environment variable IDs
-	IsRemote    bool      `json:"is_remote"`   // Whether this is for remote execution
-	ServerID    *int64    `json:"server_id"`   // Optional server for remote execution
-	SSHKeyID    *int64    `json:"ssh_key_id"`  // Optional SSH key for remote execution
-	User        string    `json:"user"`        // User to run as (for remote execution)
+	Name        string    `json:"name"`         // Display name for the preset
+	Description string    `json:"description"`  // Optional description
+	ScriptID    int64     `json:"script_id"`    // Reference to bash_scripts table
+	EnvVarIDs   []int64   `json:"env_var_ids"`  // Selected environment variable IDs
+	IsRemote    bool      `json:"is_remote"`    // Whether this is for remote execution
+	ServerID    *int64    `json:"server_id"`    // Optional server for remote execution
+	SSHKeyID    *int64    `json:"ssh_key_id"`   // Optional SSH key for remote execution
+	User        string    `json:"user"`         // User to run as (for remote execution)
+	SlackNotify string    `json:"slack_notify"` // Post results to Slack: always, failures or empty for never
+	Exclusive   bool      `json:"exclusive"`    // Runs from the preset never overlap other runs of the script
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Schedule         string     `json:"schedule"`                      // Cron expression of scheduled runs, in server time (empty when not scheduled)
+	ScheduleEnabled  bool       `json:"schedule_enabled"`              // Scheduled runs are on
+	LastRunAt        *time.Time `json:"last_run_at,omitempty"`         // Start of the last scheduled run
+	LastRunStatus    string     `json:"last_run_status,omitempty"`     // ok, warning, critical or error
+	LastRunError     string     `json:"last_run_error,omitempty"`      // Why the last scheduled run could not start
+	LastRunHistoryID *int64     `json:"last_run_history_id,omitempty"` // History entry of the last scheduled run
+
+	TimeoutSeconds int    `json:"timeout_seconds"`  // Longest a run from the preset may take, within the server's command timeout (0 for that timeout)
+	Retries        int    `json:"retries"`          // Retries of transient connection failures, unless the execution sets its own (0-5)
+	RetryBackoff   int    `json:"retry_backoff_ms"` // Wait before the first retry in milliseconds, unless the execution sets its own (0 for 1000)
+	NotifyChannel  string `json:"notify_channel"`   // Channel slack_notify posts results to, as in notification rules (empty for the Slack webhook)
 }
 
 // ScriptPresetCreate represents the data needed to create a new script preset
@@ -28,6 +62,16 @@ type ScriptPresetCreate struct {
 	ServerID    *int64  `json:"server_id,omitempty"`
 	SSHKeyID    *int64  `json:"ssh_key_id,omitempty"`
 	User        string  `json:"user,omitempty"`
+	SlackNotify string  `json:"slack_notify,omitempty"` // always, failures or empty for never
+	Exclusive   bool    `json:"exclusive"`
+
+	Schedule        string `json:"schedule,omitempty"` // Cron expression, such as "0 2 * * *"
+	ScheduleEnabled bool   `json:"schedule_enabled"`
+
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+	Retries        int    `json:"retries,omitempty"`
+	RetryBackoff   int    `json:"retry_backoff_ms,omitempty"`
+	NotifyChannel  string `json:"notify_channel,omitempty"` // webhook:<name>, slack, slack:<channel>, email or email:<address>
 }
 
 // ScriptPresetUpdate represents the data that can be updated for a script preset
@@ -40,6 +84,16 @@ type ScriptPresetUpdate struct {
 	ServerID    *int64  `json:"server_id,omitempty"`
 	SSHKeyID    *int64  `json:"ssh_key_id,omitempty"`
 	User        string  `json:"user,omitempty"`
+	SlackNotify *string `json:"slack_notify,omitempty"` // always, failures or "" for never
+	Exclusive   *bool   `json:"exclusive,omitempty"`
+
+	Schedule        *string `json:"schedule,omitempty"` // Cron expression, or "" to remove the schedule
+	ScheduleEnabled *bool   `json:"schedule_enabled,omitempty"`
+
+	TimeoutSeconds *int    `json:"timeout_seconds,omitempty"`
+	Retries        *int    `json:"retries,omitempty"`
+	RetryBackoff   *int    `json:"retry_backoff_ms,omitempty"`
+	NotifyChannel  *string `json:"notify_channel,omitempty"` // Channel, or "" for the Slack webhook
 }
 
 // ScriptPresetResponse is the API response format
@@ -53,8 +107,23 @@ type ScriptPresetResponse struct {
 	ServerID    *int64    `json:"server_id"`
 	SSHKeyID    *int64    `json:"ssh_key_id"`
 	User        string    `json:"user"`
+	SlackNotify string    `json:"slack_notify"`
+	Exclusive   bool      `json:"exclusive"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Schedule         string     `json:"schedule"`
+	ScheduleEnabled  bool       `json:"schedule_enabled"`
+	NextRunAt        *time.Time `json:"next_run_at,omitempty"` // Next scheduled run, when the schedule is enabled
+	LastRunAt        *time.Time `json:"last_run_at,omitempty"`
+	LastRunStatus    string     `json:"last_run_status,omitempty"` // ok, warning, critical or error
+	LastRunError     string     `json:"last_run_error,omitempty"`
+	LastRunHistoryID *int64     `json:"last_run_history_id,omitempty"`
+
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	Retries        int    `json:"retries"`
+	RetryBackoff   int    `json:"retry_backoff_ms"`
+	NotifyChannel  string `json:"notify_channel"`
 }
 
 // ToResponse converts a ScriptPreset to a response
@@ -63,6 +132,14 @@ func (p *ScriptPreset) ToResponse() *ScriptPresetResponse {
 	if envVarIDs == nil {
 		envVarIDs = []int64{}
 	}
+	var nextRunAt *time.Time
+	if p.ScheduleEnabled {
+		if schedule, err := cron.Parse(p.Schedule); err == nil {
+			if next := schedule.Next(time.Now()); !next.IsZero() {
+				nextRunAt = &next
+			}
+		}
+	}
 	return &ScriptPresetResponse{
 		ID:          p.ID,
 		Name:        p.Name,
@@ -73,8 +150,23 @@ func (p *ScriptPreset) ToResponse() *ScriptPresetResponse {
 		ServerID:    p.ServerID,
 		SSHKeyID:    p.SSHKeyID,
 		User:        p.User,
+		SlackNotify: p.SlackNotify,
+		Exclusive:   p.Exclusive,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
+
+		Schedule:         p.Schedule,
+		ScheduleEnabled:  p.ScheduleEnabled,
+		NextRunAt:        nextRunAt,
+		LastRunAt:        p.LastRunAt,
+		LastRunStatus:    p.LastRunStatus,
+		LastRunError:     p.LastRunError,
+		LastRunHistoryID: p.LastRunHistoryID,
+
+		TimeoutSeconds: p.TimeoutSeconds,
+		Retries:        p.Retries,
+		RetryBackoff:   p.RetryBackoff,
+		NotifyChannel:  p.NotifyChannel,
 	}
 }
 
@@ -1,36 +1,150 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/executor"
+)
+
+// Server transports: how commands reach a server
+const (
+	ServerTransportSSH   = "ssh"   // SSH to the server's address (default)
+	ServerTransportSSM   = "ssm"   // AWS Systems Manager Run Command, for EC2 instances without SSH access
+	ServerTransportAgent = "agent" // A web-cli agent on the server dials out and runs its commands, for servers without inbound SSH (e.g. behind NAT)
+)
+
+// Host key policies: how the SSH host key of a server is verified
+const (
+	HostKeyPolicyStrict   = executor.HostKeyPolicyStrict   // Only keys already in known_hosts
+	HostKeyPolicyTOFU     = executor.HostKeyPolicyTOFU     // Trust on first use (default)
+	HostKeyPolicyInsecure = executor.HostKeyPolicyInsecure // Any key
+)
 
 // Server represents a remote server configuration stored in the system
 // Either Name or IPAddress must be provided (or both can be provided)
 type Server struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name,omitempty"`       // Hostname (must follow hostname conventions)
-	IPAddress string    `json:"ip_address,omitempty"` // IP address
-	Port      int       `json:"port"`                 // SSH port (default: 22)
-	Username  string    `json:"username"`             // SSH username for remote connections
-	Group     string    `json:"group"`                // Group/category for organization
-	Source    string    `json:"source,omitempty"`     // "sqlite" or "vault"
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name,omitempty"`                                 // Hostname (must follow hostname conventions)
+	IPAddress        string    `json:"ip_address,omitempty"`                           // IP address
+	Port             int       `json:"port"`                                           // SSH port (default: 22)
+	Username         string    `json:"username"`                                       // SSH username for remote connections
+	Group            string    `json:"group"`                                          // Group/category for organization
+	RequiresApproval bool      `json:"requires_approval"`                              // Executions on this server need a second person's approval
+	MaxParallel      int       `json:"max_parallel"`                                   // Executions running at once on this server (0 for the SERVER_MAX_PARALLEL default)
+	Source           string    `json:"source,omitempty"`                               // "sqlite" or "vault"
+	InventoryID      string    `json:"inventory_id,omitempty" example:"aws:i-0abc123"` // Cloud instance the server is discovered from, kept up to date by the inventory sync
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// AWS Systems Manager settings, used by the ssm transport
+	Transport             string `json:"transport" example:"ssh"`                       // ssh, ssm or agent
+	SSMInstanceID         string `json:"ssm_instance_id,omitempty" example:"i-0abc123"` // Instance commands are sent to (default: the instance of an aws inventory_id)
+	SSMRegion             string `json:"ssm_region,omitempty" example:"eu-west-1"`      // Region of the instance
+	SSMRoleARN            string `json:"ssm_role_arn,omitempty"`                        // IAM role assumed to send commands
+	SSMAccessKeyID        string `json:"ssm_access_key_id,omitempty"`                   // Stored credentials (default: the environment's or the instance role of web-cli)
+	SSMSecretAccessKey    string `json:"-"`                                             // Secret of the stored credentials; never returned
+	HasSSMSecretAccessKey bool   `json:"has_ssm_secret_access_key,omitempty"`           // Credentials are stored
+
+	// SSH host key verification
+	HostKeyPolicy      string `json:"host_key_policy" example:"tofu"`                                                              // strict, tofu or insecure
+	HostKeyFingerprint string `json:"host_key_fingerprint,omitempty" example:"SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"` // Pinned SHA256 fingerprint, checked instead of known_hosts
+
+	// Wake-on-LAN
+	MACAddress string `json:"mac_address,omitempty" example:"00:11:22:33:44:55"` // Network card woken by POST /servers/{id}/wake
+}
+
+// SSMInstance returns the instance commands are sent to with the ssm transport
+// Servers discovered from EC2 default to their instance.
+func (s *Server) SSMInstance() string {
+	if s.SSMInstanceID != "" {
+		return s.SSMInstanceID
+	}
+	if id, ok := strings.CutPrefix(s.InventoryID, "aws:"); ok {
+		return id
+	}
+	return ""
+}
+
+// ApplySSMUpdate changes the transport and Systems Manager settings set in an update
+// Stored credentials are replaced together: a new access key ID without a secret removes the secret.
+func (s *Server) ApplySSMUpdate(update *ServerUpdate) {
+	if update.Transport != "" {
+		s.Transport = update.Transport
+	}
+	if update.SSMInstanceID != nil {
+		s.SSMInstanceID = *update.SSMInstanceID
+	}
+	if update.SSMRegion != "" {
+		s.SSMRegion = update.SSMRegion
+	}
+	if update.SSMRoleARN != nil {
+		s.SSMRoleARN = *update.SSMRoleARN
+	}
+	if update.SSMAccessKeyID != nil {
+		s.SSMAccessKeyID = *update.SSMAccessKeyID
+		s.SSMSecretAccessKey = ""
+		if update.SSMSecretAccessKey != nil && s.SSMAccessKeyID != "" {
+			s.SSMSecretAccessKey = *update.SSMSecretAccessKey
+		}
+	}
+	s.HasSSMSecretAccessKey = s.SSMSecretAccessKey != ""
+}
+
+// ApplyHostKeyUpdate changes the host key verification settings set in an update
+func (s *Server) ApplyHostKeyUpdate(update *ServerUpdate) {
+	if update.HostKeyPolicy != "" {
+		s.HostKeyPolicy = update.HostKeyPolicy
+	}
+	if update.HostKeyFingerprint != nil {
+		s.HostKeyFingerprint = *update.HostKeyFingerprint
+	}
 }
 
 // ServerCreate represents the data needed to create a new server
 // At least one of Name or IPAddress must be provided
 type ServerCreate struct {
-	Name      string `json:"name,omitempty"`
-	IPAddress string `json:"ip_address,omitempty"`
-	Port      int    `json:"port"`     // Optional, defaults to 22 if not provided
-	Username  string `json:"username"` // SSH username for remote connections
-	Group     string `json:"group"`    // Optional, defaults to "default"
+	Name             string `json:"name,omitempty"`
+	IPAddress        string `json:"ip_address,omitempty"`
+	Port             int    `json:"port"`              // Optional, defaults to 22 if not provided
+	Username         string `json:"username"`          // SSH username for remote connections
+	Group            string `json:"group"`             // Optional, defaults to "default"
+	RequiresApproval bool   `json:"requires_approval"` // Optional, executions need approval
+	MaxParallel      int    `json:"max_parallel"`      // Optional, 0 uses the server-wide default
+	InventoryID      string `json:"-"`                 // Set by the inventory sync only
+
+	Transport          string `json:"transport,omitempty"`             // Optional, ssh (default), ssm or agent
+	SSMInstanceID      string `json:"ssm_instance_id,omitempty"`       // Required for ssm unless discovered from EC2
+	SSMRegion          string `json:"ssm_region,omitempty"`            // Required for ssm
+	SSMRoleARN         string `json:"ssm_role_arn,omitempty"`          // Optional IAM role to assume
+	SSMAccessKeyID     string `json:"ssm_access_key_id,omitempty"`     // Optional stored credentials, with ssm_secret_access_key
+	SSMSecretAccessKey string `json:"ssm_secret_access_key,omitempty"` // Encrypted at rest
+
+	HostKeyPolicy      string `json:"host_key_policy,omitempty"`      // Optional, strict, tofu (default) or insecure
+	HostKeyFingerprint string `json:"host_key_fingerprint,omitempty"` // Optional pinned SHA256 fingerprint of the host key
+
+	MACAddress string `json:"mac_address,omitempty"` // Optional, for Wake-on-LAN
 }
 
 // ServerUpdate represents the data that can be updated for a server
 type ServerUpdate struct {
-	Name      string `json:"name,omitempty"`
-	IPAddress string `json:"ip_address,omitempty"`
-	Port      int    `json:"port,omitempty"`
-	Username  string `json:"username,omitempty"`
-	Group     string `json:"group,omitempty"`
+	Name             string `json:"name,omitempty"`
+	IPAddress        string `json:"ip_address,omitempty"`
+	Port             int    `json:"port,omitempty"`
+	Username         string `json:"username,omitempty"`
+	Group            string `json:"group,omitempty"`
+	RequiresApproval *bool  `json:"requires_approval,omitempty"`
+	MaxParallel      *int   `json:"max_parallel,omitempty"` // 0 restores the server-wide default
+
+	Transport          string  `json:"transport,omitempty"`
+	SSMInstanceID      *string `json:"ssm_instance_id,omitempty"` // "" restores the instance of an aws inventory_id
+	SSMRegion          string  `json:"ssm_region,omitempty"`
+	SSMRoleARN         *string `json:"ssm_role_arn,omitempty"`      // "" stops assuming a role
+	SSMAccessKeyID     *string `json:"ssm_access_key_id,omitempty"` // Replaces the stored credentials with ssm_secret_access_key; "" removes them
+	SSMSecretAccessKey *string `json:"ssm_secret_access_key,omitempty"`
+
+	HostKeyPolicy      string  `json:"host_key_policy,omitempty"`
+	HostKeyFingerprint *string `json:"host_key_fingerprint,omitempty"` // "" removes the pinned fingerprint
+
+	MACAddress *string `json:"mac_address,omitempty"` // "" removes it
 }
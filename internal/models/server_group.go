@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DefaultServerGroup is the group of servers created without one; it cannot be renamed or deleted
+const DefaultServerGroup = "default"
+
+// ServerGroup is a named group of servers with defaults for its members
+// Servers belong to the group whose name is in their group field
+type ServerGroup struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name" example:"production"`
+	Description     string    `json:"description" example:"Customer-facing web and database servers"`
+	DefaultUsername string    `json:"default_username" example:"deploy"`        // Username of servers added without one (empty for root)
+	DefaultPort     int       `json:"default_port" example:"2222"`              // SSH port of servers added without one (0 for 22)
+	DefaultSSHKeyID *int64    `json:"default_ssh_key_id,omitempty" example:"3"` // SSH key used on members when an execution selects none
+	ServerCount     int       `json:"server_count" example:"12"`                // Servers in the group
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ServerGroupCreate represents the data needed to create a server group
+type ServerGroupCreate struct {
+	Name            string `json:"name" validate:"required"`
+	Description     string `json:"description"`
+	DefaultUsername string `json:"default_username"`
+	DefaultPort     int    `json:"default_port"`
+	DefaultSSHKeyID *int64 `json:"default_ssh_key_id,omitempty"`
+}
+
+// ServerGroupUpdate represents the data that can be updated for a server group
+// Renaming a group moves its servers and server group permissions along
+type ServerGroupUpdate struct {
+	Name            string  `json:"name,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	DefaultUsername *string `json:"default_username,omitempty"`
+	DefaultPort     *int    `json:"default_port,omitempty"`
+	DefaultSSHKeyID *int64  `json:"default_ssh_key_id,omitempty"` // 0 removes the default key
+}
+
+// ServerGroupMembers represents servers to add to a server group
+type ServerGroupMembers struct {
+	ServerIDs []int64 `json:"server_ids" validate:"required"`
+}
+
+// ServerGroupExecutionResult is the outcome of a group execution on one member
+type ServerGroupExecutionResult struct {
+	ServerID int64           `json:"server_id" example:"4"`
+	Server   string          `json:"server" example:"web1"`
+	Status   int             `json:"status" example:"200"`        // HTTP status the execution would have returned on its own
+	Result   json.RawMessage `json:"result" swaggertype:"object"` // The execution's result, or its error response
+}
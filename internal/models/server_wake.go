@@ -0,0 +1,19 @@
+package models
+
+// ServerWakeRequest selects where the Wake-on-LAN magic packet of a server is sent from and to
+// All fields are optional: by default web-cli broadcasts the packet on its own network.
+type ServerWakeRequest struct {
+	RelayServerID int64  `json:"relay_server_id,omitempty" example:"3"`       // Server on the sleeping server's network that sends the packet, with python3, wakeonlan or wol
+	Broadcast     string `json:"broadcast,omitempty" example:"192.168.1.255"` // IPv4 address the packet is sent to (default: 255.255.255.255)
+	Port          int    `json:"port,omitempty" example:"9"`                  // UDP port the packet is sent to (default: 9)
+}
+
+// ServerWakeResult reports a magic packet that was sent
+// The server may take a while to boot; the packet being sent does not mean it woke up.
+type ServerWakeResult struct {
+	Server     string `json:"server" example:"nas"`
+	MACAddress string `json:"mac_address" example:"00:11:22:33:44:55"`
+	Broadcast  string `json:"broadcast" example:"255.255.255.255"`
+	Port       int    `json:"port" example:"9"`
+	Relay      string `json:"relay,omitempty" example:"pi"` // Server that sent the packet, when relayed
+}
@@ -4,13 +4,15 @@ import "time"
 
 // SSHKey represents an SSH private key stored in the system
 type SSHKey struct {
-	ID         int64     `json:"id"`
-	Name       string    `json:"name"`
-	PrivateKey string    `json:"private_key"`      // Decrypted value
-	Group      string    `json:"group"`            // Group/category for organization
-	Source     string    `json:"source,omitempty"` // "sqlite" or "vault"
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	PrivateKey string     `json:"private_key"`            // Decrypted value
+	Group      string     `json:"group"`                  // Group/category for organization
+	Source     string     `json:"source,omitempty"`       // "sqlite" or "vault"
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // Last execution or terminal session that used the key
+	UseCount   int64      `json:"use_count"`              // Number of executions and terminal sessions that used the key
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 // SSHKeyCreate represents the data needed to create a new SSH key
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// TerminalFile describes a file in a terminal session's file exchange directory
+type TerminalFile struct {
+	Path       string    `json:"path" example:"reports/disk-usage.csv"` // Slash-separated path relative to the directory
+	Size       int64     `json:"size" example:"2048"`                   // Size in bytes
+	ModifiedAt time.Time `json:"modified_at"`
+}
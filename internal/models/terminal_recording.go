@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TerminalRecording describes a recorded terminal session (asciicast v2 file)
+type TerminalRecording struct {
+	ID        string    `json:"id" example:"20251112T091500Z-3f2a9c1b"` // File name without the .cast extension
+	Width     int       `json:"width" example:"80"`                     // Terminal columns when the session started
+	Height    int       `json:"height" example:"24"`                    // Terminal rows when the session started
+	Size      int64     `json:"size" example:"48213"`                   // File size in bytes
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"` // Last write; the session end for finished sessions
+}
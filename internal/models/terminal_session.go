@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TerminalSession describes an open interactive terminal session
+type TerminalSession struct {
+	ID          string    `json:"id" example:"9c41d7e2a0b35f18"`
+	Owner       string    `json:"owner" example:"user:admin"`                                 // Principal that opened the session
+	Shell       string    `json:"shell" example:"/bin/bash"`                                  // Shell running in the session
+	Server      string    `json:"server" example:"local"`                                     // Target server ("local" for a shell on this host)
+	RecordingID string    `json:"recording_id,omitempty" example:"20251112T091500Z-3f2a9c1b"` // Recording of the session, if recorded
+	Observers   int       `json:"observers" example:"0"`                                      // Read-only observers watching the session
+	Connected   bool      `json:"connected" example:"true"`                                   // False while detached and waiting to be resumed
+	StartedAt   time.Time `json:"started_at"`
+}
@@ -12,6 +12,18 @@ type VaultConfig struct {
 	Enabled   bool      `json:"enabled"`             // Whether Vault integration is enabled
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	AuthMethod string `json:"auth_method"`          // "token" (default), "approle" or "kubernetes"
+	RoleID     string `json:"role_id,omitempty"`    // AppRole role ID
+	SecretID   string `json:"secret_id,omitempty"`  // AppRole secret ID (decrypted, not included in responses)
+	AuthMount  string `json:"auth_mount,omitempty"` // Path the auth method is enabled at (default: the method's name)
+
+	KubernetesRole string `json:"kubernetes_role,omitempty"` // Vault role bound to the pod's service account
+	JWTPath        string `json:"jwt_path,omitempty"`        // Service account token file (default: the in-cluster path)
+
+	SSHCAMount string `json:"ssh_ca_mount,omitempty"` // Path the SSH secrets engine is enabled at (default: "ssh-client-signer")
+	SSHCARole  string `json:"ssh_ca_role,omitempty"`  // Role that signs keys for remote execution (empty disables signing)
+	SSHCATTL   string `json:"ssh_ca_ttl,omitempty"`   // Requested certificate lifetime (default: the role's TTL)
 }
 
 // VaultConfigCreate represents the data needed to create/update Vault configuration
@@ -21,6 +33,18 @@ type VaultConfigCreate struct {
 	Namespace string `json:"namespace,omitempty"`
 	MountPath string `json:"mount_path,omitempty"`
 	Enabled   bool   `json:"enabled"`
+
+	AuthMethod string `json:"auth_method,omitempty"` // "token" (default), "approle" or "kubernetes"
+	RoleID     string `json:"role_id,omitempty"`
+	SecretID   string `json:"secret_id,omitempty"`
+	AuthMount  string `json:"auth_mount,omitempty"`
+
+	KubernetesRole string `json:"kubernetes_role,omitempty"`
+	JWTPath        string `json:"jwt_path,omitempty"`
+
+	SSHCAMount string `json:"ssh_ca_mount,omitempty"`
+	SSHCARole  string `json:"ssh_ca_role,omitempty"`
+	SSHCATTL   string `json:"ssh_ca_ttl,omitempty"`
 }
 
 // VaultConfigResponse is the API response format (token masked)
@@ -33,6 +57,18 @@ type VaultConfigResponse struct {
 	HasToken  bool      `json:"has_token"` // Indicates if a token is configured
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	AuthMethod  string `json:"auth_method"`
+	RoleID      string `json:"role_id,omitempty"`
+	HasSecretID bool   `json:"has_secret_id"` // Indicates if an AppRole secret ID is configured
+	AuthMount   string `json:"auth_mount,omitempty"`
+
+	KubernetesRole string `json:"kubernetes_role,omitempty"`
+	JWTPath        string `json:"jwt_path,omitempty"`
+
+	SSHCAMount string `json:"ssh_ca_mount,omitempty"`
+	SSHCARole  string `json:"ssh_ca_role,omitempty"`
+	SSHCATTL   string `json:"ssh_ca_ttl,omitempty"`
 }
 
 // ToResponse converts VaultConfig to a safe response (without token)
@@ -46,6 +82,18 @@ func (v *VaultConfig) ToResponse() VaultConfigResponse {
 		HasToken:  v.Token != "",
 		CreatedAt: v.CreatedAt,
 		UpdatedAt: v.UpdatedAt,
+
+		AuthMethod:  v.AuthMethod,
+		RoleID:      v.RoleID,
+		HasSecretID: v.SecretID != "",
+		AuthMount:   v.AuthMount,
+
+		KubernetesRole: v.KubernetesRole,
+		JWTPath:        v.JWTPath,
+
+		SSHCAMount: v.SSHCAMount,
+		SSHCARole:  v.SSHCARole,
+		SSHCATTL:   v.SSHCATTL,
 	}
 }
 
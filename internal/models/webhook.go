@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Webhook is an HTTP endpoint notified when executions finish
+type Webhook struct {
+	ID              int64      `json:"id"`
+	Name            string     `json:"name" example:"ci"`
+	URL             string     `json:"url" example:"https://ci.example.com/hooks/web-cli"`
+	Secret          string     `json:"-"`                                        // Key for the body signature; never returned
+	HasSecret       bool       `json:"has_secret"`                               // Deliveries are signed
+	Events          []string   `json:"events" example:"script.failed,command.*"` // Event types or patterns; empty for every event
+	Enabled         bool       `json:"enabled"`
+	LastStatus      string     `json:"last_status,omitempty" example:"delivered"` // Outcome of the last delivery: delivered or failed
+	LastError       string     `json:"last_error,omitempty"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"` // Time of the last delivery attempt
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Webhook delivery statuses
+const (
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusFailed    = "failed"
+)
+
+// WebhookCreate represents the data needed to create a webhook
+type WebhookCreate struct {
+	Name    string   `json:"name" validate:"required"`
+	URL     string   `json:"url" validate:"required"`
+	Secret  string   `json:"secret"`  // Optional, signs deliveries with HMAC-SHA256
+	Events  []string `json:"events"`  // Optional, defaults to every event
+	Enabled *bool    `json:"enabled"` // Optional, defaults to true
+}
+
+// WebhookUpdate represents the data that can be updated for a webhook
+type WebhookUpdate struct {
+	Name    string   `json:"name,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Secret  *string  `json:"secret,omitempty"` // "" removes the secret
+	Events  []string `json:"events,omitempty"` // Replaces the filters when present; [] subscribes to every event
+	Enabled *bool    `json:"enabled,omitempty"`
+}
+
+// WebhookTestResult reports a test delivery to a webhook
+type WebhookTestResult struct {
+	Delivered bool   `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Target is a destination events are delivered to
+type Target interface {
+	// Name identifies the target in logs
+	Name() string
+	// Send delivers one event; errors wrapped with Permanent are not retried
+	Send(ctx context.Context, event *Event) error
+}
+
+// permanentError marks a delivery failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, such as a 4xx response
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// DispatcherConfig holds delivery settings
+type DispatcherConfig struct {
+	Workers    int           // Concurrent deliveries (default: 4)
+	QueueSize  int           // Deliveries buffered while targets are slow (default: 1000)
+	MaxRetries int           // Retries of a failed delivery, with exponential backoff (default: 3, negative for none)
+	Timeout    time.Duration // Timeout of each attempt (default: 10s)
+	Backoff    time.Duration // Wait before the first retry, doubled for each one (default: 1s)
+
+	// Report is called after the last attempt of each delivery, with its error or nil
+	Report func(target Target, event *Event, err error)
+}
+
+// delivery is one event on its way to one target
+type delivery struct {
+	target Target
+	event  *Event
+}
+
+// Dispatcher delivers events to targets in the background
+// Failed deliveries are retried with exponential backoff. When the queue is
+// full, new deliveries are dropped and logged rather than blocking the caller.
+type Dispatcher struct {
+	config  DispatcherConfig
+	queue   chan delivery
+	done    chan struct{}
+	once    sync.Once
+	workers sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher and starts its workers
+func NewDispatcher(config DispatcherConfig) *Dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	} else if config.MaxRetries < 0 {
+		config.MaxRetries = 0 // Never retry
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = time.Second
+	}
+
+	d := &Dispatcher{
+		config: config,
+		queue:  make(chan delivery, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < config.Workers; i++ {
+		d.workers.Add(1)
+		go d.run()
+	}
+	return d
+}
+
+// Dispatch queues the event for delivery to each target without blocking
+func (d *Dispatcher) Dispatch(event *Event, targets ...Target) {
+	for _, target := range targets {
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+
+		select {
+		case d.queue <- delivery{target: target, event: event}:
+		default:
+			log.Printf("Warning: notification queue full, dropped %s event for %s", event.Type, target.Name())
+		}
+	}
+}
+
+// Close delivers queued events and stops the workers
+// Retries still waiting are given up
+func (d *Dispatcher) Close() {
+	d.once.Do(func() {
+		close(d.done)
+	})
+	d.workers.Wait()
+}
+
+// run delivers queued events until the dispatcher is closed
+func (d *Dispatcher) run() {
+	defer d.workers.Done()
+	for {
+		select {
+		case item := <-d.queue:
+			d.deliver(item)
+		case <-d.done:
+			for {
+				select {
+				case item := <-d.queue:
+					d.deliver(item)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends one delivery and reports its outcome
+func (d *Dispatcher) deliver(item delivery) {
+	err := d.attempt(item)
+	if err != nil {
+		log.Printf("Warning: failed to deliver %s event %s to %s: %v", item.event.Type, item.event.ID, item.target.Name(), err)
+	}
+	if d.config.Report != nil {
+		d.config.Report(item.target, item.event, err)
+	}
+}
+
+// attempt sends a delivery, retrying with exponential backoff
+func (d *Dispatcher) attempt(item delivery) error {
+	backoff := d.config.Backoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+		err := item.target.Send(ctx, item.event)
+		cancel()
+		if err == nil || IsPermanent(err) || attempt >= d.config.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-d.done:
+			return err // Do not hold up shutdown with retries
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,245 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Email TLS modes
+const (
+	EmailTLSStartTLS = "starttls" // Upgrade a plain connection with STARTTLS (default)
+	EmailTLSImplicit = "tls"      // Connect with TLS, usually on port 465
+	EmailTLSNone     = "none"     // Send in plain text, for local relays only
+)
+
+// DefaultEmailSubject is the subject template used when none is configured
+const DefaultEmailSubject = `[web-cli] {{with .Execution}}{{if eq .Kind "script"}}Script {{.ScriptName}}{{else}}Command {{.Command}}{{end}} {{if .Success}}succeeded{{else if eq .Severity "warning"}}finished with a warning{{else}}failed{{end}} on {{.Server}}{{else}}{{.Type}}{{end}}`
+
+// DefaultEmailBody is the body template used when none is configured
+const DefaultEmailBody = `{{with .Execution}}{{if eq .Kind "script"}}Script:       {{.ScriptName}}{{else}}Command:      {{.Command}}{{end}}
+Server:       {{.Server}}
+User:         {{.User}}
+Exit code:    {{.ExitCode}}
+Duration:     {{.DurationMs}} ms
+{{- if .TriggeredBy}}
+Triggered by: {{.TriggeredBy}}{{end}}
+{{- if .PresetName}}
+Preset:       {{.PresetName}}{{end}}
+{{- if .Error}}
+Error:        {{.Error}}{{end}}
+Time:         {{$.Timestamp.Format "2006-01-02 15:04:05 MST"}}
+
+Output{{if .OutputTruncated}} (last {{len .Output}} bytes){{end}}:
+
+{{.Output}}{{else}}This is a {{.Type}} event from web-cli.
+{{end}}`
+
+// EmailConfig holds SMTP and message settings
+type EmailConfig struct {
+	Host     string
+	Port     int      // Default: 465 with implicit TLS, 587 otherwise
+	Username string   // Empty to send without authentication
+	Password string   // Password for Username
+	TLS      string   // starttls (default), tls or none
+	From     string   // Sender address
+	To       []string // Recipient addresses
+	Events   []string // Event types or patterns emails are sent for (default: *.failed)
+	Subject  string   // Subject template (default: DefaultEmailSubject)
+	Body     string   // Body template (default: DefaultEmailBody)
+}
+
+// Email sends events by email through an SMTP server
+type Email struct {
+	config  EmailConfig
+	subject *template.Template
+	body    *template.Template
+}
+
+// NewEmail validates the settings and parses the templates
+// Templates use text/template and are executed with the Event
+func NewEmail(config EmailConfig) (*Email, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	switch config.TLS {
+	case "":
+		config.TLS = EmailTLSStartTLS
+	case EmailTLSStartTLS, EmailTLSImplicit, EmailTLSNone:
+	default:
+		return nil, fmt.Errorf("invalid TLS mode %q (must be starttls, tls or none)", config.TLS)
+	}
+	if config.Port == 0 {
+		config.Port = 587
+		if config.TLS == EmailTLSImplicit {
+			config.Port = 465
+		}
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", config.From, err)
+	}
+	if len(config.To) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	for _, to := range config.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid recipient address %q: %w", to, err)
+		}
+	}
+	if len(config.Events) == 0 {
+		config.Events = []string{"*.failed"}
+	}
+	for _, event := range config.Events {
+		if !ValidFilter(event) {
+			return nil, fmt.Errorf("invalid event %q (valid events: %s, or patterns such as script.* and *.failed)", event, strings.Join(EventTypes, ", "))
+		}
+	}
+	if config.Subject == "" {
+		config.Subject = DefaultEmailSubject
+	}
+	if config.Body == "" {
+		config.Body = DefaultEmailBody
+	}
+
+	subject, err := template.New("subject").Parse(config.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	body, err := template.New("body").Parse(config.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+
+	return &Email{config: config, subject: subject, body: body}, nil
+}
+
+// Name identifies the target in logs
+func (e *Email) Name() string {
+	return "email to " + strings.Join(e.config.To, ", ")
+}
+
+// WithRecipients returns a copy of the target emailing other recipients
+func (e *Email) WithRecipients(to ...string) *Email {
+	clone := *e
+	clone.config.To = to
+	return &clone
+}
+
+// Wants reports whether emails are sent for the event
+func (e *Email) Wants(event *Event) bool {
+	return event.Matches(e.config.Events)
+}
+
+// Send emails the event; rejections by the SMTP server (5xx replies) are not retried
+func (e *Email) Send(ctx context.Context, event *Event) error {
+	msg, err := e.message(event)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	err = e.send(ctx, msg)
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
+
+// message renders the templates into an RFC 5322 message
+func (e *Email) message(event *Event) ([]byte, error) {
+	var subject, body bytes.Buffer
+	if err := e.subject.Execute(&subject, event); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := e.body.Execute(&body, event); err != nil {
+		return nil, fmt.Errorf("failed to render body: %w", err)
+	}
+
+	// The subject is a single header line; newlines from the event must not start new headers
+	subjectLine := strings.Join(strings.Fields(subject.String()), " ")
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subjectLine))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@web-cli>\r\n", event.ID)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	fmt.Fprintf(&msg, "X-WebCLI-Event: %s\r\n", event.Type)
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body.String(), "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
+
+// send delivers a message over one SMTP session
+func (e *Email) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	tlsConfig := &tls.Config{ServerName: e.config.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if e.config.TLS == EmailTLSImplicit {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if e.config.TLS == EmailTLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if e.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(e.config.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.config.To {
+		rcpt, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,147 @@
+// Package notify tells external systems about finished executions
+// Events are delivered in the background to targets such as webhooks, so a
+// slow or unreachable endpoint never holds up the execution that raised them.
+package notify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"path"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// Event types
+const (
+	EventCommandSucceeded = "command.succeeded"
+	EventCommandFailed    = "command.failed"
+	EventScriptSucceeded  = "script.succeeded"
+	EventScriptWarning    = "script.warning" // The exit code is declared a warning by the script
+	EventScriptFailed     = "script.failed"
+	EventPing             = "ping" // Sent by webhook tests
+)
+
+// EventTypes lists the event types targets can subscribe to
+var EventTypes = []string{EventCommandSucceeded, EventCommandFailed, EventScriptSucceeded, EventScriptWarning, EventScriptFailed}
+
+// Execution kinds
+const (
+	KindCommand = "command"
+	KindScript  = "script"
+)
+
+// MaxOutput bounds the output included in an event, in bytes
+const MaxOutput = 4096
+
+// Event is a notification about something that happened in web-cli
+type Event struct {
+	ID        string     `json:"id" example:"9f86d081884c7d65"` // Unique per event; repeated deliveries carry the same ID
+	Type      string     `json:"type" example:"script.failed"`
+	Timestamp time.Time  `json:"timestamp"`
+	Execution *Execution `json:"execution,omitempty"`
+}
+
+// Execution describes a finished command or script run
+type Execution struct {
+	Kind            string `json:"kind" example:"script"` // command or script
+	Command         string `json:"command,omitempty"`     // Command that ran (commands only)
+	ScriptID        int64  `json:"script_id,omitempty"`
+	ScriptName      string `json:"script_name,omitempty"`
+	PresetID        int64  `json:"preset_id,omitempty"` // Script preset the script was run from
+	PresetName      string `json:"preset_name,omitempty"`
+	Server          string `json:"server" example:"web1"`  // "local" or the server's name
+	ServerGroup     string `json:"server_group,omitempty"` // Group of the remote server
+	User            string `json:"user" example:"deploy"`
+	Success         bool   `json:"success"`
+	Severity        string `json:"severity,omitempty" example:"warning"` // ok, warning or critical, from the exit code
+	ExitCode        int    `json:"exit_code"`
+	Error           string `json:"error,omitempty"` // Why the execution could not complete, such as an SSH failure
+	DurationMs      int64  `json:"duration_ms"`
+	Output          string `json:"output"`                     // Last MaxOutput bytes of the output, secrets masked
+	OutputTruncated bool   `json:"output_truncated,omitempty"` // Output was cut to its end
+	TriggeredBy     string `json:"triggered_by,omitempty"`     // Principal that ran it, such as user:alice or token:ci
+	RequestID       string `json:"request_id,omitempty"`       // ID of the API request that ran it
+}
+
+// NewExecutionEvent returns the event for a finished execution
+// The event type follows the kind and severity, and the output is cut to its last MaxOutput bytes
+func NewExecutionEvent(exec Execution) *Event {
+	exec.Output, exec.OutputTruncated = tail(exec.Output, MaxOutput)
+
+	eventType := EventCommandSucceeded
+	switch severity := exec.severity(); {
+	case exec.Kind == KindScript && severity == models.SeverityOK:
+		eventType = EventScriptSucceeded
+	case exec.Kind == KindScript && severity == models.SeverityWarning:
+		eventType = EventScriptWarning
+	case exec.Kind == KindScript:
+		eventType = EventScriptFailed
+	case !exec.Success:
+		eventType = EventCommandFailed
+	}
+	return NewEvent(eventType, &exec)
+}
+
+// severity returns the severity of the execution, ok or critical by its success when it has none
+func (e *Execution) severity() string {
+	switch {
+	case e.Severity != "":
+		return e.Severity
+	case e.Success:
+		return models.SeverityOK
+	default:
+		return models.SeverityCritical
+	}
+}
+
+// NewEvent returns an event of the type with a new ID
+func NewEvent(eventType string, exec *Execution) *Event {
+	return &Event{ID: newEventID(), Type: eventType, Timestamp: time.Now().UTC(), Execution: exec}
+}
+
+// Matches reports whether the event matches one of the filters
+// Filters are event types or patterns such as "script.*" or "*.failed"; no filters match every event
+func (e *Event) Matches(filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		if ok, _ := path.Match(filter, e.Type); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidFilter reports whether a filter is an event type or a valid pattern
+func ValidFilter(filter string) bool {
+	if _, err := path.Match(filter, ""); err != nil || filter == "" {
+		return false
+	}
+	for _, eventType := range EventTypes {
+		if ok, _ := path.Match(filter, eventType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// tail returns the last max bytes of s, cut at a character boundary
+func tail(s string, max int) (string, bool) {
+	if len(s) <= max {
+		return s, false
+	}
+	s = s[len(s)-max:]
+	for len(s) > 0 && !utf8.RuneStart(s[0]) {
+		s = s[1:]
+	}
+	return s, true
+}
+
+func newEventID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,346 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+func TestNewExecutionEvent(t *testing.T) {
+	tests := []struct {
+		kind    string
+		success bool
+		want    string
+	}{
+		{KindCommand, true, EventCommandSucceeded},
+		{KindCommand, false, EventCommandFailed},
+		{KindScript, true, EventScriptSucceeded},
+		{KindScript, false, EventScriptFailed},
+	}
+	for _, tt := range tests {
+		event := NewExecutionEvent(Execution{Kind: tt.kind, Success: tt.success})
+		if event.Type != tt.want || event.ID == "" {
+			t.Errorf("Expected a %s event with an ID, got %q (%q)", tt.want, event.Type, event.ID)
+		}
+	}
+
+	// Exit codes a script declares a warning raise their own event
+	if event := NewExecutionEvent(Execution{Kind: KindScript, Severity: models.SeverityWarning, ExitCode: 1}); event.Type != EventScriptWarning {
+		t.Errorf("Expected a %s event, got %q", EventScriptWarning, event.Type)
+	}
+
+	// Long output keeps its end, where errors usually are
+	output := strings.Repeat("x", MaxOutput) + "é done"
+	event := NewExecutionEvent(Execution{Kind: KindScript, Output: output})
+	if !event.Execution.OutputTruncated || !strings.HasSuffix(event.Execution.Output, "é done") || len(event.Execution.Output) != MaxOutput {
+		t.Errorf("Expected the last %d bytes of the output, got %d bytes", MaxOutput, len(event.Execution.Output))
+	}
+}
+
+func TestEventMatches(t *testing.T) {
+	event := &Event{Type: EventScriptFailed}
+	tests := []struct {
+		filters []string
+		want    bool
+	}{
+		{nil, true},
+		{[]string{EventScriptFailed}, true},
+		{[]string{"script.*"}, true},
+		{[]string{"*.failed"}, true},
+		{[]string{EventCommandFailed, "*.succeeded"}, false},
+	}
+	for _, tt := range tests {
+		if got := event.Matches(tt.filters); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.filters, got, tt.want)
+		}
+	}
+
+	for filter, want := range map[string]bool{"script.failed": true, "*.failed": true, "*": true, "build.*": false, "[": false, "": false} {
+		if got := ValidFilter(filter); got != want {
+			t.Errorf("ValidFilter(%q) = %v, want %v", filter, got, want)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	exec := &Execution{Kind: KindScript, ScriptName: "backup-db", ServerGroup: "production", ExitCode: 2, DurationMs: 90000}
+	tests := []struct {
+		rule models.NotificationRule
+		want bool
+	}{
+		{models.NotificationRule{Outcome: models.RuleOutcomeFailure}, true},
+		{models.NotificationRule{Outcome: models.RuleOutcomeSuccess}, false},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, Kind: KindCommand}, false},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, Script: "backup-*", ServerGroup: "production"}, true},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, Script: "deploy"}, false},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, ServerGroup: LocalServerGroup}, false},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, ExitCodes: []int{1, 2}}, true},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, ExitCodes: []int{137}}, false},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, MinDurationMs: 60000}, true},
+		{models.NotificationRule{Outcome: models.RuleOutcomeAny, MinDurationMs: 600000}, false},
+	}
+	for _, tt := range tests {
+		if got := RuleMatches(&tt.rule, exec); got != tt.want {
+			t.Errorf("RuleMatches(%+v) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+
+	// Warnings only match warning rules
+	warning := &Execution{Kind: KindScript, Severity: models.SeverityWarning, ExitCode: 1}
+	for outcome, want := range map[string]bool{models.RuleOutcomeWarning: true, models.RuleOutcomeFailure: false, models.RuleOutcomeSuccess: false, models.RuleOutcomeAny: true} {
+		if got := RuleMatches(&models.NotificationRule{Outcome: outcome}, warning); got != want {
+			t.Errorf("RuleMatches(%s) of a warning = %v, want %v", outcome, got, want)
+		}
+	}
+
+	// Local executions match the local group, and script patterns never match commands
+	local := &Execution{Kind: KindCommand, Success: true}
+	if !RuleMatches(&models.NotificationRule{Outcome: models.RuleOutcomeSuccess, ServerGroup: LocalServerGroup}, local) {
+		t.Error("Expected a local execution to match the local group")
+	}
+	if RuleMatches(&models.NotificationRule{Outcome: models.RuleOutcomeAny, Script: "*"}, local) {
+		t.Error("Expected a script pattern not to match a command")
+	}
+
+	for channel, want := range map[string]string{"webhook:ci": ChannelWebhook, "slack": ChannelSlack, "slack:#ops": ChannelSlack, "email:ops@example.com": ChannelEmail} {
+		if kind, _, err := ParseChannel(channel); err != nil || kind != want {
+			t.Errorf("ParseChannel(%q) = %q, %v, want %q", channel, kind, err, want)
+		}
+	}
+	for _, channel := range []string{"webhook", "webhook:", "email:nope", "pager:ops", ""} {
+		if _, _, err := ParseChannel(channel); err == nil {
+			t.Errorf("Expected ParseChannel(%q) to fail", channel)
+		}
+	}
+}
+
+func TestDispatcherDeliversSignedWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var received []Event
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+
+		// The first attempt fails and must be retried
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		if got := r.Header.Get(SignatureHeader); got != audit.SignWebhookBody("s3cret", body) {
+			t.Errorf("Signature mismatch: got %q", got)
+		}
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("Body is not an event: %v", err)
+		}
+		if r.Header.Get(EventHeader) != event.Type || r.Header.Get(DeliveryHeader) != event.ID {
+			t.Errorf("Event headers do not match the body: %v", r.Header)
+		}
+		received = append(received, event)
+	}))
+	defer ts.Close()
+
+	reported := make(chan error, 2)
+	d := NewDispatcher(DispatcherConfig{
+		Backoff: time.Millisecond,
+		Report:  func(_ Target, _ *Event, err error) { reported <- err },
+	})
+	defer d.Close()
+
+	webhook := &Webhook{Label: "ci", URL: ts.URL, Secret: "s3cret"}
+	d.Dispatch(NewExecutionEvent(Execution{Kind: KindScript, ScriptName: "deploy", ExitCode: 1}), webhook)
+
+	select {
+	case err := <-reported:
+		if err != nil {
+			t.Fatalf("Expected the retried delivery to succeed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Delivery was not reported")
+	}
+
+	mu.Lock()
+	if calls != 2 || len(received) != 1 || received[0].Execution.ScriptName != "deploy" {
+		t.Errorf("Expected one event after a retry, got %d calls: %v", calls, received)
+	}
+	mu.Unlock()
+
+	// Client errors are not retried
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer rejecting.Close()
+
+	d.Dispatch(NewEvent(EventPing, nil), &Webhook{Label: "gone", URL: rejecting.URL})
+	select {
+	case err := <-reported:
+		if !IsPermanent(err) {
+			t.Errorf("Expected a permanent failure for 410, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Failed delivery was not reported")
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	received := make(chan slackMessage, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Body is not a message: %v", err)
+		}
+		received <- msg
+	}))
+	defer ts.Close()
+
+	slack := &Slack{URL: ts.URL, Channel: "#ops", Username: "web-cli"}
+	event := NewExecutionEvent(Execution{
+		Kind:        KindScript,
+		ScriptName:  "deploy <prod>",
+		Server:      "web1",
+		User:        "deploy",
+		ExitCode:    2,
+		DurationMs:  1500,
+		Output:      strings.Repeat("line\n", 1000) + "Error: image not found\n",
+		TriggeredBy: "token:ci",
+		PresetName:  "nightly",
+	})
+	if err := slack.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	msg := <-received
+	if msg.Channel != "#ops" || msg.Text != "Script *deploy &lt;prod&gt;* failed on web1" {
+		t.Errorf("Unexpected message %q in %q", msg.Text, msg.Channel)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Color != "danger" {
+		t.Fatalf("Expected one red attachment, got %+v", msg.Attachments)
+	}
+	attachment := msg.Attachments[0]
+	if !strings.HasPrefix(attachment.Text, "```\n…\n") || !strings.HasSuffix(attachment.Text, "Error: image not found\n```") || len(attachment.Text) > slackMaxOutput+20 {
+		t.Errorf("Expected the end of the output, got %q", attachment.Text)
+	}
+	fields := map[string]string{}
+	for _, field := range attachment.Fields {
+		fields[field.Title] = field.Value
+	}
+	if fields["Exit code"] != "2" || fields["Duration"] != "1.5s" || fields["Preset"] != "nightly" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+}
+
+// fakeSMTP accepts one plain-text SMTP session and returns the message data it received
+func fakeSMTP(t *testing.T, rejectRcpt bool) (string, int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line + " x")[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "RCPT":
+				if rejectRcpt {
+					tp.PrintfLine("550 no such user")
+					continue
+				}
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotBytes()
+				messages <- string(data)
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func TestEmailTemplates(t *testing.T) {
+	host, port, messages := fakeSMTP(t, false)
+	email, err := NewEmail(EmailConfig{
+		Host:    host,
+		Port:    port,
+		TLS:     EmailTLSNone,
+		From:    "web-cli <web-cli@example.com>",
+		To:      []string{"ops@example.com"},
+		Subject: "{{.Execution.ScriptName}} failed\r\nBcc: everyone@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewEmail failed: %v", err)
+	}
+
+	event := NewExecutionEvent(Execution{Kind: KindScript, ScriptName: "backup", Server: "db1", ExitCode: 3, Output: "disk full\n"})
+	if !email.Wants(event) || email.Wants(NewExecutionEvent(Execution{Kind: KindScript, Success: true})) {
+		t.Errorf("Expected emails for failures only by default")
+	}
+	if err := email.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	msg := <-messages
+	if !strings.Contains(msg, "Subject: backup failed Bcc: everyone@example.com\n") {
+		t.Errorf("Expected the subject on one header line, got %q", msg)
+	}
+	if !strings.Contains(msg, "Exit code:    3\n") || !strings.Contains(msg, "disk full") {
+		t.Errorf("Expected the default body, got %q", msg)
+	}
+
+	// Rejected recipients are not retried
+	host, port, _ = fakeSMTP(t, true)
+	email, _ = NewEmail(EmailConfig{Host: host, Port: port, TLS: EmailTLSNone, From: "web-cli@example.com", To: []string{"nobody@example.com"}})
+	if err := email.Send(context.Background(), event); !IsPermanent(err) {
+		t.Errorf("Expected a permanent failure for a 550 reply, got %v", err)
+	}
+
+	for _, config := range []EmailConfig{
+		{From: "web-cli@example.com", To: []string{"ops@example.com"}},
+		{Host: host, From: "web-cli@example.com", To: []string{"ops@example.com"}, TLS: "ssl"},
+		{Host: host, From: "web-cli@example.com"},
+		{Host: host, From: "web-cli@example.com", To: []string{"ops@example.com"}, Subject: "{{.Nope"},
+	} {
+		if _, err := NewEmail(config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+}
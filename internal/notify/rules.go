@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"fmt"
+	"net/mail"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// Notification channel kinds
+const (
+	ChannelWebhook = "webhook" // webhook:<name> sends to a stored webhook
+	ChannelSlack   = "slack"   // slack or slack:<channel> posts to the configured Slack webhook
+	ChannelEmail   = "email"   // email or email:<address> emails the configured or given recipients
+)
+
+// LocalServerGroup is the server group notification rules match local executions with
+const LocalServerGroup = "local"
+
+// ParseChannel splits a rule channel into its kind and optional argument
+func ParseChannel(channel string) (kind, arg string, err error) {
+	kind, arg, _ = strings.Cut(strings.TrimSpace(channel), ":")
+	arg = strings.TrimSpace(arg)
+	switch kind {
+	case ChannelWebhook:
+		if arg == "" {
+			return "", "", fmt.Errorf("channel %q must name a webhook, as webhook:<name>", channel)
+		}
+	case ChannelSlack:
+	case ChannelEmail:
+		if arg != "" {
+			if _, err := mail.ParseAddress(arg); err != nil {
+				return "", "", fmt.Errorf("channel %q has an invalid address: %w", channel, err)
+			}
+		}
+	default:
+		return "", "", fmt.Errorf("invalid channel %q (must be webhook:<name>, slack, slack:<channel>, email or email:<address>)", channel)
+	}
+	return kind, arg, nil
+}
+
+// RuleMatches reports whether a notification rule matches a finished execution
+func RuleMatches(rule *models.NotificationRule, exec *Execution) bool {
+	if rule.Kind != "" && rule.Kind != exec.Kind {
+		return false
+	}
+	if rule.Script != "" {
+		if exec.Kind != KindScript {
+			return false
+		}
+		if ok, _ := path.Match(rule.Script, exec.ScriptName); !ok {
+			return false
+		}
+	}
+	if rule.ServerGroup != "" {
+		group := exec.ServerGroup
+		if group == "" {
+			group = LocalServerGroup
+		}
+		if rule.ServerGroup != group {
+			return false
+		}
+	}
+	switch rule.Outcome {
+	case models.RuleOutcomeFailure:
+		if exec.severity() != models.SeverityCritical {
+			return false
+		}
+	case models.RuleOutcomeWarning:
+		if exec.severity() != models.SeverityWarning {
+			return false
+		}
+	case models.RuleOutcomeSuccess:
+		if exec.severity() != models.SeverityOK {
+			return false
+		}
+	}
+	if len(rule.ExitCodes) > 0 && !slices.Contains(rule.ExitCodes, exec.ExitCode) {
+		return false
+	}
+	return exec.DurationMs >= rule.MinDurationMs
+}
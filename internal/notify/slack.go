@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// slackMaxOutput bounds the output quoted in a message, in bytes
+// Chat messages are read at a glance, so only the end of the output is shown
+const slackMaxOutput = 1500
+
+// Slack posts execution results to a Slack or Mattermost incoming webhook
+// Mattermost accepts the same message format as Slack
+type Slack struct {
+	URL      string
+	Channel  string // Channel posted to instead of the webhook's default (optional)
+	Username string // Name messages are posted as (optional)
+	Client   *http.Client
+}
+
+// slackMessage is the body of an incoming webhook request
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+// slackAttachment holds the details of a result, with a colored bar
+type slackAttachment struct {
+	Color    string       `json:"color"`
+	Fallback string       `json:"fallback"`
+	Fields   []slackField `json:"fields"`
+	Text     string       `json:"text,omitempty"`
+}
+
+// slackField is one short labeled value of an attachment
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Name identifies the target in logs
+func (s *Slack) Name() string {
+	if s.Channel != "" {
+		return "Slack " + s.Channel
+	}
+	return "Slack"
+}
+
+// WithChannel returns a copy of the target posting to another channel
+func (s *Slack) WithChannel(channel string) *Slack {
+	clone := *s
+	clone.Channel = channel
+	return &clone
+}
+
+// Send posts the event; 429 and 5xx responses are retried, other failures are not
+func (s *Slack) Send(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(s.message(event))
+	if err != nil {
+		return Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "web-cli-webhook")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("slack returned %s", resp.Status)
+	default:
+		return Permanent(fmt.Errorf("slack returned %s", resp.Status))
+	}
+}
+
+// message formats the event as a chat message
+func (s *Slack) message(event *Event) *slackMessage {
+	msg := &slackMessage{Channel: s.Channel, Username: s.Username}
+
+	exec := event.Execution
+	if exec == nil {
+		msg.Text = "web-cli " + event.Type
+		return msg
+	}
+
+	subject := "Command `" + escapeSlack(exec.Command) + "`"
+	if exec.Kind == KindScript {
+		subject = "Script *" + escapeSlack(exec.ScriptName) + "*"
+	}
+	outcome, color := "succeeded", "good"
+	switch exec.severity() {
+	case models.SeverityWarning:
+		outcome, color = "finished with a warning", "warning"
+	case models.SeverityCritical:
+		outcome, color = "failed", "danger"
+	}
+	msg.Text = fmt.Sprintf("%s %s on %s", subject, outcome, escapeSlack(exec.Server))
+
+	attachment := slackAttachment{
+		Color:    color,
+		Fallback: msg.Text,
+		Fields: []slackField{
+			{Title: "Exit code", Value: fmt.Sprint(exec.ExitCode), Short: true},
+			{Title: "Duration", Value: (time.Duration(exec.DurationMs) * time.Millisecond).String(), Short: true},
+			{Title: "User", Value: escapeSlack(exec.User), Short: true},
+		},
+	}
+	if exec.TriggeredBy != "" {
+		attachment.Fields = append(attachment.Fields, slackField{Title: "Triggered by", Value: escapeSlack(exec.TriggeredBy), Short: true})
+	}
+	if exec.PresetName != "" {
+		attachment.Fields = append(attachment.Fields, slackField{Title: "Preset", Value: escapeSlack(exec.PresetName), Short: true})
+	}
+	if exec.Error != "" {
+		attachment.Fields = append(attachment.Fields, slackField{Title: "Error", Value: escapeSlack(exec.Error)})
+	}
+
+	output, truncated := tail(exec.Output, slackMaxOutput)
+	output = strings.TrimRight(output, "\n")
+	if output != "" {
+		if truncated || exec.OutputTruncated {
+			output = "…\n" + output
+		}
+		attachment.Text = "```\n" + escapeSlack(strings.ReplaceAll(output, "```", "` ` `")) + "\n```"
+	}
+
+	msg.Attachments = []slackAttachment{attachment}
+	return msg
+}
+
+// escapeSlack escapes the characters Slack treats as markup
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/audit"
+)
+
+// Webhook delivery headers
+const (
+	SignatureHeader = audit.WebhookSignatureHeader // HMAC-SHA256 of the body, as for audit webhooks
+	EventHeader     = "X-WebCLI-Event"             // Event type
+	DeliveryHeader  = "X-WebCLI-Delivery"          // Event ID, the same for every attempt
+)
+
+// Webhook POSTs events as JSON to an HTTP endpoint
+type Webhook struct {
+	ID     int64  // ID of the stored webhook, for reporting
+	Label  string // Name of the stored webhook
+	URL    string
+	Secret string // Key for the body signature (empty to send unsigned)
+	Client *http.Client
+}
+
+// Name identifies the webhook in logs
+func (w *Webhook) Name() string {
+	return fmt.Sprintf("webhook %q", w.Label)
+}
+
+// Send POSTs the event; 429 and 5xx responses are retried, other failures are not
+func (w *Webhook) Send(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "web-cli-webhook")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID)
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, audit.SignWebhookBody(w.Secret, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	default:
+		return Permanent(fmt.Errorf("webhook returned %s", resp.Status))
+	}
+}
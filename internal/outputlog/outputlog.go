@@ -0,0 +1,129 @@
+// Package outputlog keeps large execution outputs out of the database
+// History and API responses get the end of an output cut to a size limit, while
+// the full output is saved as a compressed, encrypted log file on disk.
+package outputlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pozgo/web-cli/internal/database"
+)
+
+// Ext is the file extension of execution logs
+const Ext = ".log.gz"
+
+// ErrNotFound is returned for an execution without a log
+var ErrNotFound = errors.New("execution log not found")
+
+// Store saves the full outputs of executions whose output was truncated
+// A nil Store never truncates.
+type Store struct {
+	dir     string
+	maxSize int
+}
+
+// New creates a store keeping logs in dir, for outputs longer than maxSize bytes
+// Returns nil when maxSize is not positive, which disables truncation.
+func New(dir string, maxSize int) *Store {
+	if maxSize <= 0 {
+		return nil
+	}
+	return &Store{dir: dir, maxSize: maxSize}
+}
+
+// Truncate returns the end of output when it is over the size limit, after a line saying so
+func (s *Store) Truncate(output string) (string, bool) {
+	if s == nil || len(output) <= s.maxSize {
+		return output, false
+	}
+	kept := output[len(output)-s.maxSize:]
+	for len(kept) > 0 && !utf8.RuneStart(kept[0]) {
+		kept = kept[1:]
+	}
+	return fmt.Sprintf("[Output truncated: showing the last %d of %d bytes]\n", len(kept), len(output)) + kept, true
+}
+
+// Save writes the full output of a history entry, compressed and encrypted like the database
+// The file is written under a temporary name and renamed, so readers never see a partial log.
+func (s *Store) Save(historyID int64, output string) error {
+	if s == nil {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create execution log directory: %w", err)
+	}
+
+	encrypted, err := database.Encrypt(string(Compress(output)))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt execution log: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create execution log: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encrypted); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write execution log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write execution log: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path(historyID))
+}
+
+// Open returns the gzip-compressed full output of a history entry
+func (s *Store) Open(historyID int64) ([]byte, error) {
+	if s == nil {
+		return nil, ErrNotFound
+	}
+	encrypted, err := os.ReadFile(s.path(historyID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read execution log: %w", err)
+	}
+	compressed, err := database.Decrypt(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt execution log: %w", err)
+	}
+	return []byte(compressed), nil
+}
+
+// Compress returns output gzip-compressed, as logs are stored and served
+func Compress(output string) []byte {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	io.WriteString(gz, output) // Writes to a buffer cannot fail
+	gz.Close()
+	return compressed.Bytes()
+}
+
+// Decompress returns the output of a compressed log
+func Decompress(compressed []byte) (string, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress execution log: %w", err)
+	}
+	defer gz.Close()
+	output, err := io.ReadAll(gz)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress execution log: %w", err)
+	}
+	return string(output), nil
+}
+
+// path returns the log file of a history entry
+func (s *Store) path(historyID int64) string {
+	return filepath.Join(s.dir, strconv.FormatInt(historyID, 10)+Ext)
+}
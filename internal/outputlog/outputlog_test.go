@@ -0,0 +1,46 @@
+package outputlog
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pozgo/web-cli/internal/database"
+)
+
+func TestStore(t *testing.T) {
+	if err := database.InitializeEncryption(filepath.Join(t.TempDir(), ".encryption_key")); err != nil {
+		t.Fatalf("Failed to initialize encryption: %v", err)
+	}
+	store := New(t.TempDir(), 10)
+
+	if kept, truncated := store.Truncate("short"); kept != "short" || truncated {
+		t.Errorf("Expected short output to be kept, got %q", kept)
+	}
+	output := strings.Repeat("x", 20) + "héllo!"
+	kept, truncated := store.Truncate(output)
+	if !truncated || !strings.HasSuffix(kept, "\nxxxhéllo!") || !strings.Contains(kept, "of 27 bytes") {
+		t.Errorf("Expected the end of the output, got %q", kept)
+	}
+
+	if _, err := store.Open(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := store.Save(1, output); err != nil {
+		t.Fatalf("Failed to save log: %v", err)
+	}
+	compressed, err := store.Open(1)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	if full, err := Decompress(compressed); err != nil || full != output {
+		t.Errorf("Expected the full output, got %q (%v)", full, err)
+	}
+
+	// Without a limit nothing is truncated
+	unlimited := New(t.TempDir(), 0)
+	if kept, truncated := unlimited.Truncate(output); kept != output || truncated {
+		t.Errorf("Expected a nil store to keep the output, got %q", kept)
+	}
+}
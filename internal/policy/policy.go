@@ -0,0 +1,167 @@
+// Package policy evaluates command policies before commands and scripts are executed
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// rule is a command policy with its compiled pattern
+type rule struct {
+	policy *models.CommandPolicy
+	re     *regexp.Regexp
+}
+
+// Engine evaluates a set of command policies
+//
+// Deny policies reject matching commands for everyone they apply to. Allow
+// policies turn on allowlisting: a caller with at least one applicable allow
+// policy for a target may only run commands (or scripts) matching one of them.
+type Engine struct {
+	rules []rule
+}
+
+// New compiles the enabled policies into an engine
+func New(policies []*models.CommandPolicy) (*Engine, error) {
+	engine := &Engine{}
+	for _, p := range policies {
+		if !p.Enabled {
+			continue
+		}
+		re, err := Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("policy %q: %w", p.Name, err)
+		}
+		engine.rules = append(engine.rules, rule{policy: p, re: re})
+	}
+	return engine, nil
+}
+
+// Compile returns the regular expression for a policy pattern
+// Glob patterns match the whole trimmed command; regex patterns match anywhere
+func Compile(p *models.CommandPolicy) (*regexp.Regexp, error) {
+	switch p.MatchType {
+	case models.PolicyMatchGlob:
+		return regexp.Compile(globToRegex(p.Pattern))
+	case models.PolicyMatchRegex:
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return re, nil
+	default:
+		return nil, fmt.Errorf("invalid match type %q", p.MatchType)
+	}
+}
+
+// CheckCommand evaluates an ad-hoc command for a caller
+func (e *Engine) CheckCommand(command string, identities []string) models.PolicyDecision {
+	command = strings.TrimSpace(command)
+
+	if r := e.firstMatch(models.PolicyActionDeny, models.PolicyTargetCommand, command, identities); r != nil {
+		return denied(r.policy, "command matches deny policy")
+	}
+	return e.checkAllowlist(models.PolicyTargetCommand, command, identities)
+}
+
+// CheckScript evaluates a script for a caller
+// Deny policies for commands are applied to every line of the script; allow
+// policies for commands are not, since scripts contain control flow
+func (e *Engine) CheckScript(name, content string, identities []string) models.PolicyDecision {
+	if r := e.firstMatch(models.PolicyActionDeny, models.PolicyTargetScript, name, identities); r != nil {
+		return denied(r.policy, "script matches deny policy")
+	}
+
+	for _, line := range scriptLines(content) {
+		if r := e.firstMatch(models.PolicyActionDeny, models.PolicyTargetCommand, line, identities); r != nil {
+			return denied(r.policy, "script contains a command matching deny policy")
+		}
+	}
+
+	return e.checkAllowlist(models.PolicyTargetScript, name, identities)
+}
+
+// checkAllowlist applies the allow policies for a target
+func (e *Engine) checkAllowlist(target, value string, identities []string) models.PolicyDecision {
+	var first *models.CommandPolicy
+	for _, r := range e.rules {
+		if r.policy.Action != models.PolicyActionAllow || r.policy.Target != target || !appliesTo(r.policy, identities) {
+			continue
+		}
+		if r.re.MatchString(value) {
+			return models.PolicyDecision{Allowed: true}
+		}
+		if first == nil {
+			first = r.policy
+		}
+	}
+
+	if first != nil {
+		return denied(first, fmt.Sprintf("%s is not in the allowed list", target))
+	}
+	return models.PolicyDecision{Allowed: true}
+}
+
+// firstMatch returns the first applicable rule with the action and target matching the value
+func (e *Engine) firstMatch(action, target, value string, identities []string) *rule {
+	for i := range e.rules {
+		r := &e.rules[i]
+		if r.policy.Action == action && r.policy.Target == target && appliesTo(r.policy, identities) && r.re.MatchString(value) {
+			return r
+		}
+	}
+	return nil
+}
+
+// appliesTo reports whether a policy applies to a caller
+func appliesTo(p *models.CommandPolicy, identities []string) bool {
+	if p.Principal == "" {
+		return true
+	}
+	for _, identity := range identities {
+		if identity == p.Principal {
+			return true
+		}
+	}
+	return false
+}
+
+// denied returns a deny decision for a policy
+func denied(p *models.CommandPolicy, reason string) models.PolicyDecision {
+	return models.PolicyDecision{Allowed: false, Reason: reason, Policy: p}
+}
+
+// scriptLines returns the trimmed, non-empty, non-comment lines of a script
+func scriptLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// globToRegex converts a shell-style pattern to an anchored regular expression
+// "*" matches any characters (including "/" and spaces) and "?" matches one character
+func globToRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString(`^`)
+	for _, c := range strings.TrimSpace(pattern) {
+		switch c {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString(`$`)
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+func TestCheckCommand(t *testing.T) {
+	engine, err := New([]*models.CommandPolicy{
+		{Name: "no-root-wipe", Action: models.PolicyActionDeny, MatchType: models.PolicyMatchRegex, Pattern: `rm\s+-rf\s+/(\s|$)`, Target: models.PolicyTargetCommand, Enabled: true},
+		{Name: "operators-systemctl", Action: models.PolicyActionAllow, MatchType: models.PolicyMatchGlob, Pattern: "systemctl *", Target: models.PolicyTargetCommand, Principal: "role:operator", Enabled: true},
+		{Name: "disabled", Action: models.PolicyActionDeny, MatchType: models.PolicyMatchGlob, Pattern: "*", Target: models.PolicyTargetCommand, Enabled: false},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	operator := []string{"user:bob", "role:operator"}
+	other := []string{"user:alice"}
+
+	tests := []struct {
+		name       string
+		command    string
+		identities []string
+		allowed    bool
+		policy     string
+	}{
+		{"deny rule applies to everyone", "rm -rf /", other, false, "no-root-wipe"},
+		{"deny rule applies without principal", "sudo rm -rf / ", nil, false, "no-root-wipe"},
+		{"deny rule does not match subdirectory", "rm -rf /tmp/build", other, true, ""},
+		{"allowlisted command", "systemctl restart nginx", operator, true, ""},
+		{"command outside allowlist", "cat /etc/shadow", operator, false, "operators-systemctl"},
+		{"glob is anchored", "echo systemctl restart", operator, false, "operators-systemctl"},
+		{"allowlist ignores other principals", "cat /etc/hosts", other, true, ""},
+		{"deny wins over allow", "systemctl stop x; rm -rf /", operator, false, "no-root-wipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.CheckCommand(tt.command, tt.identities)
+			if decision.Allowed != tt.allowed {
+				t.Fatalf("Expected allowed=%v, got %+v", tt.allowed, decision)
+			}
+			if tt.policy != "" && (decision.Policy == nil || decision.Policy.Name != tt.policy) {
+				t.Errorf("Expected policy %q, got %+v", tt.policy, decision.Policy)
+			}
+		})
+	}
+}
+
+func TestCheckScript(t *testing.T) {
+	engine, err := New([]*models.CommandPolicy{
+		{Name: "no-shutdown", Action: models.PolicyActionDeny, MatchType: models.PolicyMatchGlob, Pattern: "shutdown*", Target: models.PolicyTargetCommand, Enabled: true},
+		{Name: "ci-deploy-only", Action: models.PolicyActionAllow, MatchType: models.PolicyMatchGlob, Pattern: "deploy-*", Target: models.PolicyTargetScript, Principal: "token:ci", Enabled: true},
+		{Name: "ci-ls-only", Action: models.PolicyActionAllow, MatchType: models.PolicyMatchGlob, Pattern: "ls *", Target: models.PolicyTargetCommand, Principal: "token:ci", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	if d := engine.CheckScript("maintenance", "#!/bin/bash\n# shutdown later\necho bye\n  shutdown -h now\n", nil); d.Allowed || d.Policy.Name != "no-shutdown" {
+		t.Errorf("Expected script with denied command to be rejected, got %+v", d)
+	}
+	if d := engine.CheckScript("maintenance", "# shutdown\necho ok\n", nil); !d.Allowed {
+		t.Errorf("Expected comments to be ignored, got %+v", d)
+	}
+	if d := engine.CheckScript("deploy-web", "echo deploying\n", []string{"token:ci"}); !d.Allowed {
+		t.Errorf("Expected allowlisted script to run, got %+v", d)
+	}
+	if d := engine.CheckScript("backup", "echo backup\n", []string{"token:ci"}); d.Allowed || d.Policy.Name != "ci-deploy-only" {
+		t.Errorf("Expected script outside allowlist to be rejected, got %+v", d)
+	}
+}
+
+func TestNewRejectsInvalidPatterns(t *testing.T) {
+	invalid := []*models.CommandPolicy{
+		{Name: "bad-regex", Action: models.PolicyActionDeny, MatchType: models.PolicyMatchRegex, Pattern: "rm (", Target: models.PolicyTargetCommand, Enabled: true},
+		{Name: "bad-type", Action: models.PolicyActionDeny, MatchType: "literal", Pattern: "rm", Target: models.PolicyTargetCommand, Enabled: true},
+	}
+	for _, p := range invalid {
+		if _, err := New([]*models.CommandPolicy{p}); err == nil {
+			t.Errorf("Expected policy %q to be rejected", p.Name)
+		}
+	}
+}
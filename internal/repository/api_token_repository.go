@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// apiTokenPrefix marks tokens issued by this server so they are easy to recognize in logs and secret scanners
+const apiTokenPrefix = "wcli_"
+
+// apiTokenPrefixLength is the number of token characters stored in clear for identification
+const apiTokenPrefixLength = 12
+
+// apiTokenTouchInterval limits how often last_used_at is written for a busy token
+const apiTokenTouchInterval = time.Minute
+
+// APITokenRepository handles database operations for API tokens
+type APITokenRepository struct {
+	db *database.DB
+}
+
+// NewAPITokenRepository creates a new API token repository
+func NewAPITokenRepository(db *database.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// Create generates a new API token and stores its hash
+// The returned value is the only place the token itself is available
+func (r *APITokenRepository) Create(create *models.APITokenCreate) (*models.APITokenCreated, error) {
+	if create.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	scopes, err := normalizeAPITokenScopes(create.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := normalizeRoles(create.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAgentTokenServer(scopes, create.ServerID); err != nil {
+		return nil, err
+	}
+	if create.ExpiresInDays < 0 {
+		return nil, fmt.Errorf("expires_in_days cannot be negative")
+	}
+
+	token, err := generateAPIToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var expiresAt *time.Time
+	if create.ExpiresInDays > 0 {
+		t := now.AddDate(0, 0, create.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO api_tokens (name, token_hash, prefix, scopes, roles, server_id, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		create.Name,
+		hashAPIToken(token),
+		token[:apiTokenPrefixLength],
+		strings.Join(scopes, ","),
+		strings.Join(roles, ","),
+		create.ServerID,
+		expiresAt,
+		now,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	return &models.APITokenCreated{
+		APIToken: models.APIToken{
+			ID:        id,
+			Name:      create.Name,
+			Prefix:    token[:apiTokenPrefixLength],
+			Scopes:    scopes,
+			Roles:     roles,
+			ServerID:  create.ServerID,
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Token: token,
+	}, nil
+}
+
+// GetByID retrieves an API token by its ID
+func (r *APITokenRepository) GetByID(id int64) (*models.APIToken, error) {
+	row := r.db.GetConnection().QueryRow(
+		`SELECT id, name, prefix, scopes, roles, server_id, expires_at, last_used_at, created_at, updated_at
+		FROM api_tokens WHERE id = ?`,
+		id,
+	)
+
+	token, err := scanAPIToken(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("API token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GetAll retrieves all API tokens
+func (r *APITokenRepository) GetAll() ([]*models.APIToken, error) {
+	rows, err := r.db.GetConnection().Query(
+		`SELECT id, name, prefix, scopes, roles, server_id, expires_at, last_used_at, created_at, updated_at
+		FROM api_tokens ORDER BY name ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Update changes the name and/or scopes of an API token
+// The token secret and expiry cannot be changed; create a new token instead
+func (r *APITokenRepository) Update(id int64, update *models.APITokenUpdate) (*models.APIToken, error) {
+	existing, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != "" {
+		existing.Name = update.Name
+	}
+	if update.Scopes != nil {
+		scopes, err := normalizeAPITokenScopes(update.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		existing.Scopes = scopes
+	}
+	if update.Roles != nil {
+		roles, err := normalizeRoles(update.Roles)
+		if err != nil {
+			return nil, err
+		}
+		existing.Roles = roles
+	}
+	if update.ServerID != nil {
+		existing.ServerID = update.ServerID
+	} else if !slices.Contains(existing.Scopes, models.APITokenScopeAgent) {
+		existing.ServerID = nil // Dropping the agent scope unbinds the token
+	}
+	if err := checkAgentTokenServer(existing.Scopes, existing.ServerID); err != nil {
+		return nil, err
+	}
+
+	existing.UpdatedAt = time.Now().UTC()
+
+	_, err = r.db.GetConnection().Exec(
+		"UPDATE api_tokens SET name = ?, scopes = ?, roles = ?, server_id = ?, updated_at = ? WHERE id = ?",
+		existing.Name,
+		strings.Join(existing.Scopes, ","),
+		strings.Join(existing.Roles, ","),
+		existing.ServerID,
+		existing.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update API token: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete revokes an API token by its ID
+func (r *APITokenRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM api_tokens WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete API token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("API token not found")
+	}
+
+	return nil
+}
+
+// Authenticate looks up a bearer token and records its use
+// Returns nil without error if the token is unknown or expired
+func (r *APITokenRepository) Authenticate(token string) (*models.APIToken, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return nil, nil
+	}
+
+	row := r.db.GetConnection().QueryRow(
+		`SELECT id, name, prefix, scopes, roles, server_id, expires_at, last_used_at, created_at, updated_at
+		FROM api_tokens WHERE token_hash = ?`,
+		hashAPIToken(token),
+	)
+
+	apiToken, err := scanAPIToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if apiToken.Expired(now) {
+		return nil, nil
+	}
+
+	// Record usage, at most once per interval to avoid a write on every request
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) > apiTokenTouchInterval {
+		if _, err := r.db.GetConnection().Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", now, apiToken.ID); err != nil {
+			return nil, fmt.Errorf("failed to update API token last used time: %w", err)
+		}
+		apiToken.LastUsedAt = &now
+	}
+
+	return apiToken, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAPIToken reads an API token from a query result
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	var token models.APIToken
+	var scopes, roles string
+	var serverID sql.NullInt64
+	var expiresAt, lastUsedAt sql.NullTime
+
+	if err := row.Scan(&token.ID, &token.Name, &token.Prefix, &scopes, &roles, &serverID, &expiresAt, &lastUsedAt, &token.CreatedAt, &token.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Split(scopes, ",")
+	token.Roles = []string{}
+	if roles != "" {
+		token.Roles = strings.Split(roles, ",")
+	}
+	token.ServerID = nullableID(serverID)
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &token, nil
+}
+
+// normalizeAPITokenScopes validates scopes and removes duplicates
+func normalizeAPITokenScopes(scopes []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		valid := false
+		for _, s := range models.APITokenScopes {
+			if scope == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid scope %q (valid scopes: %s)", scope, strings.Join(models.APITokenScopes, ", "))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+
+	return normalized, nil
+}
+
+// checkAgentTokenServer requires tokens with the agent scope to be bound to a server, so an agent
+// token cannot connect as the agent of any other server, and only those tokens to be bound
+func checkAgentTokenServer(scopes []string, serverID *int64) error {
+	agent := slices.Contains(scopes, models.APITokenScopeAgent)
+	if agent && serverID == nil {
+		return fmt.Errorf("server_id is required with the %s scope", models.APITokenScopeAgent)
+	}
+	if !agent && serverID != nil {
+		return fmt.Errorf("server_id is only allowed with the %s scope", models.APITokenScopeAgent)
+	}
+	return nil
+}
+
+// generateAPIToken returns a new random bearer token
+func generateAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	return apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIToken returns the SHA-256 hash stored in place of the token
+// Tokens are 256-bit random values, so a fast hash is sufficient
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// approvalColumns lists the columns read by scanApproval
+const approvalColumns = `id, kind, summary, server, run_as, reason, fingerprint, status, requested_by, decided_by, expires_at, created_at, decided_at, executed_at`
+
+// ApprovalRepository handles database operations for execution approvals
+type ApprovalRepository struct {
+	db *database.DB
+}
+
+// NewApprovalRepository creates a new approval repository
+func NewApprovalRepository(db *database.DB) *ApprovalRepository {
+	return &ApprovalRepository{db: db}
+}
+
+// Create stores a new pending approval that expires after ttl
+func (r *ApprovalRepository) Create(approval *models.Approval, ttl time.Duration) (*models.Approval, error) {
+	now := time.Now().UTC()
+	approval.Status = models.ApprovalStatusPending
+	approval.CreatedAt = now
+	approval.ExpiresAt = now.Add(ttl)
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO approvals (kind, summary, server, run_as, reason, fingerprint, status, requested_by, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		approval.Kind,
+		approval.Summary,
+		approval.Server,
+		approval.RunAs,
+		approval.Reason,
+		approval.Fingerprint,
+		approval.Status,
+		approval.RequestedBy,
+		approval.ExpiresAt,
+		approval.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	approval.ID = id
+
+	return approval, nil
+}
+
+// GetByID retrieves an approval by its ID
+func (r *ApprovalRepository) GetByID(id int64) (*models.Approval, error) {
+	row := r.db.GetConnection().QueryRow("SELECT "+approvalColumns+" FROM approvals WHERE id = ?", id)
+
+	approval, err := scanApproval(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("approval not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+
+	return approval, nil
+}
+
+// GetAll retrieves all approvals, newest first
+func (r *ApprovalRepository) GetAll() ([]*models.Approval, error) {
+	rows, err := r.db.GetConnection().Query("SELECT " + approvalColumns + " FROM approvals ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []*models.Approval
+	for rows.Next() {
+		approval, err := scanApproval(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		approvals = append(approvals, approval)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating approvals: %w", err)
+	}
+
+	return approvals, nil
+}
+
+// Decide approves or rejects a pending, unexpired approval
+func (r *ApprovalRepository) Decide(id int64, status, decidedBy string) (*models.Approval, error) {
+	if status != models.ApprovalStatusApproved && status != models.ApprovalStatusRejected {
+		return nil, fmt.Errorf("invalid approval decision %q", status)
+	}
+
+	now := time.Now().UTC()
+	result, err := r.db.GetConnection().Exec(
+		`UPDATE approvals SET status = ?, decided_by = ?, decided_at = ?
+		WHERE id = ? AND status = ? AND expires_at > ?`,
+		status,
+		decidedBy,
+		now,
+		id,
+		models.ApprovalStatusPending,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update approval: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("approval is not pending")
+	}
+
+	return r.GetByID(id)
+}
+
+// Consume marks an approved request as executed
+// It fails unless the approval is approved, unexpired, and was requested by the
+// same principal for the same execution request
+func (r *ApprovalRepository) Consume(id int64, fingerprint, requestedBy string) (*models.Approval, error) {
+	now := time.Now().UTC()
+	result, err := r.db.GetConnection().Exec(
+		`UPDATE approvals SET status = ?, executed_at = ?
+		WHERE id = ? AND status = ? AND fingerprint = ? AND requested_by = ? AND expires_at > ?`,
+		models.ApprovalStatusExecuted,
+		now,
+		id,
+		models.ApprovalStatusApproved,
+		fingerprint,
+		requestedBy,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update approval: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("approval is not valid for this request")
+	}
+
+	return r.GetByID(id)
+}
+
+// scanApproval reads an approval from a query result
+// Pending and approved requests past their expiry are reported as expired
+func scanApproval(row rowScanner) (*models.Approval, error) {
+	var a models.Approval
+	var decidedAt, executedAt sql.NullTime
+
+	if err := row.Scan(&a.ID, &a.Kind, &a.Summary, &a.Server, &a.RunAs, &a.Reason, &a.Fingerprint, &a.Status,
+		&a.RequestedBy, &a.DecidedBy, &a.ExpiresAt, &a.CreatedAt, &decidedAt, &executedAt); err != nil {
+		return nil, err
+	}
+
+	if decidedAt.Valid {
+		a.DecidedAt = &decidedAt.Time
+	}
+	if executedAt.Valid {
+		a.ExecutedAt = &executedAt.Time
+	}
+	if (a.Status == models.ApprovalStatusPending || a.Status == models.ApprovalStatusApproved) && !time.Now().UTC().Before(a.ExpiresAt) {
+		a.Status = models.ApprovalStatusExpired
+	}
+
+	return &a, nil
+}
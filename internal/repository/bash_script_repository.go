@@ -2,7 +2,9 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pozgo/web-cli/internal/database"
@@ -35,6 +37,18 @@ func (r *BashScriptRepository) Create(script *models.BashScriptCreate) (*models.
 		group = "default"
 	}
 
+	// Default interpreter to bash if not provided
+	interpreter := script.Interpreter
+	if interpreter == "" {
+		interpreter = models.ScriptInterpreterBash
+	}
+
+	tags := models.NormalizeTags(script.Tags)
+	exitCodes := script.ExitCodes
+	if exitCodes == nil {
+		exitCodes = models.ExitCodes{}
+	}
+
 	// Encrypt the content
 	encryptedContent, err := database.Encrypt(script.Content)
 	if err != nil {
@@ -43,13 +57,26 @@ func (r *BashScriptRepository) Create(script *models.BashScriptCreate) (*models.
 
 	now := time.Now().UTC()
 
-	result, err := r.db.GetConnection().Exec(
-		"INSERT INTO bash_scripts (name, description, content_encrypted, filename, group_name, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+	// The script and its first version are written together
+	tx, err := r.db.GetConnection().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO bash_scripts (name, description, content_encrypted, filename, group_name, interpreter, tags, requires_approval, exclusive, exit_codes, read_only, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		script.Name,
 		script.Description,
 		encryptedContent,
 		script.Filename,
 		group,
+		interpreter,
+		encodeTags(tags),
+		boolToInt(script.RequiresApproval),
+		boolToInt(script.Exclusive),
+		encodeExitCodes(exitCodes),
+		boolToInt(script.ReadOnly),
 		now,
 		now,
 	)
@@ -62,34 +89,46 @@ func (r *BashScriptRepository) Create(script *models.BashScriptCreate) (*models.
 		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
 	}
 
-	return &models.BashScript{
-		ID:          id,
-		Name:        script.Name,
-		Description: script.Description,
-		Content:     script.Content, // Return unencrypted content
-		Filename:    script.Filename,
-		Group:       group,
-		CreatedAt:   now,
-		UpdatedAt:   now,
-	}, nil
+	created := &models.BashScript{
+		ID:               id,
+		Name:             script.Name,
+		Description:      script.Description,
+		Content:          script.Content, // Return unencrypted content
+		Filename:         script.Filename,
+		Group:            group,
+		Interpreter:      interpreter,
+		Tags:             tags,
+		RequiresApproval: script.RequiresApproval,
+		Exclusive:        script.Exclusive,
+		ExitCodes:        exitCodes,
+		ReadOnly:         script.ReadOnly,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	if err := insertVersion(tx, created, encryptedContent); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bash script: %w", err)
+	}
+
+	return created, nil
 }
 
-// GetByID retrieves a bash script by its ID
-func (r *BashScriptRepository) GetByID(id int64) (*models.BashScript, error) {
+// bashScriptColumns are the columns read by scanBashScript, in order
+const bashScriptColumns = "id, name, description, content_encrypted, filename, group_name, interpreter, tags, requires_approval, exclusive, exit_codes, read_only, created_at, updated_at"
+
+// scanBashScript reads a script selected with bashScriptColumns and decrypts its content
+func scanBashScript(row rowScanner) (*models.BashScript, error) {
 	var script models.BashScript
 	var encryptedContent []byte
 	var description, filename sql.NullString
+	var tags, exitCodes string
 
-	err := r.db.GetConnection().QueryRow(
-		"SELECT id, name, description, content_encrypted, filename, group_name, created_at, updated_at FROM bash_scripts WHERE id = ?",
-		id,
-	).Scan(&script.ID, &script.Name, &description, &encryptedContent, &filename, &script.Group, &script.CreatedAt, &script.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("bash script not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get bash script: %w", err)
+	if err := row.Scan(&script.ID, &script.Name, &description, &encryptedContent, &filename, &script.Group, &script.Interpreter, &tags, &script.RequiresApproval, &script.Exclusive, &exitCodes, &script.ReadOnly, &script.CreatedAt, &script.UpdatedAt); err != nil {
+		return nil, err
 	}
 
 	// Handle nullable fields
@@ -99,6 +138,8 @@ func (r *BashScriptRepository) GetByID(id int64) (*models.BashScript, error) {
 	if filename.Valid {
 		script.Filename = filename.String
 	}
+	script.Tags = decodeTags(tags)
+	script.ExitCodes = decodeExitCodes(exitCodes)
 
 	// Decrypt the content
 	decryptedContent, err := database.Decrypt(encryptedContent)
@@ -110,11 +151,26 @@ func (r *BashScriptRepository) GetByID(id int64) (*models.BashScript, error) {
 	return &script, nil
 }
 
-// GetAll retrieves all bash scripts (without content for listing)
-func (r *BashScriptRepository) GetAll() ([]*models.BashScript, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, description, content_encrypted, filename, group_name, created_at, updated_at FROM bash_scripts ORDER BY group_name ASC, name ASC",
-	)
+// get retrieves the bash script matching a condition
+func (r *BashScriptRepository) get(where string, args ...any) (*models.BashScript, error) {
+	script, err := scanBashScript(r.db.GetConnection().QueryRow("SELECT "+bashScriptColumns+" FROM bash_scripts WHERE "+where, args...))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("bash script not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bash script: %w", err)
+	}
+	return script, nil
+}
+
+// list retrieves the bash scripts matching a condition
+func (r *BashScriptRepository) list(where, orderBy string, args ...any) ([]*models.BashScript, error) {
+	return r.query("SELECT "+bashScriptColumns+" FROM bash_scripts WHERE "+where+" ORDER BY "+orderBy, args...)
+}
+
+// query retrieves the bash scripts selected with bashScriptColumns
+func (r *BashScriptRepository) query(query string, args ...any) ([]*models.BashScript, error) {
+	rows, err := r.db.GetConnection().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query bash scripts: %w", err)
 	}
@@ -122,30 +178,11 @@ func (r *BashScriptRepository) GetAll() ([]*models.BashScript, error) {
 
 	var scripts []*models.BashScript
 	for rows.Next() {
-		var script models.BashScript
-		var encryptedContent []byte
-		var description, filename sql.NullString
-
-		if err := rows.Scan(&script.ID, &script.Name, &description, &encryptedContent, &filename, &script.Group, &script.CreatedAt, &script.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan bash script: %w", err)
-		}
-
-		// Handle nullable fields
-		if description.Valid {
-			script.Description = description.String
-		}
-		if filename.Valid {
-			script.Filename = filename.String
-		}
-
-		// Decrypt the content
-		decryptedContent, err := database.Decrypt(encryptedContent)
+		script, err := scanBashScript(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt content: %w", err)
+			return nil, fmt.Errorf("failed to scan bash script: %w", err)
 		}
-		script.Content = decryptedContent
-
-		scripts = append(scripts, &script)
+		scripts = append(scripts, script)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -155,50 +192,69 @@ func (r *BashScriptRepository) GetAll() ([]*models.BashScript, error) {
 	return scripts, nil
 }
 
+// GetByID retrieves a bash script by its ID
+func (r *BashScriptRepository) GetByID(id int64) (*models.BashScript, error) {
+	return r.get("id = ?", id)
+}
+
+// GetAll retrieves all bash scripts
+func (r *BashScriptRepository) GetAll() ([]*models.BashScript, error) {
+	return r.list("1 = 1", "group_name ASC, name ASC")
+}
+
 // GetByGroup retrieves all bash scripts in a specific group
 func (r *BashScriptRepository) GetByGroup(group string) ([]*models.BashScript, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, description, content_encrypted, filename, group_name, created_at, updated_at FROM bash_scripts WHERE group_name = ? ORDER BY name ASC",
-		group,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query bash scripts: %w", err)
-	}
-	defer rows.Close()
+	return r.list("group_name = ?", "name ASC", group)
+}
 
-	var scripts []*models.BashScript
-	for rows.Next() {
-		var script models.BashScript
-		var encryptedContent []byte
-		var description, filename sql.NullString
+// bashScriptSortColumns maps the fields bash scripts can be sorted by to their columns
+var bashScriptSortColumns = map[string]string{
+	"name":       "name",
+	"group":      "group_name",
+	"filename":   "filename",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
 
-		if err := rows.Scan(&script.ID, &script.Name, &description, &encryptedContent, &filename, &script.Group, &script.CreatedAt, &script.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan bash script: %w", err)
-		}
+// Search retrieves all the bash scripts matching a filter, ignoring its page
+func (r *BashScriptRepository) Search(filter models.ListFilter) ([]*models.BashScript, error) {
+	scripts, _, err := r.List(filter.Unpaged())
+	return scripts, err
+}
 
-		// Handle nullable fields
-		if description.Valid {
-			script.Description = description.String
-		}
-		if filename.Valid {
-			script.Filename = filename.String
-		}
+// List retrieves a page of the bash scripts matching a filter, and the number of matches
+// The groups and tag are matched in SQL. The text query also searches the encrypted
+// content, so it is matched after decryption and the page is then cut in memory
+func (r *BashScriptRepository) List(filter models.ListFilter) ([]*models.BashScript, int, error) {
+	q := newListQuery("bash_scripts")
+	q.groups(filter)
+	q.tag(filter)
 
-		// Decrypt the content
-		decryptedContent, err := database.Decrypt(encryptedContent)
+	if strings.TrimSpace(filter.Query) == "" {
+		query, err := q.selectSQL(bashScriptColumns, filter, bashScriptSortColumns, "group_name ASC, name ASC")
 		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt content: %w", err)
+			return nil, 0, err
 		}
-		script.Content = decryptedContent
-
-		scripts = append(scripts, &script)
+		return runList(r.db, q, filter, query, r.query)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating bash scripts: %w", err)
+	query, err := q.selectSQL(bashScriptColumns, filter.Unpaged(), bashScriptSortColumns, "group_name ASC, name ASC")
+	if err != nil {
+		return nil, 0, err
+	}
+	scripts, err := r.query(query, q.args...)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return scripts, nil
+	matched := make([]*models.BashScript, 0, len(scripts))
+	for _, script := range scripts {
+		if filter.MatchesText(append([]string{script.Name, script.Description, script.Filename, script.Content}, script.Tags...)...) {
+			matched = append(matched, script)
+		}
+	}
+	start, end := filter.Page(len(matched))
+	return matched[start:end], len(matched), nil
 }
 
 // GetGroups retrieves all distinct group names
@@ -235,6 +291,8 @@ func (r *BashScriptRepository) Update(id int64, update *models.BashScriptUpdate)
 		return nil, err
 	}
 
+	previous := *existing
+
 	// Update fields if provided
 	if update.Name != "" {
 		existing.Name = update.Name
@@ -257,82 +315,261 @@ func (r *BashScriptRepository) Update(id int64, update *models.BashScriptUpdate)
 		existing.Group = update.Group
 	}
 
-	existing.UpdatedAt = time.Now().UTC()
+	if update.Interpreter != "" {
+		existing.Interpreter = update.Interpreter
+	}
+
+	// An empty list removes all tags; omitting tags keeps them
+	if update.Tags != nil {
+		existing.Tags = models.NormalizeTags(update.Tags)
+	}
+
+	if update.RequiresApproval != nil {
+		existing.RequiresApproval = *update.RequiresApproval
+	}
+
+	if update.Exclusive != nil {
+		existing.Exclusive = *update.Exclusive
+	}
+
+	// An empty map removes the exit code meanings; omitting them keeps them
+	if update.ExitCodes != nil {
+		existing.ExitCodes = update.ExitCodes
+	}
+
+	if update.ReadOnly != nil {
+		existing.ReadOnly = *update.ReadOnly
+	}
+
+	if err := r.save(existing, versionChanged(&previous, existing)); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// save writes a changed script, recording a new version when its versioned fields changed
+func (r *BashScriptRepository) save(script *models.BashScript, newVersion bool) error {
+	script.UpdatedAt = time.Now().UTC()
 
 	// Encrypt the content
-	encryptedContent, err := database.Encrypt(existing.Content)
+	encryptedContent, err := database.Encrypt(script.Content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt content: %w", err)
+		return fmt.Errorf("failed to encrypt content: %w", err)
+	}
+
+	tx, err := r.db.GetConnection().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = r.db.GetConnection().Exec(
-		"UPDATE bash_scripts SET name = ?, description = ?, content_encrypted = ?, filename = ?, group_name = ?, updated_at = ? WHERE id = ?",
-		existing.Name,
-		existing.Description,
+	_, err = tx.Exec(
+		"UPDATE bash_scripts SET name = ?, description = ?, content_encrypted = ?, filename = ?, group_name = ?, interpreter = ?, tags = ?, requires_approval = ?, exclusive = ?, exit_codes = ?, read_only = ?, updated_at = ? WHERE id = ?",
+		script.Name,
+		script.Description,
 		encryptedContent,
-		existing.Filename,
-		existing.Group,
-		existing.UpdatedAt,
-		id,
+		script.Filename,
+		script.Group,
+		script.Interpreter,
+		encodeTags(script.Tags),
+		boolToInt(script.RequiresApproval),
+		boolToInt(script.Exclusive),
+		encodeExitCodes(script.ExitCodes),
+		boolToInt(script.ReadOnly),
+		script.UpdatedAt,
+		script.ID,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update bash script: %w", err)
+		return fmt.Errorf("failed to update bash script: %w", err)
 	}
 
-	return existing, nil
+	if newVersion {
+		if err := insertVersion(tx, script, encryptedContent); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit bash script: %w", err)
+	}
+
+	return nil
 }
 
-// Delete deletes a bash script by its ID
-func (r *BashScriptRepository) Delete(id int64) error {
-	result, err := r.db.GetConnection().Exec("DELETE FROM bash_scripts WHERE id = ?", id)
+// versionChanged reports whether any field kept in the version history differs
+// Approval requirements, exclusivity and exit code meanings are execution controls and tags are labels, not content; none is versioned
+func versionChanged(a, b *models.BashScript) bool {
+	return a.Name != b.Name ||
+		a.Description != b.Description ||
+		a.Content != b.Content ||
+		a.Filename != b.Filename ||
+		a.Group != b.Group ||
+		a.Interpreter != b.Interpreter
+}
+
+// encodeExitCodes serializes exit code meanings to the JSON object stored in the exit_codes column
+func encodeExitCodes(codes models.ExitCodes) string {
+	data, err := json.Marshal(codes)
+	if err != nil || codes == nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// decodeExitCodes parses the exit_codes column, treating invalid values as no meanings
+func decodeExitCodes(value string) models.ExitCodes {
+	var codes models.ExitCodes
+	if err := json.Unmarshal([]byte(value), &codes); err != nil || codes == nil {
+		return models.ExitCodes{}
+	}
+	return codes
+}
+
+// insertVersion records the script's current state as its next version
+func insertVersion(tx *sql.Tx, script *models.BashScript, encryptedContent []byte) error {
+	_, err := tx.Exec(
+		"INSERT INTO bash_script_versions (script_id, version, name, description, content_encrypted, filename, group_name, interpreter, created_at) SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ?, ?, ? FROM bash_script_versions WHERE script_id = ?",
+		script.ID,
+		script.Name,
+		script.Description,
+		encryptedContent,
+		script.Filename,
+		script.Group,
+		script.Interpreter,
+		script.UpdatedAt,
+		script.ID,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to delete bash script: %w", err)
+		return fmt.Errorf("failed to record bash script version: %w", err)
 	}
+	return nil
+}
 
-	rowsAffected, err := result.RowsAffected()
+// GetVersions retrieves the version history of a bash script, newest first (without content)
+func (r *BashScriptRepository) GetVersions(scriptID int64) ([]*models.BashScriptVersion, error) {
+	rows, err := r.db.GetConnection().Query(
+		"SELECT id, script_id, version, name, description, filename, group_name, interpreter, created_at FROM bash_script_versions WHERE script_id = ? ORDER BY version DESC",
+		scriptID,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to query bash script versions: %w", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("bash script not found")
+	versions := make([]*models.BashScriptVersion, 0)
+	for rows.Next() {
+		var version models.BashScriptVersion
+		var description, filename sql.NullString
+
+		if err := rows.Scan(&version.ID, &version.ScriptID, &version.Version, &version.Name, &description, &filename, &version.Group, &version.Interpreter, &version.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bash script version: %w", err)
+		}
+		version.Description = description.String
+		version.Filename = filename.String
+
+		versions = append(versions, &version)
 	}
 
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bash script versions: %w", err)
+	}
+
+	return versions, nil
 }
 
-// GetByName retrieves a bash script by its name
-func (r *BashScriptRepository) GetByName(name string) (*models.BashScript, error) {
-	var script models.BashScript
+// GetVersion retrieves one version of a bash script, including its content
+func (r *BashScriptRepository) GetVersion(scriptID int64, number int) (*models.BashScriptVersion, error) {
+	var version models.BashScriptVersion
 	var encryptedContent []byte
 	var description, filename sql.NullString
 
 	err := r.db.GetConnection().QueryRow(
-		"SELECT id, name, description, content_encrypted, filename, group_name, created_at, updated_at FROM bash_scripts WHERE name = ?",
-		name,
-	).Scan(&script.ID, &script.Name, &description, &encryptedContent, &filename, &script.Group, &script.CreatedAt, &script.UpdatedAt)
+		"SELECT id, script_id, version, name, description, content_encrypted, filename, group_name, interpreter, created_at FROM bash_script_versions WHERE script_id = ? AND version = ?",
+		scriptID,
+		number,
+	).Scan(&version.ID, &version.ScriptID, &version.Version, &version.Name, &description, &encryptedContent, &filename, &version.Group, &version.Interpreter, &version.CreatedAt)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("bash script not found")
+		return nil, fmt.Errorf("bash script version not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get bash script: %w", err)
-	}
-
-	// Handle nullable fields
-	if description.Valid {
-		script.Description = description.String
-	}
-	if filename.Valid {
-		script.Filename = filename.String
+		return nil, fmt.Errorf("failed to get bash script version: %w", err)
 	}
+	version.Description = description.String
+	version.Filename = filename.String
 
 	// Decrypt the content
 	decryptedContent, err := database.Decrypt(encryptedContent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt content: %w", err)
 	}
-	script.Content = decryptedContent
+	version.Content = decryptedContent
 
-	return &script, nil
+	return &version, nil
+}
+
+// GetLatestVersion retrieves the newest version of a bash script, including its content
+func (r *BashScriptRepository) GetLatestVersion(scriptID int64) (*models.BashScriptVersion, error) {
+	var number int
+	err := r.db.GetConnection().QueryRow(
+		"SELECT COALESCE(MAX(version), 0) FROM bash_script_versions WHERE script_id = ?",
+		scriptID,
+	).Scan(&number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest bash script version: %w", err)
+	}
+	return r.GetVersion(scriptID, number)
+}
+
+// Rollback restores a bash script to an earlier version
+// The restored state is recorded as a new version, so the rollback itself can be undone
+func (r *BashScriptRepository) Rollback(scriptID int64, number int) (*models.BashScript, error) {
+	existing, err := r.GetByID(scriptID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := r.GetVersion(scriptID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *existing
+	existing.Name = version.Name
+	existing.Description = version.Description
+	existing.Content = version.Content
+	existing.Filename = version.Filename
+	existing.Group = version.Group
+	existing.Interpreter = version.Interpreter
+
+	if err := r.save(existing, versionChanged(&previous, existing)); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// Delete deletes a bash script by its ID
+func (r *BashScriptRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM bash_scripts WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete bash script: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("bash script not found")
+	}
+
+	return nil
+}
+
+// GetByName retrieves a bash script by its name
+func (r *BashScriptRepository) GetByName(name string) (*models.BashScript, error) {
+	return r.get("name = ?", name)
 }
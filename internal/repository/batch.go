@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pozgo/web-cli/internal/database"
+)
+
+// dbtx runs statements on the connection or inside a transaction
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// BatchError reports the operation a batch stopped at; none of the batch was applied
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// applyBatch runs apply for each of n operations in one transaction, committing only if all succeed
+func applyBatch[T any](db *database.DB, n int, apply func(tx *sql.Tx, i int) (T, error)) ([]T, error) {
+	tx, err := db.GetConnection().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]T, n)
+	for i := range n {
+		if results[i], err = apply(tx, i); err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return results, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pozgo/web-cli/internal/database"
@@ -39,13 +40,21 @@ func (r *CommandHistoryRepository) Create(history *models.CommandHistoryCreate)
 	now := time.Now().UTC()
 
 	result, err := r.db.GetConnection().Exec(
-		"INSERT INTO command_history (command_encrypted, output_encrypted, exit_code, server, user, execution_time_ms, executed_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO command_history (command_encrypted, output_encrypted, exit_code, server, user, execution_time_ms, output_size, output_truncated, severity, principal, script_id, preset_id, saved_command_id, server_id, executed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		encryptedCommand,
 		encryptedOutput,
 		history.ExitCode,
 		history.Server,
 		history.User,
 		history.ExecutionTimeMs,
+		history.OutputSize,
+		boolToInt(history.OutputTruncated),
+		history.Severity,
+		history.Principal,
+		history.ScriptID,
+		history.PresetID,
+		history.SavedCommandID,
+		history.ServerID,
 		now,
 	)
 	if err != nil {
@@ -65,29 +74,128 @@ func (r *CommandHistoryRepository) Create(history *models.CommandHistoryCreate)
 		Server:          history.Server,
 		User:            history.User,
 		ExecutionTimeMs: history.ExecutionTimeMs,
+		OutputSize:      history.OutputSize,
+		OutputTruncated: history.OutputTruncated,
+		Severity:        history.Severity,
+		ScriptID:        history.ScriptID,
+		PresetID:        history.PresetID,
+		SavedCommandID:  history.SavedCommandID,
+		ServerID:        history.ServerID,
 		ExecutedAt:      now,
 	}, nil
 }
 
+// commandHistoryColumns are the columns read by scanCommandHistory, in order
+const commandHistoryColumns = "id, command_encrypted, output_encrypted, exit_code, server, user, execution_time_ms, output_size, output_truncated, severity, script_id, preset_id, saved_command_id, server_id, executed_at"
+
 // GetByID retrieves a command history record by its ID
 func (r *CommandHistoryRepository) GetByID(id int64) (*models.CommandHistory, error) {
-	var history models.CommandHistory
-	var encryptedCommand []byte
-	var encryptedOutput []byte
-
-	var user sql.NullString
-
-	err := r.db.GetConnection().QueryRow(
-		"SELECT id, command_encrypted, output_encrypted, exit_code, server, user, execution_time_ms, executed_at FROM command_history WHERE id = ?",
+	history, err := scanCommandHistory(r.db.GetConnection().QueryRow(
+		"SELECT "+commandHistoryColumns+" FROM command_history WHERE id = ?",
 		id,
-	).Scan(&history.ID, &encryptedCommand, &encryptedOutput, &history.ExitCode, &history.Server, &user, &history.ExecutionTimeMs, &history.ExecutedAt)
-
+	))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("command history not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get command history: %w", err)
 	}
+	return history, nil
+}
+
+// GetAll retrieves all command history records with optional limit
+func (r *CommandHistoryRepository) GetAll(limit int) ([]*models.CommandHistory, error) {
+	return r.GetFiltered(models.CommandHistoryFilter{Limit: limit})
+}
+
+// GetByServer retrieves command history for a specific server
+func (r *CommandHistoryRepository) GetByServer(server string, limit int) ([]*models.CommandHistory, error) {
+	return r.GetFiltered(models.CommandHistoryFilter{Server: server, Limit: limit})
+}
+
+// GetFiltered retrieves the command history records matching every set field of filter, newest first
+func (r *CommandHistoryRepository) GetFiltered(filter models.CommandHistoryFilter) ([]*models.CommandHistory, error) {
+	var conditions []string
+	var args []any
+	if filter.Server != "" {
+		conditions = append(conditions, "server = ?")
+		args = append(args, filter.Server)
+	}
+	if filter.ScriptID != nil {
+		conditions = append(conditions, "script_id = ?")
+		args = append(args, *filter.ScriptID)
+	}
+	if filter.PresetID != nil {
+		conditions = append(conditions, "preset_id = ?")
+		args = append(args, *filter.PresetID)
+	}
+	if filter.SavedCommandID != nil {
+		conditions = append(conditions, "saved_command_id = ?")
+		args = append(args, *filter.SavedCommandID)
+	}
+	if filter.ExitCode != nil {
+		conditions = append(conditions, "exit_code = ?")
+		args = append(args, *filter.ExitCode)
+	}
+	if filter.User != "" {
+		conditions = append(conditions, "user = ?")
+		args = append(args, filter.User)
+	}
+	if filter.Since != nil {
+		conditions = append(conditions, "executed_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		conditions = append(conditions, "executed_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+
+	query := "SELECT " + commandHistoryColumns + " FROM command_history"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY executed_at DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.GetConnection().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query command history: %w", err)
+	}
+	defer rows.Close()
+
+	var histories []*models.CommandHistory
+	for rows.Next() {
+		history, err := scanCommandHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating command history: %w", err)
+	}
+
+	return histories, nil
+}
+
+// scanCommandHistory reads a command history record selected with commandHistoryColumns,
+// decrypting its command and output
+func scanCommandHistory(row rowScanner) (*models.CommandHistory, error) {
+	var history models.CommandHistory
+	var encryptedCommand []byte
+	var encryptedOutput []byte
+	var user sql.NullString
+	var scriptID, presetID, savedCommandID, serverID sql.NullInt64
+
+	if err := row.Scan(&history.ID, &encryptedCommand, &encryptedOutput, &history.ExitCode, &history.Server, &user, &history.ExecutionTimeMs, &history.OutputSize, &history.OutputTruncated, &history.Severity, &scriptID, &presetID, &savedCommandID, &serverID, &history.ExecutedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan command history: %w", err)
+	}
 
 	// Decrypt command
 	decryptedCommand, err := database.Decrypt(encryptedCommand)
@@ -110,18 +218,32 @@ func (r *CommandHistoryRepository) GetByID(id int64) (*models.CommandHistory, er
 		history.User = user.String
 	}
 
+	history.ScriptID = nullableID(scriptID)
+	history.PresetID = nullableID(presetID)
+	history.SavedCommandID = nullableID(savedCommandID)
+	history.ServerID = nullableID(serverID)
+
 	return &history, nil
 }
 
-// GetAll retrieves all command history records with optional limit
-func (r *CommandHistoryRepository) GetAll(limit int) ([]*models.CommandHistory, error) {
-	query := "SELECT id, command_encrypted, output_encrypted, exit_code, server, user, execution_time_ms, executed_at FROM command_history ORDER BY executed_at DESC"
+// nullableID returns the ID held by a nullable column, or nil
+func nullableID(id sql.NullInt64) *int64 {
+	if !id.Valid {
+		return nil
+	}
+	return &id.Int64
+}
+
+// GetCommandsByPrincipal retrieves the latest commands a principal ran, newest first, leaving
+// out script executions and outputs
+func (r *CommandHistoryRepository) GetCommandsByPrincipal(principal string, limit int) ([]*models.CommandHistory, error) {
+	query := "SELECT id, command_encrypted, executed_at FROM command_history WHERE principal = ? AND script_id IS NULL ORDER BY executed_at DESC, id DESC"
 
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
 
-	rows, err := r.db.GetConnection().Query(query)
+	rows, err := r.db.GetConnection().Query(query, principal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query command history: %w", err)
 	}
@@ -131,34 +253,17 @@ func (r *CommandHistoryRepository) GetAll(limit int) ([]*models.CommandHistory,
 	for rows.Next() {
 		var history models.CommandHistory
 		var encryptedCommand []byte
-		var encryptedOutput []byte
-		var user sql.NullString
 
-		if err := rows.Scan(&history.ID, &encryptedCommand, &encryptedOutput, &history.ExitCode, &history.Server, &user, &history.ExecutionTimeMs, &history.ExecutedAt); err != nil {
+		if err := rows.Scan(&history.ID, &encryptedCommand, &history.ExecutedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan command history: %w", err)
 		}
 
-		// Decrypt command
 		decryptedCommand, err := database.Decrypt(encryptedCommand)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decrypt command: %w", err)
 		}
 		history.Command = decryptedCommand
 
-		// Decrypt output if present
-		if len(encryptedOutput) > 0 {
-			decryptedOutput, err := database.Decrypt(encryptedOutput)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decrypt output: %w", err)
-			}
-			history.Output = decryptedOutput
-		}
-
-		// Set user if present
-		if user.Valid {
-			history.User = user.String
-		}
-
 		histories = append(histories, &history)
 	}
 
@@ -169,60 +274,49 @@ func (r *CommandHistoryRepository) GetAll(limit int) ([]*models.CommandHistory,
 	return histories, nil
 }
 
-// GetByServer retrieves command history for a specific server
-func (r *CommandHistoryRepository) GetByServer(server string, limit int) ([]*models.CommandHistory, error) {
-	query := "SELECT id, command_encrypted, output_encrypted, exit_code, server, user, execution_time_ms, executed_at FROM command_history WHERE server = ? ORDER BY executed_at DESC"
+// GetRecentItems retrieves the scripts, saved commands and servers a principal ran most
+// recently, with the last execution of each; names are left for the caller to fill in
+func (r *CommandHistoryRepository) GetRecentItems(principal string, limit int) ([]*models.RecentItem, error) {
+	query := `
+		SELECT items.resource_type, items.resource_id, items.executions, h.id, h.exit_code, h.severity, h.executed_at
+		FROM (
+			SELECT resource_type, resource_id, COUNT(*) AS executions, MAX(id) AS last_id
+			FROM (
+				SELECT 'bash_scripts' AS resource_type, script_id AS resource_id, id FROM command_history WHERE principal = ? AND script_id IS NOT NULL
+				UNION ALL
+				SELECT 'saved_commands', saved_command_id, id FROM command_history WHERE principal = ? AND saved_command_id IS NOT NULL
+				UNION ALL
+				SELECT 'servers', server_id, id FROM command_history WHERE principal = ? AND server_id IS NOT NULL
+			)
+			GROUP BY resource_type, resource_id
+		) AS items
+		JOIN command_history h ON h.id = items.last_id
+		ORDER BY h.executed_at DESC, h.id DESC, items.resource_type ASC`
 
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
 
-	rows, err := r.db.GetConnection().Query(query, server)
+	rows, err := r.db.GetConnection().Query(query, principal, principal, principal)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query command history: %w", err)
+		return nil, fmt.Errorf("failed to query recent items: %w", err)
 	}
 	defer rows.Close()
 
-	var histories []*models.CommandHistory
+	var items []*models.RecentItem
 	for rows.Next() {
-		var history models.CommandHistory
-		var encryptedCommand []byte
-		var encryptedOutput []byte
-		var user sql.NullString
-
-		if err := rows.Scan(&history.ID, &encryptedCommand, &encryptedOutput, &history.ExitCode, &history.Server, &user, &history.ExecutionTimeMs, &history.ExecutedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan command history: %w", err)
+		var item models.RecentItem
+		if err := rows.Scan(&item.ResourceType, &item.ResourceID, &item.Executions, &item.LastHistoryID, &item.LastExitCode, &item.LastSeverity, &item.LastExecutedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recent item: %w", err)
 		}
-
-		// Decrypt command
-		decryptedCommand, err := database.Decrypt(encryptedCommand)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt command: %w", err)
-		}
-		history.Command = decryptedCommand
-
-		// Decrypt output if present
-		if len(encryptedOutput) > 0 {
-			decryptedOutput, err := database.Decrypt(encryptedOutput)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decrypt output: %w", err)
-			}
-			history.Output = decryptedOutput
-		}
-
-		// Set user if present
-		if user.Valid {
-			history.User = user.String
-		}
-
-		histories = append(histories, &history)
+		items = append(items, &item)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating command history: %w", err)
+		return nil, fmt.Errorf("error iterating recent items: %w", err)
 	}
 
-	return histories, nil
+	return items, nil
 }
 
 // Delete deletes a command history record by its ID
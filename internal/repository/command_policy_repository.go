@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/policy"
+)
+
+// commandPolicyColumns lists the columns read by scanCommandPolicy
+const commandPolicyColumns = `id, name, description, action, match_type, pattern, target, principal, enabled, created_at, updated_at`
+
+// CommandPolicyRepository handles database operations for command policies
+type CommandPolicyRepository struct {
+	db *database.DB
+}
+
+// NewCommandPolicyRepository creates a new command policy repository
+func NewCommandPolicyRepository(db *database.DB) *CommandPolicyRepository {
+	return &CommandPolicyRepository{db: db}
+}
+
+// Create inserts a new command policy
+func (r *CommandPolicyRepository) Create(create *models.CommandPolicyCreate) (*models.CommandPolicy, error) {
+	now := time.Now().UTC()
+	p := &models.CommandPolicy{
+		Name:        create.Name,
+		Description: create.Description,
+		Action:      create.Action,
+		MatchType:   create.MatchType,
+		Pattern:     create.Pattern,
+		Target:      create.Target,
+		Principal:   create.Principal,
+		Enabled:     create.Enabled == nil || *create.Enabled,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := normalizeCommandPolicy(p); err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO command_policies (name, description, action, match_type, pattern, target, principal, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.Name,
+		p.Description,
+		p.Action,
+		p.MatchType,
+		p.Pattern,
+		p.Target,
+		p.Principal,
+		boolToInt(p.Enabled),
+		p.CreatedAt,
+		p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create command policy: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	p.ID = id
+
+	return p, nil
+}
+
+// GetByID retrieves a command policy by its ID
+func (r *CommandPolicyRepository) GetByID(id int64) (*models.CommandPolicy, error) {
+	row := r.db.GetConnection().QueryRow("SELECT "+commandPolicyColumns+" FROM command_policies WHERE id = ?", id)
+
+	p, err := scanCommandPolicy(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("command policy not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get command policy: %w", err)
+	}
+
+	return p, nil
+}
+
+// GetAll retrieves all command policies
+func (r *CommandPolicyRepository) GetAll() ([]*models.CommandPolicy, error) {
+	return r.query("SELECT " + commandPolicyColumns + " FROM command_policies ORDER BY id ASC")
+}
+
+// GetEnabled retrieves the command policies that are evaluated before execution
+func (r *CommandPolicyRepository) GetEnabled() ([]*models.CommandPolicy, error) {
+	return r.query("SELECT " + commandPolicyColumns + " FROM command_policies WHERE enabled = 1 ORDER BY id ASC")
+}
+
+// Update updates an existing command policy
+func (r *CommandPolicyRepository) Update(id int64, update *models.CommandPolicyUpdate) (*models.CommandPolicy, error) {
+	existing, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != "" {
+		existing.Name = update.Name
+	}
+	if update.Description != nil {
+		existing.Description = *update.Description
+	}
+	if update.Action != "" {
+		existing.Action = update.Action
+	}
+	if update.MatchType != "" {
+		existing.MatchType = update.MatchType
+	}
+	if update.Pattern != "" {
+		existing.Pattern = update.Pattern
+	}
+	if update.Target != "" {
+		existing.Target = update.Target
+	}
+	if update.Principal != nil {
+		existing.Principal = *update.Principal
+	}
+	if update.Enabled != nil {
+		existing.Enabled = *update.Enabled
+	}
+	if err := normalizeCommandPolicy(existing); err != nil {
+		return nil, err
+	}
+
+	existing.UpdatedAt = time.Now().UTC()
+
+	_, err = r.db.GetConnection().Exec(
+		`UPDATE command_policies SET name = ?, description = ?, action = ?, match_type = ?, pattern = ?, target = ?, principal = ?, enabled = ?, updated_at = ?
+		WHERE id = ?`,
+		existing.Name,
+		existing.Description,
+		existing.Action,
+		existing.MatchType,
+		existing.Pattern,
+		existing.Target,
+		existing.Principal,
+		boolToInt(existing.Enabled),
+		existing.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update command policy: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete deletes a command policy by its ID
+func (r *CommandPolicyRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM command_policies WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete command policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("command policy not found")
+	}
+
+	return nil
+}
+
+// LoadEngine returns a policy engine for the enabled command policies
+func (r *CommandPolicyRepository) LoadEngine() (*policy.Engine, error) {
+	policies, err := r.GetEnabled()
+	if err != nil {
+		return nil, err
+	}
+	return policy.New(policies)
+}
+
+// query runs a command policy query and scans the results
+func (r *CommandPolicyRepository) query(query string) ([]*models.CommandPolicy, error) {
+	rows, err := r.db.GetConnection().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query command policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*models.CommandPolicy
+	for rows.Next() {
+		p, err := scanCommandPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan command policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating command policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// scanCommandPolicy reads a command policy from a query result
+func scanCommandPolicy(row rowScanner) (*models.CommandPolicy, error) {
+	var p models.CommandPolicy
+	var enabled int
+	if err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Action, &p.MatchType, &p.Pattern, &p.Target, &p.Principal, &enabled, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.Enabled = enabled == 1
+	return &p, nil
+}
+
+// normalizeCommandPolicy validates a command policy, fills in defaults and checks its pattern compiles
+func normalizeCommandPolicy(p *models.CommandPolicy) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	p.Action = strings.ToLower(strings.TrimSpace(p.Action))
+	if p.Action != models.PolicyActionAllow && p.Action != models.PolicyActionDeny {
+		return fmt.Errorf("invalid action %q (valid actions: %s, %s)", p.Action, models.PolicyActionAllow, models.PolicyActionDeny)
+	}
+
+	p.MatchType = strings.ToLower(strings.TrimSpace(p.MatchType))
+	if p.MatchType == "" {
+		p.MatchType = models.PolicyMatchGlob
+	}
+
+	p.Target = strings.ToLower(strings.TrimSpace(p.Target))
+	if p.Target == "" {
+		p.Target = models.PolicyTargetCommand
+	}
+	if p.Target != models.PolicyTargetCommand && p.Target != models.PolicyTargetScript {
+		return fmt.Errorf("invalid target %q (valid targets: %s, %s)", p.Target, models.PolicyTargetCommand, models.PolicyTargetScript)
+	}
+
+	p.Principal = strings.TrimSpace(p.Principal)
+	if p.Principal != "" {
+		valid := false
+		for _, kind := range principalKinds {
+			if strings.HasPrefix(p.Principal, kind) && len(p.Principal) > len(kind) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid principal %q (expected user:<name>, token:<name> or role:<role>)", p.Principal)
+		}
+	}
+
+	if strings.TrimSpace(p.Pattern) == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if _, err := policy.Compile(p); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -21,6 +21,11 @@ func NewEnvVariableRepository(db *database.DB) *EnvVariableRepository {
 
 // Create creates a new environment variable with encrypted value
 func (r *EnvVariableRepository) Create(envVar *models.EnvVariableCreate) (*models.EnvVariable, error) {
+	return createEnvVariable(r.db.GetConnection(), envVar)
+}
+
+// createEnvVariable inserts an environment variable with q
+func createEnvVariable(q dbtx, envVar *models.EnvVariableCreate) (*models.EnvVariable, error) {
 	// Validate input
 	if envVar.Name == "" {
 		return nil, fmt.Errorf("name is required")
@@ -43,7 +48,7 @@ func (r *EnvVariableRepository) Create(envVar *models.EnvVariableCreate) (*model
 
 	now := time.Now().UTC()
 
-	result, err := r.db.GetConnection().Exec(
+	result, err := q.Exec(
 		"INSERT INTO env_variables (name, value_encrypted, description, group_name, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
 		envVar.Name,
 		encryptedValue,
@@ -72,12 +77,81 @@ func (r *EnvVariableRepository) Create(envVar *models.EnvVariableCreate) (*model
 	}, nil
 }
 
+// CreateMany creates environment variables in one transaction, so either all or none are stored
+func (r *EnvVariableRepository) CreateMany(envVars []*models.EnvVariableCreate) ([]*models.EnvVariable, error) {
+	values := make([]string, len(envVars))
+	for i, envVar := range envVars {
+		if envVar.Name == "" || envVar.Value == "" {
+			return nil, fmt.Errorf("name and value are required")
+		}
+		values[i] = envVar.Value
+	}
+
+	// Encrypt the values in one batch
+	encryptedValues, err := database.EncryptMany(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt value: %w", err)
+	}
+
+	tx, err := r.db.GetConnection().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	created := make([]*models.EnvVariable, 0, len(envVars))
+	for i, envVar := range envVars {
+		group := envVar.Group
+		if group == "" {
+			group = "default"
+		}
+
+		result, err := tx.Exec(
+			"INSERT INTO env_variables (name, value_encrypted, description, group_name, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+			envVar.Name,
+			encryptedValues[i],
+			envVar.Description,
+			group,
+			now,
+			now,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create environment variable %s: %w", envVar.Name, err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+		}
+		created = append(created, &models.EnvVariable{
+			ID:          id,
+			Name:        envVar.Name,
+			Value:       envVar.Value,
+			Description: envVar.Description,
+			Group:       group,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit environment variables: %w", err)
+	}
+	return created, nil
+}
+
 // GetByID retrieves an environment variable by its ID
 func (r *EnvVariableRepository) GetByID(id int64) (*models.EnvVariable, error) {
+	return getEnvVariable(r.db.GetConnection(), id)
+}
+
+// getEnvVariable reads and decrypts an environment variable with q
+func getEnvVariable(q dbtx, id int64) (*models.EnvVariable, error) {
 	var envVar models.EnvVariable
 	var encryptedValue []byte
 
-	err := r.db.GetConnection().QueryRow(
+	err := q.QueryRow(
 		"SELECT id, name, value_encrypted, description, group_name, created_at, updated_at FROM env_variables WHERE id = ?",
 		id,
 	).Scan(&envVar.ID, &envVar.Name, &encryptedValue, &envVar.Description, &envVar.Group, &envVar.CreatedAt, &envVar.UpdatedAt)
@@ -126,54 +200,50 @@ func (r *EnvVariableRepository) GetByName(name string) (*models.EnvVariable, err
 	return &envVar, nil
 }
 
-// GetAll retrieves all environment variables
-func (r *EnvVariableRepository) GetAll() ([]*models.EnvVariable, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, value_encrypted, description, group_name, created_at, updated_at FROM env_variables ORDER BY group_name ASC, name ASC",
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query environment variables: %w", err)
-	}
-	defer rows.Close()
-
-	var envVars []*models.EnvVariable
-	for rows.Next() {
-		var envVar models.EnvVariable
-		var encryptedValue []byte
+// envVariableColumns are the columns scanned by list
+const envVariableColumns = "id, name, value_encrypted, description, group_name, created_at, updated_at"
 
-		if err := rows.Scan(&envVar.ID, &envVar.Name, &encryptedValue, &envVar.Description, &envVar.Group, &envVar.CreatedAt, &envVar.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan environment variable: %w", err)
-		}
+// envVariableSortColumns maps the fields environment variables can be sorted by to their columns
+var envVariableSortColumns = map[string]string{
+	"name":       "name",
+	"group":      "group_name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
 
-		// Decrypt the value
-		decryptedValue, err := database.Decrypt(encryptedValue)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt value: %w", err)
-		}
-		envVar.Value = decryptedValue
+// GetAll retrieves all environment variables
+func (r *EnvVariableRepository) GetAll() ([]*models.EnvVariable, error) {
+	return r.list("SELECT " + envVariableColumns + " FROM env_variables ORDER BY group_name ASC, name ASC")
+}
 
-		envVars = append(envVars, &envVar)
-	}
+// GetByGroup retrieves all environment variables in a specific group
+func (r *EnvVariableRepository) GetByGroup(group string) ([]*models.EnvVariable, error) {
+	return r.list("SELECT "+envVariableColumns+" FROM env_variables WHERE group_name = ? ORDER BY name ASC", group)
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating environment variables: %w", err)
+// List retrieves a page of the environment variables matching a filter, and the number of matches
+// The query is matched against the name and description; only the values on the page are decrypted
+func (r *EnvVariableRepository) List(filter models.ListFilter) ([]*models.EnvVariable, int, error) {
+	q := newListQuery("env_variables")
+	q.groups(filter)
+	q.text(filter, "name", "description")
+	query, err := q.selectSQL(envVariableColumns, filter, envVariableSortColumns, "group_name ASC, name ASC")
+	if err != nil {
+		return nil, 0, err
 	}
-
-	return envVars, nil
+	return runList(r.db, q, filter, query, r.list)
 }
 
-// GetByGroup retrieves all environment variables in a specific group
-func (r *EnvVariableRepository) GetByGroup(group string) ([]*models.EnvVariable, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, value_encrypted, description, group_name, created_at, updated_at FROM env_variables WHERE group_name = ? ORDER BY name ASC",
-		group,
-	)
+// list queries environment variables and decrypts their values in one batch
+func (r *EnvVariableRepository) list(query string, args ...any) ([]*models.EnvVariable, error) {
+	rows, err := r.db.GetConnection().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query environment variables: %w", err)
 	}
 	defer rows.Close()
 
 	var envVars []*models.EnvVariable
+	var encryptedValues [][]byte
 	for rows.Next() {
 		var envVar models.EnvVariable
 		var encryptedValue []byte
@@ -182,20 +252,23 @@ func (r *EnvVariableRepository) GetByGroup(group string) ([]*models.EnvVariable,
 			return nil, fmt.Errorf("failed to scan environment variable: %w", err)
 		}
 
-		// Decrypt the value
-		decryptedValue, err := database.Decrypt(encryptedValue)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt value: %w", err)
-		}
-		envVar.Value = decryptedValue
-
 		envVars = append(envVars, &envVar)
+		encryptedValues = append(encryptedValues, encryptedValue)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating environment variables: %w", err)
 	}
 
+	// Decrypt the values
+	values, err := database.DecryptMany(encryptedValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	for i, envVar := range envVars {
+		envVar.Value = values[i]
+	}
+
 	return envVars, nil
 }
 
@@ -227,8 +300,13 @@ func (r *EnvVariableRepository) GetGroups() ([]string, error) {
 
 // Update updates an existing environment variable
 func (r *EnvVariableRepository) Update(id int64, update *models.EnvVariableUpdate) (*models.EnvVariable, error) {
+	return updateEnvVariable(r.db.GetConnection(), id, update)
+}
+
+// updateEnvVariable changes an environment variable with q
+func updateEnvVariable(q dbtx, id int64, update *models.EnvVariableUpdate) (*models.EnvVariable, error) {
 	// Get existing variable
-	existing, err := r.GetByID(id)
+	existing, err := getEnvVariable(q, id)
 	if err != nil {
 		return nil, err
 	}
@@ -259,7 +337,7 @@ func (r *EnvVariableRepository) Update(id int64, update *models.EnvVariableUpdat
 		return nil, fmt.Errorf("failed to encrypt value: %w", err)
 	}
 
-	_, err = r.db.GetConnection().Exec(
+	_, err = q.Exec(
 		"UPDATE env_variables SET name = ?, value_encrypted = ?, description = ?, group_name = ?, updated_at = ? WHERE id = ?",
 		existing.Name,
 		encryptedValue,
@@ -277,7 +355,12 @@ func (r *EnvVariableRepository) Update(id int64, update *models.EnvVariableUpdat
 
 // Delete deletes an environment variable by its ID
 func (r *EnvVariableRepository) Delete(id int64) error {
-	result, err := r.db.GetConnection().Exec("DELETE FROM env_variables WHERE id = ?", id)
+	return deleteEnvVariable(r.db.GetConnection(), id)
+}
+
+// deleteEnvVariable removes an environment variable with q
+func deleteEnvVariable(q dbtx, id int64) error {
+	result, err := q.Exec("DELETE FROM env_variables WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete environment variable: %w", err)
 	}
@@ -294,6 +377,29 @@ func (r *EnvVariableRepository) Delete(id int64) error {
 	return nil
 }
 
+// ApplyBatch creates, updates and deletes environment variables in one transaction, so either all or none are applied
+// It returns the created or updated variable of each operation (nil for deletes), or a *BatchError
+func (r *EnvVariableRepository) ApplyBatch(ops []models.EnvVariableBatchOperation) ([]*models.EnvVariable, error) {
+	return applyBatch(r.db, len(ops), func(tx *sql.Tx, i int) (*models.EnvVariable, error) {
+		op := ops[i]
+		switch op.Action {
+		case models.BatchActionCreate:
+			return createEnvVariable(tx, &models.EnvVariableCreate{
+				Name:        op.EnvVariable.Name,
+				Value:       op.EnvVariable.Value,
+				Description: op.EnvVariable.Description,
+				Group:       op.EnvVariable.Group,
+			})
+		case models.BatchActionUpdate:
+			return updateEnvVariable(tx, op.ID, &op.EnvVariable)
+		case models.BatchActionDelete:
+			return nil, deleteEnvVariable(tx, op.ID)
+		default:
+			return nil, fmt.Errorf("unknown action %q", op.Action)
+		}
+	})
+}
+
 // GetAllAsMap returns all environment variables as a map for command execution
 func (r *EnvVariableRepository) GetAllAsMap() (map[string]string, error) {
 	envVars, err := r.GetAll()
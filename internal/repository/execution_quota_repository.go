@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// executionQuotaColumns lists the columns read by scanExecutionQuota
+const executionQuotaColumns = `id, principal, executions_per_hour, max_concurrent, max_timeout_seconds, created_at, updated_at`
+
+// ExecutionQuotaRepository handles database operations for execution quotas
+type ExecutionQuotaRepository struct {
+	db *database.DB
+}
+
+// NewExecutionQuotaRepository creates a new execution quota repository
+func NewExecutionQuotaRepository(db *database.DB) *ExecutionQuotaRepository {
+	return &ExecutionQuotaRepository{db: db}
+}
+
+// Create inserts a new execution quota
+func (r *ExecutionQuotaRepository) Create(create *models.ExecutionQuotaCreate) (*models.ExecutionQuota, error) {
+	now := time.Now().UTC()
+	quota := &models.ExecutionQuota{
+		Principal:         strings.TrimSpace(create.Principal),
+		ExecutionsPerHour: create.ExecutionsPerHour,
+		MaxConcurrent:     create.MaxConcurrent,
+		MaxTimeoutSeconds: create.MaxTimeoutSeconds,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := validateExecutionQuota(quota); err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO execution_quotas (principal, executions_per_hour, max_concurrent, max_timeout_seconds, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		quota.Principal,
+		quota.ExecutionsPerHour,
+		quota.MaxConcurrent,
+		quota.MaxTimeoutSeconds,
+		quota.CreatedAt,
+		quota.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a quota for %q already exists", quota.Principal)
+		}
+		return nil, fmt.Errorf("failed to create execution quota: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	quota.ID = id
+
+	return quota, nil
+}
+
+// GetByID retrieves an execution quota by its ID
+func (r *ExecutionQuotaRepository) GetByID(id int64) (*models.ExecutionQuota, error) {
+	row := r.db.GetConnection().QueryRow("SELECT "+executionQuotaColumns+" FROM execution_quotas WHERE id = ?", id)
+
+	quota, err := scanExecutionQuota(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("execution quota not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution quota: %w", err)
+	}
+
+	return quota, nil
+}
+
+// GetAll retrieves all execution quotas
+func (r *ExecutionQuotaRepository) GetAll() ([]*models.ExecutionQuota, error) {
+	rows, err := r.db.GetConnection().Query("SELECT " + executionQuotaColumns + " FROM execution_quotas ORDER BY principal ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query execution quotas: %w", err)
+	}
+	defer rows.Close()
+
+	var quotas []*models.ExecutionQuota
+	for rows.Next() {
+		quota, err := scanExecutionQuota(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan execution quota: %w", err)
+		}
+		quotas = append(quotas, quota)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating execution quotas: %w", err)
+	}
+
+	return quotas, nil
+}
+
+// Resolve returns the quota applying to a caller with the identities, or nil when none applies
+// Identities are tried in order, then the "*" quota; see models.ExecutionQuota
+func (r *ExecutionQuotaRepository) Resolve(identities []string) (*models.ExecutionQuota, error) {
+	quotas, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	byPrincipal := make(map[string]*models.ExecutionQuota, len(quotas))
+	for _, quota := range quotas {
+		byPrincipal[quota.Principal] = quota
+	}
+	for _, identity := range identities {
+		if quota, ok := byPrincipal[identity]; ok {
+			return quota, nil
+		}
+	}
+	return byPrincipal[models.ExecutionQuotaDefault], nil
+}
+
+// Update updates the limits of an execution quota
+func (r *ExecutionQuotaRepository) Update(id int64, update *models.ExecutionQuotaUpdate) (*models.ExecutionQuota, error) {
+	existing, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.ExecutionsPerHour != nil {
+		existing.ExecutionsPerHour = *update.ExecutionsPerHour
+	}
+	if update.MaxConcurrent != nil {
+		existing.MaxConcurrent = *update.MaxConcurrent
+	}
+	if update.MaxTimeoutSeconds != nil {
+		existing.MaxTimeoutSeconds = *update.MaxTimeoutSeconds
+	}
+	if err := validateExecutionQuota(existing); err != nil {
+		return nil, err
+	}
+
+	existing.UpdatedAt = time.Now().UTC()
+
+	_, err = r.db.GetConnection().Exec(
+		"UPDATE execution_quotas SET executions_per_hour = ?, max_concurrent = ?, max_timeout_seconds = ?, updated_at = ? WHERE id = ?",
+		existing.ExecutionsPerHour,
+		existing.MaxConcurrent,
+		existing.MaxTimeoutSeconds,
+		existing.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update execution quota: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete deletes an execution quota by its ID
+func (r *ExecutionQuotaRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM execution_quotas WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete execution quota: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("execution quota not found")
+	}
+
+	return nil
+}
+
+// scanExecutionQuota reads an execution quota from a query result
+func scanExecutionQuota(row rowScanner) (*models.ExecutionQuota, error) {
+	var quota models.ExecutionQuota
+	if err := row.Scan(&quota.ID, &quota.Principal, &quota.ExecutionsPerHour, &quota.MaxConcurrent, &quota.MaxTimeoutSeconds, &quota.CreatedAt, &quota.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// validateExecutionQuota checks the principal and limits of a quota
+func validateExecutionQuota(quota *models.ExecutionQuota) error {
+	if quota.Principal != models.ExecutionQuotaDefault {
+		valid := false
+		for _, kind := range principalKinds {
+			if strings.HasPrefix(quota.Principal, kind) && len(quota.Principal) > len(kind) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid principal %q (expected user:<name>, token:<name>, role:<role> or *)", quota.Principal)
+		}
+	}
+
+	if quota.ExecutionsPerHour < 0 || quota.MaxConcurrent < 0 || quota.MaxTimeoutSeconds < 0 {
+		return fmt.Errorf("limits cannot be negative")
+	}
+	return nil
+}
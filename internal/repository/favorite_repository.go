@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// favoriteColumns lists the columns read by scanFavorite
+const favoriteColumns = `id, resource_type, resource_id, created_at`
+
+// FavoriteRepository handles database operations for the favorites of users and API tokens
+type FavoriteRepository struct {
+	db *database.DB
+}
+
+// NewFavoriteRepository creates a new favorite repository
+func NewFavoriteRepository(db *database.DB) *FavoriteRepository {
+	return &FavoriteRepository{db: db}
+}
+
+// Add marks an item as a favorite of a principal; adding an existing favorite returns it
+func (r *FavoriteRepository) Add(principal, resourceType string, resourceID int64) (*models.Favorite, error) {
+	_, err := r.db.GetConnection().Exec(
+		"INSERT OR IGNORE INTO favorites (principal, resource_type, resource_id, created_at) VALUES (?, ?, ?, ?)",
+		principal, resourceType, resourceID, time.Now().UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add favorite: %w", err)
+	}
+
+	row := r.db.GetConnection().QueryRow(
+		"SELECT "+favoriteColumns+" FROM favorites WHERE principal = ? AND resource_type = ? AND resource_id = ?",
+		principal, resourceType, resourceID,
+	)
+	favorite, err := scanFavorite(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorite: %w", err)
+	}
+	return favorite, nil
+}
+
+// GetByPrincipal retrieves the favorites of a principal, newest first
+func (r *FavoriteRepository) GetByPrincipal(principal string) ([]*models.Favorite, error) {
+	rows, err := r.db.GetConnection().Query(
+		"SELECT "+favoriteColumns+" FROM favorites WHERE principal = ? ORDER BY created_at DESC, id DESC",
+		principal,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query favorites: %w", err)
+	}
+	defer rows.Close()
+
+	var favorites []*models.Favorite
+	for rows.Next() {
+		favorite, err := scanFavorite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan favorite: %w", err)
+		}
+		favorites = append(favorites, favorite)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating favorites: %w", err)
+	}
+
+	return favorites, nil
+}
+
+// Delete removes an item from the favorites of a principal
+func (r *FavoriteRepository) Delete(principal, resourceType string, resourceID int64) error {
+	result, err := r.db.GetConnection().Exec(
+		"DELETE FROM favorites WHERE principal = ? AND resource_type = ? AND resource_id = ?",
+		principal, resourceType, resourceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete favorite: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("favorite not found")
+	}
+
+	return nil
+}
+
+// scanFavorite reads a favorite from a query result
+func scanFavorite(row rowScanner) (*models.Favorite, error) {
+	var favorite models.Favorite
+	if err := row.Scan(&favorite.ID, &favorite.ResourceType, &favorite.ResourceID, &favorite.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &favorite, nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// roleNamePattern restricts role names to characters that are safe in comma-separated storage
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// principalKinds are the valid prefixes of a group permission principal
+var principalKinds = []string{"user:", "token:", "role:"}
+
+// GroupPermissionRepository handles database operations for group permissions
+type GroupPermissionRepository struct {
+	db *database.DB
+}
+
+// NewGroupPermissionRepository creates a new group permission repository
+func NewGroupPermissionRepository(db *database.DB) *GroupPermissionRepository {
+	return &GroupPermissionRepository{db: db}
+}
+
+// Create grants a permission on a resource group
+// Granting a principal that already has a permission on the group replaces it
+func (r *GroupPermissionRepository) Create(create *models.GroupPermissionCreate) (*models.GroupPermission, error) {
+	// A server group ID stands for the group's name
+	if create.GroupID != nil {
+		resolved := *create
+		if resolved.ResourceType == "" {
+			resolved.ResourceType = models.ResourceTypeServers
+		}
+		if resolved.ResourceType != models.ResourceTypeServers {
+			return nil, fmt.Errorf("group_id only applies to the %s resource type", models.ResourceTypeServers)
+		}
+		group, err := NewServerGroupRepository(r.db).GetByID(*create.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("server group %d not found", *create.GroupID)
+		}
+		resolved.Group = group.Name
+		create = &resolved
+	}
+
+	permission, err := normalizeGroupPermission(create)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.db.GetConnection().Exec(
+		`INSERT INTO group_permissions (resource_type, group_name, principal, permission, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(resource_type, group_name, principal) DO UPDATE SET permission = excluded.permission`,
+		permission.ResourceType,
+		permission.Group,
+		permission.Principal,
+		permission.Permission,
+		permission.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group permission: %w", err)
+	}
+
+	// Look up the ID since an upsert does not report it reliably
+	err = r.db.GetConnection().QueryRow(
+		"SELECT id, created_at FROM group_permissions WHERE resource_type = ? AND group_name = ? AND principal = ?",
+		permission.ResourceType,
+		permission.Group,
+		permission.Principal,
+	).Scan(&permission.ID, &permission.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group permission: %w", err)
+	}
+
+	return permission, nil
+}
+
+// GetAll retrieves all group permissions
+func (r *GroupPermissionRepository) GetAll() ([]*models.GroupPermission, error) {
+	rows, err := r.db.GetConnection().Query(
+		`SELECT id, resource_type, group_name, principal, permission, created_at
+		FROM group_permissions ORDER BY resource_type ASC, group_name ASC, principal ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group permissions: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []*models.GroupPermission
+	for rows.Next() {
+		var p models.GroupPermission
+		if err := rows.Scan(&p.ID, &p.ResourceType, &p.Group, &p.Principal, &p.Permission, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group permission: %w", err)
+		}
+		permissions = append(permissions, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// Delete revokes a group permission by its ID
+func (r *GroupPermissionRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM group_permissions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group permission: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("group permission not found")
+	}
+
+	return nil
+}
+
+// LoadACL returns the current group permissions as an ACL for access checks
+func (r *GroupPermissionRepository) LoadACL() (*models.GroupACL, error) {
+	permissions, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return models.NewGroupACL(permissions), nil
+}
+
+// normalizeGroupPermission validates a permission grant and fills in defaults
+func normalizeGroupPermission(create *models.GroupPermissionCreate) (*models.GroupPermission, error) {
+	resourceType := strings.TrimSpace(create.ResourceType)
+	valid := false
+	for _, t := range models.ResourceTypes {
+		if resourceType == t {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid resource type %q (valid types: %s)", resourceType, strings.Join(models.ResourceTypes, ", "))
+	}
+
+	group := strings.TrimSpace(create.Group)
+	if group == "" {
+		group = "default"
+	}
+
+	principal := strings.TrimSpace(create.Principal)
+	kindValid := false
+	for _, kind := range principalKinds {
+		if strings.HasPrefix(principal, kind) && len(principal) > len(kind) {
+			kindValid = true
+			break
+		}
+	}
+	if !kindValid {
+		return nil, fmt.Errorf("invalid principal %q (expected user:<name>, token:<name> or role:<role>)", principal)
+	}
+
+	permission := strings.ToLower(strings.TrimSpace(create.Permission))
+	if permission == "" {
+		permission = models.PermissionView
+	}
+	if permission != models.PermissionView && permission != models.PermissionExecute {
+		return nil, fmt.Errorf("invalid permission %q (valid permissions: %s, %s)", permission, models.PermissionView, models.PermissionExecute)
+	}
+
+	return &models.GroupPermission{
+		ResourceType: resourceType,
+		Group:        group,
+		Principal:    principal,
+		Permission:   permission,
+		CreatedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// normalizeRoles validates role names and removes duplicates
+func normalizeRoles(roles []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		if !roleNamePattern.MatchString(role) {
+			return nil, fmt.Errorf("invalid role %q (use letters, digits, '.', '_' or '-')", role)
+		}
+		if !seen[role] {
+			seen[role] = true
+			normalized = append(normalized, role)
+		}
+	}
+	return normalized, nil
+}
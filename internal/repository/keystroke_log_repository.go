@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// KeystrokeLogRepository handles database operations for terminal keystroke logs
+// Events are stored encrypted, in chunks appended while the session runs
+type KeystrokeLogRepository struct {
+	db *database.DB
+}
+
+// NewKeystrokeLogRepository creates a new keystroke log repository
+func NewKeystrokeLogRepository(db *database.DB) *KeystrokeLogRepository {
+	return &KeystrokeLogRepository{db: db}
+}
+
+// Create starts a keystroke log for a terminal session
+func (r *KeystrokeLogRepository) Create(sessionID, owner, server string) (*models.KeystrokeLog, error) {
+	log := &models.KeystrokeLog{
+		SessionID: sessionID,
+		Owner:     owner,
+		Server:    server,
+		StartedAt: time.Now().UTC(),
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		"INSERT INTO terminal_keystroke_logs (session_id, owner, server, started_at) VALUES (?, ?, ?, ?)",
+		log.SessionID, log.Owner, log.Server, log.StartedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keystroke log: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	log.ID = id
+
+	return log, nil
+}
+
+// AppendEvents encrypts and stores a chunk of events
+func (r *KeystrokeLogRepository) AppendEvents(logID int64, events []models.KeystrokeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode keystrokes: %w", err)
+	}
+	encrypted, err := database.Encrypt(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt keystrokes: %w", err)
+	}
+
+	_, err = r.db.GetConnection().Exec(
+		"INSERT INTO terminal_keystroke_chunks (log_id, events, created_at) VALUES (?, ?, ?)",
+		logID, encrypted, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store keystrokes: %w", err)
+	}
+	return nil
+}
+
+// End records that the session has ended
+func (r *KeystrokeLogRepository) End(logID int64) error {
+	_, err := r.db.GetConnection().Exec(
+		"UPDATE terminal_keystroke_logs SET ended_at = ? WHERE id = ?",
+		time.Now().UTC(), logID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to end keystroke log: %w", err)
+	}
+	return nil
+}
+
+// GetAll retrieves all keystroke logs without their events, newest first
+func (r *KeystrokeLogRepository) GetAll() ([]*models.KeystrokeLog, error) {
+	rows, err := r.db.GetConnection().Query(
+		"SELECT id, session_id, owner, server, started_at, ended_at FROM terminal_keystroke_logs ORDER BY started_at DESC, id DESC",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query keystroke logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []*models.KeystrokeLog{}
+	for rows.Next() {
+		log, err := scanKeystrokeLog(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan keystroke log: %w", err)
+		}
+		logs = append(logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating keystroke logs: %w", err)
+	}
+
+	return logs, nil
+}
+
+// GetByID retrieves a keystroke log with its decrypted events
+func (r *KeystrokeLogRepository) GetByID(id int64) (*models.KeystrokeLog, error) {
+	row := r.db.GetConnection().QueryRow(
+		"SELECT id, session_id, owner, server, started_at, ended_at FROM terminal_keystroke_logs WHERE id = ?", id,
+	)
+	log, err := scanKeystrokeLog(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("keystroke log not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keystroke log: %w", err)
+	}
+
+	rows, err := r.db.GetConnection().Query(
+		"SELECT events FROM terminal_keystroke_chunks WHERE log_id = ? ORDER BY id", id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query keystrokes: %w", err)
+	}
+	defer rows.Close()
+
+	log.Events = []models.KeystrokeEvent{}
+	for rows.Next() {
+		var encrypted []byte
+		if err := rows.Scan(&encrypted); err != nil {
+			return nil, fmt.Errorf("failed to scan keystrokes: %w", err)
+		}
+		data, err := database.Decrypt(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt keystrokes: %w", err)
+		}
+		var events []models.KeystrokeEvent
+		if err := json.Unmarshal([]byte(data), &events); err != nil {
+			return nil, fmt.Errorf("failed to decode keystrokes: %w", err)
+		}
+		log.Events = append(log.Events, events...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating keystrokes: %w", err)
+	}
+
+	return log, nil
+}
+
+// scanKeystrokeLog reads a keystroke log row without its events
+func scanKeystrokeLog(row rowScanner) (*models.KeystrokeLog, error) {
+	log := &models.KeystrokeLog{}
+	var endedAt sql.NullTime
+	if err := row.Scan(&log.ID, &log.SessionID, &log.Owner, &log.Server, &log.StartedAt, &endedAt); err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		log.EndedAt = &endedAt.Time
+	}
+	return log, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// ErrInvalidSort is returned for a sort field a list does not support
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// listQuery builds a filtered, sorted and paged query of one table
+type listQuery struct {
+	table string
+	where []string
+	args  []any
+}
+
+func newListQuery(table string) *listQuery {
+	return &listQuery{table: table}
+}
+
+// and adds a condition the rows must meet
+func (q *listQuery) and(condition string, args ...any) {
+	q.where = append(q.where, condition)
+	q.args = append(q.args, args...)
+}
+
+// groups restricts the rows to the filter's group and visible groups
+func (q *listQuery) groups(filter models.ListFilter) {
+	if filter.Group != "" {
+		q.and("group_name = ?", filter.Group)
+	}
+	if filter.Groups == nil {
+		return
+	}
+	if len(filter.Groups) == 0 {
+		q.and("0 = 1")
+		return
+	}
+	placeholders := strings.Repeat("?, ", len(filter.Groups))
+	args := make([]any, len(filter.Groups))
+	for i, group := range filter.Groups {
+		args[i] = group
+	}
+	q.and("group_name IN ("+placeholders[:len(placeholders)-2]+")", args...)
+}
+
+// text matches the filter's query case-insensitively against plaintext columns
+func (q *listQuery) text(filter models.ListFilter, columns ...string) {
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	if query == "" {
+		return
+	}
+	conditions := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		conditions[i] = "instr(lower(" + column + "), ?) > 0"
+		args[i] = query
+	}
+	q.and("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// tag restricts the rows to those whose JSON tags column holds the filter's tag
+func (q *listQuery) tag(filter models.ListFilter) {
+	if filter.Tag != "" {
+		q.and("EXISTS (SELECT 1 FROM json_each("+q.table+".tags) WHERE json_each.value = ?)", models.NormalizeTag(filter.Tag))
+	}
+}
+
+func (q *listQuery) condition() string {
+	if len(q.where) == 0 {
+		return "1 = 1"
+	}
+	return strings.Join(q.where, " AND ")
+}
+
+// count returns the number of matching rows
+func (q *listQuery) count(db *database.DB) (int, error) {
+	var total int
+	err := db.GetConnection().QueryRow("SELECT COUNT(*) FROM "+q.table+" WHERE "+q.condition(), q.args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", q.table, err)
+	}
+	return total, nil
+}
+
+// orderAndPage returns the ORDER BY and LIMIT clauses for the filter
+// sortColumns maps the fields the list can be sorted by to their columns
+func orderAndPage(filter models.ListFilter, sortColumns map[string]string, defaultOrder string) (string, error) {
+	order := defaultOrder
+	if filter.Sort != "" {
+		column, ok := sortColumns[filter.Sort]
+		if !ok {
+			return "", fmt.Errorf("%w %q", ErrInvalidSort, filter.Sort)
+		}
+		direction := "ASC"
+		if filter.Desc {
+			direction = "DESC"
+		}
+		// The ID keeps pages stable when values repeat
+		order = column + " " + direction + ", id " + direction
+	}
+
+	clause := " ORDER BY " + order
+	if filter.Limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	}
+	return clause, nil
+}
+
+// selectSQL returns the query selecting columns of the matching rows, sorted and paged
+func (q *listQuery) selectSQL(columns string, filter models.ListFilter, sortColumns map[string]string, defaultOrder string) (string, error) {
+	clause, err := orderAndPage(filter, sortColumns, defaultOrder)
+	if err != nil {
+		return "", err
+	}
+	return "SELECT " + columns + " FROM " + q.table + " WHERE " + q.condition() + clause, nil
+}
+
+// runList loads the page selected by query with load, and counts every match
+// The count is skipped when the list is not paged
+func runList[T any](db *database.DB, q *listQuery, filter models.ListFilter, query string, load func(query string, args ...any) ([]T, error)) ([]T, int, error) {
+	items, err := load(query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit <= 0 {
+		return items, len(items), nil
+	}
+	total, err := q.count(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
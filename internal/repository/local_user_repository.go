@@ -70,6 +70,25 @@ func (r *LocalUserRepository) GetByID(id int64) (*models.LocalUser, error) {
 	return &user, nil
 }
 
+// GetByName retrieves a local user by its name
+func (r *LocalUserRepository) GetByName(name string) (*models.LocalUser, error) {
+	var user models.LocalUser
+
+	err := r.db.GetConnection().QueryRow(
+		"SELECT id, name, created_at, updated_at FROM local_users WHERE name = ?",
+		name,
+	).Scan(&user.ID, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("local user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local user: %w", err)
+	}
+
+	return &user, nil
+}
+
 // GetAll retrieves all local users
 func (r *LocalUserRepository) GetAll() ([]*models.LocalUser, error) {
 	rows, err := r.db.GetConnection().Query(
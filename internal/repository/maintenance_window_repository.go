@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// maxMaintenanceWindowMinutes bounds how long a recurring maintenance window lasts: a week
+const maxMaintenanceWindowMinutes = 7 * 24 * 60
+
+// maintenanceWindowColumns lists the columns read by scanMaintenanceWindow
+const maintenanceWindowColumns = `id, name, description, server_group, schedule, duration_minutes, starts_at, ends_at, enabled, created_at, updated_at`
+
+// MaintenanceWindowRepository handles database operations for maintenance windows
+type MaintenanceWindowRepository struct {
+	db *database.DB
+}
+
+// NewMaintenanceWindowRepository creates a new maintenance window repository
+func NewMaintenanceWindowRepository(db *database.DB) *MaintenanceWindowRepository {
+	return &MaintenanceWindowRepository{db: db}
+}
+
+// Create inserts a new maintenance window
+func (r *MaintenanceWindowRepository) Create(create *models.MaintenanceWindowCreate) (*models.MaintenanceWindow, error) {
+	now := time.Now().UTC()
+	window := &models.MaintenanceWindow{
+		Name:            create.Name,
+		Description:     create.Description,
+		ServerGroup:     create.ServerGroup,
+		Schedule:        create.Schedule,
+		DurationMinutes: create.DurationMinutes,
+		StartsAt:        create.StartsAt,
+		EndsAt:          create.EndsAt,
+		Enabled:         create.Enabled == nil || *create.Enabled,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := r.normalize(window); err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO maintenance_windows (name, description, server_group, schedule, duration_minutes, starts_at, ends_at, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		window.Name,
+		window.Description,
+		window.ServerGroup,
+		window.Schedule,
+		window.DurationMinutes,
+		window.StartsAt,
+		window.EndsAt,
+		boolToInt(window.Enabled),
+		window.CreatedAt,
+		window.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a maintenance window named %q already exists", window.Name)
+		}
+		return nil, fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	window.ID = id
+
+	return window, nil
+}
+
+// GetByID retrieves a maintenance window by its ID
+func (r *MaintenanceWindowRepository) GetByID(id int64) (*models.MaintenanceWindow, error) {
+	row := r.db.GetConnection().QueryRow("SELECT "+maintenanceWindowColumns+" FROM maintenance_windows WHERE id = ?", id)
+
+	window, err := scanMaintenanceWindow(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("maintenance window not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+
+	return window, nil
+}
+
+// GetAll retrieves all maintenance windows
+func (r *MaintenanceWindowRepository) GetAll() ([]*models.MaintenanceWindow, error) {
+	return r.query("SELECT " + maintenanceWindowColumns + " FROM maintenance_windows ORDER BY name ASC")
+}
+
+// GetEnabled retrieves the maintenance windows that can block executions
+func (r *MaintenanceWindowRepository) GetEnabled() ([]*models.MaintenanceWindow, error) {
+	return r.query("SELECT " + maintenanceWindowColumns + " FROM maintenance_windows WHERE enabled = 1 ORDER BY name ASC")
+}
+
+// Update updates an existing maintenance window
+func (r *MaintenanceWindowRepository) Update(id int64, update *models.MaintenanceWindowUpdate) (*models.MaintenanceWindow, error) {
+	existing, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != "" {
+		existing.Name = update.Name
+	}
+	if update.Description != nil {
+		existing.Description = *update.Description
+	}
+	if update.ServerGroup != "" {
+		existing.ServerGroup = update.ServerGroup
+	}
+	if update.Schedule != nil {
+		existing.Schedule = *update.Schedule
+		if existing.Schedule != "" {
+			existing.StartsAt, existing.EndsAt = nil, nil
+		}
+	}
+	if update.DurationMinutes != nil {
+		existing.DurationMinutes = *update.DurationMinutes
+	}
+	if update.StartsAt != nil || update.EndsAt != nil {
+		existing.Schedule, existing.DurationMinutes = "", 0
+		if update.StartsAt != nil {
+			existing.StartsAt = update.StartsAt
+		}
+		if update.EndsAt != nil {
+			existing.EndsAt = update.EndsAt
+		}
+	}
+	if update.Enabled != nil {
+		existing.Enabled = *update.Enabled
+	}
+	if err := r.normalize(existing); err != nil {
+		return nil, err
+	}
+
+	existing.UpdatedAt = time.Now().UTC()
+
+	_, err = r.db.GetConnection().Exec(
+		`UPDATE maintenance_windows SET name = ?, description = ?, server_group = ?, schedule = ?, duration_minutes = ?, starts_at = ?, ends_at = ?, enabled = ?, updated_at = ?
+		WHERE id = ?`,
+		existing.Name,
+		existing.Description,
+		existing.ServerGroup,
+		existing.Schedule,
+		existing.DurationMinutes,
+		existing.StartsAt,
+		existing.EndsAt,
+		boolToInt(existing.Enabled),
+		existing.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a maintenance window named %q already exists", existing.Name)
+		}
+		return nil, fmt.Errorf("failed to update maintenance window: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete deletes a maintenance window by its ID
+func (r *MaintenanceWindowRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM maintenance_windows WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+
+	return nil
+}
+
+// query runs a maintenance window query and scans the results
+func (r *MaintenanceWindowRepository) query(query string) ([]*models.MaintenanceWindow, error) {
+	rows, err := r.db.GetConnection().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []*models.MaintenanceWindow
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, window)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating maintenance windows: %w", err)
+	}
+
+	return windows, nil
+}
+
+// normalize validates a maintenance window and checks that its server group exists
+func (r *MaintenanceWindowRepository) normalize(window *models.MaintenanceWindow) error {
+	window.Name = strings.TrimSpace(window.Name)
+	if window.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	window.ServerGroup = strings.TrimSpace(window.ServerGroup)
+	switch window.ServerGroup {
+	case "":
+		return fmt.Errorf("server_group is required")
+	case models.MaintenanceWindowAllServers:
+	default:
+		var count int
+		if err := r.db.GetConnection().QueryRow("SELECT COUNT(*) FROM server_groups WHERE name = ?", window.ServerGroup).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check server group: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("server group %q not found", window.ServerGroup)
+		}
+	}
+
+	window.Schedule = strings.TrimSpace(window.Schedule)
+	if window.Schedule != "" {
+		if window.StartsAt != nil || window.EndsAt != nil {
+			return fmt.Errorf("set either schedule and duration_minutes or starts_at and ends_at, not both")
+		}
+		if err := validation.ValidateSchedule(window.Schedule, true); err != nil {
+			return err
+		}
+		if window.DurationMinutes < 1 || window.DurationMinutes > maxMaintenanceWindowMinutes {
+			return fmt.Errorf("duration_minutes must be between 1 and %d", maxMaintenanceWindowMinutes)
+		}
+		return nil
+	}
+
+	if window.StartsAt == nil || window.EndsAt == nil {
+		return fmt.Errorf("schedule and duration_minutes, or starts_at and ends_at, are required")
+	}
+	if !window.EndsAt.After(*window.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	startsAt, endsAt := window.StartsAt.UTC(), window.EndsAt.UTC()
+	window.StartsAt, window.EndsAt = &startsAt, &endsAt
+	window.DurationMinutes = 0
+	return nil
+}
+
+// scanMaintenanceWindow reads a maintenance window from a query result
+func scanMaintenanceWindow(row rowScanner) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	var startsAt, endsAt sql.NullTime
+	var enabled int
+	if err := row.Scan(&window.ID, &window.Name, &window.Description, &window.ServerGroup, &window.Schedule, &window.DurationMinutes,
+		&startsAt, &endsAt, &enabled, &window.CreatedAt, &window.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if startsAt.Valid {
+		window.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		window.EndsAt = &endsAt.Time
+	}
+	window.Enabled = enabled == 1
+	return &window, nil
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+)
+
+// notificationRuleColumns lists the columns read by scanNotificationRule
+const notificationRuleColumns = `id, name, description, kind, script, server_group, outcome, exit_codes, min_duration_ms, channels, enabled, created_at, updated_at`
+
+// NotificationRuleRepository handles database operations for notification rules
+type NotificationRuleRepository struct {
+	db *database.DB
+}
+
+// NewNotificationRuleRepository creates a new notification rule repository
+func NewNotificationRuleRepository(db *database.DB) *NotificationRuleRepository {
+	return &NotificationRuleRepository{db: db}
+}
+
+// Create inserts a new notification rule
+func (r *NotificationRuleRepository) Create(create *models.NotificationRuleCreate) (*models.NotificationRule, error) {
+	now := time.Now().UTC()
+	rule := &models.NotificationRule{
+		Name:          create.Name,
+		Description:   create.Description,
+		Kind:          create.Kind,
+		Script:        create.Script,
+		ServerGroup:   create.ServerGroup,
+		Outcome:       create.Outcome,
+		ExitCodes:     create.ExitCodes,
+		MinDurationMs: create.MinDurationMs,
+		Channels:      create.Channels,
+		Enabled:       create.Enabled == nil || *create.Enabled,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := r.normalize(rule); err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO notification_rules (name, description, kind, script, server_group, outcome, exit_codes, min_duration_ms, channels, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rule.Name,
+		rule.Description,
+		rule.Kind,
+		rule.Script,
+		rule.ServerGroup,
+		rule.Outcome,
+		encodeJSONList(rule.ExitCodes),
+		rule.MinDurationMs,
+		encodeJSONList(rule.Channels),
+		boolToInt(rule.Enabled),
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a notification rule named %q already exists", rule.Name)
+		}
+		return nil, fmt.Errorf("failed to create notification rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	rule.ID = id
+
+	return rule, nil
+}
+
+// GetByID retrieves a notification rule by its ID
+func (r *NotificationRuleRepository) GetByID(id int64) (*models.NotificationRule, error) {
+	row := r.db.GetConnection().QueryRow("SELECT "+notificationRuleColumns+" FROM notification_rules WHERE id = ?", id)
+
+	rule, err := scanNotificationRule(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("notification rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetAll retrieves all notification rules
+func (r *NotificationRuleRepository) GetAll() ([]*models.NotificationRule, error) {
+	return r.query("SELECT " + notificationRuleColumns + " FROM notification_rules ORDER BY name ASC")
+}
+
+// GetEnabled retrieves the notification rules evaluated after executions
+func (r *NotificationRuleRepository) GetEnabled() ([]*models.NotificationRule, error) {
+	return r.query("SELECT " + notificationRuleColumns + " FROM notification_rules WHERE enabled = 1 ORDER BY id ASC")
+}
+
+// Update updates an existing notification rule
+func (r *NotificationRuleRepository) Update(id int64, update *models.NotificationRuleUpdate) (*models.NotificationRule, error) {
+	existing, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != "" {
+		existing.Name = update.Name
+	}
+	if update.Description != nil {
+		existing.Description = *update.Description
+	}
+	if update.Kind != nil {
+		existing.Kind = *update.Kind
+	}
+	if update.Script != nil {
+		existing.Script = *update.Script
+	}
+	if update.ServerGroup != nil {
+		existing.ServerGroup = *update.ServerGroup
+	}
+	if update.Outcome != "" {
+		existing.Outcome = update.Outcome
+	}
+	if update.ExitCodes != nil {
+		existing.ExitCodes = update.ExitCodes
+	}
+	if update.MinDurationMs != nil {
+		existing.MinDurationMs = *update.MinDurationMs
+	}
+	if update.Channels != nil {
+		existing.Channels = update.Channels
+	}
+	if update.Enabled != nil {
+		existing.Enabled = *update.Enabled
+	}
+	if err := r.normalize(existing); err != nil {
+		return nil, err
+	}
+
+	existing.UpdatedAt = time.Now().UTC()
+
+	_, err = r.db.GetConnection().Exec(
+		`UPDATE notification_rules SET name = ?, description = ?, kind = ?, script = ?, server_group = ?, outcome = ?, exit_codes = ?, min_duration_ms = ?, channels = ?, enabled = ?, updated_at = ?
+		WHERE id = ?`,
+		existing.Name,
+		existing.Description,
+		existing.Kind,
+		existing.Script,
+		existing.ServerGroup,
+		existing.Outcome,
+		encodeJSONList(existing.ExitCodes),
+		existing.MinDurationMs,
+		encodeJSONList(existing.Channels),
+		boolToInt(existing.Enabled),
+		existing.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a notification rule named %q already exists", existing.Name)
+		}
+		return nil, fmt.Errorf("failed to update notification rule: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete deletes a notification rule by its ID
+func (r *NotificationRuleRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM notification_rules WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("notification rule not found")
+	}
+
+	return nil
+}
+
+// query runs a notification rule query and scans the results
+func (r *NotificationRuleRepository) query(query string) ([]*models.NotificationRule, error) {
+	rows, err := r.db.GetConnection().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*models.NotificationRule
+	for rows.Next() {
+		rule, err := scanNotificationRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// normalize validates a notification rule, applies defaults and checks that its webhooks exist
+func (r *NotificationRuleRepository) normalize(rule *models.NotificationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch rule.Kind {
+	case "", notify.KindCommand, notify.KindScript:
+	default:
+		return fmt.Errorf("invalid kind %q (must be command, script or empty)", rule.Kind)
+	}
+
+	rule.Script = strings.TrimSpace(rule.Script)
+	if _, err := path.Match(rule.Script, ""); err != nil {
+		return fmt.Errorf("invalid script pattern %q", rule.Script)
+	}
+	rule.ServerGroup = strings.TrimSpace(rule.ServerGroup)
+
+	if rule.Outcome == "" {
+		rule.Outcome = models.RuleOutcomeFailure
+	}
+	switch rule.Outcome {
+	case models.RuleOutcomeAny, models.RuleOutcomeFailure, models.RuleOutcomeWarning, models.RuleOutcomeSuccess:
+	default:
+		return fmt.Errorf("invalid outcome %q (must be any, failure, warning or success)", rule.Outcome)
+	}
+
+	if rule.MinDurationMs < 0 {
+		return fmt.Errorf("min_duration_ms cannot be negative")
+	}
+	if rule.ExitCodes == nil {
+		rule.ExitCodes = []int{}
+	}
+
+	if len(rule.Channels) == 0 {
+		return fmt.Errorf("at least one channel is required")
+	}
+	channels := make([]string, 0, len(rule.Channels))
+	seen := make(map[string]bool)
+	for _, channel := range rule.Channels {
+		channel = strings.TrimSpace(channel)
+		if seen[channel] {
+			continue
+		}
+		kind, arg, err := notify.ParseChannel(channel)
+		if err != nil {
+			return err
+		}
+		if kind == notify.ChannelWebhook {
+			var count int
+			if err := r.db.GetConnection().QueryRow("SELECT COUNT(*) FROM webhooks WHERE name = ?", arg).Scan(&count); err != nil {
+				return fmt.Errorf("failed to check webhook: %w", err)
+			}
+			if count == 0 {
+				return fmt.Errorf("channel %q names an unknown webhook", channel)
+			}
+		}
+		seen[channel] = true
+		channels = append(channels, channel)
+	}
+	rule.Channels = channels
+
+	return nil
+}
+
+// scanNotificationRule reads a notification rule from a query result
+func scanNotificationRule(row rowScanner) (*models.NotificationRule, error) {
+	var rule models.NotificationRule
+	var exitCodes, channels string
+	var enabled int
+	if err := row.Scan(&rule.ID, &rule.Name, &rule.Description, &rule.Kind, &rule.Script, &rule.ServerGroup, &rule.Outcome,
+		&exitCodes, &rule.MinDurationMs, &channels, &enabled, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(exitCodes), &rule.ExitCodes); err != nil || rule.ExitCodes == nil {
+		rule.ExitCodes = []int{}
+	}
+	rule.Channels = decodeTags(channels)
+	rule.Enabled = enabled == 1
+	return &rule, nil
+}
+
+// encodeJSONList serializes a list to the JSON array stored in a column, storing [] for nil
+func encodeJSONList[T any](items []T) string {
+	data, err := json.Marshal(items)
+	if err != nil || items == nil {
+		return "[]"
+	}
+	return string(data)
+}
@@ -1,8 +1,12 @@
 package repository
 
 import (
+	"errors"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pozgo/web-cli/internal/database"
 	"github.com/pozgo/web-cli/internal/models"
@@ -96,6 +100,41 @@ func TestSSHKeyRepository(t *testing.T) {
 	}
 }
 
+func TestSSHKeyRepositoryRecordUse(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSSHKeyRepository(db)
+
+	created, err := repo.Create(&models.SSHKeyCreate{Name: "deploy-key", PrivateKey: "key-data"})
+	if err != nil {
+		t.Fatalf("Failed to create SSH key: %v", err)
+	}
+
+	// New keys have never been used
+	if created.LastUsedAt != nil || created.UseCount != 0 {
+		t.Errorf("New key should be unused, got last_used_at=%v use_count=%d", created.LastUsedAt, created.UseCount)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.RecordUse(created.ID); err != nil {
+			t.Fatalf("Failed to record SSH key use: %v", err)
+		}
+	}
+
+	keys, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get all SSH keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].UseCount != 2 || keys[0].LastUsedAt == nil {
+		t.Errorf("Expected key used twice with last_used_at set, got %+v", keys[0])
+	}
+
+	if err := repo.RecordUse(created.ID + 100); err == nil {
+		t.Error("Expected error recording use of a missing key")
+	}
+}
+
 func TestCommandHistoryRepository(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -168,6 +207,67 @@ func TestCommandHistoryRepository(t *testing.T) {
 	}
 }
 
+func TestCommandHistoryFilters(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewCommandHistoryRepository(db)
+	id := func(v int64) *int64 { return &v }
+	code := func(v int) *int { return &v }
+	entries := []*models.CommandHistoryCreate{
+		{Command: "[Script: backup] tar", Server: "local", User: "root", ExitCode: code(0), ScriptID: id(1), PresetID: id(7)},
+		{Command: "[Script: backup] tar", Server: "web-1", User: "deploy", ExitCode: code(2), ScriptID: id(1)},
+		{Command: "[Script: deploy] git pull", Server: "web-1", User: "deploy", ExitCode: code(0), ScriptID: id(2)},
+		{Command: "uptime", Server: "web-1", User: "root", ExitCode: code(0), SavedCommandID: id(3)},
+	}
+	hourAgo, inAnHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	for _, entry := range entries {
+		if _, err := repo.Create(entry); err != nil {
+			t.Fatalf("Failed to create command history: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter models.CommandHistoryFilter
+		count  int
+	}{
+		{"all", models.CommandHistoryFilter{}, 4},
+		{"script", models.CommandHistoryFilter{ScriptID: id(1)}, 2},
+		{"script on server", models.CommandHistoryFilter{ScriptID: id(1), Server: "web-1"}, 1},
+		{"preset", models.CommandHistoryFilter{PresetID: id(7)}, 1},
+		{"saved command", models.CommandHistoryFilter{SavedCommandID: id(3)}, 1},
+		{"limit", models.CommandHistoryFilter{Server: "web-1", Limit: 2}, 2},
+		{"no match", models.CommandHistoryFilter{ScriptID: id(99)}, 0},
+		{"exit code", models.CommandHistoryFilter{ExitCode: code(2)}, 1},
+		{"successes", models.CommandHistoryFilter{ExitCode: code(0)}, 3},
+		{"user", models.CommandHistoryFilter{User: "deploy"}, 2},
+		{"user and exit code", models.CommandHistoryFilter{User: "root", ExitCode: code(0)}, 2},
+		{"since", models.CommandHistoryFilter{Since: &hourAgo}, 4},
+		{"since later", models.CommandHistoryFilter{Since: &inAnHour}, 0},
+		{"until", models.CommandHistoryFilter{Until: &inAnHour}, 4},
+		{"until earlier", models.CommandHistoryFilter{Until: &hourAgo}, 0},
+		{"range", models.CommandHistoryFilter{Since: &hourAgo, Until: &inAnHour, Server: "local"}, 1},
+	}
+	for _, tt := range tests {
+		history, err := repo.GetFiltered(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(history) != tt.count {
+			t.Errorf("%s: expected %d entries, got %d", tt.name, tt.count, len(history))
+		}
+	}
+
+	history, err := repo.GetFiltered(models.CommandHistoryFilter{PresetID: id(7)})
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected the preset run, got %v, %v", history, err)
+	}
+	if entry := history[0]; entry.ScriptID == nil || *entry.ScriptID != 1 || *entry.PresetID != 7 || entry.SavedCommandID != nil {
+		t.Errorf("Expected the links of the run, got script %v preset %v saved command %v", entry.ScriptID, entry.PresetID, entry.SavedCommandID)
+	}
+}
+
 func TestEnvVariableRepository(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -294,6 +394,35 @@ func TestEnvVariableRepositoryDuplicateName(t *testing.T) {
 	}
 }
 
+func TestEnvVariableRepositoryCreateMany(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewEnvVariableRepository(db)
+
+	created, err := repo.CreateMany([]*models.EnvVariableCreate{
+		{Name: "BATCH_ONE", Value: "1", Group: "batch"},
+		{Name: "BATCH_TWO", Value: "2"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create env variables: %v", err)
+	}
+	if len(created) != 2 || created[0].ID == 0 || created[1].Group != "default" {
+		t.Errorf("Unexpected created variables: %+v", created)
+	}
+
+	// A duplicate name rolls back the whole batch
+	if _, err := repo.CreateMany([]*models.EnvVariableCreate{
+		{Name: "BATCH_THREE", Value: "3"},
+		{Name: "BATCH_ONE", Value: "again"},
+	}); err == nil {
+		t.Error("Expected error when creating duplicate env variable name")
+	}
+	if _, err := repo.GetByName("BATCH_THREE"); err == nil {
+		t.Error("Expected failed batch to be rolled back")
+	}
+}
+
 func TestBashScriptRepository(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -339,6 +468,10 @@ func TestBashScriptRepository(t *testing.T) {
 		t.Errorf("Filename mismatch: got %s, want %s", retrieved.Filename, scriptCreate.Filename)
 	}
 
+	if retrieved.Interpreter != models.ScriptInterpreterBash {
+		t.Errorf("Interpreter should default to bash, got %s", retrieved.Interpreter)
+	}
+
 	// Test GetByName
 	retrievedByName, err := repo.GetByName("Test Script")
 	if err != nil {
@@ -363,6 +496,7 @@ func TestBashScriptRepository(t *testing.T) {
 	update := &models.BashScriptUpdate{
 		Content:     "#!/bin/bash\nset -e\necho 'Updated Script'\nexit 0",
 		Description: "Updated description",
+		Interpreter: models.ScriptInterpreterSh,
 	}
 
 	updated, err := repo.Update(created.ID, update)
@@ -388,6 +522,10 @@ func TestBashScriptRepository(t *testing.T) {
 		t.Error("Updated content not persisted")
 	}
 
+	if retrieved.Interpreter != models.ScriptInterpreterSh {
+		t.Errorf("Updated interpreter not persisted: got %s", retrieved.Interpreter)
+	}
+
 	// Test Delete
 	if err := repo.Delete(created.ID); err != nil {
 		t.Fatalf("Failed to delete bash script: %v", err)
@@ -440,6 +578,287 @@ func TestBashScriptRepositoryWithoutOptionalFields(t *testing.T) {
 	}
 }
 
+func TestBashScriptVersions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewBashScriptRepository(db)
+
+	script, err := repo.Create(&models.BashScriptCreate{Name: "versioned", Content: "echo one"})
+	if err != nil {
+		t.Fatalf("Failed to create bash script: %v", err)
+	}
+
+	if _, err := repo.Update(script.ID, &models.BashScriptUpdate{Content: "echo two"}); err != nil {
+		t.Fatalf("Failed to update bash script: %v", err)
+	}
+
+	// Changing only the approval requirement does not add a version
+	requiresApproval := true
+	if _, err := repo.Update(script.ID, &models.BashScriptUpdate{RequiresApproval: &requiresApproval}); err != nil {
+		t.Fatalf("Failed to update bash script: %v", err)
+	}
+
+	versions, err := repo.GetVersions(script.ID)
+	if err != nil {
+		t.Fatalf("Failed to get versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].Version != 1 {
+		t.Fatalf("Expected versions 2 and 1, got %+v", versions)
+	}
+
+	first, err := repo.GetVersion(script.ID, 1)
+	if err != nil {
+		t.Fatalf("Failed to get version 1: %v", err)
+	}
+	if first.Content != "echo one" {
+		t.Errorf("Version 1 content mismatch: got %q", first.Content)
+	}
+
+	restored, err := repo.Rollback(script.ID, 1)
+	if err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	if restored.Content != "echo one" || !restored.RequiresApproval {
+		t.Errorf("Rollback should restore content and keep the approval requirement: %+v", restored)
+	}
+
+	latest, err := repo.GetLatestVersion(script.ID)
+	if err != nil {
+		t.Fatalf("Failed to get latest version: %v", err)
+	}
+	if latest.Version != 3 || latest.Content != "echo one" {
+		t.Errorf("Expected rollback recorded as version 3, got %d %q", latest.Version, latest.Content)
+	}
+
+	if _, err := repo.GetVersion(script.ID, 9); err == nil {
+		t.Error("Expected error for unknown version")
+	}
+
+	// Versions are removed with their script
+	if err := repo.Delete(script.ID); err != nil {
+		t.Fatalf("Failed to delete bash script: %v", err)
+	}
+	versions, err = repo.GetVersions(script.ID)
+	if err != nil {
+		t.Fatalf("Failed to get versions: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("Expected versions to be deleted with the script, got %d", len(versions))
+	}
+}
+
+func TestBashScriptSearch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewBashScriptRepository(db)
+
+	for _, create := range []*models.BashScriptCreate{
+		{Name: "deploy", Content: "rsync -a build/ web:/srv", Group: "ops", Tags: []string{"Deploy", "web", "deploy"}},
+		{Name: "backup", Content: "pg_dump app > app.sql", Description: "Nightly database dump", Tags: []string{"db"}},
+		{Name: "cleanup", Content: "rm -rf /tmp/build"},
+	} {
+		if _, err := repo.Create(create); err != nil {
+			t.Fatalf("Failed to create bash script: %v", err)
+		}
+	}
+
+	script, err := repo.GetByName("deploy")
+	if err != nil {
+		t.Fatalf("Failed to get bash script: %v", err)
+	}
+	if len(script.Tags) != 2 || script.Tags[0] != "deploy" || script.Tags[1] != "web" {
+		t.Errorf("Expected normalized tags [deploy web], got %v", script.Tags)
+	}
+
+	tests := []struct {
+		name   string
+		filter models.ListFilter
+		want   []string
+	}{
+		{"no filter", models.ListFilter{}, []string{"backup", "cleanup", "deploy"}},
+		{"tag", models.ListFilter{Tag: "WEB"}, []string{"deploy"}},
+		{"text in content", models.ListFilter{Query: "build"}, []string{"cleanup", "deploy"}},
+		{"text ignores case", models.ListFilter{Query: "NIGHTLY"}, []string{"backup"}},
+		{"text in tags", models.ListFilter{Query: "db"}, []string{"backup"}},
+		{"group and text", models.ListFilter{Group: "ops", Query: "build"}, []string{"deploy"}},
+		{"no match", models.ListFilter{Tag: "web", Query: "pg_dump"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts, err := repo.Search(tt.filter)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var names []string
+			for _, s := range scripts {
+				names = append(names, s.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Search() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	// The text query matches decrypted content, so its page is cut after matching
+	page, total, err := repo.List(models.ListFilter{Query: "build", Sort: "name", Desc: true, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != 2 || len(page) != 1 || page[0].Name != "cleanup" {
+		t.Errorf("Expected page [cleanup] of 2 matches, got %d matches", total)
+	}
+
+	// A nil tags update keeps the tags, an empty one clears them
+	updated, err := repo.Update(script.ID, &models.BashScriptUpdate{Description: "Ship it"})
+	if err != nil {
+		t.Fatalf("Failed to update bash script: %v", err)
+	}
+	if len(updated.Tags) != 2 {
+		t.Errorf("Expected tags to be kept, got %v", updated.Tags)
+	}
+	updated, err = repo.Update(script.ID, &models.BashScriptUpdate{Tags: []string{}})
+	if err != nil {
+		t.Fatalf("Failed to update bash script: %v", err)
+	}
+	if len(updated.Tags) != 0 {
+		t.Errorf("Expected tags to be cleared, got %v", updated.Tags)
+	}
+}
+
+func TestSavedCommandSearch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSavedCommandRepository(db)
+
+	for _, create := range []*models.SavedCommandCreate{
+		{Name: "Disk usage", Command: "df -h", Tags: []string{"disk", "monitoring"}},
+		{Name: "Memory", Command: "free -m", Description: "Show memory usage", Tags: []string{"monitoring"}},
+		{Name: "Uptime", Command: "uptime"},
+	} {
+		if _, err := repo.Create(create); err != nil {
+			t.Fatalf("Failed to create saved command: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter models.ListFilter
+		want   []string
+	}{
+		{"no filter", models.ListFilter{}, []string{"Disk usage", "Memory", "Uptime"}},
+		{"tag", models.ListFilter{Tag: "monitoring"}, []string{"Disk usage", "Memory"}},
+		{"text ignores case", models.ListFilter{Query: "USAGE"}, []string{"Disk usage", "Memory"}},
+		{"text in command", models.ListFilter{Query: "free"}, []string{"Memory"}},
+		{"text in tags", models.ListFilter{Query: "disk"}, []string{"Disk usage"}},
+		{"tag and text", models.ListFilter{Tag: "monitoring", Query: "uptime"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := repo.Search(tt.filter)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var names []string
+			for _, c := range commands {
+				names = append(names, c.Name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Search() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerMaxParallel(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewServerRepository(db)
+
+	created, err := repo.Create(&models.ServerCreate{Name: "web1", MaxParallel: 2})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	fetched, _ := repo.GetByID(created.ID)
+	if fetched.MaxParallel != 2 {
+		t.Errorf("Expected max_parallel 2, got %d", fetched.MaxParallel)
+	}
+
+	// 0 restores the default; negative limits are rejected
+	unlimited, negative := 0, -1
+	if updated, err := repo.Update(created.ID, &models.ServerUpdate{MaxParallel: &unlimited}); err != nil || updated.MaxParallel != 0 {
+		t.Errorf("Expected max_parallel 0, got %+v (%v)", updated, err)
+	}
+	if _, err := repo.Update(created.ID, &models.ServerUpdate{MaxParallel: &negative}); err == nil {
+		t.Error("Expected a negative max_parallel to be rejected")
+	}
+	if _, err := repo.Create(&models.ServerCreate{Name: "web2", MaxParallel: -1}); err == nil {
+		t.Error("Expected a negative max_parallel to be rejected")
+	}
+}
+
+func TestServerList(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewServerRepository(db)
+
+	for _, create := range []*models.ServerCreate{
+		{Name: "web1", IPAddress: "10.0.0.1", Port: 22, Username: "deploy", Group: "web"},
+		{Name: "web2", IPAddress: "10.0.0.2", Port: 2222, Username: "deploy", Group: "web"},
+		{Name: "db1", IPAddress: "10.0.1.1", Port: 22, Username: "postgres", Group: "db"},
+		{Name: "cache1", IPAddress: "10.0.2.1", Port: 22, Username: "redis", Group: "cache"},
+	} {
+		if _, err := repo.Create(create); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filter    models.ListFilter
+		want      []string
+		wantTotal int
+	}{
+		{"sorted by name", models.ListFilter{Sort: "name"}, []string{"cache1", "db1", "web1", "web2"}, 4},
+		{"descending", models.ListFilter{Sort: "name", Desc: true}, []string{"web2", "web1", "db1", "cache1"}, 4},
+		{"first page", models.ListFilter{Sort: "name", Limit: 3}, []string{"cache1", "db1", "web1"}, 4},
+		{"last page", models.ListFilter{Sort: "name", Limit: 3, Offset: 3}, []string{"web2"}, 4},
+		{"past the end", models.ListFilter{Sort: "name", Limit: 3, Offset: 6}, nil, 4},
+		{"group", models.ListFilter{Group: "web", Sort: "name", Limit: 1}, []string{"web1"}, 2},
+		{"visible groups", models.ListFilter{Groups: []string{"db", "cache"}, Sort: "name"}, []string{"cache1", "db1"}, 2},
+		{"no visible groups", models.ListFilter{Groups: []string{}}, nil, 0},
+		{"text in username", models.ListFilter{Query: "POSTGRES"}, []string{"db1"}, 1},
+		{"text in IP address", models.ListFilter{Query: "10.0.0.", Sort: "port", Desc: true}, []string{"web2", "web1"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers, total, err := repo.List(tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var names []string
+			for _, s := range servers {
+				names = append(names, s.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("List() = %v, want %v", names, tt.want)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("List() total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+
+	if _, _, err := repo.List(models.ListFilter{Sort: "password"}); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("Expected ErrInvalidSort for an unknown sort field, got %v", err)
+	}
+}
+
 func TestScriptPresetRepository(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -731,3 +1150,732 @@ func TestScriptPresetRepositoryValidation(t *testing.T) {
 		t.Error("Expected error when creating preset without script_id")
 	}
 }
+
+func TestAPITokenRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewAPITokenRepository(db)
+
+	// Test Create
+	created, err := repo.Create(&models.APITokenCreate{
+		Name:          "ci-deploy",
+		Scopes:        []string{"execute", "READ", "execute"},
+		Roles:         []string{"deployers"},
+		ExpiresInDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create API token: %v", err)
+	}
+
+	if created.Token == "" || created.Prefix == "" || created.Token[:len(created.Prefix)] != created.Prefix {
+		t.Errorf("Unexpected token %q with prefix %q", created.Token, created.Prefix)
+	}
+	if len(created.Scopes) != 2 || created.Scopes[0] != "execute" || created.Scopes[1] != "read" {
+		t.Errorf("Expected normalized scopes [execute read], got %v", created.Scopes)
+	}
+	if created.ExpiresAt == nil {
+		t.Error("Expected expiry to be set")
+	}
+
+	// The token itself is not stored
+	var stored string
+	if err := db.GetConnection().QueryRow("SELECT token_hash FROM api_tokens WHERE id = ?", created.ID).Scan(&stored); err != nil {
+		t.Fatalf("Failed to read token hash: %v", err)
+	}
+	if stored == created.Token {
+		t.Error("Token should be stored hashed")
+	}
+
+	// Test Authenticate
+	token, err := repo.Authenticate(created.Token)
+	if err != nil {
+		t.Fatalf("Failed to authenticate token: %v", err)
+	}
+	if token == nil || token.ID != created.ID {
+		t.Fatal("Expected token to authenticate")
+	}
+	if token.LastUsedAt == nil {
+		t.Error("Expected last used time to be recorded")
+	}
+	if !token.HasScope("execute") || token.HasScope("write") {
+		t.Errorf("Unexpected scope checks for %v", token.Scopes)
+	}
+	if len(token.Roles) != 1 || token.Roles[0] != "deployers" {
+		t.Errorf("Expected roles [deployers], got %v", token.Roles)
+	}
+
+	if token, err := repo.Authenticate("wcli_unknown"); err != nil || token != nil {
+		t.Errorf("Expected unknown token to be rejected, got %v, %v", token, err)
+	}
+
+	// Test Update
+	updated, err := repo.Update(created.ID, &models.APITokenUpdate{Scopes: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("Failed to update API token: %v", err)
+	}
+	if updated.Name != "ci-deploy" || !updated.HasScope("write") {
+		t.Errorf("Unexpected updated token: %+v", updated)
+	}
+
+	if _, err := repo.Update(created.ID, &models.APITokenUpdate{Scopes: []string{"root"}}); err == nil {
+		t.Error("Expected invalid scope to be rejected")
+	}
+	if _, err := repo.Update(created.ID, &models.APITokenUpdate{Roles: []string{"ops,admin"}}); err == nil {
+		t.Error("Expected invalid role to be rejected")
+	}
+
+	// Test GetAll
+	tokens, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to list API tokens: %v", err)
+	}
+	if len(tokens) != 1 {
+		t.Errorf("Expected 1 token, got %d", len(tokens))
+	}
+
+	// Test Delete
+	if err := repo.Delete(created.ID); err != nil {
+		t.Fatalf("Failed to delete API token: %v", err)
+	}
+	if token, _ := repo.Authenticate(created.Token); token != nil {
+		t.Error("Deleted token should not authenticate")
+	}
+}
+
+func TestAPITokenRepositoryExpired(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewAPITokenRepository(db)
+
+	created, err := repo.Create(&models.APITokenCreate{Name: "old", Scopes: []string{"read"}, ExpiresInDays: 1})
+	if err != nil {
+		t.Fatalf("Failed to create API token: %v", err)
+	}
+
+	if _, err := db.GetConnection().Exec("UPDATE api_tokens SET expires_at = ? WHERE id = ?", time.Now().UTC().Add(-time.Hour), created.ID); err != nil {
+		t.Fatalf("Failed to expire token: %v", err)
+	}
+
+	if token, err := repo.Authenticate(created.Token); err != nil || token != nil {
+		t.Errorf("Expected expired token to be rejected, got %v, %v", token, err)
+	}
+
+	if _, err := repo.Create(&models.APITokenCreate{Name: "none", Scopes: nil}); err == nil {
+		t.Error("Expected token without scopes to be rejected")
+	}
+}
+
+func TestGroupPermissionRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewGroupPermissionRepository(db)
+
+	created, err := repo.Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypeServers,
+		Group:        "production",
+		Principal:    "role:senior-ops",
+		Permission:   models.PermissionExecute,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create group permission: %v", err)
+	}
+
+	// Granting the same principal again replaces the permission
+	replaced, err := repo.Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypeServers,
+		Group:        "production",
+		Principal:    "role:senior-ops",
+		Permission:   models.PermissionView,
+	})
+	if err != nil {
+		t.Fatalf("Failed to replace group permission: %v", err)
+	}
+	if replaced.ID != created.ID || replaced.Permission != models.PermissionView {
+		t.Errorf("Expected permission %d to be replaced, got %+v", created.ID, replaced)
+	}
+
+	invalid := []models.GroupPermissionCreate{
+		{ResourceType: "saved_commands", Group: "production", Principal: "user:alice"},
+		{ResourceType: models.ResourceTypeServers, Group: "production", Principal: "alice"},
+		{ResourceType: models.ResourceTypeServers, Group: "production", Principal: "user:alice", Permission: "delete"},
+	}
+	for _, create := range invalid {
+		if _, err := repo.Create(&create); err == nil {
+			t.Errorf("Expected invalid permission to be rejected: %+v", create)
+		}
+	}
+
+	if _, err := repo.Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypeServers,
+		Group:        "production",
+		Principal:    "user:alice",
+		Permission:   models.PermissionExecute,
+	}); err != nil {
+		t.Fatalf("Failed to create group permission: %v", err)
+	}
+
+	acl, err := repo.LoadACL()
+	if err != nil {
+		t.Fatalf("Failed to load ACL: %v", err)
+	}
+
+	tests := []struct {
+		group      string
+		identities []string
+		permission string
+		expected   bool
+	}{
+		{"production", []string{"user:alice"}, models.PermissionExecute, true},
+		{"production", []string{"user:alice"}, models.PermissionView, true},
+		{"production", []string{"user:bob", "role:senior-ops"}, models.PermissionView, true},
+		{"production", []string{"user:bob", "role:senior-ops"}, models.PermissionExecute, false},
+		{"production", []string{"user:bob"}, models.PermissionView, false},
+		{"staging", []string{"user:bob"}, models.PermissionExecute, true},
+	}
+	for _, tt := range tests {
+		if got := acl.Allows(models.ResourceTypeServers, tt.group, tt.identities, tt.permission); got != tt.expected {
+			t.Errorf("Allows(%s, %v, %s) = %v, want %v", tt.group, tt.identities, tt.permission, got, tt.expected)
+		}
+	}
+
+	// Delete
+	if err := repo.Delete(created.ID); err != nil {
+		t.Fatalf("Failed to delete group permission: %v", err)
+	}
+	if err := repo.Delete(created.ID); err == nil {
+		t.Error("Expected error deleting missing group permission")
+	}
+
+	permissions, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to list group permissions: %v", err)
+	}
+	if len(permissions) != 1 || permissions[0].Principal != "user:alice" {
+		t.Errorf("Unexpected remaining permissions: %+v", permissions)
+	}
+}
+
+func TestCommandPolicyRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewCommandPolicyRepository(db)
+
+	created, err := repo.Create(&models.CommandPolicyCreate{
+		Name:    "no-root-wipe",
+		Action:  "DENY",
+		Pattern: "rm -rf /",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create command policy: %v", err)
+	}
+	if created.Action != models.PolicyActionDeny || created.MatchType != models.PolicyMatchGlob ||
+		created.Target != models.PolicyTargetCommand || !created.Enabled {
+		t.Errorf("Expected defaults to be applied, got %+v", created)
+	}
+
+	invalid := []models.CommandPolicyCreate{
+		{Name: "", Action: models.PolicyActionDeny, Pattern: "rm"},
+		{Name: "bad-action", Action: "audit", Pattern: "rm"},
+		{Name: "bad-regex", Action: models.PolicyActionDeny, MatchType: models.PolicyMatchRegex, Pattern: "rm ("},
+		{Name: "bad-target", Action: models.PolicyActionDeny, Pattern: "rm", Target: "terminal"},
+		{Name: "bad-principal", Action: models.PolicyActionDeny, Pattern: "rm", Principal: "alice"},
+	}
+	for _, create := range invalid {
+		if _, err := repo.Create(&create); err == nil {
+			t.Errorf("Expected invalid policy to be rejected: %+v", create)
+		}
+	}
+
+	// Update
+	disabled := false
+	updated, err := repo.Update(created.ID, &models.CommandPolicyUpdate{Enabled: &disabled})
+	if err != nil {
+		t.Fatalf("Failed to update command policy: %v", err)
+	}
+	if updated.Enabled {
+		t.Error("Expected policy to be disabled")
+	}
+
+	enabled, err := repo.GetEnabled()
+	if err != nil {
+		t.Fatalf("Failed to get enabled policies: %v", err)
+	}
+	if len(enabled) != 0 {
+		t.Errorf("Expected no enabled policies, got %d", len(enabled))
+	}
+
+	engine, err := repo.LoadEngine()
+	if err != nil {
+		t.Fatalf("Failed to load engine: %v", err)
+	}
+	if d := engine.CheckCommand("rm -rf /", nil); !d.Allowed {
+		t.Errorf("Expected disabled policy to be ignored, got %+v", d)
+	}
+
+	// Delete
+	if err := repo.Delete(created.ID); err != nil {
+		t.Fatalf("Failed to delete command policy: %v", err)
+	}
+	if _, err := repo.GetByID(created.ID); err == nil {
+		t.Error("Expected error getting deleted command policy")
+	}
+}
+
+func TestApprovalRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewApprovalRepository(db)
+
+	approval, err := repo.Create(&models.Approval{
+		Kind:        models.ApprovalKindScript,
+		Summary:     "rotate-certs",
+		Server:      "db1",
+		RunAs:       "root",
+		Reason:      "script requires approval",
+		Fingerprint: "abc",
+		RequestedBy: "token:ci",
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create approval: %v", err)
+	}
+	if approval.Status != models.ApprovalStatusPending {
+		t.Errorf("Expected pending approval, got %s", approval.Status)
+	}
+
+	if _, err := repo.Consume(approval.ID, "abc", "token:ci"); err == nil {
+		t.Error("Expected pending approval not to be consumable")
+	}
+
+	approved, err := repo.Decide(approval.ID, models.ApprovalStatusApproved, "token:lead")
+	if err != nil {
+		t.Fatalf("Failed to approve: %v", err)
+	}
+	if approved.Status != models.ApprovalStatusApproved || approved.DecidedBy != "token:lead" || approved.DecidedAt == nil {
+		t.Errorf("Unexpected approved approval: %+v", approved)
+	}
+	if _, err := repo.Decide(approval.ID, models.ApprovalStatusRejected, "token:lead"); err == nil {
+		t.Error("Expected decided approval not to be decided again")
+	}
+
+	if _, err := repo.Consume(approval.ID, "other", "token:ci"); err == nil {
+		t.Error("Expected approval not to cover a different request")
+	}
+	if _, err := repo.Consume(approval.ID, "abc", "token:other"); err == nil {
+		t.Error("Expected approval not to be usable by another principal")
+	}
+	executed, err := repo.Consume(approval.ID, "abc", "token:ci")
+	if err != nil {
+		t.Fatalf("Failed to consume approval: %v", err)
+	}
+	if executed.Status != models.ApprovalStatusExecuted || executed.ExecutedAt == nil {
+		t.Errorf("Unexpected executed approval: %+v", executed)
+	}
+
+	// Expired approvals cannot be decided
+	expired, err := repo.Create(&models.Approval{Kind: models.ApprovalKindCommand, Summary: "reboot", Server: "db1", RunAs: "root", Fingerprint: "def", RequestedBy: "token:ci"}, -time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create approval: %v", err)
+	}
+	got, err := repo.GetByID(expired.ID)
+	if err != nil {
+		t.Fatalf("Failed to get approval: %v", err)
+	}
+	if got.Status != models.ApprovalStatusExpired {
+		t.Errorf("Expected expired status, got %s", got.Status)
+	}
+	if _, err := repo.Decide(expired.ID, models.ApprovalStatusApproved, "token:lead"); err == nil {
+		t.Error("Expected expired approval not to be approved")
+	}
+
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get approvals: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected 2 approvals, got %d", len(all))
+	}
+}
+
+func TestKeystrokeLogRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewKeystrokeLogRepository(db)
+
+	keystrokeLog, err := repo.Create("9c41d7e2a0b35f18", "user:admin", "local")
+	if err != nil {
+		t.Fatalf("Failed to create keystroke log: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if err := repo.AppendEvents(keystrokeLog.ID, []models.KeystrokeEvent{{Time: now, Data: "sudo ls\r"}}); err != nil {
+		t.Fatalf("Failed to append keystrokes: %v", err)
+	}
+	if err := repo.AppendEvents(keystrokeLog.ID, []models.KeystrokeEvent{{Time: now, Redacted: true}, {Time: now, Data: "\r"}}); err != nil {
+		t.Fatalf("Failed to append keystrokes: %v", err)
+	}
+
+	// Keystrokes are encrypted at rest
+	var stored []byte
+	if err := db.GetConnection().QueryRow("SELECT events FROM terminal_keystroke_chunks LIMIT 1").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read stored keystrokes: %v", err)
+	}
+	if strings.Contains(string(stored), "sudo") {
+		t.Error("Expected keystrokes to be stored encrypted")
+	}
+
+	if err := repo.End(keystrokeLog.ID); err != nil {
+		t.Fatalf("Failed to end keystroke log: %v", err)
+	}
+
+	got, err := repo.GetByID(keystrokeLog.ID)
+	if err != nil {
+		t.Fatalf("Failed to get keystroke log: %v", err)
+	}
+	if got.EndedAt == nil || got.Owner != "user:admin" || got.SessionID != "9c41d7e2a0b35f18" {
+		t.Errorf("Unexpected keystroke log: %+v", got)
+	}
+	if len(got.Events) != 3 || got.Events[0].Data != "sudo ls\r" || !got.Events[1].Redacted {
+		t.Errorf("Unexpected keystrokes: %+v", got.Events)
+	}
+
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get keystroke logs: %v", err)
+	}
+	if len(all) != 1 || all[0].Events != nil {
+		t.Errorf("Expected 1 keystroke log without events, got %+v", all)
+	}
+
+	if _, err := repo.GetByID(999); err == nil {
+		t.Error("Expected error for missing keystroke log")
+	}
+}
+
+func TestWebhookRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewWebhookRepository(db)
+
+	created, err := repo.Create(&models.WebhookCreate{
+		Name:   "ci",
+		URL:    "https://ci.example.com/hook",
+		Secret: "s3cret",
+		Events: []string{"script.failed", "*.failed", "script.failed"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if !created.Enabled || !created.HasSecret || len(created.Events) != 2 {
+		t.Errorf("Expected an enabled, signed webhook with 2 events, got %+v", created)
+	}
+
+	invalid := []models.WebhookCreate{
+		{Name: "", URL: "https://example.com"},
+		{Name: "ftp", URL: "ftp://example.com"},
+		{Name: "relative", URL: "/hook"},
+		{Name: "bad-event", URL: "https://example.com", Events: []string{"build.finished"}},
+		{Name: "ci", URL: "https://example.com"},
+	}
+	for _, create := range invalid {
+		if _, err := repo.Create(&create); err == nil {
+			t.Errorf("Expected invalid webhook to be rejected: %+v", create)
+		}
+	}
+
+	// The secret is stored encrypted and read back decrypted
+	var stored []byte
+	if err := db.GetConnection().QueryRow("SELECT secret_encrypted FROM webhooks WHERE id = ?", created.ID).Scan(&stored); err != nil {
+		t.Fatalf("Failed to read stored secret: %v", err)
+	}
+	if strings.Contains(string(stored), "s3cret") {
+		t.Error("Webhook secret is stored in plain text")
+	}
+	fetched, err := repo.GetByID(created.ID)
+	if err != nil || fetched.Secret != "s3cret" {
+		t.Fatalf("Expected the decrypted secret, got %v (%v)", fetched, err)
+	}
+
+	// Removing the secret and the event filters
+	noSecret := ""
+	updated, err := repo.Update(created.ID, &models.WebhookUpdate{Secret: &noSecret, Events: []string{}})
+	if err != nil {
+		t.Fatalf("Failed to update webhook: %v", err)
+	}
+	if updated.HasSecret || len(updated.Events) != 0 {
+		t.Errorf("Expected no secret and no filters, got %+v", updated)
+	}
+
+	if err := repo.RecordDelivery(created.ID, errors.New("webhook returned 502 Bad Gateway")); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	fetched, _ = repo.GetByID(created.ID)
+	if fetched.LastStatus != models.WebhookStatusFailed || fetched.LastError == "" || fetched.LastDeliveredAt == nil {
+		t.Errorf("Expected the failed delivery to be recorded, got %+v", fetched)
+	}
+
+	disabled := false
+	if _, err := repo.Update(created.ID, &models.WebhookUpdate{Enabled: &disabled}); err != nil {
+		t.Fatalf("Failed to disable webhook: %v", err)
+	}
+	if enabled, _ := repo.GetEnabled(); len(enabled) != 0 {
+		t.Errorf("Expected no enabled webhooks, got %d", len(enabled))
+	}
+
+	if err := repo.Delete(created.ID); err != nil {
+		t.Fatalf("Failed to delete webhook: %v", err)
+	}
+	if _, err := repo.GetByID(created.ID); err == nil {
+		t.Error("Expected error getting deleted webhook")
+	}
+}
+
+func TestNotificationRuleRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := NewWebhookRepository(db).Create(&models.WebhookCreate{Name: "ci", URL: "https://ci.example.com/hook"}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	repo := NewNotificationRuleRepository(db)
+
+	created, err := repo.Create(&models.NotificationRuleCreate{
+		Name:      "backup failures",
+		Script:    "backup-*",
+		ExitCodes: []int{1, 2},
+		Channels:  []string{"webhook:ci", "email:ops@example.com", "webhook:ci"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create notification rule: %v", err)
+	}
+	if !created.Enabled || created.Outcome != models.RuleOutcomeFailure || len(created.Channels) != 2 {
+		t.Errorf("Expected an enabled failure rule with 2 channels, got %+v", created)
+	}
+
+	invalid := []models.NotificationRuleCreate{
+		{Name: "", Channels: []string{"slack"}},
+		{Name: "no-channels"},
+		{Name: "bad-channel", Channels: []string{"sms:555"}},
+		{Name: "unknown-webhook", Channels: []string{"webhook:nope"}},
+		{Name: "bad-address", Channels: []string{"email:not an address"}},
+		{Name: "bad-kind", Kind: "job", Channels: []string{"slack"}},
+		{Name: "bad-outcome", Outcome: "maybe", Channels: []string{"slack"}},
+		{Name: "bad-pattern", Script: "[", Channels: []string{"slack"}},
+		{Name: "negative", MinDurationMs: -1, Channels: []string{"slack"}},
+		{Name: "backup failures", Channels: []string{"slack"}},
+	}
+	for _, create := range invalid {
+		if _, err := repo.Create(&create); err == nil {
+			t.Errorf("Expected invalid notification rule to be rejected: %+v", create)
+		}
+	}
+
+	fetched, err := repo.GetByID(created.ID)
+	if err != nil || fetched.Script != "backup-*" || len(fetched.ExitCodes) != 2 || fetched.Channels[0] != "webhook:ci" {
+		t.Fatalf("Expected the stored rule, got %+v (%v)", fetched, err)
+	}
+
+	// Clearing the exit codes and disabling the rule
+	disabled := false
+	minDuration := int64(60000)
+	updated, err := repo.Update(created.ID, &models.NotificationRuleUpdate{ExitCodes: []int{}, MinDurationMs: &minDuration, Enabled: &disabled})
+	if err != nil {
+		t.Fatalf("Failed to update notification rule: %v", err)
+	}
+	if len(updated.ExitCodes) != 0 || updated.MinDurationMs != 60000 || updated.Enabled {
+		t.Errorf("Expected no exit codes, a duration threshold and a disabled rule, got %+v", updated)
+	}
+	if enabled, _ := repo.GetEnabled(); len(enabled) != 0 {
+		t.Errorf("Expected no enabled rules, got %d", len(enabled))
+	}
+
+	if err := repo.Delete(created.ID); err != nil {
+		t.Fatalf("Failed to delete notification rule: %v", err)
+	}
+	if _, err := repo.GetByID(created.ID); err == nil {
+		t.Error("Expected deleted notification rule to be gone")
+	}
+}
+
+func TestExecutionQuotaRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewExecutionQuotaRepository(db)
+
+	everyone, err := repo.Create(&models.ExecutionQuotaCreate{Principal: "*", ExecutionsPerHour: 100})
+	if err != nil {
+		t.Fatalf("Failed to create default quota: %v", err)
+	}
+	ci, err := repo.Create(&models.ExecutionQuotaCreate{Principal: " token:ci ", ExecutionsPerHour: 20, MaxConcurrent: 2, MaxTimeoutSeconds: 60})
+	if err != nil {
+		t.Fatalf("Failed to create token quota: %v", err)
+	}
+	if ci.Principal != "token:ci" {
+		t.Errorf("Expected the principal to be trimmed, got %q", ci.Principal)
+	}
+	if _, err := repo.Create(&models.ExecutionQuotaCreate{Principal: "role:automation", MaxConcurrent: 5}); err != nil {
+		t.Fatalf("Failed to create role quota: %v", err)
+	}
+
+	invalid := []models.ExecutionQuotaCreate{
+		{Principal: ""},
+		{Principal: "alice"},
+		{Principal: "user:"},
+		{Principal: "user:alice", ExecutionsPerHour: -1},
+		{Principal: "token:ci"},
+	}
+	for _, create := range invalid {
+		if _, err := repo.Create(&create); err == nil {
+			t.Errorf("Expected invalid quota to be rejected: %+v", create)
+		}
+	}
+
+	// The caller's own quota wins over its roles, which win over the default
+	tests := []struct {
+		identities []string
+		want       string
+	}{
+		{[]string{"token:ci", "role:automation"}, "token:ci"},
+		{[]string{"token:deploy", "role:automation"}, "role:automation"},
+		{[]string{"user:alice", "role:operator"}, "*"},
+	}
+	for _, tt := range tests {
+		quota, err := repo.Resolve(tt.identities)
+		if err != nil || quota == nil || quota.Principal != tt.want {
+			t.Errorf("Resolve(%v): expected %s, got %+v (%v)", tt.identities, tt.want, quota, err)
+		}
+	}
+
+	perHour := 0
+	updated, err := repo.Update(ci.ID, &models.ExecutionQuotaUpdate{ExecutionsPerHour: &perHour})
+	if err != nil {
+		t.Fatalf("Failed to update quota: %v", err)
+	}
+	if updated.ExecutionsPerHour != 0 || updated.MaxConcurrent != 2 || updated.MaxTimeoutSeconds != 60 {
+		t.Errorf("Expected only the hourly limit to change, got %+v", updated)
+	}
+
+	if err := repo.Delete(everyone.ID); err != nil {
+		t.Fatalf("Failed to delete quota: %v", err)
+	}
+	if quota, err := repo.Resolve([]string{"user:alice"}); err != nil || quota != nil {
+		t.Errorf("Expected no quota without a default, got %+v (%v)", quota, err)
+	}
+}
+
+func TestServerGroupRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	key, err := NewSSHKeyRepository(db).Create(&models.SSHKeyCreate{Name: "deploy", PrivateKey: "test-private-key"})
+	if err != nil {
+		t.Fatalf("Failed to create SSH key: %v", err)
+	}
+	repo := NewServerGroupRepository(db)
+	serverRepo := NewServerRepository(db)
+
+	production, err := repo.Create(&models.ServerGroupCreate{Name: "production", Description: "Live servers", DefaultUsername: "deploy", DefaultPort: 2222, DefaultSSHKeyID: &key.ID})
+	if err != nil {
+		t.Fatalf("Failed to create server group: %v", err)
+	}
+	missingKey := int64(9999)
+	invalid := []models.ServerGroupCreate{
+		{Name: ""},
+		{Name: "production"},
+		{Name: "bad-port", DefaultPort: 70000},
+		{Name: "bad-key", DefaultSSHKeyID: &missingKey},
+	}
+	for _, create := range invalid {
+		if _, err := repo.Create(&create); err == nil {
+			t.Errorf("Expected invalid server group to be rejected: %+v", create)
+		}
+	}
+
+	// New members take the group's defaults; servers in unknown groups create them
+	web, err := serverRepo.Create(&models.ServerCreate{Name: "web1", Group: "production"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if web.Username != "deploy" || web.Port != 2222 {
+		t.Errorf("Expected the group's default credentials, got %s:%d", web.Username, web.Port)
+	}
+	db1, err := serverRepo.Create(&models.ServerCreate{Name: "db1", Group: "databases"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if db1.Username != "root" || db1.Port != 22 {
+		t.Errorf("Expected the built-in defaults, got %s:%d", db1.Username, db1.Port)
+	}
+	databases, err := repo.GetByName("databases")
+	if err != nil || databases.ServerCount != 1 {
+		t.Fatalf("Expected the databases group to be created with 1 server, got %+v (%v)", databases, err)
+	}
+	if groups, _ := repo.GetAll(); len(groups) != 3 || groups[0].Name != "databases" || groups[1].Name != models.DefaultServerGroup {
+		t.Errorf("Expected databases, default and production groups, got %+v", groups)
+	}
+
+	if err := repo.AddServers(production.ID, []int64{db1.ID}); err != nil {
+		t.Fatalf("Failed to add server: %v", err)
+	}
+	if err := repo.AddServers(production.ID, []int64{9999}); err == nil {
+		t.Error("Expected an unknown server to be rejected")
+	}
+	if servers, _ := serverRepo.GetByGroup("production"); len(servers) != 2 {
+		t.Errorf("Expected 2 production servers, got %d", len(servers))
+	}
+
+	// Renaming moves the servers and permissions along
+	if _, err := NewGroupPermissionRepository(db).Create(&models.GroupPermissionCreate{GroupID: &production.ID, Principal: "role:ops", Permission: models.PermissionExecute}); err != nil {
+		t.Fatalf("Failed to create group permission by group ID: %v", err)
+	}
+	name := "live"
+	noKey := int64(0)
+	renamed, err := repo.Update(production.ID, &models.ServerGroupUpdate{Name: name, DefaultSSHKeyID: &noKey})
+	if err != nil {
+		t.Fatalf("Failed to rename server group: %v", err)
+	}
+	if renamed.Name != "live" || renamed.DefaultSSHKeyID != nil || renamed.DefaultUsername != "deploy" || renamed.ServerCount != 2 {
+		t.Errorf("Expected the renamed group without a key, got %+v", renamed)
+	}
+	if servers, _ := serverRepo.GetByGroup("live"); len(servers) != 2 {
+		t.Errorf("Expected the servers to move to the renamed group, got %d", len(servers))
+	}
+	acl, err := NewGroupPermissionRepository(db).LoadACL()
+	if err != nil || !acl.Restricted(models.ResourceTypeServers, "live") || acl.Restricted(models.ResourceTypeServers, "production") {
+		t.Errorf("Expected the permission to move to the renamed group (%v)", err)
+	}
+	defaultGroup, _ := repo.GetByName(models.DefaultServerGroup)
+	if _, err := repo.Update(defaultGroup.ID, &models.ServerGroupUpdate{Name: "other"}); err == nil {
+		t.Error("Expected renaming the default group to fail")
+	}
+
+	// Only empty groups can be deleted
+	if err := repo.Delete(production.ID); err == nil || !strings.Contains(err.Error(), "still has 2 servers") {
+		t.Errorf("Expected deleting a group with servers to fail, got %v", err)
+	}
+	if err := repo.RemoveServer(production.ID, web.ID); err != nil {
+		t.Fatalf("Failed to remove server: %v", err)
+	}
+	if err := repo.RemoveServer(production.ID, web.ID); err == nil {
+		t.Error("Expected removing a server outside the group to fail")
+	}
+	if err := repo.RemoveServer(production.ID, db1.ID); err != nil {
+		t.Fatalf("Failed to remove server: %v", err)
+	}
+	if err := repo.Delete(production.ID); err != nil {
+		t.Fatalf("Failed to delete empty server group: %v", err)
+	}
+	if acl, _ := NewGroupPermissionRepository(db).LoadACL(); acl.Restricted(models.ResourceTypeServers, "live") {
+		t.Error("Expected the deleted group's permissions to be removed")
+	}
+	if err := repo.Delete(defaultGroup.ID); err == nil {
+		t.Error("Expected deleting the default group to fail")
+	}
+}
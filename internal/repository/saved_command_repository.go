@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -35,10 +36,15 @@ func (r *SavedCommandRepository) Create(cmd *models.SavedCommandCreate) (*models
 		user = "root"
 	}
 
+	tags := models.NormalizeTags(cmd.Tags)
+	placeholders := cmd.Placeholders
+	if placeholders == nil {
+		placeholders = []models.CommandPlaceholder{}
+	}
 	now := time.Now().UTC()
 
 	result, err := r.db.GetConnection().Exec(
-		"INSERT INTO saved_commands (name, command, description, user, is_remote, server_id, ssh_key_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO saved_commands (name, command, description, user, is_remote, server_id, ssh_key_id, tags, placeholders, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		cmd.Name,
 		cmd.Command,
 		cmd.Description,
@@ -46,6 +52,8 @@ func (r *SavedCommandRepository) Create(cmd *models.SavedCommandCreate) (*models
 		cmd.IsRemote,
 		cmd.ServerID,
 		cmd.SSHKeyID,
+		encodeTags(tags),
+		encodePlaceholders(placeholders),
 		now,
 		now,
 	)
@@ -67,19 +75,37 @@ func (r *SavedCommandRepository) Create(cmd *models.SavedCommandCreate) (*models
 		IsRemote:    cmd.IsRemote,
 		ServerID:    cmd.ServerID,
 		SSHKeyID:    cmd.SSHKeyID,
+		Tags:        tags,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		Placeholders: placeholders,
 	}, nil
 }
 
-// GetByID retrieves a saved command by its ID
-func (r *SavedCommandRepository) GetByID(id int64) (*models.SavedCommand, error) {
+// savedCommandColumns are the columns read by scanSavedCommand, in order
+const savedCommandColumns = "id, name, command, description, user, is_remote, server_id, ssh_key_id, tags, placeholders, created_at, updated_at"
+
+// scanSavedCommand reads a saved command selected with savedCommandColumns
+func scanSavedCommand(row rowScanner) (*models.SavedCommand, error) {
 	var cmd models.SavedCommand
+	var tags, placeholders string
+
+	if err := row.Scan(&cmd.ID, &cmd.Name, &cmd.Command, &cmd.Description, &cmd.User, &cmd.IsRemote, &cmd.ServerID, &cmd.SSHKeyID, &tags, &placeholders, &cmd.CreatedAt, &cmd.UpdatedAt); err != nil {
+		return nil, err
+	}
+	cmd.Tags = decodeTags(tags)
+	cmd.Placeholders = decodePlaceholders(placeholders)
+
+	return &cmd, nil
+}
 
-	err := r.db.GetConnection().QueryRow(
-		"SELECT id, name, command, description, user, is_remote, server_id, ssh_key_id, created_at, updated_at FROM saved_commands WHERE id = ?",
+// GetByID retrieves a saved command by its ID
+func (r *SavedCommandRepository) GetByID(id int64) (*models.SavedCommand, error) {
+	cmd, err := scanSavedCommand(r.db.GetConnection().QueryRow(
+		"SELECT "+savedCommandColumns+" FROM saved_commands WHERE id = ?",
 		id,
-	).Scan(&cmd.ID, &cmd.Name, &cmd.Command, &cmd.Description, &cmd.User, &cmd.IsRemote, &cmd.ServerID, &cmd.SSHKeyID, &cmd.CreatedAt, &cmd.UpdatedAt)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("saved command not found")
@@ -88,14 +114,43 @@ func (r *SavedCommandRepository) GetByID(id int64) (*models.SavedCommand, error)
 		return nil, fmt.Errorf("failed to get saved command: %w", err)
 	}
 
-	return &cmd, nil
+	return cmd, nil
 }
 
 // GetAll retrieves all saved commands
 func (r *SavedCommandRepository) GetAll() ([]*models.SavedCommand, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, command, description, user, is_remote, server_id, ssh_key_id, created_at, updated_at FROM saved_commands ORDER BY name ASC",
-	)
+	return r.Search(models.ListFilter{})
+}
+
+// savedCommandSortColumns maps the fields saved commands can be sorted by to their columns
+var savedCommandSortColumns = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// Search retrieves all the saved commands matching a filter, ignoring its page
+func (r *SavedCommandRepository) Search(filter models.ListFilter) ([]*models.SavedCommand, error) {
+	commands, _, err := r.List(filter.Unpaged())
+	return commands, err
+}
+
+// List retrieves a page of the saved commands matching a filter, and the number of matches
+// The query is matched case-insensitively against the name, command, description and tags
+func (r *SavedCommandRepository) List(filter models.ListFilter) ([]*models.SavedCommand, int, error) {
+	q := newListQuery("saved_commands")
+	q.text(filter, "name", "command", "description", "tags")
+	q.tag(filter)
+	query, err := q.selectSQL(savedCommandColumns, filter, savedCommandSortColumns, "name ASC")
+	if err != nil {
+		return nil, 0, err
+	}
+	return runList(r.db, q, filter, query, r.query)
+}
+
+// query retrieves the saved commands selected with savedCommandColumns
+func (r *SavedCommandRepository) query(query string, args ...any) ([]*models.SavedCommand, error) {
+	rows, err := r.db.GetConnection().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query saved commands: %w", err)
 	}
@@ -103,13 +158,12 @@ func (r *SavedCommandRepository) GetAll() ([]*models.SavedCommand, error) {
 
 	var commands []*models.SavedCommand
 	for rows.Next() {
-		var cmd models.SavedCommand
-
-		if err := rows.Scan(&cmd.ID, &cmd.Name, &cmd.Command, &cmd.Description, &cmd.User, &cmd.IsRemote, &cmd.ServerID, &cmd.SSHKeyID, &cmd.CreatedAt, &cmd.UpdatedAt); err != nil {
+		cmd, err := scanSavedCommand(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan saved command: %w", err)
 		}
 
-		commands = append(commands, &cmd)
+		commands = append(commands, cmd)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -156,10 +210,20 @@ func (r *SavedCommandRepository) Update(id int64, update *models.SavedCommandUpd
 		existing.SSHKeyID = update.SSHKeyID
 	}
 
+	// An empty list removes all tags; omitting tags keeps them
+	if update.Tags != nil {
+		existing.Tags = models.NormalizeTags(update.Tags)
+	}
+
+	// Likewise for placeholder declarations
+	if update.Placeholders != nil {
+		existing.Placeholders = update.Placeholders
+	}
+
 	existing.UpdatedAt = time.Now().UTC()
 
 	_, err = r.db.GetConnection().Exec(
-		"UPDATE saved_commands SET name = ?, command = ?, description = ?, user = ?, is_remote = ?, server_id = ?, ssh_key_id = ?, updated_at = ? WHERE id = ?",
+		"UPDATE saved_commands SET name = ?, command = ?, description = ?, user = ?, is_remote = ?, server_id = ?, ssh_key_id = ?, tags = ?, placeholders = ?, updated_at = ? WHERE id = ?",
 		existing.Name,
 		existing.Command,
 		existing.Description,
@@ -167,6 +231,8 @@ func (r *SavedCommandRepository) Update(id int64, update *models.SavedCommandUpd
 		existing.IsRemote,
 		existing.ServerID,
 		existing.SSHKeyID,
+		encodeTags(existing.Tags),
+		encodePlaceholders(existing.Placeholders),
 		existing.UpdatedAt,
 		id,
 	)
@@ -195,3 +261,21 @@ func (r *SavedCommandRepository) Delete(id int64) error {
 
 	return nil
 }
+
+// encodePlaceholders serializes placeholder declarations to the JSON array stored in the placeholders column
+func encodePlaceholders(placeholders []models.CommandPlaceholder) string {
+	data, err := json.Marshal(placeholders)
+	if err != nil || placeholders == nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// decodePlaceholders parses the placeholders column, treating invalid values as no declarations
+func decodePlaceholders(value string) []models.CommandPlaceholder {
+	var placeholders []models.CommandPlaceholder
+	if err := json.Unmarshal([]byte(value), &placeholders); err != nil || placeholders == nil {
+		return []models.CommandPlaceholder{}
+	}
+	return placeholders
+}
@@ -3,13 +3,21 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/executor"
 	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+	"github.com/pozgo/web-cli/internal/validation"
 )
 
+// ErrInvalidPreset is returned for script preset settings out of range
+var ErrInvalidPreset = errors.New("invalid script preset")
+
 // ScriptPresetRepository handles database operations for script presets
 type ScriptPresetRepository struct {
 	db *database.DB
@@ -29,6 +37,15 @@ func (r *ScriptPresetRepository) Create(preset *models.ScriptPresetCreate) (*mod
 	if preset.ScriptID == 0 {
 		return nil, fmt.Errorf("script_id is required")
 	}
+	if err := validateSlackNotify(preset.SlackNotify); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateSchedule(preset.Schedule, preset.ScheduleEnabled); err != nil {
+		return nil, err
+	}
+	if err := r.validateRunSettings(preset.TimeoutSeconds, preset.Retries, preset.RetryBackoff, preset.NotifyChannel); err != nil {
+		return nil, err
+	}
 
 	// Serialize env_var_ids to JSON
 	envVarIDsJSON, err := json.Marshal(preset.EnvVarIDs)
@@ -40,8 +57,8 @@ func (r *ScriptPresetRepository) Create(preset *models.ScriptPresetCreate) (*mod
 
 	result, err := r.db.GetConnection().Exec(
 		`INSERT INTO script_presets 
-		(name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, created_at, updated_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		(name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, slack_notify, exclusive, schedule, schedule_enabled, timeout_seconds, retries, retry_backoff_ms, notify_channel, created_at, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		preset.Name,
 		preset.Description,
 		preset.ScriptID,
@@ -50,6 +67,14 @@ func (r *ScriptPresetRepository) Create(preset *models.ScriptPresetCreate) (*mod
 		preset.ServerID,
 		preset.SSHKeyID,
 		preset.User,
+		preset.SlackNotify,
+		boolToInt(preset.Exclusive),
+		preset.Schedule,
+		boolToInt(preset.ScheduleEnabled),
+		preset.TimeoutSeconds,
+		preset.Retries,
+		preset.RetryBackoff,
+		strings.TrimSpace(preset.NotifyChannel),
 		now,
 		now,
 	)
@@ -72,8 +97,18 @@ func (r *ScriptPresetRepository) Create(preset *models.ScriptPresetCreate) (*mod
 		ServerID:    preset.ServerID,
 		SSHKeyID:    preset.SSHKeyID,
 		User:        preset.User,
+		SlackNotify: preset.SlackNotify,
+		Exclusive:   preset.Exclusive,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		Schedule:        preset.Schedule,
+		ScheduleEnabled: preset.ScheduleEnabled,
+
+		TimeoutSeconds: preset.TimeoutSeconds,
+		Retries:        preset.Retries,
+		RetryBackoff:   preset.RetryBackoff,
+		NotifyChannel:  strings.TrimSpace(preset.NotifyChannel),
 	}, nil
 }
 
@@ -81,14 +116,15 @@ func (r *ScriptPresetRepository) Create(preset *models.ScriptPresetCreate) (*mod
 func (r *ScriptPresetRepository) GetByID(id int64) (*models.ScriptPreset, error) {
 	var preset models.ScriptPreset
 	var description, envVarIDsJSON, user sql.NullString
-	var serverID, sshKeyID sql.NullInt64
+	var serverID, sshKeyID, lastRunHistoryID sql.NullInt64
+	var lastRunAt sql.NullTime
 	var isRemote int
 
 	err := r.db.GetConnection().QueryRow(
-		`SELECT id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, created_at, updated_at 
+		`SELECT id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, slack_notify, exclusive, created_at, updated_at, schedule, schedule_enabled, last_run_at, last_run_status, last_run_error, last_run_history_id, timeout_seconds, retries, retry_backoff_ms, notify_channel 
 		FROM script_presets WHERE id = ?`,
 		id,
-	).Scan(&preset.ID, &preset.Name, &description, &preset.ScriptID, &envVarIDsJSON, &isRemote, &serverID, &sshKeyID, &user, &preset.CreatedAt, &preset.UpdatedAt)
+	).Scan(&preset.ID, &preset.Name, &description, &preset.ScriptID, &envVarIDsJSON, &isRemote, &serverID, &sshKeyID, &user, &preset.SlackNotify, &preset.Exclusive, &preset.CreatedAt, &preset.UpdatedAt, &preset.Schedule, &preset.ScheduleEnabled, &lastRunAt, &preset.LastRunStatus, &preset.LastRunError, &lastRunHistoryID, &preset.TimeoutSeconds, &preset.Retries, &preset.RetryBackoff, &preset.NotifyChannel)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("script preset not found")
@@ -110,6 +146,12 @@ func (r *ScriptPresetRepository) GetByID(id int64) (*models.ScriptPreset, error)
 	if sshKeyID.Valid {
 		preset.SSHKeyID = &sshKeyID.Int64
 	}
+	if lastRunAt.Valid {
+		preset.LastRunAt = &lastRunAt.Time
+	}
+	if lastRunHistoryID.Valid {
+		preset.LastRunHistoryID = &lastRunHistoryID.Int64
+	}
 
 	preset.IsRemote = isRemote != 0
 
@@ -127,12 +169,36 @@ func (r *ScriptPresetRepository) GetByID(id int64) (*models.ScriptPreset, error)
 	return &preset, nil
 }
 
+// scriptPresetColumns are the columns read by scanPreset, in order
+const scriptPresetColumns = "id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, slack_notify, exclusive, created_at, updated_at, schedule, schedule_enabled, last_run_at, last_run_status, last_run_error, last_run_history_id, timeout_seconds, retries, retry_backoff_ms, notify_channel"
+
+// scriptPresetSortColumns maps the fields script presets can be sorted by to their columns
+var scriptPresetSortColumns = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 // GetAll retrieves all script presets
 func (r *ScriptPresetRepository) GetAll() ([]*models.ScriptPreset, error) {
-	rows, err := r.db.GetConnection().Query(
-		`SELECT id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, created_at, updated_at 
-		FROM script_presets ORDER BY name ASC`,
-	)
+	return r.query("SELECT " + scriptPresetColumns + " FROM script_presets ORDER BY name ASC")
+}
+
+// List retrieves a page of the script presets matching a filter, and the number of matches
+// The query is matched against the name and description
+func (r *ScriptPresetRepository) List(filter models.ListFilter) ([]*models.ScriptPreset, int, error) {
+	q := newListQuery("script_presets")
+	q.text(filter, "name", "description")
+	query, err := q.selectSQL(scriptPresetColumns, filter, scriptPresetSortColumns, "name ASC")
+	if err != nil {
+		return nil, 0, err
+	}
+	return runList(r.db, q, filter, query, r.query)
+}
+
+// query retrieves the script presets selected with scriptPresetColumns
+func (r *ScriptPresetRepository) query(query string, args ...any) ([]*models.ScriptPreset, error) {
+	rows, err := r.db.GetConnection().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query script presets: %w", err)
 	}
@@ -157,7 +223,7 @@ func (r *ScriptPresetRepository) GetAll() ([]*models.ScriptPreset, error) {
 // GetByScriptID retrieves all presets for a specific script
 func (r *ScriptPresetRepository) GetByScriptID(scriptID int64) ([]*models.ScriptPreset, error) {
 	rows, err := r.db.GetConnection().Query(
-		`SELECT id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, created_at, updated_at 
+		`SELECT id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, slack_notify, exclusive, created_at, updated_at, schedule, schedule_enabled, last_run_at, last_run_status, last_run_error, last_run_history_id, timeout_seconds, retries, retry_backoff_ms, notify_channel 
 		FROM script_presets WHERE script_id = ? ORDER BY name ASC`,
 		scriptID,
 	)
@@ -215,6 +281,39 @@ func (r *ScriptPresetRepository) Update(id int64, update *models.ScriptPresetUpd
 	if update.User != "" {
 		existing.User = update.User
 	}
+	if update.SlackNotify != nil {
+		if err := validateSlackNotify(*update.SlackNotify); err != nil {
+			return nil, err
+		}
+		existing.SlackNotify = *update.SlackNotify
+	}
+	if update.Exclusive != nil {
+		existing.Exclusive = *update.Exclusive
+	}
+	if update.Schedule != nil {
+		existing.Schedule = *update.Schedule
+	}
+	if update.ScheduleEnabled != nil {
+		existing.ScheduleEnabled = *update.ScheduleEnabled
+	}
+	if err := validation.ValidateSchedule(existing.Schedule, existing.ScheduleEnabled); err != nil {
+		return nil, err
+	}
+	if update.TimeoutSeconds != nil {
+		existing.TimeoutSeconds = *update.TimeoutSeconds
+	}
+	if update.Retries != nil {
+		existing.Retries = *update.Retries
+	}
+	if update.RetryBackoff != nil {
+		existing.RetryBackoff = *update.RetryBackoff
+	}
+	if update.NotifyChannel != nil {
+		existing.NotifyChannel = strings.TrimSpace(*update.NotifyChannel)
+	}
+	if err := r.validateRunSettings(existing.TimeoutSeconds, existing.Retries, existing.RetryBackoff, existing.NotifyChannel); err != nil {
+		return nil, err
+	}
 
 	existing.UpdatedAt = time.Now().UTC()
 
@@ -226,7 +325,7 @@ func (r *ScriptPresetRepository) Update(id int64, update *models.ScriptPresetUpd
 
 	_, err = r.db.GetConnection().Exec(
 		`UPDATE script_presets 
-		SET name = ?, description = ?, script_id = ?, env_var_ids = ?, is_remote = ?, server_id = ?, ssh_key_id = ?, user = ?, updated_at = ? 
+		SET name = ?, description = ?, script_id = ?, env_var_ids = ?, is_remote = ?, server_id = ?, ssh_key_id = ?, user = ?, slack_notify = ?, exclusive = ?, schedule = ?, schedule_enabled = ?, timeout_seconds = ?, retries = ?, retry_backoff_ms = ?, notify_channel = ?, updated_at = ? 
 		WHERE id = ?`,
 		existing.Name,
 		existing.Description,
@@ -236,6 +335,14 @@ func (r *ScriptPresetRepository) Update(id int64, update *models.ScriptPresetUpd
 		existing.ServerID,
 		existing.SSHKeyID,
 		existing.User,
+		existing.SlackNotify,
+		boolToInt(existing.Exclusive),
+		existing.Schedule,
+		boolToInt(existing.ScheduleEnabled),
+		existing.TimeoutSeconds,
+		existing.Retries,
+		existing.RetryBackoff,
+		existing.NotifyChannel,
 		existing.UpdatedAt,
 		id,
 	)
@@ -246,6 +353,23 @@ func (r *ScriptPresetRepository) Update(id int64, update *models.ScriptPresetUpd
 	return existing, nil
 }
 
+// GetScheduled retrieves the script presets whose schedule is enabled
+func (r *ScriptPresetRepository) GetScheduled() ([]*models.ScriptPreset, error) {
+	return r.query("SELECT " + scriptPresetColumns + " FROM script_presets WHERE schedule_enabled = 1 AND schedule != '' ORDER BY id ASC")
+}
+
+// RecordRun stores the outcome of a scheduled run of a script preset
+func (r *ScriptPresetRepository) RecordRun(id int64, at time.Time, status, runErr string, historyID *int64) error {
+	_, err := r.db.GetConnection().Exec(
+		"UPDATE script_presets SET last_run_at = ?, last_run_status = ?, last_run_error = ?, last_run_history_id = ? WHERE id = ?",
+		at.UTC(), status, runErr, historyID, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record script preset run: %w", err)
+	}
+	return nil
+}
+
 // Delete deletes a script preset by its ID
 func (r *ScriptPresetRepository) Delete(id int64) error {
 	result, err := r.db.GetConnection().Exec("DELETE FROM script_presets WHERE id = ?", id)
@@ -269,14 +393,15 @@ func (r *ScriptPresetRepository) Delete(id int64) error {
 func (r *ScriptPresetRepository) GetByName(name string) (*models.ScriptPreset, error) {
 	var preset models.ScriptPreset
 	var description, envVarIDsJSON, user sql.NullString
-	var serverID, sshKeyID sql.NullInt64
+	var serverID, sshKeyID, lastRunHistoryID sql.NullInt64
+	var lastRunAt sql.NullTime
 	var isRemote int
 
 	err := r.db.GetConnection().QueryRow(
-		`SELECT id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, created_at, updated_at 
+		`SELECT id, name, description, script_id, env_var_ids, is_remote, server_id, ssh_key_id, user, slack_notify, exclusive, created_at, updated_at, schedule, schedule_enabled, last_run_at, last_run_status, last_run_error, last_run_history_id, timeout_seconds, retries, retry_backoff_ms, notify_channel 
 		FROM script_presets WHERE name = ?`,
 		name,
-	).Scan(&preset.ID, &preset.Name, &description, &preset.ScriptID, &envVarIDsJSON, &isRemote, &serverID, &sshKeyID, &user, &preset.CreatedAt, &preset.UpdatedAt)
+	).Scan(&preset.ID, &preset.Name, &description, &preset.ScriptID, &envVarIDsJSON, &isRemote, &serverID, &sshKeyID, &user, &preset.SlackNotify, &preset.Exclusive, &preset.CreatedAt, &preset.UpdatedAt, &preset.Schedule, &preset.ScheduleEnabled, &lastRunAt, &preset.LastRunStatus, &preset.LastRunError, &lastRunHistoryID, &preset.TimeoutSeconds, &preset.Retries, &preset.RetryBackoff, &preset.NotifyChannel)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("script preset not found")
@@ -298,6 +423,12 @@ func (r *ScriptPresetRepository) GetByName(name string) (*models.ScriptPreset, e
 	if sshKeyID.Valid {
 		preset.SSHKeyID = &sshKeyID.Int64
 	}
+	if lastRunAt.Valid {
+		preset.LastRunAt = &lastRunAt.Time
+	}
+	if lastRunHistoryID.Valid {
+		preset.LastRunHistoryID = &lastRunHistoryID.Int64
+	}
 
 	preset.IsRemote = isRemote != 0
 
@@ -319,10 +450,11 @@ func (r *ScriptPresetRepository) GetByName(name string) (*models.ScriptPreset, e
 func (r *ScriptPresetRepository) scanPreset(rows *sql.Rows) (*models.ScriptPreset, error) {
 	var preset models.ScriptPreset
 	var description, envVarIDsJSON, user sql.NullString
-	var serverID, sshKeyID sql.NullInt64
+	var serverID, sshKeyID, lastRunHistoryID sql.NullInt64
+	var lastRunAt sql.NullTime
 	var isRemote int
 
-	if err := rows.Scan(&preset.ID, &preset.Name, &description, &preset.ScriptID, &envVarIDsJSON, &isRemote, &serverID, &sshKeyID, &user, &preset.CreatedAt, &preset.UpdatedAt); err != nil {
+	if err := rows.Scan(&preset.ID, &preset.Name, &description, &preset.ScriptID, &envVarIDsJSON, &isRemote, &serverID, &sshKeyID, &user, &preset.SlackNotify, &preset.Exclusive, &preset.CreatedAt, &preset.UpdatedAt, &preset.Schedule, &preset.ScheduleEnabled, &lastRunAt, &preset.LastRunStatus, &preset.LastRunError, &lastRunHistoryID, &preset.TimeoutSeconds, &preset.Retries, &preset.RetryBackoff, &preset.NotifyChannel); err != nil {
 		return nil, fmt.Errorf("failed to scan script preset: %w", err)
 	}
 
@@ -339,6 +471,12 @@ func (r *ScriptPresetRepository) scanPreset(rows *sql.Rows) (*models.ScriptPrese
 	if sshKeyID.Valid {
 		preset.SSHKeyID = &sshKeyID.Int64
 	}
+	if lastRunAt.Valid {
+		preset.LastRunAt = &lastRunAt.Time
+	}
+	if lastRunHistoryID.Valid {
+		preset.LastRunHistoryID = &lastRunHistoryID.Int64
+	}
 
 	preset.IsRemote = isRemote != 0
 
@@ -356,6 +494,45 @@ func (r *ScriptPresetRepository) scanPreset(rows *sql.Rows) (*models.ScriptPrese
 	return &preset, nil
 }
 
+// validateSlackNotify checks a preset's Slack notification mode
+func validateSlackNotify(mode string) error {
+	if models.ValidSlackNotify(mode) {
+		return nil
+	}
+	return fmt.Errorf("invalid slack_notify %q (must be always, failures or empty)", mode)
+}
+
+// validateRunSettings checks the timeout, retry and notification channel overrides of a preset
+// A webhook channel must name a stored webhook.
+func (r *ScriptPresetRepository) validateRunSettings(timeoutSeconds, retries, retryBackoffMs int, channel string) error {
+	if timeoutSeconds < 0 {
+		return fmt.Errorf("%w: timeout_seconds cannot be negative", ErrInvalidPreset)
+	}
+	if retries < 0 || retries > executor.MaxRetries {
+		return fmt.Errorf("%w: retries must be between 0 and %d", ErrInvalidPreset, executor.MaxRetries)
+	}
+	if retryBackoffMs < 0 || time.Duration(retryBackoffMs)*time.Millisecond > executor.MaxRetryDelay {
+		return fmt.Errorf("%w: retry_backoff_ms must be between 0 and %d", ErrInvalidPreset, executor.MaxRetryDelay.Milliseconds())
+	}
+	if channel = strings.TrimSpace(channel); channel == "" {
+		return nil
+	}
+	kind, arg, err := notify.ParseChannel(channel)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPreset, err)
+	}
+	if kind == notify.ChannelWebhook {
+		var count int
+		if err := r.db.GetConnection().QueryRow("SELECT COUNT(*) FROM webhooks WHERE name = ?", arg).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check webhook: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: notify_channel %q names an unknown webhook", ErrInvalidPreset, channel)
+		}
+	}
+	return nil
+}
+
 // boolToInt converts a boolean to an integer (0 or 1)
 func boolToInt(b bool) int {
 	if b {
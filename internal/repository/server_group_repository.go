@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// serverGroupColumns lists the columns read by scanServerGroup
+const serverGroupColumns = `id, name, description, default_username, default_port, default_ssh_key_id, created_at, updated_at,
+	(SELECT COUNT(*) FROM servers WHERE servers.group_name = server_groups.name)`
+
+// ServerGroupRepository handles database operations for server groups
+type ServerGroupRepository struct {
+	db *database.DB
+}
+
+// NewServerGroupRepository creates a new server group repository
+func NewServerGroupRepository(db *database.DB) *ServerGroupRepository {
+	return &ServerGroupRepository{db: db}
+}
+
+// Create inserts a new server group
+func (r *ServerGroupRepository) Create(create *models.ServerGroupCreate) (*models.ServerGroup, error) {
+	now := time.Now().UTC()
+	group := &models.ServerGroup{
+		Name:            strings.TrimSpace(create.Name),
+		Description:     create.Description,
+		DefaultUsername: create.DefaultUsername,
+		DefaultPort:     create.DefaultPort,
+		DefaultSSHKeyID: create.DefaultSSHKeyID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if group.DefaultSSHKeyID != nil && *group.DefaultSSHKeyID == 0 {
+		group.DefaultSSHKeyID = nil
+	}
+	if err := validateServerGroup(r.db.GetConnection(), group); err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO server_groups (name, description, default_username, default_port, default_ssh_key_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		group.Name,
+		group.Description,
+		group.DefaultUsername,
+		group.DefaultPort,
+		group.DefaultSSHKeyID,
+		group.CreatedAt,
+		group.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a server group named %q already exists", group.Name)
+		}
+		return nil, fmt.Errorf("failed to create server group: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	group.ID = id
+
+	return group, nil
+}
+
+// GetByID retrieves a server group by its ID
+func (r *ServerGroupRepository) GetByID(id int64) (*models.ServerGroup, error) {
+	return getServerGroup(r.db.GetConnection(), "id = ?", id)
+}
+
+// GetByName retrieves a server group by its name
+func (r *ServerGroupRepository) GetByName(name string) (*models.ServerGroup, error) {
+	return getServerGroup(r.db.GetConnection(), "name = ?", name)
+}
+
+// getServerGroup reads the server group matching where with q
+func getServerGroup(q dbtx, where string, args ...any) (*models.ServerGroup, error) {
+	group, err := scanServerGroup(q.QueryRow("SELECT "+serverGroupColumns+" FROM server_groups WHERE "+where, args...))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("server group not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server group: %w", err)
+	}
+	return group, nil
+}
+
+// GetAll retrieves all server groups ordered by name
+func (r *ServerGroupRepository) GetAll() ([]*models.ServerGroup, error) {
+	rows, err := r.db.GetConnection().Query("SELECT " + serverGroupColumns + " FROM server_groups ORDER BY name ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query server groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*models.ServerGroup
+	for rows.Next() {
+		group, err := scanServerGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan server group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating server groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// Update updates a server group
+// A new name is carried over to the group's servers and server group permissions in the same transaction.
+func (r *ServerGroupRepository) Update(id int64, update *models.ServerGroupUpdate) (*models.ServerGroup, error) {
+	tx, err := r.db.GetConnection().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := getServerGroup(tx, "id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	oldName := existing.Name
+
+	if name := strings.TrimSpace(update.Name); name != "" && name != oldName {
+		if oldName == models.DefaultServerGroup {
+			return nil, fmt.Errorf("the %q server group cannot be renamed", models.DefaultServerGroup)
+		}
+		existing.Name = name
+	}
+	if update.Description != nil {
+		existing.Description = *update.Description
+	}
+	if update.DefaultUsername != nil {
+		existing.DefaultUsername = *update.DefaultUsername
+	}
+	if update.DefaultPort != nil {
+		existing.DefaultPort = *update.DefaultPort
+	}
+	if update.DefaultSSHKeyID != nil {
+		existing.DefaultSSHKeyID = update.DefaultSSHKeyID
+		if *update.DefaultSSHKeyID == 0 {
+			existing.DefaultSSHKeyID = nil
+		}
+	}
+	if err := validateServerGroup(tx, existing); err != nil {
+		return nil, err
+	}
+
+	existing.UpdatedAt = time.Now().UTC()
+
+	_, err = tx.Exec(
+		"UPDATE server_groups SET name = ?, description = ?, default_username = ?, default_port = ?, default_ssh_key_id = ?, updated_at = ? WHERE id = ?",
+		existing.Name,
+		existing.Description,
+		existing.DefaultUsername,
+		existing.DefaultPort,
+		existing.DefaultSSHKeyID,
+		existing.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a server group named %q already exists", existing.Name)
+		}
+		return nil, fmt.Errorf("failed to update server group: %w", err)
+	}
+
+	if existing.Name != oldName {
+		if _, err := tx.Exec("UPDATE servers SET group_name = ? WHERE group_name = ?", existing.Name, oldName); err != nil {
+			return nil, fmt.Errorf("failed to move servers to the renamed group: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE group_permissions SET group_name = ? WHERE resource_type = ? AND group_name = ?",
+			existing.Name, models.ResourceTypeServers, oldName); err != nil {
+			return nil, fmt.Errorf("failed to move permissions to the renamed group: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit server group: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete deletes an empty server group and its server group permissions
+func (r *ServerGroupRepository) Delete(id int64) error {
+	tx, err := r.db.GetConnection().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	group, err := getServerGroup(tx, "id = ?", id)
+	if err != nil {
+		return err
+	}
+	if group.Name == models.DefaultServerGroup {
+		return fmt.Errorf("the %q server group cannot be deleted", models.DefaultServerGroup)
+	}
+	if group.ServerCount > 0 {
+		return fmt.Errorf("server group %q still has %d servers", group.Name, group.ServerCount)
+	}
+
+	if _, err := tx.Exec("DELETE FROM server_groups WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete server group: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM group_permissions WHERE resource_type = ? AND group_name = ?", models.ResourceTypeServers, group.Name); err != nil {
+		return fmt.Errorf("failed to delete server group permissions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit server group deletion: %w", err)
+	}
+	return nil
+}
+
+// AddServers moves servers into a server group
+func (r *ServerGroupRepository) AddServers(id int64, serverIDs []int64) error {
+	tx, err := r.db.GetConnection().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	group, err := getServerGroup(tx, "id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, serverID := range serverIDs {
+		result, err := tx.Exec("UPDATE servers SET group_name = ?, updated_at = ? WHERE id = ?", group.Name, now, serverID)
+		if err != nil {
+			return fmt.Errorf("failed to add server %d: %w", serverID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("server %d not found", serverID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit server group members: %w", err)
+	}
+	return nil
+}
+
+// RemoveServer moves a server of a server group back to the default group
+func (r *ServerGroupRepository) RemoveServer(id, serverID int64) error {
+	group, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		"UPDATE servers SET group_name = ?, updated_at = ? WHERE id = ? AND group_name = ?",
+		models.DefaultServerGroup, time.Now().UTC(), serverID, group.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove server: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("server %d not found in group %q", serverID, group.Name)
+	}
+	return nil
+}
+
+// ensureServerGroup creates the server group named name with q if it does not exist yet,
+// and returns its defaults for new servers
+func ensureServerGroup(q dbtx, name string) (username string, port int, err error) {
+	now := time.Now().UTC()
+	if _, err := q.Exec("INSERT OR IGNORE INTO server_groups (name, created_at, updated_at) VALUES (?, ?, ?)", name, now, now); err != nil {
+		return "", 0, fmt.Errorf("failed to create server group: %w", err)
+	}
+	if err := q.QueryRow("SELECT default_username, default_port FROM server_groups WHERE name = ?", name).Scan(&username, &port); err != nil {
+		return "", 0, fmt.Errorf("failed to get server group: %w", err)
+	}
+	return username, port, nil
+}
+
+// scanServerGroup reads a server group from a query result
+func scanServerGroup(row rowScanner) (*models.ServerGroup, error) {
+	var group models.ServerGroup
+	var keyID sql.NullInt64
+	if err := row.Scan(&group.ID, &group.Name, &group.Description, &group.DefaultUsername, &group.DefaultPort, &keyID,
+		&group.CreatedAt, &group.UpdatedAt, &group.ServerCount); err != nil {
+		return nil, err
+	}
+	if keyID.Valid {
+		group.DefaultSSHKeyID = &keyID.Int64
+	}
+	return &group, nil
+}
+
+// validateServerGroup checks the name and defaults of a server group with q
+func validateServerGroup(q dbtx, group *models.ServerGroup) error {
+	if group.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if group.DefaultPort < 0 || group.DefaultPort > 65535 {
+		return fmt.Errorf("default_port must be between 0 and 65535")
+	}
+	if group.DefaultSSHKeyID != nil {
+		var exists int
+		if err := q.QueryRow("SELECT 1 FROM ssh_keys WHERE id = ?", *group.DefaultSSHKeyID).Scan(&exists); err != nil {
+			return fmt.Errorf("default SSH key %d not found", *group.DefaultSSHKeyID)
+		}
+	}
+	return nil
+}
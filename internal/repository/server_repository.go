@@ -21,38 +21,85 @@ func NewServerRepository(db *database.DB) *ServerRepository {
 
 // Create creates a new server in the database
 func (r *ServerRepository) Create(server *models.ServerCreate) (*models.Server, error) {
+	return createServer(r.db.GetConnection(), server)
+}
+
+// createServer inserts a server with q
+func createServer(q dbtx, server *models.ServerCreate) (*models.Server, error) {
 	// Validate that at least one field is provided
 	if server.Name == "" && server.IPAddress == "" {
 		return nil, fmt.Errorf("at least one of name or ip_address must be provided")
 	}
 
-	// Default port to 22 if not provided or invalid
+	if server.MaxParallel < 0 {
+		return nil, fmt.Errorf("max_parallel cannot be negative")
+	}
+
+	// Default group to "default" if not provided
+	group := server.Group
+	if group == "" {
+		group = models.DefaultServerGroup
+	}
+
+	// Servers join the group, creating it if needed, and take its defaults
+	groupUsername, groupPort, err := ensureServerGroup(q, group)
+	if err != nil {
+		return nil, err
+	}
+
+	// Default port to the group's, else 22, if not provided or invalid
 	port := server.Port
+	if port <= 0 {
+		port = groupPort
+	}
 	if port <= 0 {
 		port = 22
 	}
 
-	// Default username to root if not provided
+	// Default username to the group's, else root, if not provided
 	username := server.Username
+	if username == "" {
+		username = groupUsername
+	}
 	if username == "" {
 		username = "root"
 	}
 
-	// Default group to "default" if not provided
-	group := server.Group
-	if group == "" {
-		group = "default"
+	transport := server.Transport
+	if transport == "" {
+		transport = models.ServerTransportSSH
+	}
+	hostKeyPolicy := server.HostKeyPolicy
+	if hostKeyPolicy == "" {
+		hostKeyPolicy = models.HostKeyPolicyTOFU
+	}
+
+	secretEncrypted, err := encryptSSMSecret(server.SSMSecretAccessKey)
+	if err != nil {
+		return nil, err
 	}
 
 	now := time.Now().UTC()
 
-	result, err := r.db.GetConnection().Exec(
-		"INSERT INTO servers (name, ip_address, port, username, group_name, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+	result, err := q.Exec(
+		"INSERT INTO servers (name, ip_address, port, username, group_name, requires_approval, max_parallel, inventory_id, transport, ssm_instance_id, ssm_region, ssm_role_arn, ssm_access_key_id, ssm_secret_access_key_encrypted, host_key_policy, host_key_fingerprint, mac_address, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		nullString(server.Name),
 		nullString(server.IPAddress),
 		port,
 		username,
 		group,
+		boolToInt(server.RequiresApproval),
+		server.MaxParallel,
+		server.InventoryID,
+		transport,
+		server.SSMInstanceID,
+		server.SSMRegion,
+		server.SSMRoleARN,
+		server.SSMAccessKeyID,
+		secretEncrypted,
+		hostKeyPolicy,
+		server.HostKeyFingerprint,
+		server.MACAddress,
 		now,
 		now,
 	)
@@ -66,26 +113,41 @@ func (r *ServerRepository) Create(server *models.ServerCreate) (*models.Server,
 	}
 
 	return &models.Server{
-		ID:        id,
-		Name:      server.Name,
-		IPAddress: server.IPAddress,
-		Port:      port,
-		Username:  username,
-		Group:     group,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:                    id,
+		Name:                  server.Name,
+		IPAddress:             server.IPAddress,
+		Port:                  port,
+		Username:              username,
+		Group:                 group,
+		RequiresApproval:      server.RequiresApproval,
+		MaxParallel:           server.MaxParallel,
+		InventoryID:           server.InventoryID,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+		Transport:             transport,
+		SSMInstanceID:         server.SSMInstanceID,
+		SSMRegion:             server.SSMRegion,
+		SSMRoleARN:            server.SSMRoleARN,
+		SSMAccessKeyID:        server.SSMAccessKeyID,
+		SSMSecretAccessKey:    server.SSMSecretAccessKey,
+		HasSSMSecretAccessKey: server.SSMSecretAccessKey != "",
+		HostKeyPolicy:         hostKeyPolicy,
+		HostKeyFingerprint:    server.HostKeyFingerprint,
+		MACAddress:            server.MACAddress,
 	}, nil
 }
 
 // GetByID retrieves a server by its ID
 func (r *ServerRepository) GetByID(id int64) (*models.Server, error) {
-	var server models.Server
-	var name, ipAddress sql.NullString
+	return getServer(r.db.GetConnection(), id)
+}
 
-	err := r.db.GetConnection().QueryRow(
-		"SELECT id, name, ip_address, port, username, group_name, created_at, updated_at FROM servers WHERE id = ?",
+// getServer reads a server with q
+func getServer(q dbtx, id int64) (*models.Server, error) {
+	server, err := scanServer(q.QueryRow(
+		"SELECT "+serverColumns+" FROM servers WHERE id = ?",
 		id,
-	).Scan(&server.ID, &name, &ipAddress, &server.Port, &server.Username, &server.Group, &server.CreatedAt, &server.UpdatedAt)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("server not found")
@@ -94,49 +156,86 @@ func (r *ServerRepository) GetByID(id int64) (*models.Server, error) {
 		return nil, fmt.Errorf("failed to get server: %w", err)
 	}
 
+	return server, nil
+}
+
+// serverColumns are the columns scanned by scanServer
+const serverColumns = "id, name, ip_address, port, username, group_name, requires_approval, max_parallel, inventory_id, created_at, updated_at, transport, ssm_instance_id, ssm_region, ssm_role_arn, ssm_access_key_id, ssm_secret_access_key_encrypted, host_key_policy, host_key_fingerprint, mac_address"
+
+// scanServer reads a server from a query result and decrypts its stored AWS credentials
+func scanServer(row rowScanner) (*models.Server, error) {
+	var server models.Server
+	var name, ipAddress sql.NullString
+	var secretEncrypted []byte
+
+	if err := row.Scan(&server.ID, &name, &ipAddress, &server.Port, &server.Username, &server.Group, &server.RequiresApproval, &server.MaxParallel, &server.InventoryID, &server.CreatedAt, &server.UpdatedAt,
+		&server.Transport, &server.SSMInstanceID, &server.SSMRegion, &server.SSMRoleARN, &server.SSMAccessKeyID, &secretEncrypted, &server.HostKeyPolicy, &server.HostKeyFingerprint, &server.MACAddress); err != nil {
+		return nil, err
+	}
+
+	if len(secretEncrypted) > 0 {
+		secret, err := database.Decrypt(secretEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt SSM secret access key: %w", err)
+		}
+		server.SSMSecretAccessKey = secret
+	}
+	server.HasSSMSecretAccessKey = server.SSMSecretAccessKey != ""
 	server.Name = name.String
 	server.IPAddress = ipAddress.String
 
 	return &server, nil
 }
 
-// GetAll retrieves all servers
-func (r *ServerRepository) GetAll() ([]*models.Server, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, ip_address, port, username, group_name, created_at, updated_at FROM servers ORDER BY group_name ASC, created_at DESC",
-	)
+// encryptSSMSecret encrypts a stored AWS secret access key, storing no value for an empty one
+func encryptSSMSecret(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, nil
+	}
+	encrypted, err := database.Encrypt(secret)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query servers: %w", err)
+		return nil, fmt.Errorf("failed to encrypt SSM secret access key: %w", err)
 	}
-	defer rows.Close()
+	return encrypted, nil
+}
 
-	var servers []*models.Server
-	for rows.Next() {
-		var server models.Server
-		var name, ipAddress sql.NullString
+// serverSortColumns maps the fields servers can be sorted by to their columns
+var serverSortColumns = map[string]string{
+	"name":       "name",
+	"ip_address": "ip_address",
+	"port":       "port",
+	"username":   "username",
+	"group":      "group_name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
 
-		if err := rows.Scan(&server.ID, &name, &ipAddress, &server.Port, &server.Username, &server.Group, &server.CreatedAt, &server.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan server: %w", err)
-		}
+// GetAll retrieves all servers
+func (r *ServerRepository) GetAll() ([]*models.Server, error) {
+	return r.query("SELECT " + serverColumns + " FROM servers ORDER BY group_name ASC, created_at DESC")
+}
 
-		server.Name = name.String
-		server.IPAddress = ipAddress.String
-		servers = append(servers, &server)
-	}
+// GetByGroup retrieves all servers in a specific group
+func (r *ServerRepository) GetByGroup(group string) ([]*models.Server, error) {
+	return r.query("SELECT "+serverColumns+" FROM servers WHERE group_name = ? ORDER BY created_at DESC", group)
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating servers: %w", err)
+// List retrieves a page of the servers matching a filter, and the number of matches
+// The query is matched against the name, IP address and username
+func (r *ServerRepository) List(filter models.ListFilter) ([]*models.Server, int, error) {
+	q := newListQuery("servers")
+	q.groups(filter)
+	q.text(filter, "name", "ip_address", "username")
+	query, err := q.selectSQL(serverColumns, filter, serverSortColumns, "group_name ASC, created_at DESC")
+	if err != nil {
+		return nil, 0, err
 	}
-
-	return servers, nil
+	return runList(r.db, q, filter, query, r.query)
 }
 
-// GetByGroup retrieves all servers in a specific group
-func (r *ServerRepository) GetByGroup(group string) ([]*models.Server, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, ip_address, port, username, group_name, created_at, updated_at FROM servers WHERE group_name = ? ORDER BY created_at DESC",
-		group,
-	)
+// query retrieves the servers selected with serverColumns
+func (r *ServerRepository) query(query string, args ...any) ([]*models.Server, error) {
+	rows, err := r.db.GetConnection().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query servers: %w", err)
 	}
@@ -144,16 +243,11 @@ func (r *ServerRepository) GetByGroup(group string) ([]*models.Server, error) {
 
 	var servers []*models.Server
 	for rows.Next() {
-		var server models.Server
-		var name, ipAddress sql.NullString
-
-		if err := rows.Scan(&server.ID, &name, &ipAddress, &server.Port, &server.Username, &server.Group, &server.CreatedAt, &server.UpdatedAt); err != nil {
+		server, err := scanServer(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan server: %w", err)
 		}
-
-		server.Name = name.String
-		server.IPAddress = ipAddress.String
-		servers = append(servers, &server)
+		servers = append(servers, server)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -163,10 +257,15 @@ func (r *ServerRepository) GetByGroup(group string) ([]*models.Server, error) {
 	return servers, nil
 }
 
-// GetGroups retrieves all distinct group names
+// GetByInventoryProvider retrieves the servers discovered from an inventory provider
+func (r *ServerRepository) GetByInventoryProvider(provider string) ([]*models.Server, error) {
+	return r.query("SELECT "+serverColumns+" FROM servers WHERE inventory_id LIKE ? ORDER BY created_at ASC", provider+":%")
+}
+
+// GetGroups retrieves all distinct group names, including server groups without servers
 func (r *ServerRepository) GetGroups() ([]string, error) {
 	rows, err := r.db.GetConnection().Query(
-		"SELECT DISTINCT group_name FROM servers ORDER BY group_name ASC",
+		"SELECT name FROM server_groups UNION SELECT group_name FROM servers ORDER BY 1 ASC",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query groups: %w", err)
@@ -191,8 +290,13 @@ func (r *ServerRepository) GetGroups() ([]string, error) {
 
 // Update updates an existing server
 func (r *ServerRepository) Update(id int64, update *models.ServerUpdate) (*models.Server, error) {
+	return updateServer(r.db.GetConnection(), id, update)
+}
+
+// updateServer changes a server with q
+func updateServer(q dbtx, id int64, update *models.ServerUpdate) (*models.Server, error) {
 	// Get existing server
-	existing, err := r.GetByID(id)
+	existing, err := getServer(q, id)
 	if err != nil {
 		return nil, err
 	}
@@ -214,10 +318,30 @@ func (r *ServerRepository) Update(id int64, update *models.ServerUpdate) (*model
 		existing.Username = update.Username
 	}
 
-	if update.Group != "" {
+	if update.Group != "" && update.Group != existing.Group {
+		if _, _, err := ensureServerGroup(q, update.Group); err != nil {
+			return nil, err
+		}
 		existing.Group = update.Group
 	}
 
+	if update.RequiresApproval != nil {
+		existing.RequiresApproval = *update.RequiresApproval
+	}
+
+	if update.MaxParallel != nil {
+		if *update.MaxParallel < 0 {
+			return nil, fmt.Errorf("max_parallel cannot be negative")
+		}
+		existing.MaxParallel = *update.MaxParallel
+	}
+
+	existing.ApplySSMUpdate(update)
+	existing.ApplyHostKeyUpdate(update)
+	if update.MACAddress != nil {
+		existing.MACAddress = *update.MACAddress
+	}
+
 	// Validate that at least one field is set after update
 	if existing.Name == "" && existing.IPAddress == "" {
 		return nil, fmt.Errorf("at least one of name or ip_address must be provided")
@@ -233,15 +357,31 @@ func (r *ServerRepository) Update(id int64, update *models.ServerUpdate) (*model
 		existing.Username = "root"
 	}
 
+	secretEncrypted, err := encryptSSMSecret(existing.SSMSecretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+
 	existing.UpdatedAt = time.Now().UTC()
 
-	_, err = r.db.GetConnection().Exec(
-		"UPDATE servers SET name = ?, ip_address = ?, port = ?, username = ?, group_name = ?, updated_at = ? WHERE id = ?",
+	_, err = q.Exec(
+		"UPDATE servers SET name = ?, ip_address = ?, port = ?, username = ?, group_name = ?, requires_approval = ?, max_parallel = ?, transport = ?, ssm_instance_id = ?, ssm_region = ?, ssm_role_arn = ?, ssm_access_key_id = ?, ssm_secret_access_key_encrypted = ?, host_key_policy = ?, host_key_fingerprint = ?, mac_address = ?, updated_at = ? WHERE id = ?",
 		nullString(existing.Name),
 		nullString(existing.IPAddress),
 		existing.Port,
 		existing.Username,
 		existing.Group,
+		boolToInt(existing.RequiresApproval),
+		existing.MaxParallel,
+		existing.Transport,
+		existing.SSMInstanceID,
+		existing.SSMRegion,
+		existing.SSMRoleARN,
+		existing.SSMAccessKeyID,
+		secretEncrypted,
+		existing.HostKeyPolicy,
+		existing.HostKeyFingerprint,
+		existing.MACAddress,
 		existing.UpdatedAt,
 		id,
 	)
@@ -254,7 +394,12 @@ func (r *ServerRepository) Update(id int64, update *models.ServerUpdate) (*model
 
 // Delete deletes a server by its ID
 func (r *ServerRepository) Delete(id int64) error {
-	result, err := r.db.GetConnection().Exec("DELETE FROM servers WHERE id = ?", id)
+	return deleteServer(r.db.GetConnection(), id)
+}
+
+// deleteServer removes a server with q
+func deleteServer(q dbtx, id int64) error {
+	result, err := q.Exec("DELETE FROM servers WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete server: %w", err)
 	}
@@ -271,6 +416,48 @@ func (r *ServerRepository) Delete(id int64) error {
 	return nil
 }
 
+// ApplyBatch creates, updates and deletes servers in one transaction, so either all or none are applied
+// It returns the created or updated server of each operation (nil for deletes), or a *BatchError
+func (r *ServerRepository) ApplyBatch(ops []models.ServerBatchOperation) ([]*models.Server, error) {
+	return applyBatch(r.db, len(ops), func(tx *sql.Tx, i int) (*models.Server, error) {
+		op := ops[i]
+		switch op.Action {
+		case models.BatchActionCreate:
+			var maxParallel int
+			if op.Server.MaxParallel != nil {
+				maxParallel = *op.Server.MaxParallel
+			}
+			return createServer(tx, &models.ServerCreate{
+				Name:             op.Server.Name,
+				IPAddress:        op.Server.IPAddress,
+				Port:             op.Server.Port,
+				Username:         op.Server.Username,
+				Group:            op.Server.Group,
+				RequiresApproval: op.Server.RequiresApproval != nil && *op.Server.RequiresApproval,
+				MaxParallel:      maxParallel,
+
+				Transport:          op.Server.Transport,
+				SSMInstanceID:      derefString(op.Server.SSMInstanceID),
+				SSMRegion:          op.Server.SSMRegion,
+				SSMRoleARN:         derefString(op.Server.SSMRoleARN),
+				SSMAccessKeyID:     derefString(op.Server.SSMAccessKeyID),
+				SSMSecretAccessKey: derefString(op.Server.SSMSecretAccessKey),
+
+				HostKeyPolicy:      op.Server.HostKeyPolicy,
+				HostKeyFingerprint: derefString(op.Server.HostKeyFingerprint),
+
+				MACAddress: derefString(op.Server.MACAddress),
+			})
+		case models.BatchActionUpdate:
+			return updateServer(tx, op.ID, &op.Server)
+		case models.BatchActionDelete:
+			return nil, deleteServer(tx, op.ID)
+		default:
+			return nil, fmt.Errorf("unknown action %q", op.Action)
+		}
+	})
+}
+
 // nullString converts an empty string to sql.NullString
 func nullString(s string) sql.NullString {
 	if s == "" {
@@ -278,3 +465,11 @@ func nullString(s string) sql.NullString {
 	}
 	return sql.NullString{String: s, Valid: true}
 }
+
+// derefString returns the value of an optional string, or "" if it is not set
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -66,11 +66,12 @@ func (r *SSHKeyRepository) Create(key *models.SSHKeyCreate) (*models.SSHKey, err
 func (r *SSHKeyRepository) GetByID(id int64) (*models.SSHKey, error) {
 	var key models.SSHKey
 	var encryptedKey []byte
+	var lastUsedAt sql.NullTime
 
 	err := r.db.GetConnection().QueryRow(
-		"SELECT id, name, private_key_encrypted, group_name, created_at, updated_at FROM ssh_keys WHERE id = ?",
+		"SELECT id, name, private_key_encrypted, group_name, last_used_at, use_count, created_at, updated_at FROM ssh_keys WHERE id = ?",
 		id,
-	).Scan(&key.ID, &key.Name, &encryptedKey, &key.Group, &key.CreatedAt, &key.UpdatedAt)
+	).Scan(&key.ID, &key.Name, &encryptedKey, &key.Group, &lastUsedAt, &key.UseCount, &key.CreatedAt, &key.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("SSH key not found")
@@ -78,6 +79,9 @@ func (r *SSHKeyRepository) GetByID(id int64) (*models.SSHKey, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get SSH key: %w", err)
 	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
 
 	// Decrypt the private key
 	decryptedKey, err := database.Decrypt(encryptedKey)
@@ -89,48 +93,45 @@ func (r *SSHKeyRepository) GetByID(id int64) (*models.SSHKey, error) {
 	return &key, nil
 }
 
+// sshKeyColumns are the columns scanned by query
+const sshKeyColumns = "id, name, private_key_encrypted, group_name, last_used_at, use_count, created_at, updated_at"
+
+// sshKeySortColumns maps the fields SSH keys can be sorted by to their columns
+var sshKeySortColumns = map[string]string{
+	"name":         "name",
+	"group":        "group_name",
+	"last_used_at": "last_used_at",
+	"use_count":    "use_count",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+}
+
 // GetAll retrieves all SSH keys
 func (r *SSHKeyRepository) GetAll() ([]*models.SSHKey, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, private_key_encrypted, group_name, created_at, updated_at FROM ssh_keys ORDER BY group_name ASC, created_at DESC",
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query SSH keys: %w", err)
-	}
-	defer rows.Close()
-
-	var keys []*models.SSHKey
-	for rows.Next() {
-		var key models.SSHKey
-		var encryptedKey []byte
-
-		if err := rows.Scan(&key.ID, &key.Name, &encryptedKey, &key.Group, &key.CreatedAt, &key.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan SSH key: %w", err)
-		}
-
-		// Decrypt the private key
-		decryptedKey, err := database.Decrypt(encryptedKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt private key: %w", err)
-		}
+	return r.query("SELECT " + sshKeyColumns + " FROM ssh_keys ORDER BY group_name ASC, created_at DESC")
+}
 
-		key.PrivateKey = decryptedKey
-		keys = append(keys, &key)
-	}
+// GetByGroup retrieves all SSH keys in a specific group
+func (r *SSHKeyRepository) GetByGroup(group string) ([]*models.SSHKey, error) {
+	return r.query("SELECT "+sshKeyColumns+" FROM ssh_keys WHERE group_name = ? ORDER BY created_at DESC", group)
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating SSH keys: %w", err)
+// List retrieves a page of the SSH keys matching a filter, and the number of matches
+// The query is matched against the name; only the keys on the page are decrypted
+func (r *SSHKeyRepository) List(filter models.ListFilter) ([]*models.SSHKey, int, error) {
+	q := newListQuery("ssh_keys")
+	q.groups(filter)
+	q.text(filter, "name")
+	query, err := q.selectSQL(sshKeyColumns, filter, sshKeySortColumns, "group_name ASC, created_at DESC")
+	if err != nil {
+		return nil, 0, err
 	}
-
-	return keys, nil
+	return runList(r.db, q, filter, query, r.query)
 }
 
-// GetByGroup retrieves all SSH keys in a specific group
-func (r *SSHKeyRepository) GetByGroup(group string) ([]*models.SSHKey, error) {
-	rows, err := r.db.GetConnection().Query(
-		"SELECT id, name, private_key_encrypted, group_name, created_at, updated_at FROM ssh_keys WHERE group_name = ? ORDER BY created_at DESC",
-		group,
-	)
+// query retrieves the SSH keys selected with sshKeyColumns and decrypts their private keys
+func (r *SSHKeyRepository) query(query string, args ...any) ([]*models.SSHKey, error) {
+	rows, err := r.db.GetConnection().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query SSH keys: %w", err)
 	}
@@ -140,10 +141,14 @@ func (r *SSHKeyRepository) GetByGroup(group string) ([]*models.SSHKey, error) {
 	for rows.Next() {
 		var key models.SSHKey
 		var encryptedKey []byte
+		var lastUsedAt sql.NullTime
 
-		if err := rows.Scan(&key.ID, &key.Name, &encryptedKey, &key.Group, &key.CreatedAt, &key.UpdatedAt); err != nil {
+		if err := rows.Scan(&key.ID, &key.Name, &encryptedKey, &key.Group, &lastUsedAt, &key.UseCount, &key.CreatedAt, &key.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan SSH key: %w", err)
 		}
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
 
 		// Decrypt the private key
 		decryptedKey, err := database.Decrypt(encryptedKey)
@@ -232,6 +237,29 @@ func (r *SSHKeyRepository) Update(id int64, update *models.SSHKeyUpdate) (*model
 	return existing, nil
 }
 
+// RecordUse records that an execution or terminal session used the SSH key
+func (r *SSHKeyRepository) RecordUse(id int64) error {
+	result, err := r.db.GetConnection().Exec(
+		"UPDATE ssh_keys SET last_used_at = ?, use_count = use_count + 1 WHERE id = ?",
+		time.Now().UTC(),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record SSH key use: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("SSH key not found")
+	}
+
+	return nil
+}
+
 // Delete deletes an SSH key by its ID
 func (r *SSHKeyRepository) Delete(id int64) error {
 	result, err := r.db.GetConnection().Exec("DELETE FROM ssh_keys WHERE id = ?", id)
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// encodeTags serializes tags to the JSON array stored in the tags column
+func encodeTags(tags []string) string {
+	data, err := json.Marshal(models.NormalizeTags(tags))
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// decodeTags parses the tags column, treating invalid values as no tags
+func decodeTags(value string) []string {
+	var tags []string
+	if err := json.Unmarshal([]byte(value), &tags); err != nil || tags == nil {
+		return []string{}
+	}
+	return tags
+}
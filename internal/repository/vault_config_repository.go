@@ -22,14 +22,17 @@ func NewVaultConfigRepository(db *database.DB) *VaultConfigRepository {
 // Get retrieves the Vault configuration (there should only be one)
 func (r *VaultConfigRepository) Get() (*models.VaultConfig, error) {
 	query := `
-		SELECT id, address, token_encrypted, namespace, mount_path, enabled, created_at, updated_at
+		SELECT id, address, token_encrypted, namespace, mount_path, enabled, created_at, updated_at,
+			auth_method, role_id, secret_id_encrypted, auth_mount, kubernetes_role, jwt_path,
+			ssh_ca_mount, ssh_ca_role, ssh_ca_ttl
 		FROM vault_config
 		LIMIT 1
 	`
 
 	var cfg models.VaultConfig
-	var tokenEncrypted []byte
-	var namespace sql.NullString
+	var tokenEncrypted, secretIDEncrypted []byte
+	var namespace, roleID, authMount, kubernetesRole, jwtPath sql.NullString
+	var sshCAMount, sshCARole, sshCATTL sql.NullString
 
 	err := r.db.QueryRow(query).Scan(
 		&cfg.ID,
@@ -40,6 +43,15 @@ func (r *VaultConfigRepository) Get() (*models.VaultConfig, error) {
 		&cfg.Enabled,
 		&cfg.CreatedAt,
 		&cfg.UpdatedAt,
+		&cfg.AuthMethod,
+		&roleID,
+		&secretIDEncrypted,
+		&authMount,
+		&kubernetesRole,
+		&jwtPath,
+		&sshCAMount,
+		&sshCARole,
+		&sshCATTL,
 	)
 
 	if err == sql.ErrNoRows {
@@ -58,9 +70,25 @@ func (r *VaultConfigRepository) Get() (*models.VaultConfig, error) {
 		cfg.Token = decrypted
 	}
 
+	// Decrypt AppRole secret ID
+	if len(secretIDEncrypted) > 0 {
+		decrypted, err := database.Decrypt(secretIDEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt vault secret ID: %w", err)
+		}
+		cfg.SecretID = decrypted
+	}
+
 	if namespace.Valid {
 		cfg.Namespace = namespace.String
 	}
+	cfg.RoleID = roleID.String
+	cfg.AuthMount = authMount.String
+	cfg.KubernetesRole = kubernetesRole.String
+	cfg.JWTPath = jwtPath.String
+	cfg.SSHCAMount = sshCAMount.String
+	cfg.SSHCARole = sshCARole.String
+	cfg.SSHCATTL = sshCATTL.String
 
 	return &cfg, nil
 }
@@ -79,18 +107,34 @@ func (r *VaultConfigRepository) CreateOrUpdate(create *models.VaultConfigCreate)
 		return nil, fmt.Errorf("failed to encrypt vault token: %w", err)
 	}
 
+	// Encrypt AppRole secret ID
+	var secretIDEncrypted []byte
+	if create.SecretID != "" {
+		secretIDEncrypted, err = database.Encrypt(create.SecretID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt vault secret ID: %w", err)
+		}
+	}
+
 	mountPath := create.MountPath
 	if mountPath == "" {
 		mountPath = "secret"
 	}
 
+	authMethod := create.AuthMethod
+	if authMethod == "" {
+		authMethod = "token"
+	}
+
 	now := time.Now().UTC()
 
 	if existing == nil {
 		// Create new config
 		query := `
-			INSERT INTO vault_config (address, token_encrypted, namespace, mount_path, enabled, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
+			INSERT INTO vault_config (address, token_encrypted, namespace, mount_path, enabled, created_at, updated_at,
+				auth_method, role_id, secret_id_encrypted, auth_mount, kubernetes_role, jwt_path,
+				ssh_ca_mount, ssh_ca_role, ssh_ca_ttl)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 
 		result, err := r.db.Exec(query,
@@ -101,6 +145,15 @@ func (r *VaultConfigRepository) CreateOrUpdate(create *models.VaultConfigCreate)
 			create.Enabled,
 			now,
 			now,
+			authMethod,
+			nullString(create.RoleID),
+			secretIDEncrypted,
+			nullString(create.AuthMount),
+			nullString(create.KubernetesRole),
+			nullString(create.JWTPath),
+			nullString(create.SSHCAMount),
+			nullString(create.SSHCARole),
+			nullString(create.SSHCATTL),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create vault config: %w", err)
@@ -108,21 +161,34 @@ func (r *VaultConfigRepository) CreateOrUpdate(create *models.VaultConfigCreate)
 
 		id, _ := result.LastInsertId()
 		return &models.VaultConfig{
-			ID:        id,
-			Address:   create.Address,
-			Token:     create.Token,
-			Namespace: create.Namespace,
-			MountPath: mountPath,
-			Enabled:   create.Enabled,
-			CreatedAt: now,
-			UpdatedAt: now,
+			ID:         id,
+			Address:    create.Address,
+			Token:      create.Token,
+			Namespace:  create.Namespace,
+			MountPath:  mountPath,
+			Enabled:    create.Enabled,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			AuthMethod: authMethod,
+			RoleID:     create.RoleID,
+			SecretID:   create.SecretID,
+			AuthMount:  create.AuthMount,
+
+			KubernetesRole: create.KubernetesRole,
+			JWTPath:        create.JWTPath,
+
+			SSHCAMount: create.SSHCAMount,
+			SSHCARole:  create.SSHCARole,
+			SSHCATTL:   create.SSHCATTL,
 		}, nil
 	}
 
 	// Update existing config
 	query := `
 		UPDATE vault_config
-		SET address = ?, token_encrypted = ?, namespace = ?, mount_path = ?, enabled = ?, updated_at = ?
+		SET address = ?, token_encrypted = ?, namespace = ?, mount_path = ?, enabled = ?, updated_at = ?,
+			auth_method = ?, role_id = ?, secret_id_encrypted = ?, auth_mount = ?, kubernetes_role = ?, jwt_path = ?,
+			ssh_ca_mount = ?, ssh_ca_role = ?, ssh_ca_ttl = ?
 		WHERE id = ?
 	`
 
@@ -133,6 +199,15 @@ func (r *VaultConfigRepository) CreateOrUpdate(create *models.VaultConfigCreate)
 		mountPath,
 		create.Enabled,
 		now,
+		authMethod,
+		nullString(create.RoleID),
+		secretIDEncrypted,
+		nullString(create.AuthMount),
+		nullString(create.KubernetesRole),
+		nullString(create.JWTPath),
+		nullString(create.SSHCAMount),
+		nullString(create.SSHCARole),
+		nullString(create.SSHCATTL),
 		existing.ID,
 	)
 	if err != nil {
@@ -140,14 +215,25 @@ func (r *VaultConfigRepository) CreateOrUpdate(create *models.VaultConfigCreate)
 	}
 
 	return &models.VaultConfig{
-		ID:        existing.ID,
-		Address:   create.Address,
-		Token:     create.Token,
-		Namespace: create.Namespace,
-		MountPath: mountPath,
-		Enabled:   create.Enabled,
-		CreatedAt: existing.CreatedAt,
-		UpdatedAt: now,
+		ID:         existing.ID,
+		Address:    create.Address,
+		Token:      create.Token,
+		Namespace:  create.Namespace,
+		MountPath:  mountPath,
+		Enabled:    create.Enabled,
+		CreatedAt:  existing.CreatedAt,
+		UpdatedAt:  now,
+		AuthMethod: authMethod,
+		RoleID:     create.RoleID,
+		SecretID:   create.SecretID,
+		AuthMount:  create.AuthMount,
+
+		KubernetesRole: create.KubernetesRole,
+		JWTPath:        create.JWTPath,
+
+		SSHCAMount: create.SSHCAMount,
+		SSHCARole:  create.SSHCARole,
+		SSHCATTL:   create.SSHCATTL,
 	}, nil
 }
 
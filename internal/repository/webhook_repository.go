@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+)
+
+// webhookColumns lists the columns read by scanWebhook
+const webhookColumns = `id, name, url, secret_encrypted, events, enabled, last_status, last_error, last_delivered_at, created_at, updated_at`
+
+// WebhookRepository handles database operations for webhooks
+type WebhookRepository struct {
+	db *database.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *database.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create inserts a new webhook, encrypting its secret
+func (r *WebhookRepository) Create(create *models.WebhookCreate) (*models.Webhook, error) {
+	now := time.Now().UTC()
+	w := &models.Webhook{
+		Name:      create.Name,
+		URL:       create.URL,
+		Secret:    create.Secret,
+		Events:    create.Events,
+		Enabled:   create.Enabled == nil || *create.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := normalizeWebhook(w); err != nil {
+		return nil, err
+	}
+
+	secretEncrypted, err := encryptWebhookSecret(w.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.GetConnection().Exec(
+		`INSERT INTO webhooks (name, url, secret_encrypted, events, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		w.Name,
+		w.URL,
+		secretEncrypted,
+		encodeWebhookEvents(w.Events),
+		boolToInt(w.Enabled),
+		w.CreatedAt,
+		w.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a webhook named %q already exists", w.Name)
+		}
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	w.ID = id
+
+	return w, nil
+}
+
+// GetByID retrieves a webhook by its ID, with its secret decrypted
+func (r *WebhookRepository) GetByID(id int64) (*models.Webhook, error) {
+	row := r.db.GetConnection().QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id)
+
+	w, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetAll retrieves all webhooks
+func (r *WebhookRepository) GetAll() ([]*models.Webhook, error) {
+	return r.query("SELECT " + webhookColumns + " FROM webhooks ORDER BY name ASC")
+}
+
+// GetEnabled retrieves the webhooks events are delivered to
+func (r *WebhookRepository) GetEnabled() ([]*models.Webhook, error) {
+	return r.query("SELECT " + webhookColumns + " FROM webhooks WHERE enabled = 1 ORDER BY id ASC")
+}
+
+// Update updates an existing webhook
+func (r *WebhookRepository) Update(id int64, update *models.WebhookUpdate) (*models.Webhook, error) {
+	existing, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != "" {
+		existing.Name = update.Name
+	}
+	if update.URL != "" {
+		existing.URL = update.URL
+	}
+	if update.Secret != nil {
+		existing.Secret = *update.Secret
+	}
+	if update.Events != nil {
+		existing.Events = update.Events
+	}
+	if update.Enabled != nil {
+		existing.Enabled = *update.Enabled
+	}
+	if err := normalizeWebhook(existing); err != nil {
+		return nil, err
+	}
+
+	secretEncrypted, err := encryptWebhookSecret(existing.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.UpdatedAt = time.Now().UTC()
+
+	_, err = r.db.GetConnection().Exec(
+		`UPDATE webhooks SET name = ?, url = ?, secret_encrypted = ?, events = ?, enabled = ?, updated_at = ?
+		WHERE id = ?`,
+		existing.Name,
+		existing.URL,
+		secretEncrypted,
+		encodeWebhookEvents(existing.Events),
+		boolToInt(existing.Enabled),
+		existing.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("a webhook named %q already exists", existing.Name)
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return existing, nil
+}
+
+// RecordDelivery stores the outcome of the latest delivery to a webhook
+func (r *WebhookRepository) RecordDelivery(id int64, deliveryErr error) error {
+	status, message := models.WebhookStatusDelivered, ""
+	if deliveryErr != nil {
+		status, message = models.WebhookStatusFailed, deliveryErr.Error()
+	}
+
+	_, err := r.db.GetConnection().Exec(
+		"UPDATE webhooks SET last_status = ?, last_error = ?, last_delivered_at = ? WHERE id = ?",
+		status, message, time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a webhook by its ID
+func (r *WebhookRepository) Delete(id int64) error {
+	result, err := r.db.GetConnection().Exec("DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+// query runs a webhook query and scans the results
+func (r *WebhookRepository) query(query string) ([]*models.Webhook, error) {
+	rows, err := r.db.GetConnection().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// scanWebhook reads a webhook from a query result and decrypts its secret
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var w models.Webhook
+	var secretEncrypted []byte
+	var events string
+	var enabled int
+	var lastStatus, lastError sql.NullString
+	var lastDeliveredAt sql.NullTime
+	if err := row.Scan(&w.ID, &w.Name, &w.URL, &secretEncrypted, &events, &enabled, &lastStatus, &lastError, &lastDeliveredAt, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if len(secretEncrypted) > 0 {
+		secret, err := database.Decrypt(secretEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+		}
+		w.Secret = secret
+	}
+	w.HasSecret = w.Secret != ""
+	w.Events = decodeTags(events)
+	w.Enabled = enabled == 1
+	w.LastStatus = lastStatus.String
+	w.LastError = lastError.String
+	if lastDeliveredAt.Valid {
+		w.LastDeliveredAt = &lastDeliveredAt.Time
+	}
+	return &w, nil
+}
+
+// normalizeWebhook validates a webhook and removes duplicate event filters
+func normalizeWebhook(w *models.Webhook) error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	w.URL = strings.TrimSpace(w.URL)
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q (must be an http or https URL)", w.URL)
+	}
+
+	events := make([]string, 0, len(w.Events))
+	seen := make(map[string]bool)
+	for _, event := range w.Events {
+		event = strings.TrimSpace(event)
+		if seen[event] {
+			continue
+		}
+		if !notify.ValidFilter(event) {
+			return fmt.Errorf("invalid event %q (valid events: %s, or patterns such as script.* and *.failed)", event, strings.Join(notify.EventTypes, ", "))
+		}
+		seen[event] = true
+		events = append(events, event)
+	}
+	w.Events = events
+	w.HasSecret = w.Secret != ""
+
+	return nil
+}
+
+// encryptWebhookSecret encrypts a webhook secret, storing no value for an empty one
+func encryptWebhookSecret(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, nil
+	}
+	encrypted, err := database.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	return encrypted, nil
+}
+
+// encodeWebhookEvents serializes event filters to the JSON array stored in the events column
+func encodeWebhookEvents(events []string) string {
+	data, err := json.Marshal(events)
+	if err != nil || events == nil {
+		return "[]"
+	}
+	return string(data)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/database"
+)
+
+// dbMaintenanceTimeout bounds one maintenance run; VACUUM rewrites the whole file
+const dbMaintenanceTimeout = time.Hour
+
+// dbMaintainer checks and compacts the database once a day at a quiet time
+type dbMaintainer struct {
+	hour, minute int
+
+	mu      sync.Mutex // Guards the fields below
+	next    time.Time
+	last    *database.MaintenanceResult
+	lastErr string
+}
+
+// newDBMaintainer parses the local time of day at, as HH:MM
+// Returns nil when at is empty, which disables maintenance
+func newDBMaintainer(at string) (*dbMaintainer, error) {
+	if at == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_MAINTENANCE_TIME: must be a time of day as HH:MM, got %q", at)
+	}
+	return &dbMaintainer{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+// nextRun returns the first maintenance time after now
+func (m *dbMaintainer) nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), m.hour, m.minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// startDBMaintenance runs maintenance every day at the configured time, while read-only mode is off
+func (s *Server) startDBMaintenance() {
+	m := s.dbMaintenance
+	if m == nil {
+		return
+	}
+
+	go func() {
+		for {
+			next := m.nextRun(time.Now())
+			m.mu.Lock()
+			m.next = next
+			m.mu.Unlock()
+			log.Printf("Next database maintenance at %s", next.Format(time.RFC3339))
+
+			timer := time.NewTimer(time.Until(next))
+			<-timer.C
+			if s.maintenance.Status().Enabled {
+				log.Printf("Skipping database maintenance: read-only mode is on")
+				continue
+			}
+			s.runDBMaintenance(context.Background())
+		}
+	}()
+}
+
+// runDBMaintenance runs one maintenance pass and records its outcome for the health endpoint
+func (s *Server) runDBMaintenance(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, dbMaintenanceTimeout)
+	defer cancel()
+
+	result, err := s.db.Maintain(ctx)
+
+	m := s.dbMaintenance
+	m.mu.Lock()
+	m.last = result
+	m.lastErr = ""
+	if err != nil {
+		m.lastErr = err.Error()
+	}
+	m.mu.Unlock()
+
+	switch {
+	case err != nil:
+		log.Printf("Warning: database maintenance failed: %v", err)
+	case result.Integrity != "ok":
+		log.Printf("Warning: database integrity check found problems: %s", result.Integrity)
+	default:
+		log.Printf("Database maintenance finished in %dms: %d KB before, %d KB after",
+			result.DurationMs, result.SizeBefore/1024, result.SizeAfter/1024)
+	}
+}
+
+// checkDBMaintenanceHealth reports the outcome of the last maintenance run
+// Problems are reported as degraded: the server keeps working but needs attention
+func (s *Server) checkDBMaintenanceHealth() ComponentHealth {
+	m := s.dbMaintenance
+	if m == nil {
+		return ComponentHealth{Status: HealthStatusDisabled}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := ""
+	if !m.next.IsZero() {
+		next = fmt.Sprintf("; next run at %s", m.next.Format(time.RFC3339))
+	}
+
+	switch {
+	case m.last == nil:
+		return ComponentHealth{Status: HealthStatusOK, Message: "not run yet" + next}
+	case m.lastErr != "":
+		return ComponentHealth{Status: HealthStatusDegraded, Message: fmt.Sprintf("last run at %s failed: %s%s", m.last.Started.Format(time.RFC3339), m.lastErr, next)}
+	case m.last.Integrity != "ok":
+		return ComponentHealth{Status: HealthStatusDegraded, Message: fmt.Sprintf("integrity check at %s found problems: %s%s", m.last.Started.Format(time.RFC3339), m.last.Integrity, next)}
+	}
+	return ComponentHealth{Status: HealthStatusOK, Message: fmt.Sprintf("integrity ok at %s, %d KB reclaimed%s",
+		m.last.Started.Format(time.RFC3339), max(m.last.SizeBefore-m.last.SizeAfter, 0)/1024, next)}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package server
+
+import "syscall"
+
+// diskFreeBytes returns the bytes available to unprivileged users on the volume containing path
+func diskFreeBytes(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
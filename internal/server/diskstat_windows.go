@@ -0,0 +1,10 @@
+//go:build windows
+
+package server
+
+import "errors"
+
+// diskFreeBytes is not implemented on Windows
+func diskFreeBytes(path string) (uint64, error) {
+	return 0, errors.New("disk space check not supported on windows")
+}
@@ -0,0 +1,569 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	webcliv1 "github.com/pozgo/web-cli/api/webcli/v1"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcErrorDomain identifies web-cli in the ErrorInfo details of gRPC errors
+const grpcErrorDomain = "web-cli"
+
+// newGRPCServer builds the gRPC API server
+// It uses the HTTPS certificate when TLS is configured, including client certificate verification
+func (s *Server) newGRPCServer(handler http.Handler) (*grpc.Server, error) {
+	var opts []grpc.ServerOption
+	if s.config.TLSEnabled() {
+		cert, err := tls.LoadX509KeyPair(s.config.TLSCertPath, s.config.TLSKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate for gRPC: %w", err)
+		}
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if s.config.ClientCertAuthEnabled() {
+			if tlsConfig, err = clientCertTLSConfig(s.config.TLSClientCAPath, s.config.TLSClientAuth); err != nil {
+				return nil, err
+			}
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	registerGRPCServices(grpcServer, &grpcBridge{handler: handler, apiPath: s.config.GetBasePath() + "/api"})
+	return grpcServer, nil
+}
+
+// registerGRPCServices registers the services of the gRPC API
+func registerGRPCServices(registrar grpc.ServiceRegistrar, bridge *grpcBridge) {
+	webcliv1.RegisterServerServiceServer(registrar, &grpcServerService{bridge: bridge})
+	webcliv1.RegisterScriptServiceServer(registrar, &grpcScriptService{bridge: bridge})
+	webcliv1.RegisterExecutionServiceServer(registrar, &grpcExecutionService{bridge: bridge})
+	webcliv1.RegisterHistoryServiceServer(registrar, &grpcHistoryService{bridge: bridge})
+}
+
+// grpcBridge serves RPCs with the HTTP API's handlers
+// Each RPC becomes an in-process request through the full middleware chain, so
+// authentication, IP filtering, read-only mode, rate limits, group permissions,
+// policies, approvals, auditing and history apply to gRPC exactly as to HTTP
+type grpcBridge struct {
+	handler http.Handler
+	apiPath string // Base path of the API, such as /api or /webcli/api
+}
+
+// call runs an API request and decodes its JSON response into out
+// The response headers are returned for list totals
+func (b *grpcBridge) call(ctx context.Context, method, path string, query url.Values, body, out any) (http.Header, error) {
+	w := &bridgeWriter{header: http.Header{}, onHeader: func(header http.Header) {
+		grpc.SetHeader(ctx, requestIDMetadata(header))
+	}}
+	if err := b.serve(ctx, w, method, path, query, body); err != nil {
+		return nil, err
+	}
+	if w.status >= 400 {
+		return nil, grpcError(w.status, w.header, w.body.Bytes())
+	}
+	if err := json.Unmarshal(w.body.Bytes(), out); err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid response from %s: %v", path, err)
+	}
+	return w.header, nil
+}
+
+// serve builds the HTTP request of an RPC from its metadata and peer, and runs it
+func (b *grpcBridge) serve(ctx context.Context, w *bridgeWriter, method, path string, query url.Values, body any) error {
+	reader := io.Reader(http.NoBody)
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	u := url.URL{Path: b.apiPath + path, RawQuery: query.Encode()}
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build request: %v", err)
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range []string{"authorization", "x-request-id", "user-agent"} {
+		if values := md.Get(key); len(values) > 0 {
+			r.Header.Set(key, values[0])
+		}
+	}
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		r.Host = authority[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.TLS = &info.State
+		}
+	}
+
+	b.handler.ServeHTTP(w, r)
+	return nil
+}
+
+// requestIDMetadata returns the request's ID as response metadata
+func requestIDMetadata(header http.Header) metadata.MD {
+	return metadata.Pairs(strings.ToLower(apierror.RequestIDHeader), header.Get(apierror.RequestIDHeader))
+}
+
+// grpcError converts an API error response into a gRPC status
+// The API's error code is kept as the reason of an ErrorInfo detail and invalid
+// fields as BadRequest field violations
+func grpcError(httpStatus int, header http.Header, body []byte) error {
+	var resp apierror.Response
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Code == "" {
+		resp = apierror.Response{
+			Code:      apierror.CodeForStatus(httpStatus),
+			Message:   strings.TrimSpace(string(body)),
+			RequestID: header.Get(apierror.RequestIDHeader),
+		}
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   resp.Code,
+		Domain:   grpcErrorDomain,
+		Metadata: map[string]string{"request_id": resp.RequestID},
+	}}
+	if len(resp.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(resp.Fields))
+		for i, field := range resp.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	st := status.New(grpcCode(httpStatus), resp.Message)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcCode returns the gRPC code matching an HTTP status
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// bridgeWriter records the response of an API request
+// When events is set, a Server-Sent Events response is passed on event by event
+// as it is written instead of being buffered
+type bridgeWriter struct {
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	events   func(data []byte) error
+	onHeader func(header http.Header) // Called once the status is written
+	err      error                    // First error returned by events
+}
+
+func (w *bridgeWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bridgeWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		if w.onHeader != nil {
+			w.onHeader(w.header)
+		}
+	}
+}
+
+func (w *bridgeWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.body.Write(p)
+	if w.streaming() {
+		w.dispatch()
+	}
+	return len(p), nil
+}
+
+// Flush satisfies http.Flusher; events are passed on as soon as they are complete
+func (w *bridgeWriter) Flush() {}
+
+// streaming reports whether the response is an event stream passed on to events
+func (w *bridgeWriter) streaming() bool {
+	return w.events != nil && w.status == http.StatusOK &&
+		strings.HasPrefix(w.header.Get("Content-Type"), "text/event-stream")
+}
+
+// dispatch passes every complete event in the buffer to events
+func (w *bridgeWriter) dispatch() {
+	for {
+		buf := w.body.Bytes()
+		end := bytes.Index(buf, []byte("\n\n"))
+		if end < 0 {
+			return
+		}
+		event := buf[:end]
+		w.body.Next(end + 2)
+		data, ok := bytes.CutPrefix(event, []byte("data: "))
+		if !ok || w.err != nil {
+			continue
+		}
+		w.err = w.events(data)
+	}
+}
+
+// grpcListQuery converts a list filter into the query parameters of a list endpoint
+func grpcListQuery(filter *webcliv1.ListFilter) url.Values {
+	query := url.Values{}
+	if filter == nil {
+		return query
+	}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("group", filter.GetGroup())
+	set("q", filter.GetQuery())
+	set("tag", filter.GetTag())
+	set("sort", filter.GetSort())
+	if filter.GetDesc() {
+		query.Set("order", "desc")
+	}
+	if filter.GetPage() != 0 {
+		query.Set("page", strconv.Itoa(int(filter.GetPage())))
+	}
+	if filter.GetPerPage() != 0 {
+		query.Set("per_page", strconv.Itoa(int(filter.GetPerPage())))
+	}
+	return query
+}
+
+// listTotal returns the number of matching items reported by a list endpoint
+func listTotal(header http.Header) int32 {
+	total, _ := strconv.Atoi(header.Get("X-Total-Count"))
+	return int32(total)
+}
+
+// optionalID returns a pointer to id, or nil when it is not set
+func optionalID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// ========== Server Service ==========
+
+type grpcServerService struct {
+	webcliv1.UnimplementedServerServiceServer
+	bridge *grpcBridge
+}
+
+func (g *grpcServerService) ListServers(ctx context.Context, req *webcliv1.ListServersRequest) (*webcliv1.ListServersResponse, error) {
+	var servers []*models.Server
+	header, err := g.bridge.call(ctx, http.MethodGet, "/servers", grpcListQuery(req.GetFilter()), nil, &servers)
+	if err != nil {
+		return nil, err
+	}
+	resp := &webcliv1.ListServersResponse{Total: listTotal(header)}
+	for _, srv := range servers {
+		resp.Servers = append(resp.Servers, serverToProto(srv))
+	}
+	return resp, nil
+}
+
+func (g *grpcServerService) GetServer(ctx context.Context, req *webcliv1.GetServerRequest) (*webcliv1.Server, error) {
+	var srv models.Server
+	if _, err := g.bridge.call(ctx, http.MethodGet, fmt.Sprintf("/servers/%d", req.GetId()), nil, nil, &srv); err != nil {
+		return nil, err
+	}
+	return serverToProto(&srv), nil
+}
+
+func serverToProto(srv *models.Server) *webcliv1.Server {
+	return &webcliv1.Server{
+		Id:               srv.ID,
+		Name:             srv.Name,
+		IpAddress:        srv.IPAddress,
+		Port:             int32(srv.Port),
+		Username:         srv.Username,
+		Group:            srv.Group,
+		RequiresApproval: srv.RequiresApproval,
+		Source:           srv.Source,
+		CreatedAt:        timestamppb.New(srv.CreatedAt),
+		UpdatedAt:        timestamppb.New(srv.UpdatedAt),
+	}
+}
+
+// ========== Script Service ==========
+
+type grpcScriptService struct {
+	webcliv1.UnimplementedScriptServiceServer
+	bridge *grpcBridge
+}
+
+func (g *grpcScriptService) ListScripts(ctx context.Context, req *webcliv1.ListScriptsRequest) (*webcliv1.ListScriptsResponse, error) {
+	var scripts []*models.BashScript
+	header, err := g.bridge.call(ctx, http.MethodGet, "/bash-scripts", grpcListQuery(req.GetFilter()), nil, &scripts)
+	if err != nil {
+		return nil, err
+	}
+	resp := &webcliv1.ListScriptsResponse{Total: listTotal(header)}
+	for _, script := range scripts {
+		resp.Scripts = append(resp.Scripts, scriptToProto(script))
+	}
+	return resp, nil
+}
+
+func (g *grpcScriptService) GetScript(ctx context.Context, req *webcliv1.GetScriptRequest) (*webcliv1.Script, error) {
+	var script models.BashScript
+	if _, err := g.bridge.call(ctx, http.MethodGet, fmt.Sprintf("/bash-scripts/%d", req.GetId()), nil, nil, &script); err != nil {
+		return nil, err
+	}
+	return scriptToProto(&script), nil
+}
+
+func scriptToProto(script *models.BashScript) *webcliv1.Script {
+	return &webcliv1.Script{
+		Id:               script.ID,
+		Name:             script.Name,
+		Description:      script.Description,
+		Content:          script.Content,
+		Filename:         script.Filename,
+		Group:            script.Group,
+		Interpreter:      script.Interpreter,
+		Tags:             script.Tags,
+		RequiresApproval: script.RequiresApproval,
+		ReadOnly:         script.ReadOnly,
+		Source:           script.Source,
+		CreatedAt:        timestamppb.New(script.CreatedAt),
+		UpdatedAt:        timestamppb.New(script.UpdatedAt),
+	}
+}
+
+// ========== Execution Service ==========
+
+type grpcExecutionService struct {
+	webcliv1.UnimplementedExecutionServiceServer
+	bridge *grpcBridge
+}
+
+func (g *grpcExecutionService) ExecuteCommand(ctx context.Context, req *webcliv1.ExecuteCommandRequest) (*webcliv1.CommandResult, error) {
+	target := req.GetTarget()
+	exec := models.CommandExecution{
+		Command:      req.GetCommand(),
+		User:         req.GetUser(),
+		SudoPassword: req.GetSudoPassword(),
+		SSHPassword:  target.GetSshPassword(),
+		IsRemote:     target.GetServerId() != 0 || target.GetServerRef() != "",
+		ServerID:     optionalID(target.GetServerId()),
+		ServerRef:    target.GetServerRef(),
+		SSHKeyID:     optionalID(target.GetSshKeyId()),
+		SSHKeyRef:    target.GetSshKeyRef(),
+		ApprovalID:   optionalID(req.GetApprovalId()),
+		StdinBase64:  encodeStdin(req.GetStdin()),
+		Workdir:      req.GetWorkdir(),
+	}
+
+	var result models.CommandResult
+	if _, err := g.bridge.call(ctx, http.MethodPost, "/commands/execute", nil, exec, &result); err != nil {
+		return nil, err
+	}
+	return &webcliv1.CommandResult{
+		Command:         result.Command,
+		Output:          result.Output,
+		ExitCode:        int32(result.ExitCode),
+		User:            result.User,
+		ExecutionTimeMs: result.ExecutionTime,
+	}, nil
+}
+
+func (g *grpcExecutionService) ExecuteScript(ctx context.Context, req *webcliv1.ExecuteScriptRequest) (*webcliv1.ScriptResult, error) {
+	var result models.ScriptResult
+	if _, err := g.bridge.call(ctx, http.MethodPost, "/bash-scripts/execute", nil, scriptExecutionFromProto(req), &result); err != nil {
+		return nil, err
+	}
+	return scriptResultToProto(&result), nil
+}
+
+func (g *grpcExecutionService) StreamScript(req *webcliv1.ExecuteScriptRequest, stream grpc.ServerStreamingServer[webcliv1.ExecutionEvent]) error {
+	ctx := stream.Context()
+	w := &bridgeWriter{header: http.Header{}}
+	w.onHeader = func(header http.Header) {
+		stream.SetHeader(requestIDMetadata(header))
+	}
+	w.events = func(data []byte) error {
+		var msg StreamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		return stream.Send(streamMessageToProto(&msg))
+	}
+
+	if err := g.bridge.serve(ctx, w, http.MethodPost, "/bash-scripts/execute/stream", nil, scriptExecutionFromProto(req)); err != nil {
+		return err
+	}
+	if w.status >= 400 {
+		return grpcError(w.status, w.header, w.body.Bytes())
+	}
+	if w.err != nil {
+		return status.Errorf(codes.Unavailable, "failed to send event: %v", w.err)
+	}
+	return nil
+}
+
+// scriptExecutionFromProto converts a script execution request into the API's request body
+func scriptExecutionFromProto(req *webcliv1.ExecuteScriptRequest) models.ScriptExecution {
+	target := req.GetTarget()
+	return models.ScriptExecution{
+		ScriptID:     req.GetScriptId(),
+		ScriptName:   req.GetScriptName(),
+		ScriptGroup:  req.GetScriptGroup(),
+		User:         req.GetUser(),
+		SudoPassword: req.GetSudoPassword(),
+		SSHPassword:  target.GetSshPassword(),
+		IsRemote:     target.GetServerId() != 0 || target.GetServerRef() != "",
+		ServerID:     optionalID(target.GetServerId()),
+		ServerRef:    target.GetServerRef(),
+		SSHKeyID:     optionalID(target.GetSshKeyId()),
+		SSHKeyRef:    target.GetSshKeyRef(),
+		EnvVarIDs:    req.GetEnvVarIds(),
+		EnvVarNames:  req.GetEnvVarNames(),
+		EnvVarGroups: req.GetEnvVarGroups(),
+		ApprovalID:   optionalID(req.GetApprovalId()),
+		DryRun:       req.GetDryRun(),
+		StdinBase64:  encodeStdin(req.GetStdin()),
+		Workdir:      req.GetWorkdir(),
+	}
+}
+
+// encodeStdin encodes input for the stdin_base64 field, which keeps binary input intact
+func encodeStdin(stdin []byte) string {
+	if len(stdin) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(stdin)
+}
+
+func scriptResultToProto(result *models.ScriptResult) *webcliv1.ScriptResult {
+	return &webcliv1.ScriptResult{
+		ScriptId:        result.ScriptID,
+		ScriptName:      result.ScriptName,
+		Output:          result.Output,
+		ExitCode:        int32(result.ExitCode),
+		User:            result.User,
+		Server:          result.Server,
+		ExecutionTimeMs: result.ExecutionTime,
+		EnvVars:         result.EnvVars,
+		DryRun:          result.DryRun,
+	}
+}
+
+// streamMessageToProto converts a streamed SSE message into an execution event
+func streamMessageToProto(msg *StreamMessage) *webcliv1.ExecutionEvent {
+	switch msg.Type {
+	case "result":
+		if msg.Result != nil {
+			return &webcliv1.ExecutionEvent{Event: &webcliv1.ExecutionEvent_Result{Result: scriptResultToProto(msg.Result)}}
+		}
+	case "output":
+		return &webcliv1.ExecutionEvent{Event: &webcliv1.ExecutionEvent_Output{Output: msg.Data}}
+	case "error":
+		return &webcliv1.ExecutionEvent{Event: &webcliv1.ExecutionEvent_Error{Error: msg.Data}}
+	}
+	return &webcliv1.ExecutionEvent{Event: &webcliv1.ExecutionEvent_Status{Status: msg.Data}}
+}
+
+// ========== History Service ==========
+
+type grpcHistoryService struct {
+	webcliv1.UnimplementedHistoryServiceServer
+	bridge *grpcBridge
+}
+
+func (g *grpcHistoryService) ListHistory(ctx context.Context, req *webcliv1.ListHistoryRequest) (*webcliv1.ListHistoryResponse, error) {
+	query := url.Values{}
+	if req.GetServer() != "" {
+		query.Set("server", req.GetServer())
+	}
+	if req.GetLimit() > 0 {
+		query.Set("limit", strconv.Itoa(int(req.GetLimit())))
+	}
+
+	var history []*models.CommandHistory
+	if _, err := g.bridge.call(ctx, http.MethodGet, "/history", query, nil, &history); err != nil {
+		return nil, err
+	}
+	resp := &webcliv1.ListHistoryResponse{}
+	for _, entry := range history {
+		resp.Entries = append(resp.Entries, historyToProto(entry))
+	}
+	return resp, nil
+}
+
+func (g *grpcHistoryService) GetHistory(ctx context.Context, req *webcliv1.GetHistoryRequest) (*webcliv1.HistoryEntry, error) {
+	var entry models.CommandHistory
+	if _, err := g.bridge.call(ctx, http.MethodGet, fmt.Sprintf("/history/%d", req.GetId()), nil, nil, &entry); err != nil {
+		return nil, err
+	}
+	return historyToProto(&entry), nil
+}
+
+func historyToProto(entry *models.CommandHistory) *webcliv1.HistoryEntry {
+	resp := &webcliv1.HistoryEntry{
+		Id:              entry.ID,
+		Command:         entry.Command,
+		Output:          entry.Output,
+		Server:          entry.Server,
+		User:            entry.User,
+		ExecutionTimeMs: entry.ExecutionTimeMs,
+		ExecutedAt:      timestamppb.New(entry.ExecutedAt),
+	}
+	if entry.ExitCode != nil {
+		code := int32(*entry.ExitCode)
+		resp.ExitCode = &code
+	}
+	return resp
+}
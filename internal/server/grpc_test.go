@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	webcliv1 "github.com/pozgo/web-cli/api/webcli/v1"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialTestGRPC serves the gRPC API of server in memory and returns a client connection
+func dialTestGRPC(t *testing.T, server *Server) *grpc.ClientConn {
+	t.Helper()
+	server.router = mux.NewRouter()
+	server.setupRoutes()
+
+	grpcServer, err := server.newGRPCServer(server.handler())
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	listener := bufconn.Listen(1 << 20)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCServers(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.config = &config.Config{}
+
+	repo := repository.NewServerRepository(server.db)
+	for _, name := range []string{"web1", "web2", "db1"} {
+		if _, err := repo.Create(&models.ServerCreate{Name: name, Port: 22, Username: "deploy"}); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+	}
+
+	client := webcliv1.NewServerServiceClient(dialTestGRPC(t, server))
+	ctx := context.Background()
+
+	list, err := client.ListServers(ctx, &webcliv1.ListServersRequest{
+		Filter: &webcliv1.ListFilter{Query: "web", Sort: "name", Desc: true, PerPage: 1},
+	})
+	if err != nil {
+		t.Fatalf("ListServers failed: %v", err)
+	}
+	if list.Total != 2 || len(list.Servers) != 1 || list.Servers[0].Name != "web2" {
+		t.Errorf("Expected web2 of 2 matches, got %d: %v", list.Total, list.Servers)
+	}
+
+	srv, err := client.GetServer(ctx, &webcliv1.GetServerRequest{Id: list.Servers[0].Id})
+	if err != nil {
+		t.Fatalf("GetServer failed: %v", err)
+	}
+	if srv.Username != "deploy" || srv.Port != 22 {
+		t.Errorf("Expected the stored server, got %v", srv)
+	}
+
+	// API errors keep their code and the request ID
+	var header metadata.MD
+	_, err = client.GetServer(ctx, &webcliv1.GetServerRequest{Id: 999}, grpc.Header(&header))
+	st, _ := status.FromError(err)
+	if st.Code() != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	var info *errdetails.ErrorInfo
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*errdetails.ErrorInfo); ok {
+			info = d
+		}
+	}
+	if info == nil || info.Reason != apierror.CodeNotFound || info.Metadata["request_id"] == "" {
+		t.Errorf("Expected not_found error info with a request ID, got %v", st.Details())
+	}
+	if ids := header.Get("x-request-id"); len(ids) != 1 || ids[0] != info.GetMetadata()["request_id"] {
+		t.Errorf("Expected the request ID in the response metadata, got %v", ids)
+	}
+}
+
+func TestGRPCStreamScript(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.config = &config.Config{}
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{
+		Name:    "greet",
+		Content: "echo hello\nread name\necho \"bye $name\"\nexit 2",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	client := webcliv1.NewExecutionServiceClient(dialTestGRPC(t, server))
+	stream, err := client.StreamScript(context.Background(), &webcliv1.ExecuteScriptRequest{
+		ScriptId: script.ID,
+		User:     "current",
+		Stdin:    []byte("grpc\n"),
+	})
+	if err != nil {
+		t.Fatalf("StreamScript failed: %v", err)
+	}
+
+	var output strings.Builder
+	var result *webcliv1.ScriptResult
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to receive event: %v", err)
+		}
+		switch e := event.Event.(type) {
+		case *webcliv1.ExecutionEvent_Output:
+			output.WriteString(e.Output)
+		case *webcliv1.ExecutionEvent_Error:
+			t.Fatalf("Unexpected error event: %s", e.Error)
+		case *webcliv1.ExecutionEvent_Result:
+			result = e.Result
+		}
+	}
+
+	if !strings.Contains(output.String(), "hello") || !strings.Contains(output.String(), "bye grpc") {
+		t.Errorf("Expected streamed output, got %q", output.String())
+	}
+	if result == nil || result.ExitCode != 2 || result.ScriptName != "greet" {
+		t.Errorf("Expected a final result with exit code 2, got %v", result)
+	}
+
+	// Requests rejected before streaming starts fail the RPC
+	_, err = client.ExecuteScript(context.Background(), &webcliv1.ExecuteScriptRequest{ScriptId: script.ID, User: "bad user"})
+	if st, _ := status.FromError(err); st.Code() != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an invalid user, got %v", err)
+	}
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("AUTH_USERNAME", "admin")
+	t.Setenv("AUTH_PASSWORD", "secret-password")
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.config = &config.Config{}
+
+	client := webcliv1.NewHistoryServiceClient(dialTestGRPC(t, server))
+
+	_, err := client.ListHistory(context.Background(), &webcliv1.ListHistoryRequest{})
+	if st, _ := status.FromError(err); st.Code() != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated without credentials, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret-password")))
+	if _, err := client.ListHistory(ctx, &webcliv1.ListHistoryRequest{}); err != nil {
+		t.Errorf("Expected the credentials to be accepted, got %v", err)
+	}
+}
+
+func TestGRPCGroupPermissions(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("AUTH_USERNAME", "admin")
+	t.Setenv("AUTH_PASSWORD", "secret-password")
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.config = &config.Config{}
+
+	servers := repository.NewServerRepository(server.db)
+	staging, err := servers.Create(&models.ServerCreate{Name: "staging1", Port: 22, Username: "deploy", Group: "staging"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	prod, err := servers.Create(&models.ServerCreate{Name: "prod1", Port: 22, Username: "deploy", Group: "production"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, err := repository.NewGroupPermissionRepository(server.db).Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypeServers, Group: "production", Principal: "role:senior-ops", Permission: models.PermissionView,
+	}); err != nil {
+		t.Fatalf("Failed to create group permission: %v", err)
+	}
+	token, err := repository.NewAPITokenRepository(server.db).Create(&models.APITokenCreate{
+		Name: "ops", Scopes: []string{models.APITokenScopeRead}, Roles: []string{"ops"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create API token: %v", err)
+	}
+
+	// RPCs run through the HTTP handlers, so group permissions apply as they do over HTTP
+	client := webcliv1.NewServerServiceClient(dialTestGRPC(t, server))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token.Token)
+
+	list, err := client.ListServers(ctx, &webcliv1.ListServersRequest{})
+	if err != nil {
+		t.Fatalf("ListServers failed: %v", err)
+	}
+	if list.Total != 1 || len(list.Servers) != 1 || list.Servers[0].Id != staging.ID {
+		t.Errorf("Expected only the staging server, got %v", list.Servers)
+	}
+	if _, err := client.GetServer(ctx, &webcliv1.GetServerRequest{Id: prod.ID}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a server in a restricted group, got %v", err)
+	}
+}
@@ -2,34 +2,34 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"os/user"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
 	"github.com/pozgo/web-cli/internal/audit"
 	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/middleware"
 	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
 	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/terminal"
 	"github.com/pozgo/web-cli/internal/validation"
+	"github.com/pozgo/web-cli/internal/wol"
 )
 
 // ErrorResponse represents an error response
-// @Description Error response returned by the API
-type ErrorResponse struct {
-	Error string `json:"error" example:"Invalid request body"`
-}
-
-// HealthResponse represents the health check response
-// @Description Health check response
-type HealthResponse struct {
-	Status string `json:"status" example:"ok"`
-}
+// @Description Error response returned by the API: a stable code, a message, the invalid fields and the request ID
+type ErrorResponse = apierror.Response
 
 // CurrentUserResponse represents the current user response
 // @Description Current system user information
@@ -43,49 +43,49 @@ type CurrentUserResponse struct {
 
 // handleListSSHKeys godoc
 // @Summary List all SSH keys
-// @Description Get a list of all SSH keys stored in the system
+// @Description Get a list of all SSH keys stored in the system, optionally filtered, sorted and paged
 // @Tags SSH Keys
 // @Accept json
 // @Produce json
 // @Success 200 {array} models.SSHKey
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Param group query string false "Filter by group name"
+// @Param q query string false "Case-insensitive text in the name"
+// @Param sort query string false "Field to sort by: name, group, last_used_at, use_count, created_at, updated_at"
+// @Param order query string false "Sort order: asc or desc"
+// @Param page query int false "Page number, from 1; without page or per_page every item is returned"
+// @Param per_page query int false "Items per page, 1 to 500 (default 50)"
+// @Header 200 {integer} X-Total-Count "Number of matching items"
+// @Header 200 {string} Link "Links to the next and previous pages, when paged"
 // @Router /keys [get]
 func (s *Server) handleListSSHKeys(w http.ResponseWriter, r *http.Request) {
-	repo := repository.NewSSHKeyRepository(s.db)
-	group := r.URL.Query().Get("group")
-
-	var keys []*models.SSHKey
-	var err error
-
-	if group != "" {
-		keys, err = repo.GetByGroup(group)
-	} else {
-		keys, err = repo.GetAll()
-	}
+	filter, err := parseListFilter(r)
 	if err != nil {
-		log.Printf("Error fetching SSH keys: %v", err)
-		http.Error(w, "Failed to fetch SSH keys", http.StatusInternalServerError)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Merge with Vault keys if available
-	allKeys := s.mergeSSHKeysWithVault(r.Context(), keys)
+	repo := repository.NewSSHKeyRepository(s.db)
+	access := s.groupAccess(r)
+	if err := access.restrictGroups(&filter, models.ResourceTypeSSHKeys, repo.GetGroups); err != nil {
+		writeListError(w, err, "SSH keys")
+		return
+	}
 
-	// Filter Vault keys by group if specified
-	if group != "" {
-		filtered := make([]*models.SSHKey, 0)
-		for _, k := range allKeys {
-			if k.Group == group || (k.Group == "" && group == "default") {
-				filtered = append(filtered, k)
-			}
-		}
-		allKeys = filtered
+	// Vault keys are filtered by group and name here, and hidden if the caller has no permission on their group
+	keys, total, err := listWithVault(s, r.Context(), filter, repo.List, s.mergeSSHKeysWithVault, func(k *models.SSHKey) bool {
+		return k.Source != "vault" || (inVaultGroup(filter, k.Group) && filter.MatchesText(k.Name) && access.canView(models.ResourceTypeSSHKeys, k.Group))
+	}, sshKeySortValue)
+	if err != nil {
+		writeListError(w, err, "SSH keys")
+		return
 	}
 
+	writeListHeaders(w, r, filter, total)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(allKeys)
+	json.NewEncoder(w).Encode(keys)
 }
 
 // handleCreateSSHKey godoc
@@ -104,18 +104,23 @@ func (s *Server) handleCreateSSHKey(w http.ResponseWriter, r *http.Request) {
 	var keyCreate models.SSHKeyCreate
 
 	if err := json.NewDecoder(r.Body).Decode(&keyCreate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input
 	if err := validation.ValidateCommandName(keyCreate.Name); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid name: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
 		return
 	}
 
 	if err := validation.ValidateSSHPrivateKey(keyCreate.PrivateKey); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid SSH private key: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "private_key", fmt.Sprintf("Invalid SSH private key: %v", err))
+		return
+	}
+
+	if !s.groupAccess(r).canView(models.ResourceTypeSSHKeys, keyCreate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeSSHKeys, keyCreate.Group, models.PermissionView)
 		return
 	}
 
@@ -124,7 +129,7 @@ func (s *Server) handleCreateSSHKey(w http.ResponseWriter, r *http.Request) {
 	key, err := repo.Create(&keyCreate)
 	if err != nil {
 		log.Printf("Error creating SSH key: %v", err)
-		http.Error(w, "Failed to create SSH key", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to create SSH key", http.StatusInternalServerError)
 		return
 	}
 
@@ -151,7 +156,7 @@ func (s *Server) handleGetSSHKey(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid key ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid key ID", http.StatusBadRequest)
 		return
 	}
 
@@ -160,7 +165,12 @@ func (s *Server) handleGetSSHKey(w http.ResponseWriter, r *http.Request) {
 	key, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching SSH key: %v", err)
-		http.Error(w, "SSH key not found", http.StatusNotFound)
+		apierror.Error(w, "SSH key not found", http.StatusNotFound)
+		return
+	}
+
+	if !s.groupAccess(r).canView(models.ResourceTypeSSHKeys, key.Group) {
+		apierror.Error(w, "SSH key not found", http.StatusNotFound)
 		return
 	}
 
@@ -187,29 +197,40 @@ func (s *Server) handleUpdateSSHKey(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid key ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid key ID", http.StatusBadRequest)
 		return
 	}
 
 	var keyUpdate models.SSHKeyUpdate
 
 	if err := json.NewDecoder(r.Body).Decode(&keyUpdate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input - at least one field must be provided
 	if keyUpdate.Name == "" && keyUpdate.PrivateKey == "" {
-		http.Error(w, "At least one field (name or private_key) must be provided", http.StatusBadRequest)
+		apierror.Error(w, "At least one field (name or private_key) must be provided", http.StatusBadRequest)
 		return
 	}
 
 	repo := repository.NewSSHKeyRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	access := s.groupAccess(r)
+	if existing, err := repo.GetByID(id); err != nil || !access.canView(models.ResourceTypeSSHKeys, existing.Group) {
+		apierror.Error(w, "SSH key not found", http.StatusNotFound)
+		return
+	}
+	if keyUpdate.Group != "" && !access.canView(models.ResourceTypeSSHKeys, keyUpdate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeSSHKeys, keyUpdate.Group, models.PermissionView)
+		return
+	}
+
 	key, err := repo.Update(id, &keyUpdate)
 	if err != nil {
 		log.Printf("Error updating SSH key: %v", err)
-		http.Error(w, "Failed to update SSH key", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to update SSH key", http.StatusInternalServerError)
 		return
 	}
 
@@ -235,66 +256,93 @@ func (s *Server) handleDeleteSSHKey(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid key ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid key ID", http.StatusBadRequest)
 		return
 	}
 
 	repo := repository.NewSSHKeyRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	if existing, err := repo.GetByID(id); err != nil || !s.groupAccess(r).canView(models.ResourceTypeSSHKeys, existing.Group) {
+		apierror.Error(w, "SSH key not found", http.StatusNotFound)
+		return
+	}
+
 	if err := repo.Delete(id); err != nil {
 		log.Printf("Error deleting SSH key: %v", err)
-		http.Error(w, "Failed to delete SSH key", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete SSH key", http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// recordSSHKeyUse tracks that an execution or terminal session used an SSH key
+// Usage counters are kept for SQLite keys; every use is written to the audit log
+func (s *Server) recordSSHKeyUse(r *http.Request, key *models.SSHKey, serverName, user, usedBy string) {
+	if key == nil {
+		return
+	}
+
+	source := key.Source
+	if source == "" {
+		source = "sqlite"
+	}
+	if source == "sqlite" && key.ID > 0 {
+		if err := repository.NewSSHKeyRepository(s.db).RecordUse(key.ID); err != nil {
+			log.Printf("Warning: failed to record SSH key use: %v", err)
+		}
+	}
+
+	audit.GetLogger().LogSSHKeyUsage(r, key.Name, source, serverName, user, usedBy)
+}
+
 // handleListServers godoc
 // @Summary List all servers
-// @Description Get a list of all remote servers configured in the system
+// @Description Get a list of all remote servers configured in the system, optionally filtered, sorted and paged
 // @Tags Servers
 // @Accept json
 // @Produce json
 // @Success 200 {array} models.Server
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Param group query string false "Filter by group name"
+// @Param q query string false "Case-insensitive text in the name, IP address or username"
+// @Param sort query string false "Field to sort by: name, ip_address, port, username, group, created_at, updated_at"
+// @Param order query string false "Sort order: asc or desc"
+// @Param page query int false "Page number, from 1; without page or per_page every item is returned"
+// @Param per_page query int false "Items per page, 1 to 500 (default 50)"
+// @Header 200 {integer} X-Total-Count "Number of matching items"
+// @Header 200 {string} Link "Links to the next and previous pages, when paged"
 // @Router /servers [get]
 func (s *Server) handleListServers(w http.ResponseWriter, r *http.Request) {
-	repo := repository.NewServerRepository(s.db)
-	group := r.URL.Query().Get("group")
-
-	var servers []*models.Server
-	var err error
-
-	if group != "" {
-		servers, err = repo.GetByGroup(group)
-	} else {
-		servers, err = repo.GetAll()
-	}
+	filter, err := parseListFilter(r)
 	if err != nil {
-		log.Printf("Error fetching servers: %v", err)
-		http.Error(w, "Failed to fetch servers", http.StatusInternalServerError)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Merge with Vault servers if available
-	allServers := s.mergeServersWithVault(r.Context(), servers)
+	repo := repository.NewServerRepository(s.db)
+	access := s.groupAccess(r)
+	if err := access.restrictGroups(&filter, models.ResourceTypeServers, repo.GetGroups); err != nil {
+		writeListError(w, err, "servers")
+		return
+	}
 
-	// Filter Vault servers by group if specified
-	if group != "" {
-		filtered := make([]*models.Server, 0)
-		for _, srv := range allServers {
-			if srv.Group == group || (srv.Group == "" && group == "default") {
-				filtered = append(filtered, srv)
-			}
-		}
-		allServers = filtered
+	// Vault servers are filtered by group and text here, and hidden if the caller has no permission on their group
+	servers, total, err := listWithVault(s, r.Context(), filter, repo.List, s.mergeServersWithVault, func(srv *models.Server) bool {
+		return srv.Source != "vault" || (inVaultGroup(filter, srv.Group) &&
+			filter.MatchesText(srv.Name, srv.IPAddress, srv.Username) && access.canView(models.ResourceTypeServers, srv.Group))
+	}, serverSortValue)
+	if err != nil {
+		writeListError(w, err, "servers")
+		return
 	}
 
+	writeListHeaders(w, r, filter, total)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(allServers)
+	json.NewEncoder(w).Encode(servers)
 }
 
 // handleCreateServer godoc
@@ -313,20 +361,20 @@ func (s *Server) handleCreateServer(w http.ResponseWriter, r *http.Request) {
 	var serverCreate models.ServerCreate
 
 	if err := json.NewDecoder(r.Body).Decode(&serverCreate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input - at least one field must be provided
 	if serverCreate.Name == "" && serverCreate.IPAddress == "" {
-		http.Error(w, "At least one of name or ip_address must be provided", http.StatusBadRequest)
+		apierror.Error(w, "At least one of name or ip_address must be provided", http.StatusBadRequest)
 		return
 	}
 
 	// Validate hostname if provided
 	if serverCreate.Name != "" {
 		if err := validation.ValidateHostname(serverCreate.Name); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid hostname: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "name", fmt.Sprintf("Invalid hostname: %v", err))
 			return
 		}
 	}
@@ -334,7 +382,7 @@ func (s *Server) handleCreateServer(w http.ResponseWriter, r *http.Request) {
 	// Validate IP address if provided
 	if serverCreate.IPAddress != "" {
 		if err := validation.ValidateIPOrHostname(serverCreate.IPAddress); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid IP address or hostname: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "ip_address", fmt.Sprintf("Invalid IP address or hostname: %v", err))
 			return
 		}
 	}
@@ -342,7 +390,7 @@ func (s *Server) handleCreateServer(w http.ResponseWriter, r *http.Request) {
 	// Validate port if provided
 	if serverCreate.Port > 0 {
 		if err := validation.ValidatePort(serverCreate.Port); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid port: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "port", fmt.Sprintf("Invalid port: %v", err))
 			return
 		}
 	}
@@ -350,17 +398,49 @@ func (s *Server) handleCreateServer(w http.ResponseWriter, r *http.Request) {
 	// Validate username if provided
 	if serverCreate.Username != "" {
 		if err := validation.ValidateUsername(serverCreate.Username); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid username: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "username", fmt.Sprintf("Invalid username: %v", err))
+			return
+		}
+	}
+
+	if field, err := validateServerTransport(&models.Server{
+		Name:               serverCreate.Name,
+		InventoryID:        serverCreate.InventoryID,
+		Transport:          serverCreate.Transport,
+		SSMInstanceID:      serverCreate.SSMInstanceID,
+		SSMRegion:          serverCreate.SSMRegion,
+		SSMRoleARN:         serverCreate.SSMRoleARN,
+		SSMAccessKeyID:     serverCreate.SSMAccessKeyID,
+		SSMSecretAccessKey: serverCreate.SSMSecretAccessKey,
+	}); err != nil {
+		apierror.InvalidField(w, field, fmt.Sprintf("Invalid %s: %v", field, err))
+		return
+	}
+	if field, err := validateServerHostKey(&models.Server{
+		HostKeyPolicy:      serverCreate.HostKeyPolicy,
+		HostKeyFingerprint: serverCreate.HostKeyFingerprint,
+	}); err != nil {
+		apierror.InvalidField(w, field, fmt.Sprintf("Invalid %s: %v", field, err))
+		return
+	}
+	if serverCreate.MACAddress != "" {
+		if _, err := wol.ParseMAC(serverCreate.MACAddress); err != nil {
+			apierror.InvalidField(w, "mac_address", err.Error())
 			return
 		}
 	}
 
+	if !s.groupAccess(r).canView(models.ResourceTypeServers, serverCreate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeServers, serverCreate.Group, models.PermissionView)
+		return
+	}
+
 	repo := repository.NewServerRepository(s.db)
 
 	server, err := repo.Create(&serverCreate)
 	if err != nil {
 		log.Printf("Error creating server: %v", err)
-		http.Error(w, "Failed to create server", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to create server", http.StatusInternalServerError)
 		return
 	}
 
@@ -387,7 +467,7 @@ func (s *Server) handleGetServer(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
@@ -396,7 +476,12 @@ func (s *Server) handleGetServer(w http.ResponseWriter, r *http.Request) {
 	server, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching server: %v", err)
-		http.Error(w, "Server not found", http.StatusNotFound)
+		apierror.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if !s.groupAccess(r).canView(models.ResourceTypeServers, server.Group) {
+		apierror.Error(w, "Server not found", http.StatusNotFound)
 		return
 	}
 
@@ -423,29 +508,63 @@ func (s *Server) handleUpdateServer(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
 	var serverUpdate models.ServerUpdate
 
 	if err := json.NewDecoder(r.Body).Decode(&serverUpdate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input - at least one field must be provided
 	if serverUpdate.Name == "" && serverUpdate.IPAddress == "" {
-		http.Error(w, "At least one field (name or ip_address) must be provided", http.StatusBadRequest)
+		apierror.Error(w, "At least one field (name or ip_address) must be provided", http.StatusBadRequest)
 		return
 	}
 
 	repo := repository.NewServerRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	access := s.groupAccess(r)
+	existing, err := repo.GetByID(id)
+	if err != nil || !access.canView(models.ResourceTypeServers, existing.Group) {
+		apierror.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+	if serverUpdate.Group != "" && !access.canView(models.ResourceTypeServers, serverUpdate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeServers, serverUpdate.Group, models.PermissionView)
+		return
+	}
+
+	// The transport settings are checked as they will be after the update
+	if serverUpdate.SSMSecretAccessKey != nil && serverUpdate.SSMAccessKeyID == nil {
+		apierror.InvalidField(w, "ssm_access_key_id", "Invalid ssm_access_key_id: ssm_access_key_id is required with ssm_secret_access_key")
+		return
+	}
+	existing.ApplySSMUpdate(&serverUpdate)
+	if field, err := validateServerTransport(existing); err != nil {
+		apierror.InvalidField(w, field, fmt.Sprintf("Invalid %s: %v", field, err))
+		return
+	}
+	existing.ApplyHostKeyUpdate(&serverUpdate)
+	if field, err := validateServerHostKey(existing); err != nil {
+		apierror.InvalidField(w, field, fmt.Sprintf("Invalid %s: %v", field, err))
+		return
+	}
+	if serverUpdate.MACAddress != nil && *serverUpdate.MACAddress != "" {
+		if _, err := wol.ParseMAC(*serverUpdate.MACAddress); err != nil {
+			apierror.InvalidField(w, "mac_address", err.Error())
+			return
+		}
+	}
+
 	server, err := repo.Update(id, &serverUpdate)
 	if err != nil {
 		log.Printf("Error updating server: %v", err)
-		http.Error(w, "Failed to update server", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to update server", http.StatusInternalServerError)
 		return
 	}
 
@@ -471,15 +590,21 @@ func (s *Server) handleDeleteServer(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
 	repo := repository.NewServerRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	if existing, err := repo.GetByID(id); err != nil || !s.groupAccess(r).canView(models.ResourceTypeServers, existing.Group) {
+		apierror.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
 	if err := repo.Delete(id); err != nil {
 		log.Printf("Error deleting server: %v", err)
-		http.Error(w, "Failed to delete server", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete server", http.StatusInternalServerError)
 		return
 	}
 
@@ -503,28 +628,101 @@ func (s *Server) handleExecuteCommand(w http.ResponseWriter, r *http.Request) {
 	var exec models.CommandExecution
 
 	if err := json.NewDecoder(r.Body).Decode(&exec); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	result := s.executeCommand(w, r, &exec)
+	if result == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// executeCommand runs a command execution request through every check, the queue, history, audit
+// and notifications, and returns its result
+// Requests refused before the command ran are answered on w and return nil.
+func (s *Server) executeCommand(w http.ResponseWriter, r *http.Request, exec *models.CommandExecution) *models.CommandResult {
+	if err := applyVaultRefs(
+		vaultRef{"server_ref", exec.ServerRef, exec.ServerID, &exec.ServerName, &exec.ServerGroup},
+		vaultRef{"ssh_key_ref", exec.SSHKeyRef, exec.SSHKeyID, &exec.SSHKeyName, &exec.SSHKeyGroup},
+	); err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid reference: %v", err), http.StatusBadRequest)
+		return nil
+	}
+
 	// Validate command
 	if err := validation.ValidateCommand(exec.Command); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid command: %v", err), http.StatusBadRequest)
-		return
+		apierror.InvalidField(w, "command", fmt.Sprintf("Invalid command: %v", err))
+		return nil
 	}
 
 	// Validate and default user
 	if exec.User == "" {
 		exec.User = "root"
 	} else if err := validation.ValidateUsername(exec.User); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid user: %v", err), http.StatusBadRequest)
-		return
+		apierror.InvalidField(w, "user", fmt.Sprintf("Invalid user: %v", err))
+		return nil
+	}
+
+	stdin, err := executionInput(exec.Stdin, exec.StdinBase64)
+	if err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid stdin: %v", err), http.StatusBadRequest)
+		return nil
 	}
 
+	if err := validation.ValidateWorkdir(exec.Workdir); err != nil {
+		apierror.InvalidField(w, "workdir", fmt.Sprintf("Invalid workdir: %v", err))
+		return nil
+	}
+	retry, ok := executionRetry(w, exec.Retries, exec.RetryBackoff)
+	if !ok {
+		return nil
+	}
+	if !checkRunAsRemoteUser(w, exec.RunAsRemote, exec.IsRemote, exec.Kubernetes, exec.SudoPassword, stdin) {
+		return nil
+	}
+	opts := executor.RunOptions{Stdin: stdin, Dir: exec.Workdir, Retry: retry, Timeout: middleware.ExecutionTimeoutFromContext(r.Context())}
+	if exec.RunAsRemote != "" {
+		opts.RunAs, opts.SudoPassword = exec.RunAsRemote, exec.SudoPassword
+	}
+
+	if !exec.IsRemote && exec.Kubernetes == nil && !s.checkLocalUser(w, exec.User) {
+		return nil
+	}
+
+	// Command policies are evaluated before anything is resolved or executed
+	if !s.checkCommandPolicy(w, r, exec.Command) {
+		return nil
+	}
+
+	// Group permissions limit which servers and SSH keys the caller may use
+	access := s.groupAccess(r)
+
 	var result *executor.ExecuteResult
 	serverName := "local"
+	var serverGroup string
+	var serverID *int64
+
+	if exec.Kubernetes != nil {
+		// Execution in a pod through the Kubernetes exec API, as the container's user
+		pod := s.resolveKubernetesTarget(w, r, access, exec.Kubernetes, exec.IsRemote)
+		if pod == nil {
+			return nil
+		}
+		serverName, serverGroup = pod.name(), pod.namespace
 
-	if exec.IsRemote {
+		release, err := s.acquireExecutionSlot(r.Context(), nil, nil)
+		if err != nil {
+			writeQueueError(w, err)
+			return nil
+		}
+		runCtx, finished := s.startExecution(context.Background(), r, notify.Execution{Kind: notify.KindCommand, Command: exec.Command, Server: serverName, ServerGroup: serverGroup, User: exec.User})
+		result = s.kubernetesExecutor(pod, "").ExecuteWithOptions(runCtx, exec.Command, opts)
+		finished()
+		release()
+	} else if exec.IsRemote {
 		// Remote execution via SSH
 		var server *models.Server
 		var err error
@@ -535,54 +733,74 @@ func (s *Server) handleExecuteCommand(w http.ResponseWriter, r *http.Request) {
 			server, err = serverRepo.GetByID(*exec.ServerID)
 			if err != nil {
 				log.Printf("Error fetching server by ID: %v", err)
-				http.Error(w, "Server not found", http.StatusNotFound)
-				return
+				apierror.Error(w, "Server not found", http.StatusNotFound)
+				return nil
 			}
 		} else if exec.ServerName != "" {
 			// Try to find server by name from Vault
 			server, err = s.getServerByNameFromVault(r.Context(), exec.ServerGroup, exec.ServerName)
 			if err != nil {
 				log.Printf("Error fetching server from Vault: %v", err)
-				http.Error(w, "Server not found in Vault", http.StatusNotFound)
-				return
+				apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+				return nil
 			}
 			if server == nil {
-				http.Error(w, "Server not found in Vault", http.StatusNotFound)
-				return
+				apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+				return nil
 			}
 		} else {
-			http.Error(w, "Server ID or Server Name is required for remote execution", http.StatusBadRequest)
-			return
+			apierror.Error(w, "Server ID or Server Name is required for remote execution", http.StatusBadRequest)
+			return nil
+		}
+
+		if !access.canExecute(models.ResourceTypeServers, server.Group) {
+			denyGroupAccess(w, r, models.ResourceTypeServers, server.Group, models.PermissionExecute)
+			return nil
 		}
 
 		// Get SSH key if provided - support both ID (SQLite) and Name (Vault)
 		var privateKey string
+		var sshKey *models.SSHKey
 		if exec.SSHKeyID != nil && *exec.SSHKeyID > 0 {
 			keyRepo := repository.NewSSHKeyRepository(s.db)
 			key, err := keyRepo.GetByID(*exec.SSHKeyID)
 			if err != nil {
 				log.Printf("Error fetching SSH key by ID: %v", err)
-				http.Error(w, "SSH key not found", http.StatusNotFound)
-				return
+				apierror.Error(w, "SSH key not found", http.StatusNotFound)
+				return nil
+			}
+			if !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+				denyGroupAccess(w, r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute)
+				return nil
 			}
 			privateKey = key.PrivateKey
+			sshKey = key
 		} else if exec.SSHKeyName != "" {
 			// Try to find SSH key by name from Vault
 			key, err := s.getSSHKeyByNameFromVault(r.Context(), exec.SSHKeyGroup, exec.SSHKeyName)
 			if err != nil {
 				log.Printf("Error fetching SSH key from Vault: %v", err)
-				http.Error(w, "SSH key not found in Vault", http.StatusNotFound)
-				return
+				apierror.Error(w, "SSH key not found in Vault", http.StatusNotFound)
+				return nil
 			}
 			if key == nil {
-				http.Error(w, fmt.Sprintf("SSH key '%s' not found in Vault", exec.SSHKeyName), http.StatusNotFound)
-				return
+				apierror.Error(w, fmt.Sprintf("SSH key '%s' not found in Vault", exec.SSHKeyName), http.StatusNotFound)
+				return nil
 			}
 			if key.PrivateKey == "" {
-				http.Error(w, fmt.Sprintf("SSH key '%s' has no private key data in Vault", exec.SSHKeyName), http.StatusBadRequest)
-				return
+				apierror.Error(w, fmt.Sprintf("SSH key '%s' has no private key data in Vault", exec.SSHKeyName), http.StatusBadRequest)
+				return nil
 			}
+			if !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+				denyGroupAccess(w, r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute)
+				return nil
+			}
+			privateKey = key.PrivateKey
+			sshKey = key
+		} else if key := s.groupSSHKey(server, access); key != nil {
+			// Without a selected key, the server group's default key is used
 			privateKey = key.PrivateKey
+			sshKey = key
 		}
 
 		// Set server name for history
@@ -591,33 +809,101 @@ func (s *Server) handleExecuteCommand(w http.ResponseWriter, r *http.Request) {
 		} else if server.IPAddress != "" {
 			serverName = server.IPAddress
 		}
+		serverGroup = server.Group
+		serverID = optionalID(server.ID)
+
+		if opts.RunAs != "" && viaRunCommand(server) {
+			apierror.InvalidField(w, "run_as_remote_user", "run_as_remote_user is not available for servers using the "+server.Transport+" transport; set user")
+			return nil
+		}
+
+		if block := s.checkMaintenanceWindow(r, server, exec.User, exec.MaintenanceOverride); block != nil {
+			writeMaintenanceWindowError(w, block)
+			return nil
+		}
+
+		// Servers may require a second person's approval before anything runs
+		if !s.requireApproval(w, r, commandApprovalRequest(exec, server, serverName)) {
+			return nil
+		}
+
+		// Servers reached through Systems Manager or an agent use no SSH key or certificate
+		withoutSSH := viaRunCommand(server)
+
+		// Without a selected key, sign a short-lived one with Vault's SSH CA if configured
+		var certificate string
+		if privateKey == "" && !withoutSSH {
+			cert, err := s.vaultSSHCertificate(r.Context(), exec.User)
+			if err != nil {
+				log.Printf("Error issuing SSH certificate from Vault: %v", err)
+				apierror.Error(w, "Failed to issue SSH certificate from Vault", http.StatusInternalServerError)
+				return nil
+			}
+			if cert != nil {
+				privateKey, certificate = cert.PrivateKey, cert.Certificate
+			}
+		}
+
+		if !withoutSSH {
+			s.recordSSHKeyUse(r, sshKey, serverName, exec.User, "command")
+		}
 
 		// Execute remotely
 		remoteExec := executor.NewRemoteExecutorWithHostKeys("", true)
 		sshConfig := &executor.SSHConfig{
-			Host:       server.IPAddress,
-			Port:       server.Port,
-			Username:   exec.User,
-			PrivateKey: privateKey,
-			Password:   exec.SSHPassword, // Fallback to password if key fails
+			Host:        server.IPAddress,
+			Port:        server.Port,
+			Username:    exec.User,
+			PrivateKey:  privateKey,
+			Certificate: certificate,
+			Password:    exec.SSHPassword, // Fallback to password if key fails
+
+			HostKeyPolicy:      server.HostKeyPolicy,
+			HostKeyFingerprint: server.HostKeyFingerprint,
+		}
+		release, err := s.acquireExecutionSlot(r.Context(), server, nil)
+		if err != nil {
+			writeQueueError(w, err)
+			return nil
 		}
-		result = remoteExec.Execute(context.Background(), exec.Command, sshConfig)
+		runCtx, finished := s.startExecution(context.Background(), r, notify.Execution{Kind: notify.KindCommand, Command: exec.Command, Server: serverName, ServerGroup: serverGroup, User: exec.User})
+		if withoutSSH {
+			result = s.runCommandExecutor(server).ExecuteWithOptions(runCtx, exec.Command, exec.User, opts)
+		} else {
+			result = remoteExec.ExecuteWithOptions(runCtx, exec.Command, sshConfig, opts)
+		}
+		finished()
+		release()
 	} else {
+		if block := s.checkMaintenanceWindow(r, nil, exec.User, exec.MaintenanceOverride); block != nil {
+			writeMaintenanceWindowError(w, block)
+			return nil
+		}
+
 		// Local execution
-		localExec := executor.NewLocalExecutor()
-		result = localExec.Execute(context.Background(), exec.Command, exec.User, exec.SudoPassword)
+		release, err := s.acquireExecutionSlot(r.Context(), nil, nil)
+		if err != nil {
+			writeQueueError(w, err)
+			return nil
+		}
+		runCtx, finished := s.startExecution(context.Background(), r, notify.Execution{Kind: notify.KindCommand, Command: exec.Command, Server: serverName, ServerGroup: serverGroup, User: exec.User})
+		localExec := s.newLocalExecutor()
+		result = localExec.ExecuteWithOptions(runCtx, exec.Command, exec.User, s.sudoPassword(r, exec.SudoPassword), opts)
+		finished()
+		release()
 	}
 
 	// Store in command history (NEVER store SSH password)
 	exitCode := result.ExitCode
-	historyRepo := repository.NewCommandHistoryRepository(s.db)
-	_, err := historyRepo.Create(&models.CommandHistoryCreate{
+	history, err := s.recordHistory(r, &models.CommandHistoryCreate{
 		Command:         exec.Command,
 		Output:          result.Output,
 		ExitCode:        &exitCode,
 		Server:          serverName,
 		User:            exec.User,
 		ExecutionTimeMs: result.ExecutionTime,
+		Severity:        models.CommandSeverity(exitCode),
+		ServerID:        serverID,
 	})
 	if err != nil {
 		log.Printf("Warning: failed to save command history: %v", err)
@@ -626,6 +912,7 @@ func (s *Server) handleExecuteCommand(w http.ResponseWriter, r *http.Request) {
 
 	// Audit log the command execution
 	audit.GetLogger().LogCommandExecution(r, exec.Command, exec.User, serverName, exitCode, result.ExecutionTime, result.Error)
+	s.notifyExecution(r, notify.Execution{Kind: notify.KindCommand, Command: exec.Command, Server: serverName, ServerGroup: serverGroup, User: exec.User}, result)
 
 	// Save as template if requested
 	if exec.SaveAs != "" {
@@ -645,43 +932,64 @@ func (s *Server) handleExecuteCommand(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Return result - include error in output if present
-	output := result.Output
+	// Return result - include error in output if present; long outputs are cut like in history
+	output, truncated := s.outputLogs.Truncate(result.Output)
+	if exec.StripANSI {
+		output = executor.StripANSI(output)
+	}
 	if result.Error != nil && output == "" {
 		output = fmt.Sprintf("Error: %s", result.Error.Error())
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(models.CommandResult{
+	commandResult := models.CommandResult{
 		Command:       exec.Command,
 		Output:        output,
 		ExitCode:      result.ExitCode,
 		User:          exec.User,
 		ExecutionTime: result.ExecutionTime,
 		ExecutedAt:    "",
-	})
+		Attempts:      result.Attempts,
+		Truncated:     truncated,
+	}
+	if history != nil {
+		commandResult.HistoryID = history.ID
+	}
+	return &commandResult
 }
 
 // handleListSavedCommands godoc
 // @Summary List all saved commands
-// @Description Get a list of all saved command templates
+// @Description Get a list of all saved command templates, optionally filtered by text and tag, sorted and paged
 // @Tags Saved Commands
 // @Accept json
 // @Produce json
+// @Param q query string false "Case-insensitive text in the name, command, description or tags"
+// @Param tag query string false "Only commands with this tag"
+// @Param sort query string false "Field to sort by: name, created_at, updated_at"
+// @Param order query string false "Sort order: asc or desc"
+// @Param page query int false "Page number, from 1; without page or per_page every item is returned"
+// @Param per_page query int false "Items per page, 1 to 500 (default 50)"
+// @Header 200 {integer} X-Total-Count "Number of matching items"
+// @Header 200 {string} Link "Links to the next and previous pages, when paged"
 // @Success 200 {array} models.SavedCommand
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /saved-commands [get]
 func (s *Server) handleListSavedCommands(w http.ResponseWriter, r *http.Request) {
-	repo := repository.NewSavedCommandRepository(s.db)
+	filter, err := parseListFilter(r)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	commands, err := repo.GetAll()
+	commands, total, err := repository.NewSavedCommandRepository(s.db).List(filter)
 	if err != nil {
-		log.Printf("Error fetching saved commands: %v", err)
-		http.Error(w, "Failed to fetch saved commands", http.StatusInternalServerError)
+		writeListError(w, err, "saved commands")
 		return
 	}
 
+	writeListHeaders(w, r, filter, total)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(commands)
 }
@@ -702,18 +1010,28 @@ func (s *Server) handleCreateSavedCommand(w http.ResponseWriter, r *http.Request
 	var cmdCreate models.SavedCommandCreate
 
 	if err := json.NewDecoder(r.Body).Decode(&cmdCreate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input
 	if cmdCreate.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		apierror.Error(w, "Name is required", http.StatusBadRequest)
 		return
 	}
 
 	if cmdCreate.Command == "" {
-		http.Error(w, "Command is required", http.StatusBadRequest)
+		apierror.Error(w, "Command is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := validation.ValidateTags(cmdCreate.Tags); err != nil {
+		apierror.InvalidField(w, "tags", fmt.Sprintf("Invalid tags: %v", err))
+		return
+	}
+
+	if err := validateCommandPlaceholders(cmdCreate.Command, cmdCreate.Placeholders); err != nil {
+		apierror.InvalidField(w, "placeholders", err.Error())
 		return
 	}
 
@@ -722,7 +1040,7 @@ func (s *Server) handleCreateSavedCommand(w http.ResponseWriter, r *http.Request
 	cmd, err := repo.Create(&cmdCreate)
 	if err != nil {
 		log.Printf("Error creating saved command: %v", err)
-		http.Error(w, "Failed to create saved command", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to create saved command", http.StatusInternalServerError)
 		return
 	}
 
@@ -749,7 +1067,7 @@ func (s *Server) handleGetSavedCommand(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid command ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid command ID", http.StatusBadRequest)
 		return
 	}
 
@@ -758,7 +1076,7 @@ func (s *Server) handleGetSavedCommand(w http.ResponseWriter, r *http.Request) {
 	cmd, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching saved command: %v", err)
-		http.Error(w, "Saved command not found", http.StatusNotFound)
+		apierror.Error(w, "Saved command not found", http.StatusNotFound)
 		return
 	}
 
@@ -785,23 +1103,48 @@ func (s *Server) handleUpdateSavedCommand(w http.ResponseWriter, r *http.Request
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid command ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid command ID", http.StatusBadRequest)
 		return
 	}
 
 	var cmdUpdate models.SavedCommandUpdate
 
 	if err := json.NewDecoder(r.Body).Decode(&cmdUpdate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validation.ValidateTags(cmdUpdate.Tags); err != nil {
+		apierror.InvalidField(w, "tags", fmt.Sprintf("Invalid tags: %v", err))
 		return
 	}
 
 	repo := repository.NewSavedCommandRepository(s.db)
 
+	// Placeholder declarations are checked against the command as it will be after the update
+	if cmdUpdate.Command != "" || cmdUpdate.Placeholders != nil {
+		existing, err := repo.GetByID(id)
+		if err != nil {
+			apierror.Error(w, "Saved command not found", http.StatusNotFound)
+			return
+		}
+		command, placeholders := existing.Command, existing.Placeholders
+		if cmdUpdate.Command != "" {
+			command = cmdUpdate.Command
+		}
+		if cmdUpdate.Placeholders != nil {
+			placeholders = cmdUpdate.Placeholders
+		}
+		if err := validateCommandPlaceholders(command, placeholders); err != nil {
+			apierror.InvalidField(w, "placeholders", err.Error())
+			return
+		}
+	}
+
 	cmd, err := repo.Update(id, &cmdUpdate)
 	if err != nil {
 		log.Printf("Error updating saved command: %v", err)
-		http.Error(w, "Failed to update saved command", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to update saved command", http.StatusInternalServerError)
 		return
 	}
 
@@ -827,7 +1170,7 @@ func (s *Server) handleDeleteSavedCommand(w http.ResponseWriter, r *http.Request
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid command ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid command ID", http.StatusBadRequest)
 		return
 	}
 
@@ -835,7 +1178,7 @@ func (s *Server) handleDeleteSavedCommand(w http.ResponseWriter, r *http.Request
 
 	if err := repo.Delete(id); err != nil {
 		log.Printf("Error deleting saved command: %v", err)
-		http.Error(w, "Failed to delete saved command", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete saved command", http.StatusInternalServerError)
 		return
 	}
 
@@ -844,45 +1187,97 @@ func (s *Server) handleDeleteSavedCommand(w http.ResponseWriter, r *http.Request
 
 // handleListCommandHistory godoc
 // @Summary List command history
-// @Description Get command execution history with optional filtering
+// @Description Get command execution history with optional filtering, newest first. Filters combine: only entries matching all of them are returned.
 // @Tags Command History
 // @Accept json
 // @Produce json
 // @Param server query string false "Filter by server name"
+// @Param script_id query int false "Only runs of this script"
+// @Param preset_id query int false "Only runs started from this script preset"
+// @Param saved_command_id query int false "Only runs of this saved command"
+// @Param exit_code query int false "Only runs that exited with this code"
+// @Param user query string false "Only runs as this user"
+// @Param since query string false "Only runs at or after this time (RFC 3339)"
+// @Param until query string false "Only runs before this time (RFC 3339)"
 // @Param limit query int false "Maximum number of records to return" default(100)
+// @Param strip_ansi query bool false "Remove terminal color and cursor codes from the outputs"
 // @Success 200 {array} models.CommandHistory
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /history [get]
 func (s *Server) handleListCommandHistory(w http.ResponseWriter, r *http.Request) {
 	repo := repository.NewCommandHistoryRepository(s.db)
 
-	// Check if filtering by server
-	server := r.URL.Query().Get("server")
-	limitStr := r.URL.Query().Get("limit")
-
-	limit := 100 // Default limit
-	if limitStr != "" {
+	filter := models.CommandHistoryFilter{
+		Server: r.URL.Query().Get("server"),
+		User:   r.URL.Query().Get("user"),
+		Limit:  100, // Default limit
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
+			filter.Limit = parsedLimit
 		}
 	}
-
-	var history []*models.CommandHistory
-	var err error
-
-	if server != "" {
-		history, err = repo.GetByServer(server, limit)
-	} else {
-		history, err = repo.GetAll(limit)
+	for _, param := range []struct {
+		name string
+		id   **int64
+	}{
+		{"script_id", &filter.ScriptID},
+		{"preset_id", &filter.PresetID},
+		{"saved_command_id", &filter.SavedCommandID},
+	} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			apierror.InvalidField(w, param.name, param.name+" must be a positive integer")
+			return
+		}
+		*param.id = &id
+	}
+	if value := r.URL.Query().Get("exit_code"); value != "" {
+		exitCode, err := strconv.Atoi(value)
+		if err != nil {
+			apierror.InvalidField(w, "exit_code", "exit_code must be an integer")
+			return
+		}
+		filter.ExitCode = &exitCode
+	}
+	for _, param := range []struct {
+		name string
+		at   **time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apierror.InvalidField(w, param.name, param.name+" must be an RFC 3339 time, such as 2025-11-11T00:00:00Z")
+			return
+		}
+		*param.at = &at
 	}
 
+	history, err := repo.GetFiltered(filter)
 	if err != nil {
 		log.Printf("Error fetching command history: %v", err)
-		http.Error(w, "Failed to fetch command history", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to fetch command history", http.StatusInternalServerError)
 		return
 	}
 
+	if stripANSIRequested(r) {
+		for _, entry := range history {
+			entry.Output = executor.StripANSI(entry.Output)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(history)
 }
@@ -894,6 +1289,7 @@ func (s *Server) handleListCommandHistory(w http.ResponseWriter, r *http.Request
 // @Accept json
 // @Produce json
 // @Param id path int true "Command History ID"
+// @Param strip_ansi query bool false "Remove terminal color and cursor codes from the output"
 // @Success 200 {object} models.CommandHistory
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -905,7 +1301,7 @@ func (s *Server) handleGetCommandHistory(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid history ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid history ID", http.StatusBadRequest)
 		return
 	}
 
@@ -914,9 +1310,12 @@ func (s *Server) handleGetCommandHistory(w http.ResponseWriter, r *http.Request)
 	history, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching command history: %v", err)
-		http.Error(w, "Command history not found", http.StatusNotFound)
+		apierror.Error(w, "Command history not found", http.StatusNotFound)
 		return
 	}
+	if stripANSIRequested(r) {
+		history.Output = executor.StripANSI(history.Output)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(history)
@@ -938,7 +1337,7 @@ func (s *Server) handleListLocalUsers(w http.ResponseWriter, r *http.Request) {
 	users, err := repo.GetAll()
 	if err != nil {
 		log.Printf("Error fetching local users: %v", err)
-		http.Error(w, "Failed to fetch local users", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to fetch local users", http.StatusInternalServerError)
 		return
 	}
 
@@ -962,13 +1361,13 @@ func (s *Server) handleCreateLocalUser(w http.ResponseWriter, r *http.Request) {
 	var userCreate models.LocalUserCreate
 
 	if err := json.NewDecoder(r.Body).Decode(&userCreate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input
 	if userCreate.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		apierror.Error(w, "Name is required", http.StatusBadRequest)
 		return
 	}
 
@@ -977,7 +1376,7 @@ func (s *Server) handleCreateLocalUser(w http.ResponseWriter, r *http.Request) {
 	user, err := repo.Create(&userCreate)
 	if err != nil {
 		log.Printf("Error creating local user: %v", err)
-		http.Error(w, "Failed to create local user", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to create local user", http.StatusInternalServerError)
 		return
 	}
 
@@ -1004,7 +1403,7 @@ func (s *Server) handleGetLocalUser(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
@@ -1013,7 +1412,7 @@ func (s *Server) handleGetLocalUser(w http.ResponseWriter, r *http.Request) {
 	user, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching local user: %v", err)
-		http.Error(w, "Local user not found", http.StatusNotFound)
+		apierror.Error(w, "Local user not found", http.StatusNotFound)
 		return
 	}
 
@@ -1040,14 +1439,14 @@ func (s *Server) handleUpdateLocalUser(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
 	var userUpdate models.LocalUserUpdate
 
 	if err := json.NewDecoder(r.Body).Decode(&userUpdate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
@@ -1056,7 +1455,7 @@ func (s *Server) handleUpdateLocalUser(w http.ResponseWriter, r *http.Request) {
 	user, err := repo.Update(id, &userUpdate)
 	if err != nil {
 		log.Printf("Error updating local user: %v", err)
-		http.Error(w, "Failed to update local user", http.StatusBadRequest)
+		apierror.Error(w, "Failed to update local user", http.StatusBadRequest)
 		return
 	}
 
@@ -1082,7 +1481,7 @@ func (s *Server) handleDeleteLocalUser(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
@@ -1090,7 +1489,7 @@ func (s *Server) handleDeleteLocalUser(w http.ResponseWriter, r *http.Request) {
 
 	if err := repo.Delete(id); err != nil {
 		log.Printf("Error deleting local user: %v", err)
-		http.Error(w, "Failed to delete local user", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete local user", http.StatusInternalServerError)
 		return
 	}
 
@@ -1111,7 +1510,7 @@ func (s *Server) handleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	currentUser, err := user.Current()
 	if err != nil {
 		log.Printf("Error getting current user: %v", err)
-		http.Error(w, "Failed to get current user", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to get current user", http.StatusInternalServerError)
 		return
 	}
 
@@ -1125,16 +1524,44 @@ func (s *Server) handleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// checkLocalUser rejects local runs as users not listed under local users when
+// LOCAL_USERS_ONLY is set, instead of leaving sudo to fail; "current" is always allowed
+func (s *Server) checkLocalUser(w http.ResponseWriter, name string) bool {
+	if s.config == nil || !s.config.LocalUsersOnly || name == "current" {
+		return true
+	}
+
+	if _, err := repository.NewLocalUserRepository(s.db).GetByName(name); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Error(w, fmt.Sprintf("User '%s' is not a configured local user; add it under Local Users or run as 'current'", name), http.StatusBadRequest)
+			return false
+		}
+		log.Printf("Error fetching local user: %v", err)
+		apierror.Error(w, "Failed to check local user", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// newLocalExecutor creates a local executor using the configured shell and elevation tool
+func (s *Server) newLocalExecutor() *executor.LocalExecutor {
+	if s.config == nil {
+		return executor.NewLocalExecutor()
+	}
+	return executor.NewLocalExecutorWith(s.config.LocalShell, s.config.LocalElevation)
+}
+
 // ShellInfo represents information about an available shell
 // @Description Information about an available shell
 type ShellInfo struct {
-	Name string `json:"name" example:"bash"`
-	Path string `json:"path" example:"/bin/bash"`
+	Name    string `json:"name" example:"bash"`
+	Path    string `json:"path" example:"/bin/bash"`
+	Default bool   `json:"default,omitempty"` // Shell used when the caller does not pick one
 }
 
 // handleListAvailableShells godoc
 // @Summary List available shells
-// @Description Get a list of shells that are installed and available on the system
+// @Description Get the shells from the configured catalog that are installed on the system, marking the caller's default shell
 // @Tags System
 // @Accept json
 // @Produce json
@@ -1142,28 +1569,16 @@ type ShellInfo struct {
 // @Security BasicAuth
 // @Router /system/shells [get]
 func (s *Server) handleListAvailableShells(w http.ResponseWriter, r *http.Request) {
-	// List of common shells to check
-	shellsToCheck := []struct {
-		name  string
-		paths []string
-	}{
-		{"bash", []string{"/bin/bash", "/usr/bin/bash"}},
-		{"sh", []string{"/bin/sh", "/usr/bin/sh"}},
-		{"zsh", []string{"/bin/zsh", "/usr/bin/zsh"}},
-	}
-
-	var availableShells []ShellInfo
-
-	for _, shell := range shellsToCheck {
-		for _, path := range shell.paths {
-			if _, err := os.Stat(path); err == nil {
-				availableShells = append(availableShells, ShellInfo{
-					Name: shell.name,
-					Path: path,
-				})
-				break // Found this shell, move to next
-			}
-		}
+	available := terminal.AvailableShells(s.terminalShellCatalog())
+	defaultShell, _ := s.defaultTerminalShell(r, available)
+
+	availableShells := []ShellInfo{}
+	for _, shell := range available {
+		availableShells = append(availableShells, ShellInfo{
+			Name:    shell.Name,
+			Path:    shell.Path,
+			Default: shell.Name == defaultShell.Name,
+		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1172,46 +1587,46 @@ func (s *Server) handleListAvailableShells(w http.ResponseWriter, r *http.Reques
 
 // handleListEnvVariables godoc
 // @Summary List all environment variables
-// @Description Get a list of all environment variables (values masked by default)
+// @Description Get a list of all environment variables (values masked by default), optionally filtered, sorted and paged
 // @Tags Environment Variables
 // @Accept json
 // @Produce json
 // @Param show_values query bool false "Show actual values instead of masked values"
 // @Param group query string false "Filter by group name"
+// @Param q query string false "Case-insensitive text in the name or description"
+// @Param sort query string false "Field to sort by: name, group, created_at, updated_at"
+// @Param order query string false "Sort order: asc or desc"
+// @Param page query int false "Page number, from 1; without page or per_page every item is returned"
+// @Param per_page query int false "Items per page, 1 to 500 (default 50)"
+// @Header 200 {integer} X-Total-Count "Number of matching items"
+// @Header 200 {string} Link "Links to the next and previous pages, when paged"
 // @Success 200 {array} models.EnvVariableResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /env-variables [get]
 func (s *Server) handleListEnvVariables(w http.ResponseWriter, r *http.Request) {
-	repo := repository.NewEnvVariableRepository(s.db)
-	group := r.URL.Query().Get("group")
-
-	var envVars []*models.EnvVariable
-	var err error
-
-	if group != "" {
-		envVars, err = repo.GetByGroup(group)
-	} else {
-		envVars, err = repo.GetAll()
-	}
+	filter, err := parseListFilter(r)
 	if err != nil {
-		log.Printf("Error fetching environment variables: %v", err)
-		http.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Merge with Vault env variables if available
-	allEnvVars := s.mergeEnvVariablesWithVault(r.Context(), envVars)
+	repo := repository.NewEnvVariableRepository(s.db)
+	access := s.groupAccess(r)
+	if err := access.restrictGroups(&filter, models.ResourceTypeEnvVariables, repo.GetGroups); err != nil {
+		writeListError(w, err, "environment variables")
+		return
+	}
 
-	// Filter Vault env vars by group if specified
-	if group != "" {
-		filtered := make([]*models.EnvVariable, 0)
-		for _, ev := range allEnvVars {
-			if ev.Group == group || (ev.Group == "" && group == "default") {
-				filtered = append(filtered, ev)
-			}
-		}
-		allEnvVars = filtered
+	// Vault env vars are filtered by group and text here, and hidden if the caller has no permission on their group
+	allEnvVars, total, err := listWithVault(s, r.Context(), filter, repo.List, s.mergeEnvVariablesWithVault, func(ev *models.EnvVariable) bool {
+		return ev.Source != "vault" || (inVaultGroup(filter, ev.Group) &&
+			filter.MatchesText(ev.Name, ev.Description) && access.canView(models.ResourceTypeEnvVariables, ev.Group))
+	}, envVariableSortValue)
+	if err != nil {
+		writeListError(w, err, "environment variables")
+		return
 	}
 
 	// Check if full values are requested (for internal use)
@@ -1223,6 +1638,7 @@ func (s *Server) handleListEnvVariables(w http.ResponseWriter, r *http.Request)
 		responses[i] = envVar.ToResponse(showValues)
 	}
 
+	writeListHeaders(w, r, filter, total)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(responses)
 }
@@ -1243,18 +1659,23 @@ func (s *Server) handleCreateEnvVariable(w http.ResponseWriter, r *http.Request)
 	var envVarCreate models.EnvVariableCreate
 
 	if err := json.NewDecoder(r.Body).Decode(&envVarCreate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input
 	if err := validation.ValidateEnvVarName(envVarCreate.Name); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid name: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
 		return
 	}
 
 	if err := validation.ValidateEnvVarValue(envVarCreate.Value); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid value: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "value", fmt.Sprintf("Invalid value: %v", err))
+		return
+	}
+
+	if !s.groupAccess(r).canView(models.ResourceTypeEnvVariables, envVarCreate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeEnvVariables, envVarCreate.Group, models.PermissionView)
 		return
 	}
 
@@ -1263,7 +1684,7 @@ func (s *Server) handleCreateEnvVariable(w http.ResponseWriter, r *http.Request)
 	envVar, err := repo.Create(&envVarCreate)
 	if err != nil {
 		log.Printf("Error creating environment variable: %v", err)
-		http.Error(w, "Failed to create environment variable", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to create environment variable", http.StatusInternalServerError)
 		return
 	}
 
@@ -1292,7 +1713,7 @@ func (s *Server) handleGetEnvVariable(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid environment variable ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid environment variable ID", http.StatusBadRequest)
 		return
 	}
 
@@ -1301,7 +1722,12 @@ func (s *Server) handleGetEnvVariable(w http.ResponseWriter, r *http.Request) {
 	envVar, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching environment variable: %v", err)
-		http.Error(w, "Environment variable not found", http.StatusNotFound)
+		apierror.Error(w, "Environment variable not found", http.StatusNotFound)
+		return
+	}
+
+	if !s.groupAccess(r).canView(models.ResourceTypeEnvVariables, envVar.Group) {
+		apierror.Error(w, "Environment variable not found", http.StatusNotFound)
 		return
 	}
 
@@ -1331,38 +1757,49 @@ func (s *Server) handleUpdateEnvVariable(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid environment variable ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid environment variable ID", http.StatusBadRequest)
 		return
 	}
 
 	var envVarUpdate models.EnvVariableUpdate
 
 	if err := json.NewDecoder(r.Body).Decode(&envVarUpdate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input if provided
 	if envVarUpdate.Name != "" {
 		if err := validation.ValidateEnvVarName(envVarUpdate.Name); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid name: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
 			return
 		}
 	}
 
 	if envVarUpdate.Value != "" {
 		if err := validation.ValidateEnvVarValue(envVarUpdate.Value); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid value: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "value", fmt.Sprintf("Invalid value: %v", err))
 			return
 		}
 	}
 
 	repo := repository.NewEnvVariableRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	access := s.groupAccess(r)
+	if existing, err := repo.GetByID(id); err != nil || !access.canView(models.ResourceTypeEnvVariables, existing.Group) {
+		apierror.Error(w, "Environment variable not found", http.StatusNotFound)
+		return
+	}
+	if envVarUpdate.Group != "" && !access.canView(models.ResourceTypeEnvVariables, envVarUpdate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeEnvVariables, envVarUpdate.Group, models.PermissionView)
+		return
+	}
+
 	envVar, err := repo.Update(id, &envVarUpdate)
 	if err != nil {
 		log.Printf("Error updating environment variable: %v", err)
-		http.Error(w, "Failed to update environment variable", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to update environment variable", http.StatusInternalServerError)
 		return
 	}
 
@@ -1388,15 +1825,21 @@ func (s *Server) handleDeleteEnvVariable(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid environment variable ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid environment variable ID", http.StatusBadRequest)
 		return
 	}
 
 	repo := repository.NewEnvVariableRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	if existing, err := repo.GetByID(id); err != nil || !s.groupAccess(r).canView(models.ResourceTypeEnvVariables, existing.Group) {
+		apierror.Error(w, "Environment variable not found", http.StatusNotFound)
+		return
+	}
+
 	if err := repo.Delete(id); err != nil {
 		log.Printf("Error deleting environment variable: %v", err)
-		http.Error(w, "Failed to delete environment variable", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete environment variable", http.StatusInternalServerError)
 		return
 	}
 
@@ -1405,50 +1848,54 @@ func (s *Server) handleDeleteEnvVariable(w http.ResponseWriter, r *http.Request)
 
 // handleListBashScripts godoc
 // @Summary List all bash scripts
-// @Description Get a list of all bash scripts (without content by default)
+// @Description Get a list of all bash scripts (without content by default), optionally filtered, sorted and paged
 // @Tags Bash Scripts
 // @Accept json
 // @Produce json
 // @Param group query string false "Filter by group name"
+// @Param q query string false "Case-insensitive text in the name, description, filename, content or tags"
+// @Param tag query string false "Only scripts with this tag"
+// @Param sort query string false "Field to sort by: name, group, filename, created_at, updated_at"
+// @Param order query string false "Sort order: asc or desc"
+// @Param page query int false "Page number, from 1; without page or per_page every item is returned"
+// @Param per_page query int false "Items per page, 1 to 500 (default 50)"
+// @Header 200 {integer} X-Total-Count "Number of matching items"
+// @Header 200 {string} Link "Links to the next and previous pages, when paged"
 // @Success 200 {array} models.BashScriptResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /bash-scripts [get]
 func (s *Server) handleListBashScripts(w http.ResponseWriter, r *http.Request) {
-	repo := repository.NewBashScriptRepository(s.db)
-	group := r.URL.Query().Get("group")
-
-	var scripts []*models.BashScript
-	var err error
-
-	if group != "" {
-		scripts, err = repo.GetByGroup(group)
-	} else {
-		scripts, err = repo.GetAll()
-	}
+	filter, err := parseListFilter(r)
 	if err != nil {
-		log.Printf("Error fetching bash scripts: %v", err)
-		http.Error(w, "Failed to fetch bash scripts", http.StatusInternalServerError)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Merge with Vault scripts
-	scripts = s.mergeScriptsWithVault(r.Context(), scripts)
+	repo := repository.NewBashScriptRepository(s.db)
+	access := s.groupAccess(r)
+	if err := access.restrictGroups(&filter, models.ResourceTypeBashScripts, repo.GetGroups); err != nil {
+		writeListError(w, err, "bash scripts")
+		return
+	}
 
-	// Filter Vault scripts by group if specified
-	if group != "" {
-		filtered := make([]*models.BashScript, 0)
-		for _, s := range scripts {
-			if s.Group == group || (s.Group == "" && group == "default") {
-				filtered = append(filtered, s)
-			}
-		}
-		scripts = filtered
+	// Vault scripts are filtered by group and text here (they have no tags), and hidden
+	// if the caller has no permission on their group
+	scripts, total, err := listWithVault(s, r.Context(), filter, repo.List, s.mergeScriptsWithVault, func(script *models.BashScript) bool {
+		return script.Source != "vault" || (inVaultGroup(filter, script.Group) && filter.Tag == "" &&
+			filter.MatchesText(script.Name, script.Description, script.Filename, script.Content) &&
+			access.canView(models.ResourceTypeBashScripts, script.Group))
+	}, bashScriptSortValue)
+	if err != nil {
+		writeListError(w, err, "bash scripts")
+		return
 	}
 
 	// Convert to response format (without content for listing)
 	responses := models.BashScriptsToList(scripts)
 
+	writeListHeaders(w, r, filter, total)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(responses)
 }
@@ -1469,32 +1916,52 @@ func (s *Server) handleCreateBashScript(w http.ResponseWriter, r *http.Request)
 	var scriptCreate models.BashScriptCreate
 
 	if err := json.NewDecoder(r.Body).Decode(&scriptCreate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input
 	if err := validation.ValidateBashScriptName(scriptCreate.Name); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid name: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
 		return
 	}
 
 	if err := validation.ValidateBashScriptContent(scriptCreate.Content); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid content: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "content", fmt.Sprintf("Invalid content: %v", err))
 		return
 	}
 
 	if err := validation.ValidateBashScriptFilename(scriptCreate.Filename); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid filename: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "filename", fmt.Sprintf("Invalid filename: %v", err))
 		return
 	}
 
-	repo := repository.NewBashScriptRepository(s.db)
+	if err := validation.ValidateScriptInterpreter(scriptCreate.Interpreter); err != nil {
+		apierror.InvalidField(w, "interpreter", fmt.Sprintf("Invalid interpreter: %v", err))
+		return
+	}
+
+	if err := validation.ValidateTags(scriptCreate.Tags); err != nil {
+		apierror.InvalidField(w, "tags", fmt.Sprintf("Invalid tags: %v", err))
+		return
+	}
+
+	if err := validation.ValidateExitCodes(scriptCreate.ExitCodes); err != nil {
+		apierror.InvalidField(w, "exit_codes", fmt.Sprintf("Invalid exit codes: %v", err))
+		return
+	}
+
+	if !s.groupAccess(r).canView(models.ResourceTypeBashScripts, scriptCreate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeBashScripts, scriptCreate.Group, models.PermissionView)
+		return
+	}
+
+	repo := repository.NewBashScriptRepository(s.db)
 
 	script, err := repo.Create(&scriptCreate)
 	if err != nil {
 		log.Printf("Error creating bash script: %v", err)
-		http.Error(w, "Failed to create bash script", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to create bash script", http.StatusInternalServerError)
 		return
 	}
 
@@ -1522,7 +1989,7 @@ func (s *Server) handleGetBashScript(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid bash script ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid bash script ID", http.StatusBadRequest)
 		return
 	}
 
@@ -1531,7 +1998,12 @@ func (s *Server) handleGetBashScript(w http.ResponseWriter, r *http.Request) {
 	script, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching bash script: %v", err)
-		http.Error(w, "Bash script not found", http.StatusNotFound)
+		apierror.Error(w, "Bash script not found", http.StatusNotFound)
+		return
+	}
+
+	if !s.groupAccess(r).canView(models.ResourceTypeBashScripts, script.Group) {
+		apierror.Error(w, "Bash script not found", http.StatusNotFound)
 		return
 	}
 
@@ -1552,6 +2024,7 @@ func (s *Server) handleGetBashScript(w http.ResponseWriter, r *http.Request) {
 // @Param script body models.BashScriptUpdate true "Bash script update data"
 // @Success 200 {object} models.BashScriptResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /bash-scripts/{id} [put]
@@ -1561,45 +2034,75 @@ func (s *Server) handleUpdateBashScript(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid bash script ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid bash script ID", http.StatusBadRequest)
 		return
 	}
 
 	var scriptUpdate models.BashScriptUpdate
 
 	if err := json.NewDecoder(r.Body).Decode(&scriptUpdate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate input if provided
 	if scriptUpdate.Name != "" {
 		if err := validation.ValidateBashScriptName(scriptUpdate.Name); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid name: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
 			return
 		}
 	}
 
 	if scriptUpdate.Content != "" {
 		if err := validation.ValidateBashScriptContent(scriptUpdate.Content); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid content: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "content", fmt.Sprintf("Invalid content: %v", err))
 			return
 		}
 	}
 
 	if scriptUpdate.Filename != "" {
 		if err := validation.ValidateBashScriptFilename(scriptUpdate.Filename); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid filename: %v", err), http.StatusBadRequest)
+			apierror.InvalidField(w, "filename", fmt.Sprintf("Invalid filename: %v", err))
 			return
 		}
 	}
 
+	if err := validation.ValidateScriptInterpreter(scriptUpdate.Interpreter); err != nil {
+		apierror.InvalidField(w, "interpreter", fmt.Sprintf("Invalid interpreter: %v", err))
+		return
+	}
+
+	if err := validation.ValidateTags(scriptUpdate.Tags); err != nil {
+		apierror.InvalidField(w, "tags", fmt.Sprintf("Invalid tags: %v", err))
+		return
+	}
+
+	if err := validation.ValidateExitCodes(scriptUpdate.ExitCodes); err != nil {
+		apierror.InvalidField(w, "exit_codes", fmt.Sprintf("Invalid exit codes: %v", err))
+		return
+	}
+
 	repo := repository.NewBashScriptRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	access := s.groupAccess(r)
+	existing, err := repo.GetByID(id)
+	if err != nil || !access.canView(models.ResourceTypeBashScripts, existing.Group) {
+		apierror.Error(w, "Bash script not found", http.StatusNotFound)
+		return
+	}
+	if rejectReadOnlyScript(w, existing) {
+		return
+	}
+	if scriptUpdate.Group != "" && !access.canView(models.ResourceTypeBashScripts, scriptUpdate.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeBashScripts, scriptUpdate.Group, models.PermissionView)
+		return
+	}
+
 	script, err := repo.Update(id, &scriptUpdate)
 	if err != nil {
 		log.Printf("Error updating bash script: %v", err)
-		http.Error(w, "Failed to update bash script", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to update bash script", http.StatusInternalServerError)
 		return
 	}
 
@@ -1616,6 +2119,7 @@ func (s *Server) handleUpdateBashScript(w http.ResponseWriter, r *http.Request)
 // @Param id path int true "Bash Script ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /bash-scripts/{id} [delete]
@@ -1625,15 +2129,25 @@ func (s *Server) handleDeleteBashScript(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid bash script ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid bash script ID", http.StatusBadRequest)
 		return
 	}
 
 	repo := repository.NewBashScriptRepository(s.db)
 
+	// Resources in groups the caller cannot view are reported as missing
+	existing, err := repo.GetByID(id)
+	if err != nil || !s.groupAccess(r).canView(models.ResourceTypeBashScripts, existing.Group) {
+		apierror.Error(w, "Bash script not found", http.StatusNotFound)
+		return
+	}
+	if rejectReadOnlyScript(w, existing) {
+		return
+	}
+
 	if err := repo.Delete(id); err != nil {
 		log.Printf("Error deleting bash script: %v", err)
-		http.Error(w, "Failed to delete bash script", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete bash script", http.StatusInternalServerError)
 		return
 	}
 
@@ -1642,7 +2156,7 @@ func (s *Server) handleDeleteBashScript(w http.ResponseWriter, r *http.Request)
 
 // handleExecuteScript godoc
 // @Summary Execute a bash script
-// @Description Execute a stored bash script locally or remotely
+// @Description Execute a stored bash script locally or remotely. With dry_run the script's syntax is only checked with its interpreter (bash -n, python3 -m py_compile, node --check), on the target server for remote runs, and the result lists the env vars that would be injected; nothing is executed, approved or recorded in history.
 // @Tags Bash Scripts
 // @Accept json
 // @Produce json
@@ -1657,116 +2171,192 @@ func (s *Server) handleExecuteScript(w http.ResponseWriter, r *http.Request) {
 	var exec models.ScriptExecution
 
 	if err := json.NewDecoder(r.Body).Decode(&exec); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result := s.executeScript(w, r, &exec)
+	if result == nil {
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// executeScript runs a script execution request through every check, the queue, history, audit
+// and notifications, and returns its result
+// Requests refused before the script ran are answered on w and return nil.
+func (s *Server) executeScript(w http.ResponseWriter, r *http.Request, exec *models.ScriptExecution) *models.ScriptResult {
+	if err := applyVaultRefs(
+		vaultRef{"server_ref", exec.ServerRef, exec.ServerID, &exec.ServerName, &exec.ServerGroup},
+		vaultRef{"ssh_key_ref", exec.SSHKeyRef, exec.SSHKeyID, &exec.SSHKeyName, &exec.SSHKeyGroup},
+	); err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid reference: %v", err), http.StatusBadRequest)
+		return nil
+	}
 
 	// Validate input - either ScriptID or ScriptName must be provided
 	if exec.ScriptID == 0 && exec.ScriptName == "" {
-		http.Error(w, "Script ID or Script Name is required", http.StatusBadRequest)
-		return
+		apierror.Error(w, "Script ID or Script Name is required", http.StatusBadRequest)
+		return nil
 	}
 
 	// Validate and default user
 	if exec.User == "" {
 		exec.User = "root"
 	} else if err := validation.ValidateUsername(exec.User); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid user: %v", err), http.StatusBadRequest)
-		return
+		apierror.InvalidField(w, "user", fmt.Sprintf("Invalid user: %v", err))
+		return nil
 	}
 
+	stdin, err := executionInput(exec.Stdin, exec.StdinBase64)
+	if err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid stdin: %v", err), http.StatusBadRequest)
+		return nil
+	}
+
+	if err := validation.ValidateWorkdir(exec.Workdir); err != nil {
+		apierror.InvalidField(w, "workdir", fmt.Sprintf("Invalid workdir: %v", err))
+		return nil
+	}
+	preset := s.executionPreset(exec)
+	var presetID *int64
+	if preset != nil {
+		presetID = &preset.ID
+	}
+	retry, ok := executionRetry(w, exec.Retries, exec.RetryBackoff)
+	if !ok {
+		return nil
+	}
+	if !checkScriptUpload(w, exec) {
+		return nil
+	}
+	if !checkRunAsRemoteUser(w, exec.RunAsRemote, exec.IsRemote, exec.Kubernetes, exec.SudoPassword, stdin) {
+		return nil
+	}
+	opts := executor.RunOptions{Stdin: stdin, Dir: exec.Workdir, Retry: retry, Timeout: presetTimeout(r.Context(), preset)}
+	if exec.RunAsRemote != "" {
+		opts.RunAs, opts.SudoPassword = exec.RunAsRemote, exec.SudoPassword
+	}
+
+	if !exec.IsRemote && exec.Kubernetes == nil && !s.checkLocalUser(w, exec.User) {
+		return nil
+	}
+
+	// Group permissions limit which scripts, servers, keys and env vars the caller may use
+	access := s.groupAccess(r)
+
 	// Fetch the script - support both ID (SQLite) and Name (Vault)
 	var script *models.BashScript
-	var err error
 	if exec.ScriptID > 0 {
 		scriptRepo := repository.NewBashScriptRepository(s.db)
 		script, err = scriptRepo.GetByID(exec.ScriptID)
 		if err != nil {
 			log.Printf("Error fetching script by ID: %v", err)
-			http.Error(w, "Script not found", http.StatusNotFound)
-			return
+			apierror.Error(w, "Script not found", http.StatusNotFound)
+			return nil
 		}
 	} else if exec.ScriptName != "" {
 		script, err = s.getScriptByNameFromVault(r.Context(), exec.ScriptGroup, exec.ScriptName)
 		if err != nil {
 			log.Printf("Error fetching script from Vault: %v", err)
-			http.Error(w, "Script not found in Vault", http.StatusNotFound)
-			return
+			apierror.Error(w, "Script not found in Vault", http.StatusNotFound)
+			return nil
 		}
 		if script == nil {
-			http.Error(w, "Script not found in Vault", http.StatusNotFound)
-			return
+			apierror.Error(w, "Script not found in Vault", http.StatusNotFound)
+			return nil
 		}
 	}
 
-	// Build the script content with optional env vars
-	var scriptContent strings.Builder
-	envVarsCount := 0
+	if !access.canExecute(models.ResourceTypeBashScripts, script.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeBashScripts, script.Group, models.PermissionExecute)
+		return nil
+	}
 
-	// Determine which env vars to include
-	// Priority: EnvVarIDs (specific selection) > IncludeEnvVars (all) > none
-	envRepo := repository.NewEnvVariableRepository(s.db)
+	// Command policies may deny the script by name or by the commands it contains
+	if !s.checkScriptPolicy(w, r, script) {
+		return nil
+	}
 
-	if len(exec.EnvVarIDs) > 0 || len(exec.EnvVarNames) > 0 {
-		// Fetch specific environment variables by ID (SQLite)
-		for _, envVarID := range exec.EnvVarIDs {
-			envVar, err := envRepo.GetByID(envVarID)
-			if err != nil {
-				log.Printf("Warning: env variable ID %d not found: %v", envVarID, err)
-				continue
-			}
-			// Escape single quotes in the value for safe shell export
-			escapedValue := strings.ReplaceAll(envVar.Value, "'", "'\\''")
-			scriptContent.WriteString(fmt.Sprintf("export %s='%s'\n", envVar.Name, escapedValue))
-			envVarsCount++
-		}
-		// Fetch specific environment variables by Name (Vault)
-		for i, envVarName := range exec.EnvVarNames {
-			// Get group from EnvVarGroups if available, otherwise use default
-			envVarGroup := "default"
-			if i < len(exec.EnvVarGroups) {
-				envVarGroup = exec.EnvVarGroups[i]
-			}
-			envVar, err := s.getEnvVariableByNameFromVault(r.Context(), envVarGroup, envVarName)
-			if err != nil {
-				log.Printf("Warning: env variable '%s' not found in Vault: %v", envVarName, err)
-				continue
-			}
-			if envVar == nil {
-				log.Printf("Warning: env variable '%s' not found in Vault", envVarName)
-				continue
-			}
-			escapedValue := strings.ReplaceAll(envVar.Value, "'", "'\\''")
-			scriptContent.WriteString(fmt.Sprintf("export %s='%s'\n", envVar.Name, escapedValue))
-			envVarsCount++
-		}
-	} else if exec.IncludeEnvVars {
-		// Backwards compatibility: fetch all environment variables
-		envVars, err := envRepo.GetAll()
-		if err != nil {
-			log.Printf("Error fetching environment variables: %v", err)
-			http.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
-			return
-		}
+	// Env vars are set for the script, through SSH env requests when the server accepts them
+	env, ok := s.resolveScriptEnv(w, r, access, exec)
+	if !ok {
+		return nil
+	}
+	opts.Env = env.vars
 
-		// Prepend env vars as export statements
-		for _, envVar := range envVars {
-			// Escape single quotes in the value for safe shell export
-			escapedValue := strings.ReplaceAll(envVar.Value, "'", "'\\''")
-			scriptContent.WriteString(fmt.Sprintf("export %s='%s'\n", envVar.Name, escapedValue))
-			envVarsCount++
-		}
+	// Secret placeholders in the script are replaced by the values they reference
+	content, ok := s.renderScriptSecrets(w, r, access, script.Content, env)
+	if !ok {
+		return nil
 	}
 
-	// Append the actual script content
-	scriptContent.WriteString(script.Content)
+	// The script runs through its interpreter
+	finalScript, err := executor.ScriptCommand(script.Interpreter, content)
+	if exec.Upload {
+		// or from a file copied to the server, with its arguments
+		finalScript, opts.Upload, err = executor.UploadScript(script.Interpreter, content, exec.Args)
+	}
+	if err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid script: %v", err), http.StatusBadRequest)
+		return nil
+	}
 
-	finalScript := scriptContent.String()
+	// Dry runs check the script's syntax instead of running it, so env values never leave the server
+	if exec.DryRun {
+		finalScript, err = executor.SyntaxCheckCommand(script.Interpreter, script.Content)
+		if err != nil {
+			apierror.Error(w, fmt.Sprintf("Cannot dry-run script: %v", err), http.StatusBadRequest)
+			return nil
+		}
+		opts.Stdin = nil // The workdir is kept, so the check also shows whether it exists
+		opts.Upload = nil
+		opts.Env = nil
+	}
+
+	// Remote hosts and pods are checked by the script itself; local interpreters are checked up front
+	if !exec.IsRemote && exec.Kubernetes == nil && !checkLocalInterpreter(w, script) {
+		return nil
+	}
 
 	var result *executor.ExecuteResult
 	serverName := "local"
+	var serverGroup string
+	var serverID *int64
+
+	if exec.Kubernetes != nil {
+		// Execution in a pod through the Kubernetes exec API, as the container's user
+		pod := s.resolveKubernetesTarget(w, r, access, exec.Kubernetes, exec.IsRemote)
+		if pod == nil {
+			return nil
+		}
+		serverName, serverGroup = pod.name(), pod.namespace
 
-	if exec.IsRemote {
+		if !exec.DryRun && !s.requireApproval(w, r, scriptApprovalRequest(exec, script, nil, serverName)) {
+			return nil
+		}
+
+		unlock, holder, err := s.lockScript(r.Context(), script, exec, serverName)
+		if err != nil {
+			apierror.Error(w, lockErrorMessage(script, holder, err), http.StatusConflict)
+			return nil
+		}
+		defer unlock()
+		release, err := s.acquireExecutionSlot(r.Context(), nil, nil)
+		if err != nil {
+			writeQueueError(w, err)
+			return nil
+		}
+		// Syntax checks run nothing worth listing or cancelling
+		runCtx, finished := context.Background(), func() {}
+		if !exec.DryRun {
+			runCtx, finished = s.startExecution(context.Background(), r, scriptExecution(script, exec, serverName, serverGroup, ""))
+		}
+		result = s.kubernetesExecutor(pod, scriptShell(script)).ExecuteWithOptions(runCtx, finalScript, opts)
+		finished()
+		release()
+	} else if exec.IsRemote {
 		// Remote execution via SSH
 		var server *models.Server
 
@@ -1776,52 +2366,72 @@ func (s *Server) handleExecuteScript(w http.ResponseWriter, r *http.Request) {
 			server, err = serverRepo.GetByID(*exec.ServerID)
 			if err != nil {
 				log.Printf("Error fetching server by ID: %v", err)
-				http.Error(w, "Server not found", http.StatusNotFound)
-				return
+				apierror.Error(w, "Server not found", http.StatusNotFound)
+				return nil
 			}
 		} else if exec.ServerName != "" {
 			server, err = s.getServerByNameFromVault(r.Context(), exec.ServerGroup, exec.ServerName)
 			if err != nil {
 				log.Printf("Error fetching server from Vault: %v", err)
-				http.Error(w, "Server not found in Vault", http.StatusNotFound)
-				return
+				apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+				return nil
 			}
 			if server == nil {
-				http.Error(w, "Server not found in Vault", http.StatusNotFound)
-				return
+				apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+				return nil
 			}
 		} else {
-			http.Error(w, "Server ID or Server Name is required for remote execution", http.StatusBadRequest)
-			return
+			apierror.Error(w, "Server ID or Server Name is required for remote execution", http.StatusBadRequest)
+			return nil
+		}
+
+		if !access.canExecute(models.ResourceTypeServers, server.Group) {
+			denyGroupAccess(w, r, models.ResourceTypeServers, server.Group, models.PermissionExecute)
+			return nil
 		}
 
 		// Get SSH key if provided - support both ID (SQLite) and Name (Vault)
 		var privateKey string
+		var sshKey *models.SSHKey
 		if exec.SSHKeyID != nil && *exec.SSHKeyID > 0 {
 			keyRepo := repository.NewSSHKeyRepository(s.db)
 			key, err := keyRepo.GetByID(*exec.SSHKeyID)
 			if err != nil {
 				log.Printf("Error fetching SSH key by ID: %v", err)
-				http.Error(w, "SSH key not found", http.StatusNotFound)
-				return
+				apierror.Error(w, "SSH key not found", http.StatusNotFound)
+				return nil
+			}
+			if !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+				denyGroupAccess(w, r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute)
+				return nil
 			}
 			privateKey = key.PrivateKey
+			sshKey = key
 		} else if exec.SSHKeyName != "" {
 			key, err := s.getSSHKeyByNameFromVault(r.Context(), exec.SSHKeyGroup, exec.SSHKeyName)
 			if err != nil {
 				log.Printf("Error fetching SSH key from Vault: %v", err)
-				http.Error(w, "SSH key not found in Vault", http.StatusNotFound)
-				return
+				apierror.Error(w, "SSH key not found in Vault", http.StatusNotFound)
+				return nil
 			}
 			if key == nil {
-				http.Error(w, fmt.Sprintf("SSH key '%s' not found in Vault", exec.SSHKeyName), http.StatusNotFound)
-				return
+				apierror.Error(w, fmt.Sprintf("SSH key '%s' not found in Vault", exec.SSHKeyName), http.StatusNotFound)
+				return nil
 			}
 			if key.PrivateKey == "" {
-				http.Error(w, fmt.Sprintf("SSH key '%s' has no private key data in Vault", exec.SSHKeyName), http.StatusBadRequest)
-				return
+				apierror.Error(w, fmt.Sprintf("SSH key '%s' has no private key data in Vault", exec.SSHKeyName), http.StatusBadRequest)
+				return nil
 			}
+			if !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+				denyGroupAccess(w, r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute)
+				return nil
+			}
+			privateKey = key.PrivateKey
+			sshKey = key
+		} else if key := s.groupSSHKey(server, access); key != nil {
+			// Without a selected key, the server group's default key is used
 			privateKey = key.PrivateKey
+			sshKey = key
 		}
 
 		// Set server name for response
@@ -1830,49 +2440,172 @@ func (s *Server) handleExecuteScript(w http.ResponseWriter, r *http.Request) {
 		} else if server.IPAddress != "" {
 			serverName = server.IPAddress
 		}
+		serverGroup = server.Group
+		serverID = optionalID(server.ID)
+
+		if opts.Upload != nil && viaRunCommand(server) {
+			apierror.InvalidField(w, "upload", "upload is not available for servers using the "+server.Transport+" transport")
+			return nil
+		}
+		if opts.RunAs != "" && viaRunCommand(server) {
+			apierror.InvalidField(w, "run_as_remote_user", "run_as_remote_user is not available for servers using the "+server.Transport+" transport; set user")
+			return nil
+		}
+
+		// Syntax checks run nothing, so maintenance windows do not block them
+		if !exec.DryRun {
+			if block := s.checkMaintenanceWindow(r, server, exec.User, exec.MaintenanceOverride); block != nil {
+				writeMaintenanceWindowError(w, block)
+				return nil
+			}
+		}
+
+		// Scripts and servers may require a second person's approval before anything runs
+		if !exec.DryRun && !s.requireApproval(w, r, scriptApprovalRequest(exec, script, server, serverName)) {
+			return nil
+		}
+
+		// Servers reached through Systems Manager or an agent use no SSH key or certificate
+		withoutSSH := viaRunCommand(server)
+
+		// Without a selected key, sign a short-lived one with Vault's SSH CA if configured
+		var certificate string
+		if privateKey == "" && !withoutSSH {
+			cert, err := s.vaultSSHCertificate(r.Context(), exec.User)
+			if err != nil {
+				log.Printf("Error issuing SSH certificate from Vault: %v", err)
+				apierror.Error(w, "Failed to issue SSH certificate from Vault", http.StatusInternalServerError)
+				return nil
+			}
+			if cert != nil {
+				privateKey, certificate = cert.PrivateKey, cert.Certificate
+			}
+		}
+
+		if !withoutSSH {
+			s.recordSSHKeyUse(r, sshKey, serverName, exec.User, "script")
+		}
 
 		// Execute remotely
 		remoteExec := executor.NewRemoteExecutorWithHostKeys("", true)
 		sshConfig := &executor.SSHConfig{
-			Host:       server.IPAddress,
-			Port:       server.Port,
-			Username:   exec.User,
-			PrivateKey: privateKey,
-			Password:   exec.SSHPassword,
+			Host:        server.IPAddress,
+			Port:        server.Port,
+			Username:    exec.User,
+			PrivateKey:  privateKey,
+			Certificate: certificate,
+			Password:    exec.SSHPassword,
+
+			HostKeyPolicy:      server.HostKeyPolicy,
+			HostKeyFingerprint: server.HostKeyFingerprint,
 		}
-		result = remoteExec.Execute(context.Background(), finalScript, sshConfig)
+		unlock, holder, err := s.lockScript(r.Context(), script, exec, serverName)
+		if err != nil {
+			apierror.Error(w, lockErrorMessage(script, holder, err), http.StatusConflict)
+			return nil
+		}
+		defer unlock()
+		release, err := s.acquireExecutionSlot(r.Context(), server, nil)
+		if err != nil {
+			writeQueueError(w, err)
+			return nil
+		}
+		// Syntax checks run nothing worth listing or cancelling
+		runCtx, finished := context.Background(), func() {}
+		if !exec.DryRun {
+			runCtx, finished = s.startExecution(context.Background(), r, scriptExecution(script, exec, serverName, serverGroup, ""))
+		}
+		if withoutSSH {
+			result = s.runCommandExecutor(server).ExecuteWithOptions(runCtx, finalScript, exec.User, opts)
+		} else {
+			result = remoteExec.ExecuteWithOptions(runCtx, finalScript, sshConfig, opts)
+		}
+		finished()
+		release()
 	} else {
-		// Local execution
-		localExec := executor.NewLocalExecutor()
-		result = localExec.Execute(context.Background(), finalScript, exec.User, exec.SudoPassword)
-	}
+		// Syntax checks run nothing, so maintenance windows do not block them
+		if !exec.DryRun {
+			if block := s.checkMaintenanceWindow(r, nil, exec.User, exec.MaintenanceOverride); block != nil {
+				writeMaintenanceWindowError(w, block)
+				return nil
+			}
+		}
 
-	// Store in command history
-	exitCode := result.ExitCode
-	historyRepo := repository.NewCommandHistoryRepository(s.db)
-	_, histErr := historyRepo.Create(&models.CommandHistoryCreate{
-		Command:         fmt.Sprintf("[Script: %s] %s", script.Name, script.Content[:min(100, len(script.Content))]),
-		Output:          result.Output,
-		ExitCode:        &exitCode,
-		Server:          serverName,
-		User:            exec.User,
-		ExecutionTimeMs: result.ExecutionTime,
-	})
-	if histErr != nil {
-		log.Printf("Warning: failed to save command history: %v", histErr)
+		if !exec.DryRun && !s.requireApproval(w, r, scriptApprovalRequest(exec, script, nil, serverName)) {
+			return nil
+		}
+
+		unlock, holder, err := s.lockScript(r.Context(), script, exec, serverName)
+		if err != nil {
+			apierror.Error(w, lockErrorMessage(script, holder, err), http.StatusConflict)
+			return nil
+		}
+		defer unlock()
+
+		// Local execution; syntax checks run as the server's own user, needing no sudo
+		release, err := s.acquireExecutionSlot(r.Context(), nil, nil)
+		if err != nil {
+			writeQueueError(w, err)
+			return nil
+		}
+		// Syntax checks run nothing worth listing or cancelling
+		runCtx, finished := context.Background(), func() {}
+		if !exec.DryRun {
+			runCtx, finished = s.startExecution(context.Background(), r, scriptExecution(script, exec, serverName, serverGroup, ""))
+		}
+		localExec := s.newLocalExecutor()
+		if exec.DryRun {
+			result = localExec.ExecuteWithOptions(runCtx, finalScript, "current", "", opts)
+		} else {
+			result = localExec.ExecuteWithOptions(runCtx, finalScript, exec.User, s.sudoPassword(r, exec.SudoPassword), opts)
+		}
+		finished()
+		release()
 	}
 
-	// Audit log the script execution
-	audit.GetLogger().LogScriptExecution(r, script.Name, exec.User, serverName, exitCode, result.ExecutionTime, result.Error)
+	// Scripts that echo their env vars must not leak the values into history or the response
+	masker := executor.NewSecretMasker(env.secrets)
+	result.Output = masker.Mask(result.Output)
 
-	// Return result - include error in output if present
-	scriptOutput := result.Output
+	// Dry runs executed nothing, so they are neither recorded in history nor audited as executions
+	var history *models.CommandHistory
+	var severity string
+	if !exec.DryRun {
+		// Store in command history
+		exitCode := result.ExitCode
+		severity = script.ExitCodes.Severity(exitCode)
+		var histErr error
+		history, histErr = s.recordHistory(r, &models.CommandHistoryCreate{
+			Command:         masker.Mask(fmt.Sprintf("[Script: %s] %s", script.Name, script.Content[:min(100, len(script.Content))])),
+			Output:          result.Output,
+			ExitCode:        &exitCode,
+			Server:          serverName,
+			User:            exec.User,
+			ExecutionTimeMs: result.ExecutionTime,
+			Severity:        severity,
+			ScriptID:        optionalID(script.ID),
+			PresetID:        presetID,
+			ServerID:        serverID,
+		})
+		if histErr != nil {
+			log.Printf("Warning: failed to save command history: %v", histErr)
+		}
+
+		// Audit log the script execution
+		audit.GetLogger().LogScriptExecution(r, script.Name, exec.User, serverName, exitCode, result.ExecutionTime, result.Error)
+		s.notifyExecution(r, scriptExecution(script, exec, serverName, serverGroup, severity), result)
+	}
+
+	// Return result - include error in output if present; long outputs are cut like in history
+	scriptOutput, truncated := s.outputLogs.Truncate(result.Output)
+	if exec.StripANSI {
+		scriptOutput = executor.StripANSI(scriptOutput)
+	}
 	if result.Error != nil && scriptOutput == "" {
 		scriptOutput = fmt.Sprintf("Error: %s", result.Error.Error())
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(models.ScriptResult{
+	scriptResult := models.ScriptResult{
 		ScriptID:      script.ID,
 		ScriptName:    script.Name,
 		Output:        scriptOutput,
@@ -1880,8 +2613,361 @@ func (s *Server) handleExecuteScript(w http.ResponseWriter, r *http.Request) {
 		User:          exec.User,
 		Server:        serverName,
 		ExecutionTime: result.ExecutionTime,
-		EnvVarsCount:  envVarsCount,
-	})
+		EnvVarsCount:  len(env.names),
+		DryRun:        exec.DryRun,
+		EnvVars:       env.names,
+		Attempts:      result.Attempts,
+		Truncated:     truncated,
+		Severity:      severity,
+	}
+	if history != nil {
+		scriptResult.HistoryID = history.ID
+	}
+	return &scriptResult
+}
+
+// checkLocalInterpreter rejects local runs of a script whose interpreter is not installed
+// Bash scripts are left to the local shell as before
+func checkLocalInterpreter(w http.ResponseWriter, script *models.BashScript) bool {
+	if script.Interpreter == "" || script.Interpreter == models.ScriptInterpreterBash {
+		return true
+	}
+	if err := executor.CheckInterpreter(script.Interpreter); err != nil {
+		apierror.Error(w, fmt.Sprintf("Cannot run script: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// scriptEnv is the environment injected into a script execution
+type scriptEnv struct {
+	vars    []executor.EnvVar // Variables the script runs with
+	names   []string          // Names of the injected variables
+	secrets []string          // Values masked in the output
+}
+
+// add injects an env var
+func (e *scriptEnv) add(envVar *models.EnvVariable) {
+	e.vars = append(e.vars, executor.EnvVar{Name: envVar.Name, Value: envVar.Value})
+	e.names = append(e.names, envVar.Name)
+	e.secrets = append(e.secrets, envVar.Value)
+}
+
+// resolveScriptEnv collects the env vars a script execution selects
+// Priority: a specific selection (EnvVarIDs, EnvVarNames, EnvVarGroups) > IncludeEnvVars (all) > none
+// EnvVarGroups pairs with EnvVarNames for Vault; without names it selects every variable in those groups
+// The error response is written and false returned when a selected variable may not be used
+func (s *Server) resolveScriptEnv(w http.ResponseWriter, r *http.Request, access *groupAccess, exec *models.ScriptExecution) (*scriptEnv, bool) {
+	env := &scriptEnv{}
+	envRepo := repository.NewEnvVariableRepository(s.db)
+
+	if len(exec.EnvVarIDs) > 0 || len(exec.EnvVarNames) > 0 || len(exec.EnvVarGroups) > 0 {
+		included := make(map[int64]bool) // A variable selected by ID and by group is exported once
+
+		// Fetch specific environment variables by ID (SQLite)
+		for _, envVarID := range exec.EnvVarIDs {
+			envVar, err := envRepo.GetByID(envVarID)
+			if err != nil {
+				log.Printf("Warning: env variable ID %d not found: %v", envVarID, err)
+				continue
+			}
+			if !access.canExecute(models.ResourceTypeEnvVariables, envVar.Group) {
+				denyGroupAccess(w, r, models.ResourceTypeEnvVariables, envVar.Group, models.PermissionExecute)
+				return nil, false
+			}
+			if !included[envVar.ID] {
+				included[envVar.ID] = true
+				env.add(envVar)
+			}
+		}
+
+		// Fetch whole groups of environment variables (SQLite)
+		if len(exec.EnvVarNames) == 0 {
+			for _, group := range exec.EnvVarGroups {
+				if !access.canExecute(models.ResourceTypeEnvVariables, group) {
+					denyGroupAccess(w, r, models.ResourceTypeEnvVariables, group, models.PermissionExecute)
+					return nil, false
+				}
+				envVars, err := envRepo.GetByGroup(group)
+				if err != nil {
+					log.Printf("Error fetching environment variables in group %s: %v", group, err)
+					apierror.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
+					return nil, false
+				}
+				if len(envVars) == 0 {
+					log.Printf("Warning: env variable group '%s' is empty", group)
+				}
+				for _, envVar := range envVars {
+					if !included[envVar.ID] {
+						included[envVar.ID] = true
+						env.add(envVar)
+					}
+				}
+			}
+		}
+
+		// Fetch specific environment variables by Name (Vault)
+		for i, envVarName := range exec.EnvVarNames {
+			// Get group from EnvVarGroups if available, otherwise use default
+			envVarGroup := "default"
+			if i < len(exec.EnvVarGroups) {
+				envVarGroup = exec.EnvVarGroups[i]
+			}
+			envVar, err := s.getEnvVariableByNameFromVault(r.Context(), envVarGroup, envVarName)
+			if err != nil {
+				log.Printf("Warning: env variable '%s' not found in Vault: %v", envVarName, err)
+				continue
+			}
+			if envVar == nil {
+				log.Printf("Warning: env variable '%s' not found in Vault", envVarName)
+				continue
+			}
+			if !access.canExecute(models.ResourceTypeEnvVariables, envVar.Group) {
+				denyGroupAccess(w, r, models.ResourceTypeEnvVariables, envVar.Group, models.PermissionExecute)
+				return nil, false
+			}
+			env.add(envVar)
+		}
+	} else if exec.IncludeEnvVars {
+		// Backwards compatibility: fetch all environment variables
+		envVars, err := envRepo.GetAll()
+		if err != nil {
+			log.Printf("Error fetching environment variables: %v", err)
+			apierror.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
+			return nil, false
+		}
+		for _, envVar := range envVars {
+			if !access.canExecute(models.ResourceTypeEnvVariables, envVar.Group) {
+				continue // Restricted groups are only included when selected explicitly by a permitted caller
+			}
+			env.add(envVar)
+		}
+	}
+
+	return env, true
+}
+
+// renderScriptSecrets replaces the {{env.NAME}} and {{vault:group/NAME}} placeholders in script content
+// Only the referenced variables are read; their values are masked in the output like exported ones
+// The error response is written and false returned when a placeholder cannot be resolved or used
+func (s *Server) renderScriptSecrets(w http.ResponseWriter, r *http.Request, access *groupAccess, content string, env *scriptEnv) (string, bool) {
+	placeholders := executor.FindPlaceholders(content)
+	if len(placeholders) == 0 {
+		return content, true
+	}
+
+	envRepo := repository.NewEnvVariableRepository(s.db)
+	values := make(map[string]string, len(placeholders))
+	for _, p := range placeholders {
+		var envVar *models.EnvVariable
+		var err error
+		if p.Vault {
+			if s.getVaultClientIfEnabled() == nil {
+				apierror.Error(w, fmt.Sprintf("Cannot resolve placeholder %s: Vault is not enabled", p), http.StatusBadRequest)
+				return "", false
+			}
+			envVar, err = s.getEnvVariableByNameFromVault(r.Context(), p.Group, p.Name)
+			if err != nil || envVar == nil {
+				log.Printf("Error resolving placeholder %s from Vault: %v", p, err)
+				apierror.Error(w, fmt.Sprintf("Cannot resolve placeholder %s: variable not found in Vault", p), http.StatusBadRequest)
+				return "", false
+			}
+		} else {
+			envVar, err = envRepo.GetByName(p.Name)
+			if err != nil {
+				if strings.Contains(err.Error(), "not found") {
+					apierror.Error(w, fmt.Sprintf("Cannot resolve placeholder %s: variable not found", p), http.StatusBadRequest)
+					return "", false
+				}
+				log.Printf("Error resolving placeholder %s: %v", p, err)
+				apierror.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
+				return "", false
+			}
+		}
+
+		if !access.canExecute(models.ResourceTypeEnvVariables, envVar.Group) {
+			denyGroupAccess(w, r, models.ResourceTypeEnvVariables, envVar.Group, models.PermissionExecute)
+			return "", false
+		}
+		values[p.Key] = envVar.Value
+		if !slices.Contains(env.names, envVar.Name) {
+			env.names = append(env.names, envVar.Name)
+		}
+		env.secrets = append(env.secrets, envVar.Value)
+	}
+
+	return executor.RenderPlaceholders(content, values), true
+}
+
+// vaultRefPrefix starts references to Vault items in execution requests
+const vaultRefPrefix = "vault:"
+
+// vaultRef is a "vault:group/name" reference to a Vault item in an execution request,
+// with the request fields it stands in for
+type vaultRef struct {
+	field string  // Request field, for errors
+	ref   string  // The reference; empty if not given
+	id    *int64  // SQLite ID field of the same item
+	name  *string // Vault name field, set from the reference
+	group *string // Vault group field, set from the reference
+}
+
+// applyVaultRefs resolves references into the name and group fields of their Vault items
+// A reference without a group ("vault:name") refers to the default group; an item
+// may be given by reference, ID or name, but only one of them
+func applyVaultRefs(refs ...vaultRef) error {
+	for _, r := range refs {
+		if r.ref == "" {
+			continue
+		}
+		if (r.id != nil && *r.id > 0) || *r.name != "" {
+			return fmt.Errorf("%s cannot be combined with an ID or name for the same item", r.field)
+		}
+		path, ok := strings.CutPrefix(r.ref, vaultRefPrefix)
+		if !ok {
+			return fmt.Errorf("%s must have the form vault:group/name", r.field)
+		}
+		group, name, found := strings.Cut(path, "/")
+		if !found {
+			group, name = "default", path
+		}
+		if err := validation.ValidateVaultGroupName(group); err != nil {
+			return fmt.Errorf("%s: invalid group: %v", r.field, err)
+		}
+		if err := validation.ValidateVaultSecretName(name); err != nil {
+			return fmt.Errorf("%s: invalid name: %v", r.field, err)
+		}
+		*r.name, *r.group = name, group
+	}
+	return nil
+}
+
+// maxStdinSize is the largest input accepted for a command or script's stdin
+const maxStdinSize = 32 << 20
+
+// executionInput decodes the stdin of an execution request, given as text or base64
+// Returns nil when there is no input, so the process reads from /dev/null as before
+func executionInput(text, encoded string) ([]byte, error) {
+	if text != "" && encoded != "" {
+		return nil, fmt.Errorf("set stdin or stdin_base64, not both")
+	}
+	var input []byte
+	if encoded != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("stdin_base64 is not valid base64")
+		}
+		input = decoded
+	} else if text != "" {
+		input = []byte(text)
+	}
+	if len(input) > maxStdinSize {
+		return nil, fmt.Errorf("too large (max %d MB)", maxStdinSize>>20)
+	}
+	return input, nil
+}
+
+// stripANSIRequested reports whether the strip_ansi query parameter asks for outputs without terminal codes
+// Outputs are stored raw, so the web UI can render their colors
+func stripANSIRequested(r *http.Request) bool {
+	strip, _ := strconv.ParseBool(r.URL.Query().Get("strip_ansi"))
+	return strip
+}
+
+// executionRetry returns the retry policy of an execution request, rejecting out-of-range values
+func executionRetry(w http.ResponseWriter, retries, backoffMs int) (executor.RetryPolicy, bool) {
+	if retries < 0 || retries > executor.MaxRetries {
+		apierror.InvalidField(w, "retries", fmt.Sprintf("retries must be between 0 and %d", executor.MaxRetries))
+		return executor.RetryPolicy{}, false
+	}
+	backoff := time.Duration(backoffMs) * time.Millisecond
+	if backoffMs < 0 || backoff > executor.MaxRetryDelay {
+		apierror.InvalidField(w, "retry_backoff_ms", fmt.Sprintf("retry_backoff_ms must be between 0 and %d", executor.MaxRetryDelay.Milliseconds()))
+		return executor.RetryPolicy{}, false
+	}
+	return executor.RetryPolicy{Retries: retries, Backoff: backoff}, true
+}
+
+// executionPreset returns the preset a script execution was started from, filling its retry
+// settings into exec where the request leaves them unset
+// Returns nil without a preset, or when the preset was saved for another script.
+func (s *Server) executionPreset(exec *models.ScriptExecution) *models.ScriptPreset {
+	if exec.PresetID == nil || exec.ScriptID == 0 {
+		return nil
+	}
+	preset, err := repository.NewScriptPresetRepository(s.db).GetByID(*exec.PresetID)
+	if err != nil || preset.ScriptID != exec.ScriptID {
+		return nil
+	}
+	if exec.Retries == 0 && exec.RetryBackoff == 0 {
+		exec.Retries, exec.RetryBackoff = preset.Retries, preset.RetryBackoff
+	}
+	return preset
+}
+
+// presetTimeout returns the longest a script may run: the caller's quota timeout, shortened
+// by the timeout of the preset it was started from
+func presetTimeout(ctx context.Context, preset *models.ScriptPreset) time.Duration {
+	timeout := middleware.ExecutionTimeoutFromContext(ctx)
+	if preset != nil && preset.TimeoutSeconds > 0 {
+		presetTimeout := time.Duration(preset.TimeoutSeconds) * time.Second
+		if timeout == 0 || presetTimeout < timeout {
+			timeout = presetTimeout
+		}
+	}
+	return timeout
+}
+
+// maxScriptArgs is the most arguments an uploaded script can be given
+const maxScriptArgs = 100
+
+// checkScriptUpload validates the upload mode and arguments of a script execution
+func checkScriptUpload(w http.ResponseWriter, exec *models.ScriptExecution) bool {
+	if len(exec.Args) > 0 && !exec.Upload {
+		apierror.InvalidField(w, "args", "args are only passed to uploaded scripts; set upload")
+		return false
+	}
+	if exec.Upload && (!exec.IsRemote || exec.Kubernetes != nil) {
+		apierror.InvalidField(w, "upload", "upload is only available for remote servers")
+		return false
+	}
+	if exec.Upload && exec.RunAsRemote != "" {
+		// The uploaded file is private to the SSH user
+		apierror.InvalidField(w, "upload", "upload cannot be combined with run_as_remote_user")
+		return false
+	}
+	if len(exec.Args) > maxScriptArgs {
+		apierror.InvalidField(w, "args", fmt.Sprintf("too many args (max %d)", maxScriptArgs))
+		return false
+	}
+	for _, arg := range exec.Args {
+		if strings.ContainsRune(arg, 0) {
+			apierror.InvalidField(w, "args", "args cannot contain null bytes")
+			return false
+		}
+	}
+	return true
+}
+
+// checkRunAsRemoteUser validates running a remote execution as another user through sudo
+// Stdin cannot be combined with a sudo password, which is answered through a PTY.
+func checkRunAsRemoteUser(w http.ResponseWriter, runAs string, isRemote bool, kubernetes *models.KubernetesTarget, sudoPassword string, stdin []byte) bool {
+	if runAs == "" {
+		return true
+	}
+	if !isRemote || kubernetes != nil {
+		apierror.InvalidField(w, "run_as_remote_user", "run_as_remote_user is only available for remote servers")
+		return false
+	}
+	if err := validation.ValidateUsername(runAs); err != nil {
+		apierror.InvalidField(w, "run_as_remote_user", fmt.Sprintf("Invalid run_as_remote_user: %v", err))
+		return false
+	}
+	if sudoPassword != "" && stdin != nil {
+		apierror.InvalidField(w, "stdin", "stdin cannot be combined with run_as_remote_user and sudo_password")
+		return false
+	}
+	return true
 }
 
 // StreamMessage represents a message sent via SSE
@@ -1908,13 +2994,27 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 	var exec models.ScriptExecution
 
 	if err := json.NewDecoder(r.Body).Decode(&exec); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := applyVaultRefs(
+		vaultRef{"server_ref", exec.ServerRef, exec.ServerID, &exec.ServerName, &exec.ServerGroup},
+		vaultRef{"ssh_key_ref", exec.SSHKeyRef, exec.SSHKeyID, &exec.SSHKeyName, &exec.SSHKeyGroup},
+	); err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid reference: %v", err), http.StatusBadRequest)
 		return
 	}
 
 	// Validate input - either ScriptID or ScriptName must be provided
 	if exec.ScriptID == 0 && exec.ScriptName == "" {
-		http.Error(w, "Script ID or Script Name is required", http.StatusBadRequest)
+		apierror.Error(w, "Script ID or Script Name is required", http.StatusBadRequest)
+		return
+	}
+
+	// A syntax check has no output worth streaming
+	if exec.DryRun {
+		apierror.Error(w, "dry_run is not supported when streaming; use POST /api/bash-scripts/execute", http.StatusBadRequest)
 		return
 	}
 
@@ -1922,91 +3022,121 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 	if exec.User == "" {
 		exec.User = "root"
 	} else if err := validation.ValidateUsername(exec.User); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid user: %v", err), http.StatusBadRequest)
+		apierror.InvalidField(w, "user", fmt.Sprintf("Invalid user: %v", err))
+		return
+	}
+
+	stdin, err := executionInput(exec.Stdin, exec.StdinBase64)
+	if err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid stdin: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validation.ValidateWorkdir(exec.Workdir); err != nil {
+		apierror.InvalidField(w, "workdir", fmt.Sprintf("Invalid workdir: %v", err))
+		return
+	}
+	preset := s.executionPreset(&exec)
+	var presetID *int64
+	if preset != nil {
+		presetID = &preset.ID
+	}
+	retry, ok := executionRetry(w, exec.Retries, exec.RetryBackoff)
+	if !ok {
+		return
+	}
+	if !checkScriptUpload(w, &exec) {
+		return
+	}
+	if !checkRunAsRemoteUser(w, exec.RunAsRemote, exec.IsRemote, exec.Kubernetes, exec.SudoPassword, stdin) {
+		return
+	}
+	opts := executor.RunOptions{Stdin: stdin, Dir: exec.Workdir, Retry: retry, Timeout: presetTimeout(r.Context(), preset)}
+	if exec.RunAsRemote != "" {
+		opts.RunAs, opts.SudoPassword = exec.RunAsRemote, exec.SudoPassword
+	}
+
+	if !exec.IsRemote && exec.Kubernetes == nil && !s.checkLocalUser(w, exec.User) {
 		return
 	}
 
+	// Group permissions limit which scripts, servers, keys and env vars the caller may use
+	access := s.groupAccess(r)
+
 	// Fetch the script - support both ID (SQLite) and Name (Vault)
 	var script *models.BashScript
-	var err error
 	if exec.ScriptID > 0 {
 		scriptRepo := repository.NewBashScriptRepository(s.db)
 		script, err = scriptRepo.GetByID(exec.ScriptID)
 		if err != nil {
 			log.Printf("Error fetching script by ID: %v", err)
-			http.Error(w, "Script not found", http.StatusNotFound)
+			apierror.Error(w, "Script not found", http.StatusNotFound)
 			return
 		}
 	} else if exec.ScriptName != "" {
 		script, err = s.getScriptByNameFromVault(r.Context(), exec.ScriptGroup, exec.ScriptName)
 		if err != nil {
 			log.Printf("Error fetching script from Vault: %v", err)
-			http.Error(w, "Script not found in Vault", http.StatusNotFound)
+			apierror.Error(w, "Script not found in Vault", http.StatusNotFound)
 			return
 		}
 		if script == nil {
-			http.Error(w, "Script not found in Vault", http.StatusNotFound)
+			apierror.Error(w, "Script not found in Vault", http.StatusNotFound)
 			return
 		}
 	}
 
-	// Build the script content with optional env vars
-	var scriptContent strings.Builder
-	envVarsCount := 0
+	if !access.canExecute(models.ResourceTypeBashScripts, script.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeBashScripts, script.Group, models.PermissionExecute)
+		return
+	}
 
-	// Determine which env vars to include
-	envRepo := repository.NewEnvVariableRepository(s.db)
+	// Command policies may deny the script by name or by the commands it contains
+	if !s.checkScriptPolicy(w, r, script) {
+		return
+	}
 
-	if len(exec.EnvVarIDs) > 0 || len(exec.EnvVarNames) > 0 {
-		// Fetch specific environment variables by ID (SQLite)
-		for _, envVarID := range exec.EnvVarIDs {
-			envVar, err := envRepo.GetByID(envVarID)
-			if err != nil {
-				log.Printf("Warning: env variable ID %d not found: %v", envVarID, err)
-				continue
-			}
-			escapedValue := strings.ReplaceAll(envVar.Value, "'", "'\\''")
-			scriptContent.WriteString(fmt.Sprintf("export %s='%s'\n", envVar.Name, escapedValue))
-			envVarsCount++
-		}
-		// Fetch specific environment variables by Name (Vault)
-		for i, envVarName := range exec.EnvVarNames {
-			// Get group from EnvVarGroups if available, otherwise use default
-			envVarGroup := "default"
-			if i < len(exec.EnvVarGroups) {
-				envVarGroup = exec.EnvVarGroups[i]
-			}
-			envVar, err := s.getEnvVariableByNameFromVault(r.Context(), envVarGroup, envVarName)
-			if err != nil {
-				log.Printf("Warning: env variable '%s' not found in Vault: %v", envVarName, err)
-				continue
-			}
-			if envVar == nil {
-				log.Printf("Warning: env variable '%s' not found in Vault", envVarName)
-				continue
-			}
-			escapedValue := strings.ReplaceAll(envVar.Value, "'", "'\\''")
-			scriptContent.WriteString(fmt.Sprintf("export %s='%s'\n", envVar.Name, escapedValue))
-			envVarsCount++
-		}
-	} else if exec.IncludeEnvVars {
-		envVars, err := envRepo.GetAll()
-		if err != nil {
-			log.Printf("Error fetching environment variables: %v", err)
-			http.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
-			return
-		}
-		for _, envVar := range envVars {
-			escapedValue := strings.ReplaceAll(envVar.Value, "'", "'\\''")
-			scriptContent.WriteString(fmt.Sprintf("export %s='%s'\n", envVar.Name, escapedValue))
-			envVarsCount++
-		}
+	// Env vars are set for the script, through SSH env requests when the server accepts them
+	env, ok := s.resolveScriptEnv(w, r, access, &exec)
+	if !ok {
+		return
 	}
+	opts.Env = env.vars
 
-	scriptContent.WriteString(script.Content)
-	finalScript := scriptContent.String()
+	// Secret placeholders in the script are replaced by the values they reference
+	content, ok := s.renderScriptSecrets(w, r, access, script.Content, env)
+	if !ok {
+		return
+	}
+
+	// The script runs through its interpreter
+	finalScript, err := executor.ScriptCommand(script.Interpreter, content)
+	if exec.Upload {
+		// or from a file copied to the server, with its arguments
+		finalScript, opts.Upload, err = executor.UploadScript(script.Interpreter, content, exec.Args)
+	}
+	if err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid script: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Remote hosts and pods are checked by the script itself; local interpreters are checked up front
+	if !exec.IsRemote && exec.Kubernetes == nil && !checkLocalInterpreter(w, script) {
+		return
+	}
 
 	serverName := "local"
+	var serverGroup string
+	var serverID *int64
+
+	// Pods are resolved before streaming starts, so a bad target gets a regular error response
+	var pod *kubernetesPod
+	if exec.Kubernetes != nil {
+		if pod = s.resolveKubernetesTarget(w, r, access, exec.Kubernetes, exec.IsRemote); pod == nil {
+			return
+		}
+		serverName, serverGroup = pod.name(), pod.namespace
+	}
 
 	// Set up SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -2016,7 +3146,7 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		apierror.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
 
@@ -2054,8 +3184,14 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
+		if !access.canExecute(models.ResourceTypeServers, server.Group) {
+			sendSSE(w, flusher, "error", groupDenied(r, models.ResourceTypeServers, server.Group, models.PermissionExecute))
+			return
+		}
+
 		// Get SSH key if provided - support both ID (SQLite) and Name (Vault)
 		var privateKey string
+		var sshKey *models.SSHKey
 		if exec.SSHKeyID != nil && *exec.SSHKeyID > 0 {
 			keyRepo := repository.NewSSHKeyRepository(s.db)
 			key, err := keyRepo.GetByID(*exec.SSHKeyID)
@@ -2064,7 +3200,12 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 				sendSSE(w, flusher, "error", "SSH key not found")
 				return
 			}
+			if !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+				sendSSE(w, flusher, "error", groupDenied(r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute))
+				return
+			}
 			privateKey = key.PrivateKey
+			sshKey = key
 		} else if exec.SSHKeyName != "" {
 			key, err := s.getSSHKeyByNameFromVault(r.Context(), exec.SSHKeyGroup, exec.SSHKeyName)
 			if err != nil {
@@ -2080,7 +3221,16 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 				sendSSE(w, flusher, "error", fmt.Sprintf("SSH key '%s' has no private key data in Vault", exec.SSHKeyName))
 				return
 			}
+			if !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+				sendSSE(w, flusher, "error", groupDenied(r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute))
+				return
+			}
 			privateKey = key.PrivateKey
+			sshKey = key
+		} else if key := s.groupSSHKey(server, access); key != nil {
+			// Without a selected key, the server group's default key is used
+			privateKey = key.PrivateKey
+			sshKey = key
 		}
 
 		if server.Name != "" {
@@ -2088,41 +3238,111 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 		} else if server.IPAddress != "" {
 			serverName = server.IPAddress
 		}
+		serverGroup = server.Group
+		serverID = optionalID(server.ID)
+
+		if opts.Upload != nil && viaRunCommand(server) {
+			sendSSE(w, flusher, "error", "upload is not available for servers using the "+server.Transport+" transport")
+			return
+		}
+		if opts.RunAs != "" && viaRunCommand(server) {
+			sendSSE(w, flusher, "error", "run_as_remote_user is not available for servers using the "+server.Transport+" transport; set user")
+			return
+		}
+
+		if block := s.checkMaintenanceWindow(r, server, exec.User, exec.MaintenanceOverride); block != nil {
+			sendSSE(w, flusher, "error", block.message())
+			return
+		}
+
+		// Scripts and servers may require a second person's approval before anything runs
+		if !s.streamApproval(w, flusher, r, scriptApprovalRequest(&exec, script, server, serverName)) {
+			return
+		}
 
 		sendSSE(w, flusher, "status", fmt.Sprintf("Connecting to %s...", serverName))
 
+		// Servers reached through Systems Manager or an agent use no SSH key or certificate
+		withoutSSH := viaRunCommand(server)
+
+		// Without a selected key, sign a short-lived one with Vault's SSH CA if configured
+		var certificate string
+		if privateKey == "" && !withoutSSH {
+			cert, err := s.vaultSSHCertificate(r.Context(), exec.User)
+			if err != nil {
+				log.Printf("Error issuing SSH certificate from Vault: %v", err)
+				sendSSE(w, flusher, "error", "Failed to issue SSH certificate from Vault")
+				return
+			}
+			if cert != nil {
+				privateKey, certificate = cert.PrivateKey, cert.Certificate
+			}
+		}
+
+		if !withoutSSH {
+			s.recordSSHKeyUse(r, sshKey, serverName, exec.User, "script")
+		}
+
 		// Execute with streaming
 		remoteExec := executor.NewRemoteExecutorWithHostKeys("", true)
 		sshConfig := &executor.SSHConfig{
-			Host:       server.IPAddress,
-			Port:       server.Port,
-			Username:   exec.User,
-			PrivateKey: privateKey,
-			Password:   exec.SSHPassword,
+			Host:        server.IPAddress,
+			Port:        server.Port,
+			Username:    exec.User,
+			PrivateKey:  privateKey,
+			Certificate: certificate,
+			Password:    exec.SSHPassword,
+
+			HostKeyPolicy:      server.HostKeyPolicy,
+			HostKeyFingerprint: server.HostKeyFingerprint,
 		}
 
-		outputChan, resultChan := remoteExec.ExecuteWithStreaming(ctx, finalScript, sshConfig)
+		unlock, holder, err := s.lockScript(ctx, script, &exec, serverName)
+		if err != nil {
+			sendSSE(w, flusher, "error", lockErrorMessage(script, holder, err))
+			return
+		}
+		defer unlock()
 
-		// Stream output
-		var fullOutput strings.Builder
-		for chunk := range outputChan {
-			fullOutput.WriteString(chunk)
-			sendSSE(w, flusher, "output", chunk)
+		release, err := s.acquireExecutionSlot(ctx, server, func() { sendSSE(w, flusher, "status", "Waiting for a free execution slot...") })
+		if err != nil {
+			sendSSE(w, flusher, "error", queueErrorMessage(err))
+			return
+		}
+		defer release()
+		runCtx, finished := s.startExecution(ctx, r, scriptExecution(script, &exec, serverName, serverGroup, ""))
+		defer finished()
+
+		var outputChan <-chan string
+		var resultChan <-chan *executor.ExecuteResult
+		if withoutSSH {
+			outputChan, resultChan = s.runCommandExecutor(server).ExecuteWithStreamingOptions(runCtx, finalScript, exec.User, opts)
+		} else {
+			outputChan, resultChan = remoteExec.ExecuteWithStreamingOptions(runCtx, finalScript, sshConfig, opts)
 		}
 
+		// Stream output with env var values masked
+		masker := executor.NewSecretMasker(env.secrets)
+		streamMasked(w, flusher, masker, outputChan)
+
 		// Get final result
 		result := <-resultChan
+		result.Output = masker.Mask(result.Output)
 
 		// Save to history
 		exitCode := result.ExitCode
-		historyRepo := repository.NewCommandHistoryRepository(s.db)
-		_, err = historyRepo.Create(&models.CommandHistoryCreate{
-			Command:         fmt.Sprintf("[Script: %s] %s", script.Name, script.Content[:min(100, len(script.Content))]),
+		severity := script.ExitCodes.Severity(exitCode)
+		history, err := s.recordHistory(r, &models.CommandHistoryCreate{
+			Command:         masker.Mask(fmt.Sprintf("[Script: %s] %s", script.Name, script.Content[:min(100, len(script.Content))])),
 			Output:          result.Output,
 			ExitCode:        &exitCode,
 			Server:          serverName,
 			User:            exec.User,
 			ExecutionTimeMs: result.ExecutionTime,
+			Severity:        severity,
+			ScriptID:        optionalID(script.ID),
+			PresetID:        presetID,
+			ServerID:        serverID,
 		})
 		if err != nil {
 			log.Printf("Warning: failed to save command history: %v", err)
@@ -2130,45 +3350,92 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 
 		// Audit log the script execution
 		audit.GetLogger().LogScriptExecution(r, script.Name, exec.User, serverName, exitCode, result.ExecutionTime, result.Error)
+		s.notifyExecution(r, scriptExecution(script, &exec, serverName, serverGroup, severity), result)
 
-		// Send final result
+		// Send final result; the output was streamed whole, so the result carries it cut like in history
+		output, truncated := s.outputLogs.Truncate(result.Output)
+		if exec.StripANSI {
+			output = executor.StripANSI(output)
+		}
 		scriptResult := models.ScriptResult{
 			ScriptID:      script.ID,
 			ScriptName:    script.Name,
-			Output:        result.Output,
+			Output:        output,
 			ExitCode:      result.ExitCode,
 			User:          exec.User,
 			Server:        serverName,
 			ExecutionTime: result.ExecutionTime,
-			EnvVarsCount:  envVarsCount,
+			EnvVarsCount:  len(env.names),
+			EnvVars:       env.names,
+			Attempts:      result.Attempts,
+			Truncated:     truncated,
+			Severity:      severity,
+		}
+		if history != nil {
+			scriptResult.HistoryID = history.ID
 		}
 		sendSSEResult(w, flusher, &scriptResult)
 
 	} else {
-		// Local execution with streaming
-		localExec := executor.NewLocalExecutor()
-		outputChan, resultChan := localExec.ExecuteWithStreaming(ctx, finalScript, exec.User, exec.SudoPassword)
+		// Maintenance windows cover servers and the local machine, not pods
+		if pod == nil {
+			if block := s.checkMaintenanceWindow(r, nil, exec.User, exec.MaintenanceOverride); block != nil {
+				sendSSE(w, flusher, "error", block.message())
+				return
+			}
+		}
+		if !s.streamApproval(w, flusher, r, scriptApprovalRequest(&exec, script, nil, serverName)) {
+			return
+		}
 
-		// Stream output
-		var fullOutput strings.Builder
-		for chunk := range outputChan {
-			fullOutput.WriteString(chunk)
-			sendSSE(w, flusher, "output", chunk)
+		unlock, holder, err := s.lockScript(ctx, script, &exec, serverName)
+		if err != nil {
+			sendSSE(w, flusher, "error", lockErrorMessage(script, holder, err))
+			return
 		}
+		defer unlock()
+
+		// Local or pod execution with streaming
+		release, err := s.acquireExecutionSlot(ctx, nil, func() { sendSSE(w, flusher, "status", "Waiting for a free execution slot...") })
+		if err != nil {
+			sendSSE(w, flusher, "error", queueErrorMessage(err))
+			return
+		}
+		defer release()
+		runCtx, finished := s.startExecution(ctx, r, scriptExecution(script, &exec, serverName, serverGroup, ""))
+		defer finished()
+
+		var outputChan <-chan string
+		var resultChan <-chan *executor.ExecuteResult
+		if pod != nil {
+			outputChan, resultChan = s.kubernetesExecutor(pod, scriptShell(script)).ExecuteWithStreamingOptions(runCtx, finalScript, opts)
+		} else {
+			localExec := s.newLocalExecutor()
+			outputChan, resultChan = localExec.ExecuteWithStreamingOptions(runCtx, finalScript, exec.User, s.sudoPassword(r, exec.SudoPassword), opts)
+		}
+
+		// Stream output with env var values masked
+		masker := executor.NewSecretMasker(env.secrets)
+		streamMasked(w, flusher, masker, outputChan)
 
 		// Get final result
 		result := <-resultChan
+		result.Output = masker.Mask(result.Output)
 
 		// Save to history
 		exitCode := result.ExitCode
-		historyRepo := repository.NewCommandHistoryRepository(s.db)
-		_, err = historyRepo.Create(&models.CommandHistoryCreate{
-			Command:         fmt.Sprintf("[Script: %s] %s", script.Name, script.Content[:min(100, len(script.Content))]),
+		severity := script.ExitCodes.Severity(exitCode)
+		history, err := s.recordHistory(r, &models.CommandHistoryCreate{
+			Command:         masker.Mask(fmt.Sprintf("[Script: %s] %s", script.Name, script.Content[:min(100, len(script.Content))])),
 			Output:          result.Output,
 			ExitCode:        &exitCode,
 			Server:          serverName,
 			User:            exec.User,
 			ExecutionTimeMs: result.ExecutionTime,
+			Severity:        severity,
+			ScriptID:        optionalID(script.ID),
+			PresetID:        presetID,
+			ServerID:        serverID,
 		})
 		if err != nil {
 			log.Printf("Warning: failed to save command history: %v", err)
@@ -2176,9 +3443,13 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 
 		// Audit log the script execution
 		audit.GetLogger().LogScriptExecution(r, script.Name, exec.User, serverName, exitCode, result.ExecutionTime, result.Error)
+		s.notifyExecution(r, scriptExecution(script, &exec, serverName, serverGroup, severity), result)
 
-		// Send final result
-		scriptOutput := result.Output
+		// Send final result; the output was streamed whole, so the result carries it cut like in history
+		scriptOutput, truncated := s.outputLogs.Truncate(result.Output)
+		if exec.StripANSI {
+			scriptOutput = executor.StripANSI(scriptOutput)
+		}
 		if result.Error != nil && scriptOutput == "" {
 			scriptOutput = fmt.Sprintf("Error: %s", result.Error.Error())
 		}
@@ -2191,12 +3462,30 @@ func (s *Server) handleExecuteScriptStream(w http.ResponseWriter, r *http.Reques
 			User:          exec.User,
 			Server:        serverName,
 			ExecutionTime: result.ExecutionTime,
-			EnvVarsCount:  envVarsCount,
+			EnvVarsCount:  len(env.names),
+			EnvVars:       env.names,
+			Truncated:     truncated,
+			Severity:      severity,
+		}
+		if history != nil {
+			scriptResult.HistoryID = history.ID
 		}
 		sendSSEResult(w, flusher, &scriptResult)
 	}
 }
 
+// streamMasked sends streamed output as SSE "output" events with secret values masked
+func streamMasked(w http.ResponseWriter, flusher http.Flusher, masker *executor.SecretMasker, outputChan <-chan string) {
+	for chunk := range outputChan {
+		if masked := masker.Write(chunk); masked != "" {
+			sendSSE(w, flusher, "output", masked)
+		}
+	}
+	if rest := masker.Flush(); rest != "" {
+		sendSSE(w, flusher, "output", rest)
+	}
+}
+
 // sendSSE sends a Server-Sent Event message
 func sendSSE(w http.ResponseWriter, flusher http.Flusher, eventType, data string) {
 	msg := StreamMessage{
@@ -2223,39 +3512,52 @@ func sendSSEResult(w http.ResponseWriter, flusher http.Flusher, result *models.S
 
 // handleListScriptPresets godoc
 // @Summary List all script presets
-// @Description Get a list of all script execution presets
+// @Description Get a list of all script execution presets, optionally filtered, sorted and paged
 // @Tags Script Presets
 // @Accept json
 // @Produce json
+// @Param q query string false "Case-insensitive text in the name or description"
+// @Param sort query string false "Field to sort by: name, created_at, updated_at"
+// @Param order query string false "Sort order: asc or desc"
+// @Param page query int false "Page number, from 1; without page or per_page every item is returned"
+// @Param per_page query int false "Items per page, 1 to 500 (default 50)"
+// @Header 200 {integer} X-Total-Count "Number of matching items"
+// @Header 200 {string} Link "Links to the next and previous pages, when paged"
 // @Success 200 {array} models.ScriptPresetResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /script-presets [get]
 func (s *Server) handleListScriptPresets(w http.ResponseWriter, r *http.Request) {
-	repo := repository.NewScriptPresetRepository(s.db)
+	filter, err := parseListFilter(r)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	presets, err := repo.GetAll()
+	presets, total, err := repository.NewScriptPresetRepository(s.db).List(filter)
 	if err != nil {
-		log.Printf("Error fetching script presets: %v", err)
-		http.Error(w, "Failed to fetch script presets", http.StatusInternalServerError)
+		writeListError(w, err, "script presets")
 		return
 	}
 
 	responses := models.ScriptPresetsToList(presets)
 
+	writeListHeaders(w, r, filter, total)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(responses)
 }
 
 // handleCreateScriptPreset godoc
 // @Summary Create a script preset
-// @Description Create a new script execution preset
+// @Description Create a new script execution preset. A preset with a schedule (a cron expression in server time) and schedule_enabled also runs on that schedule; setting a schedule requires admin access.
 // @Tags Script Presets
 // @Accept json
 // @Produce json
 // @Param preset body models.ScriptPresetCreate true "Script preset to create"
 // @Success 201 {object} models.ScriptPresetResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /script-presets [post]
@@ -2263,18 +3565,32 @@ func (s *Server) handleCreateScriptPreset(w http.ResponseWriter, r *http.Request
 	var presetCreate models.ScriptPresetCreate
 
 	if err := json.NewDecoder(r.Body).Decode(&presetCreate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate required fields
 	if presetCreate.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		apierror.Error(w, "Name is required", http.StatusBadRequest)
 		return
 	}
 
 	if presetCreate.ScriptID == 0 {
-		http.Error(w, "Script ID is required", http.StatusBadRequest)
+		apierror.Error(w, "Script ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if !models.ValidSlackNotify(presetCreate.SlackNotify) {
+		apierror.Error(w, "Slack notify must be always, failures or empty", http.StatusBadRequest)
+		return
+	}
+
+	if err := validation.ValidateSchedule(presetCreate.Schedule, presetCreate.ScheduleEnabled); err != nil {
+		apierror.InvalidField(w, "schedule", fmt.Sprintf("Invalid schedule: %v", err))
+		return
+	}
+	if presetCreate.Schedule != "" && !middleware.PrincipalFromContext(r.Context()).IsAdmin() {
+		apierror.Error(w, "Scheduling script presets requires admin access", http.StatusForbidden)
 		return
 	}
 
@@ -2282,7 +3598,7 @@ func (s *Server) handleCreateScriptPreset(w http.ResponseWriter, r *http.Request
 	scriptRepo := repository.NewBashScriptRepository(s.db)
 	_, err := scriptRepo.GetByID(presetCreate.ScriptID)
 	if err != nil {
-		http.Error(w, "Script not found", http.StatusBadRequest)
+		apierror.Error(w, "Script not found", http.StatusBadRequest)
 		return
 	}
 
@@ -2292,7 +3608,7 @@ func (s *Server) handleCreateScriptPreset(w http.ResponseWriter, r *http.Request
 		for _, envVarID := range presetCreate.EnvVarIDs {
 			_, err := envRepo.GetByID(envVarID)
 			if err != nil {
-				http.Error(w, fmt.Sprintf("Environment variable with ID %d not found", envVarID), http.StatusBadRequest)
+				apierror.Error(w, fmt.Sprintf("Environment variable with ID %d not found", envVarID), http.StatusBadRequest)
 				return
 			}
 		}
@@ -2303,7 +3619,7 @@ func (s *Server) handleCreateScriptPreset(w http.ResponseWriter, r *http.Request
 		serverRepo := repository.NewServerRepository(s.db)
 		_, err := serverRepo.GetByID(*presetCreate.ServerID)
 		if err != nil {
-			http.Error(w, "Server not found", http.StatusBadRequest)
+			apierror.Error(w, "Server not found", http.StatusBadRequest)
 			return
 		}
 	}
@@ -2313,7 +3629,7 @@ func (s *Server) handleCreateScriptPreset(w http.ResponseWriter, r *http.Request
 		keyRepo := repository.NewSSHKeyRepository(s.db)
 		_, err := keyRepo.GetByID(*presetCreate.SSHKeyID)
 		if err != nil {
-			http.Error(w, "SSH key not found", http.StatusBadRequest)
+			apierror.Error(w, "SSH key not found", http.StatusBadRequest)
 			return
 		}
 	}
@@ -2321,9 +3637,13 @@ func (s *Server) handleCreateScriptPreset(w http.ResponseWriter, r *http.Request
 	repo := repository.NewScriptPresetRepository(s.db)
 
 	preset, err := repo.Create(&presetCreate)
+	if errors.Is(err, repository.ErrInvalidPreset) {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating script preset: %v", err)
-		http.Error(w, "Failed to create script preset", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to create script preset", http.StatusInternalServerError)
 		return
 	}
 
@@ -2350,7 +3670,7 @@ func (s *Server) handleGetScriptPreset(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid script preset ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid script preset ID", http.StatusBadRequest)
 		return
 	}
 
@@ -2359,7 +3679,7 @@ func (s *Server) handleGetScriptPreset(w http.ResponseWriter, r *http.Request) {
 	preset, err := repo.GetByID(id)
 	if err != nil {
 		log.Printf("Error fetching script preset: %v", err)
-		http.Error(w, "Script preset not found", http.StatusNotFound)
+		apierror.Error(w, "Script preset not found", http.StatusNotFound)
 		return
 	}
 
@@ -2369,7 +3689,7 @@ func (s *Server) handleGetScriptPreset(w http.ResponseWriter, r *http.Request) {
 
 // handleUpdateScriptPreset godoc
 // @Summary Update a script preset
-// @Description Update an existing script preset by its ID
+// @Description Update an existing script preset by its ID. Changing schedule or schedule_enabled requires admin access.
 // @Tags Script Presets
 // @Accept json
 // @Produce json
@@ -2377,6 +3697,8 @@ func (s *Server) handleGetScriptPreset(w http.ResponseWriter, r *http.Request) {
 // @Param preset body models.ScriptPresetUpdate true "Script preset update data"
 // @Success 200 {object} models.ScriptPresetResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /script-presets/{id} [put]
@@ -2386,23 +3708,52 @@ func (s *Server) handleUpdateScriptPreset(w http.ResponseWriter, r *http.Request
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid script preset ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid script preset ID", http.StatusBadRequest)
 		return
 	}
 
 	var presetUpdate models.ScriptPresetUpdate
 
 	if err := json.NewDecoder(r.Body).Decode(&presetUpdate); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if presetUpdate.SlackNotify != nil && !models.ValidSlackNotify(*presetUpdate.SlackNotify) {
+		apierror.Error(w, "Slack notify must be always, failures or empty", http.StatusBadRequest)
 		return
 	}
 
+	// Scheduled runs start without a caller, so only admins may change when they happen
+	if presetUpdate.Schedule != nil || presetUpdate.ScheduleEnabled != nil {
+		if !middleware.PrincipalFromContext(r.Context()).IsAdmin() {
+			apierror.Error(w, "Scheduling script presets requires admin access", http.StatusForbidden)
+			return
+		}
+		existing, err := repository.NewScriptPresetRepository(s.db).GetByID(id)
+		if err != nil {
+			apierror.Error(w, "Script preset not found", http.StatusNotFound)
+			return
+		}
+		schedule, enabled := existing.Schedule, existing.ScheduleEnabled
+		if presetUpdate.Schedule != nil {
+			schedule = *presetUpdate.Schedule
+		}
+		if presetUpdate.ScheduleEnabled != nil {
+			enabled = *presetUpdate.ScheduleEnabled
+		}
+		if err := validation.ValidateSchedule(schedule, enabled); err != nil {
+			apierror.InvalidField(w, "schedule", fmt.Sprintf("Invalid schedule: %v", err))
+			return
+		}
+	}
+
 	// Verify script exists if being updated
 	if presetUpdate.ScriptID != nil {
 		scriptRepo := repository.NewBashScriptRepository(s.db)
 		_, err := scriptRepo.GetByID(*presetUpdate.ScriptID)
 		if err != nil {
-			http.Error(w, "Script not found", http.StatusBadRequest)
+			apierror.Error(w, "Script not found", http.StatusBadRequest)
 			return
 		}
 	}
@@ -2413,7 +3764,7 @@ func (s *Server) handleUpdateScriptPreset(w http.ResponseWriter, r *http.Request
 		for _, envVarID := range presetUpdate.EnvVarIDs {
 			_, err := envRepo.GetByID(envVarID)
 			if err != nil {
-				http.Error(w, fmt.Sprintf("Environment variable with ID %d not found", envVarID), http.StatusBadRequest)
+				apierror.Error(w, fmt.Sprintf("Environment variable with ID %d not found", envVarID), http.StatusBadRequest)
 				return
 			}
 		}
@@ -2424,7 +3775,7 @@ func (s *Server) handleUpdateScriptPreset(w http.ResponseWriter, r *http.Request
 		serverRepo := repository.NewServerRepository(s.db)
 		_, err := serverRepo.GetByID(*presetUpdate.ServerID)
 		if err != nil {
-			http.Error(w, "Server not found", http.StatusBadRequest)
+			apierror.Error(w, "Server not found", http.StatusBadRequest)
 			return
 		}
 	}
@@ -2434,7 +3785,7 @@ func (s *Server) handleUpdateScriptPreset(w http.ResponseWriter, r *http.Request
 		keyRepo := repository.NewSSHKeyRepository(s.db)
 		_, err := keyRepo.GetByID(*presetUpdate.SSHKeyID)
 		if err != nil {
-			http.Error(w, "SSH key not found", http.StatusBadRequest)
+			apierror.Error(w, "SSH key not found", http.StatusBadRequest)
 			return
 		}
 	}
@@ -2442,9 +3793,13 @@ func (s *Server) handleUpdateScriptPreset(w http.ResponseWriter, r *http.Request
 	repo := repository.NewScriptPresetRepository(s.db)
 
 	preset, err := repo.Update(id, &presetUpdate)
+	if errors.Is(err, repository.ErrInvalidPreset) {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Error updating script preset: %v", err)
-		http.Error(w, "Failed to update script preset", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to update script preset", http.StatusInternalServerError)
 		return
 	}
 
@@ -2470,7 +3825,7 @@ func (s *Server) handleDeleteScriptPreset(w http.ResponseWriter, r *http.Request
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid script preset ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid script preset ID", http.StatusBadRequest)
 		return
 	}
 
@@ -2478,7 +3833,7 @@ func (s *Server) handleDeleteScriptPreset(w http.ResponseWriter, r *http.Request
 
 	if err := repo.Delete(id); err != nil {
 		log.Printf("Error deleting script preset: %v", err)
-		http.Error(w, "Failed to delete script preset", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete script preset", http.StatusInternalServerError)
 		return
 	}
 
@@ -2504,7 +3859,7 @@ func (s *Server) handleGetScriptPresetsByScript(w http.ResponseWriter, r *http.R
 
 	scriptID, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid script ID", http.StatusBadRequest)
+		apierror.Error(w, "Invalid script ID", http.StatusBadRequest)
 		return
 	}
 
@@ -2512,7 +3867,7 @@ func (s *Server) handleGetScriptPresetsByScript(w http.ResponseWriter, r *http.R
 	scriptRepo := repository.NewBashScriptRepository(s.db)
 	_, err = scriptRepo.GetByID(scriptID)
 	if err != nil {
-		http.Error(w, "Script not found", http.StatusNotFound)
+		apierror.Error(w, "Script not found", http.StatusNotFound)
 		return
 	}
 
@@ -2521,7 +3876,7 @@ func (s *Server) handleGetScriptPresetsByScript(w http.ResponseWriter, r *http.R
 	presets, err := repo.GetByScriptID(scriptID)
 	if err != nil {
 		log.Printf("Error fetching script presets: %v", err)
-		http.Error(w, "Failed to fetch script presets", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to fetch script presets", http.StatusInternalServerError)
 		return
 	}
 
@@ -2547,7 +3902,7 @@ func (s *Server) handleListSSHKeyGroups(w http.ResponseWriter, r *http.Request)
 	groups, err := repo.GetGroups()
 	if err != nil {
 		log.Printf("Error fetching SSH key groups: %v", err)
-		http.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
 		return
 	}
 
@@ -2569,6 +3924,8 @@ func (s *Server) handleListSSHKeyGroups(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	groups = s.groupAccess(r).visibleGroups(models.ResourceTypeSSHKeys, groups)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(groups)
 }
@@ -2589,7 +3946,7 @@ func (s *Server) handleListServerGroups(w http.ResponseWriter, r *http.Request)
 	groups, err := repo.GetGroups()
 	if err != nil {
 		log.Printf("Error fetching server groups: %v", err)
-		http.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
 		return
 	}
 
@@ -2610,6 +3967,8 @@ func (s *Server) handleListServerGroups(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	groups = s.groupAccess(r).visibleGroups(models.ResourceTypeServers, groups)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(groups)
 }
@@ -2630,7 +3989,7 @@ func (s *Server) handleListEnvVariableGroups(w http.ResponseWriter, r *http.Requ
 	groups, err := repo.GetGroups()
 	if err != nil {
 		log.Printf("Error fetching environment variable groups: %v", err)
-		http.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
 		return
 	}
 
@@ -2651,6 +4010,8 @@ func (s *Server) handleListEnvVariableGroups(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	groups = s.groupAccess(r).visibleGroups(models.ResourceTypeEnvVariables, groups)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(groups)
 }
@@ -2671,7 +4032,7 @@ func (s *Server) handleListBashScriptGroups(w http.ResponseWriter, r *http.Reque
 	groups, err := repo.GetGroups()
 	if err != nil {
 		log.Printf("Error fetching bash script groups: %v", err)
-		http.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to fetch groups", http.StatusInternalServerError)
 		return
 	}
 
@@ -2692,6 +4053,8 @@ func (s *Server) handleListBashScriptGroups(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	groups = s.groupAccess(r).visibleGroups(models.ResourceTypeBashScripts, groups)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(groups)
 }
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// viaRunCommand reports whether commands reach a server as whole scripts instead of over SSH:
+// through Systems Manager or the server's agent
+func viaRunCommand(server *models.Server) bool {
+	return server.Transport == models.ServerTransportSSM || server.Transport == models.ServerTransportAgent
+}
+
+// runCommandExecutor returns the executor of a server reached through Systems Manager or an agent
+func (s *Server) runCommandExecutor(server *models.Server) *executor.SSMExecutor {
+	if server.Transport == models.ServerTransportAgent {
+		return s.agentExecutor(server)
+	}
+	return s.ssmExecutor(server)
+}
+
+// agentExecutor returns the executor running commands on a server through its connected agent
+// Agents run whole scripts like Systems Manager does, but as the user they run as rather than root.
+func (s *Server) agentExecutor(server *models.Server) *executor.SSMExecutor {
+	connected, _ := s.agents.Get(server.Name)
+	return executor.NewAgentExecutor(func(ctx context.Context, script string, timeout time.Duration) (string, string, int, error) {
+		result, err := s.agents.Run(ctx, server.Name, script, timeout)
+		if result == nil {
+			return "", "", -1, err
+		}
+		return result.Stdout, result.Stderr, result.ExitCode, err
+	}, connected.User)
+}
+
+// handleAgentConnect godoc
+// @Summary Connect an agent
+// @Description WebSocket endpoint that "web-cli agent" dials out to, so servers without inbound SSH access can run commands. The agent names the server it runs commands for, which must use the agent transport and be the server its API token is bound to. A server has one agent at a time: others get 409 Conflict until it disconnects. Commands run as the user the agent runs as, through sudo for other users. Requires an API token with the agent scope, or admin access.
+// @Tags Servers
+// @Param name query string true "Name of the server the agent runs commands for"
+// @Success 101 "Switching Protocols"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /agents/connect [get]
+func (s *Server) handleAgentConnect(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		apierror.InvalidField(w, "name", "name is required")
+		return
+	}
+
+	server, ok := s.agentServer(w, r, name)
+	if !ok {
+		return
+	}
+	if server.Transport != models.ServerTransportAgent {
+		apierror.Error(w, fmt.Sprintf("Server '%s' does not use the agent transport", name), http.StatusConflict)
+		return
+	}
+	if _, connected := s.agents.Get(name); connected {
+		apierror.Error(w, fmt.Sprintf("An agent is already connected for server '%s'", name), http.StatusConflict)
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+
+	log.Printf("Agent for %s connected from %s", name, r.RemoteAddr)
+	if err := s.agents.Serve(ws, name, callerIdentity(r)); err != nil {
+		log.Printf("Agent for %s disconnected: %v", name, err)
+		return
+	}
+	log.Printf("Agent for %s disconnected", name)
+}
+
+// agentServer returns the server an agent connecting as name runs commands for
+// Agent tokens may only connect as the server they are bound to; admins may connect as any.
+func (s *Server) agentServer(w http.ResponseWriter, r *http.Request, name string) (*models.Server, bool) {
+	repo := repository.NewServerRepository(s.db)
+
+	principal := middleware.PrincipalFromContext(r.Context())
+	if !principal.IsAdmin() {
+		if principal.AgentServerID == nil {
+			apierror.Error(w, "API token is not bound to a server", http.StatusForbidden)
+			return nil, false
+		}
+		server, err := repo.GetByID(*principal.AgentServerID)
+		if err != nil || server.Name != name {
+			apierror.Error(w, fmt.Sprintf("API token may not connect as the agent of server '%s'", name), http.StatusForbidden)
+			return nil, false
+		}
+		return server, true
+	}
+
+	servers, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching servers: %v", err)
+		apierror.Error(w, "Failed to fetch servers", http.StatusInternalServerError)
+		return nil, false
+	}
+	var server *models.Server
+	for _, candidate := range servers {
+		if candidate.Name != name {
+			continue
+		}
+		server = candidate
+		if candidate.Transport == models.ServerTransportAgent {
+			break
+		}
+	}
+	if server == nil {
+		apierror.Error(w, fmt.Sprintf("Server '%s' not found", name), http.StatusNotFound)
+		return nil, false
+	}
+	return server, true
+}
+
+// handleListAgents godoc
+// @Summary List connected agents
+// @Description List the agents connected now, by the name of the server they run commands for, with their hostname, version, address and how many commands they are running. Servers using the agent transport without a connected agent cannot run commands. Requires admin access.
+// @Tags Servers
+// @Produce json
+// @Success 200 {array} models.ConnectedAgent
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /agents [get]
+func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.agents.List())
+}
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// errApprovalPending is returned by approvalGate when a new approval request was created
+var errApprovalPending = errors.New("approval required")
+
+// approvalRequest describes an execution that may need a second person's approval
+type approvalRequest struct {
+	required   bool   // Whether the script or server requires approval
+	reason     string // Why approval is required
+	kind       string // models.ApprovalKindCommand or models.ApprovalKindScript
+	summary    string // Command text or script name
+	server     string // Target server name, or "local"
+	runAs      string // User the execution runs as
+	approvalID *int64 // Approval supplied with the request
+	request    any    // Execution request, without secrets, the approval is bound to
+}
+
+// approvalGate enforces two-person approval for an execution
+// Without an approval ID it records a pending approval and returns it with
+// errApprovalPending. With one, it consumes the approval or returns an error.
+func (s *Server) approvalGate(r *http.Request, req *approvalRequest) (*models.Approval, error) {
+	if !req.required {
+		return nil, nil
+	}
+
+	fingerprint, err := approvalFingerprint(req.request)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := repository.NewApprovalRepository(s.db)
+	requester := callerIdentity(r)
+
+	if req.approvalID == nil {
+		approval, err := repo.Create(&models.Approval{
+			Kind:        req.kind,
+			Summary:     req.summary,
+			Server:      req.server,
+			RunAs:       req.runAs,
+			Reason:      req.reason,
+			Fingerprint: fingerprint,
+			RequestedBy: requester,
+		}, s.approvalTTL())
+		if err != nil {
+			return nil, err
+		}
+		audit.GetLogger().LogApproval(r, approval.ID, "requested", approval.Server, approval.Summary, audit.OutcomeSuccess)
+		return approval, errApprovalPending
+	}
+
+	approval, err := repo.Consume(*req.approvalID, fingerprint, requester)
+	if err != nil {
+		audit.GetLogger().LogApproval(r, *req.approvalID, "executed", req.server, req.summary, audit.OutcomeDenied)
+		return nil, err
+	}
+	audit.GetLogger().LogApproval(r, approval.ID, "executed", approval.Server, approval.Summary, audit.OutcomeSuccess)
+	return approval, nil
+}
+
+// requireApproval runs the approval gate for non-streaming handlers
+// Returns false after responding with 202 Accepted and the pending approval,
+// or 403 Forbidden if the supplied approval cannot be used
+func (s *Server) requireApproval(w http.ResponseWriter, r *http.Request, req *approvalRequest) bool {
+	approval, err := s.approvalGate(r, req)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, errApprovalPending) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(approval)
+		return false
+	}
+
+	log.Printf("Error checking approval: %v", err)
+	apierror.Error(w, "Approval required: "+err.Error(), http.StatusForbidden)
+	return false
+}
+
+// streamApproval runs the approval gate for streaming handlers after SSE headers are sent
+// A pending approval is sent as an "approval_required" event carrying the approval as JSON
+func (s *Server) streamApproval(w http.ResponseWriter, flusher http.Flusher, r *http.Request, req *approvalRequest) bool {
+	approval, err := s.approvalGate(r, req)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, errApprovalPending) {
+		data, _ := json.Marshal(approval)
+		sendSSE(w, flusher, "approval_required", string(data))
+		return false
+	}
+
+	log.Printf("Error checking approval: %v", err)
+	sendSSE(w, flusher, "error", "Approval required: "+err.Error())
+	return false
+}
+
+// commandApprovalRequest describes a remote command execution for the approval gate
+func commandApprovalRequest(exec *models.CommandExecution, server *models.Server, serverName string) *approvalRequest {
+	// Bind the approval to the request, never to passwords
+	bound := *exec
+	bound.SudoPassword, bound.SSHPassword, bound.SaveAs, bound.ApprovalID = "", "", "", nil
+
+	return &approvalRequest{
+		required:   server.RequiresApproval,
+		reason:     "server requires approval",
+		kind:       models.ApprovalKindCommand,
+		summary:    exec.Command,
+		server:     serverName,
+		runAs:      exec.User,
+		approvalID: exec.ApprovalID,
+		request:    bound,
+	}
+}
+
+// scriptApprovalRequest describes a script execution for the approval gate
+// server is nil for local execution
+func scriptApprovalRequest(exec *models.ScriptExecution, script *models.BashScript, server *models.Server, serverName string) *approvalRequest {
+	req := &approvalRequest{
+		required:   script.RequiresApproval,
+		reason:     "script requires approval",
+		kind:       models.ApprovalKindScript,
+		summary:    script.Name,
+		server:     serverName,
+		runAs:      exec.User,
+		approvalID: exec.ApprovalID,
+	}
+	if server != nil && server.RequiresApproval && !req.required {
+		req.required = true
+		req.reason = "server requires approval"
+	}
+
+	// Bind the approval to the request and script content, never to passwords
+	bound := *exec
+	bound.SudoPassword, bound.SSHPassword, bound.ApprovalID = "", "", nil
+	req.request = struct {
+		Execution models.ScriptExecution `json:"execution"`
+		Content   string                 `json:"content"`
+	}{bound, script.Content}
+
+	return req
+}
+
+// approvalFingerprint hashes an execution request so an approval only covers that exact request
+func approvalFingerprint(request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// callerIdentity returns the principal name recorded for approvals, favorites and history
+func callerIdentity(r *http.Request) string {
+	principal := middleware.PrincipalFromContext(r.Context())
+	if principal == nil || principal.Name == "" {
+		return "anonymous"
+	}
+	return principal.Name
+}
+
+// approvalTTL returns how long approval requests stay valid
+func (s *Server) approvalTTL() time.Duration {
+	if s.config == nil {
+		return time.Hour
+	}
+	return s.config.GetApprovalTTL()
+}
+
+// canApprove reports whether the caller may decide approval requests
+// Approvers are admins or principals with the approver role; authentication must be enabled
+func (s *Server) canApprove(r *http.Request) bool {
+	principal := middleware.PrincipalFromContext(r.Context())
+	if principal == nil {
+		return false
+	}
+	if principal.Admin {
+		return true
+	}
+
+	role := "approver"
+	if s.config != nil && s.config.ApproverRole != "" {
+		role = s.config.ApproverRole
+	}
+	for _, granted := range principal.Roles {
+		if granted == role {
+			return true
+		}
+	}
+	return false
+}
+
+// handleListApprovals godoc
+// @Summary List approvals
+// @Description List execution approval requests, newest first. Filter with ?status=pending|approved|rejected|executed|expired
+// @Tags Approvals
+// @Produce json
+// @Param status query string false "Status filter"
+// @Success 200 {array} models.Approval
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /approvals [get]
+func (s *Server) handleListApprovals(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewApprovalRepository(s.db)
+
+	approvals, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching approvals: %v", err)
+		apierror.Error(w, "Failed to fetch approvals", http.StatusInternalServerError)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	filtered := make([]*models.Approval, 0, len(approvals))
+	for _, a := range approvals {
+		if status == "" || a.Status == status {
+			filtered = append(filtered, a)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(filtered)
+}
+
+// handleGetApproval godoc
+// @Summary Get an approval
+// @Description Get an execution approval request by ID
+// @Tags Approvals
+// @Produce json
+// @Param id path int true "Approval ID"
+// @Success 200 {object} models.Approval
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /approvals/{id} [get]
+func (s *Server) handleGetApproval(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid approval ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewApprovalRepository(s.db)
+
+	approval, err := repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching approval: %v", err)
+		apierror.Error(w, "Approval not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(approval)
+}
+
+// handleApproveApproval godoc
+// @Summary Approve an execution
+// @Description Approve a pending execution request. The approver must be an admin or have the approver role, and cannot be the requester.
+// @Tags Approvals
+// @Produce json
+// @Param id path int true "Approval ID"
+// @Success 200 {object} models.Approval
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /approvals/{id}/approve [post]
+func (s *Server) handleApproveApproval(w http.ResponseWriter, r *http.Request) {
+	s.decideApproval(w, r, models.ApprovalStatusApproved)
+}
+
+// handleRejectApproval godoc
+// @Summary Reject an execution
+// @Description Reject a pending execution request. The approver must be an admin or have the approver role, and cannot be the requester.
+// @Tags Approvals
+// @Produce json
+// @Param id path int true "Approval ID"
+// @Success 200 {object} models.Approval
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /approvals/{id}/reject [post]
+func (s *Server) handleRejectApproval(w http.ResponseWriter, r *http.Request) {
+	s.decideApproval(w, r, models.ApprovalStatusRejected)
+}
+
+// decideApproval approves or rejects a pending approval on behalf of a second person
+func (s *Server) decideApproval(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid approval ID", http.StatusBadRequest)
+		return
+	}
+
+	action := "approved"
+	if status == models.ApprovalStatusRejected {
+		action = "rejected"
+	}
+
+	repo := repository.NewApprovalRepository(s.db)
+
+	approval, err := repo.GetByID(id)
+	if err != nil {
+		apierror.Error(w, "Approval not found", http.StatusNotFound)
+		return
+	}
+
+	if !s.canApprove(r) {
+		audit.GetLogger().LogApproval(r, id, action, approval.Server, approval.Summary, audit.OutcomeDenied)
+		apierror.Error(w, "Deciding approvals requires the approver role", http.StatusForbidden)
+		return
+	}
+	if callerIdentity(r) == approval.RequestedBy {
+		audit.GetLogger().LogApproval(r, id, action, approval.Server, approval.Summary, audit.OutcomeDenied)
+		apierror.Error(w, "Approvals must be decided by someone other than the requester", http.StatusForbidden)
+		return
+	}
+
+	decided, err := repo.Decide(id, status, callerIdentity(r))
+	if err != nil {
+		log.Printf("Error deciding approval: %v", err)
+		audit.GetLogger().LogApproval(r, id, action, approval.Server, approval.Summary, audit.OutcomeFailure)
+		apierror.Error(w, "Approval is no longer pending", http.StatusConflict)
+		return
+	}
+
+	audit.GetLogger().LogApproval(r, decided.ID, action, decided.Server, decided.Summary, audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decided)
+}
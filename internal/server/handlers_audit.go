@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// handleListKeystrokeLogs godoc
+// @Summary List terminal keystroke logs
+// @Description List the keystroke logs of terminal sessions, newest first, without their keystrokes. Logs are only kept when TERMINAL_KEYSTROKE_LOG is enabled. Requires admin access.
+// @Tags Audit
+// @Produce json
+// @Success 200 {array} models.KeystrokeLog
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /audit/keystrokes [get]
+func (s *Server) handleListKeystrokeLogs(w http.ResponseWriter, r *http.Request) {
+	logs, err := repository.NewKeystrokeLogRepository(s.db).GetAll()
+	if err != nil {
+		log.Printf("Error listing keystroke logs: %v", err)
+		apierror.Error(w, "Failed to list keystroke logs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logs)
+}
+
+// handleGetKeystrokeLog godoc
+// @Summary Get a terminal keystroke log
+// @Description Get a terminal session's keystroke log with its decrypted keystrokes. Input typed at password prompts is stored as redacted events. Requires admin access.
+// @Tags Audit
+// @Produce json
+// @Param id path int true "Keystroke log ID"
+// @Success 200 {object} models.KeystrokeLog
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /audit/keystrokes/{id} [get]
+func (s *Server) handleGetKeystrokeLog(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid keystroke log ID", http.StatusBadRequest)
+		return
+	}
+
+	keystrokeLog, err := repository.NewKeystrokeLogRepository(s.db).GetByID(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Error(w, "Keystroke log not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting keystroke log: %v", err)
+		apierror.Error(w, "Failed to get keystroke log", http.StatusInternalServerError)
+		return
+	}
+
+	// Reading what someone typed is itself audited
+	audit.GetLogger().LogTerminalSession(r, keystrokeLog.Server, "", audit.OutcomeSuccess, map[string]string{
+		"action":           "keystroke_view",
+		"session_id":       keystrokeLog.SessionID,
+		"keystroke_log_id": strconv.FormatInt(keystrokeLog.ID, 10),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keystrokeLog)
+}
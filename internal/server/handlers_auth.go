@@ -0,0 +1,410 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/middleware"
+)
+
+// LoginRequest holds the credentials for a session login
+// @Description Username and password for session login
+type LoginRequest struct {
+	Username string `json:"username" example:"admin"`
+	Password string `json:"password" example:"changeme"`
+}
+
+// SessionResponse describes a login session
+// @Description Session token and expiry returned by login and refresh
+type SessionResponse struct {
+	Token     string    `json:"token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Username  string    `json:"username" example:"admin"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UnlockRequest identifies the client IP and/or username to unlock
+// @Description Client IP and/or username to clear failed login attempts for
+type UnlockRequest struct {
+	IP       string `json:"ip,omitempty" example:"192.0.2.10"`
+	Username string `json:"username,omitempty" example:"admin"`
+}
+
+// UnlockResponse reports how many lockout entries were cleared
+// @Description Result of an unlock request
+type UnlockResponse struct {
+	Unlocked int `json:"unlocked" example:"1"`
+}
+
+// handleListLockouts godoc
+// @Summary List login lockouts
+// @Description List client IPs and usernames currently locked out after repeated failed login attempts
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} middleware.Lockout
+// @Security BasicAuth
+// @Router /auth/lockouts [get]
+func (s *Server) handleListLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts := s.lockout.List()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lockouts)
+}
+
+// handleUnlock godoc
+// @Summary Unlock a client IP or username
+// @Description Clear failed login attempts for a client IP and/or username, lifting any lockout
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body UnlockRequest true "IP and/or username to unlock"
+// @Success 200 {object} UnlockResponse
+// @Failure 400 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /auth/unlock [post]
+func (s *Server) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	var req UnlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.IP = strings.TrimSpace(req.IP)
+	req.Username = strings.TrimSpace(req.Username)
+	if req.IP == "" && req.Username == "" {
+		apierror.Error(w, "IP or username is required", http.StatusBadRequest)
+		return
+	}
+
+	unlocked := s.lockout.Unlock(req.IP, req.Username)
+	log.Printf("Login lockout cleared (ip=%q username=%q, %d entries)", req.IP, req.Username, unlocked)
+	audit.GetLogger().LogConfigChange(r, "auth_lockout", "unlock", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UnlockResponse{Unlocked: unlocked})
+}
+
+// handleLogin godoc
+// @Summary Log in
+// @Description Exchange the configured username and password for a signed session token. The token is also set as an HTTP-only cookie for browser clients and may be sent as a Bearer token. This endpoint does not require authentication.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param credentials body LoginRequest true "Login credentials"
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /auth/login [post]
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	authConfig := loadAuthConfig(s.config)
+	if !authConfig.Enabled || authConfig.Username == "" || authConfig.Password == "" {
+		apierror.Error(w, "Password login is not configured", http.StatusBadRequest)
+		return
+	}
+
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Record the attempted username as the audit actor
+	r = r.WithContext(audit.WithActor(r.Context(), req.Username))
+
+	ip := middleware.RemoteIP(r)
+	if retryAfter := s.lockout.RetryAfter(ip, req.Username); retryAfter > 0 {
+		audit.GetLogger().LogAuthAttempt(r, audit.OutcomeDenied, "lockout")
+		middleware.SetRetryAfter(w, retryAfter)
+		apierror.WithCode(w, apierror.CodeLoginLocked, "Too many failed login attempts. Try again later.", http.StatusTooManyRequests)
+		return
+	}
+
+	if !authConfig.CheckPassword(req.Username, req.Password) {
+		s.lockout.RecordFailure(ip, req.Username)
+		audit.GetLogger().LogAuthAttempt(r, audit.OutcomeFailure, "login")
+		apierror.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+
+	s.lockout.RecordSuccess(ip, req.Username)
+	audit.GetLogger().LogAuthAttempt(r, audit.OutcomeSuccess, "login")
+
+	s.startSession(w, r, req.Username)
+}
+
+// handleLogout godoc
+// @Summary Log out
+// @Description End the current session and clear the session cookie
+// @Tags Auth
+// @Success 204 "No Content"
+// @Security BasicAuth
+// @Router /auth/logout [post]
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if session := middleware.SessionFromContext(r.Context()); session != nil {
+		s.sessions.Revoke(session)
+		if s.sudoPasswords != nil {
+			s.sudoPasswords.Delete(session)
+		}
+	}
+
+	middleware.ClearSessionCookie(w, r, s.config.GetBasePath())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRefreshSession godoc
+// @Summary Refresh session
+// @Description Replace the current session token with a new one, extending the session
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /auth/refresh [post]
+func (s *Server) handleRefreshSession(w http.ResponseWriter, r *http.Request) {
+	session := middleware.SessionFromContext(r.Context())
+	if session == nil {
+		apierror.Error(w, "Request is not authenticated with a session", http.StatusBadRequest)
+		return
+	}
+
+	s.sessions.Revoke(session)
+	next := s.startSession(w, r, session.Username)
+
+	// The cached sudo password moves to the new session
+	if s.sudoPasswords != nil {
+		if next != nil {
+			s.sudoPasswords.Move(session, next)
+		} else {
+			s.sudoPasswords.Delete(session)
+		}
+	}
+}
+
+// handleGetSession godoc
+// @Summary Get current session
+// @Description Return the session that authenticated the request, or 404 if another authentication method was used
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} SessionResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /auth/session [get]
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	session := middleware.SessionFromContext(r.Context())
+	if session == nil {
+		apierror.Error(w, "No session", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SessionResponse{Username: session.Username, ExpiresAt: session.ExpiresAt})
+}
+
+// startSession issues a session token, sets the session cookie and writes the token response
+// It returns the new session, or nil when it could not be issued.
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, username string) *middleware.Session {
+	token, session, err := s.sessions.Issue(username)
+	if err != nil {
+		log.Printf("Error issuing session: %v", err)
+		apierror.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return nil
+	}
+
+	middleware.SetSessionCookie(w, r, s.config.GetBasePath(), token, session)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SessionResponse{Token: token, Username: session.Username, ExpiresAt: session.ExpiresAt})
+	return session
+}
+
+// SudoPasswordRequest holds the sudo password to cache for the session
+// @Description Sudo password reused by the session's local executions
+type SudoPasswordRequest struct {
+	SudoPassword string `json:"sudo_password"`
+}
+
+// SudoPasswordResponse reports whether the session has a cached sudo password
+// @Description Whether a sudo password is cached for the session, and until when
+type SudoPasswordResponse struct {
+	Cached    bool       `json:"cached" example:"true"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// sessionForSudoPassword returns the session of a request to the sudo password endpoints,
+// writing an error when caching is disabled or the request has no session
+func (s *Server) sessionForSudoPassword(w http.ResponseWriter, r *http.Request) *middleware.Session {
+	if s.sudoPasswords == nil {
+		apierror.Error(w, "Sudo password caching is disabled (SUDO_PASSWORD_TTL=0)", http.StatusBadRequest)
+		return nil
+	}
+	session := middleware.SessionFromContext(r.Context())
+	if session == nil {
+		apierror.Error(w, "Request is not authenticated with a session", http.StatusBadRequest)
+		return nil
+	}
+	return session
+}
+
+// handleGetSudoPassword godoc
+// @Summary Get the session's sudo password status
+// @Description Report whether a sudo password is cached for the current login session. The password itself is never returned.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} SudoPasswordResponse
+// @Failure 400 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /auth/sudo-password [get]
+func (s *Server) handleGetSudoPassword(w http.ResponseWriter, r *http.Request) {
+	session := s.sessionForSudoPassword(w, r)
+	if session == nil {
+		return
+	}
+
+	response := SudoPasswordResponse{}
+	if _, expiresAt, ok := s.sudoPasswords.Get(session); ok {
+		response.Cached, response.ExpiresAt = true, &expiresAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleSetSudoPassword godoc
+// @Summary Cache a sudo password for the session
+// @Description Keep a sudo password in memory, encrypted, for the current login session, so local executions without a sudo_password use it instead of needing it on every call. It expires after SUDO_PASSWORD_TTL, with the session or on logout.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body SudoPasswordRequest true "Sudo password"
+// @Success 200 {object} SudoPasswordResponse
+// @Failure 400 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /auth/sudo-password [post]
+func (s *Server) handleSetSudoPassword(w http.ResponseWriter, r *http.Request) {
+	session := s.sessionForSudoPassword(w, r)
+	if session == nil {
+		return
+	}
+
+	var req SudoPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.SudoPassword == "" {
+		apierror.InvalidField(w, "sudo_password", "sudo_password is required")
+		return
+	}
+
+	expiresAt, err := s.sudoPasswords.Set(session, req.SudoPassword)
+	if err != nil {
+		log.Printf("Error caching sudo password: %v", err)
+		apierror.Error(w, "Failed to cache sudo password", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SudoPasswordResponse{Cached: true, ExpiresAt: &expiresAt})
+}
+
+// handleDeleteSudoPassword godoc
+// @Summary Forget the session's sudo password
+// @Description Remove the sudo password cached for the current login session
+// @Tags Auth
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /auth/sudo-password [delete]
+func (s *Server) handleDeleteSudoPassword(w http.ResponseWriter, r *http.Request) {
+	session := s.sessionForSudoPassword(w, r)
+	if session == nil {
+		return
+	}
+
+	s.sudoPasswords.Delete(session)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sudoPassword returns the sudo password of a local execution: the one sent with it, else
+// the one cached for the request's login session
+func (s *Server) sudoPassword(r *http.Request, sent string) string {
+	if sent != "" || s.sudoPasswords == nil {
+		return sent
+	}
+	if session := middleware.SessionFromContext(r.Context()); session != nil {
+		if password, _, ok := s.sudoPasswords.Get(session); ok {
+			return password
+		}
+	}
+	return ""
+}
+
+// serveLoginPage renders the sign-in form shown to unauthenticated browsers
+// The page posts to the login endpoint and reloads once the session cookie is set
+func (s *Server) serveLoginPage(w http.ResponseWriter, r *http.Request) {
+	loginURL := s.config.GetBasePath() + "/api/auth/login"
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusUnauthorized)
+	fmt.Fprintf(w, loginPageHTML, loginURL)
+}
+
+// loginPageHTML is the sign-in form; %[1]q is the login endpoint URL
+const loginPageHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Web CLI - Sign in</title>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 40px; background: #f5f5f5; }
+        .container { max-width: 360px; margin: 60px auto; background: white; padding: 32px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
+        h1 { color: #333; font-size: 22px; margin-top: 0; }
+        label { display: block; color: #666; margin: 16px 0 4px; }
+        input { width: 100%%; box-sizing: border-box; padding: 8px; border: 1px solid #ccc; border-radius: 4px; font-size: 14px; }
+        button { width: 100%%; margin-top: 24px; padding: 10px; border: 0; border-radius: 4px; background: #1976d2; color: white; font-size: 15px; cursor: pointer; }
+        .error { color: #d32f2f; min-height: 20px; margin-top: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>Web CLI</h1>
+        <form id="login">
+            <label for="username">Username</label>
+            <input id="username" name="username" autocomplete="username" required autofocus>
+            <label for="password">Password</label>
+            <input id="password" name="password" type="password" autocomplete="current-password" required>
+            <button type="submit">Sign in</button>
+            <div class="error" id="error"></div>
+        </form>
+    </div>
+    <script>
+        document.getElementById('login').addEventListener('submit', async (event) => {
+            event.preventDefault();
+            const error = document.getElementById('error');
+            error.textContent = '';
+            const response = await fetch(%[1]q, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                credentials: 'same-origin',
+                body: JSON.stringify({
+                    username: document.getElementById('username').value,
+                    password: document.getElementById('password').value,
+                }),
+            });
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                error.textContent = (await response.text()).trim() || 'Sign in failed';
+            }
+        });
+    </script>
+</body>
+</html>
+`
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/database"
+)
+
+// backupPassphraseHeader carries the passphrase a backup is encrypted with
+const backupPassphraseHeader = "X-Backup-Passphrase"
+
+// maxRestoreSize is the largest backup accepted for restore
+const maxRestoreSize = 1 << 30
+
+// RestoreResult reports a completed restore
+type RestoreResult struct {
+	SchemaVersion int `json:"schema_version" example:"29"` // Schema version after applying migrations the backup lacked
+}
+
+// handleBackup godoc
+// @Summary Back up the database
+// @Description Download a consistent snapshot of the SQLite database, taken while the server keeps running. With the X-Backup-Passphrase header (at least 12 characters) the snapshot is encrypted with it. Secrets inside stay encrypted with this instance's key either way; keep the key with the backup.
+// @Tags System
+// @Produce octet-stream
+// @Param X-Backup-Passphrase header string false "Passphrase to encrypt the backup with"
+// @Success 200 {file} file "Database backup"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /system/backup [post]
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	passphrase := r.Header.Get(backupPassphraseHeader)
+	if passphrase != "" && len(passphrase) < database.MinBackupPassphraseLength {
+		apierror.Error(w, fmt.Sprintf("%s header must be at least %d characters", backupPassphraseHeader, database.MinBackupPassphraseLength), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("web-cli-backup-%s.db", time.Now().UTC().Format("20060102T150405Z"))
+	if passphrase != "" {
+		filename += ".enc"
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+
+	// The snapshot is taken before anything is written, so failing to take it still gets an error response
+	if err := s.db.WriteBackup(w, passphrase); err != nil {
+		log.Printf("Error backing up database: %v", err)
+		audit.GetLogger().LogConfigChange(r, "database", "backup", audit.OutcomeFailure)
+		w.Header().Del("Content-Disposition")
+		apierror.Error(w, "Failed to back up database", http.StatusInternalServerError)
+		return
+	}
+	audit.GetLogger().LogConfigChange(r, "database", "backup", audit.OutcomeSuccess)
+}
+
+// handleRestore godoc
+// @Summary Restore the database
+// @Description Replace the database with a backup taken by POST /system/backup or the backup command, while the server keeps running. Encrypted backups need the passphrase in the X-Backup-Passphrase header. Migrations the backup lacks are applied; if the backup cannot be opened the current database is kept. The backup must have been taken with the same encryption key.
+// @Tags System
+// @Accept octet-stream
+// @Produce json
+// @Param X-Backup-Passphrase header string false "Passphrase the backup was encrypted with"
+// @Param file body string true "Database backup"
+// @Success 200 {object} RestoreResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /system/restore [post]
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxRestoreSize)
+	staged, err := database.StageBackup(body, os.TempDir(), r.Header.Get(backupPassphraseHeader))
+	if err != nil {
+		audit.GetLogger().LogConfigChange(r, "database", "restore", audit.OutcomeFailure)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apierror.Error(w, "Backup too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		apierror.Error(w, fmt.Sprintf("Invalid backup: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer os.Remove(staged)
+
+	if err := s.db.RestoreLive(staged); err != nil {
+		log.Printf("Error restoring database: %v", err)
+		audit.GetLogger().LogConfigChange(r, "database", "restore", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to restore database", http.StatusInternalServerError)
+		return
+	}
+	audit.GetLogger().LogConfigChange(r, "database", "restore", audit.OutcomeSuccess)
+
+	// The restored Vault configuration may differ from the cached client's
+	s.closeVaultClient()
+
+	version, err := s.db.GetVersion()
+	if err != nil {
+		log.Printf("Error reading restored schema version: %v", err)
+	}
+	log.Printf("Database restored (schema version %d)", version)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RestoreResult{SchemaVersion: version})
+}
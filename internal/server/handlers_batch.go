@@ -0,0 +1,287 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
+	"github.com/pozgo/web-cli/internal/wol"
+)
+
+// maxBatchOperations bounds the operations of one batch request
+const maxBatchOperations = 500
+
+// handleServerBatch godoc
+// @Summary Create, update and delete servers in one batch
+// @Description Apply several server operations in one transaction. Every operation is validated first; if any is invalid or fails, none is applied. The response reports each operation's outcome.
+// @Tags Servers
+// @Accept json
+// @Produce json
+// @Param batch body models.ServerBatchRequest true "Operations to apply"
+// @Success 200 {object} models.BatchResponse
+// @Failure 400 {object} models.BatchResponse
+// @Failure 409 {object} models.BatchResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /servers/batch [post]
+func (s *Server) handleServerBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.ServerBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !checkBatchSize(w, len(req.Operations)) {
+		return
+	}
+
+	repo := repository.NewServerRepository(s.db)
+	access := s.groupAccess(r)
+	results := newBatchResults(len(req.Operations), func(i int) (string, int64) {
+		return req.Operations[i].Action, req.Operations[i].ID
+	})
+	invalid := false
+	for i, op := range req.Operations {
+		if err := checkServerOperation(r, access, repo, op); err != nil {
+			results[i].Status = models.BatchStatusFailed
+			results[i].Error = err.Error()
+			invalid = true
+		}
+	}
+	if invalid {
+		writeBatchResponse(w, http.StatusBadRequest, false, results)
+		return
+	}
+
+	servers, err := repo.ApplyBatch(req.Operations)
+	if err != nil {
+		writeBatchError(w, err, results)
+		return
+	}
+
+	for i, server := range servers {
+		results[i].Status = models.BatchStatusOK
+		if server != nil {
+			results[i].ID = server.ID
+			results[i].Item = server
+		}
+	}
+	writeBatchResponse(w, http.StatusOK, true, results)
+}
+
+// checkServerOperation validates one server operation and the caller's access to its groups
+func checkServerOperation(r *http.Request, access *groupAccess, repo *repository.ServerRepository, op models.ServerBatchOperation) error {
+	fields := op.Server
+	// The transport settings are checked as they will be after the operation
+	merged := &models.Server{}
+	switch op.Action {
+	case models.BatchActionCreate:
+		if fields.Name == "" && fields.IPAddress == "" {
+			return fmt.Errorf("at least one of name or ip_address must be provided")
+		}
+	case models.BatchActionUpdate, models.BatchActionDelete:
+		// Resources in groups the caller cannot view are reported as missing
+		existing, err := repo.GetByID(op.ID)
+		if err != nil || !access.canView(models.ResourceTypeServers, existing.Group) {
+			return fmt.Errorf("server not found")
+		}
+		if op.Action == models.BatchActionDelete {
+			return nil
+		}
+		merged = existing
+	default:
+		return fmt.Errorf("action must be create, update or delete")
+	}
+
+	if fields.Name != "" {
+		if err := validation.ValidateHostname(fields.Name); err != nil {
+			return fmt.Errorf("invalid hostname: %w", err)
+		}
+		merged.Name = fields.Name
+	}
+	if fields.IPAddress != "" {
+		if err := validation.ValidateIPOrHostname(fields.IPAddress); err != nil {
+			return fmt.Errorf("invalid IP address or hostname: %w", err)
+		}
+	}
+	if fields.Port != 0 {
+		if err := validation.ValidatePort(fields.Port); err != nil {
+			return fmt.Errorf("invalid port: %w", err)
+		}
+	}
+	if fields.Username != "" {
+		if err := validation.ValidateUsername(fields.Username); err != nil {
+			return fmt.Errorf("invalid username: %w", err)
+		}
+	}
+	if fields.SSMSecretAccessKey != nil && fields.SSMAccessKeyID == nil {
+		return fmt.Errorf("ssm_access_key_id is required with ssm_secret_access_key")
+	}
+	merged.ApplySSMUpdate(&fields)
+	if _, err := validateServerTransport(merged); err != nil {
+		return err
+	}
+	merged.ApplyHostKeyUpdate(&fields)
+	if _, err := validateServerHostKey(merged); err != nil {
+		return err
+	}
+	if fields.MACAddress != nil && *fields.MACAddress != "" {
+		if _, err := wol.ParseMAC(*fields.MACAddress); err != nil {
+			return err
+		}
+	}
+	if (op.Action == models.BatchActionCreate || fields.Group != "") && !access.canView(models.ResourceTypeServers, fields.Group) {
+		return errors.New(groupDenied(r, models.ResourceTypeServers, fields.Group, models.PermissionView))
+	}
+	return nil
+}
+
+// handleEnvVariableBatch godoc
+// @Summary Create, update and delete environment variables in one batch
+// @Description Apply several environment variable operations in one transaction. Every operation is validated first; if any is invalid or fails, none is applied. The response reports each operation's outcome, with values masked.
+// @Tags Environment Variables
+// @Accept json
+// @Produce json
+// @Param batch body models.EnvVariableBatchRequest true "Operations to apply"
+// @Success 200 {object} models.BatchResponse
+// @Failure 400 {object} models.BatchResponse
+// @Failure 409 {object} models.BatchResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /env-variables/batch [post]
+func (s *Server) handleEnvVariableBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.EnvVariableBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !checkBatchSize(w, len(req.Operations)) {
+		return
+	}
+
+	repo := repository.NewEnvVariableRepository(s.db)
+	access := s.groupAccess(r)
+	results := newBatchResults(len(req.Operations), func(i int) (string, int64) {
+		return req.Operations[i].Action, req.Operations[i].ID
+	})
+	invalid := false
+	for i, op := range req.Operations {
+		if err := checkEnvVariableOperation(r, access, repo, op); err != nil {
+			results[i].Status = models.BatchStatusFailed
+			results[i].Error = err.Error()
+			invalid = true
+		}
+	}
+	if invalid {
+		writeBatchResponse(w, http.StatusBadRequest, false, results)
+		return
+	}
+
+	envVars, err := repo.ApplyBatch(req.Operations)
+	if err != nil {
+		writeBatchError(w, err, results)
+		return
+	}
+
+	for i, envVar := range envVars {
+		results[i].Status = models.BatchStatusOK
+		if envVar != nil {
+			results[i].ID = envVar.ID
+			results[i].Item = envVar.ToResponse(false)
+		}
+	}
+	writeBatchResponse(w, http.StatusOK, true, results)
+}
+
+// checkEnvVariableOperation validates one environment variable operation and the caller's access to its groups
+func checkEnvVariableOperation(r *http.Request, access *groupAccess, repo *repository.EnvVariableRepository, op models.EnvVariableBatchOperation) error {
+	fields := op.EnvVariable
+	switch op.Action {
+	case models.BatchActionCreate:
+		if err := validation.ValidateEnvVarName(fields.Name); err != nil {
+			return fmt.Errorf("invalid name: %w", err)
+		}
+		if err := validation.ValidateEnvVarValue(fields.Value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+		if !access.canView(models.ResourceTypeEnvVariables, fields.Group) {
+			return errors.New(groupDenied(r, models.ResourceTypeEnvVariables, fields.Group, models.PermissionView))
+		}
+		return nil
+	case models.BatchActionUpdate, models.BatchActionDelete:
+		// Resources in groups the caller cannot view are reported as missing
+		existing, err := repo.GetByID(op.ID)
+		if err != nil || !access.canView(models.ResourceTypeEnvVariables, existing.Group) {
+			return fmt.Errorf("environment variable not found")
+		}
+		if op.Action == models.BatchActionDelete {
+			return nil
+		}
+	default:
+		return fmt.Errorf("action must be create, update or delete")
+	}
+
+	if fields.Name != "" {
+		if err := validation.ValidateEnvVarName(fields.Name); err != nil {
+			return fmt.Errorf("invalid name: %w", err)
+		}
+	}
+	if fields.Value != "" {
+		if err := validation.ValidateEnvVarValue(fields.Value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+	}
+	if fields.Group != "" && !access.canView(models.ResourceTypeEnvVariables, fields.Group) {
+		return errors.New(groupDenied(r, models.ResourceTypeEnvVariables, fields.Group, models.PermissionView))
+	}
+	return nil
+}
+
+// checkBatchSize rejects empty and oversized batches
+func checkBatchSize(w http.ResponseWriter, n int) bool {
+	if n == 0 {
+		apierror.Error(w, "At least one operation is required", http.StatusBadRequest)
+		return false
+	}
+	if n > maxBatchOperations {
+		apierror.Error(w, fmt.Sprintf("At most %d operations are allowed in one batch", maxBatchOperations), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// newBatchResults returns a not-applied result for each operation, which checks and the batch then fill in
+func newBatchResults(n int, op func(i int) (string, int64)) []models.BatchResult {
+	results := make([]models.BatchResult, n)
+	for i := range results {
+		action, id := op(i)
+		results[i] = models.BatchResult{Index: i, Action: action, ID: id, Status: models.BatchStatusNotApplied}
+	}
+	return results
+}
+
+// writeBatchError answers a batch that could not be applied
+// When one operation failed, it is marked in results and the results are sent with 409
+func writeBatchError(w http.ResponseWriter, err error, results []models.BatchResult) {
+	var batchErr *repository.BatchError
+	if errors.As(err, &batchErr) {
+		results[batchErr.Index].Status = models.BatchStatusFailed
+		results[batchErr.Index].Error = batchErr.Err.Error()
+		writeBatchResponse(w, http.StatusConflict, false, results)
+		return
+	}
+	log.Printf("Error applying batch: %v", err)
+	apierror.Error(w, "Failed to apply batch", http.StatusInternalServerError)
+}
+
+// writeBatchResponse encodes the outcome of a batch
+func writeBatchResponse(w http.ResponseWriter, status int, applied bool, results []models.BatchResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.BatchResponse{Applied: applied, Results: results})
+}
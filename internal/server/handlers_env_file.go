@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/dotenv"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// maxEnvFileSize is the largest .env import request accepted
+const maxEnvFileSize = 10 << 20
+
+// handleImportEnvVariables godoc
+// @Summary Import environment variables from a .env file
+// @Description Parse dotenv-format text and create its variables in one group. Variables whose name already exists are skipped. Every line is validated before anything is written; with dry_run the result only previews what would be created and skipped.
+// @Tags Environment Variables
+// @Accept json
+// @Produce json
+// @Param import body models.EnvVariableImport true ".env file contents and target group"
+// @Success 200 {object} models.EnvVariableImportResult "Dry run, or nothing to create"
+// @Success 201 {object} models.EnvVariableImportResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /env-variables/import [post]
+func (s *Server) handleImportEnvVariables(w http.ResponseWriter, r *http.Request) {
+	var req models.EnvVariableImport
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEnvFileSize)).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		apierror.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	group := req.Group
+	if group == "" {
+		group = "default"
+	}
+	access := s.groupAccess(r)
+	if !access.canView(models.ResourceTypeEnvVariables, group) {
+		denyGroupAccess(w, r, models.ResourceTypeEnvVariables, group, models.PermissionView)
+		return
+	}
+
+	entries, err := dotenv.Parse(req.Content)
+	if err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid .env file: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(entries) == 0 {
+		apierror.Error(w, "No variables found in .env file", http.StatusBadRequest)
+		return
+	}
+
+	// Validate the whole file first so a bad line never leaves a partial import
+	var problems []string
+	seen := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		if err := validation.ValidateEnvVarName(entry.Name); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: invalid name: %v", entry.Line, err))
+			continue
+		}
+		if err := validation.ValidateEnvVarValue(entry.Value); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: invalid value for %s: %v", entry.Line, entry.Name, err))
+		}
+		if line, ok := seen[entry.Name]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: %s is already set on line %d", entry.Line, entry.Name, line))
+		}
+		seen[entry.Name] = entry.Line
+	}
+	if len(problems) > 0 {
+		apierror.Error(w, fmt.Sprintf("Invalid .env file: %s", strings.Join(problems, "; ")), http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewEnvVariableRepository(s.db)
+	existing, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching environment variables: %v", err)
+		apierror.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
+		return
+	}
+	existingGroups := make(map[string]string, len(existing))
+	for _, envVar := range existing {
+		existingGroups[envVar.Name] = envVar.Group
+	}
+
+	result := &models.EnvVariableImportResult{DryRun: req.DryRun, Group: group, Items: make([]*models.EnvVariableImportItem, 0, len(entries))}
+	var creates []*models.EnvVariableCreate
+	for _, entry := range entries {
+		item := &models.EnvVariableImportItem{Name: entry.Name, Line: entry.Line, Action: "create"}
+		if existingGroup, ok := existingGroups[entry.Name]; ok {
+			item.Action = "skip"
+			// Only name the existing variable's group to users who may see it
+			if access.canView(models.ResourceTypeEnvVariables, existingGroup) {
+				item.ExistingGroup = existingGroup
+			}
+			result.Skipped++
+		} else {
+			creates = append(creates, &models.EnvVariableCreate{Name: entry.Name, Value: entry.Value, Group: group})
+			result.Created++
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	status := http.StatusOK
+	if !req.DryRun && len(creates) > 0 {
+		if _, err := repo.CreateMany(creates); err != nil {
+			log.Printf("Error importing environment variables: %v", err)
+			audit.GetLogger().LogConfigChange(r, "env_variable", "import", audit.OutcomeFailure)
+			apierror.Error(w, "Failed to import environment variables", http.StatusInternalServerError)
+			return
+		}
+		audit.GetLogger().LogConfigChange(r, "env_variable", "import", audit.OutcomeSuccess)
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleExportEnvVariables godoc
+// @Summary Export environment variables as a .env file
+// @Description Render environment variables, including ones stored in Vault, in dotenv format with decrypted values, sorted by group and name. Requires admin access; every export is audited. With masked=true values are replaced by a mask so only the names are shared.
+// @Tags Environment Variables
+// @Produce plain
+// @Param group query string false "Only export variables in this group"
+// @Param masked query bool false "Replace values with a mask"
+// @Success 200 {file} file ".env file"
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /env-variables/export [get]
+func (s *Server) handleExportEnvVariables(w http.ResponseWriter, r *http.Request) {
+	group := r.URL.Query().Get("group")
+	masked := r.URL.Query().Get("masked") == "true"
+
+	repo := repository.NewEnvVariableRepository(s.db)
+	var envVars []*models.EnvVariable
+	var err error
+	if group != "" {
+		envVars, err = repo.GetByGroup(group)
+	} else {
+		envVars, err = repo.GetAll()
+	}
+	if err != nil {
+		log.Printf("Error exporting environment variables: %v", err)
+		audit.GetLogger().LogConfigChange(r, "env_variable", "export", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to export environment variables", http.StatusInternalServerError)
+		return
+	}
+
+	allEnvVars := s.mergeEnvVariablesWithVault(r.Context(), envVars)
+	if group != "" {
+		allEnvVars = slices.DeleteFunc(allEnvVars, func(ev *models.EnvVariable) bool {
+			return ev.Group != group && !(ev.Group == "" && group == "default")
+		})
+	}
+	slices.SortFunc(allEnvVars, func(a, b *models.EnvVariable) int {
+		if c := strings.Compare(a.Group, b.Group); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	entries := make([]dotenv.Entry, len(allEnvVars))
+	for i, envVar := range allEnvVars {
+		entries[i] = dotenv.Entry{Name: envVar.Name, Value: envVar.ToResponse(!masked).Value}
+	}
+	audit.GetLogger().LogConfigChange(r, "env_variable", "export", audit.OutcomeSuccess)
+
+	filename := "web-cli.env"
+	if group != "" {
+		filename = fmt.Sprintf("web-cli-%s.env", group)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(dotenv.Format(entries)))
+}
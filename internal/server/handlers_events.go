@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+)
+
+// activityBuffer is how many events a subscriber may fall behind by before events are dropped
+// for it, so a slow dashboard never holds up executions
+const activityBuffer = 64
+
+// activityKeepAlive is how often an idle event stream sends a comment, so proxies keep it open
+const activityKeepAlive = 30 * time.Second
+
+// activityHub passes live activity events on to the subscribers of GET /events
+type activityHub struct {
+	mu          sync.Mutex
+	subscribers map[chan models.ActivityEvent]struct{}
+}
+
+// subscribe returns a channel receiving every event published from now on, and the function
+// ending the subscription
+func (h *activityHub) subscribe() (<-chan models.ActivityEvent, func()) {
+	ch := make(chan models.ActivityEvent, activityBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[chan models.ActivityEvent]struct{})
+	}
+	h.subscribers[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers, ch)
+	}
+}
+
+// publish sends an event to every subscriber with room for it
+func (h *activityHub) publish(event models.ActivityEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// executionFinished publishes the outcome of a command or script execution
+func (s *Server) executionFinished(exec *notify.Execution) {
+	exitCode := exec.ExitCode
+	actor := exec.TriggeredBy
+	if actor == "" {
+		actor = "anonymous"
+	}
+	s.activity.publish(models.ActivityEvent{
+		Type:       models.ActivityExecutionFinished,
+		Actor:      actor,
+		Kind:       exec.Kind,
+		Command:    exec.Command,
+		Script:     exec.ScriptName,
+		Server:     exec.Server,
+		User:       exec.User,
+		ExitCode:   &exitCode,
+		Severity:   exec.Severity,
+		DurationMs: exec.DurationMs,
+		RequestID:  exec.RequestID,
+	})
+}
+
+// observeAudit publishes the terminal sessions opened and closed and the configuration
+// changes recorded by the audit logger
+func (s *Server) observeAudit(event *audit.AuditEvent) {
+	activity := models.ActivityEvent{Time: event.Timestamp, Actor: event.Actor}
+	switch event.EventType {
+	case audit.EventTypeTerminalSession:
+		switch event.Metadata["action"] {
+		case "start":
+			activity.Type = models.ActivityTerminalOpened
+		case "end":
+			activity.Type = models.ActivityTerminalClosed
+		default:
+			return
+		}
+		activity.Server, activity.User, activity.SessionID = event.Target, event.User, event.Metadata["session_id"]
+	case audit.EventTypeConfigChange:
+		activity.Type = models.ActivityConfigChanged
+		activity.Resource, activity.Action, activity.Outcome = event.Target, event.Metadata["action"], string(event.Outcome)
+	default:
+		return
+	}
+	s.activity.publish(activity)
+}
+
+// handleActivityEvents godoc
+// @Summary Stream live activity
+// @Description Stream server-sent events as executions start and finish, terminal sessions open and close and configuration changes, so dashboards update without polling. Each event is a data line holding an ActivityEvent; idle streams get a comment every 30 seconds. A client that falls behind by more than 64 events misses the excess. Requires admin access.
+// @Tags System
+// @Produce text/event-stream
+// @Success 200 {object} models.ActivityEvent
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /events [get]
+func (s *Server) handleActivityEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierror.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := s.activity.subscribe()
+	defer unsubscribe()
+
+	// The stream outlives the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(activityKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
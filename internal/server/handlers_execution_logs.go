@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/outputlog"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// newOutputLogs creates the store of full outputs, nil when outputs are never truncated
+func newOutputLogs(cfg *config.Config) *outputlog.Store {
+	return outputlog.New(cfg.GetExecutionLogDir(), cfg.GetOutputMaxSize())
+}
+
+// recordHistory stores an execution in the command history with its output cut to the size limit
+// The full output of a truncated entry is saved as a log for GET /history/{id}/log, and the
+// caller and saved command of the request are recorded for GET /recent.
+func (s *Server) recordHistory(r *http.Request, entry *models.CommandHistoryCreate) (*models.CommandHistory, error) {
+	entry.Principal = callerIdentity(r)
+	if id, ok := savedCommandFromContext(r.Context()); ok {
+		entry.SavedCommandID = &id
+	}
+
+	full := entry.Output
+	entry.OutputSize = int64(len(full))
+	entry.Output, entry.OutputTruncated = s.outputLogs.Truncate(full)
+
+	history, err := repository.NewCommandHistoryRepository(s.db).Create(entry)
+	if err != nil {
+		return nil, err
+	}
+	s.suggestions.invalidate(entry.Principal)
+	if history.OutputTruncated {
+		if err := s.outputLogs.Save(history.ID, full); err != nil {
+			log.Printf("Warning: failed to save execution log of history %d: %v", history.ID, err)
+		}
+	}
+	return history, nil
+}
+
+// handleGetHistoryLog godoc
+// @Summary Download the full output of a command history entry
+// @Description Download the full output of an execution as a gzip-compressed text file, including outputs cut to OUTPUT_MAX_SIZE in history and API responses
+// @Tags Command History
+// @Produce application/gzip
+// @Param id path int true "Command History ID"
+// @Param strip_ansi query bool false "Remove terminal color and cursor codes from the output"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /history/{id}/log [get]
+func (s *Server) handleGetHistoryLog(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid history ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := repository.NewCommandHistoryRepository(s.db).GetByID(id)
+	if err != nil {
+		apierror.Error(w, "Command history not found", http.StatusNotFound)
+		return
+	}
+
+	// Entries kept whole are served from the history itself
+	var compressed []byte
+	if history.OutputTruncated {
+		compressed, err = s.outputLogs.Open(id)
+		if errors.Is(err, outputlog.ErrNotFound) {
+			apierror.Error(w, "Full output of this entry is no longer available", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error reading execution log: %v", err)
+			apierror.Error(w, "Failed to read execution log", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		compressed = outputlog.Compress(history.Output)
+	}
+	if stripANSIRequested(r) {
+		full, err := outputlog.Decompress(compressed)
+		if err != nil {
+			log.Printf("Error reading execution log: %v", err)
+			apierror.Error(w, "Failed to read execution log", http.StatusInternalServerError)
+			return
+		}
+		compressed = outputlog.Compress(executor.StripANSI(full))
+	}
+
+	name := "history-" + strconv.FormatInt(id, 10) + outputlog.Ext
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	http.ServeContent(w, r, name, history.ExecutedAt, bytes.NewReader(compressed))
+}
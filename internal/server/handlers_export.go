@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/bundle"
+)
+
+// exportPassphraseHeader carries the passphrase an export file is encrypted with
+// A header keeps it out of URLs, which end up in access logs
+const exportPassphraseHeader = "X-Export-Passphrase"
+
+// maxImportSize is the largest export file accepted for import
+const maxImportSize = 256 << 20
+
+// handleExport godoc
+// @Summary Export configuration
+// @Description Export all servers, SSH keys, environment variables, bash scripts, script presets and saved commands as one file encrypted with the passphrase in the X-Export-Passphrase header (at least 12 characters). Secrets are included, decrypted from this instance and encrypted with the passphrase.
+// @Tags System
+// @Produce json
+// @Param X-Export-Passphrase header string true "Passphrase to encrypt the export with"
+// @Success 200 {file} file "Encrypted export file"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /export [get]
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	passphrase := r.Header.Get(exportPassphraseHeader)
+	if len(passphrase) < bundle.MinPassphraseLength {
+		apierror.Error(w, fmt.Sprintf("%s header must be at least %d characters", exportPassphraseHeader, bundle.MinPassphraseLength), http.StatusBadRequest)
+		return
+	}
+
+	b, err := bundle.Export(s.db)
+	if err != nil {
+		log.Printf("Error exporting configuration: %v", err)
+		audit.GetLogger().LogConfigChange(r, "configuration", "export", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to export configuration", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := bundle.Seal(b, passphrase)
+	if err != nil {
+		log.Printf("Error encrypting export: %v", err)
+		audit.GetLogger().LogConfigChange(r, "configuration", "export", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to export configuration", http.StatusInternalServerError)
+		return
+	}
+	audit.GetLogger().LogConfigChange(r, "configuration", "export", audit.OutcomeSuccess)
+
+	filename := fmt.Sprintf("web-cli-export-%s.json", b.ExportedAt.Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
+
+// handleImport godoc
+// @Summary Import configuration
+// @Description Import an export file, decrypted with the passphrase in the X-Export-Passphrase header. Items are matched by name (servers without a name by IP address); the strategy decides what happens when one already exists: skip keeps it, overwrite replaces its fields, rename imports the item under a new name. Scripts managed by Git sync are never overwritten.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param X-Export-Passphrase header string true "Passphrase the export was encrypted with"
+// @Param strategy query string false "Conflict strategy: skip (default), overwrite or rename"
+// @Param file body string true "Export file"
+// @Success 200 {object} bundle.Result
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /import [post]
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	strategy := r.URL.Query().Get("strategy")
+	if strategy == "" {
+		strategy = bundle.StrategySkip
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		apierror.Error(w, "Export file too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	b, err := bundle.Open(data, r.Header.Get(exportPassphraseHeader))
+	if err != nil {
+		audit.GetLogger().LogConfigChange(r, "configuration", "import", audit.OutcomeFailure)
+		apierror.Error(w, fmt.Sprintf("Invalid export file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	result, err := bundle.Import(s.db, b, strategy)
+	if err != nil {
+		audit.GetLogger().LogConfigChange(r, "configuration", "import", audit.OutcomeFailure)
+		// Nothing is written when the strategy or bundle is invalid
+		if result == nil {
+			apierror.Error(w, fmt.Sprintf("Invalid import: %v", err), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error importing configuration: %v", err)
+		apierror.Error(w, "Failed to import configuration: some items may have been imported", http.StatusInternalServerError)
+		return
+	}
+	audit.GetLogger().LogConfigChange(r, "configuration", "import", audit.OutcomeSuccess)
+	log.Printf("Imported configuration exported at %s (strategy %s) in %v", b.ExportedAt.Format(time.RFC3339), strategy, time.Since(start).Round(time.Millisecond))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
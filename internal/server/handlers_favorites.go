@@ -0,0 +1,239 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// itemName returns the name of a script, saved command or server, and false when it does not
+// exist or is in a group the caller may not view
+func (s *Server) itemName(access *groupAccess, resourceType string, id int64) (string, bool) {
+	switch resourceType {
+	case models.ResourceTypeBashScripts:
+		script, err := repository.NewBashScriptRepository(s.db).GetByID(id)
+		if err != nil || !access.canView(models.ResourceTypeBashScripts, script.Group) {
+			return "", false
+		}
+		return script.Name, true
+	case models.ResourceTypeSavedCommands:
+		cmd, err := repository.NewSavedCommandRepository(s.db).GetByID(id)
+		if err != nil {
+			return "", false
+		}
+		return cmd.Name, true
+	case models.ResourceTypeServers:
+		server, err := repository.NewServerRepository(s.db).GetByID(id)
+		if err != nil || !access.canView(models.ResourceTypeServers, server.Group) {
+			return "", false
+		}
+		if server.Name == "" {
+			return server.IPAddress, true
+		}
+		return server.Name, true
+	}
+	return "", false
+}
+
+// favoriteResourceType reads the type query parameter filtering favorites and recent items
+func favoriteResourceType(w http.ResponseWriter, r *http.Request) (string, bool) {
+	resourceType := r.URL.Query().Get("type")
+	if resourceType != "" && !slices.Contains(models.FavoriteResourceTypes, resourceType) {
+		apierror.InvalidField(w, "type", "type must be one of: "+strings.Join(models.FavoriteResourceTypes, ", "))
+		return "", false
+	}
+	return resourceType, true
+}
+
+// favoriteItem reads the type and ID path variables of a favorite
+func favoriteItem(w http.ResponseWriter, r *http.Request) (string, int64, bool) {
+	vars := mux.Vars(r)
+	resourceType := vars["type"]
+	if !slices.Contains(models.FavoriteResourceTypes, resourceType) {
+		apierror.InvalidField(w, "type", "type must be one of: "+strings.Join(models.FavoriteResourceTypes, ", "))
+		return "", 0, false
+	}
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return "", 0, false
+	}
+	return resourceType, id, true
+}
+
+// handleListFavorites godoc
+// @Summary List the caller's favorites
+// @Description List the scripts, saved commands and servers the caller marked as favorites, newest first. Items that were deleted or are in groups the caller may no longer view are left out.
+// @Tags Favorites
+// @Produce json
+// @Param type query string false "Only favorites of this type" Enums(bash_scripts, saved_commands, servers)
+// @Success 200 {array} models.Favorite
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /favorites [get]
+func (s *Server) handleListFavorites(w http.ResponseWriter, r *http.Request) {
+	resourceType, ok := favoriteResourceType(w, r)
+	if !ok {
+		return
+	}
+
+	favorites, err := repository.NewFavoriteRepository(s.db).GetByPrincipal(callerIdentity(r))
+	if err != nil {
+		log.Printf("Error fetching favorites: %v", err)
+		apierror.Error(w, "Failed to fetch favorites", http.StatusInternalServerError)
+		return
+	}
+
+	access := s.groupAccess(r)
+	response := make([]*models.Favorite, 0, len(favorites))
+	for _, favorite := range favorites {
+		if resourceType != "" && favorite.ResourceType != resourceType {
+			continue
+		}
+		name, ok := s.itemName(access, favorite.ResourceType, favorite.ResourceID)
+		if !ok {
+			continue
+		}
+		favorite.Name = name
+		response = append(response, favorite)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleAddFavorite godoc
+// @Summary Add a favorite
+// @Description Mark a script, saved command or server as a favorite of the caller. Adding an existing favorite returns it unchanged.
+// @Tags Favorites
+// @Produce json
+// @Param type path string true "Item type" Enums(bash_scripts, saved_commands, servers)
+// @Param id path int true "Item ID"
+// @Success 200 {object} models.Favorite
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /favorites/{type}/{id} [put]
+func (s *Server) handleAddFavorite(w http.ResponseWriter, r *http.Request) {
+	resourceType, id, ok := favoriteItem(w, r)
+	if !ok {
+		return
+	}
+
+	name, ok := s.itemName(s.groupAccess(r), resourceType, id)
+	if !ok {
+		apierror.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+
+	favorite, err := repository.NewFavoriteRepository(s.db).Add(callerIdentity(r), resourceType, id)
+	if err != nil {
+		log.Printf("Error adding favorite: %v", err)
+		apierror.Error(w, "Failed to add favorite", http.StatusInternalServerError)
+		return
+	}
+	favorite.Name = name
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(favorite)
+}
+
+// handleDeleteFavorite godoc
+// @Summary Remove a favorite
+// @Description Remove a script, saved command or server from the caller's favorites
+// @Tags Favorites
+// @Param type path string true "Item type" Enums(bash_scripts, saved_commands, servers)
+// @Param id path int true "Item ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /favorites/{type}/{id} [delete]
+func (s *Server) handleDeleteFavorite(w http.ResponseWriter, r *http.Request) {
+	resourceType, id, ok := favoriteItem(w, r)
+	if !ok {
+		return
+	}
+
+	if err := repository.NewFavoriteRepository(s.db).Delete(callerIdentity(r), resourceType, id); err != nil {
+		log.Printf("Error deleting favorite: %v", err)
+		apierror.Error(w, "Favorite not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListRecent godoc
+// @Summary List the caller's recent items
+// @Description List the scripts, saved commands and servers the caller ran most recently, derived from command history, with the number of executions and the outcome of the last one. A saved command run on a server lists both. Items that were deleted or are in groups the caller may no longer view are left out, as are executions recorded before recent items were tracked.
+// @Tags Favorites
+// @Produce json
+// @Param type query string false "Only items of this type" Enums(bash_scripts, saved_commands, servers)
+// @Param limit query int false "Maximum number of items to return" default(10)
+// @Success 200 {array} models.RecentItem
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /recent [get]
+func (s *Server) handleListRecent(w http.ResponseWriter, r *http.Request) {
+	resourceType, ok := favoriteResourceType(w, r)
+	if !ok {
+		return
+	}
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = min(parsedLimit, 100)
+		}
+	}
+
+	principal := callerIdentity(r)
+	items, err := repository.NewCommandHistoryRepository(s.db).GetRecentItems(principal, 0)
+	if err != nil {
+		log.Printf("Error fetching recent items: %v", err)
+		apierror.Error(w, "Failed to fetch recent items", http.StatusInternalServerError)
+		return
+	}
+	favorites, err := repository.NewFavoriteRepository(s.db).GetByPrincipal(principal)
+	if err != nil {
+		log.Printf("Error fetching favorites: %v", err)
+		apierror.Error(w, "Failed to fetch favorites", http.StatusInternalServerError)
+		return
+	}
+	favorite := make(map[string]bool, len(favorites))
+	for _, f := range favorites {
+		favorite[f.ResourceType+":"+strconv.FormatInt(f.ResourceID, 10)] = true
+	}
+
+	access := s.groupAccess(r)
+	response := make([]*models.RecentItem, 0, limit)
+	for _, item := range items {
+		if len(response) == limit {
+			break
+		}
+		if resourceType != "" && item.ResourceType != resourceType {
+			continue
+		}
+		name, ok := s.itemName(access, item.ResourceType, item.ResourceID)
+		if !ok {
+			continue
+		}
+		item.Name = name
+		item.Favorite = favorite[item.ResourceType+":"+strconv.FormatInt(item.ResourceID, 10)]
+		response = append(response, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
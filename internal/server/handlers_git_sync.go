@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/gitsync"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// GitSyncStatus reports whether Git sync is configured and how its last sync went
+type GitSyncStatus struct {
+	Enabled bool `json:"enabled"`
+	*gitsync.Status
+}
+
+// rejectReadOnlyScript writes 409 and returns true if the script is managed by Git sync
+func rejectReadOnlyScript(w http.ResponseWriter, script *models.BashScript) bool {
+	if !script.ReadOnly {
+		return false
+	}
+	apierror.Error(w, "Bash script is managed by Git sync and is read-only: change it in the repository", http.StatusConflict)
+	return true
+}
+
+// handleGetGitSync godoc
+// @Summary Get Git sync status
+// @Description Report the Git sync configuration and the outcome of the last sync of bash scripts
+// @Tags Bash Scripts
+// @Produce json
+// @Success 200 {object} GitSyncStatus
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /git-sync [get]
+func (s *Server) handleGetGitSync(w http.ResponseWriter, r *http.Request) {
+	result := GitSyncStatus{}
+	if s.gitSync != nil {
+		status := s.gitSync.Status()
+		result = GitSyncStatus{Enabled: true, Status: &status}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleGitSync godoc
+// @Summary Sync scripts from Git now
+// @Description Pull the Git repository and update the synced bash scripts without waiting for the next interval
+// @Tags Bash Scripts
+// @Produce json
+// @Success 200 {object} GitSyncStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 502 {object} GitSyncStatus
+// @Security BasicAuth
+// @Router /git-sync [post]
+func (s *Server) handleGitSync(w http.ResponseWriter, r *http.Request) {
+	if s.gitSync == nil {
+		apierror.Error(w, "Git sync is not configured: set GIT_SYNC_URL", http.StatusBadRequest)
+		return
+	}
+
+	status, err := s.gitSync.Sync(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		audit.GetLogger().LogConfigChange(r, "git_sync", "sync", audit.OutcomeFailure)
+		w.WriteHeader(http.StatusBadGateway)
+	} else {
+		audit.GetLogger().LogConfigChange(r, "git_sync", "sync", audit.OutcomeSuccess)
+	}
+	json.NewEncoder(w).Encode(GitSyncStatus{Enabled: true, Status: &status})
+}
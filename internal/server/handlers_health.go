@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// Health status values reported for the server and its components
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusError    = "error"
+	HealthStatusDisabled = "disabled"
+)
+
+// minFreeDiskBytes is the free space below which the database volume is reported as degraded
+const minFreeDiskBytes = 100 * 1024 * 1024
+
+// healthCheckTimeout bounds the time spent on each component check
+const healthCheckTimeout = 3 * time.Second
+
+// HealthResponse represents the health check response
+// @Description Health check response with per-component status
+type HealthResponse struct {
+	Status     string                     `json:"status" example:"ok"`
+	Version    string                     `json:"version" example:"1.1.0"`
+	Timestamp  time.Time                  `json:"timestamp"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// ComponentHealth represents the health of a single component
+// @Description Health status of a single server component
+type ComponentHealth struct {
+	Status  string `json:"status" example:"ok"`
+	Message string `json:"message,omitempty" example:"connected"`
+}
+
+// handleHealth godoc
+// @Summary Health check
+// @Description Report the health of the server and its components (database, encryption key, Vault, disk space, last database maintenance). Returns 503 when a required component is failing. This endpoint does not require authentication.
+// @Tags System
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /health [get]
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	components := map[string]ComponentHealth{
+		"database":       s.checkDatabaseHealth(ctx),
+		"encryption":     checkEncryptionHealth(),
+		"vault":          s.checkVaultHealth(ctx),
+		"disk":           s.checkDiskHealth(),
+		"db_maintenance": s.checkDBMaintenanceHealth(),
+	}
+
+	writeHealthResponse(w, components)
+}
+
+// writeHealthResponse encodes the component results, using 503 when the server is unhealthy
+func writeHealthResponse(w http.ResponseWriter, components map[string]ComponentHealth) {
+	response := HealthResponse{
+		Status:     overallHealthStatus(components),
+		Version:    Version,
+		Timestamp:  time.Now().UTC(),
+		Components: components,
+	}
+
+	statusCode := http.StatusOK
+	if response.Status == HealthStatusError {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// overallHealthStatus derives the server status from its components
+// Any failing component makes the server unhealthy; degraded components only downgrade it
+func overallHealthStatus(components map[string]ComponentHealth) string {
+	status := HealthStatusOK
+	for _, c := range components {
+		switch c.Status {
+		case HealthStatusError:
+			return HealthStatusError
+		case HealthStatusDegraded:
+			status = HealthStatusDegraded
+		}
+	}
+	return status
+}
+
+// checkDatabaseHealth verifies the database connection responds
+func (s *Server) checkDatabaseHealth(ctx context.Context) ComponentHealth {
+	if s.db == nil {
+		return ComponentHealth{Status: HealthStatusError, Message: "database not initialized"}
+	}
+
+	if err := s.db.Ping(ctx); err != nil {
+		log.Printf("Health check: database ping failed: %v", err)
+		return ComponentHealth{Status: HealthStatusError, Message: "database unreachable"}
+	}
+
+	return ComponentHealth{Status: HealthStatusOK, Message: "connected"}
+}
+
+// checkEncryptionHealth verifies the encryption key is loaded
+func checkEncryptionHealth() ComponentHealth {
+	if !database.EncryptionReady() {
+		return ComponentHealth{Status: HealthStatusError, Message: "encryption key not initialized"}
+	}
+	return ComponentHealth{Status: HealthStatusOK, Message: "key loaded"}
+}
+
+// checkVaultHealth tests the Vault connection when the integration is enabled
+// Vault is optional, so failures are reported as degraded rather than unhealthy
+func (s *Server) checkVaultHealth(ctx context.Context) ComponentHealth {
+	if s.db == nil {
+		return ComponentHealth{Status: HealthStatusDisabled}
+	}
+
+	repo := repository.NewVaultConfigRepository(s.db)
+	cfg, err := repo.Get()
+	if err != nil {
+		log.Printf("Health check: failed to load vault config: %v", err)
+		return ComponentHealth{Status: HealthStatusDegraded, Message: "failed to load vault configuration"}
+	}
+
+	if cfg == nil || !cfg.Enabled {
+		return ComponentHealth{Status: HealthStatusDisabled}
+	}
+
+	client, err := s.vaultClient(cfg)
+	if err != nil {
+		return ComponentHealth{Status: HealthStatusDegraded, Message: sanitizeVaultError(err)}
+	}
+
+	if err := client.TestConnection(ctx); err != nil {
+		return ComponentHealth{Status: HealthStatusDegraded, Message: sanitizeVaultError(err)}
+	}
+
+	return ComponentHealth{Status: HealthStatusOK, Message: "connected"}
+}
+
+// checkDiskHealth reports free space on the volume holding the database
+func (s *Server) checkDiskHealth() ComponentHealth {
+	if s.config == nil || s.config.DatabasePath == "" {
+		return ComponentHealth{Status: HealthStatusDisabled}
+	}
+
+	free, err := diskFreeBytes(filepath.Dir(s.config.DatabasePath))
+	if err != nil {
+		log.Printf("Health check: failed to stat database volume: %v", err)
+		return ComponentHealth{Status: HealthStatusDegraded, Message: "unable to determine free disk space"}
+	}
+
+	message := fmt.Sprintf("%d MB free", free/(1024*1024))
+	if free < minFreeDiskBytes {
+		return ComponentHealth{Status: HealthStatusDegraded, Message: "low disk space: " + message}
+	}
+
+	return ComponentHealth{Status: HealthStatusOK, Message: message}
+}
+
+// handleLiveness godoc
+// @Summary Liveness probe
+// @Description Report that the server process is running. Served at /healthz outside the /api prefix and never requires authentication or HTTPS.
+// @Tags System
+// @Produce json
+// @Success 200 {object} ComponentHealth
+// @Router /healthz [get]
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ComponentHealth{Status: HealthStatusOK})
+}
+
+// handleReadiness godoc
+// @Summary Readiness probe
+// @Description Report whether the server can serve requests (database reachable and encryption key loaded). Served at /readyz outside the /api prefix and never requires authentication or HTTPS.
+// @Tags System
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /readyz [get]
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	components := map[string]ComponentHealth{
+		"database":   s.checkDatabaseHealth(ctx),
+		"encryption": checkEncryptionHealth(),
+	}
+
+	writeHealthResponse(w, components)
+}
+
+// healthRouter returns a router serving only the health probe endpoints
+// Used for the optional plain-HTTP health listener
+func (s *Server) healthRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/healthz", s.handleLiveness).Methods("GET")
+	router.HandleFunc("/readyz", s.handleReadiness).Methods("GET")
+	router.HandleFunc("/api/health", s.handleHealth).Methods("GET")
+	return router
+}
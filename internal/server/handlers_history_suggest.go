@@ -0,0 +1,164 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// commandSuggestionTTL is how long the ranked commands of a caller are reused
+// The command box asks for suggestions as the user types, and ranking decrypts the caller's
+// history; a new execution by the caller drops its entry at once.
+const commandSuggestionTTL = time.Minute
+
+// commandSuggestionScan is how many of a caller's latest commands are ranked
+const commandSuggestionScan = 1000
+
+// commandSuggestionCache keeps the ranked previous commands of each caller
+type commandSuggestionCache struct {
+	mu      sync.Mutex
+	entries map[string]commandSuggestionEntry // Principal -> ranked commands
+	// generation counts invalidations per principal, so commands ranked while an
+	// execution was recorded are not cached
+	generation map[string]uint64
+}
+
+type commandSuggestionEntry struct {
+	suggestions []models.CommandSuggestion
+	expires     time.Time
+}
+
+// get returns the ranked commands of principal and the generation new ones must be stored with
+func (c *commandSuggestionCache) get(principal string) ([]models.CommandSuggestion, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[principal]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, c.generation[principal], false
+	}
+	return entry.suggestions, c.generation[principal], true
+}
+
+// put caches the ranked commands of principal unless it was invalidated since generation was read
+func (c *commandSuggestionCache) put(principal string, generation uint64, suggestions []models.CommandSuggestion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation[principal] != generation {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]commandSuggestionEntry)
+	}
+	now := time.Now()
+	for p, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, p)
+		}
+	}
+	c.entries[principal] = commandSuggestionEntry{suggestions: suggestions, expires: now.Add(commandSuggestionTTL)}
+}
+
+// invalidate drops the ranked commands of principal
+func (c *commandSuggestionCache) invalidate(principal string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, principal)
+	if c.generation == nil {
+		c.generation = make(map[string]uint64)
+	}
+	c.generation[principal]++
+}
+
+// rankCommands counts the distinct commands of history (newest first), most run first and,
+// among equals, most recently run first
+func rankCommands(history []*models.CommandHistory) []models.CommandSuggestion {
+	index := make(map[string]int)
+	var suggestions []models.CommandSuggestion
+	for _, entry := range history {
+		command := strings.TrimSpace(entry.Command)
+		if command == "" {
+			continue
+		}
+		i, ok := index[command]
+		if !ok {
+			i = len(suggestions)
+			index[command] = i
+			suggestions = append(suggestions, models.CommandSuggestion{Command: command, LastUsedAt: entry.ExecutedAt})
+		}
+		suggestions[i].Count++
+	}
+	slices.SortStableFunc(suggestions, func(a, b models.CommandSuggestion) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return b.LastUsedAt.Compare(a.LastUsedAt)
+	})
+	return suggestions
+}
+
+// commandSuggestions returns the ranked previous commands of principal
+func (s *Server) commandSuggestions(principal string) ([]models.CommandSuggestion, error) {
+	suggestions, generation, ok := s.suggestions.get(principal)
+	if ok {
+		return suggestions, nil
+	}
+
+	history, err := repository.NewCommandHistoryRepository(s.db).GetCommandsByPrincipal(principal, commandSuggestionScan)
+	if err != nil {
+		return nil, err
+	}
+	suggestions = rankCommands(history)
+	s.suggestions.put(principal, generation, suggestions)
+	return suggestions, nil
+}
+
+// handleSuggestCommands godoc
+// @Summary Suggest commands from history
+// @Description Complete a command from the caller's previous commands: the distinct commands starting with prefix among the caller's latest 1000, most run first. Script executions are left out.
+// @Tags Command History
+// @Produce json
+// @Param prefix query string false "Start of the command being typed; empty for the most run commands"
+// @Param limit query int false "Maximum number of suggestions, up to 50" default(10)
+// @Success 200 {array} models.CommandSuggestion
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /history/suggest [get]
+func (s *Server) handleSuggestCommands(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = min(parsedLimit, 50)
+		}
+	}
+
+	suggestions, err := s.commandSuggestions(callerIdentity(r))
+	if err != nil {
+		log.Printf("Error ranking command history: %v", err)
+		apierror.Error(w, "Failed to fetch command suggestions", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]models.CommandSuggestion, 0, limit)
+	for _, suggestion := range suggestions {
+		if len(response) == limit {
+			break
+		}
+		if strings.HasPrefix(suggestion.Command, prefix) {
+			response = append(response, suggestion)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
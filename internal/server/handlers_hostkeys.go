@@ -0,0 +1,29 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// validateServerHostKey checks the host key verification settings of a server
+// It returns the invalid field and why, or "" when the settings are valid.
+func validateServerHostKey(server *models.Server) (string, error) {
+	switch server.HostKeyPolicy {
+	case "", models.HostKeyPolicyStrict, models.HostKeyPolicyTOFU, models.HostKeyPolicyInsecure:
+	default:
+		return "host_key_policy", fmt.Errorf("host_key_policy must be %s, %s or %s", models.HostKeyPolicyStrict, models.HostKeyPolicyTOFU, models.HostKeyPolicyInsecure)
+	}
+
+	if server.HostKeyFingerprint == "" {
+		return "", nil
+	}
+	if err := validation.ValidateHostKeyFingerprint(server.HostKeyFingerprint); err != nil {
+		return "host_key_fingerprint", err
+	}
+	if server.HostKeyPolicy == models.HostKeyPolicyInsecure {
+		return "host_key_fingerprint", fmt.Errorf("a pinned host_key_fingerprint cannot be combined with the %s policy", models.HostKeyPolicyInsecure)
+	}
+	return "", nil
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/inventory"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// InventoryStatus reports whether cloud inventory is configured and how its last sync went
+type InventoryStatus struct {
+	Enabled bool `json:"enabled"`
+	*inventory.Status
+}
+
+// newInventory creates the cloud inventory syncer of the configured providers
+// It returns nil when no provider is configured.
+func newInventory(cfg *config.Config, db *database.DB) (*inventory.Syncer, error) {
+	var providers []inventory.Provider
+	if len(cfg.InventoryAWSRegions) > 0 {
+		if cfg.InventoryAWSAccessKeyID == "" || cfg.InventoryAWSSecretAccessKey == "" {
+			return nil, fmt.Errorf("INVENTORY_AWS_REGIONS requires INVENTORY_AWS_ACCESS_KEY_ID and INVENTORY_AWS_SECRET_ACCESS_KEY to be set")
+		}
+		providers = append(providers, &inventory.AWS{
+			Regions:         cfg.InventoryAWSRegions,
+			AccessKeyID:     cfg.InventoryAWSAccessKeyID,
+			SecretAccessKey: cfg.InventoryAWSSecretAccessKey,
+			SessionToken:    cfg.InventoryAWSSessionToken,
+			GroupTag:        cfg.InventoryGroupTag,
+			PrivateIP:       cfg.InventoryPrivateIP,
+		})
+	}
+	if cfg.InventoryDigitalOceanToken != "" {
+		providers = append(providers, &inventory.DigitalOcean{
+			Token:     cfg.InventoryDigitalOceanToken,
+			GroupTag:  cfg.InventoryGroupTag,
+			PrivateIP: cfg.InventoryPrivateIP,
+		})
+	}
+	if cfg.InventoryHetznerToken != "" {
+		providers = append(providers, &inventory.Hetzner{
+			Token:     cfg.InventoryHetznerToken,
+			GroupTag:  cfg.InventoryGroupTag,
+			PrivateIP: cfg.InventoryPrivateIP,
+		})
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+
+	return inventory.New(inventory.Config{
+		Providers: providers,
+		Interval:  cfg.GetInventoryInterval(),
+	}, repository.NewServerRepository(db))
+}
+
+// handleGetInventory godoc
+// @Summary Get cloud inventory status
+// @Description Report the configured cloud providers and the outcome of the last inventory sync of servers
+// @Tags Servers
+// @Produce json
+// @Success 200 {object} InventoryStatus
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /inventory [get]
+func (s *Server) handleGetInventory(w http.ResponseWriter, r *http.Request) {
+	result := InventoryStatus{}
+	if s.inventory != nil {
+		status := s.inventory.Status()
+		result = InventoryStatus{Enabled: true, Status: &status}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleSyncInventory godoc
+// @Summary Sync servers from cloud providers now
+// @Description Discover the instances of the configured cloud providers and update their servers without waiting for the next interval
+// @Tags Servers
+// @Produce json
+// @Success 200 {object} InventoryStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 502 {object} InventoryStatus
+// @Security BasicAuth
+// @Router /inventory [post]
+func (s *Server) handleSyncInventory(w http.ResponseWriter, r *http.Request) {
+	if s.inventory == nil {
+		apierror.Error(w, "Cloud inventory is not configured: set INVENTORY_AWS_REGIONS, INVENTORY_DIGITALOCEAN_TOKEN or INVENTORY_HETZNER_TOKEN", http.StatusBadRequest)
+		return
+	}
+
+	status, err := s.inventory.Sync(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		audit.GetLogger().LogConfigChange(r, "inventory", "sync", audit.OutcomeFailure)
+		w.WriteHeader(http.StatusBadGateway)
+	} else {
+		audit.GetLogger().LogConfigChange(r, "inventory", "sync", audit.OutcomeSuccess)
+	}
+	json.NewEncoder(w).Encode(InventoryStatus{Enabled: true, Status: &status})
+}
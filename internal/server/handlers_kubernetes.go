@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/kubernetes"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// newKubernetesClient creates the client of the configured cluster
+// It returns nil when neither a kubeconfig nor in-cluster access is configured.
+func newKubernetesClient(cfg *config.Config) (*kubernetes.Client, error) {
+	if cfg.KubernetesKubeconfig == "" && !cfg.KubernetesInCluster {
+		return nil, nil
+	}
+	return kubernetes.New(kubernetes.Config{
+		Kubeconfig: cfg.KubernetesKubeconfig,
+		Context:    cfg.KubernetesContext,
+		InCluster:  cfg.KubernetesInCluster,
+	})
+}
+
+// kubernetesNotConfigured is returned when an execution or listing needs a cluster that is not configured
+const kubernetesNotConfigured = "Kubernetes is not configured: set KUBERNETES_KUBECONFIG or KUBERNETES_IN_CLUSTER"
+
+// handleListKubernetesNamespaces godoc
+// @Summary List Kubernetes namespaces
+// @Description List the namespaces of the configured cluster whose pods the caller may view (pods group permissions use the namespace as group)
+// @Tags Kubernetes
+// @Produce json
+// @Success 200 {array} string
+// @Failure 400 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /kubernetes/namespaces [get]
+func (s *Server) handleListKubernetesNamespaces(w http.ResponseWriter, r *http.Request) {
+	if s.kube == nil {
+		apierror.Error(w, kubernetesNotConfigured, http.StatusBadRequest)
+		return
+	}
+
+	namespaces, err := s.kube.ListNamespaces(r.Context())
+	if err != nil {
+		log.Printf("Error listing Kubernetes namespaces: %v", err)
+		apierror.Error(w, fmt.Sprintf("Failed to list namespaces: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.groupAccess(r).visibleGroups(models.ResourceTypePods, namespaces))
+}
+
+// handleListKubernetesPods godoc
+// @Summary List Kubernetes pods
+// @Description List the pods of a namespace that commands and scripts can run in, sorted by name
+// @Tags Kubernetes
+// @Produce json
+// @Param namespace query string false "Namespace (default: the namespace of the configured context)"
+// @Param selector query string false "Label selector, such as app=web"
+// @Success 200 {array} models.KubernetesPod
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /kubernetes/pods [get]
+func (s *Server) handleListKubernetesPods(w http.ResponseWriter, r *http.Request) {
+	if s.kube == nil {
+		apierror.Error(w, kubernetesNotConfigured, http.StatusBadRequest)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = s.kube.Namespace()
+	}
+	if !s.groupAccess(r).canView(models.ResourceTypePods, namespace) {
+		denyGroupAccess(w, r, models.ResourceTypePods, namespace, models.PermissionView)
+		return
+	}
+
+	pods, err := s.kube.ListPods(r.Context(), namespace, r.URL.Query().Get("selector"))
+	if err != nil {
+		log.Printf("Error listing Kubernetes pods: %v", err)
+		apierror.Error(w, fmt.Sprintf("Failed to list pods: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pods)
+}
+
+// kubernetesPod is the container an execution runs in
+type kubernetesPod struct {
+	namespace string
+	pod       string
+	container string // Empty for the pod's only container
+}
+
+// name returns how executions in the container are shown in history, audit logs and notifications
+func (p *kubernetesPod) name() string {
+	name := "k8s:" + p.namespace + "/" + p.pod
+	if p.container != "" {
+		name += "/" + p.container
+	}
+	return name
+}
+
+// resolveKubernetesTarget checks the caller may execute in the target's namespace and
+// picks the pod of a label selector
+// The error response is written and nil returned when the target cannot be used
+func (s *Server) resolveKubernetesTarget(w http.ResponseWriter, r *http.Request, access *groupAccess, target *models.KubernetesTarget, isRemote bool) *kubernetesPod {
+	if isRemote {
+		apierror.InvalidField(w, "kubernetes", "Kubernetes execution cannot be combined with is_remote")
+		return nil
+	}
+	if s.kube == nil {
+		apierror.Error(w, kubernetesNotConfigured, http.StatusBadRequest)
+		return nil
+	}
+	if (target.Pod == "") == (target.Selector == "") {
+		apierror.InvalidField(w, "kubernetes", "Either kubernetes.pod or kubernetes.selector is required")
+		return nil
+	}
+
+	pod := &kubernetesPod{namespace: target.Namespace, pod: target.Pod, container: target.Container}
+	if pod.namespace == "" {
+		pod.namespace = s.kube.Namespace()
+	}
+	if !access.canExecute(models.ResourceTypePods, pod.namespace) {
+		denyGroupAccess(w, r, models.ResourceTypePods, pod.namespace, models.PermissionExecute)
+		return nil
+	}
+
+	if target.Selector != "" {
+		pods, err := s.kube.ListPods(r.Context(), pod.namespace, target.Selector)
+		if err != nil {
+			log.Printf("Error listing Kubernetes pods: %v", err)
+			apierror.Error(w, fmt.Sprintf("Failed to list pods: %v", err), http.StatusBadGateway)
+			return nil
+		}
+		for _, p := range pods {
+			if p.Phase == "Running" {
+				pod.pod = p.Name
+				break
+			}
+		}
+		if pod.pod == "" {
+			apierror.Error(w, fmt.Sprintf("No running pod in namespace %q matches selector %q", pod.namespace, target.Selector), http.StatusNotFound)
+			return nil
+		}
+	}
+	return pod
+}
+
+// kubernetesExecutor returns an executor running commands in the pod's container with shell (default: sh)
+func (s *Server) kubernetesExecutor(pod *kubernetesPod, shell string) *executor.KubernetesExecutor {
+	return executor.NewKubernetesExecutor(func(ctx context.Context, command []string, stdin []byte, stdout, stderr io.Writer) (int, error) {
+		return s.kube.Exec(ctx, kubernetes.ExecOptions{
+			Namespace: pod.namespace,
+			Pod:       pod.pod,
+			Container: pod.container,
+			Command:   command,
+			Stdin:     stdin,
+		}, stdout, stderr)
+	}, shell)
+}
+
+// scriptShell returns the shell a script runs through in a container
+// Bash scripts need bash; other interpreters are started from a POSIX shell.
+func scriptShell(script *models.BashScript) string {
+	if script.Interpreter == "" || script.Interpreter == models.ScriptInterpreterBash {
+		return "bash"
+	}
+	return "sh"
+}
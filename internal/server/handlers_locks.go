@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// lockScript takes the lock of an exclusive script before it runs, so two runs never overlap
+// A script is exclusive when it is flagged so or when it runs from an exclusive preset saved for it.
+// Syntax checks run nothing and take no lock. Without wait_for_lock a held lock fails fast;
+// otherwise the wait is bounded by ctx.
+func (s *Server) lockScript(ctx context.Context, script *models.BashScript, exec *models.ScriptExecution, serverName string) (unlock func(), holder *executor.LockInfo, err error) {
+	exclusive := script.Exclusive
+	if !exclusive && exec.PresetID != nil {
+		preset, err := repository.NewScriptPresetRepository(s.db).GetByID(*exec.PresetID)
+		exclusive = err == nil && preset.ScriptID == script.ID && preset.Exclusive
+	}
+	if !exclusive || exec.DryRun {
+		return func() {}, nil, nil
+	}
+
+	key := fmt.Sprintf("script:%d", script.ID)
+	if script.ID == 0 {
+		key = "script:" + script.Name // Vault scripts have no ID
+	}
+	info := executor.LockInfo{Key: key, ScriptID: script.ID, Script: script.Name, Server: serverName}
+	if principal := middleware.PrincipalFromContext(ctx); principal != nil {
+		info.HeldBy = principal.Name
+	}
+	return s.locks.Acquire(ctx, info, exec.WaitForLock)
+}
+
+// lockErrorMessage describes why an exclusive script could not take its lock
+func lockErrorMessage(script *models.BashScript, holder *executor.LockInfo, err error) string {
+	if !errors.Is(err, executor.ErrLocked) {
+		return fmt.Sprintf("Request cancelled while waiting for script %q to finish", script.Name)
+	}
+	msg := fmt.Sprintf("Script %q is already running on %s", script.Name, holder.Server)
+	if holder.HeldBy != "" {
+		msg += " (started by " + holder.HeldBy + ")"
+	}
+	return msg + " since " + holder.Since.Format(time.RFC3339)
+}
+
+// handleGetExecutionLocks godoc
+// @Summary List held script locks
+// @Description List the exclusive scripts running now, with who started them, where and since when
+// @Tags System
+// @Produce json
+// @Success 200 {array} executor.LockInfo
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /executions/locks [get]
+func (s *Server) handleGetExecutionLocks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.locks.Held())
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/middleware"
+)
+
+// MaintenanceRequest turns read-only mode on or off
+type MaintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty" example:"Incident 42: credentials rotation"`
+}
+
+// handleGetMaintenance godoc
+// @Summary Get read-only mode
+// @Description Report whether the server is in read-only (maintenance) mode
+// @Tags System
+// @Produce json
+// @Success 200 {object} middleware.MaintenanceStatus
+// @Security BasicAuth
+// @Router /maintenance [get]
+func (s *Server) handleGetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var status middleware.MaintenanceStatus
+	if s.maintenance != nil {
+		status = s.maintenance.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleSetMaintenance godoc
+// @Summary Set read-only mode
+// @Description Turn read-only (maintenance) mode on or off. While on, command, script and terminal execution and all changes are rejected with 503; reads keep working.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param mode body MaintenanceRequest true "Read-only mode"
+// @Success 200 {object} middleware.MaintenanceStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /maintenance [put]
+func (s *Server) handleSetMaintenance(w http.ResponseWriter, r *http.Request) {
+	if s.maintenance == nil {
+		apierror.Error(w, "Read-only mode is not available", http.StatusInternalServerError)
+		return
+	}
+
+	var req MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	changedBy := "anonymous"
+	if principal := middleware.PrincipalFromContext(r.Context()); principal != nil {
+		changedBy = principal.Name
+	}
+
+	status := s.maintenance.Set(req.Enabled, req.Reason, changedBy)
+
+	action := "disable"
+	if req.Enabled {
+		action = "enable"
+	}
+	audit.GetLogger().LogConfigChange(r, "read_only_mode", action, audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// maintenanceWindowBlock is a maintenance window keeping an execution from running
+type maintenanceWindowBlock struct {
+	window *models.MaintenanceWindow
+	until  time.Time
+	server string
+}
+
+// message describes the window to the caller
+func (b *maintenanceWindowBlock) message() string {
+	return fmt.Sprintf("%s is in maintenance window %q until %s; set maintenance_override to run anyway",
+		b.server, b.window.Name, b.until.UTC().Format(time.RFC3339))
+}
+
+// checkMaintenanceWindow returns the maintenance window in effect for server, nil meaning the
+// local machine, unless none is or the caller overrides it
+// Blocked executions are audited as denied and overrides are audited. A window that cannot be
+// read does not block executions.
+func (s *Server) checkMaintenanceWindow(r *http.Request, server *models.Server, user string, override bool) *maintenanceWindowBlock {
+	windows, err := repository.NewMaintenanceWindowRepository(s.db).GetEnabled()
+	if err != nil {
+		log.Printf("Warning: failed to load maintenance windows: %v", err)
+		return nil
+	}
+
+	group, name := "", "local"
+	if server != nil {
+		group, name = server.Group, server.Name
+		if name == "" {
+			name = server.IPAddress
+		}
+	}
+	now := time.Now()
+	for _, window := range windows {
+		if !window.Covers(group) {
+			continue
+		}
+		until, active := window.ActiveUntil(now)
+		if !active {
+			continue
+		}
+		if override {
+			log.Printf("Maintenance window %q on %s overridden by %s", window.Name, name, callerIdentity(r))
+			audit.GetLogger().LogMaintenanceOverride(r, name, user, window.Name)
+			return nil
+		}
+		audit.GetLogger().LogAccessDenied(r, name, map[string]string{
+			"reason": "maintenance_window",
+			"window": window.Name,
+		})
+		return &maintenanceWindowBlock{window: window, until: until, server: name}
+	}
+	return nil
+}
+
+// writeMaintenanceWindowError rejects an execution blocked by a maintenance window with 423 Locked
+func writeMaintenanceWindowError(w http.ResponseWriter, block *maintenanceWindowBlock) {
+	apierror.WithCode(w, apierror.CodeMaintenanceWindow, block.message(), http.StatusLocked)
+}
+
+// withActive marks the windows in effect now
+func withActive(windows ...*models.MaintenanceWindow) {
+	now := time.Now()
+	for _, window := range windows {
+		_, window.Active = window.ActiveUntil(now)
+	}
+}
+
+// handleListMaintenanceWindows godoc
+// @Summary List maintenance windows
+// @Description List the maintenance windows blocking executions on the servers of a group, with whether each is in effect now
+// @Tags Maintenance Windows
+// @Produce json
+// @Success 200 {array} models.MaintenanceWindow
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /maintenance-windows [get]
+func (s *Server) handleListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewMaintenanceWindowRepository(s.db)
+
+	windows, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching maintenance windows: %v", err)
+		apierror.Error(w, "Failed to fetch maintenance windows", http.StatusInternalServerError)
+		return
+	}
+
+	if windows == nil {
+		windows = []*models.MaintenanceWindow{}
+	}
+	withActive(windows...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(windows)
+}
+
+// handleCreateMaintenanceWindow godoc
+// @Summary Create a maintenance window
+// @Description Create a window during which commands and scripts on the servers of a group are rejected with 423 and scheduled presets are skipped. A recurring window starts whenever its cron schedule fires and lasts duration_minutes; a one-off window lasts from starts_at until ends_at. A server group of * covers every server and the local machine.
+// @Tags Maintenance Windows
+// @Accept json
+// @Produce json
+// @Param window body models.MaintenanceWindowCreate true "Window to create"
+// @Success 201 {object} models.MaintenanceWindow
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /maintenance-windows [post]
+func (s *Server) handleCreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var create models.MaintenanceWindowCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewMaintenanceWindowRepository(s.db)
+
+	window, err := repo.Create(&create)
+	if err != nil {
+		log.Printf("Error creating maintenance window: %v", err)
+		audit.GetLogger().LogConfigChange(r, "maintenance_window", "create", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to create maintenance window: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	withActive(window)
+
+	audit.GetLogger().LogConfigChange(r, "maintenance_window", "create", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(window)
+}
+
+// handleGetMaintenanceWindow godoc
+// @Summary Get a maintenance window
+// @Description Get a maintenance window by ID
+// @Tags Maintenance Windows
+// @Produce json
+// @Param id path int true "Maintenance Window ID"
+// @Success 200 {object} models.MaintenanceWindow
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /maintenance-windows/{id} [get]
+func (s *Server) handleGetMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewMaintenanceWindowRepository(s.db)
+
+	window, err := repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching maintenance window: %v", err)
+		apierror.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+	withActive(window)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(window)
+}
+
+// handleUpdateMaintenanceWindow godoc
+// @Summary Update a maintenance window
+// @Description Update a maintenance window's timing, server group or enabled state. Setting a schedule turns a one-off window into a recurring one, and setting starts_at or ends_at does the opposite.
+// @Tags Maintenance Windows
+// @Accept json
+// @Produce json
+// @Param id path int true "Maintenance Window ID"
+// @Param window body models.MaintenanceWindowUpdate true "Fields to update"
+// @Success 200 {object} models.MaintenanceWindow
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /maintenance-windows/{id} [put]
+func (s *Server) handleUpdateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.MaintenanceWindowUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewMaintenanceWindowRepository(s.db)
+
+	window, err := repo.Update(id, &update)
+	if err != nil {
+		log.Printf("Error updating maintenance window: %v", err)
+		audit.GetLogger().LogConfigChange(r, "maintenance_window", "update", audit.OutcomeFailure)
+		if strings.Contains(err.Error(), "not found") && !strings.Contains(err.Error(), "server group") {
+			apierror.Error(w, "Maintenance window not found", http.StatusNotFound)
+			return
+		}
+		apierror.Error(w, "Failed to update maintenance window: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	withActive(window)
+
+	audit.GetLogger().LogConfigChange(r, "maintenance_window", "update", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(window)
+}
+
+// handleDeleteMaintenanceWindow godoc
+// @Summary Delete a maintenance window
+// @Description Delete a maintenance window, ending it at once if it is in effect
+// @Tags Maintenance Windows
+// @Param id path int true "Maintenance Window ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /maintenance-windows/{id} [delete]
+func (s *Server) handleDeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewMaintenanceWindowRepository(s.db)
+
+	if err := repo.Delete(id); err != nil {
+		log.Printf("Error deleting maintenance window: %v", err)
+		apierror.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "maintenance_window", "delete", audit.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
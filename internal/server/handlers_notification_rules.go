@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// handleListNotificationRules godoc
+// @Summary List notification rules
+// @Description List the rules that send matching command and script executions to webhooks, Slack or email
+// @Tags Notification Rules
+// @Produce json
+// @Success 200 {array} models.NotificationRule
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /notification-rules [get]
+func (s *Server) handleListNotificationRules(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewNotificationRuleRepository(s.db)
+
+	rules, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching notification rules: %v", err)
+		apierror.Error(w, "Failed to fetch notification rules", http.StatusInternalServerError)
+		return
+	}
+
+	if rules == nil {
+		rules = []*models.NotificationRule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// handleCreateNotificationRule godoc
+// @Summary Create a notification rule
+// @Description Create a rule evaluated after every execution. Every condition that is set must match; channels are webhook:<name>, slack, slack:<channel>, email or email:<address>.
+// @Tags Notification Rules
+// @Accept json
+// @Produce json
+// @Param rule body models.NotificationRuleCreate true "Rule to create"
+// @Success 201 {object} models.NotificationRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /notification-rules [post]
+func (s *Server) handleCreateNotificationRule(w http.ResponseWriter, r *http.Request) {
+	var create models.NotificationRuleCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewNotificationRuleRepository(s.db)
+
+	rule, err := repo.Create(&create)
+	if err != nil {
+		log.Printf("Error creating notification rule: %v", err)
+		audit.GetLogger().LogConfigChange(r, "notification_rule", "create", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to create notification rule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "notification_rule", "create", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// handleGetNotificationRule godoc
+// @Summary Get a notification rule
+// @Description Get a notification rule by ID
+// @Tags Notification Rules
+// @Produce json
+// @Param id path int true "Notification Rule ID"
+// @Success 200 {object} models.NotificationRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /notification-rules/{id} [get]
+func (s *Server) handleGetNotificationRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid notification rule ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewNotificationRuleRepository(s.db)
+
+	rule, err := repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching notification rule: %v", err)
+		apierror.Error(w, "Notification rule not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// handleUpdateNotificationRule godoc
+// @Summary Update a notification rule
+// @Description Update a notification rule's conditions, channels or enabled state
+// @Tags Notification Rules
+// @Accept json
+// @Produce json
+// @Param id path int true "Notification Rule ID"
+// @Param rule body models.NotificationRuleUpdate true "Fields to update"
+// @Success 200 {object} models.NotificationRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /notification-rules/{id} [put]
+func (s *Server) handleUpdateNotificationRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid notification rule ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.NotificationRuleUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewNotificationRuleRepository(s.db)
+
+	rule, err := repo.Update(id, &update)
+	if err != nil {
+		log.Printf("Error updating notification rule: %v", err)
+		audit.GetLogger().LogConfigChange(r, "notification_rule", "update", audit.OutcomeFailure)
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Error(w, "Notification rule not found", http.StatusNotFound)
+			return
+		}
+		apierror.Error(w, "Failed to update notification rule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "notification_rule", "update", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// handleDeleteNotificationRule godoc
+// @Summary Delete a notification rule
+// @Description Delete a notification rule
+// @Tags Notification Rules
+// @Param id path int true "Notification Rule ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /notification-rules/{id} [delete]
+func (s *Server) handleDeleteNotificationRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid notification rule ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewNotificationRuleRepository(s.db)
+
+	if err := repo.Delete(id); err != nil {
+		log.Printf("Error deleting notification rule: %v", err)
+		apierror.Error(w, "Notification rule not found", http.StatusNotFound)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "notification_rule", "delete", audit.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/swaggo/swag"
+)
+
+// handleOpenAPISpec godoc
+// @Summary Get the OpenAPI specification
+// @Description Get the Swagger 2.0 specification of this API, generated from the handler annotations at build time. The host and base path are those of the running instance, so the spec can be loaded into API clients as is.
+// @Tags System
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /openapi.json [get]
+func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		log.Printf("Error reading OpenAPI specification: %v", err)
+		apierror.Error(w, "OpenAPI specification not available", http.StatusInternalServerError)
+		return
+	}
+
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+		log.Printf("Error parsing OpenAPI specification: %v", err)
+		apierror.Error(w, "OpenAPI specification not available", http.StatusInternalServerError)
+		return
+	}
+
+	// The spec is generated for localhost; point it at this instance
+	spec["host"] = r.Host
+	spec["basePath"] = s.config.GetBasePath() + "/api"
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spec)
+}
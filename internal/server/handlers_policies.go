@@ -0,0 +1,294 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// checkCommandPolicy evaluates the command policies for an ad-hoc command
+// Returns false and writes 403 Forbidden if the command is denied
+func (s *Server) checkCommandPolicy(w http.ResponseWriter, r *http.Request, command string) bool {
+	engine, err := repository.NewCommandPolicyRepository(s.db).LoadEngine()
+	if err != nil {
+		log.Printf("Error loading command policies: %v", err)
+		apierror.Error(w, "Failed to evaluate command policies", http.StatusInternalServerError)
+		return false
+	}
+
+	decision := engine.CheckCommand(command, callerIdentities(r))
+	if !decision.Allowed {
+		denyByPolicy(w, r, "command", command, decision)
+		return false
+	}
+	return true
+}
+
+// checkScriptPolicy evaluates the command policies for a script
+// Returns false and writes 403 Forbidden if the script is denied
+func (s *Server) checkScriptPolicy(w http.ResponseWriter, r *http.Request, script *models.BashScript) bool {
+	engine, err := repository.NewCommandPolicyRepository(s.db).LoadEngine()
+	if err != nil {
+		log.Printf("Error loading command policies: %v", err)
+		apierror.Error(w, "Failed to evaluate command policies", http.StatusInternalServerError)
+		return false
+	}
+
+	decision := engine.CheckScript(script.Name, script.Content, callerIdentities(r))
+	if !decision.Allowed {
+		denyByPolicy(w, r, "script/"+script.Name, script.Content, decision)
+		return false
+	}
+	return true
+}
+
+// callerIdentities returns the identities command policies are matched against
+// Requests without a principal (authentication disabled) only match policies for everyone
+func callerIdentities(r *http.Request) []string {
+	principal := middleware.PrincipalFromContext(r.Context())
+	if principal == nil {
+		return nil
+	}
+	return principal.Identities()
+}
+
+// denyByPolicy rejects an execution denied by a command policy and audits it
+func denyByPolicy(w http.ResponseWriter, r *http.Request, target, command string, decision models.PolicyDecision) {
+	audit.GetLogger().LogPolicyDenied(r, target, command, decision.Policy.Name, decision.Reason)
+	apierror.Error(w, "Denied by command policy \""+decision.Policy.Name+"\": "+decision.Reason, http.StatusForbidden)
+}
+
+// handleListCommandPolicies godoc
+// @Summary List command policies
+// @Description List the allow and deny rules evaluated before commands and scripts are executed
+// @Tags Command Policies
+// @Produce json
+// @Success 200 {array} models.CommandPolicy
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /policies [get]
+func (s *Server) handleListCommandPolicies(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewCommandPolicyRepository(s.db)
+
+	policies, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching command policies: %v", err)
+		apierror.Error(w, "Failed to fetch command policies", http.StatusInternalServerError)
+		return
+	}
+
+	if policies == nil {
+		policies = []*models.CommandPolicy{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policies)
+}
+
+// handleCreateCommandPolicy godoc
+// @Summary Create a command policy
+// @Description Create an allow or deny rule. Deny rules reject matching commands; allow rules restrict the callers they apply to to matching commands only.
+// @Tags Command Policies
+// @Accept json
+// @Produce json
+// @Param policy body models.CommandPolicyCreate true "Policy to create"
+// @Success 201 {object} models.CommandPolicy
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /policies [post]
+func (s *Server) handleCreateCommandPolicy(w http.ResponseWriter, r *http.Request) {
+	var create models.CommandPolicyCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewCommandPolicyRepository(s.db)
+
+	policy, err := repo.Create(&create)
+	if err != nil {
+		log.Printf("Error creating command policy: %v", err)
+		audit.GetLogger().LogConfigChange(r, "command_policy", "create", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to create command policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "command_policy", "create", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// handleGetCommandPolicy godoc
+// @Summary Get a command policy
+// @Description Get a command policy by ID
+// @Tags Command Policies
+// @Produce json
+// @Param id path int true "Command Policy ID"
+// @Success 200 {object} models.CommandPolicy
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /policies/{id} [get]
+func (s *Server) handleGetCommandPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid policy ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewCommandPolicyRepository(s.db)
+
+	policy, err := repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching command policy: %v", err)
+		apierror.Error(w, "Command policy not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// handleUpdateCommandPolicy godoc
+// @Summary Update a command policy
+// @Description Update a command policy's rule, principal or enabled state
+// @Tags Command Policies
+// @Accept json
+// @Produce json
+// @Param id path int true "Command Policy ID"
+// @Param policy body models.CommandPolicyUpdate true "Fields to update"
+// @Success 200 {object} models.CommandPolicy
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /policies/{id} [put]
+func (s *Server) handleUpdateCommandPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid policy ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.CommandPolicyUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewCommandPolicyRepository(s.db)
+
+	policy, err := repo.Update(id, &update)
+	if err != nil {
+		log.Printf("Error updating command policy: %v", err)
+		audit.GetLogger().LogConfigChange(r, "command_policy", "update", audit.OutcomeFailure)
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Error(w, "Command policy not found", http.StatusNotFound)
+			return
+		}
+		apierror.Error(w, "Failed to update command policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "command_policy", "update", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// handleDeleteCommandPolicy godoc
+// @Summary Delete a command policy
+// @Description Delete a command policy
+// @Tags Command Policies
+// @Param id path int true "Command Policy ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /policies/{id} [delete]
+func (s *Server) handleDeleteCommandPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid policy ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewCommandPolicyRepository(s.db)
+
+	if err := repo.Delete(id); err != nil {
+		log.Printf("Error deleting command policy: %v", err)
+		apierror.Error(w, "Command policy not found", http.StatusNotFound)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "command_policy", "delete", audit.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTestCommandPolicies godoc
+// @Summary Test command policies
+// @Description Evaluate the enabled command policies against a command or stored script without executing it
+// @Tags Command Policies
+// @Accept json
+// @Produce json
+// @Param request body models.PolicyTestRequest true "Command or script to evaluate"
+// @Success 200 {object} models.PolicyDecision
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /policies/test [post]
+func (s *Server) handleTestCommandPolicies(w http.ResponseWriter, r *http.Request) {
+	var req models.PolicyTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Command == "" && req.ScriptID == 0 {
+		apierror.Error(w, "Command or script_id is required", http.StatusBadRequest)
+		return
+	}
+
+	engine, err := repository.NewCommandPolicyRepository(s.db).LoadEngine()
+	if err != nil {
+		log.Printf("Error loading command policies: %v", err)
+		apierror.Error(w, "Failed to evaluate command policies", http.StatusInternalServerError)
+		return
+	}
+
+	principal := &middleware.Principal{Name: req.Principal, Roles: req.Roles}
+	identities := principal.Identities()
+
+	var decision models.PolicyDecision
+	if req.ScriptID > 0 {
+		script, err := repository.NewBashScriptRepository(s.db).GetByID(req.ScriptID)
+		if err != nil {
+			log.Printf("Error fetching script by ID: %v", err)
+			apierror.Error(w, "Script not found", http.StatusNotFound)
+			return
+		}
+		decision = engine.CheckScript(script.Name, script.Content, identities)
+	} else {
+		decision = engine.CheckCommand(req.Command, identities)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decision)
+}
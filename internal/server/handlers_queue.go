@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// newExecutionQueue creates the queue bounding concurrent executions
+func newExecutionQueue(cfg *config.Config) *executor.Queue {
+	return executor.NewQueue(executor.QueueConfig{
+		MaxRunning:   cfg.MaxConcurrentExecutions,
+		MaxQueued:    cfg.MaxQueuedExecutions,
+		MaxPerServer: cfg.ServerMaxParallel,
+		Timeout:      cfg.GetExecutionQueueTimeout(),
+	})
+}
+
+// acquireExecutionSlot waits for the queue to allow an execution on server, nil meaning the local machine
+// queued, if not nil, is called when the execution has to wait
+func (s *Server) acquireExecutionSlot(ctx context.Context, server *models.Server, queued func()) (func(), error) {
+	if server == nil {
+		return s.queue.Acquire(ctx, "", 0, queued)
+	}
+	key := server.Name
+	if key == "" {
+		key = server.IPAddress
+	}
+	return s.queue.Acquire(ctx, key, server.MaxParallel, queued)
+}
+
+// queueErrorMessage describes why an execution did not get a slot
+func queueErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, executor.ErrQueueFull):
+		return "Execution queue is full, try again later"
+	case errors.Is(err, executor.ErrQueueTimeout):
+		return "Timed out waiting for a free execution slot"
+	default:
+		return "Request cancelled while waiting for a free execution slot"
+	}
+}
+
+// writeQueueError rejects an execution that did not get a slot with 503 Service Unavailable
+func writeQueueError(w http.ResponseWriter, err error) {
+	w.Header().Set("Retry-After", "5")
+	apierror.WithCode(w, apierror.CodeQueueFull, queueErrorMessage(err), http.StatusServiceUnavailable)
+}
+
+// handleGetExecutionQueue godoc
+// @Summary Get the execution queue
+// @Description Report the commands and scripts running and waiting for a slot, overall and per server, with the configured limits
+// @Tags System
+// @Produce json
+// @Success 200 {object} executor.QueueStats
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /executions/queue [get]
+func (s *Server) handleGetExecutionQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.queue.Stats())
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// QuotaStatusResponse represents the caller's execution quota and usage
+type QuotaStatusResponse struct {
+	Quota *models.ExecutionQuota `json:"quota"` // null when no quota applies
+	Usage middleware.QuotaUsage  `json:"usage"`
+}
+
+// newExecutionQuotas creates the enforcer of the quotas stored in the database
+// Quotas are read on every execution, so changes apply right away; a failed read lets the execution through.
+func newExecutionQuotas(db *database.DB) *middleware.Quotas {
+	repo := repository.NewExecutionQuotaRepository(db)
+	return middleware.NewQuotas(&middleware.QuotaConfig{
+		Lookup: func(principal *middleware.Principal) *middleware.Quota {
+			quota, err := repo.Resolve(principal.Identities())
+			if err != nil {
+				log.Printf("Warning: failed to load execution quota of %s: %v", principal.Name, err)
+				return nil
+			}
+			if quota == nil {
+				return nil
+			}
+			return &middleware.Quota{
+				ExecutionsPerHour: quota.ExecutionsPerHour,
+				MaxConcurrent:     quota.MaxConcurrent,
+				MaxTimeout:        time.Duration(quota.MaxTimeoutSeconds) * time.Second,
+			}
+		},
+	})
+}
+
+// handleGetMyQuota godoc
+// @Summary Get the caller's execution quota
+// @Description Get the quota applying to the caller and its executions running now and started in the last hour
+// @Tags Quotas
+// @Produce json
+// @Success 200 {object} QuotaStatusResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /quotas/me [get]
+func (s *Server) handleGetMyQuota(w http.ResponseWriter, r *http.Request) {
+	response := QuotaStatusResponse{}
+
+	if principal := middleware.PrincipalFromContext(r.Context()); principal != nil {
+		quota, err := repository.NewExecutionQuotaRepository(s.db).Resolve(principal.Identities())
+		if err != nil {
+			log.Printf("Error fetching execution quota: %v", err)
+			apierror.Error(w, "Failed to fetch execution quota", http.StatusInternalServerError)
+			return
+		}
+		response.Quota = quota
+		response.Usage = s.quotas.Usage(principal.Name)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleListQuotas godoc
+// @Summary List execution quotas
+// @Description List the quotas limiting the executions of users, tokens and roles
+// @Tags Quotas
+// @Produce json
+// @Success 200 {array} models.ExecutionQuota
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /quotas [get]
+func (s *Server) handleListQuotas(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewExecutionQuotaRepository(s.db)
+
+	quotas, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching execution quotas: %v", err)
+		apierror.Error(w, "Failed to fetch quotas", http.StatusInternalServerError)
+		return
+	}
+
+	if quotas == nil {
+		quotas = []*models.ExecutionQuota{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quotas)
+}
+
+// handleCreateQuota godoc
+// @Summary Create an execution quota
+// @Description Create a quota for user:<name>, token:<name>, role:<role> or * (everyone without a more specific quota). Limits of 0 are unlimited.
+// @Tags Quotas
+// @Accept json
+// @Produce json
+// @Param quota body models.ExecutionQuotaCreate true "Quota to create"
+// @Success 201 {object} models.ExecutionQuota
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /quotas [post]
+func (s *Server) handleCreateQuota(w http.ResponseWriter, r *http.Request) {
+	var create models.ExecutionQuotaCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewExecutionQuotaRepository(s.db)
+
+	quota, err := repo.Create(&create)
+	if err != nil {
+		log.Printf("Error creating execution quota: %v", err)
+		audit.GetLogger().LogConfigChange(r, "execution_quota", "create", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to create quota: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "execution_quota", "create", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(quota)
+}
+
+// handleUpdateQuota godoc
+// @Summary Update an execution quota
+// @Description Update the limits of an execution quota
+// @Tags Quotas
+// @Accept json
+// @Produce json
+// @Param id path int true "Quota ID"
+// @Param quota body models.ExecutionQuotaUpdate true "Limits to update"
+// @Success 200 {object} models.ExecutionQuota
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /quotas/{id} [put]
+func (s *Server) handleUpdateQuota(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid quota ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.ExecutionQuotaUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewExecutionQuotaRepository(s.db)
+
+	quota, err := repo.Update(id, &update)
+	if err != nil {
+		log.Printf("Error updating execution quota: %v", err)
+		audit.GetLogger().LogConfigChange(r, "execution_quota", "update", audit.OutcomeFailure)
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Error(w, "Quota not found", http.StatusNotFound)
+			return
+		}
+		apierror.Error(w, "Failed to update quota: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "execution_quota", "update", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quota)
+}
+
+// handleDeleteQuota godoc
+// @Summary Delete an execution quota
+// @Description Delete an execution quota
+// @Tags Quotas
+// @Param id path int true "Quota ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /quotas/{id} [delete]
+func (s *Server) handleDeleteQuota(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid quota ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewExecutionQuotaRepository(s.db)
+
+	if err := repo.Delete(id); err != nil {
+		log.Printf("Error deleting execution quota: %v", err)
+		apierror.Error(w, "Quota not found", http.StatusNotFound)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "execution_quota", "delete", audit.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
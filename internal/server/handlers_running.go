@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+)
+
+// runningExecution is a registered execution in progress
+type runningExecution struct {
+	info   models.RunningExecution
+	cancel context.CancelFunc
+}
+
+// executionRegistry tracks the command and script executions in progress, so they can be
+// listed and cancelled
+type executionRegistry struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[int64]*runningExecution
+}
+
+// add registers an execution cancelled by cancel and returns its ID
+func (e *executionRegistry) add(info models.RunningExecution, cancel context.CancelFunc) int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.entries == nil {
+		e.entries = make(map[int64]*runningExecution)
+	}
+	e.nextID++
+	info.ID = e.nextID
+	e.entries[info.ID] = &runningExecution{info: info, cancel: cancel}
+	return info.ID
+}
+
+// remove drops an execution that ended
+func (e *executionRegistry) remove(id int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.entries, id)
+}
+
+// list returns the executions in progress, oldest first
+func (e *executionRegistry) list() []models.RunningExecution {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	executions := make([]models.RunningExecution, 0, len(e.entries))
+	for _, entry := range e.entries {
+		info := entry.info
+		info.ElapsedMs = now.Sub(info.StartedAt).Milliseconds()
+		executions = append(executions, info)
+	}
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].ID < executions[j].ID
+	})
+	return executions
+}
+
+// cancel cancels an execution in progress
+// Returns false if no execution has the ID
+func (e *executionRegistry) cancel(id int64) (models.RunningExecution, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry, ok := e.entries[id]
+	if !ok {
+		return models.RunningExecution{}, false
+	}
+	entry.cancel()
+	return entry.info, true
+}
+
+// startExecution registers a command or script about to run and publishes its start to the
+// activity stream
+// It returns the context to run it with, cancelled when an admin cancels the execution, and
+// the function to call once it ends.
+func (s *Server) startExecution(parent context.Context, r *http.Request, exec notify.Execution) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	info := models.RunningExecution{
+		Kind:      exec.Kind,
+		Command:   exec.Command,
+		Script:    exec.ScriptName,
+		Server:    exec.Server,
+		User:      exec.User,
+		StartedBy: callerIdentity(r),
+		StartedAt: time.Now().UTC(),
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	}
+	id := s.running.add(info, cancel)
+
+	s.activity.publish(models.ActivityEvent{
+		Type:      models.ActivityExecutionStarted,
+		Time:      info.StartedAt,
+		Actor:     info.StartedBy,
+		Kind:      info.Kind,
+		Command:   info.Command,
+		Script:    info.Script,
+		Server:    info.Server,
+		User:      info.User,
+		RequestID: info.RequestID,
+	})
+
+	return ctx, func() {
+		s.running.remove(id)
+		cancel()
+	}
+}
+
+// handleListRunningExecutions godoc
+// @Summary List running executions
+// @Description List the commands and scripts (including streaming and scheduled runs) running now, oldest first, with their target, user, who started them and for how long they have run. Executions waiting for a slot are not listed; see /executions/queue. Requires admin access.
+// @Tags System
+// @Produce json
+// @Success 200 {array} models.RunningExecution
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /executions/running [get]
+func (s *Server) handleListRunningExecutions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.running.list())
+}
+
+// handleCancelRunningExecution godoc
+// @Summary Cancel a running execution
+// @Description Cancel a running command or script, killing its process or closing its SSH session. The execution ends as failed and is recorded in history with what it printed so far. Requires admin access.
+// @Tags System
+// @Param id path int true "Running execution ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /executions/running/{id} [delete]
+func (s *Server) handleCancelRunningExecution(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid execution ID", http.StatusBadRequest)
+		return
+	}
+	info, ok := s.running.cancel(id)
+	if !ok {
+		apierror.Error(w, "Running execution not found", http.StatusNotFound)
+		return
+	}
+
+	target := info.Command
+	if info.Kind == notify.KindScript {
+		target = info.Script
+	}
+	log.Printf("Execution %d of %s on %s cancelled by admin", id, info.StartedBy, info.Server)
+	audit.GetLogger().LogExecutionCancel(r, info.Kind, target, info.User, info.Server, map[string]string{
+		"action":       "cancel",
+		"execution_id": strconv.FormatInt(id, 10),
+		"started_by":   info.StartedBy,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
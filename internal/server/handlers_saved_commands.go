@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// placeholderNameRegex matches the name of a {{name}} placeholder of a saved command
+var placeholderNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// savedCommandFields are the fields of a saved command execution that come from the saved command
+var savedCommandFields = []string{
+	"command", "user", "is_remote", "server_id", "server_name", "server_group", "server_ref",
+	"ssh_key_id", "ssh_key_name", "ssh_key_group", "ssh_key_ref", "kubernetes", "save_as",
+}
+
+// savedCommandKey is the context key for the ID of the saved command an execution runs
+type savedCommandKey struct{}
+
+// savedCommandFromContext returns the ID of the saved command the request executes
+func savedCommandFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(savedCommandKey{}).(int64)
+	return id, ok
+}
+
+// validateCommandPlaceholders checks the placeholders of a saved command
+// Placeholders are used outside quotes, and each declaration has a distinct, valid name used
+// in the command.
+func validateCommandPlaceholders(command string, placeholders []models.CommandPlaceholder) error {
+	if quoted := executor.QuotedCommandPlaceholders(command); len(quoted) > 0 {
+		return fmt.Errorf("placeholder %q is inside quotes; values are quoted when the command runs, so write it unquoted as {{%s}}", quoted[0], quoted[0])
+	}
+
+	used := make(map[string]bool)
+	for _, name := range executor.FindCommandPlaceholders(command) {
+		used[name] = true
+	}
+
+	declared := make(map[string]bool)
+	for _, p := range placeholders {
+		if !placeholderNameRegex.MatchString(p.Name) {
+			return fmt.Errorf("invalid placeholder name %q (use letters, digits and underscores)", p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("placeholder %q is declared more than once", p.Name)
+		}
+		if !used[p.Name] {
+			return fmt.Errorf("placeholder %q is not used in the command as {{%s}}", p.Name, p.Name)
+		}
+		declared[p.Name] = true
+	}
+	return nil
+}
+
+// resolveSavedCommand fills in the placeholders of a saved command with values, else their
+// declared defaults, quoting each for the shell
+// It returns the resolved command, or why the values do not fit the command.
+func resolveSavedCommand(cmd *models.SavedCommand, values map[string]string) (string, error) {
+	names := executor.FindCommandPlaceholders(cmd.Command)
+	used := make(map[string]bool, len(names))
+	for _, name := range names {
+		used[name] = true
+	}
+	for name := range values {
+		if !used[name] {
+			return "", fmt.Errorf("the command has no placeholder %q", name)
+		}
+	}
+
+	defaults := make(map[string]string, len(cmd.Placeholders))
+	for _, p := range cmd.Placeholders {
+		defaults[p.Name] = p.Default
+	}
+
+	resolved := make(map[string]string, len(names))
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			value = defaults[name]
+		}
+		if !ok && value == "" {
+			return "", fmt.Errorf("a value for placeholder %q is required", name)
+		}
+		resolved[name] = value
+	}
+	return executor.RenderCommandPlaceholders(cmd.Command, resolved), nil
+}
+
+// handleExecuteSavedCommand godoc
+// @Summary Execute a saved command
+// @Description Run a saved command with its placeholders (names in double braces) filled in from values, else their declared defaults. Each value is single-quoted, so it reaches the command as one shell word. The command, user and target are the saved command's; other fields are execution options as for POST /commands/execute (sudo_password, ssh_password, stdin, workdir, retries, ...). The execution goes through the same checks, queue and history as POST /commands/execute, and history records the resolved command.
+// @Tags Saved Commands
+// @Accept json
+// @Produce json
+// @Param id path int true "Saved Command ID"
+// @Param request body models.SavedCommandExecution true "Placeholder values and execution options"
+// @Success 200 {object} models.CommandResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /saved-commands/{id}/execute [post]
+func (s *Server) handleExecuteSavedCommand(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid command ID", http.StatusBadRequest)
+		return
+	}
+
+	cmd, err := repository.NewSavedCommandRepository(s.db).GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching saved command: %v", err)
+		apierror.Error(w, "Saved command not found", http.StatusNotFound)
+		return
+	}
+
+	// The body is optional
+	body := map[string]json.RawMessage{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var req models.SavedCommandExecution
+	if raw, ok := body["values"]; ok {
+		if err := json.Unmarshal(raw, &req.Values); err != nil {
+			apierror.InvalidField(w, "values", "values must be an object of strings")
+			return
+		}
+	}
+
+	command, err := resolveSavedCommand(cmd, req.Values)
+	if err != nil {
+		apierror.InvalidField(w, "values", err.Error())
+		return
+	}
+
+	delete(body, "values")
+	for _, field := range savedCommandFields {
+		delete(body, field)
+	}
+	execution := map[string]any{
+		"command":   command,
+		"user":      cmd.User,
+		"is_remote": cmd.IsRemote,
+	}
+	if cmd.IsRemote {
+		execution["server_id"] = cmd.ServerID
+		execution["ssh_key_id"] = cmd.SSHKeyID
+	}
+	for field, value := range execution {
+		if body[field], err = json.Marshal(value); err != nil {
+			apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	execReq := r.Clone(context.WithValue(r.Context(), savedCommandKey{}, cmd.ID))
+	execReq.Body = io.NopCloser(bytes.NewReader(payload))
+	execReq.ContentLength = int64(len(payload))
+	s.handleExecuteCommand(w, execReq)
+}
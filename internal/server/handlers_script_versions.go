@@ -0,0 +1,272 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/diff"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// visibleScript loads the bash script named by the {id} route variable
+// Writes an error and returns nil if the ID is invalid or the caller cannot view the script
+func (s *Server) visibleScript(w http.ResponseWriter, r *http.Request) (*repository.BashScriptRepository, *models.BashScript) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid bash script ID", http.StatusBadRequest)
+		return nil, nil
+	}
+
+	repo := repository.NewBashScriptRepository(s.db)
+	script, err := repo.GetByID(id)
+	if err != nil || !s.groupAccess(r).canView(models.ResourceTypeBashScripts, script.Group) {
+		apierror.Error(w, "Bash script not found", http.StatusNotFound)
+		return nil, nil
+	}
+
+	return repo, script
+}
+
+// scriptVersion loads a version of a script, writing 404 if it does not exist
+func scriptVersion(w http.ResponseWriter, repo *repository.BashScriptRepository, scriptID int64, value string) *models.BashScriptVersion {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		apierror.Error(w, "Invalid version", http.StatusBadRequest)
+		return nil
+	}
+
+	version, err := repo.GetVersion(scriptID, number)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Error(w, "Version not found", http.StatusNotFound)
+			return nil
+		}
+		log.Printf("Error fetching bash script version: %v", err)
+		apierror.Error(w, "Failed to fetch version", http.StatusInternalServerError)
+		return nil
+	}
+
+	return version
+}
+
+// handleListScriptVersions godoc
+// @Summary List bash script versions
+// @Description List the version history of a bash script, newest first. Content is not included
+// @Tags Bash Scripts
+// @Produce json
+// @Param id path int true "Bash Script ID"
+// @Success 200 {array} models.BashScriptVersion
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /bash-scripts/{id}/versions [get]
+func (s *Server) handleListScriptVersions(w http.ResponseWriter, r *http.Request) {
+	repo, script := s.visibleScript(w, r)
+	if script == nil {
+		return
+	}
+
+	versions, err := repo.GetVersions(script.ID)
+	if err != nil {
+		log.Printf("Error fetching bash script versions: %v", err)
+		apierror.Error(w, "Failed to fetch versions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// handleGetScriptVersion godoc
+// @Summary Get a bash script version
+// @Description Get one version of a bash script, including its content
+// @Tags Bash Scripts
+// @Produce json
+// @Param id path int true "Bash Script ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.BashScriptVersion
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /bash-scripts/{id}/versions/{version} [get]
+func (s *Server) handleGetScriptVersion(w http.ResponseWriter, r *http.Request) {
+	repo, script := s.visibleScript(w, r)
+	if script == nil {
+		return
+	}
+
+	version := scriptVersion(w, repo, script.ID, mux.Vars(r)["version"])
+	if version == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version)
+}
+
+// handleDiffScriptVersions godoc
+// @Summary Diff bash script versions
+// @Description Unified diff of the content from one version of a bash script to another (the latest by default)
+// @Tags Bash Scripts
+// @Produce json
+// @Param id path int true "Bash Script ID"
+// @Param version path int true "Version to diff from"
+// @Param to query int false "Version to diff to (default: latest)"
+// @Success 200 {object} models.BashScriptDiff
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /bash-scripts/{id}/versions/{version}/diff [get]
+func (s *Server) handleDiffScriptVersions(w http.ResponseWriter, r *http.Request) {
+	repo, script := s.visibleScript(w, r)
+	if script == nil {
+		return
+	}
+
+	from := scriptVersion(w, repo, script.ID, mux.Vars(r)["version"])
+	if from == nil {
+		return
+	}
+
+	var to *models.BashScriptVersion
+	if value := r.URL.Query().Get("to"); value != "" {
+		to = scriptVersion(w, repo, script.ID, value)
+		if to == nil {
+			return
+		}
+	} else {
+		var err error
+		to, err = repo.GetLatestVersion(script.ID)
+		if err != nil {
+			log.Printf("Error fetching latest bash script version: %v", err)
+			apierror.Error(w, "Failed to fetch version", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.BashScriptDiff{
+		ScriptID:    script.ID,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Diff:        diff.Unified(fmt.Sprintf("version %d", from.Version), fmt.Sprintf("version %d", to.Version), from.Content, to.Content),
+	})
+}
+
+// handleRollbackScript godoc
+// @Summary Roll back a bash script
+// @Description Restore a bash script to an earlier version. The restored state is recorded as a new version
+// @Tags Bash Scripts
+// @Produce json
+// @Param id path int true "Bash Script ID"
+// @Param version path int true "Version to restore"
+// @Success 200 {object} models.BashScriptResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /bash-scripts/{id}/versions/{version}/rollback [post]
+func (s *Server) handleRollbackScript(w http.ResponseWriter, r *http.Request) {
+	repo, script := s.visibleScript(w, r)
+	if script == nil {
+		return
+	}
+
+	if rejectReadOnlyScript(w, script) {
+		return
+	}
+
+	version := scriptVersion(w, repo, script.ID, mux.Vars(r)["version"])
+	if version == nil {
+		return
+	}
+
+	// Rolling back may move the script into the group it was in at that version
+	if !s.groupAccess(r).canView(models.ResourceTypeBashScripts, version.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeBashScripts, version.Group, models.PermissionView)
+		return
+	}
+
+	restored, err := repo.Rollback(script.ID, version.Version)
+	if err != nil {
+		log.Printf("Error rolling back bash script: %v", err)
+		apierror.Error(w, "Failed to roll back bash script", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restored.ToResponse(true))
+}
+
+// handleDiffBashScript godoc
+// @Summary Diff a bash script
+// @Description GET: unified diff from an earlier version to the current content.
+// @Description POST: unified diff from the current content to proposed changes, to review an edit before saving it. Fields left empty are unchanged, as with PUT /bash-scripts/{id}
+// @Tags Bash Scripts
+// @Accept json
+// @Produce json
+// @Param id path int true "Bash Script ID"
+// @Param version query int false "Version to diff from (GET, required)"
+// @Param script body models.BashScriptUpdate false "Proposed changes (POST)"
+// @Success 200 {object} models.BashScriptDiff
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /bash-scripts/{id}/diff [get]
+// @Router /bash-scripts/{id}/diff [post]
+func (s *Server) handleDiffBashScript(w http.ResponseWriter, r *http.Request) {
+	repo, script := s.visibleScript(w, r)
+	if script == nil {
+		return
+	}
+
+	current, err := repo.GetLatestVersion(script.ID)
+	if err != nil {
+		log.Printf("Error fetching latest bash script version: %v", err)
+		apierror.Error(w, "Failed to fetch version", http.StatusInternalServerError)
+		return
+	}
+
+	result := models.BashScriptDiff{ScriptID: script.ID}
+	if r.Method == http.MethodPost {
+		var proposed models.BashScriptUpdate
+		if err := json.NewDecoder(r.Body).Decode(&proposed); err != nil {
+			apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		// Empty content keeps the current content, as an update would
+		content := script.Content
+		if proposed.Content != "" {
+			content = proposed.Content
+		}
+
+		result.FromVersion = current.Version
+		result.Diff = diff.Unified("current", "proposed", script.Content, content)
+	} else {
+		value := r.URL.Query().Get("version")
+		if value == "" {
+			apierror.Error(w, "version query parameter is required", http.StatusBadRequest)
+			return
+		}
+		from := scriptVersion(w, repo, script.ID, value)
+		if from == nil {
+			return
+		}
+
+		result.FromVersion = from.Version
+		result.ToVersion = current.Version
+		result.Diff = diff.Unified(fmt.Sprintf("version %d", from.Version), "current", from.Content, script.Content)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,519 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// groupSSHKey returns the default SSH key of a stored server's group, or nil when the group
+// has none or the caller may not use it
+func (s *Server) groupSSHKey(server *models.Server, access *groupAccess) *models.SSHKey {
+	if server.Source == "vault" {
+		return nil
+	}
+	group, err := repository.NewServerGroupRepository(s.db).GetByName(server.Group)
+	if err != nil || group.DefaultSSHKeyID == nil {
+		return nil
+	}
+	return s.serverGroupSSHKey(group, access)
+}
+
+// serverGroupSSHKey returns the default SSH key of a server group, or nil when it has none
+// or the caller may not use it
+func (s *Server) serverGroupSSHKey(group *models.ServerGroup, access *groupAccess) *models.SSHKey {
+	if group.DefaultSSHKeyID == nil {
+		return nil
+	}
+	key, err := repository.NewSSHKeyRepository(s.db).GetByID(*group.DefaultSSHKeyID)
+	if err != nil {
+		log.Printf("Warning: failed to load default SSH key of server group %q: %v", group.Name, err)
+		return nil
+	}
+	if !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+		return nil
+	}
+	return key
+}
+
+// serverGroupFromRequest returns the server group named by the id path variable, writing the error if there is none
+// Groups the caller cannot view are reported as missing
+func (s *Server) serverGroupFromRequest(w http.ResponseWriter, r *http.Request) (*models.ServerGroup, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid server group ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	group, err := repository.NewServerGroupRepository(s.db).GetByID(id)
+	if err != nil || !s.groupAccess(r).canView(models.ResourceTypeServers, group.Name) {
+		apierror.Error(w, "Server group not found", http.StatusNotFound)
+		return nil, false
+	}
+	return group, true
+}
+
+// validateServerGroupFields checks the name and default credentials of a server group, writing the error if invalid
+func validateServerGroupFields(w http.ResponseWriter, name, username string, port int) bool {
+	if name != "" {
+		if err := validation.ValidateVaultGroupName(name); err != nil {
+			apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
+			return false
+		}
+	}
+	if username != "" {
+		if err := validation.ValidateUsername(username); err != nil {
+			apierror.InvalidField(w, "default_username", fmt.Sprintf("Invalid default username: %v", err))
+			return false
+		}
+	}
+	if port > 0 {
+		if err := validation.ValidatePort(port); err != nil {
+			apierror.InvalidField(w, "default_port", fmt.Sprintf("Invalid default port: %v", err))
+			return false
+		}
+	}
+	return true
+}
+
+// handleListServerGroupEntities godoc
+// @Summary List server groups
+// @Description List the server groups the caller can view, with their descriptions, default credentials and number of servers
+// @Tags Server Groups
+// @Produce json
+// @Success 200 {array} models.ServerGroup
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups [get]
+func (s *Server) handleListServerGroupEntities(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewServerGroupRepository(s.db)
+
+	groups, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching server groups: %v", err)
+		apierror.Error(w, "Failed to fetch server groups", http.StatusInternalServerError)
+		return
+	}
+
+	groups = filterVisible(s.groupAccess(r), models.ResourceTypeServers, groups, func(g *models.ServerGroup) string { return g.Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// handleCreateServerGroup godoc
+// @Summary Create a server group
+// @Description Create a server group. Servers added to it without a username or port take its default_username and default_port, and executions on its servers that select no SSH key use its default_ssh_key_id.
+// @Tags Server Groups
+// @Accept json
+// @Produce json
+// @Param group body models.ServerGroupCreate true "Server group to create"
+// @Success 201 {object} models.ServerGroup
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups [post]
+func (s *Server) handleCreateServerGroup(w http.ResponseWriter, r *http.Request) {
+	var create models.ServerGroupCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(create.Name) == "" {
+		apierror.InvalidField(w, "name", "Name is required")
+		return
+	}
+	if !validateServerGroupFields(w, strings.TrimSpace(create.Name), create.DefaultUsername, create.DefaultPort) {
+		return
+	}
+
+	repo := repository.NewServerGroupRepository(s.db)
+
+	group, err := repo.Create(&create)
+	if err != nil {
+		log.Printf("Error creating server group: %v", err)
+		audit.GetLogger().LogConfigChange(r, "server_group", "create", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to create server group: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "server_group", "create", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+// handleGetServerGroup godoc
+// @Summary Get a server group
+// @Description Get a server group by ID
+// @Tags Server Groups
+// @Produce json
+// @Param id path int true "Server Group ID"
+// @Success 200 {object} models.ServerGroup
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id} [get]
+func (s *Server) handleGetServerGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.serverGroupFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// handleUpdateServerGroup godoc
+// @Summary Update a server group
+// @Description Update a server group's name, description or default credentials. Renaming a group moves its servers and server group permissions along; the default group cannot be renamed.
+// @Tags Server Groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Server Group ID"
+// @Param group body models.ServerGroupUpdate true "Fields to update"
+// @Success 200 {object} models.ServerGroup
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id} [put]
+func (s *Server) handleUpdateServerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid server group ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.ServerGroupUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var username string
+	var port int
+	if update.DefaultUsername != nil {
+		username = *update.DefaultUsername
+	}
+	if update.DefaultPort != nil {
+		port = *update.DefaultPort
+	}
+	if !validateServerGroupFields(w, strings.TrimSpace(update.Name), username, port) {
+		return
+	}
+
+	repo := repository.NewServerGroupRepository(s.db)
+
+	group, err := repo.Update(id, &update)
+	if err != nil {
+		log.Printf("Error updating server group: %v", err)
+		audit.GetLogger().LogConfigChange(r, "server_group", "update", audit.OutcomeFailure)
+		if strings.Contains(err.Error(), "server group not found") {
+			apierror.Error(w, "Server group not found", http.StatusNotFound)
+			return
+		}
+		apierror.Error(w, "Failed to update server group: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "server_group", "update", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// handleDeleteServerGroup godoc
+// @Summary Delete a server group
+// @Description Delete an empty server group along with its server group permissions. The default group cannot be deleted.
+// @Tags Server Groups
+// @Param id path int true "Server Group ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id} [delete]
+func (s *Server) handleDeleteServerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid server group ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewServerGroupRepository(s.db)
+
+	if err := repo.Delete(id); err != nil {
+		log.Printf("Error deleting server group: %v", err)
+		audit.GetLogger().LogConfigChange(r, "server_group", "delete", audit.OutcomeFailure)
+		switch {
+		case strings.Contains(err.Error(), "server group not found"):
+			apierror.Error(w, "Server group not found", http.StatusNotFound)
+		case strings.Contains(err.Error(), "still has"):
+			apierror.Error(w, "Failed to delete server group: "+err.Error(), http.StatusConflict)
+		default:
+			apierror.Error(w, "Failed to delete server group: "+err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "server_group", "delete", audit.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListServerGroupServers godoc
+// @Summary List the servers of a server group
+// @Description List the servers in a server group
+// @Tags Server Groups
+// @Produce json
+// @Param id path int true "Server Group ID"
+// @Success 200 {array} models.Server
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id}/servers [get]
+func (s *Server) handleListServerGroupServers(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.serverGroupFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	servers, err := repository.NewServerRepository(s.db).GetByGroup(group.Name)
+	if err != nil {
+		log.Printf("Error fetching servers of group: %v", err)
+		apierror.Error(w, "Failed to fetch servers", http.StatusInternalServerError)
+		return
+	}
+
+	if servers == nil {
+		servers = []*models.Server{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(servers)
+}
+
+// handleAddServerGroupServers godoc
+// @Summary Add servers to a server group
+// @Description Move servers into a server group. Either all of them are moved or none.
+// @Tags Server Groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Server Group ID"
+// @Param members body models.ServerGroupMembers true "Servers to add"
+// @Success 200 {array} models.Server
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id}/servers [post]
+func (s *Server) handleAddServerGroupServers(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.serverGroupFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var members models.ServerGroupMembers
+	if err := json.NewDecoder(r.Body).Decode(&members); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(members.ServerIDs) == 0 {
+		apierror.InvalidField(w, "server_ids", "At least one server ID is required")
+		return
+	}
+
+	// Moving a server needs view access to the group it leaves, like updating it
+	access := s.groupAccess(r)
+	serverRepo := repository.NewServerRepository(s.db)
+	for _, serverID := range members.ServerIDs {
+		server, err := serverRepo.GetByID(serverID)
+		if err != nil || !access.canView(models.ResourceTypeServers, server.Group) {
+			apierror.Error(w, fmt.Sprintf("Server %d not found", serverID), http.StatusNotFound)
+			return
+		}
+	}
+
+	if err := repository.NewServerGroupRepository(s.db).AddServers(group.ID, members.ServerIDs); err != nil {
+		log.Printf("Error adding servers to group: %v", err)
+		apierror.Error(w, "Failed to add servers: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.handleListServerGroupServers(w, r)
+}
+
+// handleRemoveServerGroupServer godoc
+// @Summary Remove a server from a server group
+// @Description Move a server of the group back to the default group
+// @Tags Server Groups
+// @Param id path int true "Server Group ID"
+// @Param serverId path int true "Server ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id}/servers/{serverId} [delete]
+func (s *Server) handleRemoveServerGroupServer(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.serverGroupFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	serverID, err := strconv.ParseInt(mux.Vars(r)["serverId"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	if group.Name == models.DefaultServerGroup {
+		apierror.Error(w, "Servers cannot be removed from the default group; add them to another group instead", http.StatusBadRequest)
+		return
+	}
+
+	if err := repository.NewServerGroupRepository(s.db).RemoveServer(group.ID, serverID); err != nil {
+		log.Printf("Error removing server from group: %v", err)
+		apierror.Error(w, "Server not found in group", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleExecuteServerGroupCommand godoc
+// @Summary Execute a command on a server group
+// @Description Run a command on every server of the group at once, as POST /commands/execute would on each. server_id, server_name, server_group and server_ref are ignored. Each server counts as one execution against the caller's quota and reports the status and result or error it would have returned on its own.
+// @Tags Server Groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Server Group ID"
+// @Param command body models.CommandExecution true "Command execution request"
+// @Success 200 {array} models.ServerGroupExecutionResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id}/commands/execute [post]
+func (s *Server) handleExecuteServerGroupCommand(w http.ResponseWriter, r *http.Request) {
+	var exec models.CommandExecution
+	s.executeOnServerGroup(w, r, &exec, func(w http.ResponseWriter, r *http.Request, serverID int64) any {
+		exec := exec
+		exec.IsRemote, exec.ServerID = true, &serverID
+		exec.ServerName, exec.ServerGroup, exec.ServerRef, exec.Kubernetes = "", "", "", nil
+		if result := s.executeCommand(w, r, &exec); result != nil {
+			return result
+		}
+		return nil
+	})
+}
+
+// handleExecuteServerGroupScript godoc
+// @Summary Execute a script on a server group
+// @Description Run a bash script on every server of the group at once, as POST /bash-scripts/execute would on each. server_id, server_name, server_group and server_ref are ignored. Each server counts as one execution against the caller's quota and reports the status and result or error it would have returned on its own.
+// @Tags Server Groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Server Group ID"
+// @Param script body models.ScriptExecution true "Script execution request"
+// @Success 200 {array} models.ServerGroupExecutionResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /server-groups/{id}/bash-scripts/execute [post]
+func (s *Server) handleExecuteServerGroupScript(w http.ResponseWriter, r *http.Request) {
+	var exec models.ScriptExecution
+	s.executeOnServerGroup(w, r, &exec, func(w http.ResponseWriter, r *http.Request, serverID int64) any {
+		exec := exec
+		exec.IsRemote, exec.ServerID = true, &serverID
+		exec.ServerName, exec.ServerGroup, exec.ServerRef, exec.Kubernetes = "", "", "", nil
+		if result := s.executeScript(w, r, &exec); result != nil {
+			return result
+		}
+		return nil
+	})
+}
+
+// maxGroupExecutions is how many servers of a group one group execution runs on at once
+const maxGroupExecutions = 10
+
+// executeOnServerGroup decodes the request body into exec and runs it on every server of the group
+// through run, on up to maxGroupExecutions servers at once
+// Each server's execution counts against the caller's quota and goes through the same checks, queue,
+// history and notifications as a single execution; run answers refusals on its writer and returns
+// nil, or returns the execution's result.
+func (s *Server) executeOnServerGroup(w http.ResponseWriter, r *http.Request, exec any, run func(w http.ResponseWriter, r *http.Request, serverID int64) any) {
+	group, ok := s.serverGroupFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if !s.groupAccess(r).canExecute(models.ResourceTypeServers, group.Name) {
+		denyGroupAccess(w, r, models.ResourceTypeServers, group.Name, models.PermissionExecute)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(exec); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	servers, err := repository.NewServerRepository(s.db).GetByGroup(group.Name)
+	if err != nil {
+		log.Printf("Error fetching servers of group: %v", err)
+		apierror.Error(w, "Failed to fetch servers", http.StatusInternalServerError)
+		return
+	}
+	if len(servers) == 0 {
+		apierror.Error(w, fmt.Sprintf("Server group %q has no servers", group.Name), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]models.ServerGroupExecutionResult, len(servers))
+	slots := make(chan struct{}, maxGroupExecutions)
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		results[i] = models.ServerGroupExecutionResult{ServerID: server.ID, Server: serverLabel(server)}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(result *models.ServerGroupExecutionResult) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			// Refusals are collected like the responses of internal callers of the API
+			refusal := &bridgeWriter{header: make(http.Header)}
+			req, release, ok := s.quotas.Admit(refusal, r)
+			if ok {
+				defer release()
+				if value := run(refusal, req, result.ServerID); value != nil {
+					result.Status = http.StatusOK
+					result.Result, _ = json.Marshal(value)
+					return
+				}
+			}
+			result.Status = refusal.status
+			result.Result = json.RawMessage(bytes.TrimSpace(refusal.body.Bytes()))
+			if len(result.Result) == 0 {
+				result.Result = json.RawMessage("null")
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
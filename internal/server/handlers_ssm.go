@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/ssm"
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// ssmExecutor returns the executor running commands on a server through AWS Systems Manager
+// The server's stored credentials are used, else the environment's or the instance role of web-cli.
+func (s *Server) ssmExecutor(server *models.Server) *executor.SSMExecutor {
+	client := ssm.New(ssm.Config{
+		Region:          server.SSMRegion,
+		InstanceID:      server.SSMInstance(),
+		AccessKeyID:     server.SSMAccessKeyID,
+		SecretAccessKey: server.SSMSecretAccessKey,
+		RoleARN:         server.SSMRoleARN,
+		Endpoint:        s.ssmEndpoint,
+	})
+	return executor.NewSSMExecutor(func(ctx context.Context, script string, timeout time.Duration) (string, string, int, error) {
+		result, err := client.Run(ctx, script, timeout)
+		if result == nil {
+			return "", "", -1, err
+		}
+		return result.Stdout, result.Stderr, result.ExitCode, err
+	})
+}
+
+// validateServerTransport checks the transport of a server and the settings it needs
+// It returns the invalid field and why, or "" when the server is valid.
+func validateServerTransport(server *models.Server) (string, error) {
+	switch server.Transport {
+	case "", models.ServerTransportSSH, models.ServerTransportSSM:
+	case models.ServerTransportAgent:
+		// The agent connects with the server's name
+		if server.Name == "" {
+			return "name", fmt.Errorf("name is required for the agent transport")
+		}
+	default:
+		return "transport", fmt.Errorf("transport must be %s, %s or %s", models.ServerTransportSSH, models.ServerTransportSSM, models.ServerTransportAgent)
+	}
+
+	if server.SSMInstanceID != "" {
+		if err := validation.ValidateEC2InstanceID(server.SSMInstanceID); err != nil {
+			return "ssm_instance_id", err
+		}
+	}
+	if server.SSMRegion != "" {
+		if err := validation.ValidateAWSRegion(server.SSMRegion); err != nil {
+			return "ssm_region", err
+		}
+	}
+	if server.SSMRoleARN != "" {
+		if err := validation.ValidateAWSRoleARN(server.SSMRoleARN); err != nil {
+			return "ssm_role_arn", err
+		}
+	}
+	if server.SSMAccessKeyID != "" && server.SSMSecretAccessKey == "" {
+		return "ssm_secret_access_key", fmt.Errorf("ssm_secret_access_key is required with ssm_access_key_id")
+	}
+	if server.SSMAccessKeyID == "" && server.SSMSecretAccessKey != "" {
+		return "ssm_access_key_id", fmt.Errorf("ssm_access_key_id is required with ssm_secret_access_key")
+	}
+
+	if server.Transport != models.ServerTransportSSM {
+		return "", nil
+	}
+	if server.SSMRegion == "" {
+		return "ssm_region", fmt.Errorf("ssm_region is required for the ssm transport")
+	}
+	if server.SSMInstance() == "" {
+		return "ssm_instance_id", fmt.Errorf("ssm_instance_id is required for the ssm transport unless the server is discovered from EC2")
+	}
+	return "", nil
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// SystemUserResponse is a login user of the machine
+// @Description Login user discovered from /etc/passwd
+type SystemUserResponse struct {
+	Username string `json:"username" example:"deploy"`
+	UID      int    `json:"uid" example:"1001"`
+	GID      int    `json:"gid" example:"1001"`
+	Name     string `json:"name" example:"Deploy User"`
+	HomeDir  string `json:"home_dir" example:"/home/deploy"`
+	Shell    string `json:"shell" example:"/bin/bash"`
+	Imported bool   `json:"imported" example:"false"` // Already listed under local users
+}
+
+// LocalUserImportRequest lists the discovered login users to add as local users
+// @Description Login users to import as local users
+type LocalUserImportRequest struct {
+	Names []string `json:"names"` // Empty to import every discovered user
+}
+
+// LocalUserImportResponse reports the outcome of an import of login users
+// @Description Local users created by an import, and the names already listed
+type LocalUserImportResponse struct {
+	Imported []*models.LocalUser `json:"imported"`
+	Skipped  []string            `json:"skipped"` // Already listed under local users
+}
+
+// systemUsers returns the login users within the configured UID range
+func (s *Server) systemUsers() ([]executor.SystemUser, error) {
+	file := s.passwdFile
+	if file == "" {
+		file = executor.PasswdFile
+	}
+	minUID, maxUID := 1000, 60000
+	if s.config != nil && s.config.LocalUserMaxUID > 0 {
+		minUID, maxUID = s.config.LocalUserMinUID, s.config.LocalUserMaxUID
+	}
+	return executor.ListSystemUsers(file, minUID, maxUID)
+}
+
+// localUserNames returns the names listed under local users
+func (s *Server) localUserNames() (map[string]bool, error) {
+	users, err := repository.NewLocalUserRepository(s.db).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(users))
+	for _, user := range users {
+		names[user.Name] = true
+	}
+	return names, nil
+}
+
+// handleListSystemUsers godoc
+// @Summary List login users of the machine
+// @Description Discover the login users of the machine from /etc/passwd, within the configured UID range (LOCAL_USER_MIN_UID to LOCAL_USER_MAX_UID) and without nologin or false shells, marking the ones already listed under local users. Requires admin access.
+// @Tags System
+// @Produce json
+// @Success 200 {array} SystemUserResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /system/users [get]
+func (s *Server) handleListSystemUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.systemUsers()
+	if err != nil {
+		log.Printf("Error reading system users: %v", err)
+		apierror.Error(w, "Failed to read system users", http.StatusInternalServerError)
+		return
+	}
+	imported, err := s.localUserNames()
+	if err != nil {
+		log.Printf("Error fetching local users: %v", err)
+		apierror.Error(w, "Failed to fetch local users", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]SystemUserResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, SystemUserResponse{
+			Username: user.Name,
+			UID:      user.UID,
+			GID:      user.GID,
+			Name:     user.FullName,
+			HomeDir:  user.Home,
+			Shell:    user.Shell,
+			Imported: imported[user.Name],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleImportLocalUsers godoc
+// @Summary Import login users as local users
+// @Description Add login users discovered from /etc/passwd (see GET /system/users) as local users. Names that are not discovered login users are rejected; users already listed are skipped. An empty list imports every discovered user. Requires admin access.
+// @Tags Local Users
+// @Accept json
+// @Produce json
+// @Param request body LocalUserImportRequest true "Login users to import"
+// @Success 200 {object} LocalUserImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /local-users/import [post]
+func (s *Server) handleImportLocalUsers(w http.ResponseWriter, r *http.Request) {
+	var req LocalUserImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	users, err := s.systemUsers()
+	if err != nil {
+		log.Printf("Error reading system users: %v", err)
+		apierror.Error(w, "Failed to read system users", http.StatusInternalServerError)
+		return
+	}
+	discovered := make(map[string]bool, len(users))
+	for _, user := range users {
+		discovered[user.Name] = true
+	}
+
+	names := req.Names
+	if len(names) == 0 {
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+	}
+	for _, name := range names {
+		if !discovered[name] {
+			apierror.InvalidField(w, "names", name+" is not a login user of this machine")
+			return
+		}
+	}
+
+	existing, err := s.localUserNames()
+	if err != nil {
+		log.Printf("Error fetching local users: %v", err)
+		apierror.Error(w, "Failed to fetch local users", http.StatusInternalServerError)
+		return
+	}
+
+	repo := repository.NewLocalUserRepository(s.db)
+	response := LocalUserImportResponse{Imported: []*models.LocalUser{}, Skipped: []string{}}
+	for _, name := range names {
+		if existing[name] {
+			response.Skipped = append(response.Skipped, name)
+			continue
+		}
+		user, err := repo.Create(&models.LocalUserCreate{Name: name})
+		if err != nil {
+			log.Printf("Error creating local user: %v", err)
+			apierror.Error(w, "Failed to create local user", http.StatusInternalServerError)
+			return
+		}
+		existing[name] = true
+		response.Imported = append(response.Imported, user)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SudoTestRequest holds the optional password of a sudo test
+// @Description Sudo password to check, empty to only check passwordless sudo
+type SudoTestRequest struct {
+	SudoPassword string `json:"sudo_password,omitempty"`
+}
+
+// SudoTestResponse reports whether local commands can run as a user through sudo
+// @Description Outcome of a sudo test for a local user
+type SudoTestResponse struct {
+	User           string `json:"user" example:"deploy"`
+	SudoRequired   bool   `json:"sudo_required" example:"true"` // False for the server's own user
+	Passwordless   bool   `json:"passwordless" example:"false"`
+	PasswordTested bool   `json:"password_tested" example:"true"`
+	PasswordWorks  bool   `json:"password_works" example:"true"`
+	Output         string `json:"output,omitempty" example:"sudo: a password is required"` // What sudo printed when a check failed
+	Message        string `json:"message" example:"sudo works for deploy with the password"`
+}
+
+// handleTestLocalUserSudo godoc
+// @Summary Test sudo for a local user
+// @Description Check that local commands can run as a local user through sudo before real commands fail: runs true with sudo -n (passwordless), and with sudo -S when a password is given. Only true is run. Requires admin access.
+// @Tags Local Users
+// @Accept json
+// @Produce json
+// @Param id path int true "Local User ID"
+// @Param request body SudoTestRequest false "Sudo password to check"
+// @Success 200 {object} SudoTestResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /local-users/{id}/test-sudo [post]
+func (s *Server) handleTestLocalUserSudo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional
+	var req SudoTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	localUser, err := repository.NewLocalUserRepository(s.db).GetByID(id)
+	if err != nil {
+		apierror.Error(w, "Local user not found", http.StatusNotFound)
+		return
+	}
+
+	check, err := s.newLocalExecutor().CheckSudo(r.Context(), localUser.Name, s.sudoPassword(r, req.SudoPassword))
+	if err != nil {
+		audit.GetLogger().LogConfigChange(r, "local_user", "test_sudo", audit.OutcomeFailure)
+		apierror.Error(w, fmt.Sprintf("Cannot test sudo: %v", err), http.StatusBadRequest)
+		return
+	}
+	outcome := audit.OutcomeSuccess
+	if check.Required && !check.Passwordless && !check.PasswordWorks {
+		outcome = audit.OutcomeFailure
+	}
+	audit.GetLogger().LogConfigChange(r, "local_user", "test_sudo", outcome)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SudoTestResponse{
+		User:           localUser.Name,
+		SudoRequired:   check.Required,
+		Passwordless:   check.Passwordless,
+		PasswordTested: check.PasswordTested,
+		PasswordWorks:  check.PasswordWorks,
+		Output:         check.Output,
+		Message:        sudoTestMessage(localUser.Name, check),
+	})
+}
+
+// sudoTestMessage summarizes a sudo check
+func sudoTestMessage(name string, check *executor.SudoCheck) string {
+	switch {
+	case !check.Required:
+		return fmt.Sprintf("%s is the server's own user; commands run without sudo", name)
+	case check.Passwordless:
+		return fmt.Sprintf("Passwordless sudo works for %s", name)
+	case check.PasswordWorks:
+		return fmt.Sprintf("sudo works for %s with the password", name)
+	case check.PasswordTested:
+		return fmt.Sprintf("sudo as %s fails with the password; check the password and the sudoers rules", name)
+	default:
+		return fmt.Sprintf("Passwordless sudo does not work for %s; test again with a sudo password or add a NOPASSWD rule", name)
+	}
+}
@@ -2,14 +2,26 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
 	"github.com/pozgo/web-cli/internal/repository"
 	"github.com/pozgo/web-cli/internal/terminal"
+	"github.com/pozgo/web-cli/internal/validation"
 )
 
 var upgrader = websocket.Upgrader{
@@ -23,6 +35,97 @@ var upgrader = websocket.Upgrader{
 
 // handleTerminalWebSocket handles WebSocket connections for interactive terminal sessions
 func (s *Server) handleTerminalWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Reattach to a detached session instead of starting a new one
+	if sessionID := r.URL.Query().Get("session"); sessionID != "" {
+		s.resumeTerminalSession(w, r, sessionID)
+		return
+	}
+
+	// Determine which shell to use; only shells in the catalog are allowed
+	selected, ok := s.terminalShell(r, r.URL.Query().Get("shell"))
+	if !ok {
+		apierror.Error(w, "No shells are available", http.StatusServiceUnavailable)
+		return
+	}
+	shell := selected.Path
+
+	// Group permissions limit which SSH keys and servers the session may use
+	access := s.groupAccess(r)
+
+	// Optionally open the terminal straight into SSH on a configured server
+	var target *models.Server
+	targetName := "local"
+	if serverID := r.URL.Query().Get("serverId"); serverID != "" {
+		id, err := strconv.ParseInt(serverID, 10, 64)
+		if err != nil {
+			apierror.Error(w, "Invalid server ID", http.StatusBadRequest)
+			return
+		}
+		target, err = repository.NewServerRepository(s.db).GetByID(id)
+		if err != nil {
+			log.Printf("Error fetching server by ID: %v", err)
+			apierror.Error(w, "Server not found", http.StatusNotFound)
+			return
+		}
+		if !access.canExecute(models.ResourceTypeServers, target.Group) {
+			denyGroupAccess(w, r, models.ResourceTypeServers, target.Group, models.PermissionExecute)
+			return
+		}
+		if target.RequiresApproval {
+			apierror.Error(w, "Server requires approval for execution; interactive SSH sessions to it are not allowed", http.StatusForbidden)
+			return
+		}
+		targetName = sshTargetAlias(target)
+	}
+
+	// Optionally limit the generated SSH config to the servers of one group
+	var configGroup *models.ServerGroup
+	if groupID := r.URL.Query().Get("groupId"); groupID != "" {
+		id, err := strconv.ParseInt(groupID, 10, 64)
+		if err != nil {
+			apierror.Error(w, "Invalid server group ID", http.StatusBadRequest)
+			return
+		}
+		configGroup, err = repository.NewServerGroupRepository(s.db).GetByID(id)
+		if err != nil {
+			log.Printf("Error fetching server group by ID: %v", err)
+			apierror.Error(w, "Server group not found", http.StatusNotFound)
+			return
+		}
+		if !access.canExecute(models.ResourceTypeServers, configGroup.Name) {
+			denyGroupAccess(w, r, models.ResourceTypeServers, configGroup.Name, models.PermissionExecute)
+			return
+		}
+	}
+
+	// Stored environment variables exported into the shell
+	envVars, ok := s.terminalEnvVars(w, r, access)
+	if !ok {
+		return
+	}
+	for _, envVar := range envVars {
+		selected.Env = append(selected.Env, envVar.Name+"="+envVar.Value)
+	}
+
+	// Reserve a session slot before upgrading so limits are reported as HTTP errors
+	sessionID, err := s.terminals.Register(terminalOwner(r), shell, targetName)
+	if err != nil {
+		var limitErr *terminal.LimitError
+		if errors.As(err, &limitErr) {
+			audit.GetLogger().LogTerminalSession(r, targetName, "", audit.OutcomeDenied, map[string]string{
+				"action": "start",
+				"shell":  shell,
+				"error":  limitErr.Error(),
+			})
+			apierror.Error(w, "Too many terminal sessions: "+limitErr.Error(), http.StatusTooManyRequests)
+			return
+		}
+		log.Printf("Error registering terminal session: %v", err)
+		apierror.Error(w, "Failed to create terminal session", http.StatusInternalServerError)
+		return
+	}
+	defer s.terminals.Remove(sessionID)
+
 	// Upgrade HTTP connection to WebSocket
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -30,24 +133,9 @@ func (s *Server) handleTerminalWebSocket(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Determine which shell to use
-	shell := "/bin/bash"
-	if queryShell := r.URL.Query().Get("shell"); queryShell != "" {
-		// Only allow specific shells for security
-		switch queryShell {
-		case "bash", "/bin/bash":
-			shell = "/bin/bash"
-		case "sh", "/bin/sh":
-			shell = "/bin/sh"
-		case "zsh", "/bin/zsh":
-			shell = "/bin/zsh"
-		default:
-			log.Printf("Invalid shell requested: %s, using default", queryShell)
-		}
-	}
-
 	// Check if SSH key is requested
 	var sshPrivateKey string
+	var sshKey *models.SSHKey
 	sshKeyID := r.URL.Query().Get("sshKeyId")
 	sshKeySource := r.URL.Query().Get("sshKeySource")
 
@@ -70,8 +158,13 @@ func (s *Server) handleTerminalWebSocket(w http.ResponseWriter, r *http.Request)
 						}
 					}
 				}
+				if key != nil && !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+					denyTerminal(ws, groupDenied(r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute))
+					return
+				}
 				if key != nil {
 					sshPrivateKey = key.PrivateKey
+					sshKey = &models.SSHKey{Name: key.Name, Group: key.Group, Source: "vault"}
 					log.Printf("Loaded SSH key '%s' from Vault", sshKeyID)
 				} else {
 					log.Printf("Failed to find SSH key '%s' in Vault", sshKeyID)
@@ -85,42 +178,592 @@ func (s *Server) handleTerminalWebSocket(w http.ResponseWriter, r *http.Request)
 			if err == nil {
 				repo := repository.NewSSHKeyRepository(s.db)
 				key, err := repo.GetByID(keyID)
+				if err == nil && !access.canExecute(models.ResourceTypeSSHKeys, key.Group) {
+					denyTerminal(ws, groupDenied(r, models.ResourceTypeSSHKeys, key.Group, models.PermissionExecute))
+					return
+				}
 				if err == nil {
 					sshPrivateKey = key.PrivateKey
+					sshKey = key
 					log.Printf("Loaded SSH key ID %d from local database", keyID)
 				}
 			}
 		}
 	}
 
-	// Fetch all servers from admin panel for SSH config generation
-	var servers []terminal.ServerConfig
-	serverRepo := repository.NewServerRepository(s.db)
-	serverList, err := serverRepo.GetAll()
-	if err == nil {
-		for _, srv := range serverList {
-			servers = append(servers, terminal.ServerConfig{
-				Name:      srv.Name,
-				IPAddress: srv.IPAddress,
-				Port:      srv.Port,
-				Username:  srv.Username,
+	// Without a selected key, the default key of the target's group, else the selected group, is used
+	if sshKeyID == "" {
+		var key *models.SSHKey
+		if target != nil {
+			key = s.groupSSHKey(target, access)
+		} else if configGroup != nil {
+			key = s.serverGroupSSHKey(configGroup, access)
+		}
+		if key != nil {
+			sshPrivateKey = key.PrivateKey
+			sshKey = key
+		}
+	}
+
+	// Servers the caller may run commands on get SSH config entries
+	servers := s.terminalSSHServers(access, configGroup, target)
+
+	// Record the session when configured; sessions are refused if recording fails
+	var recorder *terminal.Recorder
+	if dir := s.terminalRecordingDir(); dir != "" {
+		recorder, err = terminal.NewRecorder(dir, 80, 24, shell, s.config.TerminalRecordInput)
+		if err != nil {
+			log.Printf("Failed to start terminal recording: %v", err)
+			audit.GetLogger().LogTerminalSession(r, targetName, "", audit.OutcomeFailure, map[string]string{
+				"action": "start",
+				"shell":  shell,
+				"error":  "recording unavailable",
 			})
+			denyTerminal(ws, "Failed to start terminal recording")
+			return
+		}
+		go s.pruneTerminalRecordings(dir)
+	}
+
+	// Without a selected key, sign a short-lived one with Vault's SSH CA if configured;
+	// the target's user is the principal, otherwise the role's default user applies
+	identity := terminal.SSHIdentity{PrivateKey: sshPrivateKey}
+	if sshPrivateKey == "" && len(servers) > 0 {
+		var principal string
+		if target != nil {
+			principal = target.Username
+		}
+		cert, err := s.vaultSSHCertificate(r.Context(), principal)
+		if err != nil {
+			log.Printf("Failed to issue SSH certificate from Vault: %v", err)
+		} else if cert != nil {
+			identity = terminal.SSHIdentity{PrivateKey: cert.PrivateKey, Certificate: cert.Certificate}
 		}
 	}
 
 	// Create new terminal session with optional SSH key and server configs
-	session, err := terminal.NewSession(ws, shell, sshPrivateKey, servers)
+	var session *terminal.Session
+	if target != nil {
+		session, err = terminal.NewSSHSession(ws, selected, targetName, identity, servers)
+	} else {
+		session, err = terminal.NewSession(ws, selected, identity, servers)
+	}
 	if err != nil {
 		log.Printf("Failed to create terminal session: %v", err)
+		if recorder != nil {
+			recorder.Close()
+			os.Remove(recorder.Path())
+		}
 		ws.WriteMessage(websocket.TextMessage, []byte("Failed to create terminal session: "+err.Error()))
 		ws.Close()
 		return
 	}
 
-	log.Printf("Terminal session started with shell: %s", shell)
+	metadata := map[string]string{"shell": shell, "session_id": sessionID}
+	if len(envVars) > 0 {
+		names := make([]string, len(envVars))
+		for i, envVar := range envVars {
+			names[i] = envVar.Name
+		}
+		metadata["env_vars"] = strings.Join(names, ",")
+	}
+	recordingID := ""
+	if recorder != nil {
+		session.Record(recorder)
+		recordingID = recorder.ID()
+		metadata["recording"] = recorder.Path()
+		metadata["recording_id"] = recordingID
+	}
+
+	// Log keystrokes when configured; like recording, sessions are refused if it fails
+	var keystrokeLog *models.KeystrokeLog
+	if s.config.TerminalKeystrokeLog {
+		keystrokeLog, err = s.startKeystrokeLog(session, sessionID, terminalOwner(r), targetName)
+		if err != nil {
+			log.Printf("Failed to start keystroke log: %v", err)
+			session.Close()
+			audit.GetLogger().LogTerminalSession(r, targetName, "", audit.OutcomeFailure, map[string]string{
+				"action": "start",
+				"shell":  shell,
+				"error":  "keystroke log unavailable",
+			})
+			denyTerminal(ws, "Failed to start keystroke log")
+			return
+		}
+		metadata["keystroke_log_id"] = strconv.FormatInt(keystrokeLog.ID, 10)
+	}
+
+	session.Resumable(s.config.GetTerminalDetachGrace(), s.config.TerminalScrollback)
+	s.terminals.Attach(sessionID, session, recordingID)
+
+	// Tell the client its session ID so it can reattach after a disconnect
+	if msg, err := json.Marshal(sessionMessage{Type: "session", ID: sessionID}); err == nil {
+		ws.WriteMessage(websocket.TextMessage, msg)
+	}
+
+	targetUser := ""
+	if target != nil {
+		targetUser = target.Username
+		log.Printf("Terminal session started with SSH to %s", targetName)
+	} else {
+		log.Printf("Terminal session started with shell: %s", shell)
+	}
+	audit.GetLogger().LogTerminalSession(r, targetName, targetUser, audit.OutcomeSuccess, withAction(metadata, "start"))
+	s.recordSSHKeyUse(r, sshKey, targetName, targetUser, "terminal")
 
 	// Start the session (blocks until session ends)
+	started := time.Now()
 	session.Start()
 
+	if keystrokeLog != nil {
+		if err := repository.NewKeystrokeLogRepository(s.db).End(keystrokeLog.ID); err != nil {
+			log.Printf("Error ending keystroke log: %v", err)
+		}
+	}
+
+	metadata["duration_ms"] = strconv.FormatInt(time.Since(started).Milliseconds(), 10)
+	audit.GetLogger().LogTerminalSession(r, targetName, targetUser, audit.OutcomeSuccess, withAction(metadata, "end"))
 	log.Printf("Terminal session ended")
 }
+
+// sessionMessage is the control message telling a terminal client its session ID
+type sessionMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// resumeTerminalSession reattaches the caller to one of their detached sessions
+// The scrollback is replayed and the connection then behaves like a new session
+func (s *Server) resumeTerminalSession(w http.ResponseWriter, r *http.Request, id string) {
+	info, ok := s.terminals.Get(id)
+	if !ok {
+		apierror.Error(w, "Terminal session not found", http.StatusNotFound)
+		return
+	}
+	if terminalOwner(r) != info.Owner {
+		apierror.Error(w, "Terminal sessions can only be resumed by their owner", http.StatusForbidden)
+		return
+	}
+
+	session := s.terminals.Session(id)
+	if session == nil {
+		apierror.Error(w, "Terminal session is not running", http.StatusConflict)
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+
+	metadata := map[string]string{"session_id": id, "shell": info.Shell}
+	log.Printf("Terminal session %s resumed", id)
+	audit.GetLogger().LogTerminalSession(r, info.Server, "", audit.OutcomeSuccess, withAction(metadata, "resume"))
+
+	if msg, err := json.Marshal(sessionMessage{Type: "session", ID: id}); err == nil {
+		ws.WriteMessage(websocket.TextMessage, msg)
+	}
+
+	// Blocks until this connection drops or the session ends
+	if err := session.Attach(ws); err != nil {
+		denyTerminal(ws, "Terminal session has ended")
+	}
+}
+
+// handleListTerminalRecordings godoc
+// @Summary List terminal recordings
+// @Description List recorded terminal sessions, newest first. Empty when recording is disabled. Requires admin access.
+// @Tags Terminal
+// @Produce json
+// @Success 200 {array} models.TerminalRecording
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/recordings [get]
+func (s *Server) handleListTerminalRecordings(w http.ResponseWriter, r *http.Request) {
+	recordings := []*models.TerminalRecording{}
+	if dir := s.terminalRecordingDir(); dir != "" {
+		var err error
+		recordings, err = terminal.ListRecordings(dir)
+		if err != nil {
+			log.Printf("Error listing terminal recordings: %v", err)
+			apierror.Error(w, "Failed to list terminal recordings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recordings)
+}
+
+// handleGetTerminalRecording godoc
+// @Summary Download a terminal recording
+// @Description Stream a recorded terminal session as an asciicast v2 file for playback. Recordings of running sessions can be fetched while they grow. Requires admin access.
+// @Tags Terminal
+// @Produce application/x-asciicast
+// @Param id path string true "Recording ID"
+// @Success 200 {string} string "asciicast v2 recording"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/recordings/{id} [get]
+func (s *Server) handleGetTerminalRecording(w http.ResponseWriter, r *http.Request) {
+	dir := s.terminalRecordingDir()
+	if dir == "" {
+		apierror.Error(w, "Recording not found", http.StatusNotFound)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	path, err := terminal.RecordingPath(dir, id)
+	if err != nil {
+		apierror.Error(w, "Invalid recording ID", http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		apierror.Error(w, "Recording not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		log.Printf("Error reading terminal recording: %v", err)
+		apierror.Error(w, "Failed to read recording", http.StatusInternalServerError)
+		return
+	}
+
+	// Watching a recording is itself audited
+	audit.GetLogger().LogTerminalSession(r, "local", "", audit.OutcomeSuccess, map[string]string{
+		"action":       "playback",
+		"recording_id": id,
+	})
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", `inline; filename="`+id+terminal.RecordingExt+`"`)
+	http.ServeContent(w, r, id+terminal.RecordingExt, info.ModTime(), file)
+}
+
+// handleListTerminalSessions godoc
+// @Summary List open terminal sessions
+// @Description List the interactive terminal sessions currently open, oldest first, with their owner, shell and target server. Requires admin access.
+// @Tags Terminal
+// @Produce json
+// @Success 200 {array} models.TerminalSession
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/sessions [get]
+func (s *Server) handleListTerminalSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.terminals.List())
+}
+
+// handleCloseTerminalSession godoc
+// @Summary Close a terminal session
+// @Description Force-close an open terminal session, ending its shell and disconnecting the client. Requires admin access.
+// @Tags Terminal
+// @Param id path string true "Session ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/sessions/{id} [delete]
+func (s *Server) handleCloseTerminalSession(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	info, ok := s.terminals.Get(id)
+	if !ok || !s.terminals.Close(id) {
+		apierror.Error(w, "Terminal session not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Terminal session %s of %s closed by admin", id, info.Owner)
+	audit.GetLogger().LogTerminalSession(r, info.Server, "", audit.OutcomeSuccess, map[string]string{
+		"action":     "terminate",
+		"session_id": id,
+		"owner":      info.Owner,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleWatchTerminalSession godoc
+// @Summary Watch a terminal session
+// @Description Attach a read-only WebSocket to an open terminal session. The observer receives the same output as the session owner; anything it sends is ignored. Requires admin access or ownership of the session.
+// @Tags Terminal
+// @Param id path string true "Session ID"
+// @Success 101 "Switching Protocols"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/sessions/{id}/watch [get]
+func (s *Server) handleWatchTerminalSession(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	info, ok := s.terminals.Get(id)
+	if !ok {
+		apierror.Error(w, "Terminal session not found", http.StatusNotFound)
+		return
+	}
+
+	principal := middleware.PrincipalFromContext(r.Context())
+	if !principal.IsAdmin() && principal.Name != info.Owner {
+		apierror.Error(w, "Watching another user's terminal session requires admin access", http.StatusForbidden)
+		return
+	}
+
+	session := s.terminals.Session(id)
+	if session == nil {
+		apierror.Error(w, "Terminal session is not running", http.StatusConflict)
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+
+	metadata := map[string]string{"session_id": id, "owner": info.Owner}
+	log.Printf("Observer attached to terminal session %s of %s", id, info.Owner)
+	audit.GetLogger().LogTerminalSession(r, info.Server, "", audit.OutcomeSuccess, withAction(metadata, "watch_start"))
+
+	// Blocks until the observer disconnects or the session ends
+	session.Observe(ws)
+
+	audit.GetLogger().LogTerminalSession(r, info.Server, "", audit.OutcomeSuccess, withAction(metadata, "watch_end"))
+	log.Printf("Observer detached from terminal session %s", id)
+}
+
+// terminalSSHServers returns the SSH config entries of a terminal session: the servers the
+// caller may execute on, limited to the selected server group, and the target under its alias
+// Servers requiring approval are left out, since an SSH session to them would bypass it.
+func (s *Server) terminalSSHServers(access *groupAccess, configGroup *models.ServerGroup, target *models.Server) []terminal.ServerConfig {
+	var servers []terminal.ServerConfig
+	serverList, err := repository.NewServerRepository(s.db).GetAll()
+	if err != nil {
+		log.Printf("Error fetching servers for SSH config: %v", err)
+	}
+	for _, srv := range serverList {
+		if !access.canExecute(models.ResourceTypeServers, srv.Group) || srv.RequiresApproval {
+			continue
+		}
+		if configGroup != nil && srv.Group != configGroup.Name {
+			continue
+		}
+		if target != nil && srv.ID == target.ID {
+			continue // Added below under its alias
+		}
+		servers = append(servers, terminal.ServerConfig{
+			Name:      srv.Name,
+			IPAddress: srv.IPAddress,
+			Port:      srv.Port,
+			Username:  srv.Username,
+		})
+	}
+
+	if target != nil {
+		servers = append(servers, terminal.ServerConfig{
+			Name:      sshTargetAlias(target),
+			IPAddress: target.IPAddress,
+			Port:      target.Port,
+			Username:  target.Username,
+		})
+	}
+	return servers
+}
+
+// sshTargetAlias returns the SSH config alias used for a one-click SSH terminal
+// Servers without a name are addressed by their IP address
+func sshTargetAlias(server *models.Server) string {
+	if server.Name != "" {
+		return server.Name
+	}
+	return server.IPAddress
+}
+
+// terminalOwner returns the name terminal sessions are counted and listed under
+func terminalOwner(r *http.Request) string {
+	if principal := middleware.PrincipalFromContext(r.Context()); principal != nil {
+		return principal.Name
+	}
+	return "anonymous"
+}
+
+// withAction returns a copy of audit metadata with the session action set
+func withAction(metadata map[string]string, action string) map[string]string {
+	out := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out["action"] = action
+	return out
+}
+
+// terminalEnvVars returns the stored environment variables selected for a terminal session:
+// envVarIds picks local variables by ID, envVarNames (with envVarGroups) Vault variables by
+// name, and envGroups every variable in the listed groups
+// Writes an error response and returns false if one of them may not be used
+func (s *Server) terminalEnvVars(w http.ResponseWriter, r *http.Request, access *groupAccess) ([]*models.EnvVariable, bool) {
+	query := r.URL.Query()
+	var envVars []*models.EnvVariable
+	envRepo := repository.NewEnvVariableRepository(s.db)
+
+	for _, value := range splitQueryList(query.Get("envVarIds")) {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			apierror.Error(w, "Invalid environment variable ID", http.StatusBadRequest)
+			return nil, false
+		}
+		envVar, err := envRepo.GetByID(id)
+		if err != nil {
+			log.Printf("Warning: env variable ID %d not found: %v", id, err)
+			continue
+		}
+		envVars = append(envVars, envVar)
+	}
+
+	groups := splitQueryList(query.Get("envVarGroups"))
+	for i, name := range splitQueryList(query.Get("envVarNames")) {
+		group := "default"
+		if i < len(groups) {
+			group = groups[i]
+		}
+		envVar, err := s.getEnvVariableByNameFromVault(r.Context(), group, name)
+		if err != nil || envVar == nil {
+			log.Printf("Warning: env variable '%s' not found in Vault: %v", name, err)
+			continue
+		}
+		envVars = append(envVars, envVar)
+	}
+
+	if selected := splitQueryList(query.Get("envGroups")); len(selected) > 0 {
+		all, err := envRepo.GetAll()
+		if err != nil {
+			log.Printf("Error fetching environment variables: %v", err)
+			apierror.Error(w, "Failed to fetch environment variables", http.StatusInternalServerError)
+			return nil, false
+		}
+		for _, envVar := range s.mergeEnvVariablesWithVault(r.Context(), all) {
+			for _, group := range selected {
+				if envVar.Group == group {
+					envVars = append(envVars, envVar)
+					break
+				}
+			}
+		}
+	}
+
+	for _, envVar := range envVars {
+		if !access.canExecute(models.ResourceTypeEnvVariables, envVar.Group) {
+			denyGroupAccess(w, r, models.ResourceTypeEnvVariables, envVar.Group, models.PermissionExecute)
+			return nil, false
+		}
+		if err := validation.ValidateEnvVarName(envVar.Name); err != nil {
+			apierror.Error(w, "Invalid environment variable name: "+envVar.Name, http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	return envVars, true
+}
+
+// splitQueryList splits a comma-separated query parameter, dropping empty items
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// terminalShell returns the installed shell with the given name or path
+// Unknown or empty names fall back to the caller's default shell, then to the first installed one
+func (s *Server) terminalShell(r *http.Request, name string) (terminal.Shell, bool) {
+	available := terminal.AvailableShells(s.terminalShellCatalog())
+
+	shell, ok := terminal.FindShell(available, name)
+	if !ok {
+		if name != "" {
+			log.Printf("Invalid shell requested: %s, using default", name)
+		}
+		shell, ok = s.defaultTerminalShell(r, available)
+	}
+	if !ok {
+		return terminal.Shell{}, false
+	}
+
+	if s.config != nil {
+		shell.RCFile = s.config.TerminalShellRC
+	}
+	return shell, true
+}
+
+// defaultTerminalShell returns the caller's default shell among the available ones
+func (s *Server) defaultTerminalShell(r *http.Request, available []terminal.Shell) (terminal.Shell, bool) {
+	if s.config != nil {
+		if shell, ok := terminal.FindShell(available, s.config.GetTerminalUserShell(terminalOwner(r))); ok {
+			return shell, true
+		}
+	}
+	if len(available) == 0 {
+		return terminal.Shell{}, false
+	}
+	return available[0], true
+}
+
+// terminalShellCatalog returns the configured shell catalog, or the built-in one
+func (s *Server) terminalShellCatalog() []terminal.Shell {
+	if s.shells != nil {
+		return s.shells
+	}
+	return terminal.DefaultShells
+}
+
+// startKeystrokeLog creates a keystroke log for the session and attaches a logger writing to it
+func (s *Server) startKeystrokeLog(session *terminal.Session, sessionID, owner, server string) (*models.KeystrokeLog, error) {
+	if !database.EncryptionReady() {
+		return nil, fmt.Errorf("encryption is not initialized")
+	}
+
+	repo := repository.NewKeystrokeLogRepository(s.db)
+	keystrokeLog, err := repo.Create(sessionID, owner, server)
+	if err != nil {
+		return nil, err
+	}
+
+	session.LogKeystrokes(terminal.NewKeystrokeLogger(func(events []models.KeystrokeEvent) error {
+		return repo.AppendEvents(keystrokeLog.ID, events)
+	}))
+	return keystrokeLog, nil
+}
+
+// terminalRecordingDir returns the directory for terminal recordings, or "" when recording is off
+func (s *Server) terminalRecordingDir() string {
+	if s.config == nil {
+		return ""
+	}
+	return s.config.TerminalRecordingDir
+}
+
+// pruneTerminalRecordings deletes recordings past the retention period
+func (s *Server) pruneTerminalRecordings(dir string) {
+	removed, err := terminal.PruneRecordings(dir, s.config.GetTerminalRecordingRetention())
+	if err != nil {
+		log.Printf("Warning: failed to prune terminal recordings: %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Removed %d expired terminal recordings", removed)
+	}
+}
+
+// denyTerminal reports a permission error to the client and closes the connection
+func denyTerminal(ws *websocket.Conn, message string) {
+	ws.WriteMessage(websocket.TextMessage, []byte(message+"\r\n"))
+	ws.Close()
+}
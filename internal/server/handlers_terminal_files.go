@@ -0,0 +1,236 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/terminal"
+)
+
+// handleListTerminalFiles godoc
+// @Summary List terminal session files
+// @Description List the files in a terminal session's file exchange directory ($WEBCLI_FILES in the shell). Requires ownership of the session or admin access.
+// @Tags Terminal
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {array} models.TerminalFile
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/files/{id} [get]
+func (s *Server) handleListTerminalFiles(w http.ResponseWriter, r *http.Request) {
+	_, session, ok := s.terminalSessionForFiles(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := terminal.ListFiles(session.FilesDir())
+	if err != nil {
+		log.Printf("Error listing terminal files: %v", err)
+		apierror.Error(w, "Failed to list files", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files)
+}
+
+// handleUploadTerminalFile godoc
+// @Summary Upload a file into a terminal session
+// @Description Upload a file (multipart field "file") into a terminal session's file exchange directory. An existing file with the same name is replaced. Requires ownership of the session or admin access.
+// @Tags Terminal
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Session ID"
+// @Param file formData file true "File to upload"
+// @Success 201 {object} models.TerminalFile
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/files/{id} [post]
+func (s *Server) handleUploadTerminalFile(w http.ResponseWriter, r *http.Request) {
+	info, session, ok := s.terminalSessionForFiles(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.GetTerminalFileMaxSize()+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		apierror.Error(w, "Expected a multipart/form-data upload", http.StatusBadRequest)
+		return
+	}
+
+	var part io.ReadCloser
+	var fileName string
+	for {
+		p, err := reader.NextPart()
+		if err != nil {
+			apierror.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+		if p.FormName() == "file" {
+			part, fileName = p, p.FileName()
+			break
+		}
+		p.Close()
+	}
+	defer part.Close()
+
+	dest, err := terminal.UploadPath(session.FilesDir(), fileName)
+	if err != nil {
+		apierror.Error(w, "Invalid file name", http.StatusBadRequest)
+		return
+	}
+
+	// Write to a temporary file and rename it into place, so a symbolic link
+	// with the same name is replaced rather than followed
+	tmp, err := os.CreateTemp(session.FilesDir(), ".upload-*")
+	if err != nil {
+		log.Printf("Error creating upload file: %v", err)
+		apierror.Error(w, "Failed to store file", http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, io.LimitReader(part, s.config.GetTerminalFileMaxSize()+1))
+	tmp.Close()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			apierror.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		log.Printf("Error storing upload: %v", err)
+		apierror.Error(w, "Failed to store file", http.StatusInternalServerError)
+		return
+	}
+	if size > s.config.GetTerminalFileMaxSize() {
+		apierror.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		log.Printf("Error storing upload: %v", err)
+		apierror.Error(w, "Failed to store file", http.StatusInternalServerError)
+		return
+	}
+
+	stat, err := os.Stat(dest)
+	if err != nil {
+		log.Printf("Error reading uploaded file: %v", err)
+		apierror.Error(w, "Failed to store file", http.StatusInternalServerError)
+		return
+	}
+
+	file := &models.TerminalFile{
+		Path:       filepath.Base(dest),
+		Size:       stat.Size(),
+		ModifiedAt: stat.ModTime().UTC(),
+	}
+	audit.GetLogger().LogTerminalSession(r, info.Server, "", audit.OutcomeSuccess, map[string]string{
+		"action":     "file_upload",
+		"session_id": info.ID,
+		"file":       file.Path,
+		"size":       strconv.FormatInt(file.Size, 10),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(file)
+}
+
+// handleDownloadTerminalFile godoc
+// @Summary Download a file from a terminal session
+// @Description Download a file from a terminal session's file exchange directory. Paths are relative to the directory and cannot leave it. Requires ownership of the session or admin access.
+// @Tags Terminal
+// @Produce application/octet-stream
+// @Param id path string true "Session ID"
+// @Param path path string true "File path relative to the directory"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /terminal/files/{id}/{path} [get]
+func (s *Server) handleDownloadTerminalFile(w http.ResponseWriter, r *http.Request) {
+	info, session, ok := s.terminalSessionForFiles(w, r)
+	if !ok {
+		return
+	}
+
+	name := mux.Vars(r)["path"]
+	path, err := terminal.ResolveFile(session.FilesDir(), name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			apierror.Error(w, "File not found", http.StatusNotFound)
+			return
+		}
+		apierror.Error(w, "Invalid file path", http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		apierror.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		log.Printf("Error reading terminal file: %v", err)
+		apierror.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+
+	audit.GetLogger().LogTerminalSession(r, info.Server, "", audit.OutcomeSuccess, map[string]string{
+		"action":     "file_download",
+		"session_id": info.ID,
+		"file":       name,
+		"size":       strconv.FormatInt(stat.Size(), 10),
+	})
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(path)}))
+	http.ServeContent(w, r, "", stat.ModTime(), file)
+}
+
+// terminalSessionForFiles looks up the session named in the URL for a file request
+// Only the session owner and admins may exchange files with a session
+func (s *Server) terminalSessionForFiles(w http.ResponseWriter, r *http.Request) (*models.TerminalSession, *terminal.Session, bool) {
+	id := mux.Vars(r)["id"]
+	info, ok := s.terminals.Get(id)
+	if !ok {
+		apierror.Error(w, "Terminal session not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	if !middleware.PrincipalFromContext(r.Context()).IsAdmin() && terminalOwner(r) != info.Owner {
+		apierror.Error(w, "Terminal session files are only available to the session owner", http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	session := s.terminals.Session(id)
+	if session == nil {
+		apierror.Error(w, "Terminal session is not running", http.StatusConflict)
+		return nil, nil, false
+	}
+	return info, session, true
+}
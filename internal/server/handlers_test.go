@@ -1,18 +1,44 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	osexec "os/exec"
+	"os/user"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	_ "github.com/pozgo/web-cli/docs" // Registers the OpenAPI spec
+	"github.com/pozgo/web-cli/internal/agent"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/bundle"
+	"github.com/pozgo/web-cli/internal/config"
 	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/inventory"
 	"github.com/pozgo/web-cli/internal/middleware"
 	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+	"github.com/pozgo/web-cli/internal/outputlog"
 	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/terminal"
+	"github.com/pozgo/web-cli/internal/vault"
 )
 
 func setupTestServer(t *testing.T) (*Server, func()) {
@@ -30,7 +56,8 @@ func setupTestServer(t *testing.T) (*Server, func()) {
 	}
 
 	server := &Server{
-		db: db,
+		db:        db,
+		terminals: terminal.NewRegistry(0, 0),
 	}
 
 	cleanup := func() {
@@ -40,6 +67,11 @@ func setupTestServer(t *testing.T) (*Server, func()) {
 	return server, cleanup
 }
 
+// requireAdmin serves a handler behind the middleware keeping non-admins off admin-only endpoints
+func requireAdmin(handler http.HandlerFunc) http.Handler {
+	return middleware.RequireAdmin("")(handler)
+}
+
 func TestHandleHealth(t *testing.T) {
 	server, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -56,9 +88,34 @@ func TestHandleHealth(t *testing.T) {
 		t.Errorf("Handler returned wrong status: got %v want %v", status, http.StatusOK)
 	}
 
-	expected := `{"status":"ok"}`
-	if rr.Body.String() != expected {
-		t.Errorf("Handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
+	var response HealthResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Status != HealthStatusOK {
+		t.Errorf("Expected status %q, got %q", HealthStatusOK, response.Status)
+	}
+
+	if response.Version == "" {
+		t.Error("Expected version to be set")
+	}
+
+	expectedComponents := map[string]string{
+		"database":   HealthStatusOK,
+		"encryption": HealthStatusOK,
+		"vault":      HealthStatusDisabled,
+		"disk":       HealthStatusDisabled,
+	}
+	for name, want := range expectedComponents {
+		got, ok := response.Components[name]
+		if !ok {
+			t.Errorf("Expected component %q in response", name)
+			continue
+		}
+		if got.Status != want {
+			t.Errorf("Component %q: expected status %q, got %q", name, want, got.Status)
+		}
 	}
 
 	contentType := rr.Header().Get("Content-Type")
@@ -67,6 +124,83 @@ func TestHandleHealth(t *testing.T) {
 	}
 }
 
+func TestHandleHealth_DatabaseUnavailable(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	cleanup()
+
+	req := httptest.NewRequest("GET", "/api/health", nil)
+	rr := httptest.NewRecorder()
+	server.handleHealth(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	var response HealthResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Status != HealthStatusError {
+		t.Errorf("Expected status %q, got %q", HealthStatusError, response.Status)
+	}
+	if response.Components["database"].Status != HealthStatusError {
+		t.Errorf("Expected database status %q, got %q", HealthStatusError, response.Components["database"].Status)
+	}
+}
+
+func TestOverallHealthStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		components map[string]ComponentHealth
+		expected   string
+	}{
+		{"all ok", map[string]ComponentHealth{"a": {Status: HealthStatusOK}, "b": {Status: HealthStatusDisabled}}, HealthStatusOK},
+		{"one degraded", map[string]ComponentHealth{"a": {Status: HealthStatusOK}, "b": {Status: HealthStatusDegraded}}, HealthStatusDegraded},
+		{"error wins", map[string]ComponentHealth{"a": {Status: HealthStatusDegraded}, "b": {Status: HealthStatusError}}, HealthStatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overallHealthStatus(tt.components); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDBMaintenance(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if got := server.checkDBMaintenanceHealth(); got.Status != HealthStatusDisabled {
+		t.Errorf("Expected maintenance to be disabled by default, got %+v", got)
+	}
+	if _, err := newDBMaintainer("25:00"); err == nil {
+		t.Error("Expected error for an invalid time")
+	}
+
+	m, err := newDBMaintainer("03:30")
+	if err != nil {
+		t.Fatalf("newDBMaintainer() error = %v", err)
+	}
+	before := time.Date(2026, 1, 10, 2, 0, 0, 0, time.UTC)
+	if next := m.nextRun(before); !next.Equal(time.Date(2026, 1, 10, 3, 30, 0, 0, time.UTC)) {
+		t.Errorf("nextRun(%v) = %v, want later the same day", before, next)
+	}
+	after := time.Date(2026, 1, 10, 3, 30, 0, 0, time.UTC)
+	if next := m.nextRun(after); !next.Equal(time.Date(2026, 1, 11, 3, 30, 0, 0, time.UTC)) {
+		t.Errorf("nextRun(%v) = %v, want the next day", after, next)
+	}
+
+	server.dbMaintenance = m
+	server.runDBMaintenance(context.Background())
+	got := server.checkDBMaintenanceHealth()
+	if got.Status != HealthStatusOK || !strings.Contains(got.Message, "integrity ok") {
+		t.Errorf("Expected the health check to report the run, got %+v", got)
+	}
+}
+
 func TestHandleHealth_NoAuthRequired(t *testing.T) {
 	server, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -140,6 +274,63 @@ func TestHandleHealth_NoAuthRequired(t *testing.T) {
 	}
 }
 
+func TestHealthProbes_NoAuthOrHTTPSRequired(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	router.Use(middleware.BasicAuth(&middleware.AuthConfig{
+		Enabled:      true,
+		Username:     "admin",
+		Password:     "secret",
+		ExcludePaths: unauthenticatedPaths(""),
+	}))
+	router.Use(middleware.RequireHTTPS(&middleware.SecurityConfig{
+		RequireHTTPS: true,
+		AuthEnabled:  true,
+		ExcludePaths: unauthenticatedPaths(""),
+	}))
+	router.HandleFunc("/healthz", server.handleLiveness).Methods("GET")
+	router.HandleFunc("/readyz", server.handleReadiness).Methods("GET")
+	router.HandleFunc("/api/keys", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{"/healthz", http.StatusOK},
+		{"/readyz", http.StatusOK},
+		{"/api/keys", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d for %s, got %d", tt.expectedStatus, tt.path, rr.Code)
+			}
+		})
+	}
+}
+
+func TestHandleReadiness_DatabaseUnavailable(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	cleanup()
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	rr := httptest.NewRecorder()
+	server.healthRouter().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
+
 func TestHandleListBashScripts(t *testing.T) {
 	server, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -186,6 +377,55 @@ func TestHandleListBashScripts(t *testing.T) {
 	}
 }
 
+func TestHandleListBashScriptsFilters(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	for _, create := range []*models.BashScriptCreate{
+		{Name: "deploy", Content: "rsync -a build/ web:/srv", Tags: []string{"deploy"}},
+		{Name: "backup", Content: "pg_dump app > app.sql", Tags: []string{"db"}},
+	} {
+		if _, err := scriptRepo.Create(create); err != nil {
+			t.Fatalf("Failed to create test script: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"?tag=deploy", "deploy"},
+		{"?q=PG_DUMP", "backup"},
+		{"?q=app&tag=db", "backup"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/bash-scripts"+tt.query, nil)
+		rr := httptest.NewRecorder()
+		server.handleListBashScripts(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tt.query, rr.Code)
+		}
+		var scripts []models.BashScriptResponse
+		if err := json.NewDecoder(rr.Body).Decode(&scripts); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(scripts) != 1 || scripts[0].Name != tt.want {
+			t.Errorf("%s: expected only %q, got %+v", tt.query, tt.want, scripts)
+		}
+	}
+
+	// Invalid tags are rejected
+	body := `{"name":"tagged","content":"echo hi","tags":["has space"]}`
+	req := httptest.NewRequest("POST", "/api/bash-scripts", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	server.handleCreateBashScript(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid tag, got %d", rr.Code)
+	}
+}
+
 func TestHandleCreateBashScript(t *testing.T) {
 	server, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -419,6 +659,7 @@ func TestHandleCreateBashScript_ValidationErrors(t *testing.T) {
 		name           string
 		payload        models.BashScriptCreate
 		expectedStatus int
+		expectedField  string
 	}{
 		{
 			name: "empty name",
@@ -427,6 +668,7 @@ func TestHandleCreateBashScript_ValidationErrors(t *testing.T) {
 				Content: "#!/bin/bash\necho 'hello'",
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectedField:  "name",
 		},
 		{
 			name: "name with special chars",
@@ -435,6 +677,7 @@ func TestHandleCreateBashScript_ValidationErrors(t *testing.T) {
 				Content: "#!/bin/bash\necho 'hello'",
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectedField:  "name",
 		},
 		{
 			name: "empty content",
@@ -443,6 +686,7 @@ func TestHandleCreateBashScript_ValidationErrors(t *testing.T) {
 				Content: "",
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectedField:  "content",
 		},
 		{
 			name: "invalid filename",
@@ -452,6 +696,17 @@ func TestHandleCreateBashScript_ValidationErrors(t *testing.T) {
 				Filename: "../../../etc/passwd",
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectedField:  "filename",
+		},
+		{
+			name: "unsupported interpreter",
+			payload: models.BashScriptCreate{
+				Name:        "valid-name",
+				Content:     "puts 'hello'",
+				Interpreter: "ruby",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "interpreter",
 		},
 	}
 
@@ -471,6 +726,14 @@ func TestHandleCreateBashScript_ValidationErrors(t *testing.T) {
 				t.Errorf("Handler returned wrong status for %s: got %v want %v. Body: %s",
 					tt.name, status, tt.expectedStatus, rr.Body.String())
 			}
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if resp.Code != apierror.CodeValidationFailed || len(resp.Fields) != 1 || resp.Fields[0].Field != tt.expectedField {
+				t.Errorf("Expected a validation error on %q, got %+v", tt.expectedField, resp)
+			}
 		})
 	}
 }
@@ -816,3 +1079,3830 @@ func TestHandleCreateScriptPreset_ValidationErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestBasePathRouting(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	frontendDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(frontendDir, "index.html"), []byte("<html><head><title>Web CLI</title></head></html>"), 0644); err != nil {
+		t.Fatalf("Failed to write index.html: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(frontendDir, "assets"), 0755); err != nil {
+		t.Fatalf("Failed to create assets dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(frontendDir, "assets", "app.js"), []byte("console.log('app')"), 0644); err != nil {
+		t.Fatalf("Failed to write asset: %v", err)
+	}
+
+	server.config = &config.Config{BasePath: "/webcli/", FrontendPath: frontendDir, SwaggerUI: true}
+	server.router = mux.NewRouter()
+	server.setupRoutes()
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		bodyContains   string
+	}{
+		{"api under prefix", "/webcli/api/health", http.StatusOK, `"status":"ok"`},
+		{"api without prefix", "/api/health", http.StatusNotFound, ""},
+		{"index with base href", "/webcli/", http.StatusOK, `<base href="/webcli/">`},
+		{"spa route", "/webcli/terminal", http.StatusOK, `window.__WEBCLI_BASE_PATH__="/webcli"`},
+		{"static asset", "/webcli/assets/app.js", http.StatusOK, "console.log"},
+		{"root redirects", "/", http.StatusFound, ""},
+		{"probe at root", "/healthz", http.StatusOK, ""},
+		{"openapi spec", "/webcli/api/openapi.json", http.StatusOK, `"basePath":"/webcli/api"`},
+		{"swagger ui", "/webcli/swagger/index.html", http.StatusOK, "swagger-ui"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			server.router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d for %s, got %d", tt.expectedStatus, tt.path, rr.Code)
+			}
+			if tt.bodyContains != "" && !strings.Contains(rr.Body.String(), tt.bodyContains) {
+				t.Errorf("Expected body for %s to contain %q, got %q", tt.path, tt.bodyContains, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleUnlock(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.lockout = middleware.NewLoginLockout(&middleware.LockoutConfig{Threshold: 1, BaseDuration: time.Minute})
+	server.lockout.RecordFailure("192.0.2.1", "admin")
+
+	rr := httptest.NewRecorder()
+	server.handleListLockouts(rr, httptest.NewRequest("GET", "/api/auth/lockouts", nil))
+
+	var lockouts []middleware.Lockout
+	if err := json.NewDecoder(rr.Body).Decode(&lockouts); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(lockouts) != 2 {
+		t.Fatalf("Expected 2 lockouts, got %d", len(lockouts))
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleUnlock(rr, httptest.NewRequest("POST", "/api/auth/unlock", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for empty unlock request, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleUnlock(rr, httptest.NewRequest("POST", "/api/auth/unlock", strings.NewReader(`{"username":"admin"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+
+	var resp UnlockResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Unlocked != 1 {
+		t.Errorf("Expected 1 entry unlocked, got %d", resp.Unlocked)
+	}
+	if got := len(server.lockout.List()); got != 1 {
+		t.Errorf("Expected IP lockout to remain, got %d lockouts", got)
+	}
+}
+
+func TestHandleLoginLogout(t *testing.T) {
+	os.Setenv("AUTH_ENABLED", "true")
+	os.Setenv("AUTH_USERNAME", "admin")
+	os.Setenv("AUTH_PASSWORD", "secret")
+	defer func() {
+		os.Unsetenv("AUTH_ENABLED")
+		os.Unsetenv("AUTH_USERNAME")
+		os.Unsetenv("AUTH_PASSWORD")
+	}()
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.config = &config.Config{}
+	server.router = mux.NewRouter()
+	server.sessions, _ = middleware.NewSessionManager("test-secret", time.Hour)
+	server.setupRoutes()
+
+	// Wrong password
+	req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"username":"admin","password":"wrong"}`))
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for wrong password, got %d", rr.Code)
+	}
+
+	// Login without credentials in the request headers
+	req = httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"username":"admin","password":"secret"}`))
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for login, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var session SessionResponse
+	if err := json.NewDecoder(rr.Body).Decode(&session); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if session.Token == "" || session.Username != "admin" {
+		t.Fatalf("Unexpected session response: %+v", session)
+	}
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != middleware.SessionCookieName || !cookies[0].HttpOnly {
+		t.Fatalf("Expected HTTP-only session cookie, got %v", cookies)
+	}
+
+	// The session cookie authenticates API requests
+	req = httptest.NewRequest("GET", "/api/auth/session", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected session to authenticate, got %d", rr.Code)
+	}
+
+	// Logout revokes the session
+	req = httptest.NewRequest("POST", "/api/auth/logout", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 for logout, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/auth/session", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked session to be rejected, got %d", rr.Code)
+	}
+
+	// A locked-out client gets the same error code as from Basic Auth
+	server.lockout = middleware.NewLoginLockout(&middleware.LockoutConfig{Threshold: 1, BaseDuration: time.Minute})
+	server.lockout.RecordFailure("192.0.2.1", "admin")
+	req = httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"username":"admin","password":"secret"}`))
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	var locked ErrorResponse
+	json.NewDecoder(rr.Body).Decode(&locked)
+	if rr.Code != http.StatusTooManyRequests || locked.Code != apierror.CodeLoginLocked || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 login_locked with Retry-After, got %d %+v", rr.Code, locked)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	keyRepo := repository.NewSSHKeyRepository(server.db)
+	for _, name := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+		if _, err := keyRepo.Create(&models.SSHKeyCreate{Name: name, PrivateKey: "key-" + name}); err != nil {
+			t.Fatalf("Failed to create SSH key: %v", err)
+		}
+	}
+
+	list := func(query string) (*httptest.ResponseRecorder, []string) {
+		rr := httptest.NewRecorder()
+		server.handleListSSHKeys(rr, httptest.NewRequest("GET", "/api/keys"+query, nil))
+		var names []string
+		if rr.Code == http.StatusOK {
+			var keys []models.SSHKey
+			if err := json.NewDecoder(rr.Body).Decode(&keys); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			for _, k := range keys {
+				names = append(names, k.Name)
+			}
+		}
+		return rr, names
+	}
+
+	// Without paging parameters every key is returned
+	rr, names := list("?sort=name")
+	if len(names) != 5 || rr.Header().Get("X-Total-Count") != "5" || rr.Header().Get("Link") != "" {
+		t.Errorf("Expected all 5 keys without a Link header, got %v (headers %v)", names, rr.Header())
+	}
+
+	rr, names = list("?sort=name&page=2&per_page=2")
+	if !slices.Equal(names, []string{"charlie", "delta"}) {
+		t.Errorf("Expected page 2 to be [charlie delta], got %v", names)
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "5" {
+		t.Errorf("Expected X-Total-Count 5, got %q", got)
+	}
+	link := rr.Header().Get("Link")
+	if !strings.Contains(link, `page=3&per_page=2&sort=name>; rel="next"`) || !strings.Contains(link, `page=1&per_page=2&sort=name>; rel="prev"`) {
+		t.Errorf("Expected next and prev links, got %q", link)
+	}
+
+	rr, names = list("?sort=name&order=desc&page=3&per_page=2")
+	if !slices.Equal(names, []string{"alpha"}) || strings.Contains(rr.Header().Get("Link"), "next") {
+		t.Errorf("Expected last page [alpha] without a next link, got %v (%q)", names, rr.Header().Get("Link"))
+	}
+
+	rr, names = list("?q=ALPHA")
+	if !slices.Equal(names, []string{"alpha"}) {
+		t.Errorf("Expected ?q to match [alpha], got %v", names)
+	}
+
+	for _, query := range []string{"?sort=private_key", "?order=sideways", "?page=0", "?per_page=501", "?page=two"} {
+		if rr, _ := list(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rr.Code)
+		}
+	}
+
+	// An empty list reports zero matches
+	rr = httptest.NewRecorder()
+	server.handleListScriptPresets(rr, httptest.NewRequest("GET", "/api/script-presets?per_page=1", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Total-Count") != "0" {
+		t.Errorf("Expected an empty preset page, got %d (headers %v)", rr.Code, rr.Header())
+	}
+}
+
+func TestGroupPermissionsEnforced(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	serverRepo := repository.NewServerRepository(server.db)
+	prod, err := serverRepo.Create(&models.ServerCreate{Name: "db1", IPAddress: "192.0.2.10", Group: "production"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, err := serverRepo.Create(&models.ServerCreate{Name: "web1", IPAddress: "192.0.2.20", Group: "staging"}); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	permRepo := repository.NewGroupPermissionRepository(server.db)
+	if _, err := permRepo.Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypeServers,
+		Group:        "production",
+		Principal:    "role:senior-ops",
+		Permission:   models.PermissionView,
+	}); err != nil {
+		t.Fatalf("Failed to create group permission: %v", err)
+	}
+
+	asPrincipal := func(req *http.Request, principal *middleware.Principal) *http.Request {
+		return req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+	}
+	operator := &middleware.Principal{Name: "token:ci"}
+	senior := &middleware.Principal{Name: "token:lead", Roles: []string{"senior-ops"}}
+
+	listServers := func(principal *middleware.Principal) []models.Server {
+		rr := httptest.NewRecorder()
+		server.handleListServers(rr, asPrincipal(httptest.NewRequest("GET", "/api/servers", nil), principal))
+		var servers []models.Server
+		if err := json.NewDecoder(rr.Body).Decode(&servers); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return servers
+	}
+
+	if servers := listServers(operator); len(servers) != 1 || servers[0].Group != "staging" {
+		t.Errorf("Expected only staging servers for operator, got %+v", servers)
+	}
+	if servers := listServers(senior); len(servers) != 2 {
+		t.Errorf("Expected 2 servers for senior operator, got %d", len(servers))
+	}
+	if servers := listServers(nil); len(servers) != 2 {
+		t.Errorf("Expected 2 servers without authentication, got %d", len(servers))
+	}
+
+	// Restricted servers are reported as missing
+	req := httptest.NewRequest("GET", "/api/servers/1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": strconv.FormatInt(prod.ID, 10)})
+	rr := httptest.NewRecorder()
+	server.handleGetServer(rr, asPrincipal(req, operator))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for restricted server, got %d", rr.Code)
+	}
+
+	// View permission does not allow execution
+	body := `{"command":"uptime","is_remote":true,"server_id":` + strconv.FormatInt(prod.ID, 10) + `}`
+	rr = httptest.NewRecorder()
+	server.handleExecuteCommand(rr, asPrincipal(httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)), senior))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 executing on a view-only group, got %d", rr.Code)
+	}
+
+	// Vault items are filtered by the same permissions
+	newFakeVault(t, server)
+	if _, err := permRepo.Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypeSSHKeys,
+		Group:        "production",
+		Principal:    "role:senior-ops",
+		Permission:   models.PermissionView,
+	}); err != nil {
+		t.Fatalf("Failed to create group permission: %v", err)
+	}
+	for _, group := range []string{"production", "staging"} {
+		rr = httptest.NewRecorder()
+		server.handleCreateVaultSSHKey(rr, httptest.NewRequest("POST", "/api/vault/ssh-keys", strings.NewReader(`{"name":"deploy","private_key":"secret-`+group+`","group":"`+group+`"}`)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected 201 creating a Vault key, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+	rr = httptest.NewRecorder()
+	server.handleListVaultSSHKeys(rr, asPrincipal(httptest.NewRequest("GET", "/api/vault/ssh-keys", nil), operator))
+	var vaultKeys []vault.SSHKey
+	if err := json.NewDecoder(rr.Body).Decode(&vaultKeys); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(vaultKeys) != 1 || vaultKeys[0].Group != "staging" {
+		t.Errorf("Expected only the staging Vault key for operator, got %+v", vaultKeys)
+	}
+	rr = httptest.NewRecorder()
+	server.handleCreateVaultSSHKey(rr, asPrincipal(httptest.NewRequest("POST", "/api/vault/ssh-keys", strings.NewReader(`{"name":"mine","private_key":"k","group":"production"}`)), operator))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 creating a Vault key in a restricted group, got %d", rr.Code)
+	}
+
+	// Only admins manage permissions
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleListGroupPermissions).ServeHTTP(rr, asPrincipal(httptest.NewRequest("GET", "/api/group-permissions", nil), senior))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 listing permissions as non-admin, got %d", rr.Code)
+	}
+}
+
+func TestCommandPoliciesEnforced(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	policyRepo := repository.NewCommandPolicyRepository(server.db)
+	if _, err := policyRepo.Create(&models.CommandPolicyCreate{
+		Name:      "no-root-wipe",
+		Action:    models.PolicyActionDeny,
+		MatchType: models.PolicyMatchRegex,
+		Pattern:   `rm\s+-rf\s+/(\s|$)`,
+	}); err != nil {
+		t.Fatalf("Failed to create command policy: %v", err)
+	}
+	if _, err := policyRepo.Create(&models.CommandPolicyCreate{
+		Name:      "operators-systemctl",
+		Action:    models.PolicyActionAllow,
+		Pattern:   "systemctl *",
+		Principal: "role:operator",
+	}); err != nil {
+		t.Fatalf("Failed to create command policy: %v", err)
+	}
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	script, err := scriptRepo.Create(&models.BashScriptCreate{Name: "cleanup", Content: "#!/bin/bash\nrm -rf /\n"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	asPrincipal := func(req *http.Request, principal *middleware.Principal) *http.Request {
+		return req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+	}
+	operator := &middleware.Principal{Name: "token:ci", Roles: []string{"operator"}}
+
+	// Deny policies apply to everyone, including admins
+	rr := httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"rm -rf /"}`)))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for denied command, got %d", rr.Code)
+	}
+
+	// Allow policies restrict the principals they apply to
+	rr = httptest.NewRecorder()
+	server.handleExecuteCommand(rr, asPrincipal(httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"uptime"}`)), operator))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for command outside allowlist, got %d", rr.Code)
+	}
+
+	body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `}`
+	rr = httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for script containing denied command, got %d", rr.Code)
+	}
+
+	// Test endpoint evaluates without executing
+	test := func(body string) models.PolicyDecision {
+		rr := httptest.NewRecorder()
+		server.handleTestCommandPolicies(rr, httptest.NewRequest("POST", "/api/policies/test", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200 testing policies, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var decision models.PolicyDecision
+		if err := json.NewDecoder(rr.Body).Decode(&decision); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return decision
+	}
+
+	if d := test(`{"command":"systemctl restart nginx","principal":"token:ci","roles":["operator"]}`); !d.Allowed {
+		t.Errorf("Expected allowlisted command to be allowed, got %+v", d)
+	}
+	if d := test(`{"command":"uptime","principal":"token:ci","roles":["operator"]}`); d.Allowed || d.Policy.Name != "operators-systemctl" {
+		t.Errorf("Expected command outside allowlist to be denied, got %+v", d)
+	}
+	if d := test(`{"script_id":` + strconv.FormatInt(script.ID, 10) + `}`); d.Allowed || d.Policy.Name != "no-root-wipe" {
+		t.Errorf("Expected script to be denied, got %+v", d)
+	}
+
+	// Only admins manage policies
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleListCommandPolicies).ServeHTTP(rr, asPrincipal(httptest.NewRequest("GET", "/api/policies", nil), operator))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 listing policies as non-admin, got %d", rr.Code)
+	}
+}
+
+func TestApprovalWorkflow(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	script, err := scriptRepo.Create(&models.BashScriptCreate{Name: "rotate-certs", Content: "echo approved", RequiresApproval: true})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	asPrincipal := func(req *http.Request, principal *middleware.Principal) *http.Request {
+		return req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+	}
+	requester := &middleware.Principal{Name: "token:ci", Roles: []string{"approver"}}
+	approver := &middleware.Principal{Name: "token:lead", Roles: []string{"approver"}}
+	operator := &middleware.Principal{Name: "token:ops"}
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleExecuteScript(rr, asPrincipal(httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)), requester))
+		return rr
+	}
+	decide := func(id int64, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/approvals/1/approve", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.FormatInt(id, 10)})
+		rr := httptest.NewRecorder()
+		server.handleApproveApproval(rr, asPrincipal(req, principal))
+		return rr
+	}
+
+	// Executing a script that requires approval creates a pending approval
+	body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current"}`
+	rr := execute(body)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202 for script requiring approval, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var approval models.Approval
+	if err := json.NewDecoder(rr.Body).Decode(&approval); err != nil {
+		t.Fatalf("Failed to decode approval: %v", err)
+	}
+	if approval.Status != models.ApprovalStatusPending || approval.RequestedBy != "token:ci" {
+		t.Errorf("Unexpected approval: %+v", approval)
+	}
+
+	// Running before approval is refused
+	withApproval := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current","approval_id":` + strconv.FormatInt(approval.ID, 10) + `}`
+	if rr := execute(withApproval); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 running unapproved request, got %d", rr.Code)
+	}
+
+	// Requesters cannot approve their own requests, and approvers need the role
+	if rr := decide(approval.ID, requester); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for self-approval, got %d", rr.Code)
+	}
+	if rr := decide(approval.ID, operator); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 approving without the approver role, got %d", rr.Code)
+	}
+	if rr := decide(approval.ID, approver); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 approving, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := decide(approval.ID, approver); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 approving twice, got %d", rr.Code)
+	}
+
+	// The approval only covers the exact request
+	changed := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"nobody","approval_id":` + strconv.FormatInt(approval.ID, 10) + `}`
+	if rr := execute(changed); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 running a different request, got %d", rr.Code)
+	}
+
+	rr = execute(withApproval)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 running approved request, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "approved") {
+		t.Errorf("Expected script output, got %s", rr.Body.String())
+	}
+
+	// Approvals run once
+	if rr := execute(withApproval); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 reusing an approval, got %d", rr.Code)
+	}
+}
+
+func TestExecuteScriptInterpreters(t *testing.T) {
+	if _, err := osexec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	envVar, err := envRepo.Create(&models.EnvVariableCreate{Name: "GREETING", Value: "hello"})
+	if err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	script, err := scriptRepo.Create(&models.BashScriptCreate{
+		Name:        "py",
+		Content:     "import os, sys\nprint(os.environ['GREETING'] + ' from python')\nsys.exit(3)",
+		Interpreter: models.ScriptInterpreterPython3,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current","env_var_ids":[` + strconv.FormatInt(envVar.ID, 10) + `]}`
+
+	rr := httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.ScriptResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Output != "***** from python\n" || result.ExitCode != 3 {
+		t.Errorf("Expected python output and exit code 3, got %q (%d)", result.Output, result.ExitCode)
+	}
+
+	// Interpreters that are not installed are rejected before anything runs
+	t.Setenv("PATH", t.TempDir())
+	rr = httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "not installed") {
+		t.Errorf("Expected 400 for a missing interpreter, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExecuteScriptDryRun(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	envVar, err := envRepo.Create(&models.EnvVariableCreate{Name: "GREETING", Value: "hello"})
+	if err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+
+	marker := filepath.Join(t.TempDir(), "ran")
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	valid, err := scriptRepo.Create(&models.BashScriptCreate{Name: "touch", Content: "touch " + marker})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	broken, err := scriptRepo.Create(&models.BashScriptCreate{Name: "broken", Content: "if true; then\n  echo missing fi\n"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	dryRun := func(id int64) models.ScriptResult {
+		t.Helper()
+		body := `{"script_id":` + strconv.FormatInt(id, 10) + `,"dry_run":true,"env_var_ids":[` + strconv.FormatInt(envVar.ID, 10) + `]}`
+		rr := httptest.NewRecorder()
+		server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var result models.ScriptResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		return result
+	}
+
+	result := dryRun(valid.ID)
+	if !result.DryRun || result.ExitCode != 0 || result.Server != "local" || len(result.EnvVars) != 1 || result.EnvVars[0] != "GREETING" {
+		t.Errorf("Unexpected dry run result: %+v", result)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("Dry run executed the script")
+	}
+
+	if result := dryRun(broken.ID); result.ExitCode == 0 || !strings.Contains(result.Output, "syntax error") {
+		t.Errorf("Expected a syntax error, got %q (%d)", result.Output, result.ExitCode)
+	}
+
+	// Nothing is recorded in history
+	history, err := repository.NewCommandHistoryRepository(server.db).GetAll(10)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected no history for dry runs, got %d entries", len(history))
+	}
+}
+
+func TestExecuteScriptEnvVarGroups(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	var ids []int64
+	for _, create := range []*models.EnvVariableCreate{
+		{Name: "DB_HOST", Value: "db.staging", Group: "staging"},
+		{Name: "DB_USER", Value: "app", Group: "staging"},
+		{Name: "PROD_DB_HOST", Value: "db.prod", Group: "production"},
+	} {
+		envVar, err := envRepo.Create(create)
+		if err != nil {
+			t.Fatalf("Failed to create env variable: %v", err)
+		}
+		ids = append(ids, envVar.ID)
+	}
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "env", Content: "echo \"$DB_USER@$DB_HOST\""})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	// A variable selected by ID and by its group is injected once
+	body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current","env_var_groups":["staging"],"env_var_ids":[` + strconv.FormatInt(ids[0], 10) + `]}`
+	rr := httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.ScriptResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.EnvVarsCount != 2 || !slices.Equal(result.EnvVars, []string{"DB_HOST", "DB_USER"}) {
+		t.Errorf("Expected the staging group injected once, got %d %v", result.EnvVarsCount, result.EnvVars)
+	}
+	if result.Output != "app@*****\n" {
+		t.Errorf("Expected staging values in the output, got %q", result.Output)
+	}
+}
+
+func TestExecuteScriptPlaceholders(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	for _, create := range []*models.EnvVariableCreate{
+		{Name: "DB_PASSWORD", Value: "s3cret-pw"},
+		{Name: "UNUSED_TOKEN", Value: "unused-token"},
+	} {
+		if _, err := envRepo.Create(create); err != nil {
+			t.Fatalf("Failed to create env variable: %v", err)
+		}
+	}
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	execute := func(content string) *httptest.ResponseRecorder {
+		script, err := scriptRepo.Create(&models.BashScriptCreate{Name: "placeholders-" + strconv.Itoa(len(content)), Content: content})
+		if err != nil {
+			t.Fatalf("Failed to create script: %v", err)
+		}
+		rr := httptest.NewRecorder()
+		body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current"}`
+		server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+		return rr
+	}
+
+	// Only the referenced variable is resolved, into the script text rather than the environment
+	rr := execute("echo \"{{ env.DB_PASSWORD }}\" | wc -c; echo \"${UNUSED_TOKEN:-unset} {{env.DB_PASSWORD}}\"")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.ScriptResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if strings.TrimSpace(strings.Split(result.Output, "\n")[0]) != "10" || !strings.HasSuffix(result.Output, "unset *****\n") {
+		t.Errorf("Expected the placeholder rendered and masked, got %q", result.Output)
+	}
+	if !slices.Equal(result.EnvVars, []string{"DB_PASSWORD"}) {
+		t.Errorf("Expected only DB_PASSWORD resolved, got %v", result.EnvVars)
+	}
+
+	if rr := execute("echo {{env.MISSING_VAR}}"); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "{{env.MISSING_VAR}}") {
+		t.Errorf("Expected 400 for an unknown variable, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := execute("echo {{vault:prod/API_KEY}}"); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "Vault is not enabled") {
+		t.Errorf("Expected 400 without Vault, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestBatchOperations(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	serverRepo := repository.NewServerRepository(server.db)
+	existing, err := serverRepo.Create(&models.ServerCreate{Name: "old-host"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	id := strconv.FormatInt(existing.ID, 10)
+
+	batch := func(handler http.HandlerFunc, path, body string) (*httptest.ResponseRecorder, models.BatchResponse) {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		var resp models.BatchResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+	countServers := func() int {
+		all, _ := serverRepo.GetAll()
+		return len(all)
+	}
+
+	// An invalid operation rejects the whole batch before anything is written
+	rr, resp := batch(server.handleServerBatch, "/api/servers/batch", `{"operations":[
+		{"action":"create","server":{"name":"web-1"}},
+		{"action":"create","server":{"name":"bad host"}},
+		{"action":"delete","id":`+id+`}]}`)
+	if rr.Code != http.StatusBadRequest || resp.Applied {
+		t.Fatalf("Expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp.Results[0].Status != models.BatchStatusNotApplied || resp.Results[1].Status != models.BatchStatusFailed {
+		t.Errorf("Unexpected results: %+v", resp.Results)
+	}
+	if countServers() != 1 {
+		t.Error("Invalid batch changed servers")
+	}
+
+	// A failure inside the transaction rolls back the operations before it
+	rr, resp = batch(server.handleServerBatch, "/api/servers/batch", `{"operations":[
+		{"action":"create","server":{"name":"web-1"}},
+		{"action":"delete","id":`+id+`},
+		{"action":"delete","id":`+id+`}]}`)
+	if rr.Code != http.StatusConflict || resp.Applied || resp.Results[2].Status != models.BatchStatusFailed {
+		t.Fatalf("Expected 409 for the second delete, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if countServers() != 1 {
+		t.Error("Failed batch was partly applied")
+	}
+
+	rr, resp = batch(server.handleServerBatch, "/api/servers/batch", `{"operations":[
+		{"action":"create","server":{"name":"web-1","port":2222,"requires_approval":true}},
+		{"action":"create","server":{"ip_address":"10.0.0.2","group":"db"}},
+		{"action":"update","id":`+id+`,"server":{"username":"deploy"}}]}`)
+	if rr.Code != http.StatusOK || !resp.Applied {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	created, err := serverRepo.GetByID(resp.Results[0].ID)
+	if err != nil || created.Port != 2222 || !created.RequiresApproval {
+		t.Errorf("Unexpected created server: %+v, %v", created, err)
+	}
+	if updated, _ := serverRepo.GetByID(existing.ID); updated.Username != "deploy" || updated.Name != "old-host" {
+		t.Errorf("Unexpected updated server: %+v", updated)
+	}
+
+	// Environment variable values are masked in the results
+	rr, resp = batch(server.handleEnvVariableBatch, "/api/env-variables/batch", `{"operations":[
+		{"action":"create","env_variable":{"name":"API_KEY","value":"s3cret"}},
+		{"action":"create","env_variable":{"name":"REGION","value":"eu-west-1","group":"prod"}}]}`)
+	if rr.Code != http.StatusOK || len(resp.Results) != 2 {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "s3cret") {
+		t.Error("Batch response exposed a value")
+	}
+
+	if rr, _ := batch(server.handleEnvVariableBatch, "/api/env-variables/batch", `{"operations":[]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty batch, got %d", rr.Code)
+	}
+}
+
+func TestImportEnvVariables(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	if _, err := envRepo.Create(&models.EnvVariableCreate{Name: "DB_HOST", Value: "db.old", Group: "staging"}); err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+
+	importFile := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleImportEnvVariables(rr, httptest.NewRequest("POST", "/api/env-variables/import", strings.NewReader(body)))
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder) models.EnvVariableImportResult {
+		var result models.EnvVariableImportResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		return result
+	}
+	content := `"# app\nDB_HOST=db.new\nexport DB_USER=app\nDB_PASSWORD='s3cret'\n"`
+
+	// A dry run previews the conflict without writing anything
+	rr := importFile(`{"content":` + content + `,"group":"production","dry_run":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	result := decode(rr)
+	if !result.DryRun || result.Created != 2 || result.Skipped != 1 {
+		t.Errorf("Unexpected dry run result: %+v", result)
+	}
+	if item := result.Items[0]; item.Name != "DB_HOST" || item.Line != 2 || item.Action != "skip" || item.ExistingGroup != "staging" {
+		t.Errorf("Expected DB_HOST to be skipped, got %+v", item)
+	}
+	if all, _ := envRepo.GetAll(); len(all) != 1 {
+		t.Errorf("Dry run created variables: %d", len(all))
+	}
+
+	rr = importFile(`{"content":` + content + `,"group":"production"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if result := decode(rr); result.Created != 2 || result.Skipped != 1 {
+		t.Errorf("Unexpected import result: %+v", result)
+	}
+	password, err := envRepo.GetByName("DB_PASSWORD")
+	if err != nil || password.Value != "s3cret" || password.Group != "production" {
+		t.Errorf("Expected DB_PASSWORD in production, got %+v %v", password, err)
+	}
+	if existing, _ := envRepo.GetByName("DB_HOST"); existing.Value != "db.old" {
+		t.Errorf("Existing variable was overwritten: %q", existing.Value)
+	}
+
+	// One invalid line rejects the whole file
+	rr = importFile(`{"content":"NEW_VAR=1\n1BAD=2\nNEW_VAR=3"}`)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "line 2: invalid name") || !strings.Contains(rr.Body.String(), "already set on line 1") {
+		t.Errorf("Expected validation errors, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := envRepo.GetByName("NEW_VAR"); err == nil {
+		t.Error("Invalid file was partially imported")
+	}
+}
+
+func TestExportEnvVariables(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	for _, create := range []*models.EnvVariableCreate{
+		{Name: "DB_USER", Value: "app", Group: "staging"},
+		{Name: "DB_PASSWORD", Value: "p@ss word", Group: "staging"},
+		{Name: "API_TOKEN", Value: "s3cret"},
+	} {
+		if _, err := envRepo.Create(create); err != nil {
+			t.Fatalf("Failed to create env variable: %v", err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	requireAdmin(server.handleExportEnvVariables).ServeHTTP(rr, httptest.NewRequest("GET", "/api/env-variables/export?group=staging", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Body.String(); got != "DB_PASSWORD='p@ss word'\nDB_USER=app\n" {
+		t.Errorf("Unexpected export: %q", got)
+	}
+	if !strings.Contains(rr.Header().Get("Content-Disposition"), "web-cli-staging.env") {
+		t.Errorf("Expected attachment, got %q", rr.Header().Get("Content-Disposition"))
+	}
+
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleExportEnvVariables).ServeHTTP(rr, httptest.NewRequest("GET", "/api/env-variables/export?masked=true", nil))
+	if got := rr.Body.String(); strings.Contains(got, "s3cret") || !strings.HasPrefix(got, "API_TOKEN='••••••••'\n") {
+		t.Errorf("Expected masked export, got %q", got)
+	}
+
+	req := httptest.NewRequest("GET", "/api/env-variables/export", nil)
+	req = req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{Name: "token:ci"}))
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleExportEnvVariables).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", rr.Code)
+	}
+}
+
+func TestExecutionStdin(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	rr := httptest.NewRecorder()
+	body := `{"command":"tr a-z A-Z","user":"current","stdin":"hello\nworld\n"}`
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var cmdResult models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&cmdResult); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if cmdResult.Output != "HELLO\nWORLD\n" {
+		t.Errorf("Expected stdin piped into the command, got %q", cmdResult.Output)
+	}
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	script, err := scriptRepo.Create(&models.BashScriptCreate{Name: "count", Content: "wc -c"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	// Binary input is sent as base64
+	body = `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current","stdin_base64":"AAECAwQ="}`
+	rr = httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.ScriptResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if strings.TrimSpace(result.Output) != "5" {
+		t.Errorf("Expected 5 bytes of stdin, got %q", result.Output)
+	}
+
+	for _, body := range []string{
+		`{"command":"cat","stdin":"a","stdin_base64":"YQ=="}`,
+		`{"command":"cat","stdin_base64":"not base64!"}`,
+	} {
+		rr = httptest.NewRecorder()
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestExecutionWorkdir(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	rr := httptest.NewRecorder()
+	body := `{"command":"pwd","user":"current","workdir":"` + dir + `"}`
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if strings.TrimSpace(result.Output) != dir {
+		t.Errorf("Expected command to run in %s, got %q", dir, result.Output)
+	}
+
+	for _, workdir := range []string{"relative/dir", "/srv/../etc"} {
+		rr = httptest.NewRecorder()
+		body = `{"command":"pwd","user":"current","workdir":"` + workdir + `"}`
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", workdir, rr.Code)
+		}
+	}
+}
+
+func TestExecutionLocalUsersOnly(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.config = &config.Config{LocalUsersOnly: true}
+
+	if _, err := repository.NewLocalUserRepository(server.db).Create(&models.LocalUserCreate{Name: "deploy"}); err != nil {
+		t.Fatalf("Failed to create local user: %v", err)
+	}
+
+	// Unknown users, including the default root, are rejected before anything runs
+	for _, body := range []string{`{"command":"whoami"}`, `{"command":"whoami","user":"nobody"}`} {
+		rr := httptest.NewRecorder()
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "not a configured local user") {
+			t.Errorf("%s: expected 400 for an unknown user, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The server's own user is always allowed
+	rr := httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"true","user":"current"}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 for the current user, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Remote users are SSH logins and are not checked
+	body := `{"command":"true","user":"nobody","is_remote":true}`
+	rr = httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+	if strings.Contains(rr.Body.String(), "not a configured local user") {
+		t.Errorf("Remote user should not be checked against local users: %s", rr.Body.String())
+	}
+}
+
+func TestScriptOutputMasksEnvValues(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	envVar, err := envRepo.Create(&models.EnvVariableCreate{Name: "API_TOKEN", Value: "s3cr3t-token-value"})
+	if err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	script, err := scriptRepo.Create(&models.BashScriptCreate{Name: "leaky", Content: "echo \"token=$API_TOKEN\""})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current","env_var_ids":[` + strconv.FormatInt(envVar.ID, 10) + `]}`
+
+	rr := httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.ScriptResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Output != "token=*****\n" {
+		t.Errorf("Expected masked output, got %q", result.Output)
+	}
+
+	// Streamed chunks and the final result are masked too
+	rr = httptest.NewRecorder()
+	server.handleExecuteScriptStream(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute/stream", strings.NewReader(body)))
+	if strings.Contains(rr.Body.String(), "s3cr3t-token-value") {
+		t.Errorf("Streamed output leaked the env value: %s", rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "token=*****") {
+		t.Errorf("Expected masked streamed output, got: %s", rr.Body.String())
+	}
+
+	history, err := repository.NewCommandHistoryRepository(server.db).GetAll(10)
+	if err != nil {
+		t.Fatalf("Failed to fetch history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+	for _, h := range history {
+		if strings.Contains(h.Output, "s3cr3t-token-value") {
+			t.Errorf("History leaked the env value: %q", h.Output)
+		}
+	}
+}
+
+func TestScriptVersionsAPI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := repository.NewBashScriptRepository(server.db)
+	script, err := repo.Create(&models.BashScriptCreate{Name: "deploy", Content: "echo one\n"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	if _, err := repo.Update(script.ID, &models.BashScriptUpdate{Content: "echo two\n"}); err != nil {
+		t.Fatalf("Failed to update script: %v", err)
+	}
+
+	id := strconv.FormatInt(script.ID, 10)
+	call := func(handler http.HandlerFunc, method, path string, vars map[string]string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(method, path, nil), vars)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	rr := call(server.handleListScriptVersions, "GET", "/api/bash-scripts/"+id+"/versions", map[string]string{"id": id})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 listing versions, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var versions []models.BashScriptVersion
+	if err := json.NewDecoder(rr.Body).Decode(&versions); err != nil {
+		t.Fatalf("Failed to decode versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[0].Content != "" {
+		t.Fatalf("Expected versions 2 and 1 without content, got %+v", versions)
+	}
+
+	rr = call(server.handleDiffScriptVersions, "GET", "/api/bash-scripts/"+id+"/versions/1/diff", map[string]string{"id": id, "version": "1"})
+	var diff models.BashScriptDiff
+	if err := json.NewDecoder(rr.Body).Decode(&diff); err != nil {
+		t.Fatalf("Failed to decode diff: %v", err)
+	}
+	if diff.FromVersion != 1 || diff.ToVersion != 2 || !strings.Contains(diff.Diff, "-echo one\n+echo two\n") {
+		t.Errorf("Unexpected diff: %+v", diff)
+	}
+
+	rr = call(server.handleRollbackScript, "POST", "/api/bash-scripts/"+id+"/versions/1/rollback", map[string]string{"id": id, "version": "1"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 rolling back, got %d: %s", rr.Code, rr.Body.String())
+	}
+	restored, err := repo.GetByID(script.ID)
+	if err != nil {
+		t.Fatalf("Failed to get script: %v", err)
+	}
+	if restored.Content != "echo one\n" {
+		t.Errorf("Expected content of version 1, got %q", restored.Content)
+	}
+
+	// The rollback is itself a version, so it can be undone
+	rr = call(server.handleGetScriptVersion, "GET", "/api/bash-scripts/"+id+"/versions/3", map[string]string{"id": id, "version": "3"})
+	var latest models.BashScriptVersion
+	if err := json.NewDecoder(rr.Body).Decode(&latest); err != nil {
+		t.Fatalf("Failed to decode version: %v", err)
+	}
+	if latest.Content != "echo one\n" {
+		t.Errorf("Expected version 3 to hold the restored content, got %q", latest.Content)
+	}
+
+	// Diff of an earlier version against the current content
+	rr = call(server.handleDiffBashScript, "GET", "/api/bash-scripts/"+id+"/diff?version=2", map[string]string{"id": id})
+	diff = models.BashScriptDiff{}
+	if err := json.NewDecoder(rr.Body).Decode(&diff); err != nil {
+		t.Fatalf("Failed to decode diff: %v", err)
+	}
+	if diff.FromVersion != 2 || diff.ToVersion != 3 || !strings.Contains(diff.Diff, "-echo two\n+echo one\n") {
+		t.Errorf("Unexpected diff against current: %+v", diff)
+	}
+	if rr := call(server.handleDiffBashScript, "GET", "/api/bash-scripts/"+id+"/diff", map[string]string{"id": id}); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a version, got %d", rr.Code)
+	}
+
+	// Diff of unsaved changes against the current content
+	req := mux.SetURLVars(httptest.NewRequest("POST", "/api/bash-scripts/"+id+"/diff", strings.NewReader(`{"content":"echo three\n"}`)), map[string]string{"id": id})
+	rr = httptest.NewRecorder()
+	server.handleDiffBashScript(rr, req)
+	diff = models.BashScriptDiff{}
+	if err := json.NewDecoder(rr.Body).Decode(&diff); err != nil {
+		t.Fatalf("Failed to decode diff: %v", err)
+	}
+	if diff.FromVersion != 3 || diff.ToVersion != 0 || !strings.Contains(diff.Diff, "+++ proposed\n@@ -1 +1 @@\n-echo one\n+echo three\n") {
+		t.Errorf("Unexpected diff of proposed changes: %+v", diff)
+	}
+
+	if rr := call(server.handleGetScriptVersion, "GET", "/api/bash-scripts/"+id+"/versions/9", map[string]string{"id": id, "version": "9"}); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown version, got %d", rr.Code)
+	}
+	if rr := call(server.handleGetScriptVersion, "GET", "/api/bash-scripts/"+id+"/versions/x", map[string]string{"id": id, "version": "x"}); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid version, got %d", rr.Code)
+	}
+}
+
+func TestGitSyncedScriptsAreReadOnly(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := repository.NewBashScriptRepository(server.db)
+	script, err := repo.Create(&models.BashScriptCreate{Name: "backup", Content: "echo backup\n", Filename: "backup.sh", Group: "git", ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+
+	id := strconv.FormatInt(script.ID, 10)
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		path    string
+		body    string
+		vars    map[string]string
+	}{
+		{"update", server.handleUpdateBashScript, "PUT", "/api/bash-scripts/" + id, `{"content":"echo changed"}`, map[string]string{"id": id}},
+		{"delete", server.handleDeleteBashScript, "DELETE", "/api/bash-scripts/" + id, "", map[string]string{"id": id}},
+		{"rollback", server.handleRollbackScript, "POST", "/api/bash-scripts/" + id + "/versions/1/rollback", "", map[string]string{"id": id, "version": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mux.SetURLVars(httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)), tt.vars)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+			if rr.Code != http.StatusConflict {
+				t.Errorf("Expected 409 for read-only script, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/bash-scripts/"+id, nil), map[string]string{"id": id})
+	rr := httptest.NewRecorder()
+	server.handleGetBashScript(rr, req)
+	var response models.BashScriptResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode script: %v", err)
+	}
+	if !response.ReadOnly || response.Content != "echo backup\n" {
+		t.Errorf("Expected unchanged read-only script, got %+v", response)
+	}
+
+	// Git sync status reports when sync is not configured
+	rr = httptest.NewRecorder()
+	server.handleGetGitSync(rr, httptest.NewRequest("GET", "/api/git-sync", nil))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != `{"enabled":false}` {
+		t.Errorf("Expected disabled git sync, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	server.handleGitSync(rr, httptest.NewRequest("POST", "/api/git-sync", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 syncing without configuration, got %d", rr.Code)
+	}
+}
+
+// staticInventory is an inventory provider reporting fixed hosts
+type staticInventory []inventory.Host
+
+func (staticInventory) Name() string { return "static" }
+
+func (p staticInventory) Discover(ctx context.Context) ([]inventory.Host, error) {
+	return p, nil
+}
+
+func TestInventoryEndpoints(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	rr := httptest.NewRecorder()
+	server.handleGetInventory(rr, httptest.NewRequest("GET", "/api/inventory", nil))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != `{"enabled":false}` {
+		t.Errorf("Expected disabled inventory, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleSyncInventory).ServeHTTP(rr, httptest.NewRequest("POST", "/api/inventory", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 syncing without configuration, got %d", rr.Code)
+	}
+
+	syncer, err := inventory.New(inventory.Config{Providers: []inventory.Provider{staticInventory{
+		{ID: "1", Name: "web1", IPAddress: "203.0.113.1", Group: "production"},
+	}}}, repository.NewServerRepository(server.db))
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	server.inventory = syncer
+
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleSyncInventory).ServeHTTP(rr, httptest.NewRequest("POST", "/api/inventory", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 syncing, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var status InventoryStatus
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if !status.Enabled || status.Created != 1 || status.LastSync == nil {
+		t.Errorf("Unexpected inventory status %+v", status.Status)
+	}
+
+	servers, err := repository.NewServerRepository(server.db).GetByGroup("production")
+	if err != nil || len(servers) != 1 || servers[0].InventoryID != "static:1" {
+		t.Errorf("Expected the discovered server in its group, got %+v (%v)", servers, err)
+	}
+
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{Name: "token:ci"})
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleSyncInventory).ServeHTTP(rr, httptest.NewRequest("POST", "/api/inventory", nil).WithContext(ctx))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", rr.Code)
+	}
+}
+
+func TestTerminalRecordingsAPI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	server.config = &config.Config{TerminalRecordingDir: dir}
+
+	recorder, err := terminal.NewRecorder(dir, 80, 24, "/bin/bash", false)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	recorder.Output([]byte("$ uptime\r\n"))
+	recorder.Close()
+
+	// Listing returns the recording with its header details
+	rr := httptest.NewRecorder()
+	server.handleListTerminalRecordings(rr, httptest.NewRequest("GET", "/api/terminal/recordings", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 listing recordings, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var recordings []models.TerminalRecording
+	if err := json.NewDecoder(rr.Body).Decode(&recordings); err != nil {
+		t.Fatalf("Failed to decode recordings: %v", err)
+	}
+	if len(recordings) != 1 || recordings[0].ID != recorder.ID() || recordings[0].Width != 80 || recordings[0].Size == 0 {
+		t.Fatalf("Unexpected recordings: %+v", recordings)
+	}
+
+	get := func(id string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/terminal/recordings/"+id, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		requireAdmin(server.handleGetTerminalRecording).ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Playback streams the asciicast file
+	rr = get(recorder.ID(), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 fetching recording, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/x-asciicast" {
+		t.Errorf("Expected asciicast content type, got %q", ct)
+	}
+	if !strings.HasPrefix(rr.Body.String(), `{"version":2`) || !strings.Contains(rr.Body.String(), "uptime") {
+		t.Errorf("Unexpected recording body: %s", rr.Body.String())
+	}
+
+	if rr := get("..%2Fweb-cli", nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid recording ID, got %d", rr.Code)
+	}
+	if rr := get("20000101T000000Z-00000000", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing recording, got %d", rr.Code)
+	}
+	if rr := get(recorder.ID(), &middleware.Principal{Name: "token:ops"}); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", rr.Code)
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.config = &config.Config{FrontendPath: t.TempDir()}
+	server.maintenance = middleware.NewMaintenanceMode(&middleware.MaintenanceConfig{AllowPaths: readOnlyAllowedPaths})
+	server.router = mux.NewRouter()
+	server.setupRoutes()
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+
+	if rr := serve("PUT", "/api/maintenance", `{"enabled":true,"reason":"incident 42"}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 enabling read-only mode, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr := serve("GET", "/api/maintenance", "")
+	var status middleware.MaintenanceStatus
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !status.Enabled || status.Reason != "incident 42" {
+		t.Errorf("Expected read-only mode to be enabled, got %+v", status)
+	}
+
+	if rr := serve("GET", "/api/servers", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected reads to work in read-only mode, got %d", rr.Code)
+	}
+	if rr := serve("POST", "/api/servers", `{"name":"db1"}`); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 creating a server in read-only mode, got %d", rr.Code)
+	}
+	if rr := serve("POST", "/api/commands/execute", `{"command":"uptime"}`); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 executing in read-only mode, got %d", rr.Code)
+	}
+
+	if rr := serve("PUT", "/api/maintenance", `{"enabled":false}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 disabling read-only mode, got %d", rr.Code)
+	}
+	if rr := serve("POST", "/api/servers", `{"name":"db1"}`); rr.Code != http.StatusCreated {
+		t.Errorf("Expected 201 creating a server after read-only mode, got %d", rr.Code)
+	}
+}
+
+func TestTerminalSessionsAPI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	id, err := server.terminals.Register("user:alice", "/bin/bash", "local")
+	if err != nil {
+		t.Fatalf("Failed to register session: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleListTerminalSessions(rr, httptest.NewRequest("GET", "/api/terminal/sessions", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 listing sessions, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var sessions []models.TerminalSession
+	if err := json.NewDecoder(rr.Body).Decode(&sessions); err != nil {
+		t.Fatalf("Failed to decode sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != id || sessions[0].Owner != "user:alice" || sessions[0].Server != "local" {
+		t.Fatalf("Unexpected sessions: %+v", sessions)
+	}
+
+	closeSession := func(id string, principal *middleware.Principal) int {
+		req := httptest.NewRequest("DELETE", "/api/terminal/sessions/"+id, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		requireAdmin(server.handleCloseTerminalSession).ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := closeSession(id, &middleware.Principal{Name: "user:alice"}); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", code)
+	}
+	if code := closeSession(id, nil); code != http.StatusNoContent {
+		t.Errorf("Expected 204 closing session, got %d", code)
+	}
+	if code := closeSession("missing", nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown session, got %d", code)
+	}
+}
+
+func TestListAvailableShells(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	wrapper := filepath.Join(dir, "ops-shell")
+	if err := os.WriteFile(wrapper, []byte("#!/bin/sh\nexec /bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	server.shells = []terminal.Shell{
+		{Name: "sh", Path: "/bin/sh"},
+		{Name: "ops", Path: wrapper},
+		{Name: "missing", Path: filepath.Join(dir, "missing")},
+	}
+	server.config = &config.Config{TerminalDefaultShell: "sh", TerminalUserShells: []string{"user:alice=ops"}}
+
+	list := func(principal *middleware.Principal) []ShellInfo {
+		req := httptest.NewRequest("GET", "/api/system/shells", nil)
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		server.handleListAvailableShells(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rr.Code)
+		}
+		var shells []ShellInfo
+		if err := json.NewDecoder(rr.Body).Decode(&shells); err != nil {
+			t.Fatalf("Failed to decode shells: %v", err)
+		}
+		return shells
+	}
+
+	shells := list(nil)
+	if len(shells) != 2 || shells[1].Name != "ops" {
+		t.Fatalf("Expected installed shells only, got %+v", shells)
+	}
+	if !shells[0].Default || shells[1].Default {
+		t.Errorf("Expected sh to be the default, got %+v", shells)
+	}
+
+	shells = list(&middleware.Principal{Name: "user:alice"})
+	if shells[0].Default || !shells[1].Default {
+		t.Errorf("Expected ops to be alice's default, got %+v", shells)
+	}
+
+	// Unknown shells fall back to the default instead of running arbitrary paths
+	req := httptest.NewRequest("GET", "/api/terminal/ws?shell=/usr/bin/python3", nil)
+	shell, ok := server.terminalShell(req, "/usr/bin/python3")
+	if !ok || shell.Path != "/bin/sh" {
+		t.Errorf("Expected fallback to /bin/sh, got %+v", shell)
+	}
+}
+
+func TestKeystrokeLogsAPI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := repository.NewKeystrokeLogRepository(server.db)
+	keystrokeLog, err := repo.Create("9c41d7e2a0b35f18", "user:alice", "local")
+	if err != nil {
+		t.Fatalf("Failed to create keystroke log: %v", err)
+	}
+	if err := repo.AppendEvents(keystrokeLog.ID, []models.KeystrokeEvent{{Time: time.Now(), Data: "ls\r"}}); err != nil {
+		t.Fatalf("Failed to append keystrokes: %v", err)
+	}
+
+	get := func(id string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/audit/keystrokes/"+id, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		requireAdmin(server.handleGetKeystrokeLog).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleListKeystrokeLogs(rr, httptest.NewRequest("GET", "/api/audit/keystrokes", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 listing keystroke logs, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var logs []models.KeystrokeLog
+	if err := json.NewDecoder(rr.Body).Decode(&logs); err != nil {
+		t.Fatalf("Failed to decode keystroke logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].ID != keystrokeLog.ID || len(logs[0].Events) != 0 {
+		t.Fatalf("Unexpected keystroke logs: %+v", logs)
+	}
+
+	id := strconv.FormatInt(keystrokeLog.ID, 10)
+	rr = get(id, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 getting keystroke log, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var got models.KeystrokeLog
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode keystroke log: %v", err)
+	}
+	if len(got.Events) != 1 || got.Events[0].Data != "ls\r" {
+		t.Errorf("Unexpected keystrokes: %+v", got.Events)
+	}
+
+	if rr := get(id, &middleware.Principal{Name: "user:alice"}); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", rr.Code)
+	}
+	if rr := get("999", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown keystroke log, got %d", rr.Code)
+	}
+	if rr := get("abc", nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid ID, got %d", rr.Code)
+	}
+}
+
+func TestTerminalSSHTargetValidation(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := repository.NewServerRepository(server.db)
+	guarded, err := repo.Create(&models.ServerCreate{Name: "prod-db", IPAddress: "10.0.0.7", Username: "dba", RequiresApproval: true})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		serverID string
+		want     int
+	}{
+		{"invalid ID", "abc", http.StatusBadRequest},
+		{"unknown server", "9999", http.StatusNotFound},
+		{"server requiring approval", strconv.FormatInt(guarded.ID, 10), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			server.handleTerminalWebSocket(rr, httptest.NewRequest("GET", "/api/terminal/ws?serverId="+tt.serverID, nil))
+			if rr.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if sessions := server.terminals.List(); len(sessions) != 0 {
+		t.Errorf("Expected no sessions registered, got %d", len(sessions))
+	}
+}
+
+func TestTerminalSSHServers(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := repository.NewServerRepository(server.db)
+	web, err := repo.Create(&models.ServerCreate{Name: "web1", IPAddress: "10.0.0.5", Username: "deploy"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, err := repo.Create(&models.ServerCreate{Name: "prod-db", IPAddress: "10.0.0.7", Username: "dba", RequiresApproval: true}); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	// Servers requiring approval get no SSH config entry, so the terminal cannot reach them
+	servers := server.terminalSSHServers(server.groupAccess(httptest.NewRequest("GET", "/api/terminal/ws", nil)), nil, web)
+	if len(servers) != 1 || servers[0].Name != sshTargetAlias(web) || servers[0].IPAddress != "10.0.0.5" {
+		t.Errorf("Expected only the target, got %+v", servers)
+	}
+}
+
+func TestTerminalEnvVars(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	token, err := envRepo.Create(&models.EnvVariableCreate{Name: "API_TOKEN", Value: "s3cr'et", Group: "ci"})
+	if err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+	if _, err := envRepo.Create(&models.EnvVariableCreate{Name: "REGION", Value: "eu-west-1", Group: "shared"}); err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+	prod, err := envRepo.Create(&models.EnvVariableCreate{Name: "DB_PASSWORD", Value: "hunter2", Group: "production"})
+	if err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+	if _, err := repository.NewGroupPermissionRepository(server.db).Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypeEnvVariables,
+		Group:        "production",
+		Principal:    "role:dba",
+		Permission:   models.PermissionExecute,
+	}); err != nil {
+		t.Fatalf("Failed to create group permission: %v", err)
+	}
+
+	resolve := func(query string, principal *middleware.Principal) ([]*models.EnvVariable, int) {
+		req := httptest.NewRequest("GET", "/api/terminal/ws?"+query, nil)
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		envVars, ok := server.terminalEnvVars(rr, req, server.groupAccess(req))
+		if ok {
+			return envVars, http.StatusOK
+		}
+		return nil, rr.Code
+	}
+
+	envVars, code := resolve("envVarIds="+strconv.FormatInt(token.ID, 10)+"&envGroups=shared", nil)
+	if code != http.StatusOK || len(envVars) != 2 || envVars[0].Value != "s3cr'et" || envVars[1].Name != "REGION" {
+		t.Fatalf("Unexpected env variables (%d): %+v", code, envVars)
+	}
+
+	operator := &middleware.Principal{Name: "token:ci", Roles: []string{"operator"}}
+	if _, code := resolve("envVarIds="+strconv.FormatInt(prod.ID, 10), operator); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a restricted group, got %d", code)
+	}
+	if _, code := resolve("envGroups=production", operator); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a restricted group, got %d", code)
+	}
+	dba := &middleware.Principal{Name: "token:dba", Roles: []string{"dba"}}
+	if envVars, code := resolve("envGroups=production", dba); code != http.StatusOK || len(envVars) != 1 {
+		t.Errorf("Expected production variables for dba, got %d: %+v", code, envVars)
+	}
+	if _, code := resolve("envVarIds=abc", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid ID, got %d", code)
+	}
+}
+
+func TestTerminalFilesAPI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.config = &config.Config{TerminalFileMaxSize: 1}
+
+	id, err := server.terminals.Register("user:alice", "/bin/sh", "local")
+	if err != nil {
+		t.Fatalf("Failed to register session: %v", err)
+	}
+	session, err := terminal.NewSession(nil, terminal.Shell{Name: "sh", Path: "/bin/sh"}, terminal.SSHIdentity{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer session.Close()
+	server.terminals.Attach(id, session, "")
+
+	alice := &middleware.Principal{Name: "user:alice"}
+	serve := func(handler http.HandlerFunc, req *http.Request, vars map[string]string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		vars["id"] = id
+		req = mux.SetURLVars(req, vars)
+		req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+	upload := func(name string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", name)
+		part.Write(content)
+		writer.Close()
+		req := httptest.NewRequest("POST", "/api/terminal/files/"+id, &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return serve(server.handleUploadTerminalFile, req, map[string]string{}, alice)
+	}
+
+	// Uploads keep only the base name
+	if rr := upload("../../deploy.sh", []byte("echo hi\n")); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 uploading, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(session.FilesDir(), "deploy.sh")); err != nil {
+		t.Errorf("Expected upload in the session directory: %v", err)
+	}
+	if rr := upload("big.bin", make([]byte, 2<<20)); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an upload over the limit, got %d", rr.Code)
+	}
+
+	rr := serve(server.handleListTerminalFiles, httptest.NewRequest("GET", "/api/terminal/files/"+id, nil), map[string]string{}, alice)
+	var files []models.TerminalFile
+	if err := json.NewDecoder(rr.Body).Decode(&files); err != nil || len(files) != 1 || files[0].Path != "deploy.sh" {
+		t.Fatalf("Unexpected file list: %+v, %v", files, err)
+	}
+
+	download := func(path string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/terminal/files/"+id+"/"+path, nil)
+		return serve(server.handleDownloadTerminalFile, req, map[string]string{"path": path}, principal)
+	}
+	if rr := download("deploy.sh", alice); rr.Code != http.StatusOK || rr.Body.String() != "echo hi\n" {
+		t.Errorf("Expected file contents, got %d: %q", rr.Code, rr.Body.String())
+	}
+	if rr := download("../../etc/passwd", alice); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a path outside the directory, got %d", rr.Code)
+	}
+	if rr := download("missing.txt", alice); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing file, got %d", rr.Code)
+	}
+	if rr := download("deploy.sh", &middleware.Principal{Name: "user:bob"}); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another user, got %d", rr.Code)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if _, err := repository.NewEnvVariableRepository(server.db).Create(&models.EnvVariableCreate{Name: "API_TOKEN", Value: "s3cret"}); err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleExport(rr, httptest.NewRequest("GET", "/api/export", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without passphrase, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/export", nil)
+	req.Header.Set("X-Export-Passphrase", "correct horse battery")
+	rr = httptest.NewRecorder()
+	server.handleExport(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 exporting, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment;") {
+		t.Errorf("Expected attachment, got %q", rr.Header().Get("Content-Disposition"))
+	}
+	if strings.Contains(rr.Body.String(), "s3cret") {
+		t.Error("Export contains plaintext secret")
+	}
+	export := rr.Body.String()
+
+	req = httptest.NewRequest("POST", "/api/import", strings.NewReader(export))
+	req.Header.Set("X-Export-Passphrase", "wrong horse battery")
+	rr = httptest.NewRecorder()
+	server.handleImport(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 with wrong passphrase, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api/import?strategy=rename", strings.NewReader(export))
+	req.Header.Set("X-Export-Passphrase", "correct horse battery")
+	rr = httptest.NewRecorder()
+	server.handleImport(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 importing, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result bundle.Result
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Strategy != "rename" || result.EnvVariables.Renamed != 1 {
+		t.Errorf("Unexpected import result: %+v", result)
+	}
+
+	req = httptest.NewRequest("POST", "/api/import?strategy=merge", strings.NewReader(export))
+	req.Header.Set("X-Export-Passphrase", "correct horse battery")
+	rr = httptest.NewRecorder()
+	server.handleImport(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown strategy, got %d", rr.Code)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := repository.NewEnvVariableRepository(server.db)
+	if _, err := repo.Create(&models.EnvVariableCreate{Name: "API_TOKEN", Value: "s3cret"}); err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/system/backup", nil)
+	req.Header.Set("X-Backup-Passphrase", "correct horse battery")
+	rr := httptest.NewRecorder()
+	server.handleBackup(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 backing up, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !database.IsEncryptedBackup(rr.Body.Bytes()) {
+		t.Error("Expected an encrypted backup")
+	}
+	backup := rr.Body.String()
+
+	// Changes after the backup are undone by restoring it
+	if _, err := repo.Create(&models.EnvVariableCreate{Name: "LATER", Value: "v"}); err != nil {
+		t.Fatalf("Failed to create env variable: %v", err)
+	}
+
+	restore := func(passphrase string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/system/restore", strings.NewReader(backup))
+		req.Header.Set("X-Backup-Passphrase", passphrase)
+		rr := httptest.NewRecorder()
+		server.handleRestore(rr, req)
+		return rr
+	}
+	if rr := restore("wrong horse battery"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 with wrong passphrase, got %d", rr.Code)
+	}
+	if rr := restore("correct horse battery"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"schema_version"`) {
+		t.Fatalf("Expected 200 restoring, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	vars, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to read restored database: %v", err)
+	}
+	if len(vars) != 1 || vars[0].Name != "API_TOKEN" || vars[0].Value != "s3cret" {
+		t.Errorf("Expected only the backed up variable, got %+v", vars)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleRestore(rr, httptest.NewRequest("POST", "/api/system/restore", strings.NewReader("not a database")))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid backup, got %d", rr.Code)
+	}
+}
+
+func TestWebhooksNotifiedOfExecutions(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.notifier = newNotifier(server.db)
+	defer server.notifier.Close()
+
+	received := make(chan notify.Event, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(notify.SignatureHeader) != audit.SignWebhookBody("s3cret", body) {
+			t.Errorf("Delivery is not signed with the webhook secret")
+		}
+		var event notify.Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("Delivery is not an event: %v", err)
+		}
+		received <- event
+	}))
+	defer receiver.Close()
+
+	rr := httptest.NewRecorder()
+	body := `{"name":"alerts","url":"` + receiver.URL + `","secret":"s3cret","events":["*.failed"]}`
+	server.handleCreateWebhook(rr, httptest.NewRequest("POST", "/api/webhooks", strings.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "s3cret") {
+		t.Errorf("Webhook secret was returned: %s", rr.Body.String())
+	}
+	var webhook models.Webhook
+	if err := json.NewDecoder(rr.Body).Decode(&webhook); err != nil {
+		t.Fatalf("Failed to decode webhook: %v", err)
+	}
+
+	// Only failures match the webhook's events
+	for _, command := range []string{"true", "echo boom; exit 3"} {
+		rr = httptest.NewRecorder()
+		body = `{"command":"` + command + `","user":"current"}`
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	select {
+	case event := <-received:
+		if event.Type != notify.EventCommandFailed || event.Execution.ExitCode != 3 || event.Execution.Output != "boom\n" {
+			t.Errorf("Expected the failed command, got %+v", event.Execution)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not notified of the failed command")
+	}
+
+	// The test endpoint sends a ping and records the delivery
+	req := mux.SetURLVars(httptest.NewRequest("POST", "/api/webhooks/1/test", nil), map[string]string{"id": strconv.FormatInt(webhook.ID, 10)})
+	rr = httptest.NewRecorder()
+	server.handleTestWebhook(rr, req)
+	var result models.WebhookTestResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil || !result.Delivered {
+		t.Fatalf("Expected the ping to be delivered, got %d: %+v", rr.Code, result)
+	}
+	if event := <-received; event.Type != notify.EventPing {
+		t.Errorf("Expected a ping, got %s", event.Type)
+	}
+	select {
+	case event := <-received:
+		t.Errorf("Unexpected %s event", event.Type)
+	default:
+	}
+
+	stored, err := repository.NewWebhookRepository(server.db).GetByID(webhook.ID)
+	if err != nil || stored.LastStatus != models.WebhookStatusDelivered || stored.LastDeliveredAt == nil {
+		t.Errorf("Expected the delivery to be recorded, got %+v (%v)", stored, err)
+	}
+
+	// Managing webhooks requires admin access
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/webhooks", nil)
+	requireAdmin(server.handleListWebhooks).ServeHTTP(rr, req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{Name: "user:bob"})))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", rr.Code)
+	}
+}
+
+func TestSlackNotifiesPresetsThatOptIn(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.notifier = newNotifier(server.db)
+	defer server.notifier.Close()
+
+	received := make(chan string, 4)
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer slack.Close()
+	server.slack = &notify.Slack{URL: slack.URL}
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "flaky", Content: "echo trying; exit 1"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	presetRepo := repository.NewScriptPresetRepository(server.db)
+	quiet, err := presetRepo.Create(&models.ScriptPresetCreate{Name: "quiet", ScriptID: script.ID})
+	if err != nil {
+		t.Fatalf("Failed to create preset: %v", err)
+	}
+	alerting, err := presetRepo.Create(&models.ScriptPresetCreate{Name: "alerting", ScriptID: script.ID, SlackNotify: models.SlackNotifyFailures})
+	if err != nil {
+		t.Fatalf("Failed to create preset: %v", err)
+	}
+
+	execute := func(presetID int64) {
+		rr := httptest.NewRecorder()
+		body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current","preset_id":` + strconv.FormatInt(presetID, 10) + `}`
+		server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	// Presets that do not opt in are not posted
+	execute(quiet.ID)
+	execute(alerting.ID)
+
+	select {
+	case body := <-received:
+		if !strings.Contains(body, "Script *flaky* failed on local") || !strings.Contains(body, "alerting") {
+			t.Errorf("Expected the failed run of the alerting preset, got %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Slack was not notified of the failed run")
+	}
+	select {
+	case body := <-received:
+		t.Errorf("Unexpected message %s", body)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Invalid modes are rejected
+	rr := httptest.NewRecorder()
+	body := `{"name":"loud","script_id":` + strconv.FormatInt(script.ID, 10) + `,"slack_notify":"sometimes"}`
+	server.handleCreateScriptPreset(rr, httptest.NewRequest("POST", "/api/script-presets", strings.NewReader(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid slack_notify, got %d", rr.Code)
+	}
+}
+
+func TestNotificationRulesRouteExecutions(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.notifier = newNotifier(server.db)
+	defer server.notifier.Close()
+
+	received := make(chan notify.Event, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event notify.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("Delivery is not an event: %v", err)
+		}
+		received <- event
+	}))
+	defer receiver.Close()
+
+	posted := make(chan string, 4)
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Channel string `json:"channel"`
+		}
+		json.NewDecoder(r.Body).Decode(&msg)
+		posted <- msg.Channel
+	}))
+	defer slack.Close()
+	server.slack = &notify.Slack{URL: slack.URL, Channel: "#general"}
+
+	// The webhook only subscribes to successes; the rule sends it failures with exit code 3 too
+	if _, err := repository.NewWebhookRepository(server.db).Create(&models.WebhookCreate{Name: "alerts", URL: receiver.URL, Events: []string{"*.succeeded"}}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	body := `{"name":"exit-3","kind":"command","server_group":"local","exit_codes":[3],"channels":["webhook:alerts","slack:#ops"]}`
+	server.handleCreateNotificationRule(rr, httptest.NewRequest("POST", "/api/notification-rules", strings.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	for _, command := range []string{"exit 4", "exit 3"} {
+		rr = httptest.NewRecorder()
+		body = `{"command":"` + command + `","user":"current"}`
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	select {
+	case event := <-received:
+		if event.Type != notify.EventCommandFailed || event.Execution.ExitCode != 3 {
+			t.Errorf("Expected the command that exited with 3, got %+v", event.Execution)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not notified through the rule")
+	}
+	select {
+	case channel := <-posted:
+		if channel != "#ops" {
+			t.Errorf("Expected the rule's Slack channel, got %q", channel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Slack was not notified through the rule")
+	}
+	select {
+	case event := <-received:
+		t.Errorf("Unexpected delivery for exit code %d", event.Execution.ExitCode)
+	case channel := <-posted:
+		t.Errorf("Unexpected Slack post to %q", channel)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Rules naming unknown webhooks are rejected, and managing rules requires admin access
+	rr = httptest.NewRecorder()
+	body = `{"name":"typo","channels":["webhook:alert"]}`
+	server.handleCreateNotificationRule(rr, httptest.NewRequest("POST", "/api/notification-rules", strings.NewReader(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown webhook, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/notification-rules", nil)
+	requireAdmin(server.handleListNotificationRules).ServeHTTP(rr, req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{Name: "user:bob"})))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", rr.Code)
+	}
+}
+
+func TestExecutionQueue(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.queue = executor.NewQueue(executor.QueueConfig{MaxRunning: 1, Timeout: 20 * time.Millisecond})
+
+	// With the only slot taken, executions time out waiting
+	release, err := server.queue.Acquire(context.Background(), "", 0, nil)
+	if err != nil {
+		t.Fatalf("Failed to take the slot: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"true","user":"current"}`)))
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 503 with Retry-After, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleGetExecutionQueue(rr, httptest.NewRequest("GET", "/api/executions/queue", nil))
+	var stats executor.QueueStats
+	if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil || stats.Running != 1 || stats.MaxRunning != 1 {
+		t.Errorf("Expected one running execution, got %+v (%v)", stats, err)
+	}
+
+	release()
+	rr = httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"true","user":"current"}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 once the slot is free, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if stats := server.queue.Stats(); stats.Running != 0 {
+		t.Errorf("Expected the slot to be released, got %+v", stats)
+	}
+}
+
+func TestExclusiveScriptLock(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.locks = executor.NewLocks()
+
+	scriptRepo := repository.NewBashScriptRepository(server.db)
+	backup, err := scriptRepo.Create(&models.BashScriptCreate{Name: "backup", Content: "echo done", Exclusive: true})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	other, err := scriptRepo.Create(&models.BashScriptCreate{Name: "other", Content: "echo done"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	preset, err := repository.NewScriptPresetRepository(server.db).Create(&models.ScriptPresetCreate{Name: "nightly", ScriptID: other.ID, Exclusive: true})
+	if err != nil {
+		t.Fatalf("Failed to create preset: %v", err)
+	}
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+		return rr
+	}
+	backupBody := `{"script_id":` + strconv.FormatInt(backup.ID, 10) + `,"user":"current"}`
+
+	// A run of the script holds its lock
+	unlock, _, err := server.locks.Acquire(context.Background(), executor.LockInfo{Key: "script:" + strconv.FormatInt(backup.ID, 10), Script: "backup", Server: "local", HeldBy: "user:alice"}, false)
+	if err != nil {
+		t.Fatalf("Failed to take the lock: %v", err)
+	}
+	if rr := execute(backupBody); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "user:alice") {
+		t.Errorf("Expected 409 naming the holder, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Syntax checks run nothing, so they are not blocked
+	if rr := execute(`{"script_id":` + strconv.FormatInt(backup.ID, 10) + `,"user":"current","dry_run":true}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected a dry run to pass the lock, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleGetExecutionLocks(rr, httptest.NewRequest("GET", "/api/executions/locks", nil))
+	var held []executor.LockInfo
+	if err := json.NewDecoder(rr.Body).Decode(&held); err != nil || len(held) != 1 || held[0].Script != "backup" {
+		t.Errorf("Expected the backup lock, got %+v (%v)", held, err)
+	}
+
+	unlock()
+	if rr := execute(backupBody); rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 once the lock is released, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if held := server.locks.Held(); len(held) != 0 {
+		t.Errorf("Expected the lock to be released after the run, got %+v", held)
+	}
+
+	// An exclusive preset locks the script it was saved for
+	unlock, _, _ = server.locks.Acquire(context.Background(), executor.LockInfo{Key: "script:" + strconv.FormatInt(other.ID, 10)}, false)
+	defer unlock()
+	otherBody := `{"script_id":` + strconv.FormatInt(other.ID, 10) + `,"user":"current"`
+	if rr := execute(otherBody + `}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected a plain run of a non-exclusive script, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := execute(otherBody + `,"preset_id":` + strconv.FormatInt(preset.ID, 10) + `}`); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 from an exclusive preset, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestScriptExitCodeSeverity(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	create := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleCreateBashScript(rr, httptest.NewRequest("POST", "/api/bash-scripts", strings.NewReader(body)))
+		return rr
+	}
+	if rr := create(`{"name":"bad","content":"exit 1","exit_codes":{"1":"info"}}`); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "exit_codes") {
+		t.Errorf("Expected 400 for an unknown severity, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr := create(`{"name":"check-disk","content":"read code; exit $code","exit_codes":{"1":"warning","2+":"critical"}}`)
+	var script models.BashScript
+	if err := json.NewDecoder(rr.Body).Decode(&script); err != nil || rr.Code != http.StatusCreated || script.ExitCodes["1"] != models.SeverityWarning {
+		t.Fatalf("Expected the script with its exit codes, got %d (%v)", rr.Code, err)
+	}
+
+	// History records the severity the script declares for the exit code
+	history := repository.NewCommandHistoryRepository(server.db)
+	for code, want := range map[string]string{"0": models.SeverityOK, "1": models.SeverityWarning, "3": models.SeverityCritical} {
+		body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current","stdin":"` + code + `"}`
+		rr := httptest.NewRecorder()
+		server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+		var result models.ScriptResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil || result.HistoryID == 0 || result.Severity != want {
+			t.Fatalf("Expected a recorded run with severity %q, got %d: %+v (%v)", want, rr.Code, result, err)
+		}
+		if entry, err := history.GetByID(result.HistoryID); err != nil || entry.Severity != want {
+			t.Errorf("Exit code %s: expected severity %q, got %+v (%v)", code, want, entry, err)
+		}
+	}
+}
+
+func TestExecuteCommandRetries(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	rr := httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"true","user":"current","retries":9}`)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "retries") {
+		t.Errorf("Expected 400 for too many retries, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Refused connections are retried and every attempt is reported
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	target, err := repository.NewServerRepository(server.db).Create(&models.ServerCreate{Name: "down", IPAddress: "127.0.0.1", Port: port})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	body := `{"command":"true","user":"deploy","ssh_password":"secret","is_remote":true,"server_id":` + strconv.FormatInt(target.ID, 10) + `,"retries":2,"retry_backoff_ms":1}`
+	rr = httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+	var result models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.ExitCode != -1 || len(result.Attempts) != 3 || result.Attempts[0].Error == "" {
+		t.Errorf("Expected three failed attempts, got %+v", result)
+	}
+}
+
+func TestExecutionOutputLimit(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.outputLogs = outputlog.New(t.TempDir(), 100)
+
+	rr := httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"seq 1 1000","user":"current"}`)))
+	var result models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if !result.Truncated || result.HistoryID == 0 || !strings.HasSuffix(result.Output, "999\n1000\n") || len(result.Output) > 200 {
+		t.Fatalf("Expected the end of the output, got %+v", result)
+	}
+
+	history, err := repository.NewCommandHistoryRepository(server.db).GetByID(result.HistoryID)
+	if err != nil || !history.OutputTruncated || history.OutputSize != 3893 || history.Output != result.Output {
+		t.Errorf("Expected truncated history of 3893 bytes, got %+v (%v)", history, err)
+	}
+
+	// The full output is downloadable
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/history/1/log", nil), map[string]string{"id": strconv.FormatInt(result.HistoryID, 10)})
+	rr = httptest.NewRecorder()
+	server.handleGetHistoryLog(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("Expected the log, got %d: %s", rr.Code, rr.Body.String())
+	}
+	gz, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("Log is not gzip: %v", err)
+	}
+	full, _ := io.ReadAll(gz)
+	if !strings.HasPrefix(string(full), "1\n2\n3\n") || len(full) != 3893 {
+		t.Errorf("Expected the full output, got %d bytes", len(full))
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	body := `{"command":"printf '\\033[31mred\\033[0m'","user":"current","strip_ansi":true}`
+	rr := httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+	var result models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil || result.Output != "red" {
+		t.Fatalf("Expected plain output, got %q (%v)", result.Output, err)
+	}
+
+	// History keeps the colors unless asked for plain text
+	get := func(query string) string {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/history/1"+query, nil), map[string]string{"id": strconv.FormatInt(result.HistoryID, 10)})
+		rr := httptest.NewRecorder()
+		server.handleGetCommandHistory(rr, req)
+		var history models.CommandHistory
+		json.NewDecoder(rr.Body).Decode(&history)
+		return history.Output
+	}
+	if output := get(""); output != "\x1b[31mred\x1b[0m" {
+		t.Errorf("Expected raw output in history, got %q", output)
+	}
+	if output := get("?strip_ansi=true"); output != "red" {
+		t.Errorf("Expected plain output from history, got %q", output)
+	}
+}
+
+func TestServerGroupEndpoints(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	send := func(handler http.HandlerFunc, method, target, body string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if vars != nil {
+			req = mux.SetURLVars(req, vars)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	rr := send(server.handleCreateServerGroup, "POST", "/api/server-groups", `{"name":"production","default_username":"deploy"}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var group models.ServerGroup
+	json.NewDecoder(rr.Body).Decode(&group)
+	groupID := strconv.FormatInt(group.ID, 10)
+	vars := map[string]string{"id": groupID}
+
+	if rr := send(server.handleCreateServerGroup, "POST", "/api/server-groups", `{"name":"bad","default_username":"bad user"}`, nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid default username, got %d", rr.Code)
+	}
+
+	// Servers requiring approval stop before anything runs, so every member reports a pending approval
+	serverRepo := repository.NewServerRepository(server.db)
+	var ids []string
+	for _, name := range []string{"web1", "web2"} {
+		srv, err := serverRepo.Create(&models.ServerCreate{Name: name, IPAddress: "10.0.0.1", RequiresApproval: true})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		ids = append(ids, strconv.FormatInt(srv.ID, 10))
+	}
+	if rr := send(server.handleAddServerGroupServers, "POST", "/api/server-groups/"+groupID+"/servers", `{"server_ids":[`+strings.Join(ids, ",")+`]}`, vars); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 adding servers, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = send(server.handleExecuteServerGroupCommand, "POST", "/api/server-groups/"+groupID+"/commands/execute", `{"command":"uptime","server_name":"ignored"}`, vars)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var results []models.ServerGroupExecutionResult
+	json.NewDecoder(rr.Body).Decode(&results)
+	if len(results) != 2 {
+		t.Fatalf("Expected a result per server, got %+v", results)
+	}
+	for _, result := range results {
+		var approval models.Approval
+		json.Unmarshal(result.Result, &approval)
+		if result.Status != http.StatusAccepted || approval.Server != result.Server {
+			t.Errorf("Expected a pending approval on %s, got %d: %s", result.Server, result.Status, result.Result)
+		}
+	}
+
+	// Every server counts as one execution against the caller's quota
+	server.quotas = middleware.NewQuotas(&middleware.QuotaConfig{Lookup: func(*middleware.Principal) *middleware.Quota {
+		return &middleware.Quota{ExecutionsPerHour: 3}
+	}})
+	principal := &middleware.Principal{Name: "token:ci", Admin: true}
+	for _, expected := range [][]int{{http.StatusAccepted, http.StatusAccepted}, {http.StatusAccepted, http.StatusTooManyRequests}} {
+		req := httptest.NewRequest("POST", "/api/server-groups/"+groupID+"/commands/execute", strings.NewReader(`{"command":"uptime"}`))
+		req = mux.SetURLVars(req.WithContext(middleware.WithPrincipal(req.Context(), principal)), vars)
+		rr := httptest.NewRecorder()
+		server.handleExecuteServerGroupCommand(rr, req)
+		results = nil
+		json.NewDecoder(rr.Body).Decode(&results)
+		var statuses []int
+		for _, result := range results {
+			statuses = append(statuses, result.Status)
+		}
+		slices.Sort(statuses)
+		if !slices.Equal(statuses, expected) {
+			t.Errorf("Expected statuses %v, got %v", expected, statuses)
+		}
+	}
+	if usage := server.quotas.Usage(principal.Name); usage.LastHour != 3 || usage.Running != 0 {
+		t.Errorf("Expected 3 executions counted and none running, got %+v", usage)
+	}
+	server.quotas = nil
+
+	// Only empty groups can be deleted
+	if rr := send(server.handleDeleteServerGroup, "DELETE", "/api/server-groups/"+groupID, "", vars); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 deleting a group with servers, got %d", rr.Code)
+	}
+	for _, id := range ids {
+		if rr := send(server.handleRemoveServerGroupServer, "DELETE", "/api/server-groups/"+groupID+"/servers/"+id, "", map[string]string{"id": groupID, "serverId": id}); rr.Code != http.StatusNoContent {
+			t.Errorf("Expected 204 removing a server, got %d", rr.Code)
+		}
+	}
+	if rr := send(server.handleExecuteServerGroupCommand, "POST", "/api/server-groups/"+groupID+"/commands/execute", `{"command":"uptime"}`, vars); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 executing on an empty group, got %d", rr.Code)
+	}
+	if rr := send(server.handleDeleteServerGroup, "DELETE", "/api/server-groups/"+groupID, "", vars); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 deleting an empty group, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := send(server.handleGetServerGroup, "GET", "/api/server-groups/"+groupID, "", vars); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted group, got %d", rr.Code)
+	}
+}
+
+func TestKubernetesExecution(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	send := func(handler http.HandlerFunc, method, path, body string, ctx context.Context) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(method, path, strings.NewReader(body)).WithContext(ctx))
+		return rr
+	}
+	admin := context.Background()
+
+	if rr := send(server.handleExecuteCommand, "POST", "/api/commands/execute", `{"command":"true","kubernetes":{"pod":"web-1"}}`, admin); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "not configured") {
+		t.Errorf("Expected 400 without a cluster, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// The fake API server lists two pods and echoes the commands run in them
+	cluster := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/pods":
+			w.Write([]byte(`{"items":[
+				{"metadata":{"name":"web-1","namespace":"default"},"spec":{"containers":[{"name":"app"}]},"status":{"phase":"Pending"}},
+				{"metadata":{"name":"web-2","namespace":"default"},"spec":{"containers":[{"name":"app"}]},"status":{"phase":"Running"}}
+			]}`))
+		case "/api/v1/namespaces/default/pods/web-2/exec":
+			upgrader := websocket.Upgrader{Subprotocols: []string{"v5.channel.k8s.io"}}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.WriteMessage(websocket.BinaryMessage, append([]byte{1}, strings.Join(r.URL.Query()["command"], " ")...))
+			conn.WriteMessage(websocket.BinaryMessage, append([]byte{3}, `{"status":"Success"}`...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cluster.Close()
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	os.WriteFile(kubeconfig, []byte("current-context: test\nclusters:\n- name: test\n  cluster:\n    server: "+cluster.URL+"\ncontexts:\n- name: test\n  context:\n    cluster: test\n"), 0600)
+	kube, err := newKubernetesClient(&config.Config{KubernetesKubeconfig: kubeconfig})
+	if err != nil {
+		t.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+	server.kube = kube
+
+	rr := send(server.handleListKubernetesPods, "GET", "/api/kubernetes/pods", "", admin)
+	var pods []models.KubernetesPod
+	if err := json.NewDecoder(rr.Body).Decode(&pods); err != nil || len(pods) != 2 {
+		t.Fatalf("Expected two pods, got %d: %v", rr.Code, err)
+	}
+
+	// A selector runs in the first running pod, recorded in history under its namespace and name
+	rr = send(server.handleExecuteCommand, "POST", "/api/commands/execute", `{"command":"echo hi","kubernetes":{"selector":"app=web"}}`, admin)
+	var result models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.ExitCode != 0 || result.Output != "sh -c echo hi" {
+		t.Fatalf("Expected the command to run through sh in the pod, got %+v", result)
+	}
+	history, err := repository.NewCommandHistoryRepository(server.db).GetByID(result.HistoryID)
+	if err != nil || history.Server != "k8s:default/web-2" {
+		t.Errorf("Expected history for the pod, got %+v (%v)", history, err)
+	}
+
+	if rr := send(server.handleExecuteCommand, "POST", "/api/commands/execute", `{"command":"true","is_remote":true,"kubernetes":{"pod":"web-2"}}`, admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 combining is_remote and kubernetes, got %d", rr.Code)
+	}
+	if rr := send(server.handleExecuteCommand, "POST", "/api/commands/execute", `{"command":"true","kubernetes":{"namespace":"default"}}`, admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a pod or selector, got %d", rr.Code)
+	}
+
+	// Namespaces are restricted like other groups, through the pods resource type
+	if _, err := repository.NewGroupPermissionRepository(server.db).Create(&models.GroupPermissionCreate{
+		ResourceType: models.ResourceTypePods,
+		Group:        "default",
+		Principal:    "role:k8s",
+		Permission:   models.PermissionExecute,
+	}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	ci := middleware.WithPrincipal(context.Background(), &middleware.Principal{Name: "token:ci"})
+	if rr := send(server.handleExecuteCommand, "POST", "/api/commands/execute", `{"command":"true","kubernetes":{"pod":"web-2"}}`, ci); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a restricted namespace, got %d", rr.Code)
+	}
+	if rr := send(server.handleListKubernetesPods, "GET", "/api/kubernetes/pods?namespace=default", "", ci); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 listing a restricted namespace, got %d", rr.Code)
+	}
+}
+
+func TestSSMExecution(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	send := func(handler http.HandlerFunc, method, path, body string, vars map[string]string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, mux.SetURLVars(httptest.NewRequest(method, path, strings.NewReader(body)), vars))
+		return rr
+	}
+
+	// The fake SSM API runs every command successfully and echoes the script sent
+	var scripts []string
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIAEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var input struct {
+			InstanceIds []string
+			Parameters  map[string][]string
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSSM.SendCommand":
+			scripts = append(scripts, input.InstanceIds[0]+": "+input.Parameters["commands"][0])
+			w.Write([]byte(`{"Command":{"CommandId":"cmd-1"}}`))
+		case "AmazonSSM.GetCommandInvocation":
+			json.NewEncoder(w).Encode(map[string]any{"Status": "Success", "ResponseCode": 0, "StandardOutputContent": scripts[len(scripts)-1]})
+		}
+	}))
+	defer fake.Close()
+	server.ssmEndpoint = fake.URL
+
+	invalid := []string{
+		`{"name":"web","transport":"telnet"}`,
+		`{"name":"web","transport":"ssm","ssm_instance_id":"i-0abc123def4567890"}`,
+		`{"name":"web","transport":"ssm","ssm_region":"eu-west-1"}`,
+		`{"name":"web","transport":"ssm","ssm_region":"eu-west-1","ssm_instance_id":"i-0abc123def4567890","ssm_access_key_id":"AKIAEXAMPLE"}`,
+	}
+	for _, body := range invalid {
+		if rr := send(server.handleCreateServer, "POST", "/api/servers", body, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 creating %s, got %d", body, rr.Code)
+		}
+	}
+
+	rr := send(server.handleCreateServer, "POST", "/api/servers", `{"name":"web","transport":"ssm","ssm_region":"eu-west-1","ssm_instance_id":"i-0abc123def4567890","ssm_access_key_id":"AKIAEXAMPLE","ssm_secret_access_key":"secret"}`, nil)
+	var created models.Server
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %v", rr.Code, err)
+	}
+	if created.Transport != models.ServerTransportSSM || !created.HasSSMSecretAccessKey || strings.Contains(rr.Body.String(), `"secret"`) {
+		t.Errorf("Expected the ssm server without its secret, got %+v", created)
+	}
+	id := strconv.FormatInt(created.ID, 10)
+
+	// The command runs through Systems Manager as the requested user, recorded in history
+	rr = send(server.handleExecuteCommand, "POST", "/api/commands/execute", `{"command":"uptime","user":"deploy","is_remote":true,"server_id":`+id+`}`, nil)
+	var result models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.ExitCode != 0 || result.Output != `i-0abc123def4567890: sudo -H -u 'deploy' -- sh -c 'uptime'` {
+		t.Fatalf("Expected the command to run through SSM, got %+v", result)
+	}
+	history, err := repository.NewCommandHistoryRepository(server.db).GetByID(result.HistoryID)
+	if err != nil || history.Server != "web" {
+		t.Errorf("Expected history for the server, got %+v (%v)", history, err)
+	}
+
+	// Removing the stored credentials keeps the other settings; an incomplete transport is rejected
+	if rr := send(server.handleUpdateServer, "PUT", "/api/servers/"+id, `{"name":"web","ssm_region":"eu-west"}`, map[string]string{"id": id}); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid region, got %d", rr.Code)
+	}
+	if rr := send(server.handleUpdateServer, "PUT", "/api/servers/"+id, `{"name":"web","ssm_secret_access_key":"other"}`, map[string]string{"id": id}); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a secret without an access key ID, got %d", rr.Code)
+	}
+	rr = send(server.handleUpdateServer, "PUT", "/api/servers/"+id, `{"name":"web","ssm_access_key_id":""}`, map[string]string{"id": id})
+	var updated models.Server
+	if err := json.NewDecoder(rr.Body).Decode(&updated); err != nil || updated.HasSSMSecretAccessKey || updated.SSMAccessKeyID != "" || updated.SSMRegion != "eu-west-1" {
+		t.Errorf("Expected the credentials removed, got %d: %+v", rr.Code, updated)
+	}
+}
+
+func TestScriptUploadValidation(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "deploy", Content: "echo \"$1\""})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	target, err := repository.NewServerRepository(server.db).Create(&models.ServerCreate{Name: "worker", Transport: models.ServerTransportSSM, SSMRegion: "eu-west-1", SSMInstanceID: "i-0abc123def4567890"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	id := strconv.FormatInt(script.ID, 10)
+
+	for _, tt := range []struct{ body, field string }{
+		{`{"script_id":` + id + `,"user":"current","args":["v2"]}`, "args"},
+		{`{"script_id":` + id + `,"user":"current","upload":true}`, "upload"},
+		{`{"script_id":` + id + `,"is_remote":true,"server_id":` + strconv.FormatInt(target.ID, 10) + `,"upload":true,"args":["v2"]}`, "upload"},
+	} {
+		rr := httptest.NewRecorder()
+		server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(tt.body)))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), tt.field) {
+			t.Errorf("Expected 400 for %s, got %d: %s", tt.field, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestRunAsRemoteUserValidation(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	target, err := repository.NewServerRepository(server.db).Create(&models.ServerCreate{Name: "worker", Transport: models.ServerTransportSSM, SSMRegion: "eu-west-1", SSMInstanceID: "i-0abc123def4567890"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	serverID := strconv.FormatInt(target.ID, 10)
+
+	for _, tt := range []struct{ body, field string }{
+		{`{"command":"id","user":"current","run_as_remote_user":"deploy"}`, "run_as_remote_user"},
+		{`{"command":"id","is_remote":true,"server_id":` + serverID + `,"run_as_remote_user":"bad user"}`, "run_as_remote_user"},
+		{`{"command":"cat","is_remote":true,"server_id":` + serverID + `,"run_as_remote_user":"deploy","sudo_password":"secret","stdin":"input"}`, "stdin"},
+		{`{"command":"id","is_remote":true,"server_id":` + serverID + `,"run_as_remote_user":"deploy"}`, "run_as_remote_user"},
+	} {
+		rr := httptest.NewRecorder()
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(tt.body)))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), tt.field) {
+			t.Errorf("Expected 400 for %s, got %d: %s", tt.field, rr.Code, rr.Body.String())
+		}
+	}
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "deploy", Content: "id"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"is_remote":true,"server_id":` + serverID + `,"run_as_remote_user":"deploy","upload":true}`
+	rr := httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "upload") {
+		t.Errorf("Expected upload with run_as_remote_user to be rejected, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServerHostKeySettings(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	send := func(handler http.HandlerFunc, method, path, body string, vars map[string]string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, mux.SetURLVars(httptest.NewRequest(method, path, strings.NewReader(body)), vars))
+		return rr
+	}
+	const fingerprint = "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"
+
+	invalid := []string{
+		`{"name":"web","host_key_policy":"ask"}`,
+		`{"name":"web","host_key_fingerprint":"nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"}`,
+		`{"name":"web","host_key_policy":"insecure","host_key_fingerprint":"` + fingerprint + `"}`,
+	}
+	for _, body := range invalid {
+		if rr := send(server.handleCreateServer, "POST", "/api/servers", body, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 creating %s, got %d", body, rr.Code)
+		}
+	}
+
+	// Servers trust new host keys on first use unless they set a policy
+	rr := send(server.handleCreateServer, "POST", "/api/servers", `{"name":"web"}`, nil)
+	var created models.Server
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %v", rr.Code, err)
+	}
+	if created.HostKeyPolicy != models.HostKeyPolicyTOFU {
+		t.Errorf("Expected the tofu policy by default, got %q", created.HostKeyPolicy)
+	}
+	id := strconv.FormatInt(created.ID, 10)
+
+	rr = send(server.handleUpdateServer, "PUT", "/api/servers/"+id, `{"name":"web","host_key_policy":"strict","host_key_fingerprint":"`+fingerprint+`"}`, map[string]string{"id": id})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	updated, err := repository.NewServerRepository(server.db).GetByID(created.ID)
+	if err != nil || updated.HostKeyPolicy != models.HostKeyPolicyStrict || updated.HostKeyFingerprint != fingerprint {
+		t.Errorf("Expected the strict policy with the pinned key, got %+v (%v)", updated, err)
+	}
+
+	// The pinned fingerprint is checked with the policy it will have after the update
+	if rr := send(server.handleUpdateServer, "PUT", "/api/servers/"+id, `{"name":"web","host_key_policy":"insecure"}`, map[string]string{"id": id}); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for the insecure policy with a pinned key, got %d", rr.Code)
+	}
+	if rr := send(server.handleUpdateServer, "PUT", "/api/servers/"+id, `{"name":"web","host_key_policy":"insecure","host_key_fingerprint":""}`, map[string]string{"id": id}); rr.Code != http.StatusOK {
+		t.Errorf("Expected the pinned key to be removed, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestSystemUsersImport(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.passwdFile = filepath.Join(t.TempDir(), "passwd")
+	passwd := "root:x:0:0:root:/root:/bin/bash\n" +
+		"alice:x:1000:1000:Alice Smith:/home/alice:/bin/bash\n" +
+		"bob:x:1001:1001::/home/bob:/bin/sh\n" +
+		"svc:x:1002:1002::/srv/svc:/usr/sbin/nologin\n"
+	if err := os.WriteFile(server.passwdFile, []byte(passwd), 0644); err != nil {
+		t.Fatalf("Failed to write passwd file: %v", err)
+	}
+	if _, err := repository.NewLocalUserRepository(server.db).Create(&models.LocalUserCreate{Name: "bob"}); err != nil {
+		t.Fatalf("Failed to create local user: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	requireAdmin(server.handleListSystemUsers).ServeHTTP(rr, httptest.NewRequest("GET", "/api/system/users", nil))
+	var users []SystemUserResponse
+	if err := json.NewDecoder(rr.Body).Decode(&users); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(users) != 2 || users[0].Username != "alice" || users[0].Name != "Alice Smith" || users[0].Imported || !users[1].Imported {
+		t.Errorf("Expected alice to import and bob imported, got %+v", users)
+	}
+
+	// Only discovered login users can be imported
+	for _, body := range []string{`{"names":["root"]}`, `{"names":["svc"]}`} {
+		rr = httptest.NewRecorder()
+		server.handleImportLocalUsers(rr, httptest.NewRequest("POST", "/api/local-users/import", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	// An empty list imports every discovered user, skipping the ones already listed
+	rr = httptest.NewRecorder()
+	server.handleImportLocalUsers(rr, httptest.NewRequest("POST", "/api/local-users/import", strings.NewReader(`{}`)))
+	var result LocalUserImportResponse
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result.Imported) != 1 || result.Imported[0].Name != "alice" || len(result.Skipped) != 1 || result.Skipped[0] != "bob" {
+		t.Errorf("Expected alice imported and bob skipped, got %+v", result)
+	}
+
+	// Non-admins can neither list nor import
+	operator := &middleware.Principal{Name: "token:ci", Roles: []string{"operator"}}
+	rr = httptest.NewRecorder()
+	requireAdmin(server.handleListSystemUsers).ServeHTTP(rr, httptest.NewRequest("GET", "/api/system/users", nil).WithContext(middleware.WithPrincipal(context.Background(), operator)))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", rr.Code)
+	}
+}
+
+func TestLocalUserSudoTest(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	current, err := user.Current()
+	if err != nil {
+		t.Fatalf("Failed to get current user: %v", err)
+	}
+	localUser, err := repository.NewLocalUserRepository(server.db).Create(&models.LocalUserCreate{Name: current.Username})
+	if err != nil {
+		t.Fatalf("Failed to create local user: %v", err)
+	}
+
+	send := func(id string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/local-users/"+id+"/test-sudo", nil)
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		rr := httptest.NewRecorder()
+		requireAdmin(server.handleTestLocalUserSudo).ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The server's own user needs no sudo, so nothing is run
+	rr := send(strconv.FormatInt(localUser.ID, 10), nil)
+	var result SudoTestResponse
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if rr.Code != http.StatusOK || result.SudoRequired || result.User != current.Username {
+		t.Errorf("Expected no sudo for the server's own user, got %d %+v", rr.Code, result)
+	}
+
+	if rr := send("999", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown local user, got %d", rr.Code)
+	}
+	operator := &middleware.Principal{Name: "token:ci", Roles: []string{"operator"}}
+	if rr := send(strconv.FormatInt(localUser.ID, 10), operator); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", rr.Code)
+	}
+}
+
+func TestSessionSudoPassword(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.config = &config.Config{}
+	server.sudoPasswords, _ = middleware.NewSudoPasswordCache(time.Minute)
+	session := &middleware.Session{ID: "s1", Username: "admin", ExpiresAt: time.Now().Add(time.Hour)}
+	withSession := func(req *http.Request) *http.Request {
+		return req.WithContext(middleware.WithSession(req.Context(), session))
+	}
+
+	// A session is required
+	rr := httptest.NewRecorder()
+	server.handleSetSudoPassword(rr, httptest.NewRequest("POST", "/api/auth/sudo-password", strings.NewReader(`{"sudo_password":"secret"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a session, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleSetSudoPassword(rr, withSession(httptest.NewRequest("POST", "/api/auth/sudo-password", strings.NewReader(`{"sudo_password":""}`))))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty password, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleSetSudoPassword(rr, withSession(httptest.NewRequest("POST", "/api/auth/sudo-password", strings.NewReader(`{"sudo_password":"secret"}`))))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleGetSudoPassword(rr, withSession(httptest.NewRequest("GET", "/api/auth/sudo-password", nil)))
+	if strings.Contains(rr.Body.String(), "secret") || !strings.Contains(rr.Body.String(), `"cached":true`) {
+		t.Errorf("Expected the status without the password, got %s", rr.Body.String())
+	}
+
+	// Executions of the session use the cached password unless they send one
+	req := withSession(httptest.NewRequest("POST", "/api/commands/execute", nil))
+	if got := server.sudoPassword(req, ""); got != "secret" {
+		t.Errorf("Expected the cached password, got %q", got)
+	}
+	if got := server.sudoPassword(req, "other"); got != "other" {
+		t.Errorf("Expected the sent password, got %q", got)
+	}
+	if got := server.sudoPassword(httptest.NewRequest("POST", "/api/commands/execute", nil), ""); got != "" {
+		t.Errorf("Expected no password without a session, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleDeleteSudoPassword(rr, withSession(httptest.NewRequest("DELETE", "/api/auth/sudo-password", nil)))
+	if rr.Code != http.StatusNoContent || server.sudoPassword(req, "") != "" {
+		t.Errorf("Expected the password to be forgotten, got %d", rr.Code)
+	}
+
+	// Caching can be disabled
+	server.sudoPasswords = nil
+	rr = httptest.NewRecorder()
+	server.handleSetSudoPassword(rr, withSession(httptest.NewRequest("POST", "/api/auth/sudo-password", strings.NewReader(`{"sudo_password":"secret"}`))))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 when caching is disabled, got %d", rr.Code)
+	}
+}
+
+func TestSavedCommandPlaceholders(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	create := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleCreateSavedCommand(rr, httptest.NewRequest("POST", "/api/saved-commands", strings.NewReader(body)))
+		return rr
+	}
+
+	// Declarations must name placeholders of the command, once each
+	for _, body := range []string{
+		`{"name":"bad","command":"echo {{name}}","placeholders":[{"name":"other"}]}`,
+		`{"name":"bad","command":"echo {{name}}","placeholders":[{"name":"name"},{"name":"name"}]}`,
+		`{"name":"bad","command":"echo {{name}}","placeholders":[{"name":"na-me"}]}`,
+		// Quoted values are only safe outside other quotes
+		`{"name":"bad","command":"echo \"{{msg}}\""}`,
+		`{"name":"bad","command":"grep '{{pattern}}' /var/log/syslog"}`,
+	} {
+		if rr := create(body); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "placeholders") {
+			t.Errorf("%s: expected 400, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := create(`{"name":"greet","command":"echo {{greeting}} {{name}}","user":"current","placeholders":[{"name":"greeting","prompt":"Greeting","default":"hello"},{"name":"name","prompt":"Who to greet"}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var cmd models.SavedCommand
+	if err := json.NewDecoder(rr.Body).Decode(&cmd); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	stored, err := repository.NewSavedCommandRepository(server.db).GetByID(cmd.ID)
+	if err != nil || len(stored.Placeholders) != 2 || stored.Placeholders[0].Default != "hello" || stored.Placeholders[1].Prompt != "Who to greet" {
+		t.Fatalf("Expected the placeholders to be stored, got %+v %v", stored, err)
+	}
+
+	req := httptest.NewRequest("PUT", "/api/saved-commands/"+strconv.FormatInt(cmd.ID, 10), strings.NewReader(`{"command":"echo \"{{greeting}}\" {{name}}"}`))
+	rr = httptest.NewRecorder()
+	server.handleUpdateSavedCommand(rr, mux.SetURLVars(req, map[string]string{"id": strconv.FormatInt(cmd.ID, 10)}))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "inside quotes") {
+		t.Errorf("Expected 400 updating to a quoted placeholder, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/saved-commands/"+strconv.FormatInt(cmd.ID, 10)+"/execute", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.FormatInt(cmd.ID, 10)})
+		rr := httptest.NewRecorder()
+		server.handleExecuteSavedCommand(rr, req)
+		return rr
+	}
+
+	// A placeholder without a default needs a value, and values must match placeholders
+	for _, body := range []string{`{}`, `{"values":{"name":"x","other":"y"}}`} {
+		if rr := execute(body); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "values") {
+			t.Errorf("%s: expected 400, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	// Values are quoted, so shell syntax in them is not run; the command itself cannot be replaced
+	rr = execute(`{"values":{"name":"it's me; echo pwned"},"command":"echo replaced"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.CommandResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if strings.TrimSpace(result.Output) != "hello it's me; echo pwned" {
+		t.Errorf("Unexpected output %q", result.Output)
+	}
+
+	history, err := repository.NewCommandHistoryRepository(server.db).GetByID(result.HistoryID)
+	if err != nil || history.Command != `echo 'hello' 'it'\''s me; echo pwned'` {
+		t.Errorf("Expected history to record the resolved command, got %+v %v", history, err)
+	}
+}
+
+func TestFavoritesAndRecent(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	asPrincipal := func(req *http.Request, name string) *http.Request {
+		return req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{Name: name}))
+	}
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "hello", Content: "echo hello"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	cmd, err := repository.NewSavedCommandRepository(server.db).Create(&models.SavedCommandCreate{Name: "date", Command: "date", User: "current"})
+	if err != nil {
+		t.Fatalf("Failed to create saved command: %v", err)
+	}
+	cmdID := strconv.FormatInt(cmd.ID, 10)
+
+	// Alice runs the saved command, then the script
+	req := httptest.NewRequest("POST", "/api/saved-commands/"+cmdID+"/execute", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+	server.handleExecuteSavedCommand(rr, asPrincipal(mux.SetURLVars(req, map[string]string{"id": cmdID}), "user:alice"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for saved command, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	body := `{"script_id":` + strconv.FormatInt(script.ID, 10) + `,"user":"current"}`
+	server.handleExecuteScript(rr, asPrincipal(httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)), "user:alice"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for script, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	favorite := func(method, resourceType, id, principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/favorites/"+resourceType+"/"+id, nil)
+		req = asPrincipal(mux.SetURLVars(req, map[string]string{"type": resourceType, "id": id}), principal)
+		rr := httptest.NewRecorder()
+		if method == "PUT" {
+			server.handleAddFavorite(rr, req)
+		} else {
+			server.handleDeleteFavorite(rr, req)
+		}
+		return rr
+	}
+	if rr := favorite("PUT", "ssh_keys", "1", "user:alice"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported type, got %d", rr.Code)
+	}
+	if rr := favorite("PUT", "servers", "999", "user:alice"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing server, got %d", rr.Code)
+	}
+	for range 2 {
+		if rr := favorite("PUT", "saved_commands", cmdID, "user:alice"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"date"`) {
+			t.Fatalf("Expected 200 adding a favorite, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	listFavorites := func(principal string) []models.Favorite {
+		rr := httptest.NewRecorder()
+		server.handleListFavorites(rr, asPrincipal(httptest.NewRequest("GET", "/api/favorites", nil), principal))
+		var favorites []models.Favorite
+		if err := json.NewDecoder(rr.Body).Decode(&favorites); err != nil {
+			t.Fatalf("Failed to decode favorites: %v", err)
+		}
+		return favorites
+	}
+	if favorites := listFavorites("user:alice"); len(favorites) != 1 || favorites[0].ResourceID != cmd.ID {
+		t.Errorf("Expected alice's favorite saved command, got %+v", favorites)
+	}
+	if favorites := listFavorites("user:bob"); len(favorites) != 0 {
+		t.Errorf("Expected bob to have no favorites, got %+v", favorites)
+	}
+
+	listRecent := func(principal string) []models.RecentItem {
+		rr := httptest.NewRecorder()
+		server.handleListRecent(rr, asPrincipal(httptest.NewRequest("GET", "/api/recent", nil), principal))
+		var items []models.RecentItem
+		if err := json.NewDecoder(rr.Body).Decode(&items); err != nil {
+			t.Fatalf("Failed to decode recent items: %v", err)
+		}
+		return items
+	}
+	items := listRecent("user:alice")
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 recent items, got %+v", items)
+	}
+	if items[0].ResourceType != models.ResourceTypeBashScripts || items[0].Name != "hello" || items[0].Favorite {
+		t.Errorf("Expected the script first, got %+v", items[0])
+	}
+	if items[1].ResourceType != models.ResourceTypeSavedCommands || items[1].Name != "date" || !items[1].Favorite || items[1].Executions != 1 {
+		t.Errorf("Expected the favorite saved command second, got %+v", items[1])
+	}
+	if items := listRecent("user:bob"); len(items) != 0 {
+		t.Errorf("Expected bob to have no recent items, got %+v", items)
+	}
+
+	if rr := favorite("DELETE", "saved_commands", cmdID, "user:bob"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 removing another user's favorite, got %d", rr.Code)
+	}
+	if rr := favorite("DELETE", "saved_commands", cmdID, "user:alice"); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 removing a favorite, got %d", rr.Code)
+	}
+	if favorites := listFavorites("user:alice"); len(favorites) != 0 {
+		t.Errorf("Expected no favorites after removal, got %+v", favorites)
+	}
+}
+
+func TestSuggestCommands(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	asPrincipal := func(req *http.Request, name string) *http.Request {
+		return req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{Name: name}))
+	}
+	run := func(principal, command string) {
+		body := `{"command":"` + command + `","user":"current"}`
+		rr := httptest.NewRecorder()
+		server.handleExecuteCommand(rr, asPrincipal(httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)), principal))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200 running %q, got %d: %s", command, rr.Code, rr.Body.String())
+		}
+	}
+	suggest := func(principal, query string) []models.CommandSuggestion {
+		rr := httptest.NewRecorder()
+		server.handleSuggestCommands(rr, asPrincipal(httptest.NewRequest("GET", "/api/history/suggest?"+query, nil), principal))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var suggestions []models.CommandSuggestion
+		if err := json.NewDecoder(rr.Body).Decode(&suggestions); err != nil {
+			t.Fatalf("Failed to decode suggestions: %v", err)
+		}
+		return suggestions
+	}
+	commands := func(suggestions []models.CommandSuggestion) string {
+		var names []string
+		for _, s := range suggestions {
+			names = append(names, s.Command)
+		}
+		return strings.Join(names, ",")
+	}
+
+	run("user:alice", "echo a")
+	run("user:alice", "echo b")
+	run("user:alice", "echo a")
+	run("user:alice", "uptime")
+	run("user:bob", "echo bob")
+
+	suggestions := suggest("user:alice", "prefix=echo")
+	if commands(suggestions) != "echo a,echo b" || suggestions[0].Count != 2 {
+		t.Errorf("Expected the most run command first, got %+v", suggestions)
+	}
+	if got := commands(suggest("user:alice", "limit=1")); got != "echo a" {
+		t.Errorf("Expected one suggestion, got %q", got)
+	}
+	if got := commands(suggest("user:bob", "prefix=echo")); got != "echo bob" {
+		t.Errorf("Expected only bob's commands, got %q", got)
+	}
+
+	// New executions show up at once, despite the cache
+	run("user:alice", "echo b")
+	run("user:alice", "echo b")
+	if got := commands(suggest("user:alice", "prefix=echo")); got != "echo b,echo a" {
+		t.Errorf("Expected suggestions to follow new executions, got %q", got)
+	}
+}
+
+func TestScriptPresetSchedule(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "nightly", Content: "echo done"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	alice := &middleware.Principal{Name: "user:alice"}
+	create := func(body string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/script-presets", strings.NewReader(body))
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		server.handleCreateScriptPreset(rr, req)
+		return rr
+	}
+	scriptID := strconv.FormatInt(script.ID, 10)
+
+	for _, body := range []string{
+		`{"name":"bad","script_id":` + scriptID + `,"schedule":"61 * * * *","schedule_enabled":true}`,
+		`{"name":"bad","script_id":` + scriptID + `,"schedule_enabled":true}`,
+	} {
+		if rr := create(body, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rr.Code)
+		}
+	}
+	if rr := create(`{"name":"mine","script_id":`+scriptID+`,"schedule":"0 2 * * *"}`, alice); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 scheduling as a non-admin, got %d", rr.Code)
+	}
+
+	rr := create(`{"name":"nightly","script_id":`+scriptID+`,"user":"current","schedule":"0 2 * * *","schedule_enabled":true}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created models.ScriptPresetResponse
+	json.NewDecoder(rr.Body).Decode(&created)
+	if created.NextRunAt == nil || created.NextRunAt.Hour() != 2 || created.NextRunAt.Minute() != 0 {
+		t.Errorf("Expected the next run at 02:00, got %v", created.NextRunAt)
+	}
+
+	// Non-admins may still change the rest of a scheduled preset, but not its schedule
+	update := func(body string) int {
+		req := httptest.NewRequest("PUT", "/api/script-presets/"+strconv.FormatInt(created.ID, 10), strings.NewReader(body))
+		req = mux.SetURLVars(req.WithContext(middleware.WithPrincipal(req.Context(), alice)), map[string]string{"id": strconv.FormatInt(created.ID, 10)})
+		rr := httptest.NewRecorder()
+		server.handleUpdateScriptPreset(rr, req)
+		return rr.Code
+	}
+	if code := update(`{"schedule_enabled":false}`); code != http.StatusForbidden {
+		t.Errorf("Expected 403 disabling the schedule as a non-admin, got %d", code)
+	}
+	if code := update(`{"description":"runs every night"}`); code != http.StatusOK {
+		t.Errorf("Expected 200 updating the description as a non-admin, got %d", code)
+	}
+
+	// A scheduled run goes through script execution and records its outcome on the preset
+	presetRepo := repository.NewScriptPresetRepository(server.db)
+	preset, err := presetRepo.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch preset: %v", err)
+	}
+	at := time.Now().Truncate(time.Minute)
+	server.runScheduledPreset(context.Background(), preset, at)
+
+	preset, err = presetRepo.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch preset: %v", err)
+	}
+	if preset.LastRunStatus != "ok" || preset.LastRunError != "" || preset.LastRunAt == nil || !preset.LastRunAt.Equal(at) {
+		t.Errorf("Expected a successful run at %v, got status %q error %q at %v", at, preset.LastRunStatus, preset.LastRunError, preset.LastRunAt)
+	}
+	if preset.LastRunHistoryID == nil {
+		t.Fatal("Expected the history entry of the run")
+	}
+	history, err := repository.NewCommandHistoryRepository(server.db).GetByID(*preset.LastRunHistoryID)
+	if err != nil {
+		t.Fatalf("Failed to fetch history entry: %v", err)
+	}
+	if !strings.Contains(history.Output, "done") {
+		t.Errorf("Expected the output of the script, got %q", history.Output)
+	}
+
+	// Runs that cannot start record why
+	preset.ScriptID = script.ID + 100
+	server.runScheduledPreset(context.Background(), preset, at.Add(time.Minute))
+	preset, _ = presetRepo.GetByID(created.ID)
+	if preset.LastRunStatus != models.PresetRunError || preset.LastRunError == "" || preset.LastRunHistoryID != nil {
+		t.Errorf("Expected an error run without history, got status %q error %q history %v", preset.LastRunStatus, preset.LastRunError, preset.LastRunHistoryID)
+	}
+}
+
+func TestScriptPresetRunSettings(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.notifier = newNotifier(server.db)
+	defer server.notifier.Close()
+
+	received := make(chan string, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer hook.Close()
+	// The webhook is not subscribed to script events, so only the preset's channel reaches it
+	if _, err := repository.NewWebhookRepository(server.db).Create(&models.WebhookCreate{Name: "ops", URL: hook.URL, Events: []string{notify.EventCommandFailed}}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "slow", Content: "sleep 3; echo finished"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	scriptID := strconv.FormatInt(script.ID, 10)
+	create := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleCreateScriptPreset(rr, httptest.NewRequest("POST", "/api/script-presets", strings.NewReader(body)))
+		return rr
+	}
+
+	for _, settings := range []string{`"timeout_seconds":-1`, `"retries":9`, `"retry_backoff_ms":120000`, `"notify_channel":"pager"`, `"notify_channel":"webhook:missing"`} {
+		if rr := create(`{"name":"bad","script_id":` + scriptID + `,` + settings + `}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d: %s", settings, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := create(`{"name":"production","script_id":` + scriptID + `,"user":"current","timeout_seconds":1,"retries":2,"slack_notify":"always","notify_channel":"webhook:ops"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var preset models.ScriptPresetResponse
+	json.NewDecoder(rr.Body).Decode(&preset)
+	if preset.TimeoutSeconds != 1 || preset.Retries != 2 || preset.NotifyChannel != "webhook:ops" {
+		t.Errorf("Expected the run settings in the response, got %+v", preset)
+	}
+
+	// Runs from the preset stop at its timeout and post to its channel
+	body := `{"script_id":` + scriptID + `,"user":"current","preset_id":` + strconv.FormatInt(preset.ID, 10) + `}`
+	rr = httptest.NewRecorder()
+	server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result models.ScriptResult
+	json.NewDecoder(rr.Body).Decode(&result)
+	if result.ExitCode == 0 || strings.Contains(result.Output, "finished") {
+		t.Errorf("Expected the run to be stopped by the timeout, got exit code %d: %q", result.ExitCode, result.Output)
+	}
+
+	select {
+	case body := <-received:
+		if !strings.Contains(body, "production") {
+			t.Errorf("Expected the run of the preset, got %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The preset's channel was not notified")
+	}
+}
+
+func TestListCommandHistoryByRun(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "report", Content: "echo report"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	preset, err := repository.NewScriptPresetRepository(server.db).Create(&models.ScriptPresetCreate{Name: "weekly", ScriptID: script.ID, User: "current"})
+	if err != nil {
+		t.Fatalf("Failed to create preset: %v", err)
+	}
+	scriptID, presetID := strconv.FormatInt(script.ID, 10), strconv.FormatInt(preset.ID, 10)
+	for _, body := range []string{
+		`{"script_id":` + scriptID + `,"user":"current","preset_id":` + presetID + `}`,
+		`{"script_id":` + scriptID + `,"user":"current"}`,
+	} {
+		rr := httptest.NewRecorder()
+		server.handleExecuteScript(rr, httptest.NewRequest("POST", "/api/bash-scripts/execute", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+	rr := httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"echo plain","user":"current"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	list := func(query string) (int, []models.CommandHistory) {
+		rr := httptest.NewRecorder()
+		server.handleListCommandHistory(rr, httptest.NewRequest("GET", "/api/history?"+query, nil))
+		var history []models.CommandHistory
+		json.NewDecoder(rr.Body).Decode(&history)
+		return rr.Code, history
+	}
+
+	if _, history := list("script_id=" + scriptID); len(history) != 2 {
+		t.Errorf("Expected both runs of the script, got %d entries", len(history))
+	}
+	_, history := list("preset_id=" + presetID)
+	if len(history) != 1 || history[0].ScriptID == nil || *history[0].ScriptID != script.ID || history[0].PresetID == nil || *history[0].PresetID != preset.ID {
+		t.Errorf("Expected the run from the preset linked to the script and preset, got %+v", history)
+	}
+	for _, query := range []string{"script_id=abc", "exit_code=zero", "since=yesterday", "until=2025-11-11"} {
+		if code, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, code)
+		}
+	}
+
+	// Time and exit code filters
+	hourAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	if _, history := list("since=" + hourAgo + "&exit_code=0"); len(history) != 3 {
+		t.Errorf("Expected the 3 successful runs of the last hour, got %d entries", len(history))
+	}
+	if _, history := list("until=" + hourAgo); len(history) != 0 {
+		t.Errorf("Expected no runs before an hour ago, got %d entries", len(history))
+	}
+	if _, history := list("exit_code=1"); len(history) != 0 {
+		t.Errorf("Expected no failed runs, got %d entries", len(history))
+	}
+}
+
+func TestActivityEvents(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	defer audit.Observe(server.observeAudit)()
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/events", nil)
+	requireAdmin(server.handleActivityEvents).ServeHTTP(rr, req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{Name: "token:ci"})))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for a non-admin, got %d", rr.Code)
+	}
+
+	stream := httptest.NewServer(http.HandlerFunc(server.handleActivityEvents))
+	defer stream.Close()
+	resp, err := http.Get(stream.URL)
+	if err != nil {
+		t.Fatalf("Failed to open the event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", ct)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	lines.Scan() // Subscribed once the first comment arrives
+
+	rr = httptest.NewRecorder()
+	server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"exit 3","user":"current"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	audit.GetLogger().LogConfigChange(httptest.NewRequest("PUT", "/api/servers/1", nil), "server", "update", audit.OutcomeSuccess)
+
+	var events []models.ActivityEvent
+	for len(events) < 3 && lines.Scan() {
+		data, ok := strings.CutPrefix(lines.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event models.ActivityEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("Invalid event %q: %v", data, err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+
+	if events[0].Type != models.ActivityExecutionStarted || events[0].Command != "exit 3" || events[0].Server != "local" {
+		t.Errorf("Expected the command to start, got %+v", events[0])
+	}
+	if events[1].Type != models.ActivityExecutionFinished || events[1].ExitCode == nil || *events[1].ExitCode != 3 || events[1].Severity != models.SeverityCritical {
+		t.Errorf("Expected the command to fail, got %+v", events[1])
+	}
+	if events[2].Type != models.ActivityConfigChanged || events[2].Resource != "server" || events[2].Action != "update" || events[2].Outcome != "SUCCESS" {
+		t.Errorf("Expected the server update, got %+v", events[2])
+	}
+}
+
+func TestRunningExecutions(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	list := func() []models.RunningExecution {
+		rr := httptest.NewRecorder()
+		server.handleListRunningExecutions(rr, httptest.NewRequest("GET", "/api/executions/running", nil))
+		var running []models.RunningExecution
+		json.NewDecoder(rr.Body).Decode(&running)
+		return running
+	}
+	cancel := func(id string, principal *middleware.Principal) int {
+		req := httptest.NewRequest("DELETE", "/api/executions/running/"+id, nil)
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		requireAdmin(server.handleCancelRunningExecution).ServeHTTP(rr, mux.SetURLVars(req, map[string]string{"id": id}))
+		return rr.Code
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rr := httptest.NewRecorder()
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(`{"command":"exec sleep 30","user":"current"}`)))
+		done <- rr
+	}()
+
+	var running []models.RunningExecution
+	for deadline := time.Now().Add(5 * time.Second); len(running) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		running = list()
+	}
+	if len(running) != 1 || running[0].Command != "exec sleep 30" || running[0].Server != "local" || running[0].Kind != "command" {
+		t.Fatalf("Expected the running command, got %+v", running)
+	}
+	id := strconv.FormatInt(running[0].ID, 10)
+
+	if code := cancel(id, &middleware.Principal{Name: "token:ci"}); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", code)
+	}
+	if code := cancel("abc", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid ID, got %d", code)
+	}
+	if code := cancel(id, nil); code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", code)
+	}
+
+	select {
+	case rr := <-done:
+		var result models.CommandResult
+		json.NewDecoder(rr.Body).Decode(&result)
+		if result.ExitCode == 0 {
+			t.Errorf("Expected the cancelled command to fail, got %+v", result)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Cancelled command did not end")
+	}
+	if running := list(); len(running) != 0 {
+		t.Errorf("Expected no running executions, got %+v", running)
+	}
+	if code := cancel(id, nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 once the command ended, got %d", code)
+	}
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	create := func(body string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/maintenance-windows", strings.NewReader(body))
+		if principal != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		}
+		rr := httptest.NewRecorder()
+		requireAdmin(server.handleCreateMaintenanceWindow).ServeHTTP(rr, req)
+		return rr
+	}
+	execute := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		return rr
+	}
+
+	if rr := create(`{"name":"mine","server_group":"*","schedule":"0 2 * * *","duration_minutes":60}`, &middleware.Principal{Name: "user:alice"}); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", rr.Code)
+	}
+	for _, body := range []string{
+		`{"name":"bad","server_group":"*"}`,
+		`{"name":"bad","server_group":"nowhere","schedule":"0 2 * * *","duration_minutes":60}`,
+		`{"name":"bad","server_group":"*","schedule":"0 2 * * *"}`,
+		`{"name":"bad","server_group":"*","schedule":"0 2 * * *","duration_minutes":60,"starts_at":"2025-11-11T10:00:00Z","ends_at":"2025-11-11T12:00:00Z"}`,
+		`{"name":"bad","server_group":"*","starts_at":"2025-11-11T12:00:00Z","ends_at":"2025-11-11T10:00:00Z"}`,
+	} {
+		if rr := create(body, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rr.Code)
+		}
+	}
+
+	startsAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	endsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	rr := create(`{"name":"freeze","server_group":"*","starts_at":"`+startsAt+`","ends_at":"`+endsAt+`"}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var window models.MaintenanceWindow
+	json.NewDecoder(rr.Body).Decode(&window)
+	if !window.Active || !window.Enabled {
+		t.Errorf("Expected an active window, got %+v", window)
+	}
+
+	rr = execute(`{"command":"echo blocked","user":"current"}`)
+	var resp apierror.Response
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if rr.Code != http.StatusLocked || resp.Code != apierror.CodeMaintenanceWindow || !strings.Contains(resp.Message, `"freeze"`) {
+		t.Errorf("Expected 423 during the window, got %d: %+v", rr.Code, resp)
+	}
+	if rr := execute(`{"command":"echo override","user":"current","maintenance_override":true}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the override, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Scheduled runs are skipped without recording a run
+	script, err := repository.NewBashScriptRepository(server.db).Create(&models.BashScriptCreate{Name: "nightly", Content: "echo done"})
+	if err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	presetRepo := repository.NewScriptPresetRepository(server.db)
+	preset, err := presetRepo.Create(&models.ScriptPresetCreate{Name: "nightly", ScriptID: script.ID, User: "current"})
+	if err != nil {
+		t.Fatalf("Failed to create preset: %v", err)
+	}
+	server.runScheduledPreset(context.Background(), preset, time.Now())
+	if preset, _ = presetRepo.GetByID(preset.ID); preset.LastRunAt != nil {
+		t.Errorf("Expected the scheduled run to be skipped, got a run at %v", preset.LastRunAt)
+	}
+
+	// Disabled windows block nothing
+	req := httptest.NewRequest("PUT", "/api/maintenance-windows/"+strconv.FormatInt(window.ID, 10), strings.NewReader(`{"enabled":false}`))
+	rr = httptest.NewRecorder()
+	server.handleUpdateMaintenanceWindow(rr, mux.SetURLVars(req, map[string]string{"id": strconv.FormatInt(window.ID, 10)}))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := execute(`{"command":"echo open","user":"current"}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 once the window is disabled, got %d", rr.Code)
+	}
+
+	// Recurring windows last their duration from each time the schedule fires
+	recurring := &models.MaintenanceWindow{ServerGroup: "production", Schedule: "0 2 * * *", DurationMinutes: 90, Enabled: true}
+	day := time.Date(2025, 11, 11, 0, 0, 0, 0, time.Local)
+	for _, tc := range []struct {
+		at     time.Duration
+		active bool
+	}{
+		{time.Hour + 59*time.Minute, false},
+		{2 * time.Hour, true},
+		{3*time.Hour + 29*time.Minute, true},
+		{3*time.Hour + 30*time.Minute, false},
+	} {
+		until, active := recurring.ActiveUntil(day.Add(tc.at))
+		if active != tc.active || (active && !until.Equal(day.Add(3*time.Hour+30*time.Minute))) {
+			t.Errorf("At %v: expected active %v, got %v until %v", tc.at, tc.active, active, until)
+		}
+	}
+	if !recurring.Covers("production") || recurring.Covers("staging") || recurring.Covers("") {
+		t.Error("Expected the window to cover only the servers of its group")
+	}
+}
+
+func TestAgentTransport(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := repository.NewServerRepository(server.db)
+	edge, err := repo.Create(&models.ServerCreate{Name: "edge", Transport: models.ServerTransportAgent})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, err := repo.Create(&models.ServerCreate{Name: "web", IPAddress: "10.0.0.5"}); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleCreateServer(rr, httptest.NewRequest("POST", "/api/servers", strings.NewReader(`{"ip_address":"10.0.0.6","transport":"agent"}`)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "name") {
+		t.Errorf("Expected 400 for an agent server without a name, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Agent tokens are bound to an agent-transport server
+	for body, expected := range map[string]int{
+		`{"name":"a","scopes":["agent"]}`: http.StatusBadRequest,
+		`{"name":"b","scopes":["agent"],"server_id":` + strconv.FormatInt(edge.ID+1, 10) + `}`: http.StatusBadRequest,
+		`{"name":"c","scopes":["read"],"server_id":` + strconv.FormatInt(edge.ID, 10) + `}`:    http.StatusBadRequest,
+		`{"name":"d","scopes":["agent"],"server_id":` + strconv.FormatInt(edge.ID, 10) + `}`:   http.StatusCreated,
+	} {
+		rr := httptest.NewRecorder()
+		server.handleCreateAPIToken(rr, httptest.NewRequest("POST", "/api/tokens", strings.NewReader(body)))
+		if rr.Code != expected {
+			t.Errorf("Expected %d creating %s, got %d: %s", expected, body, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The agent runs commands as its own user without sudo
+	current, err := user.Current()
+	if err != nil {
+		t.Fatalf("Failed to get current user: %v", err)
+	}
+	execute := func(command string) models.CommandResult {
+		rr := httptest.NewRecorder()
+		body := `{"command":"` + command + `","is_remote":true,"server_id":` + strconv.FormatInt(edge.ID, 10) + `,"user":"` + current.Username + `"}`
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		var result models.CommandResult
+		json.NewDecoder(rr.Body).Decode(&result)
+		return result
+	}
+	listAgents := func() []models.ConnectedAgent {
+		rr := httptest.NewRecorder()
+		server.handleListAgents(rr, httptest.NewRequest("GET", "/api/agents", nil))
+		var agents []models.ConnectedAgent
+		json.NewDecoder(rr.Body).Decode(&agents)
+		return agents
+	}
+
+	if result := execute("echo hello"); result.ExitCode == 0 || !strings.Contains(result.Output, "agent is not connected") {
+		t.Errorf("Expected the command to fail without an agent, got %+v", result)
+	}
+
+	// Tokens stand in for the API tokens the auth middleware resolves
+	principals := map[string]*middleware.Principal{
+		"bound":   {Name: "token:edge-agent", AgentServerID: &edge.ID},
+		"unbound": {Name: "token:old-agent"},
+	}
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, ok := principals[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]; ok {
+			r = r.WithContext(middleware.WithPrincipal(r.Context(), principal))
+		}
+		server.handleAgentConnect(w, r)
+	}))
+	defer hub.Close()
+	connect := func(name, token string) int {
+		req, _ := http.NewRequest("GET", hub.URL+"?name="+name, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, tt := range []struct {
+		name, token string
+		expected    int
+	}{
+		{"web", "", http.StatusConflict},
+		{"missing", "", http.StatusNotFound},
+		{"web", "bound", http.StatusForbidden},
+		{"edge", "unbound", http.StatusForbidden},
+	} {
+		if status := connect(tt.name, tt.token); status != tt.expected {
+			t.Errorf("Expected agent %s with token %q to be rejected with %d, got %d", tt.name, tt.token, tt.expected, status)
+		}
+	}
+	if err := (&agent.Client{URL: hub.URL, Name: "web", Token: "bound"}).Run(context.Background()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected the agent to stop when rejected, got %v", err)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- (&agent.Client{URL: hub.URL, Name: "edge", Token: "bound", Version: "test"}).Run(ctx)
+	}()
+
+	var agents []models.ConnectedAgent
+	for deadline := time.Now().Add(5 * time.Second); len(agents) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		agents = listAgents()
+	}
+	if len(agents) != 1 || agents[0].Name != "edge" || agents[0].Version != "test" || agents[0].User != current.Username {
+		t.Fatalf("Expected the edge agent to be connected, got %+v", agents)
+	}
+
+	// The connected agent is not replaced by another one for the same server
+	if status := connect("edge", "bound"); status != http.StatusConflict {
+		t.Errorf("Expected a second agent to be rejected with 409, got %d", status)
+	}
+
+	result := execute(`echo hello; echo oops >&2; exit 3`)
+	if result.ExitCode != 3 || !strings.Contains(result.Output, "hello") || !strings.Contains(result.Output, "oops") {
+		t.Errorf("Expected the agent to run the command, got %+v", result)
+	}
+
+	stop()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Expected the agent to stop cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Agent did not stop")
+	}
+	for deadline := time.Now().Add(5 * time.Second); len(agents) != 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		agents = listAgents()
+	}
+	if len(agents) != 0 {
+		t.Errorf("Expected the agent to be disconnected, got %+v", agents)
+	}
+}
+
+func TestWakeServer(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	send := func(handler http.HandlerFunc, method, path, body string, vars map[string]string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, mux.SetURLVars(httptest.NewRequest(method, path, strings.NewReader(body)), vars))
+		return rr
+	}
+	wake := func(id int64, body string) *httptest.ResponseRecorder {
+		return send(server.handleWakeServer, "POST", "/api/servers/wake", body, map[string]string{"id": strconv.FormatInt(id, 10)})
+	}
+
+	if rr := send(server.handleCreateServer, "POST", "/api/servers", `{"name":"nas","mac_address":"00:11:22:33:44"}`, nil); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "mac_address") {
+		t.Errorf("Expected 400 for an invalid MAC address, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr := send(server.handleCreateServer, "POST", "/api/servers", `{"name":"nas","mac_address":"00-11-22-33-44-55"}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var nas models.Server
+	json.NewDecoder(rr.Body).Decode(&nas)
+	if nas.MACAddress != "00-11-22-33-44-55" {
+		t.Errorf("Expected the MAC address to be stored, got %+v", nas)
+	}
+
+	repo := repository.NewServerRepository(server.db)
+	web, err := repo.Create(&models.ServerCreate{Name: "web", IPAddress: "10.0.0.5"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if rr := wake(web.ID, ""); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "mac_address") {
+		t.Errorf("Expected 400 for a server without a MAC address, got %d: %s", rr.Code, rr.Body.String())
+	}
+	for _, body := range []string{`{"broadcast":"::1"}`, `{"port":70000}`, `{"relay_server_id":` + strconv.FormatInt(nas.ID, 10) + `}`} {
+		if rr := wake(nas.ID, body); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := wake(nas.ID, `{"relay_server_id":999}`); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing relay, got %d", rr.Code)
+	}
+
+	listener, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	port := strconv.Itoa(listener.LocalAddr().(*net.UDPAddr).Port)
+	received := func() []byte {
+		listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, 256)
+		n, _ := listener.Read(buf)
+		return buf[:n]
+	}
+	packet := append(bytes.Repeat([]byte{0xFF}, 6), bytes.Repeat([]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}, 16)...)
+
+	rr = wake(nas.ID, `{"broadcast":"127.0.0.1","port":`+port+`}`)
+	var result models.ServerWakeResult
+	json.NewDecoder(rr.Body).Decode(&result)
+	if rr.Code != http.StatusOK || result.Server != "nas" || result.MACAddress != "00:11:22:33:44:55" || result.Relay != "" {
+		t.Fatalf("Expected the packet to be sent, got %d: %+v", rr.Code, result)
+	}
+	if got := received(); !bytes.Equal(got, packet) {
+		t.Errorf("Expected the magic packet, got %x", got)
+	}
+
+	// Relay executions that do not run are answered as they are
+	gate, err := repo.Create(&models.ServerCreate{Name: "gate", IPAddress: "10.0.0.6", RequiresApproval: true})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if rr := wake(nas.ID, `{"relay_server_id":`+strconv.FormatInt(gate.ID, 10)+`}`); rr.Code != http.StatusAccepted {
+		t.Errorf("Expected the relay's approval response, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// A relay reached through its agent sends the packet with python3
+	if _, err := osexec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	// The relay runs it as its username, the agent's own user so no sudo is needed
+	current, err := user.Current()
+	if err != nil {
+		t.Fatalf("Failed to get current user: %v", err)
+	}
+	pi, err := repo.Create(&models.ServerCreate{Name: "pi", Username: current.Username, Transport: models.ServerTransportAgent})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	hub := httptest.NewServer(http.HandlerFunc(server.handleAgentConnect))
+	defer hub.Close()
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go (&agent.Client{URL: hub.URL, Name: "pi"}).Run(ctx)
+	for deadline := time.Now().Add(5 * time.Second); len(server.agents.List()) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rr = wake(nas.ID, `{"relay_server_id":`+strconv.FormatInt(pi.ID, 10)+`,"broadcast":"127.0.0.1","port":`+port+`}`)
+	result = models.ServerWakeResult{}
+	json.NewDecoder(rr.Body).Decode(&result)
+	if rr.Code != http.StatusOK || result.Relay != "pi" {
+		t.Fatalf("Expected the relay to send the packet, got %d: %+v", rr.Code, result)
+	}
+	if got := received(); !bytes.Equal(got, packet) {
+		t.Errorf("Expected the relayed magic packet, got %x", got)
+	}
+}
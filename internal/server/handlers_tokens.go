@@ -0,0 +1,219 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// authenticateAPIToken resolves a bearer token against the stored API tokens
+func (s *Server) authenticateAPIToken(token string) *models.APIToken {
+	repo := repository.NewAPITokenRepository(s.db)
+	apiToken, err := repo.Authenticate(token)
+	if err != nil {
+		log.Printf("Error authenticating API token: %v", err)
+		return nil
+	}
+	return apiToken
+}
+
+// handleListAPITokens godoc
+// @Summary List API tokens
+// @Description List all API tokens (token secrets are never returned)
+// @Tags API Tokens
+// @Produce json
+// @Success 200 {array} models.APIToken
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /tokens [get]
+func (s *Server) handleListAPITokens(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewAPITokenRepository(s.db)
+
+	tokens, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching API tokens: %v", err)
+		apierror.Error(w, "Failed to fetch API tokens", http.StatusInternalServerError)
+		return
+	}
+
+	if tokens == nil {
+		tokens = []*models.APIToken{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// handleCreateAPIToken godoc
+// @Summary Create an API token
+// @Description Create a scoped bearer token for API clients. The token is only returned in this response; store it securely. Tokens with the agent scope need the server_id of the agent-transport server they connect as.
+// @Tags API Tokens
+// @Accept json
+// @Produce json
+// @Param token body models.APITokenCreate true "API token to create"
+// @Success 201 {object} models.APITokenCreated
+// @Failure 400 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /tokens [post]
+func (s *Server) handleCreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var create models.APITokenCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if create.Name == "" {
+		apierror.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if !s.checkAgentTokenServer(w, create.ServerID) {
+		return
+	}
+
+	repo := repository.NewAPITokenRepository(s.db)
+
+	token, err := repo.Create(&create)
+	if err != nil {
+		log.Printf("Error creating API token: %v", err)
+		audit.GetLogger().LogConfigChange(r, "api_token", "create", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to create API token: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "api_token", "create", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// handleGetAPIToken godoc
+// @Summary Get an API token by ID
+// @Description Get a single API token by its ID (the token secret is never returned)
+// @Tags API Tokens
+// @Produce json
+// @Param id path int true "API Token ID"
+// @Success 200 {object} models.APIToken
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /tokens/{id} [get]
+func (s *Server) handleGetAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewAPITokenRepository(s.db)
+
+	token, err := repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching API token: %v", err)
+		apierror.Error(w, "API token not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+// handleUpdateAPIToken godoc
+// @Summary Update an API token
+// @Description Rename an API token, change its scopes or, for agent tokens, its server
+// @Tags API Tokens
+// @Accept json
+// @Produce json
+// @Param id path int true "API Token ID"
+// @Param token body models.APITokenUpdate true "API token update data"
+// @Success 200 {object} models.APIToken
+// @Failure 400 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /tokens/{id} [put]
+func (s *Server) handleUpdateAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.APITokenUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !s.checkAgentTokenServer(w, update.ServerID) {
+		return
+	}
+
+	repo := repository.NewAPITokenRepository(s.db)
+
+	token, err := repo.Update(id, &update)
+	if err != nil {
+		log.Printf("Error updating API token: %v", err)
+		audit.GetLogger().LogConfigChange(r, "api_token", "update", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to update API token: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "api_token", "update", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+// handleDeleteAPIToken godoc
+// @Summary Revoke an API token
+// @Description Delete an API token so it can no longer be used
+// @Tags API Tokens
+// @Param id path int true "API Token ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /tokens/{id} [delete]
+func (s *Server) handleDeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewAPITokenRepository(s.db)
+
+	if err := repo.Delete(id); err != nil {
+		log.Printf("Error deleting API token: %v", err)
+		apierror.Error(w, "API token not found", http.StatusNotFound)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "api_token", "delete", audit.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkAgentTokenServer rejects binding an agent token to a server that does not exist or does not
+// use the agent transport
+func (s *Server) checkAgentTokenServer(w http.ResponseWriter, serverID *int64) bool {
+	if serverID == nil {
+		return true
+	}
+	server, err := repository.NewServerRepository(s.db).GetByID(*serverID)
+	if err != nil {
+		apierror.InvalidField(w, "server_id", "Server not found")
+		return false
+	}
+	if server.Transport != models.ServerTransportAgent {
+		apierror.InvalidField(w, "server_id", fmt.Sprintf("Server '%s' does not use the agent transport", serverLabel(server)))
+		return false
+	}
+	return true
+}
@@ -3,12 +3,16 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
 	"time"
 
+	"github.com/pozgo/web-cli/internal/apierror"
 	"github.com/pozgo/web-cli/internal/models"
 	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
 	"github.com/pozgo/web-cli/internal/vault"
 )
 
@@ -26,7 +30,7 @@ func (s *Server) handleGetVaultConfig(w http.ResponseWriter, r *http.Request) {
 	cfg, err := repo.Get()
 	if err != nil {
 		log.Printf("Error getting vault config: %v", err)
-		http.Error(w, "Failed to get vault configuration", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to get vault configuration", http.StatusInternalServerError)
 		return
 	}
 
@@ -57,26 +61,85 @@ func (s *Server) handleGetVaultConfig(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleCreateOrUpdateVaultConfig(w http.ResponseWriter, r *http.Request) {
 	var create models.VaultConfigCreate
 	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate required fields
 	if create.Address == "" {
-		http.Error(w, "Vault address is required", http.StatusBadRequest)
+		apierror.Error(w, "Vault address is required", http.StatusBadRequest)
 		return
 	}
 
-	if create.Token == "" {
-		// If no token provided, check if we're updating existing config
-		repo := repository.NewVaultConfigRepository(s.db)
-		existing, _ := repo.Get()
-		if existing == nil || existing.Token == "" {
-			http.Error(w, "Vault token is required", http.StatusBadRequest)
+	// Secrets left out of an update keep their stored values
+	repo := repository.NewVaultConfigRepository(s.db)
+	existing, _ := repo.Get()
+
+	switch create.AuthMethod {
+	case "", vault.AuthMethodToken:
+		create.AuthMethod = vault.AuthMethodToken
+		create.RoleID, create.SecretID, create.AuthMount = "", "", ""
+		create.KubernetesRole, create.JWTPath = "", ""
+		if create.Token == "" {
+			if existing == nil || existing.Token == "" {
+				apierror.Error(w, "Vault token is required", http.StatusBadRequest)
+				return
+			}
+			// Use existing token
+			create.Token = existing.Token
+		}
+	case vault.AuthMethodAppRole:
+		create.Token = ""
+		create.KubernetesRole, create.JWTPath = "", ""
+		if create.RoleID == "" {
+			apierror.Error(w, "Vault role_id is required for AppRole authentication", http.StatusBadRequest)
+			return
+		}
+		if create.SecretID == "" {
+			if existing == nil || existing.SecretID == "" {
+				apierror.Error(w, "Vault secret_id is required for AppRole authentication", http.StatusBadRequest)
+				return
+			}
+			create.SecretID = existing.SecretID
+		}
+	case vault.AuthMethodKubernetes:
+		create.Token = ""
+		create.RoleID, create.SecretID = "", ""
+		if create.KubernetesRole == "" {
+			apierror.Error(w, "Vault kubernetes_role is required for Kubernetes authentication", http.StatusBadRequest)
+			return
+		}
+		if create.JWTPath != "" && !filepath.IsAbs(create.JWTPath) {
+			apierror.Error(w, "jwt_path must be an absolute path", http.StatusBadRequest)
+			return
+		}
+	default:
+		apierror.Error(w, "auth_method must be token, approle or kubernetes", http.StatusBadRequest)
+		return
+	}
+	if create.AuthMount != "" {
+		if err := validation.ValidateVaultGroupName(create.AuthMount); err != nil {
+			apierror.InvalidField(w, "auth_mount", fmt.Sprintf("Invalid auth_mount: %v", err))
+			return
+		}
+	}
+	if create.SSHCAMount != "" {
+		if err := validation.ValidateVaultGroupName(create.SSHCAMount); err != nil {
+			apierror.InvalidField(w, "ssh_ca_mount", fmt.Sprintf("Invalid ssh_ca_mount: %v", err))
+			return
+		}
+	}
+	if create.SSHCARole != "" {
+		if err := validation.ValidateVaultSecretName(create.SSHCARole); err != nil {
+			apierror.InvalidField(w, "ssh_ca_role", fmt.Sprintf("Invalid ssh_ca_role: %v", err))
+			return
+		}
+	}
+	if create.SSHCATTL != "" {
+		if ttl, err := time.ParseDuration(create.SSHCATTL); err != nil || ttl <= 0 {
+			apierror.Error(w, "ssh_ca_ttl must be a positive duration such as 30m", http.StatusBadRequest)
 			return
 		}
-		// Use existing token
-		create.Token = existing.Token
 	}
 
 	// Set default mount path
@@ -84,25 +147,17 @@ func (s *Server) handleCreateOrUpdateVaultConfig(w http.ResponseWriter, r *http.
 		create.MountPath = "secret"
 	}
 
-	repo := repository.NewVaultConfigRepository(s.db)
 	cfg, err := repo.CreateOrUpdate(&create)
 	if err != nil {
 		log.Printf("Error saving vault config: %v", err)
-		http.Error(w, "Failed to save vault configuration", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to save vault configuration", http.StatusInternalServerError)
 		return
 	}
 
 	// If Vault is enabled, initialize the structure automatically
 	if cfg.Enabled {
 		go func() {
-			vaultCfg := &vault.Config{
-				Address:   cfg.Address,
-				Token:     cfg.Token,
-				Namespace: cfg.Namespace,
-				MountPath: cfg.MountPath,
-			}
-
-			client, err := vault.NewClient(vaultCfg)
+			client, err := s.vaultClient(cfg)
 			if err != nil {
 				log.Printf("Warning: Failed to create Vault client for structure initialization: %v", err)
 				return
@@ -115,6 +170,8 @@ func (s *Server) handleCreateOrUpdateVaultConfig(w http.ResponseWriter, r *http.
 				log.Printf("Warning: Failed to initialize Vault structure: %v", err)
 			}
 		}()
+	} else {
+		s.closeVaultClient()
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -134,9 +191,10 @@ func (s *Server) handleDeleteVaultConfig(w http.ResponseWriter, r *http.Request)
 	repo := repository.NewVaultConfigRepository(s.db)
 	if err := repo.Delete(); err != nil {
 		log.Printf("Error deleting vault config: %v", err)
-		http.Error(w, "Failed to delete vault configuration", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to delete vault configuration", http.StatusInternalServerError)
 		return
 	}
+	s.closeVaultClient()
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -156,24 +214,17 @@ func (s *Server) handleTestVaultConnection(w http.ResponseWriter, r *http.Reques
 	cfg, err := repo.Get()
 	if err != nil {
 		log.Printf("Error getting vault config: %v", err)
-		http.Error(w, "Failed to get vault configuration", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to get vault configuration", http.StatusInternalServerError)
 		return
 	}
 
 	if cfg == nil {
-		http.Error(w, "Vault is not configured", http.StatusBadRequest)
+		apierror.Error(w, "Vault is not configured", http.StatusBadRequest)
 		return
 	}
 
 	// Create Vault client
-	vaultCfg := &vault.Config{
-		Address:   cfg.Address,
-		Token:     cfg.Token,
-		Namespace: cfg.Namespace,
-		MountPath: cfg.MountPath,
-	}
-
-	client, err := vault.NewClient(vaultCfg)
+	client, err := s.vaultClient(cfg)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -245,7 +296,7 @@ func (s *Server) handleGetVaultStatus(w http.ResponseWriter, r *http.Request) {
 	cfg, err := repo.Get()
 	if err != nil {
 		log.Printf("Error getting vault config: %v", err)
-		http.Error(w, "Failed to get vault configuration", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to get vault configuration", http.StatusInternalServerError)
 		return
 	}
 
@@ -268,14 +319,7 @@ func (s *Server) handleGetVaultStatus(w http.ResponseWriter, r *http.Request) {
 
 	// Only test connection if enabled
 	if cfg.Enabled {
-		vaultCfg := &vault.Config{
-			Address:   cfg.Address,
-			Token:     cfg.Token,
-			Namespace: cfg.Namespace,
-			MountPath: cfg.MountPath,
-		}
-
-		client, err := vault.NewClient(vaultCfg)
+		client, err := s.vaultClient(cfg)
 		if err != nil {
 			status.Error = err.Error()
 		} else {
@@ -314,7 +358,7 @@ func (s *Server) handleListVaultSSHKeys(w http.ResponseWriter, r *http.Request)
 	client, err := s.getVaultClient()
 	if err != nil {
 		log.Printf("Error getting vault client: %v", err)
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -324,10 +368,12 @@ func (s *Server) handleListVaultSSHKeys(w http.ResponseWriter, r *http.Request)
 	keys, err := client.ListSSHKeys(ctx)
 	if err != nil {
 		log.Printf("Error listing vault SSH keys: %v", err)
-		http.Error(w, "Failed to list SSH keys from Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to list SSH keys from Vault", http.StatusInternalServerError)
 		return
 	}
 
+	keys = filterVisible(s.groupAccess(r), models.ResourceTypeSSHKeys, keys, func(item vault.SSHKey) string { return item.Group })
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(keys)
 }
@@ -345,7 +391,7 @@ func (s *Server) handleListVaultSSHKeys(w http.ResponseWriter, r *http.Request)
 func (s *Server) handleListVaultServers(w http.ResponseWriter, r *http.Request) {
 	client, err := s.getVaultClient()
 	if err != nil {
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -355,10 +401,12 @@ func (s *Server) handleListVaultServers(w http.ResponseWriter, r *http.Request)
 	servers, err := client.ListServers(ctx)
 	if err != nil {
 		log.Printf("Error listing vault servers: %v", err)
-		http.Error(w, "Failed to list servers from Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to list servers from Vault", http.StatusInternalServerError)
 		return
 	}
 
+	servers = filterVisible(s.groupAccess(r), models.ResourceTypeServers, servers, func(item vault.Server) string { return item.Group })
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(servers)
 }
@@ -376,7 +424,7 @@ func (s *Server) handleListVaultServers(w http.ResponseWriter, r *http.Request)
 func (s *Server) handleListVaultEnvVariables(w http.ResponseWriter, r *http.Request) {
 	client, err := s.getVaultClient()
 	if err != nil {
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -386,10 +434,12 @@ func (s *Server) handleListVaultEnvVariables(w http.ResponseWriter, r *http.Requ
 	vars, err := client.ListEnvVariables(ctx)
 	if err != nil {
 		log.Printf("Error listing vault env variables: %v", err)
-		http.Error(w, "Failed to list environment variables from Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to list environment variables from Vault", http.StatusInternalServerError)
 		return
 	}
 
+	vars = filterVisible(s.groupAccess(r), models.ResourceTypeEnvVariables, vars, func(item vault.EnvVariable) string { return item.Group })
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(vars)
 }
@@ -407,7 +457,7 @@ func (s *Server) handleListVaultEnvVariables(w http.ResponseWriter, r *http.Requ
 func (s *Server) handleListVaultScripts(w http.ResponseWriter, r *http.Request) {
 	client, err := s.getVaultClient()
 	if err != nil {
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -417,10 +467,12 @@ func (s *Server) handleListVaultScripts(w http.ResponseWriter, r *http.Request)
 	scripts, err := client.ListBashScripts(ctx)
 	if err != nil {
 		log.Printf("Error listing vault scripts: %v", err)
-		http.Error(w, "Failed to list scripts from Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to list scripts from Vault", http.StatusInternalServerError)
 		return
 	}
 
+	scripts = filterVisible(s.groupAccess(r), models.ResourceTypeBashScripts, scripts, func(item vault.BashScript) string { return item.Group })
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(scripts)
 }
@@ -441,14 +493,7 @@ func (s *Server) getVaultClient() (*vault.Client, error) {
 		return nil, &VaultDisabledError{}
 	}
 
-	vaultCfg := &vault.Config{
-		Address:   cfg.Address,
-		Token:     cfg.Token,
-		Namespace: cfg.Namespace,
-		MountPath: cfg.MountPath,
-	}
-
-	return vault.NewClient(vaultCfg)
+	return s.vaultClient(cfg)
 }
 
 // VaultNotConfiguredError is returned when Vault is not configured
@@ -538,13 +583,14 @@ func containsAny(s string, substrs []string) bool {
 // @Param key body object{name=string,private_key=string,group=string} true "SSH Key"
 // @Success 201 {object} object{name=string,group=string,created_at=string,source=string}
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /vault/ssh-keys [post]
 func (s *Server) handleCreateVaultSSHKey(w http.ResponseWriter, r *http.Request) {
 	client, err := s.getVaultClient()
 	if err != nil {
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -555,12 +601,12 @@ func (s *Server) handleCreateVaultSSHKey(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.Name == "" || req.PrivateKey == "" {
-		http.Error(w, "Name and private_key are required", http.StatusBadRequest)
+		apierror.Error(w, "Name and private_key are required", http.StatusBadRequest)
 		return
 	}
 
@@ -568,6 +614,11 @@ func (s *Server) handleCreateVaultSSHKey(w http.ResponseWriter, r *http.Request)
 		req.Group = "default"
 	}
 
+	if !s.groupAccess(r).canView(models.ResourceTypeSSHKeys, req.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeSSHKeys, req.Group, models.PermissionView)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
@@ -580,7 +631,7 @@ func (s *Server) handleCreateVaultSSHKey(w http.ResponseWriter, r *http.Request)
 
 	if err := client.SaveSSHKey(ctx, key); err != nil {
 		log.Printf("Error saving SSH key to Vault: %v", err)
-		http.Error(w, "Failed to save SSH key to Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to save SSH key to Vault", http.StatusInternalServerError)
 		return
 	}
 
@@ -603,13 +654,14 @@ func (s *Server) handleCreateVaultSSHKey(w http.ResponseWriter, r *http.Request)
 // @Param server body object{name=string,ip_address=string,port=int,username=string,group=string} true "Server"
 // @Success 201 {object} object{name=string,ip_address=string,port=int,username=string,group=string,source=string}
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /vault/servers [post]
 func (s *Server) handleCreateVaultServer(w http.ResponseWriter, r *http.Request) {
 	client, err := s.getVaultClient()
 	if err != nil {
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -622,13 +674,13 @@ func (s *Server) handleCreateVaultServer(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// At least one of name or ip_address is required
 	if req.Name == "" && req.IPAddress == "" {
-		http.Error(w, "At least one of name or ip_address is required", http.StatusBadRequest)
+		apierror.Error(w, "At least one of name or ip_address is required", http.StatusBadRequest)
 		return
 	}
 
@@ -642,6 +694,11 @@ func (s *Server) handleCreateVaultServer(w http.ResponseWriter, r *http.Request)
 		req.Group = "default"
 	}
 
+	if !s.groupAccess(r).canView(models.ResourceTypeServers, req.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeServers, req.Group, models.PermissionView)
+		return
+	}
+
 	if req.Port == 0 {
 		req.Port = 22
 	}
@@ -663,7 +720,7 @@ func (s *Server) handleCreateVaultServer(w http.ResponseWriter, r *http.Request)
 
 	if err := client.SaveServer(ctx, srv); err != nil {
 		log.Printf("Error saving server to Vault: %v", err)
-		http.Error(w, "Failed to save server to Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to save server to Vault", http.StatusInternalServerError)
 		return
 	}
 
@@ -688,13 +745,14 @@ func (s *Server) handleCreateVaultServer(w http.ResponseWriter, r *http.Request)
 // @Param envVar body object{name=string,value=string,description=string,group=string} true "Environment Variable"
 // @Success 201 {object} object{name=string,description=string,group=string,source=string}
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BasicAuth
 // @Router /vault/env-variables [post]
 func (s *Server) handleCreateVaultEnvVariable(w http.ResponseWriter, r *http.Request) {
 	client, err := s.getVaultClient()
 	if err != nil {
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -706,12 +764,12 @@ func (s *Server) handleCreateVaultEnvVariable(w http.ResponseWriter, r *http.Req
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.Name == "" || req.Value == "" {
-		http.Error(w, "Name and value are required", http.StatusBadRequest)
+		apierror.Error(w, "Name and value are required", http.StatusBadRequest)
 		return
 	}
 
@@ -719,6 +777,11 @@ func (s *Server) handleCreateVaultEnvVariable(w http.ResponseWriter, r *http.Req
 		req.Group = "default"
 	}
 
+	if !s.groupAccess(r).canView(models.ResourceTypeEnvVariables, req.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeEnvVariables, req.Group, models.PermissionView)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
@@ -731,7 +794,7 @@ func (s *Server) handleCreateVaultEnvVariable(w http.ResponseWriter, r *http.Req
 
 	if err := client.SaveEnvVariable(ctx, envVar); err != nil {
 		log.Printf("Error saving env variable to Vault: %v", err)
-		http.Error(w, "Failed to save environment variable to Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to save environment variable to Vault", http.StatusInternalServerError)
 		return
 	}
 
@@ -754,13 +817,14 @@ func (s *Server) handleCreateVaultEnvVariable(w http.ResponseWriter, r *http.Req
 // @Param script body object true "Bash script data" example({"name":"deploy-script","description":"Production deployment script","content":"#!/bin/bash\necho 'Deploying...'","filename":"deploy.sh","group":"production"})
 // @Success 201 {object} map[string]interface{} "Created script info with source field"
 // @Failure 400 {string} string "Invalid request or Vault not configured"
+// @Failure 403 {string} string "No view permission on the group"
 // @Failure 500 {string} string "Failed to store script"
 // @Security BasicAuth
 // @Router /vault/bash-scripts [post]
 func (s *Server) handleCreateVaultScript(w http.ResponseWriter, r *http.Request) {
 	client, err := s.getVaultClient()
 	if err != nil {
-		http.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -770,15 +834,21 @@ func (s *Server) handleCreateVaultScript(w http.ResponseWriter, r *http.Request)
 		Content     string `json:"content"`
 		Filename    string `json:"filename"`
 		Group       string `json:"group"`
+		Interpreter string `json:"interpreter"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.Name == "" || req.Content == "" {
-		http.Error(w, "Name and content are required", http.StatusBadRequest)
+		apierror.Error(w, "Name and content are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := validation.ValidateScriptInterpreter(req.Interpreter); err != nil {
+		apierror.InvalidField(w, "interpreter", fmt.Sprintf("Invalid interpreter: %v", err))
 		return
 	}
 
@@ -786,6 +856,11 @@ func (s *Server) handleCreateVaultScript(w http.ResponseWriter, r *http.Request)
 		req.Group = "default"
 	}
 
+	if !s.groupAccess(r).canView(models.ResourceTypeBashScripts, req.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeBashScripts, req.Group, models.PermissionView)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
@@ -795,11 +870,12 @@ func (s *Server) handleCreateVaultScript(w http.ResponseWriter, r *http.Request)
 		Content:     req.Content,
 		Filename:    req.Filename,
 		Group:       req.Group,
+		Interpreter: req.Interpreter,
 	}
 
 	if err := client.SaveBashScript(ctx, script); err != nil {
 		log.Printf("Error saving script to Vault: %v", err)
-		http.Error(w, "Failed to save script to Vault", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to save script to Vault", http.StatusInternalServerError)
 		return
 	}
 
@@ -810,6 +886,7 @@ func (s *Server) handleCreateVaultScript(w http.ResponseWriter, r *http.Request)
 		"description": script.Description,
 		"filename":    script.Filename,
 		"group":       script.Group,
+		"interpreter": vaultScriptInterpreter(script.Interpreter),
 		"source":      "vault",
 	})
 }
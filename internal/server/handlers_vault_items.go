@@ -0,0 +1,649 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/validation"
+	"github.com/pozgo/web-cli/internal/vault"
+)
+
+// vaultItem is the Vault client and location of the item a request addresses
+// The group and name come from the /vault/{type}/{group}/{name} path
+type vaultItem struct {
+	client *vault.Client
+	group  string
+	name   string
+}
+
+// openVaultItem resolves the Vault item of a request, checking the caller may view its group
+// The error response is written and nil returned when the item cannot be used
+func (s *Server) openVaultItem(w http.ResponseWriter, r *http.Request, resourceType string) *vaultItem {
+	client, err := s.getVaultClient()
+	if err != nil {
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		return nil
+	}
+
+	vars := mux.Vars(r)
+	item := &vaultItem{client: client, group: vars["group"], name: vars["name"]}
+	if err := validation.ValidateVaultGroupName(item.group); err != nil {
+		apierror.InvalidField(w, "group", fmt.Sprintf("Invalid group name: %v", err))
+		return nil
+	}
+	if err := validation.ValidateVaultSecretName(item.name); err != nil {
+		apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
+		return nil
+	}
+
+	if !s.groupAccess(r).canView(resourceType, item.group) {
+		denyGroupAccess(w, r, resourceType, item.group, models.PermissionView)
+		return nil
+	}
+	return item
+}
+
+// handleGetVaultSSHKey godoc
+// @Summary Get SSH key from Vault
+// @Description Retrieve a single SSH key stored in Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "SSH key name"
+// @Success 200 {object} vault.SSHKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/ssh-keys/{group}/{name} [get]
+func (s *Server) handleGetVaultSSHKey(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeSSHKeys)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	key, err := item.client.GetSSHKey(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault SSH key: %v", err)
+		apierror.Error(w, "Failed to read SSH key from Vault", http.StatusInternalServerError)
+		return
+	}
+	if key == nil {
+		apierror.Error(w, "SSH key not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+// handleUpdateVaultSSHKey godoc
+// @Summary Update SSH key in Vault
+// @Description Replace the private key of an SSH key stored in Vault
+// @Tags Vault
+// @Accept json
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "SSH key name"
+// @Param key body object{private_key=string} true "SSH Key"
+// @Success 200 {object} object{name=string,group=string,created_at=string,source=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/ssh-keys/{group}/{name} [put]
+func (s *Server) handleUpdateVaultSSHKey(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeSSHKeys)
+	if item == nil {
+		return
+	}
+
+	var req struct {
+		PrivateKey string `json:"private_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.PrivateKey == "" {
+		apierror.Error(w, "private_key is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	key, err := item.client.GetSSHKey(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault SSH key: %v", err)
+		apierror.Error(w, "Failed to read SSH key from Vault", http.StatusInternalServerError)
+		return
+	}
+	if key == nil {
+		apierror.Error(w, "SSH key not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	key.PrivateKey = req.PrivateKey
+	if err := item.client.SaveSSHKey(ctx, key); err != nil {
+		log.Printf("Error saving SSH key to Vault: %v", err)
+		apierror.Error(w, "Failed to save SSH key to Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":       key.Name,
+		"group":      key.Group,
+		"created_at": key.CreatedAt,
+		"source":     "vault",
+	})
+}
+
+// handleDeleteVaultSSHKey godoc
+// @Summary Delete SSH key from Vault
+// @Description Permanently delete an SSH key and all its versions from Vault
+// @Tags Vault
+// @Param group path string true "Group"
+// @Param name path string true "SSH key name"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/ssh-keys/{group}/{name} [delete]
+func (s *Server) handleDeleteVaultSSHKey(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeSSHKeys)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	key, err := item.client.GetSSHKey(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault SSH key: %v", err)
+		apierror.Error(w, "Failed to read SSH key from Vault", http.StatusInternalServerError)
+		return
+	}
+	if key == nil {
+		apierror.Error(w, "SSH key not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	if err := item.client.DeleteSSHKey(ctx, item.group, item.name); err != nil {
+		log.Printf("Error deleting vault SSH key: %v", err)
+		apierror.Error(w, "Failed to delete SSH key from Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetVaultServer godoc
+// @Summary Get server from Vault
+// @Description Retrieve a single server configuration stored in Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Server name"
+// @Success 200 {object} vault.Server
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/servers/{group}/{name} [get]
+func (s *Server) handleGetVaultServer(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeServers)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	srv, err := item.client.GetServer(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault server: %v", err)
+		apierror.Error(w, "Failed to read server from Vault", http.StatusInternalServerError)
+		return
+	}
+	if srv == nil {
+		apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(srv)
+}
+
+// handleUpdateVaultServer godoc
+// @Summary Update server in Vault
+// @Description Update a server configuration stored in Vault. Only provided fields are changed.
+// @Tags Vault
+// @Accept json
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Server name"
+// @Param server body object{ip_address=string,port=int,username=string} true "Server"
+// @Success 200 {object} object{name=string,ip_address=string,port=int,username=string,group=string,source=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/servers/{group}/{name} [put]
+func (s *Server) handleUpdateVaultServer(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeServers)
+	if item == nil {
+		return
+	}
+
+	var req struct {
+		IPAddress string `json:"ip_address"`
+		Port      int    `json:"port"`
+		Username  string `json:"username"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Port < 0 || req.Port > 65535 {
+		apierror.Error(w, "Port must be between 1 and 65535", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	srv, err := item.client.GetServer(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault server: %v", err)
+		apierror.Error(w, "Failed to read server from Vault", http.StatusInternalServerError)
+		return
+	}
+	if srv == nil {
+		apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	if req.IPAddress != "" {
+		srv.IPAddress = req.IPAddress
+	}
+	if req.Port != 0 {
+		srv.Port = req.Port
+	}
+	if req.Username != "" {
+		srv.Username = req.Username
+	}
+
+	if err := item.client.SaveServer(ctx, srv); err != nil {
+		log.Printf("Error saving server to Vault: %v", err)
+		apierror.Error(w, "Failed to save server to Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":       srv.Name,
+		"ip_address": srv.IPAddress,
+		"port":       srv.Port,
+		"username":   srv.Username,
+		"group":      srv.Group,
+		"source":     "vault",
+	})
+}
+
+// handleDeleteVaultServer godoc
+// @Summary Delete server from Vault
+// @Description Permanently delete a server configuration and all its versions from Vault
+// @Tags Vault
+// @Param group path string true "Group"
+// @Param name path string true "Server name"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/servers/{group}/{name} [delete]
+func (s *Server) handleDeleteVaultServer(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeServers)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	srv, err := item.client.GetServer(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault server: %v", err)
+		apierror.Error(w, "Failed to read server from Vault", http.StatusInternalServerError)
+		return
+	}
+	if srv == nil {
+		apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	if err := item.client.DeleteServer(ctx, item.group, item.name); err != nil {
+		log.Printf("Error deleting vault server: %v", err)
+		apierror.Error(w, "Failed to delete server from Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetVaultEnvVariable godoc
+// @Summary Get environment variable from Vault
+// @Description Retrieve a single environment variable stored in Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Environment variable name"
+// @Success 200 {object} vault.EnvVariable
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/env-variables/{group}/{name} [get]
+func (s *Server) handleGetVaultEnvVariable(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeEnvVariables)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	envVar, err := item.client.GetEnvVariable(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault env variable: %v", err)
+		apierror.Error(w, "Failed to read environment variable from Vault", http.StatusInternalServerError)
+		return
+	}
+	if envVar == nil {
+		apierror.Error(w, "Environment variable not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(envVar)
+}
+
+// handleUpdateVaultEnvVariable godoc
+// @Summary Update environment variable in Vault
+// @Description Update an environment variable stored in Vault. Only provided fields are changed.
+// @Tags Vault
+// @Accept json
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Environment variable name"
+// @Param envVar body object{value=string,description=string} true "Environment Variable"
+// @Success 200 {object} object{name=string,description=string,group=string,source=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/env-variables/{group}/{name} [put]
+func (s *Server) handleUpdateVaultEnvVariable(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeEnvVariables)
+	if item == nil {
+		return
+	}
+
+	var req struct {
+		Value       string `json:"value"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	envVar, err := item.client.GetEnvVariable(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault env variable: %v", err)
+		apierror.Error(w, "Failed to read environment variable from Vault", http.StatusInternalServerError)
+		return
+	}
+	if envVar == nil {
+		apierror.Error(w, "Environment variable not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	if req.Value != "" {
+		envVar.Value = req.Value
+	}
+	if req.Description != "" {
+		envVar.Description = req.Description
+	}
+
+	if err := item.client.SaveEnvVariable(ctx, envVar); err != nil {
+		log.Printf("Error saving env variable to Vault: %v", err)
+		apierror.Error(w, "Failed to save environment variable to Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":        envVar.Name,
+		"description": envVar.Description,
+		"group":       envVar.Group,
+		"source":      "vault",
+	})
+}
+
+// handleDeleteVaultEnvVariable godoc
+// @Summary Delete environment variable from Vault
+// @Description Permanently delete an environment variable and all its versions from Vault
+// @Tags Vault
+// @Param group path string true "Group"
+// @Param name path string true "Environment variable name"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/env-variables/{group}/{name} [delete]
+func (s *Server) handleDeleteVaultEnvVariable(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeEnvVariables)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	envVar, err := item.client.GetEnvVariable(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault env variable: %v", err)
+		apierror.Error(w, "Failed to read environment variable from Vault", http.StatusInternalServerError)
+		return
+	}
+	if envVar == nil {
+		apierror.Error(w, "Environment variable not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	if err := item.client.DeleteEnvVariable(ctx, item.group, item.name); err != nil {
+		log.Printf("Error deleting vault env variable: %v", err)
+		apierror.Error(w, "Failed to delete environment variable from Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetVaultScript godoc
+// @Summary Get bash script from Vault
+// @Description Retrieve a single bash script stored in Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Script name"
+// @Success 200 {object} vault.BashScript
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/bash-scripts/{group}/{name} [get]
+func (s *Server) handleGetVaultScript(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeBashScripts)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	script, err := item.client.GetBashScript(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault script: %v", err)
+		apierror.Error(w, "Failed to read script from Vault", http.StatusInternalServerError)
+		return
+	}
+	if script == nil {
+		apierror.Error(w, "Script not found in Vault", http.StatusNotFound)
+		return
+	}
+	script.Interpreter = vaultScriptInterpreter(script.Interpreter)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(script)
+}
+
+// handleUpdateVaultScript godoc
+// @Summary Update bash script in Vault
+// @Description Update a bash script stored in Vault. Only provided fields are changed.
+// @Tags Vault
+// @Accept json
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Script name"
+// @Param script body object{description=string,content=string,filename=string,interpreter=string} true "Bash script data"
+// @Success 200 {object} map[string]interface{} "Updated script info with source field"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/bash-scripts/{group}/{name} [put]
+func (s *Server) handleUpdateVaultScript(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeBashScripts)
+	if item == nil {
+		return
+	}
+
+	var req struct {
+		Description string `json:"description"`
+		Content     string `json:"content"`
+		Filename    string `json:"filename"`
+		Interpreter string `json:"interpreter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.ValidateScriptInterpreter(req.Interpreter); err != nil {
+		apierror.InvalidField(w, "interpreter", fmt.Sprintf("Invalid interpreter: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	script, err := item.client.GetBashScript(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault script: %v", err)
+		apierror.Error(w, "Failed to read script from Vault", http.StatusInternalServerError)
+		return
+	}
+	if script == nil {
+		apierror.Error(w, "Script not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	if req.Description != "" {
+		script.Description = req.Description
+	}
+	if req.Content != "" {
+		script.Content = req.Content
+	}
+	if req.Filename != "" {
+		script.Filename = req.Filename
+	}
+	if req.Interpreter != "" {
+		script.Interpreter = req.Interpreter
+	}
+
+	if err := item.client.SaveBashScript(ctx, script); err != nil {
+		log.Printf("Error saving script to Vault: %v", err)
+		apierror.Error(w, "Failed to save script to Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":        script.Name,
+		"description": script.Description,
+		"filename":    script.Filename,
+		"group":       script.Group,
+		"interpreter": vaultScriptInterpreter(script.Interpreter),
+		"source":      "vault",
+	})
+}
+
+// handleDeleteVaultScript godoc
+// @Summary Delete bash script from Vault
+// @Description Permanently delete a bash script and all its versions from Vault
+// @Tags Vault
+// @Param group path string true "Group"
+// @Param name path string true "Script name"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/bash-scripts/{group}/{name} [delete]
+func (s *Server) handleDeleteVaultScript(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeBashScripts)
+	if item == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	script, err := item.client.GetBashScript(ctx, item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault script: %v", err)
+		apierror.Error(w, "Failed to read script from Vault", http.StatusInternalServerError)
+		return
+	}
+	if script == nil {
+		apierror.Error(w, "Script not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	if err := item.client.DeleteBashScript(ctx, item.group, item.name); err != nil {
+		log.Printf("Error deleting vault script: %v", err)
+		apierror.Error(w, "Failed to delete script from Vault", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
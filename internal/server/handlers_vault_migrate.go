@@ -0,0 +1,637 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
+	"github.com/pozgo/web-cli/internal/vault"
+)
+
+// itemMigration moves one item between SQLite and Vault
+// The copy is written first and the source deleted after; if the source cannot be
+// deleted the copy is removed again, so the item is never left in both stores
+type itemMigration struct {
+	label  string                                  // Item type for messages, e.g. "SSH key"
+	target string                                  // "Vault" or "SQLite"
+	exists func(ctx context.Context) (bool, error) // Whether the target already has an item with the same group and name
+	copy   func(ctx context.Context) error         // Writes the item to the target
+	undo   func(ctx context.Context) error         // Removes the copy again
+	remove func(ctx context.Context) error         // Deletes the source item
+}
+
+// migrateItem runs a migration, writing the error response and returning false if it fails
+func (s *Server) migrateItem(w http.ResponseWriter, r *http.Request, m *itemMigration) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	action := "migrate_to_" + strings.ToLower(m.target)
+
+	exists, err := m.exists(ctx)
+	if err != nil {
+		log.Printf("Error checking %s in %s: %v", m.label, m.target, err)
+		apierror.Error(w, fmt.Sprintf("Failed to check %s in %s", m.label, m.target), http.StatusInternalServerError)
+		return false
+	}
+	if exists {
+		apierror.Error(w, fmt.Sprintf("A %s with this group and name already exists in %s", m.label, m.target), http.StatusConflict)
+		return false
+	}
+
+	if err := m.copy(ctx); err != nil {
+		log.Printf("Error copying %s to %s: %v", m.label, m.target, err)
+		apierror.Error(w, fmt.Sprintf("Failed to save %s to %s", m.label, m.target), http.StatusInternalServerError)
+		audit.GetLogger().LogConfigChange(r, "migration", action, audit.OutcomeFailure)
+		return false
+	}
+
+	if err := m.remove(ctx); err != nil {
+		log.Printf("Error deleting migrated %s: %v", m.label, err)
+		if undoErr := m.undo(ctx); undoErr != nil {
+			log.Printf("Error removing %s copy from %s, it now exists in both stores: %v", m.label, m.target, undoErr)
+		}
+		apierror.Error(w, fmt.Sprintf("Failed to delete the original %s; it was not migrated", m.label), http.StatusInternalServerError)
+		audit.GetLogger().LogConfigChange(r, "migration", action, audit.OutcomeFailure)
+		return false
+	}
+
+	audit.GetLogger().LogConfigChange(r, "migration", action, audit.OutcomeSuccess)
+	return true
+}
+
+// parseMigrationID returns the SQLite ID of a migration request, writing 400 if it is invalid
+func parseMigrationID(w http.ResponseWriter, r *http.Request, label string) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, fmt.Sprintf("Invalid %s ID", label), http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// vaultLocation validates that a SQLite item's group and name can be used as a Vault path
+func vaultLocation(w http.ResponseWriter, group, name string) (string, bool) {
+	if group == "" {
+		group = "default"
+	}
+	if err := validation.ValidateVaultGroupName(group); err != nil {
+		apierror.Error(w, fmt.Sprintf("Group cannot be used in Vault: %v", err), http.StatusBadRequest)
+		return "", false
+	}
+	if err := validation.ValidateVaultSecretName(name); err != nil {
+		apierror.Error(w, fmt.Sprintf("Name cannot be used in Vault: %v", err), http.StatusBadRequest)
+		return "", false
+	}
+	return group, true
+}
+
+// writeMigratedToVault responds with the location of an item moved to Vault
+func writeMigratedToVault(w http.ResponseWriter, group, name string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":   name,
+		"group":  group,
+		"source": "vault",
+	})
+}
+
+// handleMigrateSSHKeyToVault godoc
+// @Summary Move SSH key to Vault
+// @Description Copy an SSH key to Vault under the same group and name and delete it from the database
+// @Tags SSH Keys
+// @Produce json
+// @Param id path int true "SSH Key ID"
+// @Success 200 {object} object{name=string,group=string,source=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /keys/{id}/migrate-to-vault [post]
+func (s *Server) handleMigrateSSHKeyToVault(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMigrationID(w, r, "key")
+	if !ok {
+		return
+	}
+
+	repo := repository.NewSSHKeyRepository(s.db)
+	key, err := repo.GetByID(id)
+	if err != nil || !s.groupAccess(r).canView(models.ResourceTypeSSHKeys, key.Group) {
+		apierror.Error(w, "SSH key not found", http.StatusNotFound)
+		return
+	}
+
+	client, err := s.getVaultClient()
+	if err != nil {
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		return
+	}
+	group, ok := vaultLocation(w, key.Group, key.Name)
+	if !ok {
+		return
+	}
+
+	vaultKey := &vault.SSHKey{Name: key.Name, PrivateKey: key.PrivateKey, Group: group, CreatedAt: key.CreatedAt}
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "SSH key",
+		target: "Vault",
+		exists: func(ctx context.Context) (bool, error) {
+			existing, err := client.GetSSHKey(ctx, group, key.Name)
+			return existing != nil, err
+		},
+		copy:   func(ctx context.Context) error { return client.SaveSSHKey(ctx, vaultKey) },
+		undo:   func(ctx context.Context) error { return client.DeleteSSHKey(ctx, group, key.Name) },
+		remove: func(ctx context.Context) error { return repo.Delete(id) },
+	}) {
+		return
+	}
+
+	writeMigratedToVault(w, group, key.Name)
+}
+
+// handleMigrateServerToVault godoc
+// @Summary Move server to Vault
+// @Description Copy a server to Vault under the same group and name (or IP address) and delete it from the database
+// @Tags Servers
+// @Produce json
+// @Param id path int true "Server ID"
+// @Success 200 {object} object{name=string,group=string,source=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /servers/{id}/migrate-to-vault [post]
+func (s *Server) handleMigrateServerToVault(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMigrationID(w, r, "server")
+	if !ok {
+		return
+	}
+
+	repo := repository.NewServerRepository(s.db)
+	server, err := repo.GetByID(id)
+	if err != nil || !s.groupAccess(r).canView(models.ResourceTypeServers, server.Group) {
+		apierror.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	client, err := s.getVaultClient()
+	if err != nil {
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		return
+	}
+
+	// Servers without a hostname are kept in Vault under their IP address
+	name := server.Name
+	if name == "" {
+		name = server.IPAddress
+	}
+	group, ok := vaultLocation(w, server.Group, name)
+	if !ok {
+		return
+	}
+
+	vaultServer := &vault.Server{Name: name, IPAddress: server.IPAddress, Port: server.Port, Username: server.Username, Group: group}
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "server",
+		target: "Vault",
+		exists: func(ctx context.Context) (bool, error) {
+			existing, err := client.GetServer(ctx, group, name)
+			return existing != nil, err
+		},
+		copy:   func(ctx context.Context) error { return client.SaveServer(ctx, vaultServer) },
+		undo:   func(ctx context.Context) error { return client.DeleteServer(ctx, group, name) },
+		remove: func(ctx context.Context) error { return repo.Delete(id) },
+	}) {
+		return
+	}
+
+	writeMigratedToVault(w, group, name)
+}
+
+// handleMigrateEnvVariableToVault godoc
+// @Summary Move environment variable to Vault
+// @Description Copy an environment variable to Vault under the same group and name and delete it from the database
+// @Tags Environment Variables
+// @Produce json
+// @Param id path int true "Environment Variable ID"
+// @Success 200 {object} object{name=string,group=string,source=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /env-variables/{id}/migrate-to-vault [post]
+func (s *Server) handleMigrateEnvVariableToVault(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMigrationID(w, r, "environment variable")
+	if !ok {
+		return
+	}
+
+	repo := repository.NewEnvVariableRepository(s.db)
+	envVar, err := repo.GetByID(id)
+	if err != nil || !s.groupAccess(r).canView(models.ResourceTypeEnvVariables, envVar.Group) {
+		apierror.Error(w, "Environment variable not found", http.StatusNotFound)
+		return
+	}
+
+	client, err := s.getVaultClient()
+	if err != nil {
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		return
+	}
+	group, ok := vaultLocation(w, envVar.Group, envVar.Name)
+	if !ok {
+		return
+	}
+
+	vaultVar := &vault.EnvVariable{Name: envVar.Name, Value: envVar.Value, Description: envVar.Description, Group: group}
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "environment variable",
+		target: "Vault",
+		exists: func(ctx context.Context) (bool, error) {
+			existing, err := client.GetEnvVariable(ctx, group, envVar.Name)
+			return existing != nil, err
+		},
+		copy:   func(ctx context.Context) error { return client.SaveEnvVariable(ctx, vaultVar) },
+		undo:   func(ctx context.Context) error { return client.DeleteEnvVariable(ctx, group, envVar.Name) },
+		remove: func(ctx context.Context) error { return repo.Delete(id) },
+	}) {
+		return
+	}
+
+	writeMigratedToVault(w, group, envVar.Name)
+}
+
+// handleMigrateBashScriptToVault godoc
+// @Summary Move bash script to Vault
+// @Description Copy a bash script to Vault under the same group and name and delete it, with its version history, from the database
+// @Tags Bash Scripts
+// @Produce json
+// @Param id path int true "Bash Script ID"
+// @Success 200 {object} object{name=string,group=string,source=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /bash-scripts/{id}/migrate-to-vault [post]
+func (s *Server) handleMigrateBashScriptToVault(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMigrationID(w, r, "bash script")
+	if !ok {
+		return
+	}
+
+	repo := repository.NewBashScriptRepository(s.db)
+	script, err := repo.GetByID(id)
+	if err != nil || !s.groupAccess(r).canView(models.ResourceTypeBashScripts, script.Group) {
+		apierror.Error(w, "Bash script not found", http.StatusNotFound)
+		return
+	}
+	if rejectReadOnlyScript(w, script) {
+		return
+	}
+
+	client, err := s.getVaultClient()
+	if err != nil {
+		apierror.Error(w, sanitizeVaultError(err), http.StatusBadRequest)
+		return
+	}
+	group, ok := vaultLocation(w, script.Group, script.Name)
+	if !ok {
+		return
+	}
+
+	vaultScript := &vault.BashScript{
+		Name:        script.Name,
+		Description: script.Description,
+		Content:     script.Content,
+		Filename:    script.Filename,
+		Group:       group,
+		Interpreter: script.Interpreter,
+	}
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "bash script",
+		target: "Vault",
+		exists: func(ctx context.Context) (bool, error) {
+			existing, err := client.GetBashScript(ctx, group, script.Name)
+			return existing != nil, err
+		},
+		copy:   func(ctx context.Context) error { return client.SaveBashScript(ctx, vaultScript) },
+		undo:   func(ctx context.Context) error { return client.DeleteBashScript(ctx, group, script.Name) },
+		remove: func(ctx context.Context) error { return repo.Delete(id) },
+	}) {
+		return
+	}
+
+	writeMigratedToVault(w, group, script.Name)
+}
+
+// handleMigrateVaultSSHKeyToSQLite godoc
+// @Summary Move SSH key from Vault to the database
+// @Description Copy an SSH key from Vault to the database and delete it from Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "SSH key name"
+// @Success 200 {object} models.SSHKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/ssh-keys/{group}/{name}/migrate-to-sqlite [post]
+func (s *Server) handleMigrateVaultSSHKeyToSQLite(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeSSHKeys)
+	if item == nil {
+		return
+	}
+
+	key, err := item.client.GetSSHKey(r.Context(), item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault SSH key: %v", err)
+		apierror.Error(w, "Failed to read SSH key from Vault", http.StatusInternalServerError)
+		return
+	}
+	if key == nil {
+		apierror.Error(w, "SSH key not found in Vault", http.StatusNotFound)
+		return
+	}
+	if err := validation.ValidateCommandName(key.Name); err != nil {
+		apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
+		return
+	}
+	if err := validation.ValidateSSHPrivateKey(key.PrivateKey); err != nil {
+		apierror.InvalidField(w, "private_key", fmt.Sprintf("Invalid SSH private key: %v", err))
+		return
+	}
+
+	repo := repository.NewSSHKeyRepository(s.db)
+	var created *models.SSHKey
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "SSH key",
+		target: "SQLite",
+		exists: func(ctx context.Context) (bool, error) {
+			keys, err := repo.GetByGroup(item.group)
+			if err != nil {
+				return false, err
+			}
+			for _, k := range keys {
+				if k.Name == item.name {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		copy: func(ctx context.Context) error {
+			created, err = repo.Create(&models.SSHKeyCreate{Name: key.Name, PrivateKey: key.PrivateKey, Group: item.group})
+			return err
+		},
+		undo:   func(ctx context.Context) error { return repo.Delete(created.ID) },
+		remove: func(ctx context.Context) error { return item.client.DeleteSSHKey(ctx, item.group, item.name) },
+	}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(created)
+}
+
+// handleMigrateVaultServerToSQLite godoc
+// @Summary Move server from Vault to the database
+// @Description Copy a server from Vault to the database and delete it from Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Server name"
+// @Success 200 {object} models.Server
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/servers/{group}/{name}/migrate-to-sqlite [post]
+func (s *Server) handleMigrateVaultServerToSQLite(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeServers)
+	if item == nil {
+		return
+	}
+
+	srv, err := item.client.GetServer(r.Context(), item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault server: %v", err)
+		apierror.Error(w, "Failed to read server from Vault", http.StatusInternalServerError)
+		return
+	}
+	if srv == nil {
+		apierror.Error(w, "Server not found in Vault", http.StatusNotFound)
+		return
+	}
+
+	// Servers kept under their IP address had no hostname
+	create := &models.ServerCreate{Name: srv.Name, IPAddress: srv.IPAddress, Port: srv.Port, Username: srv.Username, Group: item.group}
+	if create.Name == create.IPAddress {
+		create.Name = ""
+	}
+	if create.Name != "" {
+		if err := validation.ValidateHostname(create.Name); err != nil {
+			apierror.InvalidField(w, "name", fmt.Sprintf("Invalid hostname: %v", err))
+			return
+		}
+	}
+	if create.IPAddress != "" {
+		if err := validation.ValidateIPOrHostname(create.IPAddress); err != nil {
+			apierror.InvalidField(w, "ip_address", fmt.Sprintf("Invalid IP address or hostname: %v", err))
+			return
+		}
+	}
+
+	repo := repository.NewServerRepository(s.db)
+	var created *models.Server
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "server",
+		target: "SQLite",
+		exists: func(ctx context.Context) (bool, error) {
+			servers, err := repo.GetByGroup(item.group)
+			if err != nil {
+				return false, err
+			}
+			for _, existing := range servers {
+				if existing.Name == item.name || (existing.Name == "" && existing.IPAddress == item.name) {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		copy: func(ctx context.Context) error {
+			created, err = repo.Create(create)
+			return err
+		},
+		undo:   func(ctx context.Context) error { return repo.Delete(created.ID) },
+		remove: func(ctx context.Context) error { return item.client.DeleteServer(ctx, item.group, item.name) },
+	}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(created)
+}
+
+// handleMigrateVaultEnvVariableToSQLite godoc
+// @Summary Move environment variable from Vault to the database
+// @Description Copy an environment variable from Vault to the database and delete it from Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Variable name"
+// @Success 200 {object} models.EnvVariableResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/env-variables/{group}/{name}/migrate-to-sqlite [post]
+func (s *Server) handleMigrateVaultEnvVariableToSQLite(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeEnvVariables)
+	if item == nil {
+		return
+	}
+
+	envVar, err := item.client.GetEnvVariable(r.Context(), item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault environment variable: %v", err)
+		apierror.Error(w, "Failed to read environment variable from Vault", http.StatusInternalServerError)
+		return
+	}
+	if envVar == nil {
+		apierror.Error(w, "Environment variable not found in Vault", http.StatusNotFound)
+		return
+	}
+	if err := validation.ValidateEnvVarName(envVar.Name); err != nil {
+		apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
+		return
+	}
+	if err := validation.ValidateEnvVarValue(envVar.Value); err != nil {
+		apierror.InvalidField(w, "value", fmt.Sprintf("Invalid value: %v", err))
+		return
+	}
+
+	// Variable names are unique across groups in the database
+	repo := repository.NewEnvVariableRepository(s.db)
+	var created *models.EnvVariable
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "environment variable",
+		target: "SQLite",
+		exists: func(ctx context.Context) (bool, error) {
+			existing, _ := repo.GetByName(envVar.Name) // Fails when there is no such variable
+			return existing != nil, nil
+		},
+		copy: func(ctx context.Context) error {
+			created, err = repo.Create(&models.EnvVariableCreate{Name: envVar.Name, Value: envVar.Value, Description: envVar.Description, Group: item.group})
+			return err
+		},
+		undo:   func(ctx context.Context) error { return repo.Delete(created.ID) },
+		remove: func(ctx context.Context) error { return item.client.DeleteEnvVariable(ctx, item.group, item.name) },
+	}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(created.ToResponse(false))
+}
+
+// handleMigrateVaultScriptToSQLite godoc
+// @Summary Move bash script from Vault to the database
+// @Description Copy a bash script from Vault to the database and delete it from Vault
+// @Tags Vault
+// @Produce json
+// @Param group path string true "Group"
+// @Param name path string true "Script name"
+// @Success 200 {object} models.BashScriptResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/bash-scripts/{group}/{name}/migrate-to-sqlite [post]
+func (s *Server) handleMigrateVaultScriptToSQLite(w http.ResponseWriter, r *http.Request) {
+	item := s.openVaultItem(w, r, models.ResourceTypeBashScripts)
+	if item == nil {
+		return
+	}
+
+	script, err := item.client.GetBashScript(r.Context(), item.group, item.name)
+	if err != nil {
+		log.Printf("Error reading vault bash script: %v", err)
+		apierror.Error(w, "Failed to read bash script from Vault", http.StatusInternalServerError)
+		return
+	}
+	if script == nil {
+		apierror.Error(w, "Bash script not found in Vault", http.StatusNotFound)
+		return
+	}
+	if err := validation.ValidateBashScriptName(script.Name); err != nil {
+		apierror.InvalidField(w, "name", fmt.Sprintf("Invalid name: %v", err))
+		return
+	}
+	if err := validation.ValidateBashScriptContent(script.Content); err != nil {
+		apierror.InvalidField(w, "content", fmt.Sprintf("Invalid content: %v", err))
+		return
+	}
+	if err := validation.ValidateBashScriptFilename(script.Filename); err != nil {
+		apierror.InvalidField(w, "filename", fmt.Sprintf("Invalid filename: %v", err))
+		return
+	}
+	if err := validation.ValidateScriptInterpreter(script.Interpreter); err != nil {
+		apierror.InvalidField(w, "interpreter", fmt.Sprintf("Invalid interpreter: %v", err))
+		return
+	}
+
+	repo := repository.NewBashScriptRepository(s.db)
+	var created *models.BashScript
+	if !s.migrateItem(w, r, &itemMigration{
+		label:  "bash script",
+		target: "SQLite",
+		exists: func(ctx context.Context) (bool, error) {
+			scripts, err := repo.GetByGroup(item.group)
+			if err != nil {
+				return false, err
+			}
+			for _, existing := range scripts {
+				if existing.Name == item.name {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		copy: func(ctx context.Context) error {
+			created, err = repo.Create(&models.BashScriptCreate{
+				Name:        script.Name,
+				Description: script.Description,
+				Content:     script.Content,
+				Filename:    script.Filename,
+				Group:       item.group,
+				Interpreter: script.Interpreter,
+			})
+			return err
+		},
+		undo:   func(ctx context.Context) error { return repo.Delete(created.ID) },
+		remove: func(ctx context.Context) error { return item.client.DeleteBashScript(ctx, item.group, item.name) },
+	}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(created.ToResponse(true))
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+)
+
+// handleGetVaultSync godoc
+// @Summary Get Vault sync status
+// @Description Report the sync direction of each resource type, the conflict rule and the outcome of the last sync between SQLite and Vault
+// @Tags Vault
+// @Produce json
+// @Success 200 {object} VaultSyncStatus
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /vault/sync [get]
+func (s *Server) handleGetVaultSync(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.vaultSync.Status())
+}
+
+// handleVaultSync godoc
+// @Summary Sync with Vault now
+// @Description Sync SSH keys, servers, environment variables and scripts between SQLite and Vault by the configured policy without waiting for the next interval
+// @Tags Vault
+// @Produce json
+// @Success 200 {object} VaultSyncStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 502 {object} VaultSyncStatus
+// @Security BasicAuth
+// @Router /vault/sync [post]
+func (s *Server) handleVaultSync(w http.ResponseWriter, r *http.Request) {
+	if !s.vaultSync.enabled() {
+		apierror.Error(w, "Vault sync is not configured: set a direction such as VAULT_SYNC_SERVERS=two-way", http.StatusBadRequest)
+		return
+	}
+
+	status, err := s.syncVault(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		audit.GetLogger().LogConfigChange(r, "vault_sync", "sync", audit.OutcomeFailure)
+		w.WriteHeader(http.StatusBadGateway)
+	} else {
+		audit.GetLogger().LogConfigChange(r, "vault_sync", "sync", audit.OutcomeSuccess)
+	}
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,536 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// fakeVault is an in-memory KV v2 secrets engine mounted at "secret"
+// It accepts the token "test-token", AppRole logins with role "web-cli" and secret "s3cret",
+// and Kubernetes logins with role "web-cli" and JWT "pod-jwt"
+type fakeVault struct {
+	mu        sync.Mutex
+	secrets   map[string]map[string]interface{} // By path below the mount, e.g. "env/prod/API_KEY"
+	tokens    map[string]time.Time              // Valid tokens and when they expire (zero for never)
+	tokenTTL  int                               // Lease duration of login tokens in seconds
+	renewable bool                              // Whether tokens can be renewed by tokenTTL
+	logins    int
+	renewals  int
+}
+
+// newFakeVault starts a fake Vault and configures the server to use it
+func newFakeVault(t *testing.T, server *Server) *fakeVault {
+	t.Helper()
+	fake := &fakeVault{
+		secrets: make(map[string]map[string]interface{}),
+		tokens:  map[string]time.Time{"test-token": {}},
+	}
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+
+	if _, err := repository.NewVaultConfigRepository(server.db).CreateOrUpdate(&models.VaultConfigCreate{
+		Address: ts.URL, Token: "test-token", MountPath: "secret", Enabled: true,
+	}); err != nil {
+		t.Fatalf("Failed to configure Vault: %v", err)
+	}
+	return fake
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v1/auth/approle/login" || r.URL.Path == "/v1/auth/kubernetes/login" {
+		var body struct {
+			RoleID   string `json:"role_id"`
+			SecretID string `json:"secret_id"`
+			Role     string `json:"role"`
+			JWT      string `json:"jwt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		valid := body.RoleID == "web-cli" && body.SecretID == "s3cret"
+		if r.URL.Path == "/v1/auth/kubernetes/login" {
+			valid = body.Role == "web-cli" && body.JWT == "pod-jwt"
+		}
+		if !valid {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		f.logins++
+		token := fmt.Sprintf("login-token-%d", f.logins)
+		f.tokens[token] = time.Time{}
+		if f.tokenTTL > 0 {
+			f.tokens[token] = time.Now().Add(time.Duration(f.tokenTTL) * time.Second)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": f.tokenTTL}})
+		return
+	}
+
+	token := r.Header.Get("X-Vault-Token")
+	expiry, ok := f.tokens[token]
+	if !ok || (!expiry.IsZero() && time.Now().After(expiry)) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		ttl := 0
+		if !expiry.IsZero() {
+			ttl = int(math.Ceil(time.Until(expiry).Seconds()))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"ttl": ttl, "renewable": f.renewable}})
+		return
+	case "/v1/auth/token/renew-self":
+		if !f.renewable {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		f.renewals++
+		f.tokens[token] = time.Now().Add(time.Duration(f.tokenTTL) * time.Second)
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": f.tokenTTL, "renewable": true}})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+	switch {
+	case r.URL.Query().Get("list") == "true":
+		prefix := strings.TrimPrefix(path, "metadata/") + "/"
+		seen := make(map[string]bool)
+		var keys []string
+		for p := range f.secrets {
+			if rest, ok := strings.CutPrefix(p, prefix); ok {
+				key, _, isDir := strings.Cut(rest, "/")
+				if isDir {
+					key += "/"
+				}
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		sort.Strings(keys)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case r.Method == http.MethodGet:
+		data, ok := f.secrets[strings.TrimPrefix(path, "data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+	case r.Method == http.MethodPut || r.Method == http.MethodPost:
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.secrets[strings.TrimPrefix(path, "data/")] = body.Data
+		w.Write([]byte(`{"data":{"version":1}}`))
+	case r.Method == http.MethodDelete:
+		delete(f.secrets, strings.TrimPrefix(path, "metadata/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// loginCount returns how many logins succeeded
+func (f *fakeVault) loginCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins
+}
+
+// renewalCount returns how many token renewals succeeded
+func (f *fakeVault) renewalCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.renewals
+}
+
+// get returns a stored secret's data
+func (f *fakeVault) get(path string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.secrets[path]
+}
+
+func TestVaultItemCRUD(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	fake := newFakeVault(t, server)
+
+	call := func(handler http.HandlerFunc, method, group, name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/vault/env-variables/"+group+"/"+name, strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"group": group, "name": name})
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleCreateVaultEnvVariable(rr, httptest.NewRequest("POST", "/api/vault/env-variables", strings.NewReader(`{"name":"API_KEY","value":"v1","description":"key","group":"prod"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = call(server.handleGetVaultEnvVariable, "GET", "prod", "API_KEY", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"value":"v1"`) {
+		t.Fatalf("Expected the variable, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Only provided fields change
+	rr = call(server.handleUpdateVaultEnvVariable, "PUT", "prod", "API_KEY", `{"value":"v2"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if data := fake.get("env/prod/API_KEY"); data["value"] != "v2" || data["description"] != "key" {
+		t.Errorf("Unexpected stored variable: %v", data)
+	}
+
+	rr = call(server.handleDeleteVaultEnvVariable, "DELETE", "prod", "API_KEY", "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	for name, handler := range map[string]http.HandlerFunc{
+		"get": server.handleGetVaultEnvVariable, "update": server.handleUpdateVaultEnvVariable, "delete": server.handleDeleteVaultEnvVariable,
+	} {
+		if rr := call(handler, "PUT", "prod", "API_KEY", `{"value":"v3"}`); rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 for a deleted variable, got %d", name, rr.Code)
+		}
+	}
+
+	if rr := call(server.handleGetVaultEnvVariable, "GET", "..", "API_KEY", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid group, got %d", rr.Code)
+	}
+
+	// Other item types share the same lookup
+	rr = httptest.NewRecorder()
+	server.handleCreateVaultServer(rr, httptest.NewRequest("POST", "/api/vault/servers", strings.NewReader(`{"name":"web1","ip_address":"10.0.0.1"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = call(server.handleUpdateVaultServer, "PUT", "default", "web1", `{"port":2222}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"port":2222`) || !strings.Contains(rr.Body.String(), `"ip_address":"10.0.0.1"`) {
+		t.Errorf("Expected the port updated, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := call(server.handleUpdateVaultScript, "PUT", "default", "web1", `{"content":"echo hi"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing script, got %d", rr.Code)
+	}
+}
+
+func TestVaultAppRoleAuth(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	fake := newFakeVault(t, server)
+	fake.mu.Lock()
+	fake.tokenTTL = 1
+	fake.mu.Unlock()
+
+	configure := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleCreateOrUpdateVaultConfig(rr, httptest.NewRequest("POST", "/api/vault/config", strings.NewReader(body)))
+		return rr
+	}
+	cfg, _ := repository.NewVaultConfigRepository(server.db).Get()
+
+	if rr := configure(`{"address":"` + cfg.Address + `","auth_method":"approle","enabled":true}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without role_id, got %d", rr.Code)
+	}
+	if rr := configure(`{"address":"` + cfg.Address + `","auth_method":"kerberos","enabled":true}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported auth method, got %d", rr.Code)
+	}
+	rr := configure(`{"address":"` + cfg.Address + `","auth_method":"approle","role_id":"web-cli","secret_id":"s3cret","enabled":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "s3cret") || !strings.Contains(rr.Body.String(), `"has_secret_id":true`) {
+		t.Errorf("Expected the secret ID to be hidden, got %s", rr.Body.String())
+	}
+
+	// Updates without a secret_id keep the stored one
+	if rr := configure(`{"address":"` + cfg.Address + `","auth_method":"approle","role_id":"web-cli","enabled":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	list := func() {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		server.handleCreateVaultEnvVariable(rr, httptest.NewRequest("POST", "/api/vault/env-variables", strings.NewReader(`{"name":"API_KEY","value":"v1"}`)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	// The login token is reused across requests until it nears expiry
+	list()
+	logins := fake.loginCount()
+	list()
+	if got := fake.loginCount(); got != logins {
+		t.Errorf("Expected the token to be reused, got %d more logins", got-logins)
+	}
+	time.Sleep(800 * time.Millisecond)
+	list()
+	if got := fake.loginCount(); got != logins+1 {
+		t.Errorf("Expected a second login once the token neared expiry, got %d more logins", got-logins)
+	}
+}
+
+func TestVaultKubernetesAuth(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	fake := newFakeVault(t, server)
+	cfg, _ := repository.NewVaultConfigRepository(server.db).Get()
+
+	jwtPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(jwtPath, []byte("pod-jwt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	configure := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleCreateOrUpdateVaultConfig(rr, httptest.NewRequest("POST", "/api/vault/config", strings.NewReader(body)))
+		return rr
+	}
+	if rr := configure(`{"address":"` + cfg.Address + `","auth_method":"kubernetes","enabled":true}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without kubernetes_role, got %d", rr.Code)
+	}
+	if rr := configure(`{"address":"` + cfg.Address + `","auth_method":"kubernetes","kubernetes_role":"web-cli","jwt_path":"token","enabled":true}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a relative jwt_path, got %d", rr.Code)
+	}
+	rr := configure(`{"address":"` + cfg.Address + `","auth_method":"kubernetes","kubernetes_role":"web-cli","jwt_path":"` + jwtPath + `","enabled":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"has_token":false`) {
+		t.Errorf("Expected the static token to be dropped, got %s", rr.Body.String())
+	}
+
+	logins := fake.loginCount()
+	rr = httptest.NewRecorder()
+	server.handleCreateVaultEnvVariable(rr, httptest.NewRequest("POST", "/api/vault/env-variables", strings.NewReader(`{"name":"API_KEY","value":"v1"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if fake.loginCount() <= logins {
+		t.Error("Expected a Kubernetes login")
+	}
+}
+
+func TestVaultTokenRenewal(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	fake := newFakeVault(t, server)
+	fake.mu.Lock()
+	fake.tokenTTL = 1
+	fake.renewable = true
+	fake.tokens["test-token"] = time.Now().Add(time.Second)
+	fake.mu.Unlock()
+
+	client, err := server.getVaultClient()
+	if err != nil {
+		t.Fatalf("Failed to create Vault client: %v", err)
+	}
+	defer server.closeVaultClient()
+
+	// The token would have expired twice over without renewal
+	time.Sleep(2 * time.Second)
+	if fake.renewalCount() == 0 {
+		t.Fatal("Expected the token to be renewed")
+	}
+	if err := client.TestConnection(context.Background()); err != nil {
+		t.Errorf("Expected the renewed token to be valid: %v", err)
+	}
+}
+
+func TestVaultSync(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	fake := newFakeVault(t, server)
+
+	syncer, err := newVaultSyncer(&config.Config{VaultSyncServers: "two-way", VaultSyncEnvVariables: "to-vault", VaultSyncConflict: "vault"})
+	if err != nil {
+		t.Fatalf("newVaultSyncer() error = %v", err)
+	}
+	server.vaultSync = syncer
+	if _, err := newVaultSyncer(&config.Config{VaultSyncScripts: "sideways"}); err == nil {
+		t.Error("Expected error for an unknown direction")
+	}
+
+	serverRepo := repository.NewServerRepository(server.db)
+	serverRepo.Create(&models.ServerCreate{Name: "web1", IPAddress: "10.0.0.1", Port: 22, Username: "root"})
+	serverRepo.Create(&models.ServerCreate{Name: "web2", IPAddress: "10.0.0.3", Port: 22, Username: "root"})
+	fake.secrets["servers/prod/db1"] = map[string]interface{}{"ip_address": "10.0.0.2", "port": 22, "username": "admin"}
+	fake.secrets["servers/default/web2"] = map[string]interface{}{"ip_address": "10.0.0.4", "port": 22, "username": "root"}
+
+	envRepo := repository.NewEnvVariableRepository(server.db)
+	envRepo.Create(&models.EnvVariableCreate{Name: "API_KEY", Value: "v1"})
+	fake.secrets["env/default/VAULT_ONLY"] = map[string]interface{}{"value": "v2"}
+
+	runSync := func() VaultSyncStatus {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		server.handleVaultSync(rr, httptest.NewRequest("POST", "/api/vault/sync", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var status VaultSyncStatus
+		json.NewDecoder(rr.Body).Decode(&status)
+		return status
+	}
+
+	status := runSync()
+	if len(status.Results) != 2 {
+		t.Fatalf("Expected results for servers and env variables, got %+v", status.Results)
+	}
+	if r := status.Results[0]; r.Type != "servers" || r.ToVault != 1 || r.ToSQLite != 2 || len(r.Skipped) != 0 {
+		t.Errorf("Unexpected server result: %+v", r)
+	}
+	if r := status.Results[1]; r.Type != "env_variables" || r.ToVault != 1 || r.ToSQLite != 0 {
+		t.Errorf("Unexpected env variable result: %+v", r)
+	}
+
+	// Missing items were copied both ways and the conflict went to Vault's copy
+	if data := fake.get("servers/default/web1"); data["ip_address"] != "10.0.0.1" {
+		t.Errorf("Expected web1 in Vault, got %v", data)
+	}
+	if fake.get("env/default/API_KEY")["value"] != "v1" {
+		t.Error("Expected API_KEY in Vault")
+	}
+	servers, _ := serverRepo.GetAll()
+	ips := make(map[string]string)
+	for _, srv := range servers {
+		ips[srv.Group+"/"+srv.Name] = srv.IPAddress
+	}
+	if ips["prod/db1"] != "10.0.0.2" || ips["default/web2"] != "10.0.0.4" {
+		t.Errorf("Unexpected SQLite servers: %v", ips)
+	}
+	if envVar, _ := envRepo.GetByName("VAULT_ONLY"); envVar != nil {
+		t.Error("Expected to-vault sync not to import Vault's variables")
+	}
+
+	// Nothing is left to sync
+	status = runSync()
+	if r := status.Results[0]; r.ToVault != 0 || r.ToSQLite != 0 || r.Unchanged != 3 {
+		t.Errorf("Expected servers in sync, got %+v", r)
+	}
+}
+
+func TestVaultMigration(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	fake := newFakeVault(t, server)
+
+	call := func(handler http.HandlerFunc, vars map[string]string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest("POST", "/", nil), vars)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	repo := repository.NewEnvVariableRepository(server.db)
+	envVar, err := repo.Create(&models.EnvVariableCreate{Name: "API_KEY", Value: "v1", Group: "prod"})
+	if err != nil {
+		t.Fatalf("Failed to create variable: %v", err)
+	}
+
+	rr := call(server.handleMigrateEnvVariableToVault, map[string]string{"id": fmt.Sprint(envVar.ID)})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if fake.get("env/prod/API_KEY")["value"] != "v1" {
+		t.Error("Expected the variable in Vault")
+	}
+	if _, err := repo.GetByID(envVar.ID); err == nil {
+		t.Error("Expected the variable deleted from the database")
+	}
+
+	// An item already in the target is not overwritten
+	other, _ := repo.Create(&models.EnvVariableCreate{Name: "API_KEY", Value: "v2", Group: "prod"})
+	if rr := call(server.handleMigrateEnvVariableToVault, map[string]string{"id": fmt.Sprint(other.ID)}); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := call(server.handleMigrateVaultEnvVariableToSQLite, map[string]string{"group": "prod", "name": "API_KEY"}); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	repo.Delete(other.ID)
+
+	rr = call(server.handleMigrateVaultEnvVariableToSQLite, map[string]string{"group": "prod", "name": "API_KEY"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if fake.get("env/prod/API_KEY") != nil {
+		t.Error("Expected the variable deleted from Vault")
+	}
+	if moved, err := repo.GetByName("API_KEY"); err != nil || moved.Value != "v1" || moved.Group != "prod" {
+		t.Errorf("Expected the variable back in the database, got %+v, %v", moved, err)
+	}
+
+	if rr := call(server.handleMigrateVaultEnvVariableToSQLite, map[string]string{"group": "prod", "name": "API_KEY"}); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing item, got %d", rr.Code)
+	}
+}
+
+func TestVaultRefsInExecution(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	newFakeVault(t, server)
+
+	rr := httptest.NewRecorder()
+	server.handleCreateVaultServer(rr, httptest.NewRequest("POST", "/api/vault/servers", strings.NewReader(`{"name":"web1","ip_address":"127.0.0.1","port":1,"group":"prod"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleExecuteCommand(rr, httptest.NewRequest("POST", "/api/commands/execute", strings.NewReader(body)))
+		return rr
+	}
+
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{`{"command":"uptime","is_remote":true,"server_ref":"prod/web1"}`, http.StatusBadRequest},
+		{`{"command":"uptime","is_remote":true,"server_ref":"vault:../web1"}`, http.StatusBadRequest},
+		{`{"command":"uptime","is_remote":true,"server_ref":"vault:prod/web1","server_name":"web1"}`, http.StatusBadRequest},
+		{`{"command":"uptime","is_remote":true,"server_ref":"vault:web1"}`, http.StatusNotFound},
+		{`{"command":"uptime","is_remote":true,"server_ref":"vault:prod/web1","ssh_key_ref":"vault:prod/deploy"}`, http.StatusNotFound},
+	} {
+		if rr := execute(tc.body); rr.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d: %s", tc.body, tc.code, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The referenced server is used; connecting to it fails
+	rr = execute(`{"command":"uptime","is_remote":true,"server_ref":"vault:prod/web1"}`)
+	if rr.Code == http.StatusBadRequest || rr.Code == http.StatusNotFound {
+		t.Errorf("Expected the Vault server to be resolved, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/validation"
+	"github.com/pozgo/web-cli/internal/wol"
+)
+
+// serverLabel returns the name of a server, or its address when it has none
+func serverLabel(server *models.Server) string {
+	if server.Name != "" {
+		return server.Name
+	}
+	return server.IPAddress
+}
+
+// handleWakeServer godoc
+// @Summary Wake a server with Wake-on-LAN
+// @Description Send a Wake-on-LAN magic packet to the mac_address of a server. By default web-cli broadcasts it on its own network; with relay_server_id another server on the sleeping server's network sends it instead, running python3, wakeonlan or wol as a command execution with the same checks, history, rate limit and quota as POST /commands/execute. The body is optional. A sent packet does not mean the server woke up. Requires execute permission on the server's group.
+// @Tags Servers
+// @Accept json
+// @Produce json
+// @Param id path int true "Server ID"
+// @Param wake body models.ServerWakeRequest false "Where to send the packet from and to"
+// @Success 200 {object} models.ServerWakeResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /servers/{id}/wake [post]
+func (s *Server) handleWakeServer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var wake models.ServerWakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&wake); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Resources in groups the caller cannot view are reported as missing
+	repo := repository.NewServerRepository(s.db)
+	access := s.groupAccess(r)
+	server, err := repo.GetByID(id)
+	if err != nil || !access.canView(models.ResourceTypeServers, server.Group) {
+		apierror.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+	if !access.canExecute(models.ResourceTypeServers, server.Group) {
+		denyGroupAccess(w, r, models.ResourceTypeServers, server.Group, models.PermissionExecute)
+		return
+	}
+
+	if server.MACAddress == "" {
+		apierror.InvalidField(w, "mac_address", "The server has no mac_address to wake")
+		return
+	}
+	mac, err := wol.ParseMAC(server.MACAddress)
+	if err != nil {
+		apierror.InvalidField(w, "mac_address", err.Error())
+		return
+	}
+
+	if wake.Broadcast == "" {
+		wake.Broadcast = wol.DefaultBroadcast
+	} else if ip := net.ParseIP(wake.Broadcast); ip == nil || ip.To4() == nil {
+		apierror.InvalidField(w, "broadcast", fmt.Sprintf("Invalid broadcast: %q is not an IPv4 address", wake.Broadcast))
+		return
+	}
+	if wake.Port == 0 {
+		wake.Port = wol.DefaultPort
+	} else if err := validation.ValidatePort(wake.Port); err != nil {
+		apierror.InvalidField(w, "port", fmt.Sprintf("Invalid port: %v", err))
+		return
+	}
+
+	result := models.ServerWakeResult{
+		Server:     serverLabel(server),
+		MACAddress: mac.String(),
+		Broadcast:  wake.Broadcast,
+		Port:       wake.Port,
+	}
+	metadata := map[string]string{
+		"mac_address": result.MACAddress,
+		"broadcast":   net.JoinHostPort(wake.Broadcast, strconv.Itoa(wake.Port)),
+	}
+
+	if wake.RelayServerID == 0 {
+		err := wol.Send(mac, wake.Broadcast, wake.Port)
+		audit.GetLogger().LogServerWake(r, result.Server, metadata, err)
+		if err != nil {
+			log.Printf("Error waking server %s: %v", result.Server, err)
+			apierror.Error(w, "Failed to send magic packet", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Magic packet sent to %s (%s)", result.Server, result.MACAddress)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	relay, err := repo.GetByID(wake.RelayServerID)
+	if err != nil || !access.canView(models.ResourceTypeServers, relay.Group) {
+		apierror.Error(w, "Relay server not found", http.StatusNotFound)
+		return
+	}
+	if relay.ID == server.ID {
+		apierror.InvalidField(w, "relay_server_id", "A server cannot relay its own magic packet")
+		return
+	}
+	result.Relay = serverLabel(relay)
+	metadata["relay"] = result.Relay
+
+	// The relay runs the packet sender like any other command, as its configured user
+	relayResult := s.executeCommand(w, r, &models.CommandExecution{
+		Command:  wol.RelayScript(mac, wake.Broadcast, wake.Port),
+		User:     relay.Username,
+		IsRemote: true,
+		ServerID: &relay.ID,
+	})
+	if relayResult == nil {
+		// Errors, approvals and maintenance windows of the relay execution were answered as they are
+		audit.GetLogger().LogServerWake(r, result.Server, metadata, errors.New("relay execution was refused"))
+		return
+	}
+	if relayResult.ExitCode != 0 {
+		err := fmt.Errorf("relay exited with code %d", relayResult.ExitCode)
+		audit.GetLogger().LogServerWake(r, result.Server, metadata, err)
+		apierror.Error(w, fmt.Sprintf("Relay server %s failed to send the magic packet: %s", result.Relay, strings.TrimSpace(relayResult.Output)), http.StatusBadGateway)
+		return
+	}
+
+	audit.GetLogger().LogServerWake(r, result.Server, metadata, nil)
+	log.Printf("Magic packet sent to %s (%s) through %s", result.Server, result.MACAddress, result.Relay)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// webhookTestTimeout bounds the ping sent by a webhook test
+const webhookTestTimeout = 10 * time.Second
+
+// handleListWebhooks godoc
+// @Summary List webhooks
+// @Description List the endpoints notified when commands and scripts finish. Secrets are never returned.
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /webhooks [get]
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	repo := repository.NewWebhookRepository(s.db)
+
+	webhooks, err := repo.GetAll()
+	if err != nil {
+		log.Printf("Error fetching webhooks: %v", err)
+		apierror.Error(w, "Failed to fetch webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	if webhooks == nil {
+		webhooks = []*models.Webhook{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// handleCreateWebhook godoc
+// @Summary Create a webhook
+// @Description Create an endpoint notified when commands and scripts finish. Events filters the event types delivered (all when empty); a secret signs each delivery.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body models.WebhookCreate true "Webhook to create"
+// @Success 201 {object} models.Webhook
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /webhooks [post]
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var create models.WebhookCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewWebhookRepository(s.db)
+
+	webhook, err := repo.Create(&create)
+	if err != nil {
+		log.Printf("Error creating webhook: %v", err)
+		audit.GetLogger().LogConfigChange(r, "webhook", "create", audit.OutcomeFailure)
+		apierror.Error(w, "Failed to create webhook: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "webhook", "create", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// handleGetWebhook godoc
+// @Summary Get a webhook
+// @Description Get a webhook by ID, with the outcome of its latest delivery
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /webhooks/{id} [get]
+func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewWebhookRepository(s.db)
+
+	webhook, err := repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching webhook: %v", err)
+		apierror.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// handleUpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Update a webhook's name, URL, secret, events or enabled state. An empty secret removes it.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body models.WebhookUpdate true "Fields to update"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /webhooks/{id} [put]
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.WebhookUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierror.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewWebhookRepository(s.db)
+
+	webhook, err := repo.Update(id, &update)
+	if err != nil {
+		log.Printf("Error updating webhook: %v", err)
+		audit.GetLogger().LogConfigChange(r, "webhook", "update", audit.OutcomeFailure)
+		if strings.Contains(err.Error(), "not found") {
+			apierror.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		apierror.Error(w, "Failed to update webhook: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "webhook", "update", audit.OutcomeSuccess)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// handleDeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook
+// @Tags Webhooks
+// @Param id path int true "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /webhooks/{id} [delete]
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewWebhookRepository(s.db)
+
+	if err := repo.Delete(id); err != nil {
+		log.Printf("Error deleting webhook: %v", err)
+		apierror.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	audit.GetLogger().LogConfigChange(r, "webhook", "delete", audit.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTestWebhook godoc
+// @Summary Test a webhook
+// @Description Send a ping event to a webhook once, without retries, and report whether it was accepted
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.WebhookTestResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BasicAuth
+// @Router /webhooks/{id}/test [post]
+func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := repository.NewWebhookRepository(s.db)
+
+	webhook, err := repo.GetByID(id)
+	if err != nil {
+		log.Printf("Error fetching webhook: %v", err)
+		apierror.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), webhookTestTimeout)
+	defer cancel()
+	sendErr := webhookTarget(webhook).Send(ctx, notify.NewEvent(notify.EventPing, nil))
+
+	if err := repo.RecordDelivery(id, sendErr); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	result := models.WebhookTestResult{Delivered: sendErr == nil}
+	if sendErr != nil {
+		result.Error = sendErr.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
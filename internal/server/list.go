@@ -0,0 +1,255 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+const (
+	defaultPerPage = 50  // Items per page when only ?page is given
+	maxPerPage     = 500 // Largest ?per_page accepted
+)
+
+// parseListFilter reads the list query parameters shared by the list endpoints:
+// ?group, ?q, ?tag, ?sort, ?order (asc or desc), ?page and ?per_page
+// Without ?page or ?per_page the list is not paged, so existing clients keep getting every item
+func parseListFilter(r *http.Request) (models.ListFilter, error) {
+	query := r.URL.Query()
+	filter := models.ListFilter{
+		Group: query.Get("group"),
+		Query: query.Get("q"),
+		Tag:   query.Get("tag"),
+		Sort:  query.Get("sort"),
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	if !query.Has("page") && !query.Has("per_page") {
+		return filter, nil
+	}
+	page, perPage := 1, defaultPerPage
+	if v := query.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return filter, fmt.Errorf("page must be a positive number")
+		}
+		page = n
+	}
+	if v := query.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPerPage {
+			return filter, fmt.Errorf("per_page must be between 1 and %d", maxPerPage)
+		}
+		perPage = n
+	}
+	filter.Limit = perPage
+	filter.Offset = (page - 1) * perPage
+	return filter, nil
+}
+
+// restrictGroups limits the filter to the groups the caller may view
+// Admins see every group, so their filter is left as is
+func (a *groupAccess) restrictGroups(filter *models.ListFilter, resourceType string, groups func() ([]string, error)) error {
+	if a.principal.IsAdmin() {
+		return nil
+	}
+	all, err := groups()
+	if err != nil {
+		return err
+	}
+	filter.Groups = a.visibleGroups(resourceType, all)
+	return nil
+}
+
+// listWithVault lists items from SQLite and merges in the items stored in Vault
+// SQLite pages the list itself; Vault items cannot be queried, so while Vault is
+// enabled every SQLite match is loaded and the merged list is sorted and paged in memory.
+// keep reports whether a merged item matches the filter and is visible to the caller
+func listWithVault[T any](
+	s *Server,
+	ctx context.Context,
+	filter models.ListFilter,
+	list func(models.ListFilter) ([]T, int, error),
+	merge func(context.Context, []T) []T,
+	keep func(T) bool,
+	sortValue func(item T, field string) any,
+) ([]T, int, error) {
+	if s.getVaultClientIfEnabled() == nil {
+		items, total, err := list(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		// An empty list is encoded as [], not null
+		return append(make([]T, 0, len(items)), merge(ctx, items)...), total, nil
+	}
+
+	items, _, err := list(filter.Unpaged())
+	if err != nil {
+		return nil, 0, err
+	}
+	merged := merge(ctx, items)
+	matched := make([]T, 0, len(merged))
+	for _, item := range merged {
+		if keep(item) {
+			matched = append(matched, item)
+		}
+	}
+
+	if filter.Sort != "" {
+		slices.SortStableFunc(matched, func(a, b T) int {
+			c := compareSortValues(sortValue(a, filter.Sort), sortValue(b, filter.Sort))
+			if filter.Desc {
+				return -c
+			}
+			return c
+		})
+	}
+	start, end := filter.Page(len(matched))
+	return matched[start:end], len(matched), nil
+}
+
+// compareSortValues orders two values of a sort field the way SQLite does
+func compareSortValues(a, b any) int {
+	switch x := a.(type) {
+	case string:
+		return cmp.Compare(x, b.(string))
+	case int:
+		return cmp.Compare(x, b.(int))
+	case int64:
+		return cmp.Compare(x, b.(int64))
+	case time.Time:
+		return x.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// inVaultGroup reports whether a Vault item's group matches the filter's group
+// Vault items without a group belong to the default group
+func inVaultGroup(filter models.ListFilter, group string) bool {
+	return filter.Group == "" || group == filter.Group || (group == "" && filter.Group == "default")
+}
+
+// writeListHeaders reports the number of matching items in X-Total-Count and,
+// for a paged list, links to the neighbouring pages in a Link header
+func writeListHeaders(w http.ResponseWriter, r *http.Request, filter models.ListFilter, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if filter.Limit <= 0 {
+		return
+	}
+
+	page := filter.Offset/filter.Limit + 1
+	var links []string
+	link := func(page int, rel string) {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(filter.Limit))
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", u.String(), rel))
+	}
+	if filter.Offset+filter.Limit < total {
+		link(page+1, "next")
+	}
+	if page > 1 {
+		link(page-1, "prev")
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// writeListError answers a list that could not be loaded
+// An unsupported sort field is the caller's mistake and is reported as such
+func writeListError(w http.ResponseWriter, err error, items string) {
+	if errors.Is(err, repository.ErrInvalidSort) {
+		apierror.WithCode(w, apierror.CodeInvalidSort, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Error fetching %s: %v", items, err)
+	apierror.Error(w, "Failed to fetch "+items, http.StatusInternalServerError)
+}
+
+// sshKeySortValue returns an SSH key's value of a sort field
+func sshKeySortValue(k *models.SSHKey, field string) any {
+	switch field {
+	case "name":
+		return k.Name
+	case "group":
+		return k.Group
+	case "last_used_at":
+		if k.LastUsedAt == nil {
+			return time.Time{}
+		}
+		return *k.LastUsedAt
+	case "use_count":
+		return k.UseCount
+	case "updated_at":
+		return k.UpdatedAt
+	}
+	return k.CreatedAt
+}
+
+// serverSortValue returns a server's value of a sort field
+func serverSortValue(srv *models.Server, field string) any {
+	switch field {
+	case "name":
+		return srv.Name
+	case "ip_address":
+		return srv.IPAddress
+	case "port":
+		return srv.Port
+	case "username":
+		return srv.Username
+	case "group":
+		return srv.Group
+	case "updated_at":
+		return srv.UpdatedAt
+	}
+	return srv.CreatedAt
+}
+
+// envVariableSortValue returns an environment variable's value of a sort field
+func envVariableSortValue(ev *models.EnvVariable, field string) any {
+	switch field {
+	case "name":
+		return ev.Name
+	case "group":
+		return ev.Group
+	case "updated_at":
+		return ev.UpdatedAt
+	}
+	return ev.CreatedAt
+}
+
+// bashScriptSortValue returns a bash script's value of a sort field
+func bashScriptSortValue(script *models.BashScript, field string) any {
+	switch field {
+	case "name":
+		return script.Name
+	case "group":
+		return script.Group
+	case "filename":
+		return script.Filename
+	case "updated_at":
+		return script.UpdatedAt
+	}
+	return script.CreatedAt
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "run", "web-cli.sock")
+
+	listener, err := listenUnixSocket(socketPath, 0600)
+	if err != nil {
+		t.Fatalf("Failed to listen on socket: %v", err)
+	}
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("Socket file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected socket mode 0600, got %04o", info.Mode().Perm())
+	}
+	listener.Close()
+
+	// A stale socket from a previous run is replaced
+	listener, err = listenUnixSocket(socketPath, 0660)
+	if err != nil {
+		t.Fatalf("Failed to replace stale socket: %v", err)
+	}
+	listener.Close()
+}
+
+func TestListenUnixSocket_RefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := listenUnixSocket(path, 0660); err == nil {
+		t.Error("Expected error when path is a regular file")
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Error("Regular file should not be removed")
+	}
+}
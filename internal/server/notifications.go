@@ -0,0 +1,234 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/notify"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// newNotifier creates the dispatcher that delivers execution events
+// The outcome of each webhook delivery is stored on the webhook
+func newNotifier(db *database.DB) *notify.Dispatcher {
+	repo := repository.NewWebhookRepository(db)
+	return notify.NewDispatcher(notify.DispatcherConfig{
+		Report: func(target notify.Target, _ *notify.Event, err error) {
+			webhook, ok := target.(*notify.Webhook)
+			if !ok || webhook.ID == 0 {
+				return
+			}
+			if recordErr := repo.RecordDelivery(webhook.ID, err); recordErr != nil {
+				log.Printf("Warning: %v", recordErr)
+			}
+		},
+	})
+}
+
+// newSlackTarget returns the Slack target of the configuration, or nil when Slack is not configured
+func newSlackTarget(cfg *config.Config) (*notify.Slack, error) {
+	if cfg.SlackWebhookURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(cfg.SlackWebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid SLACK_WEBHOOK_URL: must be an http or https URL")
+	}
+	return &notify.Slack{URL: cfg.SlackWebhookURL, Channel: cfg.SlackChannel, Username: cfg.SlackUsername}, nil
+}
+
+// newEmailTarget returns the email target of the configuration, or nil when SMTP is not configured
+func newEmailTarget(cfg *config.Config) (*notify.Email, error) {
+	if cfg.SMTPHost == "" {
+		return nil, nil
+	}
+
+	var body string
+	if cfg.EmailBodyTemplate != "" {
+		data, err := os.ReadFile(cfg.EmailBodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMAIL_BODY_TEMPLATE: %w", err)
+		}
+		body = string(data)
+	}
+
+	email, err := notify.NewEmail(notify.EmailConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		TLS:      cfg.SMTPTLS,
+		From:     cfg.EmailFrom,
+		To:       cfg.EmailTo,
+		Events:   cfg.EmailEvents,
+		Subject:  cfg.EmailSubject,
+		Body:     body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid email notification settings: %w", err)
+	}
+	return email, nil
+}
+
+// scriptExecution describes a script run for its notifications
+// severity classifies its exit code by the script's exit code meanings
+func scriptExecution(script *models.BashScript, exec *models.ScriptExecution, serverName, serverGroup, severity string) notify.Execution {
+	execution := notify.Execution{Kind: notify.KindScript, ScriptID: script.ID, ScriptName: script.Name, Server: serverName, ServerGroup: serverGroup, User: exec.User, Severity: severity}
+	if exec.PresetID != nil {
+		execution.PresetID = *exec.PresetID
+	}
+	return execution
+}
+
+// notifyExecution publishes a finished command or script to the activity stream and sends its
+// event to the subscribed webhooks, by email when it matches the email events, to Slack when the script ran from a preset that opted in,
+// (or the preset's own channel) and to the channels of every notification rule it matches
+// exec describes what ran; its outcome is filled in from the result, classified by exec.Severity when set
+func (s *Server) notifyExecution(r *http.Request, exec notify.Execution, result *executor.ExecuteResult) {
+	if exec.Severity == "" {
+		exec.Severity = models.CommandSeverity(result.ExitCode)
+	}
+	exec.Success = exec.Severity == models.SeverityOK
+	exec.ExitCode = result.ExitCode
+	exec.DurationMs = result.ExecutionTime
+	exec.Output = result.Output
+	if result.Error != nil {
+		exec.Error = result.Error.Error()
+	}
+	if principal := middleware.PrincipalFromContext(r.Context()); principal != nil {
+		exec.TriggeredBy = principal.Name
+	}
+	exec.RequestID = middleware.RequestIDFromContext(r.Context())
+
+	s.executionFinished(&exec)
+	if s.notifier == nil {
+		return
+	}
+
+	webhooks, err := repository.NewWebhookRepository(s.db).GetEnabled()
+	if err != nil {
+		log.Printf("Warning: failed to load webhooks: %v", err)
+		return
+	}
+
+	// A preset only applies to the script it was saved for
+	var preset *models.ScriptPreset
+	if exec.PresetID != 0 {
+		preset, err = repository.NewScriptPresetRepository(s.db).GetByID(exec.PresetID)
+		if err != nil || preset.ScriptID != exec.ScriptID {
+			preset = nil
+			exec.PresetID = 0
+		} else {
+			exec.PresetName = preset.Name
+		}
+	}
+
+	event := notify.NewExecutionEvent(exec)
+	var targets []notify.Target
+	if s.email != nil && s.email.Wants(event) {
+		targets = append(targets, s.email)
+	}
+	if preset != nil {
+		if preset.SlackNotify == models.SlackNotifyAlways || (preset.SlackNotify == models.SlackNotifyFailures && exec.Severity == models.SeverityCritical) {
+			channel := preset.NotifyChannel
+			if channel == "" {
+				channel = notify.ChannelSlack
+			}
+			if target := s.channelTarget(channel, webhooks); target != nil {
+				targets = append(targets, target)
+			} else if preset.NotifyChannel != "" {
+				log.Printf("Warning: script preset %q: channel %q is not available", preset.Name, channel)
+			}
+		}
+	}
+	for _, webhook := range webhooks {
+		if event.Matches(webhook.Events) {
+			targets = append(targets, webhookTarget(webhook))
+		}
+	}
+	targets = append(targets, s.ruleTargets(event.Execution, webhooks)...)
+
+	// A target reached through several routes gets the event once
+	seen := make(map[string]bool)
+	unique := targets[:0]
+	for _, target := range targets {
+		if !seen[target.Name()] {
+			seen[target.Name()] = true
+			unique = append(unique, target)
+		}
+	}
+	s.notifier.Dispatch(event, unique...)
+}
+
+// ruleTargets returns the channels of the enabled notification rules the execution matches
+// Channels that are not configured, such as Slack without SLACK_WEBHOOK_URL, are logged and skipped
+func (s *Server) ruleTargets(exec *notify.Execution, webhooks []*models.Webhook) []notify.Target {
+	rules, err := repository.NewNotificationRuleRepository(s.db).GetEnabled()
+	if err != nil {
+		log.Printf("Warning: failed to load notification rules: %v", err)
+		return nil
+	}
+
+	var targets []notify.Target
+	for _, rule := range rules {
+		if !notify.RuleMatches(rule, exec) {
+			continue
+		}
+		for _, channel := range rule.Channels {
+			target := s.channelTarget(channel, webhooks)
+			if target == nil {
+				log.Printf("Warning: notification rule %q: channel %q is not available", rule.Name, channel)
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// channelTarget resolves a notification rule channel, or returns nil when it is not available
+func (s *Server) channelTarget(channel string, webhooks []*models.Webhook) notify.Target {
+	kind, arg, err := notify.ParseChannel(channel)
+	if err != nil {
+		return nil
+	}
+
+	switch kind {
+	case notify.ChannelWebhook:
+		for _, webhook := range webhooks {
+			if webhook.Name == arg {
+				return webhookTarget(webhook)
+			}
+		}
+	case notify.ChannelSlack:
+		switch {
+		case s.slack == nil:
+		case arg != "":
+			return s.slack.WithChannel(arg)
+		default:
+			return s.slack
+		}
+	case notify.ChannelEmail:
+		switch {
+		case s.email == nil:
+		case arg != "":
+			return s.email.WithRecipients(arg)
+		default:
+			return s.email
+		}
+	}
+	return nil
+}
+
+// webhookTarget returns the delivery target of a stored webhook
+func webhookTarget(webhook *models.Webhook) *notify.Webhook {
+	return &notify.Webhook{ID: webhook.ID, Label: webhook.Name, URL: webhook.URL, Secret: webhook.Secret}
+}
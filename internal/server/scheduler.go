@@ -0,0 +1,159 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/cron"
+	"github.com/pozgo/web-cli/internal/middleware"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+)
+
+// schedulerPrincipal is the caller of scheduled runs, recorded in history and the audit log
+// Only admins may schedule presets, so scheduled runs bypass group permissions as well.
+var schedulerPrincipal = &middleware.Principal{Name: "system:scheduler", Admin: true}
+
+// presetRuns tracks the scheduled runs of script presets in progress, so a run that takes
+// longer than the interval of its schedule is not started again before it ends
+type presetRuns struct {
+	mu      sync.Mutex
+	running map[int64]bool // Preset ID -> a scheduled run is in progress
+}
+
+// start marks a run of preset id as started, and reports false when one is in progress
+func (p *presetRuns) start(id int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running[id] {
+		return false
+	}
+	if p.running == nil {
+		p.running = make(map[int64]bool)
+	}
+	p.running[id] = true
+	return true
+}
+
+// finish marks the run of preset id as ended
+func (p *presetRuns) finish(id int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.running, id)
+}
+
+// startScheduler runs the scheduled script presets at the start of every minute their
+// schedule matches, while read-only mode is off
+func (s *Server) startScheduler() {
+	go func() {
+		for {
+			now := time.Now()
+			timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+			at := (<-timer.C).Truncate(time.Minute)
+			if s.maintenance.Status().Enabled {
+				continue
+			}
+			s.runScheduledPresets(at)
+		}
+	}()
+}
+
+// runScheduledPresets starts the scheduled presets whose schedule matches the minute of at
+func (s *Server) runScheduledPresets(at time.Time) {
+	presets, err := repository.NewScriptPresetRepository(s.db).GetScheduled()
+	if err != nil {
+		log.Printf("Error fetching scheduled script presets: %v", err)
+		return
+	}
+
+	for _, preset := range presets {
+		schedule, err := cron.Parse(preset.Schedule)
+		if err != nil {
+			log.Printf("Skipping script preset %q: invalid schedule %q: %v", preset.Name, preset.Schedule, err)
+			continue
+		}
+		if !schedule.Matches(at) {
+			continue
+		}
+		if !s.scheduled.start(preset.ID) {
+			log.Printf("Skipping scheduled run of script preset %q: the previous run has not ended", preset.Name)
+			continue
+		}
+		go func(preset *models.ScriptPreset) {
+			defer s.scheduled.finish(preset.ID)
+			s.runScheduledPreset(context.Background(), preset, at)
+		}(preset)
+	}
+}
+
+// runScheduledPreset runs a script preset through the script execution endpoint, so the run
+// goes through the same checks, queue, history and notifications as one started by a caller,
+// and records its outcome on the preset
+// Runs blocked by a maintenance window are skipped and leave the preset's last run as it was.
+func (s *Server) runScheduledPreset(ctx context.Context, preset *models.ScriptPreset, at time.Time) {
+	exec := models.ScriptExecution{
+		ScriptID:  preset.ScriptID,
+		User:      preset.User,
+		IsRemote:  preset.IsRemote,
+		EnvVarIDs: preset.EnvVarIDs,
+		PresetID:  &preset.ID,
+	}
+	if preset.IsRemote {
+		exec.ServerID = preset.ServerID
+		exec.SSHKeyID = preset.SSHKeyID
+	}
+
+	status, runErr, historyID := models.PresetRunError, "", (*int64)(nil)
+	payload, err := json.Marshal(exec)
+	if err != nil {
+		runErr = err.Error()
+	} else {
+		req, _ := http.NewRequestWithContext(middleware.WithPrincipal(ctx, schedulerPrincipal),
+			http.MethodPost, "/api/bash-scripts/execute", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := &bridgeWriter{header: make(http.Header)}
+		s.handleExecuteScript(w, req)
+		if w.status == http.StatusLocked {
+			log.Printf("Skipping scheduled run of script preset %q: %s", preset.Name, scheduledRunMessage(w))
+			return
+		}
+		status, runErr, historyID = scheduledRunOutcome(w)
+	}
+
+	if runErr != "" {
+		log.Printf("Scheduled run of script preset %q failed: %s", preset.Name, runErr)
+	}
+	if err := repository.NewScriptPresetRepository(s.db).RecordRun(preset.ID, at, status, runErr, historyID); err != nil {
+		log.Printf("Error recording scheduled run of script preset %q: %v", preset.Name, err)
+	}
+}
+
+// scheduledRunOutcome reads the last run status, error and history entry of a scheduled run
+// from the response of the script execution endpoint
+func scheduledRunOutcome(w *bridgeWriter) (string, string, *int64) {
+	if w.status == http.StatusOK {
+		var result models.ScriptResult
+		if err := json.Unmarshal(w.body.Bytes(), &result); err != nil {
+			return models.PresetRunError, fmt.Sprintf("invalid execution response: %v", err), nil
+		}
+		return result.Severity, "", optionalID(result.HistoryID)
+	}
+
+	return models.PresetRunError, scheduledRunMessage(w), nil
+}
+
+// scheduledRunMessage reads why the script execution endpoint rejected a scheduled run
+func scheduledRunMessage(w *bridgeWriter) string {
+	var resp apierror.Response
+	if err := json.Unmarshal(w.body.Bytes(), &resp); err != nil || resp.Message == "" {
+		return fmt.Sprintf("execution failed with status %d", w.status)
+	}
+	return resp.Message
+}
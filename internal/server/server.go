@@ -1,45 +1,230 @@
 package server
 
 import (
+	"bytes"
 	"embed"
+	"fmt"
+	"html"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/pozgo/web-cli/internal/agent"
+	"github.com/pozgo/web-cli/internal/apierror"
+	"github.com/pozgo/web-cli/internal/audit"
 	"github.com/pozgo/web-cli/internal/config"
 	"github.com/pozgo/web-cli/internal/database"
+	"github.com/pozgo/web-cli/internal/executor"
+	"github.com/pozgo/web-cli/internal/gitsync"
+	"github.com/pozgo/web-cli/internal/inventory"
+	"github.com/pozgo/web-cli/internal/kubernetes"
 	"github.com/pozgo/web-cli/internal/middleware"
-	"github.com/rs/cors"
+	"github.com/pozgo/web-cli/internal/notify"
+	"github.com/pozgo/web-cli/internal/outputlog"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/terminal"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 // EmbeddedFrontend holds the embedded frontend files
 var EmbeddedFrontend embed.FS
 
+// Version holds the build version reported by the health endpoint
+var Version = "dev"
+
+// unauthenticatedPaths returns the health endpoints that are always served
+// without authentication or HTTPS enforcement
+// The /healthz and /readyz probes stay at the listener root regardless of the base path
+func unauthenticatedPaths(basePath string) []string {
+	return []string{basePath + "/api/health", "/healthz", "/readyz"}
+}
+
+// readOnlyAllowedPaths are the state-changing API endpoints that keep working in read-only mode
+var readOnlyAllowedPaths = []string{
+	"/api/auth/login",
+	"/api/auth/logout",
+	"/api/auth/refresh",
+	"/api/maintenance",
+	"/api/policies/test",
+	"/api/system/backup",
+}
+
 // Server represents the HTTP server
 type Server struct {
-	config *config.Config
-	router *mux.Router
-	db     *database.DB
+	config        *config.Config
+	router        *mux.Router
+	db            *database.DB
+	lockout       *middleware.LoginLockout
+	sessions      *middleware.SessionManager
+	ipFilter      *middleware.IPFilter
+	maintenance   *middleware.MaintenanceMode
+	terminals     *terminal.Registry
+	shells        []terminal.Shell              // Configured shell catalog (nil for the built-in one)
+	gitSync       *gitsync.Syncer               // Git sync of bash scripts (nil when not configured)
+	inventory     *inventory.Syncer             // Cloud inventory sync of servers (nil when no provider is configured)
+	kube          *kubernetes.Client            // Cluster for pod execution (nil when not configured)
+	vaultCache    vaultClientCache              // Vault client of the stored configuration
+	suggestions   commandSuggestionCache        // Ranked previous commands of each caller, for GET /history/suggest
+	scheduled     presetRuns                    // Scheduled runs of script presets in progress
+	activity      activityHub                   // Subscribers of the live activity stream, GET /events
+	running       executionRegistry             // Command and script executions in progress
+	agents        agent.Hub                     // Connected agents of servers using the agent transport
+	vaultSync     *vaultSyncer                  // Sync between SQLite and Vault
+	dbMaintenance *dbMaintainer                 // Daily database maintenance (nil when not configured)
+	notifier      *notify.Dispatcher            // Delivery of execution events to webhooks, Slack and email
+	slack         *notify.Slack                 // Slack or Mattermost target (nil when not configured)
+	email         *notify.Email                 // Email target (nil when not configured)
+	queue         *executor.Queue               // Concurrency limits of command and script executions
+	locks         *executor.Locks               // Locks keeping runs of exclusive scripts from overlapping
+	outputLogs    *outputlog.Store              // Full outputs of truncated history entries (nil when outputs are never truncated)
+	quotas        *middleware.Quotas            // Per-principal execution quotas
+	ssmEndpoint   string                        // AWS Systems Manager API endpoint (empty for the region's)
+	passwdFile    string                        // File login users are discovered from (empty for /etc/passwd)
+	sudoPasswords *middleware.SudoPasswordCache // Sudo passwords of login sessions (nil when caching is disabled)
 }
 
 // New creates a new Server instance
 // Returns an error if critical configuration validation fails (e.g., auth misconfigured)
 func New(cfg *config.Config, db *database.DB) (*Server, error) {
 	// Validate authentication configuration at startup
-	authConfig := middleware.LoadAuthConfig()
+	authConfig := loadAuthConfig(cfg)
 	if err := authConfig.Validate(); err != nil {
 		return nil, err
 	}
 
+	sessions, err := middleware.NewSessionManager(cfg.SessionSecret, cfg.GetSessionTTL())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SessionSecret == "" && authConfig.Enabled {
+		log.Println("SESSION_SECRET is not set: using a random session secret (sessions end when the server restarts)")
+	}
+
+	var sudoPasswords *middleware.SudoPasswordCache
+	if ttl := cfg.GetSudoPasswordTTL(); ttl > 0 {
+		if sudoPasswords, err = middleware.NewSudoPasswordCache(ttl); err != nil {
+			return nil, err
+		}
+	}
+
+	ipFilter, err := middleware.NewIPFilter(&middleware.IPFilterConfig{
+		Allow:          cfg.IPAllowList,
+		Deny:           cfg.IPDenyList,
+		ExecutionAllow: cfg.ExecutionIPAllowList,
+		ExcludePaths:   unauthenticatedPaths(cfg.GetBasePath()),
+		BasePath:       cfg.GetBasePath(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var shells []terminal.Shell
+	if len(cfg.TerminalShells) > 0 {
+		shells, err = terminal.ParseShells(cfg.TerminalShells)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TERMINAL_SHELLS: %w", err)
+		}
+	}
+	if _, err := executor.DetectLocalShell(cfg.LocalShell); err != nil {
+		if cfg.LocalShell != "" {
+			return nil, fmt.Errorf("invalid LOCAL_SHELL: %w", err)
+		}
+		log.Printf("Warning: local commands are unavailable: %v", err)
+	}
+	if _, err := executor.DetectElevation(cfg.LocalElevation); err != nil {
+		return nil, fmt.Errorf("invalid LOCAL_ELEVATION: %w", err)
+	}
+	if cfg.TerminalShellRC != "" {
+		if _, err := os.Stat(cfg.TerminalShellRC); err != nil {
+			return nil, fmt.Errorf("invalid TERMINAL_SHELL_RC: %w", err)
+		}
+	}
+
+	var gitSync *gitsync.Syncer
+	if cfg.GitSyncURL != "" {
+		gitSync, err = gitsync.New(gitsync.Config{
+			URL:        cfg.GitSyncURL,
+			Branch:     cfg.GitSyncBranch,
+			Path:       cfg.GitSyncPath,
+			Group:      cfg.GitSyncGroup,
+			Interval:   cfg.GetGitSyncInterval(),
+			Username:   cfg.GitSyncUsername,
+			Token:      cfg.GitSyncToken,
+			SSHKeyPath: cfg.GitSyncSSHKeyPath,
+			Dir:        cfg.GetGitSyncDir(),
+		}, repository.NewBashScriptRepository(db))
+		if err != nil {
+			return nil, fmt.Errorf("invalid GIT_SYNC_URL: %w", err)
+		}
+	}
+
+	inventorySync, err := newInventory(cfg, db)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cloud inventory configuration: %w", err)
+	}
+
+	kube, err := newKubernetesClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kubernetes configuration: %w", err)
+	}
+
+	vaultSync, err := newVaultSyncer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dbMaintenance, err := newDBMaintainer(cfg.DBMaintenanceTime)
+	if err != nil {
+		return nil, err
+	}
+
+	slack, err := newSlackTarget(cfg)
+	if err != nil {
+		return nil, err
+	}
+	email, err := newEmailTarget(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Server{
 		config: cfg,
 		router: mux.NewRouter(),
 		db:     db,
+		lockout: middleware.NewLoginLockout(&middleware.LockoutConfig{
+			Threshold:    cfg.AuthLockoutThreshold,
+			BaseDuration: cfg.GetAuthLockoutDuration(),
+			MaxDuration:  cfg.GetAuthLockoutMaxDuration(),
+		}),
+		sessions:      sessions,
+		sudoPasswords: sudoPasswords,
+		ipFilter:      ipFilter,
+		maintenance: middleware.NewMaintenanceMode(&middleware.MaintenanceConfig{
+			Enabled:    cfg.ReadOnly,
+			Reason:     cfg.ReadOnlyReason,
+			BasePath:   cfg.GetBasePath(),
+			AllowPaths: readOnlyAllowedPaths,
+		}),
+		terminals:     terminal.NewRegistry(cfg.TerminalMaxSessions, cfg.TerminalMaxSessionsPerUser),
+		shells:        shells,
+		gitSync:       gitSync,
+		inventory:     inventorySync,
+		kube:          kube,
+		vaultSync:     vaultSync,
+		dbMaintenance: dbMaintenance,
+		notifier:      newNotifier(db),
+		slack:         slack,
+		email:         email,
+		queue:         newExecutionQueue(cfg),
+		locks:         executor.NewLocks(),
+		outputLogs:    newOutputLogs(cfg),
+		quotas:        newExecutionQuotas(db),
 	}
 
 	s.setupRoutes()
@@ -47,24 +232,145 @@ func New(cfg *config.Config, db *database.DB) (*Server, error) {
 	return s, nil
 }
 
+// loadAuthConfig loads authentication settings from the environment and
+// enables client certificate authentication when mutual TLS is configured
+func loadAuthConfig(cfg *config.Config) *middleware.AuthConfig {
+	authConfig := middleware.LoadAuthConfig()
+	authConfig.ClientCertAuth = cfg.ClientCertAuthEnabled()
+	return authConfig
+}
+
 // setupRoutes configures all HTTP routes
 func (s *Server) setupRoutes() {
 	// Load auth configuration
-	authConfig := middleware.LoadAuthConfig()
+	authConfig := loadAuthConfig(s.config)
 
-	// Exempt health endpoint from authentication
+	// Exempt health endpoints from authentication
 	// Health checks must work without credentials for Docker/K8s probes
-	authConfig.ExcludePaths = []string{"/api/health"}
+	basePath := s.config.GetBasePath()
+	authConfig.ExcludePaths = unauthenticatedPaths(basePath)
+	authConfig.Lockout = s.lockout
+	authConfig.TokenAuth = s.authenticateAPIToken
+	authConfig.BasePath = basePath
+	authConfig.Sessions = s.sessions
+	authConfig.LoginPage = http.HandlerFunc(s.serveLoginPage)
+	authConfig.ExcludePaths = append(authConfig.ExcludePaths, basePath+"/api/auth/login")
+
+	// Reject clients outside the IP allow/deny lists before authentication
+	if s.ipFilter != nil {
+		s.router.Use(s.ipFilter.Filter)
+	}
 
 	// Apply authentication middleware to all routes except excluded paths
 	s.router.Use(middleware.BasicAuth(authConfig))
 
+	// Reject non-admins on admin-only endpoints, whichever way they authenticated
+	s.router.Use(middleware.RequireAdmin(basePath))
+
+	// Reject execution and mutations while in read-only mode
+	if s.maintenance != nil {
+		s.router.Use(s.maintenance.Block)
+	}
+
+	// Mount the API, Swagger UI and frontend under the base path when configured
+	root := s.router
+	if basePath != "" {
+		s.router.Handle("/", http.RedirectHandler(basePath+"/", http.StatusFound))
+		s.router.Handle(basePath, http.RedirectHandler(basePath+"/", http.StatusMovedPermanently))
+		root = s.router.PathPrefix(basePath).Subrouter()
+		log.Printf("Serving under base path: %s", basePath)
+	}
+
 	// API routes
-	api := s.router.PathPrefix("/api").Subrouter()
+	api := root.PathPrefix("/api").Subrouter()
+
+	// Per-client rate limit shared by command, script and terminal execution endpoints
+	executionLimiter := middleware.NewRateLimiter(&middleware.RateLimitConfig{
+		RequestsPerMinute: s.config.ExecutionRateLimit,
+		Burst:             s.config.ExecutionRateBurst,
+	})
+
+	// Liveness/readiness probes (unauthenticated - excluded from auth middleware)
+	s.router.HandleFunc("/healthz", s.handleLiveness).Methods("GET")
+	s.router.HandleFunc("/readyz", s.handleReadiness).Methods("GET")
 
 	// Health endpoint (unauthenticated - excluded from auth middleware)
 	api.HandleFunc("/health", s.handleHealth).Methods("GET")
 
+	// Session login endpoints
+	api.HandleFunc("/auth/login", s.handleLogin).Methods("POST")
+	api.HandleFunc("/auth/logout", s.handleLogout).Methods("POST")
+	api.HandleFunc("/auth/refresh", s.handleRefreshSession).Methods("POST")
+	api.HandleFunc("/auth/session", s.handleGetSession).Methods("GET")
+	api.HandleFunc("/auth/sudo-password", s.handleGetSudoPassword).Methods("GET")
+	api.HandleFunc("/auth/sudo-password", s.handleSetSudoPassword).Methods("POST")
+	api.HandleFunc("/auth/sudo-password", s.handleDeleteSudoPassword).Methods("DELETE")
+
+	// Login lockout administration endpoints
+	api.HandleFunc("/auth/lockouts", s.handleListLockouts).Methods("GET")
+	api.HandleFunc("/auth/unlock", s.handleUnlock).Methods("POST")
+
+	// API token endpoints
+	api.HandleFunc("/tokens", s.handleListAPITokens).Methods("GET")
+	api.HandleFunc("/tokens", s.handleCreateAPIToken).Methods("POST")
+	api.HandleFunc("/tokens/{id}", s.handleGetAPIToken).Methods("GET")
+	api.HandleFunc("/tokens/{id}", s.handleUpdateAPIToken).Methods("PUT")
+	api.HandleFunc("/tokens/{id}", s.handleDeleteAPIToken).Methods("DELETE")
+
+	// Group permission endpoints
+	api.HandleFunc("/group-permissions", s.handleListGroupPermissions).Methods("GET")
+	api.HandleFunc("/group-permissions", s.handleCreateGroupPermission).Methods("POST")
+	api.HandleFunc("/group-permissions/{id}", s.handleDeleteGroupPermission).Methods("DELETE")
+
+	// Command policy endpoints
+	api.HandleFunc("/policies", s.handleListCommandPolicies).Methods("GET")
+	api.HandleFunc("/policies", s.handleCreateCommandPolicy).Methods("POST")
+	api.HandleFunc("/policies/test", s.handleTestCommandPolicies).Methods("POST")
+	api.HandleFunc("/policies/{id}", s.handleGetCommandPolicy).Methods("GET")
+	api.HandleFunc("/policies/{id}", s.handleUpdateCommandPolicy).Methods("PUT")
+	api.HandleFunc("/policies/{id}", s.handleDeleteCommandPolicy).Methods("DELETE")
+
+	// Webhook endpoints
+	api.HandleFunc("/webhooks", s.handleListWebhooks).Methods("GET")
+	api.HandleFunc("/webhooks", s.handleCreateWebhook).Methods("POST")
+	api.HandleFunc("/webhooks/{id}", s.handleGetWebhook).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", s.handleUpdateWebhook).Methods("PUT")
+	api.HandleFunc("/webhooks/{id}", s.handleDeleteWebhook).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/test", s.handleTestWebhook).Methods("POST")
+
+	// Notification rule endpoints
+	api.HandleFunc("/notification-rules", s.handleListNotificationRules).Methods("GET")
+	api.HandleFunc("/notification-rules", s.handleCreateNotificationRule).Methods("POST")
+	api.HandleFunc("/notification-rules/{id}", s.handleGetNotificationRule).Methods("GET")
+	api.HandleFunc("/notification-rules/{id}", s.handleUpdateNotificationRule).Methods("PUT")
+	api.HandleFunc("/notification-rules/{id}", s.handleDeleteNotificationRule).Methods("DELETE")
+	api.HandleFunc("/maintenance-windows", s.handleListMaintenanceWindows).Methods("GET")
+	api.HandleFunc("/maintenance-windows", s.handleCreateMaintenanceWindow).Methods("POST")
+	api.HandleFunc("/maintenance-windows/{id}", s.handleGetMaintenanceWindow).Methods("GET")
+	api.HandleFunc("/maintenance-windows/{id}", s.handleUpdateMaintenanceWindow).Methods("PUT")
+	api.HandleFunc("/maintenance-windows/{id}", s.handleDeleteMaintenanceWindow).Methods("DELETE")
+
+	// Execution quota endpoints
+	api.HandleFunc("/quotas", s.handleListQuotas).Methods("GET")
+	api.HandleFunc("/quotas", s.handleCreateQuota).Methods("POST")
+	api.HandleFunc("/quotas/me", s.handleGetMyQuota).Methods("GET")
+	api.HandleFunc("/quotas/{id}", s.handleUpdateQuota).Methods("PUT")
+	api.HandleFunc("/quotas/{id}", s.handleDeleteQuota).Methods("DELETE")
+
+	// Read-only mode endpoints
+	api.HandleFunc("/maintenance", s.handleGetMaintenance).Methods("GET")
+	api.HandleFunc("/maintenance", s.handleSetMaintenance).Methods("PUT")
+	api.HandleFunc("/git-sync", s.handleGetGitSync).Methods("GET")
+	api.HandleFunc("/git-sync", s.handleGitSync).Methods("POST")
+	api.HandleFunc("/export", s.handleExport).Methods("GET")
+	api.HandleFunc("/import", s.handleImport).Methods("POST")
+
+	// Approval endpoints
+	api.HandleFunc("/approvals", s.handleListApprovals).Methods("GET")
+	api.HandleFunc("/approvals/{id}", s.handleGetApproval).Methods("GET")
+	api.HandleFunc("/approvals/{id}/approve", s.handleApproveApproval).Methods("POST")
+	api.HandleFunc("/approvals/{id}/reject", s.handleRejectApproval).Methods("POST")
+
 	// SSH Keys endpoints
 	api.HandleFunc("/keys", s.handleListSSHKeys).Methods("GET")
 	api.HandleFunc("/keys", s.handleCreateSSHKey).Methods("POST")
@@ -72,17 +378,44 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/keys/{id}", s.handleGetSSHKey).Methods("GET")
 	api.HandleFunc("/keys/{id}", s.handleUpdateSSHKey).Methods("PUT")
 	api.HandleFunc("/keys/{id}", s.handleDeleteSSHKey).Methods("DELETE")
+	api.HandleFunc("/keys/{id}/migrate-to-vault", s.handleMigrateSSHKeyToVault).Methods("POST")
 
 	// Servers endpoints
 	api.HandleFunc("/servers", s.handleListServers).Methods("GET")
 	api.HandleFunc("/servers", s.handleCreateServer).Methods("POST")
+	api.HandleFunc("/servers/batch", s.handleServerBatch).Methods("POST")
 	api.HandleFunc("/servers/groups", s.handleListServerGroups).Methods("GET")
 	api.HandleFunc("/servers/{id}", s.handleGetServer).Methods("GET")
 	api.HandleFunc("/servers/{id}", s.handleUpdateServer).Methods("PUT")
 	api.HandleFunc("/servers/{id}", s.handleDeleteServer).Methods("DELETE")
+	api.HandleFunc("/servers/{id}/migrate-to-vault", s.handleMigrateServerToVault).Methods("POST")
+	api.Handle("/servers/{id}/wake", executionLimiter.Limit(s.quotas.Enforce(http.HandlerFunc(s.handleWakeServer)))).Methods("POST")
+
+	// Server group endpoints
+	api.HandleFunc("/server-groups", s.handleListServerGroupEntities).Methods("GET")
+	api.HandleFunc("/server-groups", s.handleCreateServerGroup).Methods("POST")
+	api.HandleFunc("/server-groups/{id}", s.handleGetServerGroup).Methods("GET")
+	api.HandleFunc("/server-groups/{id}", s.handleUpdateServerGroup).Methods("PUT")
+	api.HandleFunc("/server-groups/{id}", s.handleDeleteServerGroup).Methods("DELETE")
+	api.HandleFunc("/server-groups/{id}/servers", s.handleListServerGroupServers).Methods("GET")
+	api.HandleFunc("/server-groups/{id}/servers", s.handleAddServerGroupServers).Methods("POST")
+	api.HandleFunc("/server-groups/{id}/servers/{serverId}", s.handleRemoveServerGroupServer).Methods("DELETE")
+	api.HandleFunc("/inventory", s.handleGetInventory).Methods("GET")
+	api.HandleFunc("/inventory", s.handleSyncInventory).Methods("POST")
+
+	// Kubernetes endpoints
+	api.HandleFunc("/kubernetes/namespaces", s.handleListKubernetesNamespaces).Methods("GET")
+	api.HandleFunc("/kubernetes/pods", s.handleListKubernetesPods).Methods("GET")
 
 	// Command execution endpoint
-	api.HandleFunc("/commands/execute", s.handleExecuteCommand).Methods("POST")
+	api.Handle("/commands/execute", executionLimiter.Limit(s.quotas.Enforce(http.HandlerFunc(s.handleExecuteCommand)))).Methods("POST")
+	api.HandleFunc("/executions/queue", s.handleGetExecutionQueue).Methods("GET")
+	api.HandleFunc("/executions/locks", s.handleGetExecutionLocks).Methods("GET")
+	api.HandleFunc("/executions/running", s.handleListRunningExecutions).Methods("GET")
+	api.HandleFunc("/executions/running/{id}", s.handleCancelRunningExecution).Methods("DELETE")
+	api.HandleFunc("/agents", s.handleListAgents).Methods("GET")
+	api.HandleFunc("/agents/connect", s.handleAgentConnect).Methods("GET")
+	api.HandleFunc("/events", s.handleActivityEvents).Methods("GET")
 
 	// Saved commands endpoints
 	api.HandleFunc("/saved-commands", s.handleListSavedCommands).Methods("GET")
@@ -90,40 +423,67 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/saved-commands/{id}", s.handleGetSavedCommand).Methods("GET")
 	api.HandleFunc("/saved-commands/{id}", s.handleUpdateSavedCommand).Methods("PUT")
 	api.HandleFunc("/saved-commands/{id}", s.handleDeleteSavedCommand).Methods("DELETE")
+	api.Handle("/saved-commands/{id}/execute", executionLimiter.Limit(s.quotas.Enforce(http.HandlerFunc(s.handleExecuteSavedCommand)))).Methods("POST")
 
 	// Command history endpoints
 	api.HandleFunc("/history", s.handleListCommandHistory).Methods("GET")
+	api.HandleFunc("/history/suggest", s.handleSuggestCommands).Methods("GET")
 	api.HandleFunc("/history/{id}", s.handleGetCommandHistory).Methods("GET")
+	api.HandleFunc("/history/{id}/log", s.handleGetHistoryLog).Methods("GET")
+
+	// Favorites and recent items endpoints
+	api.HandleFunc("/favorites", s.handleListFavorites).Methods("GET")
+	api.HandleFunc("/favorites/{type}/{id}", s.handleAddFavorite).Methods("PUT")
+	api.HandleFunc("/favorites/{type}/{id}", s.handleDeleteFavorite).Methods("DELETE")
+	api.HandleFunc("/recent", s.handleListRecent).Methods("GET")
 
 	// Local users endpoints
 	api.HandleFunc("/local-users", s.handleListLocalUsers).Methods("GET")
 	api.HandleFunc("/local-users", s.handleCreateLocalUser).Methods("POST")
+	api.HandleFunc("/local-users/import", s.handleImportLocalUsers).Methods("POST")
 	api.HandleFunc("/local-users/{id}", s.handleGetLocalUser).Methods("GET")
 	api.HandleFunc("/local-users/{id}", s.handleUpdateLocalUser).Methods("PUT")
 	api.HandleFunc("/local-users/{id}", s.handleDeleteLocalUser).Methods("DELETE")
+	api.HandleFunc("/local-users/{id}/test-sudo", s.handleTestLocalUserSudo).Methods("POST")
 
 	// System info endpoints
 	api.HandleFunc("/system/current-user", s.handleGetCurrentUser).Methods("GET")
 	api.HandleFunc("/system/shells", s.handleListAvailableShells).Methods("GET")
+	api.HandleFunc("/system/users", s.handleListSystemUsers).Methods("GET")
+	api.HandleFunc("/system/backup", s.handleBackup).Methods("POST")
+	api.HandleFunc("/system/restore", s.handleRestore).Methods("POST")
 
 	// Environment variables endpoints
 	api.HandleFunc("/env-variables", s.handleListEnvVariables).Methods("GET")
 	api.HandleFunc("/env-variables", s.handleCreateEnvVariable).Methods("POST")
+	api.HandleFunc("/env-variables/batch", s.handleEnvVariableBatch).Methods("POST")
 	api.HandleFunc("/env-variables/groups", s.handleListEnvVariableGroups).Methods("GET")
+	api.HandleFunc("/env-variables/import", s.handleImportEnvVariables).Methods("POST")
+	api.HandleFunc("/env-variables/export", s.handleExportEnvVariables).Methods("GET")
 	api.HandleFunc("/env-variables/{id}", s.handleGetEnvVariable).Methods("GET")
 	api.HandleFunc("/env-variables/{id}", s.handleUpdateEnvVariable).Methods("PUT")
 	api.HandleFunc("/env-variables/{id}", s.handleDeleteEnvVariable).Methods("DELETE")
+	api.HandleFunc("/env-variables/{id}/migrate-to-vault", s.handleMigrateEnvVariableToVault).Methods("POST")
 
 	// Bash scripts endpoints
 	api.HandleFunc("/bash-scripts", s.handleListBashScripts).Methods("GET")
 	api.HandleFunc("/bash-scripts", s.handleCreateBashScript).Methods("POST")
 	api.HandleFunc("/bash-scripts/groups", s.handleListBashScriptGroups).Methods("GET")
-	api.HandleFunc("/bash-scripts/execute", s.handleExecuteScript).Methods("POST")
-	api.HandleFunc("/bash-scripts/execute/stream", s.handleExecuteScriptStream).Methods("POST")
+	api.Handle("/bash-scripts/execute", executionLimiter.Limit(s.quotas.Enforce(http.HandlerFunc(s.handleExecuteScript)))).Methods("POST")
+	api.Handle("/bash-scripts/execute/stream", executionLimiter.Limit(s.quotas.Enforce(http.HandlerFunc(s.handleExecuteScriptStream)))).Methods("POST")
+	// Group executions count one execution per server against quotas, in executeOnServerGroup
+	api.Handle("/server-groups/{id}/commands/execute", executionLimiter.Limit(http.HandlerFunc(s.handleExecuteServerGroupCommand))).Methods("POST")
+	api.Handle("/server-groups/{id}/bash-scripts/execute", executionLimiter.Limit(http.HandlerFunc(s.handleExecuteServerGroupScript))).Methods("POST")
 	api.HandleFunc("/bash-scripts/{id}", s.handleGetBashScript).Methods("GET")
 	api.HandleFunc("/bash-scripts/{id}", s.handleUpdateBashScript).Methods("PUT")
 	api.HandleFunc("/bash-scripts/{id}", s.handleDeleteBashScript).Methods("DELETE")
+	api.HandleFunc("/bash-scripts/{id}/migrate-to-vault", s.handleMigrateBashScriptToVault).Methods("POST")
 	api.HandleFunc("/bash-scripts/{id}/presets", s.handleGetScriptPresetsByScript).Methods("GET")
+	api.HandleFunc("/bash-scripts/{id}/diff", s.handleDiffBashScript).Methods("GET", "POST")
+	api.HandleFunc("/bash-scripts/{id}/versions", s.handleListScriptVersions).Methods("GET")
+	api.HandleFunc("/bash-scripts/{id}/versions/{version}", s.handleGetScriptVersion).Methods("GET")
+	api.HandleFunc("/bash-scripts/{id}/versions/{version}/diff", s.handleDiffScriptVersions).Methods("GET")
+	api.HandleFunc("/bash-scripts/{id}/versions/{version}/rollback", s.handleRollbackScript).Methods("POST")
 
 	// Script preset endpoints
 	api.HandleFunc("/script-presets", s.handleListScriptPresets).Methods("GET")
@@ -138,26 +498,65 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/vault/config", s.handleDeleteVaultConfig).Methods("DELETE")
 	api.HandleFunc("/vault/test", s.handleTestVaultConnection).Methods("POST")
 	api.HandleFunc("/vault/status", s.handleGetVaultStatus).Methods("GET")
+	api.HandleFunc("/vault/sync", s.handleGetVaultSync).Methods("GET")
+	api.HandleFunc("/vault/sync", s.handleVaultSync).Methods("POST")
 	api.HandleFunc("/vault/ssh-keys", s.handleListVaultSSHKeys).Methods("GET")
 	api.HandleFunc("/vault/ssh-keys", s.handleCreateVaultSSHKey).Methods("POST")
+	api.HandleFunc("/vault/ssh-keys/{group}/{name}", s.handleGetVaultSSHKey).Methods("GET")
+	api.HandleFunc("/vault/ssh-keys/{group}/{name}", s.handleUpdateVaultSSHKey).Methods("PUT")
+	api.HandleFunc("/vault/ssh-keys/{group}/{name}", s.handleDeleteVaultSSHKey).Methods("DELETE")
+	api.HandleFunc("/vault/ssh-keys/{group}/{name}/migrate-to-sqlite", s.handleMigrateVaultSSHKeyToSQLite).Methods("POST")
 	api.HandleFunc("/vault/servers", s.handleListVaultServers).Methods("GET")
 	api.HandleFunc("/vault/servers", s.handleCreateVaultServer).Methods("POST")
+	api.HandleFunc("/vault/servers/{group}/{name}", s.handleGetVaultServer).Methods("GET")
+	api.HandleFunc("/vault/servers/{group}/{name}", s.handleUpdateVaultServer).Methods("PUT")
+	api.HandleFunc("/vault/servers/{group}/{name}", s.handleDeleteVaultServer).Methods("DELETE")
+	api.HandleFunc("/vault/servers/{group}/{name}/migrate-to-sqlite", s.handleMigrateVaultServerToSQLite).Methods("POST")
 	api.HandleFunc("/vault/env-variables", s.handleListVaultEnvVariables).Methods("GET")
 	api.HandleFunc("/vault/env-variables", s.handleCreateVaultEnvVariable).Methods("POST")
+	api.HandleFunc("/vault/env-variables/{group}/{name}", s.handleGetVaultEnvVariable).Methods("GET")
+	api.HandleFunc("/vault/env-variables/{group}/{name}", s.handleUpdateVaultEnvVariable).Methods("PUT")
+	api.HandleFunc("/vault/env-variables/{group}/{name}", s.handleDeleteVaultEnvVariable).Methods("DELETE")
+	api.HandleFunc("/vault/env-variables/{group}/{name}/migrate-to-sqlite", s.handleMigrateVaultEnvVariableToSQLite).Methods("POST")
 	api.HandleFunc("/vault/bash-scripts", s.handleListVaultScripts).Methods("GET")
 	api.HandleFunc("/vault/bash-scripts", s.handleCreateVaultScript).Methods("POST")
+	api.HandleFunc("/vault/bash-scripts/{group}/{name}", s.handleGetVaultScript).Methods("GET")
+	api.HandleFunc("/vault/bash-scripts/{group}/{name}", s.handleUpdateVaultScript).Methods("PUT")
+	api.HandleFunc("/vault/bash-scripts/{group}/{name}", s.handleDeleteVaultScript).Methods("DELETE")
+	api.HandleFunc("/vault/bash-scripts/{group}/{name}/migrate-to-sqlite", s.handleMigrateVaultScriptToSQLite).Methods("POST")
 	api.HandleFunc("/vault/scripts", s.handleListVaultScripts).Methods("GET") // Backward compatibility
 
 	// Terminal WebSocket endpoint (for interactive shell)
-	api.HandleFunc("/terminal/ws", s.handleTerminalWebSocket)
-
-	// Swagger documentation endpoint (with redirect from /swagger to /swagger/index.html)
-	s.router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
-		httpSwagger.URL("/swagger/doc.json"),
-		httpSwagger.DeepLinking(true),
-		httpSwagger.DocExpansion("none"),
-		httpSwagger.DomID("swagger-ui"),
-	))
+	api.Handle("/terminal/ws", executionLimiter.Limit(s.quotas.Enforce(http.HandlerFunc(s.handleTerminalWebSocket))))
+
+	// Terminal recording playback endpoints
+	api.HandleFunc("/terminal/recordings", s.handleListTerminalRecordings).Methods("GET")
+	api.HandleFunc("/terminal/recordings/{id}", s.handleGetTerminalRecording).Methods("GET")
+
+	// Open terminal session management endpoints
+	api.HandleFunc("/terminal/sessions", s.handleListTerminalSessions).Methods("GET")
+	api.HandleFunc("/terminal/sessions/{id}", s.handleCloseTerminalSession).Methods("DELETE")
+	api.HandleFunc("/terminal/sessions/{id}/watch", s.handleWatchTerminalSession).Methods("GET")
+
+	// Terminal session file exchange endpoints
+	api.HandleFunc("/terminal/files/{id}", s.handleListTerminalFiles).Methods("GET")
+	api.HandleFunc("/terminal/files/{id}", s.handleUploadTerminalFile).Methods("POST")
+	api.HandleFunc("/terminal/files/{id}/{path:.+}", s.handleDownloadTerminalFile).Methods("GET")
+
+	// Terminal keystroke log endpoints
+	api.HandleFunc("/audit/keystrokes", s.handleListKeystrokeLogs).Methods("GET")
+	api.HandleFunc("/audit/keystrokes/{id}", s.handleGetKeystrokeLog).Methods("GET")
+
+	// OpenAPI specification, and the Swagger UI exploring it unless disabled
+	api.HandleFunc("/openapi.json", s.handleOpenAPISpec).Methods("GET")
+	if s.config.SwaggerUI {
+		root.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
+			httpSwagger.URL(basePath+"/api/openapi.json"),
+			httpSwagger.DeepLinking(true),
+			httpSwagger.DocExpansion("none"),
+			httpSwagger.DomID("swagger-ui"),
+		))
+	}
 
 	// Log auth status
 	if authConfig.Enabled {
@@ -167,85 +566,87 @@ func (s *Server) setupRoutes() {
 	}
 
 	// Serve static files from frontend build
-	s.serveFrontend()
+	s.serveFrontend(root, basePath)
 }
 
-// handleHealth godoc
-// @Summary Health check
-// @Description Check if the server is running and responsive. This endpoint does not require authentication.
-// @Tags System
-// @Produce json
-// @Success 200 {object} HealthResponse
-// @Router /health [get]
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ok"}`))
-}
-
-// serveFrontend serves the React frontend
-func (s *Server) serveFrontend() {
+// serveFrontend serves the React frontend on router, mounted at basePath
+func (s *Server) serveFrontend(router *mux.Router, basePath string) {
 	// Try to use filesystem path first (for development)
 	if _, err := os.Stat(s.config.FrontendPath); err == nil {
 		log.Printf("Serving frontend from filesystem: %s", s.config.FrontendPath)
-		s.serveFrontendFromFilesystem()
+		s.serveFrontendFromFilesystem(router, basePath)
 		return
 	}
 
 	// Fall back to embedded frontend (for production binaries)
 	log.Println("Serving frontend from embedded files")
-	s.serveFrontendFromEmbedded()
+	s.serveFrontendFromEmbedded(router, basePath)
 }
 
 // serveFrontendFromFilesystem serves frontend from filesystem
-func (s *Server) serveFrontendFromFilesystem() {
+func (s *Server) serveFrontendFromFilesystem(router *mux.Router, basePath string) {
 	staticFS := http.FileServer(http.Dir(s.config.FrontendPath))
 
-	s.router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	router.PathPrefix("/").Handler(http.StripPrefix(basePath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := filepath.Join(s.config.FrontendPath, r.URL.Path)
 
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			http.ServeFile(w, r, filepath.Join(s.config.FrontendPath, "index.html"))
+		if info, err := os.Stat(path); os.IsNotExist(err) || (err == nil && info.IsDir()) {
+			indexContent, err := os.ReadFile(filepath.Join(s.config.FrontendPath, "index.html"))
+			if err != nil {
+				apierror.Error(w, "Frontend not available", http.StatusNotFound)
+				return
+			}
+			serveIndex(w, indexContent, basePath)
 			return
 		}
 
 		staticFS.ServeHTTP(w, r)
-	})
+	})))
 }
 
 // serveFrontendFromEmbedded serves frontend from embedded files
-func (s *Server) serveFrontendFromEmbedded() {
+func (s *Server) serveFrontendFromEmbedded(router *mux.Router, basePath string) {
 	// Get the build subdirectory from embedded FS
 	buildFS, err := fs.Sub(EmbeddedFrontend, "frontend")
 	if err != nil {
 		log.Printf("Warning: Could not access embedded frontend: %v", err)
-		s.serveErrorPage()
+		s.serveErrorPage(router)
 		return
 	}
 
 	staticFS := http.FileServer(http.FS(buildFS))
 
-	s.router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	router.PathPrefix("/").Handler(http.StripPrefix(basePath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Try to read the requested file from embedded FS
-		if _, err := fs.Stat(buildFS, filepath.Join(".", r.URL.Path)); err != nil {
+		if info, err := fs.Stat(buildFS, strings.TrimPrefix(path.Clean(r.URL.Path), "/")); err != nil || info.IsDir() {
 			// File doesn't exist, serve index.html for SPA routing
 			indexContent, err := fs.ReadFile(buildFS, "index.html")
 			if err != nil {
-				http.Error(w, "Frontend not available", http.StatusNotFound)
+				apierror.Error(w, "Frontend not available", http.StatusNotFound)
 				return
 			}
-			w.Header().Set("Content-Type", "text/html")
-			w.Write(indexContent)
+			serveIndex(w, indexContent, basePath)
 			return
 		}
 
 		staticFS.ServeHTTP(w, r)
-	})
+	})))
+}
+
+// serveIndex writes index.html with a <base> element and the base path exposed to the frontend
+// so relative asset URLs, API calls and WebSocket URLs resolve under the configured prefix
+func serveIndex(w http.ResponseWriter, content []byte, basePath string) {
+	inject := fmt.Sprintf(`<head><base href="%s/"><script>window.__WEBCLI_BASE_PATH__=%q;</script>`,
+		html.EscapeString(basePath), basePath)
+	content = bytes.Replace(content, []byte("<head>"), []byte(inject), 1)
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(content)
 }
 
 // serveErrorPage serves an error page when frontend is not available
-func (s *Server) serveErrorPage() {
-	s.router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+func (s *Server) serveErrorPage(router *mux.Router) {
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		w.Write([]byte(`
 <!DOCTYPE html>
@@ -271,45 +672,85 @@ func (s *Server) serveErrorPage() {
 	})
 }
 
-// Start begins listening for HTTP requests
-func (s *Server) Start() error {
-	// Get allowed origins from environment, default to localhost only
-	allowedOrigins := []string{"http://localhost:7777", "http://127.0.0.1:7777"}
-	if envOrigins := os.Getenv("CORS_ALLOWED_ORIGINS"); envOrigins != "" {
-		// Split by comma for multiple origins
-		allowedOrigins = strings.Split(envOrigins, ",")
-		for i := range allowedOrigins {
-			allowedOrigins[i] = strings.TrimSpace(allowedOrigins[i])
-		}
+// handler wraps the router in the middleware applied to every request, on any listener
+func (s *Server) handler() http.Handler {
+	// Setup CORS policy from configuration (restrictive localhost defaults)
+	corsConfig := &middleware.CORSConfig{
+		AllowedOrigins:   s.config.GetCORSAllowedOrigins(),
+		AllowedMethods:   s.config.CORSAllowedMethods,
+		AllowedHeaders:   s.config.CORSAllowedHeaders,
+		ExposedHeaders:   s.config.CORSExposedHeaders,
+		AllowCredentials: s.config.CORSAllowCredentials,
+		MaxAge:           s.config.CORSMaxAge,
 	}
 
-	// Setup CORS with restrictive defaults
-	c := cors.New(cors.Options{
-		AllowedOrigins:   allowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	})
-
 	// Apply security headers middleware
-	securedHandler := middleware.SecureHeaders()(c.Handler(s.router))
-
-	// Load auth config for HTTPS enforcement check
-	authConfig := middleware.LoadAuthConfig()
+	securedHandler := middleware.SecureHeaders()(middleware.CORS(corsConfig)(s.router))
 
 	// Apply HTTPS enforcement middleware if configured
 	securityConfig := &middleware.SecurityConfig{
 		RequireHTTPS: s.config.RequireHTTPS,
-		AuthEnabled:  authConfig.Enabled,
+		AuthEnabled:  loadAuthConfig(s.config).Enabled,
+		ExcludePaths: unauthenticatedPaths(s.config.GetBasePath()),
 	}
 	handler := middleware.RequireHTTPS(securityConfig)(securedHandler)
 
+	// Tag every request, including rejected ones, so errors can be traced in the logs
+	return middleware.RequestID(handler)
+}
+
+// Start begins listening for HTTP requests
+func (s *Server) Start() error {
+	handler := s.handler()
+
+	// Load auth config for the TLS warnings
+	authConfig := loadAuthConfig(s.config)
+
+	if s.config.SocketOnly && s.config.ListenSocket == "" {
+		return fmt.Errorf("SOCKET_ONLY requires LISTEN_SOCKET to be set")
+	}
+
+	if s.config.TLSClientCAPath != "" && !s.config.TLSEnabled() {
+		return fmt.Errorf("TLS_CLIENT_CA_PATH requires TLS_CERT_PATH and TLS_KEY_PATH to be set")
+	}
+
 	addr := s.config.GetAddress()
-	log.Printf("Starting server on %s", addr)
 	log.Printf("Frontend path: %s", s.config.FrontendPath)
 	log.Printf("Database path: %s", s.config.DatabasePath)
-	log.Printf("CORS allowed origins: %v", allowedOrigins)
+	log.Printf("CORS allowed origins: %v", s.config.GetCORSAllowedOrigins())
+	if s.ipFilter.Enabled() {
+		log.Printf("IP access control: allow=%v deny=%v execution allow=%v", s.config.IPAllowList, s.config.IPDenyList, s.config.ExecutionIPAllowList)
+	}
+	if s.config.ReadOnly {
+		log.Printf("Read-only mode enabled: execution, terminals and changes are blocked")
+	}
+	if s.config.TerminalRecordingDir != "" {
+		log.Printf("Terminal sessions are recorded to %s", s.config.TerminalRecordingDir)
+		go s.pruneTerminalRecordings(s.config.TerminalRecordingDir)
+	}
+	if s.gitSync != nil {
+		status := s.gitSync.Status()
+		log.Printf("Syncing bash scripts from %s (branch %s) into group %q every %ds", status.URL, status.Branch, status.Group, status.Interval)
+		s.gitSync.Start()
+	}
+	if s.inventory != nil {
+		status := s.inventory.Status()
+		log.Printf("Syncing servers from %v every %ds", status.Providers, status.Interval)
+		s.inventory.Start()
+	}
+	if s.kube != nil {
+		log.Printf("Running commands in Kubernetes pods through %s (default namespace %s)", s.kube.Server(), s.kube.Namespace())
+	}
+	if s.slack != nil {
+		log.Printf("Posting results of script presets that opt in to Slack")
+	}
+	if s.email != nil {
+		log.Printf("Sending notifications by %s", s.email.Name())
+	}
+	s.startVaultSync()
+	s.startDBMaintenance()
+	s.startScheduler()
+	audit.Observe(s.observeAudit)
 
 	// Create HTTP server with proper timeouts
 	// WriteTimeout is set high to support long-running script streaming (SSE)
@@ -322,21 +763,88 @@ func (s *Server) Start() error {
 		IdleTimeout:  s.config.GetIdleTimeout(),
 	}
 
+	// Start plain-HTTP health probe listener if configured
+	if s.config.HealthPort > 0 {
+		healthServer := &http.Server{
+			Addr:         s.config.GetHealthAddress(),
+			Handler:      s.healthRouter(),
+			ReadTimeout:  s.config.GetReadTimeout(),
+			WriteTimeout: s.config.GetReadTimeout(),
+			IdleTimeout:  s.config.GetIdleTimeout(),
+		}
+		go func() {
+			log.Printf("Starting health probe listener on %s", healthServer.Addr)
+			if err := healthServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Health probe listener stopped: %v", err)
+			}
+		}()
+	}
+
+	errCh := make(chan error, 3)
+
+	// Serve the gRPC API on its own port if configured
+	if s.config.GRPCPort > 0 {
+		grpcServer, err := s.newGRPCServer(handler)
+		if err != nil {
+			return err
+		}
+		listener, err := net.Listen("tcp", s.config.GetGRPCAddress())
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC on %s: %w", s.config.GetGRPCAddress(), err)
+		}
+		log.Printf("Starting gRPC API on %s", s.config.GetGRPCAddress())
+		go func() {
+			errCh <- grpcServer.Serve(listener)
+		}()
+	}
+
+	// Serve on a unix domain socket if configured (plain HTTP, for local reverse proxies)
+	if s.config.ListenSocket != "" {
+		listener, err := listenUnixSocket(s.config.ListenSocket, s.config.GetListenSocketMode())
+		if err != nil {
+			return err
+		}
+		log.Printf("Listening on unix socket %s (mode %04o)", s.config.ListenSocket, s.config.GetListenSocketMode())
+		go func() {
+			errCh <- server.Serve(listener)
+		}()
+	}
+
+	if s.config.SocketOnly {
+		log.Println("TCP listener disabled (SOCKET_ONLY is set)")
+		return <-errCh
+	}
+
+	log.Printf("Starting server on %s", addr)
+	serveTCP := server.ListenAndServe
+
 	// Start with TLS if configured
 	if s.config.TLSEnabled() {
 		log.Printf("TLS enabled - using certificate: %s", s.config.TLSCertPath)
+		if s.config.ClientCertAuthEnabled() {
+			tlsConfig, err := clientCertTLSConfig(s.config.TLSClientCAPath, s.config.TLSClientAuth)
+			if err != nil {
+				return err
+			}
+			server.TLSConfig = tlsConfig
+			log.Printf("Mutual TLS enabled (%s) - client CA: %s", s.config.TLSClientAuth, s.config.TLSClientCAPath)
+		}
 		if s.config.RequireHTTPS && authConfig.Enabled {
 			log.Println("HTTPS enforcement is ENABLED (non-HTTPS requests will be rejected)")
 		}
-		return server.ListenAndServeTLS(s.config.TLSCertPath, s.config.TLSKeyPath)
-	}
-
-	// Warn if auth is enabled without HTTPS
-	if authConfig.Enabled && !s.config.TLSEnabled() {
+		serveTCP = func() error {
+			return server.ListenAndServeTLS(s.config.TLSCertPath, s.config.TLSKeyPath)
+		}
+	} else if authConfig.Enabled {
+		// Warn if auth is enabled without HTTPS
 		log.Println("WARNING: Authentication is enabled but TLS is not configured!")
 		log.Println("WARNING: Credentials will be transmitted in plain text!")
 		log.Println("WARNING: Set TLS_CERT_PATH and TLS_KEY_PATH environment variables for production use.")
 	}
 
-	return server.ListenAndServe()
+	go func() {
+		errCh <- serveTCP()
+	}()
+
+	return <-errCh
 }
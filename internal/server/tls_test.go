@@ -0,0 +1,90 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCA(t *testing.T, path string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(path, pemData, 0600); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+}
+
+func TestClientCertTLSConfig(t *testing.T) {
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	writeTestCA(t, caPath)
+
+	tests := []struct {
+		mode     string
+		expected tls.ClientAuthType
+	}{
+		{"", tls.VerifyClientCertIfGiven},
+		{"optional", tls.VerifyClientCertIfGiven},
+		{"require", tls.RequireAndVerifyClientCert},
+	}
+
+	for _, tt := range tests {
+		cfg, err := clientCertTLSConfig(caPath, tt.mode)
+		if err != nil {
+			t.Fatalf("mode %q: unexpected error: %v", tt.mode, err)
+		}
+		if cfg.ClientAuth != tt.expected {
+			t.Errorf("mode %q: expected client auth %v, got %v", tt.mode, tt.expected, cfg.ClientAuth)
+		}
+		if cfg.ClientCAs == nil {
+			t.Errorf("mode %q: expected client CA pool", tt.mode)
+		}
+	}
+
+	if _, err := clientCertTLSConfig(caPath, "sometimes"); err == nil {
+		t.Error("Expected error for invalid mode")
+	}
+}
+
+func TestClientCertTLSConfig_InvalidCA(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := clientCertTLSConfig(filepath.Join(dir, "missing.pem"), "require"); err == nil {
+		t.Error("Expected error for missing CA file")
+	}
+
+	bogus := filepath.Join(dir, "bogus.pem")
+	if err := os.WriteFile(bogus, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := clientCertTLSConfig(bogus, "require"); err == nil {
+		t.Error("Expected error for CA file without certificates")
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/pozgo/web-cli/internal/models"
@@ -10,6 +11,73 @@ import (
 	"github.com/pozgo/web-cli/internal/vault"
 )
 
+// vaultClientCache keeps the Vault client of the stored configuration
+// Reusing it keeps the token an auth method logged in with, instead of logging in on every request,
+// and lets the client renew its token in the background
+type vaultClientCache struct {
+	mu        sync.Mutex
+	id        int64     // Configuration the client was created from
+	updatedAt time.Time // Version of that configuration
+	client    *vault.Client
+}
+
+// vaultClient returns the client for a stored Vault configuration
+// The client is created again whenever the configuration changes; a client for
+// an older version than the cached one is not cached, so a slow caller holding
+// a stale configuration cannot replace the current client
+func (s *Server) vaultClient(cfg *models.VaultConfig) (*vault.Client, error) {
+	s.vaultCache.mu.Lock()
+	defer s.vaultCache.mu.Unlock()
+	cached := s.vaultCache.client != nil && s.vaultCache.id == cfg.ID
+	if cached && s.vaultCache.updatedAt.Equal(cfg.UpdatedAt) {
+		return s.vaultCache.client, nil
+	}
+
+	client, err := vault.NewClient(vaultClientConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	if !cached || cfg.UpdatedAt.After(s.vaultCache.updatedAt) {
+		if s.vaultCache.client != nil {
+			s.vaultCache.client.Close()
+		}
+		// Only the cached client lives long enough to keep its token renewed
+		client.StartRenewal()
+		s.vaultCache.id = cfg.ID
+		s.vaultCache.updatedAt = cfg.UpdatedAt
+		s.vaultCache.client = client
+	}
+	return client, nil
+}
+
+// closeVaultClient stops the cached client's token renewal and drops it,
+// for when Vault is disabled or its configuration removed
+func (s *Server) closeVaultClient() {
+	s.vaultCache.mu.Lock()
+	defer s.vaultCache.mu.Unlock()
+	if s.vaultCache.client != nil {
+		s.vaultCache.client.Close()
+		s.vaultCache.client = nil
+	}
+}
+
+// vaultClientConfig converts a stored Vault configuration to the client's
+func vaultClientConfig(cfg *models.VaultConfig) *vault.Config {
+	return &vault.Config{
+		Address:    cfg.Address,
+		Token:      cfg.Token,
+		Namespace:  cfg.Namespace,
+		MountPath:  cfg.MountPath,
+		AuthMethod: cfg.AuthMethod,
+		RoleID:     cfg.RoleID,
+		SecretID:   cfg.SecretID,
+		AuthMount:  cfg.AuthMount,
+
+		KubernetesRole: cfg.KubernetesRole,
+		JWTPath:        cfg.JWTPath,
+	}
+}
+
 // getVaultClientIfEnabled returns a Vault client if Vault is configured and enabled
 // Returns nil if Vault is not available (no error)
 func (s *Server) getVaultClientIfEnabled() *vault.Client {
@@ -19,14 +87,7 @@ func (s *Server) getVaultClientIfEnabled() *vault.Client {
 		return nil
 	}
 
-	vaultCfg := &vault.Config{
-		Address:   cfg.Address,
-		Token:     cfg.Token,
-		Namespace: cfg.Namespace,
-		MountPath: cfg.MountPath,
-	}
-
-	client, err := vault.NewClient(vaultCfg)
+	client, err := s.vaultClient(cfg)
 	if err != nil {
 		log.Printf("Warning: Failed to create Vault client: %v", err)
 		return nil
@@ -298,6 +359,7 @@ func (s *Server) mergeScriptsWithVault(ctx context.Context, sqliteScripts []*mod
 			Content:     vs.Content,
 			Filename:    vs.Filename,
 			Group:       vs.Group,
+			Interpreter: vaultScriptInterpreter(vs.Interpreter),
 			Source:      "vault",
 			CreatedAt:   now,
 			UpdatedAt:   now,
@@ -307,6 +369,14 @@ func (s *Server) mergeScriptsWithVault(ctx context.Context, sqliteScripts []*mod
 	return allScripts
 }
 
+// vaultScriptInterpreter defaults Vault scripts saved without an interpreter to bash
+func vaultScriptInterpreter(interpreter string) string {
+	if interpreter == "" {
+		return models.ScriptInterpreterBash
+	}
+	return interpreter
+}
+
 // getScriptByNameFromVault retrieves a script from Vault by name and group
 func (s *Server) getScriptByNameFromVault(ctx context.Context, group, name string) (*models.BashScript, error) {
 	client := s.getVaultClientIfEnabled()
@@ -333,6 +403,7 @@ func (s *Server) getScriptByNameFromVault(ctx context.Context, group, name strin
 		Content:     vs.Content,
 		Filename:    vs.Filename,
 		Group:       vs.Group,
+		Interpreter: vaultScriptInterpreter(vs.Interpreter),
 		Source:      "vault",
 		CreatedAt:   now,
 		UpdatedAt:   now,
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/vault"
+)
+
+// vaultSSHCertificate issues a short-lived key and certificate from Vault's SSH CA
+// for principal, to use in place of a stored private key
+// Returns nil if Vault is disabled or has no SSH CA role configured (no error)
+func (s *Server) vaultSSHCertificate(ctx context.Context, principal string) (*vault.SSHCertificate, error) {
+	repo := repository.NewVaultConfigRepository(s.db)
+	cfg, err := repo.Get()
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil || !cfg.Enabled || cfg.SSHCARole == "" {
+		return nil, nil
+	}
+
+	client, err := s.vaultClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return client.IssueSSHCertificate(ctx, cfg.SSHCAMount, cfg.SSHCARole, principal, cfg.SSHCATTL)
+}
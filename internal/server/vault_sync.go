@@ -0,0 +1,459 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/config"
+	"github.com/pozgo/web-cli/internal/models"
+	"github.com/pozgo/web-cli/internal/repository"
+	"github.com/pozgo/web-cli/internal/vault"
+)
+
+// Directions a resource type can be synced in
+const (
+	vaultSyncNone      = "none"
+	vaultSyncToVault   = "to-vault"   // SQLite is the source of truth
+	vaultSyncFromVault = "from-vault" // Vault is the source of truth
+	vaultSyncTwoWay    = "two-way"    // Missing items are copied both ways; differences follow the conflict rule
+)
+
+// Stores that win when a two-way synced item differs
+const (
+	vaultConflictSQLite = "sqlite"
+	vaultConflictVault  = "vault"
+	vaultConflictSkip   = "skip" // Leave both copies and report the item
+)
+
+// Resource types that can be synced, in the order they are synced
+var vaultSyncTypes = []string{"ssh_keys", "servers", "env_variables", "scripts"}
+
+// VaultSyncPolicy is the configured direction of each resource type and the conflict rule
+type VaultSyncPolicy struct {
+	Directions map[string]string `json:"directions"` // By resource type
+	Conflict   string            `json:"conflict"`
+	Interval   int               `json:"interval_seconds"` // 0 when only manual syncs run
+}
+
+// VaultSyncResult reports what one sync did for a resource type
+type VaultSyncResult struct {
+	Type      string   `json:"type"`
+	Direction string   `json:"direction"`
+	ToVault   int      `json:"to_vault"`            // Items written to Vault
+	ToSQLite  int      `json:"to_sqlite"`           // Items written to SQLite
+	Unchanged int      `json:"unchanged"`           // Items already equal in both stores
+	Conflicts []string `json:"conflicts,omitempty"` // Differing items left alone by the skip rule, as group/name
+	Skipped   []string `json:"skipped,omitempty"`   // Items that could not be written, with the reason
+	Error     string   `json:"error,omitempty"`     // Why the type could not be synced
+}
+
+// VaultSyncStatus reports the sync policy and the outcome of the last sync
+type VaultSyncStatus struct {
+	Policy   VaultSyncPolicy   `json:"policy"`
+	LastSync *time.Time        `json:"last_sync,omitempty"`
+	Results  []VaultSyncResult `json:"results,omitempty"`
+	Error    string            `json:"error,omitempty"` // Why the last sync could not run
+}
+
+// vaultSyncer syncs resource types between SQLite and Vault by policy
+type vaultSyncer struct {
+	policy VaultSyncPolicy
+
+	syncMu sync.Mutex // Serializes syncs
+	mu     sync.Mutex // Guards status
+	status VaultSyncStatus
+}
+
+// newVaultSyncer validates the sync settings of cfg
+func newVaultSyncer(cfg *config.Config) (*vaultSyncer, error) {
+	settings := []struct{ resourceType, env, direction string }{
+		{"ssh_keys", "VAULT_SYNC_SSH_KEYS", cfg.VaultSyncSSHKeys},
+		{"servers", "VAULT_SYNC_SERVERS", cfg.VaultSyncServers},
+		{"env_variables", "VAULT_SYNC_ENV_VARIABLES", cfg.VaultSyncEnvVariables},
+		{"scripts", "VAULT_SYNC_SCRIPTS", cfg.VaultSyncScripts},
+	}
+	directions := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		switch setting.direction {
+		case "":
+			directions[setting.resourceType] = vaultSyncNone
+		case vaultSyncNone, vaultSyncToVault, vaultSyncFromVault, vaultSyncTwoWay:
+			directions[setting.resourceType] = setting.direction
+		default:
+			return nil, fmt.Errorf("invalid %s: must be none, to-vault, from-vault or two-way, got %q", setting.env, setting.direction)
+		}
+	}
+
+	conflict := cfg.VaultSyncConflict
+	switch conflict {
+	case "":
+		conflict = vaultConflictSQLite
+	case vaultConflictSQLite, vaultConflictVault, vaultConflictSkip:
+	default:
+		return nil, fmt.Errorf("invalid VAULT_SYNC_CONFLICT: must be sqlite, vault or skip, got %q", conflict)
+	}
+
+	policy := VaultSyncPolicy{
+		Directions: directions,
+		Conflict:   conflict,
+		Interval:   int(cfg.GetVaultSyncInterval() / time.Second),
+	}
+	return &vaultSyncer{policy: policy, status: VaultSyncStatus{Policy: policy}}, nil
+}
+
+// enabled reports whether any resource type is synced
+func (v *vaultSyncer) enabled() bool {
+	for _, direction := range v.policy.Directions {
+		if direction != vaultSyncNone {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the sync policy and the outcome of the last sync
+func (v *vaultSyncer) Status() VaultSyncStatus {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	status := v.status
+	status.Results = slices.Clone(v.status.Results)
+	return status
+}
+
+// startVaultSync syncs with Vault at every interval, while Vault is enabled
+// and read-only mode is off
+func (s *Server) startVaultSync() {
+	interval := s.config.GetVaultSyncInterval()
+	if !s.vaultSync.enabled() || interval == 0 {
+		return
+	}
+	log.Printf("Syncing with Vault every %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if s.maintenance.Status().Enabled || s.getVaultClientIfEnabled() == nil {
+				continue
+			}
+			if _, err := s.syncVault(context.Background()); err != nil {
+				log.Printf("Warning: sync with Vault failed: %v", err)
+			}
+		}
+	}()
+}
+
+// syncVault syncs every resource type with a direction now
+// Items are matched by group and name; nothing is deleted from either store
+func (s *Server) syncVault(ctx context.Context) (VaultSyncStatus, error) {
+	v := s.vaultSync
+	v.syncMu.Lock()
+	defer v.syncMu.Unlock()
+
+	results, err := s.runVaultSync(ctx)
+
+	v.mu.Lock()
+	now := time.Now().UTC()
+	v.status.LastSync = &now
+	v.status.Results = results
+	v.status.Error = ""
+	if err != nil {
+		v.status.Error = err.Error()
+	}
+	v.mu.Unlock()
+
+	return v.Status(), err
+}
+
+// runVaultSync performs one sync, failing if Vault is unavailable or any type could not be synced
+func (s *Server) runVaultSync(ctx context.Context) ([]VaultSyncResult, error) {
+	cfg, err := repository.NewVaultConfigRepository(s.db).Get()
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil || !cfg.Enabled {
+		return nil, fmt.Errorf("vault is not configured or not enabled")
+	}
+	client, err := s.vaultClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault client: %w", err)
+	}
+	// Compare against what Vault holds now, not a list cached before changes made elsewhere
+	client.InvalidateListCache()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	var results []VaultSyncResult
+	var failed []string
+	for _, resourceType := range vaultSyncTypes {
+		direction := s.vaultSync.policy.Directions[resourceType]
+		if direction == vaultSyncNone {
+			continue
+		}
+		result := VaultSyncResult{Type: resourceType, Direction: direction}
+		if err := s.syncVaultType(ctx, client, &result); err != nil {
+			result.Error = err.Error()
+			failed = append(failed, resourceType)
+		}
+		results = append(results, result)
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("failed to sync %v", failed)
+	}
+	return results, nil
+}
+
+// syncVaultType loads both stores' items of result.Type and syncs them
+func (s *Server) syncVaultType(ctx context.Context, client *vault.Client, result *VaultSyncResult) error {
+	conflict := s.vaultSync.policy.Conflict
+
+	switch result.Type {
+	case "ssh_keys":
+		repo := repository.NewSSHKeyRepository(s.db)
+		keys, err := repo.GetAll()
+		if err != nil {
+			return err
+		}
+		local := make(map[string]vaultSyncEntry[vault.SSHKey], len(keys))
+		for _, k := range keys {
+			key := vault.SSHKey{Name: k.Name, PrivateKey: k.PrivateKey, Group: k.Group, CreatedAt: k.CreatedAt}
+			local[vaultSyncKey(k.Group, k.Name)] = vaultSyncEntry[vault.SSHKey]{id: k.ID, item: key}
+		}
+		remote, err := client.ListSSHKeys(ctx)
+		if err != nil {
+			return err
+		}
+		syncVaultItems(result, conflict, local, remote,
+			func(k vault.SSHKey) string { return vaultSyncKey(k.Group, k.Name) },
+			func(a, b vault.SSHKey) bool { return a.PrivateKey == b.PrivateKey },
+			func(k vault.SSHKey) error { return client.SaveSSHKey(ctx, &k) },
+			func(id int64, k vault.SSHKey) error {
+				if id == 0 {
+					_, err := repo.Create(&models.SSHKeyCreate{Name: k.Name, PrivateKey: k.PrivateKey, Group: k.Group})
+					return err
+				}
+				_, err := repo.Update(id, &models.SSHKeyUpdate{PrivateKey: k.PrivateKey})
+				return err
+			})
+
+	case "servers":
+		repo := repository.NewServerRepository(s.db)
+		servers, err := repo.GetAll()
+		if err != nil {
+			return err
+		}
+		local := make(map[string]vaultSyncEntry[vault.Server], len(servers))
+		for _, srv := range servers {
+			// Servers without a hostname are kept in Vault under their IP address
+			name := srv.Name
+			if name == "" {
+				name = srv.IPAddress
+			}
+			server := vault.Server{Name: name, IPAddress: srv.IPAddress, Port: srv.Port, Username: srv.Username, Group: srv.Group}
+			local[vaultSyncKey(srv.Group, name)] = vaultSyncEntry[vault.Server]{id: srv.ID, item: server}
+		}
+		remote, err := client.ListServers(ctx)
+		if err != nil {
+			return err
+		}
+		syncVaultItems(result, conflict, local, remote,
+			func(srv vault.Server) string { return vaultSyncKey(srv.Group, srv.Name) },
+			func(a, b vault.Server) bool {
+				return a.IPAddress == b.IPAddress && a.Port == b.Port && a.Username == b.Username
+			},
+			func(srv vault.Server) error { return client.SaveServer(ctx, &srv) },
+			func(id int64, srv vault.Server) error {
+				name := srv.Name
+				if name == srv.IPAddress {
+					name = ""
+				}
+				if id == 0 {
+					_, err := repo.Create(&models.ServerCreate{Name: name, IPAddress: srv.IPAddress, Port: srv.Port, Username: srv.Username, Group: srv.Group})
+					return err
+				}
+				_, err := repo.Update(id, &models.ServerUpdate{IPAddress: srv.IPAddress, Port: srv.Port, Username: srv.Username})
+				return err
+			})
+
+	case "env_variables":
+		repo := repository.NewEnvVariableRepository(s.db)
+		envVars, err := repo.GetAll()
+		if err != nil {
+			return err
+		}
+		local := make(map[string]vaultSyncEntry[vault.EnvVariable], len(envVars))
+		for _, ev := range envVars {
+			envVar := vault.EnvVariable{Name: ev.Name, Value: ev.Value, Description: ev.Description, Group: ev.Group}
+			local[vaultSyncKey(ev.Group, ev.Name)] = vaultSyncEntry[vault.EnvVariable]{id: ev.ID, item: envVar}
+		}
+		remote, err := client.ListEnvVariables(ctx)
+		if err != nil {
+			return err
+		}
+		syncVaultItems(result, conflict, local, remote,
+			func(ev vault.EnvVariable) string { return vaultSyncKey(ev.Group, ev.Name) },
+			func(a, b vault.EnvVariable) bool { return a.Value == b.Value && a.Description == b.Description },
+			func(ev vault.EnvVariable) error { return client.SaveEnvVariable(ctx, &ev) },
+			func(id int64, ev vault.EnvVariable) error {
+				if id == 0 {
+					_, err := repo.Create(&models.EnvVariableCreate{Name: ev.Name, Value: ev.Value, Description: ev.Description, Group: ev.Group})
+					return err
+				}
+				_, err := repo.Update(id, &models.EnvVariableUpdate{Value: ev.Value, Description: ev.Description})
+				return err
+			})
+
+	case "scripts":
+		repo := repository.NewBashScriptRepository(s.db)
+		scripts, err := repo.GetAll()
+		if err != nil {
+			return err
+		}
+		local := make(map[string]vaultSyncEntry[vault.BashScript], len(scripts))
+		readOnly := make(map[int64]bool)
+		for _, sc := range scripts {
+			script := vault.BashScript{
+				Name:        sc.Name,
+				Description: sc.Description,
+				Content:     sc.Content,
+				Filename:    sc.Filename,
+				Group:       sc.Group,
+				Interpreter: sc.Interpreter,
+			}
+			local[vaultSyncKey(sc.Group, sc.Name)] = vaultSyncEntry[vault.BashScript]{id: sc.ID, item: script}
+			readOnly[sc.ID] = sc.ReadOnly
+		}
+		remote, err := client.ListBashScripts(ctx)
+		if err != nil {
+			return err
+		}
+		syncVaultItems(result, conflict, local, remote,
+			func(sc vault.BashScript) string { return vaultSyncKey(sc.Group, sc.Name) },
+			func(a, b vault.BashScript) bool {
+				return a.Description == b.Description && a.Content == b.Content && a.Filename == b.Filename &&
+					scriptInterpreter(a.Interpreter) == scriptInterpreter(b.Interpreter)
+			},
+			func(sc vault.BashScript) error { return client.SaveBashScript(ctx, &sc) },
+			func(id int64, sc vault.BashScript) error {
+				if id == 0 {
+					_, err := repo.Create(&models.BashScriptCreate{
+						Name:        sc.Name,
+						Description: sc.Description,
+						Content:     sc.Content,
+						Filename:    sc.Filename,
+						Group:       sc.Group,
+						Interpreter: sc.Interpreter,
+					})
+					return err
+				}
+				if readOnly[id] {
+					return fmt.Errorf("managed by Git sync")
+				}
+				_, err := repo.Update(id, &models.BashScriptUpdate{
+					Description: sc.Description,
+					Content:     sc.Content,
+					Filename:    sc.Filename,
+					Interpreter: scriptInterpreter(sc.Interpreter),
+				})
+				return err
+			})
+	}
+	return nil
+}
+
+// scriptInterpreter returns the interpreter a script runs with, bash when unset
+func scriptInterpreter(interpreter string) string {
+	if interpreter == "" {
+		return models.ScriptInterpreterBash
+	}
+	return interpreter
+}
+
+// vaultSyncEntry is an item loaded from SQLite, with its row ID
+type vaultSyncEntry[T any] struct {
+	id   int64
+	item T
+}
+
+// vaultSyncKey identifies an item in both stores
+func vaultSyncKey(group, name string) string {
+	if group == "" {
+		group = "default"
+	}
+	return group + "/" + name
+}
+
+// syncVaultItems copies items between the stores according to result.Direction
+// writeSQLite gets the row ID of the item to update, or 0 to create it
+func syncVaultItems[T any](
+	result *VaultSyncResult,
+	conflict string,
+	local map[string]vaultSyncEntry[T],
+	remote []T,
+	key func(T) string,
+	equal func(a, b T) bool,
+	writeVault func(T) error,
+	writeSQLite func(id int64, item T) error,
+) {
+	toVault := result.Direction == vaultSyncToVault || result.Direction == vaultSyncTwoWay
+	toSQLite := result.Direction == vaultSyncFromVault || result.Direction == vaultSyncTwoWay
+
+	remoteByKey := make(map[string]T, len(remote))
+	for _, item := range remote {
+		remoteByKey[key(item)] = item
+	}
+
+	pushVault := func(k string, item T) {
+		if err := writeVault(item); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", k, err))
+			return
+		}
+		result.ToVault++
+	}
+	pushSQLite := func(k string, id int64, item T) {
+		if err := writeSQLite(id, item); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", k, err))
+			return
+		}
+		result.ToSQLite++
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(local)) {
+		entry := local[k]
+		remoteItem, inVault := remoteByKey[k]
+		switch {
+		case !inVault:
+			if toVault {
+				pushVault(k, entry.item)
+			}
+		case equal(entry.item, remoteItem):
+			result.Unchanged++
+		case result.Direction == vaultSyncToVault:
+			pushVault(k, entry.item)
+		case result.Direction == vaultSyncFromVault:
+			pushSQLite(k, entry.id, remoteItem)
+		case conflict == vaultConflictSQLite:
+			pushVault(k, entry.item)
+		case conflict == vaultConflictVault:
+			pushSQLite(k, entry.id, remoteItem)
+		default:
+			result.Conflicts = append(result.Conflicts, k)
+		}
+	}
+
+	if !toSQLite {
+		return
+	}
+	for _, k := range slices.Sorted(maps.Keys(remoteByKey)) {
+		if _, ok := local[k]; !ok {
+			pushSQLite(k, 0, remoteByKey[k])
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package ssm runs shell commands on EC2 instances through AWS Systems Manager Run Command
+package ssm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/awsauth"
+)
+
+// requestTimeout bounds each API request
+const requestTimeout = 30 * time.Second
+
+// httpClient is used for all SSM API requests
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// pollInterval is the time between checks of a running command
+var pollInterval = time.Second
+
+// deliveryTimeout is how long an instance has to pick up a command, in seconds (the SSM minimum)
+const deliveryTimeout = 30
+
+// Config selects the instance and the credentials commands are sent with
+type Config struct {
+	Region          string
+	InstanceID      string // EC2 instance or managed node (i-... or mi-...)
+	AccessKeyID     string // Stored credentials (empty for the environment's or the instance role's)
+	SecretAccessKey string
+	RoleARN         string // Optional IAM role assumed with the credentials
+	Endpoint        string // SSM API endpoint (default: https://ssm.<region>.amazonaws.com)
+	STSEndpoint     string // STS API endpoint for RoleARN (default: https://sts.<region>.amazonaws.com)
+}
+
+// Result is the outcome of a command
+// Run Command keeps at most 24,000 characters of stdout and 8,000 of stderr.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Client sends commands to one instance
+type Client struct {
+	config Config
+}
+
+// New creates a client for an instance
+func New(config Config) *Client {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://ssm." + config.Region + ".amazonaws.com"
+	}
+	return &Client{config: config}
+}
+
+// Run runs a shell script as root with the AWS-RunShellScript document and waits for it to finish
+// timeout is the longest the script may run on the instance. Cancelling ctx cancels the command.
+func (c *Client) Run(ctx context.Context, script string, timeout time.Duration) (*Result, error) {
+	creds, err := c.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := max(int(timeout.Seconds()), 1)
+	var sent struct {
+		Command struct {
+			CommandID string `json:"CommandId"`
+		} `json:"Command"`
+	}
+	err = c.call(ctx, creds, "SendCommand", map[string]any{
+		"InstanceIds":  []string{c.config.InstanceID},
+		"DocumentName": "AWS-RunShellScript",
+		"Parameters": map[string][]string{
+			"commands":         {script},
+			"executionTimeout": {strconv.Itoa(seconds)},
+		},
+		"TimeoutSeconds": deliveryTimeout,
+		"Comment":        "web-cli",
+	}, &sent)
+	if err != nil {
+		return nil, err
+	}
+	commandID := sent.Command.CommandID
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			c.cancel(creds, commandID)
+			return nil, fmt.Errorf("command execution timeout or cancelled")
+		case <-ticker.C:
+		}
+
+		var invocation struct {
+			Status                string `json:"Status"`
+			StatusDetails         string `json:"StatusDetails"`
+			ResponseCode          int    `json:"ResponseCode"`
+			StandardOutputContent string `json:"StandardOutputContent"`
+			StandardErrorContent  string `json:"StandardErrorContent"`
+		}
+		err := c.call(ctx, creds, "GetCommandInvocation", map[string]string{
+			"CommandId":  commandID,
+			"InstanceId": c.config.InstanceID,
+		}, &invocation)
+		if err != nil {
+			// The invocation only exists once the command reaches the instance
+			if strings.Contains(err.Error(), "InvocationDoesNotExist") {
+				continue
+			}
+			if ctx.Err() != nil {
+				c.cancel(creds, commandID)
+				return nil, fmt.Errorf("command execution timeout or cancelled")
+			}
+			return nil, err
+		}
+
+		result := &Result{Stdout: invocation.StandardOutputContent, Stderr: invocation.StandardErrorContent, ExitCode: invocation.ResponseCode}
+		switch invocation.Status {
+		case "Success":
+			return result, nil
+		case "Failed":
+			if invocation.ResponseCode >= 0 && invocation.StatusDetails == "Failed" {
+				return result, nil
+			}
+			return result, fmt.Errorf("SSM command failed: %s", invocation.StatusDetails)
+		case "TimedOut", "Cancelled":
+			return result, fmt.Errorf("SSM command %s: %s", strings.ToLower(invocation.Status), invocation.StatusDetails)
+		}
+	}
+}
+
+// credentials returns the credentials of the configuration, assuming its role if set
+func (c *Client) credentials(ctx context.Context) (awsauth.Credentials, error) {
+	creds := awsauth.Credentials{AccessKeyID: c.config.AccessKeyID, SecretAccessKey: c.config.SecretAccessKey}
+	if creds.AccessKeyID == "" {
+		var err error
+		if creds, err = awsauth.Default(ctx); err != nil {
+			return creds, err
+		}
+	}
+	if c.config.RoleARN == "" {
+		return creds, nil
+	}
+	return awsauth.AssumeRole(ctx, creds, c.config.Region, c.config.STSEndpoint, c.config.RoleARN, "web-cli")
+}
+
+// cancel cancels a command that is no longer waited for
+func (c *Client) cancel(creds awsauth.Credentials, commandID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	c.call(ctx, creds, "CancelCommand", map[string]any{
+		"CommandId":   commandID,
+		"InstanceIds": []string{c.config.InstanceID},
+	}, nil)
+}
+
+// apiError is the body of a failed SSM request
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// call makes an SSM API request and decodes the JSON response into v (nil to ignore it)
+func (c *Client) call(ctx context.Context, creds awsauth.Credentials, action string, input, v any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.config.Endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonSSM."+action)
+	awsauth.Sign(req, body, creds, c.config.Region, "ssm", time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("SSM API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var e apiError
+		if json.Unmarshal(data, &e) != nil || e.Type == "" {
+			return fmt.Errorf("SSM API request failed: %s", resp.Status)
+		}
+		// The type may be namespaced, as in "com.amazonaws.ssm#InvalidInstanceId"
+		if i := strings.LastIndex(e.Type, "#"); i >= 0 {
+			e.Type = e.Type[i+1:]
+		}
+		if e.Message == "" {
+			return fmt.Errorf("SSM API request failed: %s", e.Type)
+		}
+		return fmt.Errorf("SSM API request failed: %s: %s", e.Type, e.Message)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid SSM API response: %w", err)
+	}
+	return nil
+}
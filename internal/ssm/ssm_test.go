@@ -0,0 +1,114 @@
+package ssm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSSM runs commands sent to it: a command containing "exit N" fails with code N,
+// "sleep" never finishes, and anything else succeeds with the command as output
+type fakeSSM struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	commands  map[string]string
+	polls     int
+	cancelled []string
+}
+
+func newFakeSSM(t *testing.T) *fakeSSM {
+	f := &fakeSSM{commands: map[string]string{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeSSM) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKID/") || !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/ssm/aws4_request") {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"__type":"UnrecognizedClientException","message":"bad signature"}`)
+		return
+	}
+
+	var input struct {
+		InstanceIds []string
+		InstanceId  string
+		CommandId   string
+		Parameters  map[string][]string
+	}
+	json.NewDecoder(r.Body).Decode(&input)
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "AmazonSSM.SendCommand":
+		if input.InstanceIds[0] != "i-0abc" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"com.amazonaws.ssm#InvalidInstanceId","message":"Instances not in a valid state for account"}`)
+			return
+		}
+		id := fmt.Sprintf("cmd-%d", len(f.commands)+1)
+		f.commands[id] = input.Parameters["commands"][0] + " (timeout " + input.Parameters["executionTimeout"][0] + ")"
+		fmt.Fprintf(w, `{"Command":{"CommandId":%q}}`, id)
+	case "AmazonSSM.GetCommandInvocation":
+		f.polls++
+		command := f.commands[input.CommandId]
+		switch {
+		case f.polls == 1:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"InvocationDoesNotExist"}`)
+		case strings.Contains(command, "sleep"):
+			fmt.Fprint(w, `{"Status":"InProgress","ResponseCode":-1}`)
+		case strings.Contains(command, "exit 3"):
+			fmt.Fprint(w, `{"Status":"Failed","StatusDetails":"Failed","ResponseCode":3,"StandardOutputContent":"","StandardErrorContent":"failed"}`)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"Status": "Success", "StatusDetails": "Success", "ResponseCode": 0, "StandardOutputContent": command})
+		}
+	case "AmazonSSM.CancelCommand":
+		f.cancelled = append(f.cancelled, input.CommandId)
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+func TestRun(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	fake := newFakeSSM(t)
+	client := New(Config{Region: "eu-west-1", InstanceID: "i-0abc", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: fake.URL})
+
+	result, err := client.Run(context.Background(), "uptime", time.Minute)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.ExitCode != 0 || result.Stdout != "uptime (timeout 60)" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	result, err = client.Run(context.Background(), "exit 3", time.Minute)
+	if err != nil || result.ExitCode != 3 || result.Stderr != "failed" {
+		t.Errorf("Expected exit code 3, got %+v (%v)", result, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Run(ctx, "sleep 600", time.Minute); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if len(fake.cancelled) != 1 || fake.cancelled[0] != "cmd-3" {
+		t.Errorf("Expected the timed out command to be cancelled, got %v", fake.cancelled)
+	}
+
+	other := New(Config{Region: "eu-west-1", InstanceID: "i-0def", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: fake.URL})
+	if _, err := other.Run(context.Background(), "uptime", time.Minute); err == nil || !strings.Contains(err.Error(), "InvalidInstanceId: Instances not in a valid state") {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package terminal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// FilesEnvVar is the environment variable pointing the shell at its file exchange directory
+const FilesEnvVar = "WEBCLI_FILES"
+
+// ListFiles returns the regular files under dir, sorted by path
+// Symbolic links are skipped so the listing never leaves dir
+func ListFiles(dir string) ([]*models.TerminalFile, error) {
+	files := []*models.TerminalFile{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil // Removed since the directory was read
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, &models.TerminalFile{
+			Path:       filepath.ToSlash(rel),
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime().UTC(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// ResolveFile returns the path of an existing regular file under dir
+// name is slash-separated and relative to dir. Paths that escape dir,
+// including through symbolic links, are rejected.
+func ResolveFile(dir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path")
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, clean))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path")
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("not a regular file")
+	}
+	return resolved, nil
+}
+
+// UploadPath returns where an uploaded file named name is stored in dir
+// Only the base name is used, so uploads always land directly in dir
+func UploadPath(dir, name string) (string, error) {
+	base := filepath.Base(filepath.FromSlash(strings.ReplaceAll(name, "\\", "/")))
+	if base == "" || base == "." || base == ".." || base == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid file name")
+	}
+	return filepath.Join(dir, base), nil
+}
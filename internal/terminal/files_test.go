@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "reports"), 0700)
+	os.WriteFile(filepath.Join(dir, "reports", "disk.csv"), []byte("a,b\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hi"), 0600)
+	os.Symlink("/etc/passwd", filepath.Join(dir, "passwd"))
+
+	files, err := ListFiles(dir)
+	if err != nil {
+		t.Fatalf("ListFiles() error = %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "notes.txt" || files[1].Path != "reports/disk.csv" || files[1].Size != 4 {
+		t.Errorf("Unexpected files: %+v", files)
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "reports"), 0700)
+	os.WriteFile(filepath.Join(dir, "reports", "disk.csv"), []byte("a,b\n"), 0600)
+	os.Symlink("/etc/passwd", filepath.Join(dir, "passwd"))
+
+	if _, err := ResolveFile(dir, "reports/disk.csv"); err != nil {
+		t.Errorf("Expected file inside the directory to resolve, got %v", err)
+	}
+
+	for _, name := range []string{"", ".", "..", "../etc/passwd", "/etc/passwd", "reports/../../etc/passwd", "passwd", "reports"} {
+		if _, err := ResolveFile(dir, name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestUploadPath(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"build.tar.gz":        "build.tar.gz",
+		"../../etc/cron.d/x":  "x",
+		`C:\Users\me\app.log`: "app.log",
+	}
+	for name, want := range tests {
+		got, err := UploadPath(dir, name)
+		if err != nil || got != filepath.Join(dir, want) {
+			t.Errorf("UploadPath(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	for _, name := range []string{"", "..", "/"} {
+		if _, err := UploadPath(dir, name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package terminal
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+const (
+	// keystrokeFlushInterval is how often buffered keystrokes are written out
+	keystrokeFlushInterval = 5 * time.Second
+
+	// keystrokeFlushEvents flushes early once this many events are buffered
+	keystrokeFlushEvents = 256
+
+	// promptTailSize is how much recent output is kept to recognize prompts
+	promptTailSize = 256
+)
+
+// secretPromptPattern matches the end of output asking for a secret, such as
+// "Password:", "[sudo] password for admin:" or "Enter passphrase for key '...':"
+var secretPromptPattern = regexp.MustCompile(`(?i)(password|passphrase|passcode|\bpin\b|secret|token|otp|verification code)[^\n]*[:?][ \t]*$`)
+
+// ansiEscapePattern matches terminal escape sequences, stripped before matching prompts
+var ansiEscapePattern = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-Z\\-_])`)
+
+// KeystrokeSink stores a batch of keystroke events
+type KeystrokeSink func(events []models.KeystrokeEvent) error
+
+// KeystrokeLogger records what the user types in a terminal session
+// Input typed after a password prompt is replaced with a redacted event until
+// the line is submitted, since a prompt with echo disabled never shows it anyway
+type KeystrokeLogger struct {
+	mu      sync.Mutex
+	sink    KeystrokeSink
+	tail    []byte // Recent output, to recognize secret prompts
+	secret  bool   // Input is currently going to a secret prompt
+	pending []models.KeystrokeEvent
+	ticker  *time.Ticker
+	done    chan struct{}
+	closed  bool
+}
+
+// NewKeystrokeLogger creates a keystroke logger writing batches to sink
+func NewKeystrokeLogger(sink KeystrokeSink) *KeystrokeLogger {
+	k := &KeystrokeLogger{
+		sink:   sink,
+		ticker: time.NewTicker(keystrokeFlushInterval),
+		done:   make(chan struct{}),
+	}
+	go k.flushLoop()
+	return k
+}
+
+// Output watches data written by the terminal for secret prompts
+func (k *KeystrokeLogger) Output(data []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.tail = append(k.tail, data...)
+	if len(k.tail) > promptTailSize {
+		k.tail = append([]byte(nil), k.tail[len(k.tail)-promptTailSize:]...)
+	}
+	if !k.secret {
+		k.secret = secretPromptPattern.Match(ansiEscapePattern.ReplaceAll(k.tail, nil))
+	}
+}
+
+// Input records data typed by the user
+func (k *KeystrokeLogger) Input(data []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return
+	}
+
+	now := time.Now().UTC()
+	for len(data) > 0 {
+		if !k.secret {
+			k.pending = append(k.pending, models.KeystrokeEvent{Time: now, Data: string(data)})
+			break
+		}
+
+		// Everything up to the end of the line goes to the prompt
+		if n := len(k.pending); n == 0 || !k.pending[n-1].Redacted {
+			k.pending = append(k.pending, models.KeystrokeEvent{Time: now, Redacted: true})
+		}
+		end := bytes.IndexAny(data, "\r\n")
+		if end < 0 {
+			break
+		}
+		k.secret = false
+		k.tail = k.tail[:0]
+		k.pending = append(k.pending, models.KeystrokeEvent{Time: now, Data: string(data[end : end+1])})
+		data = data[end+1:]
+	}
+
+	if len(k.pending) >= keystrokeFlushEvents {
+		k.flushLocked()
+	}
+}
+
+// flushLoop writes buffered events periodically until the logger is closed
+func (k *KeystrokeLogger) flushLoop() {
+	for {
+		select {
+		case <-k.ticker.C:
+			k.mu.Lock()
+			k.flushLocked()
+			k.mu.Unlock()
+		case <-k.done:
+			return
+		}
+	}
+}
+
+// flushLocked hands buffered events to the sink; the caller holds the lock
+func (k *KeystrokeLogger) flushLocked() {
+	if len(k.pending) == 0 {
+		return
+	}
+	events := k.pending
+	k.pending = nil
+	if err := k.sink(events); err != nil {
+		log.Printf("Failed to store terminal keystrokes: %v", err)
+	}
+}
+
+// Close writes any buffered events and stops the logger
+func (k *KeystrokeLogger) Close() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return
+	}
+	k.closed = true
+	k.ticker.Stop()
+	close(k.done)
+	k.flushLocked()
+}
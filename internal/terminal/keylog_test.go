@@ -0,0 +1,50 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+func TestKeystrokeLogger(t *testing.T) {
+	var events []models.KeystrokeEvent
+	logger := NewKeystrokeLogger(func(batch []models.KeystrokeEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+
+	logger.Output([]byte("$ "))
+	logger.Input([]byte("sudo ls\r"))
+	logger.Output([]byte("sudo ls\r\n\x1b[1m[sudo] password for admin:\x1b[0m "))
+	logger.Input([]byte("hunt"))
+	logger.Input([]byte("er2\rwhoami\r"))
+	logger.Output([]byte("\r\n$ cat notes\r\ntoken: abc\r\n$ "))
+	logger.Input([]byte("exit\r"))
+	logger.Close()
+
+	var typed strings.Builder
+	redacted := 0
+	for _, event := range events {
+		if event.Redacted {
+			redacted++
+		}
+		typed.WriteString(event.Data)
+	}
+
+	if strings.Contains(typed.String(), "hunter2") || strings.Contains(typed.String(), "hunt") {
+		t.Errorf("Password was logged: %q", typed.String())
+	}
+	if redacted != 1 {
+		t.Errorf("Expected 1 redacted event, got %d", redacted)
+	}
+	if typed.String() != "sudo ls\r\rwhoami\rexit\r" {
+		t.Errorf("Unexpected keystrokes %q", typed.String())
+	}
+
+	// Input after Close is dropped
+	logger.Input([]byte("late"))
+	if strings.Contains(typed.String(), "late") {
+		t.Error("Expected input after close to be dropped")
+	}
+}
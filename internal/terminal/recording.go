@@ -0,0 +1,285 @@
+package terminal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// RecordingExt is the file extension of terminal recordings
+const RecordingExt = ".cast"
+
+// recordingIDPattern matches recording IDs: the start time and a random suffix
+var recordingIDPattern = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}Z-[0-9a-f]{8}$`)
+
+// asciicastHeader is the first line of an asciicast v2 file
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes a terminal session to an asciicast v2 file
+// Output is always recorded; input only when enabled, since it can contain typed passwords
+type Recorder struct {
+	mu          sync.Mutex
+	file        *os.File
+	path        string
+	start       time.Time
+	recordInput bool
+	pending     map[string][]byte // Incomplete UTF-8 sequences carried over per event type
+	closed      bool
+}
+
+// NewRecorder creates a recording in dir named after the current time
+// The directory is created if needed; recordings are only readable by the server user
+func NewRecorder(dir string, width, height int, shell string, recordInput bool) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate recording name: %w", err)
+	}
+
+	start := time.Now().UTC()
+	name := start.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix) + RecordingExt
+	path := filepath.Join(dir, name)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	r := &Recorder{
+		file:        file,
+		path:        path,
+		start:       start,
+		recordInput: recordInput,
+		pending:     make(map[string][]byte),
+	}
+
+	header, err := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: start.Unix(),
+		Env:       map[string]string{"SHELL": shell, "TERM": "xterm-256color"},
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(append(header, '\n')); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write recording header: %w", err)
+	}
+
+	return r, nil
+}
+
+// Path returns the recording file path
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// ID returns the recording ID used by the recordings API
+func (r *Recorder) ID() string {
+	return strings.TrimSuffix(filepath.Base(r.path), RecordingExt)
+}
+
+// Output records data written by the terminal
+func (r *Recorder) Output(data []byte) {
+	r.event("o", data)
+}
+
+// Input records data typed by the user, if input recording is enabled
+func (r *Recorder) Input(data []byte) {
+	if r.recordInput {
+		r.event("i", data)
+	}
+}
+
+// Resize records a terminal size change
+func (r *Recorder) Resize(cols, rows uint16) {
+	r.event("r", []byte(fmt.Sprintf("%dx%d", cols, rows)))
+}
+
+// event appends one [time, type, data] line
+// A UTF-8 character split across reads is held back until it is complete
+func (r *Recorder) event(kind string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	buf := append(r.pending[kind], data...)
+	cut := len(buf)
+	for i := len(buf) - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending[kind] = append([]byte(nil), buf[cut:]...)
+	if cut == 0 {
+		return
+	}
+
+	r.write(kind, string(buf[:cut]))
+}
+
+// write appends an event line straight to the file, so a crash loses nothing
+// The caller holds the lock
+func (r *Recorder) write(kind, data string) {
+	elapsed := time.Since(r.start).Seconds()
+	line, err := json.Marshal([]any{elapsed, kind, data})
+	if err != nil {
+		return
+	}
+	r.file.Write(append(line, '\n'))
+}
+
+// Close writes any held back data and closes the recording
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	for kind, rest := range r.pending {
+		if len(rest) > 0 {
+			r.write(kind, string(rest))
+		}
+	}
+
+	return r.file.Close()
+}
+
+// PruneRecordings deletes recordings in dir older than maxAge
+// Returns the number of recordings removed; maxAge <= 0 keeps everything
+func PruneRecordings(dir string, maxAge time.Duration) (int, error) {
+	if maxAge <= 0 {
+		return 0, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), RecordingExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// ListRecordings returns the recordings in dir, newest first
+// A missing directory has no recordings
+func ListRecordings(dir string) ([]*models.TerminalRecording, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*models.TerminalRecording{}, nil
+		}
+		return nil, err
+	}
+
+	recordings := make([]*models.TerminalRecording, 0, len(entries))
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), RecordingExt)
+		if entry.IsDir() || id == entry.Name() || !recordingIDPattern.MatchString(id) {
+			continue
+		}
+		recording, err := readRecordingInfo(filepath.Join(dir, entry.Name()), id)
+		if err != nil {
+			continue // Skip files removed or unreadable since listing
+		}
+		recordings = append(recordings, recording)
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+// RecordingPath returns the file path of the recording with the given ID
+// IDs are validated so they cannot refer to files outside dir
+func RecordingPath(dir, id string) (string, error) {
+	if !recordingIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid recording ID")
+	}
+	return filepath.Join(dir, id+RecordingExt), nil
+}
+
+// readRecordingInfo reads a recording's size, times and header
+func readRecordingInfo(path, id string) (*models.TerminalRecording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	recording := &models.TerminalRecording{
+		ID:        id,
+		Size:      info.Size(),
+		StartedAt: info.ModTime().UTC(),
+		UpdatedAt: info.ModTime().UTC(),
+	}
+
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return recording, nil
+	}
+	var header asciicastHeader
+	if json.Unmarshal(line, &header) == nil {
+		recording.Width = header.Width
+		recording.Height = header.Height
+		if header.Timestamp > 0 {
+			recording.StartedAt = time.Unix(header.Timestamp, 0).UTC()
+		}
+	}
+	return recording, nil
+}
@@ -0,0 +1,113 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recordings")
+
+	recorder, err := NewRecorder(dir, 80, 24, "/bin/bash", false)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	if !strings.HasSuffix(recorder.Path(), RecordingExt) || filepath.Dir(recorder.Path()) != dir {
+		t.Errorf("Unexpected recording path %q", recorder.Path())
+	}
+
+	// "é" split across two reads is recorded whole
+	recorder.Output([]byte("caf\xc3"))
+	recorder.Output([]byte("\xa9\r\n"))
+	recorder.Input([]byte("secret\r"))
+	recorder.Resize(120, 40)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Failed to close recorder: %v", err)
+	}
+
+	info, err := os.Stat(recorder.Path())
+	if err != nil {
+		t.Fatalf("Recording not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected recording mode 0600, got %v", info.Mode().Perm())
+	}
+
+	file, err := os.Open(recorder.Path())
+	if err != nil {
+		t.Fatalf("Failed to open recording: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan()
+	var header asciicastHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("Invalid header: %v", err)
+	}
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 {
+		t.Errorf("Unexpected header: %+v", header)
+	}
+
+	var events [][]any
+	for scanner.Scan() {
+		var event []any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid event line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	// Input is not recorded unless enabled
+	want := [][2]string{{"o", "caf"}, {"o", "é\r\n"}, {"r", "120x40"}}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i][1] != w[0] || events[i][2] != w[1] {
+			t.Errorf("Event %d: expected %v, got %v", i, w, events[i])
+		}
+	}
+}
+
+func TestPruneRecordings(t *testing.T) {
+	dir := t.TempDir()
+
+	old := filepath.Join(dir, "old"+RecordingExt)
+	recent := filepath.Join(dir, "recent"+RecordingExt)
+	other := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{old, recent, other} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	stale := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(old, stale, stale)
+	os.Chtimes(other, stale, stale)
+
+	removed, err := PruneRecordings(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to prune recordings: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 recording removed, got %d", removed)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected old recording to be removed")
+	}
+	for _, path := range []string{recent, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept: %v", filepath.Base(path), err)
+		}
+	}
+
+	// Zero retention keeps everything
+	if removed, _ := PruneRecordings(dir, 0); removed != 0 {
+		t.Errorf("Expected nothing removed without retention, got %d", removed)
+	}
+}
@@ -0,0 +1,180 @@
+package terminal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/models"
+)
+
+// LimitError is returned when opening a session would exceed a session limit
+type LimitError struct {
+	Limit   int
+	PerUser bool
+}
+
+func (e *LimitError) Error() string {
+	if e.PerUser {
+		return fmt.Sprintf("terminal session limit reached (%d per user)", e.Limit)
+	}
+	return fmt.Sprintf("terminal session limit reached (%d in total)", e.Limit)
+}
+
+// registryEntry is a registered session; session is nil until the PTY is started
+type registryEntry struct {
+	info    models.TerminalSession
+	session *Session
+	closed  bool
+}
+
+// snapshot returns a copy of the entry's details with the current observer count
+func (e *registryEntry) snapshot() *models.TerminalSession {
+	info := e.info
+	if e.session != nil {
+		info.Observers = e.session.Observers()
+		info.Connected = e.session.Connected()
+	}
+	return &info
+}
+
+// Registry tracks open terminal sessions and enforces session limits
+type Registry struct {
+	mu         sync.Mutex
+	entries    map[string]*registryEntry
+	maxTotal   int
+	maxPerUser int
+}
+
+// NewRegistry creates a registry; a limit of 0 means no limit
+func NewRegistry(maxTotal, maxPerUser int) *Registry {
+	return &Registry{
+		entries:    make(map[string]*registryEntry),
+		maxTotal:   maxTotal,
+		maxPerUser: maxPerUser,
+	}
+}
+
+// Register reserves a session slot for owner before the session is created
+// Returns a *LimitError when a limit is reached
+func (r *Registry) Register(owner, shell, server string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxTotal > 0 && len(r.entries) >= r.maxTotal {
+		return "", &LimitError{Limit: r.maxTotal}
+	}
+	if r.maxPerUser > 0 {
+		count := 0
+		for _, entry := range r.entries {
+			if entry.info.Owner == owner {
+				count++
+			}
+		}
+		if count >= r.maxPerUser {
+			return "", &LimitError{Limit: r.maxPerUser, PerUser: true}
+		}
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	r.entries[id] = &registryEntry{
+		info: models.TerminalSession{
+			ID:        id,
+			Owner:     owner,
+			Shell:     shell,
+			Server:    server,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+	return id, nil
+}
+
+// Attach associates a started session with its slot
+// A session closed while it was being created is closed right away
+func (r *Registry) Attach(id string, session *Session, recordingID string) {
+	r.mu.Lock()
+	entry, ok := r.entries[id]
+	closed := !ok || entry.closed
+	if ok {
+		entry.session = session
+		entry.info.RecordingID = recordingID
+	}
+	r.mu.Unlock()
+
+	if closed {
+		session.Close()
+	}
+}
+
+// Remove releases a session slot
+func (r *Registry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, id)
+}
+
+// Get returns a registered session's details
+func (r *Registry) Get(id string) (*models.TerminalSession, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[id]
+	if !ok {
+		return nil, false
+	}
+	return entry.snapshot(), true
+}
+
+// Session returns the running session with the ID, or nil if it is not running
+func (r *Registry) Session(id string) *Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[id]
+	if !ok || entry.closed {
+		return nil
+	}
+	return entry.session
+}
+
+// List returns the registered sessions, oldest first
+func (r *Registry) List() []*models.TerminalSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := make([]*models.TerminalSession, 0, len(r.entries))
+	for _, entry := range r.entries {
+		sessions = append(sessions, entry.snapshot())
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	return sessions
+}
+
+// Close terminates a registered session
+// Returns false if no session has the ID
+func (r *Registry) Close(id string) bool {
+	r.mu.Lock()
+	entry, ok := r.entries[id]
+	if !ok {
+		r.mu.Unlock()
+		return false
+	}
+	entry.closed = true
+	session := entry.session
+	r.mu.Unlock()
+
+	if session != nil {
+		session.Close()
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package terminal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistryLimits(t *testing.T) {
+	registry := NewRegistry(3, 2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := registry.Register("user:alice", "/bin/bash", "local"); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	var limitErr *LimitError
+	_, err := registry.Register("user:alice", "/bin/bash", "local")
+	if !errors.As(err, &limitErr) || !limitErr.PerUser {
+		t.Fatalf("Expected per-user limit error, got %v", err)
+	}
+
+	bobID, err := registry.Register("user:bob", "/bin/sh", "local")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	_, err = registry.Register("user:carol", "/bin/bash", "local")
+	if !errors.As(err, &limitErr) || limitErr.PerUser {
+		t.Fatalf("Expected global limit error, got %v", err)
+	}
+
+	// Releasing a slot makes room again
+	registry.Remove(bobID)
+	if _, err := registry.Register("user:carol", "/bin/bash", "local"); err != nil {
+		t.Errorf("Expected slot after removal, got %v", err)
+	}
+
+	sessions := registry.List()
+	if len(sessions) != 3 {
+		t.Fatalf("Expected 3 sessions, got %d", len(sessions))
+	}
+	if sessions[0].Owner != "user:alice" || sessions[2].Owner != "user:carol" {
+		t.Errorf("Expected sessions oldest first, got %+v", sessions)
+	}
+}
+
+func TestRegistryClose(t *testing.T) {
+	registry := NewRegistry(0, 0)
+
+	id, err := registry.Register("user:alice", "/bin/bash", "local")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if registry.Close("missing") {
+		t.Error("Expected Close() to report unknown session")
+	}
+
+	// A session closed before it is attached is closed on attach
+	if !registry.Close(id) {
+		t.Fatal("Expected Close() to find the session")
+	}
+	session := &Session{done: make(chan struct{})}
+	registry.Attach(id, session, "")
+
+	select {
+	case <-session.done:
+	default:
+		t.Error("Expected session closed when attached after Close()")
+	}
+
+	info, ok := registry.Get(id)
+	if !ok || info.Shell != "/bin/bash" {
+		t.Errorf("Get() = %+v, %v", info, ok)
+	}
+}
@@ -0,0 +1,145 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RCEnvVar is the environment variable pointing the shell at the configured startup file
+// Custom wrappers can source it themselves
+const RCEnvVar = "WEBCLI_RC"
+
+// shellNamePattern matches shell names clients select shells by
+var shellNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// Shell is a shell that terminal sessions can run
+type Shell struct {
+	Name   string   // Name clients select the shell by
+	Path   string   // Absolute path of the executable
+	Args   []string // Arguments, e.g. "ash" for busybox
+	RCFile string   // Startup file sourced after the user's own (optional)
+	Env    []string // Extra environment as NAME=value, e.g. stored env vars (kept off disk)
+}
+
+// DefaultShells is the shell catalog used when none is configured
+// A name may be listed several times; the first executable path is used
+var DefaultShells = []Shell{
+	{Name: "bash", Path: "/bin/bash"},
+	{Name: "bash", Path: "/usr/bin/bash"},
+	{Name: "sh", Path: "/bin/sh"},
+	{Name: "sh", Path: "/usr/bin/sh"},
+	{Name: "zsh", Path: "/bin/zsh"},
+	{Name: "zsh", Path: "/usr/bin/zsh"},
+	{Name: "fish", Path: "/usr/bin/fish"},
+	{Name: "fish", Path: "/usr/local/bin/fish"},
+	{Name: "ash", Path: "/bin/ash"},
+	{Name: "ash", Path: "/bin/busybox", Args: []string{"ash"}},
+}
+
+// ParseShells parses shell catalog entries of the form "name=/path/to/shell [args...]"
+func ParseShells(entries []string) ([]Shell, error) {
+	shells := make([]Shell, 0, len(entries))
+	for _, entry := range entries {
+		name, command, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(command)
+		if !ok || len(fields) == 0 {
+			return nil, fmt.Errorf("invalid shell %q: expected name=/path/to/shell", entry)
+		}
+		if !shellNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid shell name %q", name)
+		}
+		if !filepath.IsAbs(fields[0]) {
+			return nil, fmt.Errorf("shell %s must be an absolute path", name)
+		}
+		shells = append(shells, Shell{Name: name, Path: fields[0], Args: fields[1:]})
+	}
+	return shells, nil
+}
+
+// CheckExecutable returns an error unless path is an executable file
+func CheckExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
+
+// AvailableShells returns the shells in catalog that are installed, one per name
+func AvailableShells(catalog []Shell) []Shell {
+	available := []Shell{}
+	seen := make(map[string]bool)
+	for _, shell := range catalog {
+		if seen[shell.Name] || CheckExecutable(shell.Path) != nil {
+			continue
+		}
+		seen[shell.Name] = true
+		available = append(available, shell)
+	}
+	return available
+}
+
+// FindShell returns the shell in shells with the given name or path
+func FindShell(shells []Shell, name string) (Shell, bool) {
+	for _, shell := range shells {
+		if shell.Name == name || (shell.Path == name && len(shell.Args) == 0) {
+			return shell, true
+		}
+	}
+	return Shell{}, false
+}
+
+// rcStartup arranges for an interactive shell to source its RCFile after the
+// user's own startup file, writing any helper files to dir
+// Returns the arguments to put before the shell's own, and extra environment
+func rcStartup(shell Shell, dir string) ([]string, []string, error) {
+	env := []string{RCEnvVar + "=" + shell.RCFile}
+
+	name := filepath.Base(shell.Path)
+	if name == "busybox" && len(shell.Args) > 0 {
+		name = shell.Args[0]
+	}
+
+	source := ". " + quoteShellArg(shell.RCFile) + "\n"
+	switch name {
+	case "bash":
+		path := filepath.Join(dir, "bashrc")
+		if err := os.WriteFile(path, []byte("[ -f ~/.bashrc ] && . ~/.bashrc\n"+source), 0600); err != nil {
+			return nil, nil, err
+		}
+		return []string{"--rcfile", path}, env, nil
+	case "zsh":
+		// zsh reads its startup files from ZDOTDIR; the generated .zshrc restores
+		// it so the user's files are used from then on
+		zshenv := "[ -f \"$HOME/.zshenv\" ] && . \"$HOME/.zshenv\"\n"
+		zshrc := "ZDOTDIR=\"$HOME\"\n[ -f \"$HOME/.zshrc\" ] && . \"$HOME/.zshrc\"\n" + source
+		if err := os.WriteFile(filepath.Join(dir, ".zshenv"), []byte(zshenv), 0600); err != nil {
+			return nil, nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".zshrc"), []byte(zshrc), 0600); err != nil {
+			return nil, nil, err
+		}
+		return nil, append(env, "ZDOTDIR="+dir), nil
+	case "fish":
+		return []string{"--init-command", "source " + quoteShellArg(shell.RCFile)}, env, nil
+	case "sh", "ash", "dash", "ksh", "mksh":
+		// POSIX shells source $ENV when interactive
+		return nil, append(env, "ENV="+shell.RCFile), nil
+	}
+	return nil, env, nil
+}
+
+// quoteShellArg quotes s as a single shell word
+func quoteShellArg(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
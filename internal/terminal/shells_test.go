@@ -0,0 +1,137 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseShells(t *testing.T) {
+	shells, err := ParseShells([]string{"bash=/bin/bash", "ash=/bin/busybox ash", " ops = /usr/local/bin/ops-shell --restricted"})
+	if err != nil {
+		t.Fatalf("ParseShells() error = %v", err)
+	}
+	if len(shells) != 3 {
+		t.Fatalf("Expected 3 shells, got %d", len(shells))
+	}
+	if shells[1].Path != "/bin/busybox" || len(shells[1].Args) != 1 || shells[1].Args[0] != "ash" {
+		t.Errorf("Unexpected busybox shell %+v", shells[1])
+	}
+	if shells[2].Name != "ops" || shells[2].Args[0] != "--restricted" {
+		t.Errorf("Unexpected wrapper shell %+v", shells[2])
+	}
+
+	for _, entry := range []string{"/bin/bash", "bash=", "bash=bin/bash", "b ash=/bin/bash"} {
+		if _, err := ParseShells([]string{entry}); err == nil {
+			t.Errorf("Expected error for %q", entry)
+		}
+	}
+}
+
+func TestAvailableShells(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "wrapper")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec /bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "plain")
+	if err := os.WriteFile(plain, []byte("not a shell"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckExecutable(script); err != nil {
+		t.Errorf("Expected %s to be executable: %v", script, err)
+	}
+	for _, path := range []string{plain, dir, filepath.Join(dir, "missing")} {
+		if err := CheckExecutable(path); err == nil {
+			t.Errorf("Expected %s not to be executable", path)
+		}
+	}
+
+	available := AvailableShells([]Shell{
+		{Name: "custom", Path: filepath.Join(dir, "missing")},
+		{Name: "custom", Path: script},
+		{Name: "plain", Path: plain},
+	})
+	if len(available) != 1 || available[0].Path != script {
+		t.Fatalf("Expected only the wrapper to be available, got %+v", available)
+	}
+	if _, ok := FindShell(available, script); !ok {
+		t.Error("Expected shell to be found by path")
+	}
+	if _, ok := FindShell(available, "plain"); ok {
+		t.Error("Expected uninstalled shell not to be found")
+	}
+
+	if _, err := NewSession(nil, Shell{Name: "plain", Path: plain}, SSHIdentity{}, nil); err == nil {
+		t.Error("Expected session with a non-executable shell to fail")
+	}
+}
+
+func TestRCStartup(t *testing.T) {
+	dir := t.TempDir()
+	rc := "/etc/web-cli/it's.rc"
+
+	args, env, err := rcStartup(Shell{Name: "bash", Path: "/bin/bash", RCFile: rc}, dir)
+	if err != nil {
+		t.Fatalf("rcStartup() error = %v", err)
+	}
+	if len(args) != 2 || args[0] != "--rcfile" {
+		t.Fatalf("Expected --rcfile argument, got %v", args)
+	}
+	content, err := os.ReadFile(args[1])
+	if err != nil {
+		t.Fatalf("Failed to read generated rc file: %v", err)
+	}
+	if !strings.Contains(string(content), "~/.bashrc") || !strings.Contains(string(content), `. '/etc/web-cli/it'\''s.rc'`) {
+		t.Errorf("Unexpected bash rc file %q", content)
+	}
+	if len(env) != 1 || env[0] != RCEnvVar+"="+rc {
+		t.Errorf("Expected %s in environment, got %v", RCEnvVar, env)
+	}
+
+	args, env, err = rcStartup(Shell{Name: "ash", Path: "/bin/busybox", Args: []string{"ash"}, RCFile: rc}, dir)
+	if err != nil || len(args) != 0 || env[len(env)-1] != "ENV="+rc {
+		t.Errorf("Expected ENV for busybox ash, got %v %v %v", args, env, err)
+	}
+
+	_, env, err = rcStartup(Shell{Name: "zsh", Path: "/bin/zsh", RCFile: rc}, dir)
+	if err != nil || env[len(env)-1] != "ZDOTDIR="+dir {
+		t.Errorf("Expected ZDOTDIR for zsh, got %v %v", env, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".zshrc")); err != nil {
+		t.Errorf("Expected generated .zshrc: %v", err)
+	}
+}
+
+func TestSessionShellEnv(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "print-env")
+	out := filepath.Join(dir, "env.out")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s' \"$API_TOKEN\" > "+out+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := NewSession(nil, Shell{Name: "print-env", Path: script, Env: []string{"API_TOKEN=s3cr'et"}}, SSHIdentity{}, nil)
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	defer session.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(out)
+		if err == nil && len(data) > 0 {
+			if string(data) != "s3cr'et" {
+				t.Errorf("Expected API_TOKEN in the shell environment, got %q", data)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Shell did not write its environment")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/creack/pty"
 	"github.com/gorilla/websocket"
@@ -37,6 +39,13 @@ const (
 	maxTerminalCols = 500
 	maxHostnameLen  = 253
 	maxUsernameLen  = 32
+
+	// clientWriteTimeout bounds a write to a slow or vanished client
+	clientWriteTimeout = 10 * time.Second
+
+	// observerBufferSize is the number of output chunks queued for an observer
+	// before it is disconnected for falling behind
+	observerBufferSize = 256
 )
 
 // validHostnamePattern matches valid hostname characters (alphanumeric, hyphen, underscore, dot)
@@ -100,41 +109,131 @@ func ValidateServerConfig(server ServerConfig) error {
 }
 
 // Session manages a PTY session connected to a WebSocket
+// A resumable session outlives its WebSocket for a grace period, so the client
+// can reconnect and reattach with Attach
 type Session struct {
 	ptmx       *os.File
 	cmd        *exec.Cmd
-	ws         *websocket.Conn
 	done       chan struct{}
 	closeOnce  sync.Once
 	sshKeyPath string // Path to temporary SSH key file (if any)
 	tmpDir     string // Path to temporary directory for session files
+	filesDir   string // Directory for files uploaded to or downloaded from the browser
+	recorder   *Recorder
+	keylog     *KeystrokeLogger
+
+	// wsMu guards the client and scrollback, and serializes writes to the client
+	wsMu           sync.Mutex
+	ws             *websocket.Conn // Attached client, nil while detached
+	detachGrace    time.Duration
+	detachTimer    *time.Timer
+	scrollback     []byte
+	scrollbackSize int
+
+	observersMu sync.Mutex
+	observers   map[*observer]struct{}
+}
+
+// observer is a read-only WebSocket mirroring the session output
+type observer struct {
+	out chan []byte
+}
+
+// SSHIdentity is the key a session's ssh commands authenticate with
+type SSHIdentity struct {
+	PrivateKey  string // PEM-encoded private key
+	Certificate string // Certificate for the key in authorized_keys format (optional)
 }
 
 // NewSession creates a new terminal session with the specified shell
-// sshPrivateKey: if provided, will be written to a temp file and used for SSH connections
+// The shell's path is checked to be executable before it is started
+// identity: if it has a key, it is written to a temp file and used for SSH connections
 // servers: list of servers from admin panel to generate SSH config aliases
-func NewSession(ws *websocket.Conn, shell string, sshPrivateKey string, servers []ServerConfig) (*Session, error) {
-	cmd := exec.Command(shell)
+func NewSession(ws *websocket.Conn, shell Shell, identity SSHIdentity, servers []ServerConfig) (*Session, error) {
+	return newSession(ws, shell, "", identity, servers)
+}
+
+// NewSSHSession creates a terminal session already running ssh to target
+// target must be the Name of one of servers; the session ends when ssh exits
+func NewSSHSession(ws *websocket.Conn, shell Shell, target string, identity SSHIdentity, servers []ServerConfig) (*Session, error) {
+	found := false
+	for _, server := range servers {
+		if server.Name == target {
+			if err := ValidateServerConfig(server); err != nil {
+				return nil, fmt.Errorf("invalid SSH target: %w", err)
+			}
+			found = true
+			break
+		}
+	}
+	if target == "" || !found {
+		return nil, fmt.Errorf("SSH target %q is not a configured server", target)
+	}
+	return newSession(ws, shell, target, identity, servers)
+}
+
+// newSession starts shell, or ssh to sshTarget when set, in a PTY
+func newSession(ws *websocket.Conn, shell Shell, sshTarget string, identity SSHIdentity, servers []ServerConfig) (*Session, error) {
+	sshPrivateKey := identity.PrivateKey
+	if sshTarget == "" {
+		if err := CheckExecutable(shell.Path); err != nil {
+			return nil, fmt.Errorf("shell %s is not available: %w", shell.Name, err)
+		}
+	}
+
 	// Set environment with proper TERM for full terminal support
 	env := append(os.Environ(), "TERM=xterm-256color")
+	// Extra variables come first so the session's own settings take precedence
+	env = append(env, shell.Env...)
+	args := shell.Args
 
 	var sshKeyPath string
 	var tmpDir string
 
-	// Create temp directory for session files (SSH config, keys, wrapper)
+	// Directory for exchanging files with the browser, exported to the shell
+	filesDir, err := os.MkdirTemp("", "webcli-files-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create files dir: %w", err)
+	}
+	env = append(env, FilesEnvVar+"="+filesDir)
+
+	cleanup := func() {
+		os.RemoveAll(filesDir)
+		if tmpDir != "" {
+			os.RemoveAll(tmpDir)
+		}
+	}
+
+	injectRC := shell.RCFile != "" && sshTarget == ""
+
+	// Create temp directory for session files (SSH config, keys, wrapper, startup files)
 	// We always create this if we have servers or SSH key
-	if len(servers) > 0 || sshPrivateKey != "" {
-		var err error
+	if len(servers) > 0 || sshPrivateKey != "" || injectRC {
 		tmpDir, err = os.MkdirTemp("", "webcli-ssh-*")
 		if err != nil {
+			cleanup()
 			return nil, fmt.Errorf("failed to create temp dir: %w", err)
 		}
+	}
+
+	// Source the configured startup file after the user's own
+	if injectRC {
+		rcArgs, rcEnv, err := rcStartup(shell, tmpDir)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to set up shell startup file: %w", err)
+		}
+		args = append(rcArgs, args...)
+		env = append(env, rcEnv...)
+	}
+
+	if len(servers) > 0 || sshPrivateKey != "" {
 
 		// Generate SSH config file with server aliases
 		if len(servers) > 0 {
 			sshConfigPath := filepath.Join(tmpDir, "config")
 			if err := generateSSHConfig(sshConfigPath, servers); err != nil {
-				os.RemoveAll(tmpDir)
+				cleanup()
 				return nil, fmt.Errorf("failed to generate SSH config: %w", err)
 			}
 		}
@@ -149,10 +248,18 @@ func NewSession(ws *websocket.Conn, shell string, sshPrivateKey string, servers
 
 			sshKeyPath = filepath.Join(tmpDir, "id_rsa")
 			if err := os.WriteFile(sshKeyPath, []byte(keyContent), 0600); err != nil {
-				os.RemoveAll(tmpDir)
+				cleanup()
 				return nil, fmt.Errorf("failed to write SSH key: %w", err)
 			}
 
+			// ssh loads the certificate from the key's path with -cert.pub appended
+			if identity.Certificate != "" {
+				if err := os.WriteFile(sshKeyPath+"-cert.pub", []byte(identity.Certificate), 0600); err != nil {
+					cleanup()
+					return nil, fmt.Errorf("failed to write SSH certificate: %w", err)
+				}
+			}
+
 			// Add environment variable pointing to the SSH key
 			env = append(env, "SSH_KEY_PATH="+sshKeyPath)
 		}
@@ -161,7 +268,7 @@ func NewSession(ws *websocket.Conn, shell string, sshPrivateKey string, servers
 		wrapperPath := filepath.Join(tmpDir, "ssh")
 		wrapperContent := generateSSHWrapper(tmpDir, sshKeyPath, len(servers) > 0)
 		if err := os.WriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
-			os.RemoveAll(tmpDir)
+			cleanup()
 			return nil, fmt.Errorf("failed to write SSH wrapper: %w", err)
 		}
 
@@ -172,15 +279,19 @@ func NewSession(ws *websocket.Conn, shell string, sshPrivateKey string, servers
 				break
 			}
 		}
+
 	}
 
+	// Run ssh through the wrapper instead of an interactive shell when targeting a server
+	cmd := exec.Command(shell.Path, args...)
+	if sshTarget != "" {
+		cmd = exec.Command(filepath.Join(tmpDir, "ssh"), sshTarget)
+	}
 	cmd.Env = env
 
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
-		if tmpDir != "" {
-			os.RemoveAll(tmpDir)
-		}
+		cleanup()
 		return nil, err
 	}
 
@@ -188,9 +299,7 @@ func NewSession(ws *websocket.Conn, shell string, sshPrivateKey string, servers
 	if err := pty.Setsize(ptmx, &pty.Winsize{Rows: 24, Cols: 80}); err != nil {
 		ptmx.Close()
 		cmd.Process.Kill()
-		if tmpDir != "" {
-			os.RemoveAll(tmpDir)
-		}
+		cleanup()
 		return nil, err
 	}
 
@@ -201,94 +310,348 @@ func NewSession(ws *websocket.Conn, shell string, sshPrivateKey string, servers
 		done:       make(chan struct{}),
 		sshKeyPath: sshKeyPath,
 		tmpDir:     tmpDir,
+		filesDir:   filesDir,
 	}, nil
 }
 
+// Record writes the session to the recorder; call before Start
+// The recorder is closed with the session
+func (s *Session) Record(recorder *Recorder) {
+	s.recorder = recorder
+}
+
+// LogKeystrokes writes the user's input to the keystroke logger; call before Start
+// The logger is closed with the session
+func (s *Session) LogKeystrokes(logger *KeystrokeLogger) {
+	s.keylog = logger
+}
+
+// FilesDir returns the directory for exchanging files with the browser
+func (s *Session) FilesDir() string {
+	return s.filesDir
+}
+
+// Resumable keeps the shell running for grace after the client disconnects
+// unexpectedly, and keeps the last scrollbackSize bytes of output to replay to
+// reattaching clients and new observers; call before Start
+func (s *Session) Resumable(grace time.Duration, scrollbackSize int) {
+	s.detachGrace = grace
+	s.scrollbackSize = scrollbackSize
+}
+
 // Start begins bidirectional communication between WebSocket and PTY
+// It blocks until the session ends, which for a resumable session may be
+// after several clients have attached and disconnected
 func (s *Session) Start() {
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	// PTY -> WebSocket (shell output to browser)
+	// Wait for shell process to exit
 	go func() {
-		defer wg.Done()
-		buf := make([]byte, 4096)
-		for {
+		s.cmd.Wait()
+		s.Close()
+	}()
+
+	go s.readOutput()
+
+	s.wsMu.Lock()
+	ws := s.ws
+	s.wsMu.Unlock()
+	if ws != nil {
+		go s.readInput(ws)
+	}
+
+	<-s.done
+}
+
+// Attach makes ws the session's client, replaying the scrollback first
+// A client that is still attached is disconnected. Blocks until ws
+// disconnects or the session ends.
+func (s *Session) Attach(ws *websocket.Conn) error {
+	s.wsMu.Lock()
+	select {
+	case <-s.done:
+		s.wsMu.Unlock()
+		return fmt.Errorf("session has ended")
+	default:
+	}
+
+	if s.ws != nil {
+		s.ws.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		s.ws.WriteMessage(websocket.TextMessage, []byte("\r\n[session resumed from another connection]\r\n"))
+		s.ws.Close()
+	}
+	if s.detachTimer != nil {
+		s.detachTimer.Stop()
+		s.detachTimer = nil
+	}
+
+	s.ws = ws
+	if len(s.scrollback) > 0 {
+		ws.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		ws.WriteMessage(websocket.BinaryMessage, s.scrollback)
+	}
+	s.wsMu.Unlock()
+
+	s.readInput(ws)
+	return nil
+}
+
+// Connected reports whether a client is attached
+func (s *Session) Connected() bool {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	return s.ws != nil
+}
+
+// readOutput copies shell output to the client (PTY -> WebSocket)
+func (s *Session) readOutput() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.ptmx.Read(buf)
+		if err != nil {
 			select {
 			case <-s.done:
-				return
 			default:
-				n, err := s.ptmx.Read(buf)
-				if err != nil {
-					if err != io.EOF {
-						log.Printf("PTY read error: %v", err)
-					}
-					s.Close()
-					return
-				}
-				if n > 0 {
-					if err := s.ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
-						log.Printf("WebSocket write error: %v", err)
-						s.Close()
-						return
-					}
+				if err != io.EOF {
+					log.Printf("PTY read error: %v", err)
 				}
 			}
+			s.Close()
+			return
 		}
-	}()
+		if n > 0 {
+			if s.recorder != nil {
+				s.recorder.Output(buf[:n])
+			}
+			if s.keylog != nil {
+				s.keylog.Output(buf[:n])
+			}
+			s.output(buf[:n])
+		}
+	}
+}
 
-	// WebSocket -> PTY (user input to shell)
-	go func() {
-		defer wg.Done()
-		for {
+// output sends shell output to the attached client, keeping it in the
+// scrollback and mirroring it to observers
+func (s *Session) output(data []byte) {
+	s.wsMu.Lock()
+	s.appendScrollback(data)
+	s.broadcast(data)
+
+	end := false
+	if ws := s.ws; ws != nil {
+		ws.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		if err := ws.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			log.Printf("WebSocket write error: %v", err)
+			end = s.detachLocked(ws)
+		}
+	}
+	s.wsMu.Unlock()
+
+	if end {
+		s.Close()
+	}
+}
+
+// readInput copies client input to the shell (WebSocket -> PTY) until the client disconnects
+func (s *Session) readInput(ws *websocket.Conn) {
+	for {
+		messageType, message, err := ws.ReadMessage()
+		if err != nil {
 			select {
 			case <-s.done:
 				return
 			default:
-				messageType, message, err := s.ws.ReadMessage()
-				if err != nil {
-					if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
-						log.Printf("WebSocket read error: %v", err)
-					}
+			}
+			// A normal close means the user closed the terminal; other
+			// disconnects (refresh, network loss) can be resumed
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				s.Close()
+				return
+			}
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("WebSocket read error: %v", err)
+			}
+			s.detach(ws)
+			return
+		}
+
+		switch messageType {
+		case websocket.TextMessage:
+			// Check if it's a resize message
+			var resizeMsg ResizeMessage
+			if err := json.Unmarshal(message, &resizeMsg); err == nil && resizeMsg.Type == "resize" {
+				if err := s.Resize(resizeMsg.Rows, resizeMsg.Cols); err != nil {
+					log.Printf("Resize error: %v", err)
+				}
+			} else {
+				// Regular text input
+				if s.recorder != nil {
+					s.recorder.Input(message)
+				}
+				if s.keylog != nil {
+					s.keylog.Input(message)
+				}
+				if _, err := s.ptmx.Write(message); err != nil {
+					log.Printf("PTY write error: %v", err)
 					s.Close()
 					return
 				}
-
-				switch messageType {
-				case websocket.TextMessage:
-					// Check if it's a resize message
-					var resizeMsg ResizeMessage
-					if err := json.Unmarshal(message, &resizeMsg); err == nil && resizeMsg.Type == "resize" {
-						if err := s.Resize(resizeMsg.Rows, resizeMsg.Cols); err != nil {
-							log.Printf("Resize error: %v", err)
-						}
-					} else {
-						// Regular text input
-						if _, err := s.ptmx.Write(message); err != nil {
-							log.Printf("PTY write error: %v", err)
-							s.Close()
-							return
-						}
-					}
-				case websocket.BinaryMessage:
-					// Binary data goes directly to PTY
-					if _, err := s.ptmx.Write(message); err != nil {
-						log.Printf("PTY write error: %v", err)
-						s.Close()
-						return
-					}
-				}
+			}
+		case websocket.BinaryMessage:
+			// Binary data goes directly to PTY
+			if s.recorder != nil {
+				s.recorder.Input(message)
+			}
+			if s.keylog != nil {
+				s.keylog.Input(message)
+			}
+			if _, err := s.ptmx.Write(message); err != nil {
+				log.Printf("PTY write error: %v", err)
+				s.Close()
+				return
 			}
 		}
+	}
+}
+
+// detach disconnects ws if it is still the attached client
+func (s *Session) detach(ws *websocket.Conn) {
+	s.wsMu.Lock()
+	end := s.detachLocked(ws)
+	s.wsMu.Unlock()
+
+	if end {
+		s.Close()
+	}
+}
+
+// detachLocked disconnects ws if it is still the attached client and starts
+// the grace period; the caller holds wsMu
+// Returns true if the session should end because it cannot be resumed
+func (s *Session) detachLocked(ws *websocket.Conn) bool {
+	if s.ws != ws {
+		return false
+	}
+	s.ws = nil
+	ws.Close()
+
+	if s.detachGrace <= 0 {
+		return true
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(s.detachGrace, func() {
+		s.wsMu.Lock()
+		expired := s.ws == nil && s.detachTimer == timer
+		s.wsMu.Unlock()
+		if expired {
+			log.Printf("Detached terminal session was not resumed within %v", s.detachGrace)
+			s.Close()
+		}
+	})
+	s.detachTimer = timer
+	return false
+}
+
+// appendScrollback keeps the most recent output for replay; the caller holds wsMu
+func (s *Session) appendScrollback(data []byte) {
+	if s.scrollbackSize <= 0 {
+		return
+	}
+	s.scrollback = append(s.scrollback, data...)
+	if over := len(s.scrollback) - s.scrollbackSize; over > 0 {
+		// Start at a character boundary so the replay begins with valid UTF-8
+		for over < len(s.scrollback) && !utf8.RuneStart(s.scrollback[over]) {
+			over++
+		}
+		s.scrollback = append(s.scrollback[:0], s.scrollback[over:]...)
+	}
+}
+
+// Observe mirrors the session output to ws until either side closes
+// The observer first receives the scrollback, if any. Observers are view-only:
+// anything they send is discarded. An observer that cannot keep up is
+// disconnected rather than slowing down the session.
+func (s *Session) Observe(ws *websocket.Conn) {
+	defer ws.Close()
+
+	o := &observer{out: make(chan []byte, observerBufferSize)}
+	s.wsMu.Lock()
+	select {
+	case <-s.done:
+		s.wsMu.Unlock()
+		return
+	default:
+	}
+	s.observersMu.Lock()
+	if s.observers == nil {
+		s.observers = make(map[*observer]struct{})
+	}
+	s.observers[o] = struct{}{}
+	if len(s.scrollback) > 0 {
+		o.out <- append([]byte(nil), s.scrollback...)
+	}
+	s.observersMu.Unlock()
+	s.wsMu.Unlock()
+
+	defer func() {
+		s.observersMu.Lock()
+		delete(s.observers, o)
+		s.observersMu.Unlock()
 	}()
 
-	// Wait for shell process to exit
+	// Read only to notice the observer disconnecting
+	disconnected := make(chan struct{})
 	go func() {
-		s.cmd.Wait()
-		s.Close()
+		defer close(disconnected)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
 	}()
 
-	wg.Wait()
+	for {
+		select {
+		case data, ok := <-o.out:
+			if !ok {
+				ws.WriteMessage(websocket.TextMessage, []byte("\r\n[observer disconnected: too slow]\r\n"))
+				return
+			}
+			if err := ws.WriteMessage(websocket.BinaryMessage, data); err != nil {
+				return
+			}
+		case <-disconnected:
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Observers returns the number of connected observers
+func (s *Session) Observers() int {
+	s.observersMu.Lock()
+	defer s.observersMu.Unlock()
+	return len(s.observers)
+}
+
+// broadcast queues output for every observer, dropping observers that fall behind
+func (s *Session) broadcast(data []byte) {
+	s.observersMu.Lock()
+	defer s.observersMu.Unlock()
+
+	if len(s.observers) == 0 {
+		return
+	}
+	chunk := append([]byte(nil), data...)
+	for o := range s.observers {
+		select {
+		case o.out <- chunk:
+		default:
+			delete(s.observers, o)
+			close(o.out)
+		}
+	}
 }
 
 // Resize changes the PTY window size
@@ -296,7 +659,13 @@ func (s *Session) Resize(rows, cols uint16) error {
 	if err := ValidateTerminalDimensions(rows, cols); err != nil {
 		return err
 	}
-	return pty.Setsize(s.ptmx, &pty.Winsize{Rows: rows, Cols: cols})
+	if err := pty.Setsize(s.ptmx, &pty.Winsize{Rows: rows, Cols: cols}); err != nil {
+		return err
+	}
+	if s.recorder != nil {
+		s.recorder.Resize(cols, rows)
+	}
+	return nil
 }
 
 // Close terminates the session and cleans up resources
@@ -312,14 +681,31 @@ func (s *Session) Close() {
 			s.cmd.Process.Kill()
 		}
 
+		s.wsMu.Lock()
 		if s.ws != nil {
 			s.ws.Close()
 		}
+		if s.detachTimer != nil {
+			s.detachTimer.Stop()
+		}
+		s.wsMu.Unlock()
 
-		// Clean up session temp directory
+		if s.recorder != nil {
+			if err := s.recorder.Close(); err != nil {
+				log.Printf("Failed to close terminal recording: %v", err)
+			}
+		}
+		if s.keylog != nil {
+			s.keylog.Close()
+		}
+
+		// Clean up session temp directories
 		if s.tmpDir != "" {
 			os.RemoveAll(s.tmpDir)
 		}
+		if s.filesDir != "" {
+			os.RemoveAll(s.filesDir)
+		}
 	})
 }
 
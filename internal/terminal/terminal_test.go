@@ -1,6 +1,10 @@
 package terminal
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,7 +29,7 @@ func TestNewSession(t *testing.T) {
 	t.Run("session creation with valid shell", func(t *testing.T) {
 		// We can't easily test with a real WebSocket here without a server
 		// This test documents the expected interface
-		t.Log("Session interface: NewSession(*websocket.Conn, string) (*Session, error)")
+		t.Log("Session interface: NewSession(*websocket.Conn, Shell, SSHIdentity, []ServerConfig) (*Session, error)")
 	})
 }
 
@@ -92,6 +96,190 @@ func TestSessionClose(t *testing.T) {
 	})
 }
 
+func TestSessionObserve(t *testing.T) {
+	session := &Session{done: make(chan struct{})}
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		session.Observe(ws)
+	}))
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect observer: %v", err)
+	}
+	defer client.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for session.Observers() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Observer was not attached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Input from an observer is ignored; output is mirrored
+	client.WriteMessage(websocket.TextMessage, []byte("exit\n"))
+	session.broadcast([]byte("$ uptime"))
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := client.ReadMessage()
+	if err != nil || string(data) != "$ uptime" {
+		t.Fatalf("Expected mirrored output, got %q, %v", data, err)
+	}
+
+	// Closing the session disconnects observers
+	session.Close()
+	if _, _, err := client.ReadMessage(); err == nil {
+		t.Error("Expected observer disconnected when the session closes")
+	}
+}
+
+func TestNewSSHSession(t *testing.T) {
+	servers := []ServerConfig{
+		{Name: "prod", IPAddress: "10.0.0.5", Port: 22, Username: "deploy"},
+		{Name: "bad;host", IPAddress: "10.0.0.6"},
+	}
+
+	if _, err := NewSSHSession(nil, Shell{Name: "sh", Path: "/bin/sh"}, "staging", SSHIdentity{}, servers); err == nil {
+		t.Error("Expected error for a target that is not a configured server")
+	}
+	if _, err := NewSSHSession(nil, Shell{Name: "sh", Path: "/bin/sh"}, "bad;host", SSHIdentity{}, servers); err == nil {
+		t.Error("Expected error for an invalid target")
+	}
+
+	session, err := NewSSHSession(nil, Shell{Name: "sh", Path: "/bin/sh"}, "prod", SSHIdentity{}, servers)
+	if err != nil {
+		t.Fatalf("NewSSHSession() error = %v", err)
+	}
+	defer session.Close()
+
+	// ssh runs through the session's wrapper instead of a shell
+	if len(session.cmd.Args) != 2 || session.cmd.Args[0] != filepath.Join(session.tmpDir, "ssh") || session.cmd.Args[1] != "prod" {
+		t.Errorf("Unexpected command: %v", session.cmd.Args)
+	}
+}
+
+// attachServer starts a WebSocket server attaching each client to session
+func attachServer(t *testing.T, session *Session) (func() *websocket.Conn, func()) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		session.Attach(ws)
+	}))
+
+	connect := func() *websocket.Conn {
+		client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return client
+	}
+	return connect, srv.Close
+}
+
+// waitFor polls until cond is true or fails the test
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionResume(t *testing.T) {
+	session := &Session{done: make(chan struct{})}
+	session.Resumable(time.Minute, 1024)
+	connect, stop := attachServer(t, session)
+	defer stop()
+
+	// Output while nobody is attached is kept in the scrollback
+	session.output([]byte("hello "))
+
+	client := connect()
+	if _, data, err := client.ReadMessage(); err != nil || string(data) != "hello " {
+		t.Fatalf("Expected scrollback replay, got %q, %v", data, err)
+	}
+	waitFor(t, "client attached", session.Connected)
+
+	session.output([]byte("world"))
+	if _, data, err := client.ReadMessage(); err != nil || string(data) != "world" {
+		t.Fatalf("Expected live output, got %q, %v", data, err)
+	}
+
+	// A dropped connection detaches without ending the session
+	client.UnderlyingConn().Close()
+	waitFor(t, "client detached", func() bool { return !session.Connected() })
+	select {
+	case <-session.done:
+		t.Fatal("Expected session to survive a dropped connection")
+	default:
+	}
+
+	client = connect()
+	if _, data, err := client.ReadMessage(); err != nil || string(data) != "hello world" {
+		t.Fatalf("Expected full scrollback on reattach, got %q, %v", data, err)
+	}
+
+	// Closing the terminal normally ends the session
+	client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	waitFor(t, "session closed", func() bool {
+		select {
+		case <-session.done:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+func TestSessionDetachGraceExpires(t *testing.T) {
+	session := &Session{done: make(chan struct{})}
+	session.Resumable(50*time.Millisecond, 0)
+	connect, stop := attachServer(t, session)
+	defer stop()
+
+	client := connect()
+	waitFor(t, "client attached", session.Connected)
+	client.UnderlyingConn().Close()
+
+	waitFor(t, "grace period to expire", func() bool {
+		select {
+		case <-session.done:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+func TestSessionScrollbackLimit(t *testing.T) {
+	session := &Session{done: make(chan struct{}), scrollbackSize: 4}
+
+	session.wsMu.Lock()
+	session.appendScrollback([]byte("ab€"))
+	session.appendScrollback([]byte("cd"))
+	got := string(session.scrollback)
+	session.wsMu.Unlock()
+
+	// Trimming never starts in the middle of a UTF-8 character
+	if got != "cd" {
+		t.Errorf("Expected scrollback trimmed to whole characters, got %q", got)
+	}
+}
+
 // Ensure we're using the websocket package (silences unused import)
 var _ = websocket.CloseGoingAway
 
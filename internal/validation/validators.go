@@ -5,8 +5,11 @@ import (
 	"net"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/pozgo/web-cli/internal/cron"
+	"github.com/pozgo/web-cli/internal/models"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -233,6 +236,148 @@ func ValidateBashScriptFilename(filename string) error {
 	return nil
 }
 
+// windowsAbsPathRegex matches the drive prefix of an absolute Windows path
+var windowsAbsPathRegex = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+// ValidateWorkdir validates the working directory of a command or script execution
+// It must be absolute and cannot step out of itself with .. elements
+func ValidateWorkdir(dir string) error {
+	// Workdir is optional
+	if dir == "" {
+		return nil
+	}
+
+	if len(dir) > 4096 {
+		return fmt.Errorf("workdir too long (max 4096 characters)")
+	}
+
+	if strings.ContainsAny(dir, "\x00\n\r") {
+		return fmt.Errorf("workdir contains invalid characters")
+	}
+
+	if !strings.HasPrefix(dir, "/") && !windowsAbsPathRegex.MatchString(dir) {
+		return fmt.Errorf("workdir must be an absolute path")
+	}
+
+	for _, element := range strings.FieldsFunc(dir, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if element == ".." {
+			return fmt.Errorf("workdir cannot contain path traversal sequences")
+		}
+	}
+
+	return nil
+}
+
+// ValidateScriptInterpreter validates a script interpreter name
+func ValidateScriptInterpreter(interpreter string) error {
+	// Interpreter is optional and defaults to bash
+	if interpreter == "" {
+		return nil
+	}
+
+	if !slices.Contains(models.ScriptInterpreters, interpreter) {
+		return fmt.Errorf("unsupported interpreter %q (supported: %s)", interpreter, strings.Join(models.ScriptInterpreters, ", "))
+	}
+
+	return nil
+}
+
+// tagRegex validates a normalized tag: lowercase letters, digits and . _ : / -
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._:/-]{0,63}$`)
+
+// maxTags is the most tags a script or saved command can have
+const maxTags = 20
+
+// ValidateTags validates the tags of a script or saved command
+// Tags are compared after lowercasing, so "Deploy" and "deploy" are the same tag
+func ValidateTags(tags []string) error {
+	normalized := models.NormalizeTags(tags)
+	if len(normalized) > maxTags {
+		return fmt.Errorf("too many tags (max %d)", maxTags)
+	}
+
+	for _, tag := range normalized {
+		if !tagRegex.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q (max 64 letters, digits and . _ : / -, starting with a letter or digit)", tag)
+		}
+	}
+
+	return nil
+}
+
+// ValidateExitCodes validates the exit code meanings declared by a script
+func ValidateExitCodes(codes models.ExitCodes) error {
+	for key, severity := range codes {
+		if _, _, err := models.ParseExitCodeRange(key); err != nil {
+			return err
+		}
+		if !slices.Contains(models.Severities, severity) {
+			return fmt.Errorf("invalid severity %q for exit code %s (supported: %s)", severity, key, strings.Join(models.Severities, ", "))
+		}
+	}
+
+	return nil
+}
+
+// ValidateSchedule validates the cron expression of a scheduled script preset
+// An enabled schedule needs an expression.
+func ValidateSchedule(schedule string, enabled bool) error {
+	if schedule == "" {
+		if enabled {
+			return fmt.Errorf("schedule_enabled requires a schedule")
+		}
+		return nil
+	}
+	if _, err := cron.Parse(schedule); err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	return nil
+}
+
+// awsRegionRegex validates an AWS region such as eu-west-1 or us-gov-east-1
+var awsRegionRegex = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]{1,2}$`)
+
+// ValidateAWSRegion validates an AWS region name
+func ValidateAWSRegion(region string) error {
+	if !awsRegionRegex.MatchString(region) {
+		return fmt.Errorf("invalid AWS region %q (e.g. eu-west-1)", region)
+	}
+	return nil
+}
+
+// ec2InstanceIDRegex validates an EC2 instance or SSM managed node ID
+var ec2InstanceIDRegex = regexp.MustCompile(`^(i|mi)-[0-9a-f]{8,17}$`)
+
+// ValidateEC2InstanceID validates the ID of an EC2 instance (i-...) or a managed node (mi-...)
+func ValidateEC2InstanceID(id string) error {
+	if !ec2InstanceIDRegex.MatchString(id) {
+		return fmt.Errorf("invalid instance ID %q (e.g. i-0abc123def4567890)", id)
+	}
+	return nil
+}
+
+// awsRoleARNRegex validates the ARN of an IAM role
+var awsRoleARNRegex = regexp.MustCompile(`^arn:aws[a-z-]*:iam::[0-9]{12}:role/[A-Za-z0-9+=,.@_/-]{1,512}$`)
+
+// ValidateAWSRoleARN validates the ARN of an IAM role
+func ValidateAWSRoleARN(arn string) error {
+	if !awsRoleARNRegex.MatchString(arn) {
+		return fmt.Errorf("invalid IAM role ARN %q (e.g. arn:aws:iam::123456789012:role/web-cli)", arn)
+	}
+	return nil
+}
+
+// hostKeyFingerprintRegex matches SHA256 fingerprints as printed by ssh-keygen -lf
+var hostKeyFingerprintRegex = regexp.MustCompile(`^SHA256:[A-Za-z0-9+/]{43}$`)
+
+// ValidateHostKeyFingerprint validates the SHA256 fingerprint of an SSH host key
+func ValidateHostKeyFingerprint(fingerprint string) error {
+	if !hostKeyFingerprintRegex.MatchString(fingerprint) {
+		return fmt.Errorf("invalid host key fingerprint %q (e.g. SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8, from ssh-keygen -lf)", fingerprint)
+	}
+	return nil
+}
+
 // ValidateCommand validates a command string for execution
 // This performs basic sanitization to prevent common attacks
 func ValidateCommand(command string) error {
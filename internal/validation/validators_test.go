@@ -2,6 +2,8 @@ package validation
 
 import (
 	"testing"
+
+	"github.com/pozgo/web-cli/internal/models"
 )
 
 func TestValidateVaultAddress(t *testing.T) {
@@ -292,6 +294,121 @@ func TestValidateVaultSecretName(t *testing.T) {
 	}
 }
 
+func TestValidateWorkdir(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr bool
+		errMsg  string
+	}{
+		{name: "empty", dir: "", wantErr: false},
+		{name: "absolute", dir: "/srv/app", wantErr: false},
+		{name: "dots in names", dir: "/srv/app..v2/.config", wantErr: false},
+		{name: "windows drive", dir: `C:\Users\deploy`, wantErr: false},
+		{name: "relative", dir: "srv/app", wantErr: true, errMsg: "absolute"},
+		{name: "traversal", dir: "/srv/app/../../etc", wantErr: true, errMsg: "path traversal"},
+		{name: "windows traversal", dir: `C:\app\..\Windows`, wantErr: true, errMsg: "path traversal"},
+		{name: "newline", dir: "/srv\nrm -rf /", wantErr: true, errMsg: "invalid characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWorkdir(tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWorkdir(%q) error = %v, wantErr %v", tt.dir, err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && tt.errMsg != "" && !contains(err.Error(), tt.errMsg) {
+				t.Errorf("ValidateWorkdir(%q) error = %v, want error containing %q", tt.dir, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateExitCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		codes   models.ExitCodes
+		wantErr bool
+		errMsg  string
+	}{
+		{name: "none", codes: nil, wantErr: false},
+		{name: "codes and ranges", codes: models.ExitCodes{"0": "ok", "1": "warning", "2-9": "critical", "10+": "warning"}, wantErr: false},
+		{name: "negative", codes: models.ExitCodes{"-1": "ok"}, wantErr: true, errMsg: "invalid exit code"},
+		{name: "too high", codes: models.ExitCodes{"256": "ok"}, wantErr: true, errMsg: "invalid exit code"},
+		{name: "reversed range", codes: models.ExitCodes{"9-2": "ok"}, wantErr: true, errMsg: "invalid exit code"},
+		{name: "signed", codes: models.ExitCodes{"+3": "ok"}, wantErr: true, errMsg: "invalid exit code"},
+		{name: "unknown severity", codes: models.ExitCodes{"1": "info"}, wantErr: true, errMsg: "invalid severity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExitCodes(tt.codes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateExitCodes(%v) error = %v, wantErr %v", tt.codes, err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && tt.errMsg != "" && !contains(err.Error(), tt.errMsg) {
+				t.Errorf("ValidateExitCodes(%v) error = %v, want error containing %q", tt.codes, err, tt.errMsg)
+			}
+		})
+	}
+
+	// The narrowest declaration wins; undeclared codes are ok only when 0
+	codes := models.ExitCodes{"1": "warning", "2+": "critical", "3-4": "ok"}
+	for code, want := range map[int]string{0: "ok", 1: "warning", 2: "critical", 3: "ok", 200: "critical", -1: "critical"} {
+		if got := codes.Severity(code); got != want {
+			t.Errorf("Severity(%d) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestValidateAWSSettings(t *testing.T) {
+	for _, region := range []string{"eu-west-1", "us-east-2", "us-gov-east-1", "ap-southeast-3"} {
+		if err := ValidateAWSRegion(region); err != nil {
+			t.Errorf("ValidateAWSRegion(%q) error = %v", region, err)
+		}
+	}
+	for _, region := range []string{"", "eu-west", "EU-WEST-1", "eu west 1", "https://eu-west-1"} {
+		if err := ValidateAWSRegion(region); err == nil {
+			t.Errorf("ValidateAWSRegion(%q) succeeded, want an error", region)
+		}
+	}
+
+	for _, id := range []string{"i-0abc123d", "i-0abc123def4567890", "mi-0123456789abcdef0"} {
+		if err := ValidateEC2InstanceID(id); err != nil {
+			t.Errorf("ValidateEC2InstanceID(%q) error = %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "i-123", "i-0ABC123DEF", "ami-0abc123def", "i-0abc123d; reboot"} {
+		if err := ValidateEC2InstanceID(id); err == nil {
+			t.Errorf("ValidateEC2InstanceID(%q) succeeded, want an error", id)
+		}
+	}
+
+	for _, arn := range []string{"arn:aws:iam::123456789012:role/web-cli", "arn:aws-us-gov:iam::123456789012:role/ops/web-cli"} {
+		if err := ValidateAWSRoleARN(arn); err != nil {
+			t.Errorf("ValidateAWSRoleARN(%q) error = %v", arn, err)
+		}
+	}
+	for _, arn := range []string{"", "web-cli", "arn:aws:iam::123:role/web-cli", "arn:aws:iam::123456789012:user/web-cli"} {
+		if err := ValidateAWSRoleARN(arn); err == nil {
+			t.Errorf("ValidateAWSRoleARN(%q) succeeded, want an error", arn)
+		}
+	}
+}
+
+func TestValidateHostKeyFingerprint(t *testing.T) {
+	if err := ValidateHostKeyFingerprint("SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"); err != nil {
+		t.Errorf("ValidateHostKeyFingerprint error = %v", err)
+	}
+	for _, fingerprint := range []string{"", "nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8", "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8=", "MD5:16:27:ac:a5:76:28:2d:36:63:1b:56:4d:eb:df:a6:48"} {
+		if err := ValidateHostKeyFingerprint(fingerprint); err == nil {
+			t.Errorf("ValidateHostKeyFingerprint(%q) succeeded, want an error", fingerprint)
+		}
+	}
+}
+
 // contains checks if substr is contained in s
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
@@ -306,3 +423,23 @@ func findSubstr(s, substr string) bool {
 	}
 	return false
 }
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		enabled  bool
+		wantErr  bool
+	}{
+		{"", false, false},
+		{"", true, true},
+		{"0 2 * * *", true, false},
+		{"@daily", false, false},
+		{"0 2 * *", true, true},
+		{"61 * * * *", false, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSchedule(tt.schedule, tt.enabled); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSchedule(%q, %v) error = %v, wantErr %v", tt.schedule, tt.enabled, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultListCacheTTL is how long the client reuses the result of listing a
+// secret type across all groups
+// Listing reads every secret of the type, so the cache saves a round-trip per
+// secret for pages that list the same type several times; writes and deletes
+// through the client drop the type's cached list at once, changes made outside
+// this client show up once the entry expires
+const DefaultListCacheTTL = 15 * time.Second
+
+// listCache holds recent list results per secret type
+type listCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]listCacheEntry
+	// generation counts invalidations per secret type, so a list fetched while
+	// a write was in flight is not cached
+	generation map[string]uint64
+}
+
+type listCacheEntry struct {
+	items   any
+	expires time.Time
+}
+
+func newListCache(ttl time.Duration) *listCache {
+	return &listCache{
+		ttl:        ttl,
+		entries:    make(map[string]listCacheEntry),
+		generation: make(map[string]uint64),
+	}
+}
+
+// get returns the cached list of secretType and the generation a new list must be stored with
+func (l *listCache) get(secretType string) (any, uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[secretType]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, l.generation[secretType], false
+	}
+	return entry.items, l.generation[secretType], true
+}
+
+// put caches items unless secretType was invalidated since generation was read
+func (l *listCache) put(secretType string, generation uint64, items any) {
+	if l.ttl <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.generation[secretType] != generation {
+		return
+	}
+	l.entries[secretType] = listCacheEntry{items: items, expires: time.Now().Add(l.ttl)}
+}
+
+// invalidate drops the cached list of secretType
+func (l *listCache) invalidate(secretType string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, secretType)
+	l.generation[secretType]++
+}
+
+// InvalidateListCache drops all cached lists, for callers that must see
+// changes made outside this client
+func (c *Client) InvalidateListCache() {
+	c.lists.mu.Lock()
+	defer c.lists.mu.Unlock()
+	for secretType := range c.lists.entries {
+		delete(c.lists.entries, secretType)
+	}
+	for secretType := range c.lists.generation {
+		c.lists.generation[secretType]++
+	}
+}
+
+// cachedList returns the cached list of secretType, calling list if there is none
+// Callers get their own copy of the slice
+func cachedList[T any](c *Client, secretType string, list func() ([]T, error)) ([]T, error) {
+	cached, generation, ok := c.lists.get(secretType)
+	if ok {
+		return slices.Clone(cached.([]T)), nil
+	}
+
+	items, err := list()
+	if err != nil {
+		return nil, err
+	}
+	c.lists.put(secretType, generation, items)
+	return slices.Clone(items), nil
+}
@@ -0,0 +1,110 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeKV is a KV v2 engine at "secret" holding servers in one group
+type fakeKV struct {
+	mu      sync.Mutex
+	servers map[string]map[string]interface{} // By name
+	reads   int
+}
+
+func (f *fakeKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/v1/secret/metadata/servers" && r.URL.Query().Get("list") == "true":
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": []string{"prod/"}}})
+	case r.URL.Path == "/v1/secret/metadata/servers/prod" && r.URL.Query().Get("list") == "true":
+		keys := make([]string, 0, len(f.servers))
+		for name := range f.servers {
+			keys = append(keys, name)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/data/servers/prod/"):
+		name := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/servers/prod/")
+		if r.Method == http.MethodGet {
+			f.reads++
+			data, ok := f.servers[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+			return
+		}
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.servers[name] = body.Data
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeKV) readCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reads
+}
+
+func TestListCache(t *testing.T) {
+	fake := &fakeKV{servers: map[string]map[string]interface{}{
+		"web1": {"name": "web1", "ip_address": "10.0.0.1", "port": 22, "username": "root", "group": "prod"},
+	}}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client, err := NewClient(&Config{Address: ts.URL, Token: "test-token"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	servers, err := client.ListServers(ctx)
+	if err != nil || len(servers) != 1 {
+		t.Fatalf("ListServers() = %v, %v; want one server", servers, err)
+	}
+	// Changing the returned slice must not change the cached list
+	servers[0].Name = "changed"
+
+	servers, err = client.ListServers(ctx)
+	if err != nil || len(servers) != 1 || servers[0].Name != "web1" {
+		t.Fatalf("ListServers() = %v, %v; want the cached web1", servers, err)
+	}
+	if fake.readCount() != 1 {
+		t.Errorf("Expected the second list to be served from the cache, got %d reads", fake.readCount())
+	}
+
+	// A write through the client drops the cached list
+	if err := client.SaveServer(ctx, &Server{Name: "web2", IPAddress: "10.0.0.2", Port: 22, Username: "root", Group: "prod"}); err != nil {
+		t.Fatalf("SaveServer() error = %v", err)
+	}
+	servers, err = client.ListServers(ctx)
+	if err != nil || len(servers) != 2 {
+		t.Fatalf("ListServers() after save = %v, %v; want two servers", servers, err)
+	}
+
+	// Changes made elsewhere show up once the cache is invalidated
+	fake.mu.Lock()
+	delete(fake.servers, "web1")
+	fake.mu.Unlock()
+	if servers, _ := client.ListServers(ctx); len(servers) != 2 {
+		t.Errorf("Expected the cached list before invalidation, got %v", servers)
+	}
+	client.InvalidateListCache()
+	if servers, _ := client.ListServers(ctx); len(servers) != 1 {
+		t.Errorf("Expected one server after invalidation, got %v", servers)
+	}
+}
@@ -3,25 +3,64 @@ package vault
 import (
 	"context"
 	"fmt"
+	"log"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/pozgo/web-cli/internal/validation"
 )
 
+// Auth methods the client can log in with
+const (
+	AuthMethodToken      = "token"      // A static token
+	AuthMethodAppRole    = "approle"    // AppRole role_id and secret_id
+	AuthMethodKubernetes = "kubernetes" // The pod's service account token
+)
+
+// Paths the auth methods are enabled at by default
+const (
+	DefaultAppRoleMount    = "approle"
+	DefaultKubernetesMount = "kubernetes"
+)
+
+// DefaultJWTPath is where Kubernetes mounts the pod's service account token
+const DefaultJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
 // Client wraps the Vault API client with convenience methods
 type Client struct {
 	client    *api.Client
 	mountPath string
+
+	// login obtains a token for auth methods other than static tokens
+	login     func(ctx context.Context) (*api.Secret, error)
+	loginMu   sync.Mutex
+	loggedIn  bool
+	reloginAt time.Time // Zero when the token does not expire
+
+	// Background renewal, see StartRenewal
+	renewCtx    context.Context
+	stopRenewal context.CancelFunc
+	stopWatch   context.CancelFunc // Stops the watcher of the current login token
+
+	lists *listCache // Recent results of the List* methods, see DefaultListCacheTTL
 }
 
 // Config holds the configuration for connecting to Vault
 type Config struct {
-	Address   string `json:"address"`
-	Token     string `json:"token"`
-	Namespace string `json:"namespace,omitempty"`
-	MountPath string `json:"mount_path"`
+	Address    string `json:"address"`
+	Token      string `json:"token"`
+	Namespace  string `json:"namespace,omitempty"`
+	MountPath  string `json:"mount_path"`
+	AuthMethod string `json:"auth_method,omitempty"` // AuthMethodToken (default), AuthMethodAppRole or AuthMethodKubernetes
+	RoleID     string `json:"role_id,omitempty"`     // AppRole role ID
+	SecretID   string `json:"secret_id,omitempty"`   // AppRole secret ID
+	AuthMount  string `json:"auth_mount,omitempty"`  // Path the auth method is enabled at (default: the method's name)
+
+	KubernetesRole string `json:"kubernetes_role,omitempty"` // Vault role bound to the service account
+	JWTPath        string `json:"jwt_path,omitempty"`        // Service account token file (default: DefaultJWTPath)
 }
 
 // NewClient creates a new Vault client with the given configuration
@@ -39,8 +78,21 @@ func NewClient(cfg *Config) (*Client, error) {
 		return nil, fmt.Errorf("invalid vault address: %w", err)
 	}
 
-	if cfg.Token == "" {
-		return nil, fmt.Errorf("vault token is required")
+	switch cfg.AuthMethod {
+	case "", AuthMethodToken:
+		if cfg.Token == "" {
+			return nil, fmt.Errorf("vault token is required")
+		}
+	case AuthMethodAppRole:
+		if cfg.RoleID == "" || cfg.SecretID == "" {
+			return nil, fmt.Errorf("vault role_id and secret_id are required")
+		}
+	case AuthMethodKubernetes:
+		if cfg.KubernetesRole == "" {
+			return nil, fmt.Errorf("vault kubernetes_role is required")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported vault auth method: %s", cfg.AuthMethod)
 	}
 
 	vaultCfg := api.DefaultConfig()
@@ -51,7 +103,11 @@ func NewClient(cfg *Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to create vault client: %w", err)
 	}
 
-	client.SetToken(cfg.Token)
+	// api.NewClient picks up VAULT_TOKEN; only the configured credentials are used
+	client.ClearToken()
+	if cfg.Token != "" && (cfg.AuthMethod == "" || cfg.AuthMethod == AuthMethodToken) {
+		client.SetToken(cfg.Token)
+	}
 
 	if cfg.Namespace != "" {
 		client.SetNamespace(cfg.Namespace)
@@ -62,14 +118,201 @@ func NewClient(cfg *Config) (*Client, error) {
 		mountPath = "secret"
 	}
 
-	return &Client{
+	c := &Client{
 		client:    client,
 		mountPath: mountPath,
-	}, nil
+		lists:     newListCache(DefaultListCacheTTL),
+	}
+	switch cfg.AuthMethod {
+	case AuthMethodAppRole:
+		c.login = loginWith(client, cfg.AuthMount, DefaultAppRoleMount, func() (map[string]interface{}, error) {
+			return map[string]interface{}{
+				"role_id":   cfg.RoleID,
+				"secret_id": cfg.SecretID,
+			}, nil
+		})
+	case AuthMethodKubernetes:
+		jwtPath := cfg.JWTPath
+		if jwtPath == "" {
+			jwtPath = DefaultJWTPath
+		}
+		c.login = loginWith(client, cfg.AuthMount, DefaultKubernetesMount, func() (map[string]interface{}, error) {
+			// Read the token on every login; Kubernetes rotates projected tokens
+			jwt, err := os.ReadFile(jwtPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read service account token: %w", err)
+			}
+			return map[string]interface{}{
+				"role": cfg.KubernetesRole,
+				"jwt":  strings.TrimSpace(string(jwt)),
+			}, nil
+		})
+	}
+	return c, nil
+}
+
+// loginWith returns a login function that writes the credentials from data to
+// the login endpoint of the auth method enabled at authMount
+func loginWith(client *api.Client, authMount, defaultMount string, data func() (map[string]interface{}, error)) func(ctx context.Context) (*api.Secret, error) {
+	authMount = strings.Trim(authMount, "/")
+	if authMount == "" {
+		authMount = defaultMount
+	}
+	return func(ctx context.Context) (*api.Secret, error) {
+		credentials, err := data()
+		if err != nil {
+			return nil, err
+		}
+		// Log in without the expired token, which Vault would reject
+		loginClient, err := client.Clone()
+		if err != nil {
+			return nil, err
+		}
+		loginClient.ClearToken()
+		return loginClient.Logical().WriteWithContext(ctx, "auth/"+authMount+"/login", credentials)
+	}
+}
+
+// ensureToken logs in for auth methods other than static tokens
+// The first request logs in; later ones log in again once three quarters of the
+// token's TTL has passed, so an expiring token is replaced before Vault rejects it
+func (c *Client) ensureToken(ctx context.Context) error {
+	if c.login == nil {
+		return nil
+	}
+
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.loggedIn && (c.reloginAt.IsZero() || time.Now().Before(c.reloginAt)) {
+		return nil
+	}
+
+	secret, err := c.login(ctx)
+	if err != nil {
+		return fmt.Errorf("vault login failed: %w", err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("vault login failed: no token returned")
+	}
+
+	c.client.SetToken(secret.Auth.ClientToken)
+	c.loggedIn = true
+	c.setReloginAt(secret.Auth.LeaseDuration)
+
+	if c.renewCtx != nil {
+		if c.stopWatch != nil {
+			c.stopWatch()
+		}
+		var watchCtx context.Context
+		watchCtx, c.stopWatch = context.WithCancel(c.renewCtx)
+		go c.watch(watchCtx, secret)
+	}
+	return nil
+}
+
+// setReloginAt schedules the next login for a token with the given lease duration in seconds
+// The caller must hold loginMu
+func (c *Client) setReloginAt(leaseDuration int) {
+	c.reloginAt = time.Time{}
+	if ttl := time.Duration(leaseDuration) * time.Second; ttl > 0 {
+		c.reloginAt = time.Now().Add(ttl * 3 / 4)
+	}
+}
+
+// StartRenewal renews the client's token in the background until Close is called
+// Call it before the client is first used. Tokens from a login are watched as they
+// are obtained; a static token is looked up first and only watched when it is
+// renewable and has a TTL, so root and other non-expiring tokens are left alone
+func (c *Client) StartRenewal() {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.renewCtx != nil {
+		return
+	}
+	c.renewCtx, c.stopRenewal = context.WithCancel(context.Background())
+	if c.login == nil {
+		go c.watchStaticToken(c.renewCtx)
+	}
+}
+
+// Close stops background token renewal
+func (c *Client) Close() {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.stopRenewal != nil {
+		c.stopRenewal()
+	}
+}
+
+// watchStaticToken renews the configured token if it is renewable
+func (c *Client) watchStaticToken(ctx context.Context) {
+	self, err := c.client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Warning: Failed to look up Vault token for renewal: %v", err)
+		}
+		return
+	}
+	renewable, _ := self.TokenIsRenewable()
+	ttl, _ := self.TokenTTL()
+	if !renewable || ttl <= 0 {
+		return
+	}
+
+	c.watch(ctx, &api.Secret{Auth: &api.SecretAuth{
+		ClientToken:   c.client.Token(),
+		Renewable:     true,
+		LeaseDuration: int(ttl.Seconds()),
+	}})
+}
+
+// watch renews the token of secret with a LifetimeWatcher until ctx is done or
+// the token can no longer be renewed, e.g. once it reaches its max TTL
+// A login token that stops renewing is replaced by a new login on the next request
+func (c *Client) watch(ctx context.Context, secret *api.Secret) {
+	watcher, err := c.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		log.Printf("Warning: Failed to start Vault token renewal: %v", err)
+		return
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case renewal := <-watcher.RenewCh():
+			if c.login != nil && renewal.Secret != nil && renewal.Secret.Auth != nil {
+				c.loginMu.Lock()
+				c.setReloginAt(renewal.Secret.Auth.LeaseDuration)
+				c.loginMu.Unlock()
+			}
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				log.Printf("Warning: Vault token renewal stopped: %v", err)
+			}
+			if c.login != nil {
+				c.loginMu.Lock()
+				if c.client.Token() == secret.Auth.ClientToken {
+					c.loggedIn = false
+				}
+				c.loginMu.Unlock()
+			}
+			return
+		}
+	}
 }
 
 // TestConnection verifies the Vault connection and token validity
 func (c *Client) TestConnection(ctx context.Context) error {
+	if err := c.ensureToken(ctx); err != nil {
+		return err
+	}
+
 	// Try to look up the token to verify it's valid
 	_, err := c.client.Auth().Token().LookupSelfWithContext(ctx)
 	if err != nil {
@@ -183,6 +426,9 @@ func (c *Client) ReadSecret(ctx context.Context, secretType, group, name string)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.ensureToken(ctx); err != nil {
+		return nil, err
+	}
 
 	secret, err := c.client.Logical().ReadWithContext(ctx, path)
 	if err != nil {
@@ -208,6 +454,9 @@ func (c *Client) ReadSecretFlat(ctx context.Context, secretType, name string) (m
 	if err != nil {
 		return nil, err
 	}
+	if err := c.ensureToken(ctx); err != nil {
+		return nil, err
+	}
 
 	secret, err := c.client.Logical().ReadWithContext(ctx, path)
 	if err != nil {
@@ -233,6 +482,9 @@ func (c *Client) WriteSecret(ctx context.Context, secretType, group, name string
 	if err != nil {
 		return err
 	}
+	if err := c.ensureToken(ctx); err != nil {
+		return err
+	}
 
 	// KV v2 requires data to be wrapped
 	wrappedData := map[string]interface{}{
@@ -240,6 +492,7 @@ func (c *Client) WriteSecret(ctx context.Context, secretType, group, name string
 	}
 
 	_, err = c.client.Logical().WriteWithContext(ctx, path, wrappedData)
+	c.lists.invalidate(secretType)
 	if err != nil {
 		return fmt.Errorf("failed to write secret %s/%s/%s: %w", secretType, group, name, err)
 	}
@@ -253,6 +506,9 @@ func (c *Client) WriteSecretFlat(ctx context.Context, secretType, name string, d
 	if err != nil {
 		return err
 	}
+	if err := c.ensureToken(ctx); err != nil {
+		return err
+	}
 
 	// KV v2 requires data to be wrapped
 	wrappedData := map[string]interface{}{
@@ -260,6 +516,7 @@ func (c *Client) WriteSecretFlat(ctx context.Context, secretType, name string, d
 	}
 
 	_, err = c.client.Logical().WriteWithContext(ctx, path, wrappedData)
+	c.lists.invalidate(secretType)
 	if err != nil {
 		return fmt.Errorf("failed to write secret %s/%s: %w", secretType, name, err)
 	}
@@ -284,10 +541,15 @@ func (c *Client) DeleteSecret(ctx context.Context, secretType, group, name strin
 		return fmt.Errorf("invalid secret name: %w", err)
 	}
 
+	if err := c.ensureToken(ctx); err != nil {
+		return err
+	}
+
 	// For KV v2, we delete the metadata to permanently remove
 	metaPath := fmt.Sprintf("%s/metadata/%s/%s/%s", c.mountPath, secretType, group, name)
 
 	_, err := c.client.Logical().DeleteWithContext(ctx, metaPath)
+	c.lists.invalidate(secretType)
 	if err != nil {
 		return fmt.Errorf("failed to delete secret %s/%s/%s: %w", secretType, group, name, err)
 	}
@@ -305,10 +567,15 @@ func (c *Client) DeleteSecretFlat(ctx context.Context, secretType, name string)
 		return fmt.Errorf("invalid secret name: %w", err)
 	}
 
+	if err := c.ensureToken(ctx); err != nil {
+		return err
+	}
+
 	// For KV v2, we delete the metadata to permanently remove
 	metaPath := fmt.Sprintf("%s/metadata/%s/%s", c.mountPath, secretType, name)
 
 	_, err := c.client.Logical().DeleteWithContext(ctx, metaPath)
+	c.lists.invalidate(secretType)
 	if err != nil {
 		return fmt.Errorf("failed to delete secret %s/%s: %w", secretType, name, err)
 	}
@@ -322,6 +589,9 @@ func (c *Client) ListSecrets(ctx context.Context, secretType, group string) ([]s
 	if err != nil {
 		return nil, err
 	}
+	if err := c.ensureToken(ctx); err != nil {
+		return nil, err
+	}
 
 	secret, err := c.client.Logical().ListWithContext(ctx, path)
 	if err != nil {
@@ -363,6 +633,9 @@ func (c *Client) ListGroups(ctx context.Context, secretType string) ([]string, e
 	if err != nil {
 		return nil, err
 	}
+	if err := c.ensureToken(ctx); err != nil {
+		return nil, err
+	}
 
 	secret, err := c.client.Logical().ListWithContext(ctx, path)
 	if err != nil {
@@ -405,26 +678,28 @@ type SSHKey struct {
 
 // ListSSHKeys returns all SSH keys from Vault (across all groups)
 func (c *Client) ListSSHKeys(ctx context.Context) ([]SSHKey, error) {
-	groups, err := c.ListGroups(ctx, "ssh-keys")
-	if err != nil {
-		return nil, err
-	}
+	return cachedList(c, "ssh-keys", func() ([]SSHKey, error) {
+		groups, err := c.ListGroups(ctx, "ssh-keys")
+		if err != nil {
+			return nil, err
+		}
 
-	// If no groups exist, check default group
-	if len(groups) == 0 {
-		groups = []string{"default"}
-	}
+		// If no groups exist, check default group
+		if len(groups) == 0 {
+			groups = []string{"default"}
+		}
 
-	var keys []SSHKey
-	for _, group := range groups {
-		groupKeys, err := c.ListSSHKeysByGroup(ctx, group)
-		if err != nil {
-			continue
+		var keys []SSHKey
+		for _, group := range groups {
+			groupKeys, err := c.ListSSHKeysByGroup(ctx, group)
+			if err != nil {
+				continue
+			}
+			keys = append(keys, groupKeys...)
 		}
-		keys = append(keys, groupKeys...)
-	}
 
-	return keys, nil
+		return keys, nil
+	})
 }
 
 // ListSSHKeysByGroup returns all SSH keys in a specific group
@@ -521,26 +796,28 @@ type Server struct {
 
 // ListServers returns all servers from Vault (across all groups)
 func (c *Client) ListServers(ctx context.Context) ([]Server, error) {
-	groups, err := c.ListGroups(ctx, "servers")
-	if err != nil {
-		return nil, err
-	}
+	return cachedList(c, "servers", func() ([]Server, error) {
+		groups, err := c.ListGroups(ctx, "servers")
+		if err != nil {
+			return nil, err
+		}
 
-	// If no groups exist, check default group
-	if len(groups) == 0 {
-		groups = []string{"default"}
-	}
+		// If no groups exist, check default group
+		if len(groups) == 0 {
+			groups = []string{"default"}
+		}
 
-	var servers []Server
-	for _, group := range groups {
-		groupServers, err := c.ListServersByGroup(ctx, group)
-		if err != nil {
-			continue
+		var servers []Server
+		for _, group := range groups {
+			groupServers, err := c.ListServersByGroup(ctx, group)
+			if err != nil {
+				continue
+			}
+			servers = append(servers, groupServers...)
 		}
-		servers = append(servers, groupServers...)
-	}
 
-	return servers, nil
+		return servers, nil
+	})
 }
 
 // ListServersByGroup returns all servers in a specific group
@@ -651,26 +928,28 @@ type EnvVariable struct {
 
 // ListEnvVariables returns all environment variables from Vault (across all groups)
 func (c *Client) ListEnvVariables(ctx context.Context) ([]EnvVariable, error) {
-	groups, err := c.ListGroups(ctx, "env")
-	if err != nil {
-		return nil, err
-	}
+	return cachedList(c, "env", func() ([]EnvVariable, error) {
+		groups, err := c.ListGroups(ctx, "env")
+		if err != nil {
+			return nil, err
+		}
 
-	// If no groups exist, check default group
-	if len(groups) == 0 {
-		groups = []string{"default"}
-	}
+		// If no groups exist, check default group
+		if len(groups) == 0 {
+			groups = []string{"default"}
+		}
 
-	var vars []EnvVariable
-	for _, group := range groups {
-		groupVars, err := c.ListEnvVariablesByGroup(ctx, group)
-		if err != nil {
-			continue
+		var vars []EnvVariable
+		for _, group := range groups {
+			groupVars, err := c.ListEnvVariablesByGroup(ctx, group)
+			if err != nil {
+				continue
+			}
+			vars = append(vars, groupVars...)
 		}
-		vars = append(vars, groupVars...)
-	}
 
-	return vars, nil
+		return vars, nil
+	})
 }
 
 // ListEnvVariablesByGroup returns all environment variables in a specific group
@@ -752,30 +1031,33 @@ type BashScript struct {
 	Content     string `json:"content"`
 	Filename    string `json:"filename"`
 	Group       string `json:"group"`
+	Interpreter string `json:"interpreter,omitempty"`
 }
 
 // ListBashScripts returns all bash scripts from Vault (across all groups)
 func (c *Client) ListBashScripts(ctx context.Context) ([]BashScript, error) {
-	groups, err := c.ListGroups(ctx, "scripts")
-	if err != nil {
-		return nil, err
-	}
+	return cachedList(c, "scripts", func() ([]BashScript, error) {
+		groups, err := c.ListGroups(ctx, "scripts")
+		if err != nil {
+			return nil, err
+		}
 
-	// If no groups exist, check default group
-	if len(groups) == 0 {
-		groups = []string{"default"}
-	}
+		// If no groups exist, check default group
+		if len(groups) == 0 {
+			groups = []string{"default"}
+		}
 
-	var scripts []BashScript
-	for _, group := range groups {
-		groupScripts, err := c.ListBashScriptsByGroup(ctx, group)
-		if err != nil {
-			continue
+		var scripts []BashScript
+		for _, group := range groups {
+			groupScripts, err := c.ListBashScriptsByGroup(ctx, group)
+			if err != nil {
+				continue
+			}
+			scripts = append(scripts, groupScripts...)
 		}
-		scripts = append(scripts, groupScripts...)
-	}
 
-	return scripts, nil
+		return scripts, nil
+	})
 }
 
 // ListBashScriptsByGroup returns all bash scripts in a specific group
@@ -826,6 +1108,10 @@ func (c *Client) GetBashScript(ctx context.Context, group, name string) (*BashSc
 		s.Filename = fn
 	}
 
+	if interp, ok := data["interpreter"].(string); ok {
+		s.Interpreter = interp
+	}
+
 	return s, nil
 }
 
@@ -839,6 +1125,9 @@ func (c *Client) SaveBashScript(ctx context.Context, s *BashScript) error {
 		"description": s.Description,
 		"filename":    s.Filename,
 	}
+	if s.Interpreter != "" {
+		data["interpreter"] = s.Interpreter
+	}
 	return c.WriteSecret(ctx, "scripts", s.Group, s.Name, data)
 }
 
@@ -0,0 +1,82 @@
+package vault
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/pozgo/web-cli/internal/validation"
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultSSHMount is the path the SSH secrets engine is enabled at by default
+const DefaultSSHMount = "ssh-client-signer"
+
+// SSHCertificate is a freshly generated key pair whose public key was signed by Vault's SSH CA
+type SSHCertificate struct {
+	PrivateKey  string // OpenSSH PEM-encoded private key, never stored
+	Certificate string // Signed certificate in authorized_keys format
+}
+
+// IssueSSHCertificate generates an ed25519 key pair and has the SSH secrets engine at
+// mount sign it with role. principal may be empty to use the role's default user,
+// and ttl empty for the role's default TTL
+func (c *Client) IssueSSHCertificate(ctx context.Context, mount, role, principal, ttl string) (*SSHCertificate, error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		mount = DefaultSSHMount
+	}
+	if err := validation.ValidateVaultGroupName(mount); err != nil {
+		return nil, fmt.Errorf("invalid SSH mount: %w", err)
+	}
+	if err := validation.ValidateVaultSecretName(role); err != nil {
+		return nil, fmt.Errorf("invalid SSH role: %w", err)
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SSH key: %w", err)
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSH public key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "web-cli")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSH private key: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"public_key": string(ssh.MarshalAuthorizedKey(sshPublicKey)),
+		"cert_type":  "user",
+	}
+	if principal != "" {
+		data["valid_principals"] = principal
+	}
+	if ttl != "" {
+		data["ttl"] = ttl
+	}
+
+	if err := c.ensureToken(ctx); err != nil {
+		return nil, err
+	}
+	secret, err := c.client.Logical().WriteWithContext(ctx, mount+"/sign/"+role, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign SSH key: %w", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("failed to sign SSH key: no certificate returned")
+	}
+	signed, _ := secret.Data["signed_key"].(string)
+	if signed == "" {
+		return nil, fmt.Errorf("failed to sign SSH key: no certificate returned")
+	}
+
+	return &SSHCertificate{
+		PrivateKey:  string(pem.EncodeToMemory(block)),
+		Certificate: strings.TrimSpace(signed) + "\n",
+	}, nil
+}
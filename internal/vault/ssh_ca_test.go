@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestIssueSSHCertificate(t *testing.T) {
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	ca, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA signer: %v", err)
+	}
+
+	var requested map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/ssh-client-signer/sign/deployers" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":["no handler for route"]}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&requested)
+
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(requested["public_key"]))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		cert := &ssh.Certificate{
+			Key:             pub,
+			CertType:        ssh.UserCert,
+			ValidPrincipals: []string{requested["valid_principals"]},
+			ValidBefore:     ssh.CertTimeInfinity,
+		}
+		if err := cert.SignCert(rand.Reader, ca); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		signed := string(ssh.MarshalAuthorizedKey(cert))
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"signed_key": signed}})
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(&Config{Address: ts.URL, Token: "test-token"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	issued, err := client.IssueSSHCertificate(context.Background(), "", "deployers", "deploy", "30m")
+	if err != nil {
+		t.Fatalf("IssueSSHCertificate() error = %v", err)
+	}
+	if requested["cert_type"] != "user" || requested["ttl"] != "30m" {
+		t.Errorf("Unexpected sign request: %v", requested)
+	}
+
+	// The certificate belongs to the returned key
+	signer, err := ssh.ParsePrivateKey([]byte(issued.PrivateKey))
+	if err != nil {
+		t.Fatalf("Failed to parse issued private key: %v", err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(issued.Certificate))
+	if err != nil {
+		t.Fatalf("Failed to parse issued certificate: %v", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		t.Fatal("Expected a certificate")
+	}
+	if string(cert.Key.Marshal()) != string(signer.PublicKey().Marshal()) {
+		t.Error("Certificate was issued for a different key")
+	}
+	if len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "deploy" {
+		t.Errorf("Unexpected principals: %v", cert.ValidPrincipals)
+	}
+
+	if _, err := client.IssueSSHCertificate(context.Background(), "", "bad/role", "deploy", ""); err == nil {
+		t.Error("Expected error for an invalid role")
+	}
+}
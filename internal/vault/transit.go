@@ -0,0 +1,271 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pozgo/web-cli/internal/validation"
+)
+
+// transitPrefix starts every ciphertext produced by the transit engine, e.g. "vault:v1:..."
+const transitPrefix = "vault:v"
+
+// TransitConfig holds the settings for encrypting with Vault's transit engine
+type TransitConfig struct {
+	Address   string
+	Token     string
+	Namespace string
+	Mount     string        // Path the transit engine is enabled at (default: "transit")
+	Key       string        // Name of the transit key (default: "web-cli")
+	CacheSize int           // Decrypted values kept in memory (0 disables the cache)
+	CacheTTL  time.Duration // How long a decrypted value is kept
+	Timeout   time.Duration // Timeout of each transit request (default: 30s)
+}
+
+// Transit encrypts and decrypts values with a transit key, so the key material
+// stays in Vault. It implements database.Encryptor
+type Transit struct {
+	client  *Client
+	mount   string
+	key     string
+	timeout time.Duration
+	cache   *transitCache
+}
+
+// NewTransit creates a transit encryptor and renews its token in the background
+func NewTransit(cfg *TransitConfig) (*Transit, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("transit config is nil")
+	}
+
+	mount := strings.Trim(cfg.Mount, "/")
+	if mount == "" {
+		mount = "transit"
+	}
+	key := cfg.Key
+	if key == "" {
+		key = "web-cli"
+	}
+	if err := validation.ValidateVaultGroupName(mount); err != nil {
+		return nil, fmt.Errorf("invalid transit mount: %w", err)
+	}
+	if err := validation.ValidateVaultSecretName(key); err != nil {
+		return nil, fmt.Errorf("invalid transit key: %w", err)
+	}
+
+	client, err := NewClient(&Config{Address: cfg.Address, Token: cfg.Token, Namespace: cfg.Namespace})
+	if err != nil {
+		return nil, err
+	}
+	client.StartRenewal()
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	t := &Transit{client: client, mount: mount, key: key, timeout: timeout}
+	if cfg.CacheSize > 0 {
+		t.cache = newTransitCache(cfg.CacheSize, cfg.CacheTTL)
+	}
+	return t, nil
+}
+
+// Check verifies the key can be used by encrypting and decrypting a test value
+func (t *Transit) Check() error {
+	ciphertexts, err := t.EncryptBatch([]string{"web-cli"})
+	if err != nil {
+		return err
+	}
+	if _, err := t.decrypt(ciphertexts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Close stops token renewal
+func (t *Transit) Close() {
+	t.client.Close()
+}
+
+// Handles reports whether ciphertext was produced by the transit engine
+func (t *Transit) Handles(ciphertext []byte) bool {
+	return bytes.HasPrefix(ciphertext, []byte(transitPrefix))
+}
+
+// EncryptBatch encrypts plaintexts in a single transit request
+func (t *Transit) EncryptBatch(plaintexts []string) ([][]byte, error) {
+	batch := make([]interface{}, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		batch[i] = map[string]interface{}{"plaintext": base64.StdEncoding.EncodeToString([]byte(plaintext))}
+	}
+
+	results, err := t.batch("encrypt", batch)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertexts := make([][]byte, len(results))
+	for i, result := range results {
+		ciphertext, _ := result["ciphertext"].(string)
+		if !strings.HasPrefix(ciphertext, transitPrefix) {
+			return nil, fmt.Errorf("transit encrypt returned no ciphertext for item %d", i)
+		}
+		ciphertexts[i] = []byte(ciphertext)
+		t.cache.put(ciphertext, plaintexts[i])
+	}
+	return ciphertexts, nil
+}
+
+// DecryptBatch decrypts ciphertexts, sending the ones not in the cache in a single transit request
+func (t *Transit) DecryptBatch(ciphertexts [][]byte) ([]string, error) {
+	plaintexts := make([]string, len(ciphertexts))
+	var missing [][]byte
+	var missingIndexes []int
+	for i, ciphertext := range ciphertexts {
+		if plaintext, ok := t.cache.get(string(ciphertext)); ok {
+			plaintexts[i] = plaintext
+			continue
+		}
+		missing = append(missing, ciphertext)
+		missingIndexes = append(missingIndexes, i)
+	}
+	if len(missing) == 0 {
+		return plaintexts, nil
+	}
+
+	decrypted, err := t.decrypt(missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missingIndexes {
+		plaintexts[i] = decrypted[j]
+		t.cache.put(string(missing[j]), decrypted[j])
+	}
+	return plaintexts, nil
+}
+
+// decrypt decrypts ciphertexts in a single transit request, bypassing the cache
+func (t *Transit) decrypt(ciphertexts [][]byte) ([]string, error) {
+	batch := make([]interface{}, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		batch[i] = map[string]interface{}{"ciphertext": string(ciphertext)}
+	}
+
+	results, err := t.batch("decrypt", batch)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintexts := make([]string, len(results))
+	for i, result := range results {
+		encoded, _ := result["plaintext"].(string)
+		plaintext, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("transit decrypt returned invalid plaintext for item %d", i)
+		}
+		plaintexts[i] = string(plaintext)
+	}
+	return plaintexts, nil
+}
+
+// batch sends batch_input to the encrypt or decrypt endpoint of the key and returns
+// its batch_results, failing if any item failed
+func (t *Transit) batch(operation string, input []interface{}) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	if err := t.client.ensureToken(ctx); err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("%s/%s/%s", t.mount, operation, t.key)
+	secret, err := t.client.client.Logical().WriteWithContext(ctx, path, map[string]interface{}{"batch_input": input})
+	if err != nil {
+		return nil, fmt.Errorf("transit %s failed: %w", operation, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("transit %s returned no data", operation)
+	}
+
+	raw, _ := secret.Data["batch_results"].([]interface{})
+	if len(raw) != len(input) {
+		return nil, fmt.Errorf("transit %s returned %d results for %d items", operation, len(raw), len(input))
+	}
+	results := make([]map[string]interface{}, len(raw))
+	for i, item := range raw {
+		result, _ := item.(map[string]interface{})
+		if msg, _ := result["error"].(string); msg != "" {
+			return nil, fmt.Errorf("transit %s failed for item %d: %s", operation, i, msg)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// transitCache keeps recently used plaintexts by ciphertext, so repeated reads of
+// the same values do not each need a transit request
+type transitCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]transitCacheEntry
+}
+
+type transitCacheEntry struct {
+	plaintext string
+	expires   time.Time
+}
+
+func newTransitCache(size int, ttl time.Duration) *transitCache {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &transitCache{size: size, ttl: ttl, entries: make(map[string]transitCacheEntry)}
+}
+
+// get returns the cached plaintext of ciphertext; a nil cache never hits
+func (c *transitCache) get(ciphertext string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[ciphertext]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, ciphertext)
+		return "", false
+	}
+	return entry.plaintext, true
+}
+
+// put caches the plaintext of ciphertext, first dropping expired entries and,
+// if the cache is still full, all of them
+func (c *transitCache) put(ciphertext, plaintext string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.size {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.size {
+			clear(c.entries)
+		}
+	}
+	c.entries[ciphertext] = transitCacheEntry{plaintext: plaintext, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,106 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTransit is a transit engine whose "encryption" prefixes the base64 plaintext
+type fakeTransit struct {
+	mu       sync.Mutex
+	requests map[string]int // By operation
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "test-token" {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	operation := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/transit/"), "/web-cli")
+	f.mu.Lock()
+	f.requests[operation]++
+	f.mu.Unlock()
+
+	var body struct {
+		BatchInput []map[string]string `json:"batch_input"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	results := make([]map[string]string, len(body.BatchInput))
+	for i, item := range body.BatchInput {
+		switch operation {
+		case "encrypt":
+			results[i] = map[string]string{"ciphertext": "vault:v1:" + item["plaintext"]}
+		case "decrypt":
+			plaintext, ok := strings.CutPrefix(item["ciphertext"], "vault:v1:")
+			if !ok {
+				results[i] = map[string]string{"error": "invalid ciphertext"}
+				continue
+			}
+			results[i] = map[string]string{"plaintext": plaintext}
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"batch_results": results}})
+}
+
+func (f *fakeTransit) count(operation string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[operation]
+}
+
+func TestTransit(t *testing.T) {
+	fake := &fakeTransit{requests: make(map[string]int)}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	transit, err := NewTransit(&TransitConfig{Address: ts.URL, Token: "test-token", CacheSize: 10})
+	if err != nil {
+		t.Fatalf("NewTransit() error = %v", err)
+	}
+	defer transit.Close()
+
+	ciphertexts, err := transit.EncryptBatch([]string{"one", "two"})
+	if err != nil {
+		t.Fatalf("EncryptBatch() error = %v", err)
+	}
+	if fake.count("encrypt") != 1 {
+		t.Errorf("Expected one encrypt request, got %d", fake.count("encrypt"))
+	}
+	for _, ciphertext := range ciphertexts {
+		if !transit.Handles(ciphertext) {
+			t.Errorf("Expected %q to be a transit ciphertext", ciphertext)
+		}
+	}
+	if transit.Handles([]byte("local-aes-bytes")) {
+		t.Error("Expected other ciphertexts not to be handled")
+	}
+
+	// Values just encrypted are served from the cache
+	plaintexts, err := transit.DecryptBatch(ciphertexts)
+	if err != nil || plaintexts[0] != "one" || plaintexts[1] != "two" {
+		t.Fatalf("DecryptBatch() = %v, %v", plaintexts, err)
+	}
+	if fake.count("decrypt") != 0 {
+		t.Errorf("Expected cached values, got %d decrypt requests", fake.count("decrypt"))
+	}
+
+	// Values not in the cache are decrypted in one request
+	plaintexts, err = transit.DecryptBatch([][]byte{[]byte("vault:v1:dGhyZWU="), ciphertexts[0], []byte("vault:v1:Zm91cg==")})
+	if err != nil || strings.Join(plaintexts, ",") != "three,one,four" {
+		t.Fatalf("DecryptBatch() = %v, %v", plaintexts, err)
+	}
+	if fake.count("decrypt") != 1 {
+		t.Errorf("Expected one decrypt request, got %d", fake.count("decrypt"))
+	}
+
+	if _, err := transit.DecryptBatch([][]byte{[]byte("vault:v2:bad")}); err == nil || !strings.Contains(err.Error(), "invalid ciphertext") {
+		t.Errorf("Expected the item's error, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package wol wakes machines with Wake-on-LAN magic packets
+package wol
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DefaultBroadcast is the address magic packets are sent to by default: the local network
+const DefaultBroadcast = "255.255.255.255"
+
+// DefaultPort is the UDP port magic packets are sent to by default (discard)
+const DefaultPort = 9
+
+// ParseMAC parses the Ethernet MAC address of the machine to wake, e.g. 00:11:22:33:44:55
+func ParseMAC(mac string) (net.HardwareAddr, error) {
+	addr, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address %q (e.g. 00:11:22:33:44:55)", mac)
+	}
+	if len(addr) != 6 {
+		return nil, fmt.Errorf("invalid MAC address %q: Wake-on-LAN needs a 6-byte Ethernet address", mac)
+	}
+	return addr, nil
+}
+
+// MagicPacket returns the magic packet waking the machine with the MAC address: six 0xFF bytes
+// followed by the address repeated 16 times
+func MagicPacket(mac net.HardwareAddr) []byte {
+	packet := make([]byte, 0, 6+16*len(mac))
+	for i := 0; i < 6; i++ {
+		packet = append(packet, 0xFF)
+	}
+	for i := 0; i < 16; i++ {
+		packet = append(packet, mac...)
+	}
+	return packet
+}
+
+// Send sends the magic packet of the MAC address to broadcast:port over UDP
+func Send(mac net.HardwareAddr, broadcast string, port int) error {
+	conn, err := net.Dial("udp4", net.JoinHostPort(broadcast, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to open UDP socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(MagicPacket(mac)); err != nil {
+		return fmt.Errorf("failed to send magic packet: %w", err)
+	}
+	return nil
+}
+
+// RelayScript returns a POSIX shell script sending the magic packet of the MAC address to
+// broadcast:port from another machine, so machines on networks web-cli is not attached to can
+// be woken
+// The script uses python3, else wakeonlan or wol, and fails with exit code 127 without them.
+func RelayScript(mac net.HardwareAddr, broadcast string, port int) string {
+	packet := hex.EncodeToString(MagicPacket(mac))
+	portArg := strconv.Itoa(port)
+	var script strings.Builder
+	script.WriteString("if command -v python3 >/dev/null 2>&1; then\n")
+	script.WriteString("  python3 -c 'import socket,sys; s=socket.socket(socket.AF_INET, socket.SOCK_DGRAM); s.setsockopt(socket.SOL_SOCKET, socket.SO_BROADCAST, 1); s.sendto(bytes.fromhex(sys.argv[1]), (sys.argv[2], int(sys.argv[3])))' " + packet + " " + broadcast + " " + portArg + "\n")
+	script.WriteString("elif command -v wakeonlan >/dev/null 2>&1; then\n")
+	script.WriteString("  wakeonlan -i " + broadcast + " -p " + portArg + " " + mac.String() + "\n")
+	script.WriteString("elif command -v wol >/dev/null 2>&1; then\n")
+	script.WriteString("  wol -i " + broadcast + " -p " + portArg + " " + mac.String() + "\n")
+	script.WriteString("else\n")
+	script.WriteString("  echo 'python3, wakeonlan or wol is needed to send the magic packet' >&2\n")
+	script.WriteString("  exit 127\n")
+	script.WriteString("fi\n")
+	return script.String()
+}
@@ -0,0 +1,77 @@
+package wol
+
+import (
+	"bytes"
+	"net"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestParseMAC(t *testing.T) {
+	for _, mac := range []string{"00:11:22:33:44:55", "00-11-22-33-44-55", "0011.2233.4455"} {
+		addr, err := ParseMAC(mac)
+		if err != nil || addr.String() != "00:11:22:33:44:55" {
+			t.Errorf("Expected %s to parse, got %v, %v", mac, addr, err)
+		}
+	}
+	for _, mac := range []string{"", "00:11:22:33:44", "00:11:22:33:44:55:66:77", "zz:11:22:33:44:55"} {
+		if _, err := ParseMAC(mac); err == nil {
+			t.Errorf("Expected %q to be rejected", mac)
+		}
+	}
+}
+
+func TestMagicPacket(t *testing.T) {
+	mac, _ := ParseMAC("00:11:22:33:44:55")
+	packet := MagicPacket(mac)
+	if len(packet) != 102 || !bytes.Equal(packet[:6], bytes.Repeat([]byte{0xFF}, 6)) {
+		t.Fatalf("Expected 6 0xFF bytes and 96 address bytes, got %x", packet)
+	}
+	if !bytes.Equal(packet[6:], bytes.Repeat(mac, 16)) {
+		t.Errorf("Expected the address repeated 16 times, got %x", packet[6:])
+	}
+}
+
+// listen returns a UDP socket on the loopback address and its port
+func listen(t *testing.T) (*net.UDPConn, int) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn, conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestSend(t *testing.T) {
+	conn, port := listen(t)
+	mac, _ := ParseMAC("00:11:22:33:44:55")
+
+	if err := Send(mac, "127.0.0.1", port); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil || !bytes.Equal(buf[:n], MagicPacket(mac)) {
+		t.Errorf("Expected the magic packet, got %x, %v", buf[:n], err)
+	}
+}
+
+func TestRelayScript(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	conn, port := listen(t)
+	mac, _ := ParseMAC("00:11:22:33:44:55")
+
+	if output, err := exec.Command("sh", "-c", RelayScript(mac, "127.0.0.1", port)).CombinedOutput(); err != nil {
+		t.Fatalf("Relay script failed: %v: %s", err, output)
+	}
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil || !bytes.Equal(buf[:n], MagicPacket(mac)) {
+		t.Errorf("Expected the magic packet, got %x, %v", buf[:n], err)
+	}
+}
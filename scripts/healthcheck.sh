@@ -13,8 +13,16 @@ set -euo pipefail
 #   WEBCLI_TLS_KEY_PATH   - TLS key path (if set, uses https)
 #   TLS_CERT_PATH         - Alternative TLS cert path env var
 #   TLS_KEY_PATH          - Alternative TLS key path env var
+#   WEBCLI_HEALTH_PORT    - Plain-HTTP health probe port (if set, used instead)
+#   WEBCLI_BASE_PATH      - URL path prefix the app is served under
 
 readonly PORT="${WEBCLI_PORT:-7777}"
+readonly HEALTH_PORT="${WEBCLI_HEALTH_PORT:-${HEALTH_PORT:-0}}"
+
+# Prefer the dedicated plain-HTTP health listener when enabled
+if [[ "${HEALTH_PORT}" != "0" ]]; then
+    exec curl -sf "http://localhost:${HEALTH_PORT}/readyz"
+fi
 
 # Determine scheme based on TLS configuration
 # Check both WEBCLI_TLS_* and TLS_* env vars (server accepts both)
@@ -27,7 +35,11 @@ else
     SCHEME="http"
 fi
 
-readonly URL="${SCHEME}://localhost:${PORT}/api/health"
+BASE_PATH="${WEBCLI_BASE_PATH:-${BASE_PATH:-}}"
+BASE_PATH="/${BASE_PATH#/}"
+BASE_PATH="${BASE_PATH%/}"
+
+readonly URL="${SCHEME}://localhost:${PORT}${BASE_PATH}/api/health"
 
 # Use -k to accept self-signed certificates in TLS mode
 if [[ "${SCHEME}" == "https" ]]; then